@@ -0,0 +1,362 @@
+// This file defines the controllers that back up and restore the database, and the runner that tracks their progress.
+package admin
+
+// "context" provides a way to carry cancellation signals. It is used here to run the jobs past the end of the request.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to recognize a job that was not created.
+	"database/sql"
+	// "errors" provides functions for working with errors. It is used here to recognize a missing or uncreated job.
+	"errors"
+	// "log" provides a simple logging package. It is used here to log failed progress updates.
+	"log"
+	// "regexp" provides regular expression search. It is used here to check database names.
+	"regexp"
+	// "sync" provides synchronization primitives. It is used here to share the last step of a job.
+	"sync"
+	// "time" provides functions for working with time. It is used here to name the backups and to report progress.
+	"time"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to define the controllers.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to identify jobs.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/backup" is a local package that backs up and restores the database.
+	"github.com/rahulcodepython/todo-backend/backend/backup"
+	// "github.com/rahulcodepython/todo-backend/backend/binding" is a local package that binds request parameters. It is used here to bind the cursor.
+	"github.com/rahulcodepython/todo-backend/backend/binding"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+const (
+	// backupHeartbeat is how often a running job records its last step, which also reports that it is alive.
+	backupHeartbeat = 5 * time.Second
+	// backupStaleAfter is how long a running job may go without reporting before it no longer blocks new jobs.
+	backupStaleAfter = time.Minute
+)
+
+// databaseNamePattern matches the database names a restore accepts, which are plain identifiers.
+var databaseNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}$`)
+
+// StartBackupController starts a backup of the database to the storage backend and returns its job.
+// The backup runs in the background; its progress is read from the job.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (ac *AdminController) StartBackupController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// key is the storage key of the new backup.
+	key := backup.NewKey(time.Now())
+	// job is the recorded job.
+	job, err := ac.createBackupJob(c.UserContext(), "backup", key, nil, user.ID)
+	// This checks if another job is running.
+	if errors.Is(err, sql.ErrNoRows) {
+		// If one is, a conflict response is returned.
+		return response.Conflict(c, err, "Another backup or restore is running")
+	}
+	// This checks if another error occurred while recording the job.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to start backup")
+	}
+
+	// The backup runs in the background.
+	go ac.runBackupJob(job.ID, func(ctx context.Context, progress backup.Progress) error {
+		// The database is dumped to the storage backend.
+		return backup.Dump(ctx, ac.cfg.Database, ac.store, key, progress)
+	})
+
+	// A created response is returned with a success message, the job, and its location.
+	return response.OKCreatedResponse(c, "Backup started successfully", job, "/api/"+utils.APIVersion+"/admin/backups/"+job.ID.String())
+}
+
+// RestoreBackupController starts restoring a finished backup into an empty database on the same server, and returns its job.
+// The database of the server itself is never empty, so a restore goes to a new database that the server is then pointed at.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (ac *AdminController) RestoreBackupController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// backupId is the ID of the backup job.
+	backupId, err := uuid.Parse(c.Params("id"))
+	// This checks if the ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Invalid backup id")
+	}
+
+	// body is a new RestoreRequest struct.
+	body := new(RestoreRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+	// This checks if the database name is not a plain identifier.
+	if !databaseNamePattern.MatchString(body.Database) {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Database must be the name of an empty database")
+	}
+
+	// source is the backup job.
+	source, err := ac.getBackupJob(c.UserContext(), backupId)
+	// This checks if no job has the ID, or if it is not a finished backup.
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && (source.Kind != "backup" || source.Status != "succeeded")) {
+		// If so, a not found response is returned.
+		return response.NotFound(c, nil, "Backup not found")
+	}
+	// This checks if another error occurred while reading the job.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to start restore")
+	}
+
+	// job is the recorded job.
+	job, err := ac.createBackupJob(c.UserContext(), "restore", source.StorageKey, &body.Database, user.ID)
+	// This checks if another job is running.
+	if errors.Is(err, sql.ErrNoRows) {
+		// If one is, a conflict response is returned.
+		return response.Conflict(c, err, "Another backup or restore is running")
+	}
+	// This checks if another error occurred while recording the job.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to start restore")
+	}
+
+	// target is the database configuration of the server with the requested database.
+	target := ac.cfg.Database
+	// The database name is replaced.
+	target.DBName = body.Database
+	// The restore runs in the background.
+	go ac.runBackupJob(job.ID, func(ctx context.Context, progress backup.Progress) error {
+		// The backup is restored into the target database.
+		return backup.Restore(ctx, target, ac.store, source.StorageKey, progress)
+	})
+
+	// A created response is returned with a success message, the job, and its location.
+	return response.OKCreatedResponse(c, "Restore started successfully", job, "/api/"+utils.APIVersion+"/admin/backups/"+job.ID.String())
+}
+
+// ListBackupJobsController returns a page of the backups and restores, newest first.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (ac *AdminController) ListBackupJobsController(c *fiber.Ctx) error {
+	// query is the result of binding the query parameters.
+	query, err := binding.Query[BackupJobsQuery](c)
+	// This checks if any query parameter is invalid.
+	if err != nil {
+		// If one is, a bad request response is returned with the invalid parameters.
+		return response.InvalidParameters(c, err)
+	}
+	// The page size is resolved against the configured page sizes.
+	query.Limit, err = binding.PageLimit(query.Limit, ac.cfg.Pagination)
+	// This checks if the requested page size is too large.
+	if err != nil {
+		// If it is, a bad request response is returned with the invalid parameter.
+		return response.InvalidParameters(c, err)
+	}
+
+	// rows is the result of querying the jobs.
+	// One more job than the page size is read to know whether there is a next page.
+	rows, err := ac.db.QueryContext(c.UserContext(), ListBackupJobsQuery, query.Before, query.Limit+1)
+	// This checks if an error occurred while querying the jobs.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get backups")
+	}
+	// This defers closing the rows until the function returns.
+	defer rows.Close()
+
+	// jobs is a slice that will hold the jobs.
+	jobs := []BackupJob{}
+	// This iterates over the rows.
+	for rows.Next() {
+		// job is the scanned job.
+		job, err := scanBackupJob(rows)
+		// This checks if an error occurred while scanning the row.
+		if err != nil {
+			// If an error occurs, an internal server error response is returned.
+			return response.InternelServerError(c, err, "Unable to get backups")
+		}
+		// The job is appended to the jobs slice.
+		jobs = append(jobs, job)
+	}
+	// This checks if an error occurred while iterating over the rows.
+	if err := rows.Err(); err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get backups")
+	}
+
+	// page is the response for the jobs.
+	page := BackupJobsResponse{Jobs: jobs}
+	// This checks if there is a next page.
+	if len(jobs) > query.Limit {
+		// If there is, the extra job is dropped and the cursor points past the last job of the page.
+		page.Jobs = jobs[:query.Limit]
+		page.NextBefore = &page.Jobs[query.Limit-1].ID
+	}
+
+	// A success response is returned with the jobs.
+	return response.OKResponse(c, "Backups fetched successfully", page)
+}
+
+// GetBackupJobController returns a backup or restore and its progress.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (ac *AdminController) GetBackupJobController(c *fiber.Ctx) error {
+	// id is the ID of the job.
+	id, err := uuid.Parse(c.Params("id"))
+	// This checks if the ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Invalid backup id")
+	}
+
+	// job is the job.
+	job, err := ac.getBackupJob(c.UserContext(), id)
+	// This checks if no job has the ID.
+	if errors.Is(err, sql.ErrNoRows) {
+		// If none has, a not found response is returned.
+		return response.NotFound(c, nil, "Backup not found")
+	}
+	// This checks if another error occurred while reading the job.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get backups")
+	}
+
+	// A success response is returned with the job.
+	return response.OKResponse(c, "Backup fetched successfully", job)
+}
+
+// createBackupJob records a new job, unless another one is running.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param kind string - "backup" or "restore".
+// @param key string - The storage key of the backup.
+// @param target *string - The database a restore writes to, or nil for a backup.
+// @param userId uuid.UUID - The ID of the admin who started the job.
+// @return BackupJob - The job.
+// @return error - sql.ErrNoRows if another job is running, or another error if one occurred.
+func (ac *AdminController) createBackupJob(ctx context.Context, kind string, key string, target *string, userId uuid.UUID) (BackupJob, error) {
+	// The job is recorded and read back.
+	return scanBackupJob(ac.db.QueryRowContext(ctx, CreateBackupJobQuery, kind, key, target, userId, backupStaleAfter.Seconds()))
+}
+
+// getBackupJob reads a job by its ID.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param id uuid.UUID - The ID of the job.
+// @return BackupJob - The job.
+// @return error - sql.ErrNoRows if no job has the ID, or another error if one occurred.
+func (ac *AdminController) getBackupJob(ctx context.Context, id uuid.UUID) (BackupJob, error) {
+	// The job is read.
+	return scanBackupJob(ac.db.QueryRowContext(ctx, GetBackupJobQuery, id))
+}
+
+// runBackupJob runs a job and records its progress and outcome.
+// The last step is recorded every heartbeat instead of on every step, since the tools report one per table and index.
+//
+// @param id uuid.UUID - The ID of the job.
+// @param run func(context.Context, backup.Progress) error - The work of the job.
+func (ac *AdminController) runBackupJob(id uuid.UUID, run func(context.Context, backup.Progress) error) {
+	// ctx is the context of the job, which outlives the request that started it.
+	ctx := context.Background()
+	// mu guards the last step.
+	var mu sync.Mutex
+	// step is the last step the job reported.
+	var step string
+	// done receives the outcome of the job.
+	done := make(chan error, 1)
+
+	// The job runs in the background.
+	go func() {
+		// The outcome is sent once the work ends.
+		done <- run(ctx, func(s string) {
+			// The step is stored under the lock.
+			mu.Lock()
+			step = s
+			mu.Unlock()
+		})
+	}()
+
+	// ticker fires once every heartbeat.
+	ticker := time.NewTicker(backupHeartbeat)
+	// This defers stopping the ticker until the function returns.
+	defer ticker.Stop()
+
+	// This loops until the job ends.
+	for {
+		// This waits for either the next heartbeat or the end of the job.
+		select {
+		case <-ticker.C:
+			// The last step is read under the lock.
+			mu.Lock()
+			current := step
+			mu.Unlock()
+			// The last step is recorded.
+			if _, err := ac.db.ExecContext(ctx, UpdateBackupJobProgressQuery, id, current); err != nil {
+				// If an error occurs, it is logged and the job goes on.
+				log.Printf("Unable to record progress of backup job %s: %v", id, err)
+			}
+		case err := <-done:
+			// status and message are the outcome of the job.
+			status, message := "succeeded", (*string)(nil)
+			// This checks if the job failed.
+			if err != nil {
+				// If it did, the error is recorded.
+				text := err.Error()
+				status, message = "failed", &text
+				// The failure is logged.
+				log.Printf("Backup job %s failed: %v", id, err)
+			}
+			// The outcome is recorded.
+			if _, err := ac.db.ExecContext(ctx, FinishBackupJobQuery, id, status, message); err != nil {
+				// If an error occurs, it is logged.
+				log.Printf("Unable to record outcome of backup job %s: %v", id, err)
+			}
+			// The job has ended.
+			return
+		}
+	}
+}
+
+// scanBackupJob scans a row into a BackupJob.
+//
+// @param row interface{ Scan(...any) error } - The row, from QueryRowContext or QueryContext.
+// @return BackupJob - The job.
+// @return error - An error if one occurred.
+func scanBackupJob(row interface{ Scan(...any) error }) (BackupJob, error) {
+	// job is a new BackupJob struct.
+	var job BackupJob
+	// This scans the row into the job struct.
+	err := row.Scan(&job.ID, &job.Kind, &job.Status, &job.StorageKey, &job.TargetDatabase, &job.Progress, &job.Error, &job.CreatedBy, &job.CreatedAt, &job.UpdatedAt, &job.FinishedAt)
+	// The job is returned with the error, if any.
+	return job, err
+}
@@ -0,0 +1,212 @@
+// This file defines the controllers of the admin console.
+package admin
+
+// "database/sql" provides a generic SQL interface. It is used here to interact with the database.
+import (
+	"database/sql"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to define the controllers.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/backend/binding" is a local package that binds request parameters. It is used here to bind the filters and cursors.
+	"github.com/rahulcodepython/todo-backend/backend/binding"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+	// "github.com/rahulcodepython/todo-backend/backend/storage" is a local package that stores files.
+	"github.com/rahulcodepython/todo-backend/backend/storage"
+)
+
+// AdminController is a struct that holds the configuration, database connection, and storage backend.
+type AdminController struct {
+	// cfg is the application configuration.
+	cfg *config.Config
+	// db is the database connection.
+	db *sql.DB
+	// store is the storage backend the backups are kept in.
+	store storage.Storage
+}
+
+// NewAdminControl creates a new AdminController.
+// It takes the application configuration, database connection, and storage backend as input.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @param store storage.Storage - The storage backend the backups are kept in.
+// @return *AdminController - A pointer to the new AdminController.
+func NewAdminControl(cfg *config.Config, db *sql.DB, store storage.Storage) *AdminController {
+	// A new AdminController is returned.
+	return &AdminController{
+		// The cfg field is set to the application configuration.
+		cfg: cfg,
+		// The db field is set to the database connection.
+		db: db,
+		// The store field is set to the storage backend.
+		store: store,
+	}
+}
+
+// UIController serves the admin console, a single page that reads the other admin endpoints.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (ac *AdminController) UIController(c *fiber.Ctx) error {
+	// The page may show personal data, so it is never cached.
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	// The content type is set to HTML.
+	c.Type("html", "utf-8")
+	// The embedded page is sent.
+	return c.Send(indexPage)
+}
+
+// ListUsersController returns a page of the users, newest first.
+// The next page is requested by passing the returned next_before as before.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (ac *AdminController) ListUsersController(c *fiber.Ctx) error {
+	// query is the result of binding the query parameters.
+	query, err := binding.Query[UsersQuery](c)
+	// This checks if any query parameter is invalid.
+	if err != nil {
+		// If one is, a bad request response is returned with the invalid parameters.
+		return response.InvalidParameters(c, err)
+	}
+	// The page size is resolved against the configured page sizes.
+	query.Limit, err = binding.PageLimit(query.Limit, ac.cfg.Pagination)
+	// This checks if the requested page size is too large.
+	if err != nil {
+		// If it is, a bad request response is returned with the invalid parameter.
+		return response.InvalidParameters(c, err)
+	}
+
+	// rows is the result of querying the users.
+	// One more user than the page size is read to know whether there is a next page.
+	rows, err := ac.db.QueryContext(c.UserContext(), ListUsersQuery, query.Before, query.Limit+1)
+	// This checks if an error occurred while querying the users.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get users")
+	}
+	// This defers closing the rows until the function returns.
+	defer rows.Close()
+
+	// summaries is a slice that will hold the users.
+	summaries := []UserSummary{}
+	// This iterates over the rows.
+	for rows.Next() {
+		// summary is a new UserSummary struct.
+		var summary UserSummary
+		// This scans the row into the summary struct.
+		if err := rows.Scan(&summary.ID, &summary.Name, &summary.Email, &summary.Role, &summary.Plan, &summary.CreatedAt); err != nil {
+			// If an error occurs, an internal server error response is returned.
+			return response.InternelServerError(c, err, "Unable to get users")
+		}
+		// The user is appended to the summaries slice.
+		summaries = append(summaries, summary)
+	}
+	// This checks if an error occurred while iterating over the rows.
+	if err := rows.Err(); err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get users")
+	}
+
+	// page is the response for the users.
+	page := UsersResponse{Users: summaries}
+	// This checks if there is a next page.
+	if len(summaries) > query.Limit {
+		// If there is, the extra user is dropped and the cursor points past the last user of the page.
+		page.Users = summaries[:query.Limit]
+		page.NextBefore = &page.Users[query.Limit-1].ID
+	}
+
+	// A success response is returned with the users.
+	return response.OKResponse(c, "Users fetched successfully", page)
+}
+
+// ListJobsController returns a page of the outbox events in one state, newest first.
+// Pending events are the relay's queue, and the attempts and last error of each event are its delivery log.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (ac *AdminController) ListJobsController(c *fiber.Ctx) error {
+	// query is the result of binding the query parameters.
+	query, err := binding.Query[JobsQuery](c)
+	// This checks if any query parameter is invalid.
+	if err != nil {
+		// If one is, a bad request response is returned with the invalid parameters.
+		return response.InvalidParameters(c, err)
+	}
+	// The page size is resolved against the configured page sizes.
+	query.Limit, err = binding.PageLimit(query.Limit, ac.cfg.Pagination)
+	// This checks if the requested page size is too large.
+	if err != nil {
+		// If it is, a bad request response is returned with the invalid parameter.
+		return response.InvalidParameters(c, err)
+	}
+
+	// rows is the result of querying the events.
+	// One more event than the page size is read to know whether there is a next page.
+	rows, err := ac.db.QueryContext(c.UserContext(), ListJobsQuery, query.Status, query.Before, query.Limit+1)
+	// This checks if an error occurred while querying the events.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get jobs")
+	}
+	// This defers closing the rows until the function returns.
+	defer rows.Close()
+
+	// jobs is a slice that will hold the events.
+	jobs := []Job{}
+	// This iterates over the rows.
+	for rows.Next() {
+		// job is a new Job struct.
+		var job Job
+		// This scans the row into the job struct.
+		if err := rows.Scan(&job.ID, &job.EventID, &job.EventType, &job.AggregateID, &job.Attempts, &job.NextAttemptAt, &job.LastError, &job.PublishedAt, &job.FailedAt, &job.CreatedAt); err != nil {
+			// If an error occurs, an internal server error response is returned.
+			return response.InternelServerError(c, err, "Unable to get jobs")
+		}
+		// The event is appended to the jobs slice.
+		jobs = append(jobs, job)
+	}
+	// This checks if an error occurred while iterating over the rows.
+	if err := rows.Err(); err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get jobs")
+	}
+
+	// page is the response for the events.
+	page := JobsResponse{Jobs: jobs}
+	// This checks if there is a next page.
+	if len(jobs) > query.Limit {
+		// If there is, the extra event is dropped and the cursor points past the last event of the page.
+		page.Jobs = jobs[:query.Limit]
+		page.NextBefore = &page.Jobs[query.Limit-1].ID
+	}
+
+	// A success response is returned with the events.
+	return response.OKResponse(c, "Jobs fetched successfully", page)
+}
+
+// FeatureFlagsController returns the optional features and whether the configuration turns them on.
+// The flags are read from the environment at startup, so they are shown but not changed here.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (ac *AdminController) FeatureFlagsController(c *fiber.Ctx) error {
+	// flags is the list of optional features.
+	flags := []FeatureFlag{}
+	// This iterates over the optional features of the configuration.
+	for _, flag := range ac.cfg.FeatureFlags() {
+		// The feature is appended to the flags slice.
+		flags = append(flags, FeatureFlag{Name: flag.Name, Enabled: flag.Enabled, Source: flag.Source})
+	}
+
+	// A success response is returned with the flags.
+	return response.OKResponse(c, "Feature flags fetched successfully", flags)
+}
@@ -0,0 +1,187 @@
+// This file defines the serializers for admin console requests and responses.
+package admin
+
+// "time" provides functions for working with time. It is used here to define the timestamps of the responses.
+import (
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to define ID fields and the user cursor.
+	"github.com/google/uuid"
+)
+
+// UsersQuery defines the query parameters of a list users request.
+type UsersQuery struct {
+	// Before is the optional cursor of the page.
+	// query:"before" specifies that this field is bound to the "before" query parameter.
+	Before *uuid.UUID `query:"before"`
+	// Limit is the number of users per page.
+	// query:"limit" specifies that this field is bound to the "limit" query parameter.
+	Limit int `query:"limit" min:"1"`
+}
+
+// UserSummary defines the structure for a user as an admin sees it.
+type UserSummary struct {
+	// ID is the unique identifier for the user.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// Name is the name of the user.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+	// Email is the email address of the user.
+	// json:"email" specifies that this field should be marshalled to/from a JSON object with the key "email".
+	Email string `json:"email"`
+	// Role is the role of the user.
+	// json:"role" specifies that this field should be marshalled to/from a JSON object with the key "role".
+	Role string `json:"role"`
+	// Plan is the plan of the user.
+	// json:"plan" specifies that this field should be marshalled to/from a JSON object with the key "plan".
+	Plan string `json:"plan"`
+	// CreatedAt is the time the user registered.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UsersResponse defines the structure for a page of users.
+type UsersResponse struct {
+	// Users is the page of users, newest first.
+	// json:"users" specifies that this field should be marshalled to/from a JSON object with the key "users".
+	Users []UserSummary `json:"users"`
+	// NextBefore is the cursor of the next page, or null when there are no more users.
+	// json:"next_before" specifies that this field should be marshalled to/from a JSON object with the key "next_before".
+	NextBefore *uuid.UUID `json:"next_before"`
+}
+
+// JobsQuery defines the query parameters of a list jobs request.
+type JobsQuery struct {
+	// Status is the state of the events to list.
+	// query:"status" specifies that this field is bound to the "status" query parameter.
+	Status string `query:"status" default:"pending" oneof:"pending published failed"`
+	// Before is the optional cursor of the page.
+	// query:"before" specifies that this field is bound to the "before" query parameter.
+	Before *int64 `query:"before" min:"1"`
+	// Limit is the number of events per page.
+	// query:"limit" specifies that this field is bound to the "limit" query parameter.
+	Limit int `query:"limit" min:"1"`
+}
+
+// Job defines the structure for an outbox event and the outcome of its deliveries.
+// With the webhook publisher, the attempts and the last error are the webhook delivery log.
+type Job struct {
+	// ID is the position of the event in the outbox.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID int64 `json:"id"`
+	// EventID is the unique identifier of the event, sent to the subscribers.
+	// json:"event_id" specifies that this field should be marshalled to/from a JSON object with the key "event_id".
+	EventID uuid.UUID `json:"event_id"`
+	// EventType is the type of the event, such as "todo.created".
+	// json:"event_type" specifies that this field should be marshalled to/from a JSON object with the key "event_type".
+	EventType string `json:"event_type"`
+	// AggregateID is the ID of the todo or list the event is about.
+	// json:"aggregate_id" specifies that this field should be marshalled to/from a JSON object with the key "aggregate_id".
+	AggregateID uuid.UUID `json:"aggregate_id"`
+	// Attempts is the number of delivery attempts so far.
+	// json:"attempts" specifies that this field should be marshalled to/from a JSON object with the key "attempts".
+	Attempts int `json:"attempts"`
+	// NextAttemptAt is the time of the next delivery attempt of a pending event.
+	// json:"next_attempt_at" specifies that this field should be marshalled to/from a JSON object with the key "next_attempt_at".
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	// LastError is the error of the last failed attempt, if any.
+	// json:"last_error" specifies that this field should be marshalled to/from a JSON object with the key "last_error".
+	LastError *string `json:"last_error"`
+	// PublishedAt is the time the event was delivered, if it was.
+	// json:"published_at" specifies that this field should be marshalled to/from a JSON object with the key "published_at".
+	PublishedAt *time.Time `json:"published_at"`
+	// FailedAt is the time the relay gave up on the event, if it did.
+	// json:"failed_at" specifies that this field should be marshalled to/from a JSON object with the key "failed_at".
+	FailedAt *time.Time `json:"failed_at"`
+	// CreatedAt is the time the event was recorded.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// JobsResponse defines the structure for a page of outbox events.
+type JobsResponse struct {
+	// Jobs is the page of events, newest first.
+	// json:"jobs" specifies that this field should be marshalled to/from a JSON object with the key "jobs".
+	Jobs []Job `json:"jobs"`
+	// NextBefore is the cursor of the next page, or null when there are no more events.
+	// json:"next_before" specifies that this field should be marshalled to/from a JSON object with the key "next_before".
+	NextBefore *int64 `json:"next_before"`
+}
+
+// FeatureFlag defines the structure for an optional feature and whether the configuration turns it on.
+type FeatureFlag struct {
+	// Name is the name of the feature.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+	// Enabled reports whether the feature is on.
+	// json:"enabled" specifies that this field should be marshalled to/from a JSON object with the key "enabled".
+	Enabled bool `json:"enabled"`
+	// Source is the environment variable that turns the feature on.
+	// json:"source" specifies that this field should be marshalled to/from a JSON object with the key "source".
+	Source string `json:"source"`
+}
+
+// BackupJobsQuery defines the query parameters of a list backup jobs request.
+type BackupJobsQuery struct {
+	// Before is the optional cursor of the page.
+	// query:"before" specifies that this field is bound to the "before" query parameter.
+	Before *uuid.UUID `query:"before"`
+	// Limit is the number of jobs per page.
+	// query:"limit" specifies that this field is bound to the "limit" query parameter.
+	Limit int `query:"limit" min:"1"`
+}
+
+// RestoreRequest defines the structure for a request to restore a backup.
+type RestoreRequest struct {
+	// Database is the name of the empty database on the same server the backup is restored into.
+	// json:"database" specifies that this field should be marshalled to/from a JSON object with the key "database".
+	Database string `json:"database"`
+}
+
+// BackupJob defines the structure for a backup or restore and its progress.
+type BackupJob struct {
+	// ID is the unique identifier for the job.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// Kind is "backup" or "restore".
+	// json:"kind" specifies that this field should be marshalled to/from a JSON object with the key "kind".
+	Kind string `json:"kind"`
+	// Status is "running", "succeeded", or "failed".
+	// json:"status" specifies that this field should be marshalled to/from a JSON object with the key "status".
+	Status string `json:"status"`
+	// StorageKey is the key of the backup in the storage backend.
+	// json:"storage_key" specifies that this field should be marshalled to/from a JSON object with the key "storage_key".
+	StorageKey string `json:"storage_key"`
+	// TargetDatabase is the database a restore writes to, or null for a backup.
+	// json:"target_database" specifies that this field should be marshalled to/from a JSON object with the key "target_database".
+	TargetDatabase *string `json:"target_database"`
+	// Progress is the last step the job reported, such as "dumping contents of table public.todos".
+	// json:"progress" specifies that this field should be marshalled to/from a JSON object with the key "progress".
+	Progress string `json:"progress"`
+	// Error is the reason the job failed, if it did.
+	// json:"error" specifies that this field should be marshalled to/from a JSON object with the key "error".
+	Error *string `json:"error"`
+	// CreatedBy is the ID of the admin who started the job, or null if the admin was deleted.
+	// json:"created_by" specifies that this field should be marshalled to/from a JSON object with the key "created_by".
+	CreatedBy *uuid.UUID `json:"created_by"`
+	// CreatedAt is the time the job started.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the last time a running job reported that it is alive.
+	// json:"updated_at" specifies that this field should be marshalled to/from a JSON object with the key "updated_at".
+	UpdatedAt time.Time `json:"updated_at"`
+	// FinishedAt is the time the job ended, if it did.
+	// json:"finished_at" specifies that this field should be marshalled to/from a JSON object with the key "finished_at".
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// BackupJobsResponse defines the structure for a page of backup jobs.
+type BackupJobsResponse struct {
+	// Jobs is the page of jobs, newest first.
+	// json:"jobs" specifies that this field should be marshalled to/from a JSON object with the key "jobs".
+	Jobs []BackupJob `json:"jobs"`
+	// NextBefore is the cursor of the next page, or null when there are no more jobs.
+	// json:"next_before" specifies that this field should be marshalled to/from a JSON object with the key "next_before".
+	NextBefore *uuid.UUID `json:"next_before"`
+}
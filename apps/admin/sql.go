@@ -0,0 +1,39 @@
+// This file defines the SQL queries used by the admin console.
+package admin
+
+// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names and schemas.
+import "github.com/rahulcodepython/todo-backend/backend/utils"
+
+// ListUsersQuery is the SQL query to page through the users, newest first.
+// $1 is the ID the page starts before, or NULL for the first page. User IDs are UUIDv7, so they sort by creation time.
+const ListUsersQuery = `SELECT id, name, email, role, plan, created_at FROM ` + utils.UserTableName + `
+	WHERE ($1::uuid IS NULL OR id < $1) ORDER BY id DESC LIMIT $2`
+
+// ListJobsQuery is the SQL query to page through the outbox events in one state, newest first.
+// $1 is "pending", "published", or "failed", and $2 is the ID the page starts before, or NULL for the first page.
+const ListJobsQuery = `SELECT id, event_id, event_type, aggregate_id, attempts, next_attempt_at, last_error, published_at, failed_at, created_at FROM ` + utils.OutboxTableName + `
+	WHERE CASE $1 WHEN 'published' THEN published_at IS NOT NULL WHEN 'failed' THEN failed_at IS NOT NULL ELSE published_at IS NULL AND failed_at IS NULL END
+	AND ($2::bigint IS NULL OR id < $2) ORDER BY id DESC LIMIT $3`
+
+// backupJobColumns are the columns of a backup job, in the order they are scanned.
+const backupJobColumns = "id, kind, status, storage_key, target_database, progress, error, created_by, created_at, updated_at, finished_at"
+
+// CreateBackupJobQuery is the SQL query to record a new backup or restore, unless another one is running.
+// A running job whose server stopped reporting for $5 seconds is not counted, so that a crash does not block every later job.
+const CreateBackupJobQuery = `INSERT INTO ` + utils.BackupJobTableName + ` (kind, storage_key, target_database, created_by)
+	SELECT $1, $2, $3, $4 WHERE NOT EXISTS (SELECT 1 FROM ` + utils.BackupJobTableName + ` WHERE status = 'running' AND updated_at > NOW() - make_interval(secs => $5))
+	RETURNING ` + backupJobColumns
+
+// UpdateBackupJobProgressQuery is the SQL query to record the last step of a running job, which also reports that it is alive.
+const UpdateBackupJobProgressQuery = "UPDATE " + utils.BackupJobTableName + " SET progress = $2, updated_at = NOW() WHERE id = $1"
+
+// FinishBackupJobQuery is the SQL query to record the outcome of a job. $2 is "succeeded" or "failed", and $3 the error, if any.
+const FinishBackupJobQuery = "UPDATE " + utils.BackupJobTableName + " SET status = $2, error = $3, updated_at = NOW(), finished_at = NOW() WHERE id = $1"
+
+// GetBackupJobQuery is the SQL query to retrieve a job by its ID.
+const GetBackupJobQuery = "SELECT " + backupJobColumns + " FROM " + utils.BackupJobTableName + " WHERE id = $1"
+
+// ListBackupJobsQuery is the SQL query to page through the jobs, newest first.
+// $1 is the ID the page starts before, or NULL for the first page. Job IDs are UUIDv7, so they sort by creation time.
+const ListBackupJobsQuery = `SELECT ` + backupJobColumns + ` FROM ` + utils.BackupJobTableName + `
+	WHERE ($1::uuid IS NULL OR id < $1) ORDER BY id DESC LIMIT $2`
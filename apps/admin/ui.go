@@ -0,0 +1,10 @@
+// This file embeds the page of the admin console.
+package admin
+
+// _ "embed" provides access to files embedded in the binary. It is used here to ship the page with the server.
+import _ "embed"
+
+// indexPage is the admin console, a single HTML page without external assets.
+//
+//go:embed ui/index.html
+var indexPage []byte
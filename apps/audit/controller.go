@@ -0,0 +1,115 @@
+// This file defines the admin controllers for the audit log.
+package audit
+
+// "database/sql" provides a generic SQL interface. It is used here to interact with the database.
+import (
+	"database/sql"
+	// "strings" provides functions for working with strings. It is used here to normalize the method filter.
+	"strings"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to define the controllers.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/backend/binding" is a local package that binds request parameters. It is used here to bind the filters.
+	"github.com/rahulcodepython/todo-backend/backend/binding"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+)
+
+// AuditController is a struct that holds the configuration and database connection.
+type AuditController struct {
+	// cfg is the application configuration.
+	cfg *config.Config
+	// db is the database connection.
+	db *sql.DB
+}
+
+// NewAuditControl creates a new AuditController.
+// It takes the application configuration and database connection as input.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @return *AuditController - A pointer to the new AuditController.
+func NewAuditControl(cfg *config.Config, db *sql.DB) *AuditController {
+	// A new AuditController is returned.
+	return &AuditController{
+		// The cfg field is set to the application configuration.
+		cfg: cfg,
+		// The db field is set to the database connection.
+		db: db,
+	}
+}
+
+// ListEntriesController returns a page of the audit log, newest first.
+// It can be filtered as described by EntriesQuery, and the next page is requested by passing the returned next_before as before.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (ac *AuditController) ListEntriesController(c *fiber.Ctx) error {
+	// query is the result of binding the query parameters.
+	query, err := binding.Query[EntriesQuery](c)
+	// This checks if any query parameter is invalid.
+	if err != nil {
+		// If one is, a bad request response is returned with the invalid parameters.
+		return response.InvalidParameters(c, err)
+	}
+	// The page size is resolved against the configured page sizes.
+	query.Limit, err = binding.PageLimit(query.Limit, ac.cfg.Pagination)
+	// This checks if the requested page size is too large.
+	if err != nil {
+		// If it is, a bad request response is returned with the invalid parameter.
+		return response.InvalidParameters(c, err)
+	}
+	// This checks if the method filter is set.
+	if query.Method != nil {
+		// If it is, it is put in upper case like the recorded methods.
+		*query.Method = strings.ToUpper(*query.Method)
+	}
+	// limit is the page size.
+	limit := query.Limit
+
+	// rows is the result of querying the entries.
+	// One more entry than the page size is read to know whether there is a next page.
+	rows, err := ac.db.QueryContext(c.UserContext(), ListEntriesQuery, query.UserID, query.Method, query.Status, query.Since, query.Until, query.Before, query.ActorKind, limit+1)
+	// This checks if an error occurred while querying the entries.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to read audit log")
+	}
+	// This defers closing the rows until the function returns.
+	defer rows.Close()
+
+	// entries is a slice that will hold the entries.
+	entries := []Entry{}
+	// This iterates over the rows.
+	for rows.Next() {
+		// entry is a new Entry struct.
+		var entry Entry
+		// This scans the row into the entry struct.
+		if err := rows.Scan(&entry.ID, &entry.Method, &entry.Path, &entry.UserID, &entry.IP, &entry.Status, &entry.LatencyMs, &entry.ActorKind, &entry.CreatedAt); err != nil {
+			// If an error occurs, an internal server error response is returned.
+			return response.InternelServerError(c, err, "Unable to read audit log")
+		}
+		// The entry is appended to the entries slice.
+		entries = append(entries, entry)
+	}
+	// This checks if an error occurred while iterating over the rows.
+	if err := rows.Err(); err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to read audit log")
+	}
+
+	// page is the response for the entries.
+	page := EntriesResponse{Entries: entries}
+	// This checks if there is a next page.
+	if len(entries) > limit {
+		// If there is, the extra entry is dropped and the cursor points past the last entry of the page.
+		page.Entries = entries[:limit]
+		page.NextBefore = &page.Entries[limit-1].ID
+	}
+
+	// A success response is returned with the entries.
+	return response.OKResponse(c, "Audit log fetched successfully", page)
+}
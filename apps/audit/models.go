@@ -0,0 +1,41 @@
+// This file defines the data model for audit entries.
+package audit
+
+// "time" provides functions for working with time. It is used here to define the CreatedAt field.
+import (
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to define the UserID field.
+	"github.com/google/uuid"
+)
+
+// Entry represents a mutating request recorded in the audit log.
+type Entry struct {
+	// ID is the unique identifier for the entry. It increases with every entry.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID int64 `json:"id"`
+	// Method is the HTTP method of the request.
+	// json:"method" specifies that this field should be marshalled to/from a JSON object with the key "method".
+	Method string `json:"method"`
+	// Path is the URL path of the request, without the query string.
+	// json:"path" specifies that this field should be marshalled to/from a JSON object with the key "path".
+	Path string `json:"path"`
+	// UserID is the ID of the authenticated user, or null for anonymous requests.
+	// json:"user_id" specifies that this field should be marshalled to/from a JSON object with the key "user_id".
+	UserID uuid.NullUUID `json:"user_id"`
+	// IP is the IP address of the client.
+	// json:"ip" specifies that this field should be marshalled to/from a JSON object with the key "ip".
+	IP string `json:"ip"`
+	// Status is the HTTP status code of the response.
+	// json:"status" specifies that this field should be marshalled to/from a JSON object with the key "status".
+	Status int `json:"status"`
+	// LatencyMs is the time taken to handle the request, in milliseconds.
+	// json:"latency_ms" specifies that this field should be marshalled to/from a JSON object with the key "latency_ms".
+	LatencyMs int64 `json:"latency_ms"`
+	// ActorKind is "human" for a request of a user, "service" for a request of a service account, or null for anonymous requests.
+	// json:"actor_kind" specifies that this field should be marshalled to/from a JSON object with the key "actor_kind".
+	ActorKind *string `json:"actor_kind"`
+	// CreatedAt is the time the entry was recorded.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,48 @@
+// This file defines the worker that enforces the audit log retention.
+package audit
+
+// "context" provides a way to carry cancellation signals. It is used here to stop the worker on shutdown.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to delete old entries.
+	"database/sql"
+	// "log" provides a simple logging package. It is used here to log failed passes.
+	"log"
+	// "time" provides functions for working with time. It is used here to schedule the worker.
+	"time"
+
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+// pruneInterval is how often old entries are deleted.
+const pruneInterval = time.Hour
+
+// StartPruner deletes the entries older than the retention until the context is cancelled.
+// It runs even when auditing is disabled, so that the entries recorded before are still pruned.
+//
+// @param ctx context.Context - The context that stops the worker.
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+func StartPruner(ctx context.Context, cfg *config.Config, db *sql.DB) {
+	// ticker fires once every prune interval.
+	ticker := time.NewTicker(pruneInterval)
+	// This defers stopping the ticker until the worker returns.
+	defer ticker.Stop()
+
+	// This loops until the context is cancelled.
+	for {
+		// This waits for either the next tick or the cancellation.
+		select {
+		case <-ctx.Done():
+			// If the context is cancelled, the worker returns.
+			return
+		case <-ticker.C:
+			// Old entries are pruned.
+			if _, err := db.ExecContext(ctx, PruneEntriesQuery, time.Now().Add(-cfg.Audit.Retention)); err != nil {
+				// If an error occurs, it is logged.
+				log.Printf("Unable to prune audit log: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// This file defines the serializers for audit-related requests and responses.
+package audit
+
+// "time" provides functions for working with time. It is used here to define the time range.
+import (
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to define the user filter.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/backend/binding" is a local package that binds request parameters.
+	"github.com/rahulcodepython/todo-backend/backend/binding"
+)
+
+// EntriesQuery defines the query parameters of a list audit entries request.
+type EntriesQuery struct {
+	// UserID is the optional user filter.
+	// query:"user_id" specifies that this field is bound to the "user_id" query parameter.
+	UserID *uuid.UUID `query:"user_id"`
+	// Method is the optional method filter.
+	// query:"method" specifies that this field is bound to the "method" query parameter.
+	Method *string `query:"method"`
+	// Status is the optional status code filter.
+	// query:"status" specifies that this field is bound to the "status" query parameter.
+	Status *int `query:"status" min:"100" max:"599"`
+	// Since is the optional start of the time range, inclusive.
+	// query:"since" specifies that this field is bound to the "since" query parameter.
+	Since *time.Time `query:"since"`
+	// Until is the optional end of the time range, exclusive.
+	// query:"until" specifies that this field is bound to the "until" query parameter.
+	Until *time.Time `query:"until"`
+	// Before is the optional cursor of the page.
+	// query:"before" specifies that this field is bound to the "before" query parameter.
+	Before *int64 `query:"before" min:"1"`
+	// ActorKind is the optional filter that keeps the requests of users ("human") or of service accounts ("service") apart.
+	// query:"actor_kind" specifies that this field is bound to the "actor_kind" query parameter.
+	ActorKind *string `query:"actor_kind" oneof:"human service"`
+	// Limit is the number of entries per page.
+	// query:"limit" specifies that this field is bound to the "limit" query parameter.
+	Limit int `query:"limit" min:"1"`
+}
+
+// Validate checks that the time range is not reversed.
+//
+// @return binding.FieldErrors - The invalid parameters, or nil if there are none.
+func (q *EntriesQuery) Validate() binding.FieldErrors {
+	// This checks if the range ends before it starts.
+	if q.Since != nil && q.Until != nil && !q.Since.Before(*q.Until) {
+		// If it does, the start is reported.
+		return binding.FieldErrors{binding.NewFieldError("since", "must be before %s", "until")}
+	}
+	// No error is returned.
+	return nil
+}
+
+// EntriesResponse defines the structure for a page of audit entries.
+type EntriesResponse struct {
+	// Entries is the page of entries, newest first.
+	// json:"entries" specifies that this field should be marshalled to/from a JSON object with the key "entries".
+	Entries []Entry `json:"entries"`
+	// NextBefore is the cursor of the next page, or null when there are no more entries.
+	// json:"next_before" specifies that this field should be marshalled to/from a JSON object with the key "next_before".
+	NextBefore *int64 `json:"next_before"`
+}
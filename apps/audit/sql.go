@@ -0,0 +1,19 @@
+// This file defines the SQL queries used by the audit log.
+package audit
+
+// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names and schemas.
+import "github.com/rahulcodepython/todo-backend/backend/utils"
+
+// CreateEntryQuery is the SQL query to record a request in the audit log.
+const CreateEntryQuery = "INSERT INTO " + utils.AuditTableName + " (" + utils.AuditTableSchema + ") VALUES ($1, $2, $3, $4, $5, $6, $7)"
+
+// ListEntriesQuery is the SQL query to page through the audit log, newest first.
+// Each filter is skipped when its parameter is NULL, and $6 is the ID the page starts before.
+const ListEntriesQuery = `SELECT id, ` + utils.AuditTableSchema + `, created_at FROM ` + utils.AuditTableName + `
+	WHERE ($1::uuid IS NULL OR user_id = $1) AND ($2::text IS NULL OR method = $2) AND ($3::integer IS NULL OR status = $3)
+	AND ($4::timestamptz IS NULL OR created_at >= $4) AND ($5::timestamptz IS NULL OR created_at < $5) AND ($6::bigint IS NULL OR id < $6)
+	AND ($7::text IS NULL OR actor_kind = $7)
+	ORDER BY id DESC LIMIT $8`
+
+// PruneEntriesQuery is the SQL query to delete the entries older than a cutoff.
+const PruneEntriesQuery = "DELETE FROM " + utils.AuditTableName + " WHERE created_at < $1"
@@ -0,0 +1,850 @@
+// This file defines the controllers for the CalDAV endpoints.
+package caldav
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to pass the deadline of the request to the helpers.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to interact with the database.
+	"database/sql"
+	// "encoding/xml" provides functions for encoding and decoding XML. It is used here to read and write WebDAV documents.
+	"encoding/xml"
+	// "errors" provides functions for working with errors. It is used here to detect exceeded limits.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to build ETags and sync tokens.
+	"fmt"
+	// "path" provides functions for working with slash-separated paths. It is used here to read resource names from hrefs.
+	"path"
+	// "regexp" provides regular expressions. It is used here to validate resource names.
+	"regexp"
+	// "strconv" provides functions for converting strings. It is used here to parse ETags and sync tokens.
+	"strconv"
+	// "strings" provides functions for working with strings. It is used here to parse headers.
+	"strings"
+	// "time" provides functions for working with time. It is used here to set timestamps.
+	"time"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to define the controllers.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to hold IDs.
+	"github.com/google/uuid"
+	// "github.com/lib/pq" is the PostgreSQL driver. It is used here to pass arrays as query parameters.
+	"github.com/lib/pq"
+	// "github.com/rahulcodepython/todo-backend/apps/todos" is a local package that contains the todo model.
+	"github.com/rahulcodepython/todo-backend/apps/todos"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/content" is a local package that validates text written by users.
+	"github.com/rahulcodepython/todo-backend/backend/content"
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database helpers.
+	"github.com/rahulcodepython/todo-backend/backend/database"
+	// "github.com/rahulcodepython/todo-backend/backend/idgen" is a local package that creates new IDs.
+	"github.com/rahulcodepython/todo-backend/backend/idgen"
+	// "github.com/rahulcodepython/todo-backend/backend/outbox" is a local package that records domain events.
+	"github.com/rahulcodepython/todo-backend/backend/outbox"
+	// "github.com/rahulcodepython/todo-backend/backend/quota" is a local package that enforces usage limits.
+	"github.com/rahulcodepython/todo-backend/backend/quota"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+const (
+	// PrincipalPath is the path of the user's principal and calendar home.
+	PrincipalPath = "/caldav/"
+	// CollectionPath is the path of the calendar that holds the user's todos.
+	CollectionPath = "/caldav/todos/"
+	// syncTokenPrefix is the prefix of sync tokens, which must be URIs. The tokens carry a transaction cursor, and the tokens of
+	// versions, from before the cursors, do not match it, so their clients are told to sync again from the start.
+	syncTokenPrefix = "http://todo-backend/ns/sync/xid/"
+	// statusOK is the status line of found properties.
+	statusOK = "HTTP/1.1 200 OK"
+	// statusNotFound is the status line of resources that do not exist.
+	statusNotFound = "HTTP/1.1 404 Not Found"
+)
+
+// validName matches the resource names accepted for todos.
+var validName = regexp.MustCompile(`^[A-Za-z0-9@._-]{1,255}$`)
+
+// CalDAVController is a struct that holds the configuration and database connection.
+type CalDAVController struct {
+	// cfg is the application configuration.
+	cfg *config.Config
+	// db is the database connection.
+	db *sql.DB
+	// ids creates the IDs of new records.
+	ids idgen.IDGenerator
+	// content cleans and checks titles and descriptions.
+	content *content.Validator
+}
+
+// NewCalDAVControl creates a new CalDAVController.
+// It takes the application configuration, database connection, ID generator, and content validator as input.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @param ids idgen.IDGenerator - The generator of the IDs of new records.
+// @param validator *content.Validator - The validator of titles and descriptions.
+// @return *CalDAVController - A pointer to the new CalDAVController.
+func NewCalDAVControl(cfg *config.Config, db *sql.DB, ids idgen.IDGenerator, validator *content.Validator) *CalDAVController {
+	// A new CalDAVController is returned.
+	return &CalDAVController{
+		// The cfg field is set to the application configuration.
+		cfg: cfg,
+		// The db field is set to the database connection.
+		db: db,
+		// The ids field is set to the ID generator.
+		ids: ids,
+		// The content field is set to the content validator.
+		content: validator,
+	}
+}
+
+// OptionsController advertises the supported DAV classes and methods.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (dc *CalDAVController) OptionsController(c *fiber.Ctx) error {
+	// The DAV header lists the supported classes.
+	c.Set("DAV", "1, 3, calendar-access")
+	// The Allow header lists the supported methods.
+	c.Set(fiber.HeaderAllow, "OPTIONS, GET, PUT, DELETE, PROPFIND, REPORT")
+	// A 200 OK status is returned.
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// WellKnownController redirects service discovery to the principal.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (dc *CalDAVController) WellKnownController(c *fiber.Ctx) error {
+	// The client is redirected to the principal.
+	return c.Redirect(PrincipalPath, fiber.StatusMovedPermanently)
+}
+
+// PropfindPrincipalController describes the principal, and with Depth 1 also the todo calendar.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (dc *CalDAVController) PropfindPrincipalController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, the request is answered with 401 Unauthorized, which makes the client send its credentials.
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+
+	// responses holds the principal's response.
+	responses := []davResponse{found(PrincipalPath, prop{
+		// The principal is a collection and a principal.
+		ResourceType: &resourceType{Collection: &struct{}{}, Principal: &struct{}{}},
+		// The display name is the user's name.
+		DisplayName: user.Name,
+		// The principal is its own current user principal.
+		CurrentUserPrincipal: &hrefProp{Href: PrincipalPath},
+		// The calendar home is the principal.
+		CalendarHomeSet: &hrefProp{Href: PrincipalPath},
+	})}
+
+	// This checks if the children were requested.
+	if c.Get("Depth") != "0" {
+		// cursor is the sync cursor of the user's todos.
+		cursor, err := dc.syncCursor(c.UserContext(), user.ID)
+		// This checks if an error occurred while reading the cursor.
+		if err != nil {
+			// If an error occurs, an internal server error status is returned.
+			return c.SendStatus(fiber.StatusInternalServerError)
+		}
+		// The calendar's response is appended.
+		responses = append(responses, collectionResponse(cursor))
+	}
+
+	// The responses are sent as a multi-status document.
+	return writeMultistatus(c, responses, "")
+}
+
+// PropfindCollectionController describes the todo calendar, and with Depth 1 also the ETag of every todo.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (dc *CalDAVController) PropfindCollectionController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, the request is answered with 401 Unauthorized, which makes the client send its credentials.
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+
+	// cursor is the sync cursor of the user's todos.
+	cursor, err := dc.syncCursor(c.UserContext(), user.ID)
+	// This checks if an error occurred while reading the cursor.
+	if err != nil {
+		// If an error occurs, an internal server error status is returned.
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	// responses holds the calendar's response.
+	responses := []davResponse{collectionResponse(cursor)}
+
+	// This checks if the children were requested.
+	if c.Get("Depth") != "0" {
+		// allTodos is the list of the user's todos.
+		allTodos, err := dc.queryTodos(c.UserContext(), GetAllTodosQuery, user.ID)
+		// This checks if an error occurred while querying the todos.
+		if err != nil {
+			// If an error occurs, an internal server error status is returned.
+			return c.SendStatus(fiber.StatusInternalServerError)
+		}
+		// This iterates over the todos.
+		for _, todo := range allTodos {
+			// Each todo's response is appended without its calendar data.
+			responses = append(responses, todoResponse(todo, false))
+		}
+	}
+
+	// The responses are sent as a multi-status document.
+	return writeMultistatus(c, responses, "")
+}
+
+// PropfindTodoController describes one todo.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (dc *CalDAVController) PropfindTodoController(c *fiber.Ctx) error {
+	// todo is the requested todo.
+	todo, status := dc.findTodo(c)
+	// This checks if the todo could not be found.
+	if status != fiber.StatusOK {
+		// If it could not, the status is returned.
+		return c.SendStatus(status)
+	}
+
+	// The todo's response is sent as a multi-status document.
+	return writeMultistatus(c, []davResponse{todoResponse(todo, false)}, "")
+}
+
+// ReportController runs the calendar-query, calendar-multiget, and sync-collection reports on the todo calendar.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (dc *CalDAVController) ReportController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, the request is answered with 401 Unauthorized, which makes the client send its credentials.
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+
+	// report is the parsed report request.
+	var report reportRequest
+	// This parses the request body.
+	if err := xml.Unmarshal(c.Body(), &report); err != nil {
+		// If an error occurs, a bad request status is returned.
+		return c.SendStatus(fiber.StatusBadRequest)
+	}
+
+	// This runs the requested report.
+	switch report.XMLName.Local {
+	case "calendar-query":
+		// calendar-query returns every todo, since the calendar only holds VTODOs.
+		allTodos, err := dc.queryTodos(c.UserContext(), GetAllTodosQuery, user.ID)
+		// This checks if an error occurred while querying the todos.
+		if err != nil {
+			// If an error occurs, an internal server error status is returned.
+			return c.SendStatus(fiber.StatusInternalServerError)
+		}
+		// responses holds a response for every todo.
+		responses := make([]davResponse, len(allTodos))
+		// This iterates over the todos.
+		for i, todo := range allTodos {
+			// Each todo's response includes its calendar data.
+			responses[i] = todoResponse(todo, true)
+		}
+		// The responses are sent as a multi-status document.
+		return writeMultistatus(c, responses, "")
+	case "calendar-multiget":
+		// responses holds a response for every requested path.
+		responses := make([]davResponse, 0, len(report.Hrefs))
+		// This iterates over the requested paths.
+		for _, href := range report.Hrefs {
+			// todo is the result of looking up the todo by its resource name.
+			todo, err := todos.ScanTodo(dc.db.QueryRowContext(c.UserContext(), GetTodoByNameQuery, user.ID, resourceName(href)))
+			// This checks if the todo does not exist.
+			if err != nil {
+				// If it does not, a not found response is appended.
+				responses = append(responses, davResponse{Href: href, Status: statusNotFound})
+				continue
+			}
+			// The todo's response with its calendar data is appended.
+			responses = append(responses, todoResponse(todo, true))
+		}
+		// The responses are sent as a multi-status document.
+		return writeMultistatus(c, responses, "")
+	case "sync-collection":
+		// The sync-collection report is run.
+		return dc.syncCollection(c, user, report.SyncToken)
+	}
+
+	// Other reports are not supported.
+	return c.SendStatus(fiber.StatusNotImplemented)
+}
+
+// syncCollection returns the todos that changed and the todos that were deleted since a sync token.
+// Each device keeps its own token, so every device receives every change exactly once.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param user users.User - The authenticated user.
+// @param token string - The sync token of the device, or an empty string for the initial sync.
+// @return error - An error if one occurred.
+func (dc *CalDAVController) syncCollection(c *fiber.Ctx, user users.User, token string) error {
+	// cursor is the sync cursor of the user's todos.
+	cursor, err := dc.syncCursor(c.UserContext(), user.ID)
+	// This checks if an error occurred while reading the cursor.
+	if err != nil {
+		// If an error occurs, an internal server error status is returned.
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	// since is the cursor of the changes the device last saw.
+	var since int64
+	// This checks if the device sent a token.
+	if token != "" {
+		// since is the cursor carried by the token.
+		since, err = strconv.ParseInt(strings.TrimPrefix(token, syncTokenPrefix), 10, 64)
+		// This checks if the token is invalid or from the future.
+		if err != nil || !strings.HasPrefix(token, syncTokenPrefix) || since > cursor {
+			// If it is, the client is told to start over with a full sync.
+			c.Set(fiber.HeaderContentType, "application/xml; charset=utf-8")
+			return c.Status(fiber.StatusForbidden).SendString(xml.Header + `<d:error xmlns:d="DAV:"><d:valid-sync-token/></d:error>`)
+		}
+	}
+
+	// changed is the list of todos that changed since the token.
+	changed, err := dc.queryTodos(c.UserContext(), GetChangedTodosQuery, user.ID, since, cursor)
+	// This checks if an error occurred while querying the todos.
+	if err != nil {
+		// If an error occurs, an internal server error status is returned.
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	// responses holds a response for every change.
+	responses := make([]davResponse, 0, len(changed))
+	// live tracks the resource names of the changed todos.
+	live := make(map[string]bool, len(changed))
+	// This iterates over the changed todos.
+	for _, todo := range changed {
+		// Each todo's response is appended without its calendar data.
+		responses = append(responses, todoResponse(todo, false))
+		live[todoName(todo)] = true
+	}
+
+	// This checks if the device has synced before, since an initial sync has nothing to delete.
+	if since > 0 {
+		// rows is the result of querying the deleted todos.
+		rows, err := dc.db.QueryContext(c.UserContext(), GetDeletedTodoNamesQuery, user.ID, since, cursor)
+		// This checks if an error occurred while querying the deleted todos.
+		if err != nil {
+			// If an error occurs, an internal server error status is returned.
+			return c.SendStatus(fiber.StatusInternalServerError)
+		}
+		// This defers the closing of the rows until the function returns.
+		defer rows.Close()
+
+		// This iterates over the rows.
+		for rows.Next() {
+			// name is the resource name of the deleted todo.
+			var name string
+			// This scans the row.
+			if err := rows.Scan(&name); err != nil {
+				// If an error occurs, an internal server error status is returned.
+				return c.SendStatus(fiber.StatusInternalServerError)
+			}
+			// This checks if a new todo took over the name.
+			if live[name] {
+				// If one did, the deletion is not reported.
+				continue
+			}
+			// A not found response marks the deletion.
+			responses = append(responses, davResponse{Href: CollectionPath + name + ".ics", Status: statusNotFound})
+		}
+	}
+
+	// The responses are sent with the new sync token.
+	return writeMultistatus(c, responses, syncToken(cursor))
+}
+
+// GetTodoController returns the calendar data of a todo.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (dc *CalDAVController) GetTodoController(c *fiber.Ctx) error {
+	// todo is the requested todo.
+	todo, status := dc.findTodo(c)
+	// This checks if the todo could not be found.
+	if status != fiber.StatusOK {
+		// If it could not, the status is returned.
+		return c.SendStatus(status)
+	}
+
+	// The ETag header is set to the todo's version.
+	c.Set(fiber.HeaderETag, etag(todo))
+	// The Content-Type header is set to iCalendar.
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	// The calendar data is returned.
+	return c.SendString(EncodeVTODO(todo, todoName(todo)))
+}
+
+// PutTodoController creates or replaces a todo from calendar data.
+// If-Match and If-None-Match are honoured so that a client never overwrites a change it has not seen; a conflict returns 412.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (dc *CalDAVController) PutTodoController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, the request is answered with 401 Unauthorized, which makes the client send its credentials.
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+
+	// name is the resource name of the todo.
+	name := resourceName(c.Params("name"))
+	// This checks if the name is invalid.
+	if !validName.MatchString(name) {
+		// If it is, a bad request status is returned.
+		return c.SendStatus(fiber.StatusBadRequest)
+	}
+
+	// vtodo is the parsed VTODO component.
+	vtodo, err := ParseVTODO(string(c.Body()), user.Location())
+	// This checks if the calendar data is invalid.
+	if err != nil {
+		// If it is, an unsupported media type status is returned, as RFC 4791 asks for invalid calendar data.
+		return c.Status(fiber.StatusUnsupportedMediaType).SendString(err.Error())
+	}
+	// This cleans the summary and description of control characters and checks their length and words.
+	if err := dc.content.Clean(content.Text{Field: dc.content.TodoTitle, Value: &vtodo.Summary}, content.Text{Field: dc.content.TodoDescription, Value: &vtodo.Description}); err != nil {
+		// If one is invalid, a bad request status is returned with the reason.
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	// This checks if nothing is left of the summary.
+	if vtodo.Summary == "" {
+		// If nothing is, an unsupported media type status is returned, like for a VTODO without a summary.
+		return c.Status(fiber.StatusUnsupportedMediaType).SendString(errNoSummary.Error())
+	}
+
+	// dueAt is the due date of the todo.
+	var dueAt sql.NullTime
+	// This checks if the VTODO has a due date.
+	if vtodo.Due != nil {
+		// If it does, it is set.
+		dueAt = sql.NullTime{Time: *vtodo.Due, Valid: true}
+	}
+	// tags is the normalized list of tags.
+	tags := todos.NormalizeTags(vtodo.Categories)
+
+	// existing is the result of looking up the todo by its resource name.
+	existing, err := todos.ScanTodo(dc.db.QueryRowContext(c.UserContext(), GetTodoByNameQuery, user.ID, name))
+	// This checks if an error other than a missing todo occurred.
+	if err != nil && err != sql.ErrNoRows {
+		// If it did, an internal server error status is returned.
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	// exists reports whether the todo already exists.
+	exists := err == nil
+
+	// ifMatch is the value of the If-Match header.
+	ifMatch := c.Get(fiber.HeaderIfMatch)
+	// This checks if the client only wants to create the todo but it already exists, or only wants to update it but it does not exist.
+	if (exists && c.Get(fiber.HeaderIfNoneMatch) == "*") || (!exists && ifMatch != "") {
+		// If so, a precondition failed status is returned.
+		return c.SendStatus(fiber.StatusPreconditionFailed)
+	}
+
+	// This checks if the todo does not exist yet.
+	if !exists {
+		// todoId is the new UUID for the todo.
+		todoId := dc.ids.NewID()
+
+		// todo is the inserted todo.
+		var todo todos.Todo
+		// err is the result of inserting the todo and recording the event in one transaction.
+		err := database.WithTx(c.UserContext(), dc.db, func(tx *sql.Tx) error {
+			// This checks that the todo fits in the user's plan.
+			if err := quota.Check(c.UserContext(), tx, dc.cfg, user.ID, quota.Todos, 1); err != nil {
+				// If it does not, the error is returned.
+				return err
+			}
+			// todo is the result of inserting the todo.
+			var err error
+			todo, err = todos.ScanTodo(tx.QueryRowContext(c.UserContext(), CreateTodoQuery, todoId, vtodo.Summary, vtodo.Description, vtodo.Priority, vtodo.Completed, user.ID, utils.ParseTime(time.Now()), nil, 0, dueAt, pq.Array(tags), name))
+			// This checks if an error occurred while inserting the todo.
+			if err != nil {
+				// If an error occurs, it is returned.
+				return err
+			}
+			// The event is recorded.
+			return todos.RecordTodoEvent(c.UserContext(), tx, outbox.TodoCreated, todo)
+		})
+		// exceeded is the limit that was reached, if any.
+		var exceeded *quota.ExceededError
+		// This checks if the plan limit was reached.
+		if errors.As(err, &exceeded) {
+			// If it was, an insufficient storage status is returned, which WebDAV clients report as a full quota.
+			return c.SendStatus(fiber.StatusInsufficientStorage)
+		}
+		// This checks if another error occurred while executing the transaction.
+		if err != nil {
+			// If an error occurs, an internal server error status is returned.
+			return c.SendStatus(fiber.StatusInternalServerError)
+		}
+
+		// The ETag header is set to the new todo's version.
+		c.Set(fiber.HeaderETag, etag(todo))
+		// A created status is returned.
+		return c.SendStatus(fiber.StatusCreated)
+	}
+
+	// expectedVersion is the version the client expects, if it sent If-Match.
+	expectedVersion, ok := parseIfMatch(ifMatch)
+	// This checks if the If-Match header is malformed.
+	if !ok {
+		// If it is, a precondition failed status is returned.
+		return c.SendStatus(fiber.StatusPreconditionFailed)
+	}
+
+	// todo is the updated todo.
+	var todo todos.Todo
+	// err is the result of updating the todo and recording the event in one transaction.
+	err = database.WithTx(c.UserContext(), dc.db, func(tx *sql.Tx) error {
+		// todo is the result of updating the todo only if its version still matches.
+		var err error
+		todo, err = todos.ScanTodo(tx.QueryRowContext(c.UserContext(), UpdateTodoQuery, vtodo.Summary, vtodo.Description, vtodo.Priority, vtodo.Completed, dueAt, pq.Array(tags), existing.ID, expectedVersion))
+		// This checks if an error occurred while updating the todo.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// eventType is the domain event of the change.
+		eventType := outbox.TodoUpdated
+		// This checks if the change completed or reopened the todo.
+		if todo.Completed != existing.Completed {
+			// If it did, the event says so.
+			eventType = outbox.TodoReopened
+			// This checks if the todo is now completed.
+			if todo.Completed {
+				// If it is, the event is a completion.
+				eventType = outbox.TodoCompleted
+			}
+		}
+		// The event is recorded.
+		return todos.RecordTodoEvent(c.UserContext(), tx, eventType, todo)
+	})
+	// This checks if the todo changed since the client read it.
+	if err == sql.ErrNoRows {
+		// If it did, a precondition failed status is returned.
+		return c.SendStatus(fiber.StatusPreconditionFailed)
+	}
+	// This checks if another error occurred.
+	if err != nil {
+		// If it did, an internal server error status is returned.
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	// The ETag header is set to the updated todo's version.
+	c.Set(fiber.HeaderETag, etag(todo))
+	// A no content status is returned.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// DeleteTodoController soft deletes a todo, honouring If-Match.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (dc *CalDAVController) DeleteTodoController(c *fiber.Ctx) error {
+	// todo is the requested todo.
+	todo, status := dc.findTodo(c)
+	// This checks if the todo could not be found.
+	if status != fiber.StatusOK {
+		// If it could not, the status is returned.
+		return c.SendStatus(status)
+	}
+
+	// expectedVersion is the version the client expects, if it sent If-Match.
+	expectedVersion, ok := parseIfMatch(c.Get(fiber.HeaderIfMatch))
+	// This checks if the If-Match header is malformed.
+	if !ok {
+		// If it is, a precondition failed status is returned.
+		return c.SendStatus(fiber.StatusPreconditionFailed)
+	}
+
+	// err is the result of deleting the todo and recording the event in one transaction.
+	err := database.WithTx(c.UserContext(), dc.db, func(tx *sql.Tx) error {
+		// result is the result of deleting the todo only if its version still matches.
+		result, err := tx.ExecContext(c.UserContext(), DeleteTodoQuery, todo.ID, expectedVersion)
+		// This checks if an error occurred while deleting the todo.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// This checks if the todo changed since the client read it.
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			// If it did, no rows is returned so that the transaction is rolled back.
+			return sql.ErrNoRows
+		}
+		// The event is recorded.
+		return outbox.Record(c.UserContext(), tx, outbox.TodoDeleted, uuid.MustParse(todo.Owner), todo.ID, outbox.DeletedData{ID: todo.ID})
+	})
+	// This checks if the todo changed since the client read it.
+	if err == sql.ErrNoRows {
+		// If it did, a precondition failed status is returned.
+		return c.SendStatus(fiber.StatusPreconditionFailed)
+	}
+	// This checks if another error occurred.
+	if err != nil {
+		// If it did, an internal server error status is returned.
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	// A no content status is returned.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// findTodo looks up the todo named in the path for the current user.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return todos.Todo - The todo.
+// @return int - fiber.StatusOK if the todo was found, or the status to return otherwise, such as fiber.StatusUnauthorized without an authenticated user.
+func (dc *CalDAVController) findTodo(c *fiber.Ctx) (todos.Todo, int) {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, the request is answered with 401 Unauthorized, which makes the client send its credentials.
+		return todos.Todo{}, fiber.StatusUnauthorized
+	}
+
+	// name is the resource name of the todo.
+	name := resourceName(c.Params("name"))
+	// This checks if the name is invalid.
+	if !validName.MatchString(name) {
+		// If it is, the todo cannot exist.
+		return todos.Todo{}, fiber.StatusNotFound
+	}
+
+	// todo is the result of looking up the todo by its resource name.
+	todo, err := todos.ScanTodo(dc.db.QueryRowContext(c.UserContext(), GetTodoByNameQuery, user.ID, name))
+	// This checks if the todo does not exist.
+	if err == sql.ErrNoRows {
+		// If it does not, a not found status is returned.
+		return todo, fiber.StatusNotFound
+	}
+	// This checks if another error occurred.
+	if err != nil {
+		// If it did, an internal server error status is returned.
+		return todo, fiber.StatusInternalServerError
+	}
+
+	// The todo is returned.
+	return todo, fiber.StatusOK
+}
+
+// queryTodos runs a query that selects todos and scans every row.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param query string - The SQL query.
+// @param args ...any - The query arguments.
+// @return []todos.Todo - The todos.
+// @return error - An error if one occurred.
+func (dc *CalDAVController) queryTodos(ctx context.Context, query string, args ...any) ([]todos.Todo, error) {
+	// rows is the result of running the query.
+	rows, err := dc.db.QueryContext(ctx, query, args...)
+	// This checks if an error occurred while running the query.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// This defers the closing of the rows until the function returns.
+	defer rows.Close()
+
+	// result is the list of scanned todos.
+	var result []todos.Todo
+	// This iterates over the rows.
+	for rows.Next() {
+		// todo is the result of scanning the row.
+		todo, err := todos.ScanTodo(rows)
+		// This checks if an error occurred while scanning the row.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return nil, err
+		}
+		// The todo is appended to the result.
+		result = append(result, todo)
+	}
+
+	// The todos and the error of the iteration, if any, are returned.
+	return result, rows.Err()
+}
+
+// syncCursor returns the cursor just past the last change of a user's todos that can no longer be overtaken by a running transaction.
+// It only moves when the user's todos change, so it also serves as the ctag.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param userId uuid.UUID - The ID of the user.
+// @return int64 - The cursor.
+// @return error - An error if one occurred.
+func (dc *CalDAVController) syncCursor(ctx context.Context, userId uuid.UUID) (int64, error) {
+	// cursor is the cursor.
+	var cursor int64
+	// This queries the cursor.
+	err := dc.db.QueryRowContext(ctx, GetSyncCursorQuery, userId).Scan(&cursor)
+	// The cursor and the error, if any, are returned.
+	return cursor, err
+}
+
+// collectionResponse builds the response that describes the todo calendar.
+//
+// @param cursor int64 - The sync cursor of the user's todos.
+// @return davResponse - The response.
+func collectionResponse(cursor int64) davResponse {
+	// The calendar's response is returned.
+	return found(CollectionPath, prop{
+		// The calendar is a collection and a calendar.
+		ResourceType: &resourceType{Collection: &struct{}{}, Calendar: &struct{}{}},
+		// The display name of the calendar.
+		DisplayName: "Todos",
+		// The calendar only holds VTODO components.
+		SupportedComponents: &componentSet{Components: []component{{Name: "VTODO"}}},
+		// The ctag and the sync token change whenever a todo changes.
+		GetCTag:   syncToken(cursor),
+		SyncToken: syncToken(cursor),
+	})
+}
+
+// todoResponse builds the response that describes a todo.
+//
+// @param todo todos.Todo - The todo.
+// @param withData bool - True if the calendar data is included.
+// @return davResponse - The response.
+func todoResponse(todo todos.Todo, withData bool) davResponse {
+	// properties are the properties of the todo.
+	properties := prop{
+		// A todo is a plain resource.
+		ResourceType: &resourceType{},
+		// The ETag is the todo's version.
+		GetETag: etag(todo),
+		// The content type is iCalendar.
+		GetContentType: "text/calendar; charset=utf-8; component=VTODO",
+	}
+	// This checks if the calendar data was requested.
+	if withData {
+		// If it was, it is included.
+		properties.CalendarData = EncodeVTODO(todo, todoName(todo))
+	}
+	// The todo's response is returned.
+	return found(CollectionPath+todoName(todo)+".ics", properties)
+}
+
+// found builds a response whose properties were found.
+//
+// @param href string - The path of the resource.
+// @param properties prop - The properties.
+// @return davResponse - The response.
+func found(href string, properties prop) davResponse {
+	// The response is returned.
+	return davResponse{Href: href, Propstat: &propstat{Prop: properties, Status: statusOK}}
+}
+
+// writeMultistatus sends a 207 Multi-Status document.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param responses []davResponse - The responses.
+// @param token string - The sync token of a sync-collection report, or an empty string.
+// @return error - An error if one occurred.
+func writeMultistatus(c *fiber.Ctx, responses []davResponse, token string) error {
+	// body is the encoded document.
+	body, err := xml.Marshal(multistatus{XmlnsD: "DAV:", XmlnsC: "urn:ietf:params:xml:ns:caldav", XmlnsCS: "http://calendarserver.org/ns/", Responses: responses, SyncToken: token})
+	// This checks if an error occurred while encoding the document.
+	if err != nil {
+		// If an error occurs, an internal server error status is returned.
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	// The Content-Type header is set to XML.
+	c.Set(fiber.HeaderContentType, "application/xml; charset=utf-8")
+	// The document is returned with a 207 status.
+	return c.Status(fiber.StatusMultiStatus).SendString(xml.Header + string(body))
+}
+
+// todoName returns the resource name of a todo.
+//
+// @param todo todos.Todo - The todo.
+// @return string - The ical_uid of the todo, or its ID if it was not created over CalDAV.
+func todoName(todo todos.Todo) string {
+	// This checks if the todo has a CalDAV name.
+	if todo.ICalUID.Valid {
+		// If it does, it is returned.
+		return todo.ICalUID.String
+	}
+	// The ID is returned.
+	return todo.ID.String()
+}
+
+// resourceName returns the resource name from a path or an href.
+//
+// @param href string - The path.
+// @return string - The last segment of the path without the ".ics" extension.
+func resourceName(href string) string {
+	// The extension is removed from the last segment.
+	return strings.TrimSuffix(path.Base(href), ".ics")
+}
+
+// etag returns the ETag of a todo.
+//
+// @param todo todos.Todo - The todo.
+// @return string - The quoted version of the todo.
+func etag(todo todos.Todo) string {
+	// The quoted version is returned.
+	return fmt.Sprintf(`"%d"`, todo.Version)
+}
+
+// syncToken returns the sync token for a cursor.
+//
+// @param cursor int64 - The cursor.
+// @return string - The sync token.
+func syncToken(cursor int64) string {
+	// The token is returned.
+	return fmt.Sprintf("%s%d", syncTokenPrefix, cursor)
+}
+
+// parseIfMatch parses an If-Match header into the expected version.
+//
+// @param header string - The value of the If-Match header.
+// @return sql.NullInt64 - The expected version, or null if any version matches.
+// @return bool - False if the header is malformed.
+func parseIfMatch(header string) (sql.NullInt64, bool) {
+	// header is trimmed and stripped of a weak prefix and quotes.
+	header = strings.Trim(strings.TrimPrefix(strings.TrimSpace(header), "W/"), `"`)
+	// This checks if any version matches.
+	if header == "" || header == "*" {
+		// If it does, a null version is returned.
+		return sql.NullInt64{}, true
+	}
+	// version is the parsed version.
+	version, err := strconv.ParseInt(header, 10, 64)
+	// The version is returned if it was parsed.
+	return sql.NullInt64{Int64: version, Valid: err == nil}, err == nil
+}
@@ -0,0 +1,432 @@
+// This file converts todos to and from iCalendar VTODO components.
+package caldav
+
+// "errors" provides functions for working with errors. It is used here to define parse errors.
+import (
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to build the calendar data.
+	"fmt"
+	// "strconv" provides functions for converting strings. It is used here to parse priorities.
+	"strconv"
+	// "strings" provides functions for working with strings. It is used here to parse and build the calendar data.
+	"strings"
+	// "time" provides functions for working with time. It is used here to parse and format dates.
+	"time"
+
+	// "github.com/rahulcodepython/todo-backend/apps/todos" is a local package that contains the todo model.
+	"github.com/rahulcodepython/todo-backend/apps/todos"
+)
+
+// errNoVTODO is returned when the calendar data does not contain a VTODO component.
+var errNoVTODO = errors.New("calendar data does not contain a VTODO")
+
+// errNoSummary is returned when the VTODO has no summary to use as the title.
+var errNoSummary = errors.New("VTODO has no SUMMARY")
+
+// icalUTCFormat is the iCalendar format of a UTC date-time.
+const icalUTCFormat = "20060102T150405Z"
+
+// icalLocalFormat is the iCalendar format of a floating or zoned date-time.
+const icalLocalFormat = "20060102T150405"
+
+// icalDateFormat is the iCalendar format of a date.
+const icalDateFormat = "20060102"
+
+// VTODO represents the fields of a VTODO component that map to a todo.
+type VTODO struct {
+	// Summary is the title of the todo.
+	Summary string
+	// Description is the description of the todo.
+	Description string
+	// Completed reports whether the status is COMPLETED.
+	Completed bool
+	// Priority is the todo priority mapped from the iCalendar priority.
+	Priority string
+	// Due is the due date of the todo, if any.
+	Due *time.Time
+	// Categories are the tags of the todo.
+	Categories []string
+}
+
+// EncodeVTODO renders a todo as an iCalendar object with one VTODO component.
+//
+// @param todo todos.Todo - The todo to be rendered.
+// @param uid string - The UID of the component.
+// @return string - The calendar data.
+func EncodeVTODO(todo todos.Todo, uid string) string {
+	// lines are the unfolded content lines.
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//todo-backend//CalDAV//EN",
+		"BEGIN:VTODO",
+		"UID:" + escapeText(uid),
+		"DTSTAMP:" + time.Now().UTC().Format(icalUTCFormat),
+		"CREATED:" + todo.CreatedAt.UTC().Format(icalUTCFormat),
+		"LAST-MODIFIED:" + todo.UpdatedAt.UTC().Format(icalUTCFormat),
+		"SUMMARY:" + escapeText(todo.Title),
+	}
+
+	// This checks if the todo has a description.
+	if todo.Description != "" {
+		// If it does, it is added.
+		lines = append(lines, "DESCRIPTION:"+escapeText(todo.Description))
+	}
+
+	// This checks if the todo is completed.
+	if todo.Completed {
+		// If it is, the status is COMPLETED.
+		lines = append(lines, "STATUS:COMPLETED", "PERCENT-COMPLETE:100")
+	} else if todo.Status == todos.StatusInProgress {
+		// If it is being worked on, the status is IN-PROCESS.
+		lines = append(lines, "STATUS:IN-PROCESS")
+	} else {
+		// Otherwise the status is NEEDS-ACTION.
+		lines = append(lines, "STATUS:NEEDS-ACTION")
+	}
+
+	// This checks if the todo has a priority.
+	if priority := encodePriority(todo.Priority); priority != 0 {
+		// If it does, it is added.
+		lines = append(lines, fmt.Sprintf("PRIORITY:%d", priority))
+	}
+
+	// This checks if the todo has a due date.
+	if todo.DueAt.Valid {
+		// If it does, it is added in UTC.
+		lines = append(lines, "DUE:"+todo.DueAt.Time.UTC().Format(icalUTCFormat))
+	}
+
+	// This checks if the todo has tags.
+	if len(todo.Tags) > 0 {
+		// escaped are the escaped tags.
+		escaped := make([]string, len(todo.Tags))
+		// This iterates over the tags.
+		for i, tag := range todo.Tags {
+			// Each tag is escaped.
+			escaped[i] = escapeText(tag)
+		}
+		// The tags are added as categories.
+		lines = append(lines, "CATEGORIES:"+strings.Join(escaped, ","))
+	}
+
+	// The component and the calendar are closed.
+	lines = append(lines, "END:VTODO", "END:VCALENDAR")
+
+	// builder collects the folded lines.
+	var builder strings.Builder
+	// This iterates over the lines.
+	for _, line := range lines {
+		// Each line is folded and terminated with CRLF.
+		builder.WriteString(foldLine(line))
+		builder.WriteString("\r\n")
+	}
+
+	// The calendar data is returned.
+	return builder.String()
+}
+
+// ParseVTODO extracts the first VTODO component of an iCalendar object.
+// Floating date-times are read in the given time zone.
+//
+// @param data string - The calendar data.
+// @param location *time.Location - The time zone of the user.
+// @return VTODO - The parsed component.
+// @return error - An error if the data has no VTODO or no summary.
+func ParseVTODO(data string, location *time.Location) (VTODO, error) {
+	// vtodo is a new VTODO struct with the default priority.
+	vtodo := VTODO{Priority: todos.PriorityNone}
+	// inside reports whether the current line is inside the VTODO.
+	inside := false
+	// found reports whether a VTODO was seen.
+	found := false
+	// depth counts the nested components inside the VTODO, such as VALARM, whose properties are ignored.
+	depth := 0
+
+	// This iterates over the unfolded content lines.
+	for _, line := range unfoldLines(data) {
+		// name, params, and value are the parts of the content line.
+		name, params, value, ok := splitContentLine(line)
+		// This checks if the line is malformed.
+		if !ok {
+			// If it is, it is skipped.
+			continue
+		}
+
+		// This handles the component boundaries.
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VTODO") && !found:
+			// The first VTODO starts.
+			inside, found = true, true
+			continue
+		case name == "END" && strings.EqualFold(value, "VTODO") && inside && depth == 0:
+			// The VTODO ends.
+			inside = false
+			continue
+		case !inside:
+			// Lines outside the VTODO are skipped.
+			continue
+		case name == "BEGIN":
+			// A nested component starts.
+			depth++
+			continue
+		case name == "END":
+			// A nested component ends.
+			depth--
+			continue
+		case depth > 0:
+			// Properties of nested components are skipped.
+			continue
+		}
+
+		// This maps the property to the todo fields.
+		switch name {
+		case "SUMMARY":
+			// SUMMARY is the title.
+			vtodo.Summary = strings.TrimSpace(unescapeText(value))
+		case "DESCRIPTION":
+			// DESCRIPTION is the description.
+			vtodo.Description = unescapeText(value)
+		case "STATUS":
+			// STATUS COMPLETED marks the todo as completed.
+			vtodo.Completed = strings.EqualFold(value, "COMPLETED")
+		case "PRIORITY":
+			// PRIORITY is mapped to a todo priority.
+			priority, _ := strconv.Atoi(strings.TrimSpace(value))
+			vtodo.Priority = decodePriority(priority)
+		case "DUE":
+			// DUE is the due date.
+			due, err := parseDateTime(value, params, location)
+			// This checks if the date was parsed.
+			if err == nil {
+				// If it was, it is set.
+				vtodo.Due = &due
+			}
+		case "CATEGORIES":
+			// CATEGORIES are the tags. The property may appear several times.
+			for _, category := range splitEscaped(value, ',') {
+				vtodo.Categories = append(vtodo.Categories, unescapeText(category))
+			}
+		}
+	}
+
+	// This checks if no VTODO was found.
+	if !found {
+		// If none was, an error is returned.
+		return vtodo, errNoVTODO
+	}
+	// This checks if the VTODO has no summary.
+	if vtodo.Summary == "" {
+		// If it has none, an error is returned.
+		return vtodo, errNoSummary
+	}
+
+	// The parsed component is returned.
+	return vtodo, nil
+}
+
+// encodePriority maps a todo priority to an iCalendar priority, where 1 is the highest and 0 is undefined.
+//
+// @param priority string - The todo priority.
+// @return int - The iCalendar priority.
+func encodePriority(priority string) int {
+	// This maps the priority.
+	switch priority {
+	case todos.PriorityHigh:
+		return 1
+	case todos.PriorityMedium:
+		return 5
+	case todos.PriorityLow:
+		return 9
+	}
+	// Any other priority is undefined.
+	return 0
+}
+
+// decodePriority maps an iCalendar priority to a todo priority, using the ranges from RFC 5545.
+//
+// @param priority int - The iCalendar priority.
+// @return string - The todo priority.
+func decodePriority(priority int) string {
+	// This maps the priority ranges.
+	switch {
+	case priority >= 1 && priority <= 4:
+		return todos.PriorityHigh
+	case priority == 5:
+		return todos.PriorityMedium
+	case priority >= 6 && priority <= 9:
+		return todos.PriorityLow
+	}
+	// Any other priority is none.
+	return todos.PriorityNone
+}
+
+// parseDateTime parses a DATE or DATE-TIME value. Dates without a time are due at the start of the day.
+//
+// @param value string - The property value.
+// @param params map[string]string - The property parameters.
+// @param location *time.Location - The time zone used for floating times.
+// @return time.Time - The parsed time.
+// @return error - An error if the value could not be parsed.
+func parseDateTime(value string, params map[string]string, location *time.Location) (time.Time, error) {
+	// value is trimmed of surrounding spaces.
+	value = strings.TrimSpace(value)
+
+	// This checks if the value is in UTC.
+	if strings.HasSuffix(value, "Z") {
+		// If it is, it is parsed as UTC.
+		return time.Parse(icalUTCFormat, value)
+	}
+
+	// This checks if the value names its time zone.
+	if tzid, ok := params["TZID"]; ok {
+		// This loads the named time zone.
+		if zone, err := time.LoadLocation(strings.Trim(tzid, `"`)); err == nil {
+			// If it loads, it is used instead of the user's time zone.
+			location = zone
+		}
+	}
+
+	// This checks if the value is a date without a time.
+	if len(value) == len(icalDateFormat) {
+		// If it is, it is parsed as a date.
+		return time.ParseInLocation(icalDateFormat, value, location)
+	}
+
+	// The value is parsed as a local date-time.
+	return time.ParseInLocation(icalLocalFormat, value, location)
+}
+
+// unfoldLines splits calendar data into content lines, joining lines that were folded.
+//
+// @param data string - The calendar data.
+// @return []string - The unfolded lines.
+func unfoldLines(data string) []string {
+	// lines is the list of unfolded lines.
+	var lines []string
+	// This iterates over the physical lines.
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		// This checks if the line continues the previous one.
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			// If it does, it is joined without its leading whitespace.
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		// This checks if the line is not empty.
+		if strings.TrimSpace(line) != "" {
+			// If it is not, it starts a new content line.
+			lines = append(lines, line)
+		}
+	}
+	// The unfolded lines are returned.
+	return lines
+}
+
+// splitContentLine splits a content line into its uppercased name, its parameters, and its value.
+// Colons inside quoted parameter values do not end the parameters.
+//
+// @param line string - The content line.
+// @return string - The name.
+// @return map[string]string - The parameters.
+// @return string - The value.
+// @return bool - True if the line has a value.
+func splitContentLine(line string) (string, map[string]string, string, bool) {
+	// quoted reports whether the scan is inside a quoted parameter value.
+	quoted := false
+	// This iterates over the characters of the line.
+	for i, char := range line {
+		// This checks the character.
+		switch {
+		case char == '"':
+			// A quote toggles the quoted state.
+			quoted = !quoted
+		case char == ':' && !quoted:
+			// The first unquoted colon ends the name and parameters.
+			parts := strings.Split(line[:i], ";")
+			// params holds the parsed parameters.
+			params := make(map[string]string, len(parts)-1)
+			// This iterates over the parameters.
+			for _, part := range parts[1:] {
+				// key and paramValue are the parts of the parameter.
+				key, paramValue, _ := strings.Cut(part, "=")
+				params[strings.ToUpper(key)] = paramValue
+			}
+			// The parts of the line are returned.
+			return strings.ToUpper(parts[0]), params, line[i+1:], true
+		}
+	}
+	// A line without a colon is malformed.
+	return "", nil, "", false
+}
+
+// splitEscaped splits a value at a separator that is not escaped with a backslash.
+//
+// @param value string - The value.
+// @param separator byte - The separator.
+// @return []string - The parts of the value, still escaped.
+func splitEscaped(value string, separator byte) []string {
+	// parts is the list of parts.
+	var parts []string
+	// start is the index where the current part starts.
+	start := 0
+	// This iterates over the bytes of the value.
+	for i := 0; i < len(value); i++ {
+		// This checks the byte.
+		switch value[i] {
+		case '\\':
+			// An escaped byte is skipped.
+			i++
+		case separator:
+			// An unescaped separator ends the part.
+			parts = append(parts, value[start:i])
+			start = i + 1
+		}
+	}
+	// The last part is appended and the parts are returned.
+	return append(parts, value[start:])
+}
+
+// escapeText escapes a TEXT value.
+//
+// @param text string - The text.
+// @return string - The escaped text.
+func escapeText(text string) string {
+	// The special characters are escaped.
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// unescapeText unescapes a TEXT value.
+//
+// @param text string - The escaped text.
+// @return string - The text.
+func unescapeText(text string) string {
+	// The escape sequences are replaced.
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(text)
+}
+
+// foldLine folds a content line into lines of at most 75 octets without splitting UTF-8 characters.
+//
+// @param line string - The content line.
+// @return string - The folded line.
+func foldLine(line string) string {
+	// builder collects the folded line.
+	var builder strings.Builder
+	// width is the number of octets on the current physical line.
+	width := 0
+	// This iterates over the characters of the line.
+	for _, char := range line {
+		// size is the number of octets of the character.
+		size := len(string(char))
+		// This checks if the character does not fit on the current line.
+		if width+size > 75 {
+			// If it does not, a new continuation line is started.
+			builder.WriteString("\r\n ")
+			width = 1
+		}
+		// The character is written.
+		builder.WriteRune(char)
+		width += size
+	}
+	// The folded line is returned.
+	return builder.String()
+}
@@ -0,0 +1,103 @@
+// This file defines the WebDAV XML documents used by the CalDAV endpoints.
+package caldav
+
+// "encoding/xml" provides functions for encoding and decoding XML. It is used here to define the WebDAV documents.
+import (
+	"encoding/xml"
+)
+
+// multistatus defines the structure of a WebDAV 207 Multi-Status response.
+type multistatus struct {
+	// XMLName is the name of the root element.
+	XMLName xml.Name `xml:"d:multistatus"`
+	// XmlnsD declares the WebDAV namespace.
+	XmlnsD string `xml:"xmlns:d,attr"`
+	// XmlnsC declares the CalDAV namespace.
+	XmlnsC string `xml:"xmlns:c,attr"`
+	// XmlnsCS declares the CalendarServer namespace used for getctag.
+	XmlnsCS string `xml:"xmlns:cs,attr"`
+	// Responses are the responses for each resource.
+	Responses []davResponse `xml:"d:response"`
+	// SyncToken is the new sync token of a sync-collection report.
+	SyncToken string `xml:"d:sync-token,omitempty"`
+}
+
+// davResponse defines the structure of the response for one resource.
+type davResponse struct {
+	// Href is the path of the resource.
+	Href string `xml:"d:href"`
+	// Status is set instead of Propstat for resources that do not exist, such as deleted todos.
+	Status string `xml:"d:status,omitempty"`
+	// Propstat holds the properties of the resource.
+	Propstat *propstat `xml:"d:propstat,omitempty"`
+}
+
+// propstat defines the structure of a group of properties and their status.
+type propstat struct {
+	// Prop holds the properties.
+	Prop prop `xml:"d:prop"`
+	// Status is the status of the properties.
+	Status string `xml:"d:status"`
+}
+
+// prop defines the properties the server reports. Empty properties are omitted.
+type prop struct {
+	// ResourceType is the type of the resource.
+	ResourceType *resourceType `xml:"d:resourcetype,omitempty"`
+	// DisplayName is the display name of the resource.
+	DisplayName string `xml:"d:displayname,omitempty"`
+	// CurrentUserPrincipal is the principal of the authenticated user.
+	CurrentUserPrincipal *hrefProp `xml:"d:current-user-principal,omitempty"`
+	// CalendarHomeSet is the collection that holds the user's calendars.
+	CalendarHomeSet *hrefProp `xml:"c:calendar-home-set,omitempty"`
+	// SupportedComponents is the list of components a calendar accepts.
+	SupportedComponents *componentSet `xml:"c:supported-calendar-component-set,omitempty"`
+	// GetCTag changes whenever anything in the calendar changes.
+	GetCTag string `xml:"cs:getctag,omitempty"`
+	// SyncToken is the current sync token of the calendar.
+	SyncToken string `xml:"d:sync-token,omitempty"`
+	// GetETag is the entity tag of a todo.
+	GetETag string `xml:"d:getetag,omitempty"`
+	// GetContentType is the content type of a todo.
+	GetContentType string `xml:"d:getcontenttype,omitempty"`
+	// CalendarData is the iCalendar data of a todo.
+	CalendarData string `xml:"c:calendar-data,omitempty"`
+}
+
+// resourceType defines the structure of a resource type. A non-nil empty value is a plain resource.
+type resourceType struct {
+	// Collection marks a collection.
+	Collection *struct{} `xml:"d:collection,omitempty"`
+	// Calendar marks a calendar collection.
+	Calendar *struct{} `xml:"c:calendar,omitempty"`
+	// Principal marks a principal.
+	Principal *struct{} `xml:"d:principal,omitempty"`
+}
+
+// hrefProp defines the structure of a property that holds a path.
+type hrefProp struct {
+	// Href is the path.
+	Href string `xml:"d:href"`
+}
+
+// componentSet defines the structure of a list of calendar components.
+type componentSet struct {
+	// Components are the components.
+	Components []component `xml:"c:comp"`
+}
+
+// component defines the structure of a calendar component name.
+type component struct {
+	// Name is the name of the component, such as VTODO.
+	Name string `xml:"name,attr"`
+}
+
+// reportRequest defines the structure of the REPORT request bodies the server understands.
+type reportRequest struct {
+	// XMLName is the name of the root element, which selects the report.
+	XMLName xml.Name
+	// Hrefs are the paths requested by a calendar-multiget report.
+	Hrefs []string `xml:"DAV: href"`
+	// SyncToken is the token sent with a sync-collection report.
+	SyncToken string `xml:"DAV: sync-token"`
+}
@@ -0,0 +1,38 @@
+// This file defines the SQL queries used for CalDAV-related database operations.
+package caldav
+
+// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names and schemas.
+import "github.com/rahulcodepython/todo-backend/backend/utils"
+
+// todoNameCondition matches a todo by its CalDAV resource name, which is its ical_uid or, for todos created through the API, its ID.
+const todoNameCondition = "(ical_uid = $2 OR (ical_uid IS NULL AND id::text = $2))"
+
+// GetTodoByNameQuery is the SQL query to retrieve a user's todo by its resource name.
+const GetTodoByNameQuery = "SELECT " + utils.TodoSelectSchema + " FROM " + utils.TodoTableName + " WHERE owner = $1 AND deleted_at IS NULL AND " + todoNameCondition
+
+// GetAllTodosQuery is the SQL query to retrieve all todos of a user.
+const GetAllTodosQuery = "SELECT " + utils.TodoSelectSchema + " FROM " + utils.TodoTableName + " WHERE owner = $1 AND deleted_at IS NULL ORDER BY position, id"
+
+// syncHorizon is the ID of the oldest transaction still running. Every change of a transaction below it has committed, and no change
+// below it can commit later, so a sync token never passes it.
+const syncHorizon = "pg_snapshot_xmin(pg_current_snapshot())::text::bigint"
+
+// GetSyncCursorQuery is the SQL query to retrieve the cursor just past the last change of a user's todos, including deleted ones
+// and tombstones, whose transaction is below the sync horizon.
+const GetSyncCursorQuery = "SELECT GREATEST((SELECT COALESCE(MAX(change_xid) + 1, 0) FROM " + utils.TodoTableName + " WHERE owner = $1 AND change_xid < " + syncHorizon + "), (SELECT COALESCE(MAX(change_xid) + 1, 0) FROM " + utils.TodoTombstoneTableName + " WHERE owner = $1 AND change_xid < " + syncHorizon + "))"
+
+// GetChangedTodosQuery is the SQL query to retrieve a user's todos that changed between two cursors.
+const GetChangedTodosQuery = "SELECT " + utils.TodoSelectSchema + " FROM " + utils.TodoTableName + " WHERE owner = $1 AND change_xid >= $2 AND change_xid < $3 AND deleted_at IS NULL ORDER BY change_xid, version"
+
+// GetDeletedTodoNamesQuery is the SQL query to retrieve the resource names of a user's todos that were deleted between two cursors,
+// from the soft deleted todos and from the tombstones of the todos that left the table, such as for the archive.
+const GetDeletedTodoNamesQuery = "SELECT COALESCE(ical_uid, id::text) FROM " + utils.TodoTableName + " WHERE owner = $1 AND change_xid >= $2 AND change_xid < $3 AND deleted_at IS NOT NULL UNION ALL SELECT name FROM " + utils.TodoTombstoneTableName + " WHERE owner = $1 AND change_xid >= $2 AND change_xid < $3"
+
+// CreateTodoQuery is the SQL query to insert a todo created by a CalDAV client.
+const CreateTodoQuery = "INSERT INTO " + utils.TodoTableName + " (" + utils.TodoTableSchema + ", ical_uid) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING " + utils.TodoSelectSchema
+
+// UpdateTodoQuery is the SQL query to replace the fields of a todo, optionally only if its version still matches.
+const UpdateTodoQuery = "UPDATE " + utils.TodoTableName + " SET title = $1, description = $2, priority = $3, completed = $4, due_at = $5, tags = $6, reminded_at = CASE WHEN due_at IS DISTINCT FROM $5 THEN NULL ELSE reminded_at END, snoozed_until = CASE WHEN due_at IS DISTINCT FROM $5 THEN NULL ELSE snoozed_until END WHERE id = $7 AND deleted_at IS NULL AND ($8::bigint IS NULL OR version = $8) RETURNING " + utils.TodoSelectSchema
+
+// DeleteTodoQuery is the SQL query to soft delete a todo, optionally only if its version still matches.
+const DeleteTodoQuery = "UPDATE " + utils.TodoTableName + " SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL AND ($2::bigint IS NULL OR version = $2)"
@@ -0,0 +1,164 @@
+// This file defines the admin controller that reports runtime diagnostics.
+package diagnostics
+
+// "database/sql" provides a generic SQL interface. It is used here to read the connection pool statistics.
+import (
+	"database/sql"
+	// "runtime" provides access to the Go runtime. It is used here to read goroutine and memory statistics.
+	"runtime"
+	// "time" provides functions for working with time. It is used here to measure the uptime and convert durations.
+	"time"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to define the controllers.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/backend/binding" is a local package that binds request parameters. It is used here to read the number of slow statements.
+	"github.com/rahulcodepython/todo-backend/backend/binding"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database helpers. It is used here to read the slow queries.
+	"github.com/rahulcodepython/todo-backend/backend/database"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+)
+
+// DiagnosticsController is a struct that holds the configuration, database connection, and start time.
+type DiagnosticsController struct {
+	// cfg is the application configuration.
+	cfg *config.Config
+	// db is the database connection.
+	db *sql.DB
+	// startedAt is the time the controller was created, which is when the server started.
+	startedAt time.Time
+}
+
+// NewDiagnosticsControl creates a new DiagnosticsController.
+// It takes the application configuration and database connection as input.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @return *DiagnosticsController - A pointer to the new DiagnosticsController.
+func NewDiagnosticsControl(cfg *config.Config, db *sql.DB) *DiagnosticsController {
+	// A new DiagnosticsController is returned.
+	return &DiagnosticsController{
+		// The cfg field is set to the application configuration.
+		cfg: cfg,
+		// The db field is set to the database connection.
+		db: db,
+		// The startedAt field is set to the current time.
+		startedAt: time.Now(),
+	}
+}
+
+// RuntimeController reports the goroutine count, heap statistics, and database pool statistics.
+// Reading the memory statistics briefly stops the world, so it is meant for occasional use by an admin.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (dc *DiagnosticsController) RuntimeController(c *fiber.Ctx) error {
+	// memory holds the memory statistics.
+	var memory runtime.MemStats
+	// The memory statistics are read.
+	runtime.ReadMemStats(&memory)
+	// pool holds the connection pool statistics.
+	pool := dc.db.Stats()
+	// slowQueries is the number of slow queries.
+	slowQueries, _ := database.SlowQueries(0)
+
+	// An OK response is returned with a success message and the snapshot.
+	return response.OKResponse(c, "Diagnostics fetched successfully", RuntimeResponse{
+		// The GoVersion field is set to the version of Go.
+		GoVersion: runtime.Version(),
+		// The Uptime field is set to the seconds since the server started.
+		Uptime: int64(time.Since(dc.startedAt).Seconds()),
+		// The Goroutines field is set to the number of goroutines.
+		Goroutines: runtime.NumGoroutine(),
+		// The CPUs field is set to the number of usable CPUs.
+		CPUs: runtime.GOMAXPROCS(0),
+		// The Memory field is set to the memory statistics.
+		Memory: MemoryStats{
+			// The HeapAlloc field is set to the bytes of allocated heap objects.
+			HeapAlloc: memory.HeapAlloc,
+			// The HeapInuse field is set to the bytes in in-use heap spans.
+			HeapInuse: memory.HeapInuse,
+			// The HeapObjects field is set to the number of allocated heap objects.
+			HeapObjects: memory.HeapObjects,
+			// The Sys field is set to the bytes obtained from the operating system.
+			Sys: memory.Sys,
+			// The NumGC field is set to the number of garbage collections.
+			NumGC: memory.NumGC,
+			// The PauseTotal field is set to the total garbage collection pause in milliseconds.
+			PauseTotal: float64(memory.PauseTotalNs) / float64(time.Millisecond),
+		},
+		// The Database field is set to the connection pool statistics.
+		Database: DatabaseStats{
+			// The MaxOpenConnections field is set to the connection limit of the pool.
+			MaxOpenConnections: pool.MaxOpenConnections,
+			// The OpenConnections field is set to the number of open connections.
+			OpenConnections: pool.OpenConnections,
+			// The InUse field is set to the number of connections in use.
+			InUse: pool.InUse,
+			// The Idle field is set to the number of idle connections.
+			Idle: pool.Idle,
+			// The WaitCount field is set to the number of waits for a connection.
+			WaitCount: pool.WaitCount,
+			// The WaitDuration field is set to the total wait for a connection in milliseconds.
+			WaitDuration: float64(pool.WaitDuration) / float64(time.Millisecond),
+			// The MaxIdleClosed field is set to the connections closed for being idle.
+			MaxIdleClosed: pool.MaxIdleClosed,
+			// The MaxLifetimeClosed field is set to the connections closed for their age.
+			MaxLifetimeClosed: pool.MaxLifetimeClosed,
+			// The SlowQueries field is set to the number of slow queries.
+			SlowQueries: slowQueries,
+		},
+	})
+}
+
+// SlowQueriesController reports the statements that were slower than DB_SLOW_QUERY_MS on this server since it started,
+// ranked by the time they took in slow runs altogether. The statements are shown without their arguments.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (dc *DiagnosticsController) SlowQueriesController(c *fiber.Ctx) error {
+	// query is the result of binding the query parameters.
+	query, err := binding.Query[SlowQueriesQuery](c)
+	// This checks if any query parameter is invalid.
+	if err != nil {
+		// If one is, a bad request response is returned with the invalid parameters.
+		return response.InvalidParameters(c, err)
+	}
+	// total is the number of slow queries, and statements the slowest statements.
+	total, statements := database.SlowQueries(query.Limit)
+
+	// queries is a slice that will hold the statements converted into their response structure.
+	queries := make([]SlowQueryResponse, 0, len(statements))
+	// This iterates over the statements.
+	for _, statement := range statements {
+		// The statement is appended to the queries slice.
+		queries = append(queries, SlowQueryResponse{
+			// The Query field is set to the statement.
+			Query: statement.Query,
+			// The Count field is set to the number of slow runs.
+			Count: statement.Count,
+			// The Total field is set to the time of the slow runs in milliseconds.
+			Total: float64(statement.Total) / float64(time.Millisecond),
+			// The Mean field is set to the mean time of the slow runs in milliseconds.
+			Mean: float64(statement.Total) / float64(statement.Count) / float64(time.Millisecond),
+			// The Max field is set to the time of the slowest run in milliseconds.
+			Max: float64(statement.Max) / float64(time.Millisecond),
+			// The LastSeen field is set to when the statement was last slow.
+			LastSeen: statement.LastSeen,
+		})
+	}
+
+	// An OK response is returned with a success message and the slow queries.
+	return response.OKResponse(c, "Slow queries fetched successfully", SlowQueriesResponse{
+		// The Threshold field is set to the slow query threshold in milliseconds.
+		Threshold: dc.cfg.Database.SlowQueryThreshold.Milliseconds(),
+		// The Total field is set to the number of slow queries.
+		Total: total,
+		// The Queries field is set to the slowest statements.
+		Queries: queries,
+	})
+}
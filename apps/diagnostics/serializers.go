@@ -0,0 +1,122 @@
+// This file defines the serializers for the runtime diagnostics response.
+package diagnostics
+
+// "time" provides functions for working with time. It is used here to describe when a query was last slow.
+import "time"
+
+// RuntimeResponse defines the structure for a snapshot of the runtime and the database pool.
+type RuntimeResponse struct {
+	// GoVersion is the version of Go the server was built with.
+	// json:"go_version" specifies that this field should be marshalled to/from a JSON object with the key "go_version".
+	GoVersion string `json:"go_version"`
+	// Uptime is the number of seconds since the server started.
+	// json:"uptime_seconds" specifies that this field should be marshalled to/from a JSON object with the key "uptime_seconds".
+	Uptime int64 `json:"uptime_seconds"`
+	// Goroutines is the number of goroutines that currently exist.
+	// json:"goroutines" specifies that this field should be marshalled to/from a JSON object with the key "goroutines".
+	Goroutines int `json:"goroutines"`
+	// CPUs is the number of CPUs the server may use.
+	// json:"cpus" specifies that this field should be marshalled to/from a JSON object with the key "cpus".
+	CPUs int `json:"cpus"`
+	// Memory holds the heap and garbage collector statistics.
+	// json:"memory" specifies that this field should be marshalled to/from a JSON object with the key "memory".
+	Memory MemoryStats `json:"memory"`
+	// Database holds the connection pool statistics.
+	// json:"database" specifies that this field should be marshalled to/from a JSON object with the key "database".
+	Database DatabaseStats `json:"database"`
+}
+
+// MemoryStats defines the structure for the heap and garbage collector statistics.
+type MemoryStats struct {
+	// HeapAlloc is the number of bytes of allocated heap objects.
+	// json:"heap_alloc_bytes" specifies that this field should be marshalled to/from a JSON object with the key "heap_alloc_bytes".
+	HeapAlloc uint64 `json:"heap_alloc_bytes"`
+	// HeapInuse is the number of bytes in in-use heap spans.
+	// json:"heap_inuse_bytes" specifies that this field should be marshalled to/from a JSON object with the key "heap_inuse_bytes".
+	HeapInuse uint64 `json:"heap_inuse_bytes"`
+	// HeapObjects is the number of allocated heap objects.
+	// json:"heap_objects" specifies that this field should be marshalled to/from a JSON object with the key "heap_objects".
+	HeapObjects uint64 `json:"heap_objects"`
+	// Sys is the number of bytes of memory obtained from the operating system.
+	// json:"sys_bytes" specifies that this field should be marshalled to/from a JSON object with the key "sys_bytes".
+	Sys uint64 `json:"sys_bytes"`
+	// NumGC is the number of completed garbage collection cycles.
+	// json:"num_gc" specifies that this field should be marshalled to/from a JSON object with the key "num_gc".
+	NumGC uint32 `json:"num_gc"`
+	// PauseTotal is the total time the garbage collector stopped the world, in milliseconds.
+	// json:"gc_pause_total_ms" specifies that this field should be marshalled to/from a JSON object with the key "gc_pause_total_ms".
+	PauseTotal float64 `json:"gc_pause_total_ms"`
+}
+
+// DatabaseStats defines the structure for the database connection pool statistics.
+type DatabaseStats struct {
+	// MaxOpenConnections is the maximum number of open connections, or 0 for no limit.
+	// json:"max_open_connections" specifies that this field should be marshalled to/from a JSON object with the key "max_open_connections".
+	MaxOpenConnections int `json:"max_open_connections"`
+	// OpenConnections is the number of open connections.
+	// json:"open_connections" specifies that this field should be marshalled to/from a JSON object with the key "open_connections".
+	OpenConnections int `json:"open_connections"`
+	// InUse is the number of connections in use.
+	// json:"in_use" specifies that this field should be marshalled to/from a JSON object with the key "in_use".
+	InUse int `json:"in_use"`
+	// Idle is the number of idle connections.
+	// json:"idle" specifies that this field should be marshalled to/from a JSON object with the key "idle".
+	Idle int `json:"idle"`
+	// WaitCount is the number of times a request waited for a connection.
+	// json:"wait_count" specifies that this field should be marshalled to/from a JSON object with the key "wait_count".
+	WaitCount int64 `json:"wait_count"`
+	// WaitDuration is the total time spent waiting for a connection, in milliseconds.
+	// json:"wait_duration_ms" specifies that this field should be marshalled to/from a JSON object with the key "wait_duration_ms".
+	WaitDuration float64 `json:"wait_duration_ms"`
+	// MaxIdleClosed is the number of connections closed because the pool had too many idle connections.
+	// json:"max_idle_closed" specifies that this field should be marshalled to/from a JSON object with the key "max_idle_closed".
+	MaxIdleClosed int64 `json:"max_idle_closed"`
+	// MaxLifetimeClosed is the number of connections closed because they reached their maximum lifetime.
+	// json:"max_lifetime_closed" specifies that this field should be marshalled to/from a JSON object with the key "max_lifetime_closed".
+	MaxLifetimeClosed int64 `json:"max_lifetime_closed"`
+	// SlowQueries is the number of queries slower than DB_SLOW_QUERY_MS since the server started.
+	// json:"slow_queries" specifies that this field should be marshalled to/from a JSON object with the key "slow_queries".
+	SlowQueries int64 `json:"slow_queries"`
+}
+
+// SlowQueriesQuery defines the query parameters of a request for the slowest statements.
+type SlowQueriesQuery struct {
+	// Limit is the number of statements to return.
+	// query:"limit" specifies that this field is bound to the "limit" query parameter.
+	Limit int `query:"limit" default:"10" min:"1" max:"100"`
+}
+
+// SlowQueriesResponse defines the structure for the slow queries of the server since it started.
+type SlowQueriesResponse struct {
+	// Threshold is the duration above which a query is slow, in milliseconds, or 0 if queries are not watched.
+	// json:"threshold_ms" specifies that this field should be marshalled to/from a JSON object with the key "threshold_ms".
+	Threshold int64 `json:"threshold_ms"`
+	// Total is the number of slow queries.
+	// json:"total" specifies that this field should be marshalled to/from a JSON object with the key "total".
+	Total int64 `json:"total"`
+	// Queries holds the statements that took the most time in slow runs, slowest first.
+	// json:"queries" specifies that this field should be marshalled to/from a JSON object with the key "queries".
+	Queries []SlowQueryResponse `json:"queries"`
+}
+
+// SlowQueryResponse defines the structure for the counts of a slow statement.
+type SlowQueryResponse struct {
+	// Query is the statement, without its arguments.
+	// json:"query" specifies that this field should be marshalled to/from a JSON object with the key "query".
+	Query string `json:"query"`
+	// Count is the number of times the statement was slow.
+	// json:"count" specifies that this field should be marshalled to/from a JSON object with the key "count".
+	Count int64 `json:"count"`
+	// Total is the sum of the durations of the slow runs, in milliseconds.
+	// json:"total_ms" specifies that this field should be marshalled to/from a JSON object with the key "total_ms".
+	Total float64 `json:"total_ms"`
+	// Mean is the mean duration of the slow runs, in milliseconds.
+	// json:"mean_ms" specifies that this field should be marshalled to/from a JSON object with the key "mean_ms".
+	Mean float64 `json:"mean_ms"`
+	// Max is the duration of the slowest run, in milliseconds.
+	// json:"max_ms" specifies that this field should be marshalled to/from a JSON object with the key "max_ms".
+	Max float64 `json:"max_ms"`
+	// LastSeen is when the statement was last slow.
+	// json:"last_seen" specifies that this field should be marshalled to/from a JSON object with the key "last_seen".
+	LastSeen time.Time `json:"last_seen"`
+}
@@ -0,0 +1,872 @@
+// This file defines the controllers for list-related operations.
+package lists
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to pass the deadline of the request to the helpers.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to interact with the database.
+	"database/sql"
+	// "errors" provides functions for working with errors. It is used here to define and compare reorder errors.
+	"errors"
+	// "time" provides functions for working with time. It is used here to set timestamps.
+	"time"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to define the controllers.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/golang-jwt/jwt/v5" is a package for creating and verifying JWTs. It is used here to sign the confirmation tokens of bulk completions.
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to parse UUIDs.
+	"github.com/google/uuid"
+	// "github.com/lib/pq" is the PostgreSQL driver. It is used here to pass arrays as query parameters.
+	"github.com/lib/pq"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/binding" is a local package that binds request parameters.
+	"github.com/rahulcodepython/todo-backend/backend/binding"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/content" is a local package that validates text written by users.
+	"github.com/rahulcodepython/todo-backend/backend/content"
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database helpers.
+	"github.com/rahulcodepython/todo-backend/backend/database"
+	// "github.com/rahulcodepython/todo-backend/backend/i18n" is a local package that translates messages.
+	"github.com/rahulcodepython/todo-backend/backend/i18n"
+	// "github.com/rahulcodepython/todo-backend/backend/idgen" is a local package that creates new IDs.
+	"github.com/rahulcodepython/todo-backend/backend/idgen"
+	// "github.com/rahulcodepython/todo-backend/backend/outbox" is a local package that records domain events.
+	"github.com/rahulcodepython/todo-backend/backend/outbox"
+	// "github.com/rahulcodepython/todo-backend/backend/quota" is a local package that enforces usage limits.
+	"github.com/rahulcodepython/todo-backend/backend/quota"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// errForeignTodos is returned when a reorder request references todos that are not in the list.
+var errForeignTodos = errors.New("some todos do not exist or do not belong to this list")
+
+// errConfirmationRequired is returned when a bulk completion changes more todos than the threshold without a valid confirmation token.
+var errConfirmationRequired = errors.New("completing this many todos must be confirmed")
+
+// completeAllPurpose is the purpose claim of the confirmation tokens of bulk completions.
+const completeAllPurpose = "complete_all"
+
+// completeAllConfirmationTTL is how long a confirmation token of a bulk completion works.
+const completeAllConfirmationTTL = 5 * time.Minute
+
+// ErrListNotFound is returned when a list does not exist or has been deleted.
+var ErrListNotFound = errors.New("list not found")
+
+// ErrListForbidden is returned when a list belongs to another user.
+var ErrListForbidden = errors.New("list belongs to another user")
+
+// ListController is a struct that holds the configuration and database connection.
+type ListController struct {
+	// cfg is the application configuration.
+	cfg *config.Config
+	// db is the database connection.
+	db *sql.DB
+	// ids creates the IDs of new records.
+	ids idgen.IDGenerator
+	// content cleans and checks list names.
+	content *content.Validator
+}
+
+// NewListControl creates a new ListController.
+// It takes the application configuration, database connection, ID generator, and content validator as input.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @param ids idgen.IDGenerator - The generator of the IDs of new records.
+// @param validator *content.Validator - The validator of list names.
+// @return *ListController - A pointer to the new ListController.
+func NewListControl(cfg *config.Config, db *sql.DB, ids idgen.IDGenerator, validator *content.Validator) *ListController {
+	// A new ListController is returned.
+	return &ListController{
+		// The cfg field is set to the application configuration.
+		cfg: cfg,
+		// The db field is set to the database connection.
+		db: db,
+		// The ids field is set to the ID generator.
+		ids: ids,
+		// The content field is set to the content validator.
+		content: validator,
+	}
+}
+
+// ListAccessError explains why a statement scoped to the user's lists matched no list.
+// It is only called after such a statement came back empty, so the common path needs no extra round trip.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param tx *sql.Tx - The transaction of the statement.
+// @param listId uuid.UUID - The ID of the list.
+// @param currentUserId uuid.UUID - The ID of the current user.
+// @return error - ErrListNotFound if the list does not exist, ErrListForbidden if it belongs to another user, nil if it belongs to the user, or another error if one occurred.
+func ListAccessError(ctx context.Context, tx *sql.Tx, listId uuid.UUID, currentUserId uuid.UUID) error {
+	// ownerId is a variable that will hold the ID of the list's owner.
+	var ownerId uuid.UUID
+
+	// err is the result of querying the database for the list's owner.
+	err := tx.QueryRowContext(ctx, GetListOwnerQuery, listId).Scan(&ownerId)
+	// This checks if the list does not exist.
+	if err == sql.ErrNoRows {
+		// If it does not, ErrListNotFound is returned.
+		return ErrListNotFound
+	}
+	// This checks if another error occurred while querying the database.
+	if err != nil {
+		// If one did, it is returned.
+		return err
+	}
+	// This checks if the list belongs to another user.
+	if ownerId != currentUserId {
+		// If it does, ErrListForbidden is returned.
+		return ErrListForbidden
+	}
+	// Otherwise the list belongs to the user.
+	return nil
+}
+
+// listScanner is implemented by *sql.Row and *sql.Rows.
+type listScanner interface {
+	// Scan copies the columns of the current row into the values pointed at by dest.
+	Scan(dest ...any) error
+}
+
+// ScanList scans a row selected with utils.ListSelectSchema into a List struct.
+//
+// @param row listScanner - The row to be scanned.
+// @return List - The scanned list.
+// @return error - An error if one occurred.
+func ScanList(row listScanner) (List, error) {
+	// list is a new List struct.
+	var list List
+	// err is the result of scanning the row into the list struct.
+	err := row.Scan(&list.ID, &list.Name, &list.Owner, &list.CreatedAt, &list.Version, &list.Color, &list.Icon, &list.ArchivedAt)
+	// The scanned list and the error, if any, are returned.
+	return list, err
+}
+
+// RecordListEvent records a domain event about a list in the outbox, with the list as its data.
+// It must be called inside the transaction that changed the list.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param tx *sql.Tx - The transaction.
+// @param eventType string - One of the outbox event type constants.
+// @param list List - The list after the change.
+// @return error - An error if one occurred.
+func RecordListEvent(ctx context.Context, tx *sql.Tx, eventType string, list List) error {
+	// The event is recorded with the list's response structure as its data.
+	return outbox.Record(ctx, tx, eventType, list.Owner, list.ID, NewListResponse(list))
+}
+
+// CreateListController handles the creation of a new list.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (lc *ListController) CreateListController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// body is a new CreateListRequest struct.
+	body := new(CreateListRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+
+	// The name is cleaned of control characters, and its length and words are checked.
+	contentErr := lc.content.Clean(content.Text{Field: lc.content.ListName, Value: &body.Name})
+	// This checks if the name is empty.
+	if body.Name == "" {
+		// If the name is empty, a bad request response is returned.
+		return response.BadResponse(c, "Name is required")
+	}
+	// This checks if the name is too long or contains blocked words.
+	if contentErr != nil {
+		// If it does, a bad request response is returned with the invalid field.
+		return response.BadInternalResponse(c, contentErr, "Invalid request body")
+	}
+	// The color and icon are normalized and checked.
+	if errs := content.CleanAppearance(content.Appearance{Color: &body.Color, Icon: &body.Icon}); errs != nil {
+		// If one is invalid, a bad request response is returned with the invalid fields.
+		return response.BadInternalResponse(c, errs, "Invalid request body")
+	}
+
+	// listId is the new UUID for the list.
+	listId := lc.ids.NewID()
+
+	// list is a new List struct.
+	list := List{
+		// The ID field is set to the new UUID.
+		ID: listId,
+		// The Name field is set to the list's name.
+		Name: body.Name,
+		// The Owner field is set to the current user's ID.
+		Owner: user.ID,
+		// The CreatedAt field is set to the current time.
+		CreatedAt: time.Now(),
+		// The Color field is set to the list's color, which is stored as NULL when none was picked.
+		Color: sql.NullString{String: body.Color, Valid: body.Color != ""},
+		// The Icon field is set to the list's icon, which is stored as NULL when none was picked.
+		Icon: sql.NullString{String: body.Icon, Valid: body.Icon != ""},
+	}
+
+	// err is the result of creating the list and recording the event in one transaction.
+	err = database.WithTx(c.UserContext(), lc.db, func(tx *sql.Tx) error {
+		// This checks that the list fits in the user's plan.
+		if err := quota.Check(c.UserContext(), tx, lc.cfg, user.ID, quota.Lists, 1); err != nil {
+			// If it does not, the error is returned.
+			return err
+		}
+		// This executes the SQL query to create the new list and reads back its version.
+		if err := tx.QueryRowContext(c.UserContext(), CreateListQuery, list.ID, list.Name, list.Owner, list.CreatedAt, list.Color, list.Icon).Scan(&list.Version); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The event is recorded.
+		return RecordListEvent(c.UserContext(), tx, outbox.ListCreated, list)
+	})
+	// This checks if an error occurred while executing the transaction.
+	if err != nil {
+		// exceeded is the limit that was reached, if any.
+		var exceeded *quota.ExceededError
+		// This checks if the plan limit was reached.
+		if errors.As(err, &exceeded) {
+			// If it was, a quota exceeded response is returned.
+			return response.QuotaExceeded(c, exceeded)
+		}
+		// If another error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to create list")
+	}
+
+	// listResponse is the response structure of the new list.
+	listResponse := NewListResponse(list)
+	// A created response is returned with a success message, the list data, and the list's path in the Location header.
+	return response.OKCreatedResponse(c, "List created successfully", listResponse, listResponse.URL)
+}
+
+// GetListsController handles the retrieval of the current user's lists.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (lc *ListController) GetListsController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// query is the bound query parameters.
+	query, err := binding.Query[ListListsQuery](c)
+	// This checks if a parameter is invalid.
+	if err != nil {
+		// If one is, a bad request response is returned with the invalid parameters.
+		return response.InvalidParameters(c, err)
+	}
+
+	// This checks if the request asks for the lists as newline-delimited JSON.
+	if response.WantsNDJSON(c) {
+		// If it does, the lists are streamed as they are read.
+		return response.NDJSON(c, "Unable to get lists", func(ctx context.Context) (*sql.Rows, error) {
+			// The query for the lists is run.
+			return lc.db.QueryContext(ctx, GetListsByUserQuery, user.ID, sql.NullString{String: query.Color, Valid: query.Color != ""}, query.Archived)
+		}, func(rows *sql.Rows) (any, error) {
+			// list is the result of scanning the row.
+			list, err := ScanList(rows)
+			// The list is returned in its response structure.
+			return NewListResponse(list), err
+		})
+	}
+
+	// rows is the result of querying the database for the user's archived or other lists, of the requested color if one was given.
+	rows, err := lc.db.QueryContext(c.UserContext(), GetListsByUserQuery, user.ID, sql.NullString{String: query.Color, Valid: query.Color != ""}, query.Archived)
+	// This checks if an error occurred while querying the database.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get lists")
+	}
+	// This defers the closing of the rows until the function returns.
+	defer rows.Close()
+
+	// lists is a slice that will hold the retrieved lists.
+	lists := []ListResponse{}
+
+	// This iterates over the rows.
+	for rows.Next() {
+		// list is the result of scanning the row.
+		list, err := ScanList(rows)
+		// This checks if an error occurred while scanning the row.
+		if err != nil {
+			// If an error occurs, an internal server error response is returned.
+			return response.InternelServerError(c, err, "Unable to get lists")
+		}
+
+		// The list is appended to the lists slice.
+		lists = append(lists, NewListResponse(list))
+	}
+
+	// An OK response is returned with a success message and the lists.
+	return response.OKResponse(c, "Lists fetched successfully", lists)
+}
+
+// GetListController handles the retrieval of a single list.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (lc *ListController) GetListController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// listId is the parsed value of the "id" path parameter.
+	listId, err := uuid.Parse(c.Params("id"))
+	// This checks if the list ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid list id")
+	}
+
+	// list is the result of querying the database for the list.
+	list, err := ScanList(lc.db.QueryRowContext(c.UserContext(), GetListQuery, listId))
+	// This checks if the list does not exist.
+	if err == sql.ErrNoRows {
+		// If it does not, a not found response is returned.
+		return response.NotFound(c, nil, "List not found")
+	}
+	// This checks if another error occurred while querying the database.
+	if err != nil {
+		// If one did, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get list")
+	}
+	// This checks if the list belongs to another user.
+	if list.Owner != user.ID {
+		// If it does, a forbidden response is returned.
+		return response.Forbidden(c, "You are not authorized to view this list")
+	}
+
+	// An OK response is returned with a success message and the list data.
+	return response.OKResponse(c, "List fetched successfully", NewListResponse(list))
+}
+
+// UpdateListController handles renaming a list and changing its color and icon.
+// Only the fields in the request are changed; an empty color or icon removes it.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (lc *ListController) UpdateListController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// listId is the parsed value of the "id" path parameter.
+	listId, err := uuid.Parse(c.Params("id"))
+	// This checks if the list ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid list id")
+	}
+
+	// body is a new UpdateListRequest struct.
+	body := new(UpdateListRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+
+	// name is the new name, which is NULL when the name is kept.
+	var name sql.NullString
+	// This checks if a new name was given.
+	if body.Name != nil {
+		// The name is cleaned of control characters, and its length and words are checked.
+		if err := lc.content.Clean(content.Text{Field: lc.content.ListName, Value: body.Name}); err != nil {
+			// If it is invalid, a bad request response is returned with the invalid field.
+			return response.BadInternalResponse(c, err, "Invalid request body")
+		}
+		// This checks if the name is empty.
+		if *body.Name == "" {
+			// If it is, a bad request response is returned.
+			return response.BadResponse(c, "Name is required")
+		}
+		// The name is set.
+		name = sql.NullString{String: *body.Name, Valid: true}
+	}
+	// The color and icon that were given are normalized and checked.
+	if errs := content.CleanAppearance(content.Appearance{Color: body.Color, Icon: body.Icon}); errs != nil {
+		// If one is invalid, a bad request response is returned with the invalid fields.
+		return response.BadInternalResponse(c, errs, "Invalid request body")
+	}
+	// color is the new color, which is NULL when it is removed.
+	var color sql.NullString
+	// This checks if a new color was given.
+	if body.Color != nil {
+		// If one was, it is set unless it is empty.
+		color = sql.NullString{String: *body.Color, Valid: *body.Color != ""}
+	}
+	// icon is the new icon, which is NULL when it is removed.
+	var icon sql.NullString
+	// This checks if a new icon was given.
+	if body.Icon != nil {
+		// If one was, it is set unless it is empty.
+		icon = sql.NullString{String: *body.Icon, Valid: *body.Icon != ""}
+	}
+
+	// list is the updated list.
+	var list List
+	// err is the result of updating the list and recording the event in one transaction.
+	err = database.WithTx(c.UserContext(), lc.db, func(tx *sql.Tx) error {
+		// The list is updated and read back.
+		list, err = ScanList(tx.QueryRowContext(c.UserContext(), UpdateListQuery, name, body.Color != nil, color, body.Icon != nil, icon, listId, user.ID))
+		// This checks if no list of the user matched.
+		if err == sql.ErrNoRows {
+			// If none did, the reason is looked up.
+			if err := ListAccessError(c.UserContext(), tx, listId, user.ID); err != nil {
+				// The reason is returned.
+				return err
+			}
+			// Otherwise the list was deleted between the two statements.
+			return ErrListNotFound
+		}
+		// This checks if another error occurred while updating the list.
+		if err != nil {
+			// If one did, it is returned.
+			return err
+		}
+		// The event is recorded.
+		return RecordListEvent(c.UserContext(), tx, outbox.ListUpdated, list)
+	})
+	// This checks if the list does not exist.
+	if errors.Is(err, ErrListNotFound) {
+		// If it does not, a not found response is returned.
+		return response.NotFound(c, nil, "List not found")
+	}
+	// This checks if the list belongs to another user.
+	if errors.Is(err, ErrListForbidden) {
+		// If it does, a forbidden response is returned.
+		return response.Forbidden(c, "You are not authorized to update this list")
+	}
+	// This checks if another error occurred while executing the transaction.
+	if err != nil {
+		// If one did, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to update list")
+	}
+
+	// An OK response is returned with a success message and the list data.
+	return response.OKResponse(c, "List updated successfully", NewListResponse(list))
+}
+
+// ArchiveListController handles archiving a list, which hides its todos from the default views of todos.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (lc *ListController) ArchiveListController(c *fiber.Ctx) error {
+	// The list is archived now.
+	return lc.setArchived(c, sql.NullTime{Time: time.Now(), Valid: true}, "List archived successfully")
+}
+
+// UnarchiveListController handles unarchiving a list, which shows its todos again.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (lc *ListController) UnarchiveListController(c *fiber.Ctx) error {
+	// The archive time of the list is cleared.
+	return lc.setArchived(c, sql.NullTime{}, "List unarchived successfully")
+}
+
+// setArchived archives or unarchives the list of the "id" path parameter and answers with the list.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param archivedAt sql.NullTime - The archive time, or NULL to unarchive the list.
+// @param message string - The message of the success response.
+// @return error - An error if one occurred.
+func (lc *ListController) setArchived(c *fiber.Ctx, archivedAt sql.NullTime, message string) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// listId is the parsed value of the "id" path parameter.
+	listId, err := uuid.Parse(c.Params("id"))
+	// This checks if the list ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid list id")
+	}
+
+	// list is the archived or unarchived list.
+	var list List
+	// err is the result of changing the list and recording the event in one transaction.
+	err = database.WithTx(c.UserContext(), lc.db, func(tx *sql.Tx) error {
+		// The archive time is set and the list is read back.
+		list, err = ScanList(tx.QueryRowContext(c.UserContext(), SetListArchivedQuery, archivedAt, listId, user.ID))
+		// This checks if no list of the user matched.
+		if err == sql.ErrNoRows {
+			// If none did, the reason is looked up.
+			if err := ListAccessError(c.UserContext(), tx, listId, user.ID); err != nil {
+				// The reason is returned.
+				return err
+			}
+			// Otherwise the list was deleted between the two statements.
+			return ErrListNotFound
+		}
+		// This checks if another error occurred while changing the list.
+		if err != nil {
+			// If one did, it is returned.
+			return err
+		}
+		// The event is recorded.
+		return RecordListEvent(c.UserContext(), tx, outbox.ListUpdated, list)
+	})
+	// This checks if the list does not exist.
+	if errors.Is(err, ErrListNotFound) {
+		// If it does not, a not found response is returned.
+		return response.NotFound(c, nil, "List not found")
+	}
+	// This checks if the list belongs to another user.
+	if errors.Is(err, ErrListForbidden) {
+		// If it does, a forbidden response is returned.
+		return response.Forbidden(c, "You are not authorized to update this list")
+	}
+	// This checks if another error occurred while executing the transaction.
+	if err != nil {
+		// If one did, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to update list")
+	}
+
+	// An OK response is returned with the success message and the list data.
+	return response.OKResponse(c, message, NewListResponse(list))
+}
+
+// CompleteAllController handles completing every open todo of a list in one statement.
+// A list with more open todos than TODO_BULK_CONFIRM_THRESHOLD is only completed with a confirmation token,
+// which the first request answers with, so that one stray request cannot complete hundreds of todos.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (lc *ListController) CompleteAllController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// listId is the parsed value of the "id" path parameter.
+	listId, err := uuid.Parse(c.Params("id"))
+	// This checks if the list ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid list id")
+	}
+
+	// body is a new CompleteAllRequest struct.
+	body := new(CompleteAllRequest)
+	// This checks if a body was sent, since it is only needed to confirm the completion.
+	if len(c.Body()) > 0 {
+		// This parses the request body into the body struct.
+		if err := c.BodyParser(body); err != nil {
+			// If an error occurs, a bad request response is returned.
+			return response.BadInternalResponse(c, err, "Invalid request body")
+		}
+	}
+
+	// open is the number of open todos of the list.
+	var open int
+	// completed is the event of the completion.
+	var completed ListCompletedEvent
+	// err is the result of completing the todos and recording the event in one transaction.
+	err = database.WithTx(c.UserContext(), lc.db, func(tx *sql.Tx) error {
+		// The event starts empty, since the transaction is run again after a serialization failure or a deadlock,
+		// and the todos of an aborted attempt must not be counted twice.
+		completed = ListCompletedEvent{ID: listId, TodoIDs: []uuid.UUID{}}
+		// This checks that the list exists and belongs to the user.
+		if err := ListAccessError(c.UserContext(), tx, listId, user.ID); err != nil {
+			// If it does not, the reason is returned.
+			return err
+		}
+		// This counts the open todos of the list.
+		if err := tx.QueryRowContext(c.UserContext(), CountOpenListTodosQuery, listId, user.ID).Scan(&open); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// This checks if there are too many todos to complete without a confirmation, and the token does not confirm this many.
+		if open > lc.cfg.Todo.BulkConfirmThreshold && !lc.confirmsCompleteAll(body.ConfirmationToken, listId, user.ID, open) {
+			// If there are, the completion must be confirmed.
+			return errConfirmationRequired
+		}
+
+		// rows is the result of completing the open todos and reading back their IDs.
+		rows, err := tx.QueryContext(c.UserContext(), CompleteListTodosQuery, listId, user.ID)
+		// This checks if an error occurred while completing the todos.
+		if err != nil {
+			// If one did, it is returned.
+			return err
+		}
+		// This defers the closing of the rows until the transaction function returns.
+		defer rows.Close()
+		// This iterates over the completed todos.
+		for rows.Next() {
+			// id is the ID of the completed todo.
+			var id uuid.UUID
+			// This scans the ID.
+			if err := rows.Scan(&id); err != nil {
+				// If an error occurs, it is returned.
+				return err
+			}
+			// The ID is appended to the event.
+			completed.TodoIDs = append(completed.TodoIDs, id)
+		}
+		// This checks if an error occurred while iterating over the rows.
+		if err := rows.Err(); err != nil {
+			// If one did, it is returned.
+			return err
+		}
+		// The number of completed todos is set.
+		completed.CompletedCount = len(completed.TodoIDs)
+		// This checks if no todo was completed.
+		if completed.CompletedCount == 0 {
+			// If none was, there is nothing to record.
+			return nil
+		}
+		// The event is recorded.
+		return outbox.Record(c.UserContext(), tx, outbox.ListCompleted, user.ID, listId, completed)
+	})
+	// This checks if the completion must be confirmed.
+	if errors.Is(err, errConfirmationRequired) {
+		// expiresAt is the time the confirmation token stops working.
+		expiresAt := time.Now().Add(completeAllConfirmationTTL)
+		// token is a token that confirms completing this many todos of the list.
+		token, err := lc.completeAllToken(listId, user.ID, open, expiresAt)
+		// This checks if an error occurred while signing the token.
+		if err != nil {
+			// If one did, an internal server error response is returned.
+			return response.InternelServerError(c, err, "Unable to complete todos")
+		}
+		// A precondition required response is returned with the token.
+		return response.ConfirmationRequired(c, "Completing this many todos must be confirmed", CompleteAllConfirmation{ConfirmationToken: token, TodoCount: open, ExpiresAt: utils.ParseTime(expiresAt)})
+	}
+	// This checks if the list does not exist.
+	if errors.Is(err, ErrListNotFound) {
+		// If it does not, a not found response is returned.
+		return response.NotFound(c, nil, "List not found")
+	}
+	// This checks if the list belongs to another user.
+	if errors.Is(err, ErrListForbidden) {
+		// If it does, a forbidden response is returned.
+		return response.Forbidden(c, "You are not authorized to update this list")
+	}
+	// This checks if another error occurred while executing the transaction.
+	if err != nil {
+		// If one did, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to complete todos")
+	}
+
+	// An OK response is returned with a success message and the completed todos.
+	return response.OKResponse(c, "Todos completed successfully", completed)
+}
+
+// completeAllToken signs a confirmation token for completing the open todos of a list.
+// The token names the list, the user, and the number of open todos, so it stops working once that number changes.
+//
+// @param listId uuid.UUID - The ID of the list.
+// @param userId uuid.UUID - The ID of the user.
+// @param count int - The number of open todos of the list.
+// @param expiresAt time.Time - The time the token stops working.
+// @return string - The token.
+// @return error - An error if the token could not be signed.
+func (lc *ListController) completeAllToken(listId uuid.UUID, userId uuid.UUID, count int, expiresAt time.Time) (string, error) {
+	// The signed token is returned.
+	return jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, jwtlib.MapClaims{
+		// "sub" is a claim that stores the ID of the user.
+		"sub": userId.String(),
+		// "list_id" is a claim that stores the ID of the list.
+		"list_id": listId.String(),
+		// "count" is a claim that stores the number of open todos that were confirmed.
+		"count": count,
+		// "purpose" is a claim that restricts the token to bulk completions.
+		"purpose": completeAllPurpose,
+		// "exp" is a claim that stores the time the token expires.
+		"exp": expiresAt.Unix(),
+	}).SignedString([]byte(lc.cfg.JWT.SecretKey))
+}
+
+// confirmsCompleteAll reports whether a token confirms completing a number of open todos of a list.
+//
+// @param token string - The confirmation token, or empty if none was sent.
+// @param listId uuid.UUID - The ID of the list.
+// @param userId uuid.UUID - The ID of the user.
+// @param count int - The current number of open todos of the list.
+// @return bool - True if the token is valid and names the list, the user, and the count.
+func (lc *ListController) confirmsCompleteAll(token string, listId uuid.UUID, userId uuid.UUID, count int) bool {
+	// This checks if no token was sent.
+	if token == "" {
+		// If none was, nothing is confirmed.
+		return false
+	}
+	// claims is a variable that will hold the claims of the token.
+	claims := jwtlib.MapClaims{}
+	// This parses and verifies the token, only accepting the signing method it was created with.
+	_, err := jwtlib.ParseWithClaims(token, claims, func(token *jwtlib.Token) (interface{}, error) {
+		// The signing key is returned.
+		return []byte(lc.cfg.JWT.SecretKey), nil
+	}, jwtlib.WithValidMethods([]string{jwtlib.SigningMethodHS256.Alg()}), jwtlib.WithExpirationRequired())
+	// This checks if the token is invalid or expired.
+	if err != nil {
+		// If it is, nothing is confirmed.
+		return false
+	}
+	// confirmed is the count claim, which JSON decodes as a number.
+	confirmed, _ := claims["count"].(float64)
+	// The token confirms the completion if it was made for it, and for this list, user, and count.
+	return claims["purpose"] == completeAllPurpose && claims["list_id"] == listId.String() && claims["sub"] == userId.String() && int(confirmed) == count
+}
+
+// ReorderListController handles reordering the todos of a list.
+// The todos are given positions in the order of the IDs in the request, and every ID must belong to the list.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (lc *ListController) ReorderListController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// listId is the parsed value of the "id" path parameter.
+	listId, err := uuid.Parse(c.Params("id"))
+	// This checks if the list ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid list id")
+	}
+
+	// body is a new ReorderListRequest struct.
+	body := new(ReorderListRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+
+	// This checks if no todo IDs were given.
+	if len(body.TodoIDs) == 0 {
+		// If none were given, a bad request response is returned.
+		return response.BadResponse(c, "Todo ids are required")
+	}
+	// This checks if more todos were given than the batch cap allows. A reorder is not split, since half of one is not an order.
+	if len(body.TodoIDs) > lc.cfg.Limits.MaxBatchItems {
+		// If so, a bad request response is returned.
+		return response.BadResponse(c, i18n.Sprintf(c, "Too many todo ids, reorder at most %d at a time", lc.cfg.Limits.MaxBatchItems))
+	}
+
+	// todoIds holds the todo IDs as strings so that they can be passed as a PostgreSQL array.
+	todoIds := make([]string, 0, len(body.TodoIDs))
+	// seen tracks the IDs that were already added so that duplicates can be rejected.
+	seen := make(map[uuid.UUID]bool, len(body.TodoIDs))
+	// This iterates over the todo IDs.
+	for _, id := range body.TodoIDs {
+		// This checks if the ID appears more than once.
+		if seen[id] {
+			// If it does, a bad request response is returned.
+			return response.BadResponse(c, "Todo ids must be unique")
+		}
+		// The ID is marked as seen.
+		seen[id] = true
+		// The ID is appended to the todo IDs.
+		todoIds = append(todoIds, id.String())
+	}
+
+	// err is the result of validating and reordering the todos in one transaction.
+	err = database.WithTx(c.UserContext(), lc.db, func(tx *sql.Tx) error {
+		// count is the number of referenced todos that belong to the user and the list.
+		var count int
+		// owned reports whether the list belongs to the user.
+		var owned bool
+		// err is the result of locking and counting the referenced todos and checking the list in one statement.
+		if err := tx.QueryRowContext(c.UserContext(), CountListTodosForReorderQuery, pq.Array(todoIds), user.ID, listId).Scan(&count, &owned); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// This checks if the list does not belong to the user.
+		if !owned {
+			// This looks up why the list does not belong to the user.
+			if err := ListAccessError(c.UserContext(), tx, listId, user.ID); err != nil {
+				// The reason is returned.
+				return err
+			}
+			// Otherwise the list changed owner between the two statements, and the todos are treated as foreign.
+			return errForeignTodos
+		}
+
+		// This checks if any referenced todo does not belong to the user and the list.
+		if count != len(todoIds) {
+			// If one does not, an error is returned.
+			return errForeignTodos
+		}
+
+		// _, err is the result of executing the SQL query to reorder the todos.
+		if _, err := tx.ExecContext(c.UserContext(), ReorderListTodosQuery, pq.Array(todoIds)); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The event is recorded.
+		return outbox.Record(c.UserContext(), tx, outbox.ListReordered, user.ID, listId, ListReorderedEvent{ID: listId, TodoIDs: body.TodoIDs})
+	})
+	// This checks if an error occurred while executing the transaction.
+	if err != nil {
+		// This checks if the list does not exist.
+		if errors.Is(err, ErrListNotFound) {
+			// If it does not, a not found response is returned.
+			return response.NotFound(c, nil, "List not found")
+		}
+		// This checks if the list belongs to another user.
+		if errors.Is(err, ErrListForbidden) {
+			// If it does, a forbidden response is returned.
+			return response.Forbidden(c, "You are not authorized to reorder this list")
+		}
+		// This checks if the request referenced foreign todos.
+		if errors.Is(err, errForeignTodos) {
+			// If it did, a bad request response is returned.
+			return response.BadInternalResponse(c, err, "Unable to reorder list")
+		}
+		// For any other error, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to reorder list")
+	}
+
+	// An OK response is returned with a success message and the new order.
+	return response.OKResponse(c, "List reordered successfully", fiber.Map{"list_id": listId, "todo_ids": body.TodoIDs})
+}
@@ -0,0 +1,40 @@
+// This file defines the data model for lists.
+package lists
+
+// "database/sql" provides a generic SQL interface. It is used here to define the nullable Color, Icon, and ArchivedAt fields.
+import (
+	"database/sql"
+	// "time" provides functions for working with time. It is used here to define the CreatedAt field.
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to define the ID and Owner fields.
+	"github.com/google/uuid"
+)
+
+// List represents the structure of a list that groups todos.
+type List struct {
+	// ID is the unique identifier for the list.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// Name is the name of the list.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+	// Owner is the ID of the user who owns the list.
+	// json:"owner" specifies that this field should be marshalled to/from a JSON object with the key "owner".
+	Owner uuid.UUID `json:"owner"`
+	// CreatedAt is the time the list was created.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt time.Time `json:"created_at"`
+	// Version is the change sequence number of the list. The database bumps it on every change.
+	// json:"version" specifies that this field should be marshalled to/from a JSON object with the key "version".
+	Version int64 `json:"version"`
+	// Color is the hex color the user picked for the list, if any.
+	// json:"color" specifies that this field should be marshalled to/from a JSON object with the key "color".
+	Color sql.NullString `json:"color"`
+	// Icon is the emoji the user picked for the list, if any.
+	// json:"icon" specifies that this field should be marshalled to/from a JSON object with the key "icon".
+	Icon sql.NullString `json:"icon"`
+	// ArchivedAt is the time the list was archived, if it is archived.
+	// json:"archived_at" specifies that this field should be marshalled to/from a JSON object with the key "archived_at".
+	ArchivedAt sql.NullTime `json:"archived_at"`
+}
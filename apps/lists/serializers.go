@@ -0,0 +1,188 @@
+// This file defines the serializers for list-related requests and responses.
+package lists
+
+// "github.com/google/uuid" is a package for working with UUIDs. It is used here to define ID fields.
+import (
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/backend/binding" is a local package that binds request parameters.
+	"github.com/rahulcodepython/todo-backend/backend/binding"
+	// "github.com/rahulcodepython/todo-backend/backend/content" is a local package that checks the colors and icons picked by users.
+	"github.com/rahulcodepython/todo-backend/backend/content"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// CreateListRequest defines the structure for a create list request.
+type CreateListRequest struct {
+	// Name is the name of the list.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	// validate:"required,max=100" specifies that this field is required and has a maximum length of 100.
+	Name string `json:"name" validate:"required,max=100"`
+	// Color is the optional hex color of the list, such as "#1e90ff".
+	// json:"color" specifies that this field should be marshalled to/from a JSON object with the key "color".
+	Color string `json:"color"`
+	// Icon is the optional emoji of the list.
+	// json:"icon" specifies that this field should be marshalled to/from a JSON object with the key "icon".
+	Icon string `json:"icon"`
+}
+
+// UpdateListRequest defines the structure for an update list request.
+// A missing field keeps its value, and an empty color or icon removes it.
+type UpdateListRequest struct {
+	// Name is the new name of the list.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name *string `json:"name"`
+	// Color is the new hex color of the list.
+	// json:"color" specifies that this field should be marshalled to/from a JSON object with the key "color".
+	Color *string `json:"color"`
+	// Icon is the new emoji of the list.
+	// json:"icon" specifies that this field should be marshalled to/from a JSON object with the key "icon".
+	Icon *string `json:"icon"`
+}
+
+// ListListsQuery defines the query parameters of a request for the user's lists.
+type ListListsQuery struct {
+	// Color is the hex color the lists must have, or empty for every list.
+	// query:"color" specifies that this field is bound to the "color" query parameter.
+	Color string `query:"color"`
+	// Archived picks the archived lists instead of the others.
+	// query:"archived" specifies that this field is bound to the "archived" query parameter.
+	Archived bool `query:"archived"`
+}
+
+// Validate normalizes the color, so that "#ABC" finds the lists stored as "#aabbcc".
+//
+// @return binding.FieldErrors - The invalid parameters, or nil if there are none.
+func (q *ListListsQuery) Validate() binding.FieldErrors {
+	// The color is checked and normalized in place.
+	return content.CleanAppearance(content.Appearance{Color: &q.Color})
+}
+
+// ReorderListRequest defines the structure for a reorder list request.
+type ReorderListRequest struct {
+	// TodoIDs is the ordered list of todo IDs. The first ID gets the first position.
+	// json:"todo_ids" specifies that this field should be marshalled to/from a JSON object with the key "todo_ids".
+	// validate:"required,min=1" specifies that this field is required and must contain at least one ID.
+	TodoIDs []uuid.UUID `json:"todo_ids" validate:"required,min=1"`
+}
+
+// CompleteAllRequest defines the structure for a request that completes every open todo of a list.
+// The body is optional, and only needed to confirm a list with more open todos than the confirmation threshold.
+type CompleteAllRequest struct {
+	// ConfirmationToken is the token of the 428 Precondition Required response of a previous request.
+	// json:"confirmation_token" specifies that this field should be marshalled to/from a JSON object with the key "confirmation_token".
+	ConfirmationToken string `json:"confirmation_token"`
+}
+
+// CompleteAllConfirmation defines the structure for the data of a response that asks to confirm a bulk completion.
+type CompleteAllConfirmation struct {
+	// ConfirmationToken is the token to send back to complete the todos.
+	// json:"confirmation_token" specifies that this field should be marshalled to/from a JSON object with the key "confirmation_token".
+	ConfirmationToken string `json:"confirmation_token"`
+	// TodoCount is the number of open todos the token confirms completing.
+	// json:"todo_count" specifies that this field should be marshalled to/from a JSON object with the key "todo_count".
+	TodoCount int `json:"todo_count"`
+	// ExpiresAt is the time the token stops working.
+	// json:"expires_at" specifies that this field should be marshalled to/from a JSON object with the key "expires_at".
+	ExpiresAt string `json:"expires_at"`
+}
+
+// ListCompletedEvent defines the structure for the data of a list.completed event, and of the response of a bulk completion.
+type ListCompletedEvent struct {
+	// ID is the ID of the list.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// CompletedCount is the number of todos that were completed.
+	// json:"completed_count" specifies that this field should be marshalled to/from a JSON object with the key "completed_count".
+	CompletedCount int `json:"completed_count"`
+	// TodoIDs are the IDs of the todos that were completed.
+	// json:"todo_ids" specifies that this field should be marshalled to/from a JSON object with the key "todo_ids".
+	TodoIDs []uuid.UUID `json:"todo_ids"`
+}
+
+// ListReorderedEvent defines the structure for the data of a list.reordered event.
+type ListReorderedEvent struct {
+	// ID is the ID of the reordered list.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// TodoIDs is the new order of the reordered todos.
+	// json:"todo_ids" specifies that this field should be marshalled to/from a JSON object with the key "todo_ids".
+	TodoIDs []uuid.UUID `json:"todo_ids"`
+}
+
+// ListResponse defines the structure for a list response.
+type ListResponse struct {
+	// ID is the unique identifier for the list.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// Name is the name of the list.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+	// CreatedAt is the time the list was created.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt string `json:"created_at"`
+	// Version is the change sequence number of the list.
+	// json:"version" specifies that this field should be marshalled to/from a JSON object with the key "version".
+	Version int64 `json:"version"`
+	// Color is the hex color of the list, or null if none was picked.
+	// json:"color" specifies that this field should be marshalled to/from a JSON object with the key "color".
+	Color *string `json:"color"`
+	// Icon is the emoji of the list, or null if none was picked.
+	// json:"icon" specifies that this field should be marshalled to/from a JSON object with the key "icon".
+	Icon *string `json:"icon"`
+	// ArchivedAt is the time the list was archived, or null if it is not archived.
+	// json:"archived_at" specifies that this field should be marshalled to/from a JSON object with the key "archived_at".
+	ArchivedAt *string `json:"archived_at"`
+	// URL is the canonical path of the list.
+	// json:"url" specifies that this field should be marshalled to/from a JSON object with the key "url".
+	URL string `json:"url"`
+}
+
+// NewListResponse converts a list into its response structure.
+//
+// @param list List - The list to be converted.
+// @return ListResponse - The list response.
+func NewListResponse(list List) ListResponse {
+	// color is the list's color, or nil if none was picked.
+	var color *string
+	// This checks if the list has a color.
+	if list.Color.Valid {
+		// If it has, the color is set.
+		color = &list.Color.String
+	}
+	// icon is the list's icon, or nil if none was picked.
+	var icon *string
+	// This checks if the list has an icon.
+	if list.Icon.Valid {
+		// If it has, the icon is set.
+		icon = &list.Icon.String
+	}
+	// archivedAt is the formatted archive time, or nil if the list is not archived.
+	var archivedAt *string
+	// This checks if the list is archived.
+	if list.ArchivedAt.Valid {
+		// If it is, the archive time is formatted.
+		formatted := utils.ParseTime(list.ArchivedAt.Time)
+		archivedAt = &formatted
+	}
+
+	// A new ListResponse is returned.
+	return ListResponse{
+		// The ID field is set to the list's ID.
+		ID: list.ID,
+		// The Name field is set to the list's name.
+		Name: list.Name,
+		// The CreatedAt field is set to the list's creation time.
+		CreatedAt: utils.ParseTime(list.CreatedAt),
+		// The Version field is set to the list's version.
+		Version: list.Version,
+		// The Color field is set to the list's color.
+		Color: color,
+		// The Icon field is set to the list's icon.
+		Icon: icon,
+		// The ArchivedAt field is set to the list's archive time.
+		ArchivedAt: archivedAt,
+		// The URL field is set to the list's canonical path.
+		URL: utils.ResourcePath("lists", list.ID),
+	}
+}
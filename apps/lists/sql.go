@@ -0,0 +1,40 @@
+// This file defines the SQL queries used for list-related database operations.
+package lists
+
+// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names and schemas.
+import "github.com/rahulcodepython/todo-backend/backend/utils"
+
+// CreateListQuery is the SQL query to insert a new list into the database.
+const CreateListQuery = "INSERT INTO " + utils.ListTableName + " (" + utils.ListTableSchema + ", color, icon) VALUES ($1, $2, $3, $4, $5, $6) RETURNING version"
+
+// GetListsByUserQuery is the SQL query to retrieve the lists of a specific user, optionally only those of a color.
+// $3 picks the archived lists when true and the others when false.
+const GetListsByUserQuery = "SELECT " + utils.ListSelectSchema + " FROM " + utils.ListTableName + " WHERE owner = $1 AND deleted_at IS NULL AND ($2::text IS NULL OR color = $2) AND (archived_at IS NOT NULL) = $3 ORDER BY created_at"
+
+// UpdateListQuery is the SQL query to rename a list and change its color and icon.
+// A NULL name keeps the current one, and the color and icon are only set when their flag is true, so that a NULL clears them.
+const UpdateListQuery = "UPDATE " + utils.ListTableName + " SET name = COALESCE($1, name), color = CASE WHEN $2::boolean THEN $3 ELSE color END, icon = CASE WHEN $4::boolean THEN $5 ELSE icon END WHERE id = $6 AND owner = $7 AND deleted_at IS NULL RETURNING " + utils.ListSelectSchema
+
+// GetListQuery is the SQL query to retrieve a list that has not been deleted, whoever owns it.
+const GetListQuery = "SELECT " + utils.ListSelectSchema + " FROM " + utils.ListTableName + " WHERE id = $1 AND deleted_at IS NULL"
+
+// GetListOwnerQuery is the SQL query to retrieve the owner of a list.
+// It is only used to explain why a statement scoped to the user's lists matched nothing.
+const GetListOwnerQuery = "SELECT owner FROM " + utils.ListTableName + " WHERE id = $1 AND deleted_at IS NULL"
+
+// CountListTodosForReorderQuery is the SQL query to lock and count the todos among the given IDs that belong to the user and the list,
+// and to check that the list belongs to the user.
+const CountListTodosForReorderQuery = "SELECT (SELECT COUNT(*) FROM (SELECT id FROM " + utils.TodoTableName + " WHERE id = ANY($1::uuid[]) AND owner = $2 AND list_id = $3 AND deleted_at IS NULL FOR UPDATE) AS locked), EXISTS (SELECT 1 FROM " + utils.ListTableName + " WHERE id = $3 AND owner = $2 AND deleted_at IS NULL)"
+
+// SetListArchivedQuery is the SQL query to archive a list at $1, or to unarchive it when $1 is NULL.
+// Archiving a list that is already archived keeps the time it was first archived.
+const SetListArchivedQuery = "UPDATE " + utils.ListTableName + " SET archived_at = CASE WHEN $1::timestamptz IS NULL THEN NULL ELSE COALESCE(archived_at, $1) END WHERE id = $2 AND owner = $3 AND deleted_at IS NULL RETURNING " + utils.ListSelectSchema
+
+// CountOpenListTodosQuery is the SQL query to count the open todos of a list of a user.
+const CountOpenListTodosQuery = "SELECT COUNT(*) FROM " + utils.TodoTableName + " WHERE list_id = $1 AND owner = $2 AND completed = FALSE AND deleted_at IS NULL"
+
+// CompleteListTodosQuery is the SQL query to complete every open todo of a list of a user in one statement, returning their IDs.
+const CompleteListTodosQuery = "UPDATE " + utils.TodoTableName + " SET completed = TRUE WHERE list_id = $1 AND owner = $2 AND completed = FALSE AND deleted_at IS NULL RETURNING id"
+
+// ReorderListTodosQuery is the SQL query to set the position of each todo to its index in the given array.
+const ReorderListTodosQuery = "UPDATE " + utils.TodoTableName + " AS t SET position = o.ordinality FROM unnest($1::uuid[]) WITH ORDINALITY AS o(id, ordinality) WHERE t.id = o.id"
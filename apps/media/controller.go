@@ -0,0 +1,353 @@
+// This file defines the controller that serves stored images, resized to the size a client displays them at.
+package media
+
+// "bytes" provides functions for working with byte slices. It is used here to store resized images.
+import (
+	"bytes"
+	// "database/sql" provides a generic SQL interface. It is used here to record and look up the owners of the images.
+	"database/sql"
+	// "errors" provides functions for working with errors. It is used here to compare the storage and image errors.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to build the storage keys.
+	"fmt"
+	// "image" provides basic 2-D image types. It is used here for the sizes of the images.
+	"image"
+	// "io" provides basic I/O interfaces. It is used here to read the stored images.
+	"io"
+	// "net/http" provides HTTP constants and helpers. It is used here to detect the type of the stored images.
+	"net/http"
+	// "slices" provides functions for working with slices. It is used here to check the type of the stored images.
+	"slices"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to define the controllers.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to parse the media ID.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that manages users. It is used here to get the authenticated user.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/binding" is a local package that binds request parameters.
+	"github.com/rahulcodepython/todo-backend/backend/binding"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database helpers. It is used here to record an image inside a transaction.
+	"github.com/rahulcodepython/todo-backend/backend/database"
+	// "github.com/rahulcodepython/todo-backend/backend/quota" is a local package that enforces plan limits. It is used here to limit the size of a user's images.
+	"github.com/rahulcodepython/todo-backend/backend/quota"
+	// "github.com/rahulcodepython/todo-backend/backend/reqlog" is a local package that provides the logger of a request. It is used here to log resized images that could not be cached with the request they belong to.
+	"github.com/rahulcodepython/todo-backend/backend/reqlog"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+	// "github.com/rahulcodepython/todo-backend/backend/storage" is a local package that stores files.
+	"github.com/rahulcodepython/todo-backend/backend/storage"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions. It is used here to build the path of an uploaded image.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// imageTypes are the MIME types of the images that are served.
+var imageTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+// cacheControl lets clients keep an image for a day. Media IDs are never reused, so an image does not change under its ID.
+const cacheControl = "private, max-age=86400"
+
+// MediaController is a struct that holds the configuration, the database connection, and the storage backend.
+type MediaController struct {
+	// cfg is the application configuration.
+	cfg *config.Config
+	// db is the database connection, which records the owners of the images.
+	db *sql.DB
+	// store is the storage backend the images are read from, and the resized images are cached in.
+	store storage.Storage
+}
+
+// NewMediaControl creates a new MediaController.
+// It takes the application configuration, the database connection, and the storage backend as input.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @param store storage.Storage - The storage backend.
+// @return *MediaController - A pointer to the new MediaController.
+func NewMediaControl(cfg *config.Config, db *sql.DB, store storage.Storage) *MediaController {
+	// A new MediaController is returned.
+	return &MediaController{
+		// The cfg field is set to the application configuration.
+		cfg: cfg,
+		// The db field is set to the database connection.
+		db: db,
+		// The store field is set to the storage backend.
+		store: store,
+	}
+}
+
+// originalKey returns the storage key of a stored image.
+//
+// @param id uuid.UUID - The ID of the image.
+// @return string - The key.
+func originalKey(id uuid.UUID) string {
+	// The key of the image is returned.
+	return "media/" + id.String()
+}
+
+// resizedKey returns the storage key of an image resized to a size.
+// The key holds the size the image is resized to rather than the requested bounds, so that the many bounds an image
+// fits the same way share one cached file, and the cache of an image cannot grow past one file per size it can take.
+//
+// @param id uuid.UUID - The ID of the image.
+// @param size image.Point - The size of the resized image.
+// @return string - The key.
+func resizedKey(id uuid.UUID, size image.Point) string {
+	// The key of the resized image is returned.
+	return fmt.Sprintf("media/cache/%s/%dx%d", id, size.X, size.Y)
+}
+
+// UploadMediaController stores an image sent as the request body, and records the authenticated user as its owner.
+// The image is checked like the images that are served, so that every uploaded image can be resized.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (mc *MediaController) UploadMediaController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// data is the uploaded image.
+	data := c.Body()
+	// This checks if nothing was sent.
+	if len(data) == 0 {
+		// If nothing was, a bad request response is returned.
+		return response.BadResponse(c, "An image is required")
+	}
+	// This checks if the image is larger than may be served.
+	if int64(len(data)) > mc.cfg.Media.MaxSourceBytes {
+		// If it is, an unsupported media type response is returned.
+		return response.UnsupportedMediaType(c, ErrImageTooLarge, "This media cannot be served as an image")
+	}
+	// contentType is the type of the image, detected from its content rather than trusted from the request.
+	contentType := http.DetectContentType(data)
+	// This checks if the body is not a supported image.
+	if !slices.Contains(imageTypes, contentType) {
+		// If it is not, an unsupported media type response is returned.
+		return response.UnsupportedMediaType(c, ErrUnsupportedImage, "This media cannot be served as an image")
+	}
+	// size is the size of the image in pixels.
+	size, err := imageSize(data, mc.cfg.Media.MaxSourcePixels)
+	// This checks if the image could not be resized later.
+	if err != nil {
+		// If it could not, an unsupported media type response is returned.
+		return response.UnsupportedMediaType(c, err, "This media cannot be served as an image")
+	}
+
+	// id is the ID of the new image.
+	id := uuid.New()
+	// err is the result of checking the plan and recording the image and its owner in one transaction, before its file is stored,
+	// so that no file is left without an owner.
+	err = database.WithTx(c.UserContext(), mc.db, func(tx *sql.Tx) error {
+		// This checks that the image fits in the attachment storage of the user's plan.
+		if err := quota.Check(c.UserContext(), tx, mc.cfg, user.ID, quota.AttachmentBytes, int64(len(data))); err != nil {
+			// If it does not, the error is returned.
+			return err
+		}
+		// _, err is the result of recording the image.
+		_, err := tx.ExecContext(c.UserContext(), CreateAttachmentQuery, id, user.ID, contentType, len(data), size.X, size.Y)
+		// The error, if any, is returned.
+		return err
+	})
+	// exceeded is the plan limit the image would exceed.
+	var exceeded *quota.ExceededError
+	// This checks if the image would not fit in the plan.
+	if errors.As(err, &exceeded) {
+		// If it would not, a quota exceeded response is returned.
+		return response.QuotaExceeded(c, exceeded)
+	}
+	// This checks if another error occurred while recording the image.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to store media")
+	}
+	// This stores the file of the image.
+	if err := mc.store.Put(c.UserContext(), originalKey(id), bytes.NewReader(data)); err != nil {
+		// If an error occurs, the record of the image is removed, and its failure only logged, since nothing refers to it yet.
+		if _, delErr := mc.db.ExecContext(c.UserContext(), DeleteAttachmentQuery, id); delErr != nil {
+			// The failure is logged.
+			reqlog.Ctx(c).Printf("Unable to remove the record of media %s: %v", id, delErr)
+		}
+		// An internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to store media")
+	}
+
+	// The uploaded image is returned with its path.
+	return response.OKCreatedResponse(c, "Media uploaded successfully", MediaResponse{ID: id, ContentType: contentType, Size: int64(len(data)), Width: size.X, Height: size.Y}, "/api/"+utils.APIVersion+"/media/"+id.String())
+}
+
+// GetMediaController serves a stored image of the authenticated user, resized to fit within the "w" and "h" query parameters.
+// Resized images are cached in the storage backend under the size they are resized to, so that each size is only computed once.
+// An image of another user is not found, whether it is asked for as stored or resized.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (mc *MediaController) GetMediaController(c *fiber.Ctx) error {
+	// params are the query parameters of the request.
+	params, err := binding.Query[MediaParams](c)
+	// This checks if a parameter is invalid.
+	if err != nil {
+		// If one is, a bad request response is returned.
+		return response.InvalidParameters(c, err)
+	}
+	// id is the ID of the image.
+	id, err := uuid.Parse(c.Params("id"))
+	// This checks if the ID is not a UUID, in which case no image has it.
+	if err != nil {
+		// If it is not, a not found response is returned.
+		return response.NotFound(c, nil, "Media not found")
+	}
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// original is the size of the image in pixels, recorded when it was uploaded.
+	var original image.Point
+	// This looks up the image among the images of the user, before the original or any of its resized sizes is read.
+	err = mc.db.QueryRowContext(c.UserContext(), GetAttachmentQuery, id, user.ID).Scan(&original.X, &original.Y)
+	// This checks if the user has no image with the ID.
+	if errors.Is(err, sql.ErrNoRows) {
+		// If it has none, a not found response is returned, so that the images of other users cannot be told apart from missing ones.
+		return response.NotFound(c, nil, "Media not found")
+	}
+	// This checks if another error occurred while looking up the image.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to read media")
+	}
+
+	// size is the size the image is served at: the original size, or the size it is scaled down to within the bounds.
+	size := original
+	// This checks if a resized image is asked for.
+	if params.Width > 0 || params.Height > 0 {
+		// The size is the one the image fits the bounds at.
+		size = fitSize(original.X, original.Y, params.Width, params.Height)
+	}
+	// This checks if the image is scaled down, in which case the size may already be cached.
+	if size != original {
+		// This checks if the size is already cached.
+		if data, _, err := mc.read(c, resizedKey(id, size)); err == nil {
+			// If it is, the cached image is sent.
+			return send(c, data, http.DetectContentType(data))
+		}
+	}
+
+	// data is the stored image.
+	data, contentType, err := mc.read(c, originalKey(id))
+	// This checks what went wrong while reading the image.
+	switch {
+	// No image has the ID.
+	case errors.Is(err, storage.ErrNotFound):
+		// A not found response is returned.
+		return response.NotFound(c, nil, "Media not found")
+	// The image is larger than may be served, or is not an image.
+	case errors.Is(err, ErrImageTooLarge), errors.Is(err, ErrUnsupportedImage):
+		// An unsupported media type response is returned.
+		return response.UnsupportedMediaType(c, err, "This media cannot be served as an image")
+	// Any other error occurred.
+	case err != nil:
+		// An internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to read media")
+	}
+	// This checks if the image is served at its original size, as it is when asked for as stored or for bounds it already fits.
+	if size == original {
+		// If it is, the image is sent.
+		return send(c, data, contentType)
+	}
+
+	// resized is the image resized to fit the size.
+	resized, contentType, err := resizeImage(data, params.Width, params.Height, mc.cfg.Media.MaxSourcePixels)
+	// This checks if the image is too large to decode or could not be decoded.
+	if errors.Is(err, ErrImageTooLarge) || errors.Is(err, ErrUnsupportedImage) {
+		// If so, an unsupported media type response is returned.
+		return response.UnsupportedMediaType(c, err, "This media cannot be served as an image")
+	}
+	// This checks if another error occurred while resizing the image.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to resize media")
+	}
+	// This caches the resized image, which the next request of the size is served from.
+	if err := mc.store.Put(c.UserContext(), resizedKey(id, size), bytes.NewReader(resized)); err != nil {
+		// If an error occurs, it is logged; the image is still sent.
+		reqlog.Ctx(c).Printf("Unable to cache resized media %s: %v", id, err)
+	}
+	// The resized image is sent.
+	return send(c, resized, contentType)
+}
+
+// read reads a stored file, if it is a supported image within the size limit.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param key string - The storage key.
+// @return []byte - The content of the file.
+// @return string - The MIME type of the image.
+// @return error - storage.ErrNotFound if there is no file, ErrImageTooLarge or ErrUnsupportedImage if it cannot be served, or another error if one occurred.
+func (mc *MediaController) read(c *fiber.Ctx, key string) ([]byte, string, error) {
+	// file is the opened file, and size is its size.
+	file, size, err := mc.store.Open(c.UserContext(), key)
+	// This checks if the file could not be opened.
+	if err != nil {
+		// If it could not, the error is returned.
+		return nil, "", err
+	}
+	// This defers closing the file.
+	defer file.Close()
+
+	// This checks if the file is larger than may be served.
+	if size > mc.cfg.Media.MaxSourceBytes {
+		// If it is, an error is returned before it is read.
+		return nil, "", ErrImageTooLarge
+	}
+	// data is the content of the file, read no further than the limit in case the file grew since it was opened.
+	data, err := io.ReadAll(io.LimitReader(file, mc.cfg.Media.MaxSourceBytes+1))
+	// This checks if an error occurred while reading the file.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, "", err
+	}
+	// This checks if the file grew past the limit.
+	if int64(len(data)) > mc.cfg.Media.MaxSourceBytes {
+		// If it did, an error is returned.
+		return nil, "", ErrImageTooLarge
+	}
+
+	// contentType is the type of the file, detected from its content rather than trusted from its name.
+	contentType := http.DetectContentType(data)
+	// This checks if the file is not a supported image.
+	if !slices.Contains(imageTypes, contentType) {
+		// If it is not, an error is returned.
+		return nil, "", ErrUnsupportedImage
+	}
+	// The content and its type are returned.
+	return data, contentType, nil
+}
+
+// send sends an image with the headers that let clients cache it.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param data []byte - The image.
+// @param contentType string - The MIME type of the image.
+// @return error - An error if one occurred while sending the response.
+func send(c *fiber.Ctx, data []byte, contentType string) error {
+	// The type of the image is set.
+	c.Set(fiber.HeaderContentType, contentType)
+	// The image may be kept by the client.
+	c.Set(fiber.HeaderCacheControl, cacheControl)
+	// The client must not guess another type than the one detected.
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	// The image is sent.
+	return c.Send(data)
+}
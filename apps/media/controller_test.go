@@ -0,0 +1,211 @@
+// This file defines a test of the ownership and the cache of the served images.
+package media
+
+// "bytes" provides functions for working with byte slices. It is used here to send the uploaded image.
+import (
+	"bytes"
+	// "database/sql/driver" defines the interfaces of SQL drivers. It is used here to build the rows of the fake database.
+	"database/sql/driver"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to read the uploaded image.
+	"encoding/json"
+	// "image" provides basic 2-D image types. It is used here to build the uploaded image.
+	"image"
+	// "image/png" implements the PNG format. It is used here to encode the uploaded image.
+	"image/png"
+	// "net/http/httptest" provides utilities for HTTP testing. It is used here to build the requests.
+	"net/http/httptest"
+	// "os" provides functions for working with the operating system. It is used here to check the stored original.
+	"os"
+	// "path/filepath" provides functions for file paths. It is used here to find the cached images.
+	"path/filepath"
+	// "sync" provides synchronization primitives. It is used here to guard the rows of the fake database.
+	"sync"
+	// "testing" provides support for automated tests. It is used here to run the test.
+	"testing"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to serve the images.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here for the IDs of the users.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that manages users. It is used here to authenticate the requests.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/database/dbtest" is a local package that provides a fake database. It is used here to hold the owners of the images.
+	"github.com/rahulcodepython/todo-backend/backend/database/dbtest"
+	// "github.com/rahulcodepython/todo-backend/backend/quota" is a local package that enforces plan limits. It is used here for its queries.
+	"github.com/rahulcodepython/todo-backend/backend/quota"
+	// "github.com/rahulcodepython/todo-backend/backend/storage" is a local package that stores files. It is used here to keep the images in a temporary directory.
+	"github.com/rahulcodepython/todo-backend/backend/storage"
+)
+
+// TestMediaOwnershipAndCache checks that an uploaded image is only served to its owner, as stored or resized, and that
+// bounds the image fits at the same size share one cached file, while bounds it already fits are served the original.
+// It also checks that an upload that would take the user over the attachment storage of their plan is refused.
+//
+// @param t *testing.T - The test state.
+func TestMediaOwnershipAndCache(t *testing.T) {
+	// owner is the user who uploads the image, and other is another user.
+	owner, other := users.User{ID: uuid.New(), Timezone: "UTC"}, users.User{ID: uuid.New(), Timezone: "UTC"}
+
+	// mu guards the rows.
+	var mu sync.Mutex
+	// attachments are the rows of the attachments table, by the ID of the image.
+	attachments := map[string][]driver.Value{}
+	// fake is the fake database, which holds the owners and sizes of the images.
+	fake := dbtest.NewDriver()
+	// An uploaded image is recorded.
+	fake.Handle(CreateAttachmentQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		attachments[args[0].Value.(string)] = []driver.Value{args[1].Value, args[4].Value, args[5].Value, args[3].Value}
+		return dbtest.Rows{RowsAffected: 1}, nil
+	})
+	// The user is on the free plan.
+	fake.Handle(quota.LockUserPlanQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		return dbtest.Rows{Columns: []string{"plan"}, Values: [][]driver.Value{{"free"}}}, nil
+	})
+	// The attachment storage of a user is the size of their images.
+	fake.Handle(quota.SumAttachmentBytesQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		// used is the size of the images of the user.
+		var used int64
+		// This adds up the images of the user.
+		for _, row := range attachments {
+			// This checks if the user owns the image.
+			if row[0] == args[0].Value {
+				used += row[3].(int64)
+			}
+		}
+		return dbtest.Rows{Columns: []string{"sum"}, Values: [][]driver.Value{{used}}}, nil
+	})
+	// An image is found among the images of its owner only.
+	fake.Handle(GetAttachmentQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		// rows holds the size of the image, if the user owns it.
+		rows := dbtest.Rows{Columns: []string{"width", "height"}}
+		// This checks if the user owns the image.
+		if row, ok := attachments[args[0].Value.(string)]; ok && row[0] == args[1].Value {
+			rows.Values = [][]driver.Value{{row[1], row[2]}}
+		}
+		return rows, nil
+	})
+	// db is the fake database.
+	db := dbtest.OpenDB(fake)
+	t.Cleanup(func() { db.Close() })
+
+	// src is an opaque 200×100 PNG image.
+	var src bytes.Buffer
+	// This encodes the image.
+	if err := png.Encode(&src, image.NewNRGBA(image.Rect(0, 0, 200, 100))); err != nil {
+		// If it cannot be encoded, the test fails.
+		t.Fatal(err)
+	}
+
+	// dir is the directory of the storage backend.
+	dir := t.TempDir()
+	// cfg is the configuration, whose free plan holds the image once but not twice.
+	cfg := &config.Config{
+		Media: config.MediaConfig{MaxSourceBytes: 1 << 20, MaxSourcePixels: 1 << 20},
+		Quota: config.QuotaConfig{Free: config.PlanLimits{MaxAttachmentBytes: int64(src.Len()) * 3 / 2}},
+	}
+	// controller is the media controller over the fake database and the directory.
+	controller := NewMediaControl(cfg, db, storage.New(config.StorageConfig{Dir: dir}))
+	// current is the user the requests are authenticated as.
+	current := owner
+	// app serves the images to the current user.
+	app := fiber.New()
+	// authenticate authenticates the requests as the current user.
+	authenticate := func(c *fiber.Ctx) error {
+		// The user is authenticated.
+		users.SetCurrentUser(c, current)
+		// The request is handled.
+		return c.Next()
+	}
+	app.Post("/media", authenticate, controller.UploadMediaController)
+	app.Get("/media/:id", authenticate, controller.GetMediaController)
+
+	// resp is the response to the upload.
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/media", bytes.NewReader(src.Bytes())))
+	// This checks if the request failed.
+	if err != nil {
+		// If it did, the test fails.
+		t.Fatal(err)
+	}
+	// uploaded is the uploaded image.
+	var uploaded struct {
+		Data MediaResponse `json:"data"`
+	}
+	// This reads the uploaded image.
+	if err := json.NewDecoder(resp.Body).Decode(&uploaded); err != nil || resp.StatusCode != fiber.StatusCreated {
+		// If it was not uploaded, the test fails.
+		t.Fatalf("upload: status = %d, err = %v, want %d", resp.StatusCode, err, fiber.StatusCreated)
+	}
+	resp.Body.Close()
+	// This checks if the size was not read from the image.
+	if uploaded.Data.Width != 200 || uploaded.Data.Height != 100 || uploaded.Data.ContentType != "image/png" {
+		// If it was not, the test fails.
+		t.Fatalf("uploaded = %+v, want a 200×100 PNG", uploaded.Data)
+	}
+	// resp is the response to uploading the image again, which does not fit in the plan next to the first.
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodPost, "/media", bytes.NewReader(src.Bytes())))
+	// This checks if the request failed.
+	if err != nil {
+		// If it did, the test fails.
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	// This checks if the upload over the limit was not refused.
+	if resp.StatusCode != fiber.StatusForbidden || len(attachments) != 1 {
+		// If it was not, the test fails.
+		t.Fatalf("upload over the limit: status = %d, images = %d, want %d and 1 image", resp.StatusCode, len(attachments), fiber.StatusForbidden)
+	}
+
+	// get requests the image with a query, and returns the status of the response.
+	get := func(query string) int {
+		// resp is the response to the request.
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/media/"+uploaded.Data.ID.String()+query, nil))
+		// This checks if the request failed.
+		if err != nil {
+			// If it did, the test fails.
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		// The status is returned.
+		return resp.StatusCode
+	}
+
+	// This requests bounds that all fit the image at 100×50, and bounds it already fits.
+	for _, query := range []string{"?w=100", "?w=100&h=80", "?w=120&h=50", "?h=50", "?w=400&h=400"} {
+		// This checks if the owner is not served the image.
+		if status := get(query); status != fiber.StatusOK {
+			// If it is not, the test fails.
+			t.Fatalf("owner %s: status = %d, want %d", query, status, fiber.StatusOK)
+		}
+	}
+	// cached are the cached sizes of the image.
+	cached, err := filepath.Glob(filepath.Join(dir, "media", "cache", uploaded.Data.ID.String(), "*"))
+	// This checks if only the one size the bounds fit the image at was cached.
+	if err != nil || len(cached) != 1 || filepath.Base(cached[0]) != "100x50" {
+		// If it was not, the test fails.
+		t.Fatalf("cached = %v, want only 100x50", cached)
+	}
+	// This checks if the original was not stored under its key.
+	if _, err := os.Stat(filepath.Join(dir, "media", uploaded.Data.ID.String())); err != nil {
+		// If it was not, the test fails.
+		t.Fatal(err)
+	}
+
+	// The requests are now made by another user.
+	current = other
+	// This requests the image as stored, from its cached size, and at a new size.
+	for _, query := range []string{"", "?w=100", "?w=30"} {
+		// This checks if another user is served the image.
+		if status := get(query); status != fiber.StatusNotFound {
+			// If it is, the test fails.
+			t.Errorf("other user %q: status = %d, want %d", query, status, fiber.StatusNotFound)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// This file defines the decoding, resizing, and encoding of images.
+package media
+
+// "bytes" provides functions for working with byte slices. It is used here to decode and encode images in memory.
+import (
+	"bytes"
+	// "errors" provides functions for working with errors. It is used here to define the image errors.
+	"errors"
+	// "image" provides basic 2-D image types. It is used here to hold the decoded and resized images.
+	"image"
+	// "image/draw" provides image composition functions. It is used here to convert images to RGBA.
+	"image/draw"
+	// "image/gif" implements the GIF format. It is imported for its decoder, so that GIF images can be resized.
+	_ "image/gif"
+	// "image/jpeg" implements the JPEG format. It is used here to encode resized photos.
+	"image/jpeg"
+	// "image/png" implements the PNG format. It is used here to encode resized images that may be transparent.
+	"image/png"
+	// "math" provides mathematical functions. It is used here to round the resized dimensions.
+	"math"
+)
+
+// ErrUnsupportedImage is returned when a stored file is not a JPEG, PNG, or GIF image.
+var ErrUnsupportedImage = errors.New("unsupported image format")
+
+// ErrImageTooLarge is returned when a stored image has more pixels than may be decoded.
+var ErrImageTooLarge = errors.New("image is too large")
+
+// jpegQuality is the quality resized JPEG images are encoded with, which keeps thumbnails sharp and small.
+const jpegQuality = 85
+
+// resizeImage scales an image down to fit within a width and a height, keeping its aspect ratio.
+// A zero width or height leaves that side unconstrained, and an image that already fits is not enlarged.
+// JPEG images stay JPEG, and any other format becomes PNG, which keeps transparency.
+//
+// @param data []byte - The encoded image.
+// @param width int - The maximum width, or 0.
+// @param height int - The maximum height, or 0.
+// @param maxPixels int - The largest number of pixels that may be decoded.
+// @return []byte - The encoded resized image.
+// @return string - The MIME type of the resized image.
+// @return error - ErrUnsupportedImage or ErrImageTooLarge if the image cannot be resized, or another error if one occurred.
+func resizeImage(data []byte, width int, height int, maxPixels int) ([]byte, string, error) {
+	// info holds the dimensions and format, read from the header without decoding the pixels.
+	info, format, err := image.DecodeConfig(bytes.NewReader(data))
+	// This checks if the header is not one of a supported format.
+	if err != nil {
+		// If it is not, an error is returned.
+		return nil, "", ErrUnsupportedImage
+	}
+	// This checks if the image has more pixels than may be decoded.
+	if info.Width*info.Height > maxPixels {
+		// If it has, an error is returned before any memory is allocated for them.
+		return nil, "", ErrImageTooLarge
+	}
+
+	// src is the decoded image.
+	src, _, err := image.Decode(bytes.NewReader(data))
+	// This checks if the image could not be decoded.
+	if err != nil {
+		// If it could not, an error is returned.
+		return nil, "", ErrUnsupportedImage
+	}
+
+	// dst is the resized image.
+	dst := scaleDown(src, fitSize(info.Width, info.Height, width, height))
+	// out is the encoded resized image.
+	var out bytes.Buffer
+	// This checks if the image is a JPEG.
+	if format == "jpeg" {
+		// If it is, it is encoded as a JPEG.
+		if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			// If an error occurs, it is returned.
+			return nil, "", err
+		}
+		// The JPEG image is returned.
+		return out.Bytes(), "image/jpeg", nil
+	}
+	// Otherwise it is encoded as a PNG.
+	if err := png.Encode(&out, dst); err != nil {
+		// If an error occurs, it is returned.
+		return nil, "", err
+	}
+	// The PNG image is returned.
+	return out.Bytes(), "image/png", nil
+}
+
+// imageSize returns the size of an image, read from its header without decoding the pixels.
+//
+// @param data []byte - The encoded image.
+// @param maxPixels int - The largest number of pixels that may be decoded.
+// @return image.Point - The width and height of the image.
+// @return error - ErrUnsupportedImage or ErrImageTooLarge if the image could not be resized later, or nil.
+func imageSize(data []byte, maxPixels int) (image.Point, error) {
+	// info holds the dimensions, read from the header.
+	info, _, err := image.DecodeConfig(bytes.NewReader(data))
+	// This checks if the header is not one of a supported format.
+	if err != nil {
+		// If it is not, an error is returned.
+		return image.Point{}, ErrUnsupportedImage
+	}
+	// This checks if the image has more pixels than may be decoded.
+	if info.Width*info.Height > maxPixels {
+		// If it has, an error is returned.
+		return image.Point{}, ErrImageTooLarge
+	}
+	// The size is returned.
+	return image.Pt(info.Width, info.Height), nil
+}
+
+// fitSize returns the size of an image scaled down to fit within a width and a height.
+//
+// @param srcWidth int - The width of the image.
+// @param srcHeight int - The height of the image.
+// @param width int - The maximum width, or 0.
+// @param height int - The maximum height, or 0.
+// @return image.Point - The scaled size, at least one pixel on each side.
+func fitSize(srcWidth int, srcHeight int, width int, height int) image.Point {
+	// scale is the factor of the scaling, which never enlarges the image.
+	scale := 1.0
+	// This checks if the width is constrained.
+	if width > 0 {
+		// If it is, the scale fits the width.
+		scale = min(scale, float64(width)/float64(srcWidth))
+	}
+	// This checks if the height is constrained.
+	if height > 0 {
+		// If it is, the scale fits the height.
+		scale = min(scale, float64(height)/float64(srcHeight))
+	}
+	// The scaled size is returned.
+	return image.Pt(max(1, int(math.Round(float64(srcWidth)*scale))), max(1, int(math.Round(float64(srcHeight)*scale))))
+}
+
+// scaleDown resizes an image with a box filter, averaging the source pixels that each destination pixel covers.
+// It is only used to shrink images, where it gives smooth thumbnails without the aliasing of nearest-neighbour sampling.
+//
+// @param src image.Image - The image.
+// @param size image.Point - The size of the resized image, no larger than the image.
+// @return *image.RGBA - The resized image.
+func scaleDown(src image.Image, size image.Point) *image.RGBA {
+	// bounds is the rectangle of the image.
+	bounds := src.Bounds()
+	// rgba is the image converted to RGBA, so that its pixels can be read directly.
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	// The image is drawn onto the RGBA image.
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	// This checks if the image keeps its size.
+	if size.X == bounds.Dx() && size.Y == bounds.Dy() {
+		// If it does, the converted image is returned.
+		return rgba
+	}
+
+	// dst is the resized image.
+	dst := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	// This iterates over the rows of the resized image.
+	for y := 0; y < size.Y; y++ {
+		// y0 and y1 are the source rows the row covers.
+		y0, y1 := y*bounds.Dy()/size.Y, max((y+1)*bounds.Dy()/size.Y, y*bounds.Dy()/size.Y+1)
+		// This iterates over the columns of the resized image.
+		for x := 0; x < size.X; x++ {
+			// x0 and x1 are the source columns the column covers.
+			x0, x1 := x*bounds.Dx()/size.X, max((x+1)*bounds.Dx()/size.X, x*bounds.Dx()/size.X+1)
+			// sum is the sum of each channel of the covered pixels.
+			var sum [4]int
+			// This iterates over the covered rows.
+			for sy := y0; sy < y1; sy++ {
+				// offset is the position of the first covered pixel of the row.
+				offset := rgba.PixOffset(x0, sy)
+				// This iterates over the covered pixels of the row.
+				for sx := x0; sx < x1; sx++ {
+					// This adds each channel of the pixel.
+					for channel := 0; channel < 4; channel++ {
+						// The channel is added.
+						sum[channel] += int(rgba.Pix[offset+channel])
+					}
+					// The next pixel is read.
+					offset += 4
+				}
+			}
+			// count is the number of covered pixels.
+			count := (y1 - y0) * (x1 - x0)
+			// target is the position of the pixel in the resized image.
+			target := dst.PixOffset(x, y)
+			// This writes the average of each channel.
+			for channel := 0; channel < 4; channel++ {
+				// The channel is set to its rounded average.
+				dst.Pix[target+channel] = uint8((sum[channel] + count/2) / count)
+			}
+		}
+	}
+	// The resized image is returned.
+	return dst
+}
@@ -0,0 +1,30 @@
+// This file defines the request parameters of the media endpoints.
+package media
+
+// "github.com/google/uuid" is a package for working with UUIDs. It is used here for the ID of an uploaded image.
+import "github.com/google/uuid"
+
+// MediaParams defines the query parameters of a media request.
+// Both sides are optional; an image requested without either is served as it is stored.
+type MediaParams struct {
+	// Width is the largest width of the image in pixels.
+	// query:"w" specifies that this field is bound to the "w" query parameter.
+	Width int `query:"w" min:"1" max:"2048"`
+	// Height is the largest height of the image in pixels.
+	// query:"h" specifies that this field is bound to the "h" query parameter.
+	Height int `query:"h" min:"1" max:"2048"`
+}
+
+// MediaResponse defines the structure of an uploaded image.
+type MediaResponse struct {
+	// ID is the ID the image is served under.
+	ID uuid.UUID `json:"id"`
+	// ContentType is the MIME type of the image, detected from its content.
+	ContentType string `json:"content_type"`
+	// Size is the size of the image in bytes.
+	Size int64 `json:"size"`
+	// Width is the width of the image in pixels.
+	Width int `json:"width"`
+	// Height is the height of the image in pixels.
+	Height int `json:"height"`
+}
@@ -0,0 +1,14 @@
+// This file defines the SQL queries used for stored images.
+package media
+
+// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names and schemas.
+import "github.com/rahulcodepython/todo-backend/backend/utils"
+
+// CreateAttachmentQuery is the SQL query to record an uploaded image and its owner.
+const CreateAttachmentQuery = "INSERT INTO " + utils.AttachmentTableName + " (" + utils.AttachmentTableSchema + ") VALUES ($1, $2, $3, $4, $5, $6)"
+
+// GetAttachmentQuery is the SQL query to retrieve the size in pixels of an image of a user.
+const GetAttachmentQuery = "SELECT width, height FROM " + utils.AttachmentTableName + " WHERE id = $1 AND owner = $2"
+
+// DeleteAttachmentQuery is the SQL query to forget an image whose file could not be stored.
+const DeleteAttachmentQuery = "DELETE FROM " + utils.AttachmentTableName + " WHERE id = $1"
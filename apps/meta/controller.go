@@ -0,0 +1,79 @@
+// This file defines the controller that describes the capabilities of the server.
+package meta
+
+// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to define the controllers.
+import (
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models. It is used here to list the scopes of API keys.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values. It is used here to report the API version.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+	// "github.com/rahulcodepython/todo-backend/backend/version" is a local package that holds the version of the build.
+	"github.com/rahulcodepython/todo-backend/backend/version"
+)
+
+// authModes lists the ways a client can authenticate.
+// "bearer" is a token from POST /auth/login sent as "Authorization: Bearer <token>", and "basic" is
+// the email and password sent as HTTP Basic credentials, which only the CalDAV endpoints accept.
+// An API key from POST /auth/keys is sent as a bearer token too.
+var authModes = []string{"bearer", "basic"}
+
+// MetaController is a struct that holds the configuration the capabilities are read from.
+type MetaController struct {
+	// cfg is the application configuration.
+	cfg *config.Config
+}
+
+// NewMetaControl creates a new MetaController.
+// It takes the application configuration as input.
+//
+// @param cfg *config.Config - The application configuration.
+// @return *MetaController - A pointer to the new MetaController.
+func NewMetaControl(cfg *config.Config) *MetaController {
+	// A new MetaController is returned.
+	return &MetaController{
+		// The cfg field is set to the application configuration.
+		cfg: cfg,
+	}
+}
+
+// MetaController describes the capabilities of the server, so that clients do not hardcode them.
+// It needs no user, since a client reads it before logging in.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (mc *MetaController) MetaController(c *fiber.Ctx) error {
+	// features maps each optional feature to whether it is on. The environment variables behind them are left to the admin console.
+	features := map[string]bool{}
+	// This iterates over the optional features of the configuration.
+	for _, flag := range mc.cfg.FeatureFlags() {
+		// The feature is recorded.
+		features[flag.Name] = flag.Enabled
+	}
+
+	// An OK response is returned with a success message and the capabilities.
+	return response.OKResponse(c, "Metadata fetched successfully", MetaResponse{
+		// The APIVersion field is set to the version in the route prefix.
+		APIVersion: utils.APIVersion,
+		// The Build field is set to the version of the build.
+		Build: version.Current(),
+		// The Features field is set to the optional features.
+		Features: features,
+		// The Pagination field is set to the configured page sizes.
+		Pagination: PaginationLimits{
+			// The DefaultLimit field is set to the page size of a request without a limit.
+			DefaultLimit: mc.cfg.Pagination.DefaultLimit,
+			// The MaxLimit field is set to the largest page size.
+			MaxLimit: mc.cfg.Pagination.MaxLimit,
+		},
+		// The AuthModes field is set to the ways a client can authenticate.
+		AuthModes: authModes,
+		// The Scopes field is set to the scopes an API key can be given.
+		Scopes: users.Scopes,
+	})
+}
@@ -0,0 +1,37 @@
+// This file defines the serializers for the capabilities of the API.
+package meta
+
+// "github.com/rahulcodepython/todo-backend/backend/version" is a local package that holds the version of the build.
+import "github.com/rahulcodepython/todo-backend/backend/version"
+
+// MetaResponse defines the structure for the capabilities of the server that a client adapts to.
+type MetaResponse struct {
+	// APIVersion is the version of the API in the route prefix, such as "v1".
+	// json:"api_version" specifies that this field should be marshalled to/from a JSON object with the key "api_version".
+	APIVersion string `json:"api_version"`
+	// Build is the version, commit, and build time of the server.
+	// json:"build" specifies that this field should be marshalled to/from a JSON object with the key "build".
+	Build version.Info `json:"build"`
+	// Features maps the name of each optional feature to whether it is turned on.
+	// json:"features" specifies that this field should be marshalled to/from a JSON object with the key "features".
+	Features map[string]bool `json:"features"`
+	// Pagination holds the page sizes of the paginated endpoints.
+	// json:"pagination" specifies that this field should be marshalled to/from a JSON object with the key "pagination".
+	Pagination PaginationLimits `json:"pagination"`
+	// AuthModes lists the ways a client can authenticate, such as "bearer".
+	// json:"auth_modes" specifies that this field should be marshalled to/from a JSON object with the key "auth_modes".
+	AuthModes []string `json:"auth_modes"`
+	// Scopes lists the scopes an API key can be given, such as "todos:read".
+	// json:"scopes" specifies that this field should be marshalled to/from a JSON object with the key "scopes".
+	Scopes []string `json:"scopes"`
+}
+
+// PaginationLimits defines the structure for the page sizes of the paginated endpoints.
+type PaginationLimits struct {
+	// DefaultLimit is the page size used when the "limit" query parameter is missing.
+	// json:"default_limit" specifies that this field should be marshalled to/from a JSON object with the key "default_limit".
+	DefaultLimit int `json:"default_limit"`
+	// MaxLimit is the largest "limit" a request may ask for.
+	// json:"max_limit" specifies that this field should be marshalled to/from a JSON object with the key "max_limit".
+	MaxLimit int `json:"max_limit"`
+}
@@ -0,0 +1,228 @@
+// This file defines a minimal client for the Apple Push Notification service.
+package notifications
+
+// "bytes" provides functions for working with byte slices. It is used here to build the request body.
+import (
+	"bytes"
+	// "context" provides a way to carry cancellation signals. It is used here to cancel requests.
+	"context"
+	// "crypto/ecdsa" implements ECDSA. It is used here to hold the auth key.
+	"crypto/ecdsa"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to encode the payload and decode errors.
+	"encoding/json"
+	// "fmt" provides functions for formatted I/O. It is used here to build URLs and errors.
+	"fmt"
+	// "net/http" provides HTTP client and server implementations. It is used here to call APNs over HTTP/2.
+	"net/http"
+	// "os" provides functions for working with files. It is used here to read the key file.
+	"os"
+	// "sync" provides synchronization primitives. It is used here to guard the cached provider token.
+	"sync"
+	// "time" provides functions for working with time. It is used here to refresh the provider token.
+	"time"
+
+	// "github.com/golang-jwt/jwt/v5" is a package for working with JWTs. It is used here to sign the provider token.
+	"github.com/golang-jwt/jwt/v5"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+// apnsTokenLifetime is how long a provider token is reused. Apple rejects tokens older than an hour and refreshes more often than every 20 minutes.
+const apnsTokenLifetime = 50 * time.Minute
+
+// apnsClient sends notifications through APNs using token-based authentication.
+type apnsClient struct {
+	// host is the APNs host of the selected environment.
+	host string
+	// keyID is the ID of the auth key.
+	keyID string
+	// teamID is the Apple developer team ID.
+	teamID string
+	// topic is the bundle ID of the app.
+	topic string
+	// key is the auth key.
+	key *ecdsa.PrivateKey
+	// http is the HTTP client used for the calls. APNs requires HTTP/2, which the default transport negotiates.
+	http *http.Client
+	// mu guards the cached provider token.
+	mu sync.Mutex
+	// providerToken is the cached provider token.
+	providerToken string
+	// issuedAt is the time the cached provider token was signed.
+	issuedAt time.Time
+}
+
+// newAPNsClient reads the auth key and creates an apnsClient.
+//
+// @param cfg config.PushConfig - The push configuration.
+// @return *apnsClient - A pointer to the new apnsClient.
+// @return error - An error if the key could not be read.
+func newAPNsClient(cfg config.PushConfig) (*apnsClient, error) {
+	// data is the content of the key file.
+	data, err := os.ReadFile(cfg.APNsKeyFile)
+	// This checks if an error occurred while reading the file.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+
+	// key is the parsed auth key.
+	key, err := jwt.ParseECPrivateKeyFromPEM(data)
+	// This checks if an error occurred while parsing the key.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+
+	// host is the sandbox host unless production is selected.
+	host := "https://api.sandbox.push.apple.com"
+	// This checks if production is selected.
+	if cfg.APNsProduction {
+		// If it is, the production host is used.
+		host = "https://api.push.apple.com"
+	}
+
+	// A new apnsClient is returned.
+	return &apnsClient{
+		host:   host,
+		keyID:  cfg.APNsKeyID,
+		teamID: cfg.APNsTeamID,
+		topic:  cfg.APNsTopic,
+		key:    key,
+		http:   &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// token returns the provider token, signing a new one when the cached token is too old.
+//
+// @return string - The provider token.
+// @return error - An error if one occurred.
+func (ac *apnsClient) token() (string, error) {
+	// The cache is locked while the token is checked and refreshed.
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	// This checks if the cached token can still be used.
+	if ac.providerToken != "" && time.Since(ac.issuedAt) < apnsTokenLifetime {
+		// If it can, it is returned.
+		return ac.providerToken, nil
+	}
+
+	// now is the current time.
+	now := time.Now()
+	// token is the new provider token.
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"iss": ac.teamID, "iat": now.Unix()})
+	// The key ID is set in the header.
+	token.Header["kid"] = ac.keyID
+	// signed is the signed provider token.
+	signed, err := token.SignedString(ac.key)
+	// This checks if an error occurred while signing the token.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+
+	// The token is cached.
+	ac.providerToken = signed
+	ac.issuedAt = now
+	// The token is returned.
+	return signed, nil
+}
+
+// send delivers a message to one device.
+//
+// @param ctx context.Context - The context of the caller.
+// @param deviceToken string - The APNs device token.
+// @param message Message - The message.
+// @return error - errDeviceGone if the token is no longer valid, or another error if one occurred.
+func (ac *apnsClient) send(ctx context.Context, deviceToken string, message Message) error {
+	// providerToken is the provider token.
+	providerToken, err := ac.token()
+	// This checks if an error occurred while getting the token.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// body is the encoded payload.
+	body, err := json.Marshal(apnsPayload{
+		APS:    apnsAPS{Alert: apnsAlert{Title: message.Subject, Body: message.Text}, Sound: "default"},
+		TodoID: message.TodoID.String(),
+	})
+	// This checks if an error occurred while encoding the payload.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// req is the push request.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/3/device/%s", ac.host, deviceToken), bytes.NewReader(body))
+	// This checks if an error occurred while building the request.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// The headers are set.
+	req.Header.Set("Authorization", "bearer "+providerToken)
+	req.Header.Set("apns-topic", ac.topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+
+	// res is the response of APNs.
+	res, err := ac.http.Do(req)
+	// This checks if an error occurred while calling APNs.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// This defers the closing of the response body until the function returns.
+	defer res.Body.Close()
+
+	// This checks if the notification was accepted.
+	if res.StatusCode == http.StatusOK {
+		// If it was, no error is returned.
+		return nil
+	}
+
+	// failure is the decoded error response.
+	var failure apnsError
+	// The error response is decoded; a body that cannot be decoded leaves the reason empty.
+	_ = json.NewDecoder(res.Body).Decode(&failure)
+	// This checks if the device is gone.
+	if res.StatusCode == http.StatusGone || failure.Reason == "BadDeviceToken" || failure.Reason == "Unregistered" {
+		// If it is, the gone error is returned.
+		return errDeviceGone
+	}
+	// Any other failure is returned as an error.
+	return fmt.Errorf("apns send failed with status %d: %s", res.StatusCode, failure.Reason)
+}
+
+// apnsPayload is the body of a push request.
+type apnsPayload struct {
+	// APS is the Apple-defined part of the payload.
+	APS apnsAPS `json:"aps"`
+	// TodoID is the todo the notification is about.
+	TodoID string `json:"todo_id"`
+}
+
+// apnsAPS is the Apple-defined part of the payload.
+type apnsAPS struct {
+	// Alert is the visible notification.
+	Alert apnsAlert `json:"alert"`
+	// Sound is the sound played with the notification.
+	Sound string `json:"sound"`
+}
+
+// apnsAlert is the visible notification.
+type apnsAlert struct {
+	// Title is the title of the notification.
+	Title string `json:"title"`
+	// Body is the text of the notification.
+	Body string `json:"body"`
+}
+
+// apnsError is the body of a rejected push request.
+type apnsError struct {
+	// Reason is the reason the request was rejected.
+	Reason string `json:"reason"`
+}
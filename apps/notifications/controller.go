@@ -0,0 +1,622 @@
+// This file defines the controllers for notification preferences and push devices.
+package notifications
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to pass the deadline of the request to the helpers.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to interact with the database.
+	"database/sql"
+	// "net/url" provides functions for working with URLs. It is used here to validate webhook URLs.
+	"net/url"
+	// "slices" provides functions for working with slices. It is used here to validate channels.
+	"slices"
+	// "strings" provides functions for working with strings. It is used here to strip base64 padding.
+	"strings"
+	// "time" provides functions for working with time. It is used here to default the day of the digest.
+	"time"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to define the controllers.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to identify users.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database helpers.
+	"github.com/rahulcodepython/todo-backend/backend/database"
+	// "github.com/rahulcodepython/todo-backend/backend/i18n" is a local package that translates messages.
+	"github.com/rahulcodepython/todo-backend/backend/i18n"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+	// "github.com/rahulcodepython/todo-backend/backend/utils/signing" is a local package that signs URLs.
+	"github.com/rahulcodepython/todo-backend/backend/utils/signing"
+)
+
+// NotificationController is a struct that holds the configuration and database connection.
+type NotificationController struct {
+	// cfg is the application configuration.
+	cfg *config.Config
+	// db is the database connection.
+	db *sql.DB
+	// signer verifies the unsubscribe links.
+	signer *signing.Signer
+}
+
+// NewNotificationControl creates a new NotificationController.
+// It takes the application configuration and database connection as input.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @return *NotificationController - A pointer to the new NotificationController.
+func NewNotificationControl(cfg *config.Config, db *sql.DB) *NotificationController {
+	// A new NotificationController is returned.
+	return &NotificationController{
+		// The cfg field is set to the application configuration.
+		cfg: cfg,
+		// The db field is set to the database connection.
+		db: db,
+		// The signer field is set to a signer of the configured keys.
+		signer: signing.NewSigner(cfg.URLSigning, clock.System{}),
+	}
+}
+
+// GetPreferencesController returns the current user's setting for every channel.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (nc *NotificationController) GetPreferencesController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// responses is the list of preferences.
+	responses, err := nc.preferenceResponses(c.UserContext(), user.ID)
+	// This checks if an error occurred while reading the preferences.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get notification preferences")
+	}
+
+	// An OK response is returned with a success message and the preferences.
+	return response.OKResponse(c, "Notification preferences fetched successfully", responses)
+}
+
+// UpdatePreferencesController changes the current user's setting for one or more channels.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (nc *NotificationController) UpdatePreferencesController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// body is a new UpdatePreferencesRequest struct.
+	body := new(UpdatePreferencesRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+
+	// This checks if no preferences were given.
+	if len(body.Preferences) == 0 {
+		// If none were given, a bad request response is returned.
+		return response.BadResponse(c, "At least one preference is required")
+	}
+
+	// This iterates over the preferences to validate them before anything is stored.
+	for _, preference := range body.Preferences {
+		// This checks if the channel is unknown.
+		if !slices.Contains(Channels, preference.Channel) {
+			// If it is, a bad request response is returned.
+			return response.BadResponse(c, i18n.Sprintf(c, "Unknown notification channel: %s", preference.Channel))
+		}
+		// This checks if an enabled webhook has no valid URL.
+		if preference.Channel == ChannelWebhook && preference.Enabled && !validWebhookURL(preference.Target) {
+			// If it has none, a bad request response is returned.
+			return response.BadResponse(c, "Webhook notifications require an http or https URL as target")
+		}
+	}
+
+	// This stores all preferences in one transaction so that a failure leaves none changed.
+	err = database.WithTx(c.UserContext(), nc.db, func(tx *sql.Tx) error {
+		// This iterates over the preferences.
+		for _, preference := range body.Preferences {
+			// This stores the preference.
+			if _, err := tx.ExecContext(c.UserContext(), UpsertPreferenceQuery, user.ID, preference.Channel, preference.Enabled, preference.Target); err != nil {
+				// If an error occurs, it is returned.
+				return err
+			}
+		}
+		// No error is returned.
+		return nil
+	})
+	// This checks if an error occurred while storing the preferences.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to update notification preferences")
+	}
+
+	// responses is the list of preferences after the change.
+	responses, err := nc.preferenceResponses(c.UserContext(), user.ID)
+	// This checks if an error occurred while reading the preferences.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get notification preferences")
+	}
+
+	// An OK response is returned with a success message and the preferences.
+	return response.OKResponse(c, "Notification preferences updated successfully", responses)
+}
+
+// GetDevicesController returns the current user's push devices.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (nc *NotificationController) GetDevicesController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// devices is the list of the user's devices.
+	devices, err := listDevices(c.UserContext(), nc.db, user.ID)
+	// This checks if an error occurred while reading the devices.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get devices")
+	}
+
+	// responses is a slice that will hold the device responses.
+	responses := []DeviceResponse{}
+	// This iterates over the devices.
+	for _, device := range devices {
+		// The device response is appended to the responses slice.
+		responses = append(responses, newDeviceResponse(device))
+	}
+
+	// An OK response is returned with a success message and the devices.
+	return response.OKResponse(c, "Devices fetched successfully", responses)
+}
+
+// RegisterDeviceController registers a device of the current user for push notifications.
+// Registering a token again refreshes it, and a token that belonged to another user moves to the current user.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (nc *NotificationController) RegisterDeviceController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// body is a new RegisterDeviceRequest struct.
+	body := new(RegisterDeviceRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+
+	// This checks if the platform is unknown.
+	if body.Platform != PlatformFCM && body.Platform != PlatformAPNs {
+		// If it is, a bad request response is returned.
+		return response.BadResponse(c, "Platform must be fcm or apns")
+	}
+	// This checks if the token is empty.
+	if body.Token == "" {
+		// If it is, a bad request response is returned.
+		return response.BadResponse(c, "Token is required")
+	}
+
+	// device is the registered device.
+	device := Device{Token: body.Token, Platform: body.Platform}
+	// This stores the device and reads its registration time.
+	if err := nc.db.QueryRowContext(c.UserContext(), UpsertDeviceQuery, device.Token, user.ID, device.Platform).Scan(&device.CreatedAt); err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to register device")
+	}
+
+	// A created response is returned with a success message and the device.
+	return response.OKCreatedResponse(c, "Device registered successfully", newDeviceResponse(device))
+}
+
+// DeleteDeviceController unregisters one of the current user's push devices.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (nc *NotificationController) DeleteDeviceController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// result is the result of executing the SQL query to remove the device.
+	result, err := nc.db.ExecContext(c.UserContext(), DeleteDeviceQuery, c.Params("token"), user.ID)
+	// This checks if an error occurred while executing the query.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to delete device")
+	}
+
+	// This checks if no device was removed.
+	if count, _ := result.RowsAffected(); count == 0 {
+		// If none was, a not found response is returned.
+		return response.NotFound(c, nil, "Device not found")
+	}
+
+	// An OK response is returned with a success message.
+	return response.OKResponse(c, "Device deleted successfully", nil)
+}
+
+// GetWebPushKeyController returns the VAPID public key browsers subscribe with.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (nc *NotificationController) GetWebPushKeyController(c *fiber.Ctx) error {
+	// This checks if web push is disabled.
+	if nc.cfg.WebPush.PrivateKey == "" {
+		// If it is, a not found response is returned.
+		return response.NotFound(c, nil, "Web push is not configured")
+	}
+
+	// An OK response is returned with a success message and the key.
+	return response.OKResponse(c, "Web push key fetched successfully", WebPushKeyResponse{PublicKey: strings.TrimRight(nc.cfg.WebPush.PublicKey, "=")})
+}
+
+// SubscribeWebPushController registers a browser subscription of the current user.
+// Subscribing an endpoint again replaces its keys, and an endpoint that belonged to another user moves to the current user.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (nc *NotificationController) SubscribeWebPushController(c *fiber.Ctx) error {
+	// This checks if web push is disabled.
+	if nc.cfg.WebPush.PrivateKey == "" {
+		// If it is, a not found response is returned.
+		return response.NotFound(c, nil, "Web push is not configured")
+	}
+
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// body is a new WebPushSubscriptionRequest struct.
+	body := new(WebPushSubscriptionRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+
+	// endpoint is the parsed endpoint.
+	endpoint, err := url.Parse(body.Endpoint)
+	// This checks if the endpoint is not an https URL, which the Web Push protocol requires.
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Endpoint must be an https URL")
+	}
+	// This checks if the keys are invalid, so that a broken subscription is rejected now instead of failing on every reminder.
+	if _, _, err := parseSubscriptionKeys(body.Keys.P256dh, body.Keys.Auth); err != nil {
+		// If they are, a bad request response is returned.
+		return response.BadResponse(c, "Invalid subscription keys")
+	}
+
+	// subscription is the registered subscription.
+	subscription := WebPushSubscription{Endpoint: body.Endpoint, P256dh: body.Keys.P256dh, Auth: body.Keys.Auth}
+	// This stores the subscription and reads its registration time.
+	if err := nc.db.QueryRowContext(c.UserContext(), UpsertWebPushSubscriptionQuery, subscription.Endpoint, user.ID, subscription.P256dh, subscription.Auth).Scan(&subscription.CreatedAt); err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to subscribe")
+	}
+
+	// A created response is returned with a success message and the subscription.
+	return response.OKCreatedResponse(c, "Subscribed successfully", WebPushSubscriptionResponse{
+		Endpoint:  subscription.Endpoint,
+		CreatedAt: utils.ParseTime(subscription.CreatedAt),
+	})
+}
+
+// UnsubscribeWebPushController removes a browser subscription of the current user.
+// The endpoint is sent in the body because it is a URL.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (nc *NotificationController) UnsubscribeWebPushController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// body is a new WebPushSubscriptionRequest struct.
+	body := new(WebPushSubscriptionRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+
+	// result is the result of executing the SQL query to remove the subscription.
+	result, err := nc.db.ExecContext(c.UserContext(), DeleteWebPushSubscriptionQuery, body.Endpoint, user.ID)
+	// This checks if an error occurred while executing the query.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to unsubscribe")
+	}
+
+	// This checks if no subscription was removed.
+	if count, _ := result.RowsAffected(); count == 0 {
+		// If none was, a not found response is returned.
+		return response.NotFound(c, nil, "Subscription not found")
+	}
+
+	// An OK response is returned with a success message.
+	return response.OKResponse(c, "Unsubscribed successfully", nil)
+}
+
+// GetDigestController returns the current user's weekly email digest settings.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (nc *NotificationController) GetDigestController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// subscription is the user's digest settings.
+	subscription, err := loadDigestSubscription(c.UserContext(), nc.db, user.ID)
+	// This checks if an error occurred while reading the settings.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get digest settings")
+	}
+
+	// An OK response is returned with a success message and the settings.
+	return response.OKResponse(c, "Digest settings fetched successfully", nc.digestResponse(subscription))
+}
+
+// UpdateDigestController turns the current user's weekly email digest on or off and chooses its day.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (nc *NotificationController) UpdateDigestController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// body is a new DigestRequest struct.
+	body := new(DigestRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+
+	// subscription is the new settings, sent on Monday unless another day is given.
+	subscription := DigestSubscription{Enabled: body.Enabled, Day: time.Monday}
+	// This checks if a day was given.
+	if body.Day != "" {
+		// day is the parsed day.
+		day, ok := ParseWeekday(body.Day)
+		// This checks if the day is unknown.
+		if !ok {
+			// If it is, a bad request response is returned.
+			return response.BadResponse(c, "Day must be a day of the week, such as monday")
+		}
+		// The day is set.
+		subscription.Day = day
+	}
+
+	// This stores the settings and reads the time of the last digest.
+	if err := nc.db.QueryRowContext(c.UserContext(), UpsertDigestSubscriptionQuery, user.ID, subscription.Enabled, int(subscription.Day)).Scan(&subscription.LastSentAt); err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to update digest settings")
+	}
+
+	// An OK response is returned with a success message and the settings.
+	return response.OKResponse(c, "Digest settings updated successfully", nc.digestResponse(subscription))
+}
+
+// UnsubscribeController handles the unsubscribe links of emails, which work without a login.
+// The link is authenticated by its signature, and its token names the user and the emails to stop.
+// It answers both the link opened in a browser and the one-click POST of mail clients that follow RFC 8058.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (nc *NotificationController) UnsubscribeController(c *fiber.Ctx) error {
+	// This checks if the signature of the link is invalid or has expired.
+	if err := nc.signer.Verify(c.OriginalURL()); err != nil {
+		// If it is, a bad request response is returned.
+		return response.BadResponse(c, "Invalid or expired unsubscribe link")
+	}
+	// topic and userId are the emails to stop and the user who receives them.
+	topic, userId, ok := parseUnsubscribeToken(c.Query("token"))
+	// This checks if the token is malformed, which a valid signature only allows for a link signed by another version of the server.
+	if !ok {
+		// If it is, a bad request response is returned.
+		return response.BadResponse(c, "Invalid or expired unsubscribe link")
+	}
+
+	// query is the query that turns the emails of the topic off.
+	query, args := DisablePreferenceQuery, []any{userId, ChannelEmail}
+	// This checks if the link is one of the weekly digest.
+	if topic == TopicDigest {
+		// If it is, the digest is turned off instead.
+		query, args = DisableDigestQuery, []any{userId}
+	}
+	// This turns the emails off. Opening a link again changes nothing, and neither does the link of a deleted user.
+	if _, err := nc.db.ExecContext(c.UserContext(), query, args...); err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to unsubscribe")
+	}
+
+	// An OK response is returned with a success message and the topic.
+	return response.OKResponse(c, "Unsubscribed successfully", UnsubscribeResponse{Topic: topic})
+}
+
+// digestResponse builds the response of a user's weekly digest settings.
+//
+// @param subscription DigestSubscription - The settings.
+// @return DigestResponse - The response.
+func (nc *NotificationController) digestResponse(subscription DigestSubscription) DigestResponse {
+	// digestResponse is the response.
+	digestResponse := DigestResponse{
+		Available: nc.cfg.SMTP.Host != "",
+		Enabled:   subscription.Enabled,
+		Day:       WeekdayName(subscription.Day),
+		Hour:      nc.cfg.Digest.Hour,
+	}
+	// This checks if a digest was sent before.
+	if subscription.LastSentAt.Valid {
+		// If one was, the time is formatted.
+		lastSentAt := utils.ParseTime(subscription.LastSentAt.Time)
+		// The time is set.
+		digestResponse.LastSentAt = &lastSentAt
+	}
+	// The response is returned.
+	return digestResponse
+}
+
+// preferenceResponses builds the response for every channel from the user's stored preferences and the server configuration.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param userId uuid.UUID - The ID of the user.
+// @return []PreferenceResponse - The preferences of every channel.
+// @return error - An error if one occurred.
+func (nc *NotificationController) preferenceResponses(ctx context.Context, userId uuid.UUID) ([]PreferenceResponse, error) {
+	// preferences is the user's stored preferences.
+	preferences, err := loadPreferences(ctx, nc.db, userId)
+	// This checks if an error occurred while reading the preferences.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+
+	// available is the availability of every channel.
+	available := AvailableChannels(nc.cfg)
+	// responses is a slice that will hold the preference responses.
+	responses := make([]PreferenceResponse, 0, len(Channels))
+	// This iterates over the channels in a stable order.
+	for _, channel := range Channels {
+		// preference is the effective preference of the channel.
+		preference := effectivePreference(preferences, channel)
+		// The preference response is appended to the responses slice.
+		responses = append(responses, PreferenceResponse{
+			Channel:   channel,
+			Available: available[channel],
+			Enabled:   preference.Enabled,
+			Target:    preference.Target,
+		})
+	}
+
+	// The responses are returned.
+	return responses, nil
+}
+
+// listDevices reads a user's push devices.
+//
+// @param ctx context.Context - The context of the caller, which carries its deadline.
+// @param db *sql.DB - The database connection.
+// @param userId uuid.UUID - The ID of the user.
+// @return []Device - The devices.
+// @return error - An error if one occurred.
+func listDevices(ctx context.Context, db *sql.DB, userId uuid.UUID) ([]Device, error) {
+	// rows is the result of querying the devices.
+	rows, err := db.QueryContext(ctx, GetDevicesQuery, userId)
+	// This checks if an error occurred while querying the database.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// This defers the closing of the rows until the function returns.
+	defer rows.Close()
+
+	// devices is a slice that will hold the devices.
+	var devices []Device
+	// This iterates over the rows.
+	for rows.Next() {
+		// device is a new Device struct.
+		var device Device
+		// This scans the row into the device struct.
+		if err := rows.Scan(&device.Token, &device.Platform, &device.CreatedAt); err != nil {
+			// If an error occurs, it is returned.
+			return nil, err
+		}
+		// The device is appended to the devices slice.
+		devices = append(devices, device)
+	}
+
+	// The devices and the error of the iteration, if any, are returned.
+	return devices, rows.Err()
+}
+
+// newDeviceResponse converts a Device to a DeviceResponse.
+//
+// @param device Device - The device.
+// @return DeviceResponse - The device response.
+func newDeviceResponse(device Device) DeviceResponse {
+	// A new DeviceResponse is returned.
+	return DeviceResponse{
+		Platform:  device.Platform,
+		Token:     device.Token,
+		CreatedAt: utils.ParseTime(device.CreatedAt),
+	}
+}
+
+// validWebhookURL reports whether a target is an absolute http or https URL.
+//
+// @param target string - The target.
+// @return bool - True if the target is a valid webhook URL.
+func validWebhookURL(target string) bool {
+	// parsed is the parsed URL.
+	parsed, err := url.Parse(target)
+	// The URL is valid if it parsed with an http scheme and a host.
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -0,0 +1,367 @@
+// This file defines the worker that emails the weekly digest of each user's todos.
+package notifications
+
+// "bytes" implements byte buffers. It is used here to render the digest.
+import (
+	"bytes"
+	// "context" provides a way to carry cancellation signals. It is used here to stop the worker on shutdown.
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to claim due digests and read todos.
+	"database/sql"
+	// "fmt" provides functions for formatted I/O. It is used here to build the plain text digest.
+	"fmt"
+	// "html/template" implements HTML templates that escape their data. It is used here to render the HTML digest.
+	"html/template"
+	// "log" provides a simple logging package. It is used here to log failed claims and sends.
+	"log"
+	// "strings" provides functions for working with strings. It is used here to parse and build weekday names.
+	"strings"
+	// "time" provides functions for working with time. It is used here to schedule the worker and to bound the sections.
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to identify users.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+const (
+	// digestBatchSize is the maximum number of digests claimed in one run.
+	digestBatchSize = 50
+	// digestSectionLimit is the maximum number of todos listed in each section of a digest.
+	digestSectionLimit = 20
+	// digestWeek is the span the digest looks ahead for due todos and back for completed ones.
+	digestWeek = 7 * 24 * time.Hour
+)
+
+// digestTemplate renders the HTML body of a digest.
+var digestTemplate = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif;">
+<p>Hi {{.Name}}, here is your week.</p>
+{{range .Sections}}{{if .Items}}<h3>{{.Heading}}</h3>
+<ul>
+{{range .Items}}<li>{{.Title}}{{if .Due}} <span style="color: #666;">({{.Due}})</span>{{end}}</li>
+{{end}}</ul>
+{{end}}{{end}}<p style="color: #666; font-size: 12px;"><a href="{{.Unsubscribe}}">Unsubscribe from the weekly digest</a></p>
+</body>
+</html>
+`))
+
+// digestSection is a section of a rendered digest.
+type digestSection struct {
+	// Heading is the heading of the section.
+	Heading string
+	// Items are the todos of the section.
+	Items []digestLine
+}
+
+// digestLine is a todo as shown in a rendered digest.
+type digestLine struct {
+	// Title is the title of the todo.
+	Title string
+	// Due is the due date of the todo in the user's time zone, or empty if it has none.
+	Due string
+}
+
+// ParseWeekday parses a lowercase English day name, such as "monday".
+//
+// @param name string - The name of the day.
+// @return time.Weekday - The day.
+// @return bool - Whether the name is a day.
+func ParseWeekday(name string) (time.Weekday, bool) {
+	// This iterates over the days of the week.
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		// This checks if the name is the name of the day.
+		if WeekdayName(day) == name {
+			// If it is, the day is returned.
+			return day, true
+		}
+	}
+	// No day matched the name.
+	return time.Sunday, false
+}
+
+// WeekdayName returns the lowercase English name of a day, such as "monday".
+//
+// @param day time.Weekday - The day.
+// @return string - The name of the day.
+func WeekdayName(day time.Weekday) string {
+	// The name of the day is returned in lower case.
+	return strings.ToLower(day.String())
+}
+
+// StartDigestWorker emails the weekly digests as they become due until the context is cancelled.
+//
+// @param ctx context.Context - The context that stops the worker.
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @param mailer *EmailNotifier - The notifier that sends the digests.
+func StartDigestWorker(ctx context.Context, cfg *config.Config, db *sql.DB, mailer *EmailNotifier) {
+	// ticker fires once every digest interval.
+	ticker := time.NewTicker(cfg.Digest.Interval)
+	// This defers stopping the ticker until the worker returns.
+	defer ticker.Stop()
+
+	// This loops until the context is cancelled.
+	for {
+		// This waits for either the next tick or the cancellation.
+		select {
+		case <-ctx.Done():
+			// If the context is cancelled, the worker returns.
+			return
+		case <-ticker.C:
+			// On every tick, the due digests are sent.
+			sendDueDigests(ctx, db, mailer, cfg.Digest.Hour)
+		}
+	}
+}
+
+// sendDueDigests claims the digests that are due and emails each of them.
+// Digests are claimed before they are sent, so a failed send is logged and not retried until the next week.
+//
+// @param ctx context.Context - The context of the worker.
+// @param db *sql.DB - The database connection.
+// @param mailer *EmailNotifier - The notifier that sends the digests.
+// @param hour int - The hour of the day from which digests are sent.
+func sendDueDigests(ctx context.Context, db *sql.DB, mailer *EmailNotifier, hour int) {
+	// now is the time of the run, which is stored as the time the digests were sent.
+	now := time.Now()
+	// rows is the result of claiming the due digests.
+	rows, err := db.QueryContext(ctx, ClaimDueDigestsQuery, now, hour, digestBatchSize)
+	// This checks if an error occurred while claiming the digests.
+	if err != nil {
+		// If an error occurs, it is logged and the run is skipped.
+		log.Printf("Unable to claim due digests: %v", err)
+		return
+	}
+
+	// recipients is a slice that will hold the users whose digest was claimed.
+	var recipients []digestRecipient
+	// This iterates over the rows.
+	for rows.Next() {
+		// recipient is a new digestRecipient struct.
+		var recipient digestRecipient
+		// This scans the row into the recipient struct.
+		if err := rows.Scan(&recipient.UserID, &recipient.Name, &recipient.Email, &recipient.Timezone); err != nil {
+			// If an error occurs, it is logged and the row is skipped.
+			log.Printf("Unable to read due digest: %v", err)
+			continue
+		}
+		// The recipient is appended to the recipients slice.
+		recipients = append(recipients, recipient)
+	}
+	// The rows are closed before any email is sent so that the connection is released.
+	rows.Close()
+
+	// This iterates over the claimed digests.
+	for _, recipient := range recipients {
+		// This builds and sends the digest of the user.
+		if err := sendDigest(ctx, db, mailer, recipient, now); err != nil {
+			// If an error occurs, it is logged.
+			log.Printf("Unable to send digest to user %s: %v", recipient.UserID, err)
+		}
+	}
+}
+
+// sendDigest builds the digest of a user and emails it. A digest with nothing to report is not sent.
+//
+// @param ctx context.Context - The context of the worker.
+// @param db *sql.DB - The database connection.
+// @param mailer *EmailNotifier - The notifier that sends the digest.
+// @param recipient digestRecipient - The user the digest is sent to.
+// @param now time.Time - The time of the run.
+// @return error - An error if one occurred.
+func sendDigest(ctx context.Context, db *sql.DB, mailer *EmailNotifier, recipient digestRecipient, now time.Time) error {
+	// overdue is the list of open todos that are past their due date.
+	overdue, err := loadDigestItems(ctx, db, GetDigestOverdueQuery, recipient.UserID, now, digestSectionLimit)
+	// This checks if an error occurred while reading the todos.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// dueSoon is the list of open todos that are due within the week.
+	dueSoon, err := loadDigestItems(ctx, db, GetDigestDueSoonQuery, recipient.UserID, now, now.Add(digestWeek), digestSectionLimit)
+	// This checks if an error occurred while reading the todos.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// completed is the list of todos completed in the past week.
+	completed, err := loadDigestItems(ctx, db, GetDigestCompletedQuery, recipient.UserID, now.Add(-digestWeek), digestSectionLimit)
+	// This checks if an error occurred while reading the todos.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// This checks if the digest has nothing to report.
+	if len(overdue) == 0 && len(dueSoon) == 0 && len(completed) == 0 {
+		// If it has nothing, no email is sent.
+		return nil
+	}
+
+	// location is the time zone of the user, in which the due dates are shown.
+	location := users.User{Timezone: recipient.Timezone}.Location()
+	// sections are the sections of the digest.
+	sections := []digestSection{
+		{Heading: "Overdue", Items: digestLines(overdue, location)},
+		{Heading: "Due this week", Items: digestLines(dueSoon, location)},
+		{Heading: "Completed this week", Items: digestLines(completed, location)},
+	}
+
+	// unsubscribe is the link that turns the digest off.
+	unsubscribe, err := mailer.unsubscribeLink(TopicDigest, recipient.UserID)
+	// This checks if an error occurred while signing the link.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// html is the buffer the HTML body is rendered into.
+	var html bytes.Buffer
+	// This renders the HTML body.
+	if err := digestTemplate.Execute(&html, struct {
+		Name        string
+		Sections    []digestSection
+		Unsubscribe string
+	}{Name: recipient.Name, Sections: sections, Unsubscribe: unsubscribe}); err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// The digest is emailed with a plain text alternative and the unsubscribe headers.
+	return mailer.MailHTML(ctx, recipient.Email, "Your weekly todo digest", digestText(recipient.Name, sections, unsubscribe), html.String(), unsubscribe)
+}
+
+// loadDigestItems reads the todos of one section of a digest.
+//
+// @param ctx context.Context - The context of the worker.
+// @param db *sql.DB - The database connection.
+// @param query string - The query of the section.
+// @param args ...any - The arguments of the query.
+// @return []digestItem - The todos.
+// @return error - An error if one occurred.
+func loadDigestItems(ctx context.Context, db *sql.DB, query string, args ...any) ([]digestItem, error) {
+	// rows is the result of querying the todos.
+	rows, err := db.QueryContext(ctx, query, args...)
+	// This checks if an error occurred while querying the database.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// This defers the closing of the rows until the function returns.
+	defer rows.Close()
+
+	// items is a slice that will hold the todos.
+	var items []digestItem
+	// This iterates over the rows.
+	for rows.Next() {
+		// item is a new digestItem struct.
+		var item digestItem
+		// This scans the row into the item struct.
+		if err := rows.Scan(&item.Title, &item.DueAt); err != nil {
+			// If an error occurs, it is returned.
+			return nil, err
+		}
+		// The item is appended to the items slice.
+		items = append(items, item)
+	}
+
+	// The items are returned with any error that ended the iteration.
+	return items, rows.Err()
+}
+
+// digestLines formats the todos of a section for display.
+//
+// @param items []digestItem - The todos.
+// @param location *time.Location - The time zone the due dates are shown in.
+// @return []digestLine - The formatted todos.
+func digestLines(items []digestItem, location *time.Location) []digestLine {
+	// lines is a slice that will hold the formatted todos.
+	lines := make([]digestLine, 0, len(items))
+	// This iterates over the todos.
+	for _, item := range items {
+		// line is the formatted todo.
+		line := digestLine{Title: item.Title}
+		// This checks if the todo has a due date.
+		if item.DueAt.Valid {
+			// If it has, the due date is shown in the user's time zone.
+			line.Due = item.DueAt.Time.In(location).Format("Mon 2 Jan 15:04")
+		}
+		// The line is appended to the lines slice.
+		lines = append(lines, line)
+	}
+	// The lines are returned.
+	return lines
+}
+
+// digestText renders the plain text body of a digest.
+//
+// @param name string - The name of the user.
+// @param sections []digestSection - The sections of the digest.
+// @param unsubscribe string - The link that turns the digest off.
+// @return string - The plain text body.
+func digestText(name string, sections []digestSection, unsubscribe string) string {
+	// text is the builder the body is written into.
+	var text strings.Builder
+	// The greeting is written.
+	fmt.Fprintf(&text, "Hi %s, here is your week.\r\n", name)
+	// This iterates over the sections.
+	for _, section := range sections {
+		// This checks if the section is empty.
+		if len(section.Items) == 0 {
+			// If it is, it is left out.
+			continue
+		}
+		// The heading is written.
+		fmt.Fprintf(&text, "\r\n%s\r\n", section.Heading)
+		// This iterates over the todos of the section.
+		for _, line := range section.Items {
+			// This checks if the todo has a due date.
+			if line.Due != "" {
+				// If it has, it is written with its due date.
+				fmt.Fprintf(&text, "- %s (%s)\r\n", line.Title, line.Due)
+				continue
+			}
+			// Otherwise, only the title is written.
+			fmt.Fprintf(&text, "- %s\r\n", line.Title)
+		}
+	}
+	// The unsubscribe link is written last.
+	fmt.Fprintf(&text, "\r\nTo stop the weekly digest, open %s\r\n", unsubscribe)
+	// The body is returned.
+	return text.String()
+}
+
+// loadDigestSubscription reads a user's weekly digest settings. A user who never chose any has the digest off, on Monday.
+//
+// @param ctx context.Context - The context of the caller, which carries its deadline.
+// @param db *sql.DB - The database connection.
+// @param userId uuid.UUID - The ID of the user.
+// @return DigestSubscription - The settings.
+// @return error - An error if one occurred.
+func loadDigestSubscription(ctx context.Context, db *sql.DB, userId uuid.UUID) (DigestSubscription, error) {
+	// subscription is the settings, which default to the digest being off on Monday.
+	subscription := DigestSubscription{Day: time.Monday}
+	// day is the stored day of the week.
+	var day int
+	// This reads the stored settings.
+	err := db.QueryRowContext(ctx, GetDigestSubscriptionQuery, userId).Scan(&subscription.Enabled, &day, &subscription.LastSentAt)
+	// This checks if the user never chose any settings.
+	if err == sql.ErrNoRows {
+		// If the user did not, the defaults are returned.
+		return subscription, nil
+	}
+	// This checks if another error occurred.
+	if err != nil {
+		// If one did, it is returned.
+		return DigestSubscription{}, err
+	}
+	// The stored day is set.
+	subscription.Day = time.Weekday(day)
+	// The settings are returned.
+	return subscription, nil
+}
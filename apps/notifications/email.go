@@ -0,0 +1,190 @@
+// This file defines the notifier that sends emails.
+package notifications
+
+// "bytes" implements byte buffers. It is used here to build the parts of HTML emails.
+import (
+	"bytes"
+	// "context" provides a way to carry cancellation signals. It is part of the Notifier and Mailer interfaces.
+	"context"
+	// "fmt" provides functions for formatted I/O. It is used here to build the email.
+	"fmt"
+	// "mime/multipart" implements MIME multipart bodies. It is used here to send HTML emails with a plain text alternative.
+	"mime/multipart"
+	// "net" provides network primitives. It is used here to build the server address.
+	"net"
+	// "net/smtp" implements the Simple Mail Transfer Protocol. It is used here to send the email.
+	"net/smtp"
+	// "net/textproto" implements MIME headers. It is used here to set the content type of each part.
+	"net/textproto"
+	// "strings" provides functions for working with strings. It is used here to strip line breaks from headers.
+	"strings"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to identify users.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/utils/signing" is a local package that signs URLs.
+	"github.com/rahulcodepython/todo-backend/backend/utils/signing"
+)
+
+// headerReplacer removes line breaks from header values so that a todo title cannot inject headers.
+var headerReplacer = strings.NewReplacer("\r", " ", "\n", " ")
+
+// EmailNotifier sends notifications by email through an SMTP server.
+type EmailNotifier struct {
+	// cfg is the mail server configuration.
+	cfg config.SMTPConfig
+	// signer signs the unsubscribe links.
+	signer *signing.Signer
+	// publicURL is the address clients reach the server at, which the unsubscribe links point to.
+	publicURL string
+}
+
+// NewEmailNotifier creates a new EmailNotifier.
+//
+// @param cfg *config.Config - The application configuration.
+// @return *EmailNotifier - A pointer to the new EmailNotifier.
+func NewEmailNotifier(cfg *config.Config) *EmailNotifier {
+	// A new EmailNotifier is returned.
+	return &EmailNotifier{
+		// The cfg field is set to the mail server configuration.
+		cfg: cfg.SMTP,
+		// The signer field is set to a signer of the configured keys.
+		signer: signing.NewSigner(cfg.URLSigning, clock.System{}),
+		// The publicURL field is set to the public address of the server.
+		publicURL: cfg.Server.PublicURL,
+	}
+}
+
+// Channel returns the email channel.
+//
+// @return string - The channel.
+func (en *EmailNotifier) Channel() string {
+	// The email channel is returned.
+	return ChannelEmail
+}
+
+// Notify emails the message to the user's address, with a link that turns the email channel off.
+//
+// @param ctx context.Context - The context of the caller.
+// @param recipient Recipient - The user the message is sent to.
+// @param message Message - The message.
+// @return error - An error if one occurred.
+func (en *EmailNotifier) Notify(ctx context.Context, recipient Recipient, message Message) error {
+	// This checks if the user has no email address.
+	if recipient.Email == "" {
+		// If the user has none, nothing is sent.
+		return nil
+	}
+	// unsubscribe is the link that turns the email channel off.
+	unsubscribe, err := en.unsubscribeLink(TopicReminders, recipient.UserID)
+	// This checks if an error occurred while signing the link.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// The message is emailed to the user with the link.
+	return en.send(recipient.Email, message.Subject, "text/plain; charset=UTF-8", message.Text+"\r\n\r\nTo stop these emails, open "+unsubscribe+"\r\n", unsubscribe)
+}
+
+// Mail sends a plain text email to an address.
+// It also lets the notifier serve as the mailer of the security alerts of the users package, which have no unsubscribe link since they cannot be turned off.
+//
+// @param ctx context.Context - The context of the caller.
+// @param to string - The address the email is sent to.
+// @param subject string - The subject of the email.
+// @param text string - The plain text body of the email.
+// @return error - An error if one occurred.
+func (en *EmailNotifier) Mail(ctx context.Context, to string, subject string, text string) error {
+	// The email is sent with a single plain text part.
+	return en.send(to, subject, "text/plain; charset=UTF-8", text+"\r\n", "")
+}
+
+// MailHTML sends an email with an HTML body and its plain text alternative, for mail clients that do not show HTML.
+//
+// @param ctx context.Context - The context of the caller.
+// @param to string - The address the email is sent to.
+// @param subject string - The subject of the email.
+// @param text string - The plain text body of the email.
+// @param html string - The HTML body of the email.
+// @param unsubscribe string - The unsubscribe link of the email, or empty if it has none.
+// @return error - An error if one occurred.
+func (en *EmailNotifier) MailHTML(ctx context.Context, to string, subject string, text string, html string, unsubscribe string) error {
+	// body is the buffer the parts are written to.
+	var body bytes.Buffer
+	// writer writes the parts, separated by a random boundary.
+	writer := multipart.NewWriter(&body)
+	// This iterates over the parts, the plain text one first, since mail clients show the last part they understand.
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", text},
+		{"text/html; charset=UTF-8", html},
+	} {
+		// partWriter writes the content of the part.
+		partWriter, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		// This checks if an error occurred while creating the part.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The content of the part is written.
+		if _, err := partWriter.Write([]byte(part.content)); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+	}
+	// This writes the closing boundary.
+	if err := writer.Close(); err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// The email is sent with both parts.
+	return en.send(to, subject, "multipart/alternative; boundary="+writer.Boundary(), body.String(), unsubscribe)
+}
+
+// unsubscribeLink returns the signed link that unsubscribes a user from a topic.
+//
+// @param topic string - The topic, TopicReminders or TopicDigest.
+// @param userId uuid.UUID - The ID of the user.
+// @return string - The link.
+// @return error - An error if one occurred.
+func (en *EmailNotifier) unsubscribeLink(topic string, userId uuid.UUID) (string, error) {
+	// The link is signed with the notifier's keys.
+	return unsubscribeLink(en.signer, en.publicURL, topic, userId)
+}
+
+// send sends an email with a body of a content type through the mail server.
+// An email with an unsubscribe link gets the List-Unsubscribe headers of RFC 8058, so that mail clients offer one-click unsubscribing.
+//
+// @param to string - The address the email is sent to.
+// @param subject string - The subject of the email.
+// @param contentType string - The content type of the body.
+// @param content string - The body of the email.
+// @param unsubscribe string - The unsubscribe link of the email, or empty if it has none.
+// @return error - An error if one occurred.
+func (en *EmailNotifier) send(to string, subject string, contentType string, content string, unsubscribe string) error {
+	// auth is the SMTP authentication, used only when a user name is configured.
+	var auth smtp.Auth
+	// This checks if a user name is configured.
+	if en.cfg.Username != "" {
+		// If it is, plain authentication is used.
+		auth = smtp.PlainAuth("", en.cfg.Username, en.cfg.Password, en.cfg.Host)
+	}
+
+	// headers are the optional headers of the email.
+	var headers string
+	// This checks if the email has an unsubscribe link.
+	if unsubscribe != "" {
+		// If it has, mail clients are told to unsubscribe by posting to the link.
+		headers = fmt.Sprintf("List-Unsubscribe: <%s>\r\nList-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n", unsubscribe)
+	}
+
+	// body is the email with its headers.
+	body := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n%sMIME-Version: 1.0\r\nContent-Type: %s\r\n\r\n%s",
+		en.cfg.From, headerReplacer.Replace(to), headerReplacer.Replace(subject), headers, contentType, content)
+
+	// The email is sent.
+	return smtp.SendMail(net.JoinHostPort(en.cfg.Host, en.cfg.Port), auth, en.cfg.From, []string{to}, []byte(body))
+}
@@ -0,0 +1,273 @@
+// This file defines a minimal client for the Firebase Cloud Messaging HTTP v1 API.
+package notifications
+
+// "bytes" provides functions for working with byte slices. It is used here to build the request body.
+import (
+	"bytes"
+	// "context" provides a way to carry cancellation signals. It is used here to cancel requests.
+	"context"
+	// "crypto/rsa" implements RSA. It is used here to hold the service account key.
+	"crypto/rsa"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to read the credentials and encode messages.
+	"encoding/json"
+	// "fmt" provides functions for formatted I/O. It is used here to build URLs and errors.
+	"fmt"
+	// "net/http" provides HTTP client and server implementations. It is used here to call the API.
+	"net/http"
+	// "net/url" provides functions for working with URLs. It is used here to encode the token request.
+	"net/url"
+	// "os" provides functions for working with files. It is used here to read the credentials file.
+	"os"
+	// "strings" provides functions for working with strings. It is used here to build the token request body.
+	"strings"
+	// "sync" provides synchronization primitives. It is used here to guard the cached access token.
+	"sync"
+	// "time" provides functions for working with time. It is used here to expire the access token.
+	"time"
+
+	// "github.com/golang-jwt/jwt/v5" is a package for working with JWTs. It is used here to sign the token request.
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fcmScope is the OAuth scope needed to send messages.
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// fcmClient sends messages through FCM using a service account.
+type fcmClient struct {
+	// projectID is the Firebase project.
+	projectID string
+	// clientEmail is the service account email.
+	clientEmail string
+	// tokenURI is the OAuth token endpoint.
+	tokenURI string
+	// key is the service account private key.
+	key *rsa.PrivateKey
+	// http is the HTTP client used for the calls.
+	http *http.Client
+	// mu guards the cached access token.
+	mu sync.Mutex
+	// accessToken is the cached OAuth access token.
+	accessToken string
+	// expiresAt is the time the cached access token expires.
+	expiresAt time.Time
+}
+
+// newFCMClient reads a service account file and creates an fcmClient.
+//
+// @param path string - The path of the service account JSON file.
+// @return *fcmClient - A pointer to the new fcmClient.
+// @return error - An error if the file could not be read.
+func newFCMClient(path string) (*fcmClient, error) {
+	// data is the content of the file.
+	data, err := os.ReadFile(path)
+	// This checks if an error occurred while reading the file.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+
+	// account is the decoded service account.
+	var account fcmServiceAccount
+	// This decodes the file.
+	if err := json.Unmarshal(data, &account); err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+
+	// key is the parsed private key.
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	// This checks if an error occurred while parsing the key.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+
+	// A new fcmClient is returned.
+	return &fcmClient{
+		projectID:   account.ProjectID,
+		clientEmail: account.ClientEmail,
+		tokenURI:    account.TokenURI,
+		key:         key,
+		http:        &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// token returns a valid access token, requesting a new one when the cached token is about to expire.
+//
+// @param ctx context.Context - The context of the caller.
+// @return string - The access token.
+// @return error - An error if one occurred.
+func (fc *fcmClient) token(ctx context.Context) (string, error) {
+	// The cache is locked while the token is checked and refreshed.
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	// This checks if the cached token is still valid for at least a minute.
+	if fc.accessToken != "" && time.Until(fc.expiresAt) > time.Minute {
+		// If it is, it is returned.
+		return fc.accessToken, nil
+	}
+
+	// now is the current time.
+	now := time.Now()
+	// assertion is the signed JWT that is exchanged for an access token.
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   fc.clientEmail,
+		"scope": fcmScope,
+		"aud":   fc.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(fc.key)
+	// This checks if an error occurred while signing the assertion.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+
+	// form is the token request body.
+	form := url.Values{"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"}, "assertion": {assertion}}
+	// req is the token request.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fc.tokenURI, strings.NewReader(form.Encode()))
+	// This checks if an error occurred while building the request.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+	// The content type is set.
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	// res is the response of the token endpoint.
+	res, err := fc.http.Do(req)
+	// This checks if an error occurred while calling the endpoint.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+	// This defers the closing of the response body until the function returns.
+	defer res.Body.Close()
+
+	// This checks if the token request was rejected.
+	if res.StatusCode != http.StatusOK {
+		// If it was, an error with the status is returned.
+		return "", fmt.Errorf("token request failed with status %d", res.StatusCode)
+	}
+
+	// token is the decoded token response.
+	var token fcmTokenResponse
+	// This decodes the response.
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+
+	// The token is cached.
+	fc.accessToken = token.AccessToken
+	fc.expiresAt = now.Add(time.Duration(token.ExpiresIn) * time.Second)
+	// The token is returned.
+	return fc.accessToken, nil
+}
+
+// send delivers a message to one device.
+//
+// @param ctx context.Context - The context of the caller.
+// @param deviceToken string - The FCM registration token of the device.
+// @param message Message - The message.
+// @return error - errDeviceGone if the token is no longer registered, or another error if one occurred.
+func (fc *fcmClient) send(ctx context.Context, deviceToken string, message Message) error {
+	// accessToken is the OAuth access token.
+	accessToken, err := fc.token(ctx)
+	// This checks if an error occurred while getting the token.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// body is the encoded message.
+	body, err := json.Marshal(fcmSendRequest{Message: fcmMessage{
+		Token:        deviceToken,
+		Notification: fcmNotification{Title: message.Subject, Body: message.Text},
+		Data:         map[string]string{"todo_id": message.TodoID.String()},
+	}})
+	// This checks if an error occurred while encoding the message.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// req is the send request.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("https://fcm.googleapis.com/v1/projects/%s/messages:send", fc.projectID), bytes.NewReader(body))
+	// This checks if an error occurred while building the request.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// The headers are set.
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	// res is the response of the API.
+	res, err := fc.http.Do(req)
+	// This checks if an error occurred while calling the API.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// This defers the closing of the response body until the function returns.
+	defer res.Body.Close()
+
+	// This checks the status of the response.
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		// The token is no longer registered.
+		return errDeviceGone
+	case res.StatusCode != http.StatusOK:
+		// Any other failure is returned as an error.
+		return fmt.Errorf("fcm send failed with status %d", res.StatusCode)
+	}
+	// No error is returned.
+	return nil
+}
+
+// fcmServiceAccount is the subset of a service account file that is needed to send messages.
+type fcmServiceAccount struct {
+	// ProjectID is the Firebase project.
+	ProjectID string `json:"project_id"`
+	// ClientEmail is the service account email.
+	ClientEmail string `json:"client_email"`
+	// PrivateKey is the PEM-encoded private key.
+	PrivateKey string `json:"private_key"`
+	// TokenURI is the OAuth token endpoint.
+	TokenURI string `json:"token_uri"`
+}
+
+// fcmTokenResponse is the response of the OAuth token endpoint.
+type fcmTokenResponse struct {
+	// AccessToken is the access token.
+	AccessToken string `json:"access_token"`
+	// ExpiresIn is the lifetime of the token in seconds.
+	ExpiresIn int `json:"expires_in"`
+}
+
+// fcmSendRequest is the body of a send request.
+type fcmSendRequest struct {
+	// Message is the message to send.
+	Message fcmMessage `json:"message"`
+}
+
+// fcmMessage is an FCM message.
+type fcmMessage struct {
+	// Token is the registration token of the device.
+	Token string `json:"token"`
+	// Notification is the visible notification.
+	Notification fcmNotification `json:"notification"`
+	// Data is the data passed to the app.
+	Data map[string]string `json:"data"`
+}
+
+// fcmNotification is the visible part of an FCM message.
+type fcmNotification struct {
+	// Title is the title of the notification.
+	Title string `json:"title"`
+	// Body is the text of the notification.
+	Body string `json:"body"`
+}
@@ -0,0 +1,143 @@
+// This file defines the data models for notifications.
+package notifications
+
+// "database/sql" provides a generic SQL interface. It is used here to define nullable time fields.
+import (
+	"database/sql"
+	// "time" provides functions for working with time. It is used here to define time fields.
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to define ID fields.
+	"github.com/google/uuid"
+)
+
+const (
+	// ChannelEmail delivers notifications by email to the user's address.
+	ChannelEmail = "email"
+	// ChannelWebhook delivers notifications as JSON to a URL chosen by the user.
+	ChannelWebhook = "webhook"
+	// ChannelTelegram delivers notifications to the user's linked Telegram chat.
+	ChannelTelegram = "telegram"
+	// ChannelPush delivers notifications to the user's registered mobile devices through FCM or APNs.
+	ChannelPush = "push"
+	// ChannelWebPush delivers notifications to the user's subscribed browsers through the Web Push protocol.
+	ChannelWebPush = "webpush"
+)
+
+const (
+	// PlatformFCM is a device reached through Firebase Cloud Messaging.
+	PlatformFCM = "fcm"
+	// PlatformAPNs is a device reached through the Apple Push Notification service.
+	PlatformAPNs = "apns"
+)
+
+// Channels is the list of every notification channel, in the order they are shown to the user.
+var Channels = []string{ChannelEmail, ChannelWebhook, ChannelTelegram, ChannelPush, ChannelWebPush}
+
+// defaultEnabled holds the channels that are on for users who never changed their preferences.
+// Telegram, push, and web push only reach users who linked a chat, registered a device, or subscribed a browser, so turning them on keeps reminders working without setup.
+var defaultEnabled = map[string]bool{ChannelTelegram: true, ChannelPush: true, ChannelWebPush: true}
+
+// Preference represents a user's setting for one channel.
+type Preference struct {
+	// Channel is one of the channel constants.
+	Channel string
+	// Enabled reports whether notifications are sent through the channel.
+	Enabled bool
+	// Target is the channel-specific destination, such as the webhook URL. Most channels do not need one.
+	Target string
+}
+
+// Device represents a mobile device registered for push notifications.
+type Device struct {
+	// Token is the FCM registration token or APNs device token.
+	Token string
+	// Platform is one of the platform constants.
+	Platform string
+	// CreatedAt is the time the device was registered.
+	CreatedAt time.Time
+}
+
+// WebPushSubscription represents a browser subscribed to push notifications.
+type WebPushSubscription struct {
+	// Endpoint is the push service URL of the subscription.
+	Endpoint string
+	// P256dh is the browser's public key, used to encrypt the messages.
+	P256dh string
+	// Auth is the browser's authentication secret, used to encrypt the messages.
+	Auth string
+	// CreatedAt is the time the subscription was registered.
+	CreatedAt time.Time
+}
+
+// Recipient represents the user a notification is sent to.
+type Recipient struct {
+	// UserID is the ID of the user.
+	UserID uuid.UUID
+	// Name is the name of the user.
+	Name string
+	// Email is the email address of the user.
+	Email string
+	// Target is the user's destination for the channel being notified, if the channel has one.
+	Target string
+}
+
+// Message represents a notification, independent of the channel it is sent through.
+type Message struct {
+	// Subject is a short summary, used as the email subject or push title.
+	Subject string
+	// Text is the full text of the notification.
+	Text string
+	// TodoID is the todo the notification is about.
+	TodoID uuid.UUID
+	// DueAt is the due date of the todo.
+	DueAt time.Time
+}
+
+// dueReminder represents a todo that is about to become due and its owner.
+type dueReminder struct {
+	// TodoID is the ID of the todo.
+	TodoID uuid.UUID
+	// Title is the title of the todo.
+	Title string
+	// DueAt is the due date of the todo.
+	DueAt time.Time
+	// UserID is the ID of the todo's owner.
+	UserID uuid.UUID
+	// Name is the name of the todo's owner.
+	Name string
+	// Email is the email address of the todo's owner.
+	Email string
+	// Timezone is the time zone of the todo's owner.
+	Timezone string
+}
+
+// DigestSubscription represents a user's choice of the weekly email digest.
+type DigestSubscription struct {
+	// Enabled reports whether the digest is sent.
+	Enabled bool
+	// Day is the day of the week the digest is sent on, in the user's time zone.
+	Day time.Weekday
+	// LastSentAt is the time the last digest was sent, or null if none was.
+	LastSentAt sql.NullTime
+}
+
+// digestRecipient represents a user whose weekly digest is due.
+type digestRecipient struct {
+	// UserID is the ID of the user.
+	UserID uuid.UUID
+	// Name is the name of the user.
+	Name string
+	// Email is the email address of the user.
+	Email string
+	// Timezone is the time zone of the user.
+	Timezone string
+}
+
+// digestItem represents a todo listed in a weekly digest.
+type digestItem struct {
+	// Title is the title of the todo.
+	Title string
+	// DueAt is the due date of the todo, if it has one.
+	DueAt sql.NullTime
+}
@@ -0,0 +1,199 @@
+// This file defines the Notifier interface and the dispatcher that routes notifications to the channels a user enabled.
+// Adding a channel means writing a Notifier and registering it in NewDispatcher; the callers do not change.
+package notifications
+
+// "context" provides a way to carry cancellation signals. It is used here to cancel deliveries on shutdown.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to read preferences.
+	"database/sql"
+	// "log" provides a simple logging package. It is used here to log failed deliveries.
+	"log"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to identify users.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+// Notifier is implemented by every notification channel.
+type Notifier interface {
+	// Channel returns the channel constant the notifier delivers through.
+	Channel() string
+	// Notify delivers a message to a recipient. It returns nil without sending anything when the recipient
+	// has no destination on the channel, such as no linked chat or no registered device.
+	Notify(ctx context.Context, recipient Recipient, message Message) error
+}
+
+// Dispatcher routes messages to the notifiers of the channels each user enabled.
+type Dispatcher struct {
+	// db is the database connection.
+	db *sql.DB
+	// notifiers holds the available notifiers by channel.
+	notifiers map[string]Notifier
+}
+
+// NewDispatcher creates a Dispatcher with a notifier for every channel the configuration makes available.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @return *Dispatcher - A pointer to the new Dispatcher.
+// @return error - An error if a configured channel could not be set up.
+func NewDispatcher(cfg *config.Config, db *sql.DB) (*Dispatcher, error) {
+	// dispatcher is the new Dispatcher.
+	dispatcher := &Dispatcher{db: db, notifiers: map[string]Notifier{}}
+
+	// The webhook channel needs no server configuration.
+	dispatcher.Register(NewWebhookNotifier())
+	// This checks if a mail server is configured.
+	if cfg.SMTP.Host != "" {
+		// If it is, the email channel is registered.
+		dispatcher.Register(NewEmailNotifier(cfg))
+	}
+	// This checks if a Telegram bot is configured.
+	if cfg.Telegram.BotToken != "" {
+		// If it is, the Telegram channel is registered.
+		dispatcher.Register(NewTelegramNotifier(db, cfg.Telegram.BotToken))
+	}
+	// This checks if a push service is configured.
+	if cfg.Push.FCMCredentialsFile != "" || cfg.Push.APNsKeyFile != "" {
+		// notifier is the push notifier.
+		notifier, err := NewPushNotifier(db, cfg.Push)
+		// This checks if an error occurred while setting up the push services.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return nil, err
+		}
+		// The push channel is registered.
+		dispatcher.Register(notifier)
+	}
+	// This checks if VAPID keys are configured.
+	if cfg.WebPush.PrivateKey != "" {
+		// notifier is the web push notifier.
+		notifier, err := NewWebPushNotifier(db, cfg.WebPush)
+		// This checks if an error occurred while reading the keys.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return nil, err
+		}
+		// The web push channel is registered.
+		dispatcher.Register(notifier)
+	}
+
+	// The dispatcher is returned.
+	return dispatcher, nil
+}
+
+// Register adds a notifier, replacing any notifier of the same channel.
+//
+// @param notifier Notifier - The notifier.
+func (d *Dispatcher) Register(notifier Notifier) {
+	// The notifier is stored by its channel.
+	d.notifiers[notifier.Channel()] = notifier
+}
+
+// Dispatch sends a message to a user through every available channel the user enabled.
+// A failure on one channel is logged and does not stop the others.
+//
+// @param ctx context.Context - The context of the caller.
+// @param recipient Recipient - The user the message is sent to.
+// @param message Message - The message.
+func (d *Dispatcher) Dispatch(ctx context.Context, recipient Recipient, message Message) {
+	// preferences is the user's stored channel preferences.
+	preferences, err := loadPreferences(ctx, d.db, recipient.UserID)
+	// This checks if an error occurred while reading the preferences.
+	if err != nil {
+		// If an error occurs, it is logged and nothing is sent.
+		log.Printf("Unable to read notification preferences of user %s: %v", recipient.UserID, err)
+		return
+	}
+
+	// This iterates over the channels in a stable order.
+	for _, channel := range Channels {
+		// notifier is the notifier of the channel.
+		notifier, ok := d.notifiers[channel]
+		// preference is the user's effective preference for the channel.
+		preference := effectivePreference(preferences, channel)
+		// This checks if the channel is unavailable or turned off.
+		if !ok || !preference.Enabled {
+			// If it is, the channel is skipped.
+			continue
+		}
+
+		// target is the recipient with the channel's destination.
+		target := recipient
+		target.Target = preference.Target
+		// This delivers the message.
+		if err := notifier.Notify(ctx, target, message); err != nil {
+			// If an error occurs, it is logged.
+			log.Printf("Unable to send %s notification to user %s: %v", channel, recipient.UserID, err)
+		}
+	}
+}
+
+// AvailableChannels reports which channels the configuration makes available.
+//
+// @param cfg *config.Config - The application configuration.
+// @return map[string]bool - The availability of every channel.
+func AvailableChannels(cfg *config.Config) map[string]bool {
+	// The availability mirrors the registrations in NewDispatcher.
+	return map[string]bool{
+		ChannelEmail:    cfg.SMTP.Host != "",
+		ChannelWebhook:  true,
+		ChannelTelegram: cfg.Telegram.BotToken != "",
+		ChannelPush:     cfg.Push.FCMCredentialsFile != "" || cfg.Push.APNsKeyFile != "",
+		ChannelWebPush:  cfg.WebPush.PrivateKey != "",
+	}
+}
+
+// loadPreferences reads a user's stored channel preferences.
+//
+// @param ctx context.Context - The context of the caller, which carries its deadline.
+// @param db *sql.DB - The database connection.
+// @param userId uuid.UUID - The ID of the user.
+// @return map[string]Preference - The stored preferences by channel.
+// @return error - An error if one occurred.
+func loadPreferences(ctx context.Context, db *sql.DB, userId uuid.UUID) (map[string]Preference, error) {
+	// rows is the result of querying the preferences.
+	rows, err := db.QueryContext(ctx, GetPreferencesQuery, userId)
+	// This checks if an error occurred while querying the database.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// This defers the closing of the rows until the function returns.
+	defer rows.Close()
+
+	// preferences is a map that will hold the preferences.
+	preferences := map[string]Preference{}
+	// This iterates over the rows.
+	for rows.Next() {
+		// preference is a new Preference struct.
+		var preference Preference
+		// This scans the row into the preference struct.
+		if err := rows.Scan(&preference.Channel, &preference.Enabled, &preference.Target); err != nil {
+			// If an error occurs, it is returned.
+			return nil, err
+		}
+		// The preference is stored by its channel.
+		preferences[preference.Channel] = preference
+	}
+
+	// The preferences and the error of the iteration, if any, are returned.
+	return preferences, rows.Err()
+}
+
+// effectivePreference returns the stored preference for a channel, or the default if the user never changed it.
+//
+// @param preferences map[string]Preference - The stored preferences.
+// @param channel string - The channel.
+// @return Preference - The effective preference.
+func effectivePreference(preferences map[string]Preference, channel string) Preference {
+	// This checks if a preference is stored.
+	if preference, ok := preferences[channel]; ok {
+		// If it is, it is returned.
+		return preference
+	}
+	// Otherwise the default is returned.
+	return Preference{Channel: channel, Enabled: defaultEnabled[channel]}
+}
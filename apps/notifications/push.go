@@ -0,0 +1,125 @@
+// This file defines the notifier that sends push notifications to mobile devices.
+package notifications
+
+// "context" provides a way to carry cancellation signals. It is used here to cancel deliveries.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to look up and prune devices.
+	"database/sql"
+	// "errors" provides functions for working with errors. It is used here to combine delivery errors.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to build errors.
+	"fmt"
+
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+// errDeviceGone is returned by a push service when a device token is no longer valid, for example after the app was uninstalled.
+var errDeviceGone = errors.New("device token is no longer valid")
+
+// PushNotifier sends notifications to every device a user registered, through FCM or APNs depending on the device.
+type PushNotifier struct {
+	// db is the database connection.
+	db *sql.DB
+	// fcm is the FCM client, or nil if FCM is not configured.
+	fcm *fcmClient
+	// apns is the APNs client, or nil if APNs is not configured.
+	apns *apnsClient
+}
+
+// NewPushNotifier creates a new PushNotifier with a client for every configured push service.
+//
+// @param db *sql.DB - The database connection.
+// @param cfg config.PushConfig - The push configuration.
+// @return *PushNotifier - A pointer to the new PushNotifier.
+// @return error - An error if a credentials file could not be read.
+func NewPushNotifier(db *sql.DB, cfg config.PushConfig) (*PushNotifier, error) {
+	// notifier is the new PushNotifier.
+	notifier := &PushNotifier{db: db}
+
+	// This checks if FCM is configured.
+	if cfg.FCMCredentialsFile != "" {
+		// client is the FCM client.
+		client, err := newFCMClient(cfg.FCMCredentialsFile)
+		// This checks if an error occurred while reading the credentials.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return nil, fmt.Errorf("fcm: %w", err)
+		}
+		// The FCM client is set.
+		notifier.fcm = client
+	}
+
+	// This checks if APNs is configured.
+	if cfg.APNsKeyFile != "" {
+		// client is the APNs client.
+		client, err := newAPNsClient(cfg)
+		// This checks if an error occurred while reading the key.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return nil, fmt.Errorf("apns: %w", err)
+		}
+		// The APNs client is set.
+		notifier.apns = client
+	}
+
+	// The notifier is returned.
+	return notifier, nil
+}
+
+// Channel returns the push channel.
+//
+// @return string - The channel.
+func (pn *PushNotifier) Channel() string {
+	// The push channel is returned.
+	return ChannelPush
+}
+
+// Notify sends the message to every device of the user. Devices the push service reports as gone are removed.
+//
+// @param ctx context.Context - The context of the caller.
+// @param recipient Recipient - The user the message is sent to.
+// @param message Message - The message.
+// @return error - The errors of the failed deliveries, if any.
+func (pn *PushNotifier) Notify(ctx context.Context, recipient Recipient, message Message) error {
+	// devices is the list of the user's devices.
+	devices, err := listDevices(ctx, pn.db, recipient.UserID)
+	// This checks if an error occurred while reading the devices.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// errs collects the errors of the failed deliveries.
+	var errs []error
+	// This iterates over the devices.
+	for _, device := range devices {
+		// err is the result of the delivery.
+		var err error
+		// This selects the push service of the device.
+		switch {
+		case device.Platform == PlatformFCM && pn.fcm != nil:
+			err = pn.fcm.send(ctx, device.Token, message)
+		case device.Platform == PlatformAPNs && pn.apns != nil:
+			err = pn.apns.send(ctx, device.Token, message)
+		default:
+			// The push service of the device is not configured.
+			continue
+		}
+
+		// This checks if the device is gone.
+		if errors.Is(err, errDeviceGone) {
+			// If it is, it is removed so that it is not tried again.
+			_, err = pn.db.ExecContext(ctx, DeleteStaleDeviceQuery, device.Token)
+		}
+		// This checks if an error occurred.
+		if err != nil {
+			// If it did, it is collected.
+			errs = append(errs, err)
+		}
+	}
+
+	// The collected errors are returned.
+	return errors.Join(errs...)
+}
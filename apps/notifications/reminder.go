@@ -0,0 +1,102 @@
+// This file defines the worker that sends due-date reminders through the notification channels.
+package notifications
+
+// "context" provides a way to carry cancellation signals. It is used here to stop the worker on shutdown.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to claim due todos.
+	"database/sql"
+	// "fmt" provides functions for formatted I/O. It is used here to build the reminder text.
+	"fmt"
+	// "log" provides a simple logging package. It is used here to log failed claims.
+	"log"
+	// "time" provides functions for working with time. It is used here to schedule the worker.
+	"time"
+
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+// reminderBatchSize is the maximum number of reminders claimed in one run.
+const reminderBatchSize = 100
+
+// StartReminderWorker sends reminders for todos that are about to become due until the context is cancelled.
+//
+// @param ctx context.Context - The context that stops the worker.
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @param dispatcher *Dispatcher - The dispatcher that delivers the reminders.
+func StartReminderWorker(ctx context.Context, cfg *config.Config, db *sql.DB, dispatcher *Dispatcher) {
+	// ticker fires once every reminder interval.
+	ticker := time.NewTicker(cfg.Reminder.Interval)
+	// This defers stopping the ticker until the worker returns.
+	defer ticker.Stop()
+
+	// This loops until the context is cancelled.
+	for {
+		// This waits for either the next tick or the cancellation.
+		select {
+		case <-ctx.Done():
+			// If the context is cancelled, the worker returns.
+			return
+		case <-ticker.C:
+			// On every tick, the due reminders are sent.
+			sendDueReminders(ctx, db, dispatcher, cfg.Reminder.Lead)
+		}
+	}
+}
+
+// sendDueReminders claims the todos that become due within the lead time and dispatches a reminder for each.
+// Reminders are claimed before they are sent, so a failed send is logged and not retried.
+//
+// @param ctx context.Context - The context of the worker.
+// @param db *sql.DB - The database connection.
+// @param dispatcher *Dispatcher - The dispatcher that delivers the reminders.
+// @param lead time.Duration - How long before the due date a reminder is sent.
+func sendDueReminders(ctx context.Context, db *sql.DB, dispatcher *Dispatcher, lead time.Duration) {
+	// rows is the result of claiming the due todos.
+	rows, err := db.QueryContext(ctx, ClaimDueRemindersQuery, time.Now().Add(lead), reminderBatchSize)
+	// This checks if an error occurred while claiming the todos.
+	if err != nil {
+		// If an error occurs, it is logged and the run is skipped.
+		log.Printf("Unable to claim due reminders: %v", err)
+		return
+	}
+
+	// reminders is a slice that will hold the claimed reminders.
+	var reminders []dueReminder
+	// This iterates over the rows.
+	for rows.Next() {
+		// reminder is a new dueReminder struct.
+		var reminder dueReminder
+		// This scans the row into the reminder struct.
+		if err := rows.Scan(&reminder.TodoID, &reminder.Title, &reminder.DueAt, &reminder.UserID, &reminder.Name, &reminder.Email, &reminder.Timezone); err != nil {
+			// If an error occurs, it is logged and the row is skipped.
+			log.Printf("Unable to read due reminder: %v", err)
+			continue
+		}
+		// The reminder is appended to the reminders slice.
+		reminders = append(reminders, reminder)
+	}
+	// The rows are closed before any message is sent so that the connection is released.
+	rows.Close()
+
+	// This iterates over the claimed reminders.
+	for _, reminder := range reminders {
+		// location is the time zone of the todo's owner.
+		location := users.User{Timezone: reminder.Timezone}.Location()
+		// This dispatches the reminder to the owner's channels.
+		dispatcher.Dispatch(ctx, Recipient{
+			UserID: reminder.UserID,
+			Name:   reminder.Name,
+			Email:  reminder.Email,
+		}, Message{
+			Subject: "Reminder: " + reminder.Title,
+			Text:    fmt.Sprintf("Reminder: %s is due %s", reminder.Title, reminder.DueAt.In(location).Format("Mon 2 Jan 15:04")),
+			TodoID:  reminder.TodoID,
+			DueAt:   reminder.DueAt,
+		})
+	}
+}
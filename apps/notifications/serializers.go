@@ -0,0 +1,178 @@
+// This file defines the serializers for notification-related requests and responses.
+package notifications
+
+// "time" provides functions for working with time. It is used here to define time fields.
+import (
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to define ID fields.
+	"github.com/google/uuid"
+)
+
+// PreferenceRequest defines the structure for a request to change a channel preference.
+type PreferenceRequest struct {
+	// Channel is the channel to change.
+	// json:"channel" specifies that this field should be marshalled to/from a JSON object with the key "channel".
+	Channel string `json:"channel"`
+	// Enabled reports whether notifications are sent through the channel.
+	// json:"enabled" specifies that this field should be marshalled to/from a JSON object with the key "enabled".
+	Enabled bool `json:"enabled"`
+	// Target is the channel-specific destination, such as the webhook URL.
+	// json:"target" specifies that this field should be marshalled to/from a JSON object with the key "target".
+	Target string `json:"target"`
+}
+
+// UpdatePreferencesRequest defines the structure for a request to change several channel preferences at once.
+type UpdatePreferencesRequest struct {
+	// Preferences is the list of preferences to change. Channels that are not listed keep their setting.
+	// json:"preferences" specifies that this field should be marshalled to/from a JSON object with the key "preferences".
+	Preferences []PreferenceRequest `json:"preferences"`
+}
+
+// PreferenceResponse defines the structure for a channel preference response.
+type PreferenceResponse struct {
+	// Channel is the channel.
+	// json:"channel" specifies that this field should be marshalled to/from a JSON object with the key "channel".
+	Channel string `json:"channel"`
+	// Available reports whether the server is configured to send through the channel.
+	// json:"available" specifies that this field should be marshalled to/from a JSON object with the key "available".
+	Available bool `json:"available"`
+	// Enabled reports whether the user turned the channel on.
+	// json:"enabled" specifies that this field should be marshalled to/from a JSON object with the key "enabled".
+	Enabled bool `json:"enabled"`
+	// Target is the channel-specific destination, such as the webhook URL.
+	// json:"target" specifies that this field should be marshalled to/from a JSON object with the key "target".
+	Target string `json:"target"`
+}
+
+// RegisterDeviceRequest defines the structure for a request to register a push device.
+type RegisterDeviceRequest struct {
+	// Platform is the push service of the device, "fcm" or "apns".
+	// json:"platform" specifies that this field should be marshalled to/from a JSON object with the key "platform".
+	Platform string `json:"platform"`
+	// Token is the FCM registration token or APNs device token.
+	// json:"token" specifies that this field should be marshalled to/from a JSON object with the key "token".
+	Token string `json:"token"`
+}
+
+// DeviceResponse defines the structure for a push device response.
+type DeviceResponse struct {
+	// Platform is the push service of the device.
+	// json:"platform" specifies that this field should be marshalled to/from a JSON object with the key "platform".
+	Platform string `json:"platform"`
+	// Token is the device token.
+	// json:"token" specifies that this field should be marshalled to/from a JSON object with the key "token".
+	Token string `json:"token"`
+	// CreatedAt is the time the device was registered.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt string `json:"created_at"`
+}
+
+// WebhookPayload defines the structure of the JSON posted to a user's webhook URL.
+type WebhookPayload struct {
+	// Type is the kind of notification.
+	// json:"type" specifies that this field should be marshalled to/from a JSON object with the key "type".
+	Type string `json:"type"`
+	// TodoID is the todo the notification is about.
+	// json:"todo_id" specifies that this field should be marshalled to/from a JSON object with the key "todo_id".
+	TodoID uuid.UUID `json:"todo_id"`
+	// Subject is a short summary of the notification.
+	// json:"subject" specifies that this field should be marshalled to/from a JSON object with the key "subject".
+	Subject string `json:"subject"`
+	// Text is the full text of the notification.
+	// json:"text" specifies that this field should be marshalled to/from a JSON object with the key "text".
+	Text string `json:"text"`
+	// DueAt is the due date of the todo.
+	// json:"due_at" specifies that this field should be marshalled to/from a JSON object with the key "due_at".
+	DueAt time.Time `json:"due_at"`
+}
+
+// WebPushKeyRequest defines the keys of a browser subscription, as returned by PushSubscription.toJSON().
+type WebPushKeyRequest struct {
+	// P256dh is the browser's public key.
+	// json:"p256dh" specifies that this field should be marshalled to/from a JSON object with the key "p256dh".
+	P256dh string `json:"p256dh"`
+	// Auth is the browser's authentication secret.
+	// json:"auth" specifies that this field should be marshalled to/from a JSON object with the key "auth".
+	Auth string `json:"auth"`
+}
+
+// WebPushSubscriptionRequest defines the structure for a request to subscribe or unsubscribe a browser.
+// The subscription object of the browser can be sent as is.
+type WebPushSubscriptionRequest struct {
+	// Endpoint is the push service URL of the subscription.
+	// json:"endpoint" specifies that this field should be marshalled to/from a JSON object with the key "endpoint".
+	Endpoint string `json:"endpoint"`
+	// Keys holds the keys of the subscription. They are not needed to unsubscribe.
+	// json:"keys" specifies that this field should be marshalled to/from a JSON object with the key "keys".
+	Keys WebPushKeyRequest `json:"keys"`
+	// ExpirationTime is the expiry that browsers include when serializing a subscription. It is accepted so that strict JSON decoding does not reject it, but not stored.
+	// json:"expirationTime" specifies that this field should be marshalled to/from a JSON object with the key "expirationTime".
+	ExpirationTime *int64 `json:"expirationTime"`
+}
+
+// WebPushSubscriptionResponse defines the structure for a browser subscription response.
+type WebPushSubscriptionResponse struct {
+	// Endpoint is the push service URL of the subscription.
+	// json:"endpoint" specifies that this field should be marshalled to/from a JSON object with the key "endpoint".
+	Endpoint string `json:"endpoint"`
+	// CreatedAt is the time the subscription was registered.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt string `json:"created_at"`
+}
+
+// WebPushKeyResponse defines the structure for the VAPID public key response.
+type WebPushKeyResponse struct {
+	// PublicKey is the VAPID public key, passed as applicationServerKey when the browser subscribes.
+	// json:"public_key" specifies that this field should be marshalled to/from a JSON object with the key "public_key".
+	PublicKey string `json:"public_key"`
+}
+
+// WebPushPayload defines the structure of the JSON delivered to the service worker of a subscribed browser.
+type WebPushPayload struct {
+	// Title is the title of the notification.
+	// json:"title" specifies that this field should be marshalled to/from a JSON object with the key "title".
+	Title string `json:"title"`
+	// Body is the text of the notification.
+	// json:"body" specifies that this field should be marshalled to/from a JSON object with the key "body".
+	Body string `json:"body"`
+	// TodoID is the todo the notification is about.
+	// json:"todo_id" specifies that this field should be marshalled to/from a JSON object with the key "todo_id".
+	TodoID uuid.UUID `json:"todo_id"`
+}
+
+// DigestRequest defines the structure for a request to change the weekly email digest.
+type DigestRequest struct {
+	// Enabled reports whether the digest is sent.
+	// json:"enabled" specifies that this field should be marshalled to/from a JSON object with the key "enabled".
+	Enabled bool `json:"enabled"`
+	// Day is the day of the week the digest is sent on, such as "monday". It defaults to Monday.
+	// json:"day" specifies that this field should be marshalled to/from a JSON object with the key "day".
+	Day string `json:"day"`
+}
+
+// DigestResponse defines the structure for a weekly email digest response.
+type DigestResponse struct {
+	// Available reports whether the server is configured to send email.
+	// json:"available" specifies that this field should be marshalled to/from a JSON object with the key "available".
+	Available bool `json:"available"`
+	// Enabled reports whether the user turned the digest on.
+	// json:"enabled" specifies that this field should be marshalled to/from a JSON object with the key "enabled".
+	Enabled bool `json:"enabled"`
+	// Day is the day of the week the digest is sent on.
+	// json:"day" specifies that this field should be marshalled to/from a JSON object with the key "day".
+	Day string `json:"day"`
+	// Hour is the hour of the day, in the user's time zone, from which the digest is sent.
+	// json:"hour" specifies that this field should be marshalled to/from a JSON object with the key "hour".
+	Hour int `json:"hour"`
+	// LastSentAt is the time the last digest was sent, or null if none was.
+	// json:"last_sent_at" specifies that this field should be marshalled to/from a JSON object with the key "last_sent_at".
+	LastSentAt *string `json:"last_sent_at"`
+}
+
+// UnsubscribeResponse defines the structure for the response of an unsubscribe link.
+type UnsubscribeResponse struct {
+	// Topic is the emails the user unsubscribed from, "reminders" or "digest".
+	// json:"topic" specifies that this field should be marshalled to/from a JSON object with the key "topic".
+	Topic string `json:"topic"`
+}
@@ -0,0 +1,83 @@
+// This file defines the SQL queries used for notifications.
+package notifications
+
+// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names and schemas.
+import "github.com/rahulcodepython/todo-backend/backend/utils"
+
+// GetPreferencesQuery is the SQL query to retrieve a user's channel preferences.
+const GetPreferencesQuery = "SELECT channel, enabled, target FROM " + utils.NotificationChannelTableName + " WHERE user_id = $1"
+
+// UpsertPreferenceQuery is the SQL query to store a user's preference for a channel.
+const UpsertPreferenceQuery = "INSERT INTO " + utils.NotificationChannelTableName + " (user_id, channel, enabled, target) VALUES ($1, $2, $3, $4) ON CONFLICT (user_id, channel) DO UPDATE SET enabled = EXCLUDED.enabled, target = EXCLUDED.target, updated_at = NOW()"
+
+// UpsertDeviceQuery is the SQL query to register a push device, moving it to the user if another user registered it before.
+const UpsertDeviceQuery = "INSERT INTO " + utils.PushDeviceTableName + " (token, user_id, platform) VALUES ($1, $2, $3) ON CONFLICT (token) DO UPDATE SET user_id = EXCLUDED.user_id, platform = EXCLUDED.platform, created_at = NOW() RETURNING created_at"
+
+// GetDevicesQuery is the SQL query to retrieve a user's push devices.
+const GetDevicesQuery = "SELECT token, platform, created_at FROM " + utils.PushDeviceTableName + " WHERE user_id = $1 ORDER BY created_at"
+
+// DeleteDeviceQuery is the SQL query to remove one of a user's push devices.
+const DeleteDeviceQuery = "DELETE FROM " + utils.PushDeviceTableName + " WHERE token = $1 AND user_id = $2"
+
+// DeleteStaleDeviceQuery is the SQL query to remove a device token that the push service reported as no longer valid.
+const DeleteStaleDeviceQuery = "DELETE FROM " + utils.PushDeviceTableName + " WHERE token = $1"
+
+// UpsertWebPushSubscriptionQuery is the SQL query to register a browser subscription, moving it to the user if another user registered it before.
+const UpsertWebPushSubscriptionQuery = "INSERT INTO " + utils.WebPushSubscriptionTableName + " (endpoint, user_id, p256dh, auth) VALUES ($1, $2, $3, $4) ON CONFLICT (endpoint) DO UPDATE SET user_id = EXCLUDED.user_id, p256dh = EXCLUDED.p256dh, auth = EXCLUDED.auth, created_at = NOW() RETURNING created_at"
+
+// GetWebPushSubscriptionsQuery is the SQL query to retrieve a user's browser subscriptions.
+const GetWebPushSubscriptionsQuery = "SELECT endpoint, p256dh, auth, created_at FROM " + utils.WebPushSubscriptionTableName + " WHERE user_id = $1 ORDER BY created_at"
+
+// DeleteWebPushSubscriptionQuery is the SQL query to remove one of a user's browser subscriptions.
+const DeleteWebPushSubscriptionQuery = "DELETE FROM " + utils.WebPushSubscriptionTableName + " WHERE endpoint = $1 AND user_id = $2"
+
+// DeleteStaleWebPushSubscriptionQuery is the SQL query to remove a subscription that the push service reported as expired.
+const DeleteStaleWebPushSubscriptionQuery = "DELETE FROM " + utils.WebPushSubscriptionTableName + " WHERE endpoint = $1"
+
+// GetTelegramChatQuery is the SQL query to retrieve the Telegram chat linked to a user.
+const GetTelegramChatQuery = "SELECT chat_id FROM " + utils.TelegramLinkTableName + " WHERE user_id = $1 AND chat_id IS NOT NULL"
+
+// ClaimDueRemindersQuery is the SQL query to mark todos that are due before $1, and not snoozed past it, as reminded and return them with their owners.
+// Rows locked by a concurrent worker are skipped so that every reminder is claimed once.
+const ClaimDueRemindersQuery = `UPDATE ` + utils.TodoTableName + ` AS t SET reminded_at = NOW() FROM ` + utils.UserTableName + ` AS u
+	WHERE u.id = t.owner AND t.id IN (
+		SELECT id FROM ` + utils.TodoTableName + ` WHERE due_at <= $1 AND reminded_at IS NULL AND (snoozed_until IS NULL OR snoozed_until <= $1) AND completed = false AND deleted_at IS NULL
+		ORDER BY due_at LIMIT $2 FOR UPDATE SKIP LOCKED
+	) RETURNING t.id, t.title, t.due_at, u.id, u.name, u.email, u.timezone`
+
+// GetDigestSubscriptionQuery is the SQL query to retrieve a user's weekly digest settings.
+const GetDigestSubscriptionQuery = "SELECT enabled, day, last_sent_at FROM " + utils.DigestSubscriptionTableName + " WHERE user_id = $1"
+
+// UpsertDigestSubscriptionQuery is the SQL query to store a user's weekly digest settings. The time of the last digest is kept.
+const UpsertDigestSubscriptionQuery = "INSERT INTO " + utils.DigestSubscriptionTableName + " (user_id, enabled, day) VALUES ($1, $2, $3) ON CONFLICT (user_id) DO UPDATE SET enabled = EXCLUDED.enabled, day = EXCLUDED.day, updated_at = NOW() RETURNING last_sent_at"
+
+// ClaimDueDigestsQuery is the SQL query to mark the digests that are due at $1 as sent and return their users.
+// A digest is due on its day of the week from hour $2 in the user's time zone, unless one was sent in the last six days.
+// Rows locked by a concurrent worker are skipped so that every digest is claimed once.
+const ClaimDueDigestsQuery = `UPDATE ` + utils.DigestSubscriptionTableName + ` AS d SET last_sent_at = $1 FROM ` + utils.UserTableName + ` AS u
+	WHERE u.id = d.user_id AND d.user_id IN (
+		SELECT s.user_id FROM ` + utils.DigestSubscriptionTableName + ` AS s JOIN ` + utils.UserTableName + ` AS w ON w.id = s.user_id
+		WHERE s.enabled AND w.email <> '' AND EXTRACT(DOW FROM $1 AT TIME ZONE w.timezone) = s.day AND EXTRACT(HOUR FROM $1 AT TIME ZONE w.timezone) >= $2
+		AND (s.last_sent_at IS NULL OR s.last_sent_at <= $1 - INTERVAL '6 days')
+		LIMIT $3 FOR UPDATE OF s SKIP LOCKED
+	) RETURNING u.id, u.name, u.email, u.timezone`
+
+// digestTodos is the condition of the todos of user $1 that a digest lists, leaving out the todos of archived lists like the default views.
+const digestTodos = "owner = $1 AND deleted_at IS NULL AND (list_id IS NULL OR NOT EXISTS (SELECT 1 FROM " + utils.ListTableName + " AS l WHERE l.id = list_id AND l.archived_at IS NOT NULL))"
+
+// GetDigestOverdueQuery is the SQL query to retrieve up to $3 open todos of user $1 that were due before $2, oldest first.
+const GetDigestOverdueQuery = "SELECT title, due_at FROM " + utils.TodoTableName + " WHERE " + digestTodos + " AND completed = false AND due_at < $2 ORDER BY due_at LIMIT $3"
+
+// GetDigestDueSoonQuery is the SQL query to retrieve up to $4 open todos of user $1 that are due from $2 until $3, soonest first.
+const GetDigestDueSoonQuery = "SELECT title, due_at FROM " + utils.TodoTableName + " WHERE " + digestTodos + " AND completed = false AND due_at >= $2 AND due_at < $3 ORDER BY due_at LIMIT $4"
+
+// GetDigestCompletedQuery is the SQL query to retrieve up to $3 todos of user $1 that were completed since $2, latest first.
+// The completion time is the last change of the todo, which is when it was completed unless it was edited after.
+const GetDigestCompletedQuery = "SELECT title, due_at FROM " + utils.TodoTableName + " WHERE " + digestTodos + " AND completed = true AND updated_at >= $2 ORDER BY updated_at DESC LIMIT $3"
+
+// DisablePreferenceQuery is the SQL query to turn a channel of user $1 off, keeping its target.
+// It selects from the users table so that nothing is stored for a user who was deleted.
+const DisablePreferenceQuery = "INSERT INTO " + utils.NotificationChannelTableName + " (user_id, channel, enabled, target) SELECT id, $2, false, '' FROM " + utils.UserTableName + " WHERE id = $1 ON CONFLICT (user_id, channel) DO UPDATE SET enabled = false, updated_at = NOW()"
+
+// DisableDigestQuery is the SQL query to turn the weekly digest of user $1 off.
+const DisableDigestQuery = "UPDATE " + utils.DigestSubscriptionTableName + " SET enabled = false, updated_at = NOW() WHERE user_id = $1"
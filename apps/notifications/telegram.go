@@ -0,0 +1,64 @@
+// This file defines the notifier that sends notifications to a linked Telegram chat.
+package notifications
+
+// "context" provides a way to carry cancellation signals. It is used here to cancel the lookup.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to look up the linked chat.
+	"database/sql"
+
+	// "github.com/rahulcodepython/todo-backend/apps/telegram" is a local package that contains the Telegram client.
+	"github.com/rahulcodepython/todo-backend/apps/telegram"
+)
+
+// TelegramNotifier sends notifications to the Telegram chat a user linked.
+type TelegramNotifier struct {
+	// db is the database connection.
+	db *sql.DB
+	// client is the Telegram client.
+	client *telegram.Client
+}
+
+// NewTelegramNotifier creates a new TelegramNotifier.
+//
+// @param db *sql.DB - The database connection.
+// @param token string - The bot token.
+// @return *TelegramNotifier - A pointer to the new TelegramNotifier.
+func NewTelegramNotifier(db *sql.DB, token string) *TelegramNotifier {
+	// A new TelegramNotifier is returned.
+	return &TelegramNotifier{db: db, client: telegram.NewClient(token)}
+}
+
+// Channel returns the Telegram channel.
+//
+// @return string - The channel.
+func (tn *TelegramNotifier) Channel() string {
+	// The Telegram channel is returned.
+	return ChannelTelegram
+}
+
+// Notify sends the message to the user's linked chat.
+//
+// @param ctx context.Context - The context of the caller.
+// @param recipient Recipient - The user the message is sent to.
+// @param message Message - The message.
+// @return error - An error if one occurred.
+func (tn *TelegramNotifier) Notify(ctx context.Context, recipient Recipient, message Message) error {
+	// chatId is the chat linked to the user.
+	var chatId int64
+	// err is the result of looking up the chat.
+	err := tn.db.QueryRowContext(ctx, GetTelegramChatQuery, recipient.UserID).Scan(&chatId)
+	// This checks if the user has not linked a chat.
+	if err == sql.ErrNoRows {
+		// If the user has not, nothing is sent.
+		return nil
+	}
+	// This checks if another error occurred.
+	if err != nil {
+		// If it did, it is returned.
+		return err
+	}
+
+	// The message is sent.
+	return tn.client.SendMessage(chatId, message.Text)
+}
@@ -0,0 +1,78 @@
+// This file defines the signed links that unsubscribe a user from an email without a login.
+package notifications
+
+// "fmt" provides functions for formatted I/O. It is used here to build the links.
+import (
+	"fmt"
+	// "net/url" provides functions for working with URLs. It is used here to escape the token.
+	"net/url"
+	// "strings" provides functions for working with strings. It is used here to split the token.
+	"strings"
+	// "time" provides functions for working with time. It is used here to expire the links.
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to identify users.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+	// "github.com/rahulcodepython/todo-backend/backend/utils/signing" is a local package that signs URLs.
+	"github.com/rahulcodepython/todo-backend/backend/utils/signing"
+)
+
+const (
+	// TopicReminders is the topic of the reminder emails, which turns off the email channel.
+	TopicReminders = "reminders"
+	// TopicDigest is the topic of the weekly digest, which turns the digest off.
+	TopicDigest = "digest"
+	// unsubscribeLinkTTL is how long an unsubscribe link works, so that a link in an old email still works for a while.
+	unsubscribeLinkTTL = 90 * 24 * time.Hour
+)
+
+// unsubscribePath is the path of the unsubscribe endpoint, which is the part of the link the signature covers.
+var unsubscribePath = fmt.Sprintf("/api/%s/notifications/unsubscribe", utils.APIVersion)
+
+// unsubscribeLink returns the signed link that unsubscribes a user from a topic.
+// The token names the topic and the user, and the signature keeps it from being changed to another user.
+//
+// @param signer *signing.Signer - The signer of the link.
+// @param publicURL string - The address clients reach the server at.
+// @param topic string - The topic, TopicReminders or TopicDigest.
+// @param userId uuid.UUID - The ID of the user.
+// @return string - The link.
+// @return error - An error if one occurred.
+func unsubscribeLink(signer *signing.Signer, publicURL string, topic string, userId uuid.UUID) (string, error) {
+	// link is the signed path of the link. The path is signed without the public URL, since the server sees requests without it.
+	link, err := signer.Sign(unsubscribePath+"?token="+url.QueryEscape(topic+"."+userId.String()), unsubscribeLinkTTL)
+	// This checks if an error occurred while signing the link.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+	// The link is returned with the public URL.
+	return publicURL + link, nil
+}
+
+// parseUnsubscribeToken reads the topic and the user of the token of an unsubscribe link.
+//
+// @param token string - The token.
+// @return string - The topic.
+// @return uuid.UUID - The ID of the user.
+// @return bool - Whether the token is valid.
+func parseUnsubscribeToken(token string) (string, uuid.UUID, bool) {
+	// topic and rest are the parts of the token.
+	topic, rest, found := strings.Cut(token, ".")
+	// This checks if the token has no separator or an unknown topic.
+	if !found || (topic != TopicReminders && topic != TopicDigest) {
+		// If so, the token is invalid.
+		return "", uuid.Nil, false
+	}
+	// userId is the parsed ID of the user.
+	userId, err := uuid.Parse(rest)
+	// This checks if the ID is invalid.
+	if err != nil {
+		// If it is, the token is invalid.
+		return "", uuid.Nil, false
+	}
+	// The topic and the user are returned.
+	return topic, userId, true
+}
@@ -0,0 +1,90 @@
+// This file defines the notifier that posts notifications to a URL chosen by the user.
+package notifications
+
+// "bytes" provides functions for working with byte slices. It is used here to build the request body.
+import (
+	"bytes"
+	// "context" provides a way to carry cancellation signals. It is used here to cancel the request.
+	"context"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to encode the payload.
+	"encoding/json"
+	// "fmt" provides functions for formatted I/O. It is used here to build errors.
+	"fmt"
+	// "net/http" provides HTTP client and server implementations. It is used here to call the webhook.
+	"net/http"
+	// "time" provides functions for working with time. It is used here to set the request timeout.
+	"time"
+)
+
+// WebhookNotifier posts notifications as JSON to the URL the user set as the channel target.
+type WebhookNotifier struct {
+	// http is the HTTP client used for the calls.
+	http *http.Client
+}
+
+// NewWebhookNotifier creates a new WebhookNotifier.
+//
+// @return *WebhookNotifier - A pointer to the new WebhookNotifier.
+func NewWebhookNotifier() *WebhookNotifier {
+	// A new WebhookNotifier is returned with a timeout so that a slow receiver does not block the worker.
+	return &WebhookNotifier{http: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Channel returns the webhook channel.
+//
+// @return string - The channel.
+func (wn *WebhookNotifier) Channel() string {
+	// The webhook channel is returned.
+	return ChannelWebhook
+}
+
+// Notify posts the message to the user's webhook URL.
+//
+// @param ctx context.Context - The context of the caller.
+// @param recipient Recipient - The user the message is sent to.
+// @param message Message - The message.
+// @return error - An error if one occurred.
+func (wn *WebhookNotifier) Notify(ctx context.Context, recipient Recipient, message Message) error {
+	// This checks if the user has no webhook URL.
+	if recipient.Target == "" {
+		// If the user has none, nothing is sent.
+		return nil
+	}
+
+	// body is the encoded payload.
+	body, err := json.Marshal(WebhookPayload{Type: "reminder", TodoID: message.TodoID, Subject: message.Subject, Text: message.Text, DueAt: message.DueAt})
+	// This checks if an error occurred while encoding the payload.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// req is the webhook request.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, recipient.Target, bytes.NewReader(body))
+	// This checks if an error occurred while building the request.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// The content type is set.
+	req.Header.Set("Content-Type", "application/json")
+
+	// res is the response of the receiver.
+	res, err := wn.http.Do(req)
+	// This checks if an error occurred while calling the webhook.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// This defers the closing of the response body until the function returns.
+	defer res.Body.Close()
+
+	// This checks if the receiver rejected the notification.
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		// If it did, an error with the status is returned.
+		return fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+
+	// No error is returned.
+	return nil
+}
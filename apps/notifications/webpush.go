@@ -0,0 +1,456 @@
+// This file defines the notifier that sends notifications to browsers through the Web Push protocol.
+// Messages are encrypted for the browser as described in RFC 8291 and authenticated with VAPID as described in RFC 8292.
+package notifications
+
+// "bytes" provides functions for working with byte slices. It is used here to build the request body.
+import (
+	"bytes"
+	// "context" provides a way to carry cancellation signals. It is used here to cancel deliveries.
+	"context"
+	// "crypto/aes" implements AES. It is used here to encrypt the messages.
+	"crypto/aes"
+	// "crypto/cipher" implements block cipher modes. It is used here to encrypt the messages with AES-GCM.
+	"crypto/cipher"
+	// "crypto/ecdh" implements Elliptic Curve Diffie-Hellman. It is used here to agree on the message key with the browser.
+	"crypto/ecdh"
+	// "crypto/ecdsa" implements ECDSA. It is used here to hold the VAPID key.
+	"crypto/ecdsa"
+	// "crypto/elliptic" implements standard elliptic curves. It is used here to parse the VAPID key.
+	"crypto/elliptic"
+	// "crypto/hkdf" implements HKDF. It is used here to derive the message key.
+	"crypto/hkdf"
+	// "crypto/rand" provides a cryptographically secure random number generator. It is used here to generate salts and keys.
+	"crypto/rand"
+	// "crypto/sha256" implements SHA-256. It is used here as the HKDF hash.
+	"crypto/sha256"
+	// "database/sql" provides a generic SQL interface. It is used here to look up and prune subscriptions.
+	"database/sql"
+	// "encoding/base64" provides base64 encoding. It is used here to decode keys.
+	"encoding/base64"
+	// "encoding/binary" provides functions for encoding numbers. It is used here to write the record size.
+	"encoding/binary"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to encode the payload.
+	"encoding/json"
+	// "errors" provides functions for working with errors. It is used here to create and combine errors.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to build errors.
+	"fmt"
+	// "net/http" provides HTTP client and server implementations. It is used here to call the push services.
+	"net/http"
+	// "net/url" provides functions for working with URLs. It is used here to derive the VAPID audience.
+	"net/url"
+	// "strconv" provides functions for converting strings. It is used here to set the TTL header.
+	"strconv"
+	// "strings" provides functions for working with strings. It is used here to strip base64 padding.
+	"strings"
+	// "time" provides functions for working with time. It is used here to set the token expiry and the TTL.
+	"time"
+
+	// "github.com/golang-jwt/jwt/v5" is a package for working with JWTs. It is used here to sign the VAPID token.
+	"github.com/golang-jwt/jwt/v5"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to identify users.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+// webPushRecordSize is the record size written in the message header. A message is sent as a single record.
+const webPushRecordSize = 4096
+
+// WebPushNotifier sends notifications to every browser a user subscribed.
+type WebPushNotifier struct {
+	// db is the database connection.
+	db *sql.DB
+	// key is the VAPID private key.
+	key *ecdsa.PrivateKey
+	// publicKey is the VAPID public key in unpadded base64url, as sent in the Authorization header.
+	publicKey string
+	// subject is the contact of the operator.
+	subject string
+	// http is the HTTP client used for the calls.
+	http *http.Client
+}
+
+// NewWebPushNotifier creates a new WebPushNotifier from the VAPID keys.
+//
+// @param db *sql.DB - The database connection.
+// @param cfg config.WebPushConfig - The web push configuration.
+// @return *WebPushNotifier - A pointer to the new WebPushNotifier.
+// @return error - An error if the keys are invalid or do not belong together.
+func NewWebPushNotifier(db *sql.DB, cfg config.WebPushConfig) (*WebPushNotifier, error) {
+	// raw is the decoded private key.
+	raw, err := decodeBase64URL(cfg.PrivateKey)
+	// This checks if an error occurred while decoding the key.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, fmt.Errorf("vapid private key: %w", err)
+	}
+
+	// key is the parsed private key.
+	key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), raw)
+	// This checks if an error occurred while parsing the key.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, fmt.Errorf("vapid private key: %w", err)
+	}
+
+	// public is the public key of the private key.
+	public, err := key.PublicKey.ECDH()
+	// This checks if an error occurred while deriving the public key.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, fmt.Errorf("vapid private key: %w", err)
+	}
+	// publicKey is the encoded public key.
+	publicKey := base64.RawURLEncoding.EncodeToString(public.Bytes())
+	// This checks if the configured public key is missing or does not belong to the private key.
+	// Browsers subscribe with the configured key, so a mismatch would make the push services reject every message.
+	if strings.TrimRight(cfg.PublicKey, "=") != publicKey {
+		// If it does not, an error is returned.
+		return nil, errors.New("vapid public key is missing or does not match the private key")
+	}
+
+	// A new WebPushNotifier is returned.
+	return &WebPushNotifier{
+		db:        db,
+		key:       key,
+		publicKey: publicKey,
+		subject:   cfg.Subject,
+		http:      &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Channel returns the web push channel.
+//
+// @return string - The channel.
+func (wn *WebPushNotifier) Channel() string {
+	// The web push channel is returned.
+	return ChannelWebPush
+}
+
+// Notify sends the message to every browser of the user. Subscriptions the push service reports as expired are removed.
+//
+// @param ctx context.Context - The context of the caller.
+// @param recipient Recipient - The user the message is sent to.
+// @param message Message - The message.
+// @return error - The errors of the failed deliveries, if any.
+func (wn *WebPushNotifier) Notify(ctx context.Context, recipient Recipient, message Message) error {
+	// subscriptions is the list of the user's subscriptions.
+	subscriptions, err := listWebPushSubscriptions(ctx, wn.db, recipient.UserID)
+	// This checks if an error occurred while reading the subscriptions.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// This checks if the user has no subscriptions.
+	if len(subscriptions) == 0 {
+		// If the user has none, nothing is sent.
+		return nil
+	}
+
+	// payload is the encoded message shown by the service worker.
+	payload, err := json.Marshal(WebPushPayload{Title: message.Subject, Body: message.Text, TodoID: message.TodoID})
+	// This checks if an error occurred while encoding the payload.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// ttl is how long the push service keeps the message for an offline browser. A reminder is useless once the todo is due.
+	ttl := max(int(time.Until(message.DueAt).Seconds()), 60)
+
+	// errs collects the errors of the failed deliveries.
+	var errs []error
+	// This iterates over the subscriptions.
+	for _, subscription := range subscriptions {
+		// err is the result of the delivery.
+		err := wn.send(ctx, subscription, payload, ttl)
+		// This checks if the subscription is gone.
+		if errors.Is(err, errDeviceGone) {
+			// If it is, it is removed so that it is not tried again.
+			_, err = wn.db.ExecContext(ctx, DeleteStaleWebPushSubscriptionQuery, subscription.Endpoint)
+		}
+		// This checks if an error occurred.
+		if err != nil {
+			// If it did, it is collected.
+			errs = append(errs, err)
+		}
+	}
+
+	// The collected errors are returned.
+	return errors.Join(errs...)
+}
+
+// send encrypts a payload for one subscription and posts it to the subscription's push service.
+//
+// @param ctx context.Context - The context of the caller.
+// @param subscription WebPushSubscription - The subscription.
+// @param payload []byte - The plaintext payload.
+// @param ttl int - The time to live of the message in seconds.
+// @return error - errDeviceGone if the subscription expired, or another error if one occurred.
+func (wn *WebPushNotifier) send(ctx context.Context, subscription WebPushSubscription, payload []byte, ttl int) error {
+	// browserKey and authSecret are the decoded keys of the subscription.
+	browserKey, authSecret, err := parseSubscriptionKeys(subscription.P256dh, subscription.Auth)
+	// This checks if the stored keys are invalid.
+	if err != nil {
+		// If they are, the subscription cannot be used and is treated as gone.
+		return errDeviceGone
+	}
+
+	// body is the encrypted message.
+	body, err := encryptWebPush(browserKey, authSecret, payload)
+	// This checks if an error occurred while encrypting the message.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// authorization is the VAPID Authorization header value.
+	authorization, err := wn.authorization(subscription.Endpoint)
+	// This checks if an error occurred while signing the token.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// req is the push request.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	// This checks if an error occurred while building the request.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// The headers are set.
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(ttl))
+	req.Header.Set("Urgency", "high")
+
+	// res is the response of the push service.
+	res, err := wn.http.Do(req)
+	// This checks if an error occurred while calling the push service.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// This defers the closing of the response body until the function returns.
+	defer res.Body.Close()
+
+	// This checks the status of the response.
+	switch {
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone:
+		// The subscription expired or was removed by the user.
+		return errDeviceGone
+	case res.StatusCode < 200 || res.StatusCode > 299:
+		// Any other failure is returned as an error.
+		return fmt.Errorf("web push failed with status %d", res.StatusCode)
+	}
+	// No error is returned.
+	return nil
+}
+
+// authorization signs a VAPID token for the origin of an endpoint and returns the Authorization header value.
+//
+// @param endpoint string - The push service URL.
+// @return string - The header value.
+// @return error - An error if one occurred.
+func (wn *WebPushNotifier) authorization(endpoint string) (string, error) {
+	// parsed is the parsed endpoint.
+	parsed, err := url.Parse(endpoint)
+	// This checks if an error occurred while parsing the endpoint.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+
+	// token is the signed VAPID token. Its audience is the origin of the push service.
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": parsed.Scheme + "://" + parsed.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": wn.subject,
+	}).SignedString(wn.key)
+	// This checks if an error occurred while signing the token.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+
+	// The header value is returned.
+	return fmt.Sprintf("vapid t=%s, k=%s", token, wn.publicKey), nil
+}
+
+// encryptWebPush encrypts a payload for a browser with the aes128gcm content encoding of RFC 8291.
+//
+// @param browserKey *ecdh.PublicKey - The browser's public key.
+// @param authSecret []byte - The browser's authentication secret.
+// @param payload []byte - The plaintext payload.
+// @return []byte - The encrypted message, including the header.
+// @return error - An error if one occurred.
+func encryptWebPush(browserKey *ecdh.PublicKey, authSecret []byte, payload []byte) ([]byte, error) {
+	// serverKey is a new key pair used for this message only.
+	serverKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	// This checks if an error occurred while generating the key.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+
+	// shared is the shared secret of the two key pairs.
+	shared, err := serverKey.ECDH(browserKey)
+	// This checks if an error occurred while agreeing on the secret.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+
+	// serverPublic is the encoded public key of the server, which the browser needs to derive the same secret.
+	serverPublic := serverKey.PublicKey().Bytes()
+	// keyInfo binds the secret to both public keys.
+	keyInfo := "WebPush: info\x00" + string(browserKey.Bytes()) + string(serverPublic)
+	// ikm is the input keying material mixed with the authentication secret.
+	ikm, err := hkdf.Key(sha256.New, shared, authSecret, keyInfo, 32)
+	// This checks if an error occurred while deriving the material.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+
+	// salt is a random salt for this message.
+	salt := make([]byte, 16)
+	// This fills the salt from the secure random generator.
+	if _, err := rand.Read(salt); err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+
+	// contentKey is the AES key of the message.
+	contentKey, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	// This checks if an error occurred while deriving the key.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// nonce is the nonce of the single record.
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	// This checks if an error occurred while deriving the nonce.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+
+	// block is the AES cipher.
+	block, err := aes.NewCipher(contentKey)
+	// This checks if an error occurred while creating the cipher.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// gcm is the AES-GCM mode of the cipher.
+	gcm, err := cipher.NewGCM(block)
+	// This checks if an error occurred while creating the mode.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+
+	// This checks if the payload does not fit in a single record with its delimiter and tag.
+	if len(payload)+1+gcm.Overhead() > webPushRecordSize {
+		// If it does not, an error is returned.
+		return nil, errors.New("web push payload is too large")
+	}
+
+	// header is the content coding header: salt, record size, key length, and the server public key.
+	header := make([]byte, 0, 16+4+1+len(serverPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, webPushRecordSize)
+	header = append(header, byte(len(serverPublic)))
+	header = append(header, serverPublic...)
+
+	// plaintext is the payload followed by the delimiter of the last record. It is copied so that the caller's payload is not modified.
+	plaintext := append(payload[:len(payload):len(payload)], 0x02)
+	// The plaintext is encrypted after the header.
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// parseSubscriptionKeys decodes and validates the keys of a browser subscription.
+//
+// @param p256dh string - The browser's public key in base64url.
+// @param auth string - The browser's authentication secret in base64url.
+// @return *ecdh.PublicKey - The browser's public key.
+// @return []byte - The authentication secret.
+// @return error - An error if a key is invalid.
+func parseSubscriptionKeys(p256dh string, auth string) (*ecdh.PublicKey, []byte, error) {
+	// raw is the decoded public key.
+	raw, err := decodeBase64URL(p256dh)
+	// This checks if an error occurred while decoding the key.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, nil, err
+	}
+	// browserKey is the parsed public key.
+	browserKey, err := ecdh.P256().NewPublicKey(raw)
+	// This checks if the key is not a valid P-256 point.
+	if err != nil {
+		// If it is not, an error is returned.
+		return nil, nil, err
+	}
+
+	// authSecret is the decoded authentication secret.
+	authSecret, err := decodeBase64URL(auth)
+	// This checks if the secret could not be decoded or has the wrong length.
+	if err != nil || len(authSecret) != 16 {
+		// If it could not, an error is returned.
+		return nil, nil, errors.New("auth secret must be 16 bytes")
+	}
+
+	// The keys are returned.
+	return browserKey, authSecret, nil
+}
+
+// decodeBase64URL decodes base64url with or without padding, as browsers and key generators use both.
+//
+// @param value string - The encoded value.
+// @return []byte - The decoded bytes.
+// @return error - An error if the value is not valid base64url.
+func decodeBase64URL(value string) ([]byte, error) {
+	// The padding is removed and the value is decoded.
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+// listWebPushSubscriptions reads a user's browser subscriptions.
+//
+// @param ctx context.Context - The context of the caller, which carries its deadline.
+// @param db *sql.DB - The database connection.
+// @param userId uuid.UUID - The ID of the user.
+// @return []WebPushSubscription - The subscriptions.
+// @return error - An error if one occurred.
+func listWebPushSubscriptions(ctx context.Context, db *sql.DB, userId uuid.UUID) ([]WebPushSubscription, error) {
+	// rows is the result of querying the subscriptions.
+	rows, err := db.QueryContext(ctx, GetWebPushSubscriptionsQuery, userId)
+	// This checks if an error occurred while querying the database.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// This defers the closing of the rows until the function returns.
+	defer rows.Close()
+
+	// subscriptions is a slice that will hold the subscriptions.
+	var subscriptions []WebPushSubscription
+	// This iterates over the rows.
+	for rows.Next() {
+		// subscription is a new WebPushSubscription struct.
+		var subscription WebPushSubscription
+		// This scans the row into the subscription struct.
+		if err := rows.Scan(&subscription.Endpoint, &subscription.P256dh, &subscription.Auth, &subscription.CreatedAt); err != nil {
+			// If an error occurs, it is returned.
+			return nil, err
+		}
+		// The subscription is appended to the subscriptions slice.
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	// The subscriptions and the error of the iteration, if any, are returned.
+	return subscriptions, rows.Err()
+}
@@ -0,0 +1,767 @@
+// This file defines the controllers for offline sync.
+package offlinesync
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to bound every change of a push by the deadline of the request.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to interact with the database.
+	"database/sql"
+	// "errors" provides functions for working with errors. It is used here to detect exceeded limits.
+	"errors"
+	// "strconv" provides functions for converting strings. It is used here to format the cursor.
+	"strconv"
+	// "time" provides functions for working with time. It is used here to set timestamps.
+	"time"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to define the controllers.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to hold IDs.
+	"github.com/google/uuid"
+	// "github.com/lib/pq" is the PostgreSQL driver. It is used here to pass arrays as query parameters.
+	"github.com/lib/pq"
+	// "github.com/rahulcodepython/todo-backend/apps/lists" is a local package that contains the list model.
+	"github.com/rahulcodepython/todo-backend/apps/lists"
+	// "github.com/rahulcodepython/todo-backend/apps/todos" is a local package that contains the todo model.
+	"github.com/rahulcodepython/todo-backend/apps/todos"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/binding" is a local package that binds request parameters. It is used here to bind the cursor and the limit.
+	"github.com/rahulcodepython/todo-backend/backend/binding"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/content" is a local package that validates text written by users.
+	"github.com/rahulcodepython/todo-backend/backend/content"
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database helpers.
+	"github.com/rahulcodepython/todo-backend/backend/database"
+	// "github.com/rahulcodepython/todo-backend/backend/idgen" is a local package that creates new IDs. It is used here to check the IDs chosen by clients.
+	"github.com/rahulcodepython/todo-backend/backend/idgen"
+	// "github.com/rahulcodepython/todo-backend/backend/outbox" is a local package that records domain events.
+	"github.com/rahulcodepython/todo-backend/backend/outbox"
+	// "github.com/rahulcodepython/todo-backend/backend/quota" is a local package that enforces usage limits.
+	"github.com/rahulcodepython/todo-backend/backend/quota"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// SyncController is a struct that holds the configuration and database connection.
+type SyncController struct {
+	// cfg is the application configuration.
+	cfg *config.Config
+	// db is the database connection.
+	db *sql.DB
+	// content cleans and checks the titles, descriptions, and list names of pushed changes.
+	content *content.Validator
+}
+
+// NewSyncControl creates a new SyncController.
+// It takes the application configuration, database connection, and content validator as input.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @param validator *content.Validator - The validator of titles, descriptions, and list names.
+// @return *SyncController - A pointer to the new SyncController.
+func NewSyncControl(cfg *config.Config, db *sql.DB, validator *content.Validator) *SyncController {
+	// A new SyncController is returned.
+	return &SyncController{
+		// The cfg field is set to the application configuration.
+		cfg: cfg,
+		// The db field is set to the database connection.
+		db: db,
+		// The content field is set to the content validator.
+		content: validator,
+	}
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	// Scan copies the columns of the current row into the values pointed at by dest.
+	Scan(dest ...any) error
+}
+
+// withDeleted scans the deleted flag that follows the record columns, so that the record scanners can be reused.
+type withDeleted struct {
+	// row is the row being scanned.
+	row rowScanner
+	// deleted receives the deleted flag.
+	deleted *bool
+}
+
+// Scan scans the record columns followed by the deleted flag.
+//
+// @param dest ...any - The record columns.
+// @return error - An error if one occurred.
+func (w withDeleted) Scan(dest ...any) error {
+	// The deleted flag is scanned after the record columns.
+	return w.row.Scan(append(dest, w.deleted)...)
+}
+
+// PullController returns the todo, list, and tag changes since a cursor, including tombstones for deletes.
+// Changes are paged by the transaction that made them, and a page only holds transactions older than any still running, so a change
+// that commits late is never skipped; when has_more is true the client pulls again with the returned cursor.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SyncController) PullController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// query is the result of binding the query parameters.
+	query, err := binding.Query[PullQuery](c)
+	// This checks if any query parameter is invalid.
+	if err != nil {
+		// If one is, a bad request response is returned with the invalid parameters.
+		return response.InvalidParameters(c, err)
+	}
+	// since is the cursor.
+	since := query.Since
+	// limit is the page size.
+	limit := query.Limit
+
+	// latest is the cursor just past the last change of the user's records that can no longer be overtaken by a running transaction.
+	var latest int64
+	// This queries the latest cursor.
+	if err := sc.db.QueryRowContext(c.UserContext(), GetLatestCursorQuery, user.ID).Scan(&latest); err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to read changes")
+	}
+	// This checks if the cursor is ahead of the server, for example after a restore.
+	if since > latest {
+		// If it is, the client is told to start over.
+		return response.BadResponse(c, "Cursor is ahead of the server, sync again from the start")
+	}
+
+	// upper is the cursor that ends this page.
+	upper := latest
+	// err is the result of finding the cursor past the last change in the page.
+	err = sc.db.QueryRowContext(c.UserContext(), GetPageEndCursorQuery, user.ID, since, limit, latest).Scan(&upper)
+	// This checks if an error other than a short page occurred.
+	if err != nil && err != sql.ErrNoRows {
+		// If it did, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to read changes")
+	}
+
+	// result is the page of changes.
+	result := PullResponse{
+		// The Cursor field is set to the end of the page.
+		Cursor: strconv.FormatInt(upper, 10),
+		// The HasMore field reports whether changes remain after the page.
+		HasMore: upper < latest,
+		// The Todos field is initialized so that it is never null.
+		Todos: []todos.TodoResponse{},
+		// The Lists field is initialized so that it is never null.
+		Lists: []lists.ListResponse{},
+		// The Deleted field is initialized so that its slices are never null.
+		Deleted: Tombstones{Todos: []uuid.UUID{}, Lists: []uuid.UUID{}},
+	}
+
+	// err is the result of collecting the changed todos.
+	err = queryRows(c.UserContext(), sc.db, GetChangedTodosQuery, func(row rowScanner) error {
+		// todo is the result of scanning the row.
+		todo, err := todos.ScanTodo(row)
+		// This checks if the row was scanned.
+		if err == nil {
+			// If it was, the todo is appended.
+			result.Todos = append(result.Todos, todos.NewTodoResponse(todo))
+		}
+		// The error, if any, is returned.
+		return err
+	}, user.ID, since, upper)
+	// This checks if an error occurred while collecting the todos.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to read changes")
+	}
+
+	// err is the result of collecting the changed lists.
+	err = queryRows(c.UserContext(), sc.db, GetChangedListsQuery, func(row rowScanner) error {
+		// list is the result of scanning the row.
+		list, err := lists.ScanList(row)
+		// This checks if the row was scanned.
+		if err == nil {
+			// If it was, the list is appended.
+			result.Lists = append(result.Lists, lists.NewListResponse(list))
+		}
+		// The error, if any, is returned.
+		return err
+	}, user.ID, since, upper)
+	// This checks if an error occurred while collecting the lists.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to read changes")
+	}
+
+	// This collects the tombstones of deleted todos and lists.
+	if err := queryIDs(c.UserContext(), sc.db, GetDeletedTodoIDsQuery, &result.Deleted.Todos, user.ID, since, upper); err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to read changes")
+	}
+	if err := queryIDs(c.UserContext(), sc.db, GetDeletedListIDsQuery, &result.Deleted.Lists, user.ID, since, upper); err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to read changes")
+	}
+
+	// This checks if any todo changed, which may have changed the set of tags.
+	if len(result.Todos) > 0 || len(result.Deleted.Todos) > 0 {
+		// result.Tags is initialized so that an empty set is sent as an empty array.
+		result.Tags = []string{}
+		// err is the result of collecting the tags.
+		err = queryRows(c.UserContext(), sc.db, GetTagsQuery, func(row rowScanner) error {
+			// tag is the scanned tag.
+			var tag string
+			// This scans the row.
+			err := row.Scan(&tag)
+			// The tag is appended.
+			result.Tags = append(result.Tags, tag)
+			// The error, if any, is returned.
+			return err
+		}, user.ID)
+		// This checks if an error occurred while collecting the tags.
+		if err != nil {
+			// If an error occurs, an internal server error response is returned.
+			return response.InternelServerError(c, err, "Unable to read changes")
+		}
+	}
+
+	// An OK response is returned with the page of changes.
+	return response.OKResponse(c, "Changes fetched successfully", result)
+}
+
+// PushController applies changes made offline in one transaction.
+// Conflict rules: a change applies only if the record is still at the client's base version; otherwise the server copy wins
+// and is returned so the client can rebase. Deletes win over edits, and deleting an already deleted record succeeds.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SyncController) PushController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// body is a new PushRequest struct.
+	body := new(PushRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+
+	// result is the outcome of the push.
+	result := PushResponse{Applied: []AppliedChange{}, Conflicts: []Conflict{}, Skipped: []SkippedChange{}}
+
+	// listChanges and todoChanges are the changes that are applied, lists first, up to the import cap.
+	// The rest is reported as skipped instead of rejecting the push, so that the client pushes it again and a large backlog still drains.
+	listChanges, todoChanges := body.Lists, body.Todos
+	// This checks if the lists alone exceed the cap.
+	if limit := sc.cfg.Limits.MaxImportRows; len(listChanges) > limit {
+		// If they do, the lists past the cap and every todo are skipped.
+		listChanges, todoChanges = listChanges[:limit], nil
+	} else if len(listChanges)+len(todoChanges) > limit {
+		// If the todos exceed what is left of the cap, the todos past it are skipped.
+		todoChanges = todoChanges[:limit-len(listChanges)]
+	}
+	// This iterates over the skipped lists.
+	for _, change := range body.Lists[len(listChanges):] {
+		// The list is reported as skipped.
+		result.Skipped = append(result.Skipped, SkippedChange{Type: TypeList, ID: change.ID})
+	}
+	// This iterates over the skipped todos.
+	for _, change := range body.Todos[len(todoChanges):] {
+		// The todo is reported as skipped.
+		result.Skipped = append(result.Skipped, SkippedChange{Type: TypeTodo, ID: change.ID})
+	}
+
+	// err is the result of applying the changes in one transaction.
+	err = database.WithTx(c.UserContext(), sc.db, func(tx *sql.Tx) error {
+		// The applied changes and the conflicts start empty, since the transaction is run again after a serialization
+		// failure or a deadlock, and the changes of an aborted attempt must not be reported twice.
+		result.Applied, result.Conflicts = []AppliedChange{}, []Conflict{}
+		// This iterates over the list changes.
+		for _, change := range listChanges {
+			// This applies the change.
+			if err := applyListChange(c.UserContext(), tx, sc.cfg, sc.content, user.ID, change, &result); err != nil {
+				// If an error occurs, it is returned.
+				return err
+			}
+		}
+		// This iterates over the todo changes.
+		for _, change := range todoChanges {
+			// This applies the change.
+			if err := applyTodoChange(c.UserContext(), tx, sc.cfg, sc.content, user.ID, change, &result); err != nil {
+				// If an error occurs, it is returned.
+				return err
+			}
+		}
+		// No error is returned.
+		return nil
+	})
+	// This checks if an error occurred while executing the transaction.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to apply changes")
+	}
+
+	// An OK response is returned with the outcome of the push.
+	return response.OKResponse(c, "Changes applied", result)
+}
+
+// applyListChange applies one list change and records its outcome.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param tx *sql.Tx - The transaction.
+// @param cfg *config.Config - The application configuration.
+// @param validator *content.Validator - The validator of list names.
+// @param userId uuid.UUID - The ID of the user.
+// @param change ListChange - The change.
+// @param result *PushResponse - The outcome of the push.
+// @return error - An error if a query failed.
+func applyListChange(ctx context.Context, tx *sql.Tx, cfg *config.Config, validator *content.Validator, userId uuid.UUID, change ListChange, result *PushResponse) error {
+	// name is the name of the list, which is cleaned in place.
+	name := change.Name
+	// contentErr is the result of cleaning the name and checking its length and words.
+	contentErr := validator.Clean(content.Text{Field: validator.ListName, Value: &name})
+	// This checks if a created or renamed list has no valid name.
+	if !change.Deleted && (name == "" || contentErr != nil) {
+		// If it has none, the change is rejected.
+		result.Conflicts = append(result.Conflicts, Conflict{Type: TypeList, ID: change.ID, Reason: ReasonInvalid})
+		return nil
+	}
+
+	// This applies a deletion.
+	if change.Deleted {
+		// version is the version of the deleted list.
+		var version int64
+		// err is the result of deleting the list if it is still at the base version.
+		err := tx.QueryRowContext(ctx, DeleteListQuery, change.ID, userId, change.BaseVersion).Scan(&version)
+		// This checks if the list was deleted.
+		if err == nil {
+			// The todos of the list are moved out of it.
+			if err := detachListTodos(ctx, tx, userId, change.ID); err != nil {
+				// If an error occurs, it is returned.
+				return err
+			}
+			// The event is recorded.
+			if err := outbox.Record(ctx, tx, outbox.ListDeleted, userId, change.ID, outbox.DeletedData{ID: change.ID}); err != nil {
+				// If an error occurs, it is returned.
+				return err
+			}
+			// The change is recorded as applied.
+			result.Applied = append(result.Applied, AppliedChange{Type: TypeList, ID: change.ID, Version: version})
+			return nil
+		}
+		// This checks if a query failed.
+		if err != sql.ErrNoRows {
+			// If it did, the error is returned.
+			return err
+		}
+		// The conflict is explained.
+		return listConflict(ctx, tx, userId, change, result)
+	}
+
+	// list is the result of creating or renaming the list.
+	var list lists.List
+	// err is the result of the query.
+	var err error
+	// eventType is the domain event of the change.
+	var eventType string
+	// This checks if the list is new.
+	if change.BaseVersion == 0 {
+		// This checks if the client chose an ID that is not a UUIDv7, which the lists table no longer accepts.
+		if !idgen.IsUUIDv7(change.ID) {
+			// If it did, the change is rejected.
+			result.Conflicts = append(result.Conflicts, Conflict{Type: TypeList, ID: change.ID, Reason: ReasonInvalidID})
+			return nil
+		}
+		// This checks that the list fits in the user's plan.
+		if err := quota.Check(ctx, tx, cfg, userId, quota.Lists, 1); err != nil {
+			// exceeded is the limit that was reached, if any.
+			var exceeded *quota.ExceededError
+			// This checks if the plan limit was reached.
+			if errors.As(err, &exceeded) {
+				// If it was, the change is rejected.
+				result.Conflicts = append(result.Conflicts, Conflict{Type: TypeList, ID: change.ID, Reason: ReasonQuotaExceeded})
+				return nil
+			}
+			// Otherwise the error is returned.
+			return err
+		}
+		// If it is, it is created unless the ID is taken.
+		list, err = lists.ScanList(tx.QueryRowContext(ctx, CreateListQuery, change.ID, name, userId, time.Now()))
+		eventType = outbox.ListCreated
+	} else {
+		// Otherwise it is renamed if it is still at the base version.
+		list, err = lists.ScanList(tx.QueryRowContext(ctx, UpdateListQuery, name, change.ID, userId, change.BaseVersion))
+		eventType = outbox.ListUpdated
+	}
+	// This checks if the change was applied.
+	if err == nil {
+		// The event is recorded.
+		if err := lists.RecordListEvent(ctx, tx, eventType, list); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The change is recorded as applied.
+		result.Applied = append(result.Applied, AppliedChange{Type: TypeList, ID: list.ID, Version: list.Version})
+		return nil
+	}
+	// This checks if a query failed.
+	if err != sql.ErrNoRows {
+		// If it did, the error is returned.
+		return err
+	}
+	// The conflict is explained.
+	return listConflict(ctx, tx, userId, change, result)
+}
+
+// detachListTodos moves the todos of a deleted list out of it and records an event for each.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param tx *sql.Tx - The transaction.
+// @param userId uuid.UUID - The ID of the user.
+// @param listId uuid.UUID - The ID of the deleted list.
+// @return error - An error if one occurred.
+func detachListTodos(ctx context.Context, tx *sql.Tx, userId uuid.UUID, listId uuid.UUID) error {
+	// rows is the result of moving the todos out of the list.
+	rows, err := tx.QueryContext(ctx, DetachListTodosQuery, listId)
+	// This checks if an error occurred while moving the todos.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// todoIds is a slice that will hold the moved todos.
+	var todoIds []uuid.UUID
+	// This iterates over the rows.
+	for rows.Next() {
+		// todoId is the ID of a moved todo.
+		var todoId uuid.UUID
+		// This scans the row.
+		if err := rows.Scan(&todoId); err != nil {
+			// If an error occurs, the rows are closed and the error is returned.
+			rows.Close()
+			return err
+		}
+		// The ID is appended to the todo IDs.
+		todoIds = append(todoIds, todoId)
+	}
+	// The rows are closed before the events are written on the same transaction.
+	rows.Close()
+	// This checks if an error occurred during the iteration.
+	if err := rows.Err(); err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// This records an event for every moved todo.
+	for _, todoId := range todoIds {
+		// This records the event.
+		if err := outbox.Record(ctx, tx, outbox.TodoMoved, userId, todoId, todos.TodoMovedEvent{ID: todoId}); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+	}
+	// No error is returned.
+	return nil
+}
+
+// listConflict records why a list change could not be applied, with the server copy of the list.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param tx *sql.Tx - The transaction.
+// @param userId uuid.UUID - The ID of the user.
+// @param change ListChange - The rejected change.
+// @param result *PushResponse - The outcome of the push.
+// @return error - An error if a query failed.
+func listConflict(ctx context.Context, tx *sql.Tx, userId uuid.UUID, change ListChange, result *PushResponse) error {
+	// deleted reports whether the server copy is deleted.
+	var deleted bool
+	// list is the server copy of the list.
+	list, err := lists.ScanList(withDeleted{row: tx.QueryRowContext(ctx, GetListStateQuery, change.ID, userId), deleted: &deleted})
+
+	// conflict is the conflict being recorded.
+	conflict := Conflict{Type: TypeList, ID: change.ID}
+	// This classifies the conflict.
+	switch {
+	case err == sql.ErrNoRows && change.BaseVersion == 0:
+		// The ID of a new list belongs to another user.
+		conflict.Reason = ReasonIDTaken
+	case err == sql.ErrNoRows:
+		// The list does not exist.
+		conflict.Reason = ReasonNotFound
+	case err != nil:
+		// A query failed.
+		return err
+	case deleted && change.Deleted:
+		// Deleting a deleted list succeeds.
+		result.Applied = append(result.Applied, AppliedChange{Type: TypeList, ID: list.ID, Version: list.Version})
+		return nil
+	case deleted:
+		// The list was deleted on the server.
+		conflict.Reason = ReasonDeleted
+	default:
+		// The list exists; it was either created with the same ID or changed since the base version.
+		listResponse := lists.NewListResponse(list)
+		conflict.List = &listResponse
+		conflict.Reason = ReasonVersionMismatch
+		// This checks if the client tried to create it.
+		if change.BaseVersion == 0 {
+			// If it did, the ID was taken, for example by an earlier push of the same change.
+			conflict.Reason = ReasonIDTaken
+		}
+	}
+
+	// The conflict is recorded.
+	result.Conflicts = append(result.Conflicts, conflict)
+	return nil
+}
+
+// applyTodoChange applies one todo change and records its outcome.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param tx *sql.Tx - The transaction.
+// @param cfg *config.Config - The application configuration.
+// @param validator *content.Validator - The validator of titles and descriptions.
+// @param userId uuid.UUID - The ID of the user.
+// @param change TodoChange - The change.
+// @param result *PushResponse - The outcome of the push.
+// @return error - An error if a query failed.
+func applyTodoChange(ctx context.Context, tx *sql.Tx, cfg *config.Config, validator *content.Validator, userId uuid.UUID, change TodoChange, result *PushResponse) error {
+	// This applies a deletion.
+	if change.Deleted {
+		// version is the version of the deleted todo.
+		var version int64
+		// err is the result of deleting the todo if it is still at the base version.
+		err := tx.QueryRowContext(ctx, DeleteTodoQuery, change.ID, userId, change.BaseVersion).Scan(&version)
+		// This checks if the todo was deleted.
+		if err == nil {
+			// The event is recorded.
+			if err := outbox.Record(ctx, tx, outbox.TodoDeleted, userId, change.ID, outbox.DeletedData{ID: change.ID}); err != nil {
+				// If an error occurs, it is returned.
+				return err
+			}
+			// The change is recorded as applied.
+			result.Applied = append(result.Applied, AppliedChange{Type: TypeTodo, ID: change.ID, Version: version})
+			return nil
+		}
+		// This checks if a query failed.
+		if err != sql.ErrNoRows {
+			// If it did, the error is returned.
+			return err
+		}
+		// The conflict is explained.
+		return todoConflict(ctx, tx, userId, change, result)
+	}
+
+	// contentErr is the result of cleaning the title and description in place and checking their length and words.
+	contentErr := validator.Clean(content.Text{Field: validator.TodoTitle, Value: &change.Title}, content.Text{Field: validator.TodoDescription, Value: &change.Description})
+	// title is the cleaned title of the todo.
+	title := change.Title
+	// priority is the normalized priority of the todo.
+	priority, ok := todos.NormalizePriority(change.Priority)
+	// This checks if the todo is invalid.
+	if title == "" || contentErr != nil || !ok {
+		// If it is, the change is rejected.
+		result.Conflicts = append(result.Conflicts, Conflict{Type: TypeTodo, ID: change.ID, Reason: ReasonInvalid})
+		return nil
+	}
+
+	// listId is the list of the todo.
+	var listId uuid.NullUUID
+	// This checks if the todo is in a list.
+	if change.ListID != nil {
+		// usable reports whether the list belongs to the user and is not deleted.
+		var usable bool
+		// This checks the list.
+		if err := tx.QueryRowContext(ctx, CheckListUsableQuery, *change.ListID, userId).Scan(&usable); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// This checks if the list cannot be used.
+		if !usable {
+			// If it cannot, the change is rejected.
+			result.Conflicts = append(result.Conflicts, Conflict{Type: TypeTodo, ID: change.ID, Reason: ReasonInvalid})
+			return nil
+		}
+		listId = uuid.NullUUID{UUID: *change.ListID, Valid: true}
+	}
+
+	// dueAt is the due date of the todo.
+	var dueAt sql.NullTime
+	// This checks if the todo has a due date.
+	if change.DueAt != nil {
+		// If it does, it is set.
+		dueAt = sql.NullTime{Time: *change.DueAt, Valid: true}
+	}
+	// tags is the normalized list of tags.
+	tags := pq.Array(todos.NormalizeTags(change.Tags))
+
+	// todo is the result of creating or updating the todo.
+	var todo todos.Todo
+	// err is the result of the query.
+	var err error
+	// eventType is the domain event of the change.
+	var eventType string
+	// This checks if the todo is new.
+	if change.BaseVersion == 0 {
+		// This checks if the client chose an ID that is not a UUIDv7, which the todos table no longer accepts.
+		if !idgen.IsUUIDv7(change.ID) {
+			// If it did, the change is rejected.
+			result.Conflicts = append(result.Conflicts, Conflict{Type: TypeTodo, ID: change.ID, Reason: ReasonInvalidID})
+			return nil
+		}
+		// This checks that the todo fits in the user's plan.
+		if err := quota.Check(ctx, tx, cfg, userId, quota.Todos, 1); err != nil {
+			// exceeded is the limit that was reached, if any.
+			var exceeded *quota.ExceededError
+			// This checks if the plan limit was reached.
+			if errors.As(err, &exceeded) {
+				// If it was, the change is rejected.
+				result.Conflicts = append(result.Conflicts, Conflict{Type: TypeTodo, ID: change.ID, Reason: ReasonQuotaExceeded})
+				return nil
+			}
+			// Otherwise the error is returned.
+			return err
+		}
+		// If it is, it is created unless the ID is taken.
+		todo, err = todos.ScanTodo(tx.QueryRowContext(ctx, CreateTodoQuery, change.ID, title, change.Description, priority, change.Completed, userId, utils.ParseTime(time.Now()), listId, 0, dueAt, tags))
+		eventType = outbox.TodoCreated
+	} else {
+		// Otherwise it is updated if it is still at the base version.
+		todo, err = todos.ScanTodo(tx.QueryRowContext(ctx, UpdateTodoQuery, title, change.Description, priority, change.Completed, listId, dueAt, tags, change.ID, userId, change.BaseVersion))
+		eventType = outbox.TodoUpdated
+	}
+	// This checks if the change was applied.
+	if err == nil {
+		// The event is recorded.
+		if err := todos.RecordTodoEvent(ctx, tx, eventType, todo); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The change is recorded as applied.
+		result.Applied = append(result.Applied, AppliedChange{Type: TypeTodo, ID: todo.ID, Version: todo.Version})
+		return nil
+	}
+	// This checks if a query failed.
+	if err != sql.ErrNoRows {
+		// If it did, the error is returned.
+		return err
+	}
+	// The conflict is explained.
+	return todoConflict(ctx, tx, userId, change, result)
+}
+
+// todoConflict records why a todo change could not be applied, with the server copy of the todo.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param tx *sql.Tx - The transaction.
+// @param userId uuid.UUID - The ID of the user.
+// @param change TodoChange - The rejected change.
+// @param result *PushResponse - The outcome of the push.
+// @return error - An error if a query failed.
+func todoConflict(ctx context.Context, tx *sql.Tx, userId uuid.UUID, change TodoChange, result *PushResponse) error {
+	// deleted reports whether the server copy is deleted.
+	var deleted bool
+	// todo is the server copy of the todo.
+	todo, err := todos.ScanTodo(withDeleted{row: tx.QueryRowContext(ctx, GetTodoStateQuery, change.ID, userId), deleted: &deleted})
+
+	// conflict is the conflict being recorded.
+	conflict := Conflict{Type: TypeTodo, ID: change.ID}
+	// This classifies the conflict.
+	switch {
+	case err == sql.ErrNoRows && change.BaseVersion == 0 && !change.Deleted:
+		// The ID of a new todo belongs to another user.
+		conflict.Reason = ReasonIDTaken
+	case err == sql.ErrNoRows:
+		// The todo does not exist.
+		conflict.Reason = ReasonNotFound
+	case err != nil:
+		// A query failed.
+		return err
+	case deleted && change.Deleted:
+		// Deleting a deleted todo succeeds.
+		result.Applied = append(result.Applied, AppliedChange{Type: TypeTodo, ID: todo.ID, Version: todo.Version})
+		return nil
+	case deleted:
+		// The todo was deleted on the server.
+		conflict.Reason = ReasonDeleted
+	default:
+		// The todo exists; it was either created with the same ID or changed since the base version.
+		todoResponse := todos.NewTodoResponse(todo)
+		conflict.Todo = &todoResponse
+		conflict.Reason = ReasonVersionMismatch
+		// This checks if the client tried to create it.
+		if change.BaseVersion == 0 && !change.Deleted {
+			// If it did, the ID was taken, for example by an earlier push of the same change.
+			conflict.Reason = ReasonIDTaken
+		}
+	}
+
+	// The conflict is recorded.
+	result.Conflicts = append(result.Conflicts, conflict)
+	return nil
+}
+
+// queryRows runs a query and calls scan for every row.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param db *sql.DB - The database connection.
+// @param query string - The SQL query.
+// @param scan func(rowScanner) error - The function that scans a row.
+// @param args ...any - The query arguments.
+// @return error - An error if one occurred.
+func queryRows(ctx context.Context, db *sql.DB, query string, scan func(rowScanner) error, args ...any) error {
+	// rows is the result of running the query.
+	rows, err := db.QueryContext(ctx, query, args...)
+	// This checks if an error occurred while running the query.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// This defers the closing of the rows until the function returns.
+	defer rows.Close()
+
+	// This iterates over the rows.
+	for rows.Next() {
+		// This scans the row.
+		if err := scan(rows); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+	}
+	// The error of the iteration, if any, is returned.
+	return rows.Err()
+}
+
+// queryIDs runs a query that selects IDs and appends them to a slice.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param db *sql.DB - The database connection.
+// @param query string - The SQL query.
+// @param ids *[]uuid.UUID - The slice the IDs are appended to.
+// @param args ...any - The query arguments.
+// @return error - An error if one occurred.
+func queryIDs(ctx context.Context, db *sql.DB, query string, ids *[]uuid.UUID, args ...any) error {
+	// The IDs are scanned and appended.
+	return queryRows(ctx, db, query, func(row rowScanner) error {
+		// id is the scanned ID.
+		var id uuid.UUID
+		// This scans the row.
+		err := row.Scan(&id)
+		// The ID is appended.
+		*ids = append(*ids, id)
+		// The error, if any, is returned.
+		return err
+	}, args...)
+}
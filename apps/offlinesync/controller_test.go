@@ -0,0 +1,205 @@
+// This file defines a test of the deletions a pull reports for the todos the archive worker moved out of the todos table.
+package offlinesync
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to run the archive pass.
+import (
+	"context"
+	// "database/sql/driver" defines the interfaces of SQL drivers. It is used here to build the rows of the fake database.
+	"database/sql/driver"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to read the pulls.
+	"encoding/json"
+	// "fmt" provides functions for formatted I/O. It is used here to build the pull URLs.
+	"fmt"
+	// "net/http/httptest" provides utilities for HTTP testing. It is used here to build the requests.
+	"net/http/httptest"
+	// "strings" provides functions for working with strings. It is used here to check the tables the queries write and read.
+	"strings"
+	// "sync" provides synchronization primitives. It is used here to guard the rows of the fake database.
+	"sync"
+	// "testing" provides support for automated tests. It is used here to run the test.
+	"testing"
+	// "time" provides functionality for measuring and displaying time. It is used here for the times of the todo and the cutoff.
+	"time"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to serve the pulls.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here for the IDs of the user and the todo.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/apps/todos" is a local package that manages todos. It is used here to archive the todo.
+	"github.com/rahulcodepython/todo-backend/apps/todos"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that manages users. It is used here to authenticate the pulls.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/database/dbtest" is a local package that provides a fake database. It is used here to hold the todo and its tombstone.
+	"github.com/rahulcodepython/todo-backend/backend/database/dbtest"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names. It is used here to check the queries share the tombstones.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// todoColumns are the columns todos.ScanTodo reads, in its order.
+var todoColumns = []string{"id", "title", "description", "priority", "completed", "owner", "created_at", "list_id", "position", "due_at", "tags", "version", "ical_uid", "updated_at", "snoozed_until", "estimate_minutes", "status", "blocked"}
+
+// TestPullAfterArchive checks that a todo the archive worker moved out of the todos table is reported as deleted by the next pull,
+// from the tombstone the archive statement leaves, and that the cursor moves past the deletion.
+//
+// @param t *testing.T - The test state.
+func TestPullAfterArchive(t *testing.T) {
+	// The archive statement must leave the tombstones the pull reads, since the fake database only stands in for the statements.
+	if !strings.Contains(todos.ArchiveTodosQuery, "INSERT INTO "+utils.TodoTombstoneTableName) || !strings.Contains(GetDeletedTodoIDsQuery, utils.TodoTombstoneTableName) || !strings.Contains(GetLatestCursorQuery, utils.TodoTombstoneTableName) {
+		// If it does not, the test fails.
+		t.Fatalf("the archive and the pull do not share the %s table", utils.TodoTombstoneTableName)
+	}
+
+	// owner is the user who pulls.
+	owner := users.User{ID: uuid.New(), Timezone: "UTC"}
+	// id is the ID of the todo.
+	id := uuid.New()
+	// completedAt is the time the todo was completed, long before the cutoff.
+	completedAt := time.Date(2025, 1, 8, 12, 0, 0, 0, time.UTC)
+
+	// mu guards the rows of the fake database.
+	var mu sync.Mutex
+	// xid is the ID of the next transaction of the fake database.
+	xid := int64(100)
+	// live holds the transaction of the last change of the todo while it is in the todos table, or 0 once it was archived.
+	live := xid
+	// tombstone holds the transaction of the tombstone of the todo, or 0 before it was archived.
+	var tombstone int64
+	// fake is the fake database, which holds the todo and its tombstone.
+	fake := dbtest.NewDriver()
+	// The archive moves the todo, completed before the cutoff, to the archive and leaves a tombstone in a transaction of its own.
+	fake.Handle(todos.ArchiveTodosQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		// This checks if the todo is not archivable.
+		if live == 0 || !completedAt.Before(args[0].Value.(time.Time)) {
+			return dbtest.Rows{RowsAffected: 0}, nil
+		}
+		xid++
+		live, tombstone = 0, xid
+		return dbtest.Rows{RowsAffected: 1}, nil
+	})
+	// The latest cursor is past the last change of the todo or its tombstone.
+	fake.Handle(GetLatestCursorQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return dbtest.Rows{Columns: []string{"cursor"}, Values: [][]driver.Value{{max(live, tombstone) + 1}}}, nil
+	})
+	// The page is short, so it ends at the latest cursor.
+	fake.Handle(GetPageEndCursorQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		return dbtest.Rows{Columns: []string{"cursor"}}, nil
+	})
+	// The changed todos are the todo while it is in the todos table and changed within the range.
+	fake.Handle(GetChangedTodosQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		// rows holds the todo, if it changed within the range.
+		rows := dbtest.Rows{Columns: todoColumns}
+		// This checks if the todo changed within the range.
+		if live != 0 && live >= args[1].Value.(int64) && live < args[2].Value.(int64) {
+			rows.Values = [][]driver.Value{{id.String(), "Pay rent", "", todos.PriorityNone, true, owner.ID.String(), completedAt, nil, int64(0), nil, []byte("{}"), int64(1), nil, completedAt, nil, nil, "done", false}}
+		}
+		return rows, nil
+	})
+	// The deleted todos are the tombstone of the todo, if it was left within the range.
+	fake.Handle(GetDeletedTodoIDsQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		// rows holds the ID of the todo, if its tombstone was left within the range.
+		rows := dbtest.Rows{Columns: []string{"id"}}
+		// This checks if the tombstone was left within the range.
+		if tombstone != 0 && tombstone >= args[1].Value.(int64) && tombstone < args[2].Value.(int64) {
+			rows.Values = [][]driver.Value{{id.String()}}
+		}
+		return rows, nil
+	})
+	// The user has no lists.
+	fake.Handle(GetChangedListsQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		return dbtest.Rows{Columns: []string{"id"}}, nil
+	})
+	fake.Handle(GetDeletedListIDsQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		return dbtest.Rows{Columns: []string{"id"}}, nil
+	})
+	// The user has no tags.
+	fake.Handle(GetTagsQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		return dbtest.Rows{Columns: []string{"tag"}}, nil
+	})
+	// db is the fake database.
+	db := dbtest.OpenDB(fake)
+	t.Cleanup(func() { db.Close() })
+
+	// controller is the sync controller over the fake database.
+	controller := NewSyncControl(&config.Config{}, db, nil)
+	// app serves the pulls of the authenticated user.
+	app := fiber.New()
+	app.Get("/sync", func(c *fiber.Ctx) error {
+		// The user is authenticated.
+		users.SetCurrentUser(c, owner)
+		// The changes are pulled.
+		return controller.PullController(c)
+	})
+
+	// pull pulls the changes since a cursor.
+	pull := func(since string) PullResponse {
+		// resp is the response to the pull.
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, fmt.Sprintf("/sync?since=%s", since), nil))
+		// This checks if the request failed.
+		if err != nil {
+			// If it did, the test fails.
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		// This checks if the pull did not succeed.
+		if resp.StatusCode != fiber.StatusOK {
+			// If it did not, the test fails.
+			t.Fatalf("pull since %s: status = %d, want %d", since, resp.StatusCode, fiber.StatusOK)
+		}
+		// body is the pull.
+		var body struct {
+			Data PullResponse `json:"data"`
+		}
+		// This reads the pull.
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			// If it cannot be read, the test fails.
+			t.Fatal(err)
+		}
+		// The page of changes is returned.
+		return body.Data
+	}
+
+	// first is the first pull, which holds the todo.
+	first := pull("0")
+	// This checks if the todo was not pulled.
+	if len(first.Todos) != 1 || first.Todos[0].ID != id || len(first.Deleted.Todos) != 0 {
+		// If it was not, the test fails.
+		t.Fatalf("first pull = %+v, want the todo", first)
+	}
+
+	// The todo is archived.
+	archived, err := todos.ArchiveCompletedTodos(context.Background(), db, completedAt.AddDate(0, 1, 0))
+	// This checks if the todo was not archived.
+	if err != nil || archived != 1 {
+		// If it was not, the test fails.
+		t.Fatalf("ArchiveCompletedTodos = %d, %v, want 1 todo", archived, err)
+	}
+
+	// second is the pull after the archive, which reports the todo as deleted.
+	second := pull(first.Cursor)
+	// This checks if the deletion was not reported.
+	if len(second.Todos) != 0 || len(second.Deleted.Todos) != 1 || second.Deleted.Todos[0] != id {
+		// If it was not, the test fails.
+		t.Fatalf("pull after archiving = %+v, want the todo under deleted", second)
+	}
+	// This checks if the cursor did not move past the deletion.
+	if second.Cursor == first.Cursor || second.HasMore {
+		// If it did not, the test fails.
+		t.Fatalf("pull after archiving: cursor = %s, has_more = %v, want a cursor past %s and no more", second.Cursor, second.HasMore, first.Cursor)
+	}
+
+	// This checks if a pull from the new cursor reports the deletion again.
+	if third := pull(second.Cursor); len(third.Todos) != 0 || len(third.Deleted.Todos) != 0 {
+		// If it does, the test fails.
+		t.Fatalf("pull after the deletion = %+v, want no changes", third)
+	}
+}
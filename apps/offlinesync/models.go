@@ -0,0 +1,26 @@
+// This file defines the constants used by offline sync.
+package offlinesync
+
+const (
+	// TypeTodo is the record type of todos.
+	TypeTodo = "todo"
+	// TypeList is the record type of lists.
+	TypeList = "list"
+)
+
+const (
+	// ReasonVersionMismatch means the record changed on the server after the client's base version.
+	ReasonVersionMismatch = "version_mismatch"
+	// ReasonDeleted means the record was deleted on the server. Deletes win over edits.
+	ReasonDeleted = "deleted"
+	// ReasonNotFound means the record does not exist for the user.
+	ReasonNotFound = "not_found"
+	// ReasonIDTaken means a new record used an ID that already exists.
+	ReasonIDTaken = "id_taken"
+	// ReasonInvalidID means a new record used an ID that is not a UUIDv7.
+	ReasonInvalidID = "invalid_id"
+	// ReasonInvalid means the change failed validation.
+	ReasonInvalid = "invalid"
+	// ReasonQuotaExceeded means a new record would exceed a limit of the user's plan.
+	ReasonQuotaExceeded = "quota_exceeded"
+)
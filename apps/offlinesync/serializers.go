@@ -0,0 +1,171 @@
+// This file defines the serializers for offline sync requests and responses.
+package offlinesync
+
+// "time" provides functions for working with time. It is used here to define the due date field.
+import (
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to define ID fields.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/apps/lists" is a local package that contains the list response.
+	"github.com/rahulcodepython/todo-backend/apps/lists"
+	// "github.com/rahulcodepython/todo-backend/apps/todos" is a local package that contains the todo response.
+	"github.com/rahulcodepython/todo-backend/apps/todos"
+)
+
+// PullQuery defines the query parameters of a pull request.
+type PullQuery struct {
+	// Since is the cursor returned by the previous pull, or 0 to pull everything.
+	// query:"since" specifies that this field is bound to the "since" query parameter.
+	Since int64 `query:"since" default:"0" min:"0"`
+	// Limit is the maximum number of changes in the page.
+	// query:"limit" specifies that this field is bound to the "limit" query parameter.
+	Limit int `query:"limit" default:"500" min:"1" max:"1000"`
+}
+
+// PullResponse defines the structure for the changes since a cursor.
+type PullResponse struct {
+	// Cursor is the cursor to send with the next pull.
+	// json:"cursor" specifies that this field should be marshalled to/from a JSON object with the key "cursor".
+	Cursor string `json:"cursor"`
+	// HasMore reports whether more changes are waiting after this page.
+	// json:"has_more" specifies that this field should be marshalled to/from a JSON object with the key "has_more".
+	HasMore bool `json:"has_more"`
+	// Todos are the todos that were created or changed.
+	// json:"todos" specifies that this field should be marshalled to/from a JSON object with the key "todos".
+	Todos []todos.TodoResponse `json:"todos"`
+	// Lists are the lists that were created or changed.
+	// json:"lists" specifies that this field should be marshalled to/from a JSON object with the key "lists".
+	Lists []lists.ListResponse `json:"lists"`
+	// Tags is the full set of tags in use. It is only sent when a todo changed, since tags live on todos.
+	// json:"tags,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "tags", and omitted if empty.
+	Tags []string `json:"tags,omitempty"`
+	// Deleted holds the tombstones of deleted todos and lists.
+	// json:"deleted" specifies that this field should be marshalled to/from a JSON object with the key "deleted".
+	Deleted Tombstones `json:"deleted"`
+}
+
+// Tombstones defines the structure for the IDs of deleted records.
+type Tombstones struct {
+	// Todos are the IDs of deleted todos.
+	// json:"todos" specifies that this field should be marshalled to/from a JSON object with the key "todos".
+	Todos []uuid.UUID `json:"todos"`
+	// Lists are the IDs of deleted lists.
+	// json:"lists" specifies that this field should be marshalled to/from a JSON object with the key "lists".
+	Lists []uuid.UUID `json:"lists"`
+}
+
+// PushRequest defines the structure for the changes a client made offline.
+type PushRequest struct {
+	// Lists are the list changes. They are applied before the todo changes so that new todos can reference new lists.
+	// json:"lists" specifies that this field should be marshalled to/from a JSON object with the key "lists".
+	Lists []ListChange `json:"lists"`
+	// Todos are the todo changes.
+	// json:"todos" specifies that this field should be marshalled to/from a JSON object with the key "todos".
+	Todos []TodoChange `json:"todos"`
+}
+
+// TodoChange defines the structure for one todo change.
+type TodoChange struct {
+	// ID is the ID of the todo. New todos use an ID generated by the client.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// BaseVersion is the version the change was made on, or 0 for a new todo.
+	// json:"base_version" specifies that this field should be marshalled to/from a JSON object with the key "base_version".
+	BaseVersion int64 `json:"base_version"`
+	// Deleted reports whether the todo was deleted.
+	// json:"deleted" specifies that this field should be marshalled to/from a JSON object with the key "deleted".
+	Deleted bool `json:"deleted"`
+	// Title is the title of the todo.
+	// json:"title" specifies that this field should be marshalled to/from a JSON object with the key "title".
+	Title string `json:"title"`
+	// Description is the description of the todo.
+	// json:"description" specifies that this field should be marshalled to/from a JSON object with the key "description".
+	Description string `json:"description"`
+	// Priority is the priority of the todo.
+	// json:"priority" specifies that this field should be marshalled to/from a JSON object with the key "priority".
+	Priority string `json:"priority"`
+	// Completed is the completion status of the todo.
+	// json:"completed" specifies that this field should be marshalled to/from a JSON object with the key "completed".
+	Completed bool `json:"completed"`
+	// ListID is the list of the todo, if any.
+	// json:"list_id" specifies that this field should be marshalled to/from a JSON object with the key "list_id".
+	ListID *uuid.UUID `json:"list_id"`
+	// DueAt is the due date of the todo, if any.
+	// json:"due_at" specifies that this field should be marshalled to/from a JSON object with the key "due_at".
+	DueAt *time.Time `json:"due_at"`
+	// Tags are the tags of the todo.
+	// json:"tags" specifies that this field should be marshalled to/from a JSON object with the key "tags".
+	Tags []string `json:"tags"`
+}
+
+// ListChange defines the structure for one list change.
+type ListChange struct {
+	// ID is the ID of the list. New lists use an ID generated by the client.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// BaseVersion is the version the change was made on, or 0 for a new list.
+	// json:"base_version" specifies that this field should be marshalled to/from a JSON object with the key "base_version".
+	BaseVersion int64 `json:"base_version"`
+	// Deleted reports whether the list was deleted.
+	// json:"deleted" specifies that this field should be marshalled to/from a JSON object with the key "deleted".
+	Deleted bool `json:"deleted"`
+	// Name is the name of the list.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+}
+
+// PushResponse defines the structure for the outcome of a push.
+type PushResponse struct {
+	// Applied are the changes that were applied.
+	// json:"applied" specifies that this field should be marshalled to/from a JSON object with the key "applied".
+	Applied []AppliedChange `json:"applied"`
+	// Conflicts are the changes that were rejected. The server copy wins and is included so the client can rebase.
+	// json:"conflicts" specifies that this field should be marshalled to/from a JSON object with the key "conflicts".
+	Conflicts []Conflict `json:"conflicts"`
+	// Skipped are the changes past the import cap, which were not looked at and are pushed again.
+	// json:"skipped" specifies that this field should be marshalled to/from a JSON object with the key "skipped".
+	Skipped []SkippedChange `json:"skipped"`
+}
+
+// SkippedChange defines the structure for a change that was left for a later push.
+type SkippedChange struct {
+	// Type is "todo" or "list".
+	// json:"type" specifies that this field should be marshalled to/from a JSON object with the key "type".
+	Type string `json:"type"`
+	// ID is the ID of the record.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+}
+
+// AppliedChange defines the structure for an applied change.
+type AppliedChange struct {
+	// Type is "todo" or "list".
+	// json:"type" specifies that this field should be marshalled to/from a JSON object with the key "type".
+	Type string `json:"type"`
+	// ID is the ID of the record.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// Version is the new version of the record.
+	// json:"version" specifies that this field should be marshalled to/from a JSON object with the key "version".
+	Version int64 `json:"version"`
+}
+
+// Conflict defines the structure for a rejected change.
+type Conflict struct {
+	// Type is "todo" or "list".
+	// json:"type" specifies that this field should be marshalled to/from a JSON object with the key "type".
+	Type string `json:"type"`
+	// ID is the ID of the record.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// Reason is one of the Reason constants.
+	// json:"reason" specifies that this field should be marshalled to/from a JSON object with the key "reason".
+	Reason string `json:"reason"`
+	// Todo is the server copy of a conflicting todo, if it still exists.
+	// json:"todo,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "todo", and omitted if empty.
+	Todo *todos.TodoResponse `json:"todo,omitempty"`
+	// List is the server copy of a conflicting list, if it still exists.
+	// json:"list,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "list", and omitted if empty.
+	List *lists.ListResponse `json:"list,omitempty"`
+}
@@ -0,0 +1,63 @@
+// This file defines the SQL queries used for offline sync.
+package offlinesync
+
+// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names and schemas.
+import "github.com/rahulcodepython/todo-backend/backend/utils"
+
+// syncHorizon is the ID of the oldest transaction still running. Every change of a transaction below it has committed, and no change
+// below it can commit later, so a cursor never passes it. A change committed above it is left for a later pull.
+const syncHorizon = "pg_snapshot_xmin(pg_current_snapshot())::text::bigint"
+
+// GetLatestCursorQuery is the SQL query to retrieve the cursor just past the last change of a user's todos and lists, including deleted ones
+// and tombstones, whose transaction is below the sync horizon.
+const GetLatestCursorQuery = "SELECT GREATEST((SELECT COALESCE(MAX(change_xid) + 1, 0) FROM " + utils.TodoTableName + " WHERE owner = $1 AND change_xid < " + syncHorizon + "), (SELECT COALESCE(MAX(change_xid) + 1, 0) FROM " + utils.ListTableName + " WHERE owner = $1 AND change_xid < " + syncHorizon + "), (SELECT COALESCE(MAX(change_xid) + 1, 0) FROM " + utils.TodoTombstoneTableName + " WHERE owner = $1 AND change_xid < " + syncHorizon + "))"
+
+// GetPageEndCursorQuery is the SQL query to retrieve the cursor just past the transaction of the $3-th change from $2 up to the latest cursor $4,
+// which ends a page of changes. A page holds every change of its last transaction, so it can be longer than $3.
+const GetPageEndCursorQuery = "SELECT change_xid + 1 FROM (SELECT change_xid FROM " + utils.TodoTableName + " WHERE owner = $1 AND change_xid >= $2 AND change_xid < $4 UNION ALL SELECT change_xid FROM " + utils.ListTableName + " WHERE owner = $1 AND change_xid >= $2 AND change_xid < $4 UNION ALL SELECT change_xid FROM " + utils.TodoTombstoneTableName + " WHERE owner = $1 AND change_xid >= $2 AND change_xid < $4) AS changes ORDER BY change_xid LIMIT 1 OFFSET $3 - 1"
+
+// GetChangedTodosQuery is the SQL query to retrieve a user's todos that changed between two cursors.
+const GetChangedTodosQuery = "SELECT " + utils.TodoSelectSchema + " FROM " + utils.TodoTableName + " WHERE owner = $1 AND change_xid >= $2 AND change_xid < $3 AND deleted_at IS NULL ORDER BY change_xid, version"
+
+// GetDeletedTodoIDsQuery is the SQL query to retrieve the IDs of a user's todos that were deleted between two cursors, from the soft deleted
+// todos and from the tombstones of the todos that left the table, such as for the archive. A tombstone whose ID is taken again by a live todo is left out.
+const GetDeletedTodoIDsQuery = "SELECT id FROM (SELECT id, change_xid, version FROM " + utils.TodoTableName + " WHERE owner = $1 AND change_xid >= $2 AND change_xid < $3 AND deleted_at IS NOT NULL UNION ALL SELECT id, change_xid, version FROM " + utils.TodoTombstoneTableName + " AS d WHERE owner = $1 AND change_xid >= $2 AND change_xid < $3 AND NOT EXISTS (SELECT 1 FROM " + utils.TodoTableName + " AS t WHERE t.id = d.id)) AS deleted ORDER BY change_xid, version"
+
+// GetChangedListsQuery is the SQL query to retrieve a user's lists that changed between two cursors.
+const GetChangedListsQuery = "SELECT " + utils.ListSelectSchema + " FROM " + utils.ListTableName + " WHERE owner = $1 AND change_xid >= $2 AND change_xid < $3 AND deleted_at IS NULL ORDER BY change_xid, version"
+
+// GetDeletedListIDsQuery is the SQL query to retrieve the IDs of a user's lists that were deleted between two cursors.
+const GetDeletedListIDsQuery = "SELECT id FROM " + utils.ListTableName + " WHERE owner = $1 AND change_xid >= $2 AND change_xid < $3 AND deleted_at IS NOT NULL ORDER BY change_xid, version"
+
+// GetTagsQuery is the SQL query to retrieve the distinct tags of a user's todos.
+const GetTagsQuery = "SELECT DISTINCT unnest(tags) AS tag FROM " + utils.TodoTableName + " WHERE owner = $1 AND deleted_at IS NULL ORDER BY tag"
+
+// CreateTodoQuery is the SQL query to insert a todo created offline, doing nothing if the ID is taken.
+const CreateTodoQuery = "INSERT INTO " + utils.TodoTableName + " (" + utils.TodoTableSchema + ") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) ON CONFLICT (id) DO NOTHING RETURNING " + utils.TodoSelectSchema
+
+// UpdateTodoQuery is the SQL query to replace the fields of a todo if its version still matches.
+const UpdateTodoQuery = "UPDATE " + utils.TodoTableName + " SET title = $1, description = $2, priority = $3, completed = $4, list_id = $5, due_at = $6, tags = $7, reminded_at = CASE WHEN due_at IS DISTINCT FROM $6 THEN NULL ELSE reminded_at END, snoozed_until = CASE WHEN due_at IS DISTINCT FROM $6 THEN NULL ELSE snoozed_until END WHERE id = $8 AND owner = $9 AND version = $10 AND deleted_at IS NULL RETURNING " + utils.TodoSelectSchema
+
+// DeleteTodoQuery is the SQL query to soft delete a todo if its version still matches.
+const DeleteTodoQuery = "UPDATE " + utils.TodoTableName + " SET deleted_at = NOW() WHERE id = $1 AND owner = $2 AND version = $3 AND deleted_at IS NULL RETURNING version"
+
+// GetTodoStateQuery is the SQL query to retrieve a user's todo, including a deleted one, to explain a conflict.
+const GetTodoStateQuery = "SELECT " + utils.TodoSelectSchema + ", deleted_at IS NOT NULL FROM " + utils.TodoTableName + " WHERE id = $1 AND owner = $2"
+
+// CreateListQuery is the SQL query to insert a list created offline, doing nothing if the ID is taken.
+const CreateListQuery = "INSERT INTO " + utils.ListTableName + " (" + utils.ListTableSchema + ") VALUES ($1, $2, $3, $4) ON CONFLICT (id) DO NOTHING RETURNING " + utils.ListSelectSchema
+
+// UpdateListQuery is the SQL query to rename a list if its version still matches.
+const UpdateListQuery = "UPDATE " + utils.ListTableName + " SET name = $1 WHERE id = $2 AND owner = $3 AND version = $4 AND deleted_at IS NULL RETURNING " + utils.ListSelectSchema
+
+// DeleteListQuery is the SQL query to soft delete a list if its version still matches.
+const DeleteListQuery = "UPDATE " + utils.ListTableName + " SET deleted_at = NOW() WHERE id = $1 AND owner = $2 AND version = $3 AND deleted_at IS NULL RETURNING version"
+
+// DetachListTodosQuery is the SQL query to move the todos of a deleted list out of it, returning the moved todos.
+const DetachListTodosQuery = "UPDATE " + utils.TodoTableName + " SET list_id = NULL WHERE list_id = $1 RETURNING id"
+
+// GetListStateQuery is the SQL query to retrieve a user's list, including a deleted one, to explain a conflict.
+const GetListStateQuery = "SELECT " + utils.ListSelectSchema + ", deleted_at IS NOT NULL FROM " + utils.ListTableName + " WHERE id = $1 AND owner = $2"
+
+// CheckListUsableQuery is the SQL query to check that a list belongs to the user and is not deleted.
+const CheckListUsableQuery = "SELECT EXISTS (SELECT 1 FROM " + utils.ListTableName + " WHERE id = $1 AND owner = $2 AND deleted_at IS NULL)"
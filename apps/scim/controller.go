@@ -0,0 +1,931 @@
+// This file defines the controllers of SCIM provisioning. The identity provider of a single sign-on connection calls the SCIM 2.0
+// Users endpoints with the SCIM token of the connection to create, update, deactivate, and delete the users of its workspace,
+// so that people who join or leave a company gain or lose access without an admin of the application doing anything.
+// A connection only sees the users it provisioned, and the roles it sets are the roles of the application.
+package scim
+
+// "database/sql" provides a generic SQL interface. It is used here to interact with the database.
+import (
+	"database/sql"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to decode the requests.
+	"encoding/json"
+	// "errors" provides functions for working with errors. It is used here to define and compare errors.
+	"errors"
+	// "net/mail" parses email addresses. It is used here to check the email addresses of users.
+	"net/mail"
+	// "regexp" provides regular expressions. It is used here to parse filters.
+	"regexp"
+	// "strconv" provides conversions to and from strings. It is used here to parse the paging parameters and the values of filters.
+	"strconv"
+	// "strings" provides functions for working with strings. It is used here to clean the attributes.
+	"strings"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to define the controllers.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to parse user IDs.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/apps/sso" is a local package that contains the single sign-on connections. It is used here to authenticate the identity providers.
+	"github.com/rahulcodepython/todo-backend/apps/sso"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models and queries.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database helpers.
+	"github.com/rahulcodepython/todo-backend/backend/database"
+	// "github.com/rahulcodepython/todo-backend/backend/dberr" is a local package that classifies database errors.
+	"github.com/rahulcodepython/todo-backend/backend/dberr"
+	// "github.com/rahulcodepython/todo-backend/backend/idgen" is a local package that creates new IDs.
+	"github.com/rahulcodepython/todo-backend/backend/idgen"
+	// "github.com/rahulcodepython/todo-backend/backend/outbox" is a local package that records domain events.
+	"github.com/rahulcodepython/todo-backend/backend/outbox"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// contentType is the media type of SCIM responses.
+const contentType = "application/scim+json"
+
+// defaultCount and maxCount are the default and largest number of users of a page.
+const (
+	// defaultCount is the number of users of a page when the request does not ask for one.
+	defaultCount = 100
+	// maxCount is the largest number of users of a page.
+	maxCount = 200
+)
+
+// maxNameLength is the number of characters the name of a user may have.
+const maxNameLength = 100
+
+// filterPattern matches the filters identity providers send to look a user up, such as userName eq "alice@example.com".
+var filterPattern = regexp.MustCompile(`(?i)^\s*(userName|externalId)\s+eq\s+"((?:[^"\\]|\\.)*)"\s*$`)
+
+// errInvalidValue is returned when an attribute of a request has a value that is not allowed.
+var errInvalidValue = errors.New("invalid value")
+
+// errNotFound is returned when the connection provisioned no user with an ID.
+var errNotFound = errors.New("user not found")
+
+// localKey is the type of the key of the connection of a request.
+type localKey int
+
+// connectionLocal is the key of the connection a request is authenticated as.
+const connectionLocal localKey = iota
+
+// SCIMController is a struct that holds the dependencies of the SCIM controllers.
+type SCIMController struct {
+	// cfg is the application configuration.
+	cfg *config.Config
+	// db is the database connection.
+	db *sql.DB
+	// clock tells the current time.
+	clock clock.Clock
+	// ids creates the IDs of new users.
+	ids idgen.IDGenerator
+}
+
+// NewSCIMControl creates a new SCIMController.
+// It takes the application configuration, database connection, clock, and ID generator as input.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @param clk clock.Clock - The clock that tells the current time.
+// @param ids idgen.IDGenerator - The generator of the IDs of new users.
+// @return *SCIMController - A pointer to the new SCIMController.
+func NewSCIMControl(cfg *config.Config, db *sql.DB, clk clock.Clock, ids idgen.IDGenerator) *SCIMController {
+	// A new SCIMController is returned.
+	return &SCIMController{
+		// The cfg field is set to the application configuration.
+		cfg: cfg,
+		// The db field is set to the database connection.
+		db: db,
+		// The clock field is set to the clock.
+		clock: clk,
+		// The ids field is set to the ID generator.
+		ids: ids,
+	}
+}
+
+// scimError sends a SCIM error.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param status int - The HTTP status code.
+// @param scimType string - The kind of a 400 or 409 error, or empty.
+// @param detail string - The description of the error.
+// @return error - An error if one occurred while sending the response.
+func scimError(c *fiber.Ctx, status int, scimType string, detail string) error {
+	// The error is sent with the SCIM media type.
+	return c.Status(status).JSON(ErrorResponse{Schemas: []string{errorSchema}, Status: strconv.Itoa(status), SCIMType: scimType, Detail: detail}, contentType)
+}
+
+// Authenticated is a middleware that authenticates the identity provider by the SCIM token of its connection.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SCIMController) Authenticated(c *fiber.Ctx) error {
+	// token is the bearer token of the "Authorization" header.
+	token, err := utils.ParseBearerToken(c.Get("Authorization"))
+	// This checks if the header has no bearer token.
+	if err != nil {
+		// If it has none, an unauthorized error is returned.
+		return scimError(c, fiber.StatusUnauthorized, "", "Authorization header must carry a bearer token")
+	}
+	// conn is the enabled connection of the token.
+	conn, err := sso.ConnectionBySCIMToken(c.UserContext(), sc.db, token)
+	// This checks if no enabled connection has the token.
+	if errors.Is(err, sql.ErrNoRows) {
+		// If none has, an unauthorized error is returned.
+		return scimError(c, fiber.StatusUnauthorized, "", "Invalid token")
+	}
+	// This checks if another error occurred while reading the connection.
+	if err != nil {
+		// If an error occurs, an internal server error is returned.
+		return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+	}
+	// The connection is stored in the local context.
+	c.Locals(connectionLocal, conn)
+	// c.Next() calls the next middleware in the chain.
+	return c.Next()
+}
+
+// currentConnection returns the connection a request is authenticated as.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return sso.Connection - The connection.
+// @return bool - False if the route is mounted without the Authenticated middleware.
+func currentConnection(c *fiber.Ctx) (sso.Connection, bool) {
+	// conn is the connection stored in the local context, if any.
+	conn, ok := c.Locals(connectionLocal).(sso.Connection)
+	// The connection is returned.
+	return conn, ok
+}
+
+// location returns the URL of a user.
+//
+// @param id uuid.UUID - The ID of the user.
+// @return string - The URL.
+func (sc *SCIMController) location(id uuid.UUID) string {
+	// The URL is built from the public address of the server.
+	return sc.cfg.Server.PublicURL + "/api/" + utils.APIVersion + "/scim/v2/Users/" + id.String()
+}
+
+// userScanner is implemented by *sql.Row and *sql.Rows.
+type userScanner interface {
+	// Scan copies the columns of the current row into the values pointed at by dest.
+	Scan(dest ...any) error
+}
+
+// scanUser scans a row selected with userColumns into a User struct.
+//
+// @param row userScanner - The row to be scanned.
+// @return User - The scanned user.
+// @return error - An error if one occurred.
+func scanUser(row userScanner) (User, error) {
+	// user is a new User struct.
+	var user User
+	// err is the result of scanning the row into the user struct.
+	err := row.Scan(&user.ID, &user.UserName, &user.ExternalID, &user.Name, &user.Email, &user.Role, &user.Active, &user.CreatedAt, &user.UpdatedAt)
+	// The scanned user and the error, if any, are returned.
+	return user, err
+}
+
+// primaryValue returns the value of the primary entry of a multi-valued attribute, or of its first entry if none is primary.
+//
+// @param values []multiValuedAttribute - The entries.
+// @return string - The value, or empty if there are no entries.
+func primaryValue(values []multiValuedAttribute) string {
+	// This iterates over the entries.
+	for _, value := range values {
+		// This checks if the entry is the primary one.
+		if value.Primary {
+			// If it is, its value is returned.
+			return value.Value
+		}
+	}
+	// This checks if there are entries.
+	if len(values) > 0 {
+		// If there are, the value of the first one is returned.
+		return values[0].Value
+	}
+	// There is no value.
+	return ""
+}
+
+// fullName returns the name of a user from the name attribute, joining the first and last names if there is no full name.
+//
+// @param name nameAttribute - The name attribute.
+// @return string - The name, or empty if the attribute is empty.
+func fullName(name nameAttribute) string {
+	// This checks if there is a full name.
+	if formatted := strings.TrimSpace(name.Formatted); formatted != "" {
+		// If there is, it is returned.
+		return formatted
+	}
+	// The first and last names are joined.
+	return strings.TrimSpace(strings.TrimSpace(name.GivenName) + " " + strings.TrimSpace(name.FamilyName))
+}
+
+// applyRequest replaces the attributes of a user with the ones of a create or replace request. The role is only replaced if
+// the request has roles, and an empty list of roles gives the user the default role of the connection.
+//
+// @param user *User - The user.
+// @param body *userRequest - The request.
+// @param conn sso.Connection - The connection.
+func applyRequest(user *User, body *userRequest, conn sso.Connection) {
+	// The userName and externalId are replaced.
+	user.UserName = strings.ToLower(strings.TrimSpace(body.UserName))
+	user.ExternalID = strings.TrimSpace(body.ExternalID)
+	// The email address is the primary one, or the userName, which is an email address for most identity providers.
+	user.Email = strings.ToLower(strings.TrimSpace(primaryValue(body.Emails)))
+	// This checks if the request has no email address.
+	if user.Email == "" {
+		// If it has none, the userName is used.
+		user.Email = user.UserName
+	}
+	// The name is the display name, or the name attribute.
+	user.Name = strings.TrimSpace(body.DisplayName)
+	// This checks if there is no display name.
+	if user.Name == "" {
+		// If there is none, the name attribute is used.
+		user.Name = fullName(body.Name)
+	}
+	// The user is active unless the request says otherwise.
+	user.Active = body.Active == nil || *body.Active
+	// This checks if the request has roles.
+	if body.Roles != nil {
+		// If it has, the role is the primary one, or the default role of the connection.
+		user.Role = strings.ToLower(strings.TrimSpace(primaryValue(*body.Roles)))
+		// This checks if the list of roles is empty.
+		if user.Role == "" {
+			// If it is, the default role is set.
+			user.Role = conn.DefaultRole
+		}
+	}
+}
+
+// validate checks the attributes of a user, and names the user after their email address if they have no name.
+//
+// @param user *User - The user.
+// @param conn sso.Connection - The connection.
+// @return error - An error wrapping errInvalidValue if an attribute is not allowed.
+func validate(user *User, conn sso.Connection) error {
+	// This checks if the userName is missing.
+	if user.UserName == "" {
+		// If it is, an error is returned.
+		return errors.Join(errInvalidValue, errors.New("userName is required"))
+	}
+	// This checks if the email address is not a bare address.
+	if address, err := mail.ParseAddress(user.Email); err != nil || address.Address != user.Email {
+		// If it is not, an error is returned.
+		return errors.Join(errInvalidValue, errors.New("a valid email address is required"))
+	}
+	// This checks if the email address is outside the domains of the connection.
+	if !conn.AllowsEmail(user.Email) {
+		// If it is, an error is returned.
+		return errors.Join(errInvalidValue, errors.New("the email address is outside the domains of the connection"))
+	}
+	// This checks if the role is not a role of the application.
+	if user.Role != users.RoleUser && user.Role != users.RoleAdmin {
+		// If it is not, an error is returned.
+		return errors.Join(errInvalidValue, errors.New("role must be user or admin"))
+	}
+	// This checks if the user has no name.
+	if user.Name == "" {
+		// If they have none, they are named after their email address.
+		user.Name = user.Email
+	}
+	// This checks if the name is too long.
+	if len([]rune(user.Name)) > maxNameLength {
+		// If it is, an error is returned.
+		return errors.Join(errInvalidValue, errors.New("name must be at most 100 characters"))
+	}
+	// No error is returned.
+	return nil
+}
+
+// writeError sends the SCIM error of an error of a write.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param err error - The error.
+// @return error - An error if one occurred while sending the response.
+func writeError(c *fiber.Ctx, err error) error {
+	// This checks the kind of the error.
+	switch {
+	// An attribute is not allowed.
+	case errors.Is(err, errInvalidValue):
+		// A bad request error is returned.
+		return scimError(c, fiber.StatusBadRequest, "invalidValue", err.Error())
+	// The connection provisioned no user with the ID.
+	case errors.Is(err, errNotFound):
+		// A not found error is returned.
+		return scimError(c, fiber.StatusNotFound, "", "User not found")
+	// The email address or the userName is taken.
+	case dberr.Is(err, dberr.ErrUniqueViolation):
+		// A conflict error is returned.
+		return scimError(c, fiber.StatusConflict, "uniqueness", "A user with this userName or email address already exists")
+	}
+	// For any other error, an internal server error is returned.
+	return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+}
+
+// parseUserId parses the ID of the user in the path.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return uuid.UUID - The ID.
+// @return error - errNotFound if the ID is not a UUID, since no user has it.
+func parseUserId(c *fiber.Ctx) (uuid.UUID, error) {
+	// id is the parsed ID.
+	id, err := uuid.Parse(c.Params("id"))
+	// This checks if the ID is not a UUID.
+	if err != nil {
+		// If it is not, no user has it.
+		return uuid.Nil, errNotFound
+	}
+	// The ID is returned.
+	return id, nil
+}
+
+// ListUsersController pages through the users the connection provisioned. Only the filters userName eq and externalId eq are
+// supported, which are the ones identity providers use to find out if a user exists.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SCIMController) ListUsersController(c *fiber.Ctx) error {
+	// conn is the connection of the request.
+	conn, ok := currentConnection(c)
+	// This checks if the request is not authenticated.
+	if !ok {
+		// If it is not, an unauthorized error is returned.
+		return scimError(c, fiber.StatusUnauthorized, "", "Authentication required")
+	}
+
+	// userName and externalId are the values of the filter, if there is one.
+	var userName, externalId string
+	// This checks if the request has a filter.
+	if filter := c.Query("filter"); filter != "" {
+		// match is the parsed filter.
+		match := filterPattern.FindStringSubmatch(filter)
+		// This checks if the filter is not supported.
+		if match == nil {
+			// If it is not, a bad request error is returned.
+			return scimError(c, fiber.StatusBadRequest, "invalidFilter", "Only userName eq and externalId eq filters are supported")
+		}
+		// value is the value of the filter, with its escapes resolved.
+		value, err := strconv.Unquote(`"` + match[2] + `"`)
+		// This checks if the value has an invalid escape.
+		if err != nil {
+			// If it has, a bad request error is returned.
+			return scimError(c, fiber.StatusBadRequest, "invalidFilter", "Invalid filter value")
+		}
+		// This checks which attribute is filtered.
+		if strings.EqualFold(match[1], "userName") {
+			// The userName is compared in lowercase, since it is stored so.
+			userName = strings.ToLower(value)
+		} else {
+			// The externalId is compared as is.
+			externalId = value
+		}
+	}
+
+	// startIndex is the 1-based index of the first user of the page.
+	startIndex := c.QueryInt("startIndex", 1)
+	// This checks if the index is before the first user.
+	if startIndex < 1 {
+		// If it is, the page starts at the first user.
+		startIndex = 1
+	}
+	// count is the number of users of the page, up to the largest page.
+	count := min(max(c.QueryInt("count", defaultCount), 0), maxCount)
+
+	// total is the number of users that match.
+	var total int
+	// This counts the users.
+	if err := sc.db.QueryRowContext(c.UserContext(), CountUsersQuery, conn.ID, userName, externalId).Scan(&total); err != nil {
+		// If an error occurs, an internal server error is returned.
+		return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+	}
+	// rows is the result of querying the database for the page.
+	rows, err := sc.db.QueryContext(c.UserContext(), GetUsersQuery, conn.ID, userName, externalId, count, startIndex-1)
+	// This checks if an error occurred while querying the database.
+	if err != nil {
+		// If an error occurs, an internal server error is returned.
+		return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+	}
+	// This defers closing the rows until the function returns.
+	defer rows.Close()
+
+	// resources are the users of the page.
+	resources := []UserResponse{}
+	// This iterates over the rows.
+	for rows.Next() {
+		// user is the user of the row.
+		user, err := scanUser(rows)
+		// This checks if an error occurred while scanning the row.
+		if err != nil {
+			// If an error occurs, an internal server error is returned.
+			return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+		}
+		// The user is appended to the page.
+		resources = append(resources, NewUserResponse(user, sc.location(user.ID)))
+	}
+	// This checks if an error occurred while iterating over the rows.
+	if err := rows.Err(); err != nil {
+		// If an error occurs, an internal server error is returned.
+		return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+	}
+
+	// The page is sent.
+	return c.JSON(ListResponse{Schemas: []string{listSchema}, TotalResults: total, StartIndex: startIndex, ItemsPerPage: len(resources), Resources: resources}, contentType)
+}
+
+// GetUserController returns a user the connection provisioned.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SCIMController) GetUserController(c *fiber.Ctx) error {
+	// conn is the connection of the request.
+	conn, ok := currentConnection(c)
+	// This checks if the request is not authenticated.
+	if !ok {
+		// If it is not, an unauthorized error is returned.
+		return scimError(c, fiber.StatusUnauthorized, "", "Authentication required")
+	}
+	// id is the ID of the user.
+	id, err := parseUserId(c)
+	// This checks if the ID is invalid.
+	if err != nil {
+		// If it is, the error is sent.
+		return writeError(c, err)
+	}
+
+	// user is the user.
+	user, err := scanUser(sc.db.QueryRowContext(c.UserContext(), GetUserQuery, conn.ID, id))
+	// This checks if the connection provisioned no user with the ID.
+	if errors.Is(err, sql.ErrNoRows) {
+		// If it did not, a not found error is returned.
+		return writeError(c, errNotFound)
+	}
+	// This checks if another error occurred while reading the user.
+	if err != nil {
+		// If an error occurs, the error is sent.
+		return writeError(c, err)
+	}
+
+	// The user is sent.
+	return c.JSON(NewUserResponse(user, sc.location(user.ID)), contentType)
+}
+
+// CreateUserController creates a user without a password, who signs in through the connection, and links it to the connection.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SCIMController) CreateUserController(c *fiber.Ctx) error {
+	// conn is the connection of the request.
+	conn, ok := currentConnection(c)
+	// This checks if the request is not authenticated.
+	if !ok {
+		// If it is not, an unauthorized error is returned.
+		return scimError(c, fiber.StatusUnauthorized, "", "Authentication required")
+	}
+	// body is a new userRequest struct.
+	body := new(userRequest)
+	// This decodes the body, which is sent as application/scim+json and has attributes this endpoint ignores.
+	if err := json.Unmarshal(c.Body(), body); err != nil {
+		// If an error occurs, a bad request error is returned.
+		return scimError(c, fiber.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+
+	// now is the time the user is created.
+	now := sc.clock.Now()
+	// user is the new user, who has the default role of the connection unless the request has roles.
+	user := User{ID: sc.ids.NewID(), Role: conn.DefaultRole, CreatedAt: now, UpdatedAt: now}
+	// The attributes of the request are applied.
+	applyRequest(&user, body, conn)
+	// This checks the attributes.
+	if err := validate(&user, conn); err != nil {
+		// If one is not allowed, the error is sent.
+		return writeError(c, err)
+	}
+
+	// err is the result of creating the user and the link in one transaction.
+	err := database.WithTx(c.UserContext(), sc.db, func(tx *sql.Tx) error {
+		// The user is created without a password, so that they can only sign in through the connection.
+		if _, err := tx.ExecContext(c.UserContext(), users.CreateUserQuery, user.ID, user.Name, user.Email, nil, "", nil, now, now, "UTC", ""); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The role and state of the user are set.
+		if _, err := tx.ExecContext(c.UserContext(), UpdateUserQuery, user.ID, user.Name, user.Email, user.Role, user.Active, now); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The user is linked to the connection.
+		if _, err := tx.ExecContext(c.UserContext(), CreateSCIMUserQuery, user.ID, conn.ID, user.UserName, user.ExternalID, now); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The event is recorded, as for any other sign-up.
+		return outbox.Record(c.UserContext(), tx, outbox.UserRegistered, user.ID, user.ID, users.UserRegisteredEvent{ID: user.ID, Name: user.Name, Email: user.Email, Timezone: "UTC", CreatedAt: utils.ParseTime(now)})
+	})
+	// This checks if an error occurred while executing the transaction.
+	if err != nil {
+		// If an error occurs, the error is sent.
+		return writeError(c, err)
+	}
+
+	// The location of the user is set, and the user is sent.
+	c.Location(sc.location(user.ID))
+	return c.Status(fiber.StatusCreated).JSON(NewUserResponse(user, sc.location(user.ID)), contentType)
+}
+
+// ReplaceUserController replaces the attributes of a user the connection provisioned. Roles that are left out keep the role of the user.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SCIMController) ReplaceUserController(c *fiber.Ctx) error {
+	// body is a new userRequest struct.
+	body := new(userRequest)
+	// This decodes the body.
+	if err := json.Unmarshal(c.Body(), body); err != nil {
+		// If an error occurs, a bad request error is returned.
+		return scimError(c, fiber.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+	// The user is updated with the attributes of the request.
+	return sc.update(c, func(user *User, conn sso.Connection) error {
+		// The attributes are replaced.
+		applyRequest(user, body, conn)
+		// No error is returned.
+		return nil
+	})
+}
+
+// PatchUserController changes some attributes of a user the connection provisioned, such as active to deactivate them.
+// Attributes the application does not store are ignored.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SCIMController) PatchUserController(c *fiber.Ctx) error {
+	// body is a new patchRequest struct.
+	body := new(patchRequest)
+	// This decodes the body.
+	if err := json.Unmarshal(c.Body(), body); err != nil {
+		// If an error occurs, a bad request error is returned.
+		return scimError(c, fiber.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+	// The user is updated with the operations of the request.
+	return sc.update(c, func(user *User, conn sso.Connection) error {
+		// patch is the state of the operations.
+		patch := &patchState{user: user, conn: conn}
+		// This iterates over the operations.
+		for _, operation := range body.Operations {
+			// This applies the operation.
+			if err := patch.apply(operation); err != nil {
+				// If it cannot be applied, the error is returned.
+				return err
+			}
+		}
+		// The parts of the name are joined, if the operations set them.
+		patch.finish()
+		// No error is returned.
+		return nil
+	})
+}
+
+// update applies a change to a user the connection provisioned, in a transaction that locks the user, and sends the updated user.
+// A user who is deactivated loses every session and API key at once.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param change func(user *User, conn sso.Connection) error - The change, which returns an error wrapping errInvalidValue if it is not allowed.
+// @return error - An error if one occurred.
+func (sc *SCIMController) update(c *fiber.Ctx, change func(user *User, conn sso.Connection) error) error {
+	// conn is the connection of the request.
+	conn, ok := currentConnection(c)
+	// This checks if the request is not authenticated.
+	if !ok {
+		// If it is not, an unauthorized error is returned.
+		return scimError(c, fiber.StatusUnauthorized, "", "Authentication required")
+	}
+	// id is the ID of the user.
+	id, err := parseUserId(c)
+	// This checks if the ID is invalid.
+	if err != nil {
+		// If it is, the error is sent.
+		return writeError(c, err)
+	}
+
+	// user is the updated user.
+	var user User
+	// err is the result of updating the user in one transaction.
+	err = database.WithTx(c.UserContext(), sc.db, func(tx *sql.Tx) error {
+		// err is the result of reading and locking the user.
+		var err error
+		user, err = scanUser(tx.QueryRowContext(c.UserContext(), LockUserQuery, conn.ID, id))
+		// This checks if the connection provisioned no user with the ID.
+		if errors.Is(err, sql.ErrNoRows) {
+			// If it did not, an error is returned.
+			return errNotFound
+		}
+		// This checks if another error occurred while reading the user.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The change is applied.
+		if err := change(&user, conn); err != nil {
+			// If it is not allowed, the error is returned.
+			return err
+		}
+		// This checks the attributes.
+		if err := validate(&user, conn); err != nil {
+			// If one is not allowed, the error is returned.
+			return err
+		}
+
+		// now is the time of the update.
+		now := sc.clock.Now()
+		// The profile, role, and state of the user are updated.
+		if _, err := tx.ExecContext(c.UserContext(), UpdateUserQuery, user.ID, user.Name, user.Email, user.Role, user.Active, now); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The userName and externalId are updated.
+		if _, err := tx.ExecContext(c.UserContext(), UpdateSCIMUserQuery, conn.ID, user.ID, user.UserName, user.ExternalID); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// This checks if the user is deactivated.
+		if !user.Active {
+			// If they are, their tokens are deleted, so that they are signed out everywhere.
+			if _, err := tx.ExecContext(c.UserContext(), DeleteUserTokensQuery, user.ID); err != nil {
+				// If an error occurs, it is returned.
+				return err
+			}
+		}
+		// The user is read back.
+		user, err = scanUser(tx.QueryRowContext(c.UserContext(), GetUserQuery, conn.ID, id))
+		// The error, if any, is returned.
+		return err
+	})
+	// This checks if an error occurred while executing the transaction.
+	if err != nil {
+		// If an error occurs, the error is sent.
+		return writeError(c, err)
+	}
+
+	// The user is sent.
+	return c.JSON(NewUserResponse(user, sc.location(user.ID)), contentType)
+}
+
+// DeleteUserController deletes a user the connection provisioned, with their todos and lists.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SCIMController) DeleteUserController(c *fiber.Ctx) error {
+	// conn is the connection of the request.
+	conn, ok := currentConnection(c)
+	// This checks if the request is not authenticated.
+	if !ok {
+		// If it is not, an unauthorized error is returned.
+		return scimError(c, fiber.StatusUnauthorized, "", "Authentication required")
+	}
+	// id is the ID of the user.
+	id, err := parseUserId(c)
+	// This checks if the ID is invalid.
+	if err != nil {
+		// If it is, the error is sent.
+		return writeError(c, err)
+	}
+
+	// result is the result of deleting the user.
+	result, err := sc.db.ExecContext(c.UserContext(), DeleteUserQuery, conn.ID, id)
+	// This checks if an error occurred while deleting the user.
+	if err != nil {
+		// If an error occurs, the error is sent.
+		return writeError(c, err)
+	}
+	// This checks if no user was deleted.
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		// If none was, a not found error is returned.
+		return writeError(c, errNotFound)
+	}
+
+	// A no content status is returned.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// patchState applies the operations of a patch request to a user.
+type patchState struct {
+	// user is the user.
+	user *User
+	// conn is the connection.
+	conn sso.Connection
+	// givenName and familyName are the parts of the name the operations set, which are joined once every operation is applied.
+	givenName, familyName *string
+	// nameSet reports whether an operation set the full name, which takes precedence over its parts.
+	nameSet bool
+}
+
+// apply applies an operation.
+//
+// @param operation patchOperation - The operation.
+// @return error - An error wrapping errInvalidValue if the operation is not allowed.
+func (p *patchState) apply(operation patchOperation) error {
+	// This checks the kind of the operation.
+	switch strings.ToLower(operation.Op) {
+	case "add", "replace":
+		// This checks if the operation has no path.
+		if operation.Path == "" {
+			// attributes are the attributes of the value.
+			var attributes map[string]json.RawMessage
+			// This decodes the value.
+			if err := json.Unmarshal(operation.Value, &attributes); err != nil {
+				// If it is not an object, an error is returned.
+				return errors.Join(errInvalidValue, errors.New("an operation without a path needs an object value"))
+			}
+			// This iterates over the attributes.
+			for path, value := range attributes {
+				// This sets the attribute.
+				if err := p.set(path, value); err != nil {
+					// If it cannot be set, the error is returned.
+					return err
+				}
+			}
+			// No error is returned.
+			return nil
+		}
+		// The attribute of the path is set.
+		return p.set(operation.Path, operation.Value)
+	case "remove":
+		// This checks which attribute is removed.
+		switch strings.ToLower(operation.Path) {
+		case "externalid":
+			// The externalId is cleared.
+			p.user.ExternalID = ""
+		case "roles":
+			// The user gets the default role of the connection.
+			p.user.Role = p.conn.DefaultRole
+		default:
+			// Other attributes are required.
+			return errors.Join(errInvalidValue, errors.New("only externalId and roles can be removed"))
+		}
+		// No error is returned.
+		return nil
+	}
+	// Other kinds of operations do not exist.
+	return errors.Join(errInvalidValue, errors.New("op must be add, replace, or remove"))
+}
+
+// set sets an attribute of the user. Attributes the application does not store, such as phone numbers, are ignored.
+//
+// @param path string - The path of the attribute.
+// @param value json.RawMessage - The value.
+// @return error - An error wrapping errInvalidValue if the value does not fit the attribute.
+func (p *patchState) set(path string, value json.RawMessage) error {
+	// path is the path in lowercase, since attribute names are case-insensitive.
+	path = strings.ToLower(path)
+	// This checks which attribute is set.
+	switch {
+	case path == "username":
+		// The userName is set.
+		return decodeString(value, func(s string) { p.user.UserName = strings.ToLower(strings.TrimSpace(s)) })
+	case path == "externalid":
+		// The externalId is set.
+		return decodeString(value, func(s string) { p.user.ExternalID = strings.TrimSpace(s) })
+	case path == "displayname", path == "name.formatted":
+		// The full name is set.
+		p.nameSet = true
+		return decodeString(value, func(s string) { p.user.Name = strings.TrimSpace(s) })
+	case path == "name.givenname":
+		// The first name is kept until every operation is applied.
+		return decodeString(value, func(s string) { p.givenName = &s })
+	case path == "name.familyname":
+		// The last name is kept until every operation is applied.
+		return decodeString(value, func(s string) { p.familyName = &s })
+	case path == "name":
+		// name is the name attribute.
+		var name nameAttribute
+		// This decodes the value.
+		if err := json.Unmarshal(value, &name); err != nil {
+			// If it is not a name, an error is returned.
+			return errors.Join(errInvalidValue, errors.New("name must be an object"))
+		}
+		// The name is set.
+		p.nameSet = true
+		p.user.Name = fullName(name)
+		return nil
+	case path == "active":
+		// active is the value, which some identity providers send as a string.
+		var active any
+		// This decodes the value.
+		if err := json.Unmarshal(value, &active); err != nil {
+			// If it cannot be decoded, an error is returned.
+			return errors.Join(errInvalidValue, errors.New("active must be a boolean"))
+		}
+		// This checks the type of the value.
+		switch active := active.(type) {
+		case bool:
+			p.user.Active = active
+		case string:
+			// parsed is the parsed string.
+			parsed, err := strconv.ParseBool(active)
+			// This checks if the string is not a boolean.
+			if err != nil {
+				// If it is not, an error is returned.
+				return errors.Join(errInvalidValue, errors.New("active must be a boolean"))
+			}
+			p.user.Active = parsed
+		default:
+			// Other types are not booleans.
+			return errors.Join(errInvalidValue, errors.New("active must be a boolean"))
+		}
+		return nil
+	case strings.HasPrefix(path, "emails"):
+		// The email address is set from a list of emails, or from the value of a filtered path such as emails[type eq "work"].value.
+		return decodeValues(value, func(s string) { p.user.Email = strings.ToLower(strings.TrimSpace(s)) })
+	case strings.HasPrefix(path, "roles"):
+		// The role is set from a list of roles or a single role.
+		return decodeValues(value, func(s string) {
+			// role is the role, or the default role of the connection if it is empty.
+			role := strings.ToLower(strings.TrimSpace(s))
+			if role == "" {
+				role = p.conn.DefaultRole
+			}
+			p.user.Role = role
+		})
+	}
+	// Other attributes are not stored, and are ignored.
+	return nil
+}
+
+// finish joins the parts of the name the operations set, unless they also set the full name.
+func (p *patchState) finish() {
+	// This checks if no part of the name was set, or the full name was.
+	if p.nameSet || p.givenName == nil && p.familyName == nil {
+		// If so, the name is left as it is.
+		return
+	}
+	// name is the name attribute of the parts.
+	name := nameAttribute{}
+	// This checks if the first name was set.
+	if p.givenName != nil {
+		name.GivenName = *p.givenName
+	}
+	// This checks if the last name was set.
+	if p.familyName != nil {
+		name.FamilyName = *p.familyName
+	}
+	// The name is set from the parts.
+	p.user.Name = fullName(name)
+}
+
+// decodeString decodes a string value.
+//
+// @param value json.RawMessage - The value.
+// @param set func(s string) - The function that sets the attribute.
+// @return error - An error wrapping errInvalidValue if the value is not a string.
+func decodeString(value json.RawMessage, set func(s string)) error {
+	// s is the decoded string.
+	var s string
+	// This decodes the value.
+	if err := json.Unmarshal(value, &s); err != nil {
+		// If it is not a string, an error is returned.
+		return errors.Join(errInvalidValue, errors.New("value must be a string"))
+	}
+	// The attribute is set.
+	set(s)
+	return nil
+}
+
+// decodeValues decodes the value of a multi-valued attribute, which is a list of entries, a single entry, or a bare value.
+//
+// @param value json.RawMessage - The value.
+// @param set func(s string) - The function that sets the attribute to the primary value.
+// @return error - An error wrapping errInvalidValue if the value has none of these forms.
+func decodeValues(value json.RawMessage, set func(s string)) error {
+	// entries is the list of entries.
+	var entries []multiValuedAttribute
+	// This checks if the value is a list of entries.
+	if err := json.Unmarshal(value, &entries); err == nil {
+		// If it is, the primary value is set.
+		set(primaryValue(entries))
+		return nil
+	}
+	// entry is a single entry.
+	var entry multiValuedAttribute
+	// This checks if the value is a single entry.
+	if err := json.Unmarshal(value, &entry); err == nil {
+		// If it is, its value is set.
+		set(entry.Value)
+		return nil
+	}
+	// Otherwise the value must be a bare string.
+	return decodeString(value, set)
+}
@@ -0,0 +1,32 @@
+// This file defines the data model for the users that SCIM provisioning manages.
+package scim
+
+// "time" provides functions for working with time. It is used here to define the CreatedAt and UpdatedAt fields.
+import (
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to define the ID field.
+	"github.com/google/uuid"
+)
+
+// User is a user that the identity provider of a connection provisioned, as SCIM describes it.
+type User struct {
+	// ID is the ID of the user, which is also its SCIM id.
+	ID uuid.UUID
+	// UserName is the name the identity provider knows the user by, in lowercase, which is unique within the connection.
+	UserName string
+	// ExternalID is the ID of the user at the identity provider, or empty if it sent none.
+	ExternalID string
+	// Name is the name of the user.
+	Name string
+	// Email is the email address of the user.
+	Email string
+	// Role is the role of the user.
+	Role string
+	// Active reports whether the user can sign in.
+	Active bool
+	// CreatedAt is the time the user was created.
+	CreatedAt time.Time
+	// UpdatedAt is the time the user was last updated.
+	UpdatedAt time.Time
+}
@@ -0,0 +1,207 @@
+// This file defines the serializers for SCIM requests and responses, which follow the SCIM 2.0 schemas rather than the
+// response envelope of the rest of the API, so that identity providers can read them.
+package scim
+
+// "encoding/json" provides functions for encoding and decoding JSON. It is used here to hold the values of patch operations.
+import (
+	"encoding/json"
+
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions. It is used here to format times.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+const (
+	// userSchema is the schema of a user resource.
+	userSchema = "urn:ietf:params:scim:schemas:core:2.0:User"
+	// listSchema is the schema of a list response.
+	listSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	// errorSchema is the schema of an error response.
+	errorSchema = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// nameAttribute defines the structure of the name of a user.
+type nameAttribute struct {
+	// Formatted is the full name of the user.
+	// json:"formatted,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "formatted", and should be omitted if empty.
+	Formatted string `json:"formatted,omitempty"`
+	// GivenName is the first name of the user.
+	// json:"givenName,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "givenName", and should be omitted if empty.
+	GivenName string `json:"givenName,omitempty"`
+	// FamilyName is the last name of the user.
+	// json:"familyName,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "familyName", and should be omitted if empty.
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// multiValuedAttribute defines the structure of an entry of the emails or roles of a user.
+type multiValuedAttribute struct {
+	// Value is the email address or the role.
+	// json:"value" specifies that this field should be marshalled to/from a JSON object with the key "value".
+	Value string `json:"value"`
+	// Type is the kind of the entry, such as "work".
+	// json:"type,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "type", and should be omitted if empty.
+	Type string `json:"type,omitempty"`
+	// Primary reports whether the entry is the one to use.
+	// json:"primary,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "primary", and should be omitted if empty.
+	Primary bool `json:"primary,omitempty"`
+}
+
+// userRequest defines the structure for a create or replace user request.
+type userRequest struct {
+	// UserName is the name the identity provider knows the user by.
+	// json:"userName" specifies that this field should be marshalled to/from a JSON object with the key "userName".
+	UserName string `json:"userName"`
+	// ExternalID is the ID of the user at the identity provider.
+	// json:"externalId" specifies that this field should be marshalled to/from a JSON object with the key "externalId".
+	ExternalID string `json:"externalId"`
+	// Name is the name of the user.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name nameAttribute `json:"name"`
+	// DisplayName is the name of the user to show.
+	// json:"displayName" specifies that this field should be marshalled to/from a JSON object with the key "displayName".
+	DisplayName string `json:"displayName"`
+	// Emails are the email addresses of the user.
+	// json:"emails" specifies that this field should be marshalled to/from a JSON object with the key "emails".
+	Emails []multiValuedAttribute `json:"emails"`
+	// Active reports whether the user can sign in. It is a pointer so that an omitted field activates the user.
+	// json:"active" specifies that this field should be marshalled to/from a JSON object with the key "active".
+	Active *bool `json:"active"`
+	// Roles are the roles of the user. They are a pointer so that an omitted field keeps the role of the user.
+	// json:"roles" specifies that this field should be marshalled to/from a JSON object with the key "roles".
+	Roles *[]multiValuedAttribute `json:"roles"`
+}
+
+// patchRequest defines the structure for a patch user request.
+type patchRequest struct {
+	// Operations are the changes, applied in order.
+	// json:"Operations" specifies that this field should be marshalled to/from a JSON object with the key "Operations".
+	Operations []patchOperation `json:"Operations"`
+}
+
+// patchOperation defines the structure of a change of a patch request.
+type patchOperation struct {
+	// Op is "add", "replace", or "remove", in any case.
+	// json:"op" specifies that this field should be marshalled to/from a JSON object with the key "op".
+	Op string `json:"op"`
+	// Path is the attribute to change, or empty if the value holds the attributes.
+	// json:"path" specifies that this field should be marshalled to/from a JSON object with the key "path".
+	Path string `json:"path"`
+	// Value is the new value, whose type depends on the path.
+	// json:"value" specifies that this field should be marshalled to/from a JSON object with the key "value".
+	Value json.RawMessage `json:"value"`
+}
+
+// metaAttribute defines the structure of the metadata of a resource.
+type metaAttribute struct {
+	// ResourceType is always "User".
+	// json:"resourceType" specifies that this field should be marshalled to/from a JSON object with the key "resourceType".
+	ResourceType string `json:"resourceType"`
+	// Created is the time the user was created.
+	// json:"created" specifies that this field should be marshalled to/from a JSON object with the key "created".
+	Created string `json:"created"`
+	// LastModified is the time the user was last updated.
+	// json:"lastModified" specifies that this field should be marshalled to/from a JSON object with the key "lastModified".
+	LastModified string `json:"lastModified"`
+	// Location is the URL of the user.
+	// json:"location" specifies that this field should be marshalled to/from a JSON object with the key "location".
+	Location string `json:"location"`
+}
+
+// UserResponse defines the structure for a user resource.
+type UserResponse struct {
+	// Schemas lists the schema of the resource.
+	// json:"schemas" specifies that this field should be marshalled to/from a JSON object with the key "schemas".
+	Schemas []string `json:"schemas"`
+	// ID is the ID of the user.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID string `json:"id"`
+	// ExternalID is the ID of the user at the identity provider.
+	// json:"externalId,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "externalId", and should be omitted if empty.
+	ExternalID string `json:"externalId,omitempty"`
+	// UserName is the name the identity provider knows the user by.
+	// json:"userName" specifies that this field should be marshalled to/from a JSON object with the key "userName".
+	UserName string `json:"userName"`
+	// Name is the name of the user.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name nameAttribute `json:"name"`
+	// DisplayName is the name of the user to show.
+	// json:"displayName" specifies that this field should be marshalled to/from a JSON object with the key "displayName".
+	DisplayName string `json:"displayName"`
+	// Emails lists the email address of the user.
+	// json:"emails" specifies that this field should be marshalled to/from a JSON object with the key "emails".
+	Emails []multiValuedAttribute `json:"emails"`
+	// Active reports whether the user can sign in.
+	// json:"active" specifies that this field should be marshalled to/from a JSON object with the key "active".
+	Active bool `json:"active"`
+	// Roles lists the role of the user.
+	// json:"roles" specifies that this field should be marshalled to/from a JSON object with the key "roles".
+	Roles []multiValuedAttribute `json:"roles"`
+	// Meta is the metadata of the user.
+	// json:"meta" specifies that this field should be marshalled to/from a JSON object with the key "meta".
+	Meta metaAttribute `json:"meta"`
+}
+
+// NewUserResponse converts a provisioned user into its resource.
+//
+// @param user User - The user.
+// @param location string - The URL of the user.
+// @return UserResponse - The resource.
+func NewUserResponse(user User, location string) UserResponse {
+	// A new UserResponse struct is returned.
+	return UserResponse{
+		// The Schemas field is set to the schema of users.
+		Schemas: []string{userSchema},
+		// The ID field is set to the user's ID.
+		ID: user.ID.String(),
+		// The ExternalID field is set to the user's external ID.
+		ExternalID: user.ExternalID,
+		// The UserName field is set to the user's userName.
+		UserName: user.UserName,
+		// The Name field is set to the user's name.
+		Name: nameAttribute{Formatted: user.Name},
+		// The DisplayName field is set to the user's name.
+		DisplayName: user.Name,
+		// The Emails field is set to the user's email address, which is their only one.
+		Emails: []multiValuedAttribute{{Value: user.Email, Type: "work", Primary: true}},
+		// The Active field is set to whether the user can sign in.
+		Active: user.Active,
+		// The Roles field is set to the user's role.
+		Roles: []multiValuedAttribute{{Value: user.Role, Primary: true}},
+		// The Meta field is set to the metadata of the user.
+		Meta: metaAttribute{ResourceType: "User", Created: utils.ParseTime(user.CreatedAt), LastModified: utils.ParseTime(user.UpdatedAt), Location: location},
+	}
+}
+
+// ListResponse defines the structure for a page of users.
+type ListResponse struct {
+	// Schemas lists the schema of the response.
+	// json:"schemas" specifies that this field should be marshalled to/from a JSON object with the key "schemas".
+	Schemas []string `json:"schemas"`
+	// TotalResults is the number of users that match, on every page.
+	// json:"totalResults" specifies that this field should be marshalled to/from a JSON object with the key "totalResults".
+	TotalResults int `json:"totalResults"`
+	// StartIndex is the 1-based index of the first user of the page.
+	// json:"startIndex" specifies that this field should be marshalled to/from a JSON object with the key "startIndex".
+	StartIndex int `json:"startIndex"`
+	// ItemsPerPage is the number of users on the page.
+	// json:"itemsPerPage" specifies that this field should be marshalled to/from a JSON object with the key "itemsPerPage".
+	ItemsPerPage int `json:"itemsPerPage"`
+	// Resources are the users of the page.
+	// json:"Resources" specifies that this field should be marshalled to/from a JSON object with the key "Resources".
+	Resources []UserResponse `json:"Resources"`
+}
+
+// ErrorResponse defines the structure for a SCIM error.
+type ErrorResponse struct {
+	// Schemas lists the schema of the response.
+	// json:"schemas" specifies that this field should be marshalled to/from a JSON object with the key "schemas".
+	Schemas []string `json:"schemas"`
+	// Status is the HTTP status code, as a string.
+	// json:"status" specifies that this field should be marshalled to/from a JSON object with the key "status".
+	Status string `json:"status"`
+	// SCIMType is the kind of a 400 or 409 error, such as "uniqueness" or "invalidValue".
+	// json:"scimType,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "scimType", and should be omitted if empty.
+	SCIMType string `json:"scimType,omitempty"`
+	// Detail describes the error.
+	// json:"detail" specifies that this field should be marshalled to/from a JSON object with the key "detail".
+	Detail string `json:"detail"`
+}
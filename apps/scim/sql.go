@@ -0,0 +1,41 @@
+// This file defines the SQL queries used by SCIM provisioning.
+package scim
+
+// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names.
+import "github.com/rahulcodepython/todo-backend/backend/utils"
+
+// userColumns are the columns of a provisioned user, in the order scanUser reads them.
+const userColumns = "u.id, s.user_name, s.external_id, u.name, u.email, u.role, u.deactivated_at IS NULL, u.created_at, u.updated_at"
+
+// userJoin joins the provisioned users of a connection with their users.
+const userJoin = " FROM " + utils.SCIMUserTableName + " s JOIN " + utils.UserTableName + " u ON u.id = s.user_id"
+
+// GetUsersQuery is the SQL query to page through the users a connection provisioned, oldest first, optionally only the ones
+// with a userName or externalId.
+const GetUsersQuery = "SELECT " + userColumns + userJoin + " WHERE s.connection_id = $1 AND ($2 = '' OR s.user_name = $2) AND ($3 = '' OR s.external_id = $3) ORDER BY u.created_at, u.id LIMIT $4 OFFSET $5"
+
+// CountUsersQuery is the SQL query to count the users GetUsersQuery pages through.
+const CountUsersQuery = "SELECT COUNT(*)" + userJoin + " WHERE s.connection_id = $1 AND ($2 = '' OR s.user_name = $2) AND ($3 = '' OR s.external_id = $3)"
+
+// GetUserQuery is the SQL query to read a user a connection provisioned.
+const GetUserQuery = "SELECT " + userColumns + userJoin + " WHERE s.connection_id = $1 AND s.user_id = $2"
+
+// LockUserQuery is the SQL query to read a user a connection provisioned and lock it until the transaction ends, so that
+// two updates of the user are applied one after the other.
+const LockUserQuery = GetUserQuery + " FOR UPDATE OF s"
+
+// CreateSCIMUserQuery is the SQL query to link a new user to the connection that provisioned it.
+const CreateSCIMUserQuery = "INSERT INTO " + utils.SCIMUserTableName + " (user_id, connection_id, user_name, external_id, created_at) VALUES ($1, $2, $3, $4, $5)"
+
+// UpdateSCIMUserQuery is the SQL query to update the userName and externalId of a provisioned user.
+const UpdateSCIMUserQuery = "UPDATE " + utils.SCIMUserTableName + " SET user_name = $3, external_id = $4 WHERE connection_id = $1 AND user_id = $2"
+
+// UpdateUserQuery is the SQL query to update the profile, role, and state of a provisioned user. A user that is deactivated
+// keeps the time of its first deactivation.
+const UpdateUserQuery = "UPDATE " + utils.UserTableName + " SET name = $2, email = $3, role = $4, deactivated_at = CASE WHEN $5 THEN NULL ELSE COALESCE(deactivated_at, $6) END, updated_at = $6 WHERE id = $1"
+
+// DeleteUserTokensQuery is the SQL query to delete every token of a user, which ends their sessions and API keys.
+const DeleteUserTokensQuery = "DELETE FROM " + utils.JWTTableName + " WHERE user_id = $1"
+
+// DeleteUserQuery is the SQL query to delete a user a connection provisioned, with its todos and lists.
+const DeleteUserQuery = "DELETE FROM " + utils.UserTableName + " WHERE id = (SELECT user_id FROM " + utils.SCIMUserTableName + " WHERE connection_id = $1 AND user_id = $2)"
@@ -0,0 +1,435 @@
+// This file defines the controllers for service accounts, which let machines call the API without a person's password.
+package serviceaccounts
+
+// "database/sql" provides a generic SQL interface. It is used here to interact with the database.
+import (
+	"database/sql"
+	// "errors" provides functions for working with errors. It is used here to compare the scope errors.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to build the email address of an account.
+	"fmt"
+	// "strings" provides functions for working with strings. It is used here to clean names and join scopes.
+	"strings"
+	// "time" provides functions for working with time. It is used here to set timestamps.
+	"time"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to define the controllers.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to parse client IDs.
+	"github.com/google/uuid"
+	// "github.com/lib/pq" is the PostgreSQL driver. It is used here to read and write scopes.
+	"github.com/lib/pq"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models and scopes.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database helpers.
+	"github.com/rahulcodepython/todo-backend/backend/database"
+	// "github.com/rahulcodepython/todo-backend/backend/idgen" is a local package that creates new IDs.
+	"github.com/rahulcodepython/todo-backend/backend/idgen"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// grantClientCredentials is the only grant type of the token endpoint.
+const grantClientCredentials = "client_credentials"
+
+// secretPrefix starts every client secret, and tokenPrefix every token of a service account.
+const (
+	// secretPrefix is the prefix of client secrets.
+	secretPrefix = "tdsec_"
+	// tokenPrefix is the prefix of the tokens of service accounts.
+	tokenPrefix = "tds_"
+)
+
+// maxNameLength is the number of characters the name of a service account may have.
+const maxNameLength = 100
+
+// emailDomain is the domain of the email addresses of service accounts. The .invalid top-level domain is reserved and never resolves.
+const emailDomain = "service-accounts.invalid"
+
+// ServiceAccountController is a struct that holds the dependencies of the service account controllers.
+type ServiceAccountController struct {
+	// cfg is the application configuration.
+	cfg *config.Config
+	// db is the database connection.
+	db *sql.DB
+	// clock tells the current time.
+	clock clock.Clock
+	// ids creates the IDs of new accounts and tokens.
+	ids idgen.IDGenerator
+}
+
+// NewServiceAccountControl creates a new ServiceAccountController.
+// It takes the application configuration, database connection, clock, and ID generator as input.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @param clk clock.Clock - The clock that tells the current time.
+// @param ids idgen.IDGenerator - The generator of the IDs of new accounts and tokens.
+// @return *ServiceAccountController - A pointer to the new ServiceAccountController.
+func NewServiceAccountControl(cfg *config.Config, db *sql.DB, clk clock.Clock, ids idgen.IDGenerator) *ServiceAccountController {
+	// A new ServiceAccountController is returned.
+	return &ServiceAccountController{
+		// The cfg field is set to the application configuration.
+		cfg: cfg,
+		// The db field is set to the database connection.
+		db: db,
+		// The clock field is set to the clock.
+		clock: clk,
+		// The ids field is set to the ID generator.
+		ids: ids,
+	}
+}
+
+// newSecret creates a client secret and its hash.
+//
+// @return string - The secret.
+// @return string - The bcrypt hash of the secret, which is what is stored.
+// @return error - An error if one occurred.
+func newSecret() (string, string, error) {
+	// secret is the new secret.
+	secret, err := utils.CreateOpaqueToken(secretPrefix)
+	// This checks if the secret could not be created.
+	if err != nil {
+		// If it could not, the error is returned.
+		return "", "", err
+	}
+	// hash is the hash of the secret.
+	hash, err := utils.EncryptPassword(secret)
+	// The secret, its hash, and the error, if any, are returned.
+	return secret, hash, err
+}
+
+// CreateServiceAccountController creates a service account for the current user, with the scopes of the request.
+// The client secret is only shown in this response.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *ServiceAccountController) CreateServiceAccountController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// body is a new createServiceAccountRequest struct.
+	body := new(createServiceAccountRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+
+	// name is the name of the account without surrounding whitespace.
+	name := strings.TrimSpace(body.Name)
+	// This checks if the name is empty or too long.
+	if name == "" || len([]rune(name)) > maxNameLength {
+		// If it is, a bad request response is returned.
+		return response.BadResponse(c, "Service account name must be between 1 and 100 characters")
+	}
+	// scopes is the checked list of scopes.
+	scopes, err := users.ValidateScopes(body.Scopes)
+	// This checks if no scope is given.
+	if errors.Is(err, users.ErrNoScopes) {
+		// If none is, a bad request response is returned.
+		return response.BadResponse(c, "At least one scope is required")
+	}
+	// This checks if a scope does not exist.
+	if err != nil {
+		// If one does not, a bad request response is returned with the scope in the error.
+		return response.BadInternalResponse(c, err, "Invalid scope")
+	}
+
+	// secret and hash are the client secret and its hash.
+	secret, hash, err := newSecret()
+	// This checks if the secret could not be created.
+	if err != nil {
+		// If it could not, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to create service account")
+	}
+
+	// account is the new service account.
+	account := ServiceAccount{
+		// The ID field is set to a new ID, which is also the client ID.
+		ID: sc.ids.NewID(),
+		// The Name field is set to the cleaned name.
+		Name: name,
+		// The Scopes field is set to the checked scopes.
+		Scopes: scopes,
+		// The Secret field is set to the new secret.
+		Secret: secret,
+		// The CreatedAt field is set to the current time.
+		CreatedAt: sc.clock.Now(),
+	}
+	// email is the placeholder email address of the account.
+	email := fmt.Sprintf("%s@%s", account.ID, emailDomain)
+
+	// This creates the user and the credentials of the account.
+	if _, err := sc.db.ExecContext(c.UserContext(), CreateServiceAccountQuery, account.ID, account.Name, email, account.CreatedAt, user.ID, hash, pq.Array(account.Scopes)); err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to create service account")
+	}
+
+	// A created response is returned with a success message and the account.
+	return response.OKCreatedResponse(c, "Service account created successfully", NewServiceAccountResponse(account))
+}
+
+// ServiceAccountsController lists the service accounts the current user created, without their secrets.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *ServiceAccountController) ServiceAccountsController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// rows is the result of querying the database for the accounts.
+	rows, err := sc.db.QueryContext(c.UserContext(), GetServiceAccountsQuery, user.ID)
+	// This checks if an error occurred while querying the database.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to fetch service accounts")
+	}
+	// This defers closing the rows.
+	defer rows.Close()
+
+	// accounts is the list of response structures of the accounts.
+	accounts := []ServiceAccountResponse{}
+	// This iterates over the rows.
+	for rows.Next() {
+		// account is the account of the current row.
+		var account ServiceAccount
+		// This scans the row into the account.
+		if err := rows.Scan(&account.ID, &account.Name, pq.Array(&account.Scopes), &account.CreatedAt); err != nil {
+			// If an error occurs, an internal server error response is returned.
+			return response.InternelServerError(c, err, "Unable to fetch service accounts")
+		}
+		// The response structure of the account is appended.
+		accounts = append(accounts, NewServiceAccountResponse(account))
+	}
+	// This checks if an error occurred while iterating over the rows.
+	if err := rows.Err(); err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to fetch service accounts")
+	}
+
+	// An OK response is returned with a success message and the accounts.
+	return response.OKResponse(c, "Service accounts fetched successfully", accounts)
+}
+
+// RotateSecretController replaces the client secret of a service account of the current user and ends the tokens of the account,
+// so that a leaked secret stops working at once. The new secret is only shown in this response.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *ServiceAccountController) RotateSecretController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// account is the account whose secret is rotated.
+	var account ServiceAccount
+	// This parses the ID of the account.
+	account.ID, err = uuid.Parse(c.Params("id"))
+	// This checks if the ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid service account id")
+	}
+
+	// hash is the hash of the new secret.
+	var hash string
+	// This creates the new secret.
+	account.Secret, hash, err = newSecret()
+	// This checks if the secret could not be created.
+	if err != nil {
+		// If it could not, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to rotate service account secret")
+	}
+
+	// This replaces the secret and deletes the tokens issued with the old one in one transaction.
+	err = database.WithTx(c.UserContext(), sc.db, func(tx *sql.Tx) error {
+		// This replaces the secret, reading back the account.
+		if err := tx.QueryRowContext(c.UserContext(), UpdateServiceAccountSecretQuery, account.ID, user.ID, hash).Scan(&account.Name, pq.Array(&account.Scopes), &account.CreatedAt); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// _, err is the result of deleting the tokens of the account.
+		_, err := tx.ExecContext(c.UserContext(), DeleteServiceAccountTokensQuery, account.ID)
+		// The error, if any, is returned.
+		return err
+	})
+	// This checks if the user has no such account.
+	if errors.Is(err, sql.ErrNoRows) {
+		// If the user has none, a not found response is returned.
+		return response.NotFound(c, nil, "Service account not found")
+	}
+	// This checks if another error occurred.
+	if err != nil {
+		// If one did, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to rotate service account secret")
+	}
+
+	// An OK response is returned with a success message and the account with its new secret.
+	return response.OKResponse(c, "Service account secret rotated successfully", NewServiceAccountResponse(account))
+}
+
+// DeleteServiceAccountController deletes a service account of the current user, with its tokens, todos, and lists.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *ServiceAccountController) DeleteServiceAccountController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// accountId is the parsed value of the "id" path parameter.
+	accountId, err := uuid.Parse(c.Params("id"))
+	// This checks if the ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid service account id")
+	}
+
+	// result is the result of deleting the account.
+	result, err := sc.db.ExecContext(c.UserContext(), DeleteServiceAccountQuery, accountId, user.ID)
+	// This checks if an error occurred while deleting the account.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to delete service account")
+	}
+	// deleted is the number of deleted accounts.
+	deleted, err := result.RowsAffected()
+	// This checks if an error occurred while reading the number of deleted accounts.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to delete service account")
+	}
+	// This checks if the user has no such account.
+	if deleted == 0 {
+		// If the user has none, a not found response is returned.
+		return response.NotFound(c, nil, "Service account not found")
+	}
+
+	// An OK response is returned with a success message.
+	return response.OKResponse(c, "Service account deleted successfully", nil)
+}
+
+// TokenController exchanges the client credentials of a service account for a token limited to the scopes of the account.
+// The credentials are read from an HTTP Basic Authorization header, or else from the body, as OAuth 2.0 clients send them.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *ServiceAccountController) TokenController(c *fiber.Ctx) error {
+	// body is a new tokenRequest struct.
+	body := new(tokenRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+	// This checks if the grant type is not client credentials.
+	if body.GrantType != grantClientCredentials {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Unsupported grant type. Use client_credentials")
+	}
+	// This checks if the credentials are sent in an HTTP Basic Authorization header.
+	if clientId, clientSecret, ok := utils.ParseBasicAuth(c.Get(fiber.HeaderAuthorization)); ok {
+		// If they are, they take the place of the ones in the body.
+		body.ClientID, body.ClientSecret = clientId, clientSecret
+	}
+
+	// accountId is the parsed client ID.
+	accountId, err := uuid.Parse(body.ClientID)
+	// This checks if the client ID is not a valid UUID.
+	if err != nil {
+		// If it is not, an unauthorized access response is returned, the same as for a wrong secret.
+		return response.UnauthorizedAccess(c, nil, "Invalid client credentials")
+	}
+
+	// hash is the hash of the secret of the account.
+	var hash string
+	// scopes is the list of scopes of the account.
+	var scopes []string
+	// err is the result of reading the credentials of the account.
+	err = sc.db.QueryRowContext(c.UserContext(), GetServiceAccountSecretQuery, accountId).Scan(&hash, pq.Array(&scopes))
+	// This checks if there is no such account.
+	if err == sql.ErrNoRows {
+		// If there is none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, nil, "Invalid client credentials")
+	}
+	// This checks if another error occurred.
+	if err != nil {
+		// If one did, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to issue token")
+	}
+	// This checks if the secret does not match.
+	if !utils.CompareEncryptedPassword(hash, body.ClientSecret) {
+		// If it does not, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, nil, "Invalid client credentials")
+	}
+
+	// token is the new token.
+	token, err := utils.CreateOpaqueToken(tokenPrefix)
+	// This checks if the token could not be created.
+	if err != nil {
+		// If it could not, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to issue token")
+	}
+	// now is the time the token is issued.
+	now := sc.clock.Now()
+
+	// This removes the expired tokens of the account and stores the new one, so that an account asking for a token per job does not pile them up.
+	err = database.WithTx(c.UserContext(), sc.db, func(tx *sql.Tx) error {
+		// This deletes the expired tokens of the account.
+		if _, err := tx.ExecContext(c.UserContext(), users.DeleteExpiredJWTsQuery, accountId, now); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// _, err is the result of storing the new token.
+		_, err := tx.ExecContext(c.UserContext(), CreateServiceTokenQuery, sc.ids.NewID(), token, now.Add(sc.cfg.JWT.ServiceTokenExpires), accountId, pq.Array(scopes))
+		// The error, if any, is returned.
+		return err
+	})
+	// This checks if an error occurred while storing the token.
+	if err != nil {
+		// If one did, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to issue token")
+	}
+
+	// An OK response is returned with a success message and the token.
+	return response.OKResponse(c, "Token issued successfully", TokenResponse{
+		// The AccessToken field is set to the new token.
+		AccessToken: token,
+		// The TokenType field tells the client to send it as a bearer token.
+		TokenType: "Bearer",
+		// The ExpiresIn field is set to the lifetime of the token in seconds.
+		ExpiresIn: int64(sc.cfg.JWT.ServiceTokenExpires / time.Second),
+		// The Scope field is set to the scopes of the token.
+		Scope: strings.Join(scopes, " "),
+	})
+}
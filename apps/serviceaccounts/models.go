@@ -0,0 +1,24 @@
+// This file defines the data model for service accounts.
+package serviceaccounts
+
+// "time" provides functions for working with time. It is used here to define the CreatedAt field.
+import (
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to define the ID field.
+	"github.com/google/uuid"
+)
+
+// ServiceAccount represents a user without a password that a machine authenticates as with client credentials.
+type ServiceAccount struct {
+	// ID is the ID of the user of the account, which is also its client ID.
+	ID uuid.UUID
+	// Name is the name of the account.
+	Name string
+	// Scopes is the list of scopes the tokens of the account are limited to.
+	Scopes []string
+	// Secret is the client secret. It is only known when the account is created or its secret is rotated, since only its hash is stored.
+	Secret string
+	// CreatedAt is the time the account was created.
+	CreatedAt time.Time
+}
@@ -0,0 +1,90 @@
+// This file defines the serializers for service account requests and responses.
+package serviceaccounts
+
+// "github.com/google/uuid" is a package for working with UUIDs. It is used here to define the client ID in the response struct.
+import (
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions. It is used here to format times.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// createServiceAccountRequest defines the structure for a create service account request.
+type createServiceAccountRequest struct {
+	// Name is the name of the account, such as the system that uses it.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+	// Scopes is the list of scopes the tokens of the account are limited to.
+	// json:"scopes" specifies that this field should be marshalled to/from a JSON object with the key "scopes".
+	Scopes []string `json:"scopes"`
+}
+
+// tokenRequest defines the structure for a client credentials token request, sent as JSON or as a form like OAuth 2.0 clients do.
+type tokenRequest struct {
+	// GrantType must be "client_credentials".
+	// json:"grant_type" specifies that this field should be marshalled to/from a JSON object with the key "grant_type".
+	// form:"grant_type" specifies that this field is bound to the "grant_type" form field.
+	GrantType string `json:"grant_type" form:"grant_type"`
+	// ClientID is the ID of the service account. It may be sent in an HTTP Basic Authorization header instead.
+	// json:"client_id" specifies that this field should be marshalled to/from a JSON object with the key "client_id".
+	// form:"client_id" specifies that this field is bound to the "client_id" form field.
+	ClientID string `json:"client_id" form:"client_id"`
+	// ClientSecret is the secret of the service account. It may be sent in an HTTP Basic Authorization header instead.
+	// json:"client_secret" specifies that this field should be marshalled to/from a JSON object with the key "client_secret".
+	// form:"client_secret" specifies that this field is bound to the "client_secret" form field.
+	ClientSecret string `json:"client_secret" form:"client_secret"`
+}
+
+// ServiceAccountResponse defines the structure for a service account.
+type ServiceAccountResponse struct {
+	// ClientID is the ID of the account, which it authenticates with and which owns its todos.
+	// json:"client_id" specifies that this field should be marshalled to/from a JSON object with the key "client_id".
+	ClientID uuid.UUID `json:"client_id"`
+	// Name is the name of the account.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+	// Scopes is the list of scopes the tokens of the account are limited to.
+	// json:"scopes" specifies that this field should be marshalled to/from a JSON object with the key "scopes".
+	Scopes []string `json:"scopes"`
+	// ClientSecret is the secret of the account, which is only included when the account is created or its secret is rotated.
+	// json:"client_secret,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "client_secret", and should be omitted if empty.
+	ClientSecret string `json:"client_secret,omitempty"`
+	// CreatedAt is the time the account was created.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt string `json:"created_at"`
+}
+
+// NewServiceAccountResponse converts a service account into its response structure.
+//
+// @param account ServiceAccount - The account.
+// @return ServiceAccountResponse - The response structure.
+func NewServiceAccountResponse(account ServiceAccount) ServiceAccountResponse {
+	// A new ServiceAccountResponse struct is returned.
+	return ServiceAccountResponse{
+		// The ClientID field is set to the account's ID.
+		ClientID: account.ID,
+		// The Name field is set to the account's name.
+		Name: account.Name,
+		// The Scopes field is set to the account's scopes.
+		Scopes: account.Scopes,
+		// The ClientSecret field is set to the secret, which is empty unless it was just created.
+		ClientSecret: account.Secret,
+		// The CreatedAt field is set to the time the account was created.
+		CreatedAt: utils.ParseTime(account.CreatedAt),
+	}
+}
+
+// TokenResponse defines the structure for the token of a service account, named after the OAuth 2.0 token response.
+type TokenResponse struct {
+	// AccessToken is the token, which is sent as a bearer token.
+	// json:"access_token" specifies that this field should be marshalled to/from a JSON object with the key "access_token".
+	AccessToken string `json:"access_token"`
+	// TokenType is always "Bearer".
+	// json:"token_type" specifies that this field should be marshalled to/from a JSON object with the key "token_type".
+	TokenType string `json:"token_type"`
+	// ExpiresIn is the lifetime of the token in seconds.
+	// json:"expires_in" specifies that this field should be marshalled to/from a JSON object with the key "expires_in".
+	ExpiresIn int64 `json:"expires_in"`
+	// Scope is the scopes of the token, separated by spaces.
+	// json:"scope" specifies that this field should be marshalled to/from a JSON object with the key "scope".
+	Scope string `json:"scope"`
+}
@@ -0,0 +1,35 @@
+// This file defines the SQL queries used by service accounts.
+package serviceaccounts
+
+// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models. It is used here for the kinds of users and tokens.
+import (
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names and schemas.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// CreateServiceAccountQuery is the SQL query to create the user of a service account and its credentials in one statement.
+// The user has an email address under the reserved .invalid domain, since the column is required but no mail can be sent to a machine,
+// and an empty password, which no password matches.
+const CreateServiceAccountQuery = `WITH account AS (
+	INSERT INTO ` + utils.UserTableName + ` (id, name, email, image, password, created_at, updated_at, kind) VALUES ($1, $2, $3, NULL, '', $4, $4, '` + users.KindService + `') RETURNING id
+) INSERT INTO ` + utils.ServiceAccountTableName + ` (user_id, owner_id, secret_hash, scopes, created_at) SELECT id, $5, $6, $7, $4 FROM account`
+
+// GetServiceAccountsQuery is the SQL query to list the service accounts a user created, newest first.
+const GetServiceAccountsQuery = "SELECT u.id, u.name, s.scopes, s.created_at FROM " + utils.ServiceAccountTableName + " s JOIN " + utils.UserTableName + " u ON u.id = s.user_id WHERE s.owner_id = $1 ORDER BY s.created_at DESC"
+
+// GetServiceAccountSecretQuery is the SQL query to read the secret hash and scopes of a service account by its client ID.
+const GetServiceAccountSecretQuery = "SELECT secret_hash, scopes FROM " + utils.ServiceAccountTableName + " WHERE user_id = $1"
+
+// UpdateServiceAccountSecretQuery is the SQL query to replace the secret of a service account of a user, returning the account.
+const UpdateServiceAccountSecretQuery = "UPDATE " + utils.ServiceAccountTableName + " s SET secret_hash = $3 FROM " + utils.UserTableName + " u WHERE s.user_id = $1 AND s.owner_id = $2 AND u.id = s.user_id RETURNING u.name, s.scopes, s.created_at"
+
+// DeleteServiceAccountTokensQuery is the SQL query to delete every token of a service account.
+const DeleteServiceAccountTokensQuery = "DELETE FROM " + utils.JWTTableName + " WHERE user_id = $1"
+
+// DeleteServiceAccountQuery is the SQL query to delete a service account of a user. Deleting the user of the account deletes
+// its credentials, tokens, todos, and lists with it.
+const DeleteServiceAccountQuery = "DELETE FROM " + utils.UserTableName + " WHERE id = (SELECT user_id FROM " + utils.ServiceAccountTableName + " WHERE user_id = $1 AND owner_id = $2)"
+
+// CreateServiceTokenQuery is the SQL query to issue a token to a service account, limited to the scopes of the account.
+const CreateServiceTokenQuery = "INSERT INTO " + utils.JWTTableName + " (" + utils.ScopedTokenInsertSchema + ") VALUES ($1, $2, $3, $4, '" + users.TokenKindService + "', '', $5)"
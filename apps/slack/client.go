@@ -0,0 +1,65 @@
+// This file defines the calls the integration makes to the Slack Web API.
+package slack
+
+// "encoding/json" provides functions for encoding and decoding JSON. It is used here to decode the API response.
+import (
+	"encoding/json"
+	// "fmt" provides functions for formatted I/O. It is used here to build errors.
+	"fmt"
+	// "net/http" provides HTTP client and server implementations. It is used here to call the Web API.
+	"net/http"
+	// "net/url" provides functions for working with URLs. It is used here to encode the form body.
+	"net/url"
+	// "time" provides functions for working with time. It is used here to set the request timeout.
+	"time"
+
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+// oauthAccessURL is the URL of the Slack oauth.v2.access method.
+const oauthAccessURL = "https://slack.com/api/oauth.v2.access"
+
+// httpClient is the HTTP client used for Web API calls, with a timeout so that a slow API does not block the request.
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// exchangeCode exchanges an OAuth code for the installation details.
+//
+// @param cfg *config.Config - The application configuration.
+// @param code string - The OAuth code sent to the redirect URL.
+// @return oauthAccessResponse - The installation details.
+// @return error - An error if one occurred.
+func exchangeCode(cfg *config.Config, code string) (oauthAccessResponse, error) {
+	// access is a variable that will hold the decoded response.
+	var access oauthAccessResponse
+
+	// res is the response of the Web API.
+	res, err := httpClient.PostForm(oauthAccessURL, url.Values{
+		"client_id":     {cfg.Slack.ClientID},
+		"client_secret": {cfg.Slack.ClientSecret},
+		"code":          {code},
+		"redirect_uri":  {cfg.Slack.RedirectURL},
+	})
+	// This checks if an error occurred while calling the API.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return access, err
+	}
+	// This defers the closing of the response body until the function returns.
+	defer res.Body.Close()
+
+	// This decodes the response body.
+	if err := json.NewDecoder(res.Body).Decode(&access); err != nil {
+		// If an error occurs, it is returned.
+		return access, err
+	}
+
+	// This checks if Slack rejected the code.
+	if !access.OK {
+		// If it did, an error with Slack's error code is returned.
+		return access, fmt.Errorf("slack oauth.v2.access failed: %s", access.Error)
+	}
+
+	// The installation details are returned.
+	return access, nil
+}
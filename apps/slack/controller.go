@@ -0,0 +1,527 @@
+// This file defines the controllers for the Slack integration.
+package slack
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to bound the queries of a slash command by its deadline.
+import (
+	"context"
+	// "crypto/hmac" provides HMAC signatures. It is used here to verify requests sent by Slack.
+	"crypto/hmac"
+	// "crypto/sha256" provides the SHA-256 hash. It is used here to verify requests sent by Slack.
+	"crypto/sha256"
+	// "database/sql" provides a generic SQL interface. It is used here to interact with the database.
+	"database/sql"
+	// "encoding/hex" provides hexadecimal encoding. It is used here to encode the expected signature.
+	"encoding/hex"
+	// "errors" provides functions for working with errors. It is used here to compare errors.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to build command replies.
+	"fmt"
+	// "net/url" provides functions for working with URLs. It is used here to build the install URL.
+	"net/url"
+	// "strconv" provides functions for converting strings. It is used here to parse timestamps and todo numbers.
+	"strconv"
+	// "strings" provides functions for working with strings. It is used here to parse commands.
+	"strings"
+	// "time" provides functions for working with time. It is used here to check request timestamps.
+	"time"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to define the controllers.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/golang-jwt/jwt/v5" is a package for creating and verifying JWTs. It is used here to sign the OAuth state.
+	"github.com/golang-jwt/jwt/v5"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to hold user IDs.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/apps/todos" is a local package that creates, lists, and completes todos.
+	"github.com/rahulcodepython/todo-backend/apps/todos"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models and queries.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database helpers.
+	"github.com/rahulcodepython/todo-backend/backend/database"
+	// "github.com/rahulcodepython/todo-backend/backend/i18n" is a local package that translates messages.
+	"github.com/rahulcodepython/todo-backend/backend/i18n"
+	// "github.com/rahulcodepython/todo-backend/backend/quota" is a local package that enforces usage limits.
+	"github.com/rahulcodepython/todo-backend/backend/quota"
+	// "github.com/rahulcodepython/todo-backend/backend/reqlog" is a local package that provides the logger of a request. It is used here to log command errors with the request they belong to.
+	"github.com/rahulcodepython/todo-backend/backend/reqlog"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+)
+
+// authorizeURL is the URL of the Slack OAuth authorization page.
+const authorizeURL = "https://slack.com/oauth/v2/authorize"
+
+// stateTTL is how long an OAuth state stays valid.
+const stateTTL = 10 * time.Minute
+
+// statePurpose is the purpose claim of OAuth states, so that login tokens are not accepted as states.
+const statePurpose = "slack_install"
+
+// maxRequestAge is the maximum age of a signed Slack request, to prevent replays.
+const maxRequestAge = 5 * time.Minute
+
+// listLimit is the maximum number of todos shown by "/todo list".
+const listLimit = 20
+
+// usageText is the reply sent for unknown subcommands.
+const usageText = "Usage: `/todo add <text>`, `/todo list`, `/todo done <number from /todo list>`"
+
+// SlackController is a struct that holds the configuration, database connection, and todo service.
+type SlackController struct {
+	// cfg is the application configuration.
+	cfg *config.Config
+	// db is the database connection.
+	db *sql.DB
+	// todoService is the todo service that the commands act through.
+	todoService *todos.TodoService
+}
+
+// NewSlackControl creates a new SlackController.
+// It takes the application configuration, database connection, and todo service as input.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @param todoService *todos.TodoService - The todo service.
+// @return *SlackController - A pointer to the new SlackController.
+func NewSlackControl(cfg *config.Config, db *sql.DB, todoService *todos.TodoService) *SlackController {
+	// A new SlackController is returned.
+	return &SlackController{
+		// The cfg field is set to the application configuration.
+		cfg: cfg,
+		// The db field is set to the database connection.
+		db: db,
+		// The todoService field is set to the todo service.
+		todoService: todoService,
+	}
+}
+
+// InstallController returns the Slack authorization URL that installs the app and connects the current user's Slack account.
+// Every member of a workspace runs it once so that their slash commands act on their own todos.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SlackController) InstallController(c *fiber.Ctx) error {
+	// This checks if the integration is disabled.
+	if sc.cfg.Slack.SigningSecret == "" || sc.cfg.Slack.ClientID == "" {
+		// If it is, a not found response is returned.
+		return response.NotFound(c, nil, "Slack integration is not configured")
+	}
+
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// state is a signed token that ties the callback to the current user.
+	state, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		// "user_id" is a claim that stores the user's ID.
+		"user_id": user.ID.String(),
+		// "purpose" is a claim that restricts the token to the Slack install flow.
+		"purpose": statePurpose,
+		// "exp" is a claim that stores the expiration time of the state.
+		"exp": time.Now().Add(stateTTL).Unix(),
+	}).SignedString([]byte(sc.cfg.JWT.SecretKey))
+	// This checks if an error occurred while signing the state.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to create install URL")
+	}
+
+	// query is the query string of the authorization URL.
+	query := url.Values{
+		"client_id":    {sc.cfg.Slack.ClientID},
+		"scope":        {"commands"},
+		"redirect_uri": {sc.cfg.Slack.RedirectURL},
+		"state":        {state},
+	}
+
+	// An OK response is returned with the authorization URL.
+	return response.OKResponse(c, "Install URL created successfully", InstallURLResponse{URL: authorizeURL + "?" + query.Encode()})
+}
+
+// OAuthCallbackController completes the install flow, storing the workspace and mapping the Slack user to the app account in the state.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SlackController) OAuthCallbackController(c *fiber.Ctx) error {
+	// This checks if the integration is disabled.
+	if sc.cfg.Slack.SigningSecret == "" || sc.cfg.Slack.ClientID == "" {
+		// If it is, a not found response is returned.
+		return response.NotFound(c, nil, "Slack integration is not configured")
+	}
+
+	// This checks if the user declined the installation.
+	if reason := c.Query("error"); reason != "" {
+		// If they did, a bad request response is returned.
+		return response.BadResponse(c, i18n.Sprintf(c, "Slack installation was cancelled: %s", reason))
+	}
+
+	// userId is the ID of the app user from the verified state.
+	userId, err := sc.parseState(c.Query("state"))
+	// This checks if the state is invalid.
+	if err != nil {
+		// If it is, an unauthorized response is returned.
+		return response.UnauthorizedAccess(c, err, "Invalid or expired state")
+	}
+
+	// code is the OAuth code sent by Slack.
+	code := c.Query("code")
+	// This checks if the code is missing.
+	if code == "" {
+		// If it is, a bad request response is returned.
+		return response.BadResponse(c, "Code is required")
+	}
+
+	// access is the result of exchanging the code.
+	access, err := exchangeCode(sc.cfg, code)
+	// This checks if an error occurred while exchanging the code.
+	if err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Unable to complete Slack installation")
+	}
+
+	// err is the result of storing the installation and the user mapping in one transaction.
+	err = database.WithTx(c.UserContext(), sc.db, func(tx *sql.Tx) error {
+		// This stores the installation.
+		if _, err := tx.ExecContext(c.UserContext(), UpsertInstallationQuery, access.Team.ID, access.Team.Name, access.AccessToken, userId); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// This maps the Slack user to the app account.
+		_, err := tx.ExecContext(c.UserContext(), UpsertSlackUserQuery, access.Team.ID, access.AuthedUser.ID, userId)
+		// The error, if any, is returned.
+		return err
+	})
+	// This checks if an error occurred while executing the transaction.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to save Slack installation")
+	}
+
+	// An OK response is returned with the workspace details.
+	return response.OKResponse(c, "Slack connected successfully", InstallResponse{TeamID: access.Team.ID, TeamName: access.Team.Name})
+}
+
+// DisconnectController removes every Slack mapping of the current user.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SlackController) DisconnectController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// _, err is the result of executing the SQL query to remove the mappings.
+	_, err = sc.db.ExecContext(c.UserContext(), DeleteSlackUsersQuery, user.ID)
+	// This checks if an error occurred while executing the query.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to disconnect Slack")
+	}
+
+	// An OK response is returned with a success message.
+	return response.OKResponse(c, "Slack disconnected successfully", nil)
+}
+
+// CommandController handles the /todo slash command.
+// The request signature is verified before anything else, and replies are only visible to the user who ran the command.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SlackController) CommandController(c *fiber.Ctx) error {
+	// This checks if the integration is disabled.
+	if sc.cfg.Slack.SigningSecret == "" {
+		// If it is, a not found response is returned.
+		return response.NotFound(c, nil, "Slack integration is not configured")
+	}
+
+	// This verifies that the request was signed by Slack.
+	if !verifySignature(sc.cfg.Slack.SigningSecret, c.Get("X-Slack-Request-Timestamp"), c.Body(), c.Get("X-Slack-Signature"), time.Now()) {
+		// If it was not, an unauthorized response is returned.
+		return response.UnauthorizedAccess(c, nil, "Invalid Slack signature")
+	}
+
+	// command is the parsed slash command.
+	command := Command{
+		// The TeamID field is set to the workspace of the command.
+		TeamID: c.FormValue("team_id"),
+		// The UserID field is set to the Slack user who ran the command.
+		UserID: c.FormValue("user_id"),
+		// The Command field is set to the command name.
+		Command: c.FormValue("command"),
+		// The Text field is set to the text after the command name.
+		Text: strings.TrimSpace(c.FormValue("text")),
+	}
+
+	// The reply is returned as an ephemeral message.
+	return c.Status(fiber.StatusOK).JSON(commandResponse{ResponseType: "ephemeral", Text: sc.handleCommand(c.UserContext(), command)})
+}
+
+// handleCommand runs a subcommand for the mapped app user and returns the reply.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param command Command - The slash command.
+// @return string - The reply.
+func (sc *SlackController) handleCommand(ctx context.Context, command Command) string {
+	// subcommand and argument are the first word and the rest of the text.
+	subcommand, argument, _ := strings.Cut(command.Text, " ")
+	// argument is trimmed of surrounding spaces.
+	argument = strings.TrimSpace(argument)
+
+	// This checks if the subcommand is known.
+	if subcommand != "add" && subcommand != "list" && subcommand != "done" {
+		// If it is not, the usage text is returned.
+		return usageText
+	}
+
+	// user is the app account mapped to the Slack user.
+	user, err := sc.mappedUser(ctx, command.TeamID, command.UserID)
+	// This checks if the Slack user is not mapped.
+	if errors.Is(err, sql.ErrNoRows) {
+		// If it is not, the user is asked to connect their account.
+		return "Your Slack account is not connected yet. Connect it from the app's Slack integration page."
+	}
+	// This checks if another error occurred.
+	if err != nil {
+		// If it did, it is logged and a generic reply is returned.
+		reqlog.From(ctx).Printf("Unable to find app user for Slack user %s/%s: %v", command.TeamID, command.UserID, err)
+		return "Something went wrong, please try again."
+	}
+	// The user is added to the logger of the request, since no authentication middleware ran before the Slack user was matched to it.
+	reqlog.SetUser(ctx, user.ID.String())
+
+	// This runs the subcommand.
+	switch subcommand {
+	case "add":
+		// "add" creates a todo.
+		return sc.addTodo(ctx, user, argument)
+	case "list":
+		// "list" lists the open todos.
+		return sc.listTodos(ctx, user)
+	default:
+		// "done" completes a todo.
+		return sc.completeTodo(ctx, user, argument)
+	}
+}
+
+// addTodo creates a todo from the command text.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param user users.User - The app user.
+// @param text string - The text of the todo.
+// @return string - The reply.
+func (sc *SlackController) addTodo(ctx context.Context, user users.User, text string) string {
+	// todo is the todo created from the text.
+	todo, err := sc.todoService.CreateFromText(ctx, user, text)
+	// This checks if nothing was left for the title.
+	if errors.Is(err, todos.ErrEmptyTitle) {
+		// If nothing was left, the user is asked for a title.
+		return "Please include a title, e.g. `/todo add Pay rent tomorrow 9am #home`."
+	}
+	// exceeded is the limit that was reached, if any.
+	var exceeded *quota.ExceededError
+	// This checks if the plan limit was reached.
+	if errors.As(err, &exceeded) {
+		// If it was, the user is told which limit was reached.
+		return fmt.Sprintf("You have reached the limit of %d todos on your plan.", exceeded.Limit)
+	}
+	// This checks if another error occurred.
+	if err != nil {
+		// If it did, it is logged and a generic reply is returned.
+		reqlog.From(ctx).Printf("Unable to create todo from Slack for user %s: %v", user.ID, err)
+		return "Something went wrong, please try again."
+	}
+
+	// The reply with the created todo is returned.
+	return "Added: " + formatTodo(todo, user)
+}
+
+// listTodos lists the open todos of the user, numbered for "/todo done".
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param user users.User - The app user.
+// @return string - The reply.
+func (sc *SlackController) listTodos(ctx context.Context, user users.User) string {
+	// openTodos is the list of open todos.
+	openTodos, err := sc.todoService.ListOpen(ctx, user.ID, listLimit)
+	// This checks if an error occurred while listing the todos.
+	if err != nil {
+		// If it did, it is logged and a generic reply is returned.
+		reqlog.From(ctx).Printf("Unable to list todos from Slack for user %s: %v", user.ID, err)
+		return "Something went wrong, please try again."
+	}
+
+	// This checks if there are no open todos.
+	if len(openTodos) == 0 {
+		// If there are none, the user is told so.
+		return "No open todos. :tada:"
+	}
+
+	// lines is the numbered list of todos.
+	lines := make([]string, len(openTodos))
+	// This iterates over the todos.
+	for i, todo := range openTodos {
+		// Each todo is numbered starting at one.
+		lines[i] = fmt.Sprintf("%d. %s", i+1, formatTodo(todo, user))
+	}
+
+	// The list is returned.
+	return strings.Join(lines, "\n")
+}
+
+// completeTodo completes the todo with the given number from "/todo list".
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param user users.User - The app user.
+// @param argument string - The number of the todo.
+// @return string - The reply.
+func (sc *SlackController) completeTodo(ctx context.Context, user users.User, argument string) string {
+	// number is the parsed todo number.
+	number, err := strconv.Atoi(argument)
+	// This checks if the number is invalid.
+	if err != nil || number < 1 {
+		// If it is, the usage is returned.
+		return "Please give the number of the todo from `/todo list`, e.g. `/todo done 2`."
+	}
+
+	// openTodos is the list of open todos in the same order as "/todo list".
+	openTodos, err := sc.todoService.ListOpen(ctx, user.ID, listLimit)
+	// This checks if an error occurred while listing the todos.
+	if err != nil {
+		// If it did, it is logged and a generic reply is returned.
+		reqlog.From(ctx).Printf("Unable to list todos from Slack for user %s: %v", user.ID, err)
+		return "Something went wrong, please try again."
+	}
+
+	// This checks if the number is out of range.
+	if number > len(openTodos) {
+		// If it is, the user is told so.
+		return fmt.Sprintf("There is no todo number %d. Run `/todo list` to see your todos.", number)
+	}
+
+	// todo is the result of completing the selected todo.
+	todo, _, err := sc.todoService.SetCompleted(ctx, user.ID, openTodos[number-1].ID, true)
+	// This checks if an error occurred while completing the todo.
+	if err != nil {
+		// If it did, it is logged and a generic reply is returned.
+		reqlog.From(ctx).Printf("Unable to complete todo from Slack for user %s: %v", user.ID, err)
+		return "Something went wrong, please try again."
+	}
+
+	// The reply with the completed todo is returned.
+	return "Completed: " + todo.Title
+}
+
+// mappedUser retrieves the app account mapped to a Slack user.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param teamId string - The ID of the Slack workspace.
+// @param slackUserId string - The ID of the Slack user.
+// @return users.User - The mapped user.
+// @return error - sql.ErrNoRows if the Slack user is not mapped, or another error if one occurred.
+func (sc *SlackController) mappedUser(ctx context.Context, teamId string, slackUserId string) (users.User, error) {
+	// userId is the ID of the mapped user.
+	var userId uuid.UUID
+	// This queries the database for the mapped user ID.
+	if err := sc.db.QueryRowContext(ctx, GetSlackUserQuery, teamId, slackUserId).Scan(&userId); err != nil {
+		// If an error occurs, it is returned.
+		return users.User{}, err
+	}
+
+	// The mapped user's profile is returned.
+	return users.GetUserByID(ctx, sc.db, userId)
+}
+
+// parseState verifies an OAuth state and returns the app user ID it carries.
+//
+// @param state string - The signed state.
+// @return uuid.UUID - The ID of the app user.
+// @return error - An error if the state is invalid or expired.
+func (sc *SlackController) parseState(state string) (uuid.UUID, error) {
+	// claims is a variable that will hold the claims of the state.
+	claims := jwt.MapClaims{}
+	// This parses and verifies the state, only accepting the signing method it was created with.
+	_, err := jwt.ParseWithClaims(state, claims, func(token *jwt.Token) (interface{}, error) {
+		// The signing key is returned.
+		return []byte(sc.cfg.JWT.SecretKey), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	// This checks if the state is invalid.
+	if err != nil {
+		// If it is, the error is returned.
+		return uuid.Nil, err
+	}
+
+	// This checks if the state was created for the install flow.
+	if claims["purpose"] != statePurpose {
+		// If it was not, an error is returned.
+		return uuid.Nil, errors.New("state was not issued for the Slack install flow")
+	}
+
+	// userId is the user ID claim.
+	userId, _ := claims["user_id"].(string)
+	// The parsed user ID is returned.
+	return uuid.Parse(userId)
+}
+
+// verifySignature checks the v0 signature Slack adds to every request.
+//
+// @param secret string - The signing secret of the Slack app.
+// @param timestamp string - The X-Slack-Request-Timestamp header.
+// @param body []byte - The raw request body.
+// @param signature string - The X-Slack-Signature header.
+// @param now time.Time - The current time.
+// @return bool - True if the signature is valid and the request is recent.
+func verifySignature(secret string, timestamp string, body []byte, signature string, now time.Time) bool {
+	// seconds is the parsed request timestamp.
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	// This checks if the timestamp is invalid.
+	if err != nil {
+		// If it is, the request is rejected.
+		return false
+	}
+
+	// age is how long ago the request was signed.
+	age := now.Sub(time.Unix(seconds, 0))
+	// This checks if the request is too old or from the future.
+	if age > maxRequestAge || age < -maxRequestAge {
+		// If it is, the request is rejected to prevent replays.
+		return false
+	}
+
+	// mac is the HMAC of the signed base string.
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	// expected is the signature Slack should have sent.
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	// The signatures are compared in constant time.
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// formatTodo formats a todo for a reply, with its due date in the user's time zone.
+//
+// @param todo todos.Todo - The todo.
+// @param user users.User - The owner of the todo.
+// @return string - The formatted todo.
+func formatTodo(todo todos.Todo, user users.User) string {
+	// This checks if the todo has a due date.
+	if todo.DueAt.Valid {
+		// If it does, the due date is included.
+		return fmt.Sprintf("%s (due %s)", todo.Title, todo.DueAt.Time.In(user.Location()).Format("Mon 2 Jan 15:04"))
+	}
+	// The title is returned.
+	return todo.Title
+}
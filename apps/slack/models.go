@@ -0,0 +1,41 @@
+// This file defines the data models for the Slack integration.
+package slack
+
+// Command represents a slash command sent by Slack.
+type Command struct {
+	// TeamID is the ID of the Slack workspace.
+	TeamID string
+	// UserID is the ID of the Slack user who ran the command.
+	UserID string
+	// Command is the name of the command, such as "/todo".
+	Command string
+	// Text is the text after the command name.
+	Text string
+}
+
+// oauthAccessResponse represents the subset of the oauth.v2.access response that the integration uses.
+type oauthAccessResponse struct {
+	// OK reports whether the call succeeded.
+	// json:"ok" specifies that this field should be marshalled to/from a JSON object with the key "ok".
+	OK bool `json:"ok"`
+	// Error is the error code of a failed call.
+	// json:"error" specifies that this field should be marshalled to/from a JSON object with the key "error".
+	Error string `json:"error"`
+	// AccessToken is the bot token of the installation.
+	// json:"access_token" specifies that this field should be marshalled to/from a JSON object with the key "access_token".
+	AccessToken string `json:"access_token"`
+	// Team is the workspace the app was installed in.
+	// json:"team" specifies that this field should be marshalled to/from a JSON object with the key "team".
+	Team struct {
+		// ID is the ID of the workspace.
+		ID string `json:"id"`
+		// Name is the name of the workspace.
+		Name string `json:"name"`
+	} `json:"team"`
+	// AuthedUser is the Slack user who authorized the installation.
+	// json:"authed_user" specifies that this field should be marshalled to/from a JSON object with the key "authed_user".
+	AuthedUser struct {
+		// ID is the ID of the Slack user.
+		ID string `json:"id"`
+	} `json:"authed_user"`
+}
@@ -0,0 +1,29 @@
+// This file defines the serializers for Slack-related requests and responses.
+package slack
+
+// InstallURLResponse defines the structure for an install URL response.
+type InstallURLResponse struct {
+	// URL is the Slack authorization URL the user is sent to.
+	// json:"url" specifies that this field should be marshalled to/from a JSON object with the key "url".
+	URL string `json:"url"`
+}
+
+// InstallResponse defines the structure for a completed installation response.
+type InstallResponse struct {
+	// TeamID is the ID of the Slack workspace.
+	// json:"team_id" specifies that this field should be marshalled to/from a JSON object with the key "team_id".
+	TeamID string `json:"team_id"`
+	// TeamName is the name of the Slack workspace.
+	// json:"team_name" specifies that this field should be marshalled to/from a JSON object with the key "team_name".
+	TeamName string `json:"team_name"`
+}
+
+// commandResponse defines the structure for a slash command reply.
+type commandResponse struct {
+	// ResponseType is "ephemeral" so that only the user who ran the command sees the reply.
+	// json:"response_type" specifies that this field should be marshalled to/from a JSON object with the key "response_type".
+	ResponseType string `json:"response_type"`
+	// Text is the text of the reply.
+	// json:"text" specifies that this field should be marshalled to/from a JSON object with the key "text".
+	Text string `json:"text"`
+}
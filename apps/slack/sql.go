@@ -0,0 +1,17 @@
+// This file defines the SQL queries used for Slack-related database operations.
+package slack
+
+// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names and schemas.
+import "github.com/rahulcodepython/todo-backend/backend/utils"
+
+// UpsertInstallationQuery is the SQL query to store a workspace installation, replacing the bot token on reinstall.
+const UpsertInstallationQuery = "INSERT INTO " + utils.SlackInstallationTableName + " (team_id, team_name, bot_token, installed_by) VALUES ($1, $2, $3, $4) ON CONFLICT (team_id) DO UPDATE SET team_name = EXCLUDED.team_name, bot_token = EXCLUDED.bot_token, installed_by = EXCLUDED.installed_by, installed_at = NOW()"
+
+// UpsertSlackUserQuery is the SQL query to map a Slack user to an app account, replacing any previous mapping.
+const UpsertSlackUserQuery = "INSERT INTO " + utils.SlackUserTableName + " (team_id, slack_user_id, user_id) VALUES ($1, $2, $3) ON CONFLICT (team_id, slack_user_id) DO UPDATE SET user_id = EXCLUDED.user_id, linked_at = NOW()"
+
+// GetSlackUserQuery is the SQL query to retrieve the app account mapped to a Slack user.
+const GetSlackUserQuery = "SELECT user_id FROM " + utils.SlackUserTableName + " WHERE team_id = $1 AND slack_user_id = $2"
+
+// DeleteSlackUsersQuery is the SQL query to remove every Slack mapping of an app account.
+const DeleteSlackUsersQuery = "DELETE FROM " + utils.SlackUserTableName + " WHERE user_id = $1"
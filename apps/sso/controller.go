@@ -0,0 +1,918 @@
+// This file defines the controllers for single sign-on. Admins set up a connection to the OpenID Connect identity provider of a
+// workspace, and its members sign in there instead of with a password: the first sign-in creates their user, and the groups the
+// identity provider puts them in decide whether they are admins.
+package sso
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to bound the calls to the issuer.
+import (
+	"context"
+	// "crypto/hmac" implements HMAC. It is used here to derive the PKCE code verifier of a sign-in.
+	"crypto/hmac"
+	// "crypto/sha256" implements the SHA-256 hash. It is used here to derive the PKCE code verifier and its challenge.
+	"crypto/sha256"
+	// "database/sql" provides a generic SQL interface. It is used here to interact with the database.
+	"database/sql"
+	// "encoding/base64" implements base64 encoding. It is used here to encode the PKCE code verifier and its challenge.
+	"encoding/base64"
+	// "encoding/hex" implements hexadecimal encoding. It is used here to encode the hashes of SCIM tokens.
+	"encoding/hex"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to store the role mappings.
+	"encoding/json"
+	// "errors" provides functions for working with errors. It is used here to compare errors.
+	"errors"
+	// "net/url" provides functions for working with URLs. It is used here to check issuers and build the authorization URL.
+	"net/url"
+	// "regexp" provides regular expressions. It is used here to check slugs.
+	"regexp"
+	// "strings" provides functions for working with strings. It is used here to clean the settings and read the email domain.
+	"strings"
+	// "time" provides functions for working with time. It is used here to expire the state.
+	"time"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to define the controllers.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/golang-jwt/jwt/v5" is a package for creating and verifying JWTs. It is used here to sign the state of a sign-in.
+	"github.com/golang-jwt/jwt/v5"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to parse connection IDs.
+	"github.com/google/uuid"
+	// "github.com/lib/pq" is the PostgreSQL driver. It is used here to read and write the email domains.
+	"github.com/lib/pq"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models and services.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/dberr" is a local package that classifies database errors.
+	"github.com/rahulcodepython/todo-backend/backend/dberr"
+	// "github.com/rahulcodepython/todo-backend/backend/i18n" is a local package that translates messages.
+	"github.com/rahulcodepython/todo-backend/backend/i18n"
+	// "github.com/rahulcodepython/todo-backend/backend/idgen" is a local package that creates new IDs.
+	"github.com/rahulcodepython/todo-backend/backend/idgen"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// stateTTL is how long the state of a sign-in stays valid.
+const stateTTL = 10 * time.Minute
+
+// statePurpose is the purpose claim of the states of sign-ins, so that login tokens and other states are not accepted as them.
+const statePurpose = "sso_login"
+
+// defaultGroupsClaim is the claim most identity providers list the groups of a user in.
+const defaultGroupsClaim = "groups"
+
+// scimTokenPrefix is the prefix of SCIM tokens.
+const scimTokenPrefix = "tdscim_"
+
+// maxNameLength is the number of characters the name of a connection may have.
+const maxNameLength = 100
+
+// slugPattern matches the slugs of connections, which are part of URLs.
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// SSOController is a struct that holds the dependencies of the single sign-on controllers.
+type SSOController struct {
+	// cfg is the application configuration.
+	cfg *config.Config
+	// db is the database connection.
+	db *sql.DB
+	// clock tells the current time.
+	clock clock.Clock
+	// ids creates the IDs of new connections.
+	ids idgen.IDGenerator
+	// userService signs the users in.
+	userService *users.UserService
+}
+
+// NewSSOControl creates a new SSOController.
+// It takes the application configuration, database connection, clock, ID generator, and user service as input.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @param clk clock.Clock - The clock that tells the current time.
+// @param ids idgen.IDGenerator - The generator of the IDs of new connections.
+// @param userService *users.UserService - The service that signs the users in.
+// @return *SSOController - A pointer to the new SSOController.
+func NewSSOControl(cfg *config.Config, db *sql.DB, clk clock.Clock, ids idgen.IDGenerator, userService *users.UserService) *SSOController {
+	// A new SSOController is returned.
+	return &SSOController{
+		// The cfg field is set to the application configuration.
+		cfg: cfg,
+		// The db field is set to the database connection.
+		db: db,
+		// The clock field is set to the clock.
+		clock: clk,
+		// The ids field is set to the ID generator.
+		ids: ids,
+		// The userService field is set to the user service.
+		userService: userService,
+	}
+}
+
+// connectionScanner is implemented by *sql.Row and *sql.Rows.
+type connectionScanner interface {
+	// Scan copies the columns of the current row into the values pointed at by dest.
+	Scan(dest ...any) error
+}
+
+// scanConnection scans a row selected with connectionColumns into a Connection struct.
+//
+// @param row connectionScanner - The row to be scanned.
+// @return Connection - The scanned connection.
+// @return error - An error if one occurred.
+func scanConnection(row connectionScanner) (Connection, error) {
+	// conn is a new Connection struct.
+	var conn Connection
+	// mappings is the JSON of the role mappings.
+	var mappings []byte
+	// This scans the row.
+	if err := row.Scan(&conn.ID, &conn.Slug, &conn.Name, &conn.Issuer, &conn.ClientID, &conn.ClientSecret, pq.Array(&conn.EmailDomains), &conn.GroupsClaim, &mappings, &conn.DefaultRole, &conn.Enabled, &conn.CreatedAt, &conn.UpdatedAt, &conn.SCIMEnabled); err != nil {
+		// If an error occurs, it is returned.
+		return Connection{}, err
+	}
+	// The role mappings are decoded.
+	if err := json.Unmarshal(mappings, &conn.RoleMappings); err != nil {
+		// If an error occurs, it is returned.
+		return Connection{}, err
+	}
+	// The scanned connection is returned.
+	return conn, nil
+}
+
+// redirectURI returns the callback URL of a connection, which the identity provider sends the user back to.
+//
+// @param slug string - The slug of the connection.
+// @return string - The callback URL.
+func (sc *SSOController) redirectURI(slug string) string {
+	// The URL is built from the public address of the server.
+	return sc.cfg.Server.PublicURL + "/api/" + utils.APIVersion + "/auth/sso/" + slug + "/callback"
+}
+
+// codeVerifier derives the PKCE code verifier of a sign-in from its nonce, so that the callback can recompute it without storing it.
+// Only the server knows the key, so the verifier cannot be derived from the nonce by anyone who reads the state.
+//
+// @param nonce string - The nonce of the sign-in.
+// @return string - The code verifier.
+func (sc *SSOController) codeVerifier(nonce string) string {
+	// mac is the HMAC of the nonce.
+	mac := hmac.New(sha256.New, []byte(sc.cfg.JWT.SecretKey))
+	mac.Write([]byte("sso_pkce\n" + nonce))
+	// The encoded HMAC, which is 43 characters as PKCE asks, is returned.
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// validRole reports whether a role exists.
+//
+// @param role string - The role.
+// @return bool - True if the role exists.
+func validRole(role string) bool {
+	// The role must be one of the roles of the application.
+	return role == users.RoleUser || role == users.RoleAdmin
+}
+
+// validIssuer reports whether an issuer is an HTTPS URL, or an HTTP URL of the local machine for development.
+//
+// @param issuer string - The issuer.
+// @return bool - True if the issuer is valid.
+func validIssuer(issuer string) bool {
+	// u is the parsed issuer.
+	u, err := url.Parse(issuer)
+	// This checks if the issuer is not an absolute URL without a query or fragment, as OpenID Connect asks.
+	if err != nil || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		// If it is not, it is invalid.
+		return false
+	}
+	// The scheme must be HTTPS, unless the issuer runs on the local machine.
+	return u.Scheme == "https" || u.Scheme == "http" && (u.Hostname() == "localhost" || u.Hostname() == "127.0.0.1")
+}
+
+// connectionFromRequest checks a create or update request and builds the connection it describes.
+//
+// @param body *connectionRequest - The request.
+// @param create bool - Whether the connection is being created, in which case the client secret is required.
+// @return Connection - The connection, without its ID or times.
+// @return string - The message of the first invalid setting, or empty if every setting is valid.
+func connectionFromRequest(body *connectionRequest, create bool) (Connection, string) {
+	// conn is the connection, with its settings cleaned.
+	conn := Connection{
+		Slug:         strings.ToLower(strings.TrimSpace(body.Slug)),
+		Name:         strings.TrimSpace(body.Name),
+		Issuer:       strings.TrimSpace(body.Issuer),
+		ClientID:     strings.TrimSpace(body.ClientID),
+		ClientSecret: strings.TrimSpace(body.ClientSecret),
+		EmailDomains: []string{},
+		GroupsClaim:  strings.TrimSpace(body.GroupsClaim),
+		RoleMappings: map[string]string{},
+		DefaultRole:  strings.TrimSpace(body.DefaultRole),
+		Enabled:      body.Enabled == nil || *body.Enabled,
+	}
+
+	// This checks if the slug cannot be part of a URL.
+	if !slugPattern.MatchString(conn.Slug) {
+		// If it cannot, the message is returned.
+		return conn, "Slug must be 1 to 63 lowercase letters, digits, or hyphens"
+	}
+	// This checks if the name is empty or too long.
+	if conn.Name == "" || len([]rune(conn.Name)) > maxNameLength {
+		// If it is, the message is returned.
+		return conn, "Connection name must be between 1 and 100 characters"
+	}
+	// This checks if the issuer is not an HTTPS URL.
+	if !validIssuer(conn.Issuer) {
+		// If it is not, the message is returned.
+		return conn, "Issuer must be an HTTPS URL"
+	}
+	// This checks if the client ID is missing, or the client secret of a new connection.
+	if conn.ClientID == "" || create && conn.ClientSecret == "" {
+		// If one is, the message is returned.
+		return conn, "Client ID and client secret are required"
+	}
+
+	// This iterates over the email domains.
+	for _, domain := range body.EmailDomains {
+		// domain is the domain without surrounding whitespace, a leading @, or capitals, since addresses are compared in lowercase.
+		domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+		// This checks if the domain is empty or not a domain.
+		if domain == "" || strings.ContainsAny(domain, "@/ ") {
+			// If it is, the message is returned.
+			return conn, "Invalid email domain"
+		}
+		// The domain is added.
+		conn.EmailDomains = append(conn.EmailDomains, domain)
+	}
+
+	// This checks if the groups claim is empty.
+	if conn.GroupsClaim == "" {
+		// If it is, the claim most identity providers use is set.
+		conn.GroupsClaim = defaultGroupsClaim
+	}
+	// This checks if the default role is empty.
+	if conn.DefaultRole == "" {
+		// If it is, the users are not admins.
+		conn.DefaultRole = users.RoleUser
+	}
+	// This checks if the default role does not exist.
+	if !validRole(conn.DefaultRole) {
+		// If it does not, the message is returned.
+		return conn, "Role must be user or admin"
+	}
+	// This iterates over the role mappings.
+	for group, role := range body.RoleMappings {
+		// This checks if the group is empty or the role does not exist.
+		if group == "" || !validRole(role) {
+			// If either is, the message is returned.
+			return conn, "Role must be user or admin"
+		}
+		// The mapping is added.
+		conn.RoleMappings[group] = role
+	}
+
+	// The connection is returned.
+	return conn, ""
+}
+
+// CreateConnectionController creates a connection. An enabled connection is only created if its issuer can be discovered,
+// so that a mistyped issuer is reported now rather than when the first user signs in.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SSOController) CreateConnectionController(c *fiber.Ctx) error {
+	// body is a new connectionRequest struct.
+	body := new(connectionRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+
+	// conn is the connection the request describes.
+	conn, message := connectionFromRequest(body, true)
+	// This checks if a setting is invalid.
+	if message != "" {
+		// If one is, a bad request response is returned.
+		return response.BadResponse(c, message)
+	}
+	// This checks if the connection is enabled.
+	if conn.Enabled {
+		// If it is, the issuer is discovered.
+		if _, err := discover(c.UserContext(), conn.Issuer, false); err != nil {
+			// If it cannot be, a bad request response is returned.
+			return response.BadInternalResponse(c, err, "Unable to discover the issuer")
+		}
+	}
+
+	// mappings is the JSON of the role mappings.
+	mappings, err := json.Marshal(conn.RoleMappings)
+	// This checks if the role mappings could not be encoded.
+	if err != nil {
+		// If they could not, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to create connection")
+	}
+	// The connection is given a new ID.
+	conn.ID = sc.ids.NewID()
+	// This creates the connection.
+	err = sc.db.QueryRowContext(c.UserContext(), CreateConnectionQuery, conn.ID, conn.Slug, conn.Name, conn.Issuer, conn.ClientID, conn.ClientSecret, pq.Array(conn.EmailDomains), conn.GroupsClaim, string(mappings), conn.DefaultRole, conn.Enabled).Scan(&conn.CreatedAt, &conn.UpdatedAt)
+	// This checks if another connection has the slug.
+	if dberr.Is(err, dberr.ErrUniqueViolation) {
+		// If one has, a conflict response is returned.
+		return response.Conflict(c, err, "A connection with this slug already exists")
+	}
+	// This checks if another error occurred while creating the connection.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to create connection")
+	}
+
+	// A created response is returned with the connection.
+	return response.OKCreatedResponse(c, "Connection created successfully", NewConnectionResponse(conn, sc.redirectURI(conn.Slug)))
+}
+
+// ConnectionsController lists every connection, without their client secrets.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SSOController) ConnectionsController(c *fiber.Ctx) error {
+	// rows is the result of querying the database for the connections.
+	rows, err := sc.db.QueryContext(c.UserContext(), GetConnectionsQuery)
+	// This checks if an error occurred while querying the database.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get connections")
+	}
+	// This defers closing the rows until the function returns.
+	defer rows.Close()
+
+	// connections is the list of connections.
+	connections := []ConnectionResponse{}
+	// This iterates over the rows.
+	for rows.Next() {
+		// conn is the connection of the row.
+		conn, err := scanConnection(rows)
+		// This checks if an error occurred while scanning the row.
+		if err != nil {
+			// If an error occurs, an internal server error response is returned.
+			return response.InternelServerError(c, err, "Unable to get connections")
+		}
+		// The connection is appended to the list.
+		connections = append(connections, NewConnectionResponse(conn, sc.redirectURI(conn.Slug)))
+	}
+	// This checks if an error occurred while iterating over the rows.
+	if err := rows.Err(); err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get connections")
+	}
+
+	// An OK response is returned with the connections.
+	return response.OKResponse(c, "Connections fetched successfully", connections)
+}
+
+// ConnectionController returns a connection, without its client secret.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SSOController) ConnectionController(c *fiber.Ctx) error {
+	// id is the ID of the connection.
+	id, err := uuid.Parse(c.Params("id"))
+	// This checks if the ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Invalid connection id")
+	}
+
+	// conn is the connection.
+	conn, err := scanConnection(sc.db.QueryRowContext(c.UserContext(), GetConnectionQuery, id))
+	// This checks if no connection has the ID.
+	if errors.Is(err, sql.ErrNoRows) {
+		// If none has, a not found response is returned.
+		return response.NotFound(c, nil, "Connection not found")
+	}
+	// This checks if another error occurred while reading the connection.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get connections")
+	}
+
+	// An OK response is returned with the connection.
+	return response.OKResponse(c, "Connection fetched successfully", NewConnectionResponse(conn, sc.redirectURI(conn.Slug)))
+}
+
+// UpdateConnectionController replaces the settings of a connection. An empty client secret keeps the stored one.
+// The roles of the users who signed in through it change at their next sign-in.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SSOController) UpdateConnectionController(c *fiber.Ctx) error {
+	// id is the ID of the connection.
+	id, err := uuid.Parse(c.Params("id"))
+	// This checks if the ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Invalid connection id")
+	}
+
+	// body is a new connectionRequest struct.
+	body := new(connectionRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+
+	// settings is the connection the request describes.
+	settings, message := connectionFromRequest(body, false)
+	// This checks if a setting is invalid.
+	if message != "" {
+		// If one is, a bad request response is returned.
+		return response.BadResponse(c, message)
+	}
+	// This checks if the connection is enabled.
+	if settings.Enabled {
+		// If it is, the issuer is discovered, skipping the cache in case the issuer is the one that changed.
+		if _, err := discover(c.UserContext(), settings.Issuer, true); err != nil {
+			// If it cannot be, a bad request response is returned.
+			return response.BadInternalResponse(c, err, "Unable to discover the issuer")
+		}
+	}
+
+	// mappings is the JSON of the role mappings.
+	mappings, err := json.Marshal(settings.RoleMappings)
+	// This checks if the role mappings could not be encoded.
+	if err != nil {
+		// If they could not, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to update connection")
+	}
+	// conn is the updated connection.
+	conn, err := scanConnection(sc.db.QueryRowContext(c.UserContext(), UpdateConnectionQuery, id, settings.Slug, settings.Name, settings.Issuer, settings.ClientID, settings.ClientSecret, pq.Array(settings.EmailDomains), settings.GroupsClaim, string(mappings), settings.DefaultRole, settings.Enabled))
+	// This checks if no connection has the ID.
+	if errors.Is(err, sql.ErrNoRows) {
+		// If none has, a not found response is returned.
+		return response.NotFound(c, nil, "Connection not found")
+	}
+	// This checks if another connection has the slug.
+	if dberr.Is(err, dberr.ErrUniqueViolation) {
+		// If one has, a conflict response is returned.
+		return response.Conflict(c, err, "A connection with this slug already exists")
+	}
+	// This checks if another error occurred while updating the connection.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to update connection")
+	}
+
+	// An OK response is returned with the connection.
+	return response.OKResponse(c, "Connection updated successfully", NewConnectionResponse(conn, sc.redirectURI(conn.Slug)))
+}
+
+// DeleteConnectionController deletes a connection. The users who signed in through it are kept, but can no longer sign in
+// unless they set a password or another connection links them.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SSOController) DeleteConnectionController(c *fiber.Ctx) error {
+	// id is the ID of the connection.
+	id, err := uuid.Parse(c.Params("id"))
+	// This checks if the ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Invalid connection id")
+	}
+
+	// result is the result of deleting the connection.
+	result, err := sc.db.ExecContext(c.UserContext(), DeleteConnectionQuery, id)
+	// This checks if an error occurred while deleting the connection.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to delete connection")
+	}
+	// This checks if no connection was deleted.
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		// If none was, a not found response is returned.
+		return response.NotFound(c, nil, "Connection not found")
+	}
+
+	// An OK response is returned.
+	return response.OKResponse(c, "Connection deleted successfully", nil)
+}
+
+// enabledConnection reads the enabled connection with a slug.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param slug string - The slug of the connection.
+// @return Connection - The connection.
+// @return error - sql.ErrNoRows if no enabled connection has the slug, or another error if one occurred.
+func (sc *SSOController) enabledConnection(ctx context.Context, slug string) (Connection, error) {
+	// conn is the connection.
+	conn, err := scanConnection(sc.db.QueryRowContext(ctx, GetConnectionBySlugQuery, slug))
+	// This checks if the connection is disabled, which is reported like a missing one.
+	if err == nil && !conn.Enabled {
+		// If it is, no rows is returned.
+		return Connection{}, sql.ErrNoRows
+	}
+	// The connection and the error, if any, are returned.
+	return conn, err
+}
+
+// LoginController returns the authorization URL of the identity provider of a connection, which the client sends the user to.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SSOController) LoginController(c *fiber.Ctx) error {
+	// conn is the connection of the slug.
+	conn, err := sc.enabledConnection(c.UserContext(), c.Params("slug"))
+	// This checks if no enabled connection has the slug.
+	if errors.Is(err, sql.ErrNoRows) {
+		// If none has, a not found response is returned.
+		return response.NotFound(c, nil, "Connection not found")
+	}
+	// This checks if another error occurred while reading the connection.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to create sign-in URL")
+	}
+
+	// p is the identity provider of the connection.
+	p, err := discover(c.UserContext(), conn.Issuer, false)
+	// This checks if the identity provider could not be discovered.
+	if err != nil {
+		// If it could not, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to reach the identity provider")
+	}
+
+	// nonce ties the ID token to this sign-in, and the PKCE code verifier is derived from it.
+	nonce, err := utils.CreateOpaqueToken("")
+	// This checks if the nonce could not be created.
+	if err != nil {
+		// If it could not, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to create sign-in URL")
+	}
+	// state is a signed token that ties the callback to the connection and the nonce, so that no other server state is needed.
+	state, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		// "connection_id" is a claim that stores the ID of the connection.
+		"connection_id": conn.ID.String(),
+		// "nonce" is a claim that stores the nonce of the sign-in.
+		"nonce": nonce,
+		// "purpose" is a claim that restricts the token to the sign-in.
+		"purpose": statePurpose,
+		// "exp" is a claim that stores the expiration time of the state.
+		"exp": sc.clock.Now().Add(stateTTL).Unix(),
+	}).SignedString([]byte(sc.cfg.JWT.SecretKey))
+	// This checks if an error occurred while signing the state.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to create sign-in URL")
+	}
+
+	// challenge is the S256 PKCE challenge of the code verifier.
+	challenge := sha256.Sum256([]byte(sc.codeVerifier(nonce)))
+	// query is the query string of the authorization URL.
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {conn.ClientID},
+		"redirect_uri":          {sc.redirectURI(conn.Slug)},
+		"scope":                 {"openid email profile"},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	// separator joins the query to the endpoint, which may already have one.
+	separator := "?"
+	// This checks if the endpoint has a query.
+	if strings.Contains(p.metadata.AuthorizationEndpoint, "?") {
+		// If it does, the query is appended to it.
+		separator = "&"
+	}
+
+	// An OK response is returned with the authorization URL.
+	return response.OKResponse(c, "Sign-in URL created successfully", LoginURLResponse{URL: p.metadata.AuthorizationEndpoint + separator + query.Encode()})
+}
+
+// CallbackController completes a sign-in: it exchanges the code for an ID token, verifies it, and signs in the user it names,
+// creating them on their first sign-in and giving them the role their groups map to.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SSOController) CallbackController(c *fiber.Ctx) error {
+	// This checks if the identity provider refused the sign-in.
+	if reason := c.Query("error"); reason != "" {
+		// If it did, a bad request response is returned.
+		return response.BadResponse(c, i18n.Sprintf(c, "Sign-in was cancelled: %s", reason))
+	}
+
+	// conn is the connection of the slug.
+	conn, err := sc.enabledConnection(c.UserContext(), c.Params("slug"))
+	// This checks if no enabled connection has the slug.
+	if errors.Is(err, sql.ErrNoRows) {
+		// If none has, a not found response is returned.
+		return response.NotFound(c, nil, "Connection not found")
+	}
+	// This checks if another error occurred while reading the connection.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to sign in")
+	}
+
+	// nonce is the nonce of the verified state, which must have been issued for this connection.
+	nonce, err := sc.parseState(c.Query("state"), conn.ID)
+	// This checks if the state is invalid.
+	if err != nil {
+		// If it is, an unauthorized response is returned.
+		return response.UnauthorizedAccess(c, err, "Invalid or expired state")
+	}
+	// code is the code sent by the identity provider.
+	code := c.Query("code")
+	// This checks if the code is missing.
+	if code == "" {
+		// If it is, a bad request response is returned.
+		return response.BadResponse(c, "Code is required")
+	}
+
+	// p is the identity provider of the connection.
+	p, err := discover(c.UserContext(), conn.Issuer, false)
+	// This checks if the identity provider could not be discovered.
+	if err != nil {
+		// If it could not, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to reach the identity provider")
+	}
+	// rawToken is the ID token the code is exchanged for.
+	rawToken, err := exchangeCode(c.UserContext(), p, conn, code, sc.redirectURI(conn.Slug), sc.codeVerifier(nonce))
+	// This checks if the identity provider rejected the code.
+	if err != nil {
+		// If it did, an unauthorized response is returned.
+		return response.UnauthorizedAccess(c, err, "Unable to complete sign-in")
+	}
+	// claims are the claims of the verified ID token.
+	claims, err := verifyIDToken(c.UserContext(), p, conn, rawToken, nonce)
+	// This checks if the ID token is invalid.
+	if err != nil {
+		// If it is, an unauthorized response is returned.
+		return response.UnauthorizedAccess(c, err, "Unable to complete sign-in")
+	}
+
+	// identity is the user the ID token describes.
+	identity, err := identityOf(conn, claims)
+	// This checks if the ID token names no user or email address.
+	if err != nil {
+		// If it does not, an unauthorized response is returned.
+		return response.UnauthorizedAccess(c, err, "The identity provider did not send an email address")
+	}
+	// This checks if the email address is outside the domains of the connection.
+	if !conn.AllowsEmail(identity.Email) {
+		// If it is, a forbidden response is returned.
+		return response.Forbidden(c, "This email address cannot sign in through this connection")
+	}
+
+	// user and jwt are the result of signing in the user.
+	user, jwt, err := sc.userService.LoginSSO(c.UserContext(), identity, users.Client{UserAgent: c.Get(fiber.HeaderUserAgent), IP: c.IP()})
+	// This checks if the email address belongs to a user but is not verified by the identity provider.
+	if errors.Is(err, users.ErrEmailUnverified) {
+		// If it is, a conflict response is returned, since signing in would take over the account.
+		return response.Conflict(c, err, "An account with this email address already exists, and the identity provider has not verified it")
+	}
+	// This checks if the user was deactivated through SCIM.
+	if errors.Is(err, users.ErrUserDeactivated) {
+		// If they were, a forbidden response is returned.
+		return response.Forbidden(c, "This account is deactivated")
+	}
+	// This checks if another error occurred while signing in the user.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to sign in")
+	}
+
+	// An OK response is returned with the user and their session.
+	return response.OKResponse(c, "User logged in successfully", LoginResponse{ProfileResponse: users.NewProfileResponse(user), Token: jwt.Token, ExpiresAt: utils.ParseTime(jwt.ExpiresAt)})
+}
+
+// parseState verifies the state of a sign-in and returns the nonce it carries.
+//
+// @param state string - The signed state.
+// @param connectionId uuid.UUID - The connection the callback was sent to.
+// @return string - The nonce of the sign-in.
+// @return error - An error if the state is invalid, expired, or for another connection.
+func (sc *SSOController) parseState(state string, connectionId uuid.UUID) (string, error) {
+	// claims is a variable that will hold the claims of the state.
+	claims := jwt.MapClaims{}
+	// This parses and verifies the state, only accepting the signing method it was created with.
+	_, err := jwt.ParseWithClaims(state, claims, func(token *jwt.Token) (interface{}, error) {
+		// The signing key is returned.
+		return []byte(sc.cfg.JWT.SecretKey), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	// This checks if the state is invalid.
+	if err != nil {
+		// If it is, the error is returned.
+		return "", err
+	}
+
+	// This checks if the state was created for a sign-in through this connection.
+	if claims["purpose"] != statePurpose || claims["connection_id"] != connectionId.String() {
+		// If it was not, an error is returned.
+		return "", errors.New("state was not issued for this connection")
+	}
+	// nonce is the nonce claim.
+	nonce, _ := claims["nonce"].(string)
+	// This checks if the nonce is missing.
+	if nonce == "" {
+		// If it is, an error is returned.
+		return "", errors.New("state has no nonce")
+	}
+	// The nonce is returned.
+	return nonce, nil
+}
+
+// identityOf reads the user an ID token describes, with the role their groups map to.
+//
+// @param conn Connection - The connection.
+// @param claims jwt.MapClaims - The claims of the verified ID token.
+// @return users.SSOIdentity - The identity.
+// @return error - An error if the token has no subject or email address.
+func identityOf(conn Connection, claims jwt.MapClaims) (users.SSOIdentity, error) {
+	// subject is the ID of the user at the identity provider.
+	subject, _ := claims["sub"].(string)
+	// email is the email address of the user, in lowercase as the users are stored.
+	email, _ := claims["email"].(string)
+	email = strings.ToLower(strings.TrimSpace(email))
+	// This checks if either is missing.
+	if subject == "" || email == "" {
+		// If one is, an error is returned.
+		return users.SSOIdentity{}, errors.New("ID token has no subject or email claim")
+	}
+	// name is the name of the user.
+	name, _ := claims["name"].(string)
+
+	// verified reports whether the email address is verified. Some identity providers send it as a string.
+	verified := false
+	// This checks the type of the claim.
+	switch value := claims["email_verified"].(type) {
+	case bool:
+		verified = value
+	case string:
+		verified = value == "true"
+	}
+
+	// The identity is returned.
+	return users.SSOIdentity{ConnectionID: conn.ID, Subject: subject, Email: email, EmailVerified: verified, Name: strings.TrimSpace(name), Role: roleOf(conn, groupsOf(claims[conn.GroupsClaim]))}, nil
+}
+
+// groupsOf reads the groups claim of an ID token, which is a list of names or, for a single group, a name.
+//
+// @param claim any - The claim.
+// @return []string - The groups.
+func groupsOf(claim any) []string {
+	// This checks the type of the claim.
+	switch value := claim.(type) {
+	case string:
+		// A single group is returned as a list.
+		return []string{value}
+	case []any:
+		// groups is the list of the names in the claim.
+		groups := make([]string, 0, len(value))
+		// This iterates over the claim.
+		for _, item := range value {
+			// This checks if the item is a name.
+			if group, ok := item.(string); ok {
+				// If it is, it is added.
+				groups = append(groups, group)
+			}
+		}
+		// The groups are returned.
+		return groups
+	}
+	// A missing claim, or a claim of another type, lists no groups.
+	return nil
+}
+
+// roleOf returns the role the groups of a user map to: admin if any group maps to it, the role of a mapped group otherwise,
+// and the default role of the connection if no group is mapped.
+//
+// @param conn Connection - The connection.
+// @param groups []string - The groups of the user.
+// @return string - The role.
+func roleOf(conn Connection, groups []string) string {
+	// role is the role of the first mapped group.
+	role := ""
+	// This iterates over the groups.
+	for _, group := range groups {
+		// mapped is the role of the group.
+		mapped, ok := conn.RoleMappings[group]
+		// This checks if the group maps to admin, which outranks every other role.
+		if ok && mapped == users.RoleAdmin {
+			// If it does, admin is returned.
+			return users.RoleAdmin
+		}
+		// This checks if the group is the first mapped one.
+		if ok && role == "" {
+			// If it is, its role is kept.
+			role = mapped
+		}
+	}
+	// This checks if no group is mapped.
+	if role == "" {
+		// If none is, the default role is returned.
+		return conn.DefaultRole
+	}
+	// The role is returned.
+	return role
+}
+
+// HashSCIMToken returns the hash a SCIM token is stored and looked up by. The token is random and long, so a fast hash is enough.
+//
+// @param token string - The token.
+// @return string - The hex SHA-256 of the token.
+func HashSCIMToken(token string) string {
+	// sum is the SHA-256 of the token.
+	sum := sha256.Sum256([]byte(token))
+	// The encoded hash is returned.
+	return hex.EncodeToString(sum[:])
+}
+
+// ConnectionBySCIMToken reads the enabled connection a SCIM token belongs to.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param db *sql.DB - The database connection.
+// @param token string - The token.
+// @return Connection - The connection.
+// @return error - sql.ErrNoRows if no enabled connection has the token, or another error if one occurred.
+func ConnectionBySCIMToken(ctx context.Context, db *sql.DB, token string) (Connection, error) {
+	// The connection with the hash of the token is read.
+	return scanConnection(db.QueryRowContext(ctx, GetConnectionBySCIMTokenQuery, HashSCIMToken(token)))
+}
+
+// CreateSCIMTokenController creates the SCIM token of a connection, replacing the one it had. The token is only shown in this response.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SSOController) CreateSCIMTokenController(c *fiber.Ctx) error {
+	// id is the ID of the connection.
+	id, err := uuid.Parse(c.Params("id"))
+	// This checks if the ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Invalid connection id")
+	}
+
+	// token is the new token.
+	token, err := utils.CreateOpaqueToken(scimTokenPrefix)
+	// This checks if the token could not be created.
+	if err != nil {
+		// If it could not, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to create SCIM token")
+	}
+	// result is the result of storing the hash of the token.
+	result, err := sc.db.ExecContext(c.UserContext(), SetSCIMTokenQuery, id, HashSCIMToken(token))
+	// This checks if an error occurred while storing the hash.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to create SCIM token")
+	}
+	// This checks if no connection has the ID.
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		// If none has, a not found response is returned.
+		return response.NotFound(c, nil, "Connection not found")
+	}
+
+	// A created response is returned with the token and the URL of the endpoints.
+	return response.OKCreatedResponse(c, "SCIM token created successfully", SCIMTokenResponse{Token: token, BaseURL: sc.cfg.Server.PublicURL + "/api/" + utils.APIVersion + "/scim/v2"})
+}
+
+// DeleteSCIMTokenController removes the SCIM token of a connection, which stops its provisioning. The users it provisioned are kept.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (sc *SSOController) DeleteSCIMTokenController(c *fiber.Ctx) error {
+	// id is the ID of the connection.
+	id, err := uuid.Parse(c.Params("id"))
+	// This checks if the ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Invalid connection id")
+	}
+
+	// result is the result of removing the hash of the token.
+	result, err := sc.db.ExecContext(c.UserContext(), SetSCIMTokenQuery, id, nil)
+	// This checks if an error occurred while removing the hash.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to delete SCIM token")
+	}
+	// This checks if no connection has the ID.
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		// If none has, a not found response is returned.
+		return response.NotFound(c, nil, "Connection not found")
+	}
+
+	// An OK response is returned.
+	return response.OKResponse(c, "SCIM token deleted successfully", nil)
+}
@@ -0,0 +1,70 @@
+// This file defines the data model for single sign-on connections.
+package sso
+
+// "strings" provides functions for working with strings. It is used here to read the domain of an email address.
+import (
+	"strings"
+	// "time" provides functions for working with time. It is used here to define the CreatedAt and UpdatedAt fields.
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to define the ID field.
+	"github.com/google/uuid"
+)
+
+// Connection is an OpenID Connect identity provider that the members of a workspace sign in with.
+// It stands for the workspace: the users whose email addresses are under its domains sign in through it,
+// and the groups the identity provider puts them in decide their role.
+type Connection struct {
+	// ID is the unique identifier for the connection.
+	ID uuid.UUID
+	// Slug names the connection in the URLs of the sign-in, such as "acme".
+	Slug string
+	// Name is the name of the connection shown to users, such as the name of the company.
+	Name string
+	// Issuer is the URL of the identity provider, whose discovery document describes its endpoints.
+	Issuer string
+	// ClientID is the ID the application is registered under at the identity provider.
+	ClientID string
+	// ClientSecret is the secret the application authenticates to the identity provider with.
+	ClientSecret string
+	// EmailDomains are the domains of the email addresses that may sign in through the connection, or empty to allow any.
+	EmailDomains []string
+	// GroupsClaim is the claim of the ID token that lists the groups of the user.
+	GroupsClaim string
+	// RoleMappings maps the groups of the identity provider to the roles of the application.
+	RoleMappings map[string]string
+	// DefaultRole is the role of the users who are in no mapped group.
+	DefaultRole string
+	// Enabled reports whether users can sign in through the connection.
+	Enabled bool
+	// CreatedAt is the time the connection was created.
+	CreatedAt time.Time
+	// UpdatedAt is the time the connection was last updated.
+	UpdatedAt time.Time
+	// SCIMEnabled reports whether the connection has a SCIM token, with which its identity provider provisions users.
+	SCIMEnabled bool
+}
+
+// AllowsEmail reports whether an email address may sign in through, or be provisioned by, the connection.
+//
+// @param email string - The email address, in lowercase.
+// @return bool - True if the connection has no domains or the address is under one of them.
+func (conn Connection) AllowsEmail(email string) bool {
+	// This checks if the connection allows any domain.
+	if len(conn.EmailDomains) == 0 {
+		// If it does, the address is allowed.
+		return true
+	}
+	// domain is the domain of the address.
+	domain := email[strings.LastIndex(email, "@")+1:]
+	// This iterates over the domains of the connection.
+	for _, allowed := range conn.EmailDomains {
+		// This checks if the address is under the domain.
+		if domain == allowed {
+			// If it is, the address is allowed.
+			return true
+		}
+	}
+	// The address is not allowed.
+	return false
+}
@@ -0,0 +1,398 @@
+// This file defines the OpenID Connect client of the connections: it discovers the endpoints and keys of an issuer,
+// exchanges the code of a sign-in for an ID token, and verifies the token against the keys of the issuer.
+package sso
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to bound the calls to the issuer.
+import (
+	"context"
+	// "crypto/ecdsa" implements ECDSA. It is used here to read the elliptic curve keys of an issuer.
+	"crypto/ecdsa"
+	// "crypto/elliptic" implements the standard elliptic curves. It is used here to name the curve of a key.
+	"crypto/elliptic"
+	// "crypto/rsa" implements RSA. It is used here to read the RSA keys of an issuer.
+	"crypto/rsa"
+	// "encoding/base64" implements base64 encoding. It is used here to decode the numbers of the keys.
+	"encoding/base64"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to decode the documents of the issuer.
+	"encoding/json"
+	// "errors" provides functions for working with errors. It is used here to define the verification errors.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to build errors.
+	"fmt"
+	// "io" provides basic I/O primitives. It is used here to bound the size of the documents.
+	"io"
+	// "math/big" implements arbitrary-precision integers. It is used here to build the numbers of the keys.
+	"math/big"
+	// "net/http" provides HTTP client and server implementations. It is used here to call the issuer.
+	"net/http"
+	// "net/url" provides functions for working with URLs. It is used here to encode the token request.
+	"net/url"
+	// "strings" provides functions for working with strings. It is used here to build the discovery URL.
+	"strings"
+	// "sync" provides synchronization primitives. It is used here to guard the cache of the issuers.
+	"sync"
+	// "time" provides functions for working with time. It is used here to expire the cache of the issuers.
+	"time"
+
+	// "github.com/golang-jwt/jwt/v5" is a package for creating and verifying JWTs. It is used here to verify ID tokens.
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// providerTTL is how long the endpoints and keys of an issuer are cached.
+const providerTTL = time.Hour
+
+// minRefreshInterval is how soon the keys of an issuer may be fetched again when a token names a key that is not known,
+// so that tokens with made-up key IDs cannot make the server call the issuer on every request.
+const minRefreshInterval = time.Minute
+
+// maxDocumentSize is the number of bytes a document of an issuer may have.
+const maxDocumentSize = 1 << 20
+
+// ErrUnknownKey is returned when an ID token is signed by a key the issuer does not publish.
+var ErrUnknownKey = errors.New("ID token is signed by an unknown key")
+
+// ErrInvalidNonce is returned when an ID token was not issued for the sign-in it is presented to.
+var ErrInvalidNonce = errors.New("ID token nonce does not match")
+
+// httpClient is the HTTP client of the calls to the issuers, with a timeout so that a slow issuer does not block the request.
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// providerMetadata is the part of the discovery document of an issuer that the connections use.
+type providerMetadata struct {
+	// Issuer is the issuer the document describes.
+	// json:"issuer" specifies that this field should be marshalled to/from a JSON object with the key "issuer".
+	Issuer string `json:"issuer"`
+	// AuthorizationEndpoint is the URL the user signs in at.
+	// json:"authorization_endpoint" specifies that this field should be marshalled to/from a JSON object with the key "authorization_endpoint".
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	// TokenEndpoint is the URL the code of a sign-in is exchanged at.
+	// json:"token_endpoint" specifies that this field should be marshalled to/from a JSON object with the key "token_endpoint".
+	TokenEndpoint string `json:"token_endpoint"`
+	// JWKSURI is the URL of the keys the ID tokens are signed with.
+	// json:"jwks_uri" specifies that this field should be marshalled to/from a JSON object with the key "jwks_uri".
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// jsonWebKey is a public key of an issuer.
+type jsonWebKey struct {
+	// Kty is the type of the key, "RSA" or "EC".
+	// json:"kty" specifies that this field should be marshalled to/from a JSON object with the key "kty".
+	Kty string `json:"kty"`
+	// Kid is the ID of the key, which the header of a token names.
+	// json:"kid" specifies that this field should be marshalled to/from a JSON object with the key "kid".
+	Kid string `json:"kid"`
+	// Use is what the key is for. Keys for encryption are skipped.
+	// json:"use" specifies that this field should be marshalled to/from a JSON object with the key "use".
+	Use string `json:"use"`
+	// N is the modulus of an RSA key.
+	// json:"n" specifies that this field should be marshalled to/from a JSON object with the key "n".
+	N string `json:"n"`
+	// E is the exponent of an RSA key.
+	// json:"e" specifies that this field should be marshalled to/from a JSON object with the key "e".
+	E string `json:"e"`
+	// Crv is the curve of an EC key.
+	// json:"crv" specifies that this field should be marshalled to/from a JSON object with the key "crv".
+	Crv string `json:"crv"`
+	// X is the x coordinate of an EC key.
+	// json:"x" specifies that this field should be marshalled to/from a JSON object with the key "x".
+	X string `json:"x"`
+	// Y is the y coordinate of an EC key.
+	// json:"y" specifies that this field should be marshalled to/from a JSON object with the key "y".
+	Y string `json:"y"`
+}
+
+// provider is the discovered issuer, with its keys.
+type provider struct {
+	// metadata is the discovery document of the issuer.
+	metadata providerMetadata
+	// keys are the public keys of the issuer, by key ID.
+	keys map[string]any
+	// fetchedAt is when the document and the keys were fetched.
+	fetchedAt time.Time
+}
+
+var (
+	// providersMu guards the providers.
+	providersMu sync.Mutex
+	// providers are the discovered issuers, by issuer.
+	providers = map[string]*provider{}
+)
+
+// discover returns the endpoints and keys of an issuer, from the cache while they are fresh.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param issuer string - The issuer.
+// @param force bool - Whether the cache is skipped, as long as the issuer was not fetched within the minimum refresh interval.
+// @return *provider - The issuer.
+// @return error - An error if the issuer could not be discovered.
+func discover(ctx context.Context, issuer string, force bool) (*provider, error) {
+	// cached is the cached issuer, if there is one.
+	providersMu.Lock()
+	cached := providers[issuer]
+	providersMu.Unlock()
+	// This checks if the cached issuer can be used.
+	if cached != nil && (time.Since(cached.fetchedAt) < providerTTL && !force || time.Since(cached.fetchedAt) < minRefreshInterval) {
+		// If it can, it is returned.
+		return cached, nil
+	}
+
+	// p is the issuer to be fetched.
+	p := &provider{fetchedAt: time.Now()}
+	// This fetches the discovery document, which is found under the issuer.
+	if err := fetchJSON(ctx, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &p.metadata); err != nil {
+		// If an error occurs, it is returned.
+		return nil, fmt.Errorf("fetching discovery document: %w", err)
+	}
+	// This checks if the document describes another issuer, in which case its tokens could not be trusted.
+	if p.metadata.Issuer != issuer {
+		// If it does, an error is returned.
+		return nil, fmt.Errorf("discovery document is for issuer %q", p.metadata.Issuer)
+	}
+	// This checks if an endpoint is missing.
+	if p.metadata.AuthorizationEndpoint == "" || p.metadata.TokenEndpoint == "" || p.metadata.JWKSURI == "" {
+		// If one is, an error is returned.
+		return nil, errors.New("discovery document is missing an endpoint")
+	}
+
+	// set is the key set of the issuer.
+	var set struct {
+		// Keys are the keys.
+		Keys []jsonWebKey `json:"keys"`
+	}
+	// This fetches the keys.
+	if err := fetchJSON(ctx, p.metadata.JWKSURI, &set); err != nil {
+		// If an error occurs, it is returned.
+		return nil, fmt.Errorf("fetching keys: %w", err)
+	}
+	// The keys are read, skipping the ones that are not for signatures or of an unsupported type.
+	p.keys = make(map[string]any, len(set.Keys))
+	for _, key := range set.Keys {
+		// This checks if the key is for encryption.
+		if key.Use != "" && key.Use != "sig" {
+			// If it is, it is skipped.
+			continue
+		}
+		// This reads the key.
+		if public, err := key.publicKey(); err == nil {
+			// If it is supported, it is stored under its ID.
+			p.keys[key.Kid] = public
+		}
+	}
+
+	// The issuer is cached.
+	providersMu.Lock()
+	providers[issuer] = p
+	providersMu.Unlock()
+	// The issuer is returned.
+	return p, nil
+}
+
+// fetchJSON fetches and decodes a JSON document.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param target string - The URL of the document.
+// @param v any - The value the document is decoded into.
+// @return error - An error if the document could not be fetched or decoded.
+func fetchJSON(ctx context.Context, target string, v any) error {
+	// req is the request of the document.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	// This checks if the request could not be built.
+	if err != nil {
+		// If it could not, the error is returned.
+		return err
+	}
+	// The issuer is asked for JSON.
+	req.Header.Set("Accept", "application/json")
+	// res is the response of the issuer.
+	res, err := httpClient.Do(req)
+	// This checks if the issuer could not be reached.
+	if err != nil {
+		// If it could not, the error is returned.
+		return err
+	}
+	// This defers closing the body until the document is read.
+	defer res.Body.Close()
+	// This checks if the issuer answered with an error.
+	if res.StatusCode != http.StatusOK {
+		// If it did, the status is returned as the error.
+		return fmt.Errorf("%s answered %s", target, res.Status)
+	}
+	// The document is decoded, up to the maximum size.
+	return json.NewDecoder(io.LimitReader(res.Body, maxDocumentSize)).Decode(v)
+}
+
+// publicKey reads a key into the public key the JWT package verifies signatures with.
+//
+// @return any - An *rsa.PublicKey or an *ecdsa.PublicKey.
+// @return error - An error if the key is malformed or of an unsupported type.
+func (k jsonWebKey) publicKey() (any, error) {
+	// This checks the type of the key.
+	switch k.Kty {
+	case "RSA":
+		// n and e are the modulus and the exponent.
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		// This checks if either number is malformed.
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			// If one is, an error is returned.
+			return nil, errors.New("malformed RSA key")
+		}
+		// The RSA key is returned.
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		// curve is the curve of the key.
+		var curve elliptic.Curve
+		// This selects the curve.
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			// Other curves are not supported.
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		// x and y are the coordinates.
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		// This checks if either coordinate is malformed.
+		if errX != nil || errY != nil {
+			// If one is, an error is returned.
+			return nil, errors.New("malformed EC key")
+		}
+		// The EC key is returned.
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	// Other types are not supported.
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// exchangeCode exchanges the code of a sign-in for an ID token, authenticating with the client secret of the connection.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param p *provider - The issuer.
+// @param conn Connection - The connection.
+// @param code string - The code sent to the callback.
+// @param redirectURI string - The callback URL the code was sent to.
+// @param verifier string - The PKCE code verifier of the sign-in.
+// @return string - The ID token.
+// @return error - An error if the issuer rejected the code.
+func exchangeCode(ctx context.Context, p *provider, conn Connection, code string, redirectURI string, verifier string) (string, error) {
+	// form is the body of the token request.
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	}
+	// req is the token request.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	// This checks if the request could not be built.
+	if err != nil {
+		// If it could not, the error is returned.
+		return "", err
+	}
+	// The body is a form.
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// The client authenticates with HTTP Basic credentials, which every issuer accepts. They are form-encoded first, as OAuth 2.0 asks.
+	req.SetBasicAuth(url.QueryEscape(conn.ClientID), url.QueryEscape(conn.ClientSecret))
+
+	// res is the response of the issuer.
+	res, err := httpClient.Do(req)
+	// This checks if the issuer could not be reached.
+	if err != nil {
+		// If it could not, the error is returned.
+		return "", err
+	}
+	// This defers closing the body until the response is read.
+	defer res.Body.Close()
+
+	// token is the decoded response.
+	var token struct {
+		// IDToken is the ID token.
+		IDToken string `json:"id_token"`
+		// Error is the error code of a rejected request.
+		Error string `json:"error"`
+		// ErrorDescription describes the error.
+		ErrorDescription string `json:"error_description"`
+	}
+	// This decodes the response.
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxDocumentSize)).Decode(&token); err != nil {
+		// If it cannot be decoded, an error with the status is returned.
+		return "", fmt.Errorf("token endpoint answered %s", res.Status)
+	}
+	// This checks if the issuer rejected the code.
+	if res.StatusCode != http.StatusOK || token.Error != "" {
+		// If it did, an error with its code is returned.
+		return "", fmt.Errorf("token endpoint answered %s: %s %s", res.Status, token.Error, token.ErrorDescription)
+	}
+	// This checks if no ID token was issued, which happens when the openid scope was not granted.
+	if token.IDToken == "" {
+		// If none was, an error is returned.
+		return "", errors.New("token endpoint returned no ID token")
+	}
+	// The ID token is returned.
+	return token.IDToken, nil
+}
+
+// verifyIDToken verifies the signature, issuer, audience, expiry, and nonce of an ID token, and returns its claims.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param p *provider - The issuer.
+// @param conn Connection - The connection.
+// @param raw string - The ID token.
+// @param nonce string - The nonce of the sign-in.
+// @return jwt.MapClaims - The claims of the token.
+// @return error - An error if the token is invalid.
+func verifyIDToken(ctx context.Context, p *provider, conn Connection, raw string, nonce string) (jwt.MapClaims, error) {
+	// claims are the claims of the token.
+	claims := jwt.MapClaims{}
+	// This parses and verifies the token.
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (any, error) {
+		// kid is the ID of the key the token names.
+		kid, _ := token.Header["kid"].(string)
+		// This checks if the key is known.
+		if key, ok := p.keys[kid]; ok {
+			// If it is, it is returned.
+			return key, nil
+		}
+		// refreshed is the issuer with its keys fetched again, since the issuer may have rotated them.
+		refreshed, err := discover(ctx, conn.Issuer, true)
+		// This checks if the keys could not be fetched.
+		if err != nil {
+			// If they could not, the error is returned.
+			return nil, err
+		}
+		// This checks if the key is known now.
+		if key, ok := refreshed.keys[kid]; ok {
+			// If it is, it is returned.
+			return key, nil
+		}
+		// Otherwise the key is unknown.
+		return nil, ErrUnknownKey
+	},
+		// The token must be signed with an asymmetric algorithm, so that a key cannot be used as an HMAC secret.
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		// The token must be issued by the issuer of the connection, for its client, and have an expiry.
+		jwt.WithIssuer(conn.Issuer),
+		jwt.WithAudience(conn.ClientID),
+		jwt.WithExpirationRequired(),
+		// The clocks of the server and the issuer may drift apart by a minute.
+		jwt.WithLeeway(time.Minute),
+	)
+	// This checks if the token is invalid.
+	if err != nil {
+		// If it is, the error is returned.
+		return nil, err
+	}
+	// This checks if the token was issued for another sign-in, which would let a captured token be replayed.
+	if got, _ := claims["nonce"].(string); got != nonce {
+		// If it was, an error is returned.
+		return nil, ErrInvalidNonce
+	}
+	// The claims are returned.
+	return claims, nil
+}
@@ -0,0 +1,159 @@
+// This file defines the serializers for single sign-on requests and responses.
+package sso
+
+// "github.com/google/uuid" is a package for working with UUIDs. It is used here to define the ID in the response struct.
+import (
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related serializers. It is used here for the profile in the login response.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions. It is used here to format times.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// connectionRequest defines the structure for a create or update connection request.
+type connectionRequest struct {
+	// Slug names the connection in the URLs of the sign-in.
+	// json:"slug" specifies that this field should be marshalled to/from a JSON object with the key "slug".
+	Slug string `json:"slug"`
+	// Name is the name of the connection shown to users.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+	// Issuer is the URL of the identity provider.
+	// json:"issuer" specifies that this field should be marshalled to/from a JSON object with the key "issuer".
+	Issuer string `json:"issuer"`
+	// ClientID is the ID the application is registered under at the identity provider.
+	// json:"client_id" specifies that this field should be marshalled to/from a JSON object with the key "client_id".
+	ClientID string `json:"client_id"`
+	// ClientSecret is the secret of the application. It may be left empty on update to keep the stored one.
+	// json:"client_secret" specifies that this field should be marshalled to/from a JSON object with the key "client_secret".
+	ClientSecret string `json:"client_secret"`
+	// EmailDomains are the domains of the email addresses that may sign in through the connection.
+	// json:"email_domains" specifies that this field should be marshalled to/from a JSON object with the key "email_domains".
+	EmailDomains []string `json:"email_domains"`
+	// GroupsClaim is the claim of the ID token that lists the groups of the user, "groups" if empty.
+	// json:"groups_claim" specifies that this field should be marshalled to/from a JSON object with the key "groups_claim".
+	GroupsClaim string `json:"groups_claim"`
+	// RoleMappings maps the groups of the identity provider to roles.
+	// json:"role_mappings" specifies that this field should be marshalled to/from a JSON object with the key "role_mappings".
+	RoleMappings map[string]string `json:"role_mappings"`
+	// DefaultRole is the role of the users who are in no mapped group, "user" if empty.
+	// json:"default_role" specifies that this field should be marshalled to/from a JSON object with the key "default_role".
+	DefaultRole string `json:"default_role"`
+	// Enabled reports whether users can sign in through the connection. It is a pointer so that an omitted field enables it.
+	// json:"enabled" specifies that this field should be marshalled to/from a JSON object with the key "enabled".
+	Enabled *bool `json:"enabled"`
+}
+
+// ConnectionResponse defines the structure for a connection, without its client secret.
+type ConnectionResponse struct {
+	// ID is the unique identifier for the connection.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// Slug names the connection in the URLs of the sign-in.
+	// json:"slug" specifies that this field should be marshalled to/from a JSON object with the key "slug".
+	Slug string `json:"slug"`
+	// Name is the name of the connection shown to users.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+	// Issuer is the URL of the identity provider.
+	// json:"issuer" specifies that this field should be marshalled to/from a JSON object with the key "issuer".
+	Issuer string `json:"issuer"`
+	// ClientID is the ID the application is registered under at the identity provider.
+	// json:"client_id" specifies that this field should be marshalled to/from a JSON object with the key "client_id".
+	ClientID string `json:"client_id"`
+	// EmailDomains are the domains of the email addresses that may sign in through the connection.
+	// json:"email_domains" specifies that this field should be marshalled to/from a JSON object with the key "email_domains".
+	EmailDomains []string `json:"email_domains"`
+	// GroupsClaim is the claim of the ID token that lists the groups of the user.
+	// json:"groups_claim" specifies that this field should be marshalled to/from a JSON object with the key "groups_claim".
+	GroupsClaim string `json:"groups_claim"`
+	// RoleMappings maps the groups of the identity provider to roles.
+	// json:"role_mappings" specifies that this field should be marshalled to/from a JSON object with the key "role_mappings".
+	RoleMappings map[string]string `json:"role_mappings"`
+	// DefaultRole is the role of the users who are in no mapped group.
+	// json:"default_role" specifies that this field should be marshalled to/from a JSON object with the key "default_role".
+	DefaultRole string `json:"default_role"`
+	// Enabled reports whether users can sign in through the connection.
+	// json:"enabled" specifies that this field should be marshalled to/from a JSON object with the key "enabled".
+	Enabled bool `json:"enabled"`
+	// SCIMEnabled reports whether the connection has a SCIM token.
+	// json:"scim_enabled" specifies that this field should be marshalled to/from a JSON object with the key "scim_enabled".
+	SCIMEnabled bool `json:"scim_enabled"`
+	// RedirectURI is the callback URL to register at the identity provider.
+	// json:"redirect_uri" specifies that this field should be marshalled to/from a JSON object with the key "redirect_uri".
+	RedirectURI string `json:"redirect_uri"`
+	// CreatedAt is the time the connection was created.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt string `json:"created_at"`
+	// UpdatedAt is the time the connection was last updated.
+	// json:"updated_at" specifies that this field should be marshalled to/from a JSON object with the key "updated_at".
+	UpdatedAt string `json:"updated_at"`
+}
+
+// NewConnectionResponse converts a connection into its response structure.
+//
+// @param conn Connection - The connection.
+// @param redirectURI string - The callback URL of the connection.
+// @return ConnectionResponse - The response structure.
+func NewConnectionResponse(conn Connection, redirectURI string) ConnectionResponse {
+	// A new ConnectionResponse struct is returned.
+	return ConnectionResponse{
+		// The ID field is set to the connection's ID.
+		ID: conn.ID,
+		// The Slug field is set to the connection's slug.
+		Slug: conn.Slug,
+		// The Name field is set to the connection's name.
+		Name: conn.Name,
+		// The Issuer field is set to the connection's issuer.
+		Issuer: conn.Issuer,
+		// The ClientID field is set to the connection's client ID.
+		ClientID: conn.ClientID,
+		// The EmailDomains field is set to the connection's email domains.
+		EmailDomains: conn.EmailDomains,
+		// The GroupsClaim field is set to the connection's groups claim.
+		GroupsClaim: conn.GroupsClaim,
+		// The RoleMappings field is set to the connection's role mappings.
+		RoleMappings: conn.RoleMappings,
+		// The DefaultRole field is set to the connection's default role.
+		DefaultRole: conn.DefaultRole,
+		// The Enabled field is set to whether the connection is enabled.
+		Enabled: conn.Enabled,
+		// The SCIMEnabled field is set to whether the connection has a SCIM token.
+		SCIMEnabled: conn.SCIMEnabled,
+		// The RedirectURI field is set to the callback URL.
+		RedirectURI: redirectURI,
+		// The CreatedAt field is set to the time the connection was created.
+		CreatedAt: utils.ParseTime(conn.CreatedAt),
+		// The UpdatedAt field is set to the time the connection was last updated.
+		UpdatedAt: utils.ParseTime(conn.UpdatedAt),
+	}
+}
+
+// LoginURLResponse defines the structure for a sign-in URL response.
+type LoginURLResponse struct {
+	// URL is the authorization URL of the identity provider the user is sent to.
+	// json:"url" specifies that this field should be marshalled to/from a JSON object with the key "url".
+	URL string `json:"url"`
+}
+
+// LoginResponse defines the structure for a completed sign-in, which matches the response of a login with a password.
+type LoginResponse struct {
+	// ProfileResponse is the user's profile, whose fields are part of the response object.
+	users.ProfileResponse
+	// Token is the user's JWT.
+	// json:"token" specifies that this field should be marshalled to/from a JSON object with the key "token".
+	Token string `json:"token"`
+	// ExpiresAt is the expiration time of the JWT.
+	// json:"expires_at" specifies that this field should be marshalled to/from a JSON object with the key "expires_at".
+	ExpiresAt string `json:"expires_at"`
+}
+
+// SCIMTokenResponse defines the structure for a new SCIM token, which is only shown once.
+type SCIMTokenResponse struct {
+	// Token is the bearer token the identity provider sends to the SCIM endpoints.
+	// json:"token" specifies that this field should be marshalled to/from a JSON object with the key "token".
+	Token string `json:"token"`
+	// BaseURL is the URL of the SCIM endpoints to configure at the identity provider.
+	// json:"base_url" specifies that this field should be marshalled to/from a JSON object with the key "base_url".
+	BaseURL string `json:"base_url"`
+}
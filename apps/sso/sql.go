@@ -0,0 +1,34 @@
+// This file defines the SQL queries used by single sign-on connections.
+package sso
+
+// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names.
+import "github.com/rahulcodepython/todo-backend/backend/utils"
+
+// connectionColumns are the columns of a connection, in the order scanConnection reads them. The hash of the SCIM token is
+// only read as whether there is one.
+const connectionColumns = "id, slug, name, issuer, client_id, client_secret, email_domains, groups_claim, role_mappings, default_role, enabled, created_at, updated_at, scim_token_hash IS NOT NULL"
+
+// CreateConnectionQuery is the SQL query to create a connection, returning the times it was created and updated.
+const CreateConnectionQuery = "INSERT INTO " + utils.SSOConnectionTableName + " (id, slug, name, issuer, client_id, client_secret, email_domains, groups_claim, role_mappings, default_role, enabled) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING created_at, updated_at"
+
+// GetConnectionsQuery is the SQL query to list every connection, by slug.
+const GetConnectionsQuery = "SELECT " + connectionColumns + " FROM " + utils.SSOConnectionTableName + " ORDER BY slug"
+
+// GetConnectionQuery is the SQL query to read a connection by its ID.
+const GetConnectionQuery = "SELECT " + connectionColumns + " FROM " + utils.SSOConnectionTableName + " WHERE id = $1"
+
+// GetConnectionBySlugQuery is the SQL query to read a connection by its slug.
+const GetConnectionBySlugQuery = "SELECT " + connectionColumns + " FROM " + utils.SSOConnectionTableName + " WHERE slug = $1"
+
+// UpdateConnectionQuery is the SQL query to update a connection, returning it. An empty client secret keeps the stored one,
+// so that an admin can change the other settings without knowing the secret, which the API never returns.
+const UpdateConnectionQuery = "UPDATE " + utils.SSOConnectionTableName + " SET slug = $2, name = $3, issuer = $4, client_id = $5, client_secret = COALESCE(NULLIF($6, ''), client_secret), email_domains = $7, groups_claim = $8, role_mappings = $9, default_role = $10, enabled = $11, updated_at = NOW() WHERE id = $1 RETURNING " + connectionColumns
+
+// DeleteConnectionQuery is the SQL query to delete a connection. Deleting it deletes its identities and SCIM links, but not the users they link.
+const DeleteConnectionQuery = "DELETE FROM " + utils.SSOConnectionTableName + " WHERE id = $1"
+
+// GetConnectionBySCIMTokenQuery is the SQL query to read the enabled connection whose SCIM token has a hash.
+const GetConnectionBySCIMTokenQuery = "SELECT " + connectionColumns + " FROM " + utils.SSOConnectionTableName + " WHERE scim_token_hash = $1 AND enabled"
+
+// SetSCIMTokenQuery is the SQL query to replace the hash of the SCIM token of a connection, or to remove it with NULL.
+const SetSCIMTokenQuery = "UPDATE " + utils.SSOConnectionTableName + " SET scim_token_hash = $2, updated_at = NOW() WHERE id = $1"
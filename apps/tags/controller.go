@@ -0,0 +1,182 @@
+// This file defines the controllers for tag-related operations.
+// Tags belong to todos, so they are listed from the todos that use them; what is stored here is the style users pick for them.
+package tags
+
+// "database/sql" provides a generic SQL interface. It is used here to interact with the database.
+import (
+	"database/sql"
+	// "net/url" provides functions for working with URLs. It is used here to decode the tag in the path.
+	"net/url"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to define the controllers.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/apps/todos" is a local package that normalizes tags.
+	"github.com/rahulcodepython/todo-backend/apps/todos"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/binding" is a local package that binds request parameters.
+	"github.com/rahulcodepython/todo-backend/backend/binding"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/content" is a local package that checks the colors and icons picked by users.
+	"github.com/rahulcodepython/todo-backend/backend/content"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+)
+
+// TagController is a struct that holds the configuration and database connection.
+type TagController struct {
+	// cfg is the application configuration.
+	cfg *config.Config
+	// db is the database connection.
+	db *sql.DB
+}
+
+// NewTagControl creates a new TagController.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @return *TagController - A pointer to the new TagController.
+func NewTagControl(cfg *config.Config, db *sql.DB) *TagController {
+	// A new TagController is returned.
+	return &TagController{
+		// The cfg field is set to the application configuration.
+		cfg: cfg,
+		// The db field is set to the database connection.
+		db: db,
+	}
+}
+
+// GetTagsController handles the retrieval of the current user's tags and their styles.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (tc *TagController) GetTagsController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// query is the bound query parameters.
+	query, err := binding.Query[ListTagsQuery](c)
+	// This checks if a parameter is invalid.
+	if err != nil {
+		// If one is, a bad request response is returned with the invalid parameters.
+		return response.InvalidParameters(c, err)
+	}
+
+	// rows is the result of querying the database for the user's tags, of the requested color if one was given.
+	rows, err := tc.db.QueryContext(c.UserContext(), GetTagsQuery, user.ID, sql.NullString{String: query.Color, Valid: query.Color != ""})
+	// This checks if an error occurred while querying the database.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get tags")
+	}
+	// This defers the closing of the rows until the function returns.
+	defer rows.Close()
+
+	// tags is a slice that will hold the retrieved tags.
+	tags := []TagResponse{}
+	// This iterates over the rows.
+	for rows.Next() {
+		// tag is the tag of the row.
+		var tag Tag
+		// This scans the row into the tag.
+		if err := rows.Scan(&tag.Name, &tag.TodoCount, &tag.Color, &tag.Icon); err != nil {
+			// If an error occurs, an internal server error response is returned.
+			return response.InternelServerError(c, err, "Unable to get tags")
+		}
+		// The tag is appended to the tags slice.
+		tags = append(tags, NewTagResponse(tag))
+	}
+	// This checks if an error occurred while iterating over the rows.
+	if err := rows.Err(); err != nil {
+		// If one did, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get tags")
+	}
+
+	// An OK response is returned with a success message and the tags.
+	return response.OKResponse(c, "Tags fetched successfully", tags)
+}
+
+// SetTagStyleController handles setting the color and icon of one of the current user's tags.
+// The tag is normalized like the tags of todos, so "#Work" styles "work". A request with neither a color nor an icon
+// removes the style.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (tc *TagController) SetTagStyleController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// raw is the tag in the path, decoded, since tags may contain characters that are escaped in URLs.
+	raw, err := url.PathUnescape(c.Params("tag"))
+	// This checks if the tag is not a valid escaped path segment.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid tag")
+	}
+	// normalized is the tag as it is stored on todos.
+	normalized := todos.NormalizeTags([]string{raw})
+	// This checks if nothing is left of the tag.
+	if len(normalized) == 0 {
+		// If nothing is, a bad request response is returned.
+		return response.BadResponse(c, "Tag is required")
+	}
+
+	// body is a new TagStyleRequest struct.
+	body := new(TagStyleRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+	// The color and icon are normalized and checked.
+	if errs := content.CleanAppearance(content.Appearance{Color: &body.Color, Icon: &body.Icon}); errs != nil {
+		// If one is invalid, a bad request response is returned with the invalid fields.
+		return response.BadInternalResponse(c, errs, "Invalid request body")
+	}
+
+	// tag is the tag with its new style.
+	tag := Tag{
+		// The Name field is set to the normalized tag.
+		Name: normalized[0],
+		// The Color field is set to the color, which is stored as NULL when none was picked.
+		Color: sql.NullString{String: body.Color, Valid: body.Color != ""},
+		// The Icon field is set to the icon, which is stored as NULL when none was picked.
+		Icon: sql.NullString{String: body.Icon, Valid: body.Icon != ""},
+	}
+
+	// This checks if the style is empty.
+	if !tag.Color.Valid && !tag.Icon.Valid {
+		// If it is, the style is removed rather than stored as a row of NULLs.
+		_, err = tc.db.ExecContext(c.UserContext(), DeleteTagStyleQuery, user.ID, tag.Name)
+	} else {
+		// Otherwise the style is stored.
+		_, err = tc.db.ExecContext(c.UserContext(), UpsertTagStyleQuery, user.ID, tag.Name, tag.Color, tag.Icon)
+	}
+	// This checks if an error occurred while storing the style.
+	if err != nil {
+		// If one did, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to update tag")
+	}
+
+	// This counts the todos that use the tag, for the response.
+	if err := tc.db.QueryRowContext(c.UserContext(), CountTagTodosQuery, user.ID, tag.Name).Scan(&tag.TodoCount); err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to update tag")
+	}
+
+	// An OK response is returned with a success message and the tag.
+	return response.OKResponse(c, "Tag updated successfully", NewTagResponse(tag))
+}
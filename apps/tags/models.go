@@ -0,0 +1,18 @@
+// This file defines the data model for tags.
+package tags
+
+// "database/sql" provides a generic SQL interface. It is used here to define the nullable Color and Icon fields.
+import "database/sql"
+
+// Tag represents a tag of a user's todos and the style the user picked for it.
+// Tags are stored on the todos themselves; only their style has a table of its own.
+type Tag struct {
+	// Name is the normalized name of the tag.
+	Name string
+	// TodoCount is the number of the user's todos that use the tag.
+	TodoCount int64
+	// Color is the hex color the user picked for the tag, if any.
+	Color sql.NullString
+	// Icon is the emoji the user picked for the tag, if any.
+	Icon sql.NullString
+}
@@ -0,0 +1,72 @@
+// This file defines the serializers for tag-related requests and responses.
+package tags
+
+// "github.com/rahulcodepython/todo-backend/backend/binding" is a local package that binds request parameters.
+import (
+	"github.com/rahulcodepython/todo-backend/backend/binding"
+	// "github.com/rahulcodepython/todo-backend/backend/content" is a local package that checks the colors and icons picked by users.
+	"github.com/rahulcodepython/todo-backend/backend/content"
+)
+
+// ListTagsQuery defines the query parameters of a request for the user's tags.
+type ListTagsQuery struct {
+	// Color is the hex color the tags must have, or empty for every tag.
+	// query:"color" specifies that this field is bound to the "color" query parameter.
+	Color string `query:"color"`
+}
+
+// Validate normalizes the color the same way stored colors are.
+//
+// @return binding.FieldErrors - The invalid parameters, or nil if there are none.
+func (q *ListTagsQuery) Validate() binding.FieldErrors {
+	// The color is checked and normalized in place.
+	return content.CleanAppearance(content.Appearance{Color: &q.Color})
+}
+
+// TagStyleRequest defines the structure for a request that sets the style of a tag.
+// It replaces the whole style, so a missing or empty field removes it.
+type TagStyleRequest struct {
+	// Color is the hex color of the tag, such as "#1e90ff".
+	// json:"color" specifies that this field should be marshalled to/from a JSON object with the key "color".
+	Color string `json:"color"`
+	// Icon is the emoji of the tag.
+	// json:"icon" specifies that this field should be marshalled to/from a JSON object with the key "icon".
+	Icon string `json:"icon"`
+}
+
+// TagResponse defines the structure for a tag response.
+type TagResponse struct {
+	// Name is the name of the tag.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+	// TodoCount is the number of todos that use the tag.
+	// json:"todo_count" specifies that this field should be marshalled to/from a JSON object with the key "todo_count".
+	TodoCount int64 `json:"todo_count"`
+	// Color is the hex color of the tag, or null if none was picked.
+	// json:"color" specifies that this field should be marshalled to/from a JSON object with the key "color".
+	Color *string `json:"color"`
+	// Icon is the emoji of the tag, or null if none was picked.
+	// json:"icon" specifies that this field should be marshalled to/from a JSON object with the key "icon".
+	Icon *string `json:"icon"`
+}
+
+// NewTagResponse converts a tag into its response structure.
+//
+// @param tag Tag - The tag to be converted.
+// @return TagResponse - The tag response.
+func NewTagResponse(tag Tag) TagResponse {
+	// response is the tag response without its style.
+	response := TagResponse{Name: tag.Name, TodoCount: tag.TodoCount}
+	// This checks if the tag has a color.
+	if tag.Color.Valid {
+		// If it has, the color is set.
+		response.Color = &tag.Color.String
+	}
+	// This checks if the tag has an icon.
+	if tag.Icon.Valid {
+		// If it has, the icon is set.
+		response.Icon = &tag.Icon.String
+	}
+	// The tag response is returned.
+	return response
+}
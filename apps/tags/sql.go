@@ -0,0 +1,18 @@
+// This file defines the SQL queries used for tag-related database operations.
+package tags
+
+// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names and schemas.
+import "github.com/rahulcodepython/todo-backend/backend/utils"
+
+// GetTagsQuery is the SQL query to retrieve the tags of a user with the number of todos that use them and their style,
+// optionally only those of a color. A tag that was styled but is no longer used is still returned, with no todos.
+const GetTagsQuery = "SELECT COALESCE(u.tag, s.tag), COALESCE(u.todo_count, 0), s.color, s.icon FROM (SELECT tag, COUNT(*) AS todo_count FROM " + utils.TodoTableName + ", unnest(tags) AS tag WHERE owner = $1 AND deleted_at IS NULL GROUP BY tag) AS u FULL JOIN (SELECT tag, color, icon FROM " + utils.TagStyleTableName + " WHERE user_id = $1) AS s ON s.tag = u.tag WHERE ($2::text IS NULL OR s.color = $2) ORDER BY 1"
+
+// CountTagTodosQuery is the SQL query to count the todos of a user that use a tag.
+const CountTagTodosQuery = "SELECT COUNT(*) FROM " + utils.TodoTableName + " WHERE owner = $1 AND deleted_at IS NULL AND $2 = ANY(tags)"
+
+// UpsertTagStyleQuery is the SQL query to set the color and icon of a tag, replacing its previous style.
+const UpsertTagStyleQuery = "INSERT INTO " + utils.TagStyleTableName + " (" + utils.TagStyleTableSchema + ") VALUES ($1, $2, $3, $4) ON CONFLICT (user_id, tag) DO UPDATE SET color = EXCLUDED.color, icon = EXCLUDED.icon"
+
+// DeleteTagStyleQuery is the SQL query to remove the style of a tag.
+const DeleteTagStyleQuery = "DELETE FROM " + utils.TagStyleTableName + " WHERE user_id = $1 AND tag = $2"
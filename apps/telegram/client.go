@@ -0,0 +1,75 @@
+// This file defines a minimal client for the Telegram Bot API.
+package telegram
+
+// "bytes" provides functions for working with byte slices. It is used here to build the request body.
+import (
+	"bytes"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to encode the request body.
+	"encoding/json"
+	// "fmt" provides functions for formatted I/O. It is used here to build the API URL and errors.
+	"fmt"
+	// "net/http" provides HTTP client and server implementations. It is used here to call the Bot API.
+	"net/http"
+	// "time" provides functions for working with time. It is used here to set the request timeout.
+	"time"
+)
+
+// apiBaseURL is the base URL of the Telegram Bot API.
+const apiBaseURL = "https://api.telegram.org"
+
+// Client is a struct that sends messages through the Telegram Bot API.
+type Client struct {
+	// token is the bot token.
+	token string
+	// http is the HTTP client used for the calls.
+	http *http.Client
+}
+
+// NewClient creates a new Client.
+// It takes the bot token as input.
+//
+// @param token string - The bot token.
+// @return *Client - A pointer to the new Client.
+func NewClient(token string) *Client {
+	// A new Client is returned.
+	return &Client{
+		// The token field is set to the bot token.
+		token: token,
+		// The http field is set to a client with a timeout so that a slow API does not block the caller.
+		http: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SendMessage sends a text message to a chat.
+//
+// @param chatId int64 - The chat the message is sent to.
+// @param text string - The text of the message.
+// @return error - An error if one occurred.
+func (tc *Client) SendMessage(chatId int64, text string) error {
+	// body is the encoded sendMessage request.
+	body, err := json.Marshal(sendMessageRequest{ChatID: chatId, Text: text})
+	// This checks if an error occurred while encoding the request.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// res is the response of the Bot API.
+	res, err := tc.http.Post(fmt.Sprintf("%s/bot%s/sendMessage", apiBaseURL, tc.token), "application/json", bytes.NewReader(body))
+	// This checks if an error occurred while calling the API.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// This defers the closing of the response body until the function returns.
+	defer res.Body.Close()
+
+	// This checks if the API rejected the message.
+	if res.StatusCode != http.StatusOK {
+		// If it did, an error with the status is returned.
+		return fmt.Errorf("telegram sendMessage failed with status %d", res.StatusCode)
+	}
+
+	// No error is returned.
+	return nil
+}
@@ -0,0 +1,339 @@
+// This file defines the controllers for the Telegram integration.
+package telegram
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to bound the queries of a webhook by its deadline.
+import (
+	"context"
+	// "crypto/rand" provides a cryptographically secure random number generator. It is used here to generate link codes.
+	"crypto/rand"
+	// "crypto/subtle" provides constant-time comparisons. It is used here to check the webhook secret.
+	"crypto/subtle"
+	// "database/sql" provides a generic SQL interface. It is used here to interact with the database.
+	"database/sql"
+	// "encoding/hex" provides hexadecimal encoding. It is used here to encode link codes.
+	"encoding/hex"
+	// "encoding/json" provides functions for decoding JSON. It is used here to decode webhook updates.
+	"encoding/json"
+	// "errors" provides functions for working with errors. It is used here to compare errors.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to build bot replies.
+	"fmt"
+	// "strings" provides functions for working with strings. It is used here to parse bot commands.
+	"strings"
+	// "time" provides functions for working with time. It is used here to set the link code expiry.
+	"time"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to define the controllers.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to hold user IDs.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/apps/todos" is a local package that creates todos from text.
+	"github.com/rahulcodepython/todo-backend/apps/todos"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models and queries.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database helpers.
+	"github.com/rahulcodepython/todo-backend/backend/database"
+	// "github.com/rahulcodepython/todo-backend/backend/quota" is a local package that enforces usage limits.
+	"github.com/rahulcodepython/todo-backend/backend/quota"
+	// "github.com/rahulcodepython/todo-backend/backend/reqlog" is a local package that provides the logger of a request. It is used here to log webhook errors with the request they belong to.
+	"github.com/rahulcodepython/todo-backend/backend/reqlog"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// linkCodeTTL is how long a link code stays valid.
+const linkCodeTTL = 15 * time.Minute
+
+// helpText is the reply sent for /help and unknown commands.
+const helpText = "Send any message to add it as a todo, e.g. \"Pay rent tomorrow 9am #home !high\". Link this chat first with /start CODE from the app."
+
+// TelegramController is a struct that holds the configuration, database connection, and todo service.
+type TelegramController struct {
+	// cfg is the application configuration.
+	cfg *config.Config
+	// db is the database connection.
+	db *sql.DB
+	// todoService is the todo service that the commands act through.
+	todoService *todos.TodoService
+}
+
+// NewTelegramControl creates a new TelegramController.
+// It takes the application configuration, database connection, and todo service as input.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @param todoService *todos.TodoService - The todo service.
+// @return *TelegramController - A pointer to the new TelegramController.
+func NewTelegramControl(cfg *config.Config, db *sql.DB, todoService *todos.TodoService) *TelegramController {
+	// A new TelegramController is returned.
+	return &TelegramController{
+		// The cfg field is set to the application configuration.
+		cfg: cfg,
+		// The db field is set to the database connection.
+		db: db,
+		// The todoService field is set to the todo service.
+		todoService: todoService,
+	}
+}
+
+// CreateLinkController issues a one-time code that links a Telegram chat to the current user.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (tc *TelegramController) CreateLinkController(c *fiber.Ctx) error {
+	// This checks if the integration is disabled.
+	if tc.cfg.Telegram.BotToken == "" {
+		// If it is, a not found response is returned.
+		return response.NotFound(c, nil, "Telegram integration is not configured")
+	}
+
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// raw is the random bytes of the code.
+	raw := make([]byte, 6)
+	// This fills the bytes from the secure random generator.
+	if _, err := rand.Read(raw); err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to create link code")
+	}
+
+	// code is the hex-encoded link code.
+	code := strings.ToUpper(hex.EncodeToString(raw))
+	// expiresAt is the time the code expires.
+	expiresAt := time.Now().Add(linkCodeTTL)
+
+	// _, err is the result of executing the SQL query to store the code.
+	_, err = tc.db.ExecContext(c.UserContext(), UpsertLinkCodeQuery, user.ID, code, expiresAt)
+	// This checks if an error occurred while executing the query.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to create link code")
+	}
+
+	// A created response is returned with a success message and the code.
+	return response.OKCreatedResponse(c, "Link code created successfully", LinkCodeResponse{
+		// The Code field is set to the link code.
+		Code: code,
+		// The Command field is set to the message to send to the bot.
+		Command: "/start " + code,
+		// The ExpiresAt field is set to the expiry of the code.
+		ExpiresAt: utils.ParseTime(expiresAt),
+	})
+}
+
+// DeleteLinkController removes the Telegram link of the current user.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (tc *TelegramController) DeleteLinkController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// _, err is the result of executing the SQL query to remove the link.
+	_, err = tc.db.ExecContext(c.UserContext(), DeleteLinkQuery, user.ID)
+	// This checks if an error occurred while executing the query.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to unlink Telegram")
+	}
+
+	// An OK response is returned with a success message.
+	return response.OKResponse(c, "Telegram unlinked successfully", nil)
+}
+
+// WebhookController handles updates sent by Telegram.
+// Replies are returned in the webhook response so that no extra API call is needed.
+// Handled updates always get a 200 response so that Telegram does not retry them.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (tc *TelegramController) WebhookController(c *fiber.Ctx) error {
+	// This checks if the integration is disabled.
+	if tc.cfg.Telegram.BotToken == "" {
+		// If it is, a not found response is returned.
+		return response.NotFound(c, nil, "Telegram integration is not configured")
+	}
+
+	// secret is the secret token sent by Telegram.
+	secret := c.Get("X-Telegram-Bot-Api-Secret-Token")
+	// This checks if the secret does not match the configured one.
+	if tc.cfg.Telegram.WebhookSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(tc.cfg.Telegram.WebhookSecret)) != 1 {
+		// If it does not match, an unauthorized response is returned.
+		return response.UnauthorizedAccess(c, nil, "Invalid webhook secret")
+	}
+
+	// update is a new Update struct.
+	update := new(Update)
+	// This parses the request body into the update struct.
+	// The body is decoded without BodyParser, because Telegram sends many fields that the struct leaves out on purpose.
+	if err := json.Unmarshal(c.Body(), update); err != nil || update.Message == nil || update.Message.Text == "" {
+		// Updates that are not text messages are acknowledged and ignored.
+		return c.SendStatus(fiber.StatusOK)
+	}
+
+	// reply is the text sent back to the chat.
+	reply := tc.handleMessage(c.UserContext(), update.Message)
+
+	// The reply is returned as a sendMessage call.
+	return c.Status(fiber.StatusOK).JSON(sendMessageRequest{
+		// The Method field tells Telegram to send the reply as a message.
+		Method: "sendMessage",
+		// The ChatID field is set to the chat of the incoming message.
+		ChatID: update.Message.Chat.ID,
+		// The Text field is set to the reply.
+		Text: reply,
+	})
+}
+
+// handleMessage runs a bot command or adds the message as a todo and returns the reply.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param message *Message - The incoming message.
+// @return string - The reply.
+func (tc *TelegramController) handleMessage(ctx context.Context, message *Message) string {
+	// text is the trimmed message text.
+	text := strings.TrimSpace(message.Text)
+
+	// This checks if the message is a command.
+	if strings.HasPrefix(text, "/") {
+		// command and argument are the command name and the rest of the message.
+		command, argument, _ := strings.Cut(text, " ")
+		// The bot username suffix of commands sent in groups is removed.
+		command, _, _ = strings.Cut(command, "@")
+
+		// This checks the command.
+		switch command {
+		case "/start":
+			// /start links the chat.
+			return tc.linkChat(ctx, message.Chat.ID, strings.TrimSpace(argument))
+		default:
+			// Other commands get the help text.
+			return helpText
+		}
+	}
+
+	// user is the user linked to the chat.
+	user, err := tc.linkedUser(ctx, message.Chat.ID)
+	// This checks if the chat is not linked.
+	if errors.Is(err, sql.ErrNoRows) {
+		// If it is not, the user is asked to link it.
+		return "This chat is not linked yet. Create a link code in the app and send /start CODE."
+	}
+	// This checks if another error occurred.
+	if err != nil {
+		// If it did, it is logged and a generic reply is returned.
+		reqlog.From(ctx).Printf("Unable to find Telegram user for chat %d: %v", message.Chat.ID, err)
+		return "Something went wrong, please try again."
+	}
+	// The user is added to the logger of the request, since no authentication middleware ran before the chat was matched to it.
+	reqlog.SetUser(ctx, user.ID.String())
+
+	// todo is the todo created from the message.
+	todo, err := tc.todoService.CreateFromText(ctx, user, text)
+	// This checks if nothing was left for the title.
+	if errors.Is(err, todos.ErrEmptyTitle) {
+		// If nothing was left, the user is asked for a title.
+		return "Please include a title for the todo."
+	}
+	// exceeded is the limit that was reached, if any.
+	var exceeded *quota.ExceededError
+	// This checks if the plan limit was reached.
+	if errors.As(err, &exceeded) {
+		// If it was, the user is told which limit was reached.
+		return fmt.Sprintf("You have reached the limit of %d todos on your plan.", exceeded.Limit)
+	}
+	// This checks if another error occurred.
+	if err != nil {
+		// If it did, it is logged and a generic reply is returned.
+		reqlog.From(ctx).Printf("Unable to create todo from Telegram chat %d: %v", message.Chat.ID, err)
+		return "Something went wrong, please try again."
+	}
+
+	// This checks if the todo has a due date.
+	if todo.DueAt.Valid {
+		// If it does, the due date is included in the reply in the user's time zone.
+		return fmt.Sprintf("Added: %s (due %s)", todo.Title, todo.DueAt.Time.In(user.Location()).Format("Mon 2 Jan 15:04"))
+	}
+	// The reply without a due date is returned.
+	return fmt.Sprintf("Added: %s", todo.Title)
+}
+
+// linkChat links a chat to the user who owns the link code.
+// A chat can only be linked to one user, so any previous link of the chat is removed.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param chatId int64 - The chat to link.
+// @param code string - The link code.
+// @return string - The reply.
+func (tc *TelegramController) linkChat(ctx context.Context, chatId int64, code string) string {
+	// This checks if no code was given.
+	if code == "" {
+		// If none was given, the help text is returned.
+		return helpText
+	}
+
+	// err is the result of linking the chat in a transaction.
+	err := database.WithTx(ctx, tc.db, func(tx *sql.Tx) error {
+		// This detaches the chat from any previous user.
+		if _, err := tx.ExecContext(ctx, UnlinkChatQuery, chatId); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// userId is the ID of the user who owns the code.
+		var userId uuid.UUID
+		// The code is consumed and the chat is linked.
+		return tx.QueryRowContext(ctx, ConsumeLinkCodeQuery, chatId, strings.ToUpper(code)).Scan(&userId)
+	})
+	// This checks if the code is unknown or expired.
+	if errors.Is(err, sql.ErrNoRows) {
+		// If it is, the user is told so.
+		return "This link code is invalid or has expired. Create a new one in the app."
+	}
+	// This checks if another error occurred.
+	if err != nil {
+		// If it did, it is logged and a generic reply is returned.
+		reqlog.From(ctx).Printf("Unable to link Telegram chat %d: %v", chatId, err)
+		return "Something went wrong, please try again."
+	}
+
+	// The success reply is returned.
+	return "Linked! Send any message to add it as a todo."
+}
+
+// linkedUser retrieves the user linked to a chat.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param chatId int64 - The chat.
+// @return users.User - The linked user.
+// @return error - sql.ErrNoRows if the chat is not linked, or another error if one occurred.
+func (tc *TelegramController) linkedUser(ctx context.Context, chatId int64) (users.User, error) {
+	// userId is the ID of the linked user.
+	var userId uuid.UUID
+	// This queries the database for the linked user ID.
+	if err := tc.db.QueryRowContext(ctx, GetLinkedUserQuery, chatId).Scan(&userId); err != nil {
+		// If an error occurs, it is returned.
+		return users.User{}, err
+	}
+
+	// The linked user's profile is returned.
+	return users.GetUserByID(ctx, tc.db, userId)
+}
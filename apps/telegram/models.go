@@ -0,0 +1,32 @@
+// This file defines the data models for the Telegram integration.
+package telegram
+
+// Update represents the subset of a Telegram update that the bot handles.
+type Update struct {
+	// UpdateID is the unique identifier of the update.
+	// json:"update_id" specifies that this field should be marshalled to/from a JSON object with the key "update_id".
+	UpdateID int64 `json:"update_id"`
+	// Message is the new incoming message, if any.
+	// json:"message" specifies that this field should be marshalled to/from a JSON object with the key "message".
+	Message *Message `json:"message"`
+}
+
+// Message represents a Telegram message.
+type Message struct {
+	// MessageID is the unique identifier of the message inside the chat.
+	// json:"message_id" specifies that this field should be marshalled to/from a JSON object with the key "message_id".
+	MessageID int64 `json:"message_id"`
+	// Chat is the chat the message belongs to.
+	// json:"chat" specifies that this field should be marshalled to/from a JSON object with the key "chat".
+	Chat Chat `json:"chat"`
+	// Text is the text of the message.
+	// json:"text" specifies that this field should be marshalled to/from a JSON object with the key "text".
+	Text string `json:"text"`
+}
+
+// Chat represents a Telegram chat.
+type Chat struct {
+	// ID is the unique identifier of the chat.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID int64 `json:"id"`
+}
@@ -0,0 +1,29 @@
+// This file defines the serializers for Telegram-related requests and responses.
+package telegram
+
+// LinkCodeResponse defines the structure for a link code response.
+type LinkCodeResponse struct {
+	// Code is the one-time code that links a chat to the user.
+	// json:"code" specifies that this field should be marshalled to/from a JSON object with the key "code".
+	Code string `json:"code"`
+	// Command is the message the user sends to the bot to link the chat.
+	// json:"command" specifies that this field should be marshalled to/from a JSON object with the key "command".
+	Command string `json:"command"`
+	// ExpiresAt is the time the code expires.
+	// json:"expires_at" specifies that this field should be marshalled to/from a JSON object with the key "expires_at".
+	ExpiresAt string `json:"expires_at"`
+}
+
+// sendMessageRequest defines the structure for a Telegram sendMessage call.
+// It is used both for calls to the Bot API and for replies in the webhook response.
+type sendMessageRequest struct {
+	// Method is the Bot API method to call when the request is returned as a webhook reply.
+	// json:"method,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "method", and omitted if empty.
+	Method string `json:"method,omitempty"`
+	// ChatID is the chat the message is sent to.
+	// json:"chat_id" specifies that this field should be marshalled to/from a JSON object with the key "chat_id".
+	ChatID int64 `json:"chat_id"`
+	// Text is the text of the message.
+	// json:"text" specifies that this field should be marshalled to/from a JSON object with the key "text".
+	Text string `json:"text"`
+}
@@ -0,0 +1,20 @@
+// This file defines the SQL queries used for Telegram-related database operations.
+package telegram
+
+// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names and schemas.
+import "github.com/rahulcodepython/todo-backend/backend/utils"
+
+// UpsertLinkCodeQuery is the SQL query to store a new link code for a user, replacing any previous code.
+const UpsertLinkCodeQuery = "INSERT INTO " + utils.TelegramLinkTableName + " (user_id, link_code, link_code_expires_at) VALUES ($1, $2, $3) ON CONFLICT (user_id) DO UPDATE SET link_code = EXCLUDED.link_code, link_code_expires_at = EXCLUDED.link_code_expires_at"
+
+// UnlinkChatQuery is the SQL query to detach a chat from whichever user it is currently linked to.
+const UnlinkChatQuery = "UPDATE " + utils.TelegramLinkTableName + " SET chat_id = NULL, linked_at = NULL WHERE chat_id = $1"
+
+// ConsumeLinkCodeQuery is the SQL query to link a chat using an unexpired link code and invalidate the code.
+const ConsumeLinkCodeQuery = "UPDATE " + utils.TelegramLinkTableName + " SET chat_id = $1, linked_at = NOW(), link_code = NULL, link_code_expires_at = NULL WHERE link_code = $2 AND link_code_expires_at > NOW() RETURNING user_id"
+
+// DeleteLinkQuery is the SQL query to remove a user's Telegram link.
+const DeleteLinkQuery = "DELETE FROM " + utils.TelegramLinkTableName + " WHERE user_id = $1"
+
+// GetLinkedUserQuery is the SQL query to retrieve the user linked to a chat.
+const GetLinkedUserQuery = "SELECT user_id FROM " + utils.TelegramLinkTableName + " WHERE chat_id = $1"
@@ -0,0 +1,108 @@
+// This file defines the worker that moves old completed todos to the archive, which keeps the todos table small.
+package todos
+
+// "context" provides a way to carry cancellation signals. It is used here to stop the worker on shutdown.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to move the todos.
+	"database/sql"
+	// "log" provides a simple logging package. It is used here to log the passes.
+	"log"
+	// "time" provides functions for working with time. It is used here to schedule the worker and for the type of the cutoff.
+	"time"
+
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time. It is used here to compute the cutoff.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+const (
+	// archiveInterval is how often the worker looks for todos to archive.
+	archiveInterval = time.Hour
+	// archiveBatchSize is the number of todos moved by one statement, so that no statement holds many rows locked.
+	archiveBatchSize = 500
+)
+
+// StartArchiveWorker moves the todos that were completed and left unchanged for longer than the configured age to the archive,
+// once an hour until the context is cancelled.
+//
+// @param ctx context.Context - The context that stops the worker.
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @param clk clock.Clock - The clock the cutoff is computed from.
+func StartArchiveWorker(ctx context.Context, cfg *config.Config, db *sql.DB, clk clock.Clock) {
+	// ticker fires once every archive interval.
+	ticker := time.NewTicker(archiveInterval)
+	// This defers stopping the ticker until the worker returns.
+	defer ticker.Stop()
+
+	// This loops until the context is cancelled.
+	for {
+		// This waits for either the next tick or the cancellation.
+		select {
+		case <-ctx.Done():
+			// If the context is cancelled, the worker returns.
+			return
+		case <-ticker.C:
+			// On every tick, the old completed todos are archived.
+			archivePass(ctx, cfg, db, clk)
+		}
+	}
+}
+
+// archivePass archives the todos completed and unchanged for longer than the configured age, and logs the outcome.
+//
+// @param ctx context.Context - The context of the pass.
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @param clk clock.Clock - The clock the cutoff is computed from.
+func archivePass(ctx context.Context, cfg *config.Config, db *sql.DB, clk clock.Clock) {
+	// archived is the number of todos moved by the pass.
+	archived, err := ArchiveCompletedTodos(ctx, db, clk.Now().Add(-cfg.Archive.After))
+	// This checks if an error occurred while archiving.
+	if err != nil {
+		// If an error occurs, it is logged. The todos left are moved by the next pass.
+		log.Printf("Unable to archive completed todos: %v", err)
+	}
+	// This checks if any todo was archived.
+	if archived > 0 {
+		// If any was, the pass is logged.
+		log.Printf("Archived %d completed todos", archived)
+	}
+}
+
+// ArchiveCompletedTodos moves the todos completed and unchanged since before a cutoff to the archive, in batches until none is left.
+// Each todo leaves a tombstone, so that the next sync of its owner reports it as deleted.
+//
+// @param ctx context.Context - The context of the pass.
+// @param db *sql.DB - The database connection.
+// @param before time.Time - The cutoff.
+// @return int64 - The number of todos archived, including those of the batches before an error.
+// @return error - An error if one occurred.
+func ArchiveCompletedTodos(ctx context.Context, db *sql.DB, before time.Time) (int64, error) {
+	// archived is the number of todos moved in this pass.
+	var archived int64
+	// This moves batches until a batch is not full.
+	for ctx.Err() == nil {
+		// result is the result of moving a batch.
+		result, err := db.ExecContext(ctx, ArchiveTodosQuery, before, archiveBatchSize)
+		// This checks if an error occurred while moving the batch.
+		if err != nil {
+			// If an error occurs, the pass ends with it.
+			return archived, err
+		}
+		// moved is the number of todos of the batch.
+		moved, _ := result.RowsAffected()
+		// The batch is counted.
+		archived += moved
+		// This checks if the batch was not full.
+		if moved < archiveBatchSize {
+			// If it was not, no todo is left to archive.
+			break
+		}
+	}
+	// The number of todos archived is returned.
+	return archived, nil
+}
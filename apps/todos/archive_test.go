@@ -0,0 +1,52 @@
+// This file defines a test of the cutoff of the archive worker.
+package todos
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to run the pass.
+import (
+	"context"
+	// "database/sql/driver" defines the interfaces of SQL drivers. It is used here to read the arguments of the fake database.
+	"database/sql/driver"
+	// "testing" provides support for automated tests. It is used here to run the test.
+	"testing"
+	// "time" provides functionality for measuring and displaying time. It is used here for the fixed time of the clock.
+	"time"
+
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time. It is used here to fix it.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/database/dbtest" is a local package that provides a fake database.
+	"github.com/rahulcodepython/todo-backend/backend/database/dbtest"
+)
+
+// TestArchivePassCutoff checks that the archive worker moves the todos completed before the configured age, counted back from
+// the time of its clock.
+//
+// @param t *testing.T - The test state.
+func TestArchivePassCutoff(t *testing.T) {
+	// now is the time of the clock.
+	now := time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC)
+	// after is the age after which completed todos are archived.
+	after := 30 * 24 * time.Hour
+
+	// cutoffs are the cutoffs the archive statement was run with.
+	var cutoffs []time.Time
+	// fake is the fake database, which has nothing to archive.
+	fake := dbtest.NewDriver()
+	fake.Handle(ArchiveTodosQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		cutoffs = append(cutoffs, args[0].Value.(time.Time))
+		return dbtest.Rows{RowsAffected: 0}, nil
+	})
+	// db is the fake database.
+	db := dbtest.OpenDB(fake)
+	t.Cleanup(func() { db.Close() })
+
+	// The pass is run with the fixed clock.
+	archivePass(context.Background(), &config.Config{Archive: config.ArchiveConfig{After: after}}, db, clock.Fixed(now))
+
+	// This checks if the statement was not run once, with the age counted back from the clock.
+	if len(cutoffs) != 1 || !cutoffs[0].Equal(now.Add(-after)) {
+		// If it was not, the test fails.
+		t.Fatalf("cutoffs = %v, want [%s]", cutoffs, now.Add(-after))
+	}
+}
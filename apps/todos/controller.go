@@ -1,72 +1,196 @@
 // This file defines the controllers for todo-related operations.
 package todos
 
-// "database/sql" provides a generic SQL interface. It is used here to interact with the database.
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to run the query of a stream.
 import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to scan the rows of a stream.
 	"database/sql"
+	// "errors" provides functions for working with errors. It is used here to compare the service errors.
+	"errors"
 	// "math" provides basic mathematical functions. It is used here to calculate the total number of pages.
 	"math"
+	// "strconv" provides functions for converting strings. It is used here to send the counts in headers.
+	"strconv"
+	// "time" provides functions for working with time. It is used here to parse the duration of a snooze and the first day of a plan.
+	"time"
 
 	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to define the controllers.
 	"github.com/gofiber/fiber/v2"
-	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to generate and parse UUIDs.
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to parse UUIDs.
 	"github.com/google/uuid"
+	// "github.com/lib/pq" is the PostgreSQL driver. It is used here to scan the tags.
+	"github.com/lib/pq"
+	// "github.com/rahulcodepython/todo-backend/apps/lists" is a local package that contains list errors.
+	"github.com/rahulcodepython/todo-backend/apps/lists"
 	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models.
 	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/binding" is a local package that binds request parameters. It is used here to bind the list filters and the create options.
+	"github.com/rahulcodepython/todo-backend/backend/binding"
 	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
 	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/i18n" is a local package that translates messages.
+	"github.com/rahulcodepython/todo-backend/backend/i18n"
+	// "github.com/rahulcodepython/todo-backend/backend/quota" is a local package that enforces usage limits.
+	"github.com/rahulcodepython/todo-backend/backend/quota"
 	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
 	"github.com/rahulcodepython/todo-backend/backend/response"
 	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions.
 	"github.com/rahulcodepython/todo-backend/backend/utils"
 )
 
-// TodoController is a struct that holds the configuration and database connection.
+const (
+	// HeaderIdempotencyKey is the header a client sets to make creating a todo safe to retry.
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// maxIdempotencyKeyLength is the longest Idempotency-Key that is accepted.
+	maxIdempotencyKeyLength = 255
+)
+
+// TodoController is a struct that holds the configuration and the todo service.
+// It only translates between HTTP and the service, which holds the business logic.
 type TodoController struct {
 	// cfg is the application configuration.
 	cfg *config.Config
-	// db is the database connection.
-	db *sql.DB
+	// service is the todo service.
+	service *TodoService
 }
 
 // NewTodoControl creates a new TodoController.
-// It takes the application configuration and database connection as input.
+// It takes the application configuration and the todo service as input.
 //
 // @param cfg *config.Config - The application configuration.
-// @param db *sql.DB - The database connection.
+// @param service *TodoService - The todo service.
 // @return *TodoController - A pointer to the new TodoController.
-func NewTodoControl(cfg *config.Config, db *sql.DB) *TodoController {
+func NewTodoControl(cfg *config.Config, service *TodoService) *TodoController {
 	// A new TodoController is returned.
 	return &TodoController{
 		// The cfg field is set to the application configuration.
 		cfg: cfg,
-		// The db field is set to the database connection.
-		db: db,
+		// The service field is set to the todo service.
+		service: service,
 	}
 }
 
-// MatchCurrentUserWithTodoOwner checks if the current user is the owner of the todo.
-// It takes a TodoController, a todo ID, and a current user ID as input.
+// todoErrorResponse sends the response for an error of the todo service.
+// An invalid field, such as a title that is too long, gets 400, a reached plan limit gets 403, a missing todo or list gets 404,
+// a todo of another user gets 403, a stale version, a reused Idempotency-Key, or a duplicate title gets 409, and any other error gets 500.
 //
-// @param tc *TodoController - The TodoController.
-// @param todoId uuid.UUID - The ID of the todo.
-// @param currentUserId uuid.UUID - The ID of the current user.
-// @return bool - True if the current user is the owner of the todo, false otherwise.
-// @return error - An error if one occurred.
-func MatchCurrentUserWithTodoOwner(tc *TodoController, todoId uuid.UUID, currentUserId uuid.UUID) (bool, error) {
-	// userId is a variable that will hold the ID of the todo's owner.
-	var userId uuid.UUID
-
-	// err is the result of querying the database for the todo's owner.
-	err := tc.db.QueryRow(GetTodoUserQuery, todoId).Scan(&userId)
-	// This checks if an error occurred while querying the database.
-	if err != nil {
-		// If an error occurs, false and the error are returned.
-		return false, err
+// @param c *fiber.Ctx - The Fiber context.
+// @param err error - The error that occurred.
+// @param forbiddenMessage string - The message for a todo of another user.
+// @param errorMessage string - The message for any other error.
+// @return error - An error if one occurred while sending the response.
+func todoErrorResponse(c *fiber.Ctx, err error, forbiddenMessage string, errorMessage string) error {
+	// exceeded is the limit that was reached, if any.
+	var exceeded *quota.ExceededError
+	// duplicate is the open todo with the same title, if any.
+	var duplicate *DuplicateTitleError
+	// fieldErrs is the list of fields that are too long or not allowed, if any.
+	var fieldErrs binding.FieldErrors
+	// This checks what kind of error occurred.
+	switch {
+	// Nothing was given or left for the title.
+	case errors.Is(err, ErrEmptyTitle):
+		// A bad request response is returned.
+		return response.BadResponse(c, "Title is required")
+	// The priority is not one of the allowed values.
+	case errors.Is(err, ErrInvalidPriority):
+		// A bad request response is returned.
+		return response.BadResponse(c, "Priority must be one of none, low, medium, or high")
+	// The title or description is too long or contains blocked words.
+	case errors.As(err, &fieldErrs):
+		// A bad request response is returned with the invalid fields.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	// The plan limit was reached.
+	case errors.As(err, &exceeded):
+		// A quota exceeded response is returned.
+		return response.QuotaExceeded(c, exceeded)
+	// The todo was changed since the version the update is based on.
+	case errors.Is(err, ErrTodoVersionMismatch):
+		// A conflict response is returned.
+		return response.Conflict(c, err, "Todo was changed by another request")
+	// The user already created a todo with the same Idempotency-Key.
+	case errors.Is(err, ErrIdempotencyKeyUsed):
+		// A conflict response is returned.
+		return response.Conflict(c, err, "A todo was already created with this Idempotency-Key")
+	// The user has an open todo with the same title.
+	case errors.As(err, &duplicate):
+		// A conflict response is returned with the existing todo.
+		return response.Duplicate(c, "An open todo with the same title already exists", NewTodoResponse(duplicate.Existing))
+	// The todo does not exist.
+	case errors.Is(err, ErrTodoNotFound):
+		// A not found response is returned.
+		return response.NotFound(c, nil, "Todo not found")
+	// The todo belongs to another user.
+	case errors.Is(err, ErrTodoForbidden):
+		// A forbidden response is returned.
+		return response.Forbidden(c, forbiddenMessage)
+	// The status is not a column of the board.
+	case errors.Is(err, ErrInvalidStatus):
+		// A bad request response is returned.
+		return response.BadResponse(c, "Status must be one of backlog, in_progress, blocked, or done")
+	// The todo was linked as its own blocker.
+	case errors.Is(err, ErrSelfBlocker):
+		// A bad request response is returned.
+		return response.BadResponse(c, "A todo cannot block itself")
+	// The blocker cannot be used, or is not linked to the todo.
+	case errors.Is(err, ErrBlockerNotFound):
+		// A not found response is returned.
+		return response.NotFound(c, nil, "Blocker not found")
+	// The blocker already waits on the todo.
+	case errors.Is(err, ErrDependencyCycle):
+		// A conflict response is returned.
+		return response.Conflict(c, err, "The blocker already waits on this todo")
+	// The estimate is out of range.
+	case errors.Is(err, ErrInvalidEstimate):
+		// A bad request response is returned.
+		return response.BadResponse(c, "Estimate must be between 1 and 10080 minutes")
+	// The snooze gives neither or both of its time and duration.
+	case errors.Is(err, ErrSnoozeTimeRequired):
+		// A bad request response is returned.
+		return response.BadResponse(c, "Either until or duration is required")
+	// The snooze would already be over.
+	case errors.Is(err, ErrSnoozeNotInFuture):
+		// A bad request response is returned.
+		return response.BadResponse(c, "Snooze must end in the future")
+	// The target list does not exist.
+	case errors.Is(err, lists.ErrListNotFound):
+		// A not found response is returned.
+		return response.NotFound(c, nil, "List not found")
 	}
+	// For any other error, an internal server error response is returned.
+	return response.InternelServerError(c, err, errorMessage)
+}
 
-	// The function returns true if the todo's owner ID matches the current user's ID.
-	return userId == currentUserId, nil
+// parseTodoId reads the todo ID from the "id" path parameter.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return uuid.UUID - The todo ID.
+// @return error - An error if the ID is missing or is not a valid UUID.
+func parseTodoId(c *fiber.Ctx) (uuid.UUID, error) {
+	// The path parameter is parsed as a UUID.
+	return uuid.Parse(c.Params("id"))
+}
+
+// todoScanner is implemented by *sql.Row and *sql.Rows.
+type todoScanner interface {
+	// Scan copies the columns of the current row into the values pointed at by dest.
+	Scan(dest ...any) error
+}
+
+// ScanTodo scans a row selected with utils.TodoSelectSchema into a Todo struct.
+// It takes a row as input.
+//
+// @param row todoScanner - The row to be scanned.
+// @return Todo - The scanned todo.
+// @return error - An error if one occurred.
+func ScanTodo(row todoScanner) (Todo, error) {
+	// todo is a new Todo struct.
+	var todo Todo
+	// err is the result of scanning the row into the todo struct.
+	err := row.Scan(&todo.ID, &todo.Title, &todo.Description, &todo.Priority, &todo.Completed, &todo.Owner, &todo.CreatedAt, &todo.ListID, &todo.Position, &todo.DueAt, pq.Array(&todo.Tags), &todo.Version, &todo.ICalUID, &todo.UpdatedAt, &todo.SnoozedUntil, &todo.EstimateMinutes, &todo.Status, &todo.Blocked)
+	// The scanned todo and the error, if any, are returned.
+	return todo, err
 }
 
 // CreateTodoController handles the creation of a new todo.
@@ -75,8 +199,13 @@ func MatchCurrentUserWithTodoOwner(tc *TodoController, todoId uuid.UUID, current
 // @param c *fiber.Ctx - The Fiber context.
 // @return error - An error if one occurred.
 func (tc *TodoController) CreateTodoController(c *fiber.Ctx) error {
-	// user is the User object retrieved from the local context.
-	user := c.Locals("user").(users.User)
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
 
 	// body is a new Create_UpdateTodoRequest struct.
 	body := new(Create_UpdateTodoRequest)
@@ -86,51 +215,70 @@ func (tc *TodoController) CreateTodoController(c *fiber.Ctx) error {
 		return response.BadInternalResponse(c, err, "Invalid request body")
 	}
 
-	// This checks if the title is empty.
-	if body.Title == "" {
-		// If the title is empty, a bad request response is returned.
-		return response.BadResponse(c, "Title is required")
+	// idempotencyKey is the value of the Idempotency-Key header, or an empty string if the client sent none.
+	idempotencyKey := c.Get(HeaderIdempotencyKey)
+	// This checks if the key is too long to be stored.
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		// If it is, a bad request response is returned.
+		return response.BadResponse(c, "Idempotency-Key must be at most 255 characters")
+	}
+	// params is the bound query parameters, where force skips the duplicate title check.
+	params, err := binding.Query[CreateTodoParams](c)
+	// This checks if any query parameter is invalid.
+	if err != nil {
+		// If one is, a bad request response is returned with the invalid parameters.
+		return response.InvalidParameters(c, err)
 	}
 
-	// todoId is the new UUID for the todo.
-	todoId, _ := uuid.NewV7()
+	// todo is the result of creating the todo.
+	todo, err := tc.service.Create(c.UserContext(), user, TodoInput(*body), idempotencyKey, !params.Force)
+	// This checks if an error occurred while creating the todo.
+	if err != nil {
+		// If an error occurs, the matching error response is returned.
+		return todoErrorResponse(c, err, "", "Unable to create todo")
+	}
 
-	// todo is a new Todo struct.
-	todo := Todo{
-		// The ID field is set to the new UUID.
-		ID: todoId,
-		// The Title field is set to the todo's title.
-		Title: body.Title,
-		// The Completed field is set to false.
-		Completed: false,
-		// The Owner field is set to the current user's ID.
-		Owner: user.ID.String(),
-		// The CreatedAt field is set to the user's creation time.
-		CreatedAt: utils.ParseTime(user.CreatedAt),
-	}
-
-	// _, err is the result of executing the SQL query to create the new todo.
-	_, err := tc.db.Exec(CreateTodoQuery, todo.ID, todo.Title, todo.Completed, todo.Owner, todo.CreatedAt)
-	// This checks if an error occurred while executing the query.
+	// todoResponse is the response structure of the new todo.
+	todoResponse := NewTodoResponse(todo)
+	// A created response is returned with a success message, the todo data, and the todo's path in the Location header.
+	return response.OKCreatedResponse(c, "Todo created successfully", todoResponse, todoResponse.URL)
+}
+
+// QuickAddTodoController handles creating a todo from a single line of text.
+// The line is parsed for a due date, tags, and a priority in the user's time zone, and the rest becomes the title.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (tc *TodoController) QuickAddTodoController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
 	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// body is a new QuickAddTodoRequest struct.
+	body := new(QuickAddTodoRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
 		// If an error occurs, a bad request response is returned.
-		return response.BadInternalResponse(c, err, "Unable to create todo")
+		return response.BadInternalResponse(c, err, "Invalid request body")
 	}
 
-	// todoResponse is a new TodoResponse struct.
-	todoResponse := TodoResponse{
-		// The ID field is set to the todo's ID.
-		ID: todo.ID,
-		// The Title field is set to the todo's title.
-		Title: todo.Title,
-		// The Completed field is set to the todo's completion status.
-		Completed: todo.Completed,
-		// The CreatedAt field is set to the todo's creation time.
-		CreatedAt: todo.CreatedAt,
+	// todo is the result of parsing the line and creating the todo.
+	todo, err := tc.service.CreateFromText(c.UserContext(), user, body.Text)
+	// This checks if an error occurred while creating the todo.
+	if err != nil {
+		// If an error occurs, the matching error response is returned.
+		return todoErrorResponse(c, err, "", "Unable to create todo")
 	}
 
-	// A created response is returned with a success message and the todo data.
-	return response.OKCreatedResponse(c, "Todo created successfully", todoResponse)
+	// todoResponse is the response structure of the new todo.
+	todoResponse := NewTodoResponse(todo)
+	// A created response is returned with a success message, the todo data, and the todo's path in the Location header.
+	return response.OKCreatedResponse(c, "Todo created successfully", todoResponse, todoResponse.URL)
 }
 
 // GetTodosController handles the retrieval of todos.
@@ -139,120 +287,106 @@ func (tc *TodoController) CreateTodoController(c *fiber.Ctx) error {
 // @param c *fiber.Ctx - The Fiber context.
 // @return error - An error if one occurred.
 func (tc *TodoController) GetTodosController(c *fiber.Ctx) error {
-	// user is the User object retrieved from the local context.
-	user := c.Locals("user").(users.User)
-
-	// completedQuery is the value of the "completed" query parameter.
-	completedQuery := c.Query("completed")
-	// completed is the boolean value of the "completed" query parameter.
-	completed := c.QueryBool("completed")
-
-	// page is the value of the "page" query parameter, with a default of 1.
-	page := c.QueryInt("page", 1)
-	// This ensures that the page number is at least 1.
-	if page <= 0 {
-		// If the page number is less than or equal to 0, it is set to 1.
-		page = 1
-	}
-
-	// limit is the value of the "limit" query parameter, with a default of 10.
-	limit := c.QueryInt("limit", 10)
-	// This ensures that the limit is at least 1.
-	if limit <= 0 {
-		// If the limit is less than or equal to 0, it is set to 10.
-		limit = 10
-	// This ensures that the limit is at most 100.
-	} else if limit > 100 {
-		// If the limit is greater than 100, it is set to 100.
-		limit = 100
-	}
-
-	// totalItems is a variable that will hold the total number of todos.
-	var totalItems int64
-	// err is a variable that will hold any errors that occur.
-	var err error
-
-	// This checks if the "completed" query parameter is empty.
-	if completedQuery == "" {
-		// If it is empty, the total number of todos for the user is retrieved.
-		err = tc.db.QueryRow(CountTodosByUserQuery, user.ID).Scan(&totalItems)
-	} else {
-		// If it is not empty, the total number of todos for the user, filtered by completion status, is retrieved.
-		err = tc.db.QueryRow(CountTodosByUserFilteredByCompletedQuery, user.ID, completed).Scan(&totalItems)
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// query is the result of binding the query parameters.
+	query, err := binding.Query[ListTodosQuery](c)
+	// This checks if any query parameter is invalid.
+	if err != nil {
+		// If one is, a bad request response is returned with the invalid parameters.
+		return response.InvalidParameters(c, err)
+	}
+	// The view, if any, is turned into due date filters in the user's time zone.
+	query = tc.service.ApplyView(query, user)
+	// This checks if the request asks for every matching todo as newline-delimited JSON.
+	if c.Method() == fiber.MethodGet && response.WantsNDJSON(c) {
+		// If it does, the todos are streamed as they are read, without pages.
+		return response.NDJSON(c, "Unable to get todos", func(ctx context.Context) (*sql.Rows, error) {
+			// The query for every matching todo is run.
+			return tc.service.QueryAll(ctx, user.ID, query)
+		}, func(rows *sql.Rows) (any, error) {
+			// todo is the result of scanning the row.
+			todo, err := ScanTodo(rows)
+			// The todo is returned in its response structure.
+			return NewTodoResponse(todo), err
+		})
 	}
+	// The page size is resolved against the configured page sizes.
+	query.Limit, err = binding.PageLimit(query.Limit, tc.cfg.Pagination)
+	// This checks if the requested page size is too large.
+	if err != nil {
+		// If it is, a bad request response is returned with the invalid parameter.
+		return response.InvalidParameters(c, err)
+	}
+	// This checks if the requested page is too far away for its offset to be computed.
+	if _, err := binding.PageOffset(query.Page, query.Limit); err != nil {
+		// If it is, a bad request response is returned with the invalid parameter.
+		return response.InvalidParameters(c, err)
+	}
+	// page is the requested page number.
+	page := query.Page
+	// limit is the requested number of todos per page.
+	limit := query.Limit
+
+	// totalItems is the number of the user's todos that match the filters.
+	totalItems, err := tc.service.Count(c.UserContext(), user.ID, query)
 	// This checks if an error occurred while querying the database.
 	if err != nil {
 		// If an error occurs, an internal server error response is returned.
 		return response.InternelServerError(c, err, "Failed to retrieve todo count")
 	}
 
+	// totalPages is the total number of pages.
+	totalPages := int(math.Ceil(float64(totalItems) / float64(limit)))
+
+	// The counts are sent in headers, so that a HEAD request can read them without the todos.
+	c.Set("X-Total-Count", strconv.FormatInt(totalItems, 10))
+	c.Set("X-Total-Pages", strconv.Itoa(totalPages))
+	// This checks if the request only asks for the headers.
+	if c.Method() == fiber.MethodHead {
+		// If it does, the todos are not read and an empty OK response is returned.
+		return c.SendStatus(fiber.StatusOK)
+	}
+
 	// This checks if there are no todos.
 	if totalItems == 0 {
-		// If there are no todos, an OK response is returned with an empty list of todos.
-		return response.OKResponse(c, "Todos fetched successfully", PaginatedTodoResponse{
-			Results: []TodoResponse{},
-			Count: 0,
+		// If there are no todos, an OK response is returned with an empty list of todos and no links.
+		return response.OKPaginatedResponse(c, "Todos fetched successfully", PaginatedTodoResponse{
+			Results:    []TodoResponse{},
+			Count:      0,
 			TotalItems: 0,
 			TotalPages: 0,
-			Page: page,
-			Limit: limit,
-		})
+			Page:       page,
+			Limit:      limit,
+		}, utils.Pagination{})
 	}
 
-	// todos is a slice that will hold the retrieved todos.
-	var todos []TodoResponse
-	// rows is a variable that will hold the result of the database query.
-	var rows *sql.Rows
-
-	// totalPages is the total number of pages.
-	totalPages := int(math.Ceil(float64(totalItems) / float64(limit)))
-
 	// This ensures that the page number is not greater than the total number of pages.
 	if page > totalPages {
 		// If the page number is greater than the total number of pages, it is set to the total number of pages.
 		page = totalPages
 	}
 
-	// offset is the number of todos to skip.
-	offset := (page - 1) * limit
-
-	// This checks if the "completed" query parameter is empty.
-	if completedQuery == "" {
-		// If it is empty, all todos for the user are retrieved.
-		rows, err = tc.db.Query(GetTodosByUserQuery, user.ID, limit, offset)
-	} else {
-		// If it is not empty, all todos for the user, filtered by completion status, are retrieved.
-		rows, err = tc.db.Query(GetTodosByUserFilteredByCompletedQuery, user.ID, completed, limit, offset)
-	}
-
-	// This checks if an error occurred while querying the database.
+	// pageTodos is the result of retrieving the page of the user's todos that match the filters.
+	pageTodos, err := tc.service.List(c.UserContext(), user.ID, query, page)
+	// This checks if an error occurred while retrieving the todos.
 	if err != nil {
 		// If an error occurs, an internal server error response is returned.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "message": "Failed to retrieve todos"})
-	}
-	// This defers the closing of the rows until the function returns.
-	defer rows.Close()
-
-	// This iterates over the rows.
-	for rows.Next() {
-		// todo is a new Todo struct.
-		var todo Todo
-
-		// err is the result of scanning the row into the todo struct.
-		err := rows.Scan(&todo.ID, &todo.Title, &todo.Completed, &todo.Owner, &todo.CreatedAt)
-		// This checks if an error occurred while scanning the row.
-		if err != nil {
-			// If an error occurs, an internal server error response is returned.
-			return response.InternelServerError(c, err, "Unable to get todos")
-		}
+		return response.InternelServerError(c, err, "Unable to get todos")
+	}
 
+	// todos is a slice that will hold the todos converted into their response structure.
+	todos := make([]TodoResponse, 0, len(pageTodos))
+	// This iterates over the todos of the page.
+	for _, todo := range pageTodos {
 		// The todo is appended to the todos slice.
-		todos = append(todos, TodoResponse{
-			ID: todo.ID,
-			Title: todo.Title,
-			Completed: todo.Completed,
-			CreatedAt: todo.CreatedAt,
-		})
+		todos = append(todos, NewTodoResponse(todo))
 	}
 
 	// paginatedTodoResponse is a new PaginatedTodoResponse struct.
@@ -271,33 +405,245 @@ func (tc *TodoController) GetTodosController(c *fiber.Ctx) error {
 		Limit: limit,
 	}
 
-	// An OK response is returned with a success message and the paginated todo data.
-	return response.OKResponse(c, "Todo fetched successfully", paginatedTodoResponse)
+	// pagination holds the links to the neighbouring pages.
+	pagination := utils.Pagination{}
+	// This checks if there is a next page.
+	if page < totalPages {
+		// If there is, it is linked.
+		pagination.Next = response.PageURL(c, page+1)
+	}
+	// This checks if there is a previous page.
+	if page > 1 {
+		// If there is, it is linked.
+		pagination.Prev = response.PageURL(c, page-1)
+	}
+
+	// An OK response is returned with a success message, the paginated todo data, and the links.
+	return response.OKPaginatedResponse(c, "Todo fetched successfully", paginatedTodoResponse, pagination)
 }
 
-// UpdateTodoController handles the update of a todo.
+// ArchivedTodosController handles the retrieval of a page of the todos that were moved to the archive, most recently completed first.
 // It takes a Fiber context as input.
 //
 // @param c *fiber.Ctx - The Fiber context.
 // @return error - An error if one occurred.
-func (tc *TodoController) UpdateTodoController(c *fiber.Ctx) error {
-	// user is the User object retrieved from the local context.
-	user := c.Locals("user").(users.User)
+func (tc *TodoController) ArchivedTodosController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// query is the result of binding the query parameters.
+	query, err := binding.Query[ArchivedTodosQuery](c)
+	// This checks if any query parameter is invalid.
+	if err != nil {
+		// If one is, a bad request response is returned with the invalid parameters.
+		return response.InvalidParameters(c, err)
+	}
+	// The page size is resolved against the configured page sizes.
+	query.Limit, err = binding.PageLimit(query.Limit, tc.cfg.Pagination)
+	// This checks if the requested page size is too large.
+	if err != nil {
+		// If it is, a bad request response is returned with the invalid parameter.
+		return response.InvalidParameters(c, err)
+	}
+	// This checks if the requested page is too far away for its offset to be computed.
+	if _, err := binding.PageOffset(query.Page, query.Limit); err != nil {
+		// If it is, a bad request response is returned with the invalid parameter.
+		return response.InvalidParameters(c, err)
+	}
+
+	// totalItems is the number of the user's archived todos.
+	totalItems, err := tc.service.CountArchived(c.UserContext(), user.ID, query.ListID)
+	// This checks if an error occurred while querying the database.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get archived todos")
+	}
+	// totalPages is the total number of pages.
+	totalPages := int(math.Ceil(float64(totalItems) / float64(query.Limit)))
+	// page is the requested page, kept within the pages there are.
+	page := max(min(query.Page, totalPages), 1)
+
+	// archived is the result of retrieving the page of archived todos.
+	archived, err := tc.service.ListArchived(c.UserContext(), user.ID, query.ListID, query.Limit, page)
+	// This checks if an error occurred while retrieving the todos.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get archived todos")
+	}
+
+	// todos is a slice that will hold the todos converted into their response structure.
+	todos := make([]ArchivedTodoResponse, 0, len(archived))
+	// This iterates over the todos of the page.
+	for _, todo := range archived {
+		// The todo is appended to the todos slice.
+		todos = append(todos, NewArchivedTodoResponse(todo))
+	}
+
+	// pagination holds the links to the neighbouring pages.
+	pagination := utils.Pagination{}
+	// This checks if there is a next page.
+	if page < totalPages {
+		// If there is, it is linked.
+		pagination.Next = response.PageURL(c, page+1)
+	}
+	// This checks if there is a previous page.
+	if page > 1 {
+		// If there is, it is linked.
+		pagination.Prev = response.PageURL(c, page-1)
+	}
+
+	// An OK response is returned with a success message, the page of archived todos, and the links.
+	return response.OKPaginatedResponse(c, "Archived todos fetched successfully", PaginatedArchivedTodoResponse{
+		// The Results field is set to the retrieved todos.
+		Results: todos,
+		// The Count field is set to the number of retrieved todos.
+		Count: len(todos),
+		// The TotalItems field is set to the total number of archived todos.
+		TotalItems: totalItems,
+		// The TotalPages field is set to the total number of pages.
+		TotalPages: totalPages,
+		// The Page field is set to the current page number.
+		Page: page,
+		// The Limit field is set to the number of todos per page.
+		Limit: query.Limit,
+	}, pagination)
+}
+
+// PlanController sums the estimates of the user's open todos per day and marks the days whose estimates exceed the capacity.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (tc *TodoController) PlanController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// query is the result of binding the query parameters.
+	query, err := binding.Query[PlanQuery](c)
+	// This checks if any query parameter is invalid.
+	if err != nil {
+		// If one is, a bad request response is returned with the invalid parameters.
+		return response.InvalidParameters(c, err)
+	}
+	// date is the first day of the plan, or nil for today.
+	var date *time.Time
+	// This checks if a date is given.
+	if query.Date != "" {
+		// If it is, it was already checked to be a calendar date.
+		parsed, _ := time.Parse(planDateLayout, query.Date)
+		date = &parsed
+	}
+	// capacity is the estimated effort of a day.
+	capacity := tc.cfg.Todo.DailyCapacityMinutes
+	// This checks if the request sets its own capacity.
+	if query.Capacity > 0 {
+		// If it does, it is used.
+		capacity = query.Capacity
+	}
+
+	// plan is the result of summing the estimates per day.
+	plan, err := tc.service.Plan(c.UserContext(), user, date, query.Days)
+	// This checks if an error occurred while reading the plan.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get plan")
+	}
+
+	// planResponse is the response structure of the plan.
+	planResponse := PlanResponse{CapacityMinutes: capacity, Days: make([]PlanDayResponse, 0, len(plan)), OverloadedDays: []string{}}
+	// This iterates over the days.
+	for _, day := range plan {
+		// dayResponse is the response structure of the day.
+		dayResponse := PlanDayResponse{
+			// The Date field is set to the calendar date of the day.
+			Date: day.Date.Format(planDateLayout),
+			// The TodoCount field is set to the number of todos due that day.
+			TodoCount: day.TodoCount,
+			// The EstimatedMinutes field is set to the sum of their estimates.
+			EstimatedMinutes: day.EstimatedMinutes,
+			// The UnestimatedCount field is set to the number of todos without an estimate.
+			UnestimatedCount: day.UnestimatedCount,
+			// The Overloaded field is set when the estimates exceed the capacity.
+			Overloaded: day.EstimatedMinutes > int64(capacity),
+		}
+		// This checks if the day is overloaded.
+		if dayResponse.Overloaded {
+			// If it is, its date is listed.
+			planResponse.OverloadedDays = append(planResponse.OverloadedDays, dayResponse.Date)
+		}
+		// The day is appended.
+		planResponse.Days = append(planResponse.Days, dayResponse)
+	}
+
+	// An OK response is returned with a success message and the plan.
+	return response.OKResponse(c, "Plan fetched successfully", planResponse)
+}
+
+// GetTodoController handles the retrieval of a single todo.
+// It is the resource the Location header of a created todo points at.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (tc *TodoController) GetTodoController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
 
 	// todoId is the value of the "id" path parameter.
-	todoId := c.Params("id")
-	// This checks if the todo ID is empty.
-	if todoId == "" {
-		// If the todo ID is empty, a bad request response is returned.
-		return response.BadResponse(c, "Todo id is required")
+	todoId, err := parseTodoId(c)
+	// This checks if the todo ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Invalid todo id")
 	}
 
-	// matchedCurrentUserWithTodoOwner is a boolean that indicates whether the current user is the owner of the todo.
-	matchedCurrentUserWithTodoOwner, err := MatchCurrentUserWithTodoOwner(tc, uuid.MustParse(todoId), user.ID)
-	// This checks if the current user is not the owner of the todo.
-	if !matchedCurrentUserWithTodoOwner {
-		// If the current user is not the owner of the todo, an unauthorized access response is returned.
-		return response.UnauthorizedAccess(c, err, "You are not authorized to update this todo")
+	// todo is the result of retrieving the todo.
+	todo, err := tc.service.Get(c.UserContext(), user.ID, todoId)
+	// This checks if an error occurred while retrieving the todo.
+	if err != nil {
+		// If an error occurs, the matching error response is returned.
+		return todoErrorResponse(c, err, "You are not authorized to view this todo", "Unable to get todo")
+	}
+
+	// An OK response is returned with a success message and the todo data.
+	return response.OKResponse(c, "Todo fetched successfully", NewTodoResponse(todo))
+}
+
+// UpdateTodoController handles the update of a todo.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (tc *TodoController) UpdateTodoController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// todoId is the value of the "id" path parameter.
+	todoId, err := parseTodoId(c)
+	// This checks if the todo ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Invalid todo id")
 	}
 
 	// body is a new Create_UpdateTodoRequest struct.
@@ -308,99 +654,201 @@ func (tc *TodoController) UpdateTodoController(c *fiber.Ctx) error {
 		return response.BadInternalResponse(c, err, "Invalid request body")
 	}
 
-	// This checks if the title is empty.
-	if body.Title == "" {
-		// If the title is empty, a bad request response is returned.
-		return response.BadResponse(c, "Title is required")
+	// todo is the result of updating the todo.
+	todo, err := tc.service.Update(c.UserContext(), user.ID, todoId, TodoInput(*body))
+	// This checks if an error occurred while updating the todo.
+	if err != nil {
+		// If an error occurs, the matching error response is returned.
+		return todoErrorResponse(c, err, "You are not authorized to update this todo", "Unable to update todo")
 	}
 
-	// todo is a new Todo struct.
-	var todo Todo
+	// An OK response is returned with a success message and the updated todo data.
+	return response.OKResponse(c, "Todo updated successfully", NewTodoResponse(todo))
+}
 
-	// err is the result of executing the SQL query to update the todo.
-	err = tc.db.QueryRow(UpdateTodoTitleQuery, body.Title, todoId).Scan(&todo.ID, &todo.Title, &todo.Completed, &todo.Owner, &todo.CreatedAt)
-	// This checks if an error occurred while executing the query.
+// DuplicateTodoController handles duplicating a todo.
+// The copy keeps the title, description, and priority of the original but starts out not completed.
+// It is placed in the original's list unless another list is given in the request body.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (tc *TodoController) DuplicateTodoController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
 	if err != nil {
-		// If an error occurs, an internal server error response is returned.
-		return response.InternelServerError(c, err, "Unable to update todo")
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
 	}
 
-	// todoResponse is a new TodoResponse struct.
-	todoResponse := TodoResponse{
-		// The ID field is set to the todo's ID.
-		ID: todo.ID,
-		// The Title field is set to the todo's title.
-		Title: todo.Title,
-		// The Completed field is set to the todo's completion status.
-		Completed: todo.Completed,
-		// The CreatedAt field is set to the todo's creation time.
-		CreatedAt: todo.CreatedAt,
+	// todoId is the value of the "id" path parameter.
+	todoId, err := parseTodoId(c)
+	// This checks if the todo ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Invalid todo id")
 	}
 
-	// An OK response is returned with a success message and the updated todo data.
-	return response.OKResponse(c, "Todo updated successfully", todoResponse)
+	// body is a new DuplicateTodoRequest struct.
+	body := new(DuplicateTodoRequest)
+	// This checks if a request body was sent, since the target list is optional.
+	if len(c.Body()) > 0 {
+		// This parses the request body into the body struct.
+		if err := c.BodyParser(body); err != nil {
+			// If an error occurs, a bad request response is returned.
+			return response.BadInternalResponse(c, err, "Invalid request body")
+		}
+	}
+
+	// todo is the result of copying the todo.
+	todo, err := tc.service.Duplicate(c.UserContext(), user.ID, todoId, body.ListID)
+	// This checks if an error occurred while copying the todo.
+	if err != nil {
+		// This checks if the target list belongs to another user.
+		if errors.Is(err, lists.ErrListForbidden) {
+			// If it does, a forbidden response is returned.
+			return response.Forbidden(c, "You are not authorized to add todos to this list")
+		}
+		// If another error occurs, the matching error response is returned.
+		return todoErrorResponse(c, err, "You are not authorized to duplicate this todo", "Unable to duplicate todo")
+	}
+
+	// todoResponse is the response structure of the copy.
+	todoResponse := NewTodoResponse(todo)
+	// A created response is returned with a success message, the new todo data, and the copy's path in the Location header.
+	return response.OKCreatedResponse(c, "Todo duplicated successfully", todoResponse, todoResponse.URL)
+}
+
+// MoveTodosController handles moving several todos into a list at once.
+// Ownership of every todo and of the target list is validated in a single query, and the move is atomic.
+// At most the batch cap of todos is moved, and the IDs past it are returned as skipped so that the client moves them in another request.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (tc *TodoController) MoveTodosController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// body is a new MoveTodosRequest struct.
+	body := new(MoveTodosRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+
+	// moved and skipped are the IDs that are moved in this request and the IDs past the batch cap.
+	moved, skipped := body.TodoIDs, []uuid.UUID{}
+	// This checks if more todos were given than the batch cap allows.
+	if limit := tc.cfg.Limits.MaxBatchItems; len(moved) > limit {
+		// If so, the todos past the cap are skipped.
+		moved, skipped = moved[:limit], moved[limit:]
+	}
+
+	// err is the result of validating and moving the todos.
+	err = tc.service.Move(c.UserContext(), user.ID, moved, body.ListID)
+	// This checks if an error occurred while moving the todos.
+	if err != nil {
+		// This checks what kind of error occurred.
+		switch {
+		// No todo IDs were given.
+		case errors.Is(err, ErrTodoIdsRequired):
+			// A bad request response is returned.
+			return response.BadResponse(c, "Todo ids are required")
+		// A todo ID was given more than once.
+		case errors.Is(err, ErrTodoIdsNotUnique):
+			// A bad request response is returned.
+			return response.BadResponse(c, "Todo ids must be unique")
+		// The request referenced foreign todos or a foreign list.
+		case errors.Is(err, ErrTodosNotMovable):
+			// A bad request response is returned.
+			return response.BadInternalResponse(c, err, "Unable to move todos")
+		}
+		// For any other error, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to move todos")
+	}
+
+	// data is the moved todos and the skipped ones.
+	data := fiber.Map{"list_id": body.ListID, "todo_ids": moved, "skipped_ids": skipped}
+	// This checks if any todo was skipped.
+	if len(skipped) > 0 {
+		// If so, an OK response is returned with a message that says how many were left for another request.
+		return response.OKResponse(c, i18n.Sprintf(c, "Moved %d todos, %d were skipped and must be moved in another request", len(moved), len(skipped)), data)
+	}
+	// An OK response is returned with a success message and the moved todos.
+	return response.OKResponse(c, "Todos moved successfully", data)
 }
 
 // DeleteTodoController handles the deletion of a todo.
+// The todo is soft deleted so that the deletion can be undone within the undo window.
 // It takes a Fiber context as input.
 //
 // @param c *fiber.Ctx - The Fiber context.
 // @return error - An error if one occurred.
 func (tc *TodoController) DeleteTodoController(c *fiber.Ctx) error {
-	// user is the User object retrieved from the local context.
-	user := c.Locals("user").(users.User)
-
-	// todoId is the value of the "id" path parameter.
-	todoId := c.Params("id")
-	// This checks if the todo ID is empty.
-	if todoId == "" {
-		// If the todo ID is empty, a bad request response is returned.
-		return response.BadResponse(c, "Todo id is required")
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
 	}
 
-	// matchedCurrentUserWithTodoOwner is a boolean that indicates whether the current user is the owner of the todo.
-	matchedCurrentUserWithTodoOwner, err := MatchCurrentUserWithTodoOwner(tc, uuid.MustParse(todoId), user.ID)
-	// This checks if the current user is not the owner of the todo.
-	if !matchedCurrentUserWithTodoOwner {
-		// If the current user is not the owner of the todo, an unauthorized access response is returned.
-		return response.UnauthorizedAccess(c, err, "You are not authorized to update this todo")
+	// todoId is the value of the "id" path parameter.
+	todoId, err := parseTodoId(c)
+	// This checks if the todo ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Invalid todo id")
 	}
 
-	// _, err is the result of executing the SQL query to delete the todo.
-	_, err = tc.db.Exec(DeleteTodoQuery, todoId)
-	// This checks if an error occurred while executing the query.
+	// activity is the result of deleting the todo and recording the activity.
+	activity, err := tc.service.Delete(c.UserContext(), user.ID, todoId)
+	// This checks if an error occurred while deleting the todo.
 	if err != nil {
-		// If an error occurs, an internal server error response is returned.
-		return response.InternelServerError(c, err, "Unable to delete todo")
+		// If an error occurs, the matching error response is returned.
+		return todoErrorResponse(c, err, "You are not authorized to delete this todo", "Unable to delete todo")
 	}
 
-	// An OK response is returned with a success message and the deleted todo's ID.
-	return response.OKResponse(c, "Todo deleted successfully", fiber.Map{"todo_id": todoId})
+	// An OK response is returned with a success message, the deleted todo's ID, and the undo token.
+	return response.OKResponse(c, "Todo deleted successfully", DeleteTodoResponse{
+		// The TodoID field is set to the deleted todo's ID.
+		TodoID: activity.TodoID,
+		// The UndoToken field is set to the activity's ID.
+		UndoToken: activity.ID,
+		// The UndoExpiresAt field is set to the end of the undo window.
+		UndoExpiresAt: utils.ParseTime(activity.CreatedAt.Add(tc.cfg.Todo.UndoWindow)),
+	})
 }
 
 // CompleteTodoController handles the completion of a todo.
+// The previous completion status is recorded so that the change can be undone within the undo window.
 // It takes a Fiber context as input.
 //
 // @param c *fiber.Ctx - The Fiber context.
 // @return error - An error if one occurred.
 func (tc *TodoController) CompleteTodoController(c *fiber.Ctx) error {
-	// user is the User object retrieved from the local context.
-	user := c.Locals("user").(users.User)
-
-	// todoId is the value of the "id" path parameter.
-	todoId := c.Params("id")
-	// This checks if the todo ID is empty.
-	if todoId == "" {
-		// If the todo ID is empty, a bad request response is returned.
-		return response.BadResponse(c, "Todo id is required")
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
 	}
 
-	// matchedCurrentUserWithTodoOwner is a boolean that indicates whether the current user is the owner of the todo.
-	matchedCurrentUserWithTodoOwner, err := MatchCurrentUserWithTodoOwner(tc, uuid.MustParse(todoId), user.ID)
-	// This checks if the current user is not the owner of the todo.
-	if !matchedCurrentUserWithTodoOwner {
-		// If the current user is not the owner of the todo, an unauthorized access response is returned.
-		return response.UnauthorizedAccess(c, err, "You are not authorized to update this todo")
+	// todoId is the value of the "id" path parameter.
+	todoId, err := parseTodoId(c)
+	// This checks if the todo ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Invalid todo id")
 	}
 
 	// body is a new CompleteTodoRequest struct.
@@ -411,29 +859,389 @@ func (tc *TodoController) CompleteTodoController(c *fiber.Ctx) error {
 		return response.BadInternalResponse(c, err, "Invalid request body")
 	}
 
-	// todo is a new Todo struct.
-	var todo Todo
+	// This checks if the completion status is missing.
+	if body.Completed == nil {
+		// If the completion status is missing, a bad request response is returned.
+		return response.BadResponse(c, "Completed is required")
+	}
 
-	// err is the result of executing the SQL query to update the todo's completion status.
-	err = tc.db.QueryRow(UpdateTodoCompletedQuery, body.Completed, todoId).Scan(&todo.ID, &todo.Title, &todo.Completed, &todo.Owner, &todo.CreatedAt)
-	// This checks if an error occurred while executing the query.
+	// todo and activity are the result of updating the todo and recording the activity.
+	todo, activity, err := tc.service.SetCompleted(c.UserContext(), user.ID, todoId, *body.Completed)
+	// This checks if an error occurred while updating the todo.
 	if err != nil {
-		// If an error occurs, an internal server error response is returned.
-		return response.InternelServerError(c, err, "Unable to update todo")
+		// If an error occurs, the matching error response is returned.
+		return todoErrorResponse(c, err, "You are not authorized to update this todo", "Unable to update todo")
+	}
+
+	// todoResponse is a new UndoableTodoResponse struct.
+	todoResponse := UndoableTodoResponse{
+		// The TodoResponse field is set to the updated todo.
+		TodoResponse: NewTodoResponse(todo),
+		// The UndoToken field is set to the activity's ID.
+		UndoToken: activity.ID,
+		// The UndoExpiresAt field is set to the end of the undo window.
+		UndoExpiresAt: utils.ParseTime(activity.CreatedAt.Add(tc.cfg.Todo.UndoWindow)),
+	}
+
+	// An OK response is returned with a success message and the updated todo data.
+	return response.OKResponse(c, "Todo updated successfully", todoResponse)
+}
+
+// SetTodoStatusController handles moving a todo to another column of the board.
+// The previous status is recorded so that the move can be undone within the undo window.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (tc *TodoController) SetTodoStatusController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// todoId is the value of the "id" path parameter.
+	todoId, err := parseTodoId(c)
+	// This checks if the todo ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Invalid todo id")
+	}
+
+	// body is a new SetTodoStatusRequest struct.
+	body := new(SetTodoStatusRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+
+	// todo and activity are the result of moving the todo and recording the activity.
+	todo, activity, err := tc.service.SetStatus(c.UserContext(), user.ID, todoId, body.Status)
+	// This checks if an error occurred while updating the todo.
+	if err != nil {
+		// If an error occurs, the matching error response is returned.
+		return todoErrorResponse(c, err, "You are not authorized to update this todo", "Unable to update todo")
 	}
 
-	// todoResponse is a new TodoResponse struct.
-	todoResponse := TodoResponse{
-		// The ID field is set to the todo's ID.
-		ID: todo.ID,
-		// The Title field is set to the todo's title.
-		Title: todo.Title,
-		// The Completed field is set to the todo's completion status.
-		Completed: todo.Completed,
-		// The CreatedAt field is set to the todo's creation time.
-		CreatedAt: todo.CreatedAt,
+	// todoResponse is a new UndoableTodoResponse struct.
+	todoResponse := UndoableTodoResponse{
+		// The TodoResponse field is set to the updated todo.
+		TodoResponse: NewTodoResponse(todo),
+		// The UndoToken field is set to the activity's ID.
+		UndoToken: activity.ID,
+		// The UndoExpiresAt field is set to the end of the undo window.
+		UndoExpiresAt: utils.ParseTime(activity.CreatedAt.Add(tc.cfg.Todo.UndoWindow)),
 	}
 
 	// An OK response is returned with a success message and the updated todo data.
 	return response.OKResponse(c, "Todo updated successfully", todoResponse)
-}
\ No newline at end of file
+}
+
+// GetBlockersController handles the retrieval of the blockers of a todo.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (tc *TodoController) GetBlockersController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// todoId is the value of the "id" path parameter.
+	todoId, err := parseTodoId(c)
+	// This checks if the todo ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Invalid todo id")
+	}
+
+	// blockers is the result of retrieving the blockers of the todo.
+	blockers, err := tc.service.Blockers(c.UserContext(), user.ID, todoId)
+	// This checks if an error occurred while retrieving the blockers.
+	if err != nil {
+		// If an error occurs, the matching error response is returned.
+		return todoErrorResponse(c, err, "You are not authorized to view this todo", "Unable to get blockers")
+	}
+
+	// blockerResponses is a slice that will hold the blockers in the response format.
+	blockerResponses := make([]TodoResponse, 0, len(blockers))
+	// This iterates over the blockers.
+	for _, blocker := range blockers {
+		// The blocker is converted and appended.
+		blockerResponses = append(blockerResponses, NewTodoResponse(blocker))
+	}
+
+	// An OK response is returned with a success message and the blockers.
+	return response.OKResponse(c, "Blockers fetched successfully", blockerResponses)
+}
+
+// AddBlockerController handles making a todo wait on another todo of the current user.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (tc *TodoController) AddBlockerController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// todoId is the value of the "id" path parameter.
+	todoId, err := parseTodoId(c)
+	// This checks if the todo ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Invalid todo id")
+	}
+
+	// body is a new AddBlockerRequest struct.
+	body := new(AddBlockerRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+	// blockerId is the ID of the blocker.
+	blockerId, err := uuid.Parse(body.BlockerID)
+	// This checks if the blocker ID is missing or not a valid UUID.
+	if err != nil {
+		// If it is, a bad request response is returned.
+		return response.BadResponse(c, "Invalid blocker id")
+	}
+
+	// todo is the result of making the link.
+	todo, err := tc.service.AddBlocker(c.UserContext(), user.ID, todoId, blockerId)
+	// This checks if an error occurred while making the link.
+	if err != nil {
+		// If an error occurs, the matching error response is returned.
+		return todoErrorResponse(c, err, "You are not authorized to update this todo", "Unable to add blocker")
+	}
+
+	// An OK response is returned with a success message and the todo, whose blocked flag reflects the new blocker.
+	return response.OKResponse(c, "Blocker added successfully", NewTodoResponse(todo))
+}
+
+// RemoveBlockerController handles removing a blocker from a todo.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (tc *TodoController) RemoveBlockerController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// todoId is the value of the "id" path parameter.
+	todoId, err := parseTodoId(c)
+	// This checks if the todo ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Invalid todo id")
+	}
+	// blockerId is the value of the "blockerId" path parameter.
+	blockerId, err := uuid.Parse(c.Params("blockerId"))
+	// This checks if the blocker ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Invalid blocker id")
+	}
+
+	// todo is the result of removing the link.
+	todo, err := tc.service.RemoveBlocker(c.UserContext(), user.ID, todoId, blockerId)
+	// This checks if an error occurred while removing the link.
+	if err != nil {
+		// If an error occurs, the matching error response is returned.
+		return todoErrorResponse(c, err, "You are not authorized to update this todo", "Unable to remove blocker")
+	}
+
+	// An OK response is returned with a success message and the todo.
+	return response.OKResponse(c, "Blocker removed successfully", NewTodoResponse(todo))
+}
+
+// BoardController handles the retrieval of the todos of the current user grouped by status, one column per status.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (tc *TodoController) BoardController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// query is the result of binding the query parameters.
+	query, err := binding.Query[BoardQuery](c)
+	// This checks if any query parameter is invalid.
+	if err != nil {
+		// If one is, a bad request response is returned with the invalid parameters.
+		return response.InvalidParameters(c, err)
+	}
+
+	// columns is the result of grouping the todos by status.
+	columns, err := tc.service.Board(c.UserContext(), user.ID, query.ListID, query.Limit)
+	// This checks if an error occurred while reading the board.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get board")
+	}
+
+	// board is the response structure of the columns.
+	board := make([]BoardColumnResponse, 0, len(columns))
+	// This iterates over the columns.
+	for _, column := range columns {
+		// todos is the response structure of the todos of the column.
+		todos := make([]TodoResponse, 0, len(column.Todos))
+		// This iterates over the todos of the column.
+		for _, todo := range column.Todos {
+			// The todo is appended.
+			todos = append(todos, NewTodoResponse(todo))
+		}
+		// The column is appended.
+		board = append(board, BoardColumnResponse{Status: column.Status, Count: column.Count, Todos: todos})
+	}
+
+	// An OK response is returned with a success message and the columns.
+	return response.OKResponse(c, "Board fetched successfully", board)
+}
+
+// SnoozeTodoController handles snoozing a todo until a time or for a duration.
+// The previous due date is recorded so that the snooze can be undone within the undo window.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (tc *TodoController) SnoozeTodoController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// todoId is the value of the "id" path parameter.
+	todoId, err := parseTodoId(c)
+	// This checks if the todo ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Invalid todo id")
+	}
+
+	// body is a new SnoozeTodoRequest struct.
+	body := new(SnoozeTodoRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+
+	// input is the end or the length of the snooze.
+	input := SnoozeInput{Until: body.Until}
+	// This checks if a duration is given.
+	if body.Duration != "" {
+		// duration is the parsed duration.
+		duration, err := time.ParseDuration(body.Duration)
+		// This checks if the duration is invalid or not positive.
+		if err != nil || duration <= 0 {
+			// If it is, a bad request response is returned.
+			return response.BadResponse(c, "Duration must be a positive duration such as 30m or 2h")
+		}
+		// The duration is set.
+		input.Duration = duration
+	}
+
+	// todo and activity are the result of snoozing the todo and recording the activity.
+	todo, activity, err := tc.service.Snooze(c.UserContext(), user.ID, todoId, input)
+	// This checks if an error occurred while snoozing the todo.
+	if err != nil {
+		// If an error occurs, the matching error response is returned.
+		return todoErrorResponse(c, err, "You are not authorized to snooze this todo", "Unable to snooze todo")
+	}
+
+	// An OK response is returned with a success message and the snoozed todo.
+	return response.OKResponse(c, "Todo snoozed successfully", UndoableTodoResponse{
+		// The TodoResponse field is set to the snoozed todo.
+		TodoResponse: NewTodoResponse(todo),
+		// The UndoToken field is set to the activity's ID.
+		UndoToken: activity.ID,
+		// The UndoExpiresAt field is set to the end of the undo window.
+		UndoExpiresAt: utils.ParseTime(activity.CreatedAt.Add(tc.cfg.Todo.UndoWindow)),
+	})
+}
+
+// UndoTodoController handles undoing a delete, complete, or snooze action.
+// The action can only be undone within the configured undo window and only once.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (tc *TodoController) UndoTodoController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := users.CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// body is a new UndoTodoRequest struct.
+	body := new(UndoTodoRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+
+	// activityId is the parsed undo token.
+	activityId, err := uuid.Parse(body.UndoToken)
+	// This checks if the undo token is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid undo token")
+	}
+
+	// todo is the result of reversing the action.
+	todo, err := tc.service.Undo(c.UserContext(), user.ID, activityId)
+	// This checks if an error occurred while reversing the action.
+	if err != nil {
+		// exceeded is the plan limit a restored todo would exceed.
+		var exceeded *quota.ExceededError
+		// This checks what kind of error occurred.
+		switch {
+		// The restored todo would not fit in the plan.
+		case errors.As(err, &exceeded):
+			// A quota exceeded response is returned.
+			return response.QuotaExceeded(c, exceeded)
+		// The token is unknown, belongs to someone else, or was already used.
+		case errors.Is(err, ErrNothingToUndo):
+			// A not found response is returned.
+			return response.NotFound(c, err, "Nothing to undo for this token")
+		// The undo window has passed or the action cannot be undone.
+		case errors.Is(err, ErrUndoWindowExpired), errors.Is(err, ErrActionNotUndoable):
+			// A bad request response is returned.
+			return response.BadInternalResponse(c, err, "Unable to undo this action")
+		}
+		// For any other error, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to undo action")
+	}
+
+	// An OK response is returned with a success message and the restored todo data.
+	return response.OKResponse(c, "Action undone successfully", NewTodoResponse(todo))
+}
@@ -0,0 +1,131 @@
+// This file defines a test of the number of queries the todo listing runs, so that a page of todos keeps costing the same
+// number of queries however many todos, tags, and blockers it holds.
+package todos
+
+// "database/sql/driver" defines the interfaces of SQL drivers. It is used here to build the rows of the fake database.
+import (
+	"database/sql/driver"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to read the listed todos.
+	"encoding/json"
+	// "fmt" provides functions for formatted I/O. It is used here to name the cases and the todos.
+	"fmt"
+	// "net/http/httptest" provides utilities for HTTP testing. It is used here to build the requests.
+	"net/http/httptest"
+	// "testing" provides support for automated tests. It is used here to run the test.
+	"testing"
+	// "time" provides functionality for measuring and displaying time. It is used here for the times of the todos.
+	"time"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to serve the requests.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here for the IDs of the user and the todos.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that manages users. It is used here to authenticate the requests.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that provides the current time. It is used here to fix it.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration. It is used here for the page sizes.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/database/dbtest" is a local package that provides a fake database. It is used here to answer and count the queries.
+	"github.com/rahulcodepython/todo-backend/backend/database/dbtest"
+	// "github.com/rahulcodepython/todo-backend/backend/idgen" is a local package that generates IDs. It is used here to build the service.
+	"github.com/rahulcodepython/todo-backend/backend/idgen"
+)
+
+// todoColumns are the columns ScanTodo reads, in its order.
+var todoColumns = []string{"id", "title", "description", "priority", "completed", "owner", "created_at", "list_id", "position", "due_at", "tags", "version", "ical_uid", "updated_at", "snoozed_until", "estimate_minutes", "status", "blocked"}
+
+// TestGetTodosQueryCount checks that GET /todos runs the same two queries, the count and the page, for a page of one todo
+// and for a full page of todos with tags and blockers, rather than one more query per todo for its tags or blockers.
+//
+// @param t *testing.T - The test state.
+func TestGetTodosQueryCount(t *testing.T) {
+	// owner is the authenticated user.
+	owner := users.User{ID: uuid.New(), Timezone: "UTC"}
+	// now is the time the todos were created.
+	now := time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC)
+	// total is the number of todos of the user.
+	const total = 20
+
+	// fake is the fake database, which holds the user's todos.
+	fake := dbtest.NewDriver()
+	// The count answers the number of todos.
+	fake.Handle(CountTodosByUserQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		return dbtest.Rows{Columns: []string{"count"}, Values: [][]driver.Value{{int64(total)}}}, nil
+	})
+	// The page answers as many todos as its limit asks for, each with tags, every other one blocked.
+	fake.Handle(GetTodosByUserQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		// limit is the page size, the eighth argument of the query.
+		limit := int(args[7].Value.(int64))
+		// rows is the page.
+		rows := dbtest.Rows{Columns: todoColumns}
+		// This builds the todos of the page.
+		for i := range min(limit, total) {
+			rows.Values = append(rows.Values, []driver.Value{
+				uuid.New().String(), fmt.Sprintf("Todo %d", i), "", PriorityNone, false, owner.ID.String(), now, nil, int64(i), nil,
+				[]byte(`{work,"two words"}`), int64(1), nil, now, nil, nil, "todo", i%2 == 1,
+			})
+		}
+		// The page is returned.
+		return rows, nil
+	})
+	// counting counts the queries that reach the fake database.
+	counting := dbtest.NewCountingDriver(fake)
+	// db is the database over the counting driver.
+	db := dbtest.OpenDB(counting)
+	t.Cleanup(func() { db.Close() })
+
+	// cfg is the configuration, of which only the page sizes are read.
+	cfg := &config.Config{Pagination: config.PaginationConfig{DefaultLimit: 20, MaxLimit: 100}}
+	// controller is the todo controller over the fake database.
+	controller := NewTodoControl(cfg, NewTodoService(cfg, db, clock.Fixed(now), &idgen.Sequence{}, nil))
+	// app serves the todo listing to the authenticated user.
+	app := fiber.New()
+	app.Get("/todos", func(c *fiber.Ctx) error {
+		// The user is authenticated.
+		users.SetCurrentUser(c, owner)
+		// The todos are listed.
+		return controller.GetTodosController(c)
+	})
+
+	// This lists a page of one todo and a full page of todos.
+	for _, limit := range []int{1, total} {
+		t.Run(fmt.Sprintf("limit %d", limit), func(t *testing.T) {
+			// The queries are counted from zero.
+			counting.Reset()
+			// resp is the response to the listing.
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, fmt.Sprintf("/todos?limit=%d", limit), nil))
+			// This checks if the request failed.
+			if err != nil {
+				// If it did, the test fails.
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			// This checks if the listing did not succeed.
+			if resp.StatusCode != fiber.StatusOK {
+				// If it did not, the test fails.
+				t.Fatalf("status = %d, want %d", resp.StatusCode, fiber.StatusOK)
+			}
+
+			// body is the listing.
+			var body struct {
+				Data PaginatedTodoResponse `json:"data"`
+			}
+			// This reads the listing.
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				// If it cannot be read, the test fails.
+				t.Fatal(err)
+			}
+			// This checks if the page does not hold the todos, their tags, and their blockers.
+			if got := body.Data.Results; len(got) != limit || len(got[0].Tags) != 2 || got[0].Tags[1] != "two words" || limit > 1 && !got[1].Blocked {
+				// If it does not, the test fails.
+				t.Fatalf("listed %+v, want %d todos with their tags and blockers", got, limit)
+			}
+			// This checks if the listing ran any query other than the count and the page.
+			if got := counting.Count(); got != 2 {
+				// If it did, the test fails.
+				t.Errorf("GET /todos?limit=%d ran %d queries, want 2", limit, got)
+			}
+		})
+	}
+}
@@ -1,8 +1,15 @@
 // This file defines the data model for todos.
 package todos
 
-// "github.com/google/uuid" is a package for working with UUIDs. It is used here to define the ID field.
-import "github.com/google/uuid"
+// "database/sql" provides a generic SQL interface. It is used here to define nullable fields.
+import (
+	"database/sql"
+	// "time" provides functions for working with time. It is used here to define the timestamp fields.
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to define the ID field.
+	"github.com/google/uuid"
+)
 
 // Todo represents the structure of a todo item in the application.
 type Todo struct {
@@ -12,6 +19,12 @@ type Todo struct {
 	// Title is the title of the todo.
 	// json:"title" specifies that this field should be marshalled to/from a JSON object with the key "title".
 	Title string `json:"title"`
+	// Description is the optional longer description of the todo.
+	// json:"description" specifies that this field should be marshalled to/from a JSON object with the key "description".
+	Description string `json:"description"`
+	// Priority is the priority of the todo. It is one of the Priority constants.
+	// json:"priority" specifies that this field should be marshalled to/from a JSON object with the key "priority".
+	Priority string `json:"priority"`
 	// Completed is the completion status of the todo.
 	// json:"completed" specifies that this field should be marshalled to/from a JSON object with the key "completed".
 	Completed bool `json:"completed"`
@@ -20,5 +33,143 @@ type Todo struct {
 	Owner string `json:"owner"`
 	// CreatedAt is the time the todo was created.
 	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
-	CreatedAt string `json:"created_at"`
-}
\ No newline at end of file
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the time the todo was last changed. The database sets it on every change that a user can see.
+	// json:"updated_at" specifies that this field should be marshalled to/from a JSON object with the key "updated_at".
+	UpdatedAt time.Time `json:"updated_at"`
+	// ListID is the ID of the list the todo belongs to, if any.
+	// json:"list_id" specifies that this field should be marshalled to/from a JSON object with the key "list_id".
+	ListID uuid.NullUUID `json:"list_id"`
+	// Position is the position of the todo within its list.
+	// json:"position" specifies that this field should be marshalled to/from a JSON object with the key "position".
+	Position int `json:"position"`
+	// DueAt is the time the todo is due, if any.
+	// json:"due_at" specifies that this field should be marshalled to/from a JSON object with the key "due_at".
+	DueAt sql.NullTime `json:"due_at"`
+	// Tags is the list of tags of the todo.
+	// json:"tags" specifies that this field should be marshalled to/from a JSON object with the key "tags".
+	Tags []string `json:"tags"`
+	// Version is the change sequence number of the todo. The database bumps it on every change, and it is used as the ETag and sync position.
+	// json:"version" specifies that this field should be marshalled to/from a JSON object with the key "version".
+	Version int64 `json:"version"`
+	// SnoozedUntil is the time the todo was last snoozed until, if it was. Its reminder is held back until then.
+	// json:"snoozed_until" specifies that this field should be marshalled to/from a JSON object with the key "snoozed_until".
+	SnoozedUntil sql.NullTime `json:"snoozed_until"`
+	// EstimateMinutes is the estimated effort of the todo in minutes, if any.
+	// json:"estimate_minutes" specifies that this field should be marshalled to/from a JSON object with the key "estimate_minutes".
+	EstimateMinutes sql.NullInt64 `json:"estimate_minutes"`
+	// Status is the board column of the todo. The database keeps it in step with Completed: a todo is done exactly when it is completed.
+	// json:"status" specifies that this field should be marshalled to/from a JSON object with the key "status".
+	Status string `json:"status"`
+	// Blocked is whether the todo has a blocker that is not completed yet. It is computed when the todo is read.
+	// json:"blocked" specifies that this field should be marshalled to/from a JSON object with the key "blocked".
+	Blocked bool `json:"blocked"`
+	// ICalUID is the resource name a CalDAV client gave the todo, if it was created over CalDAV.
+	// json:"-" specifies that this field should be omitted from JSON serialization.
+	ICalUID sql.NullString `json:"-"`
+}
+
+// const is a keyword that declares the allowed priorities of a todo.
+const (
+	// PriorityNone is the default priority of a todo.
+	PriorityNone = "none"
+	// PriorityLow is the low priority.
+	PriorityLow = "low"
+	// PriorityMedium is the medium priority.
+	PriorityMedium = "medium"
+	// PriorityHigh is the high priority.
+	PriorityHigh = "high"
+)
+
+// const is a keyword that declares the statuses of a todo, which are the columns of the board.
+const (
+	// StatusBacklog is the status of a todo that has not been started. It is the default, and the status of a reopened todo.
+	StatusBacklog = "backlog"
+	// StatusInProgress is the status of a todo that is being worked on.
+	StatusInProgress = "in_progress"
+	// StatusBlocked is the status of a todo that cannot move on for now.
+	StatusBlocked = "blocked"
+	// StatusDone is the status of a completed todo.
+	StatusDone = "done"
+)
+
+// Statuses lists the statuses of a todo in the order of the columns of the board.
+var Statuses = []string{StatusBacklog, StatusInProgress, StatusBlocked, StatusDone}
+
+// const is a keyword that declares the actions that can be recorded in the activity log.
+const (
+	// ActivityDeleted is recorded when a todo is deleted.
+	ActivityDeleted = "deleted"
+	// ActivityCompleted is recorded when a todo is marked as completed.
+	ActivityCompleted = "completed"
+	// ActivityReopened is recorded when a todo is marked as not completed.
+	ActivityReopened = "reopened"
+	// ActivitySnoozed is recorded when a todo is snoozed.
+	ActivitySnoozed = "snoozed"
+	// ActivityStatusChanged is recorded when a todo is moved to another column of the board.
+	ActivityStatusChanged = "status_changed"
+)
+
+// TodoActivity represents an entry in the activity log of a todo.
+type TodoActivity struct {
+	// ID is the unique identifier for the activity. It doubles as the undo token.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// TodoID is the ID of the todo the activity belongs to.
+	// json:"todo_id" specifies that this field should be marshalled to/from a JSON object with the key "todo_id".
+	TodoID uuid.UUID `json:"todo_id"`
+	// Action is the action that was performed on the todo.
+	// json:"action" specifies that this field should be marshalled to/from a JSON object with the key "action".
+	Action string `json:"action"`
+	// Previous holds the state of the todo before the action was performed.
+	// json:"previous" specifies that this field should be marshalled to/from a JSON object with the key "previous".
+	Previous ActivityPrevious `json:"previous"`
+	// CreatedAt is the time the activity was recorded.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ActivityPrevious holds the fields of a todo that an activity changed, as they were before the change.
+type ActivityPrevious struct {
+	// Completed is the previous completion status of the todo.
+	// json:"completed,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "completed", and should be omitted if empty.
+	Completed *bool `json:"completed,omitempty"`
+	// Status is the previous status of the todo, recorded by status changes and completion changes.
+	// json:"status,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "status", and should be omitted if empty.
+	Status string `json:"status,omitempty"`
+	// DueAt is the previous due date of a snoozed todo, or nil if it had none.
+	// json:"due_at,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "due_at", and should be omitted if empty.
+	DueAt *time.Time `json:"due_at,omitempty"`
+	// SnoozedUntil is the previous snooze time of a snoozed todo, or nil if it had none.
+	// json:"snoozed_until,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "snoozed_until", and should be omitted if empty.
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+}
+
+// ArchivedTodo represents a todo that was completed long ago and moved to the archive.
+// It keeps the fields a user reads back, and leaves out the ones that only matter to an open todo, such as its position or reminder.
+type ArchivedTodo struct {
+	// ID is the ID the todo had before it was archived.
+	ID uuid.UUID
+	// Title is the title of the todo.
+	Title string
+	// Description is the description of the todo.
+	Description string
+	// Priority is the priority of the todo.
+	Priority string
+	// Owner is the ID of the user who owns the todo.
+	Owner uuid.UUID
+	// ListID is the ID of the list the todo belonged to, if any.
+	ListID uuid.NullUUID
+	// DueAt is the time the todo was due, if any.
+	DueAt sql.NullTime
+	// Tags is the list of tags of the todo.
+	Tags []string
+	// EstimateMinutes is the estimated effort of the todo in minutes, if any.
+	EstimateMinutes sql.NullInt64
+	// CreatedAt is the time the todo was created.
+	CreatedAt time.Time
+	// CompletedAt is the time the todo was last changed, which was its completion or a later edit.
+	CompletedAt time.Time
+	// ArchivedAt is the time the todo was moved to the archive.
+	ArchivedAt time.Time
+}
@@ -0,0 +1,374 @@
+// This file provides the parser behind the quick-add endpoint.
+// It turns a single line such as "Pay rent tomorrow 5pm #finance !high" into the fields of a todo.
+package todos
+
+// "errors" provides functions for working with errors. It is used here to define the empty title error.
+import (
+	"errors"
+	// "regexp" provides regular expression search. It is used here to recognize times of day and ISO dates.
+	"regexp"
+	// "strconv" provides functions for converting strings to other types. It is used here to parse numbers in dates and times.
+	"strconv"
+	// "strings" provides functions for working with strings. It is used here to split and normalize the input.
+	"strings"
+	// "time" provides functions for working with time. It is used here to compute due dates.
+	"time"
+)
+
+// ErrEmptyTitle is returned when a todo has no title, including when nothing is left for the title once a quick-add line is parsed.
+var ErrEmptyTitle = errors.New("title is required")
+
+// defaultDueHour is the hour of the day used when a due date is given without a time.
+const defaultDueHour = 9
+
+// maxRelativeAmount is the largest amount accepted in relative dates such as "in 3 days".
+// Larger amounts are left in the title, since they would overflow durations or leave the range of dates the database stores.
+const maxRelativeAmount = 1000
+
+// timeOfDayPattern matches times such as "5pm", "5:30pm", and "17:00".
+var timeOfDayPattern = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+
+// isoDatePattern matches dates such as "2025-10-20".
+var isoDatePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// quickAddPriorities maps the priority markers accepted by the parser to priorities.
+var quickAddPriorities = map[string]string{
+	"!high":   PriorityHigh,
+	"!h":      PriorityHigh,
+	"!!!":     PriorityHigh,
+	"!medium": PriorityMedium,
+	"!med":    PriorityMedium,
+	"!m":      PriorityMedium,
+	"!!":      PriorityMedium,
+	"!low":    PriorityLow,
+	"!l":      PriorityLow,
+	"!none":   PriorityNone,
+}
+
+// quickAddWeekdays maps weekday names and their abbreviations to weekdays.
+var quickAddWeekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// QuickAddResult holds the fields parsed from a quick-add line.
+type QuickAddResult struct {
+	// Title is what remains of the line once dates, tags, and priorities are removed.
+	Title string
+	// DueAt is the parsed due date, or nil if the line did not contain one.
+	DueAt *time.Time
+	// Tags is the list of tags given with a leading "#".
+	Tags []string
+	// Priority is the priority given with a leading "!", or PriorityNone.
+	Priority string
+}
+
+// ParseQuickAdd parses a quick-add line.
+// Relative dates such as "today", "tomorrow", "friday", and "in 3 days" are resolved against now,
+// which should already be in the user's time zone.
+//
+// @param text string - The line to be parsed.
+// @param now time.Time - The current time in the user's time zone.
+// @return QuickAddResult - The parsed fields.
+func ParseQuickAdd(text string, now time.Time) QuickAddResult {
+	// result is a new QuickAddResult with the default priority.
+	result := QuickAddResult{Priority: PriorityNone, Tags: []string{}}
+
+	// tokens are the whitespace separated words of the line.
+	tokens := strings.Fields(text)
+	// titleWords collects the words that belong to the title.
+	titleWords := make([]string, 0, len(tokens))
+
+	// date is the parsed calendar date, if any.
+	var date *time.Time
+	// exact is a parsed point in time that already includes the time of day, such as "in 2 hours".
+	var exact *time.Time
+	// hour and minute hold the parsed time of day, if any.
+	hour, minute, hasTime := 0, 0, false
+
+	// today is the start of the current day in the user's time zone.
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	// This iterates over the tokens, consuming the ones that are recognized.
+	for i := 0; i < len(tokens); i++ {
+		// word is the token lowercased and stripped of trailing punctuation.
+		word := strings.TrimRight(strings.ToLower(tokens[i]), ",.;")
+		// next is the following word, or an empty string at the end of the line.
+		next := ""
+		// This checks if there is a following token.
+		if i+1 < len(tokens) {
+			// If there is, it is normalized the same way.
+			next = strings.TrimRight(strings.ToLower(tokens[i+1]), ",.;")
+		}
+
+		// This checks if the word is a tag.
+		if strings.HasPrefix(word, "#") && len(word) > 1 {
+			// If it is, the tag is recorded without the leading "#".
+			result.Tags = append(result.Tags, word[1:])
+			continue
+		}
+
+		// This checks if the word is a priority marker.
+		if priority, ok := quickAddPriorities[word]; ok {
+			// If it is, the priority is recorded.
+			result.Priority = priority
+			continue
+		}
+
+		// This checks if the word is a connecting word followed by a date or time, such as "at 5pm" or "on friday".
+		if (word == "at" || word == "on" || word == "by") && next != "" {
+			// This checks if the following word is a date or time.
+			if _, _, ok := parseTimeOfDay(next); ok || isDateWord(next, today) {
+				// If it is, the connecting word is dropped and the next word is handled on the next iteration.
+				continue
+			}
+		}
+
+		// This checks for the relative dates and times that start with "in", such as "in 3 days".
+		if word == "in" && i+2 < len(tokens) {
+			// amount is the number of units.
+			amount, err := strconv.Atoi(next)
+			// unit is the unit of the amount.
+			unit := strings.TrimRight(strings.ToLower(tokens[i+2]), ",.;")
+			// This checks if the amount is a positive number within range.
+			if err == nil && amount > 0 && amount <= maxRelativeAmount {
+				// consumed indicates whether the unit was recognized.
+				consumed := true
+				// This resolves the amount according to its unit.
+				switch strings.TrimSuffix(unit, "s") {
+				case "minute", "min":
+					t := now.Add(time.Duration(amount) * time.Minute)
+					exact = &t
+				case "hour", "hr":
+					t := now.Add(time.Duration(amount) * time.Hour)
+					exact = &t
+				case "day":
+					d := today.AddDate(0, 0, amount)
+					date = &d
+				case "week":
+					d := today.AddDate(0, 0, 7*amount)
+					date = &d
+				case "month":
+					d := today.AddDate(0, amount, 0)
+					date = &d
+				default:
+					consumed = false
+				}
+				// This checks if the relative date was recognized.
+				if consumed {
+					// If it was, all three tokens are consumed.
+					i += 2
+					continue
+				}
+			}
+		}
+
+		// This checks for "next week" and "next <weekday>".
+		if word == "next" && next != "" {
+			// This checks if the following word is "week".
+			if next == "week" {
+				// If it is, the date is set to one week from today.
+				d := today.AddDate(0, 0, 7)
+				date = &d
+				i++
+				continue
+			}
+			// This checks if the following word is a weekday.
+			if weekday, ok := quickAddWeekdays[next]; ok {
+				// If it is, the date is set to the next occurrence of that weekday.
+				d := nextWeekday(today, weekday)
+				date = &d
+				i++
+				continue
+			}
+		}
+
+		// This checks if the word is a single-word date.
+		if d, ok := parseDateWord(word, today); ok {
+			// If it is, the date is recorded.
+			date = &d
+			// "tonight" also implies a time of day unless one is given explicitly.
+			if word == "tonight" && !hasTime {
+				hour, minute, hasTime = 20, 0, true
+			}
+			continue
+		}
+
+		// This checks if the word is a time of day.
+		if h, m, ok := parseTimeOfDay(word); ok {
+			// If it is, the time of day is recorded.
+			hour, minute, hasTime = h, m, true
+			continue
+		}
+
+		// Any other word belongs to the title.
+		titleWords = append(titleWords, tokens[i])
+	}
+
+	// The title is the remaining words joined by single spaces.
+	result.Title = strings.Join(titleWords, " ")
+
+	// This resolves the due date from the recognized parts.
+	switch {
+	// An exact point in time wins over any other part.
+	case exact != nil:
+		result.DueAt = exact
+	// A date, optionally with a time of day.
+	case date != nil:
+		// This checks if no time of day was given.
+		if !hasTime {
+			// If none was, the default hour is used.
+			hour, minute = defaultDueHour, 0
+		}
+		due := time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, now.Location())
+		result.DueAt = &due
+	// A time of day without a date means the next occurrence of that time.
+	case hasTime:
+		due := time.Date(today.Year(), today.Month(), today.Day(), hour, minute, 0, 0, now.Location())
+		// This checks if the time has already passed today.
+		if !due.After(now) {
+			// If it has, the todo is due tomorrow at that time.
+			due = due.AddDate(0, 0, 1)
+		}
+		result.DueAt = &due
+	}
+
+	// The parsed result is returned.
+	return result
+}
+
+// isDateWord reports whether a word is a single-word date understood by parseDateWord.
+//
+// @param word string - The lowercased word.
+// @param today time.Time - The start of the current day in the user's time zone.
+// @return bool - True if the word is a date.
+func isDateWord(word string, today time.Time) bool {
+	// The word is parsed against the day of the line, so that the recognition does not depend on when it runs.
+	_, ok := parseDateWord(word, today)
+	// The result of the recognition is returned.
+	return ok
+}
+
+// parseDateWord parses a single-word date such as "today", "tomorrow", "friday", or "2025-10-20".
+//
+// @param word string - The lowercased word.
+// @param today time.Time - The start of the current day in the user's time zone.
+// @return time.Time - The start of the parsed day.
+// @return bool - True if the word was recognized.
+func parseDateWord(word string, today time.Time) (time.Time, bool) {
+	// This checks the word against the known relative dates.
+	switch word {
+	case "today", "tonight":
+		return today, true
+	case "tomorrow", "tmr", "tmrw":
+		return today.AddDate(0, 0, 1), true
+	}
+
+	// This checks if the word is a weekday.
+	if weekday, ok := quickAddWeekdays[word]; ok {
+		// If it is, the next occurrence of that weekday is returned.
+		return nextWeekday(today, weekday), true
+	}
+
+	// This checks if the word is an ISO date.
+	if isoDatePattern.MatchString(word) {
+		// d is the parsed date in the user's time zone.
+		d, err := time.ParseInLocation("2006-01-02", word, today.Location())
+		// This checks if the date is valid.
+		if err == nil {
+			// If it is, the date is returned.
+			return d, true
+		}
+	}
+
+	// The word is not a date.
+	return time.Time{}, false
+}
+
+// nextWeekday returns the next occurrence of a weekday strictly after today.
+//
+// @param today time.Time - The start of the current day.
+// @param weekday time.Weekday - The wanted weekday.
+// @return time.Time - The start of the next day that falls on the weekday.
+func nextWeekday(today time.Time, weekday time.Weekday) time.Time {
+	// days is the number of days until the weekday.
+	days := (int(weekday) - int(today.Weekday()) + 7) % 7
+	// This checks if the weekday is today.
+	if days == 0 {
+		// If it is, the same weekday next week is used.
+		days = 7
+	}
+	// The date of the next occurrence is returned.
+	return today.AddDate(0, 0, days)
+}
+
+// parseTimeOfDay parses a time of day such as "5pm", "5:30pm", "17:00", "noon", or "midnight".
+// A bare number such as "5" is not treated as a time so that titles like "Read 5 pages" survive.
+//
+// @param word string - The lowercased word.
+// @return int - The hour.
+// @return int - The minute.
+// @return bool - True if the word was recognized.
+func parseTimeOfDay(word string) (int, int, bool) {
+	// This checks the word against the named times.
+	switch word {
+	case "noon":
+		return 12, 0, true
+	case "midnight":
+		return 0, 0, true
+	}
+
+	// match holds the parts of the time, if the word is one.
+	match := timeOfDayPattern.FindStringSubmatch(word)
+	// This checks if the word is a time and has either minutes or an am/pm suffix.
+	if match == nil || (match[2] == "" && match[3] == "") {
+		// If it does not, the word is not a time.
+		return 0, 0, false
+	}
+
+	// hour is the parsed hour.
+	hour, _ := strconv.Atoi(match[1])
+	// minute is the parsed minute, or zero if it was omitted.
+	minute := 0
+	// This checks if minutes were given.
+	if match[2] != "" {
+		// If they were, they are parsed.
+		minute, _ = strconv.Atoi(match[2])
+	}
+
+	// This applies the am/pm suffix.
+	switch match[3] {
+	case "am":
+		// This checks if the hour is outside the 12-hour clock.
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		// 12am is midnight.
+		if hour == 12 {
+			hour = 0
+		}
+	case "pm":
+		// This checks if the hour is outside the 12-hour clock.
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		// Every pm hour except 12pm is shifted by twelve hours.
+		if hour != 12 {
+			hour += 12
+		}
+	}
+
+	// This checks if the time is out of range.
+	if hour > 23 || minute > 59 {
+		// If it is, the word is not a time.
+		return 0, 0, false
+	}
+
+	// The parsed time is returned.
+	return hour, minute, true
+}
@@ -0,0 +1,91 @@
+// This file defines a fuzz test for the parsing of quick-add lines.
+package todos
+
+// "strings" provides functions for working with strings. It is used here to check the parsed title and tags.
+import (
+	"strings"
+	// "testing" provides support for automated tests. It is used here to run the fuzz test.
+	"testing"
+	// "time" provides functions for working with time. It is used here to fix the time the lines are parsed at.
+	"time"
+	// "unicode/utf8" provides functions for UTF-8 text. It is used here to check that the title stays valid text.
+	"unicode/utf8"
+)
+
+// FuzzParseQuickAdd checks that no line makes ParseQuickAdd panic, and that its result is one that Create either accepts or
+// rejects with ErrEmptyTitle, which the endpoint answers with 400 rather than 500.
+//
+// @param f *testing.F - The fuzzing state.
+func FuzzParseQuickAdd(f *testing.F) {
+	// seeds are lines with every part the parser recognizes.
+	seeds := []string{
+		"",
+		"   ",
+		"Buy milk tomorrow at 5pm #errands !high",
+		"call mom in 3 days",
+		"in 1000 months",
+		"in 99999999999999999999 days",
+		"report next friday 9:30am !!",
+		"at", "on friday", "by 25:99", "in 2", "next",
+		"pay rent 2025-02-30 #home #",
+		"tonight #",
+		"!!! !! !low !none",
+		"\xff\xfe invalid utf-8",
+	}
+	// This adds the seeds to the corpus.
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	// location is a time zone with a daylight saving change, so that the dates cross one.
+	location, err := time.LoadLocation("America/New_York")
+	// This checks if the time zone is unavailable.
+	if err != nil {
+		// If it is, UTC is used instead.
+		location = time.UTC
+	}
+	// now is the time the lines are parsed at.
+	now := time.Date(2025, time.March, 8, 23, 30, 0, 0, location)
+
+	// This runs the test on every input.
+	f.Fuzz(func(t *testing.T, text string) {
+		// result is the parsed line.
+		result := ParseQuickAdd(text, now)
+
+		// This checks if the title has surrounding whitespace, which Create would not recognize as empty.
+		if result.Title != strings.TrimSpace(result.Title) {
+			// If it has, the test fails.
+			t.Fatalf("ParseQuickAdd(%q) returned the title %q with surrounding whitespace", text, result.Title)
+		}
+		// This checks if a valid line gave an invalid title.
+		if utf8.ValidString(text) && !utf8.ValidString(result.Title) {
+			// If it did, the test fails.
+			t.Fatalf("ParseQuickAdd(%q) returned the invalid title %q", text, result.Title)
+		}
+		// This checks if the priority is not one that Create accepts.
+		switch result.Priority {
+		case PriorityNone, PriorityLow, PriorityMedium, PriorityHigh:
+		default:
+			// If it is not, the test fails.
+			t.Fatalf("ParseQuickAdd(%q) returned the priority %q", text, result.Priority)
+		}
+		// This checks if the tags are nil, which would be sent as null.
+		if result.Tags == nil {
+			// If they are, the test fails.
+			t.Fatalf("ParseQuickAdd(%q) returned nil tags", text)
+		}
+		// This iterates over the tags.
+		for _, tag := range result.Tags {
+			// This checks if the tag is empty, as a lone "#" would make it.
+			if tag == "" {
+				// If it is, the test fails.
+				t.Fatalf("ParseQuickAdd(%q) returned the tag %q", text, tag)
+			}
+		}
+		// This checks if the due date is in another time zone than the user's.
+		if result.DueAt != nil && result.DueAt.Location() != now.Location() {
+			// If it is, the test fails.
+			t.Fatalf("ParseQuickAdd(%q) returned a due date in %s", text, result.DueAt.Location())
+		}
+	})
+}
@@ -1,8 +1,17 @@
 // This file defines the serializers for todo-related requests and responses.
 package todos
 
-// "github.com/google/uuid" is a package for working with UUIDs. It is used here to define the ID field in the response struct.
-import "github.com/google/uuid"
+// "time" provides functions for working with time. It is used here to define the due date and the snooze time of a request.
+import (
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to define the ID field in the response struct.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/backend/binding" is a local package that binds request parameters.
+	"github.com/rahulcodepython/todo-backend/backend/binding"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
 
 // Create_UpdateTodoRequest defines the structure for a create or update todo request.
 type Create_UpdateTodoRequest struct {
@@ -10,6 +19,40 @@ type Create_UpdateTodoRequest struct {
 	// json:"title" specifies that this field should be marshalled to/from a JSON object with the key "title".
 	// validate:"required,min=3,max=255" specifies that this field is required, has a minimum length of 3, and a maximum length of 255.
 	Title string `json:"title" validate:"required,min=3,max=255"`
+	// Description is the optional description of the todo.
+	// json:"description" specifies that this field should be marshalled to/from a JSON object with the key "description".
+	Description string `json:"description"`
+	// Priority is the optional priority of the todo.
+	// json:"priority" specifies that this field should be marshalled to/from a JSON object with the key "priority".
+	// validate:"omitempty,oneof=none low medium high" specifies that this field must be one of the allowed priorities if present.
+	Priority string `json:"priority" validate:"omitempty,oneof=none low medium high"`
+	// DueAt is the optional time the todo is due, in RFC 3339 format.
+	// json:"due_at" specifies that this field should be marshalled to/from a JSON object with the key "due_at".
+	DueAt *time.Time `json:"due_at"`
+	// Tags is the optional list of tags of the todo.
+	// json:"tags" specifies that this field should be marshalled to/from a JSON object with the key "tags".
+	Tags []string `json:"tags"`
+	// EstimateMinutes is the optional estimated effort of the todo in minutes.
+	// json:"estimate_minutes" specifies that this field should be marshalled to/from a JSON object with the key "estimate_minutes".
+	EstimateMinutes *int `json:"estimate_minutes"`
+	// Version is the optional version of the todo an update is based on. The update is refused with 409 if the todo has changed since.
+	// json:"version" specifies that this field should be marshalled to/from a JSON object with the key "version".
+	Version *int64 `json:"version"`
+}
+
+// CreateTodoParams defines the query parameters of a create todo request.
+type CreateTodoParams struct {
+	// Force creates the todo even if an open todo in the same list has the same title.
+	// query:"force" specifies that this field is bound to the "force" query parameter.
+	Force bool `query:"force"`
+}
+
+// QuickAddTodoRequest defines the structure for a quick-add todo request.
+type QuickAddTodoRequest struct {
+	// Text is the line to be parsed, such as "Pay rent tomorrow 5pm #finance !high".
+	// json:"text" specifies that this field should be marshalled to/from a JSON object with the key "text".
+	// validate:"required" specifies that this field is required.
+	Text string `json:"text" validate:"required"`
 }
 
 // CompleteTodoRequest defines the structure for a complete todo request.
@@ -28,12 +71,253 @@ type TodoResponse struct {
 	// Title is the title of the todo.
 	// json:"title" specifies that this field should be marshalled to/from a JSON object with the key "title".
 	Title string `json:"title"`
+	// Description is the description of the todo.
+	// json:"description" specifies that this field should be marshalled to/from a JSON object with the key "description".
+	Description string `json:"description"`
+	// Priority is the priority of the todo.
+	// json:"priority" specifies that this field should be marshalled to/from a JSON object with the key "priority".
+	Priority string `json:"priority"`
 	// Completed is the completion status of the todo.
 	// json:"completed" specifies that this field should be marshalled to/from a JSON object with the key "completed".
 	Completed bool `json:"completed"`
 	// CreatedAt is the time the todo was created.
 	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
 	CreatedAt string `json:"created_at"`
+	// UpdatedAt is the time the todo was last changed.
+	// json:"updated_at" specifies that this field should be marshalled to/from a JSON object with the key "updated_at".
+	UpdatedAt string `json:"updated_at"`
+	// ListID is the ID of the list the todo belongs to, if any.
+	// json:"list_id" specifies that this field should be marshalled to/from a JSON object with the key "list_id".
+	ListID *uuid.UUID `json:"list_id"`
+	// Position is the position of the todo within its list.
+	// json:"position" specifies that this field should be marshalled to/from a JSON object with the key "position".
+	Position int `json:"position"`
+	// DueAt is the time the todo is due, or null if it has no due date.
+	// json:"due_at" specifies that this field should be marshalled to/from a JSON object with the key "due_at".
+	DueAt *string `json:"due_at"`
+	// Tags is the list of tags of the todo.
+	// json:"tags" specifies that this field should be marshalled to/from a JSON object with the key "tags".
+	Tags []string `json:"tags"`
+	// EstimateMinutes is the estimated effort of the todo in minutes, or null if it has no estimate.
+	// json:"estimate_minutes" specifies that this field should be marshalled to/from a JSON object with the key "estimate_minutes".
+	EstimateMinutes *int64 `json:"estimate_minutes"`
+	// SnoozedUntil is the time the todo was last snoozed until, or null if it was never snoozed or its due date was changed since.
+	// json:"snoozed_until" specifies that this field should be marshalled to/from a JSON object with the key "snoozed_until".
+	SnoozedUntil *string `json:"snoozed_until"`
+	// Status is the board column of the todo: backlog, in_progress, blocked, or done.
+	// json:"status" specifies that this field should be marshalled to/from a JSON object with the key "status".
+	Status string `json:"status"`
+	// Blocked is true while the todo waits on a blocker that is not completed, so that clients can grey it out.
+	// It is unrelated to the blocked status, which the user sets by hand.
+	// json:"blocked" specifies that this field should be marshalled to/from a JSON object with the key "blocked".
+	Blocked bool `json:"blocked"`
+	// Version is the change sequence number of the todo.
+	// json:"version" specifies that this field should be marshalled to/from a JSON object with the key "version".
+	Version int64 `json:"version"`
+	// URL is the canonical path of the todo, the same as the Location header of its creation.
+	// json:"url" specifies that this field should be marshalled to/from a JSON object with the key "url".
+	URL string `json:"url"`
+}
+
+// NewTodoResponse converts a todo into its response structure.
+//
+// @param todo Todo - The todo to be converted.
+// @return TodoResponse - The todo response.
+func NewTodoResponse(todo Todo) TodoResponse {
+	// listId is the list ID, or nil if the todo is not in a list.
+	var listId *uuid.UUID
+	// This checks if the todo is in a list.
+	if todo.ListID.Valid {
+		// If it is, the list ID is set.
+		listId = &todo.ListID.UUID
+	}
+
+	// dueAt is the formatted due date, or nil if the todo has no due date.
+	var dueAt *string
+	// This checks if the todo has a due date.
+	if todo.DueAt.Valid {
+		// If it has, the due date is formatted.
+		formatted := utils.ParseTime(todo.DueAt.Time)
+		dueAt = &formatted
+	}
+
+	// estimateMinutes is the estimated effort, or nil if the todo has no estimate.
+	var estimateMinutes *int64
+	// This checks if the todo has an estimate.
+	if todo.EstimateMinutes.Valid {
+		// If it has, the estimate is set.
+		estimateMinutes = &todo.EstimateMinutes.Int64
+	}
+
+	// snoozedUntil is the formatted snooze time, or nil if the todo is not snoozed.
+	var snoozedUntil *string
+	// This checks if the todo was snoozed.
+	if todo.SnoozedUntil.Valid {
+		// If it was, the snooze time is formatted.
+		formatted := utils.ParseTime(todo.SnoozedUntil.Time)
+		snoozedUntil = &formatted
+	}
+
+	// tags is the list of tags, never nil so that it is serialized as an empty array.
+	tags := todo.Tags
+	// This checks if the todo has no tags.
+	if tags == nil {
+		// If it has none, an empty list is used.
+		tags = []string{}
+	}
+
+	// A new TodoResponse is returned.
+	return TodoResponse{
+		// The ID field is set to the todo's ID.
+		ID: todo.ID,
+		// The Title field is set to the todo's title.
+		Title: todo.Title,
+		// The Description field is set to the todo's description.
+		Description: todo.Description,
+		// The Priority field is set to the todo's priority.
+		Priority: todo.Priority,
+		// The Completed field is set to the todo's completion status.
+		Completed: todo.Completed,
+		// The CreatedAt field is set to the todo's formatted creation time.
+		CreatedAt: utils.ParseTime(todo.CreatedAt),
+		// The UpdatedAt field is set to the todo's formatted last change time.
+		UpdatedAt: utils.ParseTime(todo.UpdatedAt),
+		// The ListID field is set to the todo's list ID.
+		ListID: listId,
+		// The Position field is set to the todo's position.
+		Position: todo.Position,
+		// The DueAt field is set to the todo's due date.
+		DueAt: dueAt,
+		// The Tags field is set to the todo's tags.
+		Tags: tags,
+		// The EstimateMinutes field is set to the todo's estimated effort.
+		EstimateMinutes: estimateMinutes,
+		// The SnoozedUntil field is set to the todo's snooze time.
+		SnoozedUntil: snoozedUntil,
+		// The Status field is set to the todo's status.
+		Status: todo.Status,
+		// The Blocked field is set to whether the todo has an open blocker.
+		Blocked: todo.Blocked,
+		// The Version field is set to the todo's version.
+		Version: todo.Version,
+		// The URL field is set to the todo's canonical path.
+		URL: utils.ResourcePath("todos", todo.ID),
+	}
+}
+
+// ListTodosQuery defines the query parameters of a list todos request.
+type ListTodosQuery struct {
+	// Page is the page number, starting at 1.
+	// query:"page" specifies that this field is bound to the "page" query parameter.
+	Page int `query:"page" default:"1" min:"1"`
+	// Limit is the number of todos per page, or zero to use the configured default.
+	// query:"limit" specifies that this field is bound to the "limit" query parameter.
+	Limit int `query:"limit" min:"1"`
+	// Sort is the order of the todos; a leading "-" sorts in descending order. "id" sorts by creation time through the UUIDv7 IDs.
+	// query:"sort" specifies that this field is bound to the "sort" query parameter.
+	Sort string `query:"sort" default:"position" oneof:"position created_at -created_at updated_at -updated_at due_at -due_at title -title id -id"`
+	// Completed is the optional completion status filter.
+	// query:"completed" specifies that this field is bound to the "completed" query parameter.
+	Completed *bool `query:"completed"`
+	// ListID is the optional list filter.
+	// query:"list_id" specifies that this field is bound to the "list_id" query parameter.
+	ListID *uuid.UUID `query:"list_id"`
+	// DueAfter is the optional start of the due date range, inclusive.
+	// query:"due_after" specifies that this field is bound to the "due_after" query parameter.
+	DueAfter *time.Time `query:"due_after"`
+	// DueBefore is the optional end of the due date range, exclusive.
+	// query:"due_before" specifies that this field is bound to the "due_before" query parameter.
+	DueBefore *time.Time `query:"due_before"`
+	// HasDueDate is the optional filter on whether a todo has a due date at all.
+	// query:"has_due_date" specifies that this field is bound to the "has_due_date" query parameter.
+	HasDueDate *bool `query:"has_due_date"`
+	// View is the optional bucket of due dates, computed in the user's time zone: "today" for todos due by the end of today, including overdue ones,
+	// "upcoming" for todos due in the seven days after today, and "someday" for todos without a due date. It replaces the due date filters.
+	// query:"view" specifies that this field is bound to the "view" query parameter.
+	View string `query:"view" oneof:"today upcoming someday"`
+	// Total is how the total is counted: "exact" counts the matching todos, and "approx" reads it from the counts kept as todos are written,
+	// which skips the count on large lists. The due date filters and views are always counted exactly.
+	// query:"total" specifies that this field is bound to the "total" query parameter.
+	Total string `query:"total" default:"exact" oneof:"exact approx"`
+}
+
+// Validate checks that the due date range is not reversed and that a view is not combined with the due date filters it replaces.
+//
+// @return binding.FieldErrors - The invalid parameters, or nil if there are none.
+func (q *ListTodosQuery) Validate() binding.FieldErrors {
+	// This checks if a view is combined with a due date filter.
+	if q.View != "" && (q.DueAfter != nil || q.DueBefore != nil || q.HasDueDate != nil) {
+		// If it is, the view is reported.
+		return binding.FieldErrors{binding.NewFieldError("view", "cannot be combined with %s", "due_after, due_before, or has_due_date")}
+	}
+	// This checks if the range ends before it starts.
+	if q.DueAfter != nil && q.DueBefore != nil && !q.DueAfter.Before(*q.DueBefore) {
+		// If it does, the start is reported.
+		return binding.FieldErrors{binding.NewFieldError("due_after", "must be before %s", "due_before")}
+	}
+	// No error is returned.
+	return nil
+}
+
+// planDateLayout is the layout of the dates of the plan, which are calendar days rather than instants.
+const planDateLayout = "2006-01-02"
+
+// PlanQuery defines the query parameters of a plan request.
+type PlanQuery struct {
+	// Date is the first day of the plan as YYYY-MM-DD, or empty for today in the user's time zone.
+	// query:"date" specifies that this field is bound to the "date" query parameter.
+	Date string `query:"date"`
+	// Days is the number of days of the plan.
+	// query:"days" specifies that this field is bound to the "days" query parameter.
+	Days int `query:"days" default:"7" min:"1" max:"31"`
+	// Capacity is the estimated effort of a day in minutes, or zero to use TODO_DAILY_CAPACITY_MINUTES.
+	// query:"capacity" specifies that this field is bound to the "capacity" query parameter.
+	Capacity int `query:"capacity" min:"1"`
+}
+
+// Validate checks that the date is a calendar date.
+//
+// @return binding.FieldErrors - The invalid parameters, or nil if there are none.
+func (q *PlanQuery) Validate() binding.FieldErrors {
+	// This checks if the date is given and is not a calendar date.
+	if _, err := time.Parse(planDateLayout, q.Date); q.Date != "" && err != nil {
+		// If it is not, the date is reported.
+		return binding.FieldErrors{binding.NewFieldError("date", "must be a date such as %s", "2026-01-31")}
+	}
+	// No error is returned.
+	return nil
+}
+
+// PlanDayResponse defines the structure for one day of a plan response.
+type PlanDayResponse struct {
+	// Date is the day as YYYY-MM-DD in the user's time zone.
+	// json:"date" specifies that this field should be marshalled to/from a JSON object with the key "date".
+	Date string `json:"date"`
+	// TodoCount is the number of open todos due that day.
+	// json:"todo_count" specifies that this field should be marshalled to/from a JSON object with the key "todo_count".
+	TodoCount int `json:"todo_count"`
+	// EstimatedMinutes is the sum of the estimates of those todos.
+	// json:"estimated_minutes" specifies that this field should be marshalled to/from a JSON object with the key "estimated_minutes".
+	EstimatedMinutes int64 `json:"estimated_minutes"`
+	// UnestimatedCount is the number of those todos without an estimate, which the sum leaves out.
+	// json:"unestimated_count" specifies that this field should be marshalled to/from a JSON object with the key "unestimated_count".
+	UnestimatedCount int `json:"unestimated_count"`
+	// Overloaded reports whether the estimates of the day exceed the capacity.
+	// json:"overloaded" specifies that this field should be marshalled to/from a JSON object with the key "overloaded".
+	Overloaded bool `json:"overloaded"`
+}
+
+// PlanResponse defines the structure for a plan response.
+type PlanResponse struct {
+	// CapacityMinutes is the estimated effort of a day the days are compared with.
+	// json:"capacity_minutes" specifies that this field should be marshalled to/from a JSON object with the key "capacity_minutes".
+	CapacityMinutes int `json:"capacity_minutes"`
+	// Days is the list of days, in order.
+	// json:"days" specifies that this field should be marshalled to/from a JSON object with the key "days".
+	Days []PlanDayResponse `json:"days"`
+	// OverloadedDays is the list of the dates of the overloaded days, so that a client can warn without scanning the days.
+	// json:"overloaded_days" specifies that this field should be marshalled to/from a JSON object with the key "overloaded_days".
+	OverloadedDays []string `json:"overloaded_days"`
 }
 
 // PaginatedTodoResponse defines the structure for a paginated todo response.
@@ -56,4 +340,249 @@ type PaginatedTodoResponse struct {
 	// Limit is the number of todos per page.
 	// json:"limit" specifies that this field should be marshalled to/from a JSON object with the key "limit".
 	Limit int `json:"limit"`
-}
\ No newline at end of file
+}
+
+// ArchivedTodosQuery defines the query parameters of an archived todos request.
+type ArchivedTodosQuery struct {
+	// Page is the page number, starting at 1.
+	// query:"page" specifies that this field is bound to the "page" query parameter.
+	Page int `query:"page" default:"1" min:"1"`
+	// Limit is the number of todos per page, or zero to use the configured default.
+	// query:"limit" specifies that this field is bound to the "limit" query parameter.
+	Limit int `query:"limit" min:"1"`
+	// ListID is the optional list filter.
+	// query:"list_id" specifies that this field is bound to the "list_id" query parameter.
+	ListID *uuid.UUID `query:"list_id"`
+}
+
+// ArchivedTodoResponse defines the structure for an archived todo response.
+type ArchivedTodoResponse struct {
+	// ID is the unique identifier for the todo.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// Title is the title of the todo.
+	// json:"title" specifies that this field should be marshalled to/from a JSON object with the key "title".
+	Title string `json:"title"`
+	// Description is the description of the todo.
+	// json:"description" specifies that this field should be marshalled to/from a JSON object with the key "description".
+	Description string `json:"description"`
+	// Priority is the priority of the todo.
+	// json:"priority" specifies that this field should be marshalled to/from a JSON object with the key "priority".
+	Priority string `json:"priority"`
+	// ListID is the ID of the list the todo belonged to, if any.
+	// json:"list_id" specifies that this field should be marshalled to/from a JSON object with the key "list_id".
+	ListID *uuid.UUID `json:"list_id"`
+	// DueAt is the time the todo was due, or null if it had no due date.
+	// json:"due_at" specifies that this field should be marshalled to/from a JSON object with the key "due_at".
+	DueAt *string `json:"due_at"`
+	// Tags is the list of tags of the todo.
+	// json:"tags" specifies that this field should be marshalled to/from a JSON object with the key "tags".
+	Tags []string `json:"tags"`
+	// EstimateMinutes is the estimated effort of the todo in minutes, or null if it had no estimate.
+	// json:"estimate_minutes" specifies that this field should be marshalled to/from a JSON object with the key "estimate_minutes".
+	EstimateMinutes *int64 `json:"estimate_minutes"`
+	// CreatedAt is the time the todo was created.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt string `json:"created_at"`
+	// CompletedAt is the time the todo was last changed while completed.
+	// json:"completed_at" specifies that this field should be marshalled to/from a JSON object with the key "completed_at".
+	CompletedAt string `json:"completed_at"`
+	// ArchivedAt is the time the todo was moved to the archive.
+	// json:"archived_at" specifies that this field should be marshalled to/from a JSON object with the key "archived_at".
+	ArchivedAt string `json:"archived_at"`
+}
+
+// NewArchivedTodoResponse converts an archived todo into its response structure.
+//
+// @param todo ArchivedTodo - The archived todo to be converted.
+// @return ArchivedTodoResponse - The archived todo response.
+func NewArchivedTodoResponse(todo ArchivedTodo) ArchivedTodoResponse {
+	// listId is the list ID, or nil if the todo was not in a list.
+	var listId *uuid.UUID
+	// This checks if the todo was in a list.
+	if todo.ListID.Valid {
+		// If it was, the list ID is set.
+		listId = &todo.ListID.UUID
+	}
+
+	// dueAt is the formatted due date, or nil if the todo had no due date.
+	var dueAt *string
+	// This checks if the todo had a due date.
+	if todo.DueAt.Valid {
+		// If it had, the due date is formatted.
+		formatted := utils.ParseTime(todo.DueAt.Time)
+		dueAt = &formatted
+	}
+
+	// estimateMinutes is the estimated effort, or nil if the todo had no estimate.
+	var estimateMinutes *int64
+	// This checks if the todo had an estimate.
+	if todo.EstimateMinutes.Valid {
+		// If it had, the estimate is set.
+		estimateMinutes = &todo.EstimateMinutes.Int64
+	}
+
+	// tags is the list of tags, never nil so that it is serialized as an empty array.
+	tags := todo.Tags
+	// This checks if the todo had no tags.
+	if tags == nil {
+		// If it had none, an empty list is used.
+		tags = []string{}
+	}
+
+	// A new ArchivedTodoResponse is returned.
+	return ArchivedTodoResponse{
+		// The ID field is set to the todo's ID.
+		ID: todo.ID,
+		// The Title field is set to the todo's title.
+		Title: todo.Title,
+		// The Description field is set to the todo's description.
+		Description: todo.Description,
+		// The Priority field is set to the todo's priority.
+		Priority: todo.Priority,
+		// The ListID field is set to the list ID, if any.
+		ListID: listId,
+		// The DueAt field is set to the formatted due date, if any.
+		DueAt: dueAt,
+		// The Tags field is set to the todo's tags.
+		Tags: tags,
+		// The EstimateMinutes field is set to the estimate, if any.
+		EstimateMinutes: estimateMinutes,
+		// The CreatedAt field is set to the formatted creation time.
+		CreatedAt: utils.ParseTime(todo.CreatedAt),
+		// The CompletedAt field is set to the formatted completion time.
+		CompletedAt: utils.ParseTime(todo.CompletedAt),
+		// The ArchivedAt field is set to the formatted archive time.
+		ArchivedAt: utils.ParseTime(todo.ArchivedAt),
+	}
+}
+
+// PaginatedArchivedTodoResponse defines the structure for a paginated archived todo response.
+type PaginatedArchivedTodoResponse struct {
+	// Results is a slice of archived todos.
+	// json:"results" specifies that this field should be marshalled to/from a JSON object with the key "results".
+	Results []ArchivedTodoResponse `json:"results"`
+	// Count is the number of todos in the current page.
+	// json:"count" specifies that this field should be marshalled to/from a JSON object with the key "count".
+	Count int `json:"count"`
+	// TotalItems is the total number of archived todos.
+	// json:"total_items" specifies that this field should be marshalled to/from a JSON object with the key "total_items".
+	TotalItems int64 `json:"total_items"`
+	// TotalPages is the total number of pages.
+	// json:"total_pages" specifies that this field should be marshalled to/from a JSON object with the key "total_pages".
+	TotalPages int `json:"total_pages"`
+	// Page is the current page number.
+	// json:"page" specifies that this field should be marshalled to/from a JSON object with the key "page".
+	Page int `json:"page"`
+	// Limit is the number of todos per page.
+	// json:"limit" specifies that this field should be marshalled to/from a JSON object with the key "limit".
+	Limit int `json:"limit"`
+}
+
+// DuplicateTodoRequest defines the structure for a duplicate todo request.
+type DuplicateTodoRequest struct {
+	// ListID is the optional list to place the copy in.
+	// json:"list_id" specifies that this field should be marshalled to/from a JSON object with the key "list_id".
+	ListID *uuid.UUID `json:"list_id"`
+}
+
+// MoveTodosRequest defines the structure for a move todos request.
+type MoveTodosRequest struct {
+	// TodoIDs is the list of todos to move, in the order they should be appended to the list.
+	// json:"todo_ids" specifies that this field should be marshalled to/from a JSON object with the key "todo_ids".
+	// validate:"required,min=1" specifies that this field is required and must contain at least one ID.
+	TodoIDs []uuid.UUID `json:"todo_ids" validate:"required,min=1"`
+	// ListID is the target list. A null list moves the todos out of any list.
+	// json:"list_id" specifies that this field should be marshalled to/from a JSON object with the key "list_id".
+	ListID *uuid.UUID `json:"list_id"`
+}
+
+// TodoMovedEvent defines the structure for the data of a todo.moved event.
+type TodoMovedEvent struct {
+	// ID is the ID of the moved todo.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// ListID is the list the todo was moved into, or null if it was moved out of any list.
+	// json:"list_id" specifies that this field should be marshalled to/from a JSON object with the key "list_id".
+	ListID *uuid.UUID `json:"list_id"`
+}
+
+// SetTodoStatusRequest defines the structure for a request that moves a todo to another column of the board.
+type SetTodoStatusRequest struct {
+	// Status is the new status: backlog, in_progress, blocked, or done.
+	// json:"status" specifies that this field should be marshalled to/from a JSON object with the key "status".
+	Status string `json:"status"`
+}
+
+// AddBlockerRequest defines the structure for a request that makes a todo wait on another todo.
+type AddBlockerRequest struct {
+	// BlockerID is the ID of the todo that has to be completed first.
+	// json:"blocker_id" specifies that this field should be marshalled to/from a JSON object with the key "blocker_id".
+	BlockerID string `json:"blocker_id"`
+}
+
+// BoardQuery defines the query parameters of a board request.
+type BoardQuery struct {
+	// ListID is the optional list filter. Without it, the todos of archived lists are left out.
+	// query:"list_id" specifies that this field is bound to the "list_id" query parameter.
+	ListID *uuid.UUID `query:"list_id"`
+	// Limit is the maximum number of todos of each column.
+	// query:"limit" specifies that this field is bound to the "limit" query parameter.
+	Limit int `query:"limit" default:"50" min:"1" max:"200"`
+}
+
+// BoardColumnResponse defines the structure for one column of a board response.
+type BoardColumnResponse struct {
+	// Status is the status of the todos of the column.
+	// json:"status" specifies that this field should be marshalled to/from a JSON object with the key "status".
+	Status string `json:"status"`
+	// Count is the number of todos with the status, which may be more than the todos returned.
+	// json:"count" specifies that this field should be marshalled to/from a JSON object with the key "count".
+	Count int64 `json:"count"`
+	// Todos is the first todos of the column, in list order.
+	// json:"todos" specifies that this field should be marshalled to/from a JSON object with the key "todos".
+	Todos []TodoResponse `json:"todos"`
+}
+
+// SnoozeTodoRequest defines the structure for a snooze todo request. Exactly one of its fields is given.
+type SnoozeTodoRequest struct {
+	// Until is the time the snooze ends, in RFC 3339.
+	// json:"until" specifies that this field should be marshalled to/from a JSON object with the key "until".
+	Until *time.Time `json:"until"`
+	// Duration is the length of the snooze from now, such as "30m" or "2h".
+	// json:"duration" specifies that this field should be marshalled to/from a JSON object with the key "duration".
+	Duration string `json:"duration"`
+}
+
+// UndoTodoRequest defines the structure for an undo request.
+type UndoTodoRequest struct {
+	// UndoToken is the token returned by a delete, complete, or snooze response.
+	// json:"undo_token" specifies that this field should be marshalled to/from a JSON object with the key "undo_token".
+	// validate:"required" specifies that this field is required.
+	UndoToken string `json:"undo_token" validate:"required"`
+}
+
+// UndoableTodoResponse defines the structure for a todo response to an action that can be undone.
+type UndoableTodoResponse struct {
+	// TodoResponse is the todo after the action was performed.
+	TodoResponse
+	// UndoToken is the token that can be used to undo the action.
+	// json:"undo_token" specifies that this field should be marshalled to/from a JSON object with the key "undo_token".
+	UndoToken uuid.UUID `json:"undo_token"`
+	// UndoExpiresAt is the time after which the action can no longer be undone.
+	// json:"undo_expires_at" specifies that this field should be marshalled to/from a JSON object with the key "undo_expires_at".
+	UndoExpiresAt string `json:"undo_expires_at"`
+}
+
+// DeleteTodoResponse defines the structure for a delete todo response.
+type DeleteTodoResponse struct {
+	// TodoID is the ID of the deleted todo.
+	// json:"todo_id" specifies that this field should be marshalled to/from a JSON object with the key "todo_id".
+	TodoID uuid.UUID `json:"todo_id"`
+	// UndoToken is the token that can be used to restore the todo.
+	// json:"undo_token" specifies that this field should be marshalled to/from a JSON object with the key "undo_token".
+	UndoToken uuid.UUID `json:"undo_token"`
+	// UndoExpiresAt is the time after which the todo can no longer be restored.
+	// json:"undo_expires_at" specifies that this field should be marshalled to/from a JSON object with the key "undo_expires_at".
+	UndoExpiresAt string `json:"undo_expires_at"`
+}
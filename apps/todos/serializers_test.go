@@ -0,0 +1,120 @@
+// This file defines a fuzz test for the binding of the query parameters of the todo listing.
+package todos
+
+// "encoding/json" provides functions for encoding and decoding JSON. It is used here to read the code of a rejected request.
+import (
+	"encoding/json"
+	// "io" provides basic I/O primitives. It is used here to read the response body.
+	"io"
+	// "net/http/httptest" provides utilities for HTTP testing. It is used here to build the requests.
+	"net/http/httptest"
+	// "net/url" provides functions for working with URLs. It is used here to encode the query parameters.
+	"net/url"
+	// "testing" provides support for automated tests. It is used here to run the fuzz test.
+	"testing"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to serve the requests.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/backend/binding" is a local package that binds query parameters.
+	"github.com/rahulcodepython/todo-backend/backend/binding"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration. It is used here for the page sizes.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+)
+
+// FuzzListTodosQuery checks that no query parameters of GET /todos make the binding or the pagination panic, and that
+// invalid ones are answered with 400 and the code "invalid_parameters", rather than reaching the database as a page whose
+// offset overflowed or answered with 500.
+//
+// @param f *testing.F - The fuzzing state.
+func FuzzListTodosQuery(f *testing.F) {
+	// The seeds are a valid query, each kind of invalid parameter, and the largest page there is.
+	f.Add("1", "20", "-created_at", "true", "", "", "", "", "", "exact")
+	f.Add("0", "0", "owner", "yes", "not-a-uuid", "2025-13-01", "", "maybe", "today", "none")
+	f.Add("9223372036854775807", "100", "", "", "", "", "", "", "", "")
+	f.Add("-1", "1000", "title", "1", "8a1c2f9e-4a8b-4a9e-9c1e-6f1f0f0f0f0f", "2025-01-02T00:00:00Z", "2025-01-01T00:00:00Z", "false", "", "approx")
+	f.Add("", "", "", "", "", "2025-01-01T00:00:00Z", "", "", "upcoming", "")
+
+	// pagination is the page sizes the limit is resolved against.
+	pagination := config.PaginationConfig{DefaultLimit: 20, MaxLimit: 100}
+	// app serves the binding and the pagination of the todo listing, without the queries to the database.
+	app := fiber.New()
+	// The route binds the query parameters the way the controller does.
+	app.Get("/todos", func(c *fiber.Ctx) error {
+		// query is the result of binding the query parameters.
+		query, err := binding.Query[ListTodosQuery](c)
+		// This checks if any query parameter is invalid.
+		if err != nil {
+			// If one is, a bad request response is returned with the invalid parameters.
+			return response.InvalidParameters(c, err)
+		}
+		// The page size is resolved against the configured page sizes.
+		query.Limit, err = binding.PageLimit(query.Limit, pagination)
+		// This checks if the requested page size is too large.
+		if err != nil {
+			// If it is, a bad request response is returned with the invalid parameter.
+			return response.InvalidParameters(c, err)
+		}
+		// offset is the number of todos before the page.
+		offset, err := binding.PageOffset(query.Page, query.Limit)
+		// This checks if the requested page is too far away.
+		if err != nil {
+			// If it is, a bad request response is returned with the invalid parameter.
+			return response.InvalidParameters(c, err)
+		}
+		// This checks if the offset that would reach the database is negative.
+		if offset < 0 || query.Limit < 1 {
+			// If it is, the request fails like a rejected query would.
+			return response.InternelServerError(c, nil, "Failed to retrieve todos")
+		}
+		// Otherwise an OK response is returned.
+		return response.OKResponse(c, "Todos fetched successfully", nil)
+	})
+
+	// This runs the test on every input.
+	f.Fuzz(func(t *testing.T, page, limit, sort, completed, listId, dueAfter, dueBefore, hasDueDate, view, total string) {
+		// values are the query parameters, of which the empty ones are left out.
+		values := url.Values{}
+		// This iterates over the parameters.
+		for name, value := range map[string]string{"page": page, "limit": limit, "sort": sort, "completed": completed, "list_id": listId, "due_after": dueAfter, "due_before": dueBefore, "has_due_date": hasDueDate, "view": view, "total": total} {
+			// This checks if the parameter is given.
+			if value != "" {
+				values.Set(name, value)
+			}
+		}
+
+		// res is the response to the request.
+		res, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/todos?"+values.Encode(), nil), -1)
+		// This checks if the request could not be served.
+		if err != nil {
+			// If it could not, the test fails.
+			t.Fatalf("GET /todos?%s failed: %v", values.Encode(), err)
+		}
+		// This defers closing the body.
+		defer res.Body.Close()
+
+		// This checks the status of the response.
+		switch res.StatusCode {
+		// The parameters were accepted.
+		case fiber.StatusOK:
+		// The parameters were rejected, which must be done with the code of invalid parameters.
+		case fiber.StatusBadRequest:
+			// body is the response body.
+			body, _ := io.ReadAll(res.Body)
+			// rejected is the code of the response.
+			var rejected struct {
+				Code string `json:"code"`
+			}
+			// This checks if the response does not carry the code of invalid parameters.
+			if err := json.Unmarshal(body, &rejected); err != nil || rejected.Code != "invalid_parameters" {
+				// If it does not, the test fails.
+				t.Fatalf("GET /todos?%s was rejected without the invalid parameters: %s", values.Encode(), body)
+			}
+		// Any other status is a failure of the server.
+		default:
+			// The test fails.
+			t.Fatalf("GET /todos?%s returned %d", values.Encode(), res.StatusCode)
+		}
+	})
+}
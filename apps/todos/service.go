@@ -0,0 +1,1697 @@
+// This file defines the service that holds the business logic of todos.
+// The service validates input, checks ownership, and runs the transactions, so that the REST controllers,
+// the chat integrations, and any other surface share one implementation and only translate its results.
+package todos
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to pass the deadline of the request to the queries.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to interact with the database.
+	"database/sql"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to store the previous state of a todo in the activity log.
+	"encoding/json"
+	// "errors" provides functions for working with errors. It is used here to define and compare the service errors.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to wrap a service error.
+	"fmt"
+	// "slices" provides functions for working with slices. It is used here to check statuses.
+	"slices"
+	// "strings" provides functions for working with strings. It is used here to normalize priorities and tags.
+	"strings"
+	// "time" provides functions for working with time. It is used here to define the due date of a todo and to compute the days of the views.
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to define and compare IDs.
+	"github.com/google/uuid"
+	// "github.com/lib/pq" is the PostgreSQL driver. It is used here to pass arrays as query parameters.
+	"github.com/lib/pq"
+	// "github.com/rahulcodepython/todo-backend/apps/lists" is a local package that contains list ownership checks.
+	"github.com/rahulcodepython/todo-backend/apps/lists"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains the user model.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/content" is a local package that validates text written by users.
+	"github.com/rahulcodepython/todo-backend/backend/content"
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database helpers.
+	"github.com/rahulcodepython/todo-backend/backend/database"
+	// "github.com/rahulcodepython/todo-backend/backend/dberr" is a local package that classifies database errors. It is used here to recognize a reused idempotency key.
+	"github.com/rahulcodepython/todo-backend/backend/dberr"
+	// "github.com/rahulcodepython/todo-backend/backend/idgen" is a local package that creates new IDs.
+	"github.com/rahulcodepython/todo-backend/backend/idgen"
+	// "github.com/rahulcodepython/todo-backend/backend/outbox" is a local package that records domain events.
+	"github.com/rahulcodepython/todo-backend/backend/outbox"
+	// "github.com/rahulcodepython/todo-backend/backend/quota" is a local package that enforces usage limits.
+	"github.com/rahulcodepython/todo-backend/backend/quota"
+)
+
+// ErrTodoNotFound is returned when a todo does not exist or has been deleted.
+var ErrTodoNotFound = errors.New("todo not found")
+
+// ErrTodoForbidden is returned when a todo belongs to another user.
+var ErrTodoForbidden = errors.New("todo belongs to another user")
+
+// ErrTodoVersionMismatch is returned when a todo was changed since the version the client based its change on.
+var ErrTodoVersionMismatch = errors.New("todo was changed since the expected version")
+
+// ErrIdempotencyKeyUsed is returned when the user already created a todo with the same Idempotency-Key.
+var ErrIdempotencyKeyUsed = errors.New("idempotency key was already used")
+
+// errTodoInOtherState is returned by todoAccessError when the todo exists and belongs to the user,
+// so the statement only missed it because of another condition, such as its version.
+var errTodoInOtherState = fmt.Errorf("%w in the expected state", ErrTodoNotFound)
+
+// ErrInvalidPriority is returned when a priority is not one of the allowed values.
+var ErrInvalidPriority = errors.New("priority must be one of none, low, medium, or high")
+
+// ErrInvalidEstimate is returned when the estimated effort of a todo is out of range.
+var ErrInvalidEstimate = errors.New("estimate must be between 1 and 10080 minutes")
+
+// ErrTodoIdsRequired is returned when a move references no todos.
+var ErrTodoIdsRequired = errors.New("todo ids are required")
+
+// ErrTodoIdsNotUnique is returned when a move references a todo more than once.
+var ErrTodoIdsNotUnique = errors.New("todo ids must be unique")
+
+// ErrTodosNotMovable is returned when a move references todos or a list that the user does not own.
+var ErrTodosNotMovable = errors.New("some todos or the target list do not exist or do not belong to you")
+
+// ErrNothingToUndo is returned when an undo token is unknown, belongs to another user, or was already used.
+var ErrNothingToUndo = errors.New("nothing to undo for this token")
+
+// ErrUndoWindowExpired is returned when an action is undone after the undo window has passed.
+var ErrUndoWindowExpired = errors.New("undo window has expired")
+
+// ErrActionNotUndoable is returned when an action cannot be undone.
+var ErrActionNotUndoable = errors.New("action cannot be undone")
+
+// ErrSnoozeTimeRequired is returned when a snooze gives neither a time nor a duration, or gives both.
+var ErrSnoozeTimeRequired = errors.New("either until or duration is required")
+
+// ErrSnoozeNotInFuture is returned when a todo is snoozed until a time that has already passed.
+var ErrSnoozeNotInFuture = errors.New("snooze must end in the future")
+
+// ErrInvalidStatus is returned when a status is not one of Statuses.
+var ErrInvalidStatus = errors.New("status must be one of backlog, in_progress, blocked, or done")
+
+// ErrSelfBlocker is returned when a todo is linked as its own blocker.
+var ErrSelfBlocker = errors.New("a todo cannot block itself")
+
+// ErrBlockerNotFound is returned when a blocker does not exist, was deleted, or belongs to another user, or is not linked to the todo.
+var ErrBlockerNotFound = errors.New("blocker not found")
+
+// ErrDependencyCycle is returned when a blocker already waits on the todo, directly or through other blockers.
+var ErrDependencyCycle = errors.New("blocker already waits on the todo")
+
+// DuplicateTitleError is returned when a todo is created while the user has an open todo with the same title in the same list.
+type DuplicateTitleError struct {
+	// Existing is the open todo with the same title.
+	Existing Todo
+}
+
+// Error returns the message of the error.
+//
+// @return string - The message.
+func (e *DuplicateTitleError) Error() string {
+	// The message names the existing todo.
+	return fmt.Sprintf("an open todo with the same title already exists: %s", e.Existing.ID)
+}
+
+// maxEstimateMinutes is the largest estimated effort of a todo, one week, since a larger task is a project rather than a todo.
+const maxEstimateMinutes = 7 * 24 * 60
+
+// TodoInput holds the fields of a todo that a user writes when creating or updating it.
+type TodoInput struct {
+	// Title is the title of the todo.
+	Title string
+	// Description is the optional longer description of the todo.
+	Description string
+	// Priority is the priority of the todo. An empty priority is stored as PriorityNone.
+	Priority string
+	// DueAt is the optional time the todo is due.
+	DueAt *time.Time
+	// Tags is the list of tags of the todo, which are normalized before they are stored.
+	Tags []string
+	// EstimateMinutes is the optional estimated effort of the todo in minutes.
+	EstimateMinutes *int
+	// Version is the optional version an update is based on. It is ignored when a todo is created.
+	Version *int64
+}
+
+// TodoService holds the business logic of todos.
+type TodoService struct {
+	// cfg is the application configuration.
+	cfg *config.Config
+	// db is the database connection.
+	db *sql.DB
+	// clock tells the current time.
+	clock clock.Clock
+	// ids creates the IDs of new rows.
+	ids idgen.IDGenerator
+	// content cleans and checks titles and descriptions.
+	content *content.Validator
+}
+
+// NewTodoService creates a new TodoService.
+// It takes the application configuration, database connection, clock, ID generator, and content validator as input.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @param clk clock.Clock - The clock that tells the current time.
+// @param ids idgen.IDGenerator - The generator of the IDs of new rows.
+// @param validator *content.Validator - The validator of titles and descriptions.
+// @return *TodoService - A pointer to the new TodoService.
+func NewTodoService(cfg *config.Config, db *sql.DB, clk clock.Clock, ids idgen.IDGenerator, validator *content.Validator) *TodoService {
+	// A new TodoService is returned.
+	return &TodoService{
+		// The cfg field is set to the application configuration.
+		cfg: cfg,
+		// The db field is set to the database connection.
+		db: db,
+		// The clock field is set to the clock.
+		clock: clk,
+		// The ids field is set to the ID generator.
+		ids: ids,
+		// The content field is set to the content validator.
+		content: validator,
+	}
+}
+
+// NormalizePriority lowercases a priority and checks that it is one of the allowed values.
+// An empty priority is normalized to PriorityNone.
+//
+// @param priority string - The priority to be normalized.
+// @return string - The normalized priority.
+// @return bool - True if the priority is allowed, false otherwise.
+func NormalizePriority(priority string) (string, bool) {
+	// priority is lowercased and trimmed.
+	priority = strings.ToLower(strings.TrimSpace(priority))
+	// This checks if the priority is empty.
+	if priority == "" {
+		// If it is, the default priority is returned.
+		return PriorityNone, true
+	}
+	// This checks the priority against the allowed values.
+	switch priority {
+	// The allowed values are returned as is.
+	case PriorityNone, PriorityLow, PriorityMedium, PriorityHigh:
+		return priority, true
+	}
+	// Any other value is rejected.
+	return "", false
+}
+
+// NormalizeTags lowercases and trims tags, strips a leading "#", and removes empty and duplicate tags.
+//
+// @param tags []string - The tags to be normalized.
+// @return []string - The normalized tags.
+func NormalizeTags(tags []string) []string {
+	// normalized is the list of normalized tags.
+	normalized := make([]string, 0, len(tags))
+	// seen tracks the tags that were already added.
+	seen := make(map[string]bool, len(tags))
+	// This iterates over the tags.
+	for _, tag := range tags {
+		// tag is stripped of NUL bytes, lowercased, trimmed, and stripped of a leading "#".
+		tag = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(strings.ReplaceAll(tag, "\x00", ""))), "#")
+		// This checks if the tag is empty or was already added.
+		if tag == "" || seen[tag] {
+			// If it is, it is skipped.
+			continue
+		}
+		// The tag is marked as seen.
+		seen[tag] = true
+		// The tag is appended to the normalized tags.
+		normalized = append(normalized, tag)
+	}
+	// The normalized tags are returned.
+	return normalized
+}
+
+// normalizeInput checks the fields of a todo and normalizes its text, priority, and tags.
+//
+// @param input TodoInput - The fields written by the user.
+// @return TodoInput - The normalized fields.
+// @return error - ErrEmptyTitle if the title is empty, the binding.FieldErrors of a title or description that is too long
+// or not allowed, ErrInvalidPriority if the priority is not allowed, or ErrInvalidEstimate if the estimate is out of range.
+func (ts *TodoService) normalizeInput(input TodoInput) (TodoInput, error) {
+	// The title and description are cleaned of control characters, and their length and words are checked.
+	contentErr := ts.content.Clean(content.Text{Field: ts.content.TodoTitle, Value: &input.Title}, content.Text{Field: ts.content.TodoDescription, Value: &input.Description})
+	// This checks if the title is empty.
+	if input.Title == "" {
+		// If it is, the empty title error is returned.
+		return input, ErrEmptyTitle
+	}
+	// This checks if the title or description is invalid.
+	if contentErr != nil {
+		// If one is, the invalid fields are returned.
+		return input, contentErr
+	}
+	// priority is the normalized priority of the todo.
+	priority, ok := NormalizePriority(input.Priority)
+	// This checks if the priority is not one of the allowed values.
+	if !ok {
+		// If it is not, the invalid priority error is returned.
+		return input, ErrInvalidPriority
+	}
+	// The priority is set to the normalized priority.
+	input.Priority = priority
+	// This checks if the estimate is given and out of range.
+	if input.EstimateMinutes != nil && (*input.EstimateMinutes < 1 || *input.EstimateMinutes > maxEstimateMinutes) {
+		// If it is, the invalid estimate error is returned.
+		return input, ErrInvalidEstimate
+	}
+	// The tags are set to the normalized tags.
+	input.Tags = NormalizeTags(input.Tags)
+	// The normalized fields are returned.
+	return input, nil
+}
+
+// todoAccessError explains why a statement scoped to the user's todos matched no todo.
+// It is only called after such a statement came back empty, so the common path needs no extra round trip.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param tx *sql.Tx - The transaction of the statement.
+// @param todoId uuid.UUID - The ID of the todo.
+// @param currentUserId uuid.UUID - The ID of the current user.
+// @return error - ErrTodoNotFound if the todo does not exist or is in another state, ErrTodoForbidden if it belongs to another user, or another error if one occurred.
+func todoAccessError(ctx context.Context, tx *sql.Tx, todoId uuid.UUID, currentUserId uuid.UUID) error {
+	// ownerId is a variable that will hold the ID of the todo's owner.
+	var ownerId uuid.UUID
+
+	// err is the result of querying the database for the todo's owner.
+	err := tx.QueryRowContext(ctx, GetTodoUserQuery, todoId).Scan(&ownerId)
+	// This checks if the todo does not exist.
+	if err == sql.ErrNoRows {
+		// If it does not, ErrTodoNotFound is returned.
+		return ErrTodoNotFound
+	}
+	// This checks if another error occurred while querying the database.
+	if err != nil {
+		// If one did, it is returned.
+		return err
+	}
+	// This checks if the todo belongs to another user.
+	if ownerId != currentUserId {
+		// If it does, ErrTodoForbidden is returned.
+		return ErrTodoForbidden
+	}
+	// Otherwise the todo exists and belongs to the user, so it was not found in the state the statement expected.
+	return errTodoInOtherState
+}
+
+// Create creates a todo for a user after checking its fields and the user's plan.
+// When an idempotency key is given, it is stored with the todo, so that a retried request cannot create the todo twice.
+// When checkDuplicate is set and duplicate detection is enabled, the todo is refused if the user already has an open todo
+// with the same title in the same list, ignoring case and extra whitespace.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param user users.User - The user who owns the new todo.
+// @param input TodoInput - The fields of the todo.
+// @param idempotencyKey string - The Idempotency-Key of the request, or an empty string.
+// @param checkDuplicate bool - Whether an open todo with the same title refuses the new one.
+// @return Todo - The created todo.
+// @return error - ErrEmptyTitle or ErrInvalidPriority if a field is invalid, a *quota.ExceededError if the plan limit is reached,
+// ErrIdempotencyKeyUsed if the key was already used, a *DuplicateTitleError if an open todo has the same title, or another error if one occurred.
+func (ts *TodoService) Create(ctx context.Context, user users.User, input TodoInput, idempotencyKey string, checkDuplicate bool) (Todo, error) {
+	// input is the checked and normalized input.
+	input, err := ts.normalizeInput(input)
+	// This checks if a field is invalid.
+	if err != nil {
+		// If one is, the error is returned.
+		return Todo{}, err
+	}
+
+	// todoId is the new UUID for the todo.
+	todoId := ts.ids.NewID()
+	// now is the creation time of the todo.
+	now := ts.clock.Now()
+
+	// todo is a new Todo struct.
+	todo := Todo{
+		// The ID field is set to the new UUID.
+		ID: todoId,
+		// The Title field is set to the todo's title.
+		Title: input.Title,
+		// The Description field is set to the todo's description.
+		Description: input.Description,
+		// The Priority field is set to the todo's priority.
+		Priority: input.Priority,
+		// The Completed field is set to false.
+		Completed: false,
+		// The Owner field is set to the user's ID.
+		Owner: user.ID.String(),
+		// The CreatedAt field is set to the current time.
+		CreatedAt: now,
+		// The UpdatedAt field is set to the creation time.
+		UpdatedAt: now,
+		// The Tags field is set to the normalized tags.
+		Tags: input.Tags,
+	}
+
+	// This checks if a due date was given.
+	if input.DueAt != nil {
+		// If it was, the DueAt field is set to it.
+		todo.DueAt = sql.NullTime{Time: *input.DueAt, Valid: true}
+	}
+	// This checks if an estimate was given.
+	if input.EstimateMinutes != nil {
+		// If it was, the EstimateMinutes field is set to it.
+		todo.EstimateMinutes = sql.NullInt64{Int64: int64(*input.EstimateMinutes), Valid: true}
+	}
+
+	// err is the result of creating the todo and recording the event in one transaction.
+	err = database.WithTx(ctx, ts.db, func(tx *sql.Tx) error {
+		// This checks that the todo fits in the user's plan.
+		if err := quota.Check(ctx, tx, ts.cfg, user.ID, quota.Todos, 1); err != nil {
+			// If it does not, the error is returned.
+			return err
+		}
+		// This checks if a todo with the same title would be a duplicate.
+		// The quota check locked the user, so two concurrent creates of the same title cannot both pass.
+		if checkDuplicate && ts.cfg.Todo.DetectDuplicateTitles {
+			// existing is the open todo with the same title, if there is one.
+			existing, err := ScanTodo(tx.QueryRowContext(ctx, GetOpenTodoByTitleQuery, user.ID, todo.ListID, todo.Title))
+			// This checks if there is such a todo.
+			if err == nil {
+				// If there is, the duplicate error is returned with it.
+				return &DuplicateTitleError{Existing: existing}
+			}
+			// This checks if an error occurred other than finding no todo.
+			if err != sql.ErrNoRows {
+				// If one did, it is returned.
+				return err
+			}
+		}
+		// This executes the SQL query to create the new todo and reads back its version.
+		if err := tx.QueryRowContext(ctx, CreateTodoQuery, todo.ID, todo.Title, todo.Description, todo.Priority, todo.Completed, todo.Owner, todo.CreatedAt, todo.ListID, todo.Position, todo.DueAt, pq.Array(todo.Tags), todo.EstimateMinutes).Scan(&todo.Version); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// This checks if the request carries an idempotency key.
+		if idempotencyKey != "" {
+			// This remembers the key, which fails with a unique violation if the user already used it.
+			if _, err := tx.ExecContext(ctx, CreateIdempotencyKeyQuery, user.ID, idempotencyKey, todo.ID); err != nil {
+				// This checks if the key was already used.
+				if dberr.Is(err, dberr.ErrUniqueViolation) {
+					// If it was, ErrIdempotencyKeyUsed is returned.
+					return ErrIdempotencyKeyUsed
+				}
+				// Otherwise the error is returned.
+				return err
+			}
+		}
+		// The event is recorded.
+		return RecordTodoEvent(ctx, tx, outbox.TodoCreated, todo)
+	})
+	// The created todo and the error, if any, are returned.
+	return todo, err
+}
+
+// CreateFromText parses a quick-add line in the user's time zone and creates the resulting todo.
+// It is shared by the quick-add endpoint and the chat integrations.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param user users.User - The user who owns the new todo.
+// @param text string - The line to be parsed.
+// @return Todo - The created todo.
+// @return error - ErrEmptyTitle if no title is left, a *quota.ExceededError if the plan limit is reached, or another error if one occurred.
+func (ts *TodoService) CreateFromText(ctx context.Context, user users.User, text string) (Todo, error) {
+	// parsed is the result of parsing the line relative to the current time in the user's time zone.
+	parsed := ParseQuickAdd(text, ts.clock.Now().In(user.Location()))
+
+	// The parsed fields are created like any other todo.
+	return ts.Create(ctx, user, TodoInput{Title: parsed.Title, Priority: parsed.Priority, DueAt: parsed.DueAt, Tags: parsed.Tags}, "", false)
+}
+
+// upcomingDays is the number of days after today that the upcoming view covers.
+const upcomingDays = 7
+
+// ApplyView turns the view of a query into due date filters, computed from the current time in the user's time zone,
+// so that every client buckets todos the same way. Unless the query filters by completion itself, a view only lists open todos.
+//
+// @param query ListTodosQuery - The query, which may have a view.
+// @param user users.User - The user whose day the view follows.
+// @return ListTodosQuery - The query with the filters of its view.
+func (ts *TodoService) ApplyView(query ListTodosQuery, user users.User) ListTodosQuery {
+	// This checks if the query has no view.
+	if query.View == "" {
+		// If it has none, it is returned unchanged.
+		return query
+	}
+	// This checks if the query does not filter by completion.
+	if query.Completed == nil {
+		// If it does not, only open todos are listed.
+		completed := false
+		query.Completed = &completed
+	}
+
+	// now is the current time in the user's time zone.
+	now := ts.clock.Now().In(user.Location())
+	// tomorrow is the start of the user's next day. It is built from the calendar date rather than by adding hours, so that days with a DST change stay whole.
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	// This sets the filters of the view.
+	switch query.View {
+	// Today holds everything due before tomorrow, so that overdue todos stay in sight.
+	case "today":
+		query.DueBefore = &tomorrow
+	// Upcoming holds the todos due in the days after today.
+	case "upcoming":
+		// end is the start of the day after the last day of the view.
+		end := tomorrow.AddDate(0, 0, upcomingDays)
+		query.DueAfter = &tomorrow
+		query.DueBefore = &end
+	// Someday holds the todos without a due date.
+	case "someday":
+		hasDueDate := false
+		query.HasDueDate = &hasDueDate
+	}
+	// The query is returned.
+	return query
+}
+
+// Count counts the todos of a user that match the filters of a query.
+// If the query asks for an approximate total and has no due date filter, the total is read from the counts the database keeps instead.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param ownerId uuid.UUID - The ID of the todos' owner.
+// @param query ListTodosQuery - The filters.
+// @return int64 - The number of matching todos.
+// @return error - An error if one occurred.
+func (ts *TodoService) Count(ctx context.Context, ownerId uuid.UUID, query ListTodosQuery) (int64, error) {
+	// count is the number of matching todos.
+	var count int64
+	// This checks if an approximate total is enough and the counts cover the filters.
+	if query.Total == "approx" && query.DueAfter == nil && query.DueBefore == nil && query.HasDueDate == nil {
+		// If so, the total is read from the counts.
+		err := ts.db.QueryRowContext(ctx, CountTodosByUserApproxQuery, ownerId, query.Completed, query.ListID).Scan(&count)
+		// The count and the error, if any, are returned.
+		return count, err
+	}
+	// err is the result of counting the user's todos that match the filters.
+	err := ts.db.QueryRowContext(ctx, CountTodosByUserQuery, ownerId, query.Completed, query.ListID, query.DueAfter, query.DueBefore, query.HasDueDate).Scan(&count)
+	// The count and the error, if any, are returned.
+	return count, err
+}
+
+// PlanDay is the planned workload of one day of a user.
+type PlanDay struct {
+	// Date is the day in the user's time zone.
+	Date time.Time
+	// TodoCount is the number of open todos due that day.
+	TodoCount int
+	// EstimatedMinutes is the sum of the estimates of those todos.
+	EstimatedMinutes int64
+	// UnestimatedCount is the number of those todos without an estimate, which the sum leaves out.
+	UnestimatedCount int
+}
+
+// Plan sums the estimates of the open todos of a user that are due on each of a number of days, in the user's time zone,
+// so that a client can show which days are overloaded. Every day of the range is returned, including days with nothing due.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param user users.User - The user, whose time zone draws the days.
+// @param date *time.Time - The first day, whose year, month, and day are read as a calendar date, or nil for today in the user's time zone.
+// @param days int - The number of days.
+// @return []PlanDay - The days, in order.
+// @return error - An error if one occurred.
+func (ts *TodoService) Plan(ctx context.Context, user users.User, date *time.Time, days int) ([]PlanDay, error) {
+	// location is the user's time zone.
+	location := user.Location()
+	// start is the first day, today in the user's time zone unless a date is given.
+	start := ts.clock.Now().In(location)
+	// This checks if a date is given.
+	if date != nil {
+		// If it is, it is used.
+		start = *date
+	}
+	// plan is the list of days, each starting at midnight in the user's time zone.
+	plan := make([]PlanDay, days)
+	// This iterates over the days.
+	for i := range plan {
+		// The day is built from the calendar date, so that days with a DST change stay whole.
+		plan[i].Date = time.Date(start.Year(), start.Month(), start.Day()+i, 0, 0, 0, 0, location)
+	}
+	// end is the start of the day after the last one.
+	end := time.Date(start.Year(), start.Month(), start.Day()+days, 0, 0, 0, 0, location)
+
+	// rows is the result of querying the database for the todos due in the range.
+	rows, err := ts.db.QueryContext(ctx, GetPlannedTodosQuery, user.ID, plan[0].Date, end)
+	// This checks if an error occurred while querying the database.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// This defers the closing of the rows until the function returns.
+	defer rows.Close()
+
+	// This iterates over the rows.
+	for rows.Next() {
+		// dueAt is the due date of the todo.
+		var dueAt time.Time
+		// estimate is the estimate of the todo, if any.
+		var estimate sql.NullInt64
+		// This scans the row.
+		if err := rows.Scan(&dueAt, &estimate); err != nil {
+			// If an error occurs, it is returned.
+			return nil, err
+		}
+		// local is the due date in the user's time zone.
+		local := dueAt.In(location)
+		// day is the index of the day the todo is due on, counted in calendar days from the first one.
+		day := int(time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC).Sub(time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)).Hours() / 24)
+		// This checks if the day is out of range, which the query already rules out.
+		if day < 0 || day >= days {
+			// If it is, the todo is skipped.
+			continue
+		}
+		// The todo is counted.
+		plan[day].TodoCount++
+		// This checks if the todo has an estimate.
+		if estimate.Valid {
+			// If it has, the estimate is added.
+			plan[day].EstimatedMinutes += estimate.Int64
+		} else {
+			// Otherwise the todo is counted as unestimated.
+			plan[day].UnestimatedCount++
+		}
+	}
+	// The days and the error of the iteration, if any, are returned.
+	return plan, rows.Err()
+}
+
+// BoardColumn is one column of the board of a user.
+type BoardColumn struct {
+	// Status is the status of the todos of the column.
+	Status string
+	// Count is the number of todos with the status.
+	Count int64
+	// Todos is the first todos of the column, in list order.
+	Todos []Todo
+}
+
+// withStatusCount scans the status count that follows the todo columns, so that ScanTodo can be reused.
+type withStatusCount struct {
+	// row is the row being scanned.
+	row todoScanner
+	// count receives the status count.
+	count *int64
+}
+
+// Scan scans the todo columns followed by the status count.
+//
+// @param dest ...any - The todo columns.
+// @return error - An error if one occurred.
+func (w withStatusCount) Scan(dest ...any) error {
+	// The status count is scanned after the todo columns.
+	return w.row.Scan(append(dest, w.count)...)
+}
+
+// Board groups the todos of a user by status, with every status as a column, in the order of Statuses,
+// even when it has no todos. Each column holds its first todos in list order and the number of todos it has in all.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param ownerId uuid.UUID - The ID of the current user.
+// @param listId *uuid.UUID - The list whose todos are grouped, or nil for the todos that are not in an archived list.
+// @param limit int - The maximum number of todos of each column.
+// @return []BoardColumn - The columns.
+// @return error - An error if one occurred.
+func (ts *TodoService) Board(ctx context.Context, ownerId uuid.UUID, listId *uuid.UUID, limit int) ([]BoardColumn, error) {
+	// columns is the list of columns, one for each status.
+	columns := make([]BoardColumn, len(Statuses))
+	// index maps a status to its column.
+	index := make(map[string]int, len(Statuses))
+	// This iterates over the statuses.
+	for i, status := range Statuses {
+		// The column starts empty.
+		columns[i] = BoardColumn{Status: status, Todos: []Todo{}}
+		// The status is mapped to the column.
+		index[status] = i
+	}
+
+	// rows is the result of querying the database for the first todos of each status.
+	rows, err := ts.db.QueryContext(ctx, GetBoardTodosQuery, ownerId, listId, limit)
+	// This checks if an error occurred while querying the database.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// This defers the closing of the rows until the function returns.
+	defer rows.Close()
+
+	// This iterates over the rows.
+	for rows.Next() {
+		// count is the number of todos with the status of the row.
+		var count int64
+		// todo is the result of scanning the row.
+		todo, err := ScanTodo(withStatusCount{row: rows, count: &count})
+		// This checks if an error occurred while scanning the row.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return nil, err
+		}
+		// column is the column of the todo.
+		column := &columns[index[todo.Status]]
+		// The count of the column is set, and the todo is appended to it.
+		column.Count = count
+		column.Todos = append(column.Todos, todo)
+	}
+	// The columns and the error of the iteration, if any, are returned.
+	return columns, rows.Err()
+}
+
+// Get retrieves a todo of a user.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param ownerId uuid.UUID - The ID of the current user.
+// @param todoId uuid.UUID - The ID of the todo.
+// @return Todo - The todo.
+// @return error - ErrTodoNotFound if the todo does not exist, ErrTodoForbidden if it belongs to another user, or another error if one occurred.
+func (ts *TodoService) Get(ctx context.Context, ownerId uuid.UUID, todoId uuid.UUID) (Todo, error) {
+	// todo is the result of querying the database for the todo.
+	todo, err := ScanTodo(ts.db.QueryRowContext(ctx, GetTodoQuery, todoId))
+	// This checks if the todo does not exist.
+	if err == sql.ErrNoRows {
+		// If it does not, ErrTodoNotFound is returned.
+		return Todo{}, ErrTodoNotFound
+	}
+	// This checks if another error occurred while querying the database.
+	if err != nil {
+		// If one did, it is returned.
+		return Todo{}, err
+	}
+	// This checks if the todo belongs to another user.
+	if todo.Owner != ownerId.String() {
+		// If it does, ErrTodoForbidden is returned.
+		return Todo{}, ErrTodoForbidden
+	}
+	// The todo is returned.
+	return todo, nil
+}
+
+// List retrieves a page of the todos of a user that match the filters of a query, in the order of the query.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param ownerId uuid.UUID - The ID of the todos' owner.
+// @param query ListTodosQuery - The filters, the order, and the page size.
+// @param page int - The page number, starting at 1.
+// @return []Todo - The todos of the page.
+// @return error - An error if one occurred.
+func (ts *TodoService) List(ctx context.Context, ownerId uuid.UUID, query ListTodosQuery, page int) ([]Todo, error) {
+	// rows is the result of retrieving the page of the user's todos that match the filters.
+	rows, err := ts.queryTodos(ctx, ownerId, query, query.Limit, (page-1)*query.Limit)
+	// This checks if an error occurred while querying the database.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// This defers the closing of the rows until the function returns.
+	defer rows.Close()
+
+	// todos is a slice that will hold the retrieved todos.
+	todos := []Todo{}
+	// This iterates over the rows.
+	for rows.Next() {
+		// todo is the result of scanning the row.
+		todo, err := ScanTodo(rows)
+		// This checks if an error occurred while scanning the row.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return nil, err
+		}
+		// The todo is appended to the todos slice.
+		todos = append(todos, todo)
+	}
+
+	// The todos and the error of the iteration, if any, are returned.
+	return todos, rows.Err()
+}
+
+// QueryAll runs the query for every todo of a user that matches the filters of a query, in the order of the query, ignoring its page size.
+// The rows are returned unread, so that the caller can stream them; the caller must close them.
+//
+// @param ctx context.Context - The context of the rows, which carries their deadline.
+// @param ownerId uuid.UUID - The ID of the todos' owner.
+// @param query ListTodosQuery - The filters and the order.
+// @return *sql.Rows - The rows, to be scanned with ScanTodo.
+// @return error - An error if one occurred.
+func (ts *TodoService) QueryAll(ctx context.Context, ownerId uuid.UUID, query ListTodosQuery) (*sql.Rows, error) {
+	// A NULL limit is no limit in PostgreSQL, so the same queries return every todo.
+	return ts.queryTodos(ctx, ownerId, query, nil, 0)
+}
+
+// queryTodos runs the query for the todos of a user that match the filters of a query, choosing the statement by the order.
+//
+// @param ctx context.Context - The context of the query.
+// @param ownerId uuid.UUID - The ID of the todos' owner.
+// @param query ListTodosQuery - The filters and the order.
+// @param limit any - The maximum number of todos, or nil for all of them.
+// @param offset int - The number of todos skipped.
+// @return *sql.Rows - The rows.
+// @return error - An error if one occurred.
+func (ts *TodoService) queryTodos(ctx context.Context, ownerId uuid.UUID, query ListTodosQuery, limit any, offset int) (*sql.Rows, error) {
+	// This selects the query by the order.
+	switch query.Sort {
+	// The creation order is read straight from the index on the UUIDv7 IDs.
+	case "id":
+		return ts.db.QueryContext(ctx, GetTodosByUserByIDQuery, ownerId, query.Completed, query.ListID, query.DueAfter, query.DueBefore, query.HasDueDate, limit, offset)
+	// The reverse creation order is read from the same index backwards.
+	case "-id":
+		return ts.db.QueryContext(ctx, GetTodosByUserByIDDescQuery, ownerId, query.Completed, query.ListID, query.DueAfter, query.DueBefore, query.HasDueDate, limit, offset)
+	}
+	// Any other order is chosen inside the query.
+	return ts.db.QueryContext(ctx, GetTodosByUserQuery, ownerId, query.Completed, query.ListID, query.DueAfter, query.DueBefore, query.HasDueDate, query.Sort, limit, offset)
+}
+
+// CountArchived counts the archived todos of a user, optionally of one list.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param ownerId uuid.UUID - The ID of the todos' owner.
+// @param listId *uuid.UUID - The list, or nil for every list.
+// @return int64 - The number of archived todos.
+// @return error - An error if one occurred.
+func (ts *TodoService) CountArchived(ctx context.Context, ownerId uuid.UUID, listId *uuid.UUID) (int64, error) {
+	// count is the number of archived todos.
+	var count int64
+	// err is the result of counting the archived todos.
+	err := ts.db.QueryRowContext(ctx, CountArchivedTodosQuery, ownerId, listId).Scan(&count)
+	// The count and the error, if any, are returned.
+	return count, err
+}
+
+// ListArchived retrieves a page of the archived todos of a user, most recently completed first.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param ownerId uuid.UUID - The ID of the todos' owner.
+// @param listId *uuid.UUID - The list, or nil for every list.
+// @param limit int - The page size.
+// @param page int - The page number, starting at 1.
+// @return []ArchivedTodo - The archived todos of the page.
+// @return error - An error if one occurred.
+func (ts *TodoService) ListArchived(ctx context.Context, ownerId uuid.UUID, listId *uuid.UUID, limit int, page int) ([]ArchivedTodo, error) {
+	// rows is the result of retrieving the page of archived todos.
+	rows, err := ts.db.QueryContext(ctx, GetArchivedTodosQuery, ownerId, listId, limit, (page-1)*limit)
+	// This checks if an error occurred while querying the database.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// This defers the closing of the rows until the function returns.
+	defer rows.Close()
+
+	// todos is a slice that will hold the retrieved todos.
+	todos := []ArchivedTodo{}
+	// This iterates over the rows.
+	for rows.Next() {
+		// todo is a new ArchivedTodo struct.
+		var todo ArchivedTodo
+		// This scans the row into the todo.
+		if err := rows.Scan(&todo.ID, &todo.Title, &todo.Description, &todo.Priority, &todo.Owner, &todo.ListID, &todo.DueAt, pq.Array(&todo.Tags), &todo.EstimateMinutes, &todo.CreatedAt, &todo.CompletedAt, &todo.ArchivedAt); err != nil {
+			// If an error occurs, it is returned.
+			return nil, err
+		}
+		// The todo is appended to the todos slice.
+		todos = append(todos, todo)
+	}
+
+	// The todos and the error of the iteration, if any, are returned.
+	return todos, rows.Err()
+}
+
+// ListOpen retrieves the first todos of a user that are not completed, in list order.
+// It is used by the chat integrations.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param ownerId uuid.UUID - The ID of the todos' owner.
+// @param limit int - The maximum number of todos.
+// @return []Todo - The open todos.
+// @return error - An error if one occurred.
+func (ts *TodoService) ListOpen(ctx context.Context, ownerId uuid.UUID, limit int) ([]Todo, error) {
+	// completed is the completion status of the todos that are listed.
+	completed := false
+	// The first page of open todos in list order is returned.
+	return ts.List(ctx, ownerId, ListTodosQuery{Sort: "position", Limit: limit, Completed: &completed}, 1)
+}
+
+// Update replaces the fields of a todo of a user.
+// When the input carries a version, the todo is only updated if it is still at that version,
+// so that a client never overwrites a change it has not seen.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param ownerId uuid.UUID - The ID of the current user.
+// @param todoId uuid.UUID - The ID of the todo.
+// @param input TodoInput - The new fields of the todo, and optionally the version they are based on.
+// @return Todo - The updated todo.
+// @return error - ErrEmptyTitle or ErrInvalidPriority if a field is invalid, ErrTodoNotFound or ErrTodoForbidden if the todo cannot be updated,
+// ErrTodoVersionMismatch if it was changed since the given version, or another error if one occurred.
+func (ts *TodoService) Update(ctx context.Context, ownerId uuid.UUID, todoId uuid.UUID, input TodoInput) (Todo, error) {
+	// input is the checked and normalized input.
+	input, err := ts.normalizeInput(input)
+	// This checks if a field is invalid.
+	if err != nil {
+		// If one is, the error is returned.
+		return Todo{}, err
+	}
+
+	// todo is the updated todo.
+	var todo Todo
+	// err is the result of updating the todo and recording the event in one transaction.
+	err = database.WithTx(ctx, ts.db, func(tx *sql.Tx) error {
+		// todo is the result of executing the SQL query to update the todo.
+		var err error
+		todo, err = ScanTodo(tx.QueryRowContext(ctx, UpdateTodoQuery, input.Title, input.Description, input.Priority, input.DueAt, pq.Array(input.Tags), todoId, ownerId, input.Version, input.EstimateMinutes))
+		// This checks if no todo of the user was updated.
+		if err == sql.ErrNoRows {
+			// accessErr is the reason no todo was updated.
+			accessErr := todoAccessError(ctx, tx, todoId, ownerId)
+			// This checks if the todo exists and belongs to the user, so only its version differs.
+			if errors.Is(accessErr, errTodoInOtherState) && input.Version != nil {
+				// If so, ErrTodoVersionMismatch is returned.
+				return ErrTodoVersionMismatch
+			}
+			// Otherwise the reason is returned.
+			return accessErr
+		}
+		// This checks if another error occurred while executing the query.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The event is recorded.
+		return RecordTodoEvent(ctx, tx, outbox.TodoUpdated, todo)
+	})
+	// The updated todo and the error, if any, are returned.
+	return todo, err
+}
+
+// Duplicate copies a todo of a user.
+// The copy keeps the title, description, and priority of the original but starts out not completed.
+// It is placed in the original's list unless another list is given.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param ownerId uuid.UUID - The ID of the current user.
+// @param todoId uuid.UUID - The ID of the todo to be copied.
+// @param listId *uuid.UUID - The optional list to place the copy in.
+// @return Todo - The copy.
+// @return error - ErrTodoNotFound or ErrTodoForbidden if the todo cannot be copied, lists.ErrListNotFound or lists.ErrListForbidden if the list cannot be used,
+// a *quota.ExceededError if the plan limit is reached, or another error if one occurred.
+func (ts *TodoService) Duplicate(ctx context.Context, ownerId uuid.UUID, todoId uuid.UUID, listId *uuid.UUID) (Todo, error) {
+	// targetListId is the optional list to place the copy in.
+	var targetListId uuid.NullUUID
+	// This checks if a target list was given.
+	if listId != nil {
+		// The target list is set to the given list.
+		targetListId = uuid.NullUUID{UUID: *listId, Valid: true}
+	}
+
+	// newTodoId is the new UUID for the copy.
+	newTodoId := ts.ids.NewID()
+
+	// todo is the copy.
+	var todo Todo
+	// err is the result of copying the todo and recording the event in one transaction.
+	err := database.WithTx(ctx, ts.db, func(tx *sql.Tx) error {
+		// This checks that the todo fits in the user's plan.
+		if err := quota.Check(ctx, tx, ts.cfg, ownerId, quota.Todos, 1); err != nil {
+			// If it does not, the error is returned.
+			return err
+		}
+		// todo is the result of executing the SQL query to copy the todo.
+		var err error
+		todo, err = ScanTodo(tx.QueryRowContext(ctx, DuplicateTodoQuery, newTodoId, todoId, targetListId, ownerId))
+		// This checks if no todo of the user was copied.
+		if err == sql.ErrNoRows {
+			// This checks if a target list was given.
+			if targetListId.Valid {
+				// This looks up whether the target list is the reason.
+				if err := lists.ListAccessError(ctx, tx, targetListId.UUID, ownerId); err != nil {
+					// If it is, the reason is returned.
+					return err
+				}
+			}
+			// Otherwise the reason lies with the todo and is returned.
+			return todoAccessError(ctx, tx, todoId, ownerId)
+		}
+		// This checks if another error occurred while executing the query.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The event is recorded.
+		return RecordTodoEvent(ctx, tx, outbox.TodoCreated, todo)
+	})
+	// The copy and the error, if any, are returned.
+	return todo, err
+}
+
+// Move moves several todos of a user into a list at once.
+// Ownership of every todo and of the target list is validated in a single query, and the move is atomic.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param ownerId uuid.UUID - The ID of the current user.
+// @param todoIds []uuid.UUID - The IDs of the todos to be moved.
+// @param listId *uuid.UUID - The target list, or nil to move the todos out of any list.
+// @return error - ErrTodoIdsRequired or ErrTodoIdsNotUnique if the IDs are invalid, ErrTodosNotMovable if a todo or the list
+// does not belong to the user, or another error if one occurred.
+func (ts *TodoService) Move(ctx context.Context, ownerId uuid.UUID, todoIds []uuid.UUID, listId *uuid.UUID) error {
+	// This checks if no todo IDs were given.
+	if len(todoIds) == 0 {
+		// If none were given, an error is returned.
+		return ErrTodoIdsRequired
+	}
+
+	// ids holds the todo IDs as strings so that they can be passed as a PostgreSQL array.
+	ids := make([]string, 0, len(todoIds))
+	// seen tracks the IDs that were already added so that duplicates can be rejected.
+	seen := make(map[uuid.UUID]bool, len(todoIds))
+	// This iterates over the todo IDs.
+	for _, id := range todoIds {
+		// This checks if the ID appears more than once.
+		if seen[id] {
+			// If it does, an error is returned.
+			return ErrTodoIdsNotUnique
+		}
+		// The ID is marked as seen.
+		seen[id] = true
+		// The ID is appended to the todo IDs.
+		ids = append(ids, id.String())
+	}
+
+	// targetListId is the target list, or NULL to move the todos out of any list.
+	var targetListId uuid.NullUUID
+	// This checks if a target list was given.
+	if listId != nil {
+		// The target list is set to the given list.
+		targetListId = uuid.NullUUID{UUID: *listId, Valid: true}
+	}
+
+	// The todos are validated and moved in one transaction.
+	return database.WithTx(ctx, ts.db, func(tx *sql.Tx) error {
+		// count is the number of referenced todos that belong to the user.
+		var count int
+		// listOwned indicates whether the target list belongs to the user.
+		var listOwned bool
+		// err is the result of locking the todos and checking ownership of the todos and the list.
+		if err := tx.QueryRowContext(ctx, CheckTodosMovableQuery, pq.Array(ids), ownerId, targetListId).Scan(&count, &listOwned); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// This checks if any referenced todo or the list does not belong to the user.
+		if count != len(ids) || !listOwned {
+			// If one does not, an error is returned.
+			return ErrTodosNotMovable
+		}
+
+		// _, err is the result of executing the SQL query to move the todos.
+		if _, err := tx.ExecContext(ctx, MoveTodosQuery, pq.Array(ids), targetListId); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// This records an event for every moved todo.
+		for _, id := range todoIds {
+			// This records the event.
+			if err := outbox.Record(ctx, tx, outbox.TodoMoved, ownerId, id, TodoMovedEvent{ID: id, ListID: listId}); err != nil {
+				// If an error occurs, it is returned.
+				return err
+			}
+		}
+		// No error is returned.
+		return nil
+	})
+}
+
+// SnoozeInput holds how long a todo is snoozed for. Exactly one of the fields is set.
+type SnoozeInput struct {
+	// Until is the time the snooze ends.
+	Until *time.Time
+	// Duration is the length of the snooze from now.
+	Duration time.Duration
+}
+
+// Snooze holds back a todo of a user until a later time: its due date is pushed to that time unless it is already later,
+// and its reminder is not sent before then. The previous due date is recorded so that the snooze can be undone within the undo window.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param ownerId uuid.UUID - The ID of the todo's owner.
+// @param todoId uuid.UUID - The ID of the todo.
+// @param input SnoozeInput - The end or the length of the snooze.
+// @return Todo - The snoozed todo.
+// @return TodoActivity - The recorded activity, whose ID is the undo token.
+// @return error - ErrSnoozeTimeRequired or ErrSnoozeNotInFuture if the snooze is invalid, ErrTodoNotFound or ErrTodoForbidden if the todo cannot be snoozed, or another error if one occurred.
+func (ts *TodoService) Snooze(ctx context.Context, ownerId uuid.UUID, todoId uuid.UUID, input SnoozeInput) (Todo, TodoActivity, error) {
+	// This checks if the snooze gives neither or both of its time and duration.
+	if (input.Until == nil) == (input.Duration == 0) {
+		// If it does, an error is returned.
+		return Todo{}, TodoActivity{}, ErrSnoozeTimeRequired
+	}
+	// now is the current time.
+	now := ts.clock.Now()
+	// until is the end of the snooze, from now when a duration is given.
+	until := now.Add(input.Duration)
+	// This checks if the end of the snooze is given directly.
+	if input.Until != nil {
+		// If it is, it is used.
+		until = *input.Until
+	}
+	// This checks if the snooze would already be over.
+	if !until.After(now) {
+		// If it would, an error is returned.
+		return Todo{}, TodoActivity{}, ErrSnoozeNotInFuture
+	}
+
+	// todo is a new Todo struct.
+	var todo Todo
+	// activity is the activity recorded for the snooze.
+	var activity TodoActivity
+
+	// err is the result of snoozing the todo and recording the activity in one transaction.
+	err := database.WithTx(ctx, ts.db, func(tx *sql.Tx) error {
+		// previousDueAt and previousSnoozedUntil are the due date and snooze time before the snooze.
+		var previousDueAt, previousSnoozedUntil sql.NullTime
+		// err is the result of locking the todo and reading its current due date and snooze time.
+		err := tx.QueryRowContext(ctx, GetTodoSnoozeForUpdateQuery, todoId, ownerId).Scan(&previousDueAt, &previousSnoozedUntil)
+		// This checks if the owner has no such todo.
+		if err == sql.ErrNoRows {
+			// If the owner has none, the reason is returned.
+			return todoAccessError(ctx, tx, todoId, ownerId)
+		}
+		// This checks if another error occurred.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// err is the result of executing the SQL query to snooze the todo.
+		todo, err = ScanTodo(tx.QueryRowContext(ctx, SnoozeTodoQuery, until, todoId, ownerId))
+		// This checks if an error occurred while executing the query.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// previous is the state the undo puts back.
+		previous := ActivityPrevious{}
+		// This checks if the todo had a due date.
+		if previousDueAt.Valid {
+			// If it had, it is recorded.
+			previous.DueAt = &previousDueAt.Time
+		}
+		// This checks if the todo was snoozed before.
+		if previousSnoozedUntil.Valid {
+			// If it was, the earlier snooze is recorded.
+			previous.SnoozedUntil = &previousSnoozedUntil.Time
+		}
+		// activity is the result of recording the snooze in the activity log.
+		activity, err = ts.recordTodoActivity(ctx, tx, todoId, ownerId, ActivitySnoozed, previous)
+		// This checks if an error occurred while recording the activity.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The event is recorded.
+		return RecordTodoEvent(ctx, tx, outbox.TodoUpdated, todo)
+	})
+	// The snoozed todo, the activity, and the error, if any, are returned.
+	return todo, activity, err
+}
+
+// Delete soft deletes a todo of a user so that the deletion can be undone within the undo window.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param ownerId uuid.UUID - The ID of the current user.
+// @param todoId uuid.UUID - The ID of the todo.
+// @return TodoActivity - The recorded activity, whose ID is the undo token.
+// @return error - ErrTodoNotFound or ErrTodoForbidden if the todo cannot be deleted, or another error if one occurred.
+func (ts *TodoService) Delete(ctx context.Context, ownerId uuid.UUID, todoId uuid.UUID) (TodoActivity, error) {
+	// activity is the activity recorded for the deletion.
+	var activity TodoActivity
+
+	// err is the result of soft deleting the todo and recording the activity in one transaction.
+	err := database.WithTx(ctx, ts.db, func(tx *sql.Tx) error {
+		// result is the result of executing the SQL query to soft delete the todo.
+		result, err := tx.ExecContext(ctx, DeleteTodoQuery, todoId, ownerId)
+		// This checks if an error occurred while executing the query.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// deleted is the number of deleted todos.
+		deleted, err := result.RowsAffected()
+		// This checks if an error occurred while reading the number.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// This checks if no todo of the user was deleted.
+		if deleted == 0 {
+			// If none was, the reason is returned.
+			return todoAccessError(ctx, tx, todoId, ownerId)
+		}
+
+		// activity is the result of recording the deletion in the activity log.
+		activity, err = ts.recordTodoActivity(ctx, tx, todoId, ownerId, ActivityDeleted, ActivityPrevious{})
+		// This checks if an error occurred while recording the activity.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The event is recorded.
+		return outbox.Record(ctx, tx, outbox.TodoDeleted, ownerId, activity.TodoID, outbox.DeletedData{ID: activity.TodoID})
+	})
+	// The activity and the error, if any, are returned.
+	return activity, err
+}
+
+// SetCompleted updates the completion status of a todo and records the change in the activity log in one transaction.
+// It is shared by the complete endpoint and the chat integrations. Only a todo of the given owner is updated.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param ownerId uuid.UUID - The ID of the todo's owner.
+// @param todoId uuid.UUID - The ID of the todo.
+// @param completed bool - The new completion status.
+// @return Todo - The updated todo.
+// @return TodoActivity - The recorded activity, whose ID is the undo token.
+// @return error - ErrTodoNotFound or ErrTodoForbidden if the todo cannot be updated, or another error if one occurred.
+func (ts *TodoService) SetCompleted(ctx context.Context, ownerId uuid.UUID, todoId uuid.UUID, completed bool) (Todo, TodoActivity, error) {
+	// todo is a new Todo struct.
+	var todo Todo
+	// activity is the activity recorded for the change.
+	var activity TodoActivity
+
+	// err is the result of updating the todo and recording the activity in one transaction.
+	err := database.WithTx(ctx, ts.db, func(tx *sql.Tx) error {
+		// previousCompleted and previousStatus are the completion status and status before the update.
+		var previousCompleted bool
+		var previousStatus string
+		// err is the result of locking the todo and reading its current completion status and status.
+		err := tx.QueryRowContext(ctx, GetTodoStatusForUpdateQuery, todoId, ownerId).Scan(&previousCompleted, &previousStatus)
+		// This checks if the owner has no such todo.
+		if err == sql.ErrNoRows {
+			// If the owner has none, the reason is returned.
+			return todoAccessError(ctx, tx, todoId, ownerId)
+		}
+		// This checks if another error occurred.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// err is the result of executing the SQL query to update the todo's completion status.
+		todo, err = ScanTodo(tx.QueryRowContext(ctx, UpdateTodoCompletedQuery, completed, todoId, ownerId))
+		// This checks if an error occurred while executing the query.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// action is the action recorded in the activity log, and eventType is the matching domain event.
+		action, eventType := ActivityReopened, outbox.TodoReopened
+		// This checks if the todo was marked as completed.
+		if todo.Completed {
+			// If it was, the action is recorded as a completion.
+			action, eventType = ActivityCompleted, outbox.TodoCompleted
+		}
+
+		// activity is the result of recording the change in the activity log.
+		// The status is recorded too, so that undoing a completion puts the todo back in its column rather than in the backlog.
+		activity, err = ts.recordTodoActivity(ctx, tx, todo.ID, ownerId, action, ActivityPrevious{Completed: &previousCompleted, Status: previousStatus})
+		// This checks if an error occurred while recording the activity.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The event is recorded.
+		return RecordTodoEvent(ctx, tx, eventType, todo)
+	})
+
+	// The updated todo, the activity, and the error, if any, are returned.
+	return todo, activity, err
+}
+
+// SetStatus moves a todo to another column of the board and records the change in the activity log.
+// Moving a todo to done completes it and moving it out of done reopens it, with the matching domain event.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param ownerId uuid.UUID - The ID of the todo's owner.
+// @param todoId uuid.UUID - The ID of the todo.
+// @param status string - The new status, one of Statuses.
+// @return Todo - The updated todo.
+// @return TodoActivity - The recorded activity, whose ID is the undo token.
+// @return error - ErrInvalidStatus, ErrTodoNotFound, or ErrTodoForbidden if the todo cannot be updated, or another error if one occurred.
+func (ts *TodoService) SetStatus(ctx context.Context, ownerId uuid.UUID, todoId uuid.UUID, status string) (Todo, TodoActivity, error) {
+	// todo is a new Todo struct.
+	var todo Todo
+	// activity is the activity recorded for the change.
+	var activity TodoActivity
+
+	// This checks if the status is not a column of the board.
+	if !slices.Contains(Statuses, status) {
+		// If it is not, an error is returned.
+		return todo, activity, ErrInvalidStatus
+	}
+
+	// err is the result of updating the todo and recording the activity in one transaction.
+	err := database.WithTx(ctx, ts.db, func(tx *sql.Tx) error {
+		// previousCompleted and previousStatus are the completion status and status before the update.
+		var previousCompleted bool
+		var previousStatus string
+		// err is the result of locking the todo and reading its current completion status and status.
+		err := tx.QueryRowContext(ctx, GetTodoStatusForUpdateQuery, todoId, ownerId).Scan(&previousCompleted, &previousStatus)
+		// This checks if the owner has no such todo.
+		if err == sql.ErrNoRows {
+			// If the owner has none, the reason is returned.
+			return todoAccessError(ctx, tx, todoId, ownerId)
+		}
+		// This checks if another error occurred.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// err is the result of moving the todo to the new status.
+		todo, err = ScanTodo(tx.QueryRowContext(ctx, UpdateTodoStatusQuery, status, todoId, ownerId))
+		// This checks if an error occurred while executing the query.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// activity is the result of recording the change in the activity log.
+		activity, err = ts.recordTodoActivity(ctx, tx, todo.ID, ownerId, ActivityStatusChanged, ActivityPrevious{Completed: &previousCompleted, Status: previousStatus})
+		// This checks if an error occurred while recording the activity.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The event is recorded.
+		return RecordTodoEvent(ctx, tx, statusEvent(previousCompleted, todo), todo)
+	})
+
+	// The updated todo, the activity, and the error, if any, are returned.
+	return todo, activity, err
+}
+
+// statusEvent picks the domain event of a status change: a completion or a reopening when the todo
+// moved into or out of done, and an update when it moved between the other columns.
+//
+// @param previousCompleted bool - Whether the todo was completed before the change.
+// @param todo Todo - The todo after the change.
+// @return string - The outbox event type.
+func statusEvent(previousCompleted bool, todo Todo) string {
+	// This checks how the completion status changed.
+	switch {
+	case todo.Completed && !previousCompleted:
+		// The todo was completed.
+		return outbox.TodoCompleted
+	case !todo.Completed && previousCompleted:
+		// The todo was reopened.
+		return outbox.TodoReopened
+	}
+	// Otherwise it only moved between columns.
+	return outbox.TodoUpdated
+}
+
+// AddBlocker makes a todo of a user wait on another of the user's todos, which then blocks it until it is completed.
+// The link is refused if the blocker already waits on the todo, since neither of them could ever start.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param ownerId uuid.UUID - The ID of the current user.
+// @param todoId uuid.UUID - The ID of the todo that waits.
+// @param blockerId uuid.UUID - The ID of the blocker.
+// @return Todo - The todo, with its blocked flag.
+// @return error - ErrSelfBlocker, ErrTodoNotFound, ErrTodoForbidden, ErrBlockerNotFound, or ErrDependencyCycle if the link cannot be made,
+// or another error if one occurred.
+func (ts *TodoService) AddBlocker(ctx context.Context, ownerId uuid.UUID, todoId uuid.UUID, blockerId uuid.UUID) (Todo, error) {
+	// This checks if the todo would block itself.
+	if todoId == blockerId {
+		// If it would, an error is returned.
+		return Todo{}, ErrSelfBlocker
+	}
+
+	// todo is the todo after the link was made.
+	var todo Todo
+	// err is the result of checking and making the link in one transaction.
+	err := database.WithTx(ctx, ts.db, func(tx *sql.Tx) error {
+		// The links of the user are serialized, so that the cycle check sees every committed link.
+		if _, err := tx.ExecContext(ctx, LockTodoDependenciesQuery, ownerId); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// err is the result of bumping the version of the todo, which also checks that the user owns it.
+		err := tx.QueryRowContext(ctx, TouchTodoQuery, todoId, ownerId).Scan(new(uuid.UUID))
+		// This checks if the owner has no such todo.
+		if err == sql.ErrNoRows {
+			// If the owner has none, the reason is returned.
+			return todoAccessError(ctx, tx, todoId, ownerId)
+		}
+		// This checks if another error occurred.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// blockerOwner is the owner of the blocker.
+		var blockerOwner uuid.UUID
+		// err is the result of querying the database for the owner of the blocker.
+		err = tx.QueryRowContext(ctx, GetTodoUserQuery, blockerId).Scan(&blockerOwner)
+		// This checks if the blocker does not exist or belongs to another user, which are not told apart.
+		if err == sql.ErrNoRows || (err == nil && blockerOwner != ownerId) {
+			// If so, ErrBlockerNotFound is returned.
+			return ErrBlockerNotFound
+		}
+		// This checks if another error occurred.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// cycle is whether the todo is already upstream of the blocker.
+		var cycle bool
+		// This checks the blockers of the blocker, following them transitively.
+		if err := tx.QueryRowContext(ctx, CheckDependencyCycleQuery, blockerId, todoId).Scan(&cycle); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// This checks if the link would close a cycle.
+		if cycle {
+			// If it would, an error is returned.
+			return ErrDependencyCycle
+		}
+
+		// The link is made.
+		if _, err := tx.ExecContext(ctx, CreateTodoDependencyQuery, todoId, blockerId); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// err is the result of reading the todo back with its blocked flag.
+		todo, err = ScanTodo(tx.QueryRowContext(ctx, GetTodoQuery, todoId))
+		// This checks if an error occurred while reading the todo.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The event is recorded.
+		return RecordTodoEvent(ctx, tx, outbox.TodoUpdated, todo)
+	})
+
+	// The todo and the error, if any, are returned.
+	return todo, err
+}
+
+// RemoveBlocker stops a todo of a user from waiting on a blocker.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param ownerId uuid.UUID - The ID of the current user.
+// @param todoId uuid.UUID - The ID of the todo that waits.
+// @param blockerId uuid.UUID - The ID of the blocker.
+// @return Todo - The todo, with its blocked flag.
+// @return error - ErrTodoNotFound or ErrTodoForbidden if the todo cannot be updated, ErrBlockerNotFound if the blocker is not linked to it,
+// or another error if one occurred.
+func (ts *TodoService) RemoveBlocker(ctx context.Context, ownerId uuid.UUID, todoId uuid.UUID, blockerId uuid.UUID) (Todo, error) {
+	// todo is the todo after the link was removed.
+	var todo Todo
+	// err is the result of removing the link in one transaction.
+	err := database.WithTx(ctx, ts.db, func(tx *sql.Tx) error {
+		// err is the result of bumping the version of the todo, which also checks that the user owns it.
+		err := tx.QueryRowContext(ctx, TouchTodoQuery, todoId, ownerId).Scan(new(uuid.UUID))
+		// This checks if the owner has no such todo.
+		if err == sql.ErrNoRows {
+			// If the owner has none, the reason is returned.
+			return todoAccessError(ctx, tx, todoId, ownerId)
+		}
+		// This checks if another error occurred.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// result is the result of removing the link.
+		result, err := tx.ExecContext(ctx, DeleteTodoDependencyQuery, todoId, blockerId)
+		// This checks if an error occurred while executing the query.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// removed is the number of links removed.
+		removed, err := result.RowsAffected()
+		// This checks if an error occurred while reading the number of links.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// This checks if the blocker was not linked to the todo.
+		if removed == 0 {
+			// If it was not, ErrBlockerNotFound is returned, and the version bump is rolled back.
+			return ErrBlockerNotFound
+		}
+
+		// err is the result of reading the todo back with its blocked flag.
+		todo, err = ScanTodo(tx.QueryRowContext(ctx, GetTodoQuery, todoId))
+		// This checks if an error occurred while reading the todo.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The event is recorded.
+		return RecordTodoEvent(ctx, tx, outbox.TodoUpdated, todo)
+	})
+
+	// The todo and the error, if any, are returned.
+	return todo, err
+}
+
+// Blockers retrieves the blockers of a todo of a user, including the completed ones.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param ownerId uuid.UUID - The ID of the current user.
+// @param todoId uuid.UUID - The ID of the todo.
+// @return []Todo - The blockers, in list order.
+// @return error - ErrTodoNotFound or ErrTodoForbidden if the todo cannot be read, or another error if one occurred.
+func (ts *TodoService) Blockers(ctx context.Context, ownerId uuid.UUID, todoId uuid.UUID) ([]Todo, error) {
+	// This checks that the todo exists and belongs to the user.
+	if _, err := ts.Get(ctx, ownerId, todoId); err != nil {
+		// If it does not, the error is returned.
+		return nil, err
+	}
+
+	// rows is the result of retrieving the blockers.
+	rows, err := ts.db.QueryContext(ctx, GetTodoBlockersQuery, todoId, ownerId)
+	// This checks if an error occurred while querying the database.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// This defers the closing of the rows until the function returns.
+	defer rows.Close()
+
+	// blockers is a slice that will hold the blockers.
+	blockers := []Todo{}
+	// This iterates over the rows.
+	for rows.Next() {
+		// blocker is the result of scanning the row.
+		blocker, err := ScanTodo(rows)
+		// This checks if an error occurred while scanning the row.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return nil, err
+		}
+		// The blocker is appended to the blockers slice.
+		blockers = append(blockers, blocker)
+	}
+
+	// The blockers and the error of the iteration, if any, are returned.
+	return blockers, rows.Err()
+}
+
+// Undo reverses a delete, complete, snooze, or status action of a user.
+// The action can only be undone within the configured undo window and only once.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param ownerId uuid.UUID - The ID of the current user.
+// @param activityId uuid.UUID - The undo token, which is the ID of the recorded activity.
+// @return Todo - The restored todo.
+// @return error - ErrNothingToUndo if the token cannot be used, ErrUndoWindowExpired or ErrActionNotUndoable if the action cannot be undone,
+// a *quota.ExceededError if a restored todo would not fit in the user's plan, or another error if one occurred.
+func (ts *TodoService) Undo(ctx context.Context, ownerId uuid.UUID, activityId uuid.UUID) (Todo, error) {
+	// todo is a new Todo struct.
+	var todo Todo
+
+	// err is the result of reversing the action and marking it as undone in one transaction.
+	err := database.WithTx(ctx, ts.db, func(tx *sql.Tx) error {
+		// activity is a new TodoActivity struct.
+		var activity TodoActivity
+		// previous is the raw JSON of the previous state.
+		var previous []byte
+
+		// err is the result of locking the activity that is being undone.
+		err := tx.QueryRowContext(ctx, GetUndoableTodoActivityQuery, activityId, ownerId).Scan(&activity.ID, &activity.TodoID, &activity.Action, &previous, &activity.CreatedAt)
+		// This checks if the token is unknown, belongs to someone else, or was already used.
+		if err == sql.ErrNoRows {
+			// If it is, the nothing to undo error is returned.
+			return ErrNothingToUndo
+		}
+		// This checks if another error occurred while querying the database.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// This checks if the undo window has passed.
+		if ts.clock.Now().Sub(activity.CreatedAt) > ts.cfg.Todo.UndoWindow {
+			// If it has, an error is returned.
+			return ErrUndoWindowExpired
+		}
+
+		// This decodes the previous state of the todo.
+		if err := json.Unmarshal(previous, &activity.Previous); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// eventType is the domain event of the reversal.
+		var eventType string
+		// This reverses the action based on its type.
+		switch activity.Action {
+		// A deletion is reversed by restoring the todo.
+		case ActivityDeleted:
+			// This checks that the restored todo fits in the user's plan, since a deleted todo no longer counts toward it.
+			if err = quota.Check(ctx, tx, ts.cfg, ownerId, quota.Todos, 1); err == nil {
+				// If it does, the todo is restored.
+				todo, err = ScanTodo(tx.QueryRowContext(ctx, RestoreTodoQuery, activity.TodoID, ownerId))
+			}
+			eventType = outbox.TodoRestored
+		// A completion change is reversed by restoring the previous status, or the previous completion status for
+		// activities recorded before todos had a status.
+		case ActivityCompleted, ActivityReopened:
+			// This checks if the previous status was recorded.
+			if activity.Previous.Status != "" {
+				// If it was, the status is restored, which restores the completion status too.
+				todo, err = ScanTodo(tx.QueryRowContext(ctx, UpdateTodoStatusQuery, activity.Previous.Status, activity.TodoID, ownerId))
+			} else {
+				// Otherwise the completion status is restored.
+				todo, err = ScanTodo(tx.QueryRowContext(ctx, UpdateTodoCompletedQuery, activity.Previous.Completed, activity.TodoID, ownerId))
+			}
+			eventType = outbox.TodoReopened
+			// This checks if the todo is completed again.
+			if todo.Completed {
+				// If it is, the reversal is a completion.
+				eventType = outbox.TodoCompleted
+			}
+		// A status change is reversed by restoring the previous status.
+		case ActivityStatusChanged:
+			// completed is the completion status before the reversal, which picks the event.
+			var completed bool
+			// current is the status before the reversal, which is only read to lock the todo.
+			var current string
+			// This locks the todo and reads its completion status.
+			if err = tx.QueryRowContext(ctx, GetTodoStatusForUpdateQuery, activity.TodoID, ownerId).Scan(&completed, &current); err == nil {
+				// The previous status is restored.
+				todo, err = ScanTodo(tx.QueryRowContext(ctx, UpdateTodoStatusQuery, activity.Previous.Status, activity.TodoID, ownerId))
+				eventType = statusEvent(completed, todo)
+			}
+		// A snooze is reversed by restoring the previous due date and snooze time.
+		case ActivitySnoozed:
+			todo, err = ScanTodo(tx.QueryRowContext(ctx, RestoreTodoSnoozeQuery, activity.Previous.DueAt, activity.Previous.SnoozedUntil, activity.TodoID, ownerId))
+			eventType = outbox.TodoUpdated
+		// Any other action cannot be undone.
+		default:
+			err = ErrActionNotUndoable
+		}
+		// This checks if the todo of the action no longer matched.
+		if err == sql.ErrNoRows {
+			// If it did not, there is nothing to undo.
+			return ErrNothingToUndo
+		}
+		// This checks if an error occurred while reversing the action.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// This records the event of the reversal.
+		if err := RecordTodoEvent(ctx, tx, eventType, todo); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// _, err is the result of marking the activity as undone.
+		_, err = tx.ExecContext(ctx, MarkTodoActivityUndoneQuery, activity.ID)
+		// The error, if any, is returned.
+		return err
+	})
+	// The restored todo and the error, if any, are returned.
+	return todo, err
+}
+
+// RecordTodoEvent records a domain event about a todo in the outbox, with the todo as its data.
+// It must be called inside the transaction that changed the todo.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param tx *sql.Tx - The transaction.
+// @param eventType string - One of the outbox event type constants.
+// @param todo Todo - The todo after the change.
+// @return error - An error if one occurred.
+func RecordTodoEvent(ctx context.Context, tx *sql.Tx, eventType string, todo Todo) error {
+	// ownerId is the parsed owner of the todo.
+	ownerId, err := uuid.Parse(todo.Owner)
+	// This checks if the owner is not a valid UUID.
+	if err != nil {
+		// If it is not, the error is returned.
+		return err
+	}
+	// The event is recorded with the todo's response structure as its data.
+	return outbox.Record(ctx, tx, eventType, ownerId, todo.ID, NewTodoResponse(todo))
+}
+
+// recordTodoActivity records an activity on a todo inside the given transaction.
+// It takes a transaction, the todo ID, the owner ID, the action, and the previous state of the todo as input.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param tx *sql.Tx - The transaction.
+// @param todoId uuid.UUID - The ID of the todo.
+// @param ownerId uuid.UUID - The ID of the todo's owner.
+// @param action string - The action that was performed.
+// @param previous ActivityPrevious - The state of the todo before the action.
+// @return TodoActivity - The recorded activity.
+// @return error - An error if one occurred.
+func (ts *TodoService) recordTodoActivity(ctx context.Context, tx *sql.Tx, todoId uuid.UUID, ownerId uuid.UUID, action string, previous ActivityPrevious) (TodoActivity, error) {
+	// activityId is the new UUID for the activity.
+	activityId := ts.ids.NewID()
+
+	// activity is a new TodoActivity struct.
+	activity := TodoActivity{
+		// The ID field is set to the new UUID.
+		ID: activityId,
+		// The TodoID field is set to the todo's ID.
+		TodoID: todoId,
+		// The Action field is set to the performed action.
+		Action: action,
+		// The Previous field is set to the previous state of the todo.
+		Previous: previous,
+	}
+
+	// previousJSON is the previous state encoded as JSON.
+	previousJSON, err := json.Marshal(previous)
+	// This checks if an error occurred while encoding the previous state.
+	if err != nil {
+		// If an error occurs, an empty activity and the error are returned.
+		return TodoActivity{}, err
+	}
+
+	// err is the result of executing the SQL query to record the activity.
+	err = tx.QueryRowContext(ctx, CreateTodoActivityQuery, activity.ID, activity.TodoID, ownerId, activity.Action, previousJSON).Scan(&activity.CreatedAt)
+	// This checks if an error occurred while executing the query.
+	if err != nil {
+		// If an error occurs, an empty activity and the error are returned.
+		return TodoActivity{}, err
+	}
+
+	// The recorded activity and no error are returned.
+	return activity, nil
+}
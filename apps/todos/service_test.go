@@ -0,0 +1,209 @@
+// This file defines tests of the todo service that pin its clock and its IDs, so that the times and IDs it writes are known.
+package todos
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to call the service.
+import (
+	"context"
+	// "database/sql/driver" defines the interfaces of SQL drivers. It is used here to build the rows of the fake database.
+	"database/sql/driver"
+	// "errors" provides functions for working with errors. It is used here to check the errors of the service.
+	"errors"
+	// "sync" provides synchronization primitives. It is used here to guard the arguments the fake database saw.
+	"sync"
+	// "testing" provides support for automated tests. It is used here to run the tests.
+	"testing"
+	// "time" provides functionality for measuring and displaying time. It is used here for the fixed time of the clock.
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here for the IDs the service is given.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that manages users. It is used here for the owner of the todos.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time. It is used here to fix it.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/content" is a local package that checks text. It is used here to check the title.
+	"github.com/rahulcodepython/todo-backend/backend/content"
+	// "github.com/rahulcodepython/todo-backend/backend/database/dbtest" is a local package that provides a fake database.
+	"github.com/rahulcodepython/todo-backend/backend/database/dbtest"
+	// "github.com/rahulcodepython/todo-backend/backend/idgen" is a local package that creates IDs. It is used here to give out known IDs.
+	"github.com/rahulcodepython/todo-backend/backend/idgen"
+	// "github.com/rahulcodepython/todo-backend/backend/outbox" is a local package that records domain events. It is used here for its query.
+	"github.com/rahulcodepython/todo-backend/backend/outbox"
+	// "github.com/rahulcodepython/todo-backend/backend/quota" is a local package that enforces plan limits. It is used here for its queries.
+	"github.com/rahulcodepython/todo-backend/backend/quota"
+)
+
+// TestCreateFromTextUsesClockAndIDs checks that a quick-add line is resolved against the time of the service clock in the
+// user's time zone, and that the todo is written with the ID of the service ID generator and the time of the clock.
+//
+// @param t *testing.T - The test state.
+func TestCreateFromTextUsesClockAndIDs(t *testing.T) {
+	// location is the time zone of the user.
+	location, err := time.LoadLocation("America/New_York")
+	// This checks if the time zone is unavailable.
+	if err != nil {
+		// If it is, the test is skipped.
+		t.Skip(err)
+	}
+	// owner is the user, who lives in New York.
+	owner := users.User{ID: uuid.New(), Timezone: location.String()}
+	// now is the time of the clock: late on March 8 in New York, already March 9 in UTC.
+	now := time.Date(2025, 3, 9, 3, 30, 0, 0, time.UTC)
+	// id is the ID the generator gives out.
+	id := uuid.MustParse("0195741c-9b00-7000-8000-000000000001")
+
+	// mu guards the arguments of the insert.
+	var mu sync.Mutex
+	// inserted are the arguments of the insert of the todo.
+	var inserted []driver.NamedValue
+	// fake is the fake database.
+	fake := dbtest.NewDriver()
+	// The user is on the free plan.
+	fake.Handle(quota.LockUserPlanQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		return dbtest.Rows{Columns: []string{"plan"}, Values: [][]driver.Value{{"free"}}}, nil
+	})
+	// The todo is inserted and its version read back.
+	fake.Handle(CreateTodoQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		inserted = args
+		return dbtest.Rows{Columns: []string{"version"}, Values: [][]driver.Value{{int64(1)}}}, nil
+	})
+	// The event is recorded.
+	fake.Handle(outbox.CreateEventQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		return dbtest.Rows{RowsAffected: 1}, nil
+	})
+	// db is the fake database.
+	db := dbtest.OpenDB(fake)
+	t.Cleanup(func() { db.Close() })
+
+	// cfg is the configuration, of which only the lengths of the title and description are read.
+	cfg := &config.Config{Content: config.ContentConfig{TitleMaxLength: 255, DescriptionMaxLength: 1000}}
+	// service is the todo service with the fixed clock and the sequence of IDs.
+	service := NewTodoService(cfg, db, clock.Fixed(now), &idgen.Sequence{IDs: []uuid.UUID{id}}, content.NewValidator(cfg, content.NewWordFilter(nil)))
+	// todo is the todo created from the line.
+	todo, err := service.CreateFromText(context.Background(), owner, "Pay rent tomorrow at 9am #home")
+	// This checks if the todo could not be created.
+	if err != nil {
+		// If it could not, the test fails.
+		t.Fatal(err)
+	}
+
+	// due is the due date of the line: 9am on the day after March 8 in New York.
+	due := time.Date(2025, 3, 9, 9, 0, 0, 0, location)
+	// This checks if the todo does not have the ID, the time, and the due date.
+	if todo.ID != id || !todo.CreatedAt.Equal(now) || !todo.DueAt.Valid || !todo.DueAt.Time.Equal(due) || todo.Title != "Pay rent" {
+		// If it does not, the test fails.
+		t.Fatalf("created %+v, want ID %s, created at %s, due %s", todo, id, now, due)
+	}
+	// This checks if the insert was not given the ID and the time.
+	if len(inserted) < 7 || inserted[0].Value != id.String() || !inserted[6].Value.(time.Time).Equal(now) {
+		// If it was not, the test fails.
+		t.Errorf("inserted with %v, want the ID %s and the time %s", inserted, id, now)
+	}
+}
+
+// TestUndoWindow checks that a delete is only undone within the undo window of the service clock, and that restoring the todo
+// is refused when it would take the user over the todo limit of their plan.
+//
+// @param t *testing.T - The test state.
+func TestUndoWindow(t *testing.T) {
+	// owner is the user who deleted the todo.
+	owner := users.User{ID: uuid.New(), Timezone: "UTC"}
+	// now is the time of the clock.
+	now := time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC)
+	// window is the undo window.
+	window := 30 * time.Second
+	// activityId and todoId are the IDs of the delete and of the todo.
+	activityId, todoId := uuid.New(), uuid.New()
+
+	// tests are the cases: how long before now the todo was deleted, how many todos the user keeps, and the error of the undo.
+	tests := []struct {
+		// name is the name of the case.
+		name string
+		// ago is how long before now the todo was deleted.
+		ago time.Duration
+		// todos is the number of todos the user keeps.
+		todos int64
+		// want is the error of the undo, or nil.
+		want error
+	}{
+		{"within the window", window - time.Second, 0, nil},
+		{"after the window", window + time.Second, 0, ErrUndoWindowExpired},
+		{"over the limit", window - time.Second, 1, &quota.ExceededError{}},
+	}
+
+	// This runs every case.
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// restored reports whether the todo was restored.
+			var restored bool
+			// fake is the fake database.
+			fake := dbtest.NewDriver()
+			// The delete was recorded a while before now.
+			fake.Handle(GetUndoableTodoActivityQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+				return dbtest.Rows{
+					Columns: []string{"id", "todo_id", "action", "previous", "created_at"},
+					Values:  [][]driver.Value{{activityId.String(), todoId.String(), ActivityDeleted, []byte("{}"), now.Add(-tt.ago)}},
+				}, nil
+			})
+			// The user is on the free plan.
+			fake.Handle(quota.LockUserPlanQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+				return dbtest.Rows{Columns: []string{"plan"}, Values: [][]driver.Value{{"free"}}}, nil
+			})
+			// The user keeps the todos of the case.
+			fake.Handle(quota.CountTodosQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+				return dbtest.Rows{Columns: []string{"count"}, Values: [][]driver.Value{{tt.todos}}}, nil
+			})
+			// The todo is restored.
+			fake.Handle(RestoreTodoQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+				restored = true
+				return dbtest.Rows{Columns: todoColumns, Values: [][]driver.Value{{
+					todoId.String(), "Pay rent", "", PriorityNone, false, owner.ID.String(), now, nil, int64(0), nil, []byte("{}"), int64(2), nil, now, nil, nil, "todo", false,
+				}}}, nil
+			})
+			// The event is recorded.
+			fake.Handle(outbox.CreateEventQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+				return dbtest.Rows{RowsAffected: 1}, nil
+			})
+			// The delete is marked as undone.
+			fake.Handle(MarkTodoActivityUndoneQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+				return dbtest.Rows{RowsAffected: 1}, nil
+			})
+			// db is the fake database.
+			db := dbtest.OpenDB(fake)
+			defer db.Close()
+
+			// cfg is the configuration, with the undo window and a free plan of one todo.
+			cfg := &config.Config{Todo: config.TodoConfig{UndoWindow: window}, Quota: config.QuotaConfig{Free: config.PlanLimits{MaxTodos: 1}}}
+			// service is the todo service with the fixed clock.
+			service := NewTodoService(cfg, db, clock.Fixed(now), &idgen.Sequence{}, nil)
+			// todo is the restored todo.
+			todo, err := service.Undo(context.Background(), owner.ID, activityId)
+
+			// This checks the error against the one of the case.
+			switch want := tt.want.(type) {
+			case nil:
+				// This checks if the todo was not restored.
+				if err != nil || !restored || todo.ID != todoId {
+					// If it was not, the test fails.
+					t.Fatalf("Undo = %+v, %v, want the restored todo", todo, err)
+				}
+			case *quota.ExceededError:
+				// This checks if the limit was not reported, or the todo restored anyway.
+				if !errors.As(err, &want) || restored {
+					// If it was not, the test fails.
+					t.Fatalf("Undo = %v, restored = %v, want a *quota.ExceededError and no restore", err, restored)
+				}
+			default:
+				// This checks if the error is not the one of the case, or the todo was restored anyway.
+				if !errors.Is(err, tt.want) || restored {
+					// If it is not, the test fails.
+					t.Fatalf("Undo = %v, restored = %v, want %v and no restore", err, restored, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -1,37 +1,159 @@
 // This file defines the SQL queries used for todo-related database operations.
 package todos
 
-// "fmt" provides functions for formatted I/O. It is used here to construct the SQL queries.
-import (
-	"fmt"
+// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names and schemas.
+import "github.com/rahulcodepython/todo-backend/backend/utils"
 
-	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names and schemas.
-	"github.com/rahulcodepython/todo-backend/backend/utils"
-)
+// CreateTodoQuery is the SQL query to insert a new todo into the database with its estimated effort.
+const CreateTodoQuery = "INSERT INTO " + utils.TodoTableName + " (" + utils.TodoTableSchema + ", estimate_minutes) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING version"
 
-// CreateTodoQuery is the SQL query to insert a new todo into the database.
-var CreateTodoQuery = fmt.Sprintf("INSERT INTO %s (%s) VALUES ($1, $2, $3, $4, $5)", utils.TodoTableName, utils.TodoTableSchema)
+// notInArchivedList is the condition that a todo is not in an archived list, which hides it from the default views.
+const notInArchivedList = "(list_id IS NULL OR NOT EXISTS (SELECT 1 FROM " + utils.ListTableName + " AS l WHERE l.id = list_id AND l.archived_at IS NOT NULL))"
 
-// GetTodosByUserQuery is the SQL query to retrieve all todos for a specific user.
-var GetTodosByUserQuery = fmt.Sprintf("SELECT %s FROM %s WHERE owner = $1 LIMIT $2 OFFSET $3", utils.TodoTableSchema, utils.TodoTableName)
+// todosByUserFilter is the WHERE clause shared by the queries that list and count the todos of a user.
+// The todos are optionally filtered by completion status, list, a due date range from $4 (inclusive) to $5 (exclusive), and whether they have a due date at all as $6.
+// A NULL completion status, list ID, bound, or due date flag disables the corresponding filter.
+// Without a list filter, the todos of archived lists are left out; asking for an archived list by its ID still shows them.
+const todosByUserFilter = "owner = $1 AND ($2::boolean IS NULL OR completed = $2) AND ($3::uuid IS NULL OR list_id = $3) AND ($4::timestamptz IS NULL OR due_at >= $4) AND ($5::timestamptz IS NULL OR due_at < $5) AND ($6::boolean IS NULL OR (due_at IS NOT NULL) = $6) AND deleted_at IS NULL AND ($3::uuid IS NOT NULL OR " + notInArchivedList + ")"
 
-// GetTodosByUserFilteredByCompletedQuery is the SQL query to retrieve all todos for a specific user, filtered by completion status.
-var GetTodosByUserFilteredByCompletedQuery = fmt.Sprintf("SELECT %s FROM %s WHERE owner = $1 AND completed = $2 LIMIT $3 OFFSET $4", utils.TodoTableSchema, utils.TodoTableName)
+// GetOpenTodoByTitleQuery is the SQL query to find the oldest open todo of a user in a list with the same title,
+// compared case-insensitively and with runs of whitespace collapsed. The title expression is the one of idx_todos_open_title.
+const GetOpenTodoByTitleQuery = `SELECT ` + utils.TodoSelectSchema + ` FROM ` + utils.TodoTableName + ` WHERE owner = $1 AND list_id IS NOT DISTINCT FROM $2::uuid AND completed = FALSE AND deleted_at IS NULL AND lower(regexp_replace(btrim(title), '\s+', ' ', 'g')) = lower(regexp_replace(btrim($3), '\s+', ' ', 'g')) ORDER BY created_at LIMIT 1`
 
-// UpdateTodoTitleQuery is the SQL query to update the title of a todo.
-var UpdateTodoTitleQuery = fmt.Sprintf("UPDATE %s SET title = $1 WHERE id = $2 returning %s", utils.TodoTableName, utils.TodoTableSchema)
+// GetTodoQuery is the SQL query to retrieve a todo that has not been deleted, whoever owns it.
+const GetTodoQuery = "SELECT " + utils.TodoSelectSchema + " FROM " + utils.TodoTableName + " WHERE id = $1 AND deleted_at IS NULL"
 
-// UpdateTodoCompletedQuery is the SQL query to update the completion status of a todo.
-var UpdateTodoCompletedQuery = fmt.Sprintf("UPDATE %s SET completed = $1 WHERE id = $2 returning %s", utils.TodoTableName, utils.TodoTableSchema)
+// GetTodosByUserQuery is the SQL query to retrieve a page of the todos of a specific user, filtered like todosByUserFilter.
+// The todos are sorted by the sort parameter given as $7, then by list order. Todos without a due date come last when sorting by due date.
+const GetTodosByUserQuery = "SELECT " + utils.TodoSelectSchema + " FROM " + utils.TodoTableName + " WHERE " + todosByUserFilter + " ORDER BY " +
+	"CASE WHEN $7 = 'created_at' THEN created_at END, CASE WHEN $7 = '-created_at' THEN created_at END DESC, " +
+	"CASE WHEN $7 = 'updated_at' THEN updated_at END, CASE WHEN $7 = '-updated_at' THEN updated_at END DESC, " +
+	"CASE WHEN $7 = 'due_at' THEN due_at END, CASE WHEN $7 = '-due_at' THEN due_at END DESC NULLS LAST, " +
+	"CASE WHEN $7 = 'title' THEN title END, CASE WHEN $7 = '-title' THEN title END DESC, " +
+	"position, id LIMIT $8 OFFSET $9"
 
-// DeleteTodoQuery is the SQL query to delete a todo.
-var DeleteTodoQuery = fmt.Sprintf("DELETE FROM %s WHERE id = $1", utils.TodoTableName)
+// GetTodosByUserByIDQuery is the SQL query to retrieve a page of the todos of a specific user in creation order, filtered like todosByUserFilter.
+// Todo IDs are UUIDv7, so a plain ORDER BY id is a creation-time sort that the idx_todos_owner_id index serves without sorting.
+const GetTodosByUserByIDQuery = "SELECT " + utils.TodoSelectSchema + " FROM " + utils.TodoTableName + " WHERE " + todosByUserFilter + " ORDER BY id LIMIT $7 OFFSET $8"
+
+// GetTodosByUserByIDDescQuery is GetTodosByUserByIDQuery with the newest todos first.
+const GetTodosByUserByIDDescQuery = "SELECT " + utils.TodoSelectSchema + " FROM " + utils.TodoTableName + " WHERE " + todosByUserFilter + " ORDER BY id DESC LIMIT $7 OFFSET $8"
+
+// UpdateTodoQuery is the SQL query to update the title, description, priority, due date, tags, and estimated effort of a todo.
+// Changing the due date clears reminded_at so that a reminder is sent for the new date, and snoozed_until, since the new date replaces the snooze.
+// Only a todo of the user given as $7 is updated, and only at the version given as $8 unless it is null, so that no row is returned for any other todo.
+const UpdateTodoQuery = "UPDATE " + utils.TodoTableName + " SET title = $1, description = $2, priority = $3, due_at = $4, tags = $5, estimate_minutes = $9, reminded_at = CASE WHEN due_at IS DISTINCT FROM $4 THEN NULL ELSE reminded_at END, snoozed_until = CASE WHEN due_at IS DISTINCT FROM $4 THEN NULL ELSE snoozed_until END WHERE id = $6 AND owner = $7 AND deleted_at IS NULL AND ($8::bigint IS NULL OR version = $8) returning " + utils.TodoSelectSchema
+
+// UpdateTodoCompletedQuery is the SQL query to update the completion status of a todo. The database moves the status along with it.
+const UpdateTodoCompletedQuery = "UPDATE " + utils.TodoTableName + " SET completed = $1 WHERE id = $2 AND owner = $3 AND deleted_at IS NULL returning " + utils.TodoSelectSchema
+
+// DeleteTodoQuery is the SQL query to soft delete a todo of the user given as $2.
+const DeleteTodoQuery = "UPDATE " + utils.TodoTableName + " SET deleted_at = NOW() WHERE id = $1 AND owner = $2 AND deleted_at IS NULL"
+
+// DuplicateTodoQuery is the SQL query to copy a todo into a new, not completed todo.
+// The copy is placed in the list given as $3, or in the original's list if $3 is NULL. Only a todo of the user given as $4 is copied, and only into a list of that user.
+const DuplicateTodoQuery = "INSERT INTO " + utils.TodoTableName + " (" + utils.TodoTableSchema + ", estimate_minutes) SELECT $1, title, description, priority, FALSE, owner, NOW(), COALESCE($3::uuid, list_id), 0, NULL, tags, estimate_minutes FROM " + utils.TodoTableName + " WHERE id = $2 AND owner = $4 AND deleted_at IS NULL AND ($3::uuid IS NULL OR EXISTS (SELECT 1 FROM " + utils.ListTableName + " WHERE id = $3 AND owner = $4 AND deleted_at IS NULL)) returning " + utils.TodoSelectSchema
+
+// RestoreTodoQuery is the SQL query to restore a soft deleted todo of a user.
+const RestoreTodoQuery = "UPDATE " + utils.TodoTableName + " SET deleted_at = NULL WHERE id = $1 AND owner = $2 AND deleted_at IS NOT NULL returning " + utils.TodoSelectSchema
+
+// UpdateTodoStatusQuery is the SQL query to move a todo to another status. The database completes or reopens it along with it.
+const UpdateTodoStatusQuery = "UPDATE " + utils.TodoTableName + " SET status = $1 WHERE id = $2 AND owner = $3 AND deleted_at IS NULL returning " + utils.TodoSelectSchema
+
+// GetTodoStatusForUpdateQuery is the SQL query to retrieve and lock the completion status and status of a todo of the user given as $2.
+const GetTodoStatusForUpdateQuery = "SELECT completed, status FROM " + utils.TodoTableName + " WHERE id = $1 AND owner = $2 AND deleted_at IS NULL FOR UPDATE"
+
+// GetTodoSnoozeForUpdateQuery is the SQL query to retrieve and lock the due date and snooze time of a todo of the user given as $2.
+const GetTodoSnoozeForUpdateQuery = "SELECT due_at, snoozed_until FROM " + utils.TodoTableName + " WHERE id = $1 AND owner = $2 AND deleted_at IS NULL FOR UPDATE"
+
+// SnoozeTodoQuery is the SQL query to snooze a todo until $1. The due date is pushed to that time unless it is already later,
+// and reminded_at is cleared so that the reminder is sent again once the snooze is over.
+const SnoozeTodoQuery = "UPDATE " + utils.TodoTableName + " SET snoozed_until = $1, due_at = GREATEST(due_at, $1), reminded_at = NULL WHERE id = $2 AND owner = $3 AND deleted_at IS NULL returning " + utils.TodoSelectSchema
+
+// RestoreTodoSnoozeQuery is the SQL query to put back the due date and snooze time a todo had before it was snoozed.
+const RestoreTodoSnoozeQuery = "UPDATE " + utils.TodoTableName + " SET due_at = $1, snoozed_until = $2, reminded_at = CASE WHEN due_at IS DISTINCT FROM $1 THEN NULL ELSE reminded_at END WHERE id = $3 AND owner = $4 AND deleted_at IS NULL returning " + utils.TodoSelectSchema
+
+// GetPlannedTodosQuery is the SQL query to retrieve the due dates and estimates of the open todos of a user due from $2 (inclusive) to $3 (exclusive).
+// The todos of archived lists are not planned.
+const GetPlannedTodosQuery = "SELECT due_at, estimate_minutes FROM " + utils.TodoTableName + " WHERE owner = $1 AND completed = FALSE AND deleted_at IS NULL AND due_at >= $2 AND due_at < $3 AND " + notInArchivedList
 
 // GetTodoUserQuery is the SQL query to retrieve the owner of a todo.
-var GetTodoUserQuery = fmt.Sprintf("SELECT owner FROM %s WHERE id = $1", utils.TodoTableName)
+// It is only used to explain why a statement scoped to the user's todos matched nothing.
+const GetTodoUserQuery = "SELECT owner FROM " + utils.TodoTableName + " WHERE id = $1 AND deleted_at IS NULL"
+
+// GetBoardTodosQuery is the SQL query to retrieve the todos of a user grouped by status, up to $3 todos per status in list order,
+// each with the number of todos of its status. The list filter is $2; without one, the todos of archived lists are left out.
+const GetBoardTodosQuery = "SELECT " + utils.TodoSelectSchema + ", status_count FROM (SELECT " + utils.TodoSelectSchema + ", COUNT(*) OVER (PARTITION BY status) AS status_count, ROW_NUMBER() OVER (PARTITION BY status ORDER BY position, id) AS status_rank FROM " + utils.TodoTableName + " WHERE owner = $1 AND deleted_at IS NULL AND ($2::uuid IS NULL OR list_id = $2) AND ($2::uuid IS NOT NULL OR " + notInArchivedList + ")) AS t WHERE status_rank <= $3 ORDER BY status, status_rank"
+
+// LockTodoDependenciesQuery is the SQL query to take the transaction-level advisory lock of the dependencies of a user's todos.
+// Two links that close a cycle together would each pass the cycle check alone, so links of the same user are made one at a time.
+const LockTodoDependenciesQuery = "SELECT pg_advisory_xact_lock(hashtext('todo_dependencies:' || $1::text))"
+
+// TouchTodoQuery is the SQL query to bump the version of a todo whose blockers changed, so that syncing clients read it again.
+// None of its columns change, so the version is set to the next one explicitly rather than left to the trigger, which ignores such updates.
+const TouchTodoQuery = "UPDATE " + utils.TodoTableName + " SET version = nextval('change_seq') WHERE id = $1 AND owner = $2 AND deleted_at IS NULL RETURNING id"
+
+// CheckDependencyCycleQuery is the SQL query to check if the todo $2 is already among the blockers of $1, directly or through other blockers.
+// If it is, making $1 a blocker of $2 would close a cycle in which no todo could ever start.
+const CheckDependencyCycleQuery = `WITH RECURSIVE upstream(id) AS (
+		SELECT blocker_id FROM ` + utils.TodoDependencyTableName + ` WHERE todo_id = $1
+		UNION
+		SELECT d.blocker_id FROM ` + utils.TodoDependencyTableName + ` AS d JOIN upstream AS u ON d.todo_id = u.id
+	)
+	SELECT EXISTS (SELECT 1 FROM upstream WHERE id = $2)`
+
+// CreateTodoDependencyQuery is the SQL query to make a todo wait on a blocker. Linking the same blocker twice is a no-op.
+const CreateTodoDependencyQuery = "INSERT INTO " + utils.TodoDependencyTableName + " (" + utils.TodoDependencyTableSchema + ") VALUES ($1, $2) ON CONFLICT DO NOTHING"
+
+// DeleteTodoDependencyQuery is the SQL query to stop a todo from waiting on a blocker.
+const DeleteTodoDependencyQuery = "DELETE FROM " + utils.TodoDependencyTableName + " WHERE todo_id = $1 AND blocker_id = $2"
+
+// GetTodoBlockersQuery is the SQL query to retrieve the blockers of a todo, completed or not, in list order.
+const GetTodoBlockersQuery = "SELECT " + utils.TodoSelectSchema + " FROM " + utils.TodoTableName + " WHERE id IN (SELECT blocker_id FROM " + utils.TodoDependencyTableName + " WHERE todo_id = $1) AND owner = $2 AND deleted_at IS NULL ORDER BY position, id"
+
+// CountTodosByUserQuery is the SQL query to count the todos of a specific user, filtered like todosByUserFilter.
+const CountTodosByUserQuery = "SELECT COUNT(*) FROM " + utils.TodoTableName + " WHERE " + todosByUserFilter
+
+// CountTodosByUserApproxQuery is the SQL query to read the number of todos of a specific user from the counts the database keeps, without scanning the todos.
+// It takes the owner, completion status, and list filters of todosByUserFilter as $1 to $3, and leaves out the todos of archived lists in the same way. It cannot filter by due date.
+const CountTodosByUserApproxQuery = "SELECT COALESCE(SUM(count), 0) FROM " + utils.TodoCountTableName + " AS c WHERE owner = $1 AND ($2::boolean IS NULL OR completed = $2) AND ($3::uuid IS NULL OR list_id = $3) AND ($3::uuid IS NOT NULL OR NOT EXISTS (SELECT 1 FROM " + utils.ListTableName + " AS l WHERE l.id = c.list_id AND l.archived_at IS NOT NULL))"
+
+// CreateIdempotencyKeyQuery is the SQL query to remember the Idempotency-Key a todo was created with.
+const CreateIdempotencyKeyQuery = "INSERT INTO " + utils.TodoIdempotencyKeyTableName + " (" + utils.TodoIdempotencyKeyTableSchema + ") VALUES ($1, $2, $3)"
+
+// CreateTodoActivityQuery is the SQL query to record an activity on a todo.
+const CreateTodoActivityQuery = "INSERT INTO " + utils.TodoActivityTableName + " (" + utils.TodoActivityTableSchema + ") VALUES ($1, $2, $3, $4, $5) RETURNING created_at"
+
+// GetUndoableTodoActivityQuery is the SQL query to retrieve and lock an activity that has not been undone yet.
+const GetUndoableTodoActivityQuery = "SELECT id, todo_id, action, previous, created_at FROM " + utils.TodoActivityTableName + " WHERE id = $1 AND owner = $2 AND undone_at IS NULL FOR UPDATE"
+
+// MarkTodoActivityUndoneQuery is the SQL query to mark an activity as undone.
+const MarkTodoActivityUndoneQuery = "UPDATE " + utils.TodoActivityTableName + " SET undone_at = NOW() WHERE id = $1"
+
+// CheckTodosMovableQuery is the SQL query to lock and count the todos among the given IDs that belong to the user,
+// and to check that the target list, if any, belongs to the user too.
+const CheckTodosMovableQuery = "SELECT (SELECT COUNT(*) FROM (SELECT id FROM " + utils.TodoTableName + " WHERE id = ANY($1::uuid[]) AND owner = $2 AND deleted_at IS NULL FOR UPDATE) AS locked), ($3::uuid IS NULL OR EXISTS (SELECT 1 FROM " + utils.ListTableName + " WHERE id = $3 AND owner = $2 AND deleted_at IS NULL))"
+
+// MoveTodosQuery is the SQL query to move todos into a list, appending them after its last todo in the given order.
+const MoveTodosQuery = "UPDATE " + utils.TodoTableName + " AS t SET list_id = $2, position = COALESCE((SELECT MAX(position) FROM " + utils.TodoTableName + " WHERE list_id IS NOT DISTINCT FROM $2::uuid AND deleted_at IS NULL), 0) + o.ordinality FROM unnest($1::uuid[]) WITH ORDINALITY AS o(id, ordinality) WHERE t.id = o.id"
+
+// ArchiveTodosQuery is the SQL query to move a batch of the todos completed and unchanged since before $1 into the archive, at most $2 of them.
+// The todos are deleted, archived, and given a tombstone in one statement, so a todo is never in both tables or in neither, syncing clients
+// are told it is gone, and rows locked by a request are left for the next run. The count of the statement is the number of tombstones, one per todo.
+// Deleting them removes their activities, blockers, and idempotency keys with them.
+const ArchiveTodosQuery = `WITH moved AS (
+	DELETE FROM ` + utils.TodoTableName + ` WHERE id IN (SELECT id FROM ` + utils.TodoTableName + ` WHERE completed AND deleted_at IS NULL AND updated_at < $1 ORDER BY updated_at LIMIT $2 FOR UPDATE SKIP LOCKED)
+	RETURNING id, title, description, priority, owner, list_id, due_at, tags, estimate_minutes, created_at, updated_at, ical_uid
+), archived AS (
+	INSERT INTO ` + utils.TodoArchiveTableName + ` (` + utils.TodoArchiveTableSchema + `) SELECT id, title, description, priority, owner, list_id, due_at, tags, estimate_minutes, created_at, updated_at FROM moved
+) INSERT INTO ` + utils.TodoTombstoneTableName + ` (` + utils.TodoTombstoneTableSchema + `) SELECT id, owner, COALESCE(ical_uid, id::text) FROM moved
+ON CONFLICT (id) DO UPDATE SET owner = EXCLUDED.owner, name = EXCLUDED.name, version = nextval('change_seq'), change_xid = pg_current_xact_id()::text::bigint, deleted_at = NOW()`
+
+// archivedTodosFilter is the WHERE clause shared by the queries that list and count the archived todos of a user, optionally of one list as $2.
+const archivedTodosFilter = "owner = $1 AND ($2::uuid IS NULL OR list_id = $2)"
 
-// CountTodosByUserQuery is the SQL query to count all todos for a specific user.
-var CountTodosByUserQuery = fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE owner = $1", utils.TodoTableName)
+// CountArchivedTodosQuery is the SQL query to count the archived todos of a user.
+const CountArchivedTodosQuery = "SELECT COUNT(*) FROM " + utils.TodoArchiveTableName + " WHERE " + archivedTodosFilter
 
-// CountTodosByUserFilteredByCompletedQuery is the SQL query to count all todos for a specific user, filtered by completion status.
-var CountTodosByUserFilteredByCompletedQuery = fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE owner = $1 AND completed = $2", utils.TodoTableName)
\ No newline at end of file
+// GetArchivedTodosQuery is the SQL query to retrieve a page of the archived todos of a user, most recently completed first.
+const GetArchivedTodosQuery = "SELECT " + utils.TodoArchiveTableSchema + ", archived_at FROM " + utils.TodoArchiveTableName + " WHERE " + archivedTodosFilter + " ORDER BY completed_at DESC, id DESC LIMIT $3 OFFSET $4"
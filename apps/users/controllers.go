@@ -1,89 +1,206 @@
 // This file defines the controllers for user-related operations.
 package users
 
-// "database/sql" provides a generic SQL interface. It is used here to interact with the database.
+// "errors" provides functions for working with errors. It is used here to compare the service errors.
 import (
-	"database/sql"
-	// "log" provides a simple logging package. It is used here to log fatal errors.
-	"log"
-	// "time" provides functions for working with time. It is used here to set timestamps.
+	"errors"
+	// "time" provides functions for working with time. It is used here to tell a device how long its codes work for.
 	"time"
 
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to parse the ID of an API key.
+	"github.com/google/uuid"
 	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to define the controllers.
 	"github.com/gofiber/fiber/v2"
-	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to generate new UUIDs.
-	"github.com/google/uuid"
-	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
-	"github.com/rahulcodepython/todo-backend/backend/config"
 	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
 	"github.com/rahulcodepython/todo-backend/backend/response"
 	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions.
 	"github.com/rahulcodepython/todo-backend/backend/utils"
 )
 
-// UserControl is a struct that holds the configuration and database connection.
+// UserControl is a struct that holds the user service.
+// It only translates between HTTP and the service, which holds the business logic.
 type UserControl struct {
-	// cfg is the application configuration.
-	cfg *config.Config
-	// db is the database connection.
-	db *sql.DB
+	// service is the user service.
+	service *UserService
 }
 
 // NewUserControl creates a new UserControl.
-// It takes the application configuration and database connection as input.
+// It takes the user service as input.
 //
-// @param cfg *config.Config - The application configuration.
-// @param db *sql.DB - The database connection.
+// @param service *UserService - The user service.
 // @return *UserControl - A pointer to the new UserControl.
-func NewUserControl(cfg *config.Config, db *sql.DB) *UserControl {
-	// This checks if the database connection is nil.
-	if db == nil {
-		// If the database connection is nil, a fatal error is logged.
-		log.Fatal("Database connection is nil in NewUserControl!")
-	}
+func NewUserControl(service *UserService) *UserControl {
 	// A new UserControl is returned.
 	return &UserControl{
-		// The cfg field is set to the application configuration.
-		cfg: cfg,
-		// The db field is set to the database connection.
-		db: db,
+		// The service field is set to the user service.
+		service: service,
 	}
 }
 
-// CreateNewJWTAndUpdateUser creates a new JWT and updates the user's row with the new JWT.
-// It takes a user, a UserControl, and a Fiber context as input.
+// userErrorResponse sends the response for an error of the user service.
+// Invalid input and revoke or email change links get 400, a missing mail server gets 503, an unknown user or API key gets 404, a login that failed too often gets 401 with a captcha flag, rejected credentials get 401,
+// turned off guest sessions get 403, a claim by a registered user gets 409, a poll of a device that gets no session gets 400 with the code
+// of the device authorization grant, and any other error gets 500.
 //
-// @param user User - The user for whom the JWT is being created.
-// @param uc *UserControl - The UserControl.
 // @param c *fiber.Ctx - The Fiber context.
-// @return JWT - The new JWT.
-// @return error - An error if one occurred.
-func CreateNewJWTAndUpdateUser(user User, uc *UserControl, c *fiber.Ctx) (JWT, error) {
-	// jwtToken is the new JWT.
-	jwtToken := utils.CreateToken(user.ID.String(), uc.cfg)
-	// tokenId is the new UUID for the JWT.
-	tokenId, _ := uuid.NewV7()
-
-	// jwt is a new JWT struct.
-	jwt := JWT{
-		// The ID field is set to the new UUID.
-		ID: tokenId,
-		// The Token field is set to the new JWT string.
-		Token: jwtToken.Token,
-		// The ExpiresAt field is set to the expiration time of the JWT.
-		ExpiresAt: jwtToken.ExpiresAt,
+// @param err error - The error that occurred.
+// @param errorMessage string - The message for any other error.
+// @return error - An error if one occurred while sending the response.
+func userErrorResponse(c *fiber.Ctx, err error, errorMessage string) error {
+	// failed is the rejected login, if the error is one.
+	var failed *LoginFailedError
+	// This checks if the login was rejected often enough that a captcha is asked for.
+	if errors.As(err, &failed) && failed.CaptchaRequired {
+		// If it was, the client is told to show a captcha, whether or not the user exists.
+		return response.CaptchaRequired(c, failed.Err, "Too many failed logins. Solve the captcha and try again")
 	}
+	// This checks what kind of error occurred.
+	switch {
+	// A required field is empty.
+	case errors.Is(err, ErrMissingFields):
+		// A bad request response is returned.
+		return response.BadResponse(c, "All fields are required")
+	// The email address is already used.
+	case errors.Is(err, ErrEmailTaken):
+		// A conflict response is returned.
+		return response.Conflict(c, err, "This email already is ready used. Try something new!")
+	// The time zone is given but empty.
+	case errors.Is(err, ErrTimezoneRequired):
+		// A bad request response is returned.
+		return response.BadResponse(c, "Timezone is required")
+	// The time zone is not a valid IANA time zone.
+	case errors.Is(err, ErrInvalidTimezone):
+		// A bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid time zone")
+	// The language is not supported.
+	case errors.Is(err, ErrInvalidLocale):
+		// A bad request response is returned.
+		return response.BadResponse(c, "Invalid locale")
+	// No preference is given.
+	case errors.Is(err, ErrNoPreferences):
+		// A bad request response is returned.
+		return response.BadResponse(c, "At least one preference is required")
+	// No user matches the email address or the token.
+	case errors.Is(err, ErrUserNotFound):
+		// A not found response is returned.
+		return response.NotFound(c, err, "User not found")
+	// The password does not match.
+	case errors.Is(err, ErrInvalidCredentials):
+		// An unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Invalid credentials")
+	// The revoke link is malformed, tampered with, or expired.
+	case errors.Is(err, ErrInvalidRevokeLink):
+		// A bad request response is returned.
+		return response.BadResponse(c, "Invalid or expired revoke link")
+	// The new email address is not a valid address.
+	case errors.Is(err, ErrInvalidEmail):
+		// A bad request response is returned.
+		return response.BadResponse(c, "Invalid email address")
+	// The new email address is the current one.
+	case errors.Is(err, ErrEmailUnchanged):
+		// A bad request response is returned.
+		return response.BadResponse(c, "This is already your email address")
+	// No mail server is configured.
+	case errors.Is(err, ErrMailUnavailable):
+		// A service unavailable response is returned.
+		return response.ServiceUnavailable(c, err, "Email is not available on this server")
+	// The email change link is malformed, tampered with, expired, or already used.
+	case errors.Is(err, ErrInvalidEmailChangeLink):
+		// A bad request response is returned.
+		return response.BadResponse(c, "Invalid or expired email change link")
+	// The API key has no name.
+	case errors.Is(err, ErrAPIKeyNameRequired):
+		// A bad request response is returned.
+		return response.BadResponse(c, "API key name is required")
+	// The name of the API key is too long.
+	case errors.Is(err, ErrAPIKeyNameTooLong):
+		// A bad request response is returned.
+		return response.BadResponse(c, "API key name must be at most 100 characters")
+	// The API key has no scopes.
+	case errors.Is(err, ErrNoScopes):
+		// A bad request response is returned.
+		return response.BadResponse(c, "At least one scope is required")
+	// A scope of the API key does not exist.
+	case errors.Is(err, ErrInvalidScope):
+		// A bad request response is returned, with the scope in the error.
+		return response.BadInternalResponse(c, err, "Invalid scope")
+	// The lifetime of the API key is out of range.
+	case errors.Is(err, ErrInvalidAPIKeyExpiry):
+		// A bad request response is returned.
+		return response.BadResponse(c, "Invalid API key expiry")
+	// The user has no such API key.
+	case errors.Is(err, ErrAPIKeyNotFound):
+		// A not found response is returned.
+		return response.NotFound(c, err, "API key not found")
+	// Guest sessions are turned off.
+	case errors.Is(err, ErrGuestModeDisabled):
+		// A forbidden response is returned.
+		return response.Forbidden(c, "Guest mode is disabled on this server")
+	// The device ID is missing or too long.
+	case errors.Is(err, ErrDeviceIDRequired):
+		// A bad request response is returned.
+		return response.BadResponse(c, "X-Device-ID header is required and must be at most 255 characters")
+	// The user who claims a guest account is not a guest.
+	case errors.Is(err, ErrNotGuest):
+		// A conflict response is returned.
+		return response.Conflict(c, err, "This account is already registered")
+	// The name of a device is too long.
+	case errors.Is(err, ErrClientNameTooLong):
+		// A bad request response is returned.
+		return response.BadResponse(c, "Client name must be at most 100 characters")
+	// No device waits for approval with the user code.
+	case errors.Is(err, ErrDeviceNotFound):
+		// A not found response is returned.
+		return response.NotFound(c, err, "Invalid or expired user code")
+	// The user has not approved or denied the device yet.
+	case errors.Is(err, ErrAuthorizationPending):
+		// The device is told to keep polling.
+		return response.DeviceGrantError(c, "authorization_pending", "Waiting for the user to approve the device")
+	// The device polled too soon.
+	case errors.Is(err, ErrSlowDown):
+		// The device is told to poll less often.
+		return response.DeviceGrantError(c, "slow_down", "Polling too fast. Wait longer between requests")
+	// The user denied the device.
+	case errors.Is(err, ErrAccessDenied):
+		// The device is told to stop.
+		return response.DeviceGrantError(c, "access_denied", "The user denied the device")
+	// The codes of the device expired.
+	case errors.Is(err, ErrExpiredDeviceCode):
+		// The device is told to start over.
+		return response.DeviceGrantError(c, "expired_token", "The device code expired. Start over")
+	// The device code was never issued or was already used.
+	case errors.Is(err, ErrInvalidDeviceCode):
+		// The device is told its code is invalid.
+		return response.DeviceGrantError(c, "invalid_grant", "Invalid device code")
+	}
+	// For any other error, an internal server error response is returned.
+	return response.InternelServerError(c, err, errorMessage)
+}
 
-	// _, err is the result of executing the SQL query to create the new JWT and update the user's row.
-	_, err := uc.db.Exec(CreateNewJWT_UpdateUserRowQuery, jwt.ID, jwt.Token, jwt.ExpiresAt, user.ID)
-	// This checks if an error occurred while executing the query.
-	if err != nil {
-		// If an error occurs, an empty JWT and the error are returned.
-		return JWT{}, err
+// newRegisterLoginResponse converts a user and their JWT into the response of the register and login endpoints.
+//
+// @param user User - The user.
+// @param jwt JWT - The user's JWT.
+// @return register_loginUserResponse - The response structure.
+func newRegisterLoginResponse(user User, jwt JWT) register_loginUserResponse {
+	// A new register_loginUserResponse struct is returned.
+	return register_loginUserResponse{
+		// The ProfileResponse field is set to the user's profile.
+		ProfileResponse: NewProfileResponse(user),
+		// The Token field is set to the JWT.
+		Token: jwt.Token,
+		// The ExpiresAt field is set to the expiration time of the JWT.
+		ExpiresAt: utils.ParseTime(jwt.ExpiresAt),
 	}
+}
 
-	// The new JWT and no error are returned.
-	return jwt, nil
+// clientOf describes the device a request comes from.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return Client - The User-Agent and IP address of the request.
+func clientOf(c *fiber.Ctx) Client {
+	// The client is returned.
+	return Client{UserAgent: c.Get(fiber.HeaderUserAgent), IP: c.IP()}
 }
 
 // RegisterUserController handles user registration.
@@ -100,235 +217,618 @@ func (uc *UserControl) RegisterUserController(c *fiber.Ctx) error {
 		return response.BadInternalResponse(c, err, "Invalid request body")
 	}
 
-	// This checks if all required fields are present.
-	if body.Name == "" || body.Email == "" || body.Password == "" {
-		// If any field is missing, a bad request response is returned.
-		return response.BadResponse(c, "All fields are required")
+	// user and jwt are the result of registering the user.
+	user, jwt, err := uc.service.Register(c.UserContext(), RegisterInput{Name: body.Name, Email: body.Email, Password: body.Password, Timezone: body.Timezone, Locale: body.Locale}, clientOf(c))
+	// This checks if an error occurred while registering the user.
+	if err != nil {
+		// If an error occurs, the matching error response is returned.
+		return userErrorResponse(c, err, "Error creating user")
 	}
 
-	// count is a variable that will hold the number of users with the same email.
-	var count int
+	// An OK response is returned with a success message and the user data.
+	return response.OKResponse(c, "User registered successfully", newRegisterLoginResponse(user, jwt))
+}
+
+// LoginUserController handles user login.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) LoginUserController(c *fiber.Ctx) error {
+	// body is a new loginUserRequest struct.
+	body := new(loginUserRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
 
-	// err is the result of querying the database to check if the email is unique.
-	err := uc.db.QueryRow(CheckUniqueEmailQuery, body.Email).Scan(&count)
-	// This checks if an error occurred while querying the database.
+	// user and jwt are the result of checking the credentials.
+	user, jwt, err := uc.service.Login(c.UserContext(), body.Email, body.Password, body.RememberMe, clientOf(c))
+	// This checks if an error occurred while logging in.
 	if err != nil {
-		// If an error occurs, an internal server error response is returned.
-		return response.InternelServerError(c, err, "Error checking unique email")
-	}
-
-	// This checks if the email is already in use.
-	if count > 0 {
-		// If the email is already in use, a bad request response is returned.
-		return response.BadResponse(c, "This email already is ready used. Try something new!")
-	}
-
-	// userId is the new UUID for the user.
-	userId, _ := uuid.NewV7()
-	// user is a new User struct.
-	user := User{
-		// The ID field is set to the new UUID.
-		ID: userId,
-		// The Name field is set to the user's name.
-		Name: body.Name,
-		// The Email field is set to the user's email address.
-		Email: body.Email,
-		// The Password field is set to the user's password.
-		Password: body.Password,
-		// The CreatedAt field is set to the current time.
-		CreatedAt: time.Now(),
-		// The UpdatedAt field is set to the current time.
-		UpdatedAt: time.Now(),
-	}
-
-	// encryptedPassword is the user's encrypted password.
-	encryptedPassword, err := utils.EncryptPassword(user.Password)
-	// This checks if an error occurred while encrypting the password.
+		// If an error occurs, the matching error response is returned.
+		return userErrorResponse(c, err, "Error logging in user")
+	}
+
+	// An OK response is returned with a success message and the user data.
+	return response.OKResponse(c, "User logged in successfully", newRegisterLoginResponse(user, jwt))
+}
+
+// LogoutUserController handles user logout.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) LogoutUserController(c *fiber.Ctx) error {
+	// jwt is the JWT object retrieved from the local context.
+	jwt, err := CurrentJWT(c)
+	// This checks if the request has no JWT, such as one authenticated with HTTP Basic authentication.
 	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// This deletes the JWT.
+	if err := uc.service.Logout(c.UserContext(), jwt); err != nil {
 		// If an error occurs, an internal server error response is returned.
-		return response.InternelServerError(c, err, "Error encrypting password")
+		return response.InternelServerError(c, err, "Error deleting JWT")
 	}
-	// The user's password is replaced with the encrypted password.
-	user.Password = encryptedPassword
 
-	// _, err is the result of executing the SQL query to create the new user.
-	_, err = uc.db.Exec(CreateUserQuery, user.ID, user.Name, user.Email, user.Image, user.Password, nil, user.CreatedAt, user.UpdatedAt)
-	// This checks if an error occurred while executing the query.
+	// An OK response is returned with a success message.
+	return response.OKResponse(c, "User logged out successfully", nil)
+}
+
+// StartGuestController starts a guest session, bound to the device named by the X-Device-ID header.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) StartGuestController(c *fiber.Ctx) error {
+	// body is a new startGuestRequest struct.
+	body := new(startGuestRequest)
+	// This parses the request body, which is optional, into the body struct.
+	if len(c.Body()) > 0 {
+		// This checks if the body is invalid.
+		if err := c.BodyParser(body); err != nil {
+			// If it is, a bad request response is returned.
+			return response.BadInternalResponse(c, err, "Invalid request body")
+		}
+	}
+
+	// user and jwt are the result of starting the session.
+	user, jwt, err := uc.service.StartGuest(c.UserContext(), GuestInput{DeviceID: c.Get(DeviceIDHeader), Timezone: body.Timezone, Locale: body.Locale}, clientOf(c))
+	// This checks if an error occurred while starting the session.
 	if err != nil {
-		// If an error occurs, an internal server error response is returned.
-		return response.InternelServerError(c, err, "Error creating user")
+		// If an error occurs, the matching error response is returned.
+		return userErrorResponse(c, err, "Error starting guest session")
 	}
 
-	// jwt is the new JWT for the user.
-	jwt, err := CreateNewJWTAndUpdateUser(user, uc, c)
-	// This checks if an error occurred while creating the JWT.
+	// An OK response is returned with a success message and the guest data.
+	return response.OKResponse(c, "Guest session started successfully", newRegisterLoginResponse(user, jwt))
+}
+
+// ClaimGuestController registers the current guest, who keeps their todos and lists.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) ClaimGuestController(c *fiber.Ctx) error {
+	// guest is the user retrieved from the local context.
+	guest, err := CurrentUser(c)
+	// This checks if the request has no user.
 	if err != nil {
-		// If an error occurs, an internal server error response is returned.
-		return response.InternelServerError(c, err, "Error creating JWT token")
-	}
-
-	// responseUser is a new register_loginUserResponse struct.
-	responseUser := register_loginUserResponse{
-		// The ID field is set to the user's ID.
-		ID: user.ID,
-		// The Name field is set to the user's name.
-		Name: user.Name,
-		// The Email field is set to the user's email address.
-		Email: user.Email,
-		// The CreatedAt field is set to the user's creation time.
-		CreatedAt: utils.ParseTime(user.CreatedAt),
-		// The UpdatedAt field is set to the user's last update time.
-		UpdatedAt: utils.ParseTime(user.UpdatedAt),
-		// The Token field is set to the new JWT.
-		Token: jwt.Token,
-		// The ExpiresAt field is set to the expiration time of the JWT.
-		ExpiresAt: utils.ParseTime(jwt.ExpiresAt),
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// body is a new registerUserRequest struct.
+	body := new(registerUserRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+
+	// user and jwt are the result of claiming the account.
+	user, jwt, err := uc.service.ClaimGuest(c.UserContext(), guest, RegisterInput{Name: body.Name, Email: body.Email, Password: body.Password, Timezone: body.Timezone, Locale: body.Locale}, clientOf(c))
+	// This checks if an error occurred while claiming the account.
+	if err != nil {
+		// If an error occurs, the matching error response is returned.
+		return userErrorResponse(c, err, "Error registering guest")
 	}
 
 	// An OK response is returned with a success message and the user data.
-	return response.OKResponse(c, "User registered successfully", responseUser)
+	return response.OKResponse(c, "User registered successfully", newRegisterLoginResponse(user, jwt))
 }
 
-// LoginUserController handles user login.
+// SessionsController lists the current user's sessions, so that they can recognize their devices.
 // It takes a Fiber context as input.
 //
 // @param c *fiber.Ctx - The Fiber context.
 // @return error - An error if one occurred.
-func (uc *UserControl) LoginUserController(c *fiber.Ctx) error {
-	// body is a new loginUserRequest struct.
-	body := new(loginUserRequest)
+func (uc *UserControl) SessionsController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+	// jwt is the JWT of the request, which marks the current session.
+	jwt, err := CurrentJWT(c)
+	// This checks if the request has no JWT, such as one authenticated with HTTP Basic authentication.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// sessions is the result of listing the sessions.
+	sessions, err := uc.service.Sessions(c.UserContext(), user.ID)
+	// This checks if an error occurred while listing the sessions.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to fetch sessions")
+	}
+
+	// sessionResponses is the list of response structures of the sessions.
+	sessionResponses := make([]SessionResponse, 0, len(sessions))
+	// This iterates over the sessions.
+	for _, session := range sessions {
+		// The response structure of the session is appended.
+		sessionResponses = append(sessionResponses, NewSessionResponse(session, jwt.ID))
+	}
+	// An OK response is returned with a success message and the sessions.
+	return response.OKResponse(c, "Sessions fetched successfully", sessionResponses)
+}
+
+// RevokeSessionPageController shows the page the revoke link of a new device alert opens, which asks before the session ends.
+// Opening the link changes nothing, so a mail scanner that fetches it cannot end the session.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) RevokeSessionPageController(c *fiber.Ctx) error {
+	// This checks if the link is invalid or expired.
+	if err := uc.service.CheckRevokeLink(c.Query("token")); err != nil {
+		// If it is, a page with the reason is sent.
+		return linkErrorPage(c, err, "Unable to revoke session")
+	}
+
+	// The page asks before the session ends.
+	return confirmLink(c, "Sign out this device?", "The device will have to log in again.", "Sign out")
+}
+
+// RevokeSessionController ends the session named by the revoke link of a new device alert, once the page of the link is confirmed.
+// The token is read from the form of the page, or from the query of a client that posts it itself.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) RevokeSessionController(c *fiber.Ctx) error {
+	// This revokes the session named by the token of the link.
+	err := uc.service.RevokeSession(c.UserContext(), c.FormValue("token"))
+	// The outcome is answered as a page or as JSON, matching the request.
+	return linkOutcome(c, err, "Unable to revoke session", "Session revoked successfully")
+}
+
+// ChangeEmailController starts changing the current user's email address, which only changes once the new address confirms it.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) ChangeEmailController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// body is a new changeEmailRequest struct.
+	body := new(changeEmailRequest)
 	// This parses the request body into the body struct.
 	if err := c.BodyParser(body); err != nil {
 		// If an error occurs, a bad request response is returned.
 		return response.BadInternalResponse(c, err, "Invalid request body")
 	}
 
-	// This checks if all required fields are present.
-	if body.Email == "" || body.Password == "" {
-		// If any field is missing, a bad request response is returned.
-		return response.BadResponse(c, "All fields are required")
+	// This requests the change, which emails both addresses.
+	if err := uc.service.RequestEmailChange(c.UserContext(), user, EmailChangeInput{NewEmail: body.NewEmail, Password: body.Password}); err != nil {
+		// If an error occurs, the matching error response is returned.
+		return userErrorResponse(c, err, "Unable to change email")
+	}
+
+	// An OK response is returned with a success message.
+	return response.OKResponse(c, "Check your new email address to confirm the change", nil)
+}
+
+// ConfirmEmailChangePageController shows the page the link sent to the new address opens, which asks before the email changes.
+// Opening the link changes nothing, so a mail scanner that fetches it cannot confirm the change.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) ConfirmEmailChangePageController(c *fiber.Ctx) error {
+	// This checks if the link is invalid, expired, or already used.
+	if err := uc.service.CheckConfirmEmailChangeLink(c.UserContext(), c.Query("token")); err != nil {
+		// If it is, a page with the reason is sent.
+		return linkErrorPage(c, err, "Unable to confirm email change")
+	}
+
+	// The page asks before the email changes.
+	return confirmLink(c, "Confirm your new email address?", "You will be logged out of every device and log in again with your new email address.", "Confirm")
+}
+
+// ConfirmEmailChangeController applies the email change named by the link sent to the new address, once the page of the link is confirmed.
+// The token is read from the form of the page, or from the query of a client that posts it itself.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) ConfirmEmailChangeController(c *fiber.Ctx) error {
+	// This applies the change named by the token of the link.
+	err := uc.service.ConfirmEmailChange(c.UserContext(), c.FormValue("token"))
+	// The outcome is answered as a page or as JSON, matching the request.
+	return linkOutcome(c, err, "Unable to confirm email change", "Email changed successfully. Log in again with your new email address")
+}
+
+// CancelEmailChangePageController shows the page the link sent to the old address opens, which asks before the change is cancelled.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) CancelEmailChangePageController(c *fiber.Ctx) error {
+	// This checks if the link is invalid or expired.
+	if err := uc.service.CheckCancelEmailChangeLink(c.Query("token")); err != nil {
+		// If it is, a page with the reason is sent.
+		return linkErrorPage(c, err, "Unable to cancel email change")
+	}
+
+	// The page asks before the change is cancelled.
+	return confirmLink(c, "Cancel the change of your email address?", "Your email address stays as it is. If you did not ask for the change, change your password too.", "Cancel the change")
+}
+
+// CancelEmailChangeController drops the email change named by the link sent to the old address, once the page of the link is confirmed.
+// The token is read from the form of the page, or from the query of a client that posts it itself.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) CancelEmailChangeController(c *fiber.Ctx) error {
+	// This cancels the change named by the token of the link.
+	err := uc.service.CancelEmailChange(c.UserContext(), c.FormValue("token"))
+	// The outcome is answered as a page or as JSON, matching the request.
+	return linkOutcome(c, err, "Unable to cancel email change", "Email change cancelled successfully")
+}
+
+// UserProfileController handles retrieving the user's profile.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) UserProfileController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
 	}
+	// An OK response is returned with a success message and the user's profile.
+	return response.OKResponse(c, "User profile fetched successfully", NewProfileResponse(user))
+}
 
-	// user is a variable that will hold the user's data.
-	var user User
-	// jwt is a variable that will hold the JWT data.
-	var jwt JWT
+// UsageController reports the current user's usage against the limits of their plan.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) UsageController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
 
-	// err is the result of querying the database for the user's profile.
-	err := uc.db.QueryRow(GetUserProfileByEmailQuery, body.Email).Scan(&user.ID, &user.Name, &user.Email, &user.Image, &user.Password, &user.JWT, &user.CreatedAt, &user.UpdatedAt)
-	// This checks if an error occurred while querying the database.
+	// usage is the usage of the user.
+	usage, err := uc.service.Usage(c.UserContext(), user.ID)
+	// This checks if an error occurred while reading the usage.
 	if err != nil {
-		// This checks if the error is sql.ErrNoRows.
-		if err == sql.ErrNoRows {
-			// If no user is found, a not found response is returned.
-			return response.NotFound(c, err, "User not found")
-		}
-		// For any other error, an internal server error response is returned.
-		return response.InternelServerError(c, err, "Error fetching user profile info")
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to get usage")
 	}
 
-	// passwordMatched is a boolean that indicates whether the passwords match.
-	passwordMatched := utils.CompareEncryptedPassword(user.Password, body.Password)
-	// This checks if the passwords do not match.
-	if !passwordMatched {
-		// If the passwords do not match, an unauthorized access response is returned.
-		return response.UnauthorizedAccess(c, err, "Invalid credentials")
+	// An OK response is returned with a success message and the usage.
+	return response.OKResponse(c, "Usage fetched successfully", UsageResponse{
+		// The Plan field is set to the plan of the user.
+		Plan: usage.Plan,
+		// The Todos field is set to the usage of todos.
+		Todos: UsageAmount{Used: usage.Todos, Limit: int64(usage.Limits.MaxTodos)},
+		// The Lists field is set to the usage of lists.
+		Lists: UsageAmount{Used: usage.Lists, Limit: int64(usage.Limits.MaxLists)},
+		// The AttachmentBytes field is set to the usage of attachment storage.
+		AttachmentBytes: UsageAmount{Used: usage.AttachmentBytes, Limit: usage.Limits.MaxAttachmentBytes},
+	})
+}
+
+// UpdatePreferencesController handles updating the current user's preferences.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) UpdatePreferencesController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
 	}
 
-	// This checks if the user already has a valid JWT.
-	if !user.JWT.Valid {
-		// If the user does not have a valid JWT, a new one is created.
-		jwt, err = CreateNewJWTAndUpdateUser(user, uc, c)
-		// This checks if an error occurred while creating the JWT.
-		if err != nil {
-			// If an error occurs, an internal server error response is returned.
-			return response.InternelServerError(c, err, "Error creating JWT token")
-		}
-	} else {
-		// If the user already has a JWT, its information is retrieved from the database.
-		err = uc.db.QueryRow(GetUserJWTInfoQuery, user.JWT).Scan(&jwt.ID, &jwt.Token, &jwt.ExpiresAt)
-		// This checks if an error occurred while querying the database.
-		if err != nil {
-			// This checks if the error is sql.ErrNoRows.
-			if err == sql.ErrNoRows {
-				// If no JWT is found, a not found response is returned.
-				return response.NotFound(c, err, "User not found")
-			}
-			// For any other error, an internal server error response is returned.
-			return response.InternelServerError(c, err, "Error fetching user login info")
-		}
+	// body is a new updatePreferencesRequest struct.
+	body := new(updatePreferencesRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
 
-		// This checks if the JWT has expired.
-		if jwt.ExpiresAt.Before(time.Now()) {
-			// If the JWT has expired, it is deleted from the database.
-			_, err := uc.db.Exec(DeleteJWTByIdQuery, jwt.ID)
-			// This checks if an error occurred while deleting the JWT.
-			if err != nil {
-				// If an error occurs, an internal server error response is returned.
-				return response.InternelServerError(c, err, "Error deleting expired JWT")
-			}
-
-			// A new JWT is created for the user.
-			jwt, err = CreateNewJWTAndUpdateUser(user, uc, c)
-			// This checks if an error occurred while creating the JWT.
-			if err != nil {
-				// If an error occurs, an internal server error response is returned.
-				return response.InternelServerError(c, err, "Error creating JWT token")
-			}
-		}
+	// user is the result of updating the preferences.
+	user, err = uc.service.UpdatePreferences(c.UserContext(), user, PreferencesInput{Timezone: body.Timezone, Locale: body.Locale})
+	// This checks if an error occurred while updating the preferences.
+	if err != nil {
+		// If an error occurs, the matching error response is returned.
+		return userErrorResponse(c, err, "Unable to update preferences")
 	}
 
-	// responseUser is a new register_loginUserResponse struct.
-	responseUser := register_loginUserResponse{
-		// The ID field is set to the user's ID.
-		ID: user.ID,
-		// The Name field is set to the user's name.
-		Name: user.Name,
-		// The Email field is set to the user's email address.
-		Email: user.Email,
-		// The CreatedAt field is set to the user's creation time.
-		CreatedAt: utils.ParseTime(user.CreatedAt),
-		// The UpdatedAt field is set to the user's last update time.
-		UpdatedAt: utils.ParseTime(user.UpdatedAt),
-		// The Token field is set to the new JWT.
-		Token: jwt.Token,
-		// The ExpiresAt field is set to the expiration time of the JWT.
-		ExpiresAt: utils.ParseTime(jwt.ExpiresAt),
+	// An OK response is returned with a success message and the user's profile.
+	return response.OKResponse(c, "Preferences updated successfully", NewProfileResponse(user))
+}
+
+// CreateAPIKeyController creates an API key for the current user, limited to the scopes of the request.
+// The key is only shown in this response.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) CreateAPIKeyController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
 	}
 
-	// An OK response is returned with a success message and the user data.
-	return response.OKResponse(c, "User logged in successfully", responseUser)
+	// body is a new createAPIKeyRequest struct.
+	body := new(createAPIKeyRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+
+	// key is the result of creating the key.
+	key, err := uc.service.CreateAPIKey(c.UserContext(), user, APIKeyInput{Name: body.Name, Scopes: body.Scopes, ExpiresInDays: body.ExpiresInDays})
+	// This checks if an error occurred while creating the key.
+	if err != nil {
+		// If an error occurs, the matching error response is returned.
+		return userErrorResponse(c, err, "Unable to create API key")
+	}
+
+	// A created response is returned with a success message and the key.
+	return response.OKCreatedResponse(c, "API key created successfully", NewAPIKeyResponse(key))
 }
 
-// LogoutUserController handles user logout.
+// APIKeysController lists the current user's API keys, without the keys themselves.
 // It takes a Fiber context as input.
 //
 // @param c *fiber.Ctx - The Fiber context.
 // @return error - An error if one occurred.
-func (uc *UserControl) LogoutUserController(c *fiber.Ctx) error {
-	// jwt is the JWT object retrieved from the local context.
-	jwt := c.Locals("jwt").(JWT)
+func (uc *UserControl) APIKeysController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
 
-	// _, err is the result of executing the SQL query to delete the JWT.
-	_, err := uc.db.Exec(DeleteJWTByIdQuery, jwt.ID)
-	// This checks if an error occurred while executing the query.
+	// keys is the result of listing the keys.
+	keys, err := uc.service.APIKeys(c.UserContext(), user.ID)
+	// This checks if an error occurred while listing the keys.
 	if err != nil {
 		// If an error occurs, an internal server error response is returned.
-		return response.InternelServerError(c, err, "Error deleting JWT")
+		return response.InternelServerError(c, err, "Unable to fetch API keys")
+	}
+
+	// keyResponses is the list of response structures of the keys.
+	keyResponses := make([]APIKeyResponse, 0, len(keys))
+	// This iterates over the keys.
+	for _, key := range keys {
+		// The response structure of the key is appended.
+		keyResponses = append(keyResponses, NewAPIKeyResponse(key))
+	}
+	// An OK response is returned with a success message and the keys.
+	return response.OKResponse(c, "API keys fetched successfully", keyResponses)
+}
+
+// DeleteAPIKeyController deletes an API key of the current user.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) DeleteAPIKeyController(c *fiber.Ctx) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := CurrentUser(c)
+	// This checks if the request has no authenticated user, which means the route is mounted without its authentication middleware.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// keyId is the parsed value of the "id" path parameter.
+	keyId, err := uuid.Parse(c.Params("id"))
+	// This checks if the key ID is not a valid UUID.
+	if err != nil {
+		// If it is not, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid API key id")
+	}
+
+	// This deletes the key.
+	if err := uc.service.DeleteAPIKey(c.UserContext(), user.ID, keyId); err != nil {
+		// If an error occurs, the matching error response is returned.
+		return userErrorResponse(c, err, "Unable to delete API key")
 	}
 
 	// An OK response is returned with a success message.
-	return response.OKResponse(c, "User logged out successfully", nil)
+	return response.OKResponse(c, "API key deleted successfully", nil)
 }
 
-// UserProfileController handles retrieving the user's profile.
+// StartDeviceController creates the codes of a device that asks to sign in, such as the todoctl CLI.
 // It takes a Fiber context as input.
 //
 // @param c *fiber.Ctx - The Fiber context.
 // @return error - An error if one occurred.
-func (uc *UserControl) UserProfileController(c *fiber.Ctx) error {
-	// user is the User object retrieved from the local context.
-	user := c.Locals("user").(User)
-	// An OK response is returned with a success message and the user data.
-	return response.OKResponse(c, "User profile fetched successfully", user)
-}
\ No newline at end of file
+func (uc *UserControl) StartDeviceController(c *fiber.Ctx) error {
+	// body is a new deviceCodeRequest struct.
+	body := new(deviceCodeRequest)
+	// This parses the request body, which is optional, into the body struct.
+	if len(c.Body()) > 0 {
+		// This checks if the body is invalid.
+		if err := c.BodyParser(body); err != nil {
+			// If it is, a bad request response is returned.
+			return response.BadInternalResponse(c, err, "Invalid request body")
+		}
+	}
+
+	// authorization is the result of creating the codes.
+	authorization, err := uc.service.StartDeviceAuthorization(c.UserContext(), body.ClientName, clientOf(c))
+	// This checks if an error occurred while creating the codes.
+	if err != nil {
+		// If an error occurs, the matching error response is returned.
+		return userErrorResponse(c, err, "Error starting device authorization")
+	}
+
+	// An OK response is returned with a success message and the codes.
+	return response.OKResponse(c, "Device authorization started successfully", DeviceCodeResponse{
+		// The DeviceCode field is set to the code the device polls with.
+		DeviceCode: authorization.DeviceCode,
+		// The UserCode field is set to the code the user enters.
+		UserCode: authorization.UserCode,
+		// The VerificationURI field is set to the page where the user enters the code.
+		VerificationURI: authorization.VerificationURI,
+		// The VerificationURIComplete field is set to the page with the code filled in.
+		VerificationURIComplete: authorization.VerificationURIComplete,
+		// The ExpiresIn field is set to the number of seconds left until the codes expire.
+		ExpiresIn: int64(authorization.ExpiresAt.Sub(uc.service.clock.Now()) / time.Second),
+		// The Interval field is set to the time between polls.
+		Interval: authorization.Interval,
+	})
+}
+
+// DeviceTokenController answers a device that polls with its device code, with a session once the user approved it.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) DeviceTokenController(c *fiber.Ctx) error {
+	// body is a new deviceTokenRequest struct.
+	body := new(deviceTokenRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+	// This checks if the grant type is not the device code.
+	if body.GrantType != DeviceCodeGrantType {
+		// If it is not, a bad request response is returned.
+		return response.BadResponse(c, "Unsupported grant type. Use urn:ietf:params:oauth:grant-type:device_code")
+	}
+
+	// user and jwt are the result of the poll.
+	user, jwt, err := uc.service.PollDeviceAuthorization(c.UserContext(), body.DeviceCode, clientOf(c))
+	// This checks if the device gets no session.
+	if err != nil {
+		// If it does not, the matching error response is returned.
+		return userErrorResponse(c, err, "Error issuing device token")
+	}
+
+	// An OK response is returned with a success message and the user data, like a login.
+	return response.OKResponse(c, "User logged in successfully", newRegisterLoginResponse(user, jwt))
+}
+
+// PendingDeviceController shows the device that waits for approval with a user code, so that the user can check it is theirs.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) PendingDeviceController(c *fiber.Ctx) error {
+	// device is the device that waits with the code in the query string.
+	device, err := uc.service.PendingDevice(c.UserContext(), c.Query("user_code"))
+	// This checks if an error occurred while reading the device.
+	if err != nil {
+		// If an error occurs, the matching error response is returned.
+		return userErrorResponse(c, err, "Error reading device")
+	}
+
+	// An OK response is returned with a success message and the device.
+	return response.OKResponse(c, "Device retrieved successfully", NewDeviceRequestResponse(device))
+}
+
+// ApproveDeviceController approves the device that waits with a user code, which then gets a session of the current user.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) ApproveDeviceController(c *fiber.Ctx) error {
+	// The device is approved.
+	return uc.decideDevice(c, true, "Device approved successfully")
+}
+
+// DenyDeviceController denies the device that waits with a user code, which is then told to stop polling.
+// It takes a Fiber context as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred.
+func (uc *UserControl) DenyDeviceController(c *fiber.Ctx) error {
+	// The device is denied.
+	return uc.decideDevice(c, false, "Device denied successfully")
+}
+
+// decideDevice approves or denies the device that waits with the user code of the request body, on behalf of the current user.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param approve bool - Whether the device is approved.
+// @param message string - The message of the success response.
+// @return error - An error if one occurred.
+func (uc *UserControl) decideDevice(c *fiber.Ctx, approve bool, message string) error {
+	// user is the authenticated user retrieved from the local context.
+	user, err := CurrentUser(c)
+	// This checks if the request has no user.
+	if err != nil {
+		// If it has none, an unauthorized access response is returned.
+		return response.UnauthorizedAccess(c, err, "Authentication required")
+	}
+
+	// body is a new deviceDecisionRequest struct.
+	body := new(deviceDecisionRequest)
+	// This parses the request body into the body struct.
+	if err := c.BodyParser(body); err != nil {
+		// If an error occurs, a bad request response is returned.
+		return response.BadInternalResponse(c, err, "Invalid request body")
+	}
+
+	// This approves or denies the device.
+	if err := uc.service.DecideDevice(c.UserContext(), user, body.UserCode, approve); err != nil {
+		// If an error occurs, the matching error response is returned.
+		return userErrorResponse(c, err, "Error deciding device")
+	}
+
+	// An OK response is returned with a success message.
+	return response.OKResponse(c, message, nil)
+}
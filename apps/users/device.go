@@ -0,0 +1,461 @@
+// This file defines the device authorization grant of OAuth 2.0, which signs in command-line tools such as todoctl and
+// headless scripts without a browser or a password. The device asks for a pair of codes and shows the user the short one
+// with the page to enter it at. The user approves the device there while they are logged in, and the device, which polls
+// with the long code in the meantime, then gets a session of its own, like a login from that device.
+package users
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to pass the deadline of the request to the queries.
+import (
+	"context"
+	// "crypto/rand" provides a cryptographically secure random number generator. It is used here to create the user codes.
+	"crypto/rand"
+	// "crypto/sha256" implements the SHA-256 hash. It is used here to hash the device codes.
+	"crypto/sha256"
+	// "database/sql" provides a generic SQL interface. It is used here to run the polls in a transaction.
+	"database/sql"
+	// "encoding/hex" implements hexadecimal encoding. It is used here to store the hashes of the device codes as text.
+	"encoding/hex"
+	// "errors" provides functions for working with errors. It is used here to define the device errors.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to wrap errors.
+	"fmt"
+	// "math/big" implements arbitrary-precision arithmetic. It is used here to pick the letters of the user codes without bias.
+	"math/big"
+	// "net/url" provides functions for working with URLs. It is used here to build the verification link that carries the user code.
+	"net/url"
+	// "strings" provides functions for working with strings. It is used here to normalize the user codes and cut long User-Agent headers.
+	"strings"
+	// "time" provides functions for working with time. It is used here to date the codes and space the polls.
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to read the user who approved a device.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database helpers.
+	"github.com/rahulcodepython/todo-backend/backend/database"
+	// "github.com/rahulcodepython/todo-backend/backend/dberr" is a local package that classifies database errors. It is used here to retry a user code that is taken.
+	"github.com/rahulcodepython/todo-backend/backend/dberr"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// DeviceCodeGrantType is the grant type of a device that polls for its session.
+const DeviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// deviceCodePrefix is the prefix of device codes.
+const deviceCodePrefix = "tdd_"
+
+// userCodeAlphabet holds the letters of user codes. It has no vowels, so that a code never spells a word, and no letters that look like digits.
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+// userCodeLength is the number of letters of a user code, which is shown as two groups of four.
+const userCodeLength = 8
+
+// userCodeAttempts is the number of user codes tried before giving up, in case a new code is already taken.
+const userCodeAttempts = 3
+
+// devicePollInterval is the number of seconds a device waits between polls.
+const devicePollInterval = 5
+
+// maxClientNameLength is the number of characters the name of a device may have.
+const maxClientNameLength = 100
+
+// The states of a device.
+const (
+	// deviceStatusPending is the state of a device that waits for the user.
+	deviceStatusPending = "pending"
+	// deviceStatusApproved is the state of a device the user approved.
+	deviceStatusApproved = "approved"
+	// deviceStatusDenied is the state of a device the user denied.
+	deviceStatusDenied = "denied"
+)
+
+// ErrClientNameTooLong is returned when a device names itself with more than maxClientNameLength characters.
+var ErrClientNameTooLong = errors.New("client name is too long")
+
+// ErrDeviceNotFound is returned when no device waits for approval with a user code, because it is wrong, expired, or already used.
+var ErrDeviceNotFound = errors.New("device not found")
+
+// ErrAuthorizationPending is returned when a device polls before the user approved or denied it.
+var ErrAuthorizationPending = errors.New("authorization pending")
+
+// ErrSlowDown is returned when a device polls again before the interval has passed.
+var ErrSlowDown = errors.New("polling too fast")
+
+// ErrAccessDenied is returned when the user denied a device.
+var ErrAccessDenied = errors.New("access denied")
+
+// ErrExpiredDeviceCode is returned when a device polls after its codes expired.
+var ErrExpiredDeviceCode = errors.New("device code expired")
+
+// ErrInvalidDeviceCode is returned when a device polls with a code that was never issued or was already used.
+var ErrInvalidDeviceCode = errors.New("invalid device code")
+
+// DeviceAuthorization holds the codes of a device that asked to sign in, and where the user approves it.
+type DeviceAuthorization struct {
+	// DeviceCode is the code the device polls with. It is a secret of the device.
+	DeviceCode string
+	// UserCode is the short code the user enters, formatted as two groups of four letters.
+	UserCode string
+	// VerificationURI is the page where the user enters the code.
+	VerificationURI string
+	// VerificationURIComplete is the page with the code filled in, for devices that can show a link or a QR code.
+	VerificationURIComplete string
+	// ExpiresAt is the time the codes stop working.
+	ExpiresAt time.Time
+	// Interval is the number of seconds the device waits between polls.
+	Interval int
+}
+
+// DeviceRequest is a device that waits for approval, as it is shown to the user before they approve it.
+type DeviceRequest struct {
+	// UserCode is the user code of the device, formatted as two groups of four letters.
+	UserCode string
+	// ClientName is the name the device gave itself, such as "todoctl", or empty.
+	ClientName string
+	// UserAgent is the User-Agent header the device asked with.
+	UserAgent string
+	// IP is the IP address the device asked from.
+	IP string
+	// CreatedAt is the time the device asked to sign in.
+	CreatedAt time.Time
+	// ExpiresAt is the time the codes of the device stop working.
+	ExpiresAt time.Time
+}
+
+// hashDeviceCode returns the hash a device code is stored and looked up by. The code is random and long, so a fast hash is enough.
+//
+// @param deviceCode string - The device code.
+// @return string - The hash, in hexadecimal.
+func hashDeviceCode(deviceCode string) string {
+	// sum is the hash of the code.
+	sum := sha256.Sum256([]byte(deviceCode))
+	// The encoded hash is returned.
+	return hex.EncodeToString(sum[:])
+}
+
+// newUserCode creates a random user code, without the dash it is shown with.
+//
+// @return string - The user code.
+// @return error - An error if the random number generator failed.
+func newUserCode() (string, error) {
+	// code holds the letters of the code.
+	code := make([]byte, userCodeLength)
+	// This picks each letter.
+	for i := range code {
+		// n is the index of the letter in the alphabet.
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(userCodeAlphabet))))
+		// This checks if the random number generator failed.
+		if err != nil {
+			// If it did, the error is returned.
+			return "", err
+		}
+		// The letter is set.
+		code[i] = userCodeAlphabet[n.Int64()]
+	}
+	// The code is returned.
+	return string(code), nil
+}
+
+// normalizeUserCode turns a user code as the user typed it into the form it is stored in, without case, spaces, or dashes.
+//
+// @param userCode string - The user code as typed.
+// @return string - The stored form.
+func normalizeUserCode(userCode string) string {
+	// The letters are kept in upper case, and anything else is dropped.
+	return strings.Map(func(r rune) rune {
+		// This checks if the character is a letter.
+		if r >= 'a' && r <= 'z' {
+			// If it is a lower case one, it is turned to upper case.
+			return r - 'a' + 'A'
+		}
+		// This checks if the character is an upper case letter.
+		if r >= 'A' && r <= 'Z' {
+			// If it is, it is kept.
+			return r
+		}
+		// Other characters are dropped.
+		return -1
+	}, userCode)
+}
+
+// formatUserCode formats a stored user code as two groups of four letters, which is easier to read out and type.
+//
+// @param userCode string - The stored user code.
+// @return string - The formatted user code.
+func formatUserCode(userCode string) string {
+	// This checks if the code has a length that cannot be split in two.
+	if len(userCode) != userCodeLength {
+		// If it has, it is returned as it is.
+		return userCode
+	}
+	// The halves are joined with a dash.
+	return userCode[:userCodeLength/2] + "-" + userCode[userCodeLength/2:]
+}
+
+// StartDeviceAuthorization creates the codes of a device that asks to sign in. The expired codes of every device are removed first,
+// so that abandoned requests do not pile up.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param clientName string - The name the device gives itself, such as "todoctl", or empty.
+// @param client Client - The device, which the user is shown before they approve it.
+// @return DeviceAuthorization - The codes of the device.
+// @return error - ErrClientNameTooLong if the name is too long, or another error if one occurred.
+func (us *UserService) StartDeviceAuthorization(ctx context.Context, clientName string, client Client) (DeviceAuthorization, error) {
+	// clientName is the name without surrounding spaces.
+	clientName = strings.TrimSpace(clientName)
+	// This checks if the name is too long.
+	if len([]rune(clientName)) > maxClientNameLength {
+		// If it is, an error is returned.
+		return DeviceAuthorization{}, ErrClientNameTooLong
+	}
+
+	// now is the time the device asks.
+	now := us.clock.Now()
+	// The expired codes are deleted.
+	if _, err := us.db.ExecContext(ctx, DeleteExpiredDeviceCodesQuery, now); err != nil {
+		// If an error occurs, it is returned.
+		return DeviceAuthorization{}, fmt.Errorf("deleting expired device codes: %w", err)
+	}
+
+	// deviceCode is the code the device polls with.
+	deviceCode, err := utils.CreateOpaqueToken(deviceCodePrefix)
+	// This checks if the code could not be created.
+	if err != nil {
+		// If it could not, the error is returned.
+		return DeviceAuthorization{}, fmt.Errorf("creating device code: %w", err)
+	}
+
+	// userAgent is the User-Agent header, cut short so that a client cannot store an arbitrarily long one.
+	userAgent := client.UserAgent
+	// This checks if the header is too long.
+	if len(userAgent) > maxUserAgentLength {
+		// If it is, it is cut at a character boundary.
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+	}
+
+	// expiresAt is the time the codes stop working.
+	expiresAt := now.Add(us.cfg.Device.Expires)
+	// userCode is the code the user enters.
+	var userCode string
+	// This tries new user codes until one is free, which is almost always the first.
+	for attempt := 1; ; attempt++ {
+		// userCode is a new code.
+		userCode, err = newUserCode()
+		// This checks if the code could not be created.
+		if err != nil {
+			// If it could not, the error is returned.
+			return DeviceAuthorization{}, fmt.Errorf("creating user code: %w", err)
+		}
+		// _, err is the result of storing the codes.
+		_, err = us.db.ExecContext(ctx, CreateDeviceCodeQuery, us.ids.NewID(), hashDeviceCode(deviceCode), userCode, clientName, userAgent, client.IP, expiresAt, now)
+		// This checks if the user code is taken and another one can be tried.
+		if dberr.Is(err, dberr.ErrUniqueViolation) && attempt < userCodeAttempts {
+			// If it is, another one is tried.
+			continue
+		}
+		// This checks if an error occurred while storing the codes.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return DeviceAuthorization{}, fmt.Errorf("creating device codes: %w", err)
+		}
+		// The codes are stored.
+		break
+	}
+
+	// verificationURI is the page where the user enters the code.
+	verificationURI := us.cfg.Device.VerificationURL
+	// This checks if no page is configured.
+	if verificationURI == "" {
+		// If none is, the page of the web client at the public address is used.
+		verificationURI = us.cfg.Server.PublicURL + "/device"
+	}
+	// The codes are returned.
+	return DeviceAuthorization{
+		// The DeviceCode field is set to the device code.
+		DeviceCode: deviceCode,
+		// The UserCode field is set to the formatted user code.
+		UserCode: formatUserCode(userCode),
+		// The VerificationURI field is set to the page.
+		VerificationURI: verificationURI,
+		// The VerificationURIComplete field is set to the page with the code in its query string.
+		VerificationURIComplete: verificationURI + "?" + url.Values{"user_code": {formatUserCode(userCode)}}.Encode(),
+		// The ExpiresAt field is set to the time the codes stop working.
+		ExpiresAt: expiresAt,
+		// The Interval field is set to the time between polls.
+		Interval: devicePollInterval,
+	}, nil
+}
+
+// PollDeviceAuthorization answers a device that polls with its device code. Once the user approved the device, its codes are
+// deleted and it gets a session, like a login from the device; until then, the error tells the device whether to keep polling.
+// Each poll is recorded, so that a device that polls too often is told to slow down.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param deviceCode string - The device code.
+// @param client Client - The device the session is issued to.
+// @return User - The user who approved the device.
+// @return JWT - The new session.
+// @return error - ErrAuthorizationPending, ErrSlowDown, ErrAccessDenied, ErrExpiredDeviceCode, or ErrInvalidDeviceCode if no session is issued, or another error if one occurred.
+func (us *UserService) PollDeviceAuthorization(ctx context.Context, deviceCode string, client Client) (User, JWT, error) {
+	// hash is the hash of the device code.
+	hash := hashDeviceCode(deviceCode)
+	// now is the time of the poll.
+	now := us.clock.Now()
+	// user is the user who approved the device.
+	var user User
+	// outcome is the answer of a poll that issues no session. It is kept apart from the error of the transaction,
+	// so that the poll is recorded even though no session is issued.
+	var outcome error
+
+	// err is the result of reading and updating the device in one transaction.
+	err := database.WithTx(ctx, us.db, func(tx *sql.Tx) error {
+		// The outcome starts empty, since the transaction is run again after a serialization failure or a deadlock,
+		// and the outcome of an aborted attempt must not carry over.
+		outcome = nil
+		// status is the state of the device.
+		var status string
+		// userId is the user who approved or denied the device, if one did.
+		var userId uuid.NullUUID
+		// expiresAt is the time the codes stop working.
+		var expiresAt time.Time
+		// lastPolledAt is the time of the previous poll, if there was one.
+		var lastPolledAt sql.NullTime
+		// err is the result of reading and locking the device.
+		err := tx.QueryRowContext(ctx, LockDeviceCodeQuery, hash).Scan(&status, &userId, &expiresAt, &lastPolledAt)
+		// This checks if no device has the code.
+		if errors.Is(err, sql.ErrNoRows) {
+			// If none has, the code is invalid.
+			outcome = ErrInvalidDeviceCode
+			return nil
+		}
+		// This checks if another error occurred while reading the device.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// This checks what the device is told.
+		switch {
+		// The codes expired.
+		case !now.Before(expiresAt):
+			outcome = ErrExpiredDeviceCode
+		// The user denied the device.
+		case status == deviceStatusDenied:
+			outcome = ErrAccessDenied
+		// The user has not decided yet, and the device polled too soon.
+		case status == deviceStatusPending && lastPolledAt.Valid && now.Sub(lastPolledAt.Time) < devicePollInterval*time.Second:
+			outcome = ErrSlowDown
+		// The user has not decided yet.
+		case status == deviceStatusPending:
+			outcome = ErrAuthorizationPending
+		}
+		// This checks if the device keeps polling.
+		if errors.Is(outcome, ErrSlowDown) || errors.Is(outcome, ErrAuthorizationPending) {
+			// If it does, the poll is recorded.
+			_, err := tx.ExecContext(ctx, TouchDeviceCodeQuery, hash, now)
+			// The error, if any, is returned.
+			return err
+		}
+
+		// The codes are deleted, since they are used or can no longer be.
+		if _, err := tx.ExecContext(ctx, DeleteDeviceCodeQuery, hash); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// This checks if the device is told to stop.
+		if outcome != nil {
+			// If it is, nothing else is done.
+			return nil
+		}
+		// The user who approved the device is read.
+		return tx.QueryRowContext(ctx, GetUserProfileByIdQuery, userId.UUID).Scan(&user.ID, &user.Name, &user.Email, &user.Image, &user.Password, &user.JWT, &user.CreatedAt, &user.UpdatedAt, &user.Timezone, &user.Locale)
+	})
+	// This checks if an error occurred while executing the transaction.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return User{}, JWT{}, fmt.Errorf("polling device authorization: %w", err)
+	}
+	// This checks if the device gets no session.
+	if outcome != nil {
+		// If it does not, the answer is returned.
+		return User{}, JWT{}, outcome
+	}
+
+	// jwt is the new session of the device.
+	jwt, err := us.loginToken(ctx, user, client, us.cfg.JWT.Expires)
+	// This checks if an error occurred while issuing the JWT.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return User{}, JWT{}, err
+	}
+	// The device is recorded, and the user is alerted if it is new, as for a login with a password.
+	us.recordDevice(ctx, user, jwt, client, true)
+	// The user and the JWT are returned.
+	return user, jwt, nil
+}
+
+// PendingDevice reads a device that waits for approval, so that the user can check that it is theirs before they approve it.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param userCode string - The user code, as the user typed it.
+// @return DeviceRequest - The device.
+// @return error - ErrDeviceNotFound if no device waits with the code, or another error if one occurred.
+func (us *UserService) PendingDevice(ctx context.Context, userCode string) (DeviceRequest, error) {
+	// device is the device.
+	var device DeviceRequest
+	// err is the result of reading the device.
+	err := us.db.QueryRowContext(ctx, GetPendingDeviceCodeQuery, normalizeUserCode(userCode), us.clock.Now()).Scan(&device.UserCode, &device.ClientName, &device.UserAgent, &device.IP, &device.CreatedAt, &device.ExpiresAt)
+	// This checks if no device waits with the code.
+	if errors.Is(err, sql.ErrNoRows) {
+		// If none does, an error is returned.
+		return DeviceRequest{}, ErrDeviceNotFound
+	}
+	// This checks if another error occurred while reading the device.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return DeviceRequest{}, fmt.Errorf("reading device: %w", err)
+	}
+	// The user code is formatted as it is shown.
+	device.UserCode = formatUserCode(device.UserCode)
+	// The device is returned.
+	return device, nil
+}
+
+// DecideDevice approves or denies a device that waits for approval, on behalf of a user. An approved device gets a session of
+// the user at its next poll, and a denied one is told to stop.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param user User - The user who decides.
+// @param userCode string - The user code, as the user typed it.
+// @param approve bool - Whether the device is approved.
+// @return error - ErrDeviceNotFound if no device waits with the code, or another error if one occurred.
+func (us *UserService) DecideDevice(ctx context.Context, user User, userCode string, approve bool) error {
+	// status is the new state of the device.
+	status := deviceStatusDenied
+	// This checks if the device is approved.
+	if approve {
+		// If it is, it is marked so.
+		status = deviceStatusApproved
+	}
+	// result is the result of deciding on the device.
+	result, err := us.db.ExecContext(ctx, DecideDeviceCodeQuery, normalizeUserCode(userCode), us.clock.Now(), status, user.ID)
+	// This checks if an error occurred while deciding on the device.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return fmt.Errorf("deciding device: %w", err)
+	}
+	// affected is the number of devices that were decided on.
+	affected, err := result.RowsAffected()
+	// This checks if an error occurred while counting the devices.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return fmt.Errorf("deciding device: %w", err)
+	}
+	// This checks if no device waits with the code.
+	if affected == 0 {
+		// If none does, an error is returned.
+		return ErrDeviceNotFound
+	}
+	// No error is returned.
+	return nil
+}
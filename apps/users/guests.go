@@ -0,0 +1,306 @@
+// This file defines guest sessions, which let people try the application before they register.
+// A guest is a user of the guest kind on the guest plan, whose small limits are enforced by the quota package like any other plan.
+// The token of a guest is bound to the device that started the session, which sends the same device ID with every request,
+// so that a token copied to another device is useless. Registering from the session claims the account: the guest becomes
+// a registered user in one transaction, and keeps their todos and lists since they were theirs all along.
+package users
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to pass the deadline of the request to the queries.
+import (
+	"context"
+	// "crypto/sha256" implements the SHA-256 hash. It is used here to hash the device IDs.
+	"crypto/sha256"
+	// "database/sql" provides a generic SQL interface. It is used here to run the claim in a transaction.
+	"database/sql"
+	// "encoding/hex" implements hexadecimal encoding. It is used here to store the hashes of the device IDs as text.
+	"encoding/hex"
+	// "errors" provides functions for working with errors. It is used here to define the guest errors.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to build the email address of a guest and to wrap errors.
+	"fmt"
+	// "log" provides logging functions. It is used here to log the failures of the pruner.
+	"log"
+	// "strings" provides functions for working with strings. It is used here to cut long User-Agent headers.
+	"strings"
+	// "time" provides functions for working with time. It is used here to validate time zones and to schedule the pruner.
+	"time"
+
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time. It is used here to tell which guests have expired.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database helpers.
+	"github.com/rahulcodepython/todo-backend/backend/database"
+	// "github.com/rahulcodepython/todo-backend/backend/dberr" is a local package that classifies database errors. It is used here to recognize a taken email address.
+	"github.com/rahulcodepython/todo-backend/backend/dberr"
+	// "github.com/rahulcodepython/todo-backend/backend/i18n" is a local package that translates messages. It is used here to validate the guest's language.
+	"github.com/rahulcodepython/todo-backend/backend/i18n"
+	// "github.com/rahulcodepython/todo-backend/backend/outbox" is a local package that records domain events.
+	"github.com/rahulcodepython/todo-backend/backend/outbox"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// DeviceIDHeader is the header of the ID a device chose for itself, which binds the token of a guest to the device.
+const DeviceIDHeader = "X-Device-ID"
+
+// guestTokenPrefix is the prefix of the tokens of guests.
+const guestTokenPrefix = "tdg_"
+
+// guestEmailDomain is the domain of the email addresses of guests. The .invalid top-level domain is reserved and never resolves.
+const guestEmailDomain = "guests.invalid"
+
+// guestName is the name of a guest until they register.
+const guestName = "Guest"
+
+// maxDeviceIDLength is the number of bytes a device ID may have.
+const maxDeviceIDLength = 255
+
+// guestPruneInterval is how often the abandoned guests are deleted.
+const guestPruneInterval = time.Hour
+
+// ErrGuestModeDisabled is returned when a guest session is started on a server that does not allow them.
+var ErrGuestModeDisabled = errors.New("guest mode is disabled")
+
+// ErrDeviceIDRequired is returned when a guest session is started without a device ID, or with one that is too long.
+var ErrDeviceIDRequired = errors.New("device ID is required")
+
+// ErrNotGuest is returned when a user who is not a guest tries to claim a guest account.
+var ErrNotGuest = errors.New("user is not a guest")
+
+// GuestInput holds the fields of a request to start a guest session.
+type GuestInput struct {
+	// DeviceID is the ID the device chose for itself, which it sends with every request of the session.
+	DeviceID string
+	// Timezone is the IANA time zone of the guest. It defaults to UTC.
+	Timezone string
+	// Locale is the language of the guest. It defaults to following the Accept-Language header.
+	Locale string
+}
+
+// DeviceHash returns the hash of a device ID, which is what is stored with a guest token.
+//
+// @param deviceId string - The device ID.
+// @return string - The hash, in hexadecimal.
+func DeviceHash(deviceId string) string {
+	// sum is the hash of the ID.
+	sum := sha256.Sum256([]byte(deviceId))
+	// The encoded hash is returned.
+	return hex.EncodeToString(sum[:])
+}
+
+// StartGuest creates a guest and issues their token, bound to the device that asked for it.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param input GuestInput - The fields of the guest.
+// @param client Client - The device the token is issued to.
+// @return User - The new guest.
+// @return JWT - The new token.
+// @return error - ErrGuestModeDisabled if guests are not allowed, ErrDeviceIDRequired, ErrInvalidTimezone, or ErrInvalidLocale if a field is invalid, or another error if one occurred.
+func (us *UserService) StartGuest(ctx context.Context, input GuestInput, client Client) (User, JWT, error) {
+	// This checks if guest sessions are turned off.
+	if !us.cfg.Guest.Enabled {
+		// If they are, an error is returned.
+		return User{}, JWT{}, ErrGuestModeDisabled
+	}
+	// This checks if the device ID is missing or too long.
+	if input.DeviceID == "" || len(input.DeviceID) > maxDeviceIDLength {
+		// If it is, an error is returned.
+		return User{}, JWT{}, ErrDeviceIDRequired
+	}
+	// This checks if no time zone was given.
+	if input.Timezone == "" {
+		// If none was given, UTC is used.
+		input.Timezone = "UTC"
+	}
+	// This checks if the time zone is a valid IANA time zone.
+	if _, err := time.LoadLocation(input.Timezone); err != nil {
+		// If it is not, an error is returned.
+		return User{}, JWT{}, fmt.Errorf("%w: %v", ErrInvalidTimezone, err)
+	}
+	// This checks if the language is given but not supported.
+	if input.Locale != "" && !i18n.Supported(input.Locale) {
+		// If it is not, an error is returned.
+		return User{}, JWT{}, ErrInvalidLocale
+	}
+
+	// token is the token of the guest.
+	token, err := utils.CreateOpaqueToken(guestTokenPrefix)
+	// This checks if the token could not be created.
+	if err != nil {
+		// If it could not, the error is returned.
+		return User{}, JWT{}, fmt.Errorf("creating guest token: %w", err)
+	}
+
+	// now is the creation time of the guest.
+	now := us.clock.Now()
+	// userId is the ID of the guest.
+	userId := us.ids.NewID()
+	// user is the new guest, whose email address is unique since it holds the ID.
+	user := User{ID: userId, Name: guestName, Email: fmt.Sprintf("%s@%s", userId, guestEmailDomain), CreatedAt: now, UpdatedAt: now, Timezone: input.Timezone, Locale: input.Locale}
+	// jwt is the token of the guest.
+	jwt := JWT{ID: us.ids.NewID(), Token: token, ExpiresAt: now.Add(us.cfg.Guest.Expires), Kind: TokenKindGuest}
+
+	// userAgent is the User-Agent header, cut short so that a client cannot store an arbitrarily long one.
+	userAgent := client.UserAgent
+	// This checks if the header is too long.
+	if len(userAgent) > maxUserAgentLength {
+		// If it is, it is cut at a character boundary.
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+	}
+
+	// This creates the guest and the token.
+	if _, err := us.db.ExecContext(ctx, CreateGuestQuery, user.ID, user.Name, user.Email, now, user.Timezone, user.Locale, jwt.ID, jwt.Token, jwt.ExpiresAt, userAgent, client.IP, DeviceHash(input.DeviceID)); err != nil {
+		// If an error occurs, it is returned.
+		return User{}, JWT{}, fmt.Errorf("creating guest: %w", err)
+	}
+	// The guest and the token are returned.
+	return user, jwt, nil
+}
+
+// ClaimGuest registers a guest, who keeps their ID and with it every todo and list they created.
+// The guest becomes a registered user on the free plan, their guest tokens are deleted, and the event of a new user
+// is recorded, all in one transaction, so that a failed claim leaves the guest as they were. A new session is then issued.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param guest User - The guest.
+// @param input RegisterInput - The fields of the new user. An empty time zone or language keeps the one of the guest.
+// @param client Client - The device the new session is issued to.
+// @return User - The registered user.
+// @return JWT - The new session.
+// @return error - ErrNotGuest if the user already registered, ErrMissingFields, ErrEmailTaken, ErrInvalidTimezone, or ErrInvalidLocale if a field is invalid, or another error if one occurred.
+func (us *UserService) ClaimGuest(ctx context.Context, guest User, input RegisterInput, client Client) (User, JWT, error) {
+	// This checks if all required fields are present.
+	if input.Name == "" || input.Email == "" || input.Password == "" {
+		// If any field is missing, an error is returned.
+		return User{}, JWT{}, ErrMissingFields
+	}
+	// This checks if no time zone was given.
+	if input.Timezone == "" {
+		// If none was given, the time zone of the guest is kept.
+		input.Timezone = guest.Timezone
+	}
+	// This checks if the time zone is a valid IANA time zone.
+	if _, err := time.LoadLocation(input.Timezone); err != nil {
+		// If it is not, an error is returned.
+		return User{}, JWT{}, fmt.Errorf("%w: %v", ErrInvalidTimezone, err)
+	}
+	// This checks if no language was given.
+	if input.Locale == "" {
+		// If none was given, the language of the guest is kept.
+		input.Locale = guest.Locale
+	}
+	// This checks if the language is not supported.
+	if input.Locale != "" && !i18n.Supported(input.Locale) {
+		// If it is not, an error is returned.
+		return User{}, JWT{}, ErrInvalidLocale
+	}
+
+	// encryptedPassword is the user's encrypted password.
+	encryptedPassword, err := utils.EncryptPassword(input.Password)
+	// This checks if an error occurred while encrypting the password.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return User{}, JWT{}, fmt.Errorf("encrypting password: %w", err)
+	}
+
+	// user is the registered user, with the ID and creation time of the guest.
+	user := guest
+	user.Name = input.Name
+	user.Email = input.Email
+	user.Password = encryptedPassword
+	user.Timezone = input.Timezone
+	user.Locale = input.Locale
+	user.UpdatedAt = us.clock.Now()
+
+	// err is the result of claiming the account in one transaction.
+	err = database.WithTx(ctx, us.db, func(tx *sql.Tx) error {
+		// result is the result of turning the guest into a registered user.
+		result, err := tx.ExecContext(ctx, ClaimGuestQuery, user.ID, user.Name, user.Email, user.Password, user.Timezone, user.Locale, user.UpdatedAt)
+		// This checks if an error occurred while updating the user.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// This checks if the user was not a guest, such as when the claim was sent twice.
+		if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+			// If they were not, the claim is refused.
+			return ErrNotGuest
+		}
+		// The guest tokens are deleted, since the user now logs in with their password.
+		if _, err := tx.ExecContext(ctx, DeleteGuestTokensQuery, user.ID); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The event is recorded without the password or any token, as for any other sign-up.
+		return outbox.Record(ctx, tx, outbox.UserRegistered, user.ID, user.ID, UserRegisteredEvent{ID: user.ID, Name: user.Name, Email: user.Email, Timezone: user.Timezone, CreatedAt: utils.ParseTime(user.CreatedAt)})
+	})
+	// This checks if the unique index on the email address rejected the user.
+	if errors.Is(err, dberr.ErrUniqueViolation) && dberr.Constraint(err) == emailUniqueConstraint {
+		// If it did, the email address is taken.
+		return User{}, JWT{}, ErrEmailTaken
+	}
+	// This checks if the user was not a guest.
+	if errors.Is(err, ErrNotGuest) {
+		// If they were not, the error is returned.
+		return User{}, JWT{}, err
+	}
+	// This checks if an error occurred while executing the transaction.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return User{}, JWT{}, fmt.Errorf("claiming guest: %w", err)
+	}
+
+	// jwt is the first session of the registered user.
+	jwt, err := us.issueToken(ctx, user, client, us.cfg.JWT.Expires)
+	// This checks if an error occurred while issuing the JWT.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return User{}, JWT{}, err
+	}
+	// The device is remembered, so that the user's later logins from it raise no alert.
+	us.recordDevice(ctx, user, jwt, client, false)
+	// The user and the JWT are returned.
+	return user, jwt, nil
+}
+
+// StartGuestPruner deletes the guests whose tokens have all expired until the context is cancelled.
+// Deleting a guest deletes their todos and lists with them, so abandoned trials do not pile up.
+// It runs even when guest sessions are turned off, so that the guests created before are still pruned.
+//
+// @param ctx context.Context - The context that stops the worker.
+// @param db *sql.DB - The database connection.
+// @param clk clock.Clock - The clock that tells which guest tokens have expired.
+func StartGuestPruner(ctx context.Context, db *sql.DB, clk clock.Clock) {
+	// ticker fires once every prune interval.
+	ticker := time.NewTicker(guestPruneInterval)
+	// This defers stopping the ticker until the worker returns.
+	defer ticker.Stop()
+
+	// This loops until the context is cancelled.
+	for {
+		// This waits for either the next tick or the cancellation.
+		select {
+		case <-ctx.Done():
+			// If the context is cancelled, the worker returns.
+			return
+		case <-ticker.C:
+			// The abandoned guests are pruned.
+			if err := pruneGuests(ctx, db, clk); err != nil {
+				// If an error occurs, it is logged.
+				log.Printf("Unable to prune guests: %v", err)
+			}
+		}
+	}
+}
+
+// pruneGuests deletes the guests whose tokens have all expired by the current time of a clock.
+//
+// @param ctx context.Context - The context of the pass.
+// @param db *sql.DB - The database connection.
+// @param clk clock.Clock - The clock that tells which guest tokens have expired.
+// @return error - An error if one occurred.
+func pruneGuests(ctx context.Context, db *sql.DB, clk clock.Clock) error {
+	// _, err is the result of deleting the abandoned guests.
+	_, err := db.ExecContext(ctx, PruneGuestsQuery, clk.Now())
+	// The error, if any, is returned.
+	return err
+}
@@ -0,0 +1,69 @@
+// This file defines a test of the time the pruners of guests and revoked tokens prune up to.
+package users
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to run the pruners.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here for the type of the pruners.
+	"database/sql"
+	// "database/sql/driver" defines the interfaces of SQL drivers. It is used here to read the arguments of the fake database.
+	"database/sql/driver"
+	// "testing" provides support for automated tests. It is used here to run the test.
+	"testing"
+	// "time" provides functionality for measuring and displaying time. It is used here for the fixed time of the clock.
+	"time"
+
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time. It is used here to fix it.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/database/dbtest" is a local package that provides a fake database.
+	"github.com/rahulcodepython/todo-backend/backend/database/dbtest"
+)
+
+// TestPrunersUseClock checks that the guest pruner and the revoked token pruner prune what expired by the time of their clock.
+//
+// @param t *testing.T - The test state.
+func TestPrunersUseClock(t *testing.T) {
+	// now is the time of the clock.
+	now := time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC)
+
+	// tests are the pruners and their statements.
+	tests := []struct {
+		// name is the name of the pruner.
+		name string
+		// query is the statement of the pruner.
+		query string
+		// prune runs the pruner once.
+		prune func(ctx context.Context, db *sql.DB, clk clock.Clock) error
+	}{
+		{"guests", PruneGuestsQuery, pruneGuests},
+		{"revoked tokens", PruneRevokedTokensQuery, pruneRevokedTokens},
+	}
+
+	// This runs every pruner.
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// times are the times the statement was run with.
+			var times []time.Time
+			// fake is the fake database, which has nothing to prune.
+			fake := dbtest.NewDriver()
+			fake.Handle(tt.query, func(args []driver.NamedValue) (dbtest.Rows, error) {
+				times = append(times, args[0].Value.(time.Time))
+				return dbtest.Rows{RowsAffected: 0}, nil
+			})
+			// db is the fake database.
+			db := dbtest.OpenDB(fake)
+			defer db.Close()
+
+			// This runs the pruner with the fixed clock.
+			if err := tt.prune(context.Background(), db, clock.Fixed(now)); err != nil {
+				// If it fails, the test fails.
+				t.Fatal(err)
+			}
+			// This checks if the statement was not run once, with the time of the clock.
+			if len(times) != 1 || !times[0].Equal(now) {
+				// If it was not, the test fails.
+				t.Fatalf("pruned up to %v, want [%s]", times, now)
+			}
+		})
+	}
+}
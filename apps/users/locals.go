@@ -0,0 +1,82 @@
+// This file defines typed accessors for the authenticated user and JWT that the authentication middlewares store in the Fiber context.
+// The values are stored under keys of an unexported type, so that no other package can overwrite them by reusing a name,
+// and reading them reports an error instead of panicking when a route is mounted without its middlewares.
+package users
+
+// "errors" provides functions for working with errors. It is used here to define the error of a request without an authenticated user.
+import (
+	"errors"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to read and write the locals of a request.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/backend/reqlog" is a local package that provides the logger of a request. It is used here to name the user in it.
+	"github.com/rahulcodepython/todo-backend/backend/reqlog"
+)
+
+// ErrNotAuthenticated is returned when a request has no authenticated user or JWT in its context.
+var ErrNotAuthenticated = errors.New("request is not authenticated")
+
+// localKey is the type of the keys of the locals of the authentication middlewares.
+type localKey int
+
+const (
+	// userLocal is the key of the authenticated user.
+	userLocal localKey = iota
+	// jwtLocal is the key of the JWT of the request.
+	jwtLocal
+)
+
+// SetCurrentUser stores the authenticated user of a request.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param user User - The authenticated user.
+func SetCurrentUser(c *fiber.Ctx, user User) {
+	// The user is stored in the local context.
+	c.Locals(userLocal, user)
+	// The user is added to the logger of the request.
+	reqlog.SetUser(c.UserContext(), user.ID.String())
+}
+
+// CurrentUser returns the authenticated user of a request, stored by the AuthenticatedUser or BasicAuthenticatedUser middleware.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return User - The authenticated user.
+// @return error - ErrNotAuthenticated if the request has no authenticated user.
+func CurrentUser(c *fiber.Ctx) (User, error) {
+	// user is the user stored in the local context, if any.
+	user, ok := c.Locals(userLocal).(User)
+	// This checks if there is no user.
+	if !ok {
+		// If there is not, an error is returned.
+		return User{}, ErrNotAuthenticated
+	}
+	// The user is returned.
+	return user, nil
+}
+
+// SetCurrentJWT stores the JWT a request is authenticated with.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param jwt JWT - The JWT.
+func SetCurrentJWT(c *fiber.Ctx, jwt JWT) {
+	// The JWT is stored in the local context.
+	c.Locals(jwtLocal, jwt)
+}
+
+// CurrentJWT returns the JWT a request is authenticated with, stored by the Authenticated middleware.
+// Requests authenticated with HTTP Basic authentication have none.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return JWT - The JWT.
+// @return error - ErrNotAuthenticated if the request has no JWT.
+func CurrentJWT(c *fiber.Ctx) (JWT, error) {
+	// jwt is the JWT stored in the local context, if any.
+	jwt, ok := c.Locals(jwtLocal).(JWT)
+	// This checks if there is no JWT.
+	if !ok {
+		// If there is not, an error is returned.
+		return JWT{}, ErrNotAuthenticated
+	}
+	// The JWT is returned.
+	return jwt, nil
+}
@@ -1,14 +1,31 @@
 // This file defines the data models for users and JWTs.
 package users
 
-// "time" provides functions for working with time. It is used here to define the CreatedAt and UpdatedAt fields.
+// "database/sql" provides a generic SQL interface. It is used here to define the nullable last-used time of a session.
 import (
+	"database/sql"
+	// "time" provides functions for working with time. It is used here to define the CreatedAt and UpdatedAt fields.
 	"time"
 
 	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to define the ID and JWT fields.
 	"github.com/google/uuid"
 )
 
+// RoleAdmin is the role of the users that can access the admin endpoints.
+const RoleAdmin = "admin"
+
+// RoleUser is the role every user has unless they are made an admin.
+const RoleUser = "user"
+
+// KindHuman is the kind of the users who register and log in with a password.
+const KindHuman = "human"
+
+// KindService is the kind of the users that are service accounts: they have no password, and authenticate with client credentials.
+const KindService = "service"
+
+// KindGuest is the kind of the users who started a guest session: they have no password until they claim the account by registering.
+const KindGuest = "guest"
+
 // User represents the structure of a user in the application.
 type User struct {
 	// ID is the unique identifier for the user.
@@ -20,13 +37,13 @@ type User struct {
 	// Email is the user's email address.
 	// json:"email" specifies that this field should be marshalled to/from a JSON object with the key "email".
 	Email string `json:"email"`
-	// Image is the user's profile image.
+	// Image is the user's profile image, or nil if the user has none, which the database stores as NULL.
 	// json:"image" specifies that this field should be marshalled to/from a JSON object with the key "image".
-	Image string `json:"image"`
+	Image *string `json:"image"`
 	// Password is the user's hashed password.
 	// json:"-" specifies that this field should be omitted from JSON serialization.
 	Password string `json:"-"`
-	// JWT is the user's JSON Web Token.
+	// JWT is the ID of the most recent session of the user. Every unexpired session in jwt_tokens is valid, not only this one.
 	// json:"-" specifies that this field should be omitted from JSON serialization.
 	JWT uuid.NullUUID `json:"-"`
 	// CreatedAt is the time the user was created.
@@ -35,6 +52,27 @@ type User struct {
 	// UpdatedAt is the time the user was last updated.
 	// json:"updated_at" specifies that this field should be marshalled to/from a JSON object with the key "updated_at".
 	UpdatedAt time.Time `json:"updated_at"`
+	// Timezone is the IANA name of the user's time zone, such as "Asia/Kolkata".
+	// json:"timezone" specifies that this field should be marshalled to/from a JSON object with the key "timezone".
+	Timezone string `json:"timezone"`
+	// Locale is the language the user chose for API responses, such as "es", or empty to follow the Accept-Language header.
+	// json:"locale" specifies that this field should be marshalled to/from a JSON object with the key "locale".
+	Locale string `json:"locale"`
+}
+
+// Location returns the user's time zone, falling back to UTC if it cannot be loaded.
+//
+// @return *time.Location - The user's time zone.
+func (u User) Location() *time.Location {
+	// location is the loaded time zone.
+	location, err := time.LoadLocation(u.Timezone)
+	// This checks if the time zone could not be loaded.
+	if err != nil || u.Timezone == "" {
+		// If it could not, UTC is returned.
+		return time.UTC
+	}
+	// The loaded time zone is returned.
+	return location
 }
 
 // JWT represents the structure of a JSON Web Token.
@@ -48,4 +86,44 @@ type JWT struct {
 	// ExpiresAt is the expiration time of the JWT.
 	// json:"expires_at" specifies that this field should be marshalled to/from a JSON object with the key "expires_at".
 	ExpiresAt time.Time `json:"expires_at"`
-}
\ No newline at end of file
+	// Scopes is the list of scopes the token is limited to, or nil if it may do everything its user can.
+	// json:"scopes,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "scopes", and should be omitted if empty.
+	Scopes []string `json:"scopes,omitempty"`
+	// Kind is the kind of the token: a session, an API key, or the token of a service account.
+	// json:"kind" specifies that this field should be marshalled to/from a JSON object with the key "kind".
+	Kind string `json:"kind"`
+}
+
+// Session is a JWT as its user sees it: the device it was issued to and when it was last used.
+type Session struct {
+	// ID is the ID of the JWT.
+	ID uuid.UUID
+	// UserAgent is the User-Agent header of the request the JWT was issued to.
+	UserAgent string
+	// IP is the IP address the JWT was last used from.
+	IP string
+	// CreatedAt is the time the JWT was issued.
+	CreatedAt time.Time
+	// LastUsedAt is the time the JWT was last used, recorded at most once per interval, or null if it was never used.
+	LastUsedAt sql.NullTime
+	// ExpiresAt is the expiration time of the JWT.
+	ExpiresAt time.Time
+}
+
+// APIKey is a token a user created for another tool, limited to the scopes it was given.
+type APIKey struct {
+	// ID is the ID of the key.
+	ID uuid.UUID
+	// Name is the name the user gave the key, so that they can tell their keys apart.
+	Name string
+	// Scopes is the list of scopes the key is limited to.
+	Scopes []string
+	// Token is the key itself. It is only known when the key is created, since listing the keys does not read it.
+	Token string
+	// CreatedAt is the time the key was created.
+	CreatedAt time.Time
+	// LastUsedAt is the time the key was last used, or null if it was never used.
+	LastUsedAt sql.NullTime
+	// ExpiresAt is the expiration time of the key.
+	ExpiresAt time.Time
+}
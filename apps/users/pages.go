@@ -0,0 +1,155 @@
+// This file defines the pages that the links of account emails open in a browser. A link only shows a page that asks
+// before anything changes, and the button of the page posts its token back, so that a mail scanner or a link preview
+// that fetches every link of an email cannot act on the user's behalf.
+package users
+
+// "bytes" provides functions for working with byte slices. It is used here to render a page before it is sent.
+import (
+	"bytes"
+	// "errors" provides functions for working with errors. It is used here to tell the invalid links apart.
+	"errors"
+	// "html/template" implements HTML templates that escape their data. It is used here to render the pages.
+	"html/template"
+	// "strings" provides functions for working with strings. It is used here to recognize the forms of the pages.
+	"strings"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to send the pages.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/backend/i18n" is a local package that translates messages. It is used here to translate the pages.
+	"github.com/rahulcodepython/todo-backend/backend/i18n"
+	// "github.com/rahulcodepython/todo-backend/backend/reqlog" is a local package that provides the logger of a request. It is used here to log the failures a page hides.
+	"github.com/rahulcodepython/todo-backend/backend/reqlog"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+)
+
+// pageTemplate renders a page of a link: a question with a button that posts the token back, or the outcome of the link.
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+</head>
+<body style="font-family: sans-serif; max-width: 32rem; margin: 4rem auto; padding: 0 1rem;">
+<h1>{{.Title}}</h1>
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+{{if .Button}}<form method="post" action="{{.Action}}">
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">{{.Button}}</button>
+</form>{{end}}
+</body>
+</html>
+`))
+
+// page holds the text of a page of a link.
+type page struct {
+	// Lang is the language of the page.
+	Lang string
+	// Title is the heading of the page.
+	Title string
+	// Message is the text under the heading, or empty.
+	Message string
+	// Action is the path the button posts to.
+	Action string
+	// Token is the token of the link, which the button posts back.
+	Token string
+	// Button is the label of the button, or empty for a page without one.
+	Button string
+}
+
+// confirmLink sends the page a link opens, which asks before anything changes and posts the token of the link back to its path.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param title string - The question of the page, translated before it is shown.
+// @param message string - The text under the question, translated before it is shown.
+// @param button string - The label of the button, translated before it is shown.
+// @return error - An error if one occurred while sending the page.
+func confirmLink(c *fiber.Ctx, title string, message string, button string) error {
+	// The question is sent with a button that posts the token of the link to the same path.
+	return sendPage(c, fiber.StatusOK, page{Title: i18n.T(c, title), Message: i18n.T(c, message), Action: c.Path(), Token: c.Query("token"), Button: i18n.T(c, button)})
+}
+
+// linkOutcome answers the request that applies a link. The form of a page gets a page, and any other client,
+// such as an app that posts the token itself, gets the usual JSON response.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param err error - The error of applying the link, or nil.
+// @param errorMessage string - The message of an unexpected error.
+// @param message string - The message of success.
+// @return error - An error if one occurred while sending the response.
+func linkOutcome(c *fiber.Ctx, err error, errorMessage string, message string) error {
+	// This checks if the request was not sent by the form of a page.
+	if !strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEApplicationForm) {
+		// This checks if the link could not be applied.
+		if err != nil {
+			// If it could not, the matching error response is returned.
+			return userErrorResponse(c, err, errorMessage)
+		}
+		// An OK response is returned with the message.
+		return response.OKResponse(c, message, nil)
+	}
+	// This checks if the link could not be applied.
+	if err != nil {
+		// If it could not, a page with the reason is sent.
+		return linkErrorPage(c, err, errorMessage)
+	}
+	// A page with the message is sent.
+	return sendPage(c, fiber.StatusOK, page{Title: i18n.T(c, message)})
+}
+
+// linkErrorPage sends the page of a link that cannot be used, naming the reason when the link itself is at fault.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param err error - The error of the link.
+// @param errorMessage string - The message of an unexpected error.
+// @return error - An error if one occurred while sending the page.
+func linkErrorPage(c *fiber.Ctx, err error, errorMessage string) error {
+	// This checks which link was refused.
+	switch {
+	// The revoke link is malformed, tampered with, or expired.
+	case errors.Is(err, ErrInvalidRevokeLink):
+		// A page with the reason is sent.
+		return sendPage(c, fiber.StatusBadRequest, page{Title: i18n.T(c, "Invalid or expired revoke link")})
+	// The email change link is malformed, tampered with, expired, or already used.
+	case errors.Is(err, ErrInvalidEmailChangeLink):
+		// A page with the reason is sent.
+		return sendPage(c, fiber.StatusBadRequest, page{Title: i18n.T(c, "Invalid or expired email change link")})
+	// Another user took the new address after the change was requested.
+	case errors.Is(err, ErrEmailTaken):
+		// A page with the reason is sent.
+		return sendPage(c, fiber.StatusConflict, page{Title: i18n.T(c, "This email already is ready used. Try something new!")})
+	}
+	// Any other error is logged, since the page does not show it.
+	reqlog.Ctx(c).Printf("%s: %v", errorMessage, err)
+	// A page with the message is sent.
+	return sendPage(c, fiber.StatusInternalServerError, page{Title: i18n.T(c, errorMessage)})
+}
+
+// sendPage renders and sends a page of a link. The page may not be cached, framed, or leak its token in a referrer.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param status int - The HTTP status of the page.
+// @param p page - The text of the page.
+// @return error - An error if one occurred while rendering or sending the page.
+func sendPage(c *fiber.Ctx, status int, p page) error {
+	// The language of the page is the locale of the request.
+	p.Lang = i18n.Locale(c)
+	// out is the rendered page.
+	var out bytes.Buffer
+	// This renders the page.
+	if err := pageTemplate.Execute(&out, p); err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return response.InternelServerError(c, err, "Unable to render page")
+	}
+	// The page carries a token, so it is not kept by caches.
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	// The token in the address is not sent to other sites.
+	c.Set(fiber.HeaderReferrerPolicy, "no-referrer")
+	// The page runs no scripts, loads nothing, only posts to this server, and cannot be framed by another site to trick a click.
+	c.Set(fiber.HeaderContentSecurityPolicy, "default-src 'none'; style-src 'unsafe-inline'; form-action 'self'; frame-ancestors 'none'")
+	// The page is HTML.
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	// The page is sent.
+	return c.Status(status).Send(out.Bytes())
+}
@@ -0,0 +1,303 @@
+// This file defines a test of the pages the links of account emails open, which only act once their button is pressed.
+package users
+
+// "database/sql/driver" defines the interfaces of SQL drivers. It is used here to read the arguments of the fake database.
+import (
+	"database/sql/driver"
+	// "io" provides basic I/O primitives. It is used here to read the pages.
+	"io"
+	// "net/http/httptest" provides utilities for HTTP testing. It is used here to build the requests.
+	"net/http/httptest"
+	// "net/url" provides functions for parsing URLs. It is used here to read the token of the link and to post it back.
+	"net/url"
+	// "strings" provides functions for working with strings. It is used here to check the pages and to send the forms.
+	"strings"
+	// "sync" provides synchronization primitives. It is used here to guard the state of the fake database.
+	"sync"
+	// "testing" provides support for automated tests. It is used here to run the test.
+	"testing"
+	// "time" provides functionality for measuring and displaying time. It is used here for the fixed time of the clock.
+	"time"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to serve the pages.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here for the IDs of the session, the user, and the email change.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time. It is used here to fix it.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/database/dbtest" is a local package that provides a fake database.
+	"github.com/rahulcodepython/todo-backend/backend/database/dbtest"
+	// "github.com/rahulcodepython/todo-backend/backend/idgen" is a local package that creates IDs.
+	"github.com/rahulcodepython/todo-backend/backend/idgen"
+)
+
+// TestRevokeLinkAsksFirst checks that opening the revoke link of a new device alert only shows a page that asks, and that the
+// session ends once the form of the page posts the token back. A broken link shows a page that says so and ends nothing.
+//
+// @param t *testing.T - The test state.
+func TestRevokeLinkAsksFirst(t *testing.T) {
+	// now is the time of the clock.
+	now := time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC)
+	// session is the session the link names.
+	session := JWT{ID: uuid.New(), ExpiresAt: now.Add(time.Hour)}
+
+	// mu guards the ended sessions.
+	var mu sync.Mutex
+	// ended are the IDs of the sessions the fake database ended.
+	var ended []string
+	// fake is the fake database.
+	fake := dbtest.NewDriver()
+	// A session is ended.
+	fake.Handle(DeleteJWTByIdQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		ended = append(ended, args[0].Value.(string))
+		return dbtest.Rows{RowsAffected: 1}, nil
+	})
+	// db is the fake database.
+	db := dbtest.OpenDB(fake)
+	t.Cleanup(func() { db.Close() })
+
+	// cfg is the configuration, of which only the signing key and the public address are read.
+	cfg := &config.Config{JWT: config.JWTConfig{SecretKey: "secret"}, Server: config.ServerConfig{PublicURL: "https://todo.example"}}
+	// service is the user service with the fixed clock.
+	service := NewUserService(cfg, db, clock.Fixed(now), &idgen.Sequence{}, nil)
+	// controller is the user controller over the service.
+	controller := NewUserControl(service)
+	// app serves the pages of the link.
+	app := fiber.New()
+	app.Get("/auth/sessions/revoke", controller.RevokeSessionPageController)
+	app.Post("/auth/sessions/revoke", controller.RevokeSessionController)
+
+	// link is the revoke link of the session.
+	link, err := service.revokeLink(session)
+	// This checks if the link could not be built.
+	if err != nil {
+		// If it could not, the test fails.
+		t.Fatal(err)
+	}
+	// parsed is the link, whose token is posted back.
+	parsed, err := url.Parse(link)
+	// This checks if the link cannot be parsed.
+	if err != nil {
+		// If it cannot, the test fails.
+		t.Fatal(err)
+	}
+	// token is the token of the link.
+	token := parsed.Query().Get("token")
+
+	// This opens the link, as a mail scanner would.
+	status, body := send(t, app, fiber.MethodGet, "/auth/sessions/revoke?token="+url.QueryEscape(token), nil)
+	// This checks if the link did not show the form, or ended the session.
+	if status != fiber.StatusOK || !strings.Contains(body, `<form method="post" action="/auth/sessions/revoke">`) || !strings.Contains(body, `value="`+token+`"`) || len(ended) != 0 {
+		// If it did, the test fails.
+		t.Fatalf("GET: status = %d, ended = %v, body = %s, want the form and no session ended", status, ended, body)
+	}
+
+	// This opens a broken link.
+	status, body = send(t, app, fiber.MethodGet, "/auth/sessions/revoke?token=broken", nil)
+	// This checks if the broken link did not say so.
+	if status != fiber.StatusBadRequest || strings.Contains(body, "<form") || !strings.Contains(body, "Invalid or expired revoke link") {
+		// If it did not, the test fails.
+		t.Fatalf("GET broken: status = %d, body = %s, want %d and the reason", status, body, fiber.StatusBadRequest)
+	}
+
+	// This posts the form with a broken token.
+	status, _ = send(t, app, fiber.MethodPost, "/auth/sessions/revoke", url.Values{"token": {"broken"}})
+	// This checks if the broken token was not refused, or ended a session.
+	if status != fiber.StatusBadRequest || len(ended) != 0 {
+		// If it was not, the test fails.
+		t.Fatalf("POST broken: status = %d, ended = %v, want %d and no session ended", status, ended, fiber.StatusBadRequest)
+	}
+
+	// This posts the form of the page.
+	status, body = send(t, app, fiber.MethodPost, "/auth/sessions/revoke", url.Values{"token": {token}})
+	// This checks if the session was not ended, or the outcome was not shown as a page.
+	if status != fiber.StatusOK || !strings.Contains(body, "Session revoked successfully") || len(ended) != 1 || ended[0] != session.ID.String() {
+		// If it was not, the test fails.
+		t.Fatalf("POST: status = %d, ended = %v, body = %s, want the session %s ended", status, ended, body, session.ID)
+	}
+}
+
+// TestEmailChangeLinksAskFirst checks that opening the links of an email change only shows a page that asks, and that the
+// change is applied or dropped once the form of the page posts the token back. A used confirmation link shows a page that says so.
+//
+// @param t *testing.T - The test state.
+func TestEmailChangeLinksAskFirst(t *testing.T) {
+	// now is the time of the clock.
+	now := time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC)
+	// userId is the ID of the user whose address changes, and changeId the ID of the change.
+	userId, changeId := uuid.New(), uuid.New()
+
+	// mu guards the change and the statements.
+	var mu sync.Mutex
+	// pending reports whether the change is still waiting.
+	pending := true
+	// applied and cancelled report whether the change was applied and dropped.
+	var applied, cancelled bool
+	// fake is the fake database, which holds the change.
+	fake := dbtest.NewDriver()
+	// The change is found while it is waiting.
+	fake.Handle(CheckEmailChangeQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		// rows holds the new address, if the change is waiting.
+		rows := dbtest.Rows{Columns: []string{"new_email"}}
+		// This checks if the change is waiting.
+		if pending {
+			rows.Values = [][]driver.Value{{"new@example.com"}}
+		}
+		return rows, nil
+	})
+	// The change is taken while it is waiting.
+	fake.Handle(TakeEmailChangeQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		// rows holds the user and the new address, if the change is waiting.
+		rows := dbtest.Rows{Columns: []string{"user_id", "new_email"}}
+		// This checks if the change is waiting.
+		if pending {
+			pending = false
+			rows.Values = [][]driver.Value{{userId.String(), "new@example.com"}}
+		}
+		return rows, nil
+	})
+	// The address is changed.
+	fake.Handle(UpdateUserEmailQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		applied = true
+		return dbtest.Rows{RowsAffected: 1}, nil
+	})
+	// The sessions of the user are ended.
+	fake.Handle(DeleteSessionsQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		return dbtest.Rows{RowsAffected: 1}, nil
+	})
+	// The change is dropped.
+	fake.Handle(DeleteEmailChangeQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		cancelled = true
+		return dbtest.Rows{RowsAffected: 1}, nil
+	})
+	// db is the fake database.
+	db := dbtest.OpenDB(fake)
+	t.Cleanup(func() { db.Close() })
+
+	// cfg is the configuration, of which only the signing key and the public address are read.
+	cfg := &config.Config{JWT: config.JWTConfig{SecretKey: "secret"}, Server: config.ServerConfig{PublicURL: "https://todo.example"}}
+	// service is the user service with the fixed clock.
+	service := NewUserService(cfg, db, clock.Fixed(now), &idgen.Sequence{}, nil)
+	// controller is the user controller over the service.
+	controller := NewUserControl(service)
+	// app serves the pages of the links.
+	app := fiber.New()
+	app.Get("/auth/email/confirm", controller.ConfirmEmailChangePageController)
+	app.Post("/auth/email/confirm", controller.ConfirmEmailChangeController)
+	app.Get("/auth/email/cancel", controller.CancelEmailChangePageController)
+	app.Post("/auth/email/cancel", controller.CancelEmailChangeController)
+
+	// token returns the token of a link of the change.
+	token := func(purpose string, action string) string {
+		// link is the link of the change.
+		link, err := service.emailChangeLink(purpose, action, changeId, now.Add(time.Hour))
+		// This checks if the link could not be built.
+		if err != nil {
+			// If it could not, the test fails.
+			t.Fatal(err)
+		}
+		// parsed is the link, whose token is posted back.
+		parsed, err := url.Parse(link)
+		// This checks if the link cannot be parsed.
+		if err != nil {
+			// If it cannot, the test fails.
+			t.Fatal(err)
+		}
+		// The token of the link is returned.
+		return parsed.Query().Get("token")
+	}
+	// confirmToken and cancelToken are the tokens of the links to the new and the old address.
+	confirmToken, cancelToken := token(confirmEmailChangePurpose, "confirm"), token(cancelEmailChangePurpose, "cancel")
+
+	// This opens both links, as a mail scanner would.
+	for _, target := range []string{"/auth/email/confirm?token=" + url.QueryEscape(confirmToken), "/auth/email/cancel?token=" + url.QueryEscape(cancelToken)} {
+		// status and body are the response to opening the link.
+		status, body := send(t, app, fiber.MethodGet, target, nil)
+		// This checks if the link did not show the form, or acted.
+		if status != fiber.StatusOK || !strings.Contains(body, "<form") || applied || cancelled || !pending {
+			// If it did, the test fails.
+			t.Fatalf("GET %s: status = %d, applied = %v, cancelled = %v, body = %s, want the form and no change", target, status, applied, cancelled, body)
+		}
+	}
+
+	// This opens the confirmation link as a cancel link, whose purpose it was not signed for.
+	status, body := send(t, app, fiber.MethodGet, "/auth/email/cancel?token="+url.QueryEscape(confirmToken), nil)
+	// This checks if the link was not refused.
+	if status != fiber.StatusBadRequest || strings.Contains(body, "<form") {
+		// If it was not, the test fails.
+		t.Fatalf("GET cancel with the confirmation token: status = %d, want %d", status, fiber.StatusBadRequest)
+	}
+
+	// This posts the form of the confirmation page.
+	status, body = send(t, app, fiber.MethodPost, "/auth/email/confirm", url.Values{"token": {confirmToken}})
+	// This checks if the change was not applied, or the outcome was not shown as a page.
+	if status != fiber.StatusOK || !applied || !strings.Contains(body, "Email changed successfully") {
+		// If it was not, the test fails.
+		t.Fatalf("POST confirm: status = %d, applied = %v, body = %s, want the change applied", status, applied, body)
+	}
+
+	// This opens the used confirmation link again.
+	status, body = send(t, app, fiber.MethodGet, "/auth/email/confirm?token="+url.QueryEscape(confirmToken), nil)
+	// This checks if the used link did not say so.
+	if status != fiber.StatusBadRequest || strings.Contains(body, "<form") || !strings.Contains(body, "Invalid or expired email change link") {
+		// If it did not, the test fails.
+		t.Fatalf("GET used confirm: status = %d, body = %s, want %d and the reason", status, body, fiber.StatusBadRequest)
+	}
+
+	// This posts the cancel link as a client that sends the token itself.
+	status, body = send(t, app, fiber.MethodPost, "/auth/email/cancel?token="+url.QueryEscape(cancelToken), nil)
+	// This checks if the change was not dropped, or the outcome was not answered as JSON.
+	if status != fiber.StatusOK || !cancelled || !strings.HasPrefix(body, "{") {
+		// If it was not, the test fails.
+		t.Fatalf("POST cancel: status = %d, cancelled = %v, body = %s, want the change dropped and JSON", status, cancelled, body)
+	}
+}
+
+// send sends a request to an app, with a form when one is given, and returns the status and the body of the response.
+//
+// @param t *testing.T - The test state.
+// @param app *fiber.App - The app that serves the request.
+// @param method string - The method of the request.
+// @param target string - The path and query of the request.
+// @param form url.Values - The form of the request, or nil.
+// @return int - The status of the response.
+// @return string - The body of the response.
+func send(t *testing.T, app *fiber.App, method string, target string, form url.Values) (int, string) {
+	// req is the request, which carries the form when there is one.
+	req := httptest.NewRequest(method, target, nil)
+	// This checks if there is a form.
+	if form != nil {
+		// If there is, it is sent as the body.
+		req = httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationForm)
+	}
+	// resp is the response to the request.
+	resp, err := app.Test(req)
+	// This checks if the request failed.
+	if err != nil {
+		// If it did, the test fails.
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	// body is the body of the response.
+	body, err := io.ReadAll(resp.Body)
+	// This checks if the body cannot be read.
+	if err != nil {
+		// If it cannot, the test fails.
+		t.Fatal(err)
+	}
+	// The status and the body are returned.
+	return resp.StatusCode, string(body)
+}
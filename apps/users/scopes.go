@@ -0,0 +1,82 @@
+// This file defines the scopes that limit what an API key can do.
+package users
+
+// "fmt" provides functions for formatted I/O. It is used here to name an invalid scope.
+import (
+	"fmt"
+	// "slices" provides functions for working with slices. It is used here to look up and sort scopes.
+	"slices"
+)
+
+// TokenKindSession is the kind of the tokens issued by register and login, which may do everything their user can.
+const TokenKindSession = "session"
+
+// TokenKindAPIKey is the kind of the tokens a user creates for other tools, which may only do what their scopes allow.
+const TokenKindAPIKey = "api_key"
+
+// TokenKindService is the kind of the tokens service accounts get from their client credentials, which may only do what the scopes of the account allow.
+const TokenKindService = "service"
+
+// TokenKindGuest is the kind of the tokens of guests, which only work from the device that started the session.
+const TokenKindGuest = "guest"
+
+// The scopes are named after a resource and an access level. A write scope does not imply the read scope of its resource,
+// so that a key can be made to only add todos without reading them back.
+const (
+	// ScopeTodosRead allows reading todos.
+	ScopeTodosRead = "todos:read"
+	// ScopeTodosWrite allows creating, changing, and deleting todos.
+	ScopeTodosWrite = "todos:write"
+	// ScopeListsRead allows reading lists.
+	ScopeListsRead = "lists:read"
+	// ScopeListsWrite allows creating and changing lists.
+	ScopeListsWrite = "lists:write"
+	// ScopeProfileRead allows reading the profile, usage, and sessions of the user.
+	ScopeProfileRead = "profile:read"
+	// ScopeProfileWrite allows changing the preferences of the user.
+	ScopeProfileWrite = "profile:write"
+	// ScopeMediaRead allows reading stored images.
+	ScopeMediaRead = "media:read"
+	// ScopeMediaWrite allows uploading images.
+	ScopeMediaWrite = "media:write"
+)
+
+// Scopes is every scope an API key can be given.
+var Scopes = []string{ScopeTodosRead, ScopeTodosWrite, ScopeListsRead, ScopeListsWrite, ScopeProfileRead, ScopeProfileWrite, ScopeMediaRead, ScopeMediaWrite}
+
+// ValidateScopes checks the scopes of a new API key or service account.
+//
+// @param scopes []string - The requested scopes.
+// @return []string - The scopes, sorted and without repetitions.
+// @return error - ErrNoScopes if there are none, or ErrInvalidScope naming a scope that does not exist.
+func ValidateScopes(scopes []string) ([]string, error) {
+	// This checks if no scope is given.
+	if len(scopes) == 0 {
+		// If none is, an error is returned.
+		return nil, ErrNoScopes
+	}
+	// This iterates over the scopes.
+	for _, scope := range scopes {
+		// This checks if the scope does not exist.
+		if !slices.Contains(Scopes, scope) {
+			// If it does not, an error naming it is returned.
+			return nil, fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+		}
+	}
+	// The sorted scopes without repetitions are returned.
+	return slices.Compact(slices.Sorted(slices.Values(scopes))), nil
+}
+
+// HasScope reports whether the token allows a scope. A token without scopes is a session, which allows every scope.
+//
+// @param scope string - The scope.
+// @return bool - Whether the token allows the scope.
+func (j JWT) HasScope(scope string) bool {
+	// This checks if the token is not limited to scopes.
+	if j.Scopes == nil {
+		// If it is not, every scope is allowed.
+		return true
+	}
+	// Otherwise the scope must be one of the scopes of the token.
+	return slices.Contains(j.Scopes, scope)
+}
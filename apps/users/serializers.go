@@ -2,7 +2,11 @@
 package users
 
 // "github.com/google/uuid" is a package for working with UUIDs. It is used here to define the ID field in the response struct.
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions. It is used here to format times.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
 
 // registerUserRequest defines the structure for a user registration request.
 type registerUserRequest struct {
@@ -21,10 +25,17 @@ type registerUserRequest struct {
 	// json:"password" specifies that this field should be marshalled to/from a JSON object with the key "password".
 	// validate:"required,min=6" specifies that this field is required and has a minimum length of 6.
 	Password string `json:"password" validate:"required,min=6"`
+	// Timezone is the optional IANA name of the user's time zone. It defaults to UTC.
+	// json:"timezone" specifies that this field should be marshalled to/from a JSON object with the key "timezone".
+	Timezone string `json:"timezone"`
+	// Locale is the optional language for API responses, such as "es". It defaults to following the Accept-Language header.
+	// json:"locale" specifies that this field should be marshalled to/from a JSON object with the key "locale".
+	Locale string `json:"locale"`
 }
 
-// register_loginUserResponse defines the structure for a user registration or login response.
-type register_loginUserResponse struct {
+// ProfileResponse defines the structure for the profile of a user, as the user sees it.
+// It lists the public fields one by one, so that a field added to User, such as a secret, is never sent unless it is added here as well.
+type ProfileResponse struct {
 	// ID is the user's ID.
 	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
 	ID uuid.UUID `json:"id"`
@@ -34,21 +45,78 @@ type register_loginUserResponse struct {
 	// Email is the user's email address.
 	// json:"email" specifies that this field should be marshalled to/from a JSON object with the key "email".
 	Email string `json:"email"`
-	// Image is the user's profile image.
+	// Image is the user's profile image, or nil if the user has none.
 	// json:"image" specifies that this field should be marshalled to/from a JSON object with the key "image".
-	Image string `json:"image"`
+	Image *string `json:"image"`
+	// CreatedAt is the time the user was created.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt string `json:"created_at"`
+	// UpdatedAt is the time the user was last updated.
+	// json:"updated_at" specifies that this field should be marshalled to/from a JSON object with the key "updated_at".
+	UpdatedAt string `json:"updated_at"`
+	// Timezone is the IANA name of the user's time zone.
+	// json:"timezone" specifies that this field should be marshalled to/from a JSON object with the key "timezone".
+	Timezone string `json:"timezone"`
+	// Locale is the language the user chose for API responses, or empty to follow the Accept-Language header.
+	// json:"locale" specifies that this field should be marshalled to/from a JSON object with the key "locale".
+	Locale string `json:"locale"`
+}
+
+// NewProfileResponse converts a user into the response structure of their profile.
+//
+// @param user User - The user.
+// @return ProfileResponse - The response structure.
+func NewProfileResponse(user User) ProfileResponse {
+	// A new ProfileResponse struct is returned.
+	return ProfileResponse{
+		// The ID field is set to the user's ID.
+		ID: user.ID,
+		// The Name field is set to the user's name.
+		Name: user.Name,
+		// The Email field is set to the user's email address.
+		Email: user.Email,
+		// The Image field is set to the user's profile image, if any.
+		Image: user.Image,
+		// The CreatedAt field is set to the user's creation time.
+		CreatedAt: utils.ParseTime(user.CreatedAt),
+		// The UpdatedAt field is set to the user's last update time.
+		UpdatedAt: utils.ParseTime(user.UpdatedAt),
+		// The Timezone field is set to the user's time zone.
+		Timezone: user.Timezone,
+		// The Locale field is set to the user's language.
+		Locale: user.Locale,
+	}
+}
+
+// register_loginUserResponse defines the structure for a user registration or login response.
+type register_loginUserResponse struct {
+	// ProfileResponse is the user's profile, whose fields are part of the response object.
+	ProfileResponse
 	// Token is the user's JWT.
 	// json:"token,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "token", and should be omitted if empty.
 	Token string `json:"token,omitempty"`
 	// ExpiresAt is the expiration time of the JWT.
 	// json:"expires_at,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "expires_at", and should be omitted if empty.
 	ExpiresAt string `json:"expires_at,omitempty"`
-	// CreatedAt is the time the user was created.
+}
+
+// UserRegisteredEvent defines the structure for the data of a user.registered event.
+type UserRegisteredEvent struct {
+	// ID is the ID of the user.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// Name is the name of the user.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+	// Email is the email address of the user.
+	// json:"email" specifies that this field should be marshalled to/from a JSON object with the key "email".
+	Email string `json:"email"`
+	// Timezone is the IANA time zone of the user.
+	// json:"timezone" specifies that this field should be marshalled to/from a JSON object with the key "timezone".
+	Timezone string `json:"timezone"`
+	// CreatedAt is the time the user registered.
 	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
 	CreatedAt string `json:"created_at"`
-	// UpdatedAt is the time the user was last updated.
-	// json:"updated_at" specifies that this field should be marshalled to/from a JSON object with the key "updated_at".
-	UpdatedAt string `json:"updated_at"`
 }
 
 // loginUserRequest defines the structure for a user login request.
@@ -61,4 +129,282 @@ type loginUserRequest struct {
 	// json:"password" specifies that this field should be marshalled to/from a JSON object with the key "password".
 	// validate:"required,min=6" specifies that this field is required and has a minimum length of 6.
 	Password string `json:"password" validate:"required,min=6"`
-}
\ No newline at end of file
+	// RememberMe reports whether the session is kept for the longer lifetime, rather than the default one.
+	// json:"remember_me" specifies that this field should be marshalled to/from a JSON object with the key "remember_me".
+	RememberMe bool `json:"remember_me"`
+}
+
+// startGuestRequest defines the structure for a request to start a guest session. The device ID is sent in the X-Device-ID header.
+type startGuestRequest struct {
+	// Timezone is the optional IANA name of the guest's time zone. It defaults to UTC.
+	// json:"timezone" specifies that this field should be marshalled to/from a JSON object with the key "timezone".
+	Timezone string `json:"timezone"`
+	// Locale is the optional language for API responses, such as "es". It defaults to following the Accept-Language header.
+	// json:"locale" specifies that this field should be marshalled to/from a JSON object with the key "locale".
+	Locale string `json:"locale"`
+}
+
+// updatePreferencesRequest defines the structure for an update preferences request.
+type updatePreferencesRequest struct {
+	// Timezone is the IANA name of the user's time zone, such as "Asia/Kolkata". It is left unchanged when omitted.
+	// json:"timezone" specifies that this field should be marshalled to/from a JSON object with the key "timezone".
+	Timezone *string `json:"timezone"`
+	// Locale is the language for API responses, such as "es", or an empty string to follow the Accept-Language header. It is left unchanged when omitted.
+	// json:"locale" specifies that this field should be marshalled to/from a JSON object with the key "locale".
+	Locale *string `json:"locale"`
+}
+
+// UsageAmount defines the structure for the usage of one resource.
+type UsageAmount struct {
+	// Used is the current usage.
+	// json:"used" specifies that this field should be marshalled to/from a JSON object with the key "used".
+	Used int64 `json:"used"`
+	// Limit is the limit of the user's plan, or zero if it is unlimited.
+	// json:"limit" specifies that this field should be marshalled to/from a JSON object with the key "limit".
+	Limit int64 `json:"limit"`
+}
+
+// UsageResponse defines the structure for a usage response.
+type UsageResponse struct {
+	// Plan is the plan of the user.
+	// json:"plan" specifies that this field should be marshalled to/from a JSON object with the key "plan".
+	Plan string `json:"plan"`
+	// Todos is the usage of todos.
+	// json:"todos" specifies that this field should be marshalled to/from a JSON object with the key "todos".
+	Todos UsageAmount `json:"todos"`
+	// Lists is the usage of lists.
+	// json:"lists" specifies that this field should be marshalled to/from a JSON object with the key "lists".
+	Lists UsageAmount `json:"lists"`
+	// AttachmentBytes is the usage of attachment storage in bytes.
+	// json:"attachment_bytes" specifies that this field should be marshalled to/from a JSON object with the key "attachment_bytes".
+	AttachmentBytes UsageAmount `json:"attachment_bytes"`
+}
+
+// SessionResponse defines the structure for a session in the sessions listing.
+type SessionResponse struct {
+	// ID is the ID of the session.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// UserAgent is the User-Agent of the device the session was opened on.
+	// json:"user_agent" specifies that this field should be marshalled to/from a JSON object with the key "user_agent".
+	UserAgent string `json:"user_agent"`
+	// IP is the IP address the session was last used from.
+	// json:"ip" specifies that this field should be marshalled to/from a JSON object with the key "ip".
+	IP string `json:"ip"`
+	// CreatedAt is the time the session was opened.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt string `json:"created_at"`
+	// LastUsedAt is the time the session was last used, or null if it was never used.
+	// json:"last_used_at" specifies that this field should be marshalled to/from a JSON object with the key "last_used_at".
+	LastUsedAt *string `json:"last_used_at"`
+	// ExpiresAt is the time the session expires.
+	// json:"expires_at" specifies that this field should be marshalled to/from a JSON object with the key "expires_at".
+	ExpiresAt string `json:"expires_at"`
+	// Current reports whether the session is the one of the request.
+	// json:"current" specifies that this field should be marshalled to/from a JSON object with the key "current".
+	Current bool `json:"current"`
+}
+
+// NewSessionResponse converts a session into its response structure.
+//
+// @param session Session - The session.
+// @param currentId uuid.UUID - The ID of the session of the request.
+// @return SessionResponse - The response structure.
+func NewSessionResponse(session Session, currentId uuid.UUID) SessionResponse {
+	// sessionResponse is the response structure of the session.
+	sessionResponse := SessionResponse{
+		// The ID field is set to the session's ID.
+		ID: session.ID,
+		// The UserAgent field is set to the session's User-Agent.
+		UserAgent: session.UserAgent,
+		// The IP field is set to the session's last IP address.
+		IP: session.IP,
+		// The CreatedAt field is set to the time the session was opened.
+		CreatedAt: utils.ParseTime(session.CreatedAt),
+		// The ExpiresAt field is set to the time the session expires.
+		ExpiresAt: utils.ParseTime(session.ExpiresAt),
+		// The Current field is set if the session is the one of the request.
+		Current: session.ID == currentId,
+	}
+	// This checks if the session was used.
+	if session.LastUsedAt.Valid {
+		// lastUsedAt is the formatted last-used time.
+		lastUsedAt := utils.ParseTime(session.LastUsedAt.Time)
+		// If it was, the LastUsedAt field is set to it.
+		sessionResponse.LastUsedAt = &lastUsedAt
+	}
+	// The response structure is returned.
+	return sessionResponse
+}
+
+// changeEmailRequest defines the structure for a change email request.
+type changeEmailRequest struct {
+	// NewEmail is the address the user wants to use.
+	// json:"new_email" specifies that this field should be marshalled to/from a JSON object with the key "new_email".
+	NewEmail string `json:"new_email"`
+	// Password is the current password of the user.
+	// json:"password" specifies that this field should be marshalled to/from a JSON object with the key "password".
+	Password string `json:"password"`
+}
+
+// createAPIKeyRequest defines the structure for a create API key request.
+type createAPIKeyRequest struct {
+	// Name is the name of the key, such as the tool it is for.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+	// Scopes is the list of scopes the key is limited to, such as "todos:read".
+	// json:"scopes" specifies that this field should be marshalled to/from a JSON object with the key "scopes".
+	Scopes []string `json:"scopes"`
+	// ExpiresInDays is the lifetime of the key in days. It defaults to API_KEY_EXPIRY_DAYS when omitted.
+	// json:"expires_in_days" specifies that this field should be marshalled to/from a JSON object with the key "expires_in_days".
+	ExpiresInDays int `json:"expires_in_days"`
+}
+
+// APIKeyResponse defines the structure for an API key in the API key listing and in the response that creates it.
+type APIKeyResponse struct {
+	// ID is the ID of the key, which deletes it.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// Name is the name of the key.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+	// Scopes is the list of scopes the key is limited to.
+	// json:"scopes" specifies that this field should be marshalled to/from a JSON object with the key "scopes".
+	Scopes []string `json:"scopes"`
+	// Token is the key itself, which is only included when the key is created.
+	// json:"token,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "token", and should be omitted if empty.
+	Token string `json:"token,omitempty"`
+	// CreatedAt is the time the key was created.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt string `json:"created_at"`
+	// LastUsedAt is the time the key was last used, or null if it was never used.
+	// json:"last_used_at" specifies that this field should be marshalled to/from a JSON object with the key "last_used_at".
+	LastUsedAt *string `json:"last_used_at"`
+	// ExpiresAt is the time the key expires.
+	// json:"expires_at" specifies that this field should be marshalled to/from a JSON object with the key "expires_at".
+	ExpiresAt string `json:"expires_at"`
+}
+
+// NewAPIKeyResponse converts an API key into its response structure.
+//
+// @param key APIKey - The key.
+// @return APIKeyResponse - The response structure.
+func NewAPIKeyResponse(key APIKey) APIKeyResponse {
+	// keyResponse is the response structure of the key.
+	keyResponse := APIKeyResponse{
+		// The ID field is set to the key's ID.
+		ID: key.ID,
+		// The Name field is set to the key's name.
+		Name: key.Name,
+		// The Scopes field is set to the key's scopes.
+		Scopes: key.Scopes,
+		// The Token field is set to the key itself, which is empty unless the key was just created.
+		Token: key.Token,
+		// The CreatedAt field is set to the time the key was created.
+		CreatedAt: utils.ParseTime(key.CreatedAt),
+		// The ExpiresAt field is set to the time the key expires.
+		ExpiresAt: utils.ParseTime(key.ExpiresAt),
+	}
+	// This checks if the key was used.
+	if key.LastUsedAt.Valid {
+		// lastUsedAt is the formatted last-used time.
+		lastUsedAt := utils.ParseTime(key.LastUsedAt.Time)
+		// If it was, the LastUsedAt field is set to it.
+		keyResponse.LastUsedAt = &lastUsedAt
+	}
+	// The response structure is returned.
+	return keyResponse
+}
+
+// deviceCodeRequest defines the structure for a request of a device to sign in. It may be sent as JSON or as a form, like OAuth 2.0 clients do.
+type deviceCodeRequest struct {
+	// ClientName is the optional name of the device, such as "todoctl", which the user is shown before they approve it.
+	// json:"client_name" specifies that this field should be marshalled to/from a JSON object with the key "client_name".
+	// form:"client_name" specifies that this field is bound to the "client_name" form field.
+	ClientName string `json:"client_name" form:"client_name"`
+}
+
+// DeviceCodeResponse defines the structure for the codes of a device.
+type DeviceCodeResponse struct {
+	// DeviceCode is the code the device polls with.
+	// json:"device_code" specifies that this field should be marshalled to/from a JSON object with the key "device_code".
+	DeviceCode string `json:"device_code"`
+	// UserCode is the code the user enters.
+	// json:"user_code" specifies that this field should be marshalled to/from a JSON object with the key "user_code".
+	UserCode string `json:"user_code"`
+	// VerificationURI is the page where the user enters the code.
+	// json:"verification_uri" specifies that this field should be marshalled to/from a JSON object with the key "verification_uri".
+	VerificationURI string `json:"verification_uri"`
+	// VerificationURIComplete is the page with the code filled in.
+	// json:"verification_uri_complete" specifies that this field should be marshalled to/from a JSON object with the key "verification_uri_complete".
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	// ExpiresIn is the number of seconds the codes work for.
+	// json:"expires_in" specifies that this field should be marshalled to/from a JSON object with the key "expires_in".
+	ExpiresIn int64 `json:"expires_in"`
+	// Interval is the number of seconds the device waits between polls.
+	// json:"interval" specifies that this field should be marshalled to/from a JSON object with the key "interval".
+	Interval int `json:"interval"`
+}
+
+// deviceTokenRequest defines the structure for a poll of a device. It may be sent as JSON or as a form, like OAuth 2.0 clients do.
+type deviceTokenRequest struct {
+	// GrantType must be "urn:ietf:params:oauth:grant-type:device_code".
+	// json:"grant_type" specifies that this field should be marshalled to/from a JSON object with the key "grant_type".
+	// form:"grant_type" specifies that this field is bound to the "grant_type" form field.
+	GrantType string `json:"grant_type" form:"grant_type"`
+	// DeviceCode is the code the device got when it asked to sign in.
+	// json:"device_code" specifies that this field should be marshalled to/from a JSON object with the key "device_code".
+	// form:"device_code" specifies that this field is bound to the "device_code" form field.
+	DeviceCode string `json:"device_code" form:"device_code"`
+}
+
+// deviceDecisionRequest defines the structure for a request to approve or deny a device.
+type deviceDecisionRequest struct {
+	// UserCode is the code the device shows, with or without its dash.
+	// json:"user_code" specifies that this field should be marshalled to/from a JSON object with the key "user_code".
+	UserCode string `json:"user_code"`
+}
+
+// DeviceRequestResponse defines the structure for a device that waits for approval.
+type DeviceRequestResponse struct {
+	// UserCode is the code of the device.
+	// json:"user_code" specifies that this field should be marshalled to/from a JSON object with the key "user_code".
+	UserCode string `json:"user_code"`
+	// ClientName is the name the device gave itself, or empty.
+	// json:"client_name" specifies that this field should be marshalled to/from a JSON object with the key "client_name".
+	ClientName string `json:"client_name"`
+	// UserAgent is the User-Agent header the device asked with.
+	// json:"user_agent" specifies that this field should be marshalled to/from a JSON object with the key "user_agent".
+	UserAgent string `json:"user_agent"`
+	// IP is the IP address the device asked from.
+	// json:"ip" specifies that this field should be marshalled to/from a JSON object with the key "ip".
+	IP string `json:"ip"`
+	// CreatedAt is the time the device asked to sign in.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt string `json:"created_at"`
+	// ExpiresAt is the time the codes of the device stop working.
+	// json:"expires_at" specifies that this field should be marshalled to/from a JSON object with the key "expires_at".
+	ExpiresAt string `json:"expires_at"`
+}
+
+// NewDeviceRequestResponse converts a device that waits for approval into its response structure.
+//
+// @param device DeviceRequest - The device.
+// @return DeviceRequestResponse - The response structure.
+func NewDeviceRequestResponse(device DeviceRequest) DeviceRequestResponse {
+	// A new DeviceRequestResponse struct is returned.
+	return DeviceRequestResponse{
+		// The UserCode field is set to the code of the device.
+		UserCode: device.UserCode,
+		// The ClientName field is set to the name of the device.
+		ClientName: device.ClientName,
+		// The UserAgent field is set to the User-Agent header of the device.
+		UserAgent: device.UserAgent,
+		// The IP field is set to the IP address of the device.
+		IP: device.IP,
+		// The CreatedAt field is set to the time the device asked.
+		CreatedAt: utils.ParseTime(device.CreatedAt),
+		// The ExpiresAt field is set to the time the codes stop working.
+		ExpiresAt: utils.ParseTime(device.ExpiresAt),
+	}
+}
@@ -0,0 +1,91 @@
+// This file defines a test of the profile image of a user, from its column to its JSON.
+package users
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to read the user.
+import (
+	"context"
+	// "database/sql/driver" defines the interfaces of SQL drivers. It is used here to build the row of the fake database.
+	"database/sql/driver"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to serialize the user.
+	"encoding/json"
+	// "testing" provides support for automated tests. It is used here to run the test.
+	"testing"
+	// "time" provides functionality for measuring and displaying time. It is used here for the times of the user.
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here for the ID of the user.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/backend/database/dbtest" is a local package that provides a fake database. It is used here to hold the user.
+	"github.com/rahulcodepython/todo-backend/backend/database/dbtest"
+)
+
+// TestUserImage checks that a NULL image column scans into a user without an error and is serialized as JSON null, both in
+// the user and in its profile response, and that an image that is set is serialized as a string.
+//
+// @param t *testing.T - The test state.
+func TestUserImage(t *testing.T) {
+	// tests are the values of the image column and the JSON they must be serialized to.
+	tests := []struct {
+		// name is the name of the case.
+		name string
+		// column is the value of the image column.
+		column driver.Value
+		// want is the JSON of the image.
+		want string
+	}{
+		{"null", nil, `null`},
+		{"set", "https://example.com/rahul.png", `"https://example.com/rahul.png"`},
+	}
+
+	// This runs every case.
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// id is the ID of the user.
+			id := uuid.New()
+			// now is the time the user was created.
+			now := time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC)
+			// fake is the fake database, which holds the user.
+			fake := dbtest.NewDriver()
+			fake.Handle(GetUserProfileByIdQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+				return dbtest.Rows{
+					Columns: []string{"id", "name", "email", "image", "password", "jwt", "created_at", "updated_at", "timezone", "locale"},
+					Values:  [][]driver.Value{{id.String(), "Rahul", "rahul@example.com", tt.column, "hash", nil, now, now, "UTC", ""}},
+				}, nil
+			})
+			// db is the fake database.
+			db := dbtest.OpenDB(fake)
+			defer db.Close()
+
+			// user is the user read from the database.
+			user, err := GetUserByID(context.Background(), db, id)
+			// This checks if the user could not be read.
+			if err != nil {
+				// If it could not, the test fails.
+				t.Fatalf("GetUserByID: %v", err)
+			}
+
+			// This serializes the user and its profile response.
+			for name, value := range map[string]any{"User": user, "ProfileResponse": NewProfileResponse(user)} {
+				// data is the JSON of the value.
+				data, err := json.Marshal(value)
+				// This checks if the value could not be serialized.
+				if err != nil {
+					// If it could not, the test fails.
+					t.Fatalf("marshalling %s: %v", name, err)
+				}
+				// fields are the fields of the JSON, with their values unparsed.
+				var fields map[string]json.RawMessage
+				// This reads the fields of the JSON.
+				if err := json.Unmarshal(data, &fields); err != nil {
+					// If they cannot be read, the test fails.
+					t.Fatal(err)
+				}
+				// This checks if the image is missing or serialized as something else.
+				if got, ok := fields["image"]; !ok || string(got) != tt.want {
+					// If it is, the test fails.
+					t.Errorf("%s image = %s, want %s", name, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,1380 @@
+// This file defines the service that holds the business logic of users.
+// The service validates input, checks credentials, and runs the transactions, so that the controllers
+// and any other surface share one implementation and only translate its results.
+package users
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to pass the deadline of the request to the queries.
+import (
+	"context"
+	// "crypto/sha256" implements the SHA-256 hash. It is used here to fingerprint the devices users log in from.
+	"crypto/sha256"
+	// "database/sql" provides a generic SQL interface. It is used here to interact with the database.
+	"database/sql"
+	// "encoding/hex" implements hexadecimal encoding. It is used here to store device fingerprints as text.
+	"encoding/hex"
+	// "errors" provides functions for working with errors. It is used here to define the service errors.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to wrap errors with the step that failed.
+	"fmt"
+	// "net/mail" implements parsing of mail messages. It is used here to validate new email addresses.
+	"net/mail"
+	// "net/url" provides functions for working with URLs. It is used here to build the revoke and email change links.
+	"net/url"
+	// "strings" provides functions for working with strings. It is used here to cut long User-Agent headers and normalize email addresses.
+	"strings"
+	// "time" provides functions for working with time. It is used here to validate time zones.
+	"time"
+
+	// "github.com/golang-jwt/jwt/v5" is a package for creating and verifying JWTs. It is used here to sign the revoke links.
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to look up users by ID.
+	"github.com/google/uuid"
+	// "github.com/lib/pq" is the PostgreSQL driver. It is used here to read and write the scopes of API keys.
+	"github.com/lib/pq"
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database helpers.
+	"github.com/rahulcodepython/todo-backend/backend/database"
+	// "github.com/rahulcodepython/todo-backend/backend/dberr" is a local package that classifies database errors. It is used here to recognize a taken email address.
+	"github.com/rahulcodepython/todo-backend/backend/dberr"
+	// "github.com/rahulcodepython/todo-backend/backend/i18n" is a local package that translates messages. It is used here to validate the user's language.
+	"github.com/rahulcodepython/todo-backend/backend/i18n"
+	// "github.com/rahulcodepython/todo-backend/backend/idgen" is a local package that creates new IDs.
+	"github.com/rahulcodepython/todo-backend/backend/idgen"
+	// "github.com/rahulcodepython/todo-backend/backend/outbox" is a local package that records domain events.
+	"github.com/rahulcodepython/todo-backend/backend/outbox"
+	// "github.com/rahulcodepython/todo-backend/backend/quota" is a local package that enforces usage limits.
+	"github.com/rahulcodepython/todo-backend/backend/quota"
+	// "github.com/rahulcodepython/todo-backend/backend/reqlog" is a local package that provides the logger of a request. It is used here to log the failures of the new device alerts, which never fail a login, with the request they belong to.
+	"github.com/rahulcodepython/todo-backend/backend/reqlog"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// ErrMissingFields is returned when a required field is empty.
+var ErrMissingFields = errors.New("all fields are required")
+
+// ErrEmailTaken is returned when an email address is already used by another user.
+var ErrEmailTaken = errors.New("email is already used")
+
+// ErrInvalidTimezone is returned when a time zone is not a valid IANA time zone.
+var ErrInvalidTimezone = errors.New("invalid time zone")
+
+// ErrTimezoneRequired is returned when a time zone is given but empty.
+var ErrTimezoneRequired = errors.New("timezone is required")
+
+// ErrInvalidLocale is returned when a language is not supported.
+var ErrInvalidLocale = errors.New("invalid locale")
+
+// ErrNoPreferences is returned when a preferences update changes nothing.
+var ErrNoPreferences = errors.New("at least one preference is required")
+
+// ErrUserNotFound is returned when no user matches an email address or a token.
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrInvalidCredentials is returned when a password does not match.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// LoginFailedError is returned when a login is rejected. It wraps ErrUserNotFound or ErrInvalidCredentials,
+// and tells whether the email address failed often enough that a captcha is now asked for.
+type LoginFailedError struct {
+	// Err is the reason the login was rejected.
+	Err error
+	// Failures is the number of recent failed logins of the email address, including this one.
+	Failures int
+	// CaptchaRequired reports whether the client should show a captcha before the next attempt.
+	CaptchaRequired bool
+}
+
+// Error returns the message of the reason the login was rejected.
+//
+// @return string - The message.
+func (e *LoginFailedError) Error() string {
+	// The message of the reason is returned.
+	return e.Err.Error()
+}
+
+// Unwrap returns the reason the login was rejected, so that errors.Is recognizes it.
+//
+// @return error - The reason.
+func (e *LoginFailedError) Unwrap() error {
+	// The reason is returned.
+	return e.Err
+}
+
+// ErrInvalidRevokeLink is returned when a revoke link is malformed, tampered with, or expired.
+var ErrInvalidRevokeLink = errors.New("invalid revoke link")
+
+// ErrInvalidEmail is returned when a new email address is not a valid address.
+var ErrInvalidEmail = errors.New("invalid email address")
+
+// ErrEmailUnchanged is returned when a user asks to change their email address to the one they already have.
+var ErrEmailUnchanged = errors.New("email address is unchanged")
+
+// ErrMailUnavailable is returned when an action has to send an email but no mail server is configured.
+var ErrMailUnavailable = errors.New("email is not configured")
+
+// ErrInvalidEmailChangeLink is returned when the link of an email change is malformed, tampered with, expired, or already used.
+var ErrInvalidEmailChangeLink = errors.New("invalid email change link")
+
+// ErrAPIKeyNameRequired is returned when an API key is created without a name.
+var ErrAPIKeyNameRequired = errors.New("API key name is required")
+
+// ErrAPIKeyNameTooLong is returned when the name of an API key is longer than maxAPIKeyNameLength characters.
+var ErrAPIKeyNameTooLong = errors.New("API key name is too long")
+
+// ErrNoScopes is returned when an API key is created without scopes, since a key that can do nothing is a mistake.
+var ErrNoScopes = errors.New("at least one scope is required")
+
+// ErrInvalidScope is returned when an API key is created with a scope that does not exist.
+var ErrInvalidScope = errors.New("invalid scope")
+
+// ErrInvalidAPIKeyExpiry is returned when the lifetime chosen for an API key is not positive or is longer than the configured maximum.
+var ErrInvalidAPIKeyExpiry = errors.New("invalid API key expiry")
+
+// ErrAPIKeyNotFound is returned when the user has no API key with an ID.
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+// ErrAlreadyBootstrapped is returned when the first admin was already created, or skipped, by an earlier start.
+var ErrAlreadyBootstrapped = errors.New("the first admin was already bootstrapped")
+
+// ErrAdminExists is returned when the first admin is not created because the database already has an admin.
+var ErrAdminExists = errors.New("an admin already exists")
+
+// bootstrapAdminStep is the name under which the creation of the first admin is recorded in the bootstrap table.
+const bootstrapAdminStep = "admin"
+
+// emailUniqueConstraint is the name PostgreSQL gives the unique constraint on the email column of the users table.
+const emailUniqueConstraint = "users_email_key"
+
+// RegisterInput holds the fields of a new user.
+type RegisterInput struct {
+	// Name is the name of the user.
+	Name string
+	// Email is the email address of the user.
+	Email string
+	// Password is the plain password of the user, which is encrypted before it is stored.
+	Password string
+	// Timezone is the IANA time zone of the user. An empty time zone is stored as UTC.
+	Timezone string
+	// Locale is the language of the user. An empty language follows the Accept-Language header.
+	Locale string
+}
+
+// Client describes the device a JWT is issued to, so that its user can recognize the session later.
+type Client struct {
+	// UserAgent is the User-Agent header of the request.
+	UserAgent string
+	// IP is the IP address of the request.
+	IP string
+}
+
+// maxUserAgentLength is the number of bytes of a User-Agent header that are kept with a session.
+const maxUserAgentLength = 512
+
+// maxAPIKeyNameLength is the number of characters the name of an API key may have.
+const maxAPIKeyNameLength = 100
+
+// apiKeyPrefix starts every API key, so that a leaked key is easy to recognize and search for.
+const apiKeyPrefix = "tdk_"
+
+// revokePurpose is the purpose claim of revoke links, so that login tokens and other signed tokens are not accepted as links.
+const revokePurpose = "revoke_session"
+
+// confirmEmailChangePurpose is the purpose claim of the links that confirm an email change, which are sent to the new address.
+const confirmEmailChangePurpose = "confirm_email_change"
+
+// cancelEmailChangePurpose is the purpose claim of the links that cancel an email change, which are sent to the old address.
+const cancelEmailChangePurpose = "cancel_email_change"
+
+// Mailer sends plain text emails. The users package only needs to send security alerts,
+// so it depends on this interface rather than on the notifications package, which depends on users.
+type Mailer interface {
+	// Mail sends an email with a subject and a plain text body to an address.
+	Mail(ctx context.Context, to string, subject string, text string) error
+}
+
+// APIKeyInput holds the fields of a new API key.
+type APIKeyInput struct {
+	// Name is the name of the key.
+	Name string
+	// Scopes is the list of scopes the key is limited to.
+	Scopes []string
+	// ExpiresInDays is the lifetime of the key in days, or zero for the configured default.
+	ExpiresInDays int
+}
+
+// EmailChangeInput holds the fields of a request to change a user's email address.
+type EmailChangeInput struct {
+	// NewEmail is the address the user wants to use.
+	NewEmail string
+	// Password is the current password of the user, so that a stolen session alone cannot take over the account.
+	Password string
+}
+
+// PreferencesInput holds the preferences a user changes. A nil field is left unchanged.
+type PreferencesInput struct {
+	// Timezone is the new IANA time zone of the user.
+	Timezone *string
+	// Locale is the new language of the user. An empty language follows the Accept-Language header again.
+	Locale *string
+}
+
+// UserService holds the business logic of users.
+type UserService struct {
+	// cfg is the application configuration.
+	cfg *config.Config
+	// db is the database connection.
+	db *sql.DB
+	// clock tells the current time.
+	clock clock.Clock
+	// ids creates the IDs of new rows.
+	ids idgen.IDGenerator
+	// mailer sends the new device alerts, or is nil when no mail server is configured.
+	mailer Mailer
+	// throttle counts failed logins and slows down the logins of an email address that failed too often.
+	throttle *LoginThrottle
+}
+
+// NewUserService creates a new UserService.
+// It takes the application configuration, database connection, clock, ID generator, and mailer as input.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @param clk clock.Clock - The clock that tells the current time.
+// @param ids idgen.IDGenerator - The generator of the IDs of new rows.
+// @param mailer Mailer - The mailer of the new device alerts, or nil to send none.
+// @return *UserService - A pointer to the new UserService.
+func NewUserService(cfg *config.Config, db *sql.DB, clk clock.Clock, ids idgen.IDGenerator, mailer Mailer) *UserService {
+	// A new UserService is returned.
+	return &UserService{
+		// The cfg field is set to the application configuration.
+		cfg: cfg,
+		// The db field is set to the database connection.
+		db: db,
+		// The clock field is set to the clock.
+		clock: clk,
+		// The ids field is set to the ID generator.
+		ids: ids,
+		// The mailer field is set to the mailer.
+		mailer: mailer,
+		// The throttle field is set to a throttle of failed logins over the same database and clock.
+		throttle: NewLoginThrottle(cfg, db, clk),
+	}
+}
+
+// GetUserByID retrieves a user's profile by user ID.
+// It is used by the integrations, which identify users without a JWT.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param db *sql.DB - The database connection.
+// @param userId uuid.UUID - The ID of the user.
+// @return User - The user.
+// @return error - sql.ErrNoRows if the user does not exist, or another error if one occurred.
+func GetUserByID(ctx context.Context, db *sql.DB, userId uuid.UUID) (User, error) {
+	// user is a variable that will hold the user's data.
+	var user User
+
+	// err is the result of querying the database for the user's profile.
+	err := db.QueryRowContext(ctx, GetUserProfileByIdQuery, userId).Scan(
+		// The following are the fields to be scanned from the database row.
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.Image,
+		&user.Password,
+		&user.JWT,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.Timezone,
+		&user.Locale,
+	)
+	// The user and the error, if any, are returned.
+	return user, err
+}
+
+// Register creates a new user after checking the fields, and issues the user's first JWT.
+// The email address is checked by the unique index of the users table rather than by a query before the insert,
+// so that two sign-ups racing with the same email address cannot both succeed.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param input RegisterInput - The fields of the new user.
+// @param client Client - The device the JWT is issued to.
+// @return User - The new user.
+// @return JWT - The new JWT.
+// @return error - ErrMissingFields, ErrEmailTaken, ErrInvalidTimezone, or ErrInvalidLocale if a field is invalid, or another error if one occurred.
+func (us *UserService) Register(ctx context.Context, input RegisterInput, client Client) (User, JWT, error) {
+	// This checks if all required fields are present.
+	if input.Name == "" || input.Email == "" || input.Password == "" {
+		// If any field is missing, an error is returned.
+		return User{}, JWT{}, ErrMissingFields
+	}
+
+	// This checks if no time zone was given.
+	if input.Timezone == "" {
+		// If none was given, UTC is used.
+		input.Timezone = "UTC"
+	}
+	// This checks if the time zone is a valid IANA time zone.
+	if _, err := time.LoadLocation(input.Timezone); err != nil {
+		// If it is not, an error is returned.
+		return User{}, JWT{}, fmt.Errorf("%w: %v", ErrInvalidTimezone, err)
+	}
+	// This checks if the language is given but not supported.
+	if input.Locale != "" && !i18n.Supported(input.Locale) {
+		// If it is not, an error is returned.
+		return User{}, JWT{}, ErrInvalidLocale
+	}
+
+	// userId is the new UUID for the user.
+	userId := us.ids.NewID()
+	// now is the creation time of the user.
+	now := us.clock.Now()
+	// user is a new User struct.
+	user := User{
+		// The ID field is set to the new UUID.
+		ID: userId,
+		// The Name field is set to the user's name.
+		Name: input.Name,
+		// The Email field is set to the user's email address.
+		Email: input.Email,
+		// The CreatedAt field is set to the current time.
+		CreatedAt: now,
+		// The UpdatedAt field is set to the current time.
+		UpdatedAt: now,
+		// The Timezone field is set to the user's time zone.
+		Timezone: input.Timezone,
+		// The Locale field is set to the user's language.
+		Locale: input.Locale,
+	}
+
+	// encryptedPassword is the user's encrypted password.
+	encryptedPassword, err := utils.EncryptPassword(input.Password)
+	// This checks if an error occurred while encrypting the password.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return User{}, JWT{}, fmt.Errorf("encrypting password: %w", err)
+	}
+	// The user's password is set to the encrypted password.
+	user.Password = encryptedPassword
+
+	// err is the result of creating the user and recording the event in one transaction.
+	err = database.WithTx(ctx, us.db, func(tx *sql.Tx) error {
+		// _, err is the result of executing the SQL query to create the new user.
+		if _, err := tx.ExecContext(ctx, CreateUserQuery, user.ID, user.Name, user.Email, user.Image, user.Password, nil, user.CreatedAt, user.UpdatedAt, user.Timezone, user.Locale); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The event is recorded without the password or any token.
+		return outbox.Record(ctx, tx, outbox.UserRegistered, user.ID, user.ID, UserRegisteredEvent{ID: user.ID, Name: user.Name, Email: user.Email, Timezone: user.Timezone, CreatedAt: utils.ParseTime(user.CreatedAt)})
+	})
+	// This checks if the unique index on the email address rejected the user, which also catches concurrent sign-ups with the same email.
+	if errors.Is(err, dberr.ErrUniqueViolation) && dberr.Constraint(err) == emailUniqueConstraint {
+		// If it did, the email address is taken.
+		return User{}, JWT{}, ErrEmailTaken
+	}
+	// This checks if an error occurred while executing the transaction.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return User{}, JWT{}, fmt.Errorf("creating user: %w", err)
+	}
+
+	// jwt is the new JWT for the user.
+	jwt, err := us.issueToken(ctx, user, client, us.cfg.JWT.Expires)
+	// This checks if an error occurred while issuing the JWT.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return User{}, JWT{}, err
+	}
+	// The device is remembered, so that the user's later logins from it raise no alert.
+	us.recordDevice(ctx, user, jwt, client, false)
+	// The user and the JWT are returned.
+	return user, jwt, nil
+}
+
+// BootstrapAdmin creates the first admin of a deployment, so that a fresh database is not left without a way into the admin endpoints.
+// It runs at most once per database: the attempt is recorded in the bootstrap table in the same transaction, and a database
+// that already has an admin records the step without creating anyone. Servers that start together take turns on the record.
+//
+// @param ctx context.Context - The context of the call.
+// @param email string - The email address of the admin.
+// @param password string - The plain password of the admin.
+// @return User - The new admin.
+// @return error - ErrAlreadyBootstrapped or ErrAdminExists if no admin was created, ErrMissingFields, ErrInvalidEmail, or ErrEmailTaken if a field is invalid, or another error if one occurred.
+func (us *UserService) BootstrapAdmin(ctx context.Context, email string, password string) (User, error) {
+	// This checks if the credentials are present.
+	if email == "" || password == "" {
+		// If either is missing, an error is returned.
+		return User{}, ErrMissingFields
+	}
+	// This checks if the email address is valid.
+	if _, err := mail.ParseAddress(email); err != nil {
+		// If it is not, an error is returned.
+		return User{}, ErrInvalidEmail
+	}
+
+	// now is the creation time of the admin.
+	now := us.clock.Now()
+	// user is the new admin, named after the role since only the email address is configured.
+	user := User{ID: us.ids.NewID(), Name: "Admin", Email: email, CreatedAt: now, UpdatedAt: now, Timezone: "UTC"}
+	// encryptedPassword is the admin's encrypted password.
+	encryptedPassword, err := utils.EncryptPassword(password)
+	// This checks if an error occurred while encrypting the password.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return User{}, fmt.Errorf("encrypting password: %w", err)
+	}
+	// The admin's password is set to the encrypted password.
+	user.Password = encryptedPassword
+
+	// created reports whether the transaction created the admin, rather than finding one.
+	var created bool
+	// err is the result of recording the step and creating the admin in one transaction.
+	err = database.WithTx(ctx, us.db, func(tx *sql.Tx) error {
+		// Nothing is created yet, which matters when the transaction is run again.
+		created = false
+		// result is the result of recording the step, which waits for a server recording it at the same time.
+		result, err := tx.ExecContext(ctx, RecordBootstrapQuery, bootstrapAdminStep, now)
+		// This checks if an error occurred while recording the step.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// recorded is the number of rows the record inserted.
+		recorded, err := result.RowsAffected()
+		// This checks if an error occurred while reading it.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// This checks if the step was recorded before.
+		if recorded == 0 {
+			// If it was, nothing is created.
+			return ErrAlreadyBootstrapped
+		}
+
+		// exists reports whether the database already has an admin, such as one promoted by hand before the variables were set.
+		var exists bool
+		// This checks if an admin exists.
+		if err := tx.QueryRowContext(ctx, AdminExistsQuery).Scan(&exists); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// This checks if one does.
+		if exists {
+			// If one does, the step is committed as done without creating anyone, so it is not tried again.
+			return nil
+		}
+
+		// The admin is created with the admin role.
+		if _, err := tx.ExecContext(ctx, CreateUserQuery, user.ID, user.Name, user.Email, user.Image, user.Password, nil, user.CreatedAt, user.UpdatedAt, user.Timezone, user.Locale); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, SetUserRoleQuery, RoleAdmin, user.ID); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The admin is recorded with the step.
+		if _, err := tx.ExecContext(ctx, SetBootstrapUserQuery, user.ID, bootstrapAdminStep); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The admin is created once the transaction commits.
+		created = true
+		// The event is recorded without the password, like any registration.
+		return outbox.Record(ctx, tx, outbox.UserRegistered, user.ID, user.ID, UserRegisteredEvent{ID: user.ID, Name: user.Name, Email: user.Email, Timezone: user.Timezone, CreatedAt: utils.ParseTime(user.CreatedAt)})
+	})
+	// This checks if the email address is taken by a user who is not an admin, which rolls the step back so that it can be fixed and retried.
+	if errors.Is(err, dberr.ErrUniqueViolation) && dberr.Constraint(err) == emailUniqueConstraint {
+		// If it is, an error is returned.
+		return User{}, ErrEmailTaken
+	}
+	// This checks if the step was recorded before.
+	if errors.Is(err, ErrAlreadyBootstrapped) {
+		// If it was, the error is returned as it is.
+		return User{}, err
+	}
+	// This checks if an error occurred while executing the transaction.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return User{}, fmt.Errorf("bootstrapping admin: %w", err)
+	}
+	// This checks if the admin was not created because one existed.
+	if !created {
+		// If it was not, an error is returned.
+		return User{}, ErrAdminExists
+	}
+	// The admin is returned.
+	return user, nil
+}
+
+// Login checks a user's credentials and returns the user's JWT.
+// Failed logins are counted per email address, and once an address has failed more than the free attempts, every login
+// to it is delayed by a backoff that doubles with each failure, however many IP addresses the attempts come from.
+// A login from a device the user never used before is announced to them by email, with a link that revokes the session.
+// A login that asks to be remembered gets a session with the longer lifetime of JWT_REMEMBER_ME_EXPIRY_HOURS.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param email string - The email address of the user.
+// @param password string - The plain password of the user.
+// @param rememberMe bool - Whether the session is kept for the longer lifetime.
+// @param client Client - The device a new JWT is issued to.
+// @return User - The user.
+// @return JWT - The user's JWT.
+// @return error - ErrMissingFields, or a *LoginFailedError wrapping ErrUserNotFound or ErrInvalidCredentials if the credentials are rejected, or another error if one occurred.
+func (us *UserService) Login(ctx context.Context, email string, password string, rememberMe bool, client Client) (User, JWT, error) {
+	// This checks if all required fields are present.
+	if email == "" || password == "" {
+		// If any field is missing, an error is returned.
+		return User{}, JWT{}, ErrMissingFields
+	}
+
+	// failures is the number of recent failed logins of the email address.
+	failures, err := us.throttle.Failures(ctx, email)
+	// This checks if an error occurred while counting them.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return User{}, JWT{}, err
+	}
+	// This waits out the backoff of the failed logins before the credentials are checked.
+	if err := us.throttle.Wait(ctx, failures); err != nil {
+		// If the request ends first, its error is returned.
+		return User{}, JWT{}, err
+	}
+
+	// user is a variable that will hold the user's data.
+	var user User
+	// err is the result of querying the database for the user's profile.
+	err = us.db.QueryRowContext(ctx, GetUserProfileByEmailQuery, email).Scan(&user.ID, &user.Name, &user.Email, &user.Image, &user.Password, &user.JWT, &user.CreatedAt, &user.UpdatedAt, &user.Timezone, &user.Locale)
+	// This checks if no user has the email address.
+	if err == sql.ErrNoRows {
+		// If none has, the failure is counted and an error is returned.
+		return User{}, JWT{}, us.throttle.Fail(ctx, email, ErrUserNotFound)
+	}
+	// This checks if another error occurred while querying the database.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return User{}, JWT{}, fmt.Errorf("fetching user profile: %w", err)
+	}
+
+	// This checks if the passwords do not match.
+	if !utils.CompareEncryptedPassword(user.Password, password) {
+		// If they do not, the failure is counted and an error is returned.
+		return User{}, JWT{}, us.throttle.Fail(ctx, email, ErrInvalidCredentials)
+	}
+	// The failed logins of the address are forgotten now that the right password was given.
+	if err := us.throttle.Clear(ctx, email, failures); err != nil {
+		// If an error occurs, it is returned.
+		return User{}, JWT{}, err
+	}
+
+	// lifetime is how long the session of the login is valid, which is longer if it asks to be remembered.
+	lifetime := us.cfg.JWT.Expires
+	// This checks if the login asks to be remembered.
+	if rememberMe {
+		lifetime = us.cfg.JWT.RememberMeExpires
+	}
+	// jwt is the new JWT of the login.
+	jwt, err := us.loginToken(ctx, user, client, lifetime)
+	// This checks if an error occurred while getting the JWT.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return User{}, JWT{}, err
+	}
+	// The device is recorded, and the user is alerted if they never logged in from it before.
+	us.recordDevice(ctx, user, jwt, client, true)
+	// The user and the JWT are returned.
+	return user, jwt, nil
+}
+
+// loginToken issues a new JWT for a user who just logged in.
+// Every login gets a session of its own, so that two devices never share a credential and logging out on one leaves the other signed in.
+// The user's expired sessions are removed first, since each login adds one.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param user User - The user.
+// @param client Client - The device the new JWT is issued to.
+// @param lifetime time.Duration - How long the new JWT is valid.
+// @return JWT - The new JWT.
+// @return error - An error if one occurred.
+func (us *UserService) loginToken(ctx context.Context, user User, client Client, lifetime time.Duration) (JWT, error) {
+	// The expired sessions of the user are deleted.
+	if _, err := us.db.ExecContext(ctx, DeleteExpiredJWTsQuery, user.ID, us.clock.Now()); err != nil {
+		// If an error occurs, it is returned.
+		return JWT{}, fmt.Errorf("deleting expired JWTs: %w", err)
+	}
+	// A new JWT is issued for the user.
+	return us.issueToken(ctx, user, client, lifetime)
+}
+
+// Logout deletes a JWT, so that it can no longer be used.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param jwt JWT - The JWT to be deleted.
+// @return error - An error if one occurred.
+func (us *UserService) Logout(ctx context.Context, jwt JWT) error {
+	// _, err is the result of executing the SQL query to delete the JWT.
+	_, err := us.db.ExecContext(ctx, DeleteJWTByIdQuery, jwt.ID)
+	// The error, if any, is returned.
+	return err
+}
+
+// Usage reads a user's usage against the limits of their plan.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param userId uuid.UUID - The ID of the user.
+// @return quota.Usage - The usage of the user.
+// @return error - An error if one occurred.
+func (us *UserService) Usage(ctx context.Context, userId uuid.UUID) (quota.Usage, error) {
+	// The usage is read from the database.
+	return quota.GetUsage(ctx, us.db, us.cfg, userId)
+}
+
+// UpdatePreferences changes a user's time zone and language after checking them.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param user User - The user.
+// @param input PreferencesInput - The preferences to be changed.
+// @return User - The updated user.
+// @return error - ErrNoPreferences, ErrTimezoneRequired, ErrInvalidTimezone, or ErrInvalidLocale if the input is invalid, or another error if one occurred.
+func (us *UserService) UpdatePreferences(ctx context.Context, user User, input PreferencesInput) (User, error) {
+	// This checks if no preference is given.
+	if input.Timezone == nil && input.Locale == nil {
+		// If none is, an error is returned.
+		return User{}, ErrNoPreferences
+	}
+
+	// This checks if the time zone is given.
+	if input.Timezone != nil {
+		// This checks if the time zone is empty.
+		if *input.Timezone == "" {
+			// If it is, an error is returned.
+			return User{}, ErrTimezoneRequired
+		}
+		// This checks if the time zone is a valid IANA time zone.
+		if _, err := time.LoadLocation(*input.Timezone); err != nil {
+			// If it is not, an error is returned.
+			return User{}, fmt.Errorf("%w: %v", ErrInvalidTimezone, err)
+		}
+		// The user's time zone is set to the new time zone.
+		user.Timezone = *input.Timezone
+	}
+
+	// This checks if the language is given.
+	if input.Locale != nil {
+		// This checks if the language is not supported. An empty language follows the Accept-Language header again.
+		if *input.Locale != "" && !i18n.Supported(*input.Locale) {
+			// If it is not, an error is returned.
+			return User{}, ErrInvalidLocale
+		}
+		// The user's language is set to the new language.
+		user.Locale = *input.Locale
+	}
+
+	// err is the result of executing the SQL query to update the preferences.
+	err := us.db.QueryRowContext(ctx, UpdateUserPreferencesQuery, user.Timezone, user.Locale, user.ID).Scan(&user.UpdatedAt)
+	// The updated user and the error, if any, are returned.
+	return user, err
+}
+
+// IdleExpiresAt returns the time a session ends for going unused: the idle timeout after its last use, or after it was
+// issued if it was never used. The last use is recorded at most once per interval, so the time may come up to an interval early.
+//
+// @param cfg *config.Config - The application configuration, which holds the idle timeout.
+// @param createdAt time.Time - The time the session was issued.
+// @param lastUsedAt sql.NullTime - The time the session was last used, if it was.
+// @return time.Time - The time the session ends for going unused.
+// @return bool - Whether sessions end for going unused at all.
+func IdleExpiresAt(cfg *config.Config, createdAt time.Time, lastUsedAt sql.NullTime) (time.Time, bool) {
+	// This checks if sessions only end when they expire.
+	if cfg.JWT.IdleTimeout <= 0 {
+		// If they do, there is no such time.
+		return time.Time{}, false
+	}
+	// lastActiveAt is the last time the session was known to be in use.
+	lastActiveAt := createdAt
+	// This checks if the session was used since it was issued.
+	if lastUsedAt.Valid && lastUsedAt.Time.After(createdAt) {
+		lastActiveAt = lastUsedAt.Time
+	}
+	// The time the session ends for going unused is returned.
+	return lastActiveAt.Add(cfg.JWT.IdleTimeout), true
+}
+
+// Sessions lists the unexpired sessions of a user, most recently used first. While an idle timeout is set, the sessions that
+// went unused for longer are left out, and the expiry of the others is the earlier of the time they expire and the time they
+// end for going unused.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param userId uuid.UUID - The ID of the user.
+// @return []Session - The sessions.
+// @return error - An error if one occurred.
+func (us *UserService) Sessions(ctx context.Context, userId uuid.UUID) ([]Session, error) {
+	// rows is the result of querying the database for the sessions.
+	rows, err := us.db.QueryContext(ctx, GetSessionsQuery, userId)
+	// This checks if an error occurred while querying the database.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// This defers closing the rows.
+	defer rows.Close()
+
+	// sessions is the list of sessions, which is empty rather than nil when the user has none.
+	sessions := []Session{}
+	// This iterates over the rows.
+	for rows.Next() {
+		// session is the session of the current row.
+		var session Session
+		// This scans the row into the session.
+		if err := rows.Scan(&session.ID, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt); err != nil {
+			// If an error occurs, it is returned.
+			return nil, err
+		}
+		// This checks if the session ends for going unused before it expires.
+		if idleExpiresAt, ok := IdleExpiresAt(us.cfg, session.CreatedAt, session.LastUsedAt); ok && idleExpiresAt.Before(session.ExpiresAt) {
+			// This checks if the session has already ended.
+			if !idleExpiresAt.After(us.clock.Now()) {
+				// If it has, it is left out, since the next request with it is refused.
+				continue
+			}
+			// Otherwise its expiry is the time it ends for going unused.
+			session.ExpiresAt = idleExpiresAt
+		}
+		// The session is appended to the list.
+		sessions = append(sessions, session)
+	}
+	// The sessions and the error of the iteration, if any, are returned.
+	return sessions, rows.Err()
+}
+
+// RevokeSession deletes the session named by a revoke link, so that a user can end a login they do not recognize
+// from the alert email without logging in. Revoking a session that is already gone succeeds.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param token string - The signed token of the revoke link.
+// @return error - ErrInvalidRevokeLink if the token is invalid or expired, or another error if one occurred.
+func (us *UserService) RevokeSession(ctx context.Context, token string) error {
+	// tokenId is the ID of the session named by the link.
+	tokenId, err := us.parseRevokeLink(token)
+	// This checks if the link is invalid.
+	if err != nil {
+		// If it is, the error is returned.
+		return err
+	}
+
+	// _, err is the result of executing the SQL query to delete the session.
+	_, err = us.db.ExecContext(ctx, DeleteJWTByIdQuery, tokenId)
+	// The error, if any, is returned.
+	return err
+}
+
+// CheckRevokeLink verifies the token of a revoke link without ending the session, so that the page the link opens
+// can tell an expired link apart before it asks to end the session.
+//
+// @param token string - The signed token of the link.
+// @return error - ErrInvalidRevokeLink if the token is invalid or expired.
+func (us *UserService) CheckRevokeLink(token string) error {
+	// The token is verified, and the session it names is left alone.
+	_, err := us.parseRevokeLink(token)
+	// The error, if any, is returned.
+	return err
+}
+
+// parseRevokeLink verifies the token of a revoke link and returns the ID of the session it names.
+//
+// @param token string - The signed token of the link.
+// @return uuid.UUID - The ID of the session.
+// @return error - ErrInvalidRevokeLink if the token is invalid, expired, or was created for another purpose.
+func (us *UserService) parseRevokeLink(token string) (uuid.UUID, error) {
+	// claims is a variable that will hold the claims of the token.
+	claims := jwtlib.MapClaims{}
+	// This parses and verifies the token, only accepting the signing method it was created with.
+	_, err := jwtlib.ParseWithClaims(token, claims, func(token *jwtlib.Token) (interface{}, error) {
+		// The signing key is returned.
+		return []byte(us.cfg.JWT.SecretKey), nil
+	}, jwtlib.WithValidMethods([]string{jwtlib.SigningMethodHS256.Alg()}), jwtlib.WithExpirationRequired(), jwtlib.WithTimeFunc(us.clock.Now))
+	// This checks if the token is invalid or was not created for a revoke link.
+	if err != nil || claims["purpose"] != revokePurpose {
+		// If it is, an error is returned.
+		return uuid.Nil, ErrInvalidRevokeLink
+	}
+
+	// sessionId is the session ID claim.
+	sessionId, _ := claims["session_id"].(string)
+	// tokenId is the parsed ID of the session.
+	tokenId, err := uuid.Parse(sessionId)
+	// This checks if the session ID is invalid.
+	if err != nil {
+		// If it is, an error is returned.
+		return uuid.Nil, ErrInvalidRevokeLink
+	}
+	// The ID of the session is returned.
+	return tokenId, nil
+}
+
+// RequestEmailChange starts changing a user's email address. Nothing changes until the new address confirms it:
+// the new address gets a confirmation link, and the old one a notice with a link that cancels the change.
+// A new request replaces any pending one, so only the latest links work.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param user User - The user.
+// @param input EmailChangeInput - The new address and the current password.
+// @return error - ErrMissingFields, ErrInvalidEmail, ErrEmailUnchanged, or ErrEmailTaken if the address is rejected, ErrInvalidCredentials if the password does not match,
+// ErrMailUnavailable if no mail server is configured, or another error if one occurred.
+func (us *UserService) RequestEmailChange(ctx context.Context, user User, input EmailChangeInput) error {
+	// newEmail is the new address without surrounding whitespace.
+	newEmail := strings.TrimSpace(input.NewEmail)
+	// This checks if all required fields are present.
+	if newEmail == "" || input.Password == "" {
+		// If any field is missing, an error is returned.
+		return ErrMissingFields
+	}
+	// This checks if the new address is a bare, valid address rather than one with a display name or none at all.
+	if address, err := mail.ParseAddress(newEmail); err != nil || address.Address != newEmail {
+		// If it is not, an error is returned.
+		return ErrInvalidEmail
+	}
+	// This checks if the new address is the current one, ignoring case.
+	if strings.EqualFold(newEmail, user.Email) {
+		// If it is, an error is returned.
+		return ErrEmailUnchanged
+	}
+	// This checks if the password matches, so that a stolen session cannot move the account to another address.
+	if !utils.CompareEncryptedPassword(user.Password, input.Password) {
+		// If it does not, an error is returned.
+		return ErrInvalidCredentials
+	}
+	// This checks if no mail server is configured, since the change cannot be confirmed without one.
+	if us.mailer == nil {
+		// If none is, an error is returned.
+		return ErrMailUnavailable
+	}
+
+	// taken reports whether another user already has the new address.
+	var taken bool
+	// This checks if the new address is taken. It is checked again when the change is applied.
+	if err := us.db.QueryRowContext(ctx, EmailUsedQuery, newEmail).Scan(&taken); err != nil {
+		// If an error occurs, it is returned.
+		return fmt.Errorf("checking email: %w", err)
+	}
+	// This checks if the new address is taken.
+	if taken {
+		// If it is, an error is returned.
+		return ErrEmailTaken
+	}
+
+	// changeId is the ID of the pending change, which the links name.
+	changeId := us.ids.NewID()
+	// expiresAt is the time the links stop working.
+	expiresAt := us.clock.Now().Add(us.cfg.JWT.EmailChangeExpires)
+	// confirmLink is the link that applies the change.
+	confirmLink, err := us.emailChangeLink(confirmEmailChangePurpose, "confirm", changeId, expiresAt)
+	// This checks if an error occurred while signing the link.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return fmt.Errorf("signing confirm link: %w", err)
+	}
+	// cancelLink is the link that drops the change.
+	cancelLink, err := us.emailChangeLink(cancelEmailChangePurpose, "cancel", changeId, expiresAt)
+	// This checks if an error occurred while signing the link.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return fmt.Errorf("signing cancel link: %w", err)
+	}
+
+	// This records the pending change, replacing the one before it.
+	if _, err := us.db.ExecContext(ctx, UpsertEmailChangeQuery, changeId, user.ID, newEmail, expiresAt); err != nil {
+		// If an error occurs, it is returned.
+		return fmt.Errorf("recording email change: %w", err)
+	}
+
+	// text is the body of the email to the new address.
+	text := fmt.Sprintf("Hi %s,\n\nConfirm that you want to use this address for your account with the link below. "+
+		"You will be logged out of every device once it changes.\n\n%s\n\nIf you did not ask for this, you can ignore this email.\n", user.Name, confirmLink)
+	// This sends the confirmation to the new address. The change is only applied from this link.
+	if err := us.mailer.Mail(ctx, newEmail, "Confirm your new email address", text); err != nil {
+		// If an error occurs, it is returned, since the change cannot be confirmed without it.
+		return fmt.Errorf("sending confirmation: %w", err)
+	}
+
+	// text is the body of the email to the old address.
+	text = fmt.Sprintf("Hi %s,\n\nSomeone asked to change the email address of your account to %s. "+
+		"It changes once the new address confirms it.\n\nIf this was not you, cancel the change with the link below and change your password.\n\n%s\n",
+		user.Name, newEmail, cancelLink)
+	// This sends the notice to the old address.
+	if err := us.mailer.Mail(ctx, user.Email, "Your email address is about to change", text); err != nil {
+		// If an error occurs, it is logged, since the confirmation is already on its way.
+		reqlog.From(ctx).Printf("Unable to send the email change notice of user %s: %v", user.ID, err)
+	}
+	// No error is returned.
+	return nil
+}
+
+// ConfirmEmailChange applies the pending email change named by a confirmation link and logs the user out of every session,
+// so that whoever held the account under its old address has to log in again.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param token string - The signed token of the confirmation link.
+// @return error - ErrInvalidEmailChangeLink if the link is invalid, expired, or already used, ErrEmailTaken if another user took the address in the meantime, or another error if one occurred.
+func (us *UserService) ConfirmEmailChange(ctx context.Context, token string) error {
+	// changeId is the ID of the change named by the link.
+	changeId, err := us.parseEmailChangeLink(token, confirmEmailChangePurpose)
+	// This checks if the link is invalid.
+	if err != nil {
+		// If it is, the error is returned.
+		return err
+	}
+
+	// err is the result of applying the change and ending the sessions in one transaction.
+	err = database.WithTx(ctx, us.db, func(tx *sql.Tx) error {
+		// userId is the ID of the user whose address changes.
+		var userId uuid.UUID
+		// newEmail is the new address.
+		var newEmail string
+		// This removes the pending change, so that the link cannot be used twice.
+		if err := tx.QueryRowContext(ctx, TakeEmailChangeQuery, changeId, us.clock.Now()).Scan(&userId, &newEmail); err != nil {
+			// This checks if the change is gone, expired, or was replaced by a newer one.
+			if err == sql.ErrNoRows {
+				// If it is, the link is invalid.
+				return ErrInvalidEmailChangeLink
+			}
+			// If another error occurs, it is returned.
+			return err
+		}
+		// This changes the address.
+		if _, err := tx.ExecContext(ctx, UpdateUserEmailQuery, newEmail, userId); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// _, err is the result of ending every session of the user.
+		_, err := tx.ExecContext(ctx, DeleteSessionsQuery, userId)
+		// The error, if any, is returned.
+		return err
+	})
+	// This checks if another user took the address after the change was requested.
+	if errors.Is(err, dberr.ErrUniqueViolation) && dberr.Constraint(err) == emailUniqueConstraint {
+		// If they did, the email address is taken.
+		return ErrEmailTaken
+	}
+	// The error, if any, is returned.
+	return err
+}
+
+// CheckConfirmEmailChangeLink verifies a confirmation link without applying the change, so that the page the link opens
+// can tell an expired or used link apart before it asks to confirm.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param token string - The signed token of the confirmation link.
+// @return error - ErrInvalidEmailChangeLink if the link is invalid, expired, or already used, or another error if one occurred.
+func (us *UserService) CheckConfirmEmailChangeLink(ctx context.Context, token string) error {
+	// changeId is the ID of the change named by the link.
+	changeId, err := us.parseEmailChangeLink(token, confirmEmailChangePurpose)
+	// This checks if the link is invalid.
+	if err != nil {
+		// If it is, the error is returned.
+		return err
+	}
+
+	// newEmail is the new address, which is only read to learn that the change is still waiting.
+	var newEmail string
+	// This checks if the change is still waiting.
+	if err := us.db.QueryRowContext(ctx, CheckEmailChangeQuery, changeId, us.clock.Now()).Scan(&newEmail); err != nil {
+		// This checks if the change is gone, expired, or was replaced by a newer one.
+		if err == sql.ErrNoRows {
+			// If it is, the link is invalid.
+			return ErrInvalidEmailChangeLink
+		}
+		// If another error occurs, it is returned.
+		return err
+	}
+	// No error is returned.
+	return nil
+}
+
+// CheckCancelEmailChangeLink verifies a cancel link without dropping the change, so that the page the link opens
+// can tell an expired link apart before it asks to cancel.
+//
+// @param token string - The signed token of the cancel link.
+// @return error - ErrInvalidEmailChangeLink if the link is invalid or expired.
+func (us *UserService) CheckCancelEmailChangeLink(token string) error {
+	// The token is verified, and the change it names is left alone.
+	_, err := us.parseEmailChangeLink(token, cancelEmailChangePurpose)
+	// The error, if any, is returned.
+	return err
+}
+
+// CancelEmailChange drops the pending email change named by a cancel link. Cancelling a change that is already gone succeeds.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param token string - The signed token of the cancel link.
+// @return error - ErrInvalidEmailChangeLink if the link is invalid or expired, or another error if one occurred.
+func (us *UserService) CancelEmailChange(ctx context.Context, token string) error {
+	// changeId is the ID of the change named by the link.
+	changeId, err := us.parseEmailChangeLink(token, cancelEmailChangePurpose)
+	// This checks if the link is invalid.
+	if err != nil {
+		// If it is, the error is returned.
+		return err
+	}
+
+	// _, err is the result of executing the SQL query to delete the change.
+	_, err = us.db.ExecContext(ctx, DeleteEmailChangeQuery, changeId)
+	// The error, if any, is returned.
+	return err
+}
+
+// CreateAPIKey creates an API key for a user, limited to the given scopes, so that the user can hand it to another tool
+// without giving that tool everything their account can do. The key itself is only returned here and cannot be read again.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param user User - The user.
+// @param input APIKeyInput - The fields of the key.
+// @return APIKey - The new key, with the key itself.
+// @return error - A validation error if the input is invalid, or another error if one occurred.
+func (us *UserService) CreateAPIKey(ctx context.Context, user User, input APIKeyInput) (APIKey, error) {
+	// name is the name of the key without surrounding whitespace.
+	name := strings.TrimSpace(input.Name)
+	// This checks if the name is empty.
+	if name == "" {
+		// If it is, an error is returned.
+		return APIKey{}, ErrAPIKeyNameRequired
+	}
+	// This checks if the name is too long.
+	if len([]rune(name)) > maxAPIKeyNameLength {
+		// If it is, an error is returned.
+		return APIKey{}, ErrAPIKeyNameTooLong
+	}
+
+	// scopes is the checked list of scopes.
+	scopes, err := ValidateScopes(input.Scopes)
+	// This checks if a scope is missing or invalid.
+	if err != nil {
+		// If one is, the error is returned.
+		return APIKey{}, err
+	}
+
+	// lifetime is the lifetime of the key, which is the configured default unless the request chose one.
+	lifetime := us.cfg.JWT.APIKeyExpires
+	// This checks if the request chose a lifetime.
+	if input.ExpiresInDays != 0 {
+		// lifetime is the chosen lifetime.
+		lifetime = 24 * time.Hour * time.Duration(input.ExpiresInDays)
+		// This checks if the chosen lifetime is out of range.
+		if input.ExpiresInDays < 0 || lifetime > us.cfg.JWT.APIKeyMaxExpires {
+			// If it is, an error is returned.
+			return APIKey{}, ErrInvalidAPIKeyExpiry
+		}
+	}
+
+	// token is the key itself.
+	token, err := utils.CreateOpaqueToken(apiKeyPrefix)
+	// This checks if the key could not be created.
+	if err != nil {
+		// If it could not, the error is returned.
+		return APIKey{}, fmt.Errorf("creating API key: %w", err)
+	}
+
+	// key is the new API key.
+	key := APIKey{
+		// The ID field is set to a new ID.
+		ID: us.ids.NewID(),
+		// The Name field is set to the cleaned name.
+		Name: name,
+		// The Scopes field is set to the cleaned scopes.
+		Scopes: scopes,
+		// The Token field is set to the key itself.
+		Token: token,
+		// The ExpiresAt field is set to the end of the lifetime of the key.
+		ExpiresAt: us.clock.Now().Add(lifetime),
+	}
+
+	// This stores the key and reads back the time it was created.
+	if err := us.db.QueryRowContext(ctx, CreateAPIKeyQuery, key.ID, key.Token, key.ExpiresAt, user.ID, key.Name, pq.Array(key.Scopes)).Scan(&key.CreatedAt); err != nil {
+		// If an error occurs, it is returned.
+		return APIKey{}, fmt.Errorf("creating API key: %w", err)
+	}
+
+	// The new key is returned.
+	return key, nil
+}
+
+// APIKeys lists the unexpired API keys of a user, newest first, without the keys themselves.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param userId uuid.UUID - The ID of the user.
+// @return []APIKey - The keys.
+// @return error - An error if one occurred.
+func (us *UserService) APIKeys(ctx context.Context, userId uuid.UUID) ([]APIKey, error) {
+	// rows is the result of querying the database for the keys.
+	rows, err := us.db.QueryContext(ctx, GetAPIKeysQuery, userId)
+	// This checks if an error occurred while querying the database.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// This defers closing the rows.
+	defer rows.Close()
+
+	// keys is the list of keys, which is empty rather than nil when the user has none.
+	keys := []APIKey{}
+	// This iterates over the rows.
+	for rows.Next() {
+		// key is the key of the current row.
+		var key APIKey
+		// This scans the row into the key.
+		if err := rows.Scan(&key.ID, &key.Name, pq.Array(&key.Scopes), &key.CreatedAt, &key.LastUsedAt, &key.ExpiresAt); err != nil {
+			// If an error occurs, it is returned.
+			return nil, err
+		}
+		// The key is appended to the list.
+		keys = append(keys, key)
+	}
+	// The keys and the error of the iteration, if any, are returned.
+	return keys, rows.Err()
+}
+
+// DeleteAPIKey deletes an API key of a user, so that the tool it was given to loses access at once.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param userId uuid.UUID - The ID of the user.
+// @param keyId uuid.UUID - The ID of the key.
+// @return error - ErrAPIKeyNotFound if the user has no such key, or another error if one occurred.
+func (us *UserService) DeleteAPIKey(ctx context.Context, userId uuid.UUID, keyId uuid.UUID) error {
+	// result is the result of executing the SQL query to delete the key.
+	result, err := us.db.ExecContext(ctx, DeleteAPIKeyQuery, keyId, userId)
+	// This checks if an error occurred while executing the query.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// deleted is the number of deleted keys.
+	deleted, err := result.RowsAffected()
+	// This checks if an error occurred while reading the number of deleted keys.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// This checks if no key was deleted.
+	if deleted == 0 {
+		// If none was, an error is returned.
+		return ErrAPIKeyNotFound
+	}
+	// No error is returned.
+	return nil
+}
+
+// issueToken creates a new JWT and updates the user's row with the new JWT.
+// The device the JWT is issued to is stored with it, so that it shows up in the user's sessions.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param user User - The user for whom the JWT is being created.
+// @param client Client - The device the JWT is issued to.
+// @param lifetime time.Duration - How long the JWT is valid.
+// @return JWT - The new JWT.
+// @return error - An error if one occurred.
+func (us *UserService) issueToken(ctx context.Context, user User, client Client, lifetime time.Duration) (JWT, error) {
+	// tokenId is the new UUID for the JWT, which is also the ID of its session.
+	tokenId := us.ids.NewID()
+	// jti is the ID of the JWT itself, which is remembered after the JWT is revoked.
+	jti := us.ids.NewID()
+	// jwtToken is the new JWT.
+	jwtToken := utils.CreateToken(user.ID.String(), tokenId.String(), jti.String(), lifetime, us.cfg, us.clock.Now())
+
+	// jwt is a new JWT struct.
+	jwt := JWT{
+		// The ID field is set to the new UUID.
+		ID: tokenId,
+		// The Token field is set to the new JWT string.
+		Token: jwtToken.Token,
+		// The ExpiresAt field is set to the expiration time of the JWT.
+		ExpiresAt: jwtToken.ExpiresAt,
+	}
+
+	// userAgent is the User-Agent header, cut short so that a client cannot store an arbitrarily long one.
+	userAgent := client.UserAgent
+	// This checks if the header is too long.
+	if len(userAgent) > maxUserAgentLength {
+		// If it is, it is cut at a character boundary.
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+	}
+
+	// fingerprint is the fingerprint of the client the JWT is bound to, or NULL if sessions are not bound.
+	var fingerprint sql.NullString
+	// This checks if sessions are bound to the client they were issued to.
+	if us.cfg.JWT.BindFingerprint {
+		// If they are, the fingerprint of the client is stored with the JWT.
+		fingerprint = sql.NullString{String: ClientFingerprint(us.cfg, client.UserAgent, client.IP), Valid: true}
+	}
+
+	// _, err is the result of executing the SQL query to create the new JWT and update the user's row.
+	if _, err := us.db.ExecContext(ctx, CreateNewJWT_UpdateUserRowQuery, jwt.ID, jwt.Token, jwt.ExpiresAt, user.ID, userAgent, client.IP, jti, fingerprint); err != nil {
+		// If an error occurs, an empty JWT and the error are returned.
+		return JWT{}, fmt.Errorf("creating JWT token: %w", err)
+	}
+
+	// The new JWT and no error are returned.
+	return jwt, nil
+}
+
+// recordDevice remembers the device a user logged in from and, when asked to, alerts the user of a device they never used before.
+// No alert is sent for the first device of a user, since every account starts with one. A failure is logged rather than returned,
+// so that the alert can never keep a user from logging in.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param user User - The user.
+// @param jwt JWT - The session of the login.
+// @param client Client - The device of the login.
+// @param alert bool - Whether the user is alerted of a new device.
+func (us *UserService) recordDevice(ctx context.Context, user User, jwt JWT, client Client, alert bool) {
+	// sum is the fingerprint of the device, made of its User-Agent header and IP address.
+	sum := sha256.Sum256([]byte(client.UserAgent + "\n" + client.IP))
+	// isNew and knowsOthers report whether the device is new and whether the user used other devices before.
+	var isNew, knowsOthers bool
+	// This records the device.
+	if err := us.db.QueryRowContext(ctx, RecordKnownDeviceQuery, user.ID, hex.EncodeToString(sum[:]), us.clock.Now()).Scan(&isNew, &knowsOthers); err != nil {
+		// If an error occurs, it is logged.
+		reqlog.From(ctx).Printf("Unable to record the login device of user %s: %v", user.ID, err)
+		// Nothing else is done.
+		return
+	}
+
+	// This checks if the user should be alerted.
+	if !alert || !isNew || !knowsOthers || us.mailer == nil {
+		// If not, nothing else is done.
+		return
+	}
+	// The alert is sent in the background, so that a slow mail server does not delay the login.
+	// Its context outlives the request, which ends as soon as the login is answered.
+	go us.sendNewDeviceAlert(context.WithoutCancel(ctx), user, jwt, client)
+}
+
+// sendNewDeviceAlert emails a user about a login from a new device, with a link that revokes its session.
+//
+// @param ctx context.Context - The context of the alert.
+// @param user User - The user.
+// @param jwt JWT - The session of the login.
+// @param client Client - The device of the login.
+func (us *UserService) sendNewDeviceAlert(ctx context.Context, user User, jwt JWT, client Client) {
+	// link is the link that revokes the session.
+	link, err := us.revokeLink(jwt)
+	// This checks if an error occurred while signing the link.
+	if err != nil {
+		// If an error occurs, it is logged and no email is sent.
+		reqlog.From(ctx).Printf("Unable to create the revoke link of user %s: %v", user.ID, err)
+		return
+	}
+
+	// location is the user's time zone, which falls back to UTC if it cannot be loaded.
+	location, err := time.LoadLocation(user.Timezone)
+	// This checks if the time zone could not be loaded.
+	if err != nil {
+		// If it could not, UTC is used.
+		location = time.UTC
+	}
+	// device is the User-Agent header of the login, or a placeholder when the client sent none.
+	device := client.UserAgent
+	// This checks if the client sent no User-Agent header.
+	if device == "" {
+		// If it did not, a placeholder is shown.
+		device = "Unknown device"
+	}
+
+	// text is the body of the email.
+	text := fmt.Sprintf("Hi %s,\n\nYour account was just used to log in from a new device.\n\nDevice: %s\nIP address: %s\nTime: %s\n\n"+
+		"If this was you, you can ignore this email. If it was not, end the session with the link below and change your password.\n\n%s\n",
+		user.Name, device, client.IP, us.clock.Now().In(location).Format("Mon 2 Jan 2006 15:04 MST"), link)
+	// This sends the email.
+	if err := us.mailer.Mail(ctx, user.Email, "New login to your account", text); err != nil {
+		// If an error occurs, it is logged.
+		reqlog.From(ctx).Printf("Unable to send the new device alert of user %s: %v", user.ID, err)
+	}
+}
+
+// revokeLink builds the link of a new device alert, which carries a signed token naming the session.
+// The token expires with the session, since there is nothing left to revoke after that.
+//
+// @param session JWT - The session the link revokes.
+// @return string - The link.
+// @return error - An error if the token could not be signed.
+func (us *UserService) revokeLink(session JWT) (string, error) {
+	// token is a signed token that names the session.
+	token, err := jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, jwtlib.MapClaims{
+		// "session_id" is a claim that stores the ID of the session.
+		"session_id": session.ID.String(),
+		// "purpose" is a claim that restricts the token to revoke links.
+		"purpose": revokePurpose,
+		// "exp" is a claim that stores the expiration time of the session.
+		"exp": session.ExpiresAt.Unix(),
+	}).SignedString([]byte(us.cfg.JWT.SecretKey))
+	// This checks if an error occurred while signing the token.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+	// The link to the revoke endpoint is returned.
+	return fmt.Sprintf("%s/api/%s/auth/sessions/revoke?token=%s", us.cfg.Server.PublicURL, utils.APIVersion, url.QueryEscape(token)), nil
+}
+
+// emailChangeLink builds a link of an email change, which carries a signed token naming the change.
+//
+// @param purpose string - The purpose claim of the token, which tells confirm and cancel links apart.
+// @param action string - The last segment of the path of the endpoint, "confirm" or "cancel".
+// @param changeId uuid.UUID - The ID of the change.
+// @param expiresAt time.Time - The time the link stops working.
+// @return string - The link.
+// @return error - An error if the token could not be signed.
+func (us *UserService) emailChangeLink(purpose string, action string, changeId uuid.UUID, expiresAt time.Time) (string, error) {
+	// token is a signed token that names the change.
+	token, err := jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, jwtlib.MapClaims{
+		// "change_id" is a claim that stores the ID of the change.
+		"change_id": changeId.String(),
+		// "purpose" is a claim that restricts the token to one kind of link.
+		"purpose": purpose,
+		// "exp" is a claim that stores the time the change expires.
+		"exp": expiresAt.Unix(),
+	}).SignedString([]byte(us.cfg.JWT.SecretKey))
+	// This checks if an error occurred while signing the token.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+	// The link to the endpoint is returned.
+	return fmt.Sprintf("%s/api/%s/auth/email/%s?token=%s", us.cfg.Server.PublicURL, utils.APIVersion, action, url.QueryEscape(token)), nil
+}
+
+// parseEmailChangeLink verifies the token of an email change link and returns the ID of the change it names.
+//
+// @param token string - The signed token of the link.
+// @param purpose string - The purpose the token must have been created for.
+// @return uuid.UUID - The ID of the change.
+// @return error - ErrInvalidEmailChangeLink if the token is invalid, expired, or was created for another purpose.
+func (us *UserService) parseEmailChangeLink(token string, purpose string) (uuid.UUID, error) {
+	// claims is a variable that will hold the claims of the token.
+	claims := jwtlib.MapClaims{}
+	// This parses and verifies the token, only accepting the signing method it was created with.
+	_, err := jwtlib.ParseWithClaims(token, claims, func(token *jwtlib.Token) (interface{}, error) {
+		// The signing key is returned.
+		return []byte(us.cfg.JWT.SecretKey), nil
+	}, jwtlib.WithValidMethods([]string{jwtlib.SigningMethodHS256.Alg()}), jwtlib.WithExpirationRequired(), jwtlib.WithTimeFunc(us.clock.Now))
+	// This checks if the token is invalid or was created for another purpose.
+	if err != nil || claims["purpose"] != purpose {
+		// If it is, an error is returned.
+		return uuid.Nil, ErrInvalidEmailChangeLink
+	}
+
+	// changeId is the change ID claim.
+	changeId, _ := claims["change_id"].(string)
+	// id is the parsed ID of the change.
+	id, err := uuid.Parse(changeId)
+	// This checks if the change ID is invalid.
+	if err != nil {
+		// If it is, an error is returned.
+		return uuid.Nil, ErrInvalidEmailChangeLink
+	}
+	// The ID of the change is returned.
+	return id, nil
+}
@@ -1,31 +1,154 @@
 // This file defines the SQL queries used for user-related database operations.
 package users
 
-// "fmt" provides functions for formatted I/O. It is used here to construct the SQL queries.
-import (
-	"fmt"
-
-	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names and schemas.
-	"github.com/rahulcodepython/todo-backend/backend/utils"
-)
+// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names and schemas.
+import "github.com/rahulcodepython/todo-backend/backend/utils"
 
 // CreateUserQuery is the SQL query to insert a new user into the database.
-var CreateUserQuery = fmt.Sprintf("INSERT INTO %s (%s) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)", utils.UserTableName, utils.UserTableSchema)
-
-// CheckUniqueEmailQuery is the SQL query to check if an email is unique.
-var CheckUniqueEmailQuery = fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE email = $1", utils.UserTableName)
+const CreateUserQuery = "INSERT INTO " + utils.UserTableName + " (" + utils.UserTableSchema + ") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)"
 
-// GetUserProfileByEmailQuery is the SQL query to retrieve a user's profile by email.
-var GetUserProfileByEmailQuery = fmt.Sprintf("SELECT %s FROM %s WHERE email = $1", utils.UserTableSchema, utils.UserTableName)
+// GetUserProfileByEmailQuery is the SQL query to retrieve a user's profile by email. Service accounts are left out, since they cannot log in with a password,
+// and so are users deactivated by SCIM provisioning.
+const GetUserProfileByEmailQuery = "SELECT " + utils.UserTableSchema + " FROM " + utils.UserTableName + " WHERE email = $1 AND kind = '" + KindHuman + "' AND deactivated_at IS NULL"
 
-// GetUserJWTInfoQuery is the SQL query to retrieve a user's JWT information by user ID.
-var GetUserJWTInfoQuery = fmt.Sprintf("SELECT %s FROM %s WHERE id = $1", utils.JWTTableSchema, utils.JWTTableName)
+// DeleteExpiredJWTsQuery is the SQL query to delete the JWTs of a user that expired by a given time.
+const DeleteExpiredJWTsQuery = "DELETE FROM " + utils.JWTTableName + " WHERE user_id = $1 AND expires_at <= $2"
 
 // DeleteJWTByIdQuery is the SQL query to delete a JWT by its ID.
-var DeleteJWTByIdQuery = fmt.Sprintf("DELETE FROM %s WHERE id = $1", utils.JWTTableName)
+const DeleteJWTByIdQuery = "DELETE FROM " + utils.JWTTableName + " WHERE id = $1"
+
+// CreateNewJWT_UpdateUserRowQuery is the SQL query to create a new JWT, with its jti and the fingerprint it is bound to if any,
+// and record it in the user's row as the most recent session.
+const CreateNewJWT_UpdateUserRowQuery = "WITH new_token AS (INSERT INTO " + utils.JWTTableName + " (" + utils.JWTInsertSchema + ", jti, fingerprint_hash) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id) UPDATE " + utils.UserTableName + " SET jwt = (SELECT id FROM new_token) WHERE id = $4"
+
+// GetRevokedTokenQuery is the SQL query to find the user of a JWT that was revoked before it expired, by its jti.
+const GetRevokedTokenQuery = "SELECT user_id FROM " + utils.RevokedTokenTableName + " WHERE jti = $1 AND expires_at > $2"
+
+// PruneRevokedTokensQuery is the SQL query to forget the revoked JWTs that have expired by a given time, since they are refused anyway.
+const PruneRevokedTokensQuery = "DELETE FROM " + utils.RevokedTokenTableName + " WHERE expires_at <= $1"
+
+// GetUserProfileByJWTQuery is the SQL query to retrieve a user's profile by JWT, through the owner of the session rather than
+// the most recent session of the user, since every session of the user is valid. A deactivated user has no profile, in case a token outlived the deactivation.
+const GetUserProfileByJWTQuery = "SELECT " + utils.UserTableSchema + " FROM " + utils.UserTableName + " WHERE id = (SELECT user_id FROM " + utils.JWTTableName + " WHERE id = $1) AND deactivated_at IS NULL"
+
+// UpdateUserPreferencesQuery is the SQL query to update a user's preferences.
+const UpdateUserPreferencesQuery = "UPDATE " + utils.UserTableName + " SET timezone = $1, locale = $2, updated_at = NOW() WHERE id = $3 RETURNING updated_at"
+
+// GetUserProfileByIdQuery is the SQL query to retrieve a user's profile by user ID.
+const GetUserProfileByIdQuery = "SELECT " + utils.UserTableSchema + " FROM " + utils.UserTableName + " WHERE id = $1"
+
+// GetUserRoleQuery is the SQL query to retrieve a user's role by user ID.
+const GetUserRoleQuery = "SELECT role FROM " + utils.UserTableName + " WHERE id = $1"
+
+// RecordBootstrapQuery is the SQL query to record a one-time setup step, which inserts no row if the step was recorded before.
+const RecordBootstrapQuery = "INSERT INTO bootstrap (name, created_at) VALUES ($1, $2) ON CONFLICT (name) DO NOTHING"
+
+// SetBootstrapUserQuery is the SQL query to record the user a setup step created.
+const SetBootstrapUserQuery = "UPDATE bootstrap SET user_id = $1 WHERE name = $2"
+
+// AdminExistsQuery is the SQL query to check if any user has the admin role.
+const AdminExistsQuery = "SELECT EXISTS (SELECT 1 FROM " + utils.UserTableName + " WHERE role = '" + RoleAdmin + "')"
+
+// SetUserRoleQuery is the SQL query to set a user's role.
+const SetUserRoleQuery = "UPDATE " + utils.UserTableName + " SET role = $1 WHERE id = $2"
+
+// CreateGuestQuery is the SQL query to create a guest on the guest plan and their token in one statement.
+// Like a service account, a guest has an email address under a reserved .invalid domain and an empty password, which no password matches.
+const CreateGuestQuery = `WITH guest AS (
+	INSERT INTO ` + utils.UserTableName + ` (id, name, email, image, password, created_at, updated_at, timezone, locale, kind, plan) VALUES ($1, $2, $3, NULL, '', $4, $4, $5, $6, '` + KindGuest + `', 'guest') RETURNING id
+) INSERT INTO ` + utils.JWTTableName + ` (` + utils.JWTInsertSchema + `, kind, device_hash) SELECT $7, $8, $9, id, $10, $11, '` + TokenKindGuest + `', $12 FROM guest`
+
+// ClaimGuestQuery is the SQL query to turn a guest into a registered user on the free plan. It changes nothing if the user is not a guest.
+const ClaimGuestQuery = "UPDATE " + utils.UserTableName + " SET name = $2, email = $3, password = $4, timezone = $5, locale = $6, updated_at = $7, kind = '" + KindHuman + "', plan = 'free' WHERE id = $1 AND kind = '" + KindGuest + "'"
+
+// DeleteGuestTokensQuery is the SQL query to delete the guest tokens of a user.
+const DeleteGuestTokensQuery = "DELETE FROM " + utils.JWTTableName + " WHERE user_id = $1 AND kind = '" + TokenKindGuest + "'"
+
+// PruneGuestsQuery is the SQL query to delete the guests who have no unexpired token left, with their todos and lists.
+const PruneGuestsQuery = "DELETE FROM " + utils.UserTableName + " u WHERE u.kind = '" + KindGuest + "' AND NOT EXISTS (SELECT 1 FROM " + utils.JWTTableName + " t WHERE t.user_id = u.id AND t.expires_at > $1)"
+
+// GetSSOIdentityUserQuery is the SQL query to find the user the subject of a single sign-on connection is linked to.
+const GetSSOIdentityUserQuery = "SELECT user_id FROM " + utils.SSOIdentityTableName + " WHERE connection_id = $1 AND subject = $2"
+
+// CreateSSOIdentityQuery is the SQL query to link the subject of a single sign-on connection to a user.
+const CreateSSOIdentityQuery = "INSERT INTO " + utils.SSOIdentityTableName + " (connection_id, subject, user_id, created_at, last_login_at) VALUES ($1, $2, $3, $4, $4)"
+
+// TouchSSOIdentityQuery is the SQL query to record the last sign-in of the subject of a single sign-on connection.
+const TouchSSOIdentityQuery = "UPDATE " + utils.SSOIdentityTableName + " SET last_login_at = $3 WHERE connection_id = $1 AND subject = $2"
+
+// IsUserDeactivatedQuery is the SQL query to check if a user was deactivated by SCIM provisioning.
+const IsUserDeactivatedQuery = "SELECT deactivated_at IS NOT NULL FROM " + utils.UserTableName + " WHERE id = $1"
+
+// LockUserByEmailQuery is the SQL query to find the person with an email address and lock their row until the transaction ends.
+const LockUserByEmailQuery = "SELECT id FROM " + utils.UserTableName + " WHERE email = $1 AND kind = '" + KindHuman + "' FOR UPDATE"
+
+// GetSessionsQuery is the SQL query to list the unexpired sessions of a user, most recently used first.
+const GetSessionsQuery = "SELECT " + utils.SessionSelectSchema + " FROM " + utils.JWTTableName + " WHERE user_id = $1 AND kind = '" + TokenKindSession + "' AND expires_at > NOW() ORDER BY COALESCE(last_used_at, created_at) DESC"
+
+// CreateAPIKeyQuery is the SQL query to create an API key, returning the time it was created.
+const CreateAPIKeyQuery = "INSERT INTO " + utils.JWTTableName + " (" + utils.ScopedTokenInsertSchema + ") VALUES ($1, $2, $3, $4, '" + TokenKindAPIKey + "', $5, $6) RETURNING created_at"
+
+// GetAPIKeysQuery is the SQL query to list the unexpired API keys of a user, newest first.
+const GetAPIKeysQuery = "SELECT " + utils.APIKeySelectSchema + " FROM " + utils.JWTTableName + " WHERE user_id = $1 AND kind = '" + TokenKindAPIKey + "' AND expires_at > NOW() ORDER BY created_at DESC"
+
+// DeleteAPIKeyQuery is the SQL query to delete an API key of a user. It cannot delete a session or the key of another user.
+const DeleteAPIKeyQuery = "DELETE FROM " + utils.JWTTableName + " WHERE id = $1 AND user_id = $2 AND kind = '" + TokenKindAPIKey + "'"
+
+// TouchSessionQuery is the SQL query to record the last use of a session and the IP address it came from.
+const TouchSessionQuery = "UPDATE " + utils.JWTTableName + " SET last_used_at = $2, ip = $3 WHERE id = $1"
+
+// RecordKnownDeviceQuery is the SQL query to record a login from a device, returning whether the device is new
+// and whether the user had logged in from any other device before.
+const RecordKnownDeviceQuery = "INSERT INTO " + utils.KnownDeviceTableName + " (user_id, fingerprint, first_seen_at, last_seen_at) VALUES ($1, $2, $3, $3) ON CONFLICT (user_id, fingerprint) DO UPDATE SET last_seen_at = EXCLUDED.last_seen_at RETURNING xmax = 0, EXISTS (SELECT 1 FROM " + utils.KnownDeviceTableName + " WHERE user_id = $1 AND fingerprint <> $2)"
+
+// GetLoginFailuresQuery is the SQL query to count the failed logins of an email address that are still remembered.
+const GetLoginFailuresQuery = "SELECT failures FROM " + utils.LoginFailureTableName + " WHERE email = $1 AND last_failed_at > $2::timestamptz - $3::int * INTERVAL '1 second'"
+
+// RecordLoginFailureQuery is the SQL query to count a failed login of an email address, starting over once the earlier ones are forgotten.
+const RecordLoginFailureQuery = "INSERT INTO " + utils.LoginFailureTableName + " AS f (email, failures, last_failed_at) VALUES ($1, 1, $2) ON CONFLICT (email) DO UPDATE SET failures = CASE WHEN f.last_failed_at > $2::timestamptz - $3::int * INTERVAL '1 second' THEN f.failures + 1 ELSE 1 END, last_failed_at = EXCLUDED.last_failed_at RETURNING failures"
+
+// ClearLoginFailuresQuery is the SQL query to forget the failed logins of an email address.
+const ClearLoginFailuresQuery = "DELETE FROM " + utils.LoginFailureTableName + " WHERE email = $1"
+
+// EmailUsedQuery is the SQL query to check whether an email address belongs to any user.
+const EmailUsedQuery = "SELECT EXISTS (SELECT 1 FROM " + utils.UserTableName + " WHERE email = $1)"
+
+// UpsertEmailChangeQuery is the SQL query to record the pending email change of a user, replacing the one before it.
+const UpsertEmailChangeQuery = "INSERT INTO " + utils.EmailChangeTableName + " (id, user_id, new_email, expires_at) VALUES ($1, $2, $3, $4) ON CONFLICT (user_id) DO UPDATE SET id = EXCLUDED.id, new_email = EXCLUDED.new_email, expires_at = EXCLUDED.expires_at, created_at = NOW()"
+
+// TakeEmailChangeQuery is the SQL query to remove an unexpired pending email change, returning its user and new address.
+const TakeEmailChangeQuery = "DELETE FROM " + utils.EmailChangeTableName + " WHERE id = $1 AND expires_at > $2 RETURNING user_id, new_email"
+
+// CheckEmailChangeQuery is the SQL query to check that a pending email change is still waiting and unexpired.
+const CheckEmailChangeQuery = "SELECT new_email FROM " + utils.EmailChangeTableName + " WHERE id = $1 AND expires_at > $2"
+
+// DeleteEmailChangeQuery is the SQL query to remove a pending email change.
+const DeleteEmailChangeQuery = "DELETE FROM " + utils.EmailChangeTableName + " WHERE id = $1"
+
+// UpdateUserEmailQuery is the SQL query to change the email address of a user.
+const UpdateUserEmailQuery = "UPDATE " + utils.UserTableName + " SET email = $1, updated_at = NOW() WHERE id = $2"
+
+// DeleteSessionsQuery is the SQL query to delete every session of a user. API keys are kept, since they do not depend on the email address.
+const DeleteSessionsQuery = "DELETE FROM " + utils.JWTTableName + " WHERE user_id = $1 AND kind = '" + TokenKindSession + "'"
+
+// DeleteExpiredDeviceCodesQuery is the SQL query to delete the codes of devices that expired by a given time.
+const DeleteExpiredDeviceCodesQuery = "DELETE FROM " + utils.DeviceCodeTableName + " WHERE expires_at <= $1"
+
+// CreateDeviceCodeQuery is the SQL query to create the codes of a device that asks to sign in.
+const CreateDeviceCodeQuery = "INSERT INTO " + utils.DeviceCodeTableName + " (id, device_code_hash, user_code, client_name, user_agent, ip, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
+
+// LockDeviceCodeQuery is the SQL query to read the state of a device by its device code and lock it until the transaction ends,
+// so that two polls of the device never issue two sessions.
+const LockDeviceCodeQuery = "SELECT status, user_id, expires_at, last_polled_at FROM " + utils.DeviceCodeTableName + " WHERE device_code_hash = $1 FOR UPDATE"
+
+// TouchDeviceCodeQuery is the SQL query to record the time a device polled.
+const TouchDeviceCodeQuery = "UPDATE " + utils.DeviceCodeTableName + " SET last_polled_at = $2 WHERE device_code_hash = $1"
+
+// DeleteDeviceCodeQuery is the SQL query to delete the codes of a device by its device code.
+const DeleteDeviceCodeQuery = "DELETE FROM " + utils.DeviceCodeTableName + " WHERE device_code_hash = $1"
 
-// CreateNewJWT_UpdateUserRowQuery is the SQL query to create a new JWT and update the user's row with the new JWT.
-var CreateNewJWT_UpdateUserRowQuery = fmt.Sprintf("WITH new_token AS (INSERT INTO %s (%s) VALUES ($1, $2, $3) RETURNING id) UPDATE %s SET jwt = (SELECT id FROM new_token) WHERE id = $4", utils.JWTTableName, utils.JWTTableSchema, utils.UserTableName)
+// GetPendingDeviceCodeQuery is the SQL query to read a device that waits for approval by its user code.
+const GetPendingDeviceCodeQuery = "SELECT user_code, client_name, user_agent, ip, created_at, expires_at FROM " + utils.DeviceCodeTableName + " WHERE user_code = $1 AND status = 'pending' AND expires_at > $2"
 
-// GetUserProfileByJWTQuery is the SQL query to retrieve a user's profile by JWT.
-var GetUserProfileByJWTQuery = fmt.Sprintf("SELECT %s FROM %s WHERE jwt = $1", utils.UserTableSchema, utils.UserTableName)
\ No newline at end of file
+// DecideDeviceCodeQuery is the SQL query to approve or deny a device that waits for approval, on behalf of a user.
+const DecideDeviceCodeQuery = "UPDATE " + utils.DeviceCodeTableName + " SET status = $3, user_id = $4 WHERE user_code = $1 AND status = 'pending' AND expires_at > $2"
@@ -0,0 +1,188 @@
+// This file defines the sign-in of users through a single sign-on connection. The sso package verifies who the identity
+// provider says the user is, and this file turns that identity into a user of the application: the first sign-in creates
+// the user, or links an existing user with the same verified email address, and every sign-in updates the role the groups of
+// the user map to, so that the identity provider stays the source of truth for who is an admin.
+package users
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to pass the deadline of the request to the queries.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to run the sign-in in a transaction.
+	"database/sql"
+	// "errors" provides functions for working with errors. It is used here to define the sign-in errors.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to wrap errors.
+	"fmt"
+	// "time" provides functions for working with time. It is used here to date the sign-in.
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to identify connections and users.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database helpers.
+	"github.com/rahulcodepython/todo-backend/backend/database"
+	// "github.com/rahulcodepython/todo-backend/backend/outbox" is a local package that records domain events.
+	"github.com/rahulcodepython/todo-backend/backend/outbox"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// ErrEmailUnverified is returned when an identity provider signs in an email address that belongs to a user, but does not vouch for it.
+var ErrEmailUnverified = errors.New("email address is not verified by the identity provider")
+
+// ErrUserDeactivated is returned when a user that SCIM provisioning deactivated signs in through a connection.
+var ErrUserDeactivated = errors.New("user is deactivated")
+
+// SSOIdentity is a user as an identity provider described them in a verified ID token.
+type SSOIdentity struct {
+	// ConnectionID is the ID of the connection the user signed in through.
+	ConnectionID uuid.UUID
+	// Subject is the ID of the user at the identity provider, which never changes, unlike their email address.
+	Subject string
+	// Email is the email address of the user.
+	Email string
+	// EmailVerified reports whether the identity provider verified the email address.
+	EmailVerified bool
+	// Name is the name of the user, or empty to name them after their email address.
+	Name string
+	// Role is the role the groups of the user map to.
+	Role string
+}
+
+// LoginSSO signs a user in through a single sign-on connection and issues them a session.
+// A user is found by the subject the connection linked before; otherwise a user with the same email address is linked if the
+// identity provider verified the address, and a new user without a password is created if there is none. The user gets the
+// role of the identity every time, and all of it happens in one transaction.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param identity SSOIdentity - The verified identity.
+// @param client Client - The device the session is issued to.
+// @return User - The signed in user.
+// @return JWT - The new session.
+// @return error - ErrEmailUnverified if the address belongs to a user but is not verified, ErrUserDeactivated if the user is deactivated,
+// or another error if one occurred.
+func (us *UserService) LoginSSO(ctx context.Context, identity SSOIdentity, client Client) (User, JWT, error) {
+	// user is the signed in user.
+	var user User
+	// now is the time of the sign-in.
+	now := us.clock.Now()
+
+	// err is the result of finding or creating the user in one transaction.
+	err := database.WithTx(ctx, us.db, func(tx *sql.Tx) error {
+		// userId is the user the subject was linked to before, if it was.
+		var userId uuid.UUID
+		// err is the result of looking up the link.
+		err := tx.QueryRowContext(ctx, GetSSOIdentityUserQuery, identity.ConnectionID, identity.Subject).Scan(&userId)
+		// This checks if the subject was never linked.
+		if errors.Is(err, sql.ErrNoRows) {
+			// If it was not, the user is found by the email address or created.
+			userId, err = us.linkSSOUser(ctx, tx, identity, now)
+		}
+		// This checks if an error occurred while finding the user.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// deactivated reports whether the identity provider deactivated the user through SCIM.
+		var deactivated bool
+		// This checks if the user is deactivated.
+		if err := tx.QueryRowContext(ctx, IsUserDeactivatedQuery, userId).Scan(&deactivated); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// This checks if the user is deactivated, in which case they cannot sign in until they are reactivated.
+		if deactivated {
+			// If they are, the sign-in is refused.
+			return ErrUserDeactivated
+		}
+
+		// The last sign-in of the link is recorded.
+		if _, err := tx.ExecContext(ctx, TouchSSOIdentityQuery, identity.ConnectionID, identity.Subject, now); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The role of the identity is set.
+		if _, err := tx.ExecContext(ctx, SetUserRoleQuery, identity.Role, userId); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The user is read back.
+		return tx.QueryRowContext(ctx, GetUserProfileByIdQuery, userId).Scan(&user.ID, &user.Name, &user.Email, &user.Image, &user.Password, &user.JWT, &user.CreatedAt, &user.UpdatedAt, &user.Timezone, &user.Locale)
+	})
+	// This checks if the address belongs to a user but is not verified, or the user is deactivated.
+	if errors.Is(err, ErrEmailUnverified) || errors.Is(err, ErrUserDeactivated) {
+		// If it does, the error is returned.
+		return User{}, JWT{}, err
+	}
+	// This checks if an error occurred while executing the transaction.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return User{}, JWT{}, fmt.Errorf("signing in with SSO: %w", err)
+	}
+
+	// jwt is the new session of the user.
+	jwt, err := us.loginToken(ctx, user, client, us.cfg.JWT.Expires)
+	// This checks if an error occurred while issuing the JWT.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return User{}, JWT{}, err
+	}
+	// The device is recorded, and the user is alerted if it is new, as for a login with a password.
+	us.recordDevice(ctx, user, jwt, client, true)
+	// The user and the JWT are returned.
+	return user, jwt, nil
+}
+
+// linkSSOUser links the subject of an identity to the user with its email address, or to a new user if there is none.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param tx *sql.Tx - The transaction of the sign-in.
+// @param identity SSOIdentity - The verified identity.
+// @param now time.Time - The time of the sign-in.
+// @return uuid.UUID - The ID of the user.
+// @return error - ErrEmailUnverified if the address belongs to a user but is not verified, or another error if one occurred.
+func (us *UserService) linkSSOUser(ctx context.Context, tx *sql.Tx, identity SSOIdentity, now time.Time) (uuid.UUID, error) {
+	// userId is the user with the email address, if there is one.
+	var userId uuid.UUID
+	// err is the result of looking up the email address. The row is locked until the sign-in ends, and of two first sign-ins
+	// that both find no user, the unique indexes let only one create it.
+	err := tx.QueryRowContext(ctx, LockUserByEmailQuery, identity.Email).Scan(&userId)
+	// This checks if the address belongs to a user.
+	if err == nil {
+		// This checks if the identity provider does not vouch for the address, in which case anyone could claim it.
+		if !identity.EmailVerified {
+			// If it does not, the user is not linked.
+			return uuid.Nil, ErrEmailUnverified
+		}
+	} else if errors.Is(err, sql.ErrNoRows) {
+		// name is the name of the new user, which is their email address if the identity provider sent none.
+		name := identity.Name
+		// This checks if no name was sent.
+		if name == "" {
+			// If none was, the email address is used.
+			name = identity.Email
+		}
+		// userId is the ID of the new user.
+		userId = us.ids.NewID()
+		// The user is created without a password, so that they can only sign in through the connection.
+		if _, err := tx.ExecContext(ctx, CreateUserQuery, userId, name, identity.Email, nil, "", nil, now, now, "UTC", ""); err != nil {
+			// If an error occurs, it is returned.
+			return uuid.Nil, err
+		}
+		// The event is recorded, as for any other sign-up.
+		if err := outbox.Record(ctx, tx, outbox.UserRegistered, userId, userId, UserRegisteredEvent{ID: userId, Name: name, Email: identity.Email, Timezone: "UTC", CreatedAt: utils.ParseTime(now)}); err != nil {
+			// If an error occurs, it is returned.
+			return uuid.Nil, err
+		}
+	} else {
+		// If another error occurred, it is returned.
+		return uuid.Nil, err
+	}
+
+	// The subject is linked to the user.
+	if _, err := tx.ExecContext(ctx, CreateSSOIdentityQuery, identity.ConnectionID, identity.Subject, userId, now); err != nil {
+		// If an error occurs, it is returned.
+		return uuid.Nil, err
+	}
+	// The ID of the user is returned.
+	return userId, nil
+}
@@ -0,0 +1,199 @@
+// This file defines the throttling of failed logins per account, shared by every surface that checks a password:
+// the login endpoint and the HTTP Basic authentication of CalDAV and the admin console.
+package users
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to pass the deadline of the request to the queries.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to count the failed logins.
+	"database/sql"
+	// "fmt" provides functions for formatted I/O. It is used here to wrap errors with the step that failed.
+	"fmt"
+	// "strings" provides functions for working with strings. It is used here to normalize email addresses.
+	"strings"
+	// "time" provides functions for working with time. It is used here to delay throttled logins.
+	"time"
+
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+// LoginThrottle counts the failed logins of email addresses and slows down the logins of an address that failed too often.
+// Failures are counted per address rather than per IP address, so that an attacker spreading guesses over many proxies is slowed down too.
+type LoginThrottle struct {
+	// cfg is the configuration of the throttling.
+	cfg config.LoginThrottleConfig
+	// db is the database connection.
+	db *sql.DB
+	// clock tells the current time.
+	clock clock.Clock
+}
+
+// NewLoginThrottle creates a new LoginThrottle.
+// It takes the application configuration, database connection, and clock as input.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @param clk clock.Clock - The clock that tells the current time.
+// @return *LoginThrottle - A pointer to the new LoginThrottle.
+func NewLoginThrottle(cfg *config.Config, db *sql.DB, clk clock.Clock) *LoginThrottle {
+	// A new LoginThrottle is returned.
+	return &LoginThrottle{
+		// The cfg field is set to the configuration of the throttling.
+		cfg: cfg.LoginThrottle,
+		// The db field is set to the database connection.
+		db: db,
+		// The clock field is set to the clock.
+		clock: clk,
+	}
+}
+
+// loginKey returns the key the failed logins of an email address are counted under, so that changing its case does not start a new count.
+//
+// @param email string - The email address.
+// @return string - The normalized email address.
+func loginKey(email string) string {
+	// The trimmed, lower-cased email address is returned.
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Failures returns the number of recent failed logins of an email address.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param email string - The email address.
+// @return int - The number of failed logins that are still remembered.
+// @return error - An error if one occurred.
+func (t *LoginThrottle) Failures(ctx context.Context, email string) (int, error) {
+	// failures is the number of recent failed logins of the email address.
+	var failures int
+	// err is the result of querying the database for the failed logins.
+	err := t.db.QueryRowContext(ctx, GetLoginFailuresQuery, loginKey(email), t.clock.Now(), int(t.cfg.Window.Seconds())).Scan(&failures)
+	// This checks if an error occurred other than the address having no recent failures.
+	if err != nil && err != sql.ErrNoRows {
+		// If an error occurs, it is returned.
+		return 0, fmt.Errorf("fetching failed logins: %w", err)
+	}
+	// The number of failures is returned.
+	return failures, nil
+}
+
+// Wait waits out the backoff of a number of recent failed logins, before the credentials are checked.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param failures int - The number of recent failed logins.
+// @return error - The error of the context if the request ended first, or nil.
+func (t *LoginThrottle) Wait(ctx context.Context, failures int) error {
+	// The backoff is waited out.
+	return sleep(ctx, t.delay(failures))
+}
+
+// Locked reports whether an email address failed often enough that a captcha is asked for.
+// A client that cannot show a captcha, such as a CalDAV client sending Basic credentials, is locked out until the failures are forgotten.
+//
+// @param failures int - The number of recent failed logins.
+// @return bool - Whether the address is locked.
+func (t *LoginThrottle) Locked(failures int) bool {
+	// The address is locked once it reached the failures after which a captcha is asked for.
+	return t.cfg.CaptchaAfter > 0 && failures >= t.cfg.CaptchaAfter
+}
+
+// Fail counts a failed login of an email address.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param email string - The email address.
+// @param reason error - ErrUserNotFound or ErrInvalidCredentials.
+// @return error - A *LoginFailedError wrapping the reason, or another error if the failure could not be counted.
+func (t *LoginThrottle) Fail(ctx context.Context, email string, reason error) error {
+	// failures is the number of recent failed logins of the email address, including this one.
+	var failures int
+	// This counts the failure.
+	if err := t.db.QueryRowContext(ctx, RecordLoginFailureQuery, loginKey(email), t.clock.Now(), int(t.cfg.Window.Seconds())).Scan(&failures); err != nil {
+		// If an error occurs, it is returned.
+		return fmt.Errorf("recording failed login: %w", err)
+	}
+	// The rejected login is returned.
+	return &LoginFailedError{Err: reason, Failures: failures, CaptchaRequired: t.Locked(failures)}
+}
+
+// Clear forgets the failed logins of an email address once the right password was given.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param email string - The email address.
+// @param failures int - The number of recent failed logins, so that an address without any costs no statement.
+// @return error - An error if one occurred.
+func (t *LoginThrottle) Clear(ctx context.Context, email string, failures int) error {
+	// This checks if the address had no failed logins.
+	if failures == 0 {
+		// If it had none, there is nothing to forget.
+		return nil
+	}
+	// The failed logins are forgotten.
+	if _, err := t.db.ExecContext(ctx, ClearLoginFailuresQuery, loginKey(email)); err != nil {
+		// If an error occurs, it is returned.
+		return fmt.Errorf("clearing failed logins: %w", err)
+	}
+	// Nil is returned.
+	return nil
+}
+
+// RetryAfter returns how long a locked email address stays locked at most, since its failures are forgotten a window after the last one.
+//
+// @return time.Duration - The window of the failures.
+func (t *LoginThrottle) RetryAfter() time.Duration {
+	// The window is returned.
+	return t.cfg.Window
+}
+
+// delay returns how long a login waits after a number of recent failures.
+// The free attempts are not delayed, and each failure after them doubles the delay up to its maximum.
+//
+// @param failures int - The number of recent failed logins.
+// @return time.Duration - The delay.
+func (t *LoginThrottle) delay(failures int) time.Duration {
+	// throttle is the configuration of the throttling.
+	throttle := t.cfg
+	// This checks if the failures are within the free attempts.
+	if failures < throttle.FreeAttempts || throttle.BaseDelay <= 0 {
+		// If they are, the login is not delayed.
+		return 0
+	}
+	// delay starts at the base delay.
+	delay := throttle.BaseDelay
+	// This doubles the delay for every failure after the free attempts, stopping at the maximum.
+	for i := throttle.FreeAttempts; i < failures && delay < throttle.MaxDelay; i++ {
+		// The delay is doubled.
+		delay *= 2
+	}
+	// The delay, cut at the maximum, is returned.
+	return min(delay, throttle.MaxDelay)
+}
+
+// sleep waits for a duration, or until a context ends.
+//
+// @param ctx context.Context - The context.
+// @param delay time.Duration - The duration.
+// @return error - The error of the context if it ended first, or nil.
+func sleep(ctx context.Context, delay time.Duration) error {
+	// This checks if there is nothing to wait for.
+	if delay <= 0 {
+		// If there is not, nil is returned.
+		return nil
+	}
+	// timer fires once the duration has passed.
+	timer := time.NewTimer(delay)
+	// This defers stopping the timer, in case the context ends first.
+	defer timer.Stop()
+	// This waits for whichever comes first.
+	select {
+	// The duration has passed.
+	case <-timer.C:
+		// Nil is returned.
+		return nil
+	// The context has ended.
+	case <-ctx.Done():
+		// Its error is returned.
+		return ctx.Err()
+	}
+}
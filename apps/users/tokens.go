@@ -0,0 +1,136 @@
+// This file defines the protections of JWTs against being stolen. Every JWT carries a jti claim, the ID of the token, which is
+// remembered after the JWT is revoked, so that a replay of a revoked JWT is recognized and logged rather than taken for a made up
+// token. A session can also be bound to the fingerprint of the client it was issued to, the hash of its User-Agent header and
+// the subnet of its IP address, so that a stolen token is refused from another device or network.
+package users
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to stop the pruner.
+import (
+	"context"
+	// "crypto/sha256" provides the SHA-256 hash. It is used here to hash the fingerprint of a client.
+	"crypto/sha256"
+	// "database/sql" provides a generic SQL interface. It is used here to prune the revoked tokens.
+	"database/sql"
+	// "encoding/hex" provides hexadecimal encoding. It is used here to encode the fingerprint.
+	"encoding/hex"
+	// "log" provides a simple logging package. It is used here to log a failure to prune the revoked tokens.
+	"log"
+	// "net/netip" provides IP address types. It is used here to reduce an IP address to its subnet.
+	"net/netip"
+	// "time" provides functions for working with time. It is used here to schedule the pruner.
+	"time"
+
+	// "github.com/golang-jwt/jwt/v5" is a package for parsing and verifying JWTs. It is used here to read the jti claim.
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to parse the jti claim.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time. It is used here to tell which revoked tokens have expired.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+// revokedTokenPruneInterval is how often the revoked tokens that have expired are forgotten.
+const revokedTokenPruneInterval = time.Hour
+
+// ClientFingerprint returns the fingerprint of a client that a session is bound to: the hash of its User-Agent header and of
+// the subnet of its IP address, with the prefix lengths of the configuration, so that a client that moves within its network
+// keeps its session. An IP address that cannot be parsed is used as it is.
+//
+// @param cfg *config.Config - The application configuration.
+// @param userAgent string - The User-Agent header of the client.
+// @param ip string - The IP address of the client.
+// @return string - The fingerprint, in hexadecimal.
+func ClientFingerprint(cfg *config.Config, userAgent string, ip string) string {
+	// subnet is the subnet of the IP address, or the address as it is if it cannot be parsed.
+	subnet := ip
+	// This checks if the IP address can be parsed.
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		// addr is the address, with an IPv4 address mapped into IPv6 read as IPv4.
+		addr = addr.Unmap()
+		// bits is the prefix length of the address family.
+		bits := cfg.JWT.FingerprintIPv6Prefix
+		// This checks if the address is an IPv4 address.
+		if addr.Is4() {
+			bits = cfg.JWT.FingerprintIPv4Prefix
+		}
+		// This reduces the address to its subnet.
+		if prefix, err := addr.Prefix(bits); err == nil {
+			subnet = prefix.String()
+		}
+	}
+	// sum is the hash of the User-Agent header and the subnet.
+	sum := sha256.Sum256([]byte(userAgent + "\n" + subnet))
+	// The encoded hash is returned.
+	return hex.EncodeToString(sum[:])
+}
+
+// TokenJTI verifies a JWT and returns its jti claim. It does not check whether the JWT has expired, since a revoked JWT is
+// recognized until it is forgotten, which happens once it has expired.
+//
+// @param cfg *config.Config - The application configuration, which holds the signing key.
+// @param token string - The JWT.
+// @return uuid.UUID - The jti of the JWT.
+// @return bool - Whether the JWT was signed by the server and has a jti.
+func TokenJTI(cfg *config.Config, token string) (uuid.UUID, bool) {
+	// claims is a variable that will hold the claims of the token.
+	claims := jwtlib.MapClaims{}
+	// This parses and verifies the token, only accepting the signing method it was created with.
+	_, err := jwtlib.ParseWithClaims(token, claims, func(token *jwtlib.Token) (interface{}, error) {
+		// The signing key is returned.
+		return []byte(cfg.JWT.SecretKey), nil
+	}, jwtlib.WithValidMethods([]string{jwtlib.SigningMethodHS256.Alg()}), jwtlib.WithoutClaimsValidation())
+	// This checks if the token was not signed by the server.
+	if err != nil {
+		// If it was not, it has no jti to trust.
+		return uuid.Nil, false
+	}
+	// value is the jti claim, if any.
+	value, _ := claims["jti"].(string)
+	// jti is the parsed jti.
+	jti, err := uuid.Parse(value)
+	// The jti is returned, if it is one.
+	return jti, err == nil
+}
+
+// StartRevokedTokenPruner forgets the revoked tokens that have expired until the context is cancelled, since an expired JWT
+// is refused anyway.
+//
+// @param ctx context.Context - The context that stops the worker.
+// @param db *sql.DB - The database connection.
+// @param clk clock.Clock - The clock that tells which revoked tokens have expired.
+func StartRevokedTokenPruner(ctx context.Context, db *sql.DB, clk clock.Clock) {
+	// ticker fires once every prune interval.
+	ticker := time.NewTicker(revokedTokenPruneInterval)
+	// This defers stopping the ticker until the worker returns.
+	defer ticker.Stop()
+
+	// This loops until the context is cancelled.
+	for {
+		// This waits for either the next tick or the cancellation.
+		select {
+		case <-ctx.Done():
+			// If the context is cancelled, the worker returns.
+			return
+		case <-ticker.C:
+			// The expired revoked tokens are forgotten.
+			if err := pruneRevokedTokens(ctx, db, clk); err != nil {
+				// If an error occurs, it is logged.
+				log.Printf("Unable to prune revoked tokens: %v", err)
+			}
+		}
+	}
+}
+
+// pruneRevokedTokens forgets the revoked tokens that have expired by the current time of a clock.
+//
+// @param ctx context.Context - The context of the pass.
+// @param db *sql.DB - The database connection.
+// @param clk clock.Clock - The clock that tells which revoked tokens have expired.
+// @return error - An error if one occurred.
+func pruneRevokedTokens(ctx context.Context, db *sql.DB, clk clock.Clock) error {
+	// _, err is the result of deleting the expired revoked tokens.
+	_, err := db.ExecContext(ctx, PruneRevokedTokensQuery, clk.Now())
+	// The error, if any, is returned.
+	return err
+}
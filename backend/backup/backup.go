@@ -0,0 +1,294 @@
+// This file defines logical backups of the database, taken with pg_dump and restored with pg_restore.
+// Backups are stored in the custom format of pg_dump in the storage backend, under "backups/<time>.dump",
+// so that they are restored with the tools of PostgreSQL even without this application.
+package backup
+
+// "bytes" implements byte buffers. It is used here to split the output of the tools into lines.
+import (
+	"bytes"
+	// "context" provides a way to carry cancellation signals. It is used here to stop the tools.
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to check that the target of a restore is empty.
+	"database/sql"
+	// "errors" provides functions for working with errors. It is used here to define the backup errors.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to build the environment and the errors.
+	"fmt"
+	// "io" provides basic I/O interfaces. It is used here to stream the backups.
+	"io"
+	// "os" provides functions for working with the operating system. It is used here to pass the environment to the tools.
+	"os"
+	// "os/exec" runs external commands. It is used here to run pg_dump and pg_restore.
+	"os/exec"
+	// "strconv" provides functions for converting strings. It is used here to pass the port.
+	"strconv"
+	// "strings" provides functions for working with strings. It is used here to clean the progress lines.
+	"strings"
+	// "time" provides functions for working with time. It is used here to name the backups.
+	"time"
+
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database helpers.
+	"github.com/rahulcodepython/todo-backend/backend/database"
+	// "github.com/rahulcodepython/todo-backend/backend/storage" is a local package that stores files.
+	"github.com/rahulcodepython/todo-backend/backend/storage"
+)
+
+// ErrNotEmpty is returned when a restore targets a database that already has tables.
+var ErrNotEmpty = errors.New("target database is not empty")
+
+// Progress is called with each step a tool reports, such as "dumping contents of table public.todos".
+type Progress func(step string)
+
+// NewKey returns the storage key of a backup taken at a time.
+//
+// @param now time.Time - The time of the backup.
+// @return string - The key.
+func NewKey(now time.Time) string {
+	// The key is named after the time in UTC, so that the keys sort by time.
+	return "backups/" + now.UTC().Format("20060102T150405Z") + ".dump"
+}
+
+// Dump takes a backup of a database with pg_dump and stores it under a key.
+// The backup is only stored once pg_dump succeeds, so a failed backup leaves no file behind.
+//
+// @param ctx context.Context - The context that stops the backup.
+// @param cfg config.DatabaseConfig - The database to back up.
+// @param store storage.Storage - The storage the backup is written to.
+// @param key string - The key of the backup.
+// @param progress Progress - The function the steps are reported to.
+// @return error - An error if one occurred.
+func Dump(ctx context.Context, cfg config.DatabaseConfig, store storage.Storage, key string, progress Progress) error {
+	// cmd is the pg_dump command, which writes the custom format without owners and privileges, so that the backup restores under another role.
+	cmd := exec.CommandContext(ctx, "pg_dump", "--format=custom", "--no-owner", "--no-privileges", "--verbose")
+	// The connection settings are passed in the environment, so that the password is not visible in the process list.
+	cmd.Env = environment(cfg)
+	// steps collects the verbose output of pg_dump.
+	steps := &stepWriter{tool: "pg_dump", progress: progress}
+	// The verbose output is reported as progress.
+	cmd.Stderr = steps
+
+	// stdout is the backup as pg_dump writes it.
+	stdout, err := cmd.StdoutPipe()
+	// This checks if an error occurred while creating the pipe.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// This starts pg_dump.
+	if err := cmd.Start(); err != nil {
+		// If an error occurs, it is returned.
+		return fmt.Errorf("unable to start pg_dump: %w", err)
+	}
+
+	// This stores the backup. The reader fails instead of ending when pg_dump fails, so that the storage discards the file.
+	if err := store.Put(ctx, key, &exitReader{reader: stdout, cmd: cmd, steps: steps}); err != nil {
+		// If an error occurs, pg_dump is stopped and waited for, unless it already exited.
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		// The error is returned.
+		return err
+	}
+	// The backup is stored.
+	return nil
+}
+
+// Restore restores a backup into a database that has no tables, with pg_restore in a single transaction,
+// so that a failed restore leaves the database empty.
+//
+// @param ctx context.Context - The context that stops the restore.
+// @param cfg config.DatabaseConfig - The database to restore into.
+// @param store storage.Storage - The storage the backup is read from.
+// @param key string - The key of the backup.
+// @param progress Progress - The function the steps are reported to.
+// @return error - ErrNotEmpty if the database has tables, storage.ErrNotFound if there is no such backup, or another error if one occurred.
+func Restore(ctx context.Context, cfg config.DatabaseConfig, store storage.Storage, key string, progress Progress) error {
+	// This checks that the database is empty, since restoring into existing tables would mix two datasets.
+	if err := checkEmpty(ctx, cfg); err != nil {
+		// If it is not, or the check failed, the error is returned.
+		return err
+	}
+
+	// file is the stored backup.
+	file, _, err := store.Open(ctx, key)
+	// This checks if an error occurred while opening the backup.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// This defers closing the backup until the function returns.
+	defer file.Close()
+
+	// cmd is the pg_restore command, which reads the backup from its input and applies all of it or nothing.
+	cmd := exec.CommandContext(ctx, "pg_restore", "--no-owner", "--no-privileges", "--single-transaction", "--exit-on-error", "--verbose", "--dbname", cfg.DBName)
+	// The connection settings are passed in the environment, so that the password is not visible in the process list.
+	cmd.Env = environment(cfg)
+	// The backup is the input of pg_restore.
+	cmd.Stdin = file
+	// steps collects the verbose output of pg_restore.
+	steps := &stepWriter{tool: "pg_restore", progress: progress}
+	// The verbose output is reported as progress.
+	cmd.Stderr = steps
+
+	// This runs pg_restore until it exits.
+	if err := cmd.Run(); err != nil {
+		// If it fails, the error is returned with the last line it wrote.
+		return steps.failure(err)
+	}
+	// The backup is restored.
+	return nil
+}
+
+// checkEmpty checks that a database has no tables in its public schema.
+//
+// @param ctx context.Context - The context of the caller.
+// @param cfg config.DatabaseConfig - The database.
+// @return error - ErrNotEmpty if the database has tables, or another error if one occurred.
+func checkEmpty(ctx context.Context, cfg config.DatabaseConfig) error {
+	// db is a connection to the database, used for this check only.
+	db, err := sql.Open("postgres", database.ConnectionString(cfg))
+	// This checks if an error occurred while opening the connection.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// This defers closing the connection until the function returns.
+	defer db.Close()
+
+	// tables is the number of tables in the public schema.
+	var tables int
+	// This counts the tables.
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM pg_tables WHERE schemaname = 'public'").Scan(&tables); err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// This checks if the database has tables.
+	if tables > 0 {
+		// If it has, ErrNotEmpty is returned.
+		return ErrNotEmpty
+	}
+	// The database is empty.
+	return nil
+}
+
+// environment returns the environment of the tools, with the connection settings of a database.
+//
+// @param cfg config.DatabaseConfig - The database.
+// @return []string - The environment.
+func environment(cfg config.DatabaseConfig) []string {
+	// env is the environment of the application, which keeps PATH and the locale, with the settings added.
+	env := append(os.Environ(),
+		"PGHOST="+cfg.DBHost,
+		"PGPORT="+strconv.Itoa(cfg.DBPort),
+		"PGUSER="+cfg.DBUser,
+		"PGPASSWORD="+cfg.DBPassword,
+		"PGDATABASE="+cfg.DBName,
+		"PGSSLMODE="+cfg.DBSSLMode,
+	)
+	// This checks if the certificate authorities of the server are set.
+	if cfg.DBSSLRootCert != "" {
+		// If they are, the tools verify the server with them.
+		env = append(env, "PGSSLROOTCERT="+cfg.DBSSLRootCert)
+	}
+	// This checks if a client certificate is set.
+	if cfg.DBSSLCert != "" {
+		// If it is, the tools authenticate with it and its key.
+		env = append(env, "PGSSLCERT="+cfg.DBSSLCert, "PGSSLKEY="+cfg.DBSSLKey)
+	}
+	// The environment is returned.
+	return env
+}
+
+// stepWriter receives the verbose output of a tool and reports each line as a step.
+type stepWriter struct {
+	// tool is the name of the tool, which prefixes its lines.
+	tool string
+	// progress is the function the steps are reported to.
+	progress Progress
+	// pending is the part of a line that has not ended yet.
+	pending []byte
+	// last is the last line, which says why the tool failed when it does.
+	last string
+}
+
+// Write reports every complete line of the output.
+//
+// @param p []byte - The output.
+// @return int - The number of bytes consumed, which is all of them.
+// @return error - Always nil.
+func (sw *stepWriter) Write(p []byte) (int, error) {
+	// The output is added to the unfinished line.
+	sw.pending = append(sw.pending, p...)
+	// This loops while a complete line is pending.
+	for {
+		// end is the position of the end of the next line.
+		end := bytes.IndexByte(sw.pending, '\n')
+		// This checks if no line is complete.
+		if end < 0 {
+			// If none is, the rest waits for more output.
+			return len(p), nil
+		}
+		// line is the line without the name of the tool.
+		line := strings.TrimPrefix(strings.TrimSpace(string(sw.pending[:end])), sw.tool+": ")
+		// The line is removed from the pending output.
+		sw.pending = sw.pending[end+1:]
+		// This checks if the line is empty.
+		if line == "" {
+			// If it is, it is skipped.
+			continue
+		}
+		// The line is kept as the last one.
+		sw.last = line
+		// This checks if progress is reported.
+		if sw.progress != nil {
+			// If it is, the line is reported.
+			sw.progress(line)
+		}
+	}
+}
+
+// failure wraps the error of a tool with the last line it wrote.
+//
+// @param err error - The error of the tool.
+// @return error - The wrapped error.
+func (sw *stepWriter) failure(err error) error {
+	// This checks if the tool wrote nothing.
+	if sw.last == "" {
+		// If it wrote nothing, the error is returned with the name of the tool.
+		return fmt.Errorf("%s failed: %w", sw.tool, err)
+	}
+	// The error is returned with the last line.
+	return fmt.Errorf("%s failed: %w: %s", sw.tool, err, sw.last)
+}
+
+// exitReader reads the output of a command and, at its end, waits for the command to exit.
+// It ends with the error of the command if the command failed, so that a truncated output is not taken as complete.
+type exitReader struct {
+	// reader is the output of the command.
+	reader io.Reader
+	// cmd is the command.
+	cmd *exec.Cmd
+	// steps is the verbose output of the command, which explains its failure.
+	steps *stepWriter
+}
+
+// Read reads the output of the command.
+//
+// @param p []byte - The buffer to read into.
+// @return int - The number of bytes read.
+// @return error - io.EOF once the command succeeded, the error of the command if it failed, or the error of the read.
+func (er *exitReader) Read(p []byte) (int, error) {
+	// n is the number of bytes read.
+	n, err := er.reader.Read(p)
+	// This checks if the output ended.
+	if err == io.EOF {
+		// If it ended, the command is waited for.
+		if waitErr := er.cmd.Wait(); waitErr != nil {
+			// If the command failed, its error is returned instead of the end of the output.
+			return n, er.steps.failure(waitErr)
+		}
+	}
+	// The bytes and the error of the read are returned.
+	return n, err
+}
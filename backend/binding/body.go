@@ -0,0 +1,113 @@
+// This file defines the JSON decoding of request bodies.
+package binding
+
+// "bytes" provides functions for working with byte slices. It is used here to read the body with a decoder.
+import (
+	"bytes"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to decode the body.
+	"encoding/json"
+	// "strings" provides functions for working with strings. It is used here to read the field name out of the decoder error.
+	"strings"
+)
+
+// JSONDecoder returns the decoder that Fiber's BodyParser uses for JSON bodies.
+// A strict decoder rejects fields that the target struct does not have, so that a typo such as "titel"
+// is reported instead of silently leaving the field empty.
+// Either decoder rejects a body nested deeper than the maximum depth before decoding it, so that a crafted body cannot make the decoder recurse without bound.
+//
+// @param strict bool - Whether unknown fields are rejected.
+// @param maxDepth int - The maximum nesting depth of objects and arrays.
+// @return func(data []byte, target any) error - The decoder, to be set as the JSONDecoder of the Fiber configuration.
+func JSONDecoder(strict bool, maxDepth int) func(data []byte, target any) error {
+	// decode is the standard decoder, or the strict decoder when unknown fields are rejected.
+	decode := json.Unmarshal
+	// This checks if unknown fields are rejected.
+	if strict {
+		// If they are, the strict decoder is used.
+		decode = decodeStrict
+	}
+	// The decoder is returned behind the depth check.
+	return func(data []byte, target any) error {
+		// This checks if the body is nested too deeply.
+		if depth(data) > maxDepth {
+			// If it is, the body is reported as invalid without being decoded.
+			return FieldErrors{NewFieldError("body", "is nested deeper than %d levels", maxDepth)}
+		}
+		// The body is decoded.
+		return decode(data, target)
+	}
+}
+
+// depth returns the deepest nesting of objects and arrays in a JSON document, ignoring brackets inside strings.
+// It does not validate the document, which the decoder does afterwards.
+//
+// @param data []byte - The document.
+// @return int - The deepest nesting, 0 for a scalar.
+func depth(data []byte) int {
+	// current is the nesting at the current byte, and deepest the deepest nesting so far.
+	current, deepest := 0, 0
+	// inString reports whether the current byte is inside a string, and escaped whether it follows a backslash in one.
+	inString, escaped := false, false
+	// This iterates over the bytes of the document.
+	for _, b := range data {
+		// This checks if the byte is inside a string.
+		if inString {
+			// This checks what the byte means inside the string.
+			switch {
+			case escaped:
+				// An escaped byte never ends the string.
+				escaped = false
+			case b == '\\':
+				// A backslash escapes the next byte.
+				escaped = true
+			case b == '"':
+				// A quote ends the string.
+				inString = false
+			}
+			continue
+		}
+		// This checks what the byte means outside a string.
+		switch b {
+		case '"':
+			// A quote starts a string.
+			inString = true
+		case '{', '[':
+			// An opening bracket nests one level deeper.
+			current++
+			// The deepest nesting is updated.
+			deepest = max(deepest, current)
+		case '}', ']':
+			// A closing bracket leaves a level.
+			current--
+		}
+	}
+	// The deepest nesting is returned.
+	return deepest
+}
+
+// decodeStrict decodes a JSON body and rejects the fields that the target does not have.
+//
+// @param data []byte - The body.
+// @param target any - A pointer to the target.
+// @return error - The FieldErrors of an unknown field, or another error if the body is not valid JSON for the target.
+func decodeStrict(data []byte, target any) error {
+	// decoder reads the body.
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// The decoder is told to fail on fields that the target does not have.
+	decoder.DisallowUnknownFields()
+	// err is the result of decoding the body.
+	err := decoder.Decode(target)
+	// This checks if the body could not be decoded.
+	if err != nil {
+		// This checks if the body has a field that the target does not have.
+		// encoding/json reports it only as text, in the form `json: unknown field "name"`.
+		if quoted, ok := strings.CutPrefix(err.Error(), `json: unknown field "`); ok {
+			// name is the name of the field, without the closing quote.
+			name := strings.TrimSuffix(quoted, `"`)
+			// The field is reported by name.
+			return FieldErrors{NewFieldError(name, "is not a known field")}
+		}
+	}
+	// The error, if any, is returned.
+	return err
+}
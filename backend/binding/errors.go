@@ -0,0 +1,54 @@
+// This file defines the errors reported for invalid request parameters.
+package binding
+
+// "fmt" provides functions for formatted I/O. It is used here to fill in the error messages.
+import (
+	"fmt"
+	// "strings" provides functions for working with strings. It is used here to join the error messages.
+	"strings"
+)
+
+// FieldError describes why one request parameter is invalid.
+type FieldError struct {
+	// Field is the name of the invalid parameter.
+	// json:"field" specifies that this field should be marshalled to/from a JSON object with the key "field".
+	Field string `json:"field"`
+	// Message describes what is wrong with the parameter.
+	// json:"message" specifies that this field should be marshalled to/from a JSON object with the key "message".
+	Message string `json:"message"`
+	// Format is the English format string of the message, so that the message can be translated.
+	// json:"-" specifies that this field should not be marshalled.
+	Format string `json:"-"`
+	// Args are the values of the message.
+	// json:"-" specifies that this field should not be marshalled.
+	Args []any `json:"-"`
+}
+
+// NewFieldError creates a FieldError for a parameter from an English format string.
+//
+// @param field string - The name of the invalid parameter.
+// @param format string - The English format string of the message.
+// @param args ...any - The values of the message.
+// @return FieldError - The new FieldError.
+func NewFieldError(field string, format string, args ...any) FieldError {
+	// A new FieldError is returned with the message filled in.
+	return FieldError{Field: field, Message: fmt.Sprintf(format, args...), Format: format, Args: args}
+}
+
+// FieldErrors is the list of invalid parameters of a request.
+type FieldErrors []FieldError
+
+// Error returns the messages of the invalid parameters.
+//
+// @return string - The message.
+func (e FieldErrors) Error() string {
+	// messages is the list of messages, one per parameter.
+	messages := make([]string, 0, len(e))
+	// This iterates over the invalid parameters.
+	for _, fieldErr := range e {
+		// The message is prefixed with the name of the parameter.
+		messages = append(messages, fieldErr.Field+" "+fieldErr.Message)
+	}
+	// The messages are joined.
+	return strings.Join(messages, "; ")
+}
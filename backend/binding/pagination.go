@@ -0,0 +1,50 @@
+// This file defines the page size of the paginated endpoints.
+package binding
+
+// "math" provides mathematical constants. It is used here to bound the offset of a page.
+import (
+	"math"
+
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration. It is used here to read the page sizes.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+// PageLimit resolves the "limit" query parameter of a paginated endpoint against the configured page sizes.
+// The parameter is bound with `query:"limit" min:"1"` and no default, so that a missing limit is zero here.
+//
+// @param requested int - The bound limit, or zero if the request did not ask for one.
+// @param pagination config.PaginationConfig - The configured page sizes.
+// @return int - The page size.
+// @return error - The FieldErrors of the limit if it is above the largest page size, or nil.
+func PageLimit(requested int, pagination config.PaginationConfig) (int, error) {
+	// This checks if the request did not ask for a page size.
+	if requested == 0 {
+		// If it did not, the default page size is used.
+		return pagination.DefaultLimit, nil
+	}
+	// This checks if the requested page size is too large.
+	if requested > pagination.MaxLimit {
+		// If it is, the limit is reported like any other invalid parameter.
+		return 0, FieldErrors{NewFieldError("limit", "must be at most %d", pagination.MaxLimit)}
+	}
+	// The requested page size is returned.
+	return requested, nil
+}
+
+// PageOffset returns the number of rows before a page of a paginated endpoint, which is bound with `query:"page" min:"1"`.
+// A page so far away that its offset does not fit an int is reported like any other invalid parameter, rather than
+// overflowing into a negative offset that the database rejects.
+//
+// @param page int - The page number, starting at 1.
+// @param limit int - The page size, as resolved by PageLimit.
+// @return int - The number of rows before the page.
+// @return error - The FieldErrors of the page if its offset is out of range, or nil.
+func PageOffset(page int, limit int) (int, error) {
+	// This checks if the offset of the page does not fit an int.
+	if page < 1 || limit > 0 && page-1 > math.MaxInt/limit {
+		// If it does not, the page is reported.
+		return 0, FieldErrors{NewFieldError("page", "must be at most %d", math.MaxInt/max(limit, 1)+1)}
+	}
+	// The offset is returned.
+	return (page - 1) * limit, nil
+}
@@ -0,0 +1,204 @@
+// This file defines a typed binding of query parameters with validation.
+package binding
+
+// "fmt" provides functions for formatted I/O. It is used here to describe unsupported field types.
+import (
+	"fmt"
+	// "reflect" provides run-time reflection. It is used here to fill in the fields of the target struct.
+	"reflect"
+	// "slices" provides functions for working with slices. It is used here to check the allowed values.
+	"slices"
+	// "strconv" provides functions for converting strings. It is used here to parse numbers, booleans, and bounds.
+	"strconv"
+	// "strings" provides functions for working with strings. It is used here to read the allowed values.
+	"strings"
+	// "time" provides functions for working with time. It is used here to parse times.
+	"time"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to read the query parameters.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to parse IDs.
+	"github.com/google/uuid"
+)
+
+// Validator is implemented by query structs that check rules spanning several parameters, such as a time range.
+// Validate is only called once every parameter was parsed.
+type Validator interface {
+	// Validate returns the invalid parameters, or nil if there are none.
+	Validate() FieldErrors
+}
+
+// Query binds the query parameters of a request to a struct of type T.
+// Each field with a `query:"name"` tag is filled in from the parameter of that name. The tags
+// `default:"value"`, `min:"n"` and `max:"n"` for numbers, and `oneof:"a b c"` for strings refine it.
+// Fields may be strings, ints, int64s, bools, time.Times in RFC 3339, or uuid.UUIDs, and a pointer
+// to one of these is left nil when the parameter is missing. Every invalid parameter is reported,
+// rather than the first one only.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return T - The bound parameters.
+// @return error - The FieldErrors of the invalid parameters, or nil if all of them are valid.
+func Query[T any](c *fiber.Ctx) (T, error) {
+	// target is the struct that is filled in.
+	var target T
+	// value is the struct as a reflected value.
+	value := reflect.ValueOf(&target).Elem()
+
+	// errs is the list of invalid parameters.
+	var errs FieldErrors
+	// This iterates over the fields of the struct.
+	for i := 0; i < value.NumField(); i++ {
+		// field is the description of the field.
+		field := value.Type().Field(i)
+		// name is the name of the query parameter of the field.
+		name := field.Tag.Get("query")
+		// This checks if the field is not bound to a parameter.
+		if name == "" {
+			// If it is not, the field is skipped.
+			continue
+		}
+		// raw is the value of the parameter, or the default of the field.
+		raw := c.Query(name, field.Tag.Get("default"))
+		// This checks if the parameter is missing and has no default.
+		if raw == "" {
+			// If it is, the field keeps its zero value.
+			continue
+		}
+		// This parses the parameter into the field.
+		if fieldErr := setField(value.Field(i), field.Tag, name, raw); fieldErr != nil {
+			// If it is invalid, the error is collected.
+			errs = append(errs, *fieldErr)
+		}
+	}
+
+	// This checks if every parameter was parsed and the struct has rules of its own.
+	if validator, ok := any(&target).(Validator); ok && len(errs) == 0 {
+		// If so, the rules are checked.
+		errs = validator.Validate()
+	}
+	// This checks if any parameter is invalid.
+	if len(errs) > 0 {
+		// If one is, the errors are returned.
+		return target, errs
+	}
+	// The bound parameters are returned.
+	return target, nil
+}
+
+// setField parses the value of a parameter into a field.
+//
+// @param field reflect.Value - The field.
+// @param tag reflect.StructTag - The tags of the field.
+// @param name string - The name of the parameter.
+// @param raw string - The value of the parameter.
+// @return *FieldError - The error if the value is invalid, or nil.
+func setField(field reflect.Value, tag reflect.StructTag, name string, raw string) *FieldError {
+	// This checks if the field is optional.
+	if field.Kind() == reflect.Pointer {
+		// elem is a new value for the field to point to.
+		elem := reflect.New(field.Type().Elem())
+		// This parses the parameter into the new value.
+		if fieldErr := setField(elem.Elem(), tag, name, raw); fieldErr != nil {
+			// If it is invalid, the error is returned.
+			return fieldErr
+		}
+		// The field is set to point to the new value.
+		field.Set(elem)
+		// No error is returned.
+		return nil
+	}
+
+	// This handles the struct types by the type of the field.
+	switch target := field.Addr().Interface().(type) {
+	// A time is parsed in RFC 3339.
+	case *time.Time:
+		// parsed is the parsed time.
+		parsed, err := time.Parse(time.RFC3339, raw)
+		// This checks if the time is invalid.
+		if err != nil {
+			// If it is, an error is returned.
+			return fieldError(name, "must be an RFC 3339 time")
+		}
+		// The field is set.
+		*target = parsed
+		// No error is returned.
+		return nil
+	// A UUID is parsed in its text form.
+	case *uuid.UUID:
+		// parsed is the parsed UUID.
+		parsed, err := uuid.Parse(raw)
+		// This checks if the UUID is invalid.
+		if err != nil {
+			// If it is, an error is returned.
+			return fieldError(name, "must be a UUID")
+		}
+		// The field is set.
+		*target = parsed
+		// No error is returned.
+		return nil
+	}
+
+	// This handles the basic types by the kind of the field.
+	switch field.Kind() {
+	// A string may be limited to a set of values.
+	case reflect.String:
+		// This checks if the string is limited to a set of values.
+		if oneOf := tag.Get("oneof"); oneOf != "" && !slices.Contains(strings.Fields(oneOf), raw) {
+			// If it is not one of them, an error is returned.
+			return fieldError(name, "must be one of %s", strings.Join(strings.Fields(oneOf), ", "))
+		}
+		// The field is set.
+		field.SetString(raw)
+	// A number may be limited to a range.
+	case reflect.Int, reflect.Int64:
+		// parsed is the parsed number.
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		// This checks if the number is invalid.
+		if err != nil {
+			// If it is, an error is returned.
+			return fieldError(name, "must be an integer")
+		}
+		// This checks if the number is below its minimum.
+		if min, err := strconv.ParseInt(tag.Get("min"), 10, 64); err == nil && parsed < min {
+			// If it is, an error is returned.
+			return fieldError(name, "must be at least %d", min)
+		}
+		// This checks if the number is above its maximum.
+		if max, err := strconv.ParseInt(tag.Get("max"), 10, 64); err == nil && parsed > max {
+			// If it is, an error is returned.
+			return fieldError(name, "must be at most %d", max)
+		}
+		// The field is set.
+		field.SetInt(parsed)
+	// A boolean is parsed like strconv.ParseBool, so "true", "1", "false", and "0" are accepted.
+	case reflect.Bool:
+		// parsed is the parsed boolean.
+		parsed, err := strconv.ParseBool(raw)
+		// This checks if the boolean is invalid.
+		if err != nil {
+			// If it is, an error is returned.
+			return fieldError(name, "must be true or false")
+		}
+		// The field is set.
+		field.SetBool(parsed)
+	// Any other type is a mistake in the query struct.
+	default:
+		// The mistake is reported to the developer.
+		panic(fmt.Sprintf("binding: unsupported type %s of query parameter %s", field.Type(), name))
+	}
+	// No error is returned.
+	return nil
+}
+
+// fieldError returns a pointer to a new FieldError.
+//
+// @param field string - The name of the invalid parameter.
+// @param format string - The English format string of the message.
+// @param args ...any - The values of the message.
+// @return *FieldError - The new FieldError.
+func fieldError(field string, format string, args ...any) *FieldError {
+	// fieldErr is the new FieldError.
+	fieldErr := NewFieldError(field, format, args...)
+	// A pointer to it is returned.
+	return &fieldErr
+}
@@ -0,0 +1,343 @@
+// This file wires the application together: configuration, database, controllers, server, and background workers.
+// It is the one place where dependencies are constructed, and it owns their lifecycle from start to shutdown.
+package bootstrap
+
+// "context" provides a way to carry cancellation signals. It is used here to stop the background workers.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to hold the database connection.
+	"database/sql"
+	// "errors" provides functions for working with errors. It is used here to recognize the outcomes of the first admin.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to build the server address and wrap errors.
+	"fmt"
+	// "log" provides a simple logging package. It is used here to log the lifecycle of the application.
+	"log"
+	// "sync" provides synchronization primitives. It is used here to wait for the background workers to stop.
+	"sync"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to create the HTTP server.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/apps/admin" is a local package that contains the admin console controllers.
+	"github.com/rahulcodepython/todo-backend/apps/admin"
+	// "github.com/rahulcodepython/todo-backend/apps/audit" is a local package that contains the audit log controllers and pruner.
+	"github.com/rahulcodepython/todo-backend/apps/audit"
+	// "github.com/rahulcodepython/todo-backend/apps/caldav" is a local package that contains the CalDAV controllers.
+	"github.com/rahulcodepython/todo-backend/apps/caldav"
+	// "github.com/rahulcodepython/todo-backend/apps/diagnostics" is a local package that contains the runtime diagnostics controllers.
+	"github.com/rahulcodepython/todo-backend/apps/diagnostics"
+	// "github.com/rahulcodepython/todo-backend/apps/lists" is a local package that contains the list controllers.
+	"github.com/rahulcodepython/todo-backend/apps/lists"
+	// "github.com/rahulcodepython/todo-backend/apps/media" is a local package that contains the media controllers.
+	"github.com/rahulcodepython/todo-backend/apps/media"
+	// "github.com/rahulcodepython/todo-backend/apps/meta" is a local package that contains the capabilities controller.
+	"github.com/rahulcodepython/todo-backend/apps/meta"
+	// "github.com/rahulcodepython/todo-backend/apps/notifications" is a local package that contains the notification controllers and reminder worker.
+	"github.com/rahulcodepython/todo-backend/apps/notifications"
+	// "github.com/rahulcodepython/todo-backend/apps/offlinesync" is a local package that contains the offline sync controllers.
+	"github.com/rahulcodepython/todo-backend/apps/offlinesync"
+	// "github.com/rahulcodepython/todo-backend/apps/scim" is a local package that contains the SCIM provisioning controllers.
+	"github.com/rahulcodepython/todo-backend/apps/scim"
+	// "github.com/rahulcodepython/todo-backend/apps/serviceaccounts" is a local package that contains the service account controllers.
+	"github.com/rahulcodepython/todo-backend/apps/serviceaccounts"
+	// "github.com/rahulcodepython/todo-backend/apps/sso" is a local package that contains the single sign-on controllers.
+	"github.com/rahulcodepython/todo-backend/apps/sso"
+	// "github.com/rahulcodepython/todo-backend/apps/slack" is a local package that contains the Slack integration controllers.
+	"github.com/rahulcodepython/todo-backend/apps/slack"
+	// "github.com/rahulcodepython/todo-backend/apps/tags" is a local package that contains the tag controllers.
+	"github.com/rahulcodepython/todo-backend/apps/tags"
+	// "github.com/rahulcodepython/todo-backend/apps/telegram" is a local package that contains the Telegram integration controllers.
+	"github.com/rahulcodepython/todo-backend/apps/telegram"
+	// "github.com/rahulcodepython/todo-backend/apps/todos" is a local package that contains the todo service and controllers.
+	"github.com/rahulcodepython/todo-backend/apps/todos"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains the user service and controllers.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/binding" is a local package that binds request parameters. It is used here to decode request bodies.
+	"github.com/rahulcodepython/todo-backend/backend/binding"
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/content" is a local package that validates text written by users.
+	"github.com/rahulcodepython/todo-backend/backend/content"
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that manages the database connection.
+	"github.com/rahulcodepython/todo-backend/backend/database"
+	// "github.com/rahulcodepython/todo-backend/backend/idgen" is a local package that creates new IDs.
+	"github.com/rahulcodepython/todo-backend/backend/idgen"
+	// "github.com/rahulcodepython/todo-backend/backend/lifecycle" is a local package that runs the shutdown of the application.
+	"github.com/rahulcodepython/todo-backend/backend/lifecycle"
+	// "github.com/rahulcodepython/todo-backend/backend/outbox" is a local package that contains the outbox relay.
+	"github.com/rahulcodepython/todo-backend/backend/outbox"
+	// "github.com/rahulcodepython/todo-backend/backend/router" is a local package that sets up the application's API routes.
+	"github.com/rahulcodepython/todo-backend/backend/router"
+	// "github.com/rahulcodepython/todo-backend/backend/storage" is a local package that stores files.
+	"github.com/rahulcodepython/todo-backend/backend/storage"
+)
+
+// Worker is a background task that runs until its context is cancelled.
+type Worker struct {
+	// Name identifies the worker in the logs.
+	Name string
+	// Run performs the work and returns once the context is cancelled.
+	Run func(ctx context.Context)
+}
+
+// Container holds every long-lived dependency of the application.
+type Container struct {
+	// Config is the application configuration.
+	Config *config.Config
+	// DB is the database connection.
+	DB *sql.DB
+	// Controllers holds the controllers served by the router.
+	Controllers router.Controllers
+	// Server is the HTTP server, with every route registered.
+	Server *fiber.App
+	// Workers are the background tasks started with the server.
+	Workers []Worker
+
+	// stopWorkers cancels the context of the workers, and is called by the shutdown.
+	stopWorkers context.CancelFunc
+	// workers tracks the running workers, so that shutdown can wait for them.
+	workers sync.WaitGroup
+}
+
+// New builds the container from a configuration.
+// It connects to the database and constructs the services, the controllers, the server, and the workers, but starts nothing.
+//
+// @param cfg *config.Config - The application configuration.
+// @return *Container - The container.
+// @return error - An error if a dependency could not be created.
+func New(cfg *config.Config) (*Container, error) {
+	// db is the database connection, which exits the application if the database cannot be reached.
+	db := database.ConnectDB(cfg)
+
+	// dispatcher routes reminders to the notification channels each user enabled.
+	dispatcher, err := notifications.NewDispatcher(cfg, db)
+	// This checks if the dispatcher could not be created.
+	if err != nil {
+		// If it could not, the connection is closed and the error is returned.
+		_ = db.Close()
+		return nil, fmt.Errorf("unable to create notification dispatcher: %w", err)
+	}
+	// publisher is the destination of the domain events selected by the configuration.
+	publisher, err := outbox.NewPublisher(cfg)
+	// This checks if the publisher could not be created.
+	if err != nil {
+		// If it could not, the connection is closed and the error is returned.
+		_ = db.Close()
+		return nil, fmt.Errorf("unable to create outbox publisher: %w", err)
+	}
+
+	// validator cleans and checks titles, descriptions, and list names on every surface that writes them.
+	validator := content.NewValidator(cfg, content.NewWordFilter(cfg.Content.BlockedWords))
+	// mailer sends the security alerts of the user service when a mail server is configured, and is left nil otherwise.
+	var mailer users.Mailer
+	// This checks if a mail server is configured.
+	if cfg.SMTP.Host != "" {
+		// If it is, the email notifier sends the alerts.
+		mailer = notifications.NewEmailNotifier(cfg)
+	}
+	// store is the storage backend of images and backups.
+	store := storage.New(cfg.Storage)
+	// todoService holds the business logic of todos, shared by the REST controllers and the chat integrations.
+	todoService := todos.NewTodoService(cfg, db, clock.System{}, idgen.UUIDv7{}, validator)
+	// userService holds the business logic of users.
+	userService := users.NewUserService(cfg, db, clock.System{}, idgen.UUIDv7{}, mailer)
+
+	// This checks if a first admin is configured.
+	if cfg.Bootstrap.AdminEmail != "" {
+		// If one is, it is created unless an earlier start did, so that a new deployment can reach the admin endpoints.
+		if err := BootstrapAdmin(context.Background(), userService, cfg.Bootstrap.AdminEmail, cfg.Bootstrap.AdminPassword); err != nil {
+			// If it could not be created, the connection is closed and the error is returned.
+			_ = db.Close()
+			return nil, err
+		}
+	}
+
+	// container is the new container.
+	container := &Container{
+		// The Config field is set to the application configuration.
+		Config: cfg,
+		// The DB field is set to the database connection.
+		DB: db,
+		// The Controllers field is set to one instance of every controller.
+		Controllers: router.Controllers{
+			// The user controller handles registration, login, and profiles.
+			Users: users.NewUserControl(userService),
+			// The todo controller handles todos.
+			Todos: todos.NewTodoControl(cfg, todoService),
+			// The list controller handles lists.
+			Lists: lists.NewListControl(cfg, db, idgen.UUIDv7{}, validator),
+			// The tag controller handles the styles of tags.
+			Tags: tags.NewTagControl(cfg, db),
+			// The sync controller handles offline sync.
+			Sync: offlinesync.NewSyncControl(cfg, db, validator),
+			// The media controller serves stored images, resized for the client.
+			Media: media.NewMediaControl(cfg, db, store),
+			// The notification controller handles notification preferences and devices.
+			Notifications: notifications.NewNotificationControl(cfg, db),
+			// The Telegram controller handles the Telegram integration.
+			Telegram: telegram.NewTelegramControl(cfg, db, todoService),
+			// The Slack controller handles the Slack integration.
+			Slack: slack.NewSlackControl(cfg, db, todoService),
+			// The audit controller handles the audit log.
+			Audit: audit.NewAuditControl(cfg, db),
+			// The CalDAV controller handles CalDAV clients.
+			CalDAV: caldav.NewCalDAVControl(cfg, db, idgen.UUIDv7{}, validator),
+			// The diagnostics controller reports runtime and database pool statistics.
+			Diagnostics: diagnostics.NewDiagnosticsControl(cfg, db),
+			// The admin controller serves the admin console and the data it shows.
+			Admin: admin.NewAdminControl(cfg, db, store),
+			// The meta controller describes the capabilities of the server.
+			Meta: meta.NewMetaControl(cfg),
+			// The service account controller handles the accounts machines authenticate as, and their tokens.
+			ServiceAccounts: serviceaccounts.NewServiceAccountControl(cfg, db, clock.System{}, idgen.UUIDv7{}),
+			// The single sign-on controller handles the connections to identity providers, and signs users in through them.
+			SSO: sso.NewSSOControl(cfg, db, clock.System{}, idgen.UUIDv7{}, userService),
+			// The SCIM controller lets the identity providers of the connections create, update, and deactivate users.
+			SCIM: scim.NewSCIMControl(cfg, db, clock.System{}, idgen.UUIDv7{}),
+		},
+		// The Workers field is set to the background tasks.
+		Workers: []Worker{
+			// The database monitor pings the database, and reconnects with a backoff while it cannot be reached.
+			{Name: "database monitor", Run: func(ctx context.Context) { database.Monitor(ctx, db) }},
+			// The reminder worker sends due-date reminders through the enabled channels.
+			{Name: "reminders", Run: func(ctx context.Context) { notifications.StartReminderWorker(ctx, cfg, db, dispatcher) }},
+			// The outbox relay publishes domain events from the outbox.
+			{Name: "outbox relay", Run: func(ctx context.Context) { outbox.StartRelay(ctx, cfg, db, publisher) }},
+			// The audit pruner deletes audit entries older than the retention.
+			{Name: "audit pruner", Run: func(ctx context.Context) { audit.StartPruner(ctx, cfg, db) }},
+			// The guest pruner deletes the guests whose tokens have all expired.
+			{Name: "guest pruner", Run: func(ctx context.Context) { users.StartGuestPruner(ctx, db, clock.System{}) }},
+			// The revoked token pruner forgets the revoked JWTs that have expired.
+			{Name: "revoked token pruner", Run: func(ctx context.Context) { users.StartRevokedTokenPruner(ctx, db, clock.System{}) }},
+		},
+	}
+
+	// This checks if a mail server is configured.
+	if cfg.SMTP.Host != "" {
+		// If it is, the digest worker emails the weekly digests.
+		container.Workers = append(container.Workers, Worker{Name: "digests", Run: func(ctx context.Context) {
+			notifications.StartDigestWorker(ctx, cfg, db, notifications.NewEmailNotifier(cfg))
+		}})
+	}
+
+	// This checks if the archive is turned on.
+	if cfg.Archive.After > 0 {
+		// If it is, the archive worker moves the old completed todos to the archive.
+		container.Workers = append(container.Workers, Worker{Name: "todo archive", Run: func(ctx context.Context) { todos.StartArchiveWorker(ctx, cfg, db, clock.System{}) }})
+	}
+
+	// The server is created with the WebDAV methods used by CalDAV added to the default request methods,
+	// and with the JSON decoder of the request bodies, which rejects unknown fields when STRICT_JSON is set and bodies nested deeper than LIMIT_JSON_MAX_DEPTH.
+	container.Server = fiber.New(fiber.Config{
+		RequestMethods: append(fiber.DefaultMethods[:len(fiber.DefaultMethods):len(fiber.DefaultMethods)], "PROPFIND", "REPORT"),
+		JSONDecoder:    binding.JSONDecoder(cfg.Server.StrictJSON, cfg.Limits.MaxJSONDepth),
+	})
+	// router.Router() is called to set up all the application routes and middleware.
+	router.Router(container.Server, cfg, db, container.Controllers)
+
+	// The container is returned.
+	return container, nil
+}
+
+// BootstrapAdmin creates the first admin of the deployment and logs the outcome.
+// An admin that an earlier start created, or that already existed, is not an error.
+//
+// @param ctx context.Context - The context of the call.
+// @param userService *users.UserService - The user service.
+// @param email string - The email address of the admin.
+// @param password string - The password of the admin.
+// @return error - An error if the admin could not be created.
+func BootstrapAdmin(ctx context.Context, userService *users.UserService, email string, password string) error {
+	// admin is the new admin, if one is created.
+	admin, err := userService.BootstrapAdmin(ctx, email, password)
+	// This checks how the bootstrap went.
+	switch {
+	case errors.Is(err, users.ErrAlreadyBootstrapped):
+		// The step was done before, so the variables can be removed.
+		log.Println("First admin was already bootstrapped; BOOTSTRAP_ADMIN_EMAIL and BOOTSTRAP_ADMIN_PASSWORD can be removed.")
+	case errors.Is(err, users.ErrAdminExists):
+		// An admin existed, so none was created and the step is done.
+		log.Println("An admin already exists; no first admin was bootstrapped.")
+	case err != nil:
+		// The admin could not be created.
+		return fmt.Errorf("unable to bootstrap the first admin: %w", err)
+	default:
+		// The admin was created.
+		log.Printf("First admin %s bootstrapped.", admin.ID)
+	}
+	// No error is returned.
+	return nil
+}
+
+// Start starts the background workers and the HTTP server.
+// The server listens in the background; a failure to listen stops the application.
+func (c *Container) Start() {
+	// ctx is the context of the workers, cancelled by the shutdown.
+	ctx, stop := context.WithCancel(context.Background())
+	// The cancel function is kept for the shutdown.
+	c.stopWorkers = stop
+
+	// This iterates over the workers.
+	for _, worker := range c.Workers {
+		// The worker is tracked.
+		c.workers.Add(1)
+		// The worker is started in the background.
+		go func(worker Worker) {
+			// This defers marking the worker as stopped.
+			defer c.workers.Done()
+			// The worker runs until the context is cancelled.
+			worker.Run(ctx)
+			// A message is logged once the worker has stopped.
+			log.Printf("%s stopped", worker.Name)
+		}(worker)
+	}
+
+	// address is the server address, made of the host and port from the configuration.
+	address := fmt.Sprintf("%s:%s", c.Config.Server.Host, c.Config.Server.Port)
+	// A new goroutine is started to run the server, so that the caller can wait for a shutdown signal.
+	go func() {
+		// server.Listen() starts the HTTP server and listens for incoming requests on the specified address.
+		if err := c.Server.Listen(address); err != nil {
+			// If an error occurs while starting the server, log the error and panic.
+			log.Panicf("Server error: %v", err)
+		}
+	}()
+}
+
+// RegisterShutdown registers the shutdown of the application, in the reverse order of Start.
+// The server stops taking requests and finishes the active ones, then the workers are stopped and awaited,
+// and finally the database connection is closed.
+//
+// @param lc *lifecycle.Lifecycle - The lifecycle that runs the hooks.
+func (c *Container) RegisterShutdown(lc *lifecycle.Lifecycle) {
+	// The server stops listening and waits for the active requests, until the deadline of the shutdown.
+	lc.OnShutdown("http server", c.Server.ShutdownWithContext)
+	// The workers are stopped and awaited.
+	lc.OnShutdown("workers", func(ctx context.Context) error {
+		// This checks if the workers were started.
+		if c.stopWorkers != nil {
+			// If they were, they are stopped.
+			c.stopWorkers()
+		}
+		// stopped is closed once every worker has returned.
+		stopped := make(chan struct{})
+		// This waits for the workers in the background, so that the deadline can be kept.
+		go func() {
+			c.workers.Wait()
+			close(stopped)
+		}()
+		// This waits for the workers or the deadline.
+		select {
+		case <-stopped:
+			// Every worker returned.
+			return nil
+		case <-ctx.Done():
+			// The deadline passed first.
+			return ctx.Err()
+		}
+	})
+	// The database connection is closed once nothing uses it anymore.
+	lc.OnShutdown("database", func(context.Context) error { return c.DB.Close() })
+}
@@ -0,0 +1,190 @@
+// This file defines a circuit breaker that stops calls to a dependency after it failed several times in a row.
+// While the breaker is open, calls fail at once instead of waiting on a dependency that is down, and after a cooldown
+// a single probe call is let through to find out whether the dependency recovered.
+package breaker
+
+// "fmt" provides functions for formatted I/O. It is used here to build the error message.
+import (
+	"fmt"
+	// "log" provides a simple logging package. It is used here to log when the breaker opens and closes.
+	"log"
+	// "sync" provides synchronization primitives. It is used here to guard the state of the breaker.
+	"sync"
+	// "time" provides functions for working with time. It is used here to time the cooldown.
+	"time"
+
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+)
+
+const (
+	// Closed is the state in which every call is let through.
+	Closed = "closed"
+	// Open is the state in which every call fails at once.
+	Open = "open"
+	// HalfOpen is the state in which a single probe call is let through after the cooldown.
+	HalfOpen = "half_open"
+)
+
+// OpenError is returned instead of making a call while the breaker is open.
+type OpenError struct {
+	// RetryAfter is how long until the breaker lets a probe call through.
+	RetryAfter time.Duration
+}
+
+// Error returns the message of the error.
+//
+// @return string - The message.
+func (e *OpenError) Error() string {
+	// The message is returned.
+	return fmt.Sprintf("circuit breaker is open, retry after %s", e.RetryAfter)
+}
+
+// Breaker is a circuit breaker. It is safe for concurrent use.
+type Breaker struct {
+	// name identifies the breaker in the logs.
+	name string
+	// threshold is the number of consecutive failures that opens the breaker.
+	threshold int
+	// cooldown is how long the breaker stays open before it lets a probe call through.
+	cooldown time.Duration
+	// clock tells the current time.
+	clock clock.Clock
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// state is Closed, Open, or HalfOpen.
+	state string
+	// failures is the number of consecutive failures.
+	failures int
+	// openedAt is when the breaker last opened.
+	openedAt time.Time
+	// probing reports whether a probe call is in flight.
+	probing bool
+}
+
+// New creates a closed breaker.
+// A threshold of zero or less creates a breaker that never opens.
+//
+// @param name string - The name of the breaker in the logs.
+// @param threshold int - The number of consecutive failures that opens the breaker.
+// @param cooldown time.Duration - How long the breaker stays open before it lets a probe call through.
+// @param clk clock.Clock - The clock the cooldown is timed with.
+// @return *Breaker - A pointer to the new Breaker.
+func New(name string, threshold int, cooldown time.Duration, clk clock.Clock) *Breaker {
+	// A new closed Breaker is returned.
+	return &Breaker{name: name, threshold: threshold, cooldown: cooldown, clock: clk, state: Closed}
+}
+
+// Allow reports whether a call may be made. Every allowed call must be followed by Record.
+//
+// @return error - An *OpenError if the call must not be made, or nil.
+func (b *Breaker) Allow() error {
+	// The state is locked.
+	b.mu.Lock()
+	// This defers unlocking the state.
+	defer b.mu.Unlock()
+
+	// This handles the call by the state of the breaker.
+	switch b.state {
+	// An open breaker lets a probe through once the cooldown has passed.
+	case Open:
+		// wait is how long until the cooldown has passed.
+		wait := b.openedAt.Add(b.cooldown).Sub(b.clock.Now())
+		// This checks if the cooldown has not passed yet.
+		if wait > 0 {
+			// If it has not, the call fails at once.
+			return &OpenError{RetryAfter: wait}
+		}
+		// Otherwise the breaker is half-open and this call is the probe.
+		b.state = HalfOpen
+		// The probe is in flight.
+		b.probing = true
+		// No error is returned.
+		return nil
+	// A half-open breaker lets only one probe through at a time.
+	case HalfOpen:
+		// This checks if the probe is still in flight.
+		if b.probing {
+			// If it is, the call fails at once.
+			return &OpenError{RetryAfter: b.cooldown}
+		}
+		// Otherwise this call is the next probe.
+		b.probing = true
+		// No error is returned.
+		return nil
+	}
+	// A closed breaker lets every call through.
+	return nil
+}
+
+// Record reports the outcome of an allowed call.
+// A failure is a sign that the dependency is down, such as a broken connection, and not an error the dependency answered with.
+//
+// @param failed bool - True if the call failed.
+func (b *Breaker) Record(failed bool) {
+	// The state is locked.
+	b.mu.Lock()
+	// This defers unlocking the state.
+	defer b.mu.Unlock()
+
+	// This checks if the call succeeded.
+	if !failed {
+		// This checks if the call was a probe.
+		if b.state == HalfOpen {
+			// If it was, the dependency recovered, which is logged.
+			log.Printf("%s circuit breaker closed", b.name)
+		}
+		// This checks if the breaker is not open, since a call that started before it opened says nothing about now.
+		if b.state != Open {
+			// If it is not, the breaker is closed.
+			b.state = Closed
+			// The failures are reset.
+			b.failures = 0
+			// No probe is in flight.
+			b.probing = false
+		}
+		// Nothing else is done.
+		return
+	}
+
+	// The failure is counted.
+	b.failures++
+	// This handles the failure by the state of the breaker.
+	switch {
+	// A call that started before the breaker opened does not extend the cooldown.
+	case b.state == Open:
+		// Nothing else is done.
+		return
+	// A failed probe means the dependency is still down.
+	case b.state == HalfOpen:
+		// The breaker is opened again, which is logged.
+		log.Printf("%s circuit breaker probe failed, staying open", b.name)
+	// A closed breaker opens once the failures reach the threshold, unless it never opens.
+	case b.threshold > 0 && b.failures >= b.threshold:
+		// The transition is logged.
+		log.Printf("%s circuit breaker opened after %d consecutive failures", b.name, b.failures)
+	// Otherwise the breaker stays closed.
+	default:
+		// Nothing else is done.
+		return
+	}
+	// The breaker is opened.
+	b.state = Open
+	// The cooldown starts now.
+	b.openedAt = b.clock.Now()
+	// No probe is in flight.
+	b.probing = false
+}
+
+// State returns the state of the breaker.
+//
+// @return string - Closed, Open, or HalfOpen.
+func (b *Breaker) State() string {
+	// The state is locked.
+	b.mu.Lock()
+	// This defers unlocking the state.
+	defer b.mu.Unlock()
+	// The state is returned.
+	return b.state
+}
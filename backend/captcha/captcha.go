@@ -0,0 +1,148 @@
+// This file defines the verification of captcha tokens with hCaptcha or Cloudflare Turnstile.
+// Both providers share the siteverify protocol: the token is posted as a form with the secret key, and the answer is JSON.
+package captcha
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to bound the call to the provider by the request.
+import (
+	"context"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to decode the answer of the provider.
+	"encoding/json"
+	// "errors" provides functions for working with errors. It is used here to define the missing token error.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to describe a failed call.
+	"fmt"
+	// "net/http" provides HTTP client and server implementations. It is used here to call the provider.
+	"net/http"
+	// "net/url" provides functions for working with URLs. It is used here to encode the form.
+	"net/url"
+	// "strings" provides functions for working with strings. It is used here to send the form and join the error codes.
+	"strings"
+	// "time" provides functions for working with time. It is used here to set the request timeout.
+	"time"
+
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+// MissingToken is the error code both providers use for a request without a token.
+const MissingToken = "missing-input-response"
+
+// ErrMissingToken is returned when a request carries no captcha token.
+var ErrMissingToken = errors.New("captcha token is required")
+
+// RejectedError is returned when the provider does not accept a token, such as an expired or reused one.
+type RejectedError struct {
+	// Codes are the error codes of the provider, such as "invalid-input-response".
+	Codes []string
+}
+
+// Error describes the rejection.
+//
+// @return string - The message.
+func (e *RejectedError) Error() string {
+	// The error codes are listed in the message.
+	return "captcha rejected: " + strings.Join(e.Codes, ", ")
+}
+
+// Verifier checks captcha tokens.
+type Verifier interface {
+	// Verify returns nil if the token was solved by the client at the IP address.
+	Verify(ctx context.Context, token string, remoteIP string) error
+}
+
+// NewVerifier creates the verifier of the configured provider.
+//
+// @param cfg config.CaptchaConfig - The captcha configuration.
+// @return Verifier - The verifier, or nil when no provider is configured.
+func NewVerifier(cfg config.CaptchaConfig) Verifier {
+	// This checks if no provider is configured.
+	if cfg.Provider == "" {
+		// If none is, there is nothing to verify with.
+		return nil
+	}
+	// A new SiteVerifier is returned.
+	return &SiteVerifier{
+		// The url field is set to the siteverify endpoint.
+		url: cfg.VerifyURL,
+		// The secret field is set to the secret key.
+		secret: cfg.Secret,
+		// The http field is set to a client with a timeout, so that a slow provider cannot hold the request for long.
+		http: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// SiteVerifier verifies tokens with the siteverify endpoint of a provider.
+type SiteVerifier struct {
+	// url is the siteverify endpoint.
+	url string
+	// secret is the secret key of the site.
+	secret string
+	// http is the HTTP client used for the calls.
+	http *http.Client
+}
+
+// siteverifyResponse is the answer of a siteverify endpoint.
+type siteverifyResponse struct {
+	// Success reports whether the token is valid.
+	// json:"success" specifies that this field should be marshalled to/from a JSON object with the key "success".
+	Success bool `json:"success"`
+	// ErrorCodes lists the reasons the token was rejected.
+	// json:"error-codes" specifies that this field should be marshalled to/from a JSON object with the key "error-codes".
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// Verify posts the token to the provider.
+//
+// @param ctx context.Context - The context of the request, which carries its deadline.
+// @param token string - The captcha token the client solved.
+// @param remoteIP string - The IP address of the client, which the provider compares with the one that solved the captcha.
+// @return error - ErrMissingToken if the token is empty, a *RejectedError if the provider rejected it, or another error if the provider could not be reached.
+func (sv *SiteVerifier) Verify(ctx context.Context, token string, remoteIP string) error {
+	// This checks if the client sent no token.
+	if token == "" {
+		// If it did not, the provider is not called.
+		return ErrMissingToken
+	}
+
+	// form is the body of the verification request.
+	form := url.Values{"secret": {sv.secret}, "response": {token}, "remoteip": {remoteIP}}
+	// req is the verification request.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sv.url, strings.NewReader(form.Encode()))
+	// This checks if an error occurred while building the request.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// The body is sent as a form.
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	// res is the answer of the provider.
+	res, err := sv.http.Do(req)
+	// This checks if the provider could not be reached.
+	if err != nil {
+		// If it could not, the error is returned.
+		return fmt.Errorf("calling captcha provider: %w", err)
+	}
+	// This defers closing the body of the answer.
+	defer res.Body.Close()
+	// This checks if the provider failed.
+	if res.StatusCode != http.StatusOK {
+		// If it did, an error is returned.
+		return fmt.Errorf("captcha provider answered %s", res.Status)
+	}
+
+	// result is the decoded answer.
+	var result siteverifyResponse
+	// This decodes the answer.
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		// If an error occurs, it is returned.
+		return fmt.Errorf("decoding captcha answer: %w", err)
+	}
+	// This checks if the token was rejected.
+	if !result.Success {
+		// If it was, the codes of the provider are returned.
+		return &RejectedError{Codes: result.ErrorCodes}
+	}
+	// The token is valid.
+	return nil
+}
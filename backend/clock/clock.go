@@ -0,0 +1,36 @@
+// This file defines the clock that services read the current time from.
+// Services take a Clock instead of calling time.Now() directly, so that timestamps and expiry checks can be fixed in a test.
+package clock
+
+// "time" provides functions for working with time. It is used here to read the current time.
+import (
+	"time"
+)
+
+// Clock tells the current time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+}
+
+// System is the Clock backed by the system time.
+type System struct{}
+
+// Now returns the current system time.
+//
+// @return time.Time - The current time.
+func (System) Now() time.Time {
+	// The system time is returned.
+	return time.Now()
+}
+
+// Fixed is a Clock that always returns the same time.
+type Fixed time.Time
+
+// Now returns the fixed time.
+//
+// @return time.Time - The fixed time.
+func (f Fixed) Now() time.Time {
+	// The fixed time is returned.
+	return time.Time(f)
+}
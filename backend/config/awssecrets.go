@@ -0,0 +1,281 @@
+// This file reads secrets from AWS Secrets Manager through its HTTP API, signed with Signature Version 4,
+// so that the application does not depend on the AWS SDK for the few calls it makes at startup.
+package config
+
+// "bytes" provides functions for working with byte slices. It is used here to send the body of the calls.
+import (
+	"bytes"
+	// "crypto/hmac" implements HMAC. It is used here to derive the signing key and sign the calls.
+	"crypto/hmac"
+	// "crypto/sha256" implements SHA-256. It is used here to hash the calls for their signature.
+	"crypto/sha256"
+	// "encoding/hex" provides hexadecimal encoding. It is used here to write the hashes and the signature.
+	"encoding/hex"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to write the calls and read their answers.
+	"encoding/json"
+	// "fmt" provides functions for formatted I/O. It is used here to build the errors and the authorization header.
+	"fmt"
+	// "io" provides basic I/O primitives. It is used here to read the error answers.
+	"io"
+	// "log" provides a simple logging package. It is used here to stop the application when no region is configured.
+	"log"
+	// "net/http" provides an HTTP client. It is used here to call AWS.
+	"net/http"
+	// "os" provides a platform-independent interface to operating system functionality. It is used here to read the credentials of the environment.
+	"os"
+	// "sort" provides functions for sorting slices. It is used here to order the signed headers.
+	"sort"
+	// "strings" provides functions for working with strings. It is used here to parse the references and build the canonical request.
+	"strings"
+	// "time" provides functions for working with time. It is used here to date the signature.
+	"time"
+)
+
+// awsCredentials are the credentials the calls to AWS are signed with.
+type awsCredentials struct {
+	// AccessKeyID is the ID of the access key.
+	// json:"AccessKeyId" specifies that this field should be marshalled to/from a JSON object with the key "AccessKeyId".
+	AccessKeyID string `json:"AccessKeyId"`
+	// SecretAccessKey is the secret of the access key.
+	// json:"SecretAccessKey" specifies that this field should be marshalled to/from a JSON object with the key "SecretAccessKey".
+	SecretAccessKey string `json:"SecretAccessKey"`
+	// SessionToken is the token of temporary credentials, or empty for long-lived ones.
+	// json:"Token" specifies that this field should be marshalled to/from a JSON object with the key "Token".
+	SessionToken string `json:"Token"`
+}
+
+// awsSecretsProvider reads secrets from AWS Secrets Manager.
+type awsSecretsProvider struct {
+	// region is the region of the secrets whose reference is not an ARN.
+	region string
+	// client is the HTTP client of the calls.
+	client *http.Client
+}
+
+// newAWSSecretsProvider creates the AWS Secrets Manager provider, in the region of "AWS_REGION" or "AWS_DEFAULT_REGION".
+//
+// @return SecretProvider - The AWS Secrets Manager provider.
+func newAWSSecretsProvider() SecretProvider {
+	// The provider is returned.
+	return &awsSecretsProvider{region: firstNonEmpty(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION")), client: &http.Client{Timeout: secretTimeout}}
+}
+
+// Secret returns the current version of a secret of AWS Secrets Manager, or a key of it if the secret is a JSON object.
+// The secret is in the region of its ARN, or in the configured region if it is referenced by name.
+//
+// @param reference string - The name or ARN of the secret, optionally followed by "#" and a key, such as "prod/todo-backend#password".
+// @return string - The value.
+// @return error - An error if the secret cannot be read.
+func (p *awsSecretsProvider) Secret(reference string) (string, error) {
+	// id and key are the two halves of the reference.
+	id, key, _ := strings.Cut(reference, "#")
+	// This checks if the reference names no secret.
+	if id == "" {
+		// If it names none, an error is returned.
+		return "", fmt.Errorf("%w: expected aws-sm:<secret id>[#<key>]", ErrInvalidSecretReference)
+	}
+	// region is the region of the secret, taken from its ARN, such as "arn:aws:secretsmanager:eu-west-1:123456789012:secret:name", if it is one.
+	region := p.region
+	// This checks if the reference is an ARN.
+	if parts := strings.Split(id, ":"); len(parts) > 3 && parts[0] == "arn" {
+		// If it is, the region of the ARN is used.
+		region = parts[3]
+	}
+	// This checks if no region is known.
+	if region == "" {
+		// If none is, a fatal error is logged.
+		log.Fatalf("AWS_REGION must be set to read secrets from AWS Secrets Manager by name")
+	}
+
+	// credentials are the credentials of the call.
+	credentials, err := p.credentials()
+	// This checks if an error occurred while reading the credentials.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+	// body is the body of the call.
+	body, err := json.Marshal(map[string]string{"SecretId": id})
+	// This checks if an error occurred while encoding the body.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+	// request is the GetSecretValue call.
+	request, err := http.NewRequest(http.MethodPost, "https://secretsmanager."+region+".amazonaws.com/", bytes.NewReader(body))
+	// This checks if an error occurred while creating the request.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+	// The headers name the operation.
+	request.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	request.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	// The call is signed.
+	signAWSRequest(request, body, credentials, region, "secretsmanager", time.Now())
+
+	// response is the answer of AWS.
+	response, err := p.client.Do(request)
+	// This checks if an error occurred while calling AWS.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+	// This defers closing the body until the function returns.
+	defer response.Body.Close()
+	// This checks if AWS refused the call, such as for a missing secret or credentials without access.
+	if response.StatusCode != http.StatusOK {
+		// detail is the start of the answer, which names the error without holding the secret.
+		detail, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		// An error is returned.
+		return "", fmt.Errorf("aws answered %s: %s", response.Status, strings.TrimSpace(string(detail)))
+	}
+	// answer is the answer of AWS.
+	var answer struct {
+		// SecretString is the value of a text secret.
+		// json:"SecretString" specifies that this field should be marshalled to/from a JSON object with the key "SecretString".
+		SecretString *string `json:"SecretString"`
+	}
+	// This decodes the answer.
+	if err := json.NewDecoder(response.Body).Decode(&answer); err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+	// This checks if the secret is binary.
+	if answer.SecretString == nil {
+		// If it is, an error is returned, since the configuration only holds text.
+		return "", fmt.Errorf("secret %s is binary", id)
+	}
+	// The secret, or its key, is returned.
+	return jsonSecretKey(*answer.SecretString, key)
+}
+
+// credentials returns the credentials of the calls: the "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", and "AWS_SESSION_TOKEN" variables,
+// or else the credentials of the task role that ECS serves to its containers.
+//
+// @return awsCredentials - The credentials.
+// @return error - An error if no credentials are found.
+func (p *awsSecretsProvider) credentials() (awsCredentials, error) {
+	// This checks if the environment holds credentials.
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		// If it does, they are returned.
+		return awsCredentials{AccessKeyID: id, SecretAccessKey: secret, SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+	// uri is the path of the credentials of the task role, which ECS sets.
+	uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI")
+	// This checks if the application does not run in ECS.
+	if uri == "" {
+		// If it does not, an error is returned.
+		return awsCredentials{}, fmt.Errorf("no AWS credentials: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or run with an ECS task role")
+	}
+	// response is the answer of the credentials endpoint of ECS.
+	response, err := p.client.Get("http://169.254.170.2" + uri)
+	// This checks if an error occurred while calling the endpoint.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return awsCredentials{}, err
+	}
+	// This defers closing the body until the function returns.
+	defer response.Body.Close()
+	// This checks if the endpoint refused the call.
+	if response.StatusCode != http.StatusOK {
+		// If it did, an error is returned.
+		return awsCredentials{}, fmt.Errorf("ECS credentials endpoint answered %s", response.Status)
+	}
+	// credentials are the credentials of the task role.
+	var credentials awsCredentials
+	// This decodes the credentials.
+	err = json.NewDecoder(response.Body).Decode(&credentials)
+	// The credentials and the error, if any, are returned.
+	return credentials, err
+}
+
+// signAWSRequest signs a call to AWS with Signature Version 4, setting its X-Amz-Date, X-Amz-Security-Token, and Authorization headers.
+// Every header set before it is signed, along with the host.
+//
+// @param request *http.Request - The call, with every other header set.
+// @param body []byte - The body of the call.
+// @param credentials awsCredentials - The credentials.
+// @param region string - The region of the service.
+// @param service string - The name of the service, such as "secretsmanager".
+// @param now time.Time - The time of the call.
+func signAWSRequest(request *http.Request, body []byte, credentials awsCredentials, region string, service string, now time.Time) {
+	// stamp is the time of the call, and day its date.
+	stamp := now.UTC().Format("20060102T150405Z")
+	day := stamp[:8]
+	// The time of the call is set, and the token of temporary credentials.
+	request.Header.Set("X-Amz-Date", stamp)
+	// This checks if the credentials are temporary.
+	if credentials.SessionToken != "" {
+		// If they are, their token is sent.
+		request.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	// names are the lowercase names of the signed headers, in order, and headers the canonical headers.
+	names := []string{"host"}
+	// This iterates over the headers.
+	for name := range request.Header {
+		// The header is signed.
+		names = append(names, strings.ToLower(name))
+	}
+	// The headers are sorted by name.
+	sort.Strings(names)
+	// headers is the canonical headers, one "name:value" line each.
+	var headers strings.Builder
+	// This iterates over the names.
+	for _, name := range names {
+		// value is the value of the header, which is the host for "host".
+		value := request.Header.Get(name)
+		// This checks if the header is the host.
+		if name == "host" {
+			// If it is, the host of the URL is used.
+			value = request.URL.Host
+		}
+		// The header is written.
+		headers.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	// signedHeaders is the list of signed headers.
+	signedHeaders := strings.Join(names, ";")
+	// path is the path of the call, which is "/" for an empty one.
+	path := firstNonEmpty(request.URL.EscapedPath(), "/")
+	// canonical is the canonical request, whose hash is signed.
+	canonical := strings.Join([]string{request.Method, path, request.URL.Query().Encode(), headers.String(), signedHeaders, sha256Hex(body)}, "\n")
+	// scope is the scope of the signature.
+	scope := day + "/" + region + "/" + service + "/aws4_request"
+	// toSign is the string that is signed.
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	// key is the signing key, derived from the secret for the day, region, and service.
+	key := hmacSHA256([]byte("AWS4"+credentials.SecretAccessKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	// The authorization header is set with the signature.
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		credentials.AccessKeyID, scope, signedHeaders, hex.EncodeToString(hmacSHA256(key, toSign))))
+}
+
+// sha256Hex returns the hexadecimal SHA-256 hash of data.
+//
+// @param data []byte - The data.
+// @return string - The hash.
+func sha256Hex(data []byte) string {
+	// sum is the hash of the data.
+	sum := sha256.Sum256(data)
+	// The hash is returned in hexadecimal.
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with a key.
+//
+// @param key []byte - The key.
+// @param data string - The data.
+// @return []byte - The HMAC.
+func hmacSHA256(key []byte, data string) []byte {
+	// mac is the HMAC of the key.
+	mac := hmac.New(sha256.New, key)
+	// The data is written.
+	mac.Write([]byte(data))
+	// The HMAC is returned.
+	return mac.Sum(nil)
+}
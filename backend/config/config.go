@@ -8,6 +8,8 @@ import (
 	"os"
 	// "strconv" provides functions for converting strings to other types. It is used here to convert the database port and JWT expiry to integers.
 	"strconv"
+	// "strings" provides functions for working with strings. It is used here to read the CORS origins of the environment.
+	"strings"
 	// "time" provides functions for working with time. It is used here to set the JWT expiration duration.
 	"time"
 
@@ -21,11 +23,21 @@ type ServerConfig struct {
 	Port string
 	// Host is the host of the server.
 	Host string
+	// RequestTimeout is how long a request may take before its queries are cancelled and it is answered with 504.
+	RequestTimeout time.Duration
+	// ShutdownTimeout is how long the shutdown may take, from draining the requests to closing the database, before the application exits anyway.
+	ShutdownTimeout time.Duration
+	// StrictJSON reports whether JSON request bodies with fields that the endpoint does not know are rejected with 400.
+	StrictJSON bool
+	// PublicURL is the address clients reach the server at, used to build the links sent by email.
+	PublicURL string
+	// VersionHeader reports whether every response carries the version of the build in the X-App-Version header.
+	VersionHeader bool
 }
 
 // DatabaseConfig defines the structure for database-related configuration.
 type DatabaseConfig struct {
-	// DBHost is the host of the database.
+	// DBHost is the host of the database, or the absolute directory of its Unix socket, such as "/var/run/postgresql".
 	DBHost string
 	// DBPort is the port of the database.
 	DBPort int
@@ -37,6 +49,20 @@ type DatabaseConfig struct {
 	DBName string
 	// DBSSLMode is the SSL mode for the database connection.
 	DBSSLMode string
+	// DBSSLRootCert is the file of the certificate authorities the certificate of the server is verified with, or empty for the system ones.
+	DBSSLRootCert string
+	// DBSSLCert is the file of the client certificate the application authenticates to the database with, or empty for none.
+	DBSSLCert string
+	// DBSSLKey is the file of the private key of the client certificate.
+	DBSSLKey string
+	// BreakerThreshold is the number of consecutive database failures that opens the circuit breaker, or 0 to never open it.
+	BreakerThreshold int
+	// BreakerCooldown is how long the circuit breaker stays open before a probe query is let through.
+	BreakerCooldown time.Duration
+	// SlowQueryThreshold is how long a query may take before it is logged as slow, or 0 to not watch queries.
+	SlowQueryThreshold time.Duration
+	// HealthCheckInterval is how often the database is pinged in the background, and how long a pooled connection may sit idle before it is pinged on reuse.
+	HealthCheckInterval time.Duration
 }
 
 // JWTConfig defines the structure for JWT-related configuration.
@@ -45,12 +71,432 @@ type JWTConfig struct {
 	SecretKey string
 	// Expires is the duration for which a JWT is valid.
 	Expires time.Duration
+	// RememberMeExpires is the duration for which the JWT of a login that asks to be remembered is valid.
+	RememberMeExpires time.Duration
+	// LastUsedInterval is how stale the last-used time of a session may get before a request records it again,
+	// so that a busy client does not write to the database on every request.
+	LastUsedInterval time.Duration
+	// IdleTimeout is how long a session may go unused before it ends, however long it has left before it expires,
+	// or zero if sessions only end when they expire.
+	IdleTimeout time.Duration
+	// APIKeyExpires is the lifetime of an API key whose request does not choose one.
+	APIKeyExpires time.Duration
+	// APIKeyMaxExpires is the longest lifetime a user may choose for an API key.
+	APIKeyMaxExpires time.Duration
+	// ServiceTokenExpires is the lifetime of the tokens service accounts get from their client credentials.
+	ServiceTokenExpires time.Duration
+	// EmailChangeExpires is how long the confirmation link of an email change stays valid.
+	EmailChangeExpires time.Duration
+	// BindFingerprint reports whether a session only works from the User-Agent and the IP subnet it was issued to,
+	// so that a stolen token is refused from another device or network.
+	BindFingerprint bool
+	// FingerprintIPv4Prefix is the length of the prefix of an IPv4 address that the fingerprint of a session keeps.
+	FingerprintIPv4Prefix int
+	// FingerprintIPv6Prefix is the length of the prefix of an IPv6 address that the fingerprint of a session keeps.
+	FingerprintIPv6Prefix int
+}
+
+// TodoConfig defines the structure for todo-related configuration.
+type TodoConfig struct {
+	// UndoWindow is the duration for which a delete or complete action can be undone.
+	UndoWindow time.Duration
+	// DetectDuplicateTitles reports whether creating a todo is refused with 409 while the user has an open todo with the same normalized title.
+	DetectDuplicateTitles bool
+	// DailyCapacityMinutes is the estimated effort a user can get through in a day, above which the plan marks a day as overloaded.
+	DailyCapacityMinutes int
+	// BulkConfirmThreshold is the number of todos above which completing every todo of a list asks for a confirmation token first.
+	BulkConfirmThreshold int
+}
+
+// PaginationConfig defines the structure for the page sizes of the paginated endpoints.
+type PaginationConfig struct {
+	// DefaultLimit is the page size used when a request does not ask for one.
+	DefaultLimit int
+	// MaxLimit is the largest page size a request may ask for.
+	MaxLimit int
+}
+
+// ContentConfig defines the structure for the validation of text written by users.
+type ContentConfig struct {
+	// TitleMaxLength is the maximum number of characters of a todo title.
+	TitleMaxLength int
+	// DescriptionMaxLength is the maximum number of characters of a todo description.
+	DescriptionMaxLength int
+	// ListNameMaxLength is the maximum number of characters of a list name.
+	ListNameMaxLength int
+	// BlockedWords are the words that titles, descriptions, and list names may not contain. It is empty to allow all words.
+	BlockedWords []string
+}
+
+// LimitsConfig defines the structure for the caps on the size of bulk requests, so that a large request is cut short instead of timing out or exhausting memory.
+type LimitsConfig struct {
+	// MaxBatchItems is the maximum number of items of a bulk request, such as the todos of a move; the rest is reported as skipped.
+	MaxBatchItems int
+	// MaxImportRows is the maximum number of changes of a sync push that are applied; the rest is reported as skipped.
+	MaxImportRows int
+	// MaxJSONDepth is the maximum nesting depth of objects and arrays in a JSON request body.
+	MaxJSONDepth int
+}
+
+// RateLimitConfig defines the structure for rate limiting configuration.
+type RateLimitConfig struct {
+	// Window is the time frame in which requests are counted.
+	Window time.Duration
+	// ReadMax is the number of read requests an authenticated user can make per window.
+	ReadMax int
+	// WriteMax is the number of write requests an authenticated user can make per window.
+	WriteMax int
+	// AnonymousMax is the number of requests an IP address can make per window to the endpoints that do not require a user.
+	AnonymousMax int
+	// ServiceReadMax is the number of read requests a service account can make per window.
+	ServiceReadMax int
+	// ServiceWriteMax is the number of write requests a service account can make per window.
+	ServiceWriteMax int
+}
+
+// LoginThrottleConfig defines the structure for the throttling of failed logins per account.
+// It complements the limit by IP address, which an attacker spreading guesses over many proxies does not hit.
+type LoginThrottleConfig struct {
+	// FreeAttempts is the number of failed logins an account may have before its logins are slowed down.
+	FreeAttempts int
+	// BaseDelay is the delay of the first slowed down login. It doubles with every further failure.
+	BaseDelay time.Duration
+	// MaxDelay is the longest delay of a login.
+	MaxDelay time.Duration
+	// CaptchaAfter is the number of failed logins after which a captcha is asked for. It is 0 to never ask for one.
+	CaptchaAfter int
+	// Window is how long a failed login is remembered after the last one.
+	Window time.Duration
+}
+
+// StorageConfig defines the structure for the storage backend of files, such as images and backups.
+type StorageConfig struct {
+	// Dir is the directory the files are stored in.
+	Dir string
+}
+
+// MediaConfig defines the structure for the resizing of stored images.
+type MediaConfig struct {
+	// MaxSourceBytes is the largest stored image that is served or resized.
+	MaxSourceBytes int64
+	// MaxSourcePixels is the largest number of pixels of an image that is decoded, so that a small file cannot expand into gigabytes of memory.
+	MaxSourcePixels int
+}
+
+// URLSigningConfig defines the structure for the keys of signed URLs, such as download and unsubscribe links.
+type URLSigningConfig struct {
+	// Keys are the signing keys. The first one signs new URLs and every one of them verifies,
+	// so that a key can be rotated out without breaking the URLs it signed until they expire.
+	Keys []SigningKey
+}
+
+// SigningKey is one key of signed URLs.
+type SigningKey struct {
+	// ID names the key in the URLs it signs.
+	ID string
+	// Secret is the HMAC secret of the key.
+	Secret string
+}
+
+// RequestSigningConfig defines the structure for the signatures of the requests to internal endpoints, such as the admin API.
+type RequestSigningConfig struct {
+	// Keys are the keys requests are signed with, each named by its ID in the requests. Signatures are not asked for when there are none.
+	Keys []SigningKey
+	// MaxSkew is how far the timestamp of a signed request may be from the time of the server, so that a captured request cannot be replayed later.
+	MaxSkew time.Duration
+}
+
+// BootstrapConfig defines the structure for the one-time setup of a new deployment.
+type BootstrapConfig struct {
+	// AdminEmail is the email address of the first admin, which is created on the first start if the database has no admin. Nothing is created when it is empty.
+	AdminEmail string
+	// AdminPassword is the password of the first admin.
+	AdminPassword string
+}
+
+// GuestConfig defines the structure for the guest sessions, which let people try the application before they register.
+type GuestConfig struct {
+	// Enabled reports whether guest sessions can be started.
+	Enabled bool
+	// Expires is the lifetime of a guest token. A guest whose tokens have all expired is deleted with their todos and lists.
+	Expires time.Duration
+}
+
+// DeviceConfig defines the structure for the device authorization grant, which signs in command-line tools such as todoctl
+// once the user approves a short code from a browser.
+type DeviceConfig struct {
+	// VerificationURL is the page where users enter the code of a device. When it is empty, PUBLIC_URL followed by /device is used.
+	VerificationURL string
+	// Expires is how long a device has to be approved before its codes stop working.
+	Expires time.Duration
+}
+
+// CaptchaConfig defines the structure for the captcha that protects the endpoints which create accounts.
+type CaptchaConfig struct {
+	// Provider is the captcha service, "hcaptcha" or "turnstile". Captchas are not asked for when it is empty.
+	Provider string
+	// Secret is the secret key the server verifies captcha tokens with.
+	Secret string
+	// VerifyURL is the siteverify endpoint of the provider.
+	VerifyURL string
+}
+
+// AuditConfig defines the structure for the audit log of mutating requests.
+type AuditConfig struct {
+	// Enabled reports whether mutating requests are recorded.
+	Enabled bool
+	// Retention is how long audit entries are kept before they are pruned.
+	Retention time.Duration
+}
+
+// DiagnosticsConfig defines the structure for the admin profiling and runtime diagnostics endpoints.
+type DiagnosticsConfig struct {
+	// Enabled reports whether the pprof and runtime diagnostics endpoints are mounted.
+	Enabled bool
+}
+
+// ReminderConfig defines the structure for due-date reminder configuration.
+type ReminderConfig struct {
+	// Interval is how often the reminder worker looks for todos that are due.
+	Interval time.Duration
+	// Lead is how long before the due date a reminder is sent.
+	Lead time.Duration
+}
+
+// ArchiveConfig defines the structure for the archive of old completed todos.
+type ArchiveConfig struct {
+	// After is how long a completed todo stays unchanged before it is moved to the archive. Zero turns the archive off.
+	After time.Duration
+}
+
+// DigestConfig defines the structure for the weekly email digest configuration.
+type DigestConfig struct {
+	// Interval is how often the digest worker looks for users whose digest is due.
+	Interval time.Duration
+	// Hour is the hour of the day, in the user's time zone, from which the digest of the preferred day is sent.
+	Hour int
+}
+
+// SMTPConfig defines the structure for the mail server used to send email notifications.
+type SMTPConfig struct {
+	// Host is the host of the mail server. Email notifications are disabled when it is empty.
+	Host string
+	// Port is the port of the mail server.
+	Port string
+	// Username is the user name used to sign in to the mail server.
+	Username string
+	// Password is the password used to sign in to the mail server.
+	Password string
+	// From is the sender address of the emails.
+	From string
+}
+
+// PushConfig defines the structure for mobile push notification configuration.
+type PushConfig struct {
+	// FCMCredentialsFile is the path of the Firebase service account JSON file. FCM is disabled when it is empty.
+	FCMCredentialsFile string
+	// APNsKeyFile is the path of the APNs auth key (.p8) file. APNs is disabled when it is empty.
+	APNsKeyFile string
+	// APNsKeyID is the ID of the APNs auth key.
+	APNsKeyID string
+	// APNsTeamID is the Apple developer team ID.
+	APNsTeamID string
+	// APNsTopic is the bundle ID of the iOS app.
+	APNsTopic string
+	// APNsProduction selects the production APNs environment instead of the sandbox.
+	APNsProduction bool
+}
+
+// WebPushConfig defines the structure for browser push notification configuration.
+type WebPushConfig struct {
+	// PublicKey is the VAPID public key, an uncompressed P-256 point in unpadded base64url. Browsers need it to subscribe.
+	PublicKey string
+	// PrivateKey is the VAPID private key, a P-256 scalar in unpadded base64url. Web push is disabled when it is empty.
+	PrivateKey string
+	// Subject is the contact URL or mailto: address push services use to reach the operator.
+	Subject string
+}
+
+// TelegramConfig defines the structure for Telegram bot configuration.
+type TelegramConfig struct {
+	// BotToken is the token of the Telegram bot. The integration is disabled when it is empty.
+	BotToken string
+	// WebhookSecret is the secret Telegram sends in the X-Telegram-Bot-Api-Secret-Token header.
+	WebhookSecret string
+}
+
+// SlackConfig defines the structure for Slack app configuration.
+type SlackConfig struct {
+	// ClientID is the client ID of the Slack app.
+	ClientID string
+	// ClientSecret is the client secret of the Slack app.
+	ClientSecret string
+	// SigningSecret is used to verify requests sent by Slack. The integration is disabled when it is empty.
+	SigningSecret string
+	// RedirectURL is the OAuth redirect URL registered with the Slack app.
+	RedirectURL string
+}
+
+// OutboxConfig defines the structure for the outbox relay configuration.
+type OutboxConfig struct {
+	// Publisher selects where events are published: "webhook", "nats", or "kafka".
+	// When it is empty, the webhook is used if a URL is configured.
+	Publisher string
+	// WebhookURL is the URL that domain events are posted to. When it is empty, events are marked as published without being sent.
+	WebhookURL string
+	// WebhookSecret is used to sign the events posted to the webhook.
+	WebhookSecret string
+	// Interval is how often the relay looks for unpublished events.
+	Interval time.Duration
+	// BatchSize is the maximum number of events published in one pass.
+	BatchSize int
+	// MaxAttempts is the number of failed attempts after which an event is given up on.
+	MaxAttempts int
+	// Retention is how long published and failed events are kept.
+	Retention time.Duration
+	// NATSURL is the URL of the NATS server.
+	NATSURL string
+	// NATSSubjectPrefix is prepended to the event type to form the NATS subject.
+	NATSSubjectPrefix string
+	// KafkaBrokers is a comma-separated list of Kafka broker addresses.
+	KafkaBrokers string
+	// KafkaTopic is the Kafka topic the events are written to.
+	KafkaTopic string
+}
+
+// PlanLimits defines the usage limits of a plan. A limit of zero means unlimited.
+type PlanLimits struct {
+	// MaxTodos is the maximum number of todos a user can keep, not counting deleted ones.
+	MaxTodos int
+	// MaxLists is the maximum number of lists a user can keep.
+	MaxLists int
+	// MaxAttachmentBytes is the maximum total size of a user's attachments in bytes.
+	MaxAttachmentBytes int64
+}
+
+// QuotaConfig defines the structure for the per-plan usage limits.
+type QuotaConfig struct {
+	// Free holds the limits of the free plan, which every user starts on.
+	Free PlanLimits
+	// Pro holds the limits of the paid plan.
+	Pro PlanLimits
+	// Guest holds the limits of guests, which are kept small since anyone can start a guest session.
+	Guest PlanLimits
+}
+
+// Limits returns the limits of a plan. Unknown plans get the free limits.
+//
+// @param plan string - The plan of the user.
+// @return PlanLimits - The limits of the plan.
+func (qc QuotaConfig) Limits(plan string) PlanLimits {
+	// This checks which plan it is.
+	switch plan {
+	// The paid plan.
+	case "pro":
+		// The paid limits are returned.
+		return qc.Pro
+	// The plan of guests.
+	case "guest":
+		// The guest limits are returned.
+		return qc.Guest
+	}
+	// Otherwise the free limits are returned.
+	return qc.Free
 }
 
 // CORSConfig defines the structure for CORS-related configuration.
 type CORSConfig struct {
-	// CorsOrigins is a comma-separated list of allowed origins for CORS requests.
-	CorsOrigins string
+	// AllowOrigins is a comma-separated list of allowed origins for CORS requests.
+	// An origin may use a subdomain wildcard, such as "https://*.example.com".
+	AllowOrigins string
+	// AllowMethods is a comma-separated list of methods allowed in CORS requests.
+	AllowMethods string
+	// AllowHeaders is a comma-separated list of headers allowed in CORS requests.
+	AllowHeaders string
+	// ExposeHeaders is a comma-separated list of response headers that browsers may read.
+	ExposeHeaders string
+	// AllowCredentials is true if CORS requests may carry cookies and authorization headers.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache the result of a preflight request.
+	MaxAge time.Duration
+}
+
+// corsOrigins reads the allowed CORS origins of an environment.
+// The "CORS_ORIGINS_<ENV>" environment variable, such as "CORS_ORIGINS_PROD", takes precedence over "CORS_ORIGINS".
+//
+// @param environment string - The environment in which the application is running.
+// @return string - The comma-separated list of allowed origins.
+func corsOrigins(environment string) string {
+	// This checks if the environment has its own origins.
+	if origins := os.Getenv("CORS_ORIGINS_" + strings.ToUpper(environment)); origins != "" {
+		// If it has, they are returned.
+		return origins
+	}
+	// Otherwise the shared origins are returned.
+	return HandleMissingEnvValues("CORS_ORIGINS", "http://localhost:3000")
+}
+
+// urlSigningKeys reads the keys of signed URLs from the "URL_SIGNING_KEYS" environment variable,
+// a comma-separated list of "id:secret" pairs with the signing key first.
+// When it is not set, a single key derived from the JWT secret is used, so that signed URLs work out of the box.
+//
+// @param jwtSecret string - The JWT secret key.
+// @return []SigningKey - The keys.
+func urlSigningKeys(jwtSecret string) []SigningKey {
+	// raw is the value of the environment variable.
+	raw := HandleMissingEnvValues("URL_SIGNING_KEYS", "")
+	// This checks if no keys are configured.
+	if raw == "" {
+		// If none are, the JWT secret is used with a purpose prefix, so that a URL signature is never a valid JWT signature.
+		return []SigningKey{{ID: "default", Secret: "url-signing:" + jwtSecret}}
+	}
+	// The configured keys are returned.
+	return parseSigningKeys("URL_SIGNING_KEYS", raw)
+}
+
+// requestSigningKeys reads the keys of signed requests from the "REQUEST_SIGNING_KEYS" environment variable,
+// a comma-separated list of "id:secret" pairs, one for each service that calls the internal endpoints.
+// There is no default, since a secret every server derives alike would also be known to whoever holds the JWT secret.
+//
+// @return []SigningKey - The keys, or nil if requests are not signed.
+func requestSigningKeys() []SigningKey {
+	// raw is the value of the environment variable.
+	raw := HandleMissingEnvValues("REQUEST_SIGNING_KEYS", "")
+	// This checks if no keys are configured.
+	if raw == "" {
+		// If none are, requests are not signed.
+		return nil
+	}
+	// The configured keys are returned.
+	return parseSigningKeys("REQUEST_SIGNING_KEYS", raw)
+}
+
+// parseSigningKeys parses a comma-separated list of "id:secret" pairs.
+//
+// @param name string - The name of the environment variable, for the error.
+// @param raw string - The value of the environment variable.
+// @return []SigningKey - The keys.
+func parseSigningKeys(name string, raw string) []SigningKey {
+	// keys is the list of keys.
+	var keys []SigningKey
+	// seen is the set of key IDs, so that two keys cannot share an ID.
+	seen := map[string]bool{}
+	// This iterates over the pairs.
+	for _, pair := range strings.Split(raw, ",") {
+		// id and secret are the two halves of the pair.
+		id, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		// This checks if the pair is malformed or its ID is repeated.
+		if !ok || id == "" || secret == "" || seen[id] {
+			// If it is, a fatal error is logged.
+			log.Fatalf("Error parsing %s: %q is not a unique id:secret pair", name, id)
+		}
+		// The ID is marked as seen.
+		seen[id] = true
+		// The key is appended.
+		keys = append(keys, SigningKey{ID: id, Secret: secret})
+	}
+	// The keys are returned.
+	return keys
 }
 
 // Config is the main configuration struct that aggregates all other configuration types.
@@ -65,17 +511,70 @@ type Config struct {
 	JWT JWTConfig
 	// CORS holds the CORS-specific configuration.
 	CORS CORSConfig
+	// Todo holds the todo-specific configuration.
+	Todo TodoConfig
+	// Pagination holds the page sizes of the paginated endpoints.
+	Pagination PaginationConfig
+	// Content holds the validation of text written by users.
+	Content ContentConfig
+	// Limits holds the caps on the size of bulk requests.
+	Limits LimitsConfig
+	// Reminder holds the reminder-specific configuration.
+	Reminder ReminderConfig
+	// Digest holds the weekly email digest configuration.
+	Digest DigestConfig
+	// Archive holds the archive of old completed todos.
+	Archive ArchiveConfig
+	// Telegram holds the Telegram-specific configuration.
+	Telegram TelegramConfig
+	// Slack holds the Slack-specific configuration.
+	Slack SlackConfig
+	// Outbox holds the outbox relay configuration.
+	Outbox OutboxConfig
+	// SMTP holds the mail server configuration.
+	SMTP SMTPConfig
+	// Push holds the mobile push configuration.
+	Push PushConfig
+	// WebPush holds the browser push configuration.
+	WebPush WebPushConfig
+	// Quota holds the per-plan usage limits.
+	Quota QuotaConfig
+	// RateLimit holds the rate limiting configuration.
+	RateLimit RateLimitConfig
+	// LoginThrottle holds the configuration of the throttling of failed logins.
+	LoginThrottle LoginThrottleConfig
+	// Storage holds the storage backend configuration.
+	Storage StorageConfig
+	// Media holds the configuration of the resizing of images.
+	Media MediaConfig
+	// URLSigning holds the keys of signed URLs.
+	URLSigning URLSigningConfig
+	// RequestSigning holds the keys of signed requests to internal endpoints.
+	RequestSigning RequestSigningConfig
+	// Captcha holds the captcha configuration.
+	Captcha CaptchaConfig
+	// Bootstrap holds the one-time setup of a new deployment.
+	Bootstrap BootstrapConfig
+	// Guest holds the guest session configuration.
+	Guest GuestConfig
+	// Device holds the device authorization grant configuration.
+	Device DeviceConfig
+	// Audit holds the audit log configuration.
+	Audit AuditConfig
+	// Diagnostics holds the profiling and runtime diagnostics configuration.
+	Diagnostics DiagnosticsConfig
 }
 
 // HandleMissingEnvValues retrieves the value of an environment variable or returns a default value if it is not set.
 // It takes the name of the environment variable and a default value as input.
+// The value may be read from a file named by the variable with the "_FILE" suffix, or from a secret manager, as resolveEnv describes.
 //
 // @param envName string - The name of the environment variable.
 // @param defaultValue string - The default value to be returned if the environment variable is not set.
 // @return string - The value of the environment variable or the default value.
 func HandleMissingEnvValues(envName string, defaultValue string) string {
-	// envValue is the value of the environment variable.
-	envValue := os.Getenv(envName)
+	// envValue is the value of the environment variable, or of the file or secret it points to.
+	envValue := resolveEnv(envName)
 	// This checks if the environment variable is empty.
 	if envValue == "" {
 		// If the environment variable is empty, a warning is logged.
@@ -100,12 +599,39 @@ func LoadConfig() *Config {
 		log.Fatalf("Error loading .env file: %v", err)
 	}
 
-	// dbPort is the port of the database.
-	dbPort, err := strconv.Atoi(HandleMissingEnvValues("DB_PORT", "5432"))
-	// This checks if an error occurred while converting the database port to an integer.
+	// target is the connection settings of the database, read from DATABASE_URL or the discrete variables.
+	target := databaseTarget()
+
+	// breakerThreshold is the number of consecutive database failures that opens the circuit breaker.
+	breakerThreshold, err := strconv.Atoi(HandleMissingEnvValues("DB_BREAKER_THRESHOLD", "5"))
+	// This checks if an error occurred while converting the breaker threshold to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing DB_BREAKER_THRESHOLD: %v", err)
+	}
+
+	// breakerCooldown is the circuit breaker cooldown in seconds.
+	breakerCooldown, err := strconv.Atoi(HandleMissingEnvValues("DB_BREAKER_COOLDOWN_SECONDS", "30"))
+	// This checks if an error occurred while converting the breaker cooldown to an integer.
 	if err != nil {
 		// If an error occurs, a fatal error is logged.
-		log.Fatalf("Error parsing DB_PORT: %v", err)
+		log.Fatalf("Error parsing DB_BREAKER_COOLDOWN_SECONDS: %v", err)
+	}
+
+	// slowQueryThreshold is the duration in milliseconds after which a query is logged as slow.
+	slowQueryThreshold, err := strconv.Atoi(HandleMissingEnvValues("DB_SLOW_QUERY_MS", "500"))
+	// This checks if the slow query threshold is not a non-negative integer.
+	if err != nil || slowQueryThreshold < 0 {
+		// If it is not, a fatal error is logged.
+		log.Fatalf("DB_SLOW_QUERY_MS must be a non-negative integer, got %q", os.Getenv("DB_SLOW_QUERY_MS"))
+	}
+
+	// healthCheckInterval is the interval of the database health checks in seconds.
+	healthCheckInterval, err := strconv.Atoi(HandleMissingEnvValues("DB_HEALTH_CHECK_SECONDS", "15"))
+	// This checks if the health check interval is not a positive integer.
+	if err != nil || healthCheckInterval < 1 {
+		// If it is not, a fatal error is logged.
+		log.Fatalf("DB_HEALTH_CHECK_SECONDS must be a positive integer, got %q", os.Getenv("DB_HEALTH_CHECK_SECONDS"))
 	}
 
 	// expiry is the JWT expiration duration in hours.
@@ -116,42 +642,878 @@ func LoadConfig() *Config {
 		log.Fatalf("Error parsing JWT_EXPIRY_HOURS: %v", err)
 	}
 
+	// rememberMeExpiry is the expiration duration in hours of the JWT of a login that asks to be remembered.
+	rememberMeExpiry, err := strconv.Atoi(HandleMissingEnvValues("JWT_REMEMBER_ME_EXPIRY_HOURS", "720"))
+	// This checks if the expiry is not an integer, or shorter than the expiry of the other logins.
+	if err != nil || rememberMeExpiry < expiry {
+		// If it is, a fatal error is logged.
+		log.Fatalf("JWT_REMEMBER_ME_EXPIRY_HOURS must be an integer no less than JWT_EXPIRY_HOURS, got %q", os.Getenv("JWT_REMEMBER_ME_EXPIRY_HOURS"))
+	}
+
+	// lastUsedInterval is the interval between two records of the last use of a session, in seconds.
+	lastUsedInterval, err := strconv.Atoi(HandleMissingEnvValues("SESSION_LAST_USED_INTERVAL_SECONDS", "300"))
+	// This checks if an error occurred while converting the interval to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing SESSION_LAST_USED_INTERVAL_SECONDS: %v", err)
+	}
+
+	// idleTimeout is how long a session may go unused in hours, or zero if it may until it expires.
+	idleTimeout, err := strconv.Atoi(HandleMissingEnvValues("SESSION_IDLE_TIMEOUT_HOURS", "0"))
+	// This checks if the idle timeout is not a non-negative integer.
+	if err != nil || idleTimeout < 0 {
+		// If it is not, a fatal error is logged.
+		log.Fatalf("SESSION_IDLE_TIMEOUT_HOURS must be a non-negative integer, got %q", os.Getenv("SESSION_IDLE_TIMEOUT_HOURS"))
+	}
+	// This checks if the idle timeout is shorter than the interval between two records of the last use of a session,
+	// in which case a session in use would end because its last use was not recorded yet.
+	if idleTimeout > 0 && time.Hour*time.Duration(idleTimeout) <= time.Second*time.Duration(lastUsedInterval) {
+		// If it is, a fatal error is logged.
+		log.Fatalf("SESSION_IDLE_TIMEOUT_HOURS must be longer than SESSION_LAST_USED_INTERVAL_SECONDS, got %q", os.Getenv("SESSION_IDLE_TIMEOUT_HOURS"))
+	}
+
+	// apiKeyExpiryDays is the default lifetime of an API key in days.
+	apiKeyExpiryDays, err := strconv.Atoi(HandleMissingEnvValues("API_KEY_EXPIRY_DAYS", "90"))
+	// This checks if an error occurred while converting the lifetime to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing API_KEY_EXPIRY_DAYS: %v", err)
+	}
+	// apiKeyMaxExpiryDays is the longest lifetime of an API key in days.
+	apiKeyMaxExpiryDays, err := strconv.Atoi(HandleMissingEnvValues("API_KEY_MAX_EXPIRY_DAYS", "365"))
+	// This checks if an error occurred while converting the lifetime to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing API_KEY_MAX_EXPIRY_DAYS: %v", err)
+	}
+	// This checks if the default lifetime is out of the allowed range, which would reject every key that does not choose one.
+	if apiKeyExpiryDays < 1 || apiKeyExpiryDays > apiKeyMaxExpiryDays {
+		// If it is, a fatal error is logged.
+		log.Fatalf("API_KEY_EXPIRY_DAYS must be between 1 and API_KEY_MAX_EXPIRY_DAYS (%d), got %d", apiKeyMaxExpiryDays, apiKeyExpiryDays)
+	}
+
+	// serviceTokenExpiry is the lifetime of the tokens of service accounts in minutes.
+	serviceTokenExpiry, err := strconv.Atoi(HandleMissingEnvValues("SERVICE_ACCOUNT_TOKEN_EXPIRY_MINUTES", "60"))
+	// This checks if an error occurred while converting the lifetime to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing SERVICE_ACCOUNT_TOKEN_EXPIRY_MINUTES: %v", err)
+	}
+
+	// emailChangeExpiry is the lifetime of an email change confirmation link in hours.
+	emailChangeExpiry, err := strconv.Atoi(HandleMissingEnvValues("EMAIL_CHANGE_EXPIRY_HOURS", "24"))
+	// This checks if an error occurred while converting the lifetime to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing EMAIL_CHANGE_EXPIRY_HOURS: %v", err)
+	}
+
+	// fingerprintIPv4Prefix is the length of the IPv4 prefix a session is bound to.
+	fingerprintIPv4Prefix, err := strconv.Atoi(HandleMissingEnvValues("JWT_FINGERPRINT_IPV4_PREFIX", "24"))
+	// This checks if the prefix is not a valid IPv4 prefix length.
+	if err != nil || fingerprintIPv4Prefix < 0 || fingerprintIPv4Prefix > 32 {
+		// If it is not, a fatal error is logged.
+		log.Fatalf("JWT_FINGERPRINT_IPV4_PREFIX must be between 0 and 32, got %q", os.Getenv("JWT_FINGERPRINT_IPV4_PREFIX"))
+	}
+	// fingerprintIPv6Prefix is the length of the IPv6 prefix a session is bound to.
+	fingerprintIPv6Prefix, err := strconv.Atoi(HandleMissingEnvValues("JWT_FINGERPRINT_IPV6_PREFIX", "64"))
+	// This checks if the prefix is not a valid IPv6 prefix length.
+	if err != nil || fingerprintIPv6Prefix < 0 || fingerprintIPv6Prefix > 128 {
+		// If it is not, a fatal error is logged.
+		log.Fatalf("JWT_FINGERPRINT_IPV6_PREFIX must be between 0 and 128, got %q", os.Getenv("JWT_FINGERPRINT_IPV6_PREFIX"))
+	}
+
+	// requestTimeout is the request budget in seconds.
+	requestTimeout, err := strconv.Atoi(HandleMissingEnvValues("REQUEST_TIMEOUT_SECONDS", "10"))
+	// This checks if an error occurred while converting the request budget to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing REQUEST_TIMEOUT_SECONDS: %v", err)
+	}
+
+	// requestSigningMaxSkew is how far the timestamp of a signed request may be from the time of the server, in seconds.
+	requestSigningMaxSkew, err := strconv.Atoi(HandleMissingEnvValues("REQUEST_SIGNING_MAX_SKEW_SECONDS", "300"))
+	// This checks if the skew is not a positive integer.
+	if err != nil || requestSigningMaxSkew < 1 {
+		// If it is not, a fatal error is logged.
+		log.Fatalf("REQUEST_SIGNING_MAX_SKEW_SECONDS must be a positive integer, got %q", os.Getenv("REQUEST_SIGNING_MAX_SKEW_SECONDS"))
+	}
+
+	// shutdownTimeout is the deadline of the shutdown in seconds.
+	shutdownTimeout, err := strconv.Atoi(HandleMissingEnvValues("SHUTDOWN_TIMEOUT_SECONDS", "30"))
+	// This checks if the deadline is not a positive integer.
+	if err != nil || shutdownTimeout < 1 {
+		// If it is not, a fatal error is logged.
+		log.Fatalf("SHUTDOWN_TIMEOUT_SECONDS must be a positive integer, got %q", os.Getenv("SHUTDOWN_TIMEOUT_SECONDS"))
+	}
+
+	// undoWindow is the undo window duration in seconds.
+	undoWindow, err := strconv.Atoi(HandleMissingEnvValues("UNDO_WINDOW_SECONDS", "30"))
+	// This checks if an error occurred while converting the undo window to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing UNDO_WINDOW_SECONDS: %v", err)
+	}
+
+	// dailyCapacity is the estimated effort of a day in minutes.
+	dailyCapacity, err := strconv.Atoi(HandleMissingEnvValues("TODO_DAILY_CAPACITY_MINUTES", "480"))
+	// This checks if an error occurred while converting the capacity to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing TODO_DAILY_CAPACITY_MINUTES: %v", err)
+	}
+
+	// bulkConfirmThreshold is the number of todos a bulk completion may change without a confirmation token.
+	bulkConfirmThreshold, err := strconv.Atoi(HandleMissingEnvValues("TODO_BULK_CONFIRM_THRESHOLD", "50"))
+	// This checks if an error occurred while converting the threshold to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing TODO_BULK_CONFIRM_THRESHOLD: %v", err)
+	}
+
+	// titleMaxLength is the maximum number of characters of a todo title.
+	titleMaxLength, err := strconv.Atoi(HandleMissingEnvValues("CONTENT_TITLE_MAX_LENGTH", "255"))
+	// This checks if an error occurred while converting the title limit to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing CONTENT_TITLE_MAX_LENGTH: %v", err)
+	}
+
+	// descriptionMaxLength is the maximum number of characters of a todo description.
+	descriptionMaxLength, err := strconv.Atoi(HandleMissingEnvValues("CONTENT_DESCRIPTION_MAX_LENGTH", "10000"))
+	// This checks if an error occurred while converting the description limit to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing CONTENT_DESCRIPTION_MAX_LENGTH: %v", err)
+	}
+
+	// listNameMaxLength is the maximum number of characters of a list name.
+	listNameMaxLength, err := strconv.Atoi(HandleMissingEnvValues("CONTENT_LIST_NAME_MAX_LENGTH", "100"))
+	// This checks if an error occurred while converting the list name limit to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing CONTENT_LIST_NAME_MAX_LENGTH: %v", err)
+	}
+
+	// blockedWords are the words that user text may not contain.
+	var blockedWords []string
+	// This checks if any word is blocked.
+	if words := HandleMissingEnvValues("CONTENT_BLOCKED_WORDS", ""); words != "" {
+		// If any is, the comma-separated words are split.
+		blockedWords = strings.Split(words, ",")
+	}
+
+	// batchMaxItems is the maximum number of items of a bulk request.
+	batchMaxItems, err := strconv.Atoi(HandleMissingEnvValues("LIMIT_BATCH_MAX_ITEMS", "500"))
+	// This checks if the value is not a positive integer.
+	if err != nil || batchMaxItems < 1 {
+		// If it is not, a fatal error is logged.
+		log.Fatalf("LIMIT_BATCH_MAX_ITEMS must be a positive integer, got %q", os.Getenv("LIMIT_BATCH_MAX_ITEMS"))
+	}
+
+	// importMaxRows is the maximum number of changes of a sync push that are applied.
+	importMaxRows, err := strconv.Atoi(HandleMissingEnvValues("LIMIT_IMPORT_MAX_ROWS", "500"))
+	// This checks if the value is not a positive integer.
+	if err != nil || importMaxRows < 1 {
+		// If it is not, a fatal error is logged.
+		log.Fatalf("LIMIT_IMPORT_MAX_ROWS must be a positive integer, got %q", os.Getenv("LIMIT_IMPORT_MAX_ROWS"))
+	}
+
+	// jsonMaxDepth is the maximum nesting depth of a JSON request body.
+	jsonMaxDepth, err := strconv.Atoi(HandleMissingEnvValues("LIMIT_JSON_MAX_DEPTH", "32"))
+	// This checks if the value is not a positive integer.
+	if err != nil || jsonMaxDepth < 1 {
+		// If it is not, a fatal error is logged.
+		log.Fatalf("LIMIT_JSON_MAX_DEPTH must be a positive integer, got %q", os.Getenv("LIMIT_JSON_MAX_DEPTH"))
+	}
+
+	// reminderInterval is the reminder worker interval in seconds.
+	reminderInterval, err := strconv.Atoi(HandleMissingEnvValues("REMINDER_INTERVAL_SECONDS", "60"))
+	// This checks if an error occurred while converting the reminder interval to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing REMINDER_INTERVAL_SECONDS: %v", err)
+	}
+
+	// reminderLead is the reminder lead time in minutes.
+	reminderLead, err := strconv.Atoi(HandleMissingEnvValues("REMINDER_LEAD_MINUTES", "15"))
+	// This checks if an error occurred while converting the reminder lead time to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing REMINDER_LEAD_MINUTES: %v", err)
+	}
+
+	// archiveAfter is the number of days a completed todo stays unchanged before it is archived.
+	archiveAfter, err := strconv.Atoi(HandleMissingEnvValues("ARCHIVE_AFTER_DAYS", "365"))
+	// This checks if the value is not a non-negative integer.
+	if err != nil || archiveAfter < 0 {
+		// If it is not, a fatal error is logged.
+		log.Fatalf("ARCHIVE_AFTER_DAYS must be a non-negative integer, got %q", os.Getenv("ARCHIVE_AFTER_DAYS"))
+	}
+
+	// digestInterval is the digest worker interval in seconds.
+	digestInterval, err := strconv.Atoi(HandleMissingEnvValues("DIGEST_INTERVAL_SECONDS", "900"))
+	// This checks if an error occurred while converting the digest interval to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing DIGEST_INTERVAL_SECONDS: %v", err)
+	}
+
+	// digestHour is the local hour from which digests are sent.
+	digestHour, err := strconv.Atoi(HandleMissingEnvValues("DIGEST_HOUR", "8"))
+	// This checks if the hour is not an hour of the day, which would never or always match.
+	if err != nil || digestHour < 0 || digestHour > 23 {
+		// If it is not, a fatal error is logged.
+		log.Fatalf("DIGEST_HOUR must be an hour from 0 to 23, got %q", os.Getenv("DIGEST_HOUR"))
+	}
+
+	// outboxInterval is the outbox relay interval in seconds.
+	outboxInterval, err := strconv.Atoi(HandleMissingEnvValues("OUTBOX_INTERVAL_SECONDS", "5"))
+	// This checks if an error occurred while converting the outbox interval to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing OUTBOX_INTERVAL_SECONDS: %v", err)
+	}
+
+	// outboxBatchSize is the maximum number of events published in one pass.
+	outboxBatchSize, err := strconv.Atoi(HandleMissingEnvValues("OUTBOX_BATCH_SIZE", "100"))
+	// This checks if an error occurred while converting the outbox batch size to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing OUTBOX_BATCH_SIZE: %v", err)
+	}
+
+	// outboxMaxAttempts is the number of failed attempts after which an event is given up on.
+	outboxMaxAttempts, err := strconv.Atoi(HandleMissingEnvValues("OUTBOX_MAX_ATTEMPTS", "10"))
+	// This checks if an error occurred while converting the outbox attempt limit to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing OUTBOX_MAX_ATTEMPTS: %v", err)
+	}
+
+	// outboxRetention is the outbox retention in days.
+	outboxRetention, err := strconv.Atoi(HandleMissingEnvValues("OUTBOX_RETENTION_DAYS", "7"))
+	// This checks if an error occurred while converting the outbox retention to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing OUTBOX_RETENTION_DAYS: %v", err)
+	}
+
+	// freeMaxTodos is the maximum number of todos on the free plan.
+	freeMaxTodos, err := strconv.Atoi(HandleMissingEnvValues("QUOTA_FREE_MAX_TODOS", "1000"))
+	// This checks if an error occurred while converting the limit to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing QUOTA_FREE_MAX_TODOS: %v", err)
+	}
+
+	// freeMaxLists is the maximum number of lists on the free plan.
+	freeMaxLists, err := strconv.Atoi(HandleMissingEnvValues("QUOTA_FREE_MAX_LISTS", "50"))
+	// This checks if an error occurred while converting the limit to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing QUOTA_FREE_MAX_LISTS: %v", err)
+	}
+
+	// freeMaxAttachmentMB is the maximum attachment storage in megabytes on the free plan.
+	freeMaxAttachmentMB, err := strconv.Atoi(HandleMissingEnvValues("QUOTA_FREE_MAX_ATTACHMENT_MB", "100"))
+	// This checks if an error occurred while converting the limit to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing QUOTA_FREE_MAX_ATTACHMENT_MB: %v", err)
+	}
+
+	// proMaxTodos is the maximum number of todos on the pro plan.
+	proMaxTodos, err := strconv.Atoi(HandleMissingEnvValues("QUOTA_PRO_MAX_TODOS", "0"))
+	// This checks if an error occurred while converting the limit to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing QUOTA_PRO_MAX_TODOS: %v", err)
+	}
+
+	// proMaxLists is the maximum number of lists on the pro plan.
+	proMaxLists, err := strconv.Atoi(HandleMissingEnvValues("QUOTA_PRO_MAX_LISTS", "0"))
+	// This checks if an error occurred while converting the limit to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing QUOTA_PRO_MAX_LISTS: %v", err)
+	}
+
+	// proMaxAttachmentMB is the maximum attachment storage in megabytes on the pro plan.
+	proMaxAttachmentMB, err := strconv.Atoi(HandleMissingEnvValues("QUOTA_PRO_MAX_ATTACHMENT_MB", "10240"))
+	// This checks if an error occurred while converting the limit to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing QUOTA_PRO_MAX_ATTACHMENT_MB: %v", err)
+	}
+
+	// guestMaxTodos is the maximum number of todos of a guest.
+	guestMaxTodos, err := strconv.Atoi(HandleMissingEnvValues("QUOTA_GUEST_MAX_TODOS", "50"))
+	// This checks if an error occurred while converting the limit to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing QUOTA_GUEST_MAX_TODOS: %v", err)
+	}
+
+	// guestMaxLists is the maximum number of lists of a guest.
+	guestMaxLists, err := strconv.Atoi(HandleMissingEnvValues("QUOTA_GUEST_MAX_LISTS", "3"))
+	// This checks if an error occurred while converting the limit to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing QUOTA_GUEST_MAX_LISTS: %v", err)
+	}
+
+	// guestMaxAttachmentMB is the maximum attachment storage in megabytes of a guest.
+	guestMaxAttachmentMB, err := strconv.Atoi(HandleMissingEnvValues("QUOTA_GUEST_MAX_ATTACHMENT_MB", "10"))
+	// This checks if an error occurred while converting the limit to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing QUOTA_GUEST_MAX_ATTACHMENT_MB: %v", err)
+	}
+
+	// guestExpiryDays is the number of days a guest token is valid.
+	guestExpiryDays, err := strconv.Atoi(HandleMissingEnvValues("GUEST_TOKEN_EXPIRY_DAYS", "30"))
+	// This checks if the value is not a positive integer.
+	if err != nil || guestExpiryDays <= 0 {
+		// If it is not, a fatal error is logged.
+		log.Fatalf("GUEST_TOKEN_EXPIRY_DAYS must be a positive integer, got %q", os.Getenv("GUEST_TOKEN_EXPIRY_DAYS"))
+	}
+
+	// deviceCodeExpiry is the number of minutes a device has to be approved.
+	deviceCodeExpiry, err := strconv.Atoi(HandleMissingEnvValues("DEVICE_CODE_EXPIRY_MINUTES", "10"))
+	// This checks if the value is not a positive integer.
+	if err != nil || deviceCodeExpiry <= 0 {
+		// If it is not, a fatal error is logged.
+		log.Fatalf("DEVICE_CODE_EXPIRY_MINUTES must be a positive integer, got %q", os.Getenv("DEVICE_CODE_EXPIRY_MINUTES"))
+	}
+
+	// rateLimitWindow is the rate limit window in seconds.
+	rateLimitWindow, err := strconv.Atoi(HandleMissingEnvValues("RATE_LIMIT_WINDOW_SECONDS", "60"))
+	// This checks if an error occurred while converting the value to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing RATE_LIMIT_WINDOW_SECONDS: %v", err)
+	}
+
+	// rateLimitRead is the number of read requests a user can make per window.
+	rateLimitRead, err := strconv.Atoi(HandleMissingEnvValues("RATE_LIMIT_READ_MAX", "300"))
+	// This checks if an error occurred while converting the value to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing RATE_LIMIT_READ_MAX: %v", err)
+	}
+
+	// rateLimitWrite is the number of write requests a user can make per window.
+	rateLimitWrite, err := strconv.Atoi(HandleMissingEnvValues("RATE_LIMIT_WRITE_MAX", "60"))
+	// This checks if an error occurred while converting the value to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing RATE_LIMIT_WRITE_MAX: %v", err)
+	}
+
+	// rateLimitAnonymous is the number of requests an IP address can make per window without a user.
+	rateLimitAnonymous, err := strconv.Atoi(HandleMissingEnvValues("RATE_LIMIT_ANONYMOUS_MAX", "60"))
+	// This checks if an error occurred while converting the value to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing RATE_LIMIT_ANONYMOUS_MAX: %v", err)
+	}
+	// rateLimitServiceRead is the number of read requests a service account can make per window.
+	// Machines poll more steadily than people, so they get budgets of their own instead of sharing the ones of users.
+	rateLimitServiceRead, err := strconv.Atoi(HandleMissingEnvValues("RATE_LIMIT_SERVICE_READ_MAX", "600"))
+	// This checks if an error occurred while converting the value to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing RATE_LIMIT_SERVICE_READ_MAX: %v", err)
+	}
+	// rateLimitServiceWrite is the number of write requests a service account can make per window.
+	rateLimitServiceWrite, err := strconv.Atoi(HandleMissingEnvValues("RATE_LIMIT_SERVICE_WRITE_MAX", "120"))
+	// This checks if an error occurred while converting the value to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing RATE_LIMIT_SERVICE_WRITE_MAX: %v", err)
+	}
+
+	// loginFreeAttempts is the number of failed logins an account may have before it is slowed down.
+	loginFreeAttempts, err := strconv.Atoi(HandleMissingEnvValues("LOGIN_THROTTLE_FREE_ATTEMPTS", "3"))
+	// This checks if an error occurred while converting the value to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing LOGIN_THROTTLE_FREE_ATTEMPTS: %v", err)
+	}
+
+	// loginBaseDelay is the delay of the first slowed down login, in milliseconds.
+	loginBaseDelay, err := strconv.Atoi(HandleMissingEnvValues("LOGIN_THROTTLE_BASE_DELAY_MS", "500"))
+	// This checks if an error occurred while converting the value to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing LOGIN_THROTTLE_BASE_DELAY_MS: %v", err)
+	}
+
+	// loginMaxDelay is the longest delay of a login, in seconds.
+	loginMaxDelay, err := strconv.Atoi(HandleMissingEnvValues("LOGIN_THROTTLE_MAX_DELAY_SECONDS", "5"))
+	// This checks if an error occurred while converting the value to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing LOGIN_THROTTLE_MAX_DELAY_SECONDS: %v", err)
+	}
+
+	// loginCaptchaAfter is the number of failed logins after which a captcha is asked for.
+	loginCaptchaAfter, err := strconv.Atoi(HandleMissingEnvValues("LOGIN_CAPTCHA_AFTER_FAILURES", "5"))
+	// This checks if an error occurred while converting the value to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing LOGIN_CAPTCHA_AFTER_FAILURES: %v", err)
+	}
+
+	// loginWindow is how long failed logins are remembered, in seconds.
+	loginWindow, err := strconv.Atoi(HandleMissingEnvValues("LOGIN_THROTTLE_WINDOW_SECONDS", "900"))
+	// This checks if an error occurred while converting the value to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing LOGIN_THROTTLE_WINDOW_SECONDS: %v", err)
+	}
+
+	// auditRetention is the number of days audit entries are kept.
+	auditRetention, err := strconv.Atoi(HandleMissingEnvValues("AUDIT_RETENTION_DAYS", "90"))
+	// This checks if an error occurred while converting the retention to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing AUDIT_RETENTION_DAYS: %v", err)
+	}
+
+	// corsMaxAge is the number of seconds browsers may cache a preflight request.
+	corsMaxAge, err := strconv.Atoi(HandleMissingEnvValues("CORS_MAX_AGE_SECONDS", "600"))
+	// This checks if an error occurred while converting the max age to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing CORS_MAX_AGE_SECONDS: %v", err)
+	}
+
+	// environment is the environment in which the application is running.
+	environment := HandleMissingEnvValues("ENV", "dev")
+
+	// allowOrigins is the list of allowed CORS origins of the environment.
+	allowOrigins := corsOrigins(environment)
+	// allowCredentials is true when the "CORS_ALLOW_CREDENTIALS" environment variable is "true".
+	allowCredentials := HandleMissingEnvValues("CORS_ALLOW_CREDENTIALS", "false") == "true"
+	// This iterates over the allowed origins.
+	for _, origin := range strings.Split(allowOrigins, ",") {
+		// This checks if credentials are allowed from any origin, which browsers reject and which would leak them.
+		if allowCredentials && strings.TrimSpace(origin) == "*" {
+			// If they are, a fatal error is logged.
+			log.Fatalf("CORS_ALLOW_CREDENTIALS cannot be used with the \"*\" origin; list the origins instead")
+		}
+	}
+
+	// pageDefaultLimit is the page size of a request that does not ask for one.
+	pageDefaultLimit, err := strconv.Atoi(HandleMissingEnvValues("PAGE_DEFAULT_LIMIT", "10"))
+	// This checks if an error occurred while converting the page size to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing PAGE_DEFAULT_LIMIT: %v", err)
+	}
+	// pageMaxLimit is the largest page size a request may ask for.
+	pageMaxLimit, err := strconv.Atoi(HandleMissingEnvValues("PAGE_MAX_LIMIT", "100"))
+	// This checks if an error occurred while converting the page size to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing PAGE_MAX_LIMIT: %v", err)
+	}
+	// This checks if the default page size is out of the allowed range, which would answer every request without a limit with an error.
+	if pageDefaultLimit < 1 || pageDefaultLimit > pageMaxLimit {
+		// If it is, a fatal error is logged.
+		log.Fatalf("PAGE_DEFAULT_LIMIT must be between 1 and PAGE_MAX_LIMIT (%d), got %d", pageMaxLimit, pageDefaultLimit)
+	}
+
+	// mediaMaxSourceMB is the largest stored image that is served or resized, in megabytes.
+	mediaMaxSourceMB, err := strconv.Atoi(HandleMissingEnvValues("MEDIA_MAX_SOURCE_MB", "20"))
+	// This checks if an error occurred while converting the limit to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing MEDIA_MAX_SOURCE_MB: %v", err)
+	}
+
+	// mediaMaxSourcePixels is the largest number of pixels of an image that is decoded.
+	mediaMaxSourcePixels, err := strconv.Atoi(HandleMissingEnvValues("MEDIA_MAX_SOURCE_PIXELS", "40000000"))
+	// This checks if an error occurred while converting the limit to an integer.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Error parsing MEDIA_MAX_SOURCE_PIXELS: %v", err)
+	}
+
+	// jwtSecret is the secret key of the JWTs, which also derives the default key of signed URLs.
+	jwtSecret := HandleMissingEnvValues("JWT_SECRET_KEY", "vCYKhw6zTyXIt7ckaKNnv7KarP2wzhZegyoxLLiK6MGKTnVo9z")
+
+	// captchaProvider is the captcha service, or an empty string to ask for no captcha.
+	captchaProvider := HandleMissingEnvValues("CAPTCHA_PROVIDER", "")
+	// captchaVerifyURL is the siteverify endpoint of the provider, unless one is configured.
+	var captchaVerifyURL string
+	// This selects the endpoint of the provider.
+	switch captchaProvider {
+	case "hcaptcha":
+		// hCaptcha verifies tokens at its API host.
+		captchaVerifyURL = "https://api.hcaptcha.com/siteverify"
+	case "turnstile":
+		// Cloudflare Turnstile verifies tokens at its challenges host.
+		captchaVerifyURL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+	case "":
+		// No captcha is asked for.
+	default:
+		// Any other provider is a configuration error.
+		log.Fatalf("Unknown CAPTCHA_PROVIDER %q; use hcaptcha or turnstile", captchaProvider)
+	}
+	// captchaSecret is the secret key of the captcha.
+	captchaSecret := HandleMissingEnvValues("CAPTCHA_SECRET", "")
+	// This checks if a provider is configured without its secret, which would reject every token.
+	if captchaProvider != "" && captchaSecret == "" {
+		// If it is, a fatal error is logged.
+		log.Fatalf("CAPTCHA_SECRET is required when CAPTCHA_PROVIDER is set")
+	}
+
 	// A pointer to a new Config struct is returned.
 	return &Config{
 		// The Environment field is set to the value of the "ENV" environment variable, or "dev" if it is not set.
-		Environment: HandleMissingEnvValues("ENV", "dev"),
+		Environment: environment,
 		// The Server field is populated with the server configuration.
 		Server: ServerConfig{
 			// The Port field is set to the value of the "PORT" environment variable, or "8000" if it is not set.
 			Port: HandleMissingEnvValues("PORT", "8000"),
 			// The Host field is set to the value of the "HOST" environment variable, or "localhost" if it is not set.
 			Host: HandleMissingEnvValues("HOST", "localhost"),
+			// The RequestTimeout field is set to the request budget, where zero leaves requests unbounded.
+			RequestTimeout: time.Second * time.Duration(requestTimeout),
+			// The ShutdownTimeout field is set to the deadline of the shutdown.
+			ShutdownTimeout: time.Second * time.Duration(shutdownTimeout),
+			// The StrictJSON field is true when the "STRICT_JSON" environment variable is "true".
+			StrictJSON: HandleMissingEnvValues("STRICT_JSON", "false") == "true",
+			// The VersionHeader field is true when the "APP_VERSION_HEADER" environment variable is "true".
+			VersionHeader: HandleMissingEnvValues("APP_VERSION_HEADER", "false") == "true",
+			// The PublicURL field is set to the value of the "PUBLIC_URL" environment variable without a trailing slash, or the local server.
+			PublicURL: strings.TrimSuffix(HandleMissingEnvValues("PUBLIC_URL", "http://localhost:8000"), "/"),
 		},
 		// The Database field is populated with the database configuration.
 		Database: DatabaseConfig{
-			// The DBHost field is set to the value of the "DB_HOST" environment variable, or "localhost" if it is not set.
-			DBHost: HandleMissingEnvValues("DB_HOST", "localhost"),
-			// The DBPort field is set to the value of the dbPort variable.
-			DBPort: dbPort,
-			// The DBUser field is set to the value of the "DB_USER" environment variable, or "postgres" if it is not set.
-			DBUser: HandleMissingEnvValues("DB_USER", "postgres"),
-			// The DBPassword field is set to the value of the "DB_PASSWORD" environment variable, or "postgres" if it is not set.
-			DBPassword: HandleMissingEnvValues("DB_PASSWORD", "postgres"),
-			// The DBName field is set to the value of the "DB_NAME" environment variable, or "postgres" if it is not set.
-			DBName:    HandleMissingEnvValues("DB_NAME", "postgres"), // The DBSSLMode field is set to the value of the `DB_SSLMODE` environment variable, or `disable` if it is not set.
-			DBSSLMode: HandleMissingEnvValues("DB_SSLMODE", "disable"),
+			// The DBHost field is set to the host of the database, or the directory of its Unix socket.
+			DBHost: target.DBHost,
+			// The DBPort field is set to the port of the database.
+			DBPort: target.DBPort,
+			// The DBUser field is set to the user of the database.
+			DBUser: target.DBUser,
+			// The DBPassword field is set to the password of the database.
+			DBPassword: target.DBPassword,
+			// The DBName field is set to the name of the database.
+			DBName: target.DBName,
+			// The DBSSLMode field is set to the SSL mode of the connection.
+			DBSSLMode: target.DBSSLMode,
+			// The DBSSLRootCert field is set to the file of the certificate authorities of the server.
+			DBSSLRootCert: target.DBSSLRootCert,
+			// The DBSSLCert field is set to the file of the client certificate.
+			DBSSLCert: target.DBSSLCert,
+			// The DBSSLKey field is set to the file of the private key of the client certificate.
+			DBSSLKey: target.DBSSLKey,
+			// The BreakerThreshold field is set to the circuit breaker threshold.
+			BreakerThreshold: breakerThreshold,
+			// The BreakerCooldown field is set to the circuit breaker cooldown.
+			BreakerCooldown: time.Second * time.Duration(breakerCooldown),
+			// The SlowQueryThreshold field is set to the slow query threshold.
+			SlowQueryThreshold: time.Millisecond * time.Duration(slowQueryThreshold),
+			// The HealthCheckInterval field is set to the health check interval.
+			HealthCheckInterval: time.Second * time.Duration(healthCheckInterval),
 		},
 		// The JWT field is populated with the JWT configuration.
 		JWT: JWTConfig{
 			// The SecretKey field is set to the value of the "JWT_SECRET_KEY" environment variable, or a default value if it is not set.
-			SecretKey: HandleMissingEnvValues("JWT_SECRET_KEY", "vCYKhw6zTyXIt7ckaKNnv7KarP2wzhZegyoxLLiK6MGKTnVo9z"),
+			SecretKey: jwtSecret,
 			// The Expires field is set to the JWT expiration duration.
 			Expires: time.Hour * time.Duration(expiry),
+			// The RememberMeExpires field is set to the JWT expiration duration of the logins that ask to be remembered.
+			RememberMeExpires: time.Hour * time.Duration(rememberMeExpiry),
+			// The LastUsedInterval field is set to the interval between two records of the last use of a session.
+			LastUsedInterval: time.Second * time.Duration(lastUsedInterval),
+			// The IdleTimeout field is set to how long a session may go unused.
+			IdleTimeout: time.Hour * time.Duration(idleTimeout),
+			// The APIKeyExpires field is set to the default lifetime of an API key.
+			APIKeyExpires: 24 * time.Hour * time.Duration(apiKeyExpiryDays),
+			// The APIKeyMaxExpires field is set to the longest lifetime of an API key.
+			APIKeyMaxExpires: 24 * time.Hour * time.Duration(apiKeyMaxExpiryDays),
+			// The ServiceTokenExpires field is set to the lifetime of the tokens of service accounts.
+			ServiceTokenExpires: time.Minute * time.Duration(serviceTokenExpiry),
+			// The EmailChangeExpires field is set to the lifetime of email change confirmation links.
+			EmailChangeExpires: time.Hour * time.Duration(emailChangeExpiry),
+			// The BindFingerprint field is set to whether sessions are bound to the device and network they were issued to.
+			BindFingerprint: HandleMissingEnvValues("JWT_BIND_FINGERPRINT", "false") == "true",
+			// The FingerprintIPv4Prefix field is set to the length of the IPv4 prefix a session is bound to.
+			FingerprintIPv4Prefix: fingerprintIPv4Prefix,
+			// The FingerprintIPv6Prefix field is set to the length of the IPv6 prefix a session is bound to.
+			FingerprintIPv6Prefix: fingerprintIPv6Prefix,
 		},
 		// The CORS field is populated with the CORS configuration.
 		CORS: CORSConfig{
-			// The CorsOrigins field is set to the value of the "CORS_ORIGINS" environment variable, or "http://localhost:3000" if it is not set.
-			CorsOrigins: HandleMissingEnvValues("CORS_ORIGINS", "http://localhost:3000"),
+			// The AllowOrigins field is set to the origins of the environment.
+			AllowOrigins: allowOrigins,
+			// The AllowMethods field is set to the value of the "CORS_ALLOW_METHODS" environment variable, or the methods of the API if it is not set.
+			AllowMethods: HandleMissingEnvValues("CORS_ALLOW_METHODS", "GET,POST,PUT,PATCH,DELETE,HEAD,OPTIONS"),
+			// The AllowHeaders field is set to the value of the "CORS_ALLOW_HEADERS" environment variable, or the headers the API reads if it is not set.
+			AllowHeaders: HandleMissingEnvValues("CORS_ALLOW_HEADERS", "Origin,Content-Type,Accept,Accept-Language,Authorization,Idempotency-Key,If-Match,If-None-Match,X-Captcha-Token,X-Device-ID"),
+			// The ExposeHeaders field is set to the value of the "CORS_EXPOSE_HEADERS" environment variable, or the headers the API sets if it is not set.
+			ExposeHeaders: HandleMissingEnvValues("CORS_EXPOSE_HEADERS", "Content-Language,Deprecation,ETag,Link,Location,Retry-After,X-App-Version,X-RateLimit-Limit,X-RateLimit-Remaining,X-RateLimit-Reset,X-Request-ID,X-Total-Count,X-Total-Pages"),
+			// The AllowCredentials field is set to whether credentials are allowed.
+			AllowCredentials: allowCredentials,
+			// The MaxAge field is set to the preflight cache duration.
+			MaxAge: time.Second * time.Duration(corsMaxAge),
+		},
+		// The Todo field is populated with the todo configuration.
+		Todo: TodoConfig{
+			// The UndoWindow field is set to the undo window duration.
+			UndoWindow: time.Second * time.Duration(undoWindow),
+			// The DetectDuplicateTitles field is true when the "DETECT_DUPLICATE_TITLES" environment variable is "true".
+			DetectDuplicateTitles: HandleMissingEnvValues("DETECT_DUPLICATE_TITLES", "false") == "true",
+			// The DailyCapacityMinutes field is set to the estimated effort of a day.
+			DailyCapacityMinutes: dailyCapacity,
+			// The BulkConfirmThreshold field is set to the size of a bulk completion that needs a confirmation.
+			BulkConfirmThreshold: bulkConfirmThreshold,
+		},
+		// The Content field is populated with the validation of user text.
+		Content: ContentConfig{
+			// The TitleMaxLength field is set to the title limit.
+			TitleMaxLength: titleMaxLength,
+			// The DescriptionMaxLength field is set to the description limit.
+			DescriptionMaxLength: descriptionMaxLength,
+			// The ListNameMaxLength field is set to the list name limit.
+			ListNameMaxLength: listNameMaxLength,
+			// The BlockedWords field is set to the blocked words.
+			BlockedWords: blockedWords,
+		},
+		// The Limits field is populated with the caps on the size of bulk requests.
+		Limits: LimitsConfig{
+			// The MaxBatchItems field is set to the bulk request cap.
+			MaxBatchItems: batchMaxItems,
+			// The MaxImportRows field is set to the sync push cap.
+			MaxImportRows: importMaxRows,
+			// The MaxJSONDepth field is set to the nesting cap of JSON bodies.
+			MaxJSONDepth: jsonMaxDepth,
+		},
+		// The Pagination field is populated with the page sizes.
+		Pagination: PaginationConfig{
+			// The DefaultLimit field is set to the page size of a request that does not ask for one.
+			DefaultLimit: pageDefaultLimit,
+			// The MaxLimit field is set to the largest page size.
+			MaxLimit: pageMaxLimit,
+		},
+		// The RateLimit field is populated with the rate limiting configuration.
+		RateLimit: RateLimitConfig{
+			// The Window field is set to the rate limit window.
+			Window: time.Second * time.Duration(rateLimitWindow),
+			// The ReadMax field is set to the read request limit.
+			ReadMax: rateLimitRead,
+			// The WriteMax field is set to the write request limit.
+			WriteMax: rateLimitWrite,
+			// The AnonymousMax field is set to the anonymous request limit.
+			AnonymousMax: rateLimitAnonymous,
+			// The ServiceReadMax field is set to the read request limit of service accounts.
+			ServiceReadMax: rateLimitServiceRead,
+			// The ServiceWriteMax field is set to the write request limit of service accounts.
+			ServiceWriteMax: rateLimitServiceWrite,
+		},
+		// The LoginThrottle field is populated with the configuration of the throttling of failed logins.
+		LoginThrottle: LoginThrottleConfig{
+			// The FreeAttempts field is set to the number of failed logins that are not slowed down.
+			FreeAttempts: loginFreeAttempts,
+			// The BaseDelay field is set to the delay of the first slowed down login.
+			BaseDelay: time.Millisecond * time.Duration(loginBaseDelay),
+			// The MaxDelay field is set to the longest delay of a login.
+			MaxDelay: time.Second * time.Duration(loginMaxDelay),
+			// The CaptchaAfter field is set to the number of failed logins after which a captcha is asked for.
+			CaptchaAfter: loginCaptchaAfter,
+			// The Window field is set to how long failed logins are remembered.
+			Window: time.Second * time.Duration(loginWindow),
+		},
+		// The Storage field is populated with the storage backend configuration.
+		Storage: StorageConfig{
+			// The Dir field is set to the value of the "STORAGE_DIR" environment variable, or "storage" if it is not set.
+			Dir: HandleMissingEnvValues("STORAGE_DIR", "storage"),
+		},
+		// The Media field is populated with the configuration of the resizing of images.
+		Media: MediaConfig{
+			// The MaxSourceBytes field is set to the largest stored image that is served.
+			MaxSourceBytes: int64(mediaMaxSourceMB) << 20,
+			// The MaxSourcePixels field is set to the largest number of pixels that is decoded.
+			MaxSourcePixels: mediaMaxSourcePixels,
+		},
+		// The URLSigning field is populated with the keys of signed URLs.
+		URLSigning: URLSigningConfig{
+			// The Keys field is set to the configured keys, or a key derived from the JWT secret.
+			Keys: urlSigningKeys(jwtSecret),
+		},
+		// The RequestSigning field is populated with the keys of signed requests.
+		RequestSigning: RequestSigningConfig{
+			// The Keys field is set to the configured keys, if any.
+			Keys: requestSigningKeys(),
+			// The MaxSkew field is set to the largest accepted difference of the timestamps.
+			MaxSkew: time.Duration(requestSigningMaxSkew) * time.Second,
+		},
+		// The Bootstrap field is populated with the first admin of a new deployment.
+		Bootstrap: BootstrapConfig{
+			// The AdminEmail field is set to the value of the "BOOTSTRAP_ADMIN_EMAIL" environment variable, if any.
+			AdminEmail: HandleMissingEnvValues("BOOTSTRAP_ADMIN_EMAIL", ""),
+			// The AdminPassword field is set to the value of the "BOOTSTRAP_ADMIN_PASSWORD" environment variable, which may be read from a file or a secret manager.
+			AdminPassword: HandleMissingEnvValues("BOOTSTRAP_ADMIN_PASSWORD", ""),
+		},
+		// The Guest field is populated with the guest session configuration.
+		Guest: GuestConfig{
+			// The Enabled field is true when the "GUEST_MODE_ENABLED" environment variable is "true".
+			Enabled: HandleMissingEnvValues("GUEST_MODE_ENABLED", "false") == "true",
+			// The Expires field is set to the lifetime of a guest token.
+			Expires: 24 * time.Hour * time.Duration(guestExpiryDays),
+		},
+		// The Device field is populated with the device authorization grant configuration.
+		Device: DeviceConfig{
+			// The VerificationURL field is set to the value of the "DEVICE_VERIFICATION_URL" environment variable without a trailing slash, if any.
+			VerificationURL: strings.TrimSuffix(HandleMissingEnvValues("DEVICE_VERIFICATION_URL", ""), "/"),
+			// The Expires field is set to the lifetime of the codes of a device.
+			Expires: time.Minute * time.Duration(deviceCodeExpiry),
+		},
+		// The Captcha field is populated with the captcha configuration.
+		Captcha: CaptchaConfig{
+			// The Provider field is set to the captcha service.
+			Provider: captchaProvider,
+			// The Secret field is set to the secret key of the captcha.
+			Secret: captchaSecret,
+			// The VerifyURL field is set to the value of the "CAPTCHA_VERIFY_URL" environment variable, or the endpoint of the provider.
+			VerifyURL: HandleMissingEnvValues("CAPTCHA_VERIFY_URL", captchaVerifyURL),
+		},
+		// The Audit field is populated with the audit log configuration.
+		Audit: AuditConfig{
+			// The Enabled field is true when the "AUDIT_ENABLED" environment variable is "true".
+			Enabled: HandleMissingEnvValues("AUDIT_ENABLED", "false") == "true",
+			// The Retention field is set to the audit retention.
+			Retention: 24 * time.Hour * time.Duration(auditRetention),
+		},
+		// The Diagnostics field is populated with the profiling and runtime diagnostics configuration.
+		Diagnostics: DiagnosticsConfig{
+			// The Enabled field is true when the "DIAGNOSTICS_ENABLED" environment variable is "true".
+			Enabled: HandleMissingEnvValues("DIAGNOSTICS_ENABLED", "false") == "true",
+		},
+		// The Reminder field is populated with the reminder configuration.
+		Reminder: ReminderConfig{
+			// The Interval field is set to the reminder worker interval.
+			Interval: time.Second * time.Duration(reminderInterval),
+			// The Lead field is set to the reminder lead time.
+			Lead: time.Minute * time.Duration(reminderLead),
+		},
+		// The Digest field is populated with the weekly digest configuration.
+		Digest: DigestConfig{
+			// The Interval field is set to the digest worker interval.
+			Interval: time.Second * time.Duration(digestInterval),
+			// The Hour field is set to the local hour from which digests are sent.
+			Hour: digestHour,
+		},
+		// The Archive field is populated with the archive configuration.
+		Archive: ArchiveConfig{
+			// The After field is set to the age of the completed todos that are archived, where zero turns the archive off.
+			After: 24 * time.Hour * time.Duration(archiveAfter),
+		},
+		// The Telegram field is populated with the Telegram configuration.
+		Telegram: TelegramConfig{
+			// The BotToken field is set to the value of the "TELEGRAM_BOT_TOKEN" environment variable, or an empty string to disable the integration.
+			BotToken: HandleMissingEnvValues("TELEGRAM_BOT_TOKEN", ""),
+			// The WebhookSecret field is set to the value of the "TELEGRAM_WEBHOOK_SECRET" environment variable.
+			WebhookSecret: HandleMissingEnvValues("TELEGRAM_WEBHOOK_SECRET", ""),
+		},
+		// The Slack field is populated with the Slack configuration.
+		Slack: SlackConfig{
+			// The ClientID field is set to the value of the "SLACK_CLIENT_ID" environment variable.
+			ClientID: HandleMissingEnvValues("SLACK_CLIENT_ID", ""),
+			// The ClientSecret field is set to the value of the "SLACK_CLIENT_SECRET" environment variable.
+			ClientSecret: HandleMissingEnvValues("SLACK_CLIENT_SECRET", ""),
+			// The SigningSecret field is set to the value of the "SLACK_SIGNING_SECRET" environment variable, or an empty string to disable the integration.
+			SigningSecret: HandleMissingEnvValues("SLACK_SIGNING_SECRET", ""),
+			// The RedirectURL field is set to the value of the "SLACK_REDIRECT_URL" environment variable.
+			RedirectURL: HandleMissingEnvValues("SLACK_REDIRECT_URL", ""),
+		},
+		// The SMTP field is populated with the mail server configuration.
+		SMTP: SMTPConfig{
+			// The Host field is set to the value of the "SMTP_HOST" environment variable, or an empty string to disable email notifications.
+			Host: HandleMissingEnvValues("SMTP_HOST", ""),
+			// The Port field is set to the value of the "SMTP_PORT" environment variable, or "587" if it is not set.
+			Port: HandleMissingEnvValues("SMTP_PORT", "587"),
+			// The Username field is set to the value of the "SMTP_USERNAME" environment variable.
+			Username: HandleMissingEnvValues("SMTP_USERNAME", ""),
+			// The Password field is set to the value of the "SMTP_PASSWORD" environment variable.
+			Password: HandleMissingEnvValues("SMTP_PASSWORD", ""),
+			// The From field is set to the value of the "SMTP_FROM" environment variable.
+			From: HandleMissingEnvValues("SMTP_FROM", "todo@localhost"),
+		},
+		// The Push field is populated with the mobile push configuration.
+		Push: PushConfig{
+			// The FCMCredentialsFile field is set to the value of the "FCM_CREDENTIALS_FILE" environment variable, or an empty string to disable FCM.
+			FCMCredentialsFile: HandleMissingEnvValues("FCM_CREDENTIALS_FILE", ""),
+			// The APNsKeyFile field is set to the value of the "APNS_KEY_FILE" environment variable, or an empty string to disable APNs.
+			APNsKeyFile: HandleMissingEnvValues("APNS_KEY_FILE", ""),
+			// The APNsKeyID field is set to the value of the "APNS_KEY_ID" environment variable.
+			APNsKeyID: HandleMissingEnvValues("APNS_KEY_ID", ""),
+			// The APNsTeamID field is set to the value of the "APNS_TEAM_ID" environment variable.
+			APNsTeamID: HandleMissingEnvValues("APNS_TEAM_ID", ""),
+			// The APNsTopic field is set to the value of the "APNS_TOPIC" environment variable.
+			APNsTopic: HandleMissingEnvValues("APNS_TOPIC", ""),
+			// The APNsProduction field is true when the "APNS_PRODUCTION" environment variable is "true".
+			APNsProduction: HandleMissingEnvValues("APNS_PRODUCTION", "false") == "true",
+		},
+		// The WebPush field is populated with the browser push configuration.
+		WebPush: WebPushConfig{
+			// The PublicKey field is set to the value of the "VAPID_PUBLIC_KEY" environment variable.
+			PublicKey: HandleMissingEnvValues("VAPID_PUBLIC_KEY", ""),
+			// The PrivateKey field is set to the value of the "VAPID_PRIVATE_KEY" environment variable, or an empty string to disable web push.
+			PrivateKey: HandleMissingEnvValues("VAPID_PRIVATE_KEY", ""),
+			// The Subject field is set to the value of the "VAPID_SUBJECT" environment variable.
+			Subject: HandleMissingEnvValues("VAPID_SUBJECT", "mailto:todo@localhost"),
+		},
+		// The Outbox field is populated with the outbox relay configuration.
+		Outbox: OutboxConfig{
+			// The Publisher field is set to the value of the "OUTBOX_PUBLISHER" environment variable.
+			Publisher: HandleMissingEnvValues("OUTBOX_PUBLISHER", ""),
+			// The WebhookURL field is set to the value of the "OUTBOX_WEBHOOK_URL" environment variable, or an empty string to only prune events.
+			WebhookURL: HandleMissingEnvValues("OUTBOX_WEBHOOK_URL", ""),
+			// The WebhookSecret field is set to the value of the "OUTBOX_WEBHOOK_SECRET" environment variable.
+			WebhookSecret: HandleMissingEnvValues("OUTBOX_WEBHOOK_SECRET", ""),
+			// The Interval field is set to the outbox relay interval.
+			Interval: time.Second * time.Duration(outboxInterval),
+			// The BatchSize field is set to the outbox batch size.
+			BatchSize: outboxBatchSize,
+			// The MaxAttempts field is set to the outbox attempt limit.
+			MaxAttempts: outboxMaxAttempts,
+			// The Retention field is set to the outbox retention.
+			Retention: 24 * time.Hour * time.Duration(outboxRetention),
+			// The NATSURL field is set to the value of the "NATS_URL" environment variable, or the default local server.
+			NATSURL: HandleMissingEnvValues("NATS_URL", "nats://localhost:4222"),
+			// The NATSSubjectPrefix field is set to the value of the "NATS_SUBJECT_PREFIX" environment variable, or "todo.events".
+			NATSSubjectPrefix: HandleMissingEnvValues("NATS_SUBJECT_PREFIX", "todo.events"),
+			// The KafkaBrokers field is set to the value of the "KAFKA_BROKERS" environment variable, or the default local broker.
+			KafkaBrokers: HandleMissingEnvValues("KAFKA_BROKERS", "localhost:9092"),
+			// The KafkaTopic field is set to the value of the "KAFKA_TOPIC" environment variable, or "todo-events".
+			KafkaTopic: HandleMissingEnvValues("KAFKA_TOPIC", "todo-events"),
+		},
+		// The Quota field is populated with the per-plan usage limits.
+		Quota: QuotaConfig{
+			// The Free field is set to the limits of the free plan.
+			Free: PlanLimits{
+				MaxTodos:           freeMaxTodos,
+				MaxLists:           freeMaxLists,
+				MaxAttachmentBytes: int64(freeMaxAttachmentMB) << 20,
+			},
+			// The Pro field is set to the limits of the paid plan.
+			Pro: PlanLimits{
+				MaxTodos:           proMaxTodos,
+				MaxLists:           proMaxLists,
+				MaxAttachmentBytes: int64(proMaxAttachmentMB) << 20,
+			},
+			// The Guest field is set to the limits of guests.
+			Guest: PlanLimits{
+				MaxTodos:           guestMaxTodos,
+				MaxLists:           guestMaxLists,
+				MaxAttachmentBytes: int64(guestMaxAttachmentMB) << 20,
+			},
 		},
 	}
 }
@@ -0,0 +1,45 @@
+// This file lists the optional features of the application and whether the configuration turns them on.
+package config
+
+// FeatureFlag describes an optional feature and the environment variable that turns it on.
+type FeatureFlag struct {
+	// Name is the name of the feature, such as "telegram".
+	Name string
+	// Enabled reports whether the feature is on.
+	Enabled bool
+	// Source is the environment variable that turns the feature on.
+	Source string
+}
+
+// FeatureFlags lists the optional features. They are read from the environment at startup, so they never change while the server runs.
+//
+// @return []FeatureFlag - The features, in a stable order.
+func (cfg *Config) FeatureFlags() []FeatureFlag {
+	// The list of optional features is returned.
+	return []FeatureFlag{
+		// The audit log records mutating requests.
+		{Name: "audit_log", Enabled: cfg.Audit.Enabled, Source: "AUDIT_ENABLED"},
+		// The diagnostics endpoints serve profiles and runtime statistics.
+		{Name: "diagnostics", Enabled: cfg.Diagnostics.Enabled, Source: "DIAGNOSTICS_ENABLED"},
+		// Guests can try the application before they register.
+		{Name: "guest_mode", Enabled: cfg.Guest.Enabled, Source: "GUEST_MODE_ENABLED"},
+		// Registration asks for a solved captcha when a provider is set.
+		{Name: "captcha", Enabled: cfg.Captcha.Provider != "", Source: "CAPTCHA_PROVIDER"},
+		// Email reminders need a mail server.
+		{Name: "email_reminders", Enabled: cfg.SMTP.Host != "", Source: "SMTP_HOST"},
+		// The weekly digest is sent by email, so it needs a mail server too.
+		{Name: "email_digest", Enabled: cfg.SMTP.Host != "", Source: "SMTP_HOST"},
+		// Android push needs FCM credentials.
+		{Name: "fcm_push", Enabled: cfg.Push.FCMCredentialsFile != "", Source: "FCM_CREDENTIALS_FILE"},
+		// iOS push needs an APNs key.
+		{Name: "apns_push", Enabled: cfg.Push.APNsKeyFile != "", Source: "APNS_KEY_FILE"},
+		// Browser push needs a VAPID key pair.
+		{Name: "web_push", Enabled: cfg.WebPush.PublicKey != "" && cfg.WebPush.PrivateKey != "", Source: "VAPID_PUBLIC_KEY"},
+		// The Telegram bot needs its token.
+		{Name: "telegram", Enabled: cfg.Telegram.BotToken != "", Source: "TELEGRAM_BOT_TOKEN"},
+		// The Slack app needs its client ID.
+		{Name: "slack", Enabled: cfg.Slack.ClientID != "", Source: "SLACK_CLIENT_ID"},
+		// Events are delivered to a webhook when its URL is set.
+		{Name: "outbox_webhook", Enabled: cfg.Outbox.Publisher == "webhook" || (cfg.Outbox.Publisher == "" && cfg.Outbox.WebhookURL != ""), Source: "OUTBOX_WEBHOOK_URL"},
+	}
+}
@@ -0,0 +1,298 @@
+// This file reads secrets from Google Cloud Secret Manager through its HTTP API, authenticated with the service account
+// of GOOGLE_APPLICATION_CREDENTIALS or, on Google Cloud, with the service account of the instance from the metadata server.
+package config
+
+// "crypto" provides the hash identifiers. It is used here to name the hash of the signed token.
+import (
+	"crypto"
+	// "crypto/rand" provides a secure random source. It is used here to sign the token of the service account.
+	"crypto/rand"
+	// "crypto/rsa" implements RSA. It is used here to sign the token of the service account.
+	"crypto/rsa"
+	// "crypto/sha256" implements SHA-256. It is used here to hash the token of the service account.
+	"crypto/sha256"
+	// "crypto/x509" parses keys. It is used here to read the private key of the service account.
+	"crypto/x509"
+	// "encoding/base64" provides base64 encoding. It is used here to encode the token and decode the secrets.
+	"encoding/base64"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to read the key file and the answers of Google.
+	"encoding/json"
+	// "encoding/pem" decodes PEM blocks. It is used here to read the private key of the service account.
+	"encoding/pem"
+	// "errors" provides functions for working with errors. It is used here to build the errors of the key file.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to build the errors of the calls.
+	"fmt"
+	// "io" provides basic I/O primitives. It is used here to read the error answers.
+	"io"
+	// "log" provides a simple logging package. It is used here to stop the application when no project is configured.
+	"log"
+	// "net/http" provides an HTTP client. It is used here to call Google.
+	"net/http"
+	// "net/url" provides URL encoding. It is used here to exchange the token of the service account.
+	"net/url"
+	// "os" provides a platform-independent interface to operating system functionality. It is used here to read the credentials of the environment.
+	"os"
+	// "strings" provides functions for working with strings. It is used here to parse the references.
+	"strings"
+	// "time" provides functions for working with time. It is used here to date the token of the service account.
+	"time"
+)
+
+const (
+	// gcpScope is the scope of the access token, which Secret Manager requires.
+	gcpScope = "https://www.googleapis.com/auth/cloud-platform"
+	// gcpMetadataToken is the token endpoint of the metadata server of Google Cloud.
+	gcpMetadataToken = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// gcpSecretsProvider reads secrets from Google Cloud Secret Manager.
+type gcpSecretsProvider struct {
+	// project is the project of the secrets referenced by name alone.
+	project string
+	// client is the HTTP client of the calls.
+	client *http.Client
+	// token is the access token of the calls, fetched with the first one.
+	token string
+}
+
+// newGCPSecretsProvider creates the Secret Manager provider, with the project of "GOOGLE_CLOUD_PROJECT".
+//
+// @return SecretProvider - The Secret Manager provider.
+func newGCPSecretsProvider() SecretProvider {
+	// The provider is returned.
+	return &gcpSecretsProvider{project: os.Getenv("GOOGLE_CLOUD_PROJECT"), client: &http.Client{Timeout: secretTimeout}}
+}
+
+// Secret returns a version of a secret of Secret Manager, or a key of it if the secret is a JSON object.
+//
+// @param reference string - The full name of the version, such as "projects/my-project/secrets/db-password/versions/3",
+// or the name of a secret of the configured project, whose latest version is read; optionally followed by "#" and a key.
+// @return string - The value.
+// @return error - An error if the secret cannot be read.
+func (p *gcpSecretsProvider) Secret(reference string) (string, error) {
+	// name and key are the two halves of the reference.
+	name, key, _ := strings.Cut(reference, "#")
+	// This checks if the reference names no secret.
+	if name == "" {
+		// If it names none, an error is returned.
+		return "", fmt.Errorf("%w: expected gcp-sm:<secret>[#<key>] or gcp-sm:projects/<project>/secrets/<secret>/versions/<version>[#<key>]", ErrInvalidSecretReference)
+	}
+	// This checks if the reference is the name of a secret alone.
+	if !strings.HasPrefix(name, "projects/") {
+		// This checks if no project is known.
+		if p.project == "" {
+			// If none is, a fatal error is logged.
+			log.Fatalf("GOOGLE_CLOUD_PROJECT must be set to read secrets from Secret Manager by name")
+		}
+		// The latest version of the secret in the project is read.
+		name = "projects/" + p.project + "/secrets/" + name + "/versions/latest"
+	}
+
+	// This checks if no access token was fetched yet.
+	if p.token == "" {
+		// token is the access token.
+		token, err := p.accessToken()
+		// This checks if an error occurred while fetching the token.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return "", err
+		}
+		// The token is kept for the next secrets.
+		p.token = token
+	}
+	// request is the request for the version.
+	request, err := http.NewRequest(http.MethodGet, "https://secretmanager.googleapis.com/v1/"+name+":access", nil)
+	// This checks if an error occurred while creating the request.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+	// The token authenticates the request.
+	request.Header.Set("Authorization", "Bearer "+p.token)
+	// response is the answer of Google.
+	response, err := p.client.Do(request)
+	// This checks if an error occurred while calling Google.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+	// This defers closing the body until the function returns.
+	defer response.Body.Close()
+	// This checks if Google refused the request, such as for a missing secret or an account without access.
+	if response.StatusCode != http.StatusOK {
+		// detail is the start of the answer, which names the error without holding the secret.
+		detail, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		// An error is returned.
+		return "", fmt.Errorf("secret manager answered %s: %s", response.Status, strings.TrimSpace(string(detail)))
+	}
+	// answer is the answer of Google, whose payload holds the secret in base64.
+	var answer struct {
+		// Payload is the payload of the version.
+		// json:"payload" specifies that this field should be marshalled to/from a JSON object with the key "payload".
+		Payload struct {
+			// Data is the secret, in base64.
+			// json:"data" specifies that this field should be marshalled to/from a JSON object with the key "data".
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	// This decodes the answer.
+	if err := json.NewDecoder(response.Body).Decode(&answer); err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+	// secret is the decoded secret.
+	secret, err := base64.StdEncoding.DecodeString(answer.Payload.Data)
+	// This checks if the payload is not base64.
+	if err != nil {
+		// If it is not, an error is returned.
+		return "", err
+	}
+	// The secret, or its key, is returned.
+	return jsonSecretKey(string(secret), key)
+}
+
+// accessToken returns an access token for Secret Manager: one exchanged for a token signed with the key file of GOOGLE_APPLICATION_CREDENTIALS,
+// or else the token of the service account of the instance, which the metadata server of Google Cloud serves.
+//
+// @return string - The access token.
+// @return error - An error if no token can be fetched.
+func (p *gcpSecretsProvider) accessToken() (string, error) {
+	// request is the request for the token.
+	var request *http.Request
+	// This checks if a key file is configured.
+	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
+		// form is the exchange of a token signed with the key for an access token.
+		form, tokenURI, err := serviceAccountGrant(path)
+		// This checks if an error occurred while signing the token.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return "", err
+		}
+		// The request exchanges the token.
+		request, err = http.NewRequest(http.MethodPost, tokenURI, strings.NewReader(form.Encode()))
+		// This checks if an error occurred while creating the request.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return "", err
+		}
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		// err is the error of creating the request.
+		var err error
+		// The request asks the metadata server, which only answers requests that name it.
+		request, err = http.NewRequest(http.MethodGet, gcpMetadataToken, nil)
+		// This checks if an error occurred while creating the request.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return "", err
+		}
+		request.Header.Set("Metadata-Flavor", "Google")
+	}
+
+	// response is the answer of Google.
+	response, err := p.client.Do(request)
+	// This checks if an error occurred while calling Google.
+	if err != nil {
+		// If an error occurs, it is returned, which is what happens outside Google Cloud without a key file.
+		return "", fmt.Errorf("no Google credentials: set GOOGLE_APPLICATION_CREDENTIALS, or run on Google Cloud: %w", err)
+	}
+	// This defers closing the body until the function returns.
+	defer response.Body.Close()
+	// This checks if Google refused the request.
+	if response.StatusCode != http.StatusOK {
+		// If it did, an error is returned.
+		return "", fmt.Errorf("google token endpoint answered %s", response.Status)
+	}
+	// answer is the answer of Google.
+	var answer struct {
+		// AccessToken is the access token.
+		// json:"access_token" specifies that this field should be marshalled to/from a JSON object with the key "access_token".
+		AccessToken string `json:"access_token"`
+	}
+	// This decodes the answer.
+	if err := json.NewDecoder(response.Body).Decode(&answer); err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+	// The token is returned.
+	return answer.AccessToken, nil
+}
+
+// serviceAccountGrant signs a token with the key of a service account, which Google exchanges for an access token.
+//
+// @param path string - The path of the key file of the service account.
+// @return url.Values - The form of the exchange.
+// @return string - The URI of the exchange.
+// @return error - An error if the key file cannot be read.
+func serviceAccountGrant(path string) (url.Values, string, error) {
+	// content is the content of the key file.
+	content, err := os.ReadFile(path)
+	// This checks if the file cannot be read.
+	if err != nil {
+		// If it cannot, the error is returned.
+		return nil, "", err
+	}
+	// account is the key file.
+	var account struct {
+		// ClientEmail is the email of the service account.
+		// json:"client_email" specifies that this field should be marshalled to/from a JSON object with the key "client_email".
+		ClientEmail string `json:"client_email"`
+		// PrivateKey is the private key of the service account, in PEM.
+		// json:"private_key" specifies that this field should be marshalled to/from a JSON object with the key "private_key".
+		PrivateKey string `json:"private_key"`
+		// TokenURI is where the token is exchanged.
+		// json:"token_uri" specifies that this field should be marshalled to/from a JSON object with the key "token_uri".
+		TokenURI string `json:"token_uri"`
+	}
+	// This decodes the key file.
+	if err := json.Unmarshal(content, &account); err != nil {
+		// If it is not JSON, an error is returned.
+		return nil, "", fmt.Errorf("GOOGLE_APPLICATION_CREDENTIALS: %w", err)
+	}
+	// block is the PEM block of the private key.
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	// This checks if the file holds no service account key.
+	if account.ClientEmail == "" || block == nil {
+		// If it does not, an error is returned.
+		return nil, "", errors.New("GOOGLE_APPLICATION_CREDENTIALS is not the key file of a service account")
+	}
+	// parsed is the private key.
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	// This checks if the key cannot be parsed.
+	if err != nil {
+		// If it cannot, the error is returned.
+		return nil, "", fmt.Errorf("GOOGLE_APPLICATION_CREDENTIALS: %w", err)
+	}
+	// key is the private key, which must be RSA.
+	key, ok := parsed.(*rsa.PrivateKey)
+	// This checks if the key is not RSA.
+	if !ok {
+		// If it is not, an error is returned.
+		return nil, "", errors.New("GOOGLE_APPLICATION_CREDENTIALS does not hold an RSA key")
+	}
+	// tokenURI is where the token is exchanged, which Google's endpoint is by default.
+	tokenURI := firstNonEmpty(account.TokenURI, "https://oauth2.googleapis.com/token")
+
+	// now is the time the token is issued.
+	now := time.Now().Unix()
+	// header and claims are the two signed parts of the token.
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{"iss": account.ClientEmail, "scope": gcpScope, "aud": tokenURI, "iat": now, "exp": now + 3600})
+	// unsigned is the part of the token that is signed.
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	// digest is the hash of the signed part.
+	digest := sha256.Sum256([]byte(unsigned))
+	// signature is the signature of the token.
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	// This checks if an error occurred while signing.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, "", err
+	}
+	// The form of the exchange is returned.
+	return url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)},
+	}, tokenURI, nil
+}
@@ -0,0 +1,333 @@
+// This file resolves the secrets of the configuration that are not written into the environment.
+// A variable such as DB_PASSWORD may instead name a file holding its value in DB_PASSWORD_FILE, as Docker and Kubernetes secrets are mounted,
+// and its value may be a reference to a secret kept by a SecretProvider, such as "vault:secret/data/todo-backend#db_password"
+// or "aws-sm:prod/todo-backend#db_password". References are resolved when the configuration is loaded, so a rotated secret is picked up by a restart.
+package config
+
+// "encoding/json" provides functions for decoding JSON. It is used here to read the answers of Vault and the keys of JSON secrets.
+import (
+	"encoding/json"
+	// "errors" provides functions for working with errors. It is used here to define the errors of the references.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to build the errors of the providers.
+	"fmt"
+	// "log" provides a simple logging package. It is used here to stop the application when a secret cannot be read.
+	"log"
+	// "net/http" provides an HTTP client. It is used here to call Vault.
+	"net/http"
+	// "os" provides a platform-independent interface to operating system functionality. It is used here to read the environment and the secret files.
+	"os"
+	// "strings" provides functions for working with strings. It is used here to parse the references.
+	"strings"
+	// "sync" provides synchronization primitives. It is used here to create each provider once.
+	"sync"
+	// "time" provides functions for working with time. It is used here to bound the calls to the providers.
+	"time"
+)
+
+// secretTimeout bounds each call to a secret manager.
+const secretTimeout = 10 * time.Second
+
+// ErrInvalidSecretReference is returned for a reference that does not name a secret in the form its provider expects.
+var ErrInvalidSecretReference = errors.New("invalid secret reference")
+
+// SecretProvider reads secrets from where they are kept.
+type SecretProvider interface {
+	// Secret returns the value a reference names. The form of the reference depends on the provider.
+	Secret(reference string) (string, error)
+}
+
+// secretProviders create the providers of the references, by the scheme that starts a reference, such as "vault" in "vault:secret/data/app#key".
+// A provider is created when a variable first points to it, so that a secret manager only needs to be configured when it is used.
+var secretProviders = map[string]func() SecretProvider{
+	// "env:NAME" reads another environment variable.
+	"env": func() SecretProvider { return envProvider{} },
+	// "file:/path" reads a file.
+	"file": func() SecretProvider { return fileProvider{} },
+	// "vault:<path>#<key>" reads a key of a secret of HashiCorp Vault.
+	"vault": newVaultProvider,
+	// "aws-sm:<secret id>[#<key>]" reads a secret of AWS Secrets Manager.
+	"aws-sm": newAWSSecretsProvider,
+	// "gcp-sm:<secret>[#<key>]" reads a secret of Google Cloud Secret Manager.
+	"gcp-sm": newGCPSecretsProvider,
+}
+
+var (
+	// providersMu guards the created providers.
+	providersMu sync.Mutex
+	// providers are the created providers, by scheme.
+	providers = map[string]SecretProvider{}
+)
+
+// resolveEnv returns the value of a variable of the configuration, or an empty string if it is not set.
+// The value is read from the variable, or from the file named by the variable with the "_FILE" suffix, without its trailing newline;
+// setting both is an error. A value that starts with the scheme of a provider, such as "vault:", is replaced with the secret it names.
+// The application stops if a file or a secret cannot be read, and the values are never logged.
+//
+// @param name string - The name of the variable.
+// @return string - The value.
+func resolveEnv(name string) string {
+	// value is the value of the variable, and file the file named by its "_FILE" variant.
+	value, file := os.Getenv(name), os.Getenv(name+"_FILE")
+	// This checks if both are set.
+	if value != "" && file != "" {
+		// If they are, a fatal error is logged, since it is unclear which one is meant.
+		log.Fatalf("%s and %s_FILE are both set; set only one of them", name, name)
+	}
+	// This checks if the value is in a file.
+	if file != "" {
+		// content is the content of the file.
+		content, err := fileProvider{}.Secret(file)
+		// This checks if the file cannot be read.
+		if err != nil {
+			// If it cannot, a fatal error is logged.
+			log.Fatalf("Unable to read %s_FILE: %v", name, err)
+		}
+		// The value is the content of the file.
+		value = content
+	}
+	// scheme and reference are the two halves of a reference, if the value is one.
+	scheme, reference, ok := strings.Cut(value, ":")
+	// This checks if the value does not start with the scheme of a provider.
+	if _, known := secretProviders[scheme]; !ok || !known {
+		// If it does not, the value is returned as it is.
+		return value
+	}
+	// secret is the value the reference names.
+	secret, err := secretProvider(scheme).Secret(reference)
+	// This checks if the secret cannot be read.
+	if err != nil {
+		// If it cannot, a fatal error is logged.
+		log.Fatalf("Unable to read %s from %s: %v", name, scheme, err)
+	}
+	// The secret is returned.
+	return secret
+}
+
+// secretProvider returns the provider of a scheme, creating it on first use.
+//
+// @param scheme string - The scheme, which must be a key of secretProviders.
+// @return SecretProvider - The provider.
+func secretProvider(scheme string) SecretProvider {
+	// The providers are locked while one is looked up or created.
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	// provider is the provider, if it was created before.
+	provider, ok := providers[scheme]
+	// This checks if it was not.
+	if !ok {
+		// If it was not, it is created.
+		provider = secretProviders[scheme]()
+		providers[scheme] = provider
+	}
+	// The provider is returned.
+	return provider
+}
+
+// jsonSecretKey returns a key of a secret that holds a JSON object, such as the username and password of a database secret,
+// or the whole secret if no key is asked for.
+//
+// @param secret string - The secret.
+// @param key string - The key, or empty for the whole secret.
+// @return string - The value.
+// @return error - An error if the secret is not a JSON object or has no string at the key.
+func jsonSecretKey(secret string, key string) (string, error) {
+	// This checks if no key is asked for.
+	if key == "" {
+		// If none is, the whole secret is returned.
+		return secret, nil
+	}
+	// object is the secret as a JSON object.
+	var object map[string]interface{}
+	// This decodes the secret.
+	if err := json.Unmarshal([]byte(secret), &object); err != nil {
+		// If it is not a JSON object, an error is returned without the secret.
+		return "", errors.New("secret is not a JSON object")
+	}
+	// value is the value of the key, which must be a string.
+	value, ok := object[key].(string)
+	// This checks if the key is missing or not a string.
+	if !ok {
+		// If it is, an error is returned.
+		return "", fmt.Errorf("secret has no string key %q", key)
+	}
+	// The value is returned.
+	return value, nil
+}
+
+// envProvider reads secrets from other environment variables, such as the variables a platform injects under its own names.
+type envProvider struct{}
+
+// Secret returns the value of an environment variable.
+//
+// @param reference string - The name of the variable.
+// @return string - The value.
+// @return error - An error if the variable is not set.
+func (envProvider) Secret(reference string) (string, error) {
+	// value is the value of the variable.
+	value, ok := os.LookupEnv(reference)
+	// This checks if the variable is not set.
+	if !ok {
+		// If it is not, an error is returned.
+		return "", fmt.Errorf("environment variable %s is not set", reference)
+	}
+	// The value is returned.
+	return value, nil
+}
+
+// fileProvider reads secrets from files, such as the secrets Docker and Kubernetes mount.
+type fileProvider struct{}
+
+// Secret returns the content of a file without its trailing newline.
+//
+// @param reference string - The path of the file.
+// @return string - The content.
+// @return error - An error if the file cannot be read.
+func (fileProvider) Secret(reference string) (string, error) {
+	// content is the content of the file.
+	content, err := os.ReadFile(reference)
+	// This checks if the file cannot be read.
+	if err != nil {
+		// If it cannot, the error is returned.
+		return "", err
+	}
+	// The content is returned without the newline editors and secret tools end files with.
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// vaultProvider reads secrets from the key/value engine of HashiCorp Vault.
+type vaultProvider struct {
+	// address is the address of Vault, such as "https://vault.internal:8200".
+	address string
+	// token is the token the application authenticates to Vault with.
+	token string
+	// client is the HTTP client of the calls.
+	client *http.Client
+	// mu guards the cached secrets.
+	mu sync.Mutex
+	// cache holds the secrets already read, by path, so that several variables of one path are read with one call.
+	cache map[string]map[string]interface{}
+}
+
+// newVaultProvider creates the Vault provider from the "VAULT_ADDR" and "VAULT_TOKEN" variables. The token may be read from "VAULT_TOKEN_FILE" as well.
+//
+// @return SecretProvider - The Vault provider.
+func newVaultProvider() SecretProvider {
+	// address is the address of Vault.
+	address := strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/")
+	// token is the token of the application.
+	token := os.Getenv("VAULT_TOKEN")
+	// This checks if the token is mounted as a file. It is not resolved as a reference, since it is what references are read with.
+	if file := os.Getenv("VAULT_TOKEN_FILE"); file != "" {
+		// content is the content of the file.
+		content, err := fileProvider{}.Secret(file)
+		// This checks if the file cannot be read.
+		if err != nil {
+			// If it cannot, a fatal error is logged.
+			log.Fatalf("Unable to read VAULT_TOKEN_FILE: %v", err)
+		}
+		// The token is the content of the file.
+		token = content
+	}
+	// This checks if Vault is not configured.
+	if address == "" || token == "" {
+		// If it is not, a fatal error is logged.
+		log.Fatalf("VAULT_ADDR and VAULT_TOKEN must be set to read secrets from Vault")
+	}
+	// The provider is returned.
+	return &vaultProvider{address: address, token: token, client: &http.Client{Timeout: secretTimeout}, cache: map[string]map[string]interface{}{}}
+}
+
+// Secret returns the value of a key of a secret in Vault. Both version 1 and version 2 of the key/value engine are read,
+// with the path as Vault's HTTP API names it, such as "secret/data/todo-backend" for version 2.
+//
+// @param reference string - The path and key of the secret, separated by "#", such as "secret/data/todo-backend#db_password".
+// @return string - The value.
+// @return error - An error if the secret cannot be read.
+func (v *vaultProvider) Secret(reference string) (string, error) {
+	// path and key are the two halves of the reference.
+	path, key, ok := strings.Cut(reference, "#")
+	// This checks if the reference is malformed.
+	if !ok || path == "" || key == "" {
+		// If it is, an error is returned.
+		return "", fmt.Errorf("%w: expected vault:<path>#<key>", ErrInvalidSecretReference)
+	}
+	// data is the secret at the path.
+	data, err := v.read(strings.Trim(path, "/"))
+	// This checks if an error occurred while reading the secret.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+	// value is the value of the key, which must be a string.
+	value, ok := data[key].(string)
+	// This checks if the key is missing or not a string.
+	if !ok {
+		// If it is, an error is returned.
+		return "", fmt.Errorf("secret %s has no string key %q", path, key)
+	}
+	// The value is returned.
+	return value, nil
+}
+
+// read returns the keys of the secret at a path, calling Vault once per path.
+//
+// @param path string - The path of the secret.
+// @return map[string]interface{} - The keys of the secret.
+// @return error - An error if the secret cannot be read.
+func (v *vaultProvider) read(path string) (map[string]interface{}, error) {
+	// The cache is locked while the secret is read, so that a path is not read twice.
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	// This checks if the secret was read before.
+	if data, ok := v.cache[path]; ok {
+		// If it was, it is returned.
+		return data, nil
+	}
+
+	// request is the request for the secret.
+	request, err := http.NewRequest(http.MethodGet, v.address+"/v1/"+path, nil)
+	// This checks if an error occurred while creating the request.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// The token authenticates the request.
+	request.Header.Set("X-Vault-Token", v.token)
+	// response is the answer of Vault.
+	response, err := v.client.Do(request)
+	// This checks if an error occurred while calling Vault.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// This defers closing the body until the function returns.
+	defer response.Body.Close()
+	// This checks if Vault refused the request, such as for a missing secret or a token without access.
+	if response.StatusCode != http.StatusOK {
+		// If it did, an error is returned.
+		return nil, fmt.Errorf("vault answered %s for %s", response.Status, path)
+	}
+
+	// body is the answer of Vault, whose data holds the keys in version 1, and a data object with the keys in version 2.
+	var body struct {
+		// Data is the data of the secret.
+		// json:"data" specifies that this field should be marshalled to/from a JSON object with the key "data".
+		Data map[string]interface{} `json:"data"`
+	}
+	// This decodes the answer.
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// data is the keys of the secret.
+	data := body.Data
+	// This checks if the answer is of version 2.
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		// If it is, the keys are the nested data.
+		data = nested
+	}
+	// The secret is cached and returned.
+	v.cache[path] = data
+	return data, nil
+}
@@ -0,0 +1,110 @@
+// This file defines the validation of the color and icon that users pick for lists and tags.
+// Clients render them as they are, so they are checked and normalized here once, for every surface that stores them.
+package content
+
+// "regexp" provides regular expressions. It is used here to check hex colors.
+import (
+	"regexp"
+	// "strings" provides functions for working with strings. It is used here to normalize colors.
+	"strings"
+	// "unicode" provides functions for classifying characters. It is used here to check icons.
+	"unicode"
+	// "unicode/utf8" provides functions for working with UTF-8. It is used here to count the characters of icons.
+	"unicode/utf8"
+
+	// "github.com/rahulcodepython/todo-backend/backend/binding" is a local package that describes invalid fields.
+	"github.com/rahulcodepython/todo-backend/backend/binding"
+)
+
+// maxIconLength is the maximum number of code points of an icon.
+// It fits the longest emoji sequences in use, such as families joined with zero-width joiners and skin tones.
+const maxIconLength = 16
+
+// hexColor matches a color in the "#rgb" or "#rrggbb" notation, once lowercased.
+var hexColor = regexp.MustCompile(`^#([0-9a-f]{3}|[0-9a-f]{6})$`)
+
+// Appearance is the color and icon of a list or a tag. An empty value means that none was picked.
+type Appearance struct {
+	// Color points to the hex color.
+	Color *string
+	// Icon points to the emoji icon.
+	Icon *string
+}
+
+// CleanAppearance normalizes the color and icon in place and checks them.
+// Colors are trimmed, lowercased, and expanded from "#rgb" to "#rrggbb"; icons are trimmed and must be an emoji.
+// A nil pointer is skipped, so that partial updates can pass only the fields they change.
+//
+// @param appearance Appearance - The color and icon to be cleaned.
+// @return binding.FieldErrors - The invalid fields, or nil if both are valid. It is returned as is, so that query structs can return it from Validate.
+func CleanAppearance(appearance Appearance) binding.FieldErrors {
+	// errs is the list of invalid fields.
+	var errs binding.FieldErrors
+	// This checks if a color was given.
+	if appearance.Color != nil {
+		// color is the color, trimmed and lowercased.
+		color := strings.ToLower(strings.TrimSpace(*appearance.Color))
+		// This checks if a color that is not a hex color was given.
+		if color != "" && !hexColor.MatchString(color) {
+			// If one was, the error is collected.
+			errs = append(errs, binding.NewFieldError("color", "must be a hex color such as #1e90ff"))
+		}
+		// This checks if the color uses the short notation.
+		if len(color) == 4 && hexColor.MatchString(color) {
+			// If it does, each digit is doubled, so that equal colors are stored, and filtered, the same way.
+			color = string([]byte{'#', color[1], color[1], color[2], color[2], color[3], color[3]})
+		}
+		// The value is replaced with the normalized color.
+		*appearance.Color = color
+	}
+	// This checks if an icon was given.
+	if appearance.Icon != nil {
+		// icon is the icon without control characters and surrounding whitespace.
+		icon := clean(*appearance.Icon, false)
+		// This checks if the icon is too long.
+		if utf8.RuneCountInString(icon) > maxIconLength {
+			// If it is, the error is collected.
+			errs = append(errs, binding.NewFieldError("icon", "must be at most %d characters", maxIconLength))
+		} else if icon != "" && !isEmoji(icon) {
+			// If it is not an emoji, the error is collected.
+			errs = append(errs, binding.NewFieldError("icon", "must be an emoji"))
+		}
+		// The value is replaced with the cleaned icon.
+		*appearance.Icon = icon
+	}
+	// The invalid fields, if any, are returned.
+	return errs
+}
+
+// isEmoji reports whether text is made only of the characters that emoji sequences are built from.
+// Unicode has no single property for emoji, so symbols are accepted along with the joiners, selectors, modifiers,
+// and tag characters that combine them, and the digits, "#", and "*" that start keycaps. Letters and spaces are not.
+//
+// @param text string - The text.
+// @return bool - True if the text is an emoji.
+func isEmoji(text string) bool {
+	// symbols counts the pictographic characters, since a sequence of joiners alone is not an icon.
+	symbols := 0
+	// This iterates over the characters of the text.
+	for _, r := range text {
+		// This checks the kind of the character.
+		switch {
+		case unicode.IsSymbol(r) && r > unicode.MaxASCII:
+			// Symbols beyond ASCII, such as pictographs, regional indicators, and skin tone modifiers, are counted.
+			symbols++
+		case r == '\u200d' || r == '\ufe0f' || r == '\u20e3' || (r >= 0xe0020 && r <= 0xe007f):
+			// The zero-width joiner, the emoji variation selector, the keycap mark, and the tag characters of subdivision flags are allowed.
+		case r == '#' || r == '*' || (r >= '0' && r <= '9'):
+			// The bases of keycaps are allowed, but only count once the keycap mark turns them into one.
+			if !strings.ContainsRune(text, '\u20e3') {
+				return false
+			}
+			symbols++
+		default:
+			// Any other character, such as a letter or a space, is not part of an emoji.
+			return false
+		}
+	}
+	// The text is an emoji if it contains at least one pictographic character.
+	return symbols > 0
+}
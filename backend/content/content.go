@@ -0,0 +1,147 @@
+// This file defines the validation that text written by users goes through before it is stored.
+// Titles, descriptions, and list names are cleaned of control characters, limited in length, and passed to an
+// optional filter, in one place, so that every surface that writes them, REST, offline sync, or CalDAV, agrees on the rules.
+package content
+
+// "strings" provides functions for working with strings. It is used here to clean the text.
+import (
+	"strings"
+	// "unicode" provides functions for classifying characters. It is used here to find control characters.
+	"unicode"
+	// "unicode/utf8" provides functions for working with UTF-8. It is used here to count characters.
+	"unicode/utf8"
+
+	// "github.com/rahulcodepython/todo-backend/backend/binding" is a local package that describes invalid fields.
+	"github.com/rahulcodepython/todo-backend/backend/binding"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+// Field describes a text field that users write.
+type Field struct {
+	// Name is the name of the field in request bodies, used to report it.
+	Name string
+	// MaxLength is the maximum number of characters of the field, or zero for no limit.
+	MaxLength int
+	// Multiline reports whether the field keeps line breaks and tabs. Single-line fields turn them into spaces.
+	Multiline bool
+}
+
+// Text is the value of a field that is cleaned in place.
+type Text struct {
+	// Field is the field the value belongs to.
+	Field Field
+	// Value points to the value.
+	Value *string
+}
+
+// Filter is a hook that decides whether text may be stored, such as a profanity filter.
+type Filter interface {
+	// Allows reports whether the cleaned text of a field may be stored.
+	Allows(field Field, text string) bool
+}
+
+// Validator cleans and checks the text written by users.
+type Validator struct {
+	// TodoTitle is the title of a todo.
+	TodoTitle Field
+	// TodoDescription is the description of a todo.
+	TodoDescription Field
+	// ListName is the name of a list.
+	ListName Field
+	// filter rejects text that may not be stored, or nil to allow all text.
+	filter Filter
+}
+
+// NewValidator creates a new Validator with the limits of the configuration.
+//
+// @param cfg *config.Config - The application configuration.
+// @param filter Filter - The filter of the text, or nil to allow all text.
+// @return *Validator - A pointer to the new Validator.
+func NewValidator(cfg *config.Config, filter Filter) *Validator {
+	// A pointer to a new Validator struct is returned.
+	return &Validator{
+		// The TodoTitle field is a single line.
+		TodoTitle: Field{Name: "title", MaxLength: cfg.Content.TitleMaxLength},
+		// The TodoDescription field keeps its line breaks.
+		TodoDescription: Field{Name: "description", MaxLength: cfg.Content.DescriptionMaxLength, Multiline: true},
+		// The ListName field is a single line.
+		ListName: Field{Name: "name", MaxLength: cfg.Content.ListNameMaxLength},
+		// The filter field is set to the filter.
+		filter: filter,
+	}
+}
+
+// Clean cleans each text in place and checks it.
+// Invalid UTF-8 and control characters are removed, single-line text is trimmed, and the length and the filter are checked.
+// An empty text is not an error here; whether a field is required is up to the caller.
+//
+// @param texts ...Text - The texts to be cleaned.
+// @return error - The binding.FieldErrors of the invalid texts, or nil if all of them are valid.
+func (v *Validator) Clean(texts ...Text) error {
+	// errs is the list of invalid texts.
+	var errs binding.FieldErrors
+	// This iterates over the texts.
+	for _, text := range texts {
+		// cleaned is the text without the characters that are never stored.
+		cleaned := clean(*text.Value, text.Field.Multiline)
+		// The value is replaced with the cleaned text.
+		*text.Value = cleaned
+
+		// This checks if the text is too long.
+		if text.Field.MaxLength > 0 && utf8.RuneCountInString(cleaned) > text.Field.MaxLength {
+			// If it is, the error is collected.
+			errs = append(errs, binding.NewFieldError(text.Field.Name, "must be at most %d characters", text.Field.MaxLength))
+			continue
+		}
+		// This checks if the filter rejects the text.
+		if v.filter != nil && cleaned != "" && !v.filter.Allows(text.Field, cleaned) {
+			// If it does, the error is collected.
+			errs = append(errs, binding.NewFieldError(text.Field.Name, "contains words that are not allowed"))
+		}
+	}
+	// This checks if any text is invalid.
+	if len(errs) > 0 {
+		// If one is, the errors are returned.
+		return errs
+	}
+	// No error is returned.
+	return nil
+}
+
+// clean removes invalid UTF-8 and control characters from text.
+// Line breaks are normalized to "\n" and kept with tabs in multiline text; in single-line text they become spaces and
+// the text is trimmed.
+//
+// @param text string - The text.
+// @param multiline bool - Whether the text keeps line breaks and tabs.
+// @return string - The cleaned text.
+func clean(text string, multiline bool) string {
+	// text is the text with invalid UTF-8 removed and Windows and old Mac line breaks turned into "\n".
+	text = strings.ReplaceAll(strings.ReplaceAll(strings.ToValidUTF8(text, ""), "\r\n", "\n"), "\r", "\n")
+	// cleaned is the text with control characters removed or replaced.
+	cleaned := strings.Map(func(r rune) rune {
+		// This checks if the character is a line break or a tab.
+		if r == '\n' || r == '\t' {
+			// If it is, it is kept in multiline text and becomes a space otherwise.
+			if multiline {
+				return r
+			}
+			return ' '
+		}
+		// This checks if the character is another control character, such as NUL, which the database rejects.
+		if unicode.IsControl(r) {
+			// If it is, it is dropped.
+			return -1
+		}
+		// Any other character is kept.
+		return r
+	}, text)
+	// This checks if the text is a single line.
+	if !multiline {
+		// If it is, the surrounding whitespace is trimmed.
+		return strings.TrimSpace(cleaned)
+	}
+	// The cleaned text is returned.
+	return cleaned
+}
@@ -0,0 +1,58 @@
+// This file defines the filter that rejects text containing blocked words.
+package content
+
+// "strings" provides functions for working with strings. It is used here to split text into words.
+import (
+	"strings"
+	// "unicode" provides functions for classifying characters. It is used here to find word boundaries.
+	"unicode"
+)
+
+// WordFilter is the Filter that rejects text containing any of a list of blocked words, ignoring case.
+// Words are matched whole, so a blocked word inside a longer word is allowed.
+type WordFilter struct {
+	// words is the set of blocked words, in lower case.
+	words map[string]bool
+}
+
+// NewWordFilter creates a new WordFilter.
+//
+// @param words []string - The blocked words. Empty words are ignored.
+// @return WordFilter - The new WordFilter, which allows all text if no word is blocked.
+func NewWordFilter(words []string) WordFilter {
+	// filter is the new WordFilter.
+	filter := WordFilter{words: make(map[string]bool, len(words))}
+	// This iterates over the blocked words.
+	for _, word := range words {
+		// This checks if the word is not empty.
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			// If it is not, it is blocked.
+			filter.words[word] = true
+		}
+	}
+	// The filter is returned.
+	return filter
+}
+
+// Allows reports whether text contains no blocked word.
+//
+// @param field Field - The field of the text, which applies the same words to every field.
+// @param text string - The text.
+// @return bool - True if the text contains no blocked word.
+func (f WordFilter) Allows(field Field, text string) bool {
+	// This checks if no word is blocked.
+	if len(f.words) == 0 {
+		// If none is, the text is allowed.
+		return true
+	}
+	// This iterates over the words of the text, which are separated by anything but letters and digits.
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) }) {
+		// This checks if the word is blocked.
+		if f.words[word] {
+			// If it is, the text is not allowed.
+			return false
+		}
+	}
+	// The text is allowed.
+	return true
+}
@@ -0,0 +1,344 @@
+// This file wraps the PostgreSQL driver in a circuit breaker, so that every query of the application goes through it.
+// When the database fails several times in a row, queries fail at once with a *breaker.OpenError instead of piling up
+// connections and timeouts, until a probe query finds the database reachable again. The queries are timed on their way through as well,
+// and the connections of the pool are checked before they are reused, so that the ones left stale by a restart of the database are dropped.
+package database
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to tell timeouts from cancelled requests.
+import (
+	"context"
+	// "database/sql/driver" defines the interfaces of SQL drivers. It is used here to wrap the PostgreSQL connections.
+	"database/sql/driver"
+	// "errors" provides functions for inspecting errors. It is used here to classify the failures.
+	"errors"
+	// "io" provides basic I/O primitives. It is used here to recognize a connection closed by the server.
+	"io"
+	// "net" provides network primitives. It is used here to recognize network failures.
+	"net"
+	// "strings" provides functions for working with strings. It is used here to read the class of an error code.
+	"strings"
+	// "time" provides functions for working with time. It is used here to time the queries.
+	"time"
+
+	// "github.com/lib/pq" is the PostgreSQL driver. It is used here to read the error codes of the server.
+	"github.com/lib/pq"
+	// "github.com/rahulcodepython/todo-backend/backend/breaker" is a local package that provides the circuit breaker.
+	"github.com/rahulcodepython/todo-backend/backend/breaker"
+)
+
+// breakerConnector is a driver.Connector that opens connections through a circuit breaker.
+type breakerConnector struct {
+	// connector is the connector of the PostgreSQL driver.
+	connector driver.Connector
+	// breaker is the circuit breaker of the database.
+	breaker *breaker.Breaker
+	// slow is the log of the slow queries.
+	slow *slowQueryLog
+	// health is the health monitor of the database.
+	health *healthMonitor
+}
+
+// postgresConn is the set of interfaces that a PostgreSQL connection implements.
+type postgresConn interface {
+	driver.Conn
+	driver.QueryerContext
+	driver.ExecerContext
+	driver.ConnPrepareContext
+	driver.ConnBeginTx
+	driver.Pinger
+	driver.SessionResetter
+	driver.Validator
+}
+
+// breakerConn is a connection whose calls go through a circuit breaker.
+type breakerConn struct {
+	// postgresConn is the connection of the PostgreSQL driver.
+	postgresConn
+	// breaker is the circuit breaker of the database.
+	breaker *breaker.Breaker
+	// slow is the log of the slow queries.
+	slow *slowQueryLog
+	// health is the health monitor of the database.
+	health *healthMonitor
+	// generation is the generation of the health monitor the connection was last checked in.
+	generation uint64
+	// usedAt is when the connection was last taken from the pool.
+	usedAt time.Time
+}
+
+// breakerTx is a transaction whose commit is recorded by a circuit breaker.
+type breakerTx struct {
+	// tx is the transaction of the PostgreSQL driver.
+	tx driver.Tx
+	// breaker is the circuit breaker of the database.
+	breaker *breaker.Breaker
+}
+
+// Connect opens a connection, unless the breaker is open.
+//
+// @param ctx context.Context - The context of the caller.
+// @return driver.Conn - The connection.
+// @return error - A *breaker.OpenError if the breaker is open, or the error of the driver.
+func (bc *breakerConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	// This checks if the breaker lets the call through.
+	if err := bc.breaker.Allow(); err != nil {
+		// If it does not, the error is returned.
+		return nil, err
+	}
+	// conn is the new connection.
+	conn, err := bc.connector.Connect(ctx)
+	// failed reports whether the connection failed because of the database.
+	failed := connectionFailed(ctx, err)
+	// The outcome is recorded.
+	bc.breaker.Record(failed)
+	// This checks if the connection failed because of the database.
+	if failed {
+		// If it did, the health monitor is told.
+		bc.health.failed()
+	}
+	// This checks if an error occurred while connecting.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// postgres is the connection as a PostgreSQL connection.
+	postgres, ok := conn.(postgresConn)
+	// This checks if the connection does not implement the interfaces of a PostgreSQL connection.
+	if !ok {
+		// If it does not, the connection is returned unwrapped.
+		return conn, nil
+	}
+	// The wrapped connection is returned.
+	return &breakerConn{postgresConn: postgres, breaker: bc.breaker, slow: bc.slow, health: bc.health, generation: bc.health.generation.Load(), usedAt: time.Now()}, nil
+}
+
+// Driver returns the PostgreSQL driver.
+//
+// @return driver.Driver - The driver.
+func (bc *breakerConnector) Driver() driver.Driver {
+	// The driver of the wrapped connector is returned.
+	return bc.connector.Driver()
+}
+
+// guard makes a call through the breaker and records its outcome.
+//
+// @param ctx context.Context - The context of the call.
+// @param call func() error - The call.
+// @return error - A *breaker.OpenError if the breaker is open, or the error of the call.
+func (bc *breakerConn) guard(ctx context.Context, call func() error) error {
+	// This checks if the breaker lets the call through.
+	if err := bc.breaker.Allow(); err != nil {
+		// If it does not, the error is returned.
+		return err
+	}
+	// err is the error of the call, if any.
+	err := call()
+	// failed reports whether the call failed because of the database.
+	failed := connectionFailed(ctx, err)
+	// The outcome is recorded.
+	bc.breaker.Record(failed)
+	// This checks if the call failed because of the database.
+	if failed {
+		// If it did, the health monitor is told, which has the other connections of the pool checked before they are reused.
+		bc.health.failed()
+	}
+	// The error, if any, is returned.
+	return err
+}
+
+// ResetSession is called by database/sql before a connection of the pool is reused.
+// A connection that sat idle for longer than the check interval, or that was last checked before a connection failure, is pinged first,
+// and a connection that does not answer is reported as bad, so that database/sql drops it and runs the query on another one instead of failing it.
+//
+// @param ctx context.Context - The context of the caller.
+// @return error - driver.ErrBadConn if the connection is stale, or another error if one occurred.
+func (bc *breakerConn) ResetSession(ctx context.Context) error {
+	// This resets the session of the driver.
+	if err := bc.postgresConn.ResetSession(ctx); err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// generation is the current generation of the health monitor, and now the time the connection is taken.
+	generation, now := bc.health.generation.Load(), time.Now()
+	// This checks if the connection was checked since the last failure and used within the check interval.
+	if generation == bc.generation && now.Sub(bc.usedAt) < bc.health.interval {
+		// If it was, it is reused without a ping.
+		bc.usedAt = now
+		return nil
+	}
+	// This pings the connection, bypassing the breaker, since a stale connection says nothing about the database.
+	if err := bc.postgresConn.Ping(ctx); err != nil {
+		// This checks if the caller gave up, which says nothing about the connection.
+		if ctx.Err() != nil {
+			// If it did, the error of the context is returned.
+			return ctx.Err()
+		}
+		// Otherwise the connection is stale and is dropped.
+		return driver.ErrBadConn
+	}
+	// The connection is marked as checked.
+	bc.generation, bc.usedAt = generation, now
+	return nil
+}
+
+// QueryContext runs a query through the breaker and times it until the database starts answering, without the reading of the rows.
+//
+// @param ctx context.Context - The context of the query.
+// @param query string - The query.
+// @param args []driver.NamedValue - The arguments of the query.
+// @return driver.Rows - The rows.
+// @return error - An error if one occurred.
+func (bc *breakerConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (rows driver.Rows, err error) {
+	// started is the start of the query.
+	started := time.Now()
+	// The duration of the query is recorded once it returns.
+	defer func() { bc.slow.observe(query, len(args), time.Since(started)) }()
+	// The query is run through the breaker.
+	err = bc.guard(ctx, func() error {
+		// The query is run by the driver.
+		rows, err = bc.postgresConn.QueryContext(ctx, query, args)
+		// The error, if any, is returned.
+		return err
+	})
+	// The rows and the error are returned.
+	return rows, err
+}
+
+// ExecContext runs a statement through the breaker and times it.
+//
+// @param ctx context.Context - The context of the statement.
+// @param query string - The statement.
+// @param args []driver.NamedValue - The arguments of the statement.
+// @return driver.Result - The result.
+// @return error - An error if one occurred.
+func (bc *breakerConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (result driver.Result, err error) {
+	// started is the start of the statement.
+	started := time.Now()
+	// The duration of the statement is recorded once it returns.
+	defer func() { bc.slow.observe(query, len(args), time.Since(started)) }()
+	// The statement is run through the breaker.
+	err = bc.guard(ctx, func() error {
+		// The statement is run by the driver.
+		result, err = bc.postgresConn.ExecContext(ctx, query, args)
+		// The error, if any, is returned.
+		return err
+	})
+	// The result and the error are returned.
+	return result, err
+}
+
+// PrepareContext prepares a statement through the breaker.
+//
+// @param ctx context.Context - The context of the statement.
+// @param query string - The statement.
+// @return driver.Stmt - The prepared statement.
+// @return error - An error if one occurred.
+func (bc *breakerConn) PrepareContext(ctx context.Context, query string) (stmt driver.Stmt, err error) {
+	// The statement is prepared through the breaker.
+	err = bc.guard(ctx, func() error {
+		// The statement is prepared by the driver.
+		stmt, err = bc.postgresConn.PrepareContext(ctx, query)
+		// The error, if any, is returned.
+		return err
+	})
+	// The prepared statement and the error are returned.
+	return stmt, err
+}
+
+// BeginTx begins a transaction through the breaker.
+//
+// @param ctx context.Context - The context of the transaction.
+// @param opts driver.TxOptions - The options of the transaction.
+// @return driver.Tx - The transaction.
+// @return error - An error if one occurred.
+func (bc *breakerConn) BeginTx(ctx context.Context, opts driver.TxOptions) (tx driver.Tx, err error) {
+	// The transaction is begun through the breaker.
+	err = bc.guard(ctx, func() error {
+		// The transaction is begun by the driver.
+		tx, err = bc.postgresConn.BeginTx(ctx, opts)
+		// The error, if any, is returned.
+		return err
+	})
+	// This checks if an error occurred while beginning the transaction.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// The wrapped transaction is returned.
+	return &breakerTx{tx: tx, breaker: bc.breaker}, nil
+}
+
+// Ping checks the connection through the breaker.
+//
+// @param ctx context.Context - The context of the ping.
+// @return error - An error if one occurred.
+func (bc *breakerConn) Ping(ctx context.Context) error {
+	// The connection is checked through the breaker.
+	return bc.guard(ctx, func() error {
+		// The connection is checked by the driver.
+		return bc.postgresConn.Ping(ctx)
+	})
+}
+
+// Commit commits the transaction and records its outcome.
+// It is not refused while the breaker is open, since the transaction already holds a connection.
+//
+// @return error - An error if one occurred.
+func (bt *breakerTx) Commit() error {
+	// err is the error of the commit, if any.
+	err := bt.tx.Commit()
+	// The outcome is recorded.
+	bt.breaker.Record(connectionFailed(context.Background(), err))
+	// The error, if any, is returned.
+	return err
+}
+
+// Rollback rolls the transaction back. It is never refused, so that connections are always released.
+//
+// @return error - An error if one occurred.
+func (bt *breakerTx) Rollback() error {
+	// The transaction is rolled back by the driver.
+	return bt.tx.Rollback()
+}
+
+// connectionFailed reports whether an error means that the database is unreachable or overloaded,
+// rather than an error the database answered with, such as a violated constraint.
+//
+// @param ctx context.Context - The context of the call.
+// @param err error - The error of the call.
+// @return bool - True if the error counts as a failure of the database.
+func connectionFailed(ctx context.Context, err error) bool {
+	// This checks if the call succeeded, or if the caller gave up on it.
+	if err == nil || errors.Is(err, driver.ErrSkip) || errors.Is(ctx.Err(), context.Canceled) {
+		// If so, it is not a failure.
+		return false
+	}
+	// This checks if the call ran out of time, which is how a hanging database shows.
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) || errors.Is(err, context.DeadlineExceeded) {
+		// If so, it is a failure.
+		return true
+	}
+	// This checks if the connection broke.
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		// If so, it is a failure.
+		return true
+	}
+	// netErr is the network error, if the error is one.
+	var netErr net.Error
+	// This checks if the error is a network error.
+	if errors.As(err, &netErr) {
+		// If so, it is a failure.
+		return true
+	}
+	// pqErr is the error of the server, if the error is one.
+	var pqErr *pq.Error
+	// This checks if the error is an error of the server.
+	if errors.As(err, &pqErr) {
+		// code is the SQLSTATE code of the error.
+		code := string(pqErr.Code)
+		// Connection exceptions (08), insufficient resources (53), and a server that is shutting down or starting (57P0x) are failures.
+		return strings.HasPrefix(code, "08") || strings.HasPrefix(code, "53") || strings.HasPrefix(code, "57P0")
+	}
+	// Any other error is not a failure of the database.
+	return false
+}
@@ -1,36 +1,112 @@
 // This file provides functions for connecting to and initializing the database.
 package database
 
-// "database/sql" provides a generic SQL interface. It is used here to interact with the database.
+// "context" provides contexts that carry deadlines. It is used here to bound the database ping.
 import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to interact with the database.
 	"database/sql"
+	// "database/sql/driver" defines the interfaces of database drivers. It is used here to discard the connection of a lock that could not be released.
+	"database/sql/driver"
 	// "fmt" provides functions for formatted I/O. It is used here to construct the database connection string.
 	"fmt"
 	// "log" provides a simple logging package. It is used here to log database-related messages.
 	"log"
+	// "strings" provides functions for working with strings. It is used here to quote the values of the connection string.
+	"strings"
+	// "time" provides functions for working with time. It is used here to set the ping timeout.
+	"time"
 
+	// "github.com/lib/pq" is the PostgreSQL driver. It is used here to create the connector of the database.
+	"github.com/lib/pq"
+	// "github.com/rahulcodepython/todo-backend/backend/breaker" is a local package that provides the circuit breaker.
+	"github.com/rahulcodepython/todo-backend/backend/breaker"
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time. It is used here to time the cooldown of the breaker.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
 	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
 	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+// PingTimeout is the longest a ping waits for the database.
+const PingTimeout = 3 * time.Second
 
-	// _ "github.com/lib/pq" is the PostgreSQL driver. The underscore indicates that it is imported for its side effects (registering the driver).
-	_ "github.com/lib/pq"
+const (
+	// TrySchemaLockQuery is the SQL query to take the session-level advisory lock of the schema changes, if no other server holds it.
+	TrySchemaLockQuery = "SELECT pg_try_advisory_lock(hashtext('schema_migrations'))"
+	// SchemaLockQuery is the SQL query to wait for the session-level advisory lock of the schema changes.
+	SchemaLockQuery = "SELECT pg_advisory_lock(hashtext('schema_migrations'))"
+	// SchemaUnlockQuery is the SQL query to release the advisory lock of the schema changes.
+	SchemaUnlockQuery = "SELECT pg_advisory_unlock(hashtext('schema_migrations'))"
 )
 
-// PingDB checks if the database connection is alive.
-// It takes a database connection as input.
+// PingDB checks if the database connection is alive, giving up after PingTimeout or when the context ends.
+// It returns the error rather than exiting, so that the caller decides between stopping at startup and reporting it at runtime.
 //
+// @param ctx context.Context - The context of the caller, such as the request context.
 // @param db *sql.DB - The database connection.
-func PingDB(db *sql.DB) {
-	// db.Ping() verifies a connection to the database is still alive, establishing a connection if necessary.
-	if err := db.Ping(); err != nil {
-		// If the ping fails, a message is logged.
-		log.Println("Unable to ping database")
+// @return error - An error if the database could not be reached in time.
+func PingDB(ctx context.Context, db *sql.DB) error {
+	// ctx is bounded by the ping timeout, and cancel releases its timer.
+	ctx, cancel := context.WithTimeout(ctx, PingTimeout)
+	// This defers releasing the timer until the function returns.
+	defer cancel()
+
+	// db.PingContext() verifies a connection to the database is still alive, establishing a connection if necessary.
+	return db.PingContext(ctx)
+}
+
+// lockSchema takes the advisory lock of the schema changes, waiting while another server holds it, so that the servers that start together
+// do not race to create the same tables, indexes, and types, which fails one of them with a duplicate key on the catalog.
+// The lock is held by a connection of its own, and PostgreSQL releases it if the server dies with it.
+//
+// @param db *sql.DB - The database connection.
+// @return func() - A function that releases the lock.
+func lockSchema(db *sql.DB) func() {
+	// ctx is the context of the lock, which lives as long as the schema changes.
+	ctx := context.Background()
+	// conn is the connection that holds the lock, since an advisory lock belongs to the session that took it.
+	conn, err := db.Conn(ctx)
+	// This checks if an error occurred while taking a connection.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to lock the schema")
 		// The application is terminated with a fatal error.
 		log.Fatal(err)
 	}
 
-	// If the ping is successful, a success message is logged.
-	log.Println("Database is healthy.")
+	// locked reports whether the lock was free.
+	var locked bool
+	// This tries to take the lock without waiting.
+	if err := conn.QueryRowContext(ctx, TrySchemaLockQuery).Scan(&locked); err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to lock the schema")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// This checks if another server holds the lock.
+	if !locked {
+		// If one does, the wait is logged, so that a server that seems stuck at startup explains itself.
+		log.Println("Waiting for another server to finish the schema changes...")
+		// This waits for the lock.
+		if _, err := conn.ExecContext(ctx, SchemaLockQuery); err != nil {
+			// If an error occurs, a message is logged.
+			log.Println("Unable to lock the schema")
+			// The application is terminated with a fatal error.
+			log.Fatal(err)
+		}
+	}
+
+	// A function that releases the lock and returns its connection to the pool is returned.
+	return func() {
+		// This releases the lock.
+		if _, err := conn.ExecContext(ctx, SchemaUnlockQuery); err != nil {
+			// If an error occurs, it is logged, and the lock is released when the connection is closed instead.
+			log.Printf("Unable to unlock the schema: %v", err)
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		// The connection is returned to the pool.
+		_ = conn.Close()
+	}
 }
 
 // createTable creates the necessary tables in the database if they do not already exist.
@@ -91,6 +167,35 @@ func createTable(db *sql.DB) {
 	// A success message is logged after the table is created.
 	log.Println("users table created successfully.")
 
+	// This is the SQL query to store the missing profile images of the users created with an empty one as NULL, so that every user without an image reads the same.
+	query = `UPDATE users SET image = NULL WHERE image = ''`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while clearing the empty images.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to clear empty user images")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+
+	// This is the SQL query to add the time zone and plan columns to the users table.
+	query = `
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC';
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS plan TEXT NOT NULL DEFAULT 'free';
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user';
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT '';
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while altering the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to add columns to users table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+
 	// This is the SQL query to create the todos table.
 	query = `
 		CREATE TABLE IF NOT EXISTS todos (
@@ -119,33 +224,1254 @@ func createTable(db *sql.DB) {
 	}
 	// A success message is logged after the table is created.
 	log.Println("todos table created successfully.")
-}
 
-// ConnectDB establishes a connection to the database.
-// It takes the application configuration as input and returns a database connection.
-//
-// @param cfg *config.Config - The application configuration.
-// @return *sql.DB - The database connection.
-func ConnectDB(cfg *config.Config) *sql.DB {
-	// connectionString is the connection string for the database.
-	connectionString := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s", cfg.Database.DBHost, cfg.Database.DBPort, cfg.Database.DBUser, cfg.Database.DBPassword, cfg.Database.DBName, cfg.Database.DBSSLMode)
+	// This is the SQL query to create the lists table.
+	query = `
+		CREATE TABLE IF NOT EXISTS lists (
+		id UUID PRIMARY KEY,
+		name TEXT NOT NULL,
+		owner UUID NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
 
-	// db is the database connection.
-	// sql.Open() opens a database specified by its database driver name and a driver-specific data source name.
-	db, err := sql.Open("postgres", connectionString)
-	// This checks if an error occurred while opening the database connection.
+		CONSTRAINT fk_owner
+			FOREIGN KEY(owner)
+			REFERENCES users(id)
+			ON DELETE CASCADE
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_lists_owner ON lists(owner);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table.
 	if err != nil {
 		// If an error occurs, a message is logged.
-		log.Println("Unable to connect with database")
+		log.Println("Unable to create lists table")
 		// The application is terminated with a fatal error.
 		log.Fatal(err)
 	}
+	// A success message is logged after the table is created.
+	log.Println("lists table created successfully.")
 
-	// PingDB() is called to check if the database connection is alive.
-	PingDB(db)
-	// createTable() is called to create the necessary tables in the database.
-	createTable(db)
+	// This is the SQL query to add the soft delete, description, priority, list, due date, and tag columns to the todos table.
+	query = `
+		ALTER TABLE todos ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+		ALTER TABLE todos ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+		ALTER TABLE todos ADD COLUMN IF NOT EXISTS priority TEXT NOT NULL DEFAULT 'none';
+		ALTER TABLE todos ADD COLUMN IF NOT EXISTS list_id UUID REFERENCES lists(id) ON DELETE SET NULL;
+		ALTER TABLE todos ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE todos ADD COLUMN IF NOT EXISTS due_at TIMESTAMPTZ;
+		ALTER TABLE todos ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+		ALTER TABLE todos ADD COLUMN IF NOT EXISTS reminded_at TIMESTAMPTZ;
 
-	// The database connection is returned.
-	return db
-}
\ No newline at end of file
+		CREATE INDEX IF NOT EXISTS idx_todos_due_at ON todos(due_at) WHERE reminded_at IS NULL AND deleted_at IS NULL;
+
+		CREATE INDEX IF NOT EXISTS idx_todos_list_id_position ON todos(list_id, position);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while altering the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to add columns to todos table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+
+	// This is the SQL query to add change tracking to the todos and lists tables.
+	// Every insert and every update a user can see takes the next value of change_seq as the row's version, which is used for ETags and conflict checks.
+	// Bookkeeping such as the reminder worker marking a todo as reminded changes no version, so clients do not sync the todo again,
+	// and a statement that sets the version itself, such as the touch of a todo whose blockers changed, keeps it.
+	// The row also records change_xid, the ID of the transaction of its last change, which the sync cursors are built on: a version is taken
+	// when the row is written but only seen once its transaction commits, so a cursor at the highest version seen would skip a change that
+	// took a lower version in a transaction still running, while a cursor below the oldest running transaction cannot. Existing rows get 0.
+	// Lists are soft deleted so that their deletion can be synced.
+	query = `
+		CREATE SEQUENCE IF NOT EXISTS change_seq;
+
+		ALTER TABLE todos ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT nextval('change_seq');
+		ALTER TABLE todos ADD COLUMN IF NOT EXISTS ical_uid TEXT;
+		ALTER TABLE todos ADD COLUMN IF NOT EXISTS change_xid BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE lists ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT nextval('change_seq');
+		ALTER TABLE lists ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+		ALTER TABLE lists ADD COLUMN IF NOT EXISTS change_xid BIGINT NOT NULL DEFAULT 0;
+
+		CREATE OR REPLACE FUNCTION bump_version() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP = 'INSERT' OR NEW.version IS DISTINCT FROM OLD.version THEN
+				NEW.change_xid := pg_current_xact_id()::text::bigint;
+			ELSIF (to_jsonb(NEW) - 'version' - 'change_xid' - 'reminded_at' - 'updated_at') IS DISTINCT FROM (to_jsonb(OLD) - 'version' - 'change_xid' - 'reminded_at' - 'updated_at') THEN
+				NEW.version := nextval('change_seq');
+				NEW.change_xid := pg_current_xact_id()::text::bigint;
+			END IF;
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS todos_bump_version ON todos;
+		CREATE TRIGGER todos_bump_version BEFORE INSERT OR UPDATE ON todos FOR EACH ROW EXECUTE FUNCTION bump_version();
+		DROP TRIGGER IF EXISTS lists_bump_version ON lists;
+		CREATE TRIGGER lists_bump_version BEFORE INSERT OR UPDATE ON lists FOR EACH ROW EXECUTE FUNCTION bump_version();
+
+		CREATE INDEX IF NOT EXISTS idx_todos_owner_version ON todos(owner, version);
+		CREATE INDEX IF NOT EXISTS idx_lists_owner_version ON lists(owner, version);
+		CREATE INDEX IF NOT EXISTS idx_todos_owner_change_xid ON todos(owner, change_xid);
+		CREATE INDEX IF NOT EXISTS idx_lists_owner_change_xid ON lists(owner, change_xid);
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_todos_owner_ical_uid ON todos(owner, ical_uid) WHERE ical_uid IS NOT NULL AND deleted_at IS NULL;
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while adding change tracking.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to add change tracking to todos and lists tables")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+
+	// This is the SQL query to add the last change time to the todos table.
+	// Existing todos are backfilled with their creation time without bumping their version, so that clients do not sync them again.
+	// The time is only touched by changes that a user can see, not by bookkeeping such as the reminder worker marking a todo as reminded.
+	query = `
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'todos' AND column_name = 'updated_at') THEN
+				ALTER TABLE todos ADD COLUMN updated_at TIMESTAMPTZ;
+				ALTER TABLE todos DISABLE TRIGGER todos_bump_version;
+				UPDATE todos SET updated_at = created_at;
+				ALTER TABLE todos ENABLE TRIGGER todos_bump_version;
+				ALTER TABLE todos ALTER COLUMN updated_at SET DEFAULT NOW(), ALTER COLUMN updated_at SET NOT NULL;
+			END IF;
+		END
+		$$;
+
+		CREATE OR REPLACE FUNCTION touch_todo_updated_at() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP = 'INSERT' THEN
+				NEW.updated_at := NEW.created_at;
+			ELSIF (to_jsonb(NEW) - 'version' - 'change_xid' - 'reminded_at' - 'updated_at') IS DISTINCT FROM (to_jsonb(OLD) - 'version' - 'change_xid' - 'reminded_at' - 'updated_at') THEN
+				NEW.updated_at := NOW();
+			END IF;
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS todos_touch_updated_at ON todos;
+		CREATE TRIGGER todos_touch_updated_at BEFORE INSERT OR UPDATE ON todos FOR EACH ROW EXECUTE FUNCTION touch_todo_updated_at();
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while adding the column.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to add updated_at to todos table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+
+	// This is the SQL query to create the todo_activities table.
+	query = `
+		CREATE TABLE IF NOT EXISTS todo_activities (
+		id UUID PRIMARY KEY,
+		todo_id UUID NOT NULL,
+		owner UUID NOT NULL,
+		action TEXT NOT NULL,
+		previous JSONB NOT NULL DEFAULT '{}',
+		undone_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+		CONSTRAINT fk_todo
+			FOREIGN KEY(todo_id)
+			REFERENCES todos(id)
+			ON DELETE CASCADE,
+		CONSTRAINT fk_owner
+			FOREIGN KEY(owner)
+			REFERENCES users(id)
+			ON DELETE CASCADE
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_todo_activities_todo_id ON todo_activities(todo_id);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create todo activities table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is created.
+	log.Println("todo_activities table created successfully.")
+
+	// This is the SQL query to create the todo_idempotency_keys table.
+	// The primary key makes a second create with the same key of the same user fail with a unique violation.
+	query = `
+		CREATE TABLE IF NOT EXISTS todo_idempotency_keys (
+		owner UUID NOT NULL,
+		key TEXT NOT NULL,
+		todo_id UUID NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+		PRIMARY KEY(owner, key),
+		CONSTRAINT fk_todo
+			FOREIGN KEY(todo_id)
+			REFERENCES todos(id)
+			ON DELETE CASCADE,
+		CONSTRAINT fk_owner
+			FOREIGN KEY(owner)
+			REFERENCES users(id)
+			ON DELETE CASCADE
+		);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create todo idempotency keys table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is created.
+	log.Println("todo_idempotency_keys table created successfully.")
+
+	// This is the SQL query to create the telegram_links table.
+	query = `
+		CREATE TABLE IF NOT EXISTS telegram_links (
+		user_id UUID PRIMARY KEY,
+		chat_id BIGINT UNIQUE,
+		link_code TEXT UNIQUE,
+		link_code_expires_at TIMESTAMPTZ,
+		linked_at TIMESTAMPTZ,
+
+		CONSTRAINT fk_user
+			FOREIGN KEY(user_id)
+			REFERENCES users(id)
+			ON DELETE CASCADE
+		);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create telegram links table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is created.
+	log.Println("telegram_links table created successfully.")
+
+	// This is the SQL query to create the slack_installations and slack_users tables.
+	query = `
+		CREATE TABLE IF NOT EXISTS slack_installations (
+		team_id TEXT PRIMARY KEY,
+		team_name TEXT NOT NULL DEFAULT '',
+		bot_token TEXT NOT NULL,
+		installed_by UUID,
+		installed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+		CONSTRAINT fk_installed_by
+			FOREIGN KEY(installed_by)
+			REFERENCES users(id)
+			ON DELETE SET NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS slack_users (
+		team_id TEXT NOT NULL,
+		slack_user_id TEXT NOT NULL,
+		user_id UUID NOT NULL,
+		linked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+		PRIMARY KEY (team_id, slack_user_id),
+		CONSTRAINT fk_team
+			FOREIGN KEY(team_id)
+			REFERENCES slack_installations(team_id)
+			ON DELETE CASCADE,
+		CONSTRAINT fk_user
+			FOREIGN KEY(user_id)
+			REFERENCES users(id)
+			ON DELETE CASCADE
+		);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the tables.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create slack tables")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the tables are created.
+	log.Println("slack tables created successfully.")
+
+	// This is the SQL query to create the notification_channels, push_devices, and web_push_subscriptions tables.
+	query = `
+		CREATE TABLE IF NOT EXISTS notification_channels (
+		user_id UUID NOT NULL,
+		channel TEXT NOT NULL,
+		enabled BOOLEAN NOT NULL,
+		target TEXT NOT NULL DEFAULT '',
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+		PRIMARY KEY (user_id, channel),
+		CONSTRAINT fk_user
+			FOREIGN KEY(user_id)
+			REFERENCES users(id)
+			ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS push_devices (
+		token TEXT PRIMARY KEY,
+		user_id UUID NOT NULL,
+		platform TEXT NOT NULL CHECK (platform IN ('fcm', 'apns')),
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+		CONSTRAINT fk_user
+			FOREIGN KEY(user_id)
+			REFERENCES users(id)
+			ON DELETE CASCADE
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_push_devices_user_id ON push_devices(user_id);
+
+		CREATE TABLE IF NOT EXISTS web_push_subscriptions (
+		endpoint TEXT PRIMARY KEY,
+		user_id UUID NOT NULL,
+		p256dh TEXT NOT NULL,
+		auth TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+		CONSTRAINT fk_user
+			FOREIGN KEY(user_id)
+			REFERENCES users(id)
+			ON DELETE CASCADE
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_web_push_subscriptions_user_id ON web_push_subscriptions(user_id);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the tables.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create notification tables")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the tables are created.
+	log.Println("notification tables created successfully.")
+
+	// This is the SQL query to create the outbox table.
+	// Events are written in the same transaction as the change they describe, and the relay publishes them in id order.
+	query = `
+		CREATE TABLE IF NOT EXISTS outbox (
+		id BIGSERIAL PRIMARY KEY,
+		event_id UUID NOT NULL UNIQUE,
+		event_type TEXT NOT NULL,
+		aggregate_id UUID NOT NULL,
+		owner UUID NOT NULL,
+		payload JSONB NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		last_error TEXT,
+		published_at TIMESTAMPTZ,
+		failed_at TIMESTAMPTZ
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (next_attempt_at, id) WHERE published_at IS NULL AND failed_at IS NULL;
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create outbox table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is created.
+	log.Println("outbox table created successfully.")
+
+	// This is the SQL query to create the api_audit table.
+	// It records the mutating requests, so that admins can investigate abuse.
+	query = `
+		CREATE TABLE IF NOT EXISTS api_audit (
+		id BIGSERIAL PRIMARY KEY,
+		method TEXT NOT NULL,
+		path TEXT NOT NULL,
+		user_id UUID,
+		ip TEXT NOT NULL,
+		status INTEGER NOT NULL,
+		latency_ms BIGINT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_api_audit_created_at ON api_audit (created_at);
+		CREATE INDEX IF NOT EXISTS idx_api_audit_user_id ON api_audit (user_id, created_at);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create api audit table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is created.
+	log.Println("api_audit table created successfully.")
+
+	// This is the SQL query to standardize the IDs of users, tokens, todos, lists, and todo activities on UUIDv7.
+	// uuid_generate_v7() gives the ID columns a default, so rows inserted without an ID still sort by creation time.
+	// The version checks are added NOT VALID, so that the version 4 IDs of rows created before the rollout stay valid
+	// while every new row must use a version 7 ID. Ordering by id is only a creation-time sort among version 7 IDs.
+	query = `
+		CREATE OR REPLACE FUNCTION uuid_generate_v7() RETURNS uuid AS $$
+			SELECT encode(set_bit(set_bit(overlay(uuid_send(gen_random_uuid())
+				PLACING substring(int8send(floor(extract(epoch FROM clock_timestamp()) * 1000)::bigint) FROM 3) FROM 1 FOR 6),
+				52, 1), 53, 1), 'hex')::uuid;
+		$$ LANGUAGE sql VOLATILE;
+
+		ALTER TABLE users ALTER COLUMN id SET DEFAULT uuid_generate_v7();
+		ALTER TABLE jwt_tokens ALTER COLUMN id SET DEFAULT uuid_generate_v7();
+		ALTER TABLE todos ALTER COLUMN id SET DEFAULT uuid_generate_v7();
+		ALTER TABLE lists ALTER COLUMN id SET DEFAULT uuid_generate_v7();
+		ALTER TABLE todo_activities ALTER COLUMN id SET DEFAULT uuid_generate_v7();
+
+		DO $$
+		DECLARE
+			name TEXT;
+		BEGIN
+			FOREACH name IN ARRAY ARRAY['users', 'jwt_tokens', 'todos', 'lists', 'todo_activities'] LOOP
+				IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = name || '_id_uuidv7') THEN
+					EXECUTE format('ALTER TABLE %I ADD CONSTRAINT %I CHECK (substr(id::text, 15, 1) = ''7'') NOT VALID', name, name || '_id_uuidv7');
+				END IF;
+			END LOOP;
+		END
+		$$;
+
+		CREATE INDEX IF NOT EXISTS idx_todos_owner_id ON todos(owner, id) WHERE deleted_at IS NULL;
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while standardizing the IDs.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to standardize IDs on UUIDv7")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the IDs are standardized.
+	log.Println("UUIDv7 ID defaults and checks created successfully.")
+
+	// This is the SQL query to create the index of the open todos by normalized title, which duplicate detection looks up on every create.
+	query = `
+		CREATE INDEX IF NOT EXISTS idx_todos_open_title ON todos(owner, lower(regexp_replace(btrim(title), '\s+', ' ', 'g'))) WHERE completed = FALSE AND deleted_at IS NULL;
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the index.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create open todo title index")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the index is created.
+	log.Println("Open todo title index created successfully.")
+
+	// This is the SQL query to add the session metadata to the jwt_tokens table.
+	// user_id is filled in for the tokens issued before it existed from the jwt column of their user.
+	query = `
+		ALTER TABLE jwt_tokens ADD COLUMN IF NOT EXISTS user_id UUID REFERENCES users(id) ON DELETE CASCADE;
+		ALTER TABLE jwt_tokens ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '';
+		ALTER TABLE jwt_tokens ADD COLUMN IF NOT EXISTS ip TEXT NOT NULL DEFAULT '';
+		ALTER TABLE jwt_tokens ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMPTZ;
+
+		UPDATE jwt_tokens SET user_id = users.id FROM users WHERE users.jwt = jwt_tokens.id AND jwt_tokens.user_id IS NULL;
+
+		CREATE INDEX IF NOT EXISTS idx_jwt_tokens_user_id ON jwt_tokens(user_id);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while altering the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to add session metadata to jwt token table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is altered.
+	log.Println("jwt_tokens session metadata created successfully.")
+
+	// This is the SQL query to create the known_devices table, which remembers the devices each user logged in from.
+	// A device is identified by a fingerprint of its User-Agent header and IP address, so that no raw address is kept twice.
+	query = `
+		CREATE TABLE IF NOT EXISTS known_devices (
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			fingerprint TEXT NOT NULL,
+			first_seen_at TIMESTAMPTZ NOT NULL,
+			last_seen_at TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (user_id, fingerprint)
+		);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create known devices table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is created.
+	log.Println("known_devices table created successfully.")
+
+	// This is the SQL query to create the bootstrap table, which records the one-time setup steps of a deployment, such as creating the first admin,
+	// so that a step is never repeated, even after the user it created is deleted or the variables that asked for it are left set.
+	query = `
+		CREATE TABLE IF NOT EXISTS bootstrap (
+			name TEXT PRIMARY KEY,
+			user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create bootstrap table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is created.
+	log.Println("bootstrap table created successfully.")
+
+	// This is the SQL query to create the login_failures table, which counts the recent failed logins of each email address.
+	// It is keyed by the address rather than the user, so that guesses against addresses without an account are slowed down too.
+	query = `
+		CREATE TABLE IF NOT EXISTS login_failures (
+			email TEXT PRIMARY KEY,
+			failures INTEGER NOT NULL,
+			last_failed_at TIMESTAMPTZ NOT NULL
+		);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create login failures table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is created.
+	log.Println("login_failures table created successfully.")
+
+	// This is the SQL query to add the kind, name, and scopes of a token to the jwt_tokens table, so that API keys live next to the sessions.
+	// A NULL scopes column means that the token may do everything its user can, which is how every session works.
+	query = `
+		ALTER TABLE jwt_tokens ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'session';
+		ALTER TABLE jwt_tokens ADD COLUMN IF NOT EXISTS name TEXT NOT NULL DEFAULT '';
+		ALTER TABLE jwt_tokens ADD COLUMN IF NOT EXISTS scopes TEXT[];
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while altering the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to add token scopes to jwt token table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is altered.
+	log.Println("jwt_tokens scopes created successfully.")
+
+	// This is the SQL query to add service accounts.
+	// A service account is a user of the service kind, so that it owns todos and lists like anyone else, and its credentials
+	// live in the service_accounts table next to the human user who created it. Deleting that user deletes the account.
+	query = `
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'human';
+
+		CREATE TABLE IF NOT EXISTS service_accounts (
+			user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			secret_hash TEXT NOT NULL,
+			scopes TEXT[] NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_service_accounts_owner_id ON service_accounts(owner_id);
+
+		ALTER TABLE api_audit ADD COLUMN IF NOT EXISTS actor_kind TEXT;
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create service account table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is created.
+	log.Println("service_accounts table created successfully.")
+
+	// This is the SQL query to create the email_changes table if it does not exist.
+	// A user has at most one pending change, so that a new request replaces the links of the one before it.
+	query = `
+		CREATE TABLE IF NOT EXISTS email_changes (
+			id UUID PRIMARY KEY,
+			user_id UUID NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+			new_email TEXT NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create email change table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is created.
+	log.Println("email_changes table created successfully.")
+
+	// This is the SQL query to add the snooze time of todos, until which their reminders are held back.
+	query = `
+		ALTER TABLE todos ADD COLUMN IF NOT EXISTS snoozed_until TIMESTAMPTZ;
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while altering the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to add snoozed_until to todos table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is altered.
+	log.Println("todos snoozed_until created successfully.")
+
+	// This is the SQL query to add the estimated effort of todos, which the planning endpoint sums per day.
+	query = `
+		ALTER TABLE todos ADD COLUMN IF NOT EXISTS estimate_minutes INTEGER CHECK (estimate_minutes > 0);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while altering the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to add estimate_minutes to todos table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is altered.
+	log.Println("todos estimate_minutes created successfully.")
+
+	// This is the SQL query to add the color and icon that users pick for their lists, and the table that holds those of their tags.
+	// Tags are not rows of their own, so a style is keyed by the user and the tag's name, and may exist before any todo uses the tag.
+	query = `
+		ALTER TABLE lists ADD COLUMN IF NOT EXISTS color TEXT;
+		ALTER TABLE lists ADD COLUMN IF NOT EXISTS icon TEXT;
+		CREATE TABLE IF NOT EXISTS tag_styles (
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			tag TEXT NOT NULL,
+			color TEXT,
+			icon TEXT,
+			PRIMARY KEY (user_id, tag)
+		);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the columns or the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to add list colors and icons or create tag_styles table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the columns and the table are created.
+	log.Println("list colors and icons and tag_styles table created successfully.")
+
+	// This is the SQL query to add the archive time of lists. The todos of an archived list are hidden from the default views.
+	query = `
+		ALTER TABLE lists ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while altering the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to add archived_at to lists table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is altered.
+	log.Println("lists archived_at created successfully.")
+
+	// This is the SQL query to add the board status of todos, which refines the completed flag.
+	// Existing completed todos are backfilled as done without bumping their version or change time, and a trigger keeps
+	// the two columns in step, so that the statements that only set completed, from sync, CalDAV, or bulk completion, still move the status.
+	query = `
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'todos' AND column_name = 'status') THEN
+				ALTER TABLE todos ADD COLUMN status TEXT NOT NULL DEFAULT 'backlog' CHECK (status IN ('backlog', 'in_progress', 'blocked', 'done'));
+				ALTER TABLE todos DISABLE TRIGGER todos_bump_version;
+				ALTER TABLE todos DISABLE TRIGGER todos_touch_updated_at;
+				UPDATE todos SET status = 'done' WHERE completed;
+				ALTER TABLE todos ENABLE TRIGGER todos_touch_updated_at;
+				ALTER TABLE todos ENABLE TRIGGER todos_bump_version;
+			END IF;
+		END
+		$$;
+
+		CREATE OR REPLACE FUNCTION sync_todo_status() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP = 'INSERT' THEN
+				IF NEW.completed THEN
+					NEW.status := 'done';
+				ELSIF NEW.status = 'done' THEN
+					NEW.completed := TRUE;
+				END IF;
+			ELSIF NEW.status IS DISTINCT FROM OLD.status THEN
+				NEW.completed := NEW.status = 'done';
+			ELSIF NEW.completed IS DISTINCT FROM OLD.completed THEN
+				NEW.status := CASE WHEN NEW.completed THEN 'done' ELSE 'backlog' END;
+			END IF;
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS todos_sync_status ON todos;
+		CREATE TRIGGER todos_sync_status BEFORE INSERT OR UPDATE ON todos FOR EACH ROW EXECUTE FUNCTION sync_todo_status();
+		CREATE INDEX IF NOT EXISTS idx_todos_owner_status ON todos(owner, status) WHERE deleted_at IS NULL;
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while adding the column.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to add status to todos table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is altered.
+	log.Println("todos status created successfully.")
+
+	// This is the SQL query to create the todo_dependencies table, where each row says that a todo cannot start before its blocker is completed.
+	// todo_blocked() tells whether a todo has an open blocker. It is part of the columns read back for todos, so every view reports it.
+	query = `
+		CREATE TABLE IF NOT EXISTS todo_dependencies (
+			todo_id UUID NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+			blocker_id UUID NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (todo_id, blocker_id),
+			CHECK (todo_id <> blocker_id)
+		);
+		CREATE INDEX IF NOT EXISTS idx_todo_dependencies_blocker ON todo_dependencies(blocker_id);
+		CREATE OR REPLACE FUNCTION todo_blocked(todo UUID) RETURNS BOOLEAN AS $$
+			SELECT EXISTS (
+				SELECT 1 FROM todo_dependencies d JOIN todos b ON b.id = d.blocker_id
+				WHERE d.todo_id = todo AND b.completed = FALSE AND b.deleted_at IS NULL
+			);
+		$$ LANGUAGE sql STABLE;
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table or the function.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create todo_dependencies table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table and the function are created.
+	log.Println("todo_dependencies table created successfully.")
+
+	// This is the SQL query to create the digest_subscriptions table, which holds the users who opted in to the weekly email digest.
+	// day is the preferred day of the week, from 0 for Sunday to 6 for Saturday, and last_sent_at keeps a digest from being sent twice in a week.
+	query = `
+		CREATE TABLE IF NOT EXISTS digest_subscriptions (
+			user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			day SMALLINT NOT NULL CHECK (day BETWEEN 0 AND 6),
+			last_sent_at TIMESTAMPTZ,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_digest_subscriptions_enabled ON digest_subscriptions(day) WHERE enabled;
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create digest subscriptions table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is created.
+	log.Println("digest_subscriptions table created successfully.")
+
+	// This is the SQL query to create the backup_jobs table, which records the backups and restores started by admins and their progress.
+	// A running job touches updated_at while it works, so that one whose server stopped can be told apart and does not block new jobs.
+	query = `
+		CREATE TABLE IF NOT EXISTS backup_jobs (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+			kind TEXT NOT NULL CHECK (kind IN ('backup', 'restore')),
+			status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed')),
+			storage_key TEXT NOT NULL,
+			target_database TEXT,
+			progress TEXT NOT NULL DEFAULT '',
+			error TEXT,
+			created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			finished_at TIMESTAMPTZ
+		);
+		CREATE INDEX IF NOT EXISTS idx_backup_jobs_running ON backup_jobs(updated_at) WHERE status = 'running';
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create backup jobs table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is created.
+	log.Println("backup_jobs table created successfully.")
+
+	// This is the SQL query to create the todo_archive table, which holds the todos that were completed long ago, out of the way of the todos table.
+	// A todo is moved there with the time it was completed, and the partial index on todos finds the todos that are due to be moved.
+	query = `
+		CREATE TABLE IF NOT EXISTS todo_archive (
+			id UUID PRIMARY KEY,
+			title TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			priority TEXT NOT NULL DEFAULT 'none',
+			owner UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			list_id UUID REFERENCES lists(id) ON DELETE SET NULL,
+			due_at TIMESTAMPTZ,
+			tags TEXT[] NOT NULL DEFAULT '{}',
+			estimate_minutes INTEGER,
+			created_at TIMESTAMPTZ NOT NULL,
+			completed_at TIMESTAMPTZ NOT NULL,
+			archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_todo_archive_owner_completed_at ON todo_archive(owner, completed_at DESC, id DESC);
+		CREATE INDEX IF NOT EXISTS idx_todos_archivable ON todos(updated_at) WHERE completed AND deleted_at IS NULL;
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create todo archive table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is created.
+	log.Println("todo_archive table created successfully.")
+
+	// This is the SQL query to create the todo_counts table, which holds the number of live todos of each user by list and completion,
+	// so that a list can report its total without counting the rows of the todos table. A trigger moves the counts in the transaction of every write,
+	// including the completions made by a status change, the deletes cascaded from lists and users, and the moves to the archive. Todos without a list are counted under the zero UUID.
+	// The counts are filled from the todos table when the table is created, with the todos table locked against writes until the trigger is in place.
+	query = `
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_name = 'todo_counts') THEN
+				CREATE TABLE todo_counts (
+					owner UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+					list_id UUID NOT NULL,
+					completed BOOLEAN NOT NULL,
+					count BIGINT NOT NULL DEFAULT 0,
+					PRIMARY KEY (owner, list_id, completed)
+				);
+				LOCK TABLE todos IN SHARE ROW EXCLUSIVE MODE;
+				INSERT INTO todo_counts (owner, list_id, completed, count)
+					SELECT owner, COALESCE(list_id, '00000000-0000-0000-0000-000000000000'), completed, COUNT(*) FROM todos WHERE deleted_at IS NULL GROUP BY 1, 2, 3;
+			END IF;
+		END
+		$$;
+
+		CREATE OR REPLACE FUNCTION count_todos() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP = 'UPDATE' AND (NEW.owner, NEW.list_id, NEW.completed, NEW.deleted_at IS NULL) IS NOT DISTINCT FROM (OLD.owner, OLD.list_id, OLD.completed, OLD.deleted_at IS NULL) THEN
+				RETURN NULL;
+			END IF;
+			IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.deleted_at IS NULL THEN
+				UPDATE todo_counts SET count = count - 1
+				WHERE owner = OLD.owner AND list_id = COALESCE(OLD.list_id, '00000000-0000-0000-0000-000000000000') AND completed = OLD.completed;
+			END IF;
+			IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.deleted_at IS NULL THEN
+				INSERT INTO todo_counts (owner, list_id, completed, count)
+				VALUES (NEW.owner, COALESCE(NEW.list_id, '00000000-0000-0000-0000-000000000000'), NEW.completed, 1)
+				ON CONFLICT (owner, list_id, completed) DO UPDATE SET count = todo_counts.count + 1;
+			END IF;
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS todos_count ON todos;
+		CREATE TRIGGER todos_count AFTER INSERT OR UPDATE OR DELETE ON todos FOR EACH ROW EXECUTE FUNCTION count_todos();
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table or the trigger.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create todo counts table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table and the trigger are created.
+	log.Println("todo_counts table created successfully.")
+
+	// This is the SQL query to create the tables of the schema change helpers.
+	// A backfill keeps the ID of the last row of its last batch as its checkpoint, and a toggle turns a dual write on while a schema change is rolled out.
+	query = `
+		CREATE TABLE IF NOT EXISTS schema_backfills (
+			name TEXT PRIMARY KEY,
+			checkpoint UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000',
+			rows_done BIGINT NOT NULL DEFAULT 0,
+			started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			finished_at TIMESTAMPTZ
+		);
+		CREATE TABLE IF NOT EXISTS schema_toggles (
+			name TEXT PRIMARY KEY,
+			enabled BOOLEAN NOT NULL DEFAULT FALSE,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the tables.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create schema change tables")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the tables are created.
+	log.Println("schema_backfills and schema_toggles tables created successfully.")
+
+	// This is the SQL query to bind tokens to a device. A guest is a user of the guest kind on the guest plan, and their token
+	// only works from the device that started the session, which is told apart by the hash of the ID it sends with each request.
+	query = `
+		ALTER TABLE jwt_tokens ADD COLUMN IF NOT EXISTS device_hash TEXT;
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while altering the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to alter jwt_tokens table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is altered.
+	log.Println("jwt_tokens device_hash created successfully.")
+
+	// This is the SQL query to create the tables of single sign-on. A connection is the OpenID Connect client of a workspace,
+	// set up by an admin rather than by environment variables, and an identity links the subject a connection signs in to a user.
+	// The client secret is kept as is, since it is sent to the identity provider, and it is never returned by the API.
+	query = `
+		CREATE TABLE IF NOT EXISTS sso_connections (
+			id UUID PRIMARY KEY,
+			slug TEXT NOT NULL UNIQUE,
+			name TEXT NOT NULL,
+			issuer TEXT NOT NULL,
+			client_id TEXT NOT NULL,
+			client_secret TEXT NOT NULL,
+			email_domains TEXT[] NOT NULL DEFAULT '{}',
+			groups_claim TEXT NOT NULL DEFAULT 'groups',
+			role_mappings JSONB NOT NULL DEFAULT '{}',
+			default_role TEXT NOT NULL DEFAULT 'user',
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE TABLE IF NOT EXISTS sso_identities (
+			connection_id UUID NOT NULL REFERENCES sso_connections(id) ON DELETE CASCADE,
+			subject TEXT NOT NULL,
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			created_at TIMESTAMPTZ NOT NULL,
+			last_login_at TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (connection_id, subject)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_sso_identities_user_id ON sso_identities(user_id);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the tables.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create single sign-on tables")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the tables are created.
+	log.Println("sso_connections and sso_identities tables created successfully.")
+
+	// This is the SQL query to create the tables of SCIM provisioning. The identity provider of a connection creates, updates,
+	// and deactivates its users with the token of the connection, of which only the hash is kept. A SCIM user links a user to the
+	// connection that provisioned it, under the userName the identity provider knows it by, and a deactivated user cannot sign in.
+	query = `
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS deactivated_at TIMESTAMPTZ;
+
+		ALTER TABLE sso_connections ADD COLUMN IF NOT EXISTS scim_token_hash TEXT UNIQUE;
+
+		CREATE TABLE IF NOT EXISTS scim_users (
+			user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			connection_id UUID NOT NULL REFERENCES sso_connections(id) ON DELETE CASCADE,
+			user_name TEXT NOT NULL,
+			external_id TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL,
+			UNIQUE (connection_id, user_name)
+		);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create SCIM provisioning tables")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is created.
+	log.Println("scim_users table created successfully.")
+
+	// This is the SQL query to create the device_codes table of the device authorization grant. A device polls with its device code,
+	// of which only the hash is kept, while the user approves the short user code from a browser. The user is only set once the
+	// code is approved or denied.
+	query = `
+		CREATE TABLE IF NOT EXISTS device_codes (
+			id UUID PRIMARY KEY,
+			device_code_hash TEXT NOT NULL UNIQUE,
+			user_code TEXT NOT NULL UNIQUE,
+			client_name TEXT NOT NULL DEFAULT '',
+			user_agent TEXT NOT NULL DEFAULT '',
+			ip TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'denied')),
+			user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+			expires_at TIMESTAMPTZ NOT NULL,
+			last_polled_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_device_codes_expires_at ON device_codes(expires_at);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create device_codes table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is created.
+	log.Println("device_codes table created successfully.")
+
+	// This is the SQL query to give every JWT its own ID, the jti claim, and to remember the IDs of the JWTs that were revoked
+	// before they expired. A revoked JWT no longer has a row to be found by, so without the list a replay of it could not be
+	// told apart from a made up token. The trigger fills the list whenever a row is deleted, whichever query deletes it, and a
+	// JWT is only kept in it until it would have expired. A session can also be bound to the fingerprint of the device and
+	// the network it was issued to.
+	query = `
+		ALTER TABLE jwt_tokens ADD COLUMN IF NOT EXISTS jti UUID UNIQUE;
+		ALTER TABLE jwt_tokens ADD COLUMN IF NOT EXISTS fingerprint_hash TEXT;
+
+		CREATE TABLE IF NOT EXISTS revoked_tokens (
+			jti UUID PRIMARY KEY,
+			user_id UUID,
+			revoked_at TIMESTAMPTZ NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
+		CREATE OR REPLACE FUNCTION revoke_jti() RETURNS trigger AS $$
+		BEGIN
+			IF OLD.jti IS NOT NULL AND OLD.expires_at > NOW() THEN
+				INSERT INTO revoked_tokens (jti, user_id, revoked_at, expires_at) VALUES (OLD.jti, OLD.user_id, NOW(), OLD.expires_at) ON CONFLICT (jti) DO NOTHING;
+			END IF;
+			RETURN OLD;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS jwt_tokens_revoke_jti ON jwt_tokens;
+		CREATE TRIGGER jwt_tokens_revoke_jti AFTER DELETE ON jwt_tokens FOR EACH ROW EXECUTE FUNCTION revoke_jti();
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create revoked_tokens table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is created.
+	log.Println("revoked_tokens table and jwt_tokens jti created successfully.")
+
+	// This is the SQL query to create the todo_tombstones table, which records the todos that leave the todos table for good, such as
+	// when they are moved to the archive, so that syncing clients and CalDAV devices are told they are gone. A tombstone takes a version
+	// and the ID of its transaction like a change of a todo, and keeps the CalDAV resource name the todo was known by.
+	query = `
+		CREATE TABLE IF NOT EXISTS todo_tombstones (
+			id UUID PRIMARY KEY,
+			owner UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			name TEXT NOT NULL,
+			version BIGINT NOT NULL DEFAULT nextval('change_seq'),
+			change_xid BIGINT NOT NULL DEFAULT pg_current_xact_id()::text::bigint,
+			deleted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_todo_tombstones_owner_change_xid ON todo_tombstones(owner, change_xid);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create todo_tombstones table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is created.
+	log.Println("todo_tombstones table created successfully.")
+
+	// This is the SQL query to create the attachments table, which records who uploaded each image in the storage backend, so that
+	// only its owner is served it, and its size in bytes and pixels, so that its usage is counted and its resized sizes are known
+	// without reading it.
+	query = `
+		CREATE TABLE IF NOT EXISTS attachments (
+			id UUID PRIMARY KEY,
+			owner UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			content_type TEXT NOT NULL,
+			size BIGINT NOT NULL,
+			width INTEGER NOT NULL,
+			height INTEGER NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_attachments_owner ON attachments(owner);
+	`
+	// db.Exec() executes a query without returning any rows.
+	_, err = db.Exec(query)
+	// This checks if an error occurred while creating the table.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to create attachments table")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+	// A success message is logged after the table is created.
+	log.Println("attachments table created successfully.")
+}
+
+// ConnectionString returns the connection string of the PostgreSQL driver for a database configuration.
+// A host that is an absolute directory connects through the Unix socket in it, and the certificate files are only added when they are set.
+//
+// @param cfg config.DatabaseConfig - The database configuration.
+// @return string - The connection string.
+func ConnectionString(cfg config.DatabaseConfig) string {
+	// The settings are written as key='value' pairs, quoted so that a password from DATABASE_URL may hold spaces and quotes.
+	settings := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		quoteSetting(cfg.DBHost), cfg.DBPort, quoteSetting(cfg.DBUser), quoteSetting(cfg.DBPassword), quoteSetting(cfg.DBName), quoteSetting(cfg.DBSSLMode))
+	// This checks if the certificate authorities of the server are set.
+	if cfg.DBSSLRootCert != "" {
+		// If they are, the certificate of the server is verified with them.
+		settings += " sslrootcert=" + quoteSetting(cfg.DBSSLRootCert)
+	}
+	// This checks if a client certificate is set.
+	if cfg.DBSSLCert != "" {
+		// If it is, the application authenticates with it and its key.
+		settings += " sslcert=" + quoteSetting(cfg.DBSSLCert) + " sslkey=" + quoteSetting(cfg.DBSSLKey)
+	}
+	// The connection string is returned.
+	return settings
+}
+
+// quoteSetting quotes a value of a connection string, escaping its backslashes and single quotes.
+//
+// @param value string - The value.
+// @return string - The quoted value.
+func quoteSetting(value string) string {
+	// The value is escaped and wrapped in single quotes.
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// ConnectDB establishes a connection to the database.
+// It takes the application configuration as input and returns a database connection.
+//
+// @param cfg *config.Config - The application configuration.
+// @return *sql.DB - The database connection.
+func ConnectDB(cfg *config.Config) *sql.DB {
+	// connectionString is the connection string for the database.
+	connectionString := ConnectionString(cfg.Database)
+	// The slow queries are logged above the configured threshold.
+	slowQueries.threshold = cfg.Database.SlowQueryThreshold
+	// The connections of the pool are checked, and the database pinged by the monitor, every check interval.
+	monitor.interval = cfg.Database.HealthCheckInterval
+
+	// connector is the connector of the PostgreSQL driver.
+	// pq.NewConnector() parses the connection string.
+	connector, err := pq.NewConnector(connectionString)
+	// This checks if an error occurred while parsing the connection string.
+	if err != nil {
+		// If an error occurs, a message is logged.
+		log.Println("Unable to connect with database")
+		// The application is terminated with a fatal error.
+		log.Fatal(err)
+	}
+
+	// db is the database connection.
+	// sql.OpenDB() opens a database whose connections go through the circuit breaker of the database.
+	db := sql.OpenDB(&breakerConnector{
+		// The connector field is set to the connector of the PostgreSQL driver.
+		connector: connector,
+		// The breaker field is set to a new breaker, opened by consecutive failures of the database.
+		breaker: breaker.New("database", cfg.Database.BreakerThreshold, cfg.Database.BreakerCooldown, clock.System{}),
+		// The slow field is set to the log of the slow queries.
+		slow: slowQueries,
+		// The health field is set to the health monitor, which the connection failures are reported to.
+		health: monitor,
+	})
+
+	// PingDB() is called to check if the database connection is alive.
+	if err := PingDB(context.Background(), db); err != nil {
+		// If the ping fails, a message is logged.
+		log.Println("Unable to ping database")
+		// The application is terminated with a fatal error, since it cannot run without the database.
+		log.Fatal(err)
+	}
+	// If the ping is successful, a success message is logged.
+	log.Println("Database is healthy.")
+	// The schema is locked while the tables are created, so that only one of the servers that start together changes it at a time.
+	unlockSchema := lockSchema(db)
+	// createTable() is called to create the necessary tables in the database. A server that waited finds them created, and changes nothing.
+	createTable(db)
+	// The lock is released for the next server.
+	unlockSchema()
+
+	// The database connection is returned.
+	return db
+}
@@ -0,0 +1,176 @@
+// This file defines a driver wrapper that counts the statements that go through it, so that a test can catch an endpoint
+// that runs one query per row of its page, an N+1, by checking that its number of queries does not grow with the page.
+package dbtest
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here by the context-aware driver methods.
+import (
+	"context"
+	// "database/sql/driver" defines the interfaces of SQL drivers. It is used here to wrap another driver.
+	"database/sql/driver"
+	// "sync/atomic" provides atomic operations. It is used here to count the statements of concurrent connections.
+	"sync/atomic"
+)
+
+// CountingDriver is a driver that counts the statements run through another driver. Beginning, committing, and rolling back
+// transactions are not counted, since they do not grow with the rows an endpoint reads.
+type CountingDriver struct {
+	// driver is the wrapped driver.
+	driver driver.Driver
+	// count is the number of statements run.
+	count atomic.Int64
+}
+
+// NewCountingDriver wraps a driver in a counting driver.
+//
+// @param d driver.Driver - The wrapped driver.
+// @return *CountingDriver - The counting driver.
+func NewCountingDriver(d driver.Driver) *CountingDriver {
+	// The counting driver is returned.
+	return &CountingDriver{driver: d}
+}
+
+// Count returns the number of statements run since the driver was created or last reset.
+//
+// @return int64 - The number of statements.
+func (d *CountingDriver) Count() int64 {
+	// The count is returned.
+	return d.count.Load()
+}
+
+// Reset sets the number of statements back to zero, such as after the statements of setting a test up.
+func (d *CountingDriver) Reset() {
+	// The count is reset.
+	d.count.Store(0)
+}
+
+// Open opens a connection of the wrapped driver and wraps it.
+//
+// @param name string - The data source name.
+// @return driver.Conn - The counting connection.
+// @return error - An error if one occurred.
+func (d *CountingDriver) Open(name string) (driver.Conn, error) {
+	// conn is the connection of the wrapped driver.
+	conn, err := d.driver.Open(name)
+	// This checks if an error occurred while opening the connection.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// The wrapped connection is returned.
+	return &countingConn{Conn: conn, count: &d.count}, nil
+}
+
+// countingConn is a connection that counts the statements run on it.
+type countingConn struct {
+	// Conn is the wrapped connection.
+	driver.Conn
+	// count is the count of the driver.
+	count *atomic.Int64
+}
+
+// QueryContext counts and runs a query, or asks database/sql to prepare it if the wrapped connection cannot run it directly.
+//
+// @param ctx context.Context - The context of the query.
+// @param query string - The query.
+// @param args []driver.NamedValue - The arguments of the query.
+// @return driver.Rows - The rows.
+// @return error - An error if one occurred, or driver.ErrSkip.
+func (c *countingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	// queryer is the wrapped connection, if it runs queries directly.
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	// This checks if it does not.
+	if !ok {
+		// If it does not, the query is prepared and counted when it runs.
+		return nil, driver.ErrSkip
+	}
+	// The query is counted.
+	c.count.Add(1)
+	// The query is run by the wrapped connection.
+	return queryer.QueryContext(ctx, query, args)
+}
+
+// ExecContext counts and runs a statement, or asks database/sql to prepare it if the wrapped connection cannot run it directly.
+//
+// @param ctx context.Context - The context of the statement.
+// @param query string - The statement.
+// @param args []driver.NamedValue - The arguments of the statement.
+// @return driver.Result - The result.
+// @return error - An error if one occurred, or driver.ErrSkip.
+func (c *countingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	// execer is the wrapped connection, if it runs statements directly.
+	execer, ok := c.Conn.(driver.ExecerContext)
+	// This checks if it does not.
+	if !ok {
+		// If it does not, the statement is prepared and counted when it runs.
+		return nil, driver.ErrSkip
+	}
+	// The statement is counted.
+	c.count.Add(1)
+	// The statement is run by the wrapped connection.
+	return execer.ExecContext(ctx, query, args)
+}
+
+// Prepare prepares a statement of the wrapped connection, which is counted each time it runs.
+//
+// @param query string - The statement.
+// @return driver.Stmt - The counting statement.
+// @return error - An error if one occurred.
+func (c *countingConn) Prepare(query string) (driver.Stmt, error) {
+	// stmt is the statement of the wrapped connection.
+	stmt, err := c.Conn.Prepare(query)
+	// This checks if an error occurred while preparing the statement.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// The wrapped statement is returned.
+	return &countingStmt{Stmt: stmt, count: c.count}, nil
+}
+
+// BeginTx begins a transaction of the wrapped connection, with its options if it takes them.
+//
+// @param ctx context.Context - The context of the transaction.
+// @param opts driver.TxOptions - The options of the transaction.
+// @return driver.Tx - The transaction.
+// @return error - An error if one occurred.
+func (c *countingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	// This checks if the wrapped connection takes the options of a transaction.
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		// If it does, the transaction is begun with them.
+		return beginner.BeginTx(ctx, opts)
+	}
+	// Otherwise the transaction is begun without them.
+	return c.Conn.Begin() //nolint:staticcheck // The fallback of drivers without BeginTx.
+}
+
+// countingStmt is a prepared statement that is counted each time it runs.
+type countingStmt struct {
+	// Stmt is the wrapped statement.
+	driver.Stmt
+	// count is the count of the driver.
+	count *atomic.Int64
+}
+
+// Exec counts and runs the statement.
+//
+// @param args []driver.Value - The arguments of the statement.
+// @return driver.Result - The result.
+// @return error - An error if one occurred.
+func (s *countingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	// The statement is counted.
+	s.count.Add(1)
+	// The statement is run by the wrapped statement.
+	return s.Stmt.Exec(args) //nolint:staticcheck // The statement is only prepared for drivers without ExecerContext.
+}
+
+// Query counts and runs the query.
+//
+// @param args []driver.Value - The arguments of the query.
+// @return driver.Rows - The rows.
+// @return error - An error if one occurred.
+func (s *countingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	// The query is counted.
+	s.count.Add(1)
+	// The query is run by the wrapped statement.
+	return s.Stmt.Query(args) //nolint:staticcheck // The statement is only prepared for drivers without QueryerContext.
+}
@@ -0,0 +1,332 @@
+// This file defines a fake SQL driver for tests. Each statement the code under test runs is answered by a handler registered
+// for its exact text, which the code takes from the constants of its sql.go, so that services and controllers can be tested
+// without a PostgreSQL server. A statement without a handler fails, so that a test notices a query it did not expect.
+package dbtest
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here by the context-aware driver methods.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to open a database over the fake driver.
+	"database/sql"
+	// "database/sql/driver" defines the interfaces of SQL drivers. It is used here to implement the fake driver.
+	"database/sql/driver"
+	// "fmt" provides functions for formatted I/O. It is used here to describe an unexpected statement.
+	"fmt"
+	// "io" provides basic I/O primitives. It is used here to end the rows.
+	"io"
+	// "strings" provides functions for working with strings. It is used here to shorten the statement in an error.
+	"strings"
+	// "sync" provides synchronization primitives. It is used here to guard the handlers, which concurrent tests share.
+	"sync"
+)
+
+// Rows is the answer of a handler: the rows of a query, or the number of rows a statement affected.
+type Rows struct {
+	// Columns are the names of the columns of the rows.
+	Columns []string
+	// Values are the rows, each with one value per column, of the types a driver returns: int64, float64, bool, []byte, string, time.Time, or nil.
+	Values [][]driver.Value
+	// RowsAffected is the number of rows an Exec reports, or -1 to report the number of Values.
+	RowsAffected int64
+}
+
+// Handler answers a statement from its arguments.
+type Handler func(args []driver.NamedValue) (Rows, error)
+
+// Driver is a fake SQL driver that answers statements with the handlers registered for them.
+type Driver struct {
+	// mu guards the handlers.
+	mu sync.Mutex
+	// handlers are the handlers, by the text of their statement.
+	handlers map[string]Handler
+}
+
+// NewDriver returns a fake driver without handlers.
+//
+// @return *Driver - The fake driver.
+func NewDriver() *Driver {
+	// The fake driver is returned.
+	return &Driver{handlers: map[string]Handler{}}
+}
+
+// Handle registers the handler of a statement, replacing the one it had.
+//
+// @param query string - The text of the statement, such as a constant of a sql.go.
+// @param handler Handler - The handler.
+func (d *Driver) Handle(query string, handler Handler) {
+	// The handlers are locked while the handler is registered.
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// The handler is registered.
+	d.handlers[query] = handler
+}
+
+// Open opens a connection to the fake database.
+//
+// @param name string - The data source name, which is ignored.
+// @return driver.Conn - The connection.
+// @return error - Always nil.
+func (d *Driver) Open(name string) (driver.Conn, error) {
+	// A new connection is returned.
+	return &fakeConn{driver: d}, nil
+}
+
+// OpenDB opens a database over a driver, such as a fake driver or a counting driver wrapping one.
+//
+// @param d driver.Driver - The driver.
+// @return *sql.DB - The database.
+func OpenDB(d driver.Driver) *sql.DB {
+	// The database is opened over a connector of the driver.
+	return sql.OpenDB(connector{driver: d})
+}
+
+// run answers a statement with its handler.
+//
+// @param query string - The text of the statement.
+// @param args []driver.NamedValue - The arguments of the statement.
+// @return Rows - The answer of the handler.
+// @return error - The error of the handler, or an error if the statement has no handler.
+func (d *Driver) run(query string, args []driver.NamedValue) (Rows, error) {
+	// The handler is looked up while the handlers are locked.
+	d.mu.Lock()
+	handler, ok := d.handlers[query]
+	d.mu.Unlock()
+	// This checks if the statement has no handler.
+	if !ok {
+		// If it has none, an error naming the statement is returned.
+		return Rows{}, fmt.Errorf("dbtest: unexpected statement: %.120s", strings.Join(strings.Fields(query), " "))
+	}
+	// The handler answers the statement.
+	return handler(args)
+}
+
+// connector opens the connections of a database over a driver.
+type connector struct {
+	// driver is the driver.
+	driver driver.Driver
+}
+
+// Connect opens a connection.
+//
+// @param ctx context.Context - The context of the caller.
+// @return driver.Conn - The connection.
+// @return error - An error if one occurred.
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
+	// The connection is opened by the driver.
+	return c.driver.Open("")
+}
+
+// Driver returns the driver.
+//
+// @return driver.Driver - The driver.
+func (c connector) Driver() driver.Driver {
+	// The driver is returned.
+	return c.driver
+}
+
+// fakeConn is a connection to the fake database.
+type fakeConn struct {
+	// driver is the fake driver that answers the statements.
+	driver *Driver
+}
+
+// QueryContext answers a query.
+//
+// @param ctx context.Context - The context of the query.
+// @param query string - The query.
+// @param args []driver.NamedValue - The arguments of the query.
+// @return driver.Rows - The rows.
+// @return error - An error if one occurred.
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	// rows is the answer of the handler.
+	rows, err := c.driver.run(query, args)
+	// This checks if the handler failed.
+	if err != nil {
+		// If it did, the error is returned.
+		return nil, err
+	}
+	// The rows are returned.
+	return &fakeRows{rows: rows}, nil
+}
+
+// ExecContext answers a statement.
+//
+// @param ctx context.Context - The context of the statement.
+// @param query string - The statement.
+// @param args []driver.NamedValue - The arguments of the statement.
+// @return driver.Result - The number of affected rows.
+// @return error - An error if one occurred.
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	// rows is the answer of the handler.
+	rows, err := c.driver.run(query, args)
+	// This checks if the handler failed.
+	if err != nil {
+		// If it did, the error is returned.
+		return nil, err
+	}
+	// This checks if the handler reports the number of its rows as affected.
+	if rows.RowsAffected < 0 {
+		// If it does, that number is returned.
+		return driver.RowsAffected(len(rows.Values)), nil
+	}
+	// The number of affected rows is returned.
+	return driver.RowsAffected(rows.RowsAffected), nil
+}
+
+// Prepare prepares a statement, which is answered by its handler when it runs.
+//
+// @param query string - The statement.
+// @return driver.Stmt - The prepared statement.
+// @return error - Always nil.
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	// The prepared statement is returned.
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+// Begin begins a transaction, which the fake database does not isolate.
+//
+// @return driver.Tx - The transaction.
+// @return error - Always nil.
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	// The transaction is returned.
+	return fakeTx{}, nil
+}
+
+// BeginTx begins a transaction, which the fake database does not isolate.
+//
+// @param ctx context.Context - The context of the transaction.
+// @param opts driver.TxOptions - The options of the transaction, which are ignored.
+// @return driver.Tx - The transaction.
+// @return error - Always nil.
+func (c *fakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	// The transaction is returned.
+	return fakeTx{}, nil
+}
+
+// Close closes the connection.
+//
+// @return error - Always nil.
+func (c *fakeConn) Close() error {
+	// There is nothing to close.
+	return nil
+}
+
+// fakeStmt is a prepared statement of the fake database.
+type fakeStmt struct {
+	// conn is the connection the statement was prepared on.
+	conn *fakeConn
+	// query is the statement.
+	query string
+}
+
+// Close closes the statement.
+//
+// @return error - Always nil.
+func (s *fakeStmt) Close() error {
+	// There is nothing to close.
+	return nil
+}
+
+// NumInput reports that the number of arguments is not checked.
+//
+// @return int - -1.
+func (s *fakeStmt) NumInput() int {
+	// The number of arguments is not checked.
+	return -1
+}
+
+// Exec runs the statement.
+//
+// @param args []driver.Value - The arguments of the statement.
+// @return driver.Result - The number of affected rows.
+// @return error - An error if one occurred.
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	// The statement is answered like an unprepared one.
+	return s.conn.ExecContext(context.Background(), s.query, named(args))
+}
+
+// Query runs the query.
+//
+// @param args []driver.Value - The arguments of the query.
+// @return driver.Rows - The rows.
+// @return error - An error if one occurred.
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	// The query is answered like an unprepared one.
+	return s.conn.QueryContext(context.Background(), s.query, named(args))
+}
+
+// named numbers arguments the way the context-aware driver methods receive them.
+//
+// @param args []driver.Value - The arguments.
+// @return []driver.NamedValue - The numbered arguments.
+func named(args []driver.Value) []driver.NamedValue {
+	// values is the numbered arguments.
+	values := make([]driver.NamedValue, len(args))
+	// This numbers the arguments from 1.
+	for i, arg := range args {
+		values[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	// The numbered arguments are returned.
+	return values
+}
+
+// fakeTx is a transaction of the fake database, whose commit and rollback do nothing.
+type fakeTx struct{}
+
+// Commit commits the transaction.
+//
+// @return error - Always nil.
+func (fakeTx) Commit() error {
+	// There is nothing to commit.
+	return nil
+}
+
+// Rollback rolls the transaction back.
+//
+// @return error - Always nil.
+func (fakeTx) Rollback() error {
+	// There is nothing to roll back.
+	return nil
+}
+
+// fakeRows reads the rows of a handler.
+type fakeRows struct {
+	// rows is the answer of the handler.
+	rows Rows
+	// next is the index of the next row.
+	next int
+}
+
+// Columns returns the names of the columns.
+//
+// @return []string - The names of the columns.
+func (r *fakeRows) Columns() []string {
+	// The names of the columns are returned.
+	return r.rows.Columns
+}
+
+// Close closes the rows.
+//
+// @return error - Always nil.
+func (r *fakeRows) Close() error {
+	// There is nothing to close.
+	return nil
+}
+
+// Next reads the next row.
+//
+// @param dest []driver.Value - The values of the row.
+// @return error - io.EOF once every row was read.
+func (r *fakeRows) Next(dest []driver.Value) error {
+	// This checks if every row was read.
+	if r.next >= len(r.rows.Values) {
+		// If every row was, io.EOF is returned.
+		return io.EOF
+	}
+	// The values of the row are copied.
+	copy(dest, r.rows.Values[r.next])
+	// The next row is moved to.
+	r.next++
+	// No error is returned.
+	return nil
+}
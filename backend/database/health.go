@@ -0,0 +1,163 @@
+// This file watches the health of the database connection. When PostgreSQL restarts or fails over, the connections of the pool go stale
+// and the first query on each of them fails, so a connection that sat idle for a check interval, or that was opened before a connection failure,
+// is pinged before it is reused and dropped if the ping fails. A monitor pings the database in the background, marks it degraded while it cannot be
+// reached, and tries again with a jittered backoff until it comes back, which /readyz reports so that load balancers route around the server.
+package database
+
+// "context" provides a way to carry cancellation signals. It is used here to stop the monitor on shutdown.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to ping the database.
+	"database/sql"
+	// "log" provides logging functions. It is used here to log when the database is lost and restored.
+	"log"
+	// "math/rand/v2" provides pseudo-random numbers. It is used here to spread the reconnection attempts of the servers.
+	"math/rand/v2"
+	// "sync" provides synchronization primitives. It is used here to guard the health of the database.
+	"sync"
+	// "sync/atomic" provides atomic values. It is used here to count the connection failures without a lock on every query.
+	"sync/atomic"
+	// "time" provides functions for working with time. It is used here to schedule the checks.
+	"time"
+)
+
+const (
+	// reconnectBaseDelay is the wait before the second reconnection attempt, doubled for every attempt after it.
+	reconnectBaseDelay = 500 * time.Millisecond
+	// reconnectMaxDelay is the longest wait between reconnection attempts.
+	reconnectMaxDelay = 30 * time.Second
+)
+
+// Health describes the state of the database connection.
+type Health struct {
+	// Degraded reports whether the last check could not reach the database.
+	Degraded bool
+	// Since is when the database became unreachable, or when it was last reached again.
+	Since time.Time
+	// LastError is the error of the last failed check, or empty if the database is reachable.
+	LastError string
+	// Attempts is the number of failed checks since the database became unreachable.
+	Attempts int
+}
+
+// healthMonitor tracks the health of the database.
+type healthMonitor struct {
+	// interval is how often the monitor pings the database, and how long a connection may sit idle before it is pinged on reuse.
+	interval time.Duration
+	// generation is increased by every connection failure, so that the connections checked before it are pinged on reuse.
+	generation atomic.Uint64
+	// wake is signalled by a connection failure, so that the monitor checks the database at once.
+	wake chan struct{}
+	// mu guards the health.
+	mu sync.Mutex
+	// health is the state of the database.
+	health Health
+}
+
+// monitor is the health monitor of the database opened by ConnectDB.
+var monitor = &healthMonitor{wake: make(chan struct{}, 1)}
+
+// failed records a connection failure. The connections of the pool are pinged before they are reused, and the monitor checks the database.
+func (m *healthMonitor) failed() {
+	// The generation is increased, which every pooled connection compares with the one it was last checked in.
+	m.generation.Add(1)
+	// This wakes the monitor, unless it is already woken.
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// record records the outcome of a check of the database.
+//
+// @param err error - The error of the check, or nil if the database was reached.
+// @return time.Duration - How long until the next check.
+func (m *healthMonitor) record(err error) time.Duration {
+	// The health is locked while it is updated.
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// now is the time of the check.
+	now := time.Now()
+	// This checks if the database was reached.
+	if err == nil {
+		// This checks if it was unreachable before.
+		if m.health.Degraded {
+			// If it was, the restore is logged, and the pooled connections are checked again since they may have been opened to the old server.
+			log.Printf("Database connection restored after %s and %d failed attempts", now.Sub(m.health.Since).Round(time.Second), m.health.Attempts)
+			m.generation.Add(1)
+			m.health = Health{Since: now}
+		}
+		// The next check is one interval away.
+		return m.interval
+	}
+
+	// This checks if the database was reachable before.
+	if !m.health.Degraded {
+		// If it was, the loss is logged.
+		log.Printf("Database is unreachable, reconnecting: %v", err)
+		m.health = Health{Degraded: true, Since: now}
+	}
+	// The failed attempt is counted.
+	m.health.Attempts++
+	m.health.LastError = err.Error()
+	// delay is the backoff of the attempt, doubled for every failed attempt up to the longest wait.
+	delay := reconnectMaxDelay
+	// This checks if the attempt is early enough for the doubled delay to stay below the longest wait.
+	if m.health.Attempts < 16 {
+		// If it is, the delay is doubled once per attempt.
+		delay = min(reconnectBaseDelay<<(m.health.Attempts-1), reconnectMaxDelay)
+	}
+	// Half of the delay is random, so that the servers that lost the database together do not reconnect together.
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// CurrentHealth returns the state of the database connection, as the monitor last saw it.
+//
+// @return Health - The state.
+func CurrentHealth() Health {
+	// The health is locked while it is copied.
+	monitor.mu.Lock()
+	defer monitor.mu.Unlock()
+	// The health is returned.
+	return monitor.health
+}
+
+// Monitor pings the database every check interval until the context is cancelled, and right away after a connection failure.
+// While the database cannot be reached, it is marked degraded and pinged again with a jittered backoff, and each ping opens
+// a new connection once the stale ones are dropped.
+//
+// @param ctx context.Context - The context that stops the monitor.
+// @param db *sql.DB - The database connection.
+func Monitor(ctx context.Context, db *sql.DB) {
+	// timer fires when the next check is due.
+	timer := time.NewTimer(monitor.interval)
+	// This defers stopping the timer until the monitor returns.
+	defer timer.Stop()
+
+	// This loops until the context is cancelled.
+	for {
+		// This waits for the next check, a connection failure, or the cancellation.
+		select {
+		case <-ctx.Done():
+			// If the context is cancelled, the monitor returns.
+			return
+		case <-timer.C:
+		case <-monitor.wake:
+			// This checks if the database is already being reconnected.
+			if CurrentHealth().Degraded {
+				// If it is, the failure changes nothing and the backoff is kept.
+				continue
+			}
+		}
+		// err is the result of pinging the database.
+		err := PingDB(ctx, db)
+		// This checks if the ping was stopped by the shutdown.
+		if ctx.Err() != nil {
+			// If it was, the monitor returns without marking the database degraded.
+			return
+		}
+		// The outcome is recorded and the next check is scheduled.
+		timer.Reset(monitor.record(err))
+	}
+}
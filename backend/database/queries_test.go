@@ -0,0 +1,669 @@
+// This file defines a lint that keeps every SQL query of the server a constant, so that no request value can ever be spliced
+// into one. It type-checks the Go files of the module and looks at the query of every call of Exec, Query, and QueryRow of
+// database/sql, and of their Context variants. A query must be a constant expression, a variable that is only ever assigned
+// constants, or a parameter of a function whose every caller passes a constant, so that wrappers such as loadDigestItems are
+// checked at their call sites. A query built with fmt.Sprintf or + from anything that is not a constant fails the lint.
+package database
+
+// "fmt" provides functions for formatted I/O. It is used here to describe the queries that fail the lint.
+import (
+	"fmt"
+	// "go/ast" declares the types of the syntax tree of Go. It is used here to find the calls and the assignments.
+	"go/ast"
+	// "go/build" gathers information about Go packages. It is used here to leave out the files that build constraints exclude.
+	"go/build"
+	// "go/importer" provides access to the export data of the standard library. It is used here to import database/sql.
+	"go/importer"
+	// "go/parser" parses Go source files. It is used here to read the files of the module.
+	"go/parser"
+	// "go/token" defines the positions of Go source files. It is used here to report where a query is.
+	"go/token"
+	// "go/types" type-checks Go packages. It is used here to tell constants apart from variables and to resolve the calls.
+	"go/types"
+	// "os" provides a platform-independent interface to operating system functionality. It is used here to read go.mod and the directories.
+	"os"
+	// "path/filepath" provides functions for manipulating file paths. It is used here to walk the module.
+	"path/filepath"
+	// "slices" provides functions for working with slices. It is used here to sort the reports.
+	"slices"
+	// "strings" provides functions for working with strings. It is used here to read the module path and the import paths.
+	"strings"
+	// "testing" provides support for automated tests. It is used here to run the lint.
+	"testing"
+)
+
+// queryArguments are the methods of database/sql that run a query, by the index of the query among their arguments.
+var queryArguments = map[string]int{
+	"Exec":            0,
+	"Query":           0,
+	"QueryRow":        0,
+	"ExecContext":     1,
+	"QueryContext":    1,
+	"QueryRowContext": 1,
+}
+
+// TestQueriesAreConstant fails for every query of the module that is not a constant.
+//
+// @param t *testing.T - The test state.
+func TestQueriesAreConstant(t *testing.T) {
+	// root is the root of the module, two directories up from this package.
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	// This checks if the root cannot be found.
+	if err != nil {
+		// If it cannot, the test fails.
+		t.Fatal(err)
+	}
+	// gomod is the go.mod of the module, which names it.
+	gomod, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	// This checks if go.mod cannot be read.
+	if err != nil {
+		// If it cannot, the test fails.
+		t.Fatal(err)
+	}
+	// module is the path of the module.
+	var module string
+	// This looks for the module directive.
+	for _, line := range strings.Split(string(gomod), "\n") {
+		if path, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			module = strings.TrimSpace(path)
+		}
+	}
+
+	// linter type-checks the packages of the module.
+	linter := newQueryLinter(module, func(path string) (map[string]string, error) {
+		// The files of the package are read from its directory.
+		return readPackage(filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(strings.TrimPrefix(path, module), "/"))))
+	})
+	// This walks the module for its packages.
+	err = filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+		// This checks if the directory cannot be read.
+		if err != nil {
+			// If it cannot, the walk stops.
+			return err
+		}
+		// This checks if the entry is a file.
+		if !entry.IsDir() {
+			// If it is, nothing is done, since packages are read whole.
+			return nil
+		}
+		// This skips hidden directories, vendored code, and test data, which the go command leaves out too.
+		if name := entry.Name(); path != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "vendor" || name == "testdata") {
+			return filepath.SkipDir
+		}
+		// rel is the directory relative to the root.
+		rel, err := filepath.Rel(root, path)
+		// This checks if the path is outside the root.
+		if err != nil {
+			// If it is, the walk stops.
+			return err
+		}
+		// importPath is the import path of the package of the directory.
+		importPath := module
+		// This checks if the directory is below the root.
+		if rel != "." {
+			importPath += "/" + filepath.ToSlash(rel)
+		}
+		// The package of the directory, if it has one, is type-checked.
+		return linter.add(importPath)
+	})
+	// This checks if the module could not be read.
+	if err != nil {
+		// If it could not, the test fails.
+		t.Fatal(err)
+	}
+
+	// This reports every query that is not a constant.
+	for _, report := range linter.lint() {
+		t.Error(report)
+	}
+}
+
+// TestQueryLinter checks that the lint accepts the kinds of constant queries the module uses, and reports the kinds of
+// dynamic queries it exists to catch.
+//
+// @param t *testing.T - The test state.
+func TestQueryLinter(t *testing.T) {
+	// tests are the sources of a package and the number of queries of it that must fail the lint.
+	tests := []struct {
+		// name is the name of the case.
+		name string
+		// body is the body of a function with a *sql.DB named db, a context named ctx, and a string named input that is not a constant.
+		body string
+		// want is the number of reports.
+		want int
+	}{
+		{"literal", `db.Exec("DELETE FROM todos")`, 0},
+		{"constant", `db.QueryRowContext(ctx, selectQuery, input)`, 0},
+		{"constants joined with +", `db.Exec(selectQuery + " LIMIT 1")`, 0},
+		{"variable only assigned constants", "var query string\nquery = `CREATE TABLE a ()`\ndb.Exec(query)\nquery = selectQuery\ndb.Exec(query)", 0},
+		{"wrapper called with a constant", `run(ctx, db, selectQuery)`, 0},
+		{"sprintf", `db.Exec(fmt.Sprintf("DELETE FROM %s", input))`, 1},
+		{"joined with a variable", `db.QueryContext(ctx, selectQuery+input)`, 1},
+		{"variable assigned a dynamic value", "query := selectQuery\nquery = query + input\ndb.Exec(query)", 1},
+		{"wrapper called with a value that is not a constant", `run(ctx, db, fmt.Sprint(input))`, 1},
+		{"field only given constants", "b := batch{query: selectQuery}\nb.query = `DELETE FROM todos`\ndb.Exec(b.query)", 0},
+		{"field given a dynamic value", "b := batch{query: selectQuery}\nb.query += input\ndb.Exec(b.query)", 1},
+		{"quoted identifier", `db.Exec("DROP INDEX " + pq.QuoteIdentifier(input))`, 0},
+		{"transaction", "tx, _ := db.BeginTx(ctx, nil)\ntx.ExecContext(ctx, \"DELETE FROM \"+input)", 1},
+	}
+
+	// This runs every case.
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// source is a package with the body of the case.
+			source := `package example
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+const selectQuery = "SELECT id FROM todos WHERE owner = $1"
+
+var _ = pq.QuoteIdentifier
+
+type batch struct {
+	query string
+}
+
+func run(ctx context.Context, db *sql.DB, query string) {
+	db.ExecContext(ctx, query)
+}
+
+func example(ctx context.Context, db *sql.DB) {
+	input := fmt.Sprint(ctx)
+	` + tt.body + `
+}
+`
+			// linter type-checks the package.
+			linter := newQueryLinter("example.com/m", func(path string) (map[string]string, error) {
+				return map[string]string{"example.go": source}, nil
+			})
+			// This checks if the package cannot be type-checked.
+			if err := linter.add("example.com/m/example"); err != nil {
+				// If it cannot, the test fails.
+				t.Fatal(err)
+			}
+			// This checks if the lint reported another number of queries.
+			if reports := linter.lint(); len(reports) != tt.want {
+				// If it did, the test fails.
+				t.Errorf("got %d reports %q, want %d", len(reports), reports, tt.want)
+			}
+		})
+	}
+}
+
+// queryLinter type-checks the packages of a module and finds their queries that are not constants.
+type queryLinter struct {
+	// module is the path of the module, whose packages are type-checked from source.
+	module string
+	// read returns the files of a package of the module, by their names.
+	read func(path string) (map[string]string, error)
+	// fset holds the positions of the files.
+	fset *token.FileSet
+	// std imports the standard library from its export data.
+	std types.Importer
+	// packages are the type-checked packages, by their import path.
+	packages map[string]*types.Package
+	// checked are the type-checked packages of the module, in the order they were added.
+	checked []*checkedPackage
+}
+
+// checkedPackage is a type-checked package of the module.
+type checkedPackage struct {
+	// files are the files of the package.
+	files []*ast.File
+	// info is what the type checker learned of the files.
+	info *types.Info
+}
+
+// newQueryLinter returns a linter of the packages of a module.
+//
+// @param module string - The path of the module.
+// @param read func(path string) (map[string]string, error) - The function that reads the files of a package of the module.
+// @return *queryLinter - The linter.
+func newQueryLinter(module string, read func(path string) (map[string]string, error)) *queryLinter {
+	// fset holds the positions of the files.
+	fset := token.NewFileSet()
+	// The linter is returned.
+	return &queryLinter{module: module, read: read, fset: fset, std: importer.ForCompiler(fset, "gc", nil), packages: map[string]*types.Package{}}
+}
+
+// add type-checks a package of the module, if it was not yet, along with the packages of the module it imports.
+//
+// @param path string - The import path of the package.
+// @return error - An error if the files of the package cannot be read or parsed.
+func (l *queryLinter) add(path string) error {
+	// This checks if the package was type-checked already.
+	if _, ok := l.packages[path]; ok {
+		// If it was, nothing is done.
+		return nil
+	}
+	// sources are the files of the package.
+	sources, err := l.read(path)
+	// This checks if the files cannot be read, or the directory has none.
+	if err != nil || len(sources) == 0 {
+		// If they cannot, the error, if any, is returned.
+		return err
+	}
+	// names are the names of the files, sorted so that the type checker sees them in a stable order.
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	// files are the parsed files.
+	files := make([]*ast.File, 0, len(names))
+	// This parses the files.
+	for _, name := range names {
+		// file is the parsed file.
+		file, err := parser.ParseFile(l.fset, filepath.Join(filepath.FromSlash(path), name), sources[name], parser.SkipObjectResolution)
+		// This checks if the file cannot be parsed.
+		if err != nil {
+			// If it cannot, the error is returned.
+			return err
+		}
+		files = append(files, file)
+	}
+
+	// info is what the type checker learns of the files.
+	info := &types.Info{Types: map[ast.Expr]types.TypeAndValue{}, Defs: map[*ast.Ident]types.Object{}, Uses: map[*ast.Ident]types.Object{}}
+	// config checks the package with its imports resolved by the linter. Errors are ignored, since the packages outside
+	// the module and the standard library are not loaded; what they hold is never a query of the module.
+	config := types.Config{Importer: l, Error: func(error) {}}
+	// The package is type-checked.
+	pkg, _ := config.Check(path, l.fset, files, info)
+	// The package is remembered.
+	l.packages[path] = pkg
+	l.checked = append(l.checked, &checkedPackage{files: files, info: info})
+	// No error is returned.
+	return nil
+}
+
+// Import imports a package for the type checker: a package of the module from its source, a package of the standard library
+// from its export data, and any other package as an empty one.
+//
+// @param path string - The import path.
+// @return *types.Package - The package.
+// @return error - Always nil, since a package that cannot be imported is imported empty.
+func (l *queryLinter) Import(path string) (*types.Package, error) {
+	// This checks if the package is one of the module.
+	if path == l.module || strings.HasPrefix(path, l.module+"/") {
+		// If it is, it is type-checked from source.
+		if err := l.add(path); err == nil && l.packages[path] != nil {
+			// If it could be, it is returned.
+			return l.packages[path], nil
+		}
+	} else if !strings.Contains(strings.Split(path, "/")[0], ".") {
+		// If it is a package of the standard library, it is imported from its export data.
+		if pkg, err := l.std.Import(path); err == nil {
+			// If it could be, it is returned.
+			return pkg, nil
+		}
+	}
+	// name is the name the package is most likely imported under, skipping a version suffix such as v2.
+	elements := strings.Split(path, "/")
+	name := elements[len(elements)-1]
+	if len(elements) > 1 && strings.HasPrefix(name, "v") && strings.Trim(name[1:], "0123456789") == "" {
+		name = elements[len(elements)-2]
+	}
+	// pkg is an empty package in place of the one that cannot be imported.
+	pkg := types.NewPackage(path, name)
+	pkg.MarkComplete()
+	// The empty package is returned.
+	return pkg, nil
+}
+
+// facts are what the lint knows of the values of the variables and fields of the module.
+type facts struct {
+	// assigned are the values assigned to each variable and field.
+	assigned map[*types.Var][]ast.Expr
+	// dynamic are the variables and fields that are assigned in ways the lint cannot follow, such as from a call returning
+	// several values, or through their address.
+	dynamic map[*types.Var]bool
+	// params are the parameters of the declared functions, with their function and their index.
+	params map[*types.Var]param
+}
+
+// param is a parameter of a declared function.
+type param struct {
+	// fn is the function.
+	fn *types.Func
+	// index is the index of the parameter.
+	index int
+}
+
+// lint returns a report of every query of the added packages that is not a constant.
+//
+// @return []string - The reports, sorted by position.
+func (l *queryLinter) lint() []string {
+	// facts are collected from every package first, since a field may be assigned in another package than the one that runs its query.
+	facts := facts{assigned: map[*types.Var][]ast.Expr{}, dynamic: map[*types.Var]bool{}, params: map[*types.Var]param{}}
+	for _, pkg := range l.checked {
+		l.collect(pkg, facts)
+	}
+	// sinks are the wrappers that pass a parameter on as a query, by the indexes of those parameters. The methods of
+	// database/sql are recognized by their package, so it starts empty.
+	sinks := map[*types.Func]map[int]bool{}
+	// This repeats the lint until it finds no new wrapper, since a wrapper may be called by another one.
+	for {
+		// count is the number of parameters of wrappers found before this round.
+		count := countSinks(sinks)
+		// reports are the reports of this round.
+		var reports []string
+		// This lints every package.
+		for _, pkg := range l.checked {
+			reports = append(reports, l.lintPackage(pkg, facts, sinks)...)
+		}
+		// This checks if no new wrapper was found.
+		if countSinks(sinks) == count {
+			// If none was, the reports are returned in order.
+			slices.Sort(reports)
+			return reports
+		}
+	}
+}
+
+// countSinks returns the number of parameters of wrappers that are passed on as queries.
+//
+// @param sinks map[*types.Func]map[int]bool - The wrappers.
+// @return int - The number of parameters.
+func countSinks(sinks map[*types.Func]map[int]bool) int {
+	// count is the number of parameters.
+	count := 0
+	for _, indexes := range sinks {
+		count += len(indexes)
+	}
+	return count
+}
+
+// collect adds the assignments and the parameters of a package to the facts.
+//
+// @param pkg *checkedPackage - The package.
+// @param facts facts - The facts.
+func (l *queryLinter) collect(pkg *checkedPackage, facts facts) {
+	for _, file := range pkg.files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			switch node := node.(type) {
+			// A function declaration has parameters that its callers give.
+			case *ast.FuncDecl:
+				// fn is the declared function.
+				fn, _ := pkg.info.Defs[node.Name].(*types.Func)
+				// index is the index of the next parameter.
+				index := 0
+				for _, field := range node.Type.Params.List {
+					for _, name := range field.Names {
+						if v, ok := pkg.info.Defs[name].(*types.Var); ok && fn != nil {
+							facts.params[v] = param{fn: fn, index: index}
+						}
+						index++
+					}
+					// An unnamed parameter still takes an index.
+					if len(field.Names) == 0 {
+						index++
+					}
+				}
+			// An assignment gives a value to each variable or field on its left, if it has as many values as variables.
+			case *ast.AssignStmt:
+				for i, lhs := range node.Lhs {
+					if v := l.variable(pkg.info, lhs); v != nil {
+						if len(node.Rhs) == len(node.Lhs) && node.Tok != token.ADD_ASSIGN {
+							facts.assigned[v] = append(facts.assigned[v], node.Rhs[i])
+						} else {
+							facts.dynamic[v] = true
+						}
+					}
+				}
+			// A declaration gives a value to each variable it names, or the zero value, which is the constant "" for a string.
+			case *ast.ValueSpec:
+				for i, name := range node.Names {
+					if v, ok := pkg.info.Defs[name].(*types.Var); ok {
+						if len(node.Values) == len(node.Names) {
+							facts.assigned[v] = append(facts.assigned[v], node.Values[i])
+						} else if len(node.Values) != 0 {
+							facts.dynamic[v] = true
+						}
+					}
+				}
+			// A struct literal gives a value to each field it sets.
+			case *ast.CompositeLit:
+				// fields are the fields of the struct, if the literal is one.
+				fields, _ := types.Unalias(pkg.info.Types[node].Type).Underlying().(*types.Struct)
+				if fields == nil {
+					break
+				}
+				for i, elt := range node.Elts {
+					// This checks if the field is named.
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						if key, ok := kv.Key.(*ast.Ident); ok {
+							if v, ok := pkg.info.Uses[key].(*types.Var); ok {
+								facts.assigned[v] = append(facts.assigned[v], kv.Value)
+							}
+						}
+					} else if i < fields.NumFields() {
+						// Otherwise the field is the one at the position of the value.
+						facts.assigned[fields.Field(i)] = append(facts.assigned[fields.Field(i)], elt)
+					}
+				}
+			// A variable or field whose address is taken can be assigned anywhere.
+			case *ast.UnaryExpr:
+				if v := l.variable(pkg.info, node.X); v != nil && node.Op == token.AND {
+					facts.dynamic[v] = true
+				}
+			}
+			return true
+		})
+	}
+}
+
+// lintPackage reports the queries of a package that are not constants, and adds the functions that pass a parameter on as a query to the sinks.
+//
+// @param pkg *checkedPackage - The package.
+// @param facts facts - What the lint knows of the variables and fields of the module.
+// @param sinks map[*types.Func]map[int]bool - The wrappers that pass a parameter on as a query, by the indexes of those parameters.
+// @return []string - The reports.
+func (l *queryLinter) lintPackage(pkg *checkedPackage, facts facts, sinks map[*types.Func]map[int]bool) []string {
+	// constant reports whether an expression is always a constant, and if not, why.
+	var constant func(expr ast.Expr, seen map[*types.Var]bool) (bool, string)
+	constant = func(expr ast.Expr, seen map[*types.Var]bool) (bool, string) {
+		// This checks if the type checker computed the value of the expression.
+		if pkg.info.Types[expr].Value != nil {
+			// If it did, it is a constant.
+			return true, ""
+		}
+		// v is the variable or field the expression names, if it names one.
+		var v *types.Var
+		// This looks at what the expression is.
+		switch expr := ast.Unparen(expr).(type) {
+		// An identifier may name a variable, or a parameter, which is constant if every caller passes a constant.
+		case *ast.Ident:
+			v, _ = pkg.info.Uses[expr].(*types.Var)
+			// This checks if the variable is a parameter of a declared function.
+			if p, ok := facts.params[v]; ok && v != nil {
+				// If it is, the function becomes a sink whose callers are linted.
+				if sinks[p.fn] == nil {
+					sinks[p.fn] = map[int]bool{}
+				}
+				sinks[p.fn][p.index] = true
+				return true, ""
+			}
+		// A selector may name a field.
+		case *ast.SelectorExpr:
+			v, _ = pkg.info.Uses[expr.Sel].(*types.Var)
+		// A call is not a constant, unless it quotes its argument.
+		case *ast.CallExpr:
+			if sel, ok := expr.Fun.(*ast.SelectorExpr); ok {
+				// This checks if the call is of fmt.Sprintf, the usual way SQL injection is written.
+				if sel.Sel.Name == "Sprintf" {
+					return false, "is built with fmt.Sprintf"
+				}
+				// This checks if the call is of pq.QuoteIdentifier or pq.QuoteLiteral, which quote what they are given so that it
+				// can only ever be one identifier or one literal. They are the only way to put a name in a statement such as
+				// CREATE INDEX, which takes no parameters.
+				if x, ok := sel.X.(*ast.Ident); ok && (sel.Sel.Name == "QuoteIdentifier" || sel.Sel.Name == "QuoteLiteral") {
+					if name, ok := pkg.info.Uses[x].(*types.PkgName); ok && name.Imported().Path() == "github.com/lib/pq" {
+						return true, ""
+					}
+				}
+			}
+		// An addition is constant if each of its operands is.
+		case *ast.BinaryExpr:
+			if expr.Op == token.ADD {
+				for _, operand := range []ast.Expr{expr.X, expr.Y} {
+					if ok, _ := constant(operand, seen); !ok {
+						return false, "is built with + from something that is not a constant"
+					}
+				}
+				return true, ""
+			}
+		}
+		// This checks if the expression names a variable or field that is only assigned in ways the lint can follow.
+		if v == nil || facts.dynamic[v] {
+			// If it does not, it is not a constant.
+			return false, "is not a constant"
+		}
+		// This checks if the variable is being checked already, which means its values refer to it.
+		if seen[v] {
+			return false, fmt.Sprintf("%s is built from itself", v.Name())
+		}
+		seen[v] = true
+		defer delete(seen, v)
+		// This checks every value of the variable.
+		for _, value := range facts.assigned[v] {
+			if ok, why := constant(value, seen); !ok {
+				return false, fmt.Sprintf("%s is assigned a query that %s", v.Name(), why)
+			}
+		}
+		// Every value is a constant.
+		return true, ""
+	}
+
+	// reports are the reports of the package.
+	var reports []string
+	// This looks at the query of every call that runs one.
+	for _, file := range pkg.files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			// call is the call, if the node is one.
+			call, ok := node.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			// This checks every argument of the call that is a query.
+			for _, index := range l.queryIndexes(pkg.info, call, sinks) {
+				// This checks if the query is not a constant.
+				if ok, why := constant(call.Args[index], map[*types.Var]bool{}); !ok {
+					// If it is not, it is reported.
+					reports = append(reports, fmt.Sprintf("%s: the query of %s %s", l.fset.Position(call.Args[index].Pos()), types.ExprString(call.Fun), why))
+				}
+			}
+			return true
+		})
+	}
+	// The reports are returned.
+	return reports
+}
+
+// queryIndexes returns the indexes of the arguments of a call that are queries.
+//
+// @param info *types.Info - What the type checker learned of the file of the call.
+// @param call *ast.CallExpr - The call.
+// @param sinks map[*types.Func]map[int]bool - The wrappers that pass a parameter on as a query, by the indexes of those parameters.
+// @return []int - The indexes, or none if the call runs no query.
+func (l *queryLinter) queryIndexes(info *types.Info, call *ast.CallExpr, sinks map[*types.Func]map[int]bool) []int {
+	// name is the identifier of the called function.
+	var name *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		name = fun
+	case *ast.SelectorExpr:
+		name = fun.Sel
+	default:
+		return nil
+	}
+	// fn is the called function.
+	fn, ok := info.Uses[name].(*types.Func)
+	if !ok {
+		return nil
+	}
+	fn = fn.Origin()
+	// indexes are the indexes of the queries.
+	var indexes []int
+	// This checks if the function is a method of database/sql that runs a query.
+	if index, ok := queryArguments[fn.Name()]; ok && fn.Pkg() != nil && fn.Pkg().Path() == "database/sql" {
+		indexes = append(indexes, index)
+	}
+	// This adds the parameters the function passes on as queries, if it is a wrapper.
+	for index := range sinks[fn] {
+		indexes = append(indexes, index)
+	}
+	// The indexes of the arguments the call has are returned.
+	return slices.DeleteFunc(indexes, func(index int) bool { return index >= len(call.Args) })
+}
+
+// variable returns the variable or field an expression names, if it names one.
+//
+// @param info *types.Info - What the type checker learned of the file of the expression.
+// @param expr ast.Expr - The expression.
+// @return *types.Var - The variable or field, or nil.
+func (l *queryLinter) variable(info *types.Info, expr ast.Expr) *types.Var {
+	// ident is the identifier of the variable, or of the field if the expression selects one.
+	var ident *ast.Ident
+	switch expr := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		ident = expr
+	case *ast.SelectorExpr:
+		ident = expr.Sel
+	default:
+		return nil
+	}
+	// This checks if the identifier defines the variable, as the left of := does.
+	if v, ok := info.Defs[ident].(*types.Var); ok {
+		return v
+	}
+	// Otherwise the identifier uses it.
+	v, _ := info.Uses[ident].(*types.Var)
+	return v
+}
+
+// readPackage reads the Go files of a directory that the build constraints include, leaving out the tests.
+//
+// @param dir string - The directory.
+// @return map[string]string - The files, by their names.
+// @return error - An error if the directory cannot be read.
+func readPackage(dir string) (map[string]string, error) {
+	// entries are the entries of the directory.
+	entries, err := os.ReadDir(dir)
+	// This checks if the directory cannot be read.
+	if err != nil {
+		// If it cannot, the error is returned.
+		return nil, err
+	}
+	// sources are the files.
+	sources := map[string]string{}
+	// This reads the files.
+	for _, entry := range entries {
+		// name is the name of the entry.
+		name := entry.Name()
+		// This skips the directories, the tests, and the files that are not Go.
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		// This skips the files the build constraints exclude.
+		if ok, err := build.Default.MatchFile(dir, name); err != nil || !ok {
+			continue
+		}
+		// source is the content of the file.
+		source, err := os.ReadFile(filepath.Join(dir, name))
+		// This checks if the file cannot be read.
+		if err != nil {
+			// If it cannot, the error is returned.
+			return nil, err
+		}
+		sources[name] = string(source)
+	}
+	// The files are returned.
+	return sources, nil
+}
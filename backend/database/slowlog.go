@@ -0,0 +1,131 @@
+// This file watches the duration of the queries that go through the database driver. A query slower than the configured threshold
+// is logged without its arguments, which may hold personal data or secrets, and counted by statement, so that an admin can see which
+// statements are slow most often without turning on the logs of the database.
+package database
+
+// "log" provides logging functions. It is used here to log the slow queries.
+import (
+	"log"
+	// "sort" provides functions for sorting slices. It is used here to rank the slow statements.
+	"sort"
+	// "strings" provides functions for working with strings. It is used here to collapse the whitespace of the statements.
+	"strings"
+	// "sync" provides synchronization primitives. It is used here to guard the counts of the slow statements.
+	"sync"
+	// "time" provides functions for working with time. It is used here to time the queries.
+	"time"
+)
+
+// maxSlowStatements is the number of distinct statements whose counts are kept.
+// The statements are constants of the code, so the limit is only reached if a statement is built with changing text;
+// the slow queries of statements beyond it are still logged and counted in the total.
+const maxSlowStatements = 1000
+
+// SlowQuery holds the counts of a statement that was slower than the threshold.
+type SlowQuery struct {
+	// Query is the statement, with its whitespace collapsed and without its arguments.
+	Query string
+	// Count is the number of times the statement was slow.
+	Count int64
+	// Total is the sum of the durations of the slow runs.
+	Total time.Duration
+	// Max is the duration of the slowest run.
+	Max time.Duration
+	// LastSeen is when the statement was last slow.
+	LastSeen time.Time
+}
+
+// slowQueryLog counts the slow queries of the server since it started.
+type slowQueryLog struct {
+	// threshold is the duration above which a query is slow, or 0 to not watch queries.
+	threshold time.Duration
+	// mu guards the counts.
+	mu sync.Mutex
+	// total is the number of slow queries.
+	total int64
+	// statements holds the counts of each slow statement, by statement.
+	statements map[string]*SlowQuery
+}
+
+// slowQueries is the log of the slow queries of the database opened by ConnectDB.
+var slowQueries = &slowQueryLog{statements: map[string]*SlowQuery{}}
+
+// observe records the duration of a query, and logs and counts it if it is slower than the threshold.
+//
+// @param query string - The statement.
+// @param args int - The number of arguments of the statement, which are not logged.
+// @param elapsed time.Duration - How long the database took to answer.
+func (l *slowQueryLog) observe(query string, args int, elapsed time.Duration) {
+	// This checks if queries are not watched, or if the query was fast enough.
+	if l.threshold <= 0 || elapsed < l.threshold {
+		// If so, nothing is recorded.
+		return
+	}
+	// statement is the statement on one line, so that multi-line statements are logged and counted alike.
+	statement := strings.Join(strings.Fields(query), " ")
+	// The query is logged with the number of its arguments in place of their values.
+	log.Printf("Slow query took %s with %d redacted arguments: %s", elapsed.Round(time.Millisecond), args, statement)
+
+	// The counts are locked while they are updated.
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// The query is counted in the total.
+	l.total++
+	// entry is the counts of the statement, if it was slow before.
+	entry, ok := l.statements[statement]
+	// This checks if the statement was not slow before.
+	if !ok {
+		// This checks if the counts of no more statements are kept.
+		if len(l.statements) >= maxSlowStatements {
+			// If so, the query is only counted in the total.
+			return
+		}
+		// Otherwise the statement gets its counts.
+		entry = &SlowQuery{Query: statement}
+		l.statements[statement] = entry
+	}
+	// The run is added to the counts of the statement.
+	entry.Count++
+	entry.Total += elapsed
+	entry.Max = max(entry.Max, elapsed)
+	entry.LastSeen = time.Now()
+}
+
+// SlowQueries returns the number of slow queries since the server started, and the statements that took the most time
+// in slow runs altogether, so that a statement that is often a little slow ranks above one that was very slow once.
+//
+// @param limit int - The number of statements to return.
+// @return int64 - The number of slow queries.
+// @return []SlowQuery - The statements, slowest first.
+func SlowQueries(limit int) (int64, []SlowQuery) {
+	// The counts are locked while they are copied.
+	slowQueries.mu.Lock()
+	// statements holds a copy of the counts, so that they are sorted outside of the lock.
+	statements := make([]SlowQuery, 0, len(slowQueries.statements))
+	// This iterates over the counts.
+	for _, entry := range slowQueries.statements {
+		// The counts are copied.
+		statements = append(statements, *entry)
+	}
+	// total is the number of slow queries.
+	total := slowQueries.total
+	slowQueries.mu.Unlock()
+
+	// The statements are sorted by their total time, then by their text so that ties keep their order.
+	sort.Slice(statements, func(i, j int) bool {
+		// This checks if the two statements took the same time.
+		if statements[i].Total == statements[j].Total {
+			// If they did, they are ordered by their text.
+			return statements[i].Query < statements[j].Query
+		}
+		// Otherwise the slower one comes first.
+		return statements[i].Total > statements[j].Total
+	})
+	// This checks if there are more statements than asked for.
+	if len(statements) > limit {
+		// If there are, the rest are left out.
+		statements = statements[:limit]
+	}
+	// The total and the statements are returned.
+	return total, statements
+}
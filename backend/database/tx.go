@@ -0,0 +1,97 @@
+// This file provides a helper for running database operations inside a transaction.
+package database
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to bind the transaction to the deadline of the caller.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to begin, commit, and roll back transactions.
+	"database/sql"
+	// "math/rand/v2" provides pseudo-random numbers. It is used here to spread the retries of concurrent transactions apart.
+	"math/rand/v2"
+	// "time" provides functions for working with time. It is used here to wait between retries.
+	"time"
+
+	// "github.com/rahulcodepython/todo-backend/backend/dberr" is a local package that classifies database errors.
+	"github.com/rahulcodepython/todo-backend/backend/dberr"
+)
+
+// maxTxAttempts is the number of times a transaction is run before a serialization failure or a deadlock is returned.
+const maxTxAttempts = 3
+
+// txRetryDelay is the base delay before a retry, doubled on every further attempt.
+const txRetryDelay = 20 * time.Millisecond
+
+// WithTx runs the given function inside a database transaction.
+// The transaction is committed if the function returns nil and rolled back otherwise.
+// It is also rolled back if the context is cancelled or its deadline passes before the commit.
+// Errors of the database are classified, so that callers can test them with errors.Is against the kinds of the dberr package.
+// A transaction aborted by a serialization failure or a deadlock is run again, up to maxTxAttempts times in total,
+// so the function must not have effects outside the transaction.
+//
+// @param ctx context.Context - The context of the caller, which carries its deadline.
+// @param db *sql.DB - The database connection.
+// @param fn func(tx *sql.Tx) error - The function to be run inside the transaction.
+// @return error - An error if one occurred.
+func WithTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	// err is the error of the latest attempt.
+	var err error
+	// This runs the transaction until it succeeds, fails for good, or runs out of attempts.
+	for attempt := 1; ; attempt++ {
+		// The transaction is run once.
+		err = runTx(ctx, db, fn)
+		// This checks if the attempt succeeded, cannot succeed on a retry, or was the last one.
+		if !dberr.Retryable(err) || attempt == maxTxAttempts {
+			// If so, its error is returned.
+			return err
+		}
+		// delay is the backoff before the next attempt, with jitter so that the conflicting transactions do not collide again.
+		delay := txRetryDelay<<(attempt-1) + rand.N(txRetryDelay)
+		// This waits for the delay, unless the caller gives up first.
+		select {
+		case <-ctx.Done():
+			// If the caller gives up, the error of the attempt is returned.
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// WithTxOnce runs the given function inside a database transaction like WithTx, but only once, returning a serialization
+// failure or a deadlock instead of running the function again. It is meant for functions with effects outside the
+// transaction, such as publishing events, which a retry would repeat.
+//
+// @param ctx context.Context - The context of the caller, which carries its deadline.
+// @param db *sql.DB - The database connection.
+// @param fn func(tx *sql.Tx) error - The function to be run inside the transaction.
+// @return error - An error if one occurred.
+func WithTxOnce(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	// The transaction is run once.
+	return runTx(ctx, db, fn)
+}
+
+// runTx runs the given function inside one database transaction.
+//
+// @param ctx context.Context - The context of the caller.
+// @param db *sql.DB - The database connection.
+// @param fn func(tx *sql.Tx) error - The function to be run inside the transaction.
+// @return error - The classified error, if one occurred.
+func runTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	// tx is the new transaction.
+	tx, err := db.BeginTx(ctx, nil)
+	// This checks if an error occurred while beginning the transaction.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return dberr.Classify(err)
+	}
+
+	// This runs the function inside the transaction.
+	if err := fn(tx); err != nil {
+		// If the function fails, the transaction is rolled back.
+		_ = tx.Rollback()
+		// The error from the function is returned.
+		return dberr.Classify(err)
+	}
+
+	// The transaction is committed and any commit error is returned, since a serializable transaction can fail at the commit.
+	return dberr.Classify(tx.Commit())
+}
@@ -0,0 +1,142 @@
+// This file classifies the errors of PostgreSQL into the kinds the application handles,
+// so that a rejected constraint is told apart from a failure of the database by errors.Is rather than by SQLSTATE codes.
+package dberr
+
+// "errors" provides functions for working with errors. It is used here to define the kinds and unwrap the driver error.
+import (
+	"errors"
+
+	// "github.com/lib/pq" is the PostgreSQL driver. It is used here to read the SQLSTATE code of an error.
+	"github.com/lib/pq"
+)
+
+// ErrUniqueViolation is the kind of an insert or update rejected by a unique index.
+var ErrUniqueViolation = errors.New("unique violation")
+
+// ErrForeignKeyViolation is the kind of a write that references a missing row, or deletes a row that is still referenced.
+var ErrForeignKeyViolation = errors.New("foreign key violation")
+
+// ErrCheckViolation is the kind of a write rejected by a check constraint.
+var ErrCheckViolation = errors.New("check violation")
+
+// ErrSerializationFailure is the kind of a transaction aborted because it conflicted with a concurrent one. It may be retried.
+var ErrSerializationFailure = errors.New("serialization failure")
+
+// ErrDeadlock is the kind of a transaction aborted to break a deadlock. It may be retried.
+var ErrDeadlock = errors.New("deadlock detected")
+
+// kinds maps the SQLSTATE codes to their kinds.
+var kinds = map[pq.ErrorCode]error{
+	// 23505 is unique_violation.
+	"23505": ErrUniqueViolation,
+	// 23503 is foreign_key_violation.
+	"23503": ErrForeignKeyViolation,
+	// 23514 is check_violation.
+	"23514": ErrCheckViolation,
+	// 40001 is serialization_failure.
+	"40001": ErrSerializationFailure,
+	// 40P01 is deadlock_detected.
+	"40P01": ErrDeadlock,
+}
+
+// Error is an error of the database with a known kind.
+type Error struct {
+	// Kind is one of the kinds of this package.
+	Kind error
+	// Constraint is the name of the violated constraint, or empty if the error is not a violation.
+	Constraint string
+	// Err is the error of the driver.
+	Err *pq.Error
+}
+
+// Error returns the message of the driver.
+//
+// @return string - The message.
+func (e *Error) Error() string {
+	// The message of the driver is returned.
+	return e.Err.Error()
+}
+
+// Is reports whether the error is of a kind, so that errors.Is(err, dberr.ErrUniqueViolation) works.
+//
+// @param target error - The kind.
+// @return bool - True if the error is of the kind.
+func (e *Error) Is(target error) bool {
+	// The error matches its own kind.
+	return target == e.Kind
+}
+
+// Unwrap returns the error of the driver.
+//
+// @return error - The error of the driver.
+func (e *Error) Unwrap() error {
+	// The error of the driver is returned.
+	return e.Err
+}
+
+// Classify wraps an error of the database in an *Error if its SQLSTATE code has a known kind.
+// Any other error, including nil and an error that is already classified, is returned unchanged.
+//
+// @param err error - The error.
+// @return error - The classified error.
+func Classify(err error) error {
+	// classified is the classified error, if the error already is one.
+	var classified *Error
+	// This checks if the error is already classified.
+	if errors.As(err, &classified) {
+		// If it is, it is returned unchanged.
+		return err
+	}
+	// pqErr is the error of the driver, if the error is one.
+	var pqErr *pq.Error
+	// This checks if the error is not an error of the driver.
+	if !errors.As(err, &pqErr) {
+		// If it is not, it is returned unchanged.
+		return err
+	}
+	// kind is the kind of the code.
+	kind, ok := kinds[pqErr.Code]
+	// This checks if the code has no known kind.
+	if !ok {
+		// If it has none, the error is returned unchanged.
+		return err
+	}
+	// The classified error is returned.
+	return &Error{Kind: kind, Constraint: pqErr.Constraint, Err: pqErr}
+}
+
+// Is reports whether an error of the database is of a kind, classifying it first.
+//
+// @param err error - The error.
+// @param kind error - The kind.
+// @return bool - True if the error is of the kind.
+func Is(err error, kind error) bool {
+	// The classified error is compared with the kind.
+	return errors.Is(Classify(err), kind)
+}
+
+// Constraint returns the name of the constraint an error violated.
+//
+// @param err error - The error.
+// @return string - The name of the constraint, or empty if the error is not a violation.
+func Constraint(err error) string {
+	// classified is the classified error, if the error is one.
+	var classified *Error
+	// This checks if the error is classified.
+	if errors.As(Classify(err), &classified) {
+		// If it is, its constraint is returned.
+		return classified.Constraint
+	}
+	// Otherwise no constraint is returned.
+	return ""
+}
+
+// Retryable reports whether an error aborted a transaction that may succeed if it is run again,
+// which is the case of a serialization failure and of a deadlock.
+//
+// @param err error - The error.
+// @return bool - True if the transaction may be retried.
+func Retryable(err error) bool {
+	// The error is retryable if it is of either kind.
+	return Is(err, ErrSerializationFailure) || Is(err, ErrDeadlock)
+}
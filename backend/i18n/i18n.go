@@ -0,0 +1,207 @@
+// This file defines the message catalogs and the locale negotiation used to translate API responses.
+// The catalogs are keyed by the English message, so English needs no catalog and a missing translation falls back to English.
+package i18n
+
+// "embed" provides access to files embedded in the binary. It is used here to ship the message catalogs.
+import (
+	"embed"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to load the message catalogs.
+	"encoding/json"
+	// "fmt" provides functions for formatted I/O. It is used here to fill in translated messages.
+	"fmt"
+	// "log" provides a simple logging package. It is used here to report a malformed catalog.
+	"log"
+	// "sort" provides functions for sorting. It is used here to rank the languages of the Accept-Language header.
+	"sort"
+	// "strconv" provides functions for converting strings. It is used here to parse quality values.
+	"strconv"
+	// "strings" provides functions for working with strings. It is used here to parse the Accept-Language header.
+	"strings"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to read the locale of a request.
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultLocale is the locale used when a client asks for none of the supported locales.
+const DefaultLocale = "en"
+
+// localesFS holds the message catalogs, one JSON file per locale.
+//
+//go:embed locales/*.json
+var localesFS embed.FS
+
+// catalogs maps a locale to its catalog, which maps an English message to its translation.
+var catalogs = loadCatalogs()
+
+// loadCatalogs reads the embedded message catalogs.
+//
+// @return map[string]map[string]string - The catalogs by locale.
+func loadCatalogs() map[string]map[string]string {
+	// entries is the list of catalog files.
+	entries, err := localesFS.ReadDir("locales")
+	// This checks if an error occurred while listing the catalogs.
+	if err != nil {
+		// If an error occurs, a fatal error is logged.
+		log.Fatalf("Unable to read message catalogs: %v", err)
+	}
+
+	// loaded is the map of catalogs, which always includes English.
+	loaded := map[string]map[string]string{DefaultLocale: {}}
+	// This iterates over the catalog files.
+	for _, entry := range entries {
+		// data is the content of the catalog file.
+		data, err := localesFS.ReadFile("locales/" + entry.Name())
+		// This checks if an error occurred while reading the file.
+		if err != nil {
+			// If an error occurs, a fatal error is logged.
+			log.Fatalf("Unable to read message catalog %s: %v", entry.Name(), err)
+		}
+		// catalog is the decoded catalog.
+		catalog := map[string]string{}
+		// This decodes the catalog.
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			// If an error occurs, a fatal error is logged.
+			log.Fatalf("Unable to parse message catalog %s: %v", entry.Name(), err)
+		}
+		// The catalog is stored under the file name without the extension.
+		loaded[strings.TrimSuffix(entry.Name(), ".json")] = catalog
+	}
+	// The catalogs are returned.
+	return loaded
+}
+
+// Supported reports whether a locale has a catalog.
+//
+// @param locale string - The locale, such as "es".
+// @return bool - True if the locale is supported.
+func Supported(locale string) bool {
+	// ok reports whether the locale has a catalog.
+	_, ok := catalogs[locale]
+	// The result is returned.
+	return ok
+}
+
+// Negotiate picks the best supported locale from an Accept-Language header, such as "fr-CA,fr;q=0.9,en;q=0.8".
+// Regional variants match their base language, and the default locale is returned when nothing matches.
+//
+// @param header string - The Accept-Language header.
+// @return string - The negotiated locale.
+func Negotiate(header string) string {
+	// candidate is a language of the header with its quality.
+	type candidate struct {
+		// tag is the language tag.
+		tag string
+		// quality is the preference of the language, from 0 to 1.
+		quality float64
+	}
+
+	// candidates is the list of languages of the header.
+	var candidates []candidate
+	// This iterates over the comma-separated languages.
+	for _, part := range strings.Split(header, ",") {
+		// fields is the language tag followed by its parameters.
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		// tag is the language tag in lower case.
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		// This checks if the tag is empty.
+		if tag == "" {
+			// If it is, the language is skipped.
+			continue
+		}
+		// quality defaults to 1 when it is not given.
+		quality := 1.0
+		// This iterates over the parameters of the language.
+		for _, param := range fields[1:] {
+			// This checks if the parameter is the quality.
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				// parsed is the parsed quality.
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					// The quality is set.
+					quality = parsed
+				}
+			}
+		}
+		// The language is appended to the candidates.
+		candidates = append(candidates, candidate{tag: tag, quality: quality})
+	}
+
+	// The candidates are sorted by quality, keeping the header order for equal qualities.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		// The candidate with the higher quality comes first.
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	// This iterates over the candidates in order of preference.
+	for _, candidate := range candidates {
+		// This checks if the language was refused.
+		if candidate.quality <= 0 {
+			// If it was, the remaining languages are refused too.
+			break
+		}
+		// base is the language without its region, such as "fr" for "fr-ca".
+		base, _, _ := strings.Cut(candidate.tag, "-")
+		// This checks if the language is supported.
+		if Supported(candidate.tag) {
+			// If it is, it is returned.
+			return candidate.tag
+		}
+		// This checks if the base of the language is supported.
+		if Supported(base) {
+			// If it is, the base is returned.
+			return base
+		}
+	}
+	// The default locale is returned when nothing matches.
+	return DefaultLocale
+}
+
+// Translate returns the translation of an English message in a locale, or the message itself if there is none.
+//
+// @param locale string - The locale.
+// @param message string - The English message.
+// @return string - The translated message.
+func Translate(locale, message string) string {
+	// This checks if the catalog has a translation.
+	if translated, ok := catalogs[locale][message]; ok && translated != "" {
+		// If it has, the translation is returned.
+		return translated
+	}
+	// Otherwise the English message is returned.
+	return message
+}
+
+// Locale returns the locale of a request, as set by the Locale middleware, or the default locale.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return string - The locale of the request.
+func Locale(c *fiber.Ctx) string {
+	// This checks if a locale was set for the request.
+	if locale, ok := c.Locals("locale").(string); ok && locale != "" {
+		// If one was, it is returned.
+		return locale
+	}
+	// Otherwise the default locale is returned.
+	return DefaultLocale
+}
+
+// T translates an English message into the locale of a request.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param message string - The English message.
+// @return string - The translated message.
+func T(c *fiber.Ctx, message string) string {
+	// The message is translated into the locale of the request.
+	return Translate(Locale(c), message)
+}
+
+// Sprintf translates an English format string into the locale of a request and fills it in.
+// It is used for messages that carry values, so that the catalog has one entry for every value.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param format string - The English format string.
+// @param args ...any - The values of the message.
+// @return string - The translated message.
+func Sprintf(c *fiber.Ctx, format string, args ...any) string {
+	// The format string is translated and filled in.
+	return fmt.Sprintf(T(c, format), args...)
+}
@@ -0,0 +1,68 @@
+// This file defines the generator that services create new IDs with.
+// Services take an IDGenerator instead of calling uuid.NewV7() directly, so that IDs can be predicted in a test.
+package idgen
+
+// "sync" provides synchronization primitives. It is used here to let concurrent requests share a sequence.
+import (
+	"sync"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to generate the IDs.
+	"github.com/google/uuid"
+)
+
+// IDGenerator creates new IDs.
+type IDGenerator interface {
+	// NewID returns a new, unique ID.
+	NewID() uuid.UUID
+}
+
+// UUIDv7 is the IDGenerator that creates time-ordered version 7 UUIDs.
+type UUIDv7 struct{}
+
+// NewID returns a new version 7 UUID.
+// It panics only if the system's random number generator fails.
+//
+// @return uuid.UUID - The new ID.
+func (UUIDv7) NewID() uuid.UUID {
+	// A new version 7 UUID is returned.
+	return uuid.Must(uuid.NewV7())
+}
+
+// IsUUIDv7 reports whether an ID is a version 7 UUID.
+// The ID tables only accept version 7 IDs for new rows, so IDs chosen by clients are checked with it first.
+//
+// @param id uuid.UUID - The ID.
+// @return bool - True if the ID is a version 7 UUID.
+func IsUUIDv7(id uuid.UUID) bool {
+	// The version is read from the ID.
+	return id.Version() == 7
+}
+
+// Sequence is an IDGenerator that returns a fixed list of IDs in order, and the nil UUID once the list is used up.
+// It may be shared by concurrent requests, which each take IDs of their own.
+type Sequence struct {
+	// mu guards the IDs, so that two concurrent requests never take the same one.
+	mu sync.Mutex
+	// IDs are the IDs that are left to be returned.
+	IDs []uuid.UUID
+}
+
+// NewID returns the next ID of the sequence.
+//
+// @return uuid.UUID - The next ID, or the nil UUID if none is left.
+func (s *Sequence) NewID() uuid.UUID {
+	// The IDs are locked while the next one is taken.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// This checks if the sequence is used up.
+	if len(s.IDs) == 0 {
+		// If it is, the nil UUID is returned.
+		return uuid.Nil
+	}
+	// id is the next ID.
+	id := s.IDs[0]
+	// The ID is removed from the sequence.
+	s.IDs = s.IDs[1:]
+	// The ID is returned.
+	return id
+}
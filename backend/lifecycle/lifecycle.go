@@ -0,0 +1,138 @@
+// This file runs the shutdown of the application. The parts of the application register a hook for what they must do before
+// the process exits, such as draining the HTTP server, stopping the workers, and closing the database, and the hooks run one after
+// another in the order they were registered once a shutdown signal arrives. The whole sequence shares one deadline, so that a hook
+// that hangs cannot keep the process from exiting before the orchestrator kills it, and each step is logged as a key=value line.
+package lifecycle
+
+// "context" provides a way to carry deadlines. It is used here to bound the shutdown.
+import (
+	"context"
+	// "errors" provides functions for working with errors. It is used here to define the error of a missed deadline.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to name the hook that missed the deadline.
+	"fmt"
+	// "log" provides logging functions. It is used here to log the steps of the shutdown.
+	"log"
+	// "os" provides a platform-independent interface to operating system functionality. It is used here to receive the shutdown signals.
+	"os"
+	// "os/signal" provides functions for handling incoming signals. It is used here to wait for a shutdown signal.
+	"os/signal"
+	// "sync" provides synchronization primitives. It is used here to guard the hooks.
+	"sync"
+	// "time" provides functions for working with time. It is used here to time the hooks.
+	"time"
+)
+
+// ErrDeadlineExceeded is returned by Shutdown when a hook was still running at the deadline, and the hooks after it were skipped.
+var ErrDeadlineExceeded = errors.New("shutdown deadline exceeded")
+
+// Hook is a step of the shutdown.
+type Hook struct {
+	// Name identifies the hook in the logs.
+	Name string
+	// Run performs the step. It should return once its context is done.
+	Run func(ctx context.Context) error
+}
+
+// Lifecycle holds the shutdown hooks of the application.
+type Lifecycle struct {
+	// timeout is the deadline of the whole shutdown.
+	timeout time.Duration
+	// logger writes the steps of the shutdown.
+	logger *log.Logger
+	// mu guards the hooks.
+	mu sync.Mutex
+	// hooks are the registered hooks, in the order they run.
+	hooks []Hook
+}
+
+// New creates a new Lifecycle.
+//
+// @param timeout time.Duration - The deadline of the whole shutdown.
+// @return *Lifecycle - A pointer to the new Lifecycle.
+func New(timeout time.Duration) *Lifecycle {
+	// A new Lifecycle is returned, whose logger writes where the standard logger does.
+	return &Lifecycle{timeout: timeout, logger: log.New(log.Writer(), "component=lifecycle ", log.Flags()|log.Lmsgprefix)}
+}
+
+// OnShutdown registers a hook that runs after the hooks registered before it.
+//
+// @param name string - The name of the hook.
+// @param run func(ctx context.Context) error - The step, which is given the context of the deadline.
+func (l *Lifecycle) OnShutdown(name string, run func(ctx context.Context) error) {
+	// The hooks are locked while the hook is appended.
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// The hook is appended.
+	l.hooks = append(l.hooks, Hook{Name: name, Run: run})
+}
+
+// Wait blocks until the process receives one of the signals.
+//
+// @param signals ...os.Signal - The signals that start the shutdown, such as os.Interrupt and syscall.SIGTERM.
+// @return os.Signal - The signal that was received.
+func (l *Lifecycle) Wait(signals ...os.Signal) os.Signal {
+	// c is a channel that receives the signals, with a buffer so that a signal sent before the receive is not lost.
+	c := make(chan os.Signal, 1)
+	// The channel is registered for the signals, and released once one is received.
+	signal.Notify(c, signals...)
+	defer signal.Stop(c)
+	// received is the signal.
+	received := <-c
+	// The signal is logged.
+	l.logger.Printf("event=signal signal=%q", received.String())
+	// The signal is returned.
+	return received
+}
+
+// Shutdown runs the hooks in order within the deadline. A hook that fails is logged and the next one runs;
+// a hook that is still running at the deadline is left behind, and the hooks after it are skipped.
+//
+// @return error - ErrDeadlineExceeded if the deadline passed, or nil.
+func (l *Lifecycle) Shutdown() error {
+	// hooks are the hooks, copied so that a hook may register another without a deadlock.
+	l.mu.Lock()
+	hooks := append([]Hook(nil), l.hooks...)
+	l.mu.Unlock()
+
+	// ctx is the context of the deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	// This defers releasing the context until the shutdown returns.
+	defer cancel()
+	// started is the start of the shutdown.
+	started := time.Now()
+	// The start is logged.
+	l.logger.Printf("event=shutdown_started hooks=%d timeout=%s", len(hooks), l.timeout)
+
+	// This iterates over the hooks.
+	for i, hook := range hooks {
+		// begun is the start of the hook.
+		begun := time.Now()
+		// done receives the error of the hook.
+		done := make(chan error, 1)
+		// The hook runs in the background, so that the deadline is kept even if it ignores its context.
+		go func() { done <- hook.Run(ctx) }()
+		// This waits for the hook or the deadline.
+		select {
+		case err := <-done:
+			// This checks if the hook failed.
+			if err != nil {
+				// If it did, the failure is logged and the shutdown goes on.
+				l.logger.Printf("event=hook_failed hook=%q duration=%s error=%q", hook.Name, time.Since(begun).Round(time.Millisecond), err.Error())
+				continue
+			}
+			// The hook is logged.
+			l.logger.Printf("event=hook_done hook=%q duration=%s", hook.Name, time.Since(begun).Round(time.Millisecond))
+		case <-ctx.Done():
+			// The hook that missed the deadline and the skipped ones are logged.
+			l.logger.Printf("event=deadline_exceeded hook=%q skipped=%d duration=%s", hook.Name, len(hooks)-i-1, time.Since(started).Round(time.Millisecond))
+			// The error names the hook.
+			return fmt.Errorf("%w while running %s", ErrDeadlineExceeded, hook.Name)
+		}
+	}
+
+	// The end is logged.
+	l.logger.Printf("event=shutdown_complete duration=%s", time.Since(started).Round(time.Millisecond))
+	// No error is returned.
+	return nil
+}
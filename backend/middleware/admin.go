@@ -0,0 +1,49 @@
+// This file defines middleware for restricting routes to admins.
+package middleware
+
+// "database/sql" provides a generic SQL interface. It is used here to query the user's role.
+import (
+	"database/sql"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to create middleware.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models and queries.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+)
+
+// AdminUser is a middleware that only lets users with the admin role through.
+// It should be used after the AuthenticatedUser middleware.
+// The role is read on every request, so that revoking it takes effect immediately.
+//
+// @param db *sql.DB - The database connection.
+// @return fiber.Handler - The Fiber handler.
+func AdminUser(db *sql.DB) fiber.Handler {
+	// This returns a new Fiber handler.
+	return func(c *fiber.Ctx) error {
+		// user is the User object retrieved from the local context.
+		user, err := users.CurrentUser(c)
+		// This checks if there is no authenticated user.
+		if err != nil {
+			// If there is not, it returns an unauthorized access response.
+			return response.UnauthorizedAccess(c, err, "Authentication required")
+		}
+
+		// role is the role of the user.
+		var role string
+		// This queries the role of the user.
+		if err := db.QueryRowContext(c.UserContext(), users.GetUserRoleQuery, user.ID).Scan(&role); err != nil {
+			// If an error occurs, it returns an internal server error response.
+			return response.InternelServerError(c, err, "Error fetching user role")
+		}
+		// This checks if the user is not an admin.
+		if role != users.RoleAdmin {
+			// If the user is not, it returns a forbidden response.
+			return response.Forbidden(c, "Admin access required")
+		}
+
+		// c.Next() calls the next middleware in the chain.
+		return c.Next()
+	}
+}
@@ -0,0 +1,91 @@
+// This file defines a middleware for recording mutating requests in the audit log.
+package middleware
+
+// "database/sql" provides a generic SQL interface. It is used here to write the audit entries.
+import (
+	"database/sql"
+	// "errors" provides functions for working with errors. It is used here to read the status of a returned Fiber error.
+	"errors"
+	// "log" provides a simple logging package. It is used here to log entries that could not be recorded.
+	"log"
+	// "time" provides functions for working with time. It is used here to measure the latency.
+	"time"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to create middleware.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to hold the optional user ID.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/apps/audit" is a local package that contains the audit queries.
+	"github.com/rahulcodepython/todo-backend/apps/audit"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+// Audit is a middleware that records the method, path, user, status, and latency of every mutating request in the audit log.
+// It should be applied to the whole app; the user is read after the request is handled, once the route's middlewares have set it.
+// When auditing is disabled it passes requests straight through.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @return fiber.Handler - The Fiber handler.
+func Audit(cfg *config.Config, db *sql.DB) fiber.Handler {
+	// This returns a new Fiber handler.
+	return func(c *fiber.Ctx) error {
+		// This checks if auditing is disabled or the request only reads data.
+		if !cfg.Audit.Enabled || isReadMethod(c.Method()) {
+			// If so, the request is not recorded.
+			return c.Next()
+		}
+
+		// start is the time the request started.
+		start := time.Now()
+		// err is the result of handling the request.
+		err := c.Next()
+		// latency is the time taken to handle the request.
+		latency := time.Since(start)
+
+		// status is the status code of the response.
+		status := c.Response().StatusCode()
+		// This checks if the request ended with an error that the error handler has not written yet.
+		if err != nil {
+			// fiberErr is the error as a Fiber error.
+			var fiberErr *fiber.Error
+			// This checks if the error carries a status code.
+			if errors.As(err, &fiberErr) {
+				// If it does, that status code is recorded.
+				status = fiberErr.Code
+			} else {
+				// Otherwise the error handler responds with an internal server error.
+				status = fiber.StatusInternalServerError
+			}
+		}
+
+		// userId is the ID of the authenticated user, if there is one.
+		var userId uuid.NullUUID
+		// actorKind tells the requests of service accounts from the ones of people, and is null for anonymous requests.
+		var actorKind sql.NullString
+		// This checks if a user is authenticated.
+		if user, err := users.CurrentUser(c); err == nil {
+			// If one is, the user ID is recorded.
+			userId = uuid.NullUUID{UUID: user.ID, Valid: true}
+			// The user is recorded as a person, unless the token is the one of a service account.
+			actorKind = sql.NullString{String: users.KindHuman, Valid: true}
+			// This checks if the request carries the token of a service account.
+			if jwt, err := users.CurrentJWT(c); err == nil && jwt.Kind == users.TokenKindService {
+				// If it does, the user is recorded as a service account.
+				actorKind.String = users.KindService
+			}
+		}
+
+		// This records the entry. A failure is logged rather than failing a request that has already been handled.
+		if _, dbErr := db.Exec(audit.CreateEntryQuery, c.Method(), c.Path(), userId, c.IP(), status, latency.Milliseconds(), actorKind); dbErr != nil {
+			// If an error occurs, it is logged.
+			log.Printf("Unable to record audit entry: %v", dbErr)
+		}
+
+		// The result of handling the request is returned.
+		return err
+	}
+}
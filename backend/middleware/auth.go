@@ -1,78 +1,111 @@
 // This file defines a middleware for handling authentication.
 package middleware
 
-// "database/sql" provides a generic SQL interface. It is used here to query the database.
+// "crypto/subtle" provides constant-time comparisons. It is used here to check the device and the client fingerprint a token is bound to.
 import (
+	"crypto/subtle"
+	// "database/sql" provides a generic SQL interface. It is used here to query the database.
 	"database/sql"
-	// "strings" provides functions for working with strings. It is used here to split the Authorization header.
-	"strings"
+	// "errors" provides functions for working with errors. It is used here to compare the header parse errors.
+	"errors"
+	// "log" provides a simple logging package. It is used here to log a failure to record the last use of a session, and the replays of revoked tokens.
+	"log"
 	// "time" provides functions for working with time. It is used here to check if a JWT has expired.
 	"time"
 
 	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to create middleware.
 	"github.com/gofiber/fiber/v2"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to read the user of a revoked token.
+	"github.com/google/uuid"
+	// "github.com/lib/pq" is the PostgreSQL driver. It is used here to read the scopes of the token.
+	"github.com/lib/pq"
 	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models and queries.
 	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
 	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
 	"github.com/rahulcodepython/todo-backend/backend/response"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions. It is used here to parse the Authorization header.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
 )
 
 // Authenticated is a middleware that checks if a user is authenticated.
-// It takes a database connection as input and returns a Fiber handler.
+// It also records when and from which IP address the session was last used, at most once per configured interval.
+// A token bound to a device, such as the token of a guest, is only accepted with the X-Device-ID header of that device.
+// A JWT that was revoked before it expired is recognized by its jti and logged as a replay, and while JWT_BIND_FINGERPRINT
+// is on, a session bound to a client fingerprint is only accepted from a client with the same fingerprint.
+// While an idle timeout is set, a session that went unused for longer ends, however long it has left before it expires.
+// It takes the application configuration and a database connection as input and returns a Fiber handler.
 //
+// @param cfg *config.Config - The application configuration.
 // @param db *sql.DB - The database connection.
 // @return fiber.Handler - The Fiber handler.
-func Authenticated(db *sql.DB) fiber.Handler {
+func Authenticated(cfg *config.Config, db *sql.DB) fiber.Handler {
 	// This returns a new Fiber handler.
 	return func(c *fiber.Ctx) error {
-		// authorization is the value of the "Authorization" header.
-		authorization := c.Get("Authorization")
-
-		// This checks if the Authorization header is empty.
-		if authorization == "" {
-			// If the header is empty, it returns an unauthorized access response.
-			return response.UnauthorizedAccess(c, nil, "Authorization header is missing")
-		}
-
-		// authorizationParts is a slice of strings that contains the parts of the Authorization header.
-		authorizationParts := strings.Split(authorization, " ")
-
-		// This checks if the header has exactly two parts.
-		if len(authorizationParts) != 2 {
-			// If the header does not have two parts, it returns an unauthorized access response.
+		// token is the bearer token of the "Authorization" header.
+		token, err := utils.ParseBearerToken(c.Get("Authorization"))
+		// This checks if the header was rejected.
+		if err != nil {
+			// This checks why the header was rejected.
+			switch {
+			// The header is empty.
+			case errors.Is(err, utils.ErrAuthorizationMissing):
+				// An unauthorized access response is returned.
+				return response.UnauthorizedAccess(c, nil, "Authorization header is missing")
+			// The header uses another scheme.
+			case errors.Is(err, utils.ErrAuthorizationScheme):
+				// An unauthorized access response is returned.
+				return response.UnauthorizedAccess(c, nil, "Authorization type must be 'Bearer'")
+			// The token cannot be a token that was issued.
+			case errors.Is(err, utils.ErrTokenMalformed):
+				// An unauthorized access response is returned.
+				return response.UnauthorizedAccess(c, nil, "Invalid token")
+			}
+			// Otherwise the header is not a scheme followed by one credential, and an unauthorized access response is returned.
 			return response.UnauthorizedAccess(c, nil, "Invalid Authorization header format. Expected 'Bearer <token>'")
 		}
 
-		// This checks if the first part of the header is "Bearer".
-		if authorizationParts[0] != "Bearer" {
-			// If the first part is not "Bearer", it returns an unauthorized access response.
-			return response.UnauthorizedAccess(c, nil, "Authorization type must be 'Bearer'")
-		}
-
-		// token is the second part of the Authorization header.
-		token := authorizationParts[1]
-
-		// This checks if the token is empty.
-		if token == "" {
-			// If the token is empty, it returns an unauthorized access response.
-			return response.UnauthorizedAccess(c, nil, "Token is missing")
-		}
-
 		// count is a variable that will hold the number of rows returned by the query.
 		var count int
 		// jwt is a variable that will hold the JWT data.
 		var jwt users.JWT
+		// createdAt is the time the token was issued.
+		var createdAt time.Time
+		// lastUsedAt is the time the session was last used, if it was.
+		var lastUsedAt sql.NullTime
+		// deviceHash is the hash of the device ID the token is bound to, if it is bound to one.
+		var deviceHash sql.NullString
+		// fingerprintHash is the fingerprint of the client the token is bound to, if it is bound to one.
+		var fingerprintHash sql.NullString
 
 		// err is the result of querying the database for the JWT.
-		// db.QueryRow() executes a query that is expected to return at most one row.
-		err := db.QueryRow(
+		// db.QueryRowContext() executes a query that is expected to return at most one row, bounded by the deadline of the request.
+		err = db.QueryRowContext(c.UserContext(),
 			// This is the SQL query to retrieve the JWT.
-			"SELECT COUNT(*) OVER() AS count, id, token, expires_at FROM jwt_tokens WHERE token = $1",
+			"SELECT COUNT(*) OVER() AS count, id, token, expires_at, created_at, last_used_at, scopes, kind, device_hash, fingerprint_hash FROM jwt_tokens WHERE token = $1",
 			// token is the token from the Authorization header.
 			token,
-		).Scan(&count, &jwt.ID, &jwt.Token, &jwt.ExpiresAt)
-
-		// This checks if an error occurred while querying the database.
+		).Scan(&count, &jwt.ID, &jwt.Token, &jwt.ExpiresAt, &createdAt, &lastUsedAt, pq.Array(&jwt.Scopes), &jwt.Kind, &deviceHash, &fingerprintHash)
+
+		// This checks if the token does not exist, in which case the query returns no row at all.
+		if err == sql.ErrNoRows {
+			// This checks if the token is a JWT the server signed, which means it was revoked or has expired.
+			if jti, ok := users.TokenJTI(cfg, token); ok {
+				// userId is the user of the JWT, if it was revoked before it expired.
+				var userId uuid.NullUUID
+				// This checks if the JWT was revoked before it expired, which means someone still uses it after a logout or a revocation.
+				if err := db.QueryRowContext(c.UserContext(), users.GetRevokedTokenQuery, jti, time.Now()).Scan(&userId); err == nil {
+					// If it was, the replay is logged, so that a stolen token shows up in the logs.
+					log.Printf("Replay of revoked token %s of user %s from %s", jti, userId.UUID, c.IP())
+					// It then returns an unauthorized access response.
+					return response.UnauthorizedAccess(c, nil, "Token has been revoked. Please login again.")
+				}
+			}
+			// Otherwise it returns an unauthorized access response.
+			return response.UnauthorizedAccess(c, nil, "Invalid token")
+		}
+		// This checks if another error occurred while querying the database.
 		if err != nil {
 			// If an error occurs, it returns an internal server error response.
 			return response.InternelServerError(c, err, "Internal Server Error")
@@ -87,7 +120,7 @@ func Authenticated(db *sql.DB) fiber.Handler {
 		// This checks if the token has expired.
 		if jwt.ExpiresAt.Before(time.Now()) {
 			// If the token has expired, it is deleted from the database.
-			_, err := db.Exec(users.DeleteJWTByIdQuery, jwt.ID)
+			_, err := db.ExecContext(c.UserContext(), users.DeleteJWTByIdQuery, jwt.ID)
 			// This checks if an error occurred while deleting the token.
 			if err != nil {
 				// If an error occurs, it returns an internal server error response.
@@ -97,10 +130,51 @@ func Authenticated(db *sql.DB) fiber.Handler {
 			return response.UnauthorizedAccess(c, nil, "Token has expired. Please login again.")
 		}
 
-		// The JWT data is stored in the local context.
-		c.Locals("jwt", jwt)
+		// now is the time of the request.
+		now := time.Now()
+		// This checks if the token is a session that went unused for longer than the idle timeout. API keys and the tokens of
+		// service accounts and guests are left alone, since they are meant to be used now and then.
+		if idleExpiresAt, ok := users.IdleExpiresAt(cfg, createdAt, lastUsedAt); ok && jwt.Kind == users.TokenKindSession && !idleExpiresAt.After(now) {
+			// If it is, the session is deleted from the database, as an expired token is.
+			_, err := db.ExecContext(c.UserContext(), users.DeleteJWTByIdQuery, jwt.ID)
+			// This checks if an error occurred while deleting the session.
+			if err != nil {
+				// If an error occurs, it returns an internal server error response.
+				return response.InternelServerError(c, err, "Internal Server Error")
+			}
+			// It then returns an unauthorized access response.
+			return response.UnauthorizedAccess(c, nil, "Session has expired due to inactivity. Please login again.")
+		}
+
+		// This checks if the token is bound to a device, as the token of a guest is, and the request comes from another one.
+		// The hashes are compared in constant time, so that the comparison does not reveal how much of a guessed device ID is right.
+		if deviceHash.Valid && subtle.ConstantTimeCompare([]byte(deviceHash.String), []byte(users.DeviceHash(c.Get(users.DeviceIDHeader)))) != 1 {
+			// If it does, it returns an unauthorized access response, as for any token that is not valid.
+			return response.UnauthorizedAccess(c, nil, "Invalid token")
+		}
+
+		// This checks if the token is bound to the fingerprint of a client, and the request comes from a client with another one.
+		// The binding is only checked while it is turned on, so that turning it off lets the bound sessions work from anywhere again.
+		if cfg.JWT.BindFingerprint && fingerprintHash.Valid && subtle.ConstantTimeCompare([]byte(fingerprintHash.String), []byte(users.ClientFingerprint(cfg, c.Get(fiber.HeaderUserAgent), c.IP()))) != 1 {
+			// If it does, the mismatch is logged, since it may be a stolen token.
+			log.Printf("Session %s used from another client fingerprint from %s", jwt.ID, c.IP())
+			// It then returns an unauthorized access response, as for any token that is not valid.
+			return response.UnauthorizedAccess(c, nil, "Invalid token")
+		}
+
+		// This checks if the last use of the session was recorded too long ago, or never.
+		if !lastUsedAt.Valid || now.Sub(lastUsedAt.Time) >= cfg.JWT.LastUsedInterval {
+			// If it was, the use is recorded. A failure is only logged, since the request itself is authenticated.
+			if _, err := db.ExecContext(c.UserContext(), users.TouchSessionQuery, jwt.ID, now, c.IP()); err != nil {
+				// The failure is logged.
+				log.Printf("Unable to record the last use of session %s: %v", jwt.ID, err)
+			}
+		}
+
+		// The JWT data is stored in the local context, with the scopes that RequireScope checks.
+		users.SetCurrentJWT(c, jwt)
 
 		// c.Next() calls the next middleware in the chain.
 		return c.Next()
 	}
-}
\ No newline at end of file
+}
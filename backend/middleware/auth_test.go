@@ -0,0 +1,216 @@
+// This file defines a test of the sessions of concurrent logins, from the login to the authentication of their JWTs.
+package middleware
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here for the logins and the logout.
+import (
+	"context"
+	// "database/sql/driver" defines the interfaces of SQL drivers. It is used here to build the rows of the fake database.
+	"database/sql/driver"
+	// "net/http/httptest" provides utilities for HTTP testing. It is used here to build the requests.
+	"net/http/httptest"
+	// "sync" provides synchronization primitives. It is used here to guard the sessions of the fake database and to wait for the logins.
+	"sync"
+	// "testing" provides support for automated tests. It is used here to run the test.
+	"testing"
+	// "time" provides functionality for measuring and displaying time. It is used here for the lifetime of the sessions.
+	"time"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to serve the authenticated requests.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here for the IDs the logins are given.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that manages users. It is used here to log in and out.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that provides the current time. It is used here to fix it.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration. It is used here for the signing key and the lifetime of the sessions.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/database/dbtest" is a local package that provides a fake database. It is used here to hold the user and the sessions.
+	"github.com/rahulcodepython/todo-backend/backend/database/dbtest"
+	// "github.com/rahulcodepython/todo-backend/backend/idgen" is a local package that generates IDs. It is used here to give the logins known IDs.
+	"github.com/rahulcodepython/todo-backend/backend/idgen"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions. It is used here to hash the password.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// selectJWTQuery is the query Authenticated looks a JWT up with.
+const selectJWTQuery = "SELECT COUNT(*) OVER() AS count, id, token, expires_at, created_at, last_used_at, scopes, kind, device_hash, fingerprint_hash FROM jwt_tokens WHERE token = $1"
+
+// session is a row of the sessions of the fake database.
+type session struct {
+	// id is the ID of the session.
+	id string
+	// token is the JWT of the session.
+	token string
+	// expiresAt is the time the session expires.
+	expiresAt time.Time
+}
+
+// TestConcurrentLoginSessions checks that two logins of the same user at the same instant each get a session of their own,
+// with distinct JWTs and jtis, and that logging out of one leaves the other authenticated.
+//
+// @param t *testing.T - The test state.
+func TestConcurrentLoginSessions(t *testing.T) {
+	// now is the instant of both logins, close to the real time since Authenticated checks expiry against it.
+	now := time.Now().Truncate(time.Second)
+	// hash is the hashed password of the user.
+	hash, err := utils.EncryptPassword("correct horse")
+	// This checks if the password could not be hashed.
+	if err != nil {
+		// If it could not, the test fails.
+		t.Fatal(err)
+	}
+	// user is the user who logs in twice.
+	user := users.User{ID: uuid.New(), Name: "Rahul", Email: "rahul@example.com", Password: hash, Timezone: "UTC"}
+
+	// mu guards the sessions.
+	var mu sync.Mutex
+	// sessions are the sessions of the fake database, by the ID of their session.
+	sessions := map[string]session{}
+	// fake is the fake database, which holds the user and the sessions.
+	fake := dbtest.NewDriver()
+	// The email address has no failed logins.
+	fake.Handle(users.GetLoginFailuresQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		return dbtest.Rows{Columns: []string{"failures"}}, nil
+	})
+	// The email address belongs to the user.
+	fake.Handle(users.GetUserProfileByEmailQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		return dbtest.Rows{
+			Columns: []string{"id", "name", "email", "image", "password", "jwt", "created_at", "updated_at", "timezone", "locale"},
+			Values:  [][]driver.Value{{user.ID.String(), user.Name, user.Email, nil, user.Password, nil, now, now, user.Timezone, ""}},
+		}, nil
+	})
+	// The user has no expired sessions.
+	fake.Handle(users.DeleteExpiredJWTsQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		return dbtest.Rows{}, nil
+	})
+	// A login adds its session.
+	fake.Handle(users.CreateNewJWT_UpdateUserRowQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		// id is the ID of the session.
+		id := args[0].Value.(string)
+		sessions[id] = session{id: id, token: args[1].Value.(string), expiresAt: args[2].Value.(time.Time)}
+		return dbtest.Rows{RowsAffected: 1}, nil
+	})
+	// The device of the logins is known.
+	fake.Handle(users.RecordKnownDeviceQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		return dbtest.Rows{Columns: []string{"new", "others"}, Values: [][]driver.Value{{false, false}}}, nil
+	})
+	// A logout deletes its session.
+	fake.Handle(users.DeleteJWTByIdQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(sessions, args[0].Value.(string))
+		return dbtest.Rows{RowsAffected: 1}, nil
+	})
+	// A JWT is looked up among the sessions.
+	fake.Handle(selectJWTQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		// rows holds the session of the JWT, if it has one.
+		rows := dbtest.Rows{Columns: []string{"count", "id", "token", "expires_at", "created_at", "last_used_at", "scopes", "kind", "device_hash", "fingerprint_hash"}}
+		// This looks for the session of the JWT.
+		for _, s := range sessions {
+			if s.token == args[0].Value.(string) {
+				rows.Values = append(rows.Values, []driver.Value{int64(1), s.id, s.token, s.expiresAt, now, nil, []byte("{}"), users.TokenKindSession, nil, nil})
+			}
+		}
+		return rows, nil
+	})
+	// No JWT was revoked.
+	fake.Handle(users.GetRevokedTokenQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		return dbtest.Rows{Columns: []string{"user_id"}}, nil
+	})
+	// The use of a session is recorded.
+	fake.Handle(users.TouchSessionQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		return dbtest.Rows{RowsAffected: 1}, nil
+	})
+	// db is the fake database.
+	db := dbtest.OpenDB(fake)
+	t.Cleanup(func() { db.Close() })
+
+	// ids are the IDs the logins are given, a session ID and a jti each.
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+	// sequence gives the IDs out in order, to whichever login asks first.
+	sequence := &idgen.Sequence{IDs: append([]uuid.UUID{}, ids...)}
+	// cfg is the configuration, of which only the JWTs are read.
+	cfg := &config.Config{JWT: config.JWTConfig{SecretKey: "test-secret", Expires: time.Hour, RememberMeExpires: 24 * time.Hour, LastUsedInterval: time.Minute}}
+	// service is the user service over the fake database, with the clock fixed so that both JWTs have the same times.
+	service := users.NewUserService(cfg, db, clock.Fixed(now), sequence, nil)
+
+	// jwts are the JWTs of the two logins.
+	var jwts [2]users.JWT
+	// errs are the errors of the two logins.
+	var errs [2]error
+	// wg waits for the logins.
+	var wg sync.WaitGroup
+	// This logs the user in twice at once, from two devices.
+	for i := range jwts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, jwts[i], errs[i] = service.Login(context.Background(), user.Email, "correct horse", false, users.Client{UserAgent: "device", IP: "192.0.2.1"})
+		}()
+	}
+	wg.Wait()
+	// This checks if a login failed.
+	for i, err := range errs {
+		if err != nil {
+			// If one did, the test fails.
+			t.Fatalf("login %d: %v", i, err)
+		}
+	}
+
+	// jti0 and jti1 are the jtis of the JWTs.
+	jti0, ok0 := users.TokenJTI(cfg, jwts[0].Token)
+	jti1, ok1 := users.TokenJTI(cfg, jwts[1].Token)
+	// This checks if the logins share a JWT, a session, or a jti.
+	if !ok0 || !ok1 || jwts[0].Token == jwts[1].Token || jwts[0].ID == jwts[1].ID || jti0 == jti1 {
+		// If they do, the test fails.
+		t.Fatalf("logins got sessions %s and %s with jtis %s and %s, want distinct sessions, JWTs, and jtis", jwts[0].ID, jwts[1].ID, jti0, jti1)
+	}
+	// This checks if the logins did not take the four IDs between them, a session ID and a jti each.
+	if got := map[uuid.UUID]bool{jwts[0].ID: true, jwts[1].ID: true, jti0: true, jti1: true}; len(got) != len(ids) || !got[ids[0]] || !got[ids[1]] || !got[ids[2]] || !got[ids[3]] {
+		// If they did not, the test fails.
+		t.Fatalf("logins used IDs %v, want %v", got, ids)
+	}
+
+	// The first session is logged out.
+	if err := service.Logout(context.Background(), jwts[0]); err != nil {
+		// If it cannot be, the test fails.
+		t.Fatal(err)
+	}
+
+	// app serves a route behind the authentication, which answers with the ID of the session.
+	app := fiber.New()
+	app.Get("/me", Authenticated(cfg, db), func(c *fiber.Ctx) error {
+		// jwt is the authenticated JWT.
+		jwt, err := users.CurrentJWT(c)
+		// This checks if the request has no JWT.
+		if err != nil {
+			return err
+		}
+		// The ID of the session is returned.
+		return c.SendString(jwt.ID.String())
+	})
+	// This authenticates both JWTs: the one logged out is refused, and the other still works.
+	for i, want := range []int{fiber.StatusUnauthorized, fiber.StatusOK} {
+		// req is a request with the JWT.
+		req := httptest.NewRequest(fiber.MethodGet, "/me", nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+jwts[i].Token)
+		// resp is the response to the request.
+		resp, err := app.Test(req)
+		// This checks if the request failed.
+		if err != nil {
+			// If it did, the test fails.
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		// This checks if the JWT was answered with another status.
+		if resp.StatusCode != want {
+			// If it was, the test fails.
+			t.Errorf("session %d after logging out session 0: status = %d, want %d", i, resp.StatusCode, want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// This file defines a middleware for HTTP Basic authentication.
+package middleware
+
+// "database/sql" provides a generic SQL interface. It is used here to query the database.
+import (
+	"database/sql"
+	// "errors" provides functions for working with errors. It is used here to tell a counted failure from a database error.
+	"errors"
+	// "strconv" provides functions for converting strings. It is used here to format the Retry-After header.
+	"strconv"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to create middleware.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models and queries.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time. It is used here to date the failed logins.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration. It is used here for the throttling of failed logins.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses. It is used here to answer a locked out address.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// BasicAuthenticatedUser is a middleware that authenticates a user with their email and password sent as HTTP Basic credentials.
+// It is used by protocols such as CalDAV whose clients cannot send bearer tokens.
+// On success the user is stored in the local context, like AuthenticatedUser does.
+// Failed attempts are throttled per email address like logins are, sharing their count, and since a Basic client cannot
+// show a captcha, an address that failed often enough to be asked for one is refused with 429 until its failures are forgotten.
+// It takes the application configuration and a database connection as input and returns a Fiber handler.
+//
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @return fiber.Handler - The Fiber handler.
+func BasicAuthenticatedUser(cfg *config.Config, db *sql.DB) fiber.Handler {
+	// throttle counts the failed attempts and slows down an address that failed too often.
+	throttle := users.NewLoginThrottle(cfg, db, clock.System{})
+
+	// This returns a new Fiber handler.
+	return func(c *fiber.Ctx) error {
+		// email and password are the credentials from the Authorization header.
+		email, password, ok := utils.ParseBasicAuth(c.Get("Authorization"))
+		// This checks if the credentials are missing or malformed.
+		if !ok {
+			// If they are, the client is asked to authenticate.
+			return basicChallenge(c)
+		}
+
+		// failures is the number of recent failed logins of the email address.
+		failures, err := throttle.Failures(c.UserContext(), email)
+		// This checks if an error occurred while counting them.
+		if err != nil {
+			// If it did, an internal server error status is returned.
+			return c.SendStatus(fiber.StatusInternalServerError)
+		}
+		// This checks if the address is locked out.
+		if throttle.Locked(failures) {
+			// If it is, the client is told when to try again, and the password is not checked.
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(throttle.RetryAfter().Seconds())))
+			// A 429 Too Many Requests status is returned.
+			return response.TooManyRequests(c, "Too many failed logins. Try again later")
+		}
+		// This waits out the backoff of the failed logins before the credentials are checked.
+		if err := throttle.Wait(c.UserContext(), failures); err != nil {
+			// If the request ends first, its error is returned.
+			return err
+		}
+
+		// user is a variable that will hold the user's data.
+		var user users.User
+
+		// err is the result of querying the database for the user's profile.
+		err = db.QueryRowContext(c.UserContext(), users.GetUserProfileByEmailQuery, email).Scan(&user.ID, &user.Name, &user.Email, &user.Image, &user.Password, &user.JWT, &user.CreatedAt, &user.UpdatedAt, &user.Timezone, &user.Locale)
+		// This checks if no user has the email.
+		if err == sql.ErrNoRows {
+			// If none does, the failure is counted and the client is asked to authenticate.
+			return basicFailure(c, throttle, email, users.ErrUserNotFound)
+		}
+		// This checks if another error occurred.
+		if err != nil {
+			// If it did, an internal server error status is returned.
+			return c.SendStatus(fiber.StatusInternalServerError)
+		}
+
+		// This checks if the password does not match.
+		if !utils.CompareEncryptedPassword(user.Password, password) {
+			// If it does not, the failure is counted and the client is asked to authenticate.
+			return basicFailure(c, throttle, email, users.ErrInvalidCredentials)
+		}
+		// The failed logins of the address are forgotten now that the right password was given.
+		if err := throttle.Clear(c.UserContext(), email, failures); err != nil {
+			// If they cannot be, an internal server error status is returned.
+			return c.SendStatus(fiber.StatusInternalServerError)
+		}
+
+		// The user's data is stored in the local context.
+		users.SetCurrentUser(c, user)
+		// This checks if the user chose a language.
+		if user.Locale != "" {
+			// If the user did, it replaces the negotiated language.
+			setLocale(c, user.Locale)
+		}
+
+		// c.Next() calls the next middleware in the chain.
+		return c.Next()
+	}
+}
+
+// basicFailure counts a failed attempt of an email address and asks the client for Basic credentials again.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param throttle *users.LoginThrottle - The throttle of failed logins.
+// @param email string - The email address of the attempt.
+// @param reason error - users.ErrUserNotFound or users.ErrInvalidCredentials.
+// @return error - An error if one occurred while sending the response.
+func basicFailure(c *fiber.Ctx, throttle *users.LoginThrottle, email string, reason error) error {
+	// This counts the failure, which only fails with a *users.LoginFailedError once it is counted.
+	if err := throttle.Fail(c.UserContext(), email, reason); !errors.As(err, new(*users.LoginFailedError)) {
+		// If it could not be counted, an internal server error status is returned.
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	// The client is asked to authenticate.
+	return basicChallenge(c)
+}
+
+// basicChallenge sends a 401 response asking the client for Basic credentials.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred while sending the response.
+func basicChallenge(c *fiber.Ctx) error {
+	// The WWW-Authenticate header tells the client which scheme to use.
+	c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="todo-backend", charset="UTF-8"`)
+	// A 401 Unauthorized status is returned.
+	return c.SendStatus(fiber.StatusUnauthorized)
+}
@@ -0,0 +1,163 @@
+// This file defines a test of the throttling of failed HTTP Basic authentications, which share the count of failed logins.
+package middleware
+
+// "database/sql/driver" defines the interfaces of SQL drivers. It is used here to build the rows of the fake database.
+import (
+	"database/sql/driver"
+	// "encoding/base64" implements base64 encoding. It is used here to build the Basic credentials.
+	"encoding/base64"
+	// "net/http/httptest" provides utilities for HTTP testing. It is used here to build the requests.
+	"net/http/httptest"
+	// "sync" provides synchronization primitives. It is used here to guard the failures of the fake database.
+	"sync"
+	// "testing" provides support for automated tests. It is used here to run the test.
+	"testing"
+	// "time" provides functionality for measuring and displaying time. It is used here to measure the delays.
+	"time"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to serve the authenticated requests.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here for the ID of the user.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that manages users. It is used here for its queries.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration. It is used here for the throttling.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/database/dbtest" is a local package that provides a fake database. It is used here to hold the user and the failures.
+	"github.com/rahulcodepython/todo-backend/backend/database/dbtest"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides utility functions. It is used here to hash the password.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// TestBasicAuthThrottle checks that repeated wrong Basic credentials are delayed by the same backoff as logins, and that
+// once the address failed often enough to be asked for a captcha, even the right password is refused with 429 until the
+// failures are forgotten.
+//
+// @param t *testing.T - The test state.
+func TestBasicAuthThrottle(t *testing.T) {
+	// now is the time the user was created.
+	now := time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC)
+	// hash is the hashed password of the user.
+	hash, err := utils.EncryptPassword("correct horse")
+	// This checks if the password could not be hashed.
+	if err != nil {
+		// If it could not, the test fails.
+		t.Fatal(err)
+	}
+	// user is the user the credentials are for.
+	user := users.User{ID: uuid.New(), Name: "Rahul", Email: "rahul@example.com", Password: hash, Timezone: "UTC"}
+
+	// mu guards the failures.
+	var mu sync.Mutex
+	// failures are the failed logins of the fake database, by email address.
+	failures := map[string]int64{}
+	// fake is the fake database, which holds the user and the failures.
+	fake := dbtest.NewDriver()
+	// The failures of an address are counted.
+	fake.Handle(users.GetLoginFailuresQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		// rows holds the failures of the address, if it has any.
+		rows := dbtest.Rows{Columns: []string{"failures"}}
+		// This checks if the address has failures.
+		if n := failures[args[0].Value.(string)]; n > 0 {
+			rows.Values = [][]driver.Value{{n}}
+		}
+		return rows, nil
+	})
+	// A failure is recorded.
+	fake.Handle(users.RecordLoginFailureQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failures[args[0].Value.(string)]++
+		return dbtest.Rows{Columns: []string{"failures"}, Values: [][]driver.Value{{failures[args[0].Value.(string)]}}}, nil
+	})
+	// The failures of an address are forgotten.
+	fake.Handle(users.ClearLoginFailuresQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(failures, args[0].Value.(string))
+		return dbtest.Rows{RowsAffected: 1}, nil
+	})
+	// The email address belongs to the user.
+	fake.Handle(users.GetUserProfileByEmailQuery, func(args []driver.NamedValue) (dbtest.Rows, error) {
+		return dbtest.Rows{
+			Columns: []string{"id", "name", "email", "image", "password", "jwt", "created_at", "updated_at", "timezone", "locale"},
+			Values:  [][]driver.Value{{user.ID.String(), user.Name, user.Email, nil, user.Password, nil, now, now, user.Timezone, ""}},
+		}, nil
+	})
+	// db is the fake database.
+	db := dbtest.OpenDB(fake)
+	t.Cleanup(func() { db.Close() })
+
+	// cfg is the configuration, of which only the throttling is read: one free attempt, then 50ms doubling, and a lockout at three failures.
+	cfg := &config.Config{LoginThrottle: config.LoginThrottleConfig{FreeAttempts: 1, BaseDelay: 50 * time.Millisecond, MaxDelay: time.Second, CaptchaAfter: 3, Window: time.Minute}}
+	// app serves a route behind the Basic authentication.
+	app := fiber.New()
+	app.Get("/caldav", BasicAuthenticatedUser(cfg, db), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	// authenticate sends a request with Basic credentials, and returns its response and how long it took.
+	authenticate := func(password string) (int, string, time.Duration) {
+		// req is a request with the credentials.
+		req := httptest.NewRequest(fiber.MethodGet, "/caldav", nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Basic "+base64.StdEncoding.EncodeToString([]byte(user.Email+":"+password)))
+		// start is the time the request was sent.
+		start := time.Now()
+		// resp is the response to the request.
+		resp, err := app.Test(req, -1)
+		// This checks if the request failed.
+		if err != nil {
+			// If it did, the test fails.
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		// The status, the Retry-After header, and the time taken are returned.
+		return resp.StatusCode, resp.Header.Get(fiber.HeaderRetryAfter), time.Since(start)
+	}
+
+	// This sends three wrong passwords: the first is free, and the next wait 50ms and 100ms before they are checked.
+	for i, minDelay := range []time.Duration{0, 50 * time.Millisecond, 100 * time.Millisecond} {
+		// status and took are the response to the wrong password.
+		status, _, took := authenticate("wrong")
+		// This checks if the wrong password was not refused with a challenge.
+		if status != fiber.StatusUnauthorized {
+			// If it was not, the test fails.
+			t.Fatalf("attempt %d: status = %d, want %d", i+1, status, fiber.StatusUnauthorized)
+		}
+		// This checks if the attempt was not delayed by the backoff.
+		if took < minDelay {
+			// If it was not, the test fails.
+			t.Errorf("attempt %d took %s, want at least %s", i+1, took, minDelay)
+		}
+	}
+	// This checks if the failures were not counted under the address.
+	if got := failures[user.Email]; got != 3 {
+		// If they were not, the test fails.
+		t.Fatalf("failures = %d, want 3", got)
+	}
+
+	// The right password is refused now that the address is locked out.
+	status, retryAfter, _ := authenticate("correct horse")
+	// This checks if the locked out address was let in, or not told when to try again.
+	if status != fiber.StatusTooManyRequests || retryAfter != "60" {
+		// If it was, the test fails.
+		t.Fatalf("locked out: status = %d, Retry-After = %q, want %d and %q", status, retryAfter, fiber.StatusTooManyRequests, "60")
+	}
+
+	// The failures are forgotten, as they are a window after the last one.
+	mu.Lock()
+	failures[user.Email] = 1
+	mu.Unlock()
+	// This checks if the right password is not accepted once the address is no longer locked.
+	if status, _, _ := authenticate("correct horse"); status != fiber.StatusOK {
+		// If it is not, the test fails.
+		t.Fatalf("after the lockout: status = %d, want %d", status, fiber.StatusOK)
+	}
+	// This checks if the right password did not clear the failures.
+	if got := failures[user.Email]; got != 0 {
+		// If it did not, the test fails.
+		t.Errorf("failures after the right password = %d, want 0", got)
+	}
+}
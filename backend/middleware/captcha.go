@@ -0,0 +1,60 @@
+// This file defines the middleware that asks for a solved captcha.
+package middleware
+
+// "errors" provides functions for working with errors. It is used here to recognize the errors of the verifier.
+import (
+	"errors"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to create middleware.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/backend/captcha" is a local package that verifies captcha tokens.
+	"github.com/rahulcodepython/todo-backend/backend/captcha"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+)
+
+// CaptchaHeader is the header clients send the solved captcha token in.
+// A header rather than a body field keeps the middleware independent of the body of each endpoint.
+const CaptchaHeader = "X-Captcha-Token"
+
+// Captcha is a middleware that lets a request through only with a captcha token the provider accepts.
+// It does nothing when no captcha provider is configured.
+//
+// @param cfg *config.Config - The application configuration.
+// @return fiber.Handler - The Fiber handler.
+func Captcha(cfg *config.Config) fiber.Handler {
+	// verifier checks the tokens, or is nil when captchas are disabled.
+	verifier := captcha.NewVerifier(cfg.Captcha)
+	// This returns a new Fiber handler.
+	return func(c *fiber.Ctx) error {
+		// This checks if captchas are disabled.
+		if verifier == nil {
+			// If they are, the request is let through.
+			return c.Next()
+		}
+
+		// err is the result of verifying the token of the request.
+		err := verifier.Verify(c.UserContext(), c.Get(CaptchaHeader), c.IP())
+		// rejected is the rejection of the provider, if the error is one.
+		var rejected *captcha.RejectedError
+		// This checks how the verification went.
+		switch {
+		// The token is valid.
+		case err == nil:
+			// The request is let through.
+			return c.Next()
+		// The request carries no token.
+		case errors.Is(err, captcha.ErrMissingToken):
+			// A captcha failed response is returned with the code the providers use for a missing token.
+			return response.CaptchaFailed(c, "A captcha is required", []string{captcha.MissingToken})
+		// The provider rejected the token.
+		case errors.As(err, &rejected):
+			// A captcha failed response is returned with the codes of the provider.
+			return response.CaptchaFailed(c, "The captcha could not be verified", rejected.Codes)
+		}
+		// The provider could not be reached, so a service unavailable response is returned.
+		return response.ServiceUnavailable(c, err, "Unable to verify the captcha")
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 // Cors is a middleware that handles CORS.
 // It takes the application configuration as input and returns a Fiber handler.
+// Every request goes through it; preflight requests from origins that are not allowed get no CORS headers.
 //
 // @param cfg *config.Config - The application configuration.
 // @return fiber.Handler - The Fiber handler.
@@ -19,13 +20,16 @@ func Cors(cfg *config.Config) fiber.Handler {
 	// cors.New() returns a new CORS middleware with the specified configuration.
 	return cors.New(cors.Config{
 		// AllowOrigins is a list of origins that are allowed to make cross-origin requests.
-		AllowOrigins: cfg.CORS.CorsOrigins,
+		AllowOrigins: cfg.CORS.AllowOrigins,
+		// AllowMethods is a list of methods that are allowed in cross-origin requests.
+		AllowMethods: cfg.CORS.AllowMethods,
 		// AllowHeaders is a list of headers that are allowed in cross-origin requests.
-		AllowHeaders: "Origin, Content-Type, Accept",
-		// Next is a function that determines whether to skip this middleware.
-		Next: func(c *fiber.Ctx) bool {
-			// The middleware is skipped if the request is coming from the server itself.
-			return c.IP() == cfg.Server.Host
-		},
+		AllowHeaders: cfg.CORS.AllowHeaders,
+		// ExposeHeaders is a list of response headers that browsers may read.
+		ExposeHeaders: cfg.CORS.ExposeHeaders,
+		// AllowCredentials indicates whether cross-origin requests may carry credentials.
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		// MaxAge is the number of seconds browsers may cache a preflight request.
+		MaxAge: int(cfg.CORS.MaxAge.Seconds()),
 	})
-}
\ No newline at end of file
+}
@@ -1,45 +1,221 @@
 // This file defines middleware for rate limiting.
+// Every response carries the X-RateLimit-Limit, X-RateLimit-Remaining, and X-RateLimit-Reset headers, and a 429 response also carries Retry-After.
 package middleware
 
-// "time" provides functions for working with time. It is used here to set the expiration time for the rate limiter.
+// "strconv" provides functions for converting strings. It is used here to write the rate limit headers.
 import (
+	"strconv"
+	// "time" provides functions for working with time. It is used here to read the time of a request and set the expiration of the strict limiter.
 	"time"
 
 	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to create middleware.
 	"github.com/gofiber/fiber/v2"
 	// "github.com/gofiber/fiber/v2/middleware/limiter" is a middleware that provides rate limiting.
 	"github.com/gofiber/fiber/v2/middleware/limiter"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models. It is used here to key the limits on the user.
+	"github.com/rahulcodepython/todo-backend/apps/users"
 	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
 	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/i18n" is a local package that translates messages.
+	"github.com/rahulcodepython/todo-backend/backend/i18n"
 	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
 	"github.com/rahulcodepython/todo-backend/backend/response"
 )
 
-// GeneralAPILimiter is a middleware that provides general rate limiting for the API.
-// It takes the application configuration as input and returns a Fiber handler.
+// RateLimits holds the counters of the rate limiters, so that the budget of a caller can also be reported on the responses
+// that no limiter saw, such as a request rejected by the authentication middleware or a route without a limit.
+type RateLimits struct {
+	// cfg is the application configuration.
+	cfg *config.Config
+	// store holds the request counts of every caller.
+	store *rateStore
+}
+
+// NewRateLimits creates the counters of the rate limiters.
+// It takes the application configuration as input.
 //
 // @param cfg *config.Config - The application configuration.
+// @return *RateLimits - A pointer to the new RateLimits.
+func NewRateLimits(cfg *config.Config) *RateLimits {
+	// A new RateLimits is returned.
+	return &RateLimits{
+		// The cfg field is set to the application configuration.
+		cfg: cfg,
+		// The store field is set to an empty store with the configured window.
+		store: newRateStore(cfg.RateLimit.Window),
+	}
+}
+
+// Anonymous is a middleware that provides rate limiting by IP address for the endpoints that do not require a user.
+//
 // @return fiber.Handler - The Fiber handler.
-func GeneralAPILimiter(cfg *config.Config) fiber.Handler {
-	// limiter.New() returns a new limiter middleware with the specified configuration.
-	return limiter.New(limiter.Config{
-		// Max is the maximum number of requests that can be made in the given time frame.
-		Max: 60,
-		// Expiration is the time frame in which the requests are counted.
-		Expiration: 1 * time.Minute,
-		// LimiterMiddleware is the storage for the limiter.
-		LimiterMiddleware: limiter.SlidingWindow{},
-		// LimitReached is a function that is called when the limit is reached.
-		LimitReached: func(c *fiber.Ctx) error {
-			// response.TooManyRequests() sends a 429 Too Many Requests response.
-			return response.TooManyRequests(c, "Too many requests, please try again after one minute.")
-		},
-		// Next is a function that determines whether to skip this middleware.
-		Next: func(c *fiber.Ctx) bool {
-			// The middleware is skipped if the request is coming from the server itself.
-			return c.IP() == cfg.Server.Host
-		},
-	})
+func (rl *RateLimits) Anonymous() fiber.Handler {
+	// This returns a new Fiber handler.
+	return func(c *fiber.Ctx) error {
+		// This checks if the request is coming from the server itself.
+		if c.IP() == rl.cfg.Server.Host {
+			// If it is, it is not counted.
+			return c.Next()
+		}
+		// The request is counted against the IP address.
+		return rl.limit(c, anonymousKey(c), rl.cfg.RateLimit.AnonymousMax)
+	}
+}
+
+// User is a middleware that provides rate limiting per authenticated user, with separate buckets for reads and writes,
+// so that a client polling for changes does not use up the budget for saving them.
+// It must be placed after the authentication middlewares. Requests without a user fall back to the token, then to the IP address.
+//
+// @return fiber.Handler - The Fiber handler.
+func (rl *RateLimits) User() fiber.Handler {
+	// This returns a new Fiber handler.
+	return func(c *fiber.Ctx) error {
+		// key and max are the bucket of the request and its limit.
+		key, max := rl.userBucket(c)
+		// The request is counted against the bucket.
+		return rl.limit(c, key, max)
+	}
+}
+
+// Headers is a middleware that reports the budget of the caller on every response, including the ones no limiter saw,
+// so that clients can slow down before they are limited. It should be applied to the whole app; the budget is only read, never counted.
+// An authenticated caller is reported its read or write bucket, and any other caller the bucket of its IP address.
+//
+// @return fiber.Handler - The Fiber handler.
+func (rl *RateLimits) Headers() fiber.Handler {
+	// This returns a new Fiber handler.
+	return func(c *fiber.Ctx) error {
+		// err is the result of handling the request.
+		err := c.Next()
+		// This checks if a limiter already reported the budget.
+		if c.GetRespHeader(headerRateLimitLimit) != "" {
+			// If one did, the request is done.
+			return err
+		}
+		// key and max are the bucket of the caller and its limit.
+		key, max := anonymousKey(c), rl.cfg.RateLimit.AnonymousMax
+		// This checks if the caller was authenticated by the route.
+		if _, err := users.CurrentUser(c); err == nil {
+			// If it was, its read or write bucket is reported.
+			key, max = rl.userBucket(c)
+		}
+		// The budget is reported without counting the request.
+		setRateLimitHeaders(c, rl.store.peek(key, max, time.Now()))
+		// The result of the request is returned.
+		return err
+	}
+}
+
+// limit counts a request against a bucket, and either passes it on or answers it with 429 once the bucket is over its limit.
+// The headers are set before the request is passed on, so that error responses of the handler carry them too.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param key string - The bucket.
+// @param max int - The number of requests the bucket allows in a window.
+// @return error - An error if one occurred.
+func (rl *RateLimits) limit(c *fiber.Ctx, key string, max int) error {
+	// state is the budget of the bucket after counting the request.
+	state := rl.store.take(key, max, time.Now())
+	// The budget is reported.
+	setRateLimitHeaders(c, state)
+	// This checks if the bucket is over its limit.
+	if state.limited {
+		// If it is, the client is told when to retry and a 429 response is returned.
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(state.resetSeconds()))
+		return limitReached(c)
+	}
+	// The request is passed on.
+	return c.Next()
+}
+
+// userBucket returns the read or write bucket of the caller of a request, and its limit.
+// Service accounts have limits of their own, since a machine calls at a steadier rate than a person.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return string - The bucket.
+// @return int - The number of requests the bucket allows in a window.
+func (rl *RateLimits) userBucket(c *fiber.Ctx) (string, int) {
+	// readMax and writeMax are the limits of a person.
+	readMax, writeMax := rl.cfg.RateLimit.ReadMax, rl.cfg.RateLimit.WriteMax
+	// This checks if the request carries the token of a service account.
+	if jwt, err := users.CurrentJWT(c); err == nil && jwt.Kind == users.TokenKindService {
+		// If it does, the limits of service accounts apply.
+		readMax, writeMax = rl.cfg.RateLimit.ServiceReadMax, rl.cfg.RateLimit.ServiceWriteMax
+	}
+	// This checks if the request only reads data.
+	if isReadMethod(c.Method()) {
+		// Requests that only read data use the read bucket.
+		return rateLimitKey(c) + ":read", readMax
+	}
+	// All other requests use the write bucket.
+	return rateLimitKey(c) + ":write", writeMax
+}
+
+// anonymousKey returns the bucket of a request to an endpoint that does not require a user, which is kept apart from the user buckets.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return string - The bucket.
+func anonymousKey(c *fiber.Ctx) string {
+	// The key is the IP address.
+	return "ip:" + c.IP()
+}
+
+// setRateLimitHeaders reports the budget of a bucket in the X-RateLimit headers.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param state rateState - The budget.
+func setRateLimitHeaders(c *fiber.Ctx, state rateState) {
+	// The limit of the bucket is set.
+	c.Set(headerRateLimitLimit, strconv.Itoa(state.limit))
+	// The requests left in the window are set.
+	c.Set(headerRateLimitRemaining, strconv.Itoa(state.remaining))
+	// The seconds until the window resets are set.
+	c.Set(headerRateLimitReset, strconv.Itoa(state.resetSeconds()))
+}
+
+// isReadMethod reports whether a request method only reads data, including the WebDAV methods used by CalDAV.
+//
+// @param method string - The request method.
+// @return bool - True if the method only reads data.
+func isReadMethod(method string) bool {
+	// This selects on the request method.
+	switch method {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions, "PROPFIND", "REPORT":
+		// These methods only read data.
+		return true
+	default:
+		// All other methods change data.
+		return false
+	}
+}
+
+// rateLimitKey returns the caller a request is counted against: the user if one is authenticated, otherwise the token, otherwise the IP address.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return string - The key of the caller.
+func rateLimitKey(c *fiber.Ctx) string {
+	// This checks if a user is authenticated.
+	if user, err := users.CurrentUser(c); err == nil {
+		// If one is, the requests are counted against the user.
+		return "user:" + user.ID.String()
+	}
+	// This checks if a token was validated.
+	if jwt, err := users.CurrentJWT(c); err == nil {
+		// If one was, the requests are counted against the token.
+		return "token:" + jwt.ID.String()
+	}
+	// Otherwise the requests are counted against the IP address.
+	return "ip:" + c.IP()
+}
+
+// limitReached sends the response for a request over the limit.
+// The limiter has already set the Retry-After and X-RateLimit headers.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred while sending the response.
+func limitReached(c *fiber.Ctx) error {
+	// response.TooManyRequests() sends a 429 Too Many Requests response.
+	return response.TooManyRequests(c, i18n.Sprintf(c, "Too many requests, please try again in %s seconds.", c.GetRespHeader(fiber.HeaderRetryAfter)))
 }
 
 // StrictSecurityLimiter is a middleware that provides strict rate limiting for security-sensitive endpoints.
@@ -65,4 +241,4 @@ func StrictSecurityLimiter(cfg *config.Config) fiber.Handler {
 			return c.IP() == cfg.Server.Host
 		},
 	})
-}
\ No newline at end of file
+}
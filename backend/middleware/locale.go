@@ -0,0 +1,37 @@
+// This file defines a middleware for choosing the language of the responses.
+package middleware
+
+// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to create middleware.
+import (
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/backend/i18n" is a local package that translates messages.
+	"github.com/rahulcodepython/todo-backend/backend/i18n"
+)
+
+// Locale is a middleware that negotiates the language of the responses from the Accept-Language header.
+// The user authentication middlewares replace it with the user's own choice when they made one.
+//
+// @return fiber.Handler - The Fiber handler.
+func Locale() fiber.Handler {
+	// This returns a new Fiber handler.
+	return func(c *fiber.Ctx) error {
+		// Caches are told that the response depends on the Accept-Language header.
+		c.Vary(fiber.HeaderAcceptLanguage)
+		// The negotiated language is stored for the request.
+		setLocale(c, i18n.Negotiate(c.Get(fiber.HeaderAcceptLanguage)))
+
+		// c.Next() calls the next middleware in the chain.
+		return c.Next()
+	}
+}
+
+// setLocale stores the language of a request and announces it in the Content-Language header.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param locale string - The language of the request.
+func setLocale(c *fiber.Ctx, locale string) {
+	// The language is stored in the local context.
+	c.Locals("locale", locale)
+	// The Content-Language header is set to the language.
+	c.Set(fiber.HeaderContentLanguage, locale)
+}
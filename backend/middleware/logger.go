@@ -1,13 +1,18 @@
 // This file defines a middleware for logging HTTP requests.
 package middleware
 
-// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to create middleware.
+// "os" provides a platform-independent interface to operating system functionality. It is used here to write the access log to the standard output.
 import (
+	"os"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to create middleware.
 	"github.com/gofiber/fiber/v2"
 	// "github.com/gofiber/fiber/v2/middleware/logger" is a middleware that logs requests.
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
 	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/redact" is a local package that redacts credentials from the logs.
+	"github.com/rahulcodepython/todo-backend/backend/redact"
 )
 
 // Logger is a middleware that logs HTTP requests.
@@ -19,7 +24,7 @@ func Logger(cfg *config.Config) fiber.Handler {
 	// logger.New() returns a new logger middleware with the specified configuration.
 	return logger.New(logger.Config{
 		// Format is the format of the log message.
-		Format: "[${time}] ${protocol}://${ip}:${port} - ${method} : ${status} | ${path} | ${latency} \n", // Time is the timestamp of the log entry.
+		Format: "[${time}] ${protocol}://${ip}:${port} - ${method} : ${status} | ${path} | ${latency} | ${locals:requestid} \n", // Time is the timestamp of the log entry.
 		// Protocol is the protocol used for the request (e.g., HTTP/1.1).
 		// IP is the IP address of the client.
 		// Port is the port number of the server.
@@ -27,6 +32,9 @@ func Logger(cfg *config.Config) fiber.Handler {
 		// Status is the HTTP status code of the response.
 		// Path is the URL path of the request.
 		// Latency is the time taken to process the request.
+		// The request ID is the ID the RequestID middleware gave the request, which the lines of its logger carry as well.
 
+		// Output is where the lines are written, redacted like the lines of the standard logger in case a path carries an email address or a token.
+		Output: redact.NewWriter(os.Stdout),
 	})
 }
@@ -0,0 +1,123 @@
+// This file defines the handler for requests that no route matched.
+package middleware
+
+// "slices" provides functions for working with slices. It is used here to sort and deduplicate the allowed methods.
+import (
+	"slices"
+	// "strings" provides functions for working with strings. It is used here to compare path segments.
+	"strings"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to create middleware and list the routes.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+)
+
+// MethodNotAllowed is a handler for requests that no route matched.
+// It must be registered after every route. When the path is known under other methods it answers
+// OPTIONS with 204 and any other method with 405, both with an Allow header; otherwise it answers 404.
+//
+// @param app *fiber.App - The Fiber application, whose routes are matched against the path.
+// @return fiber.Handler - The Fiber handler.
+func MethodNotAllowed(app *fiber.App) fiber.Handler {
+	// This returns a new Fiber handler.
+	return func(c *fiber.Ctx) error {
+		// allowed is the list of methods registered for the path.
+		allowed := allowedMethods(app, c.Path())
+		// This checks if the path is unknown.
+		if len(allowed) == 0 {
+			// If it is, a not found response is returned.
+			return response.NotFound(c, nil, "Route not found")
+		}
+
+		// This checks if the client asks which methods are allowed.
+		if c.Method() == fiber.MethodOptions {
+			// If it does, the methods are listed without a body.
+			c.Set(fiber.HeaderAllow, strings.Join(allowed, ", "))
+			// A no content response is returned.
+			return c.SendStatus(fiber.StatusNoContent)
+		}
+		// Otherwise a method not allowed response is returned.
+		return response.MethodNotAllowed(c, allowed)
+	}
+}
+
+// allowedMethods lists the methods of the routes that match a path, including OPTIONS.
+//
+// @param app *fiber.App - The Fiber application.
+// @param path string - The path of the request.
+// @return []string - The sorted methods, or nil if no route matches the path.
+func allowedMethods(app *fiber.App, path string) []string {
+	// allowed is the list of methods that match.
+	var allowed []string
+	// This iterates over the routes, leaving out middleware.
+	for _, route := range app.GetRoutes(true) {
+		// This checks if the route matches the path.
+		if routeMatches(route.Path, path) {
+			// If it does, its method is allowed.
+			allowed = append(allowed, route.Method)
+		}
+	}
+	// This checks if no route matches.
+	if len(allowed) == 0 {
+		// If none does, nil is returned.
+		return nil
+	}
+	// OPTIONS is always allowed on a known path.
+	allowed = append(allowed, fiber.MethodOptions)
+	// The methods are sorted.
+	slices.Sort(allowed)
+	// Duplicates are removed and the methods are returned.
+	return slices.Compact(allowed)
+}
+
+// routeMatches reports whether a route path, such as "/api/v1/todos/:id/duplicate", matches a request path.
+// It understands named parameters with an optional suffix, such as ":name.ics", optional parameters, and wildcards.
+//
+// @param pattern string - The path of the route.
+// @param path string - The path of the request.
+// @return bool - True if the route matches the path.
+func routeMatches(pattern string, path string) bool {
+	// patternSegments are the segments of the route path.
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	// pathSegments are the segments of the request path.
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+
+	// This iterates over the segments of the route path.
+	for i, segment := range patternSegments {
+		// This checks if the segment is a wildcard, which matches the rest of the path.
+		if strings.HasPrefix(segment, "*") || strings.HasPrefix(segment, "+") {
+			// If it is, the route matches.
+			return true
+		}
+		// This checks if the request path has no segment left.
+		if i >= len(pathSegments) {
+			// If it has none, the route only matches if the remaining segment is optional.
+			return strings.HasSuffix(segment, "?") && i == len(patternSegments)-1
+		}
+		// This checks if the segment is a parameter.
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			// suffix is the literal text after the parameter name, such as ".ics".
+			var suffix string
+			// This checks if the parameter has a suffix.
+			if index := strings.IndexAny(name, ".-"); index != -1 {
+				// If it has, the suffix is kept.
+				suffix = name[index:]
+			}
+			// This checks if the segment is too short or does not end with the suffix.
+			if len(pathSegments[i]) <= len(suffix) || !strings.HasSuffix(pathSegments[i], suffix) {
+				// If it is, the route does not match.
+				return false
+			}
+			// The next segment is compared.
+			continue
+		}
+		// This checks if the literal segment differs, ignoring case like the router does.
+		if !strings.EqualFold(segment, pathSegments[i]) {
+			// If it does, the route does not match.
+			return false
+		}
+	}
+	// The route matches if every segment of the request path was consumed.
+	return len(patternSegments) == len(pathSegments)
+}
@@ -0,0 +1,190 @@
+// This file defines the in-memory counters of the rate limiters.
+// They count with a sliding window: the requests of the previous window are weighted by how much of it still overlaps the last window length,
+// which smooths the burst a fixed window allows at its boundary.
+package middleware
+
+// "math" provides mathematical functions. It is used here to round the reset time up to whole seconds.
+import (
+	"math"
+	// "sync" provides synchronization primitives. It is used here to guard the counters.
+	"sync"
+	// "time" provides functions for working with time. It is used here to track the windows.
+	"time"
+)
+
+// The names of the headers that report the budget of a caller.
+const (
+	// headerRateLimitLimit is the number of requests the bucket allows in a window.
+	headerRateLimitLimit = "X-RateLimit-Limit"
+	// headerRateLimitRemaining is the number of requests left in the window.
+	headerRateLimitRemaining = "X-RateLimit-Remaining"
+	// headerRateLimitReset is the number of seconds until the window resets.
+	headerRateLimitReset = "X-RateLimit-Reset"
+)
+
+// rateStore holds the request counts of every bucket.
+type rateStore struct {
+	// mu guards the counters.
+	mu sync.Mutex
+	// window is the length of a window.
+	window time.Duration
+	// counters maps each bucket to its counts.
+	counters map[string]*rateCounter
+	// sweptAt is the last time the expired counters were removed.
+	sweptAt time.Time
+}
+
+// rateCounter holds the counts of one bucket.
+type rateCounter struct {
+	// windowEnd is the end of the current window.
+	windowEnd time.Time
+	// previous is the number of requests in the previous window.
+	previous int
+	// current is the number of requests in the current window.
+	current int
+}
+
+// rateState describes the budget of a bucket.
+type rateState struct {
+	// limit is the number of requests the bucket allows in a window.
+	limit int
+	// remaining is the number of requests left, never below zero.
+	remaining int
+	// reset is the time until the current window ends.
+	reset time.Duration
+	// limited reports whether the bucket is over its limit.
+	limited bool
+}
+
+// resetSeconds returns the time until the window ends in whole seconds, rounded up so that a client waiting for it is not limited again.
+//
+// @return int - The seconds until the window ends.
+func (s rateState) resetSeconds() int {
+	// The duration is rounded up to seconds.
+	return int(math.Ceil(s.reset.Seconds()))
+}
+
+// newRateStore creates an empty store.
+//
+// @param window time.Duration - The length of a window.
+// @return *rateStore - A pointer to the new store.
+func newRateStore(window time.Duration) *rateStore {
+	// A new store is returned.
+	return &rateStore{
+		// The window field is set to the length of a window.
+		window: window,
+		// The counters field is set to an empty map.
+		counters: map[string]*rateCounter{},
+	}
+}
+
+// take counts a request against a bucket and returns its budget.
+//
+// @param key string - The bucket.
+// @param limit int - The number of requests the bucket allows in a window.
+// @param now time.Time - The time of the request.
+// @return rateState - The budget after counting the request.
+func (s *rateStore) take(key string, limit int, now time.Time) rateState {
+	// The counters are locked.
+	s.mu.Lock()
+	// This defers unlocking the counters.
+	defer s.mu.Unlock()
+
+	// This removes the expired counters once per window, so that the callers of the past do not pile up.
+	if now.Sub(s.sweptAt) >= s.window {
+		// The counters are swept.
+		s.sweep(now)
+	}
+	// counter is the counts of the bucket.
+	counter, ok := s.counters[key]
+	// This checks if the bucket has no counts yet.
+	if !ok {
+		// If it has none, a window starts with the request.
+		counter = &rateCounter{windowEnd: now.Add(s.window)}
+		s.counters[key] = counter
+	}
+	// The window is moved up to the time of the request.
+	counter.advance(now, s.window)
+	// The request is counted.
+	counter.current++
+	// The budget is returned.
+	return counter.state(limit, now, s.window)
+}
+
+// peek returns the budget of a bucket without counting a request.
+//
+// @param key string - The bucket.
+// @param limit int - The number of requests the bucket allows in a window.
+// @param now time.Time - The time of the request.
+// @return rateState - The budget.
+func (s *rateStore) peek(key string, limit int, now time.Time) rateState {
+	// The counters are locked.
+	s.mu.Lock()
+	// This defers unlocking the counters.
+	defer s.mu.Unlock()
+
+	// counter is the counts of the bucket.
+	counter, ok := s.counters[key]
+	// This checks if the bucket has no counts.
+	if !ok {
+		// If it has none, the whole budget is left.
+		return rateState{limit: limit, remaining: limit, reset: s.window}
+	}
+	// The window is moved up to now.
+	counter.advance(now, s.window)
+	// The budget is returned.
+	return counter.state(limit, now, s.window)
+}
+
+// sweep removes the counters whose previous window no longer overlaps the last window length.
+//
+// @param now time.Time - The current time.
+func (s *rateStore) sweep(now time.Time) {
+	// This iterates over the counters.
+	for key, counter := range s.counters {
+		// This checks if both windows of the counter are over.
+		if !now.Before(counter.windowEnd.Add(s.window)) {
+			// If they are, the counter is removed.
+			delete(s.counters, key)
+		}
+	}
+	// The time of the sweep is kept.
+	s.sweptAt = now
+}
+
+// advance moves the window of a counter up to a time, turning the current count into the previous one when the window ended.
+//
+// @param now time.Time - The time.
+// @param window time.Duration - The length of a window.
+func (rc *rateCounter) advance(now time.Time, window time.Duration) {
+	// This checks if the current window has not ended.
+	if now.Before(rc.windowEnd) {
+		// If it has not, nothing changes.
+		return
+	}
+	// This checks if a whole window passed without a request.
+	if now.Sub(rc.windowEnd) >= window {
+		// If one did, both counts are over and a window starts now.
+		rc.previous, rc.current = 0, 0
+		rc.windowEnd = now.Add(window)
+		return
+	}
+	// Otherwise the next window follows the current one.
+	rc.previous, rc.current = rc.current, 0
+	rc.windowEnd = rc.windowEnd.Add(window)
+}
+
+// state returns the budget of a counter.
+//
+// @param limit int - The number of requests the bucket allows in a window.
+// @param now time.Time - The current time.
+// @param window time.Duration - The length of a window.
+// @return rateState - The budget.
+func (rc *rateCounter) state(limit int, now time.Time, window time.Duration) rateState {
+	// reset is the time until the current window ends.
+	reset := rc.windowEnd.Sub(now)
+	// rate is the requests of the current window plus the share of the previous window that still overlaps the last window length.
+	rate := int(float64(rc.previous)*float64(reset)/float64(window)) + rc.current
+	// The budget is returned, with the remaining requests never below zero.
+	return rateState{limit: limit, remaining: max(0, limit-rate), reset: reset, limited: rate > limit}
+}
@@ -18,4 +18,4 @@ import (
 func Recover(cfg *config.Config) fiber.Handler {
 	// recover.New() returns a new recover middleware with default configuration.
 	return recover.New()
-}
\ No newline at end of file
+}
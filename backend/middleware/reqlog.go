@@ -0,0 +1,30 @@
+// This file defines a middleware that gives each request its logger.
+package middleware
+
+// "strings" provides functions for working with strings. It is used here to copy the fields of the logger out of the buffers of the request.
+import (
+	"strings"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to create middleware.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/backend/reqlog" is a local package that provides the logger of a request.
+	"github.com/rahulcodepython/todo-backend/backend/reqlog"
+)
+
+// RequestLogger is a middleware that stores the logger of a request in its user context, prefixed with the ID of the request and its route.
+// The authentication middlewares add the user to it once they find one.
+// It must run after RequestID, whose ID it reads.
+//
+// @return fiber.Handler - The Fiber handler.
+func RequestLogger() fiber.Handler {
+	// This returns a new Fiber handler.
+	return func(c *fiber.Ctx) error {
+		// requestId is the ID the RequestID middleware gave the request.
+		requestId, _ := c.Locals("requestid").(string)
+		// The logger is stored in the user context, with its fields copied since Fiber reuses the buffers of a request once it is answered,
+		// while a line may still be logged by work the request started in the background.
+		c.SetUserContext(reqlog.WithRequest(c.UserContext(), strings.Clone(requestId), c.Method(), strings.Clone(c.Path())))
+		// The next handler is called.
+		return c.Next()
+	}
+}
@@ -0,0 +1,28 @@
+// This file defines a middleware for tagging each request with an ID.
+package middleware
+
+// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to create middleware.
+import (
+	"github.com/gofiber/fiber/v2"
+	// "github.com/gofiber/fiber/v2/middleware/requestid" is a middleware that sets the X-Request-ID header.
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to generate the request IDs.
+	"github.com/google/uuid"
+)
+
+// RequestID is a middleware that gives each request an ID, keeping the X-Request-ID header of the client if it sent one.
+// The ID is sent back in the X-Request-ID header and stored in the "requestid" local for the response metadata.
+//
+// @return fiber.Handler - The Fiber handler.
+func RequestID() fiber.Handler {
+	// requestid.New() returns a new request ID middleware with the specified configuration.
+	return requestid.New(requestid.Config{
+		// Generator creates the ID of a request that did not bring one.
+		Generator: func() string {
+			// id is a new time-ordered UUID, which does not reveal the number of requests the server handled.
+			id, _ := uuid.NewV7()
+			// The ID is returned as a string.
+			return id.String()
+		},
+	})
+}
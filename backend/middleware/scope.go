@@ -0,0 +1,81 @@
+// This file defines middleware for limiting what an API key can do.
+package middleware
+
+// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to create middleware.
+import (
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains user-related models. It is used here to read the scopes of the token.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+)
+
+// RequireScope is a middleware that only lets a token through if it has the scope of a resource for the method of the request:
+// "<resource>:read" for GET, HEAD, and OPTIONS requests, and "<resource>:write" for every other method.
+// A route that touches several resources needs the scope of each. Sessions have every scope.
+// It should be used after the Authenticated middleware.
+//
+// @param resources ...string - The resources of the route, such as "todos".
+// @return fiber.Handler - The Fiber handler.
+func RequireScope(resources ...string) fiber.Handler {
+	// This returns a new Fiber handler.
+	return func(c *fiber.Ctx) error {
+		// jwt is the JWT of the request.
+		jwt, err := users.CurrentJWT(c)
+		// This checks if there is no authenticated token.
+		if err != nil {
+			// If there is not, it returns an unauthorized access response.
+			return response.UnauthorizedAccess(c, err, "Authentication required")
+		}
+
+		// access is the access level the method needs.
+		access := ":write"
+		// This checks if the method only reads.
+		if isReadMethod(c.Method()) {
+			// If it does, reading is enough.
+			access = ":read"
+		}
+		// This iterates over the resources of the route.
+		for _, resource := range resources {
+			// This checks if the token does not have the scope of the resource.
+			if !jwt.HasScope(resource + access) {
+				// If it does not, it returns a forbidden response that names the scope.
+				return response.InsufficientScope(c, resource+access)
+			}
+		}
+
+		// c.Next() calls the next middleware in the chain.
+		return c.Next()
+	}
+}
+
+// RequireSession is a middleware that rejects API keys and the tokens of guests, for the routes no scope covers,
+// such as the ones that manage API keys, integrations, and notifications. A guest is asked to register first.
+// It should be used after the Authenticated middleware.
+//
+// @return fiber.Handler - The Fiber handler.
+func RequireSession() fiber.Handler {
+	// This returns a new Fiber handler.
+	return func(c *fiber.Ctx) error {
+		// jwt is the JWT of the request.
+		jwt, err := users.CurrentJWT(c)
+		// This checks if there is no authenticated token.
+		if err != nil {
+			// If there is not, it returns an unauthorized access response.
+			return response.UnauthorizedAccess(c, err, "Authentication required")
+		}
+		// This checks if the token belongs to a guest.
+		if jwt.Kind == users.TokenKindGuest {
+			// If it does, it returns a forbidden response that asks the guest to register.
+			return response.RegistrationRequired(c)
+		}
+		// This checks if the token is limited to scopes, which only API keys are.
+		if jwt.Scopes != nil {
+			// If it is, it returns a forbidden response.
+			return response.InsufficientScope(c, "")
+		}
+
+		// c.Next() calls the next middleware in the chain.
+		return c.Next()
+	}
+}
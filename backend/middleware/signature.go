@@ -0,0 +1,52 @@
+// This file defines the middleware that asks internal endpoints for signed requests.
+package middleware
+
+// "errors" provides functions for working with errors. It is used here to recognize the errors of the verifier.
+import (
+	"errors"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to create middleware.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+	// "github.com/rahulcodepython/todo-backend/backend/utils/signing" is a local package that signs and verifies requests.
+	"github.com/rahulcodepython/todo-backend/backend/utils/signing"
+)
+
+// RequireSignature is a middleware that lets a request through only with a valid signature of one of the configured keys,
+// in addition to the token the other middlewares check. It does nothing when no keys are configured.
+//
+// @param cfg *config.Config - The application configuration.
+// @return fiber.Handler - The Fiber handler.
+func RequireSignature(cfg *config.Config) fiber.Handler {
+	// verifier checks the signatures, or is nil when requests are not signed.
+	verifier := signing.NewRequestVerifier(cfg.RequestSigning, clock.System{})
+	// This returns a new Fiber handler.
+	return func(c *fiber.Ctx) error {
+		// This checks if requests are not signed.
+		if verifier == nil {
+			// If they are not, the request is let through.
+			return c.Next()
+		}
+
+		// err is the result of verifying the signature, over the path and query as the client sent them.
+		err := verifier.Verify(c.Get(signing.KeyHeader), c.Get(signing.TimestampHeader), c.Get(signing.SignatureHeader), c.Method(), c.OriginalURL(), c.Body())
+		// This checks if the request is too old.
+		if errors.Is(err, signing.ErrStaleRequest) {
+			// If it is, an unauthorized access response is returned that tells the caller to check its clock.
+			return response.UnauthorizedAccess(c, err, "Request signature has expired")
+		}
+		// This checks if the signature is invalid.
+		if err != nil {
+			// If it is, an unauthorized access response is returned.
+			return response.UnauthorizedAccess(c, err, "Invalid request signature")
+		}
+
+		// c.Next() calls the next middleware in the chain.
+		return c.Next()
+	}
+}
@@ -0,0 +1,51 @@
+// This file defines a middleware that limits how long a request may take.
+package middleware
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to give each request a deadline.
+import (
+	"context"
+	// "errors" provides functions for inspecting errors. It is used here to recognize a passed deadline.
+	"errors"
+	// "time" provides functions for working with time. It is used here to express the budget.
+	"time"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to create middleware.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
+	"github.com/rahulcodepython/todo-backend/backend/response"
+)
+
+// Timeout is a middleware that cancels the context of a request once its budget has passed.
+// The context is the user context of the request, so every query run with c.UserContext() is cancelled with it,
+// and the handler that sees the failed query answers 504 Gateway Timeout instead of 500 through the response package.
+// It can be applied to a group or to a single route, and a budget of zero or less leaves the request unbounded.
+//
+// @param budget time.Duration - How long the request may take.
+// @return fiber.Handler - The Fiber handler.
+func Timeout(budget time.Duration) fiber.Handler {
+	// This returns a new Fiber handler.
+	return func(c *fiber.Ctx) error {
+		// This checks if the request is unbounded.
+		if budget <= 0 {
+			// If it is, the next handler is called.
+			return c.Next()
+		}
+
+		// ctx is the context of the request, cancelled once the budget has passed.
+		ctx, cancel := context.WithTimeout(c.UserContext(), budget)
+		// This defers releasing the timer of the context.
+		defer cancel()
+		// The context is stored as the user context of the request, where the handlers read it.
+		c.SetUserContext(ctx)
+
+		// err is the error returned by the next handlers, if any.
+		err := c.Next()
+		// This checks if a handler returned the passed deadline instead of answering.
+		if errors.Is(err, context.DeadlineExceeded) {
+			// If it did, a gateway timeout response is returned.
+			return response.GatewayTimeout(c, err, "")
+		}
+		// The error, if any, is returned.
+		return err
+	}
+}
@@ -22,29 +22,20 @@ import (
 func AuthenticatedUser(db *sql.DB) fiber.Handler {
 	// This returns a new Fiber handler.
 	return func(c *fiber.Ctx) error {
-		// jwtInterface is the JWT object retrieved from the local context.
-		jwtInterface := c.Locals("jwt")
-
+		// jwt is the JWT object retrieved from the local context.
+		jwt, err := users.CurrentJWT(c)
 		// This checks if the JWT exists in the context.
-		if jwtInterface == nil {
+		if err != nil {
 			// If the JWT does not exist, it returns an unauthorized access response.
-			return response.UnauthorizedAccess(c, nil, "Authentication required")
-		}
-
-		// jwt is the JWT object after type assertion.
-		jwt, ok := jwtInterface.(users.JWT)
-		// This checks if the type assertion was successful.
-		if !ok {
-			// If the type assertion fails, it returns an internal server error response.
-			return response.InternelServerError(c, nil, "Invalid authentication data")
+			return response.UnauthorizedAccess(c, err, "Authentication required")
 		}
 
 		// user is a variable that will hold the user's data.
 		var user users.User
 
 		// err is the result of querying the database for the user's profile.
-		// db.QueryRow() executes a query that is expected to return at most one row.
-		err := db.QueryRow(
+		// db.QueryRowContext() executes a query that is expected to return at most one row, bounded by the deadline of the request.
+		err = db.QueryRowContext(c.UserContext(),
 			// users.GetUserProfileByJWTQuery is the SQL query to retrieve the user's profile.
 			users.GetUserProfileByJWTQuery,
 			// jwt.ID is the ID of the JWT.
@@ -59,6 +50,8 @@ func AuthenticatedUser(db *sql.DB) fiber.Handler {
 			&user.JWT,
 			&user.CreatedAt,
 			&user.UpdatedAt,
+			&user.Timezone,
+			&user.Locale,
 		)
 
 		// This checks if an error occurred while querying the database.
@@ -73,9 +66,14 @@ func AuthenticatedUser(db *sql.DB) fiber.Handler {
 		}
 
 		// The user's data is stored in the local context.
-		c.Locals("user", user)
+		users.SetCurrentUser(c, user)
+		// This checks if the user chose a language.
+		if user.Locale != "" {
+			// If the user did, it replaces the negotiated language.
+			setLocale(c, user.Locale)
+		}
 
 		// c.Next() calls the next middleware in the chain.
 		return c.Next()
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,34 @@
+// This file defines a middleware that tells clients which build answered them.
+package middleware
+
+// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to create middleware.
+import (
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/version" is a local package that holds the version of the build.
+	"github.com/rahulcodepython/todo-backend/backend/version"
+)
+
+// AppVersion is a middleware that sets the X-App-Version header of every response to the version and commit of the build,
+// which helps to tell a client bug from a server that was not yet deployed. It passes requests straight through unless
+// the header is turned on, since the header reveals the exact build to anyone.
+//
+// @param cfg *config.Config - The application configuration.
+// @return fiber.Handler - The Fiber handler.
+func AppVersion(cfg *config.Config) fiber.Handler {
+	// info is the version of the build, which does not change while the server runs.
+	info := version.Current()
+	// value is the header value, such as "1.4.0+3f2c1ab".
+	value := info.Version + "+" + info.Commit
+	// This returns a new Fiber handler.
+	return func(c *fiber.Ctx) error {
+		// This checks if the header is turned on.
+		if cfg.Server.VersionHeader {
+			// If it is, the header is set before the handler runs, so that error responses carry it too.
+			c.Set("X-App-Version", value)
+		}
+		// The request is passed on.
+		return c.Next()
+	}
+}
@@ -0,0 +1,171 @@
+// This file defines backfills, which fill a new column of a large table in small batches with a checkpoint after each,
+// so that no batch holds its rows locked for long and a stopped backfill carries on where it stopped.
+package migrate
+
+// "bytes" provides functions for working with byte slices. It is used here to compare IDs.
+import (
+	"bytes"
+	// "context" provides a way to carry cancellation signals. It is used here to stop a backfill between batches.
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to run each batch in a transaction with its checkpoint.
+	"database/sql"
+	// "errors" provides functions for working with errors. It is used here to define the backfill errors.
+	"errors"
+	// "log" provides logging functions. It is used here to log the progress of a backfill.
+	"log"
+	// "time" provides functions for working with time. It is used here to pause between batches.
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to hold the checkpoint.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database helpers.
+	"github.com/rahulcodepython/todo-backend/backend/database"
+)
+
+// ErrInvalidBackfill is returned when a backfill has no name, no batch statement, or no batch size.
+var ErrInvalidBackfill = errors.New("backfill needs a name, a batch statement, and a batch size")
+
+// Backfill describes a backfill of a table whose rows have UUID keys.
+type Backfill struct {
+	// Name identifies the backfill and its checkpoint, such as "todos_search_title".
+	Name string
+	// Batch is the statement that fills one batch. It takes the checkpoint as $1, which is the zero UUID at the start,
+	// and the batch size as $2, goes over at most that many rows with a greater ID in ID order, and returns the ID of each, such as
+	// "UPDATE todos SET x = ... WHERE id IN (SELECT id FROM todos WHERE id > $1 ORDER BY id LIMIT $2) RETURNING id".
+	// The backfill is finished when a batch returns no rows.
+	Batch string
+	// BatchSize is the number of rows of a batch.
+	BatchSize int
+	// Pause is the wait between batches, which leaves room for the requests, autovacuum, and the replicas.
+	Pause time.Duration
+}
+
+// Progress is called after each batch with the number of rows the backfill went over so far.
+type Progress func(rows int64)
+
+// Run runs a backfill to its end, or until the context is stopped. Each batch runs in one transaction together with its checkpoint,
+// so a batch is either saved with its checkpoint or not at all, and running the backfill again resumes after the last saved batch.
+// The checkpoint row is locked during a batch, so servers that run the same backfill take turns instead of filling the same rows.
+// A finished backfill is not run again.
+//
+// @param ctx context.Context - The context that stops the backfill between batches.
+// @param db *sql.DB - The database connection.
+// @param progress Progress - The function the progress is reported to, or nil.
+// @return error - An error if one occurred.
+func (b Backfill) Run(ctx context.Context, db *sql.DB, progress Progress) error {
+	// This checks if the backfill is described completely.
+	if b.Name == "" || b.Batch == "" || b.BatchSize < 1 {
+		// If it is not, an error is returned.
+		return ErrInvalidBackfill
+	}
+	// This registers the backfill, which starts its checkpoint at the zero UUID.
+	if _, err := db.ExecContext(ctx, CreateBackfillQuery, b.Name); err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// This runs batches until the backfill is finished.
+	for {
+		// done reports whether the backfill is finished, and rows the number of rows it went over so far.
+		var done bool
+		var rows int64
+		// err is the result of running one batch with its checkpoint.
+		err := database.WithTx(ctx, db, func(tx *sql.Tx) error {
+			// err is the error of the batch, if any.
+			var err error
+			done, rows, err = b.batch(ctx, tx)
+			return err
+		})
+		// This checks if an error occurred while running the batch.
+		if err != nil {
+			// If an error occurs, it is returned. The checkpoint is still at the last saved batch.
+			return err
+		}
+		// This checks if progress is reported.
+		if progress != nil {
+			// If it is, the rows so far are reported.
+			progress(rows)
+		}
+		// This checks if the backfill is finished.
+		if done {
+			// If it is, it is logged.
+			log.Printf("Backfill %s finished after %d rows", b.Name, rows)
+			return nil
+		}
+		// This pauses before the next batch, unless the backfill is stopped.
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(b.Pause):
+		}
+	}
+}
+
+// batch runs one batch of a backfill in a transaction and moves its checkpoint.
+//
+// @param ctx context.Context - The context of the batch.
+// @param tx *sql.Tx - The transaction.
+// @return bool - Whether the backfill is finished.
+// @return int64 - The number of rows the backfill went over so far.
+// @return error - An error if one occurred.
+func (b Backfill) batch(ctx context.Context, tx *sql.Tx) (bool, int64, error) {
+	// checkpoint is the ID of the last row of the last saved batch, rows the number of rows so far, and finished whether the backfill ended.
+	var checkpoint uuid.UUID
+	var rows int64
+	var finished bool
+	// This locks the backfill and reads its checkpoint.
+	if err := tx.QueryRowContext(ctx, LockBackfillQuery, b.Name).Scan(&checkpoint, &rows, &finished); err != nil {
+		// If an error occurs, it is returned.
+		return false, 0, err
+	}
+	// This checks if another server finished the backfill meanwhile.
+	if finished {
+		// If one did, there is nothing left to do.
+		return true, rows, nil
+	}
+
+	// result is the result of the batch statement, which returns the IDs it went over.
+	result, err := tx.QueryContext(ctx, b.Batch, checkpoint, b.BatchSize)
+	// This checks if an error occurred while running the batch.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return false, 0, err
+	}
+	// This defers closing the result until the function returns.
+	defer result.Close()
+
+	// last is the greatest ID of the batch, and count the number of its rows.
+	last, count := checkpoint, int64(0)
+	// This iterates over the IDs.
+	for result.Next() {
+		// id is the ID of a row of the batch.
+		var id uuid.UUID
+		// This scans the ID.
+		if err := result.Scan(&id); err != nil {
+			// If an error occurs, it is returned.
+			return false, 0, err
+		}
+		// The row is counted.
+		count++
+		// This checks if the ID is after the greatest so far, in the byte order PostgreSQL sorts UUIDs in.
+		if bytes.Compare(id[:], last[:]) > 0 {
+			// If it is, it becomes the greatest.
+			last = id
+		}
+	}
+	// This checks if an error occurred while reading the IDs.
+	if err := result.Err(); err != nil {
+		// If an error occurs, it is returned.
+		return false, 0, err
+	}
+
+	// This checks if the batch went over no rows.
+	if count == 0 {
+		// If it did not, the backfill is marked as finished.
+		_, err := tx.ExecContext(ctx, FinishBackfillQuery, b.Name)
+		return true, rows, err
+	}
+	// The checkpoint is moved past the batch.
+	_, err = tx.ExecContext(ctx, SaveBackfillQuery, b.Name, last, count)
+	return false, rows + count, err
+}
@@ -0,0 +1,149 @@
+// This file defines helpers that change the schema of large tables without blocking the requests that write to them.
+// A plain CREATE INDEX blocks every write to its table until it finishes, and an ALTER TABLE that waits for a lock
+// queues every later query of the table behind it, so both are run here in a way that leaves the table usable.
+package migrate
+
+// "context" provides a way to carry cancellation signals. It is used here to stop a schema change.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to run the statements on one connection.
+	"database/sql"
+	// "errors" provides functions for working with errors. It is used here to recognize a missing index.
+	"errors"
+	// "fmt" provides functions for formatted I/O. It is used here to build the statements and the errors.
+	"fmt"
+	// "log" provides logging functions. It is used here to log the retries of a schema change.
+	"log"
+	// "time" provides functions for working with time. It is used here to wait between retries.
+	"time"
+
+	// "github.com/lib/pq" is the PostgreSQL driver. It is used here to quote the names of indexes and to recognize a lock timeout.
+	"github.com/lib/pq"
+)
+
+const (
+	// LockTimeout is how long a schema change waits for the lock of its table before it gives up and is retried,
+	// so that it never holds the queries queued behind it for longer.
+	LockTimeout = 2 * time.Second
+	// lockRetries is the number of times a schema change that timed out on its lock is retried.
+	lockRetries = 10
+	// lockRetryDelay is the wait before the first retry, doubled for every retry after it.
+	lockRetryDelay = time.Second
+)
+
+// lockNotAvailable is the SQLSTATE of a statement cancelled by the lock timeout.
+const lockNotAvailable = "55P03"
+
+// AlterTable runs a schema change, such as adding a nullable column, that needs a short exclusive lock of its table.
+// The statement waits at most LockTimeout for the lock and is retried with a growing delay when it times out,
+// so that a long transaction on the table delays the change instead of every request that queues behind it.
+// Changes that rewrite the table, such as adding a column with a volatile default, still block it and should be split into a column and a Backfill.
+//
+// @param ctx context.Context - The context that stops the change.
+// @param db *sql.DB - The database connection.
+// @param statement string - The statement.
+// @return error - An error if the statement failed, or if it could not take the lock after every retry.
+func AlterTable(ctx context.Context, db *sql.DB, statement string) error {
+	// delay is the wait before the next retry.
+	delay := lockRetryDelay
+	// This tries the statement until it takes the lock or runs out of retries.
+	for attempt := 0; ; attempt++ {
+		// err is the result of running the statement with the lock timeout.
+		err := withLockTimeout(ctx, db, func(conn *sql.Conn) error {
+			// The statement is executed.
+			_, err := conn.ExecContext(ctx, statement)
+			return err
+		})
+		// pqErr is the error of PostgreSQL, if the error is one.
+		var pqErr *pq.Error
+		// This checks if the statement did anything other than time out on its lock, or if the retries are used up.
+		if !errors.As(err, &pqErr) || pqErr.Code != lockNotAvailable || attempt == lockRetries {
+			// If so, the result is returned.
+			return err
+		}
+		// The retry is logged, since a schema change that keeps timing out points at a long transaction.
+		log.Printf("Schema change timed out on its lock, retrying in %s: %s", delay, statement)
+		// This waits before the retry, unless the change is stopped.
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		// The delay is doubled for the next retry.
+		delay *= 2
+	}
+}
+
+// CreateIndexConcurrently builds an index of the public schema with CREATE INDEX CONCURRENTLY, which lets writes continue during the build.
+// It does nothing if the index exists and is valid. A concurrent build that failed, or whose server stopped, leaves an invalid index behind,
+// which is dropped concurrently and built again. It must not run inside a transaction, which PostgreSQL refuses for concurrent builds.
+//
+// @param ctx context.Context - The context that stops the build.
+// @param db *sql.DB - The database connection.
+// @param name string - The name of the index.
+// @param definition string - The rest of the statement after the name, such as "ON todos (owner, due_at) WHERE deleted_at IS NULL".
+// @return error - An error if one occurred.
+func CreateIndexConcurrently(ctx context.Context, db *sql.DB, name string, definition string) error {
+	// valid reports whether the index finished building.
+	var valid bool
+	// err is the result of looking up the index.
+	err := db.QueryRowContext(ctx, GetIndexValidQuery, name).Scan(&valid)
+	// This checks if the index exists.
+	switch {
+	case err == nil && valid:
+		// If it exists and is valid, there is nothing to do.
+		return nil
+	case err == nil:
+		// If it exists but is invalid, it is dropped before it is built again.
+		log.Printf("Index %s is invalid, dropping it to build it again", name)
+		// This drops the invalid index without blocking the table.
+		if _, err := db.ExecContext(ctx, "DROP INDEX CONCURRENTLY IF EXISTS "+pq.QuoteIdentifier(name)); err != nil {
+			// If an error occurs, it is returned.
+			return fmt.Errorf("unable to drop invalid index %s: %w", name, err)
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		// If the lookup failed, the error is returned.
+		return err
+	}
+
+	// started is the start of the build, which is logged with its duration.
+	started := time.Now()
+	// This builds the index without blocking writes.
+	if _, err := db.ExecContext(ctx, "CREATE INDEX CONCURRENTLY "+pq.QuoteIdentifier(name)+" "+definition); err != nil {
+		// If an error occurs, it is returned. The invalid index it leaves behind is dropped by the next call.
+		return fmt.Errorf("unable to create index %s: %w", name, err)
+	}
+	// The build is logged.
+	log.Printf("Index %s created in %s", name, time.Since(started).Round(time.Millisecond))
+	return nil
+}
+
+// withLockTimeout runs a function on a connection whose statements wait at most LockTimeout for a lock.
+// The timeout is reset before the connection returns to the pool, so that requests are not affected by it.
+//
+// @param ctx context.Context - The context of the function.
+// @param db *sql.DB - The database connection.
+// @param run func(conn *sql.Conn) error - The function.
+// @return error - The error of the function, or another error if one occurred.
+func withLockTimeout(ctx context.Context, db *sql.DB, run func(conn *sql.Conn) error) error {
+	// conn is a connection of the pool, reserved for the function.
+	conn, err := db.Conn(ctx)
+	// This checks if an error occurred while reserving the connection.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// This defers returning the connection to the pool until the function returns.
+	defer conn.Close()
+
+	// This sets the lock timeout of the session.
+	if _, err := conn.ExecContext(ctx, SetLockTimeoutQuery, fmt.Sprintf("%dms", LockTimeout.Milliseconds())); err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// This defers resetting the lock timeout, with a context that still works if the function was stopped.
+	defer conn.ExecContext(context.WithoutCancel(ctx), ResetLockTimeoutQuery)
+
+	// The function is run.
+	return run(conn)
+}
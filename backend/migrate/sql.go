@@ -0,0 +1,32 @@
+// This file defines the SQL queries used by the schema change helpers.
+package migrate
+
+// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names and schemas.
+import "github.com/rahulcodepython/todo-backend/backend/utils"
+
+// SetLockTimeoutQuery is the SQL query to limit how long a statement of the session waits for a lock.
+const SetLockTimeoutQuery = "SELECT set_config('lock_timeout', $1, false)"
+
+// ResetLockTimeoutQuery is the SQL query to restore the lock timeout of the session before it returns to the pool.
+const ResetLockTimeoutQuery = "RESET lock_timeout"
+
+// GetIndexValidQuery is the SQL query to check if an index of the public schema exists and finished building.
+const GetIndexValidQuery = "SELECT i.indisvalid FROM pg_class AS c JOIN pg_index AS i ON i.indexrelid = c.oid WHERE c.relname = $1 AND c.relnamespace = 'public'::regnamespace"
+
+// CreateBackfillQuery is the SQL query to register a backfill, unless it is registered already.
+const CreateBackfillQuery = "INSERT INTO " + utils.SchemaBackfillTableName + " (name) VALUES ($1) ON CONFLICT (name) DO NOTHING"
+
+// LockBackfillQuery is the SQL query to lock a backfill and read its checkpoint, so that only one server runs a batch of it at a time.
+const LockBackfillQuery = "SELECT checkpoint, rows_done, finished_at IS NOT NULL FROM " + utils.SchemaBackfillTableName + " WHERE name = $1 FOR UPDATE"
+
+// SaveBackfillQuery is the SQL query to move the checkpoint of a backfill after a batch.
+const SaveBackfillQuery = "UPDATE " + utils.SchemaBackfillTableName + " SET checkpoint = $2, rows_done = rows_done + $3, updated_at = NOW() WHERE name = $1"
+
+// FinishBackfillQuery is the SQL query to mark a backfill as finished.
+const FinishBackfillQuery = "UPDATE " + utils.SchemaBackfillTableName + " SET finished_at = NOW(), updated_at = NOW() WHERE name = $1"
+
+// GetTogglesQuery is the SQL query to read every toggle.
+const GetTogglesQuery = "SELECT name, enabled FROM " + utils.SchemaToggleTableName
+
+// SetToggleQuery is the SQL query to turn a toggle on or off.
+const SetToggleQuery = "INSERT INTO " + utils.SchemaToggleTableName + " (name, enabled) VALUES ($1, $2) ON CONFLICT (name) DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = NOW()"
@@ -0,0 +1,128 @@
+// This file defines the toggles of dual writes, which a schema change turns on while the code writes both the old and the new shape of a column.
+// Toggles are stored in the database rather than the environment, so that every server follows a switch without a restart.
+package migrate
+
+// "context" provides a way to carry deadlines. It is used here to bound the reads of the toggles.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to read and write the toggles.
+	"database/sql"
+	// "log" provides logging functions. It is used here to log a failed refresh.
+	"log"
+	// "sync" provides synchronization primitives. It is used here to guard the cached toggles.
+	"sync"
+	// "time" provides functions for working with time. It is used here to refresh the toggles.
+	"time"
+
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+)
+
+// Toggles reads the dual write toggles, caching them for a refresh interval so that a write does not read them every time.
+// A server follows a switch within the refresh interval, so the code that reads with the new shape should only be deployed once every server has had that long to start writing it.
+type Toggles struct {
+	// db is the database connection.
+	db *sql.DB
+	// clock tells the current time.
+	clock clock.Clock
+	// refresh is how long the cached toggles are used.
+	refresh time.Duration
+	// mu guards the cached toggles.
+	mu sync.Mutex
+	// values are the cached toggles, by name.
+	values map[string]bool
+	// loadedAt is when the toggles were last read.
+	loadedAt time.Time
+}
+
+// NewToggles creates the reader of the dual write toggles.
+//
+// @param db *sql.DB - The database connection.
+// @param clk clock.Clock - The clock that times the refresh.
+// @param refresh time.Duration - How long the cached toggles are used.
+// @return *Toggles - The reader.
+func NewToggles(db *sql.DB, clk clock.Clock, refresh time.Duration) *Toggles {
+	// The reader is returned with no toggles loaded, so that the first call reads them.
+	return &Toggles{db: db, clock: clk, refresh: refresh}
+}
+
+// Enabled reports whether a toggle is on. A toggle that was never set is off.
+// If the toggles cannot be read, the last read values are kept, so that a brief database error does not flip the writes.
+//
+// @param ctx context.Context - The context of the caller, such as the request context.
+// @param name string - The name of the toggle, such as "todos_dual_write_search_title".
+// @return bool - Whether the toggle is on.
+func (t *Toggles) Enabled(ctx context.Context, name string) bool {
+	// The cached toggles are locked while they are read or refreshed.
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// now is the current time.
+	now := t.clock.Now()
+	// This checks if the cached toggles are older than the refresh interval.
+	if t.values == nil || now.Sub(t.loadedAt) >= t.refresh {
+		// values is the result of reading the toggles.
+		values, err := loadToggles(ctx, t.db)
+		// This checks if an error occurred while reading the toggles.
+		if err != nil {
+			// If an error occurs, it is logged and the cached toggles are kept.
+			log.Printf("Unable to refresh schema toggles: %v", err)
+		} else {
+			// Otherwise the cached toggles are replaced.
+			t.values = values
+		}
+		// The time of the read is kept either way, so that a failing database is not read on every call.
+		t.loadedAt = now
+	}
+	// The toggle is returned.
+	return t.values[name]
+}
+
+// SetToggle turns a toggle on or off.
+//
+// @param ctx context.Context - The context of the caller.
+// @param db *sql.DB - The database connection.
+// @param name string - The name of the toggle.
+// @param enabled bool - Whether the toggle is turned on.
+// @return error - An error if one occurred.
+func SetToggle(ctx context.Context, db *sql.DB, name string, enabled bool) error {
+	// The toggle is written.
+	_, err := db.ExecContext(ctx, SetToggleQuery, name, enabled)
+	return err
+}
+
+// loadToggles reads every toggle.
+//
+// @param ctx context.Context - The context of the read.
+// @param db *sql.DB - The database connection.
+// @return map[string]bool - The toggles, by name.
+// @return error - An error if one occurred.
+func loadToggles(ctx context.Context, db *sql.DB) (map[string]bool, error) {
+	// rows is the result of reading the toggles.
+	rows, err := db.QueryContext(ctx, GetTogglesQuery)
+	// This checks if an error occurred while reading the toggles.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// This defers closing the rows until the function returns.
+	defer rows.Close()
+
+	// values holds the toggles.
+	values := map[string]bool{}
+	// This iterates over the rows.
+	for rows.Next() {
+		// name and enabled are the columns of the toggle.
+		var name string
+		var enabled bool
+		// This scans the toggle.
+		if err := rows.Scan(&name, &enabled); err != nil {
+			// If an error occurs, it is returned.
+			return nil, err
+		}
+		// The toggle is added.
+		values[name] = enabled
+	}
+	// The toggles and the error of the iteration, if any, are returned.
+	return values, rows.Err()
+}
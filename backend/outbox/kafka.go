@@ -0,0 +1,69 @@
+// This file defines the publisher that sends events to Kafka.
+package outbox
+
+// "context" provides a way to carry cancellation signals. It is used here to cancel a write on shutdown.
+import (
+	"context"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to encode the event.
+	"encoding/json"
+
+	// "github.com/segmentio/kafka-go" is a Kafka client. It is used here to write the events.
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaPublisher writes events to a Kafka topic, keyed by aggregate so that the events of one todo, list, or user stay in one partition and in order.
+type KafkaPublisher struct {
+	// writer is the Kafka writer.
+	writer *kafka.Writer
+}
+
+// NewKafkaPublisher creates a new KafkaPublisher.
+//
+// @param brokers []string - The addresses of the Kafka brokers.
+// @param topic string - The topic the events are written to.
+// @return *KafkaPublisher - A pointer to the new KafkaPublisher.
+func NewKafkaPublisher(brokers []string, topic string) *KafkaPublisher {
+	// A new KafkaPublisher is returned.
+	return &KafkaPublisher{
+		// The writer field is set to a writer that waits for all in-sync replicas, so that an acknowledged event is not lost.
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+		},
+	}
+}
+
+// Publish writes the event and waits until the brokers have acknowledged it.
+//
+// @param ctx context.Context - The context of the relay.
+// @param event Event - The event.
+// @return error - An error if the brokers did not acknowledge the event.
+func (kp *KafkaPublisher) Publish(ctx context.Context, event Event) error {
+	// body is the encoded event.
+	body, err := json.Marshal(event)
+	// This checks if an error occurred while encoding the event.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// The message is written with the aggregate as its key and headers that describe the event.
+	return kp.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.AggregateID.String()),
+		Value: body,
+		Headers: []kafka.Header{
+			{Key: "event-id", Value: []byte(event.ID.String())},
+			{Key: "event-type", Value: []byte(event.Type)},
+		},
+	})
+}
+
+// Close flushes and closes the writer.
+//
+// @return error - An error if one occurred.
+func (kp *KafkaPublisher) Close() error {
+	// The writer is closed.
+	return kp.writer.Close()
+}
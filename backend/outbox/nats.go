@@ -0,0 +1,78 @@
+// This file defines the publisher that sends events to NATS.
+package outbox
+
+// "context" provides a way to carry cancellation signals. It is used here to bound the flush.
+import (
+	"context"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to encode the event.
+	"encoding/json"
+
+	// "github.com/nats-io/nats.go" is the NATS client. It is used here to publish the events.
+	"github.com/nats-io/nats.go"
+)
+
+// NATSPublisher publishes events to NATS subjects named after the event type, such as "todo.events.todo.completed".
+type NATSPublisher struct {
+	// conn is the connection to the NATS server.
+	conn *nats.Conn
+	// subjectPrefix is prepended to the event type to form the subject.
+	subjectPrefix string
+}
+
+// NewNATSPublisher connects to NATS and creates a new NATSPublisher.
+// The connection is retried in the background, so a NATS server that is down at startup only delays publishing.
+//
+// @param url string - The URL of the NATS server.
+// @param subjectPrefix string - The prefix of the subjects.
+// @return *NATSPublisher - A pointer to the new NATSPublisher.
+// @return error - An error if the connection could not be set up.
+func NewNATSPublisher(url string, subjectPrefix string) (*NATSPublisher, error) {
+	// conn is the connection to the NATS server.
+	conn, err := nats.Connect(url, nats.Name("todo-backend outbox"), nats.RetryOnFailedConnect(true), nats.MaxReconnects(-1))
+	// This checks if an error occurred while connecting.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// A new NATSPublisher is returned.
+	return &NATSPublisher{conn: conn, subjectPrefix: subjectPrefix}, nil
+}
+
+// Publish sends the event and waits until the server has received it.
+// The Nats-Msg-Id header carries the event ID, so a JetStream stream on the subjects drops duplicates.
+//
+// @param ctx context.Context - The context of the relay.
+// @param event Event - The event.
+// @return error - An error if the server did not receive the event.
+func (np *NATSPublisher) Publish(ctx context.Context, event Event) error {
+	// body is the encoded event.
+	body, err := json.Marshal(event)
+	// This checks if an error occurred while encoding the event.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// msg is the NATS message.
+	msg := nats.NewMsg(np.subjectPrefix + "." + event.Type)
+	// The body and headers describe the event.
+	msg.Data = body
+	msg.Header.Set(nats.MsgIdHdr, event.ID.String())
+	msg.Header.Set("Event-Type", event.Type)
+
+	// This publishes the message.
+	if err := np.conn.PublishMsg(msg); err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// The flush returns once the server has processed the message, or fails if it is unreachable.
+	return np.conn.FlushWithContext(ctx)
+}
+
+// Close drains the connection so that buffered messages are sent before it closes.
+//
+// @return error - An error if one occurred.
+func (np *NATSPublisher) Close() error {
+	// The connection is drained.
+	return np.conn.Drain()
+}
@@ -0,0 +1,103 @@
+// This file defines domain events and records them in the outbox.
+// Events are written in the same transaction as the change they describe, so an event exists if and only if the change was committed.
+package outbox
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to bound the insert of an event by the deadline of the caller.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to write events inside the caller's transaction.
+	"database/sql"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to encode the event data.
+	"encoding/json"
+	// "time" provides functions for working with time. It is used here to define the event time.
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to generate event IDs.
+	"github.com/google/uuid"
+)
+
+const (
+	// UserRegistered is recorded when a user registers.
+	UserRegistered = "user.registered"
+	// TodoCreated is recorded when a todo is created, including duplicates and todos added through integrations.
+	TodoCreated = "todo.created"
+	// TodoUpdated is recorded when the fields of a todo change.
+	TodoUpdated = "todo.updated"
+	// TodoCompleted is recorded when a todo is marked as completed.
+	TodoCompleted = "todo.completed"
+	// TodoReopened is recorded when a todo is marked as not completed.
+	TodoReopened = "todo.reopened"
+	// TodoMoved is recorded for every todo that is moved into or out of a list.
+	TodoMoved = "todo.moved"
+	// TodoDeleted is recorded when a todo is deleted.
+	TodoDeleted = "todo.deleted"
+	// TodoRestored is recorded when a deletion is undone.
+	TodoRestored = "todo.restored"
+	// ListCreated is recorded when a list is created.
+	ListCreated = "list.created"
+	// ListUpdated is recorded when a list is renamed, restyled, archived, or unarchived.
+	ListUpdated = "list.updated"
+	// ListCompleted is recorded when every open todo of a list is completed at once. Its data names the completed todos.
+	ListCompleted = "list.completed"
+	// ListReordered is recorded when the todos of a list are reordered.
+	ListReordered = "list.reordered"
+	// ListDeleted is recorded when a list is deleted.
+	ListDeleted = "list.deleted"
+)
+
+// Event defines the structure of a domain event as it is published.
+type Event struct {
+	// ID is the unique ID of the event. Consumers use it to drop events they have already seen.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// Type is one of the event type constants.
+	// json:"type" specifies that this field should be marshalled to/from a JSON object with the key "type".
+	Type string `json:"type"`
+	// AggregateID is the ID of the user, todo, or list the event is about. Events of one aggregate are published in order.
+	// json:"aggregate_id" specifies that this field should be marshalled to/from a JSON object with the key "aggregate_id".
+	AggregateID uuid.UUID `json:"aggregate_id"`
+	// Owner is the ID of the user the aggregate belongs to.
+	// json:"owner" specifies that this field should be marshalled to/from a JSON object with the key "owner".
+	Owner uuid.UUID `json:"owner"`
+	// OccurredAt is the time the change was committed.
+	// json:"occurred_at" specifies that this field should be marshalled to/from a JSON object with the key "occurred_at".
+	OccurredAt time.Time `json:"occurred_at"`
+	// Data is the state of the aggregate after the change.
+	// json:"data" specifies that this field should be marshalled to/from a JSON object with the key "data".
+	Data json.RawMessage `json:"data"`
+}
+
+// DeletedData defines the structure for the data of a deletion event.
+type DeletedData struct {
+	// ID is the ID of the deleted aggregate.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+}
+
+// Record writes a domain event to the outbox inside the given transaction.
+// The caller must commit the transaction for the event to be published.
+//
+// @param ctx context.Context - The context of the caller, which carries its deadline.
+// @param tx *sql.Tx - The transaction of the change.
+// @param eventType string - One of the event type constants.
+// @param ownerId uuid.UUID - The ID of the user the aggregate belongs to.
+// @param aggregateId uuid.UUID - The ID of the user, todo, or list the event is about.
+// @param data any - The data of the event, encoded as JSON.
+// @return error - An error if one occurred.
+func Record(ctx context.Context, tx *sql.Tx, eventType string, ownerId uuid.UUID, aggregateId uuid.UUID, data any) error {
+	// payload is the data encoded as JSON.
+	payload, err := json.Marshal(data)
+	// This checks if an error occurred while encoding the data.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// eventId is the new UUID for the event.
+	eventId, _ := uuid.NewV7()
+
+	// _, err is the result of executing the SQL query to write the event.
+	_, err = tx.ExecContext(ctx, CreateEventQuery, eventId, eventType, aggregateId, ownerId, payload)
+	// The error, if any, is returned.
+	return err
+}
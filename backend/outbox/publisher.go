@@ -0,0 +1,180 @@
+// This file defines where the relay publishes events.
+package outbox
+
+// "bytes" provides functions for working with byte slices. It is used here to build the request body.
+import (
+	"bytes"
+	// "context" provides a way to carry cancellation signals. It is used here to cancel a publish on shutdown.
+	"context"
+	// "crypto/hmac" implements HMAC. It is used here to sign webhook requests.
+	"crypto/hmac"
+	// "crypto/sha256" implements SHA-256. It is used here as the HMAC hash.
+	"crypto/sha256"
+	// "encoding/hex" implements hexadecimal encoding. It is used here to encode the signature.
+	"encoding/hex"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to encode the event.
+	"encoding/json"
+	// "fmt" provides functions for formatted I/O. It is used here to build errors.
+	"fmt"
+	// "net/http" provides HTTP client and server implementations. It is used here to call the webhook.
+	"net/http"
+	// "strings" provides functions for working with strings. It is used here to split the broker list.
+	"strings"
+	// "time" provides functions for working with time. It is used here to set the request timeout.
+	"time"
+
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+// Publisher is implemented by every destination the relay can publish events to.
+// Publish must return nil only once the destination has accepted the event; any error makes the relay retry it later.
+type Publisher interface {
+	// Publish delivers one event.
+	Publish(ctx context.Context, event Event) error
+	// Close releases the connection to the destination when the relay stops.
+	Close() error
+}
+
+// NewPublisher creates the publisher selected by the configuration.
+// When no destination is configured, events are discarded once published so that the outbox does not grow without bound.
+//
+// @param cfg *config.Config - The application configuration.
+// @return Publisher - The publisher.
+// @return error - An error if the publisher is unknown or could not be created.
+func NewPublisher(cfg *config.Config) (Publisher, error) {
+	// This selects the publisher.
+	switch cfg.Outbox.Publisher {
+	case "nats":
+		// A NATS publisher is returned.
+		return NewNATSPublisher(cfg.Outbox.NATSURL, cfg.Outbox.NATSSubjectPrefix)
+	case "kafka":
+		// A Kafka publisher is returned.
+		return NewKafkaPublisher(strings.Split(cfg.Outbox.KafkaBrokers, ","), cfg.Outbox.KafkaTopic), nil
+	case "webhook":
+		// A webhook publisher is returned.
+		return NewWebhookPublisher(cfg.Outbox.WebhookURL, cfg.Outbox.WebhookSecret), nil
+	case "":
+		// This checks if a webhook is configured.
+		if cfg.Outbox.WebhookURL != "" {
+			// If it is, a webhook publisher is returned.
+			return NewWebhookPublisher(cfg.Outbox.WebhookURL, cfg.Outbox.WebhookSecret), nil
+		}
+		// Otherwise the events are discarded.
+		return discardPublisher{}, nil
+	}
+	// Any other value is a configuration error.
+	return nil, fmt.Errorf("unknown outbox publisher %q", cfg.Outbox.Publisher)
+}
+
+// discardPublisher accepts every event without delivering it.
+type discardPublisher struct{}
+
+// Publish accepts the event.
+//
+// @param ctx context.Context - The context of the relay.
+// @param event Event - The event.
+// @return error - Always nil.
+func (discardPublisher) Publish(ctx context.Context, event Event) error {
+	// The event is accepted.
+	return nil
+}
+
+// Close does nothing.
+//
+// @return error - Always nil.
+func (discardPublisher) Close() error {
+	// There is nothing to release.
+	return nil
+}
+
+// WebhookPublisher posts events as JSON to a URL.
+type WebhookPublisher struct {
+	// url is the URL the events are posted to.
+	url string
+	// secret is used to sign the request body. Requests are not signed when it is empty.
+	secret string
+	// http is the HTTP client used for the calls.
+	http *http.Client
+}
+
+// NewWebhookPublisher creates a new WebhookPublisher.
+//
+// @param url string - The URL the events are posted to.
+// @param secret string - The secret used to sign the request body.
+// @return *WebhookPublisher - A pointer to the new WebhookPublisher.
+func NewWebhookPublisher(url string, secret string) *WebhookPublisher {
+	// A new WebhookPublisher is returned.
+	return &WebhookPublisher{
+		// The url field is set to the webhook URL.
+		url: url,
+		// The secret field is set to the signing secret.
+		secret: secret,
+		// The http field is set to a client with a timeout so that a slow receiver does not block the relay.
+		http: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Publish posts the event to the webhook.
+// The X-Event-ID header lets the receiver drop duplicates, and X-Signature-256 carries an HMAC-SHA256 of the body.
+//
+// @param ctx context.Context - The context of the relay.
+// @param event Event - The event.
+// @return error - An error if the receiver did not accept the event.
+func (wp *WebhookPublisher) Publish(ctx context.Context, event Event) error {
+	// body is the encoded event.
+	body, err := json.Marshal(event)
+	// This checks if an error occurred while encoding the event.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// req is the webhook request.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wp.url, bytes.NewReader(body))
+	// This checks if an error occurred while building the request.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// The headers describe the event.
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", event.ID.String())
+	req.Header.Set("X-Event-Type", event.Type)
+	// This checks if the requests are signed.
+	if wp.secret != "" {
+		// mac is the HMAC of the body.
+		mac := hmac.New(sha256.New, []byte(wp.secret))
+		mac.Write(body)
+		// The signature header is set.
+		req.Header.Set("X-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	// res is the response of the receiver.
+	res, err := wp.http.Do(req)
+	// This checks if an error occurred while calling the webhook.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// This defers the closing of the response body until the function returns.
+	defer res.Body.Close()
+
+	// This checks if the receiver rejected the event.
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		// If it did, an error with the status is returned.
+		return fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+
+	// No error is returned.
+	return nil
+}
+
+// Close closes the idle connections to the receiver.
+//
+// @return error - Always nil.
+func (wp *WebhookPublisher) Close() error {
+	// The idle connections are closed.
+	wp.http.CloseIdleConnections()
+	return nil
+}
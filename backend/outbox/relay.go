@@ -0,0 +1,186 @@
+// This file defines the relay that publishes outbox events.
+// Delivery is at least once: an event is marked as published only after the publisher accepted it,
+// so a crash in between publishes it again with the same ID, which consumers use to drop the duplicate.
+package outbox
+
+// "context" provides a way to carry cancellation signals. It is used here to stop the relay on shutdown.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to claim and update events.
+	"database/sql"
+	// "log" provides a simple logging package. It is used here to log failed passes.
+	"log"
+	// "time" provides functions for working with time. It is used here to schedule the relay and retries.
+	"time"
+
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database helpers.
+	"github.com/rahulcodepython/todo-backend/backend/database"
+)
+
+// maxRetryDelay is the longest wait between two attempts of an event.
+const maxRetryDelay = time.Hour
+
+// StartRelay publishes pending events and prunes old ones until the context is cancelled.
+//
+// @param ctx context.Context - The context that stops the relay.
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @param publisher Publisher - The destination of the events.
+func StartRelay(ctx context.Context, cfg *config.Config, db *sql.DB, publisher Publisher) {
+	// ticker fires once every relay interval.
+	ticker := time.NewTicker(cfg.Outbox.Interval)
+	// This defers stopping the ticker until the relay returns.
+	defer ticker.Stop()
+	// This defers closing the publisher until the relay returns.
+	defer publisher.Close()
+
+	// This loops until the context is cancelled.
+	for {
+		// This waits for either the next tick or the cancellation.
+		select {
+		case <-ctx.Done():
+			// If the context is cancelled, the relay returns.
+			return
+		case <-ticker.C:
+			// On every tick, pending events are published until a pass comes back short or the relay is stopped.
+			for publishPending(ctx, cfg, db, publisher) == cfg.Outbox.BatchSize && ctx.Err() == nil {
+				// The next batch is published straight away.
+				continue
+			}
+			// Old events are pruned.
+			if _, err := db.ExecContext(ctx, PruneEventsQuery, time.Now().Add(-cfg.Outbox.Retention)); err != nil {
+				// If an error occurs, it is logged.
+				log.Printf("Unable to prune outbox: %v", err)
+			}
+		}
+	}
+}
+
+// publishPending claims a batch of pending events, publishes them in order, and records the outcome of each.
+// Only one relay publishes at a time, even with several instances running, so that events leave in order.
+//
+// @param ctx context.Context - The context of the relay.
+// @param cfg *config.Config - The application configuration.
+// @param db *sql.DB - The database connection.
+// @param publisher Publisher - The destination of the events.
+// @return int - The number of events claimed.
+func publishPending(ctx context.Context, cfg *config.Config, db *sql.DB, publisher Publisher) int {
+	// claimed is the number of events claimed.
+	claimed := 0
+
+	// err is the result of publishing the batch in one transaction. The transaction is not run again after a serialization
+	// failure or a deadlock, since that would publish the events of the batch twice; the next pass claims them instead.
+	err := database.WithTxOnce(ctx, db, func(tx *sql.Tx) error {
+		// locked reports whether this relay holds the relay lock.
+		var locked bool
+		// This tries to take the relay lock.
+		if err := tx.QueryRowContext(ctx, TryRelayLockQuery).Scan(&locked); err != nil || !locked {
+			// If another relay holds it, or an error occurs, the pass ends.
+			return err
+		}
+
+		// rows is the result of claiming the pending events.
+		rows, err := tx.QueryContext(ctx, ClaimPendingEventsQuery, cfg.Outbox.BatchSize)
+		// This checks if an error occurred while claiming the events.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+
+		// events is a slice that will hold the claimed events.
+		var events []pendingEvent
+		// This iterates over the rows.
+		for rows.Next() {
+			// event is a new pendingEvent struct.
+			var event pendingEvent
+			// This scans the row into the event struct.
+			if err := rows.Scan(&event.rowId, &event.ID, &event.Type, &event.AggregateID, &event.Owner, &event.Data, &event.OccurredAt, &event.attempts); err != nil {
+				// If an error occurs, the rows are closed and the error is returned.
+				rows.Close()
+				return err
+			}
+			// The event is appended to the events slice.
+			events = append(events, event)
+		}
+		// The rows are closed before any event is published.
+		rows.Close()
+		// claimed is set to the number of events.
+		claimed = len(events)
+
+		// failed holds the aggregates with a failed event in this batch, whose later events must wait.
+		failed := map[string]bool{}
+		// This iterates over the claimed events.
+		for _, event := range events {
+			// This checks if an earlier event of the same aggregate failed.
+			if failed[event.AggregateID.String()] {
+				// If one did, the event is left for a later pass.
+				continue
+			}
+
+			// This publishes the event.
+			if err := publisher.Publish(ctx, event.Event); err != nil {
+				// The aggregate is held back for the rest of the batch.
+				failed[event.AggregateID.String()] = true
+				// attempts is the number of attempts including this one.
+				attempts := event.attempts + 1
+				// giveUp reports whether the attempt limit was reached.
+				giveUp := attempts >= cfg.Outbox.MaxAttempts
+				// This records the failed attempt.
+				if _, err := tx.ExecContext(ctx, MarkEventRetryQuery, event.rowId, err.Error(), time.Now().Add(retryDelay(attempts)), giveUp); err != nil {
+					// If an error occurs, it is returned.
+					return err
+				}
+				// This checks if the event was given up on.
+				if giveUp {
+					// If it was, it is logged, since its aggregate's later events are now published without it.
+					log.Printf("Giving up on outbox event %s after %d attempts: %v", event.ID, attempts, err)
+				}
+				continue
+			}
+
+			// This marks the event as published.
+			if _, err := tx.ExecContext(ctx, MarkEventPublishedQuery, event.rowId); err != nil {
+				// If an error occurs, it is returned.
+				return err
+			}
+		}
+
+		// No error is returned.
+		return nil
+	})
+	// This checks if an error occurred while publishing the batch.
+	if err != nil {
+		// If an error occurs, it is logged and the pass is reported as empty.
+		log.Printf("Unable to publish outbox events: %v", err)
+		return 0
+	}
+
+	// The number of claimed events is returned.
+	return claimed
+}
+
+// retryDelay returns how long to wait before the next attempt of an event, doubling with every attempt.
+//
+// @param attempts int - The number of attempts made so far.
+// @return time.Duration - The delay.
+func retryDelay(attempts int) time.Duration {
+	// This checks if the delay would exceed the maximum.
+	if attempts > 12 {
+		// If it would, the maximum is returned.
+		return maxRetryDelay
+	}
+	// The delay doubles with every attempt, starting at one second.
+	return min(time.Second<<attempts, maxRetryDelay)
+}
+
+// pendingEvent is an event claimed from the outbox.
+type pendingEvent struct {
+	// Event is the event as it is published.
+	Event
+	// rowId is the ID of the outbox row.
+	rowId int64
+	// attempts is the number of attempts made so far.
+	attempts int
+}
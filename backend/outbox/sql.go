@@ -0,0 +1,27 @@
+// This file defines the SQL queries used by the outbox.
+package outbox
+
+// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names and schemas.
+import "github.com/rahulcodepython/todo-backend/backend/utils"
+
+// CreateEventQuery is the SQL query to write an event to the outbox.
+const CreateEventQuery = "INSERT INTO " + utils.OutboxTableName + " (" + utils.OutboxTableSchema + ") VALUES ($1, $2, $3, $4, $5)"
+
+// TryRelayLockQuery is the SQL query to take the transaction-level advisory lock that lets only one relay publish at a time.
+const TryRelayLockQuery = "SELECT pg_try_advisory_xact_lock(hashtext('outbox_relay'))"
+
+// ClaimPendingEventsQuery is the SQL query to lock the next events that are due for publishing, in order.
+// An event is held back while an earlier event of the same aggregate waits for a retry, so that each aggregate's events stay in order.
+const ClaimPendingEventsQuery = `SELECT id, event_id, event_type, aggregate_id, owner, payload, created_at, attempts FROM ` + utils.OutboxTableName + ` AS pending
+	WHERE published_at IS NULL AND failed_at IS NULL AND next_attempt_at <= NOW()
+	AND NOT EXISTS (SELECT 1 FROM ` + utils.OutboxTableName + ` AS earlier WHERE earlier.aggregate_id = pending.aggregate_id AND earlier.id < pending.id AND earlier.published_at IS NULL AND earlier.failed_at IS NULL AND earlier.next_attempt_at > NOW())
+	ORDER BY id LIMIT $1 FOR UPDATE`
+
+// MarkEventPublishedQuery is the SQL query to mark an event as published.
+const MarkEventPublishedQuery = "UPDATE " + utils.OutboxTableName + " SET published_at = NOW(), attempts = attempts + 1, last_error = NULL WHERE id = $1"
+
+// MarkEventRetryQuery is the SQL query to record a failed attempt and schedule the next one, or give up when $4 is true.
+const MarkEventRetryQuery = "UPDATE " + utils.OutboxTableName + " SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3, failed_at = CASE WHEN $4 THEN NOW() ELSE NULL END WHERE id = $1"
+
+// PruneEventsQuery is the SQL query to delete published and failed events older than a cutoff.
+const PruneEventsQuery = "DELETE FROM " + utils.OutboxTableName + " WHERE published_at < $1 OR failed_at < $1"
@@ -0,0 +1,195 @@
+// This file enforces the per-plan usage limits and reports a user's usage.
+// Limits are checked inside the transaction that creates the resource, with the user row locked, so concurrent creates cannot exceed them.
+package quota
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to bound the usage queries by the deadline of the caller.
+import (
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to count usage inside the caller's transaction.
+	"database/sql"
+	// "fmt" provides functions for formatted I/O. It is used here to build the error message.
+	"fmt"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to identify users.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+const (
+	// Todos is the resource of the todo limit.
+	Todos = "todos"
+	// Lists is the resource of the list limit.
+	Lists = "lists"
+	// AttachmentBytes is the resource of the attachment storage limit.
+	AttachmentBytes = "attachment_bytes"
+)
+
+// ExceededError is returned when a create would take a user over a limit of their plan.
+// It is included as the error detail of the response, so that clients can tell the user which limit they reached.
+type ExceededError struct {
+	// Resource is the resource whose limit was reached.
+	// json:"resource" specifies that this field should be marshalled to/from a JSON object with the key "resource".
+	Resource string `json:"resource"`
+	// Limit is the limit of the user's plan.
+	// json:"limit" specifies that this field should be marshalled to/from a JSON object with the key "limit".
+	Limit int64 `json:"limit"`
+	// Used is the current usage of the resource.
+	// json:"used" specifies that this field should be marshalled to/from a JSON object with the key "used".
+	Used int64 `json:"used"`
+}
+
+// Error returns the message of the error.
+//
+// @return string - The message.
+func (e *ExceededError) Error() string {
+	// The message names the resource and the limit.
+	return fmt.Sprintf("%s limit of %d reached", e.Resource, e.Limit)
+}
+
+// Usage represents a user's plan, current usage, and limits.
+type Usage struct {
+	// Plan is the plan of the user.
+	Plan string
+	// Todos is the number of todos the user keeps.
+	Todos int64
+	// Lists is the number of lists the user keeps.
+	Lists int64
+	// AttachmentBytes is the total size of the user's attachments, such as uploaded images.
+	AttachmentBytes int64
+	// Limits is the limits of the user's plan.
+	Limits config.PlanLimits
+}
+
+// Check verifies that a user can create more of a resource without exceeding the limit of their plan.
+// It locks the user row until the transaction ends, so it must be called in the transaction that creates the resource.
+//
+// @param ctx context.Context - The context of the caller, which carries its deadline.
+// @param tx *sql.Tx - The transaction that creates the resource.
+// @param cfg *config.Config - The application configuration.
+// @param userId uuid.UUID - The ID of the user.
+// @param resource string - One of the resource constants.
+// @param adding int64 - How much of the resource is being created.
+// @return error - An *ExceededError if the limit would be exceeded, or another error if one occurred.
+func Check(ctx context.Context, tx *sql.Tx, cfg *config.Config, userId uuid.UUID, resource string, adding int64) error {
+	// plan is the plan of the user.
+	var plan string
+	// This reads the plan and locks the user.
+	if err := tx.QueryRowContext(ctx, LockUserPlanQuery, userId).Scan(&plan); err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// limit is the limit of the resource on the user's plan.
+	limit := limitOf(cfg.Quota.Limits(plan), resource)
+	// This checks if the resource is unlimited.
+	if limit == 0 {
+		// If it is, no error is returned.
+		return nil
+	}
+
+	// used is the current usage of the resource.
+	used, err := usageOf(ctx, tx, userId, resource)
+	// This checks if an error occurred while counting the usage.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// This checks if the create would exceed the limit.
+	if used+adding > limit {
+		// If it would, the exceeded error is returned.
+		return &ExceededError{Resource: resource, Limit: limit, Used: used}
+	}
+	// No error is returned.
+	return nil
+}
+
+// GetUsage reads a user's plan, current usage, and limits.
+//
+// @param ctx context.Context - The context of the caller, which carries its deadline.
+// @param db *sql.DB - The database connection.
+// @param cfg *config.Config - The application configuration.
+// @param userId uuid.UUID - The ID of the user.
+// @return Usage - The usage.
+// @return error - An error if one occurred.
+func GetUsage(ctx context.Context, db *sql.DB, cfg *config.Config, userId uuid.UUID) (Usage, error) {
+	// usage is the usage of the user.
+	var usage Usage
+	// This reads the plan.
+	if err := db.QueryRowContext(ctx, GetUserPlanQuery, userId).Scan(&usage.Plan); err != nil {
+		// If an error occurs, it is returned.
+		return usage, err
+	}
+	// This counts the todos.
+	if err := db.QueryRowContext(ctx, CountTodosQuery, userId).Scan(&usage.Todos); err != nil {
+		// If an error occurs, it is returned.
+		return usage, err
+	}
+	// This counts the lists.
+	if err := db.QueryRowContext(ctx, CountListsQuery, userId).Scan(&usage.Lists); err != nil {
+		// If an error occurs, it is returned.
+		return usage, err
+	}
+	// This adds up the size of the attachments.
+	if err := db.QueryRowContext(ctx, SumAttachmentBytesQuery, userId).Scan(&usage.AttachmentBytes); err != nil {
+		// If an error occurs, it is returned.
+		return usage, err
+	}
+
+	// The limits of the plan are set.
+	usage.Limits = cfg.Quota.Limits(usage.Plan)
+	// The usage is returned.
+	return usage, nil
+}
+
+// limitOf returns the limit of a resource.
+//
+// @param limits config.PlanLimits - The limits of a plan.
+// @param resource string - One of the resource constants.
+// @return int64 - The limit, or zero if it is unlimited.
+func limitOf(limits config.PlanLimits, resource string) int64 {
+	// This selects the limit of the resource.
+	switch resource {
+	case Todos:
+		return int64(limits.MaxTodos)
+	case Lists:
+		return int64(limits.MaxLists)
+	case AttachmentBytes:
+		return limits.MaxAttachmentBytes
+	}
+	// Unknown resources are not limited.
+	return 0
+}
+
+// usageOf counts the current usage of a resource.
+//
+// @param ctx context.Context - The context of the caller, which carries its deadline.
+// @param tx *sql.Tx - The transaction.
+// @param userId uuid.UUID - The ID of the user.
+// @param resource string - One of the resource constants.
+// @return int64 - The usage.
+// @return error - An error if one occurred.
+func usageOf(ctx context.Context, tx *sql.Tx, userId uuid.UUID, resource string) (int64, error) {
+	// query is the query that counts the resource.
+	var query string
+	// This selects the query of the resource.
+	switch resource {
+	case Todos:
+		query = CountTodosQuery
+	case Lists:
+		query = CountListsQuery
+	case AttachmentBytes:
+		query = SumAttachmentBytesQuery
+	default:
+		// Unknown resources are not counted.
+		return 0, nil
+	}
+
+	// used is the usage of the resource.
+	var used int64
+	// This counts the resource.
+	err := tx.QueryRowContext(ctx, query, userId).Scan(&used)
+	// The usage and the error, if any, are returned.
+	return used, err
+}
@@ -0,0 +1,20 @@
+// This file defines the SQL queries used to enforce usage quotas.
+package quota
+
+// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values for table names and schemas.
+import "github.com/rahulcodepython/todo-backend/backend/utils"
+
+// LockUserPlanQuery is the SQL query to read a user's plan and lock the user, so that concurrent creates of the same user are checked one at a time.
+const LockUserPlanQuery = "SELECT plan FROM " + utils.UserTableName + " WHERE id = $1 FOR UPDATE"
+
+// GetUserPlanQuery is the SQL query to read a user's plan.
+const GetUserPlanQuery = "SELECT plan FROM " + utils.UserTableName + " WHERE id = $1"
+
+// CountTodosQuery is the SQL query to count a user's todos that are not deleted.
+const CountTodosQuery = "SELECT COUNT(*) FROM " + utils.TodoTableName + " WHERE owner = $1 AND deleted_at IS NULL"
+
+// CountListsQuery is the SQL query to count a user's lists that are not deleted.
+const CountListsQuery = "SELECT COUNT(*) FROM " + utils.ListTableName + " WHERE owner = $1 AND deleted_at IS NULL"
+
+// SumAttachmentBytesQuery is the SQL query to add up the size in bytes of a user's uploaded images.
+const SumAttachmentBytesQuery = "SELECT COALESCE(SUM(size), 0) FROM " + utils.AttachmentTableName + " WHERE owner = $1"
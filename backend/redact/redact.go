@@ -0,0 +1,116 @@
+// This file redacts credentials and personal data from log lines before they are written, so that the logs can be kept,
+// shipped, and read by operators without exposing the secrets of the users. It hides the values of Authorization headers,
+// bearer and basic credentials, JWTs, the values of fields named like passwords, secrets, tokens, and API keys,
+// in JSON, Go structs, and query strings alike, and the local part of email addresses.
+// It sits in front of the standard logger, so a line is redacted however it was formatted, including a request body logged while debugging.
+package redact
+
+// "bytes" provides functions for working with byte slices. It is used here to find the end of the last complete line.
+import (
+	"bytes"
+	// "io" provides basic I/O primitives. It is used here to wrap the output of the loggers.
+	"io"
+	// "regexp" provides regular expressions. It is used here to find the values to redact.
+	"regexp"
+	// "sync" provides synchronization primitives. It is used here to keep the writes of concurrent loggers apart.
+	"sync"
+)
+
+// Redacted replaces a redacted value.
+const Redacted = "[REDACTED]"
+
+// maxPendingBytes is the length a line without an end may grow to before it is redacted and written anyway,
+// so that a writer that never ends its line cannot make the redacting writer hold on to it forever.
+const maxPendingBytes = 64 << 10
+
+var (
+	// authorizationPattern matches the value of an Authorization or Proxy-Authorization header, as a header line, a JSON field, or a Go map.
+	authorizationPattern = regexp.MustCompile(`(?i)((?:proxy-)?authorization"?\s*[:=]\s*"?\[?)[^"\r\n,}\]]+`)
+	// credentialsPattern matches the credentials of a Bearer or Basic authorization, wherever they appear.
+	credentialsPattern = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]+`)
+	// jwtPattern matches a JWT, whose header always starts with the base64 of `{"`.
+	jwtPattern = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	// quotedFieldPattern matches a quoted value of a sensitive field, as in JSON: "password": "hunter2".
+	quotedFieldPattern = regexp.MustCompile(`(?i)("[\w-]*(?:password|passwd|secret|token|api[_-]?key|jwt)[\w-]*"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	// bareFieldPattern matches an unquoted value of a sensitive field, as in a Go struct or a query string: Password:hunter2 or token=abc.
+	// The value must follow the separator directly, so that prose such as "invalid token: expired" is left alone.
+	bareFieldPattern = regexp.MustCompile(`(?i)(\b[\w-]*(?:password|passwd|secret|token|api[_-]?key|jwt)[\w-]*[:=])[^\s"&,;}\[\]]+`)
+	// emailPattern matches an email address, whose local part is kept to its first character.
+	emailPattern = regexp.MustCompile(`\b([A-Za-z0-9])[A-Za-z0-9._%+-]*(@[A-Za-z0-9.-]+\.[A-Za-z]{2,})\b`)
+)
+
+// String returns a log line with its credentials and email addresses redacted.
+//
+// @param line string - The line.
+// @return string - The redacted line.
+func String(line string) string {
+	// The values of the authorization headers are redacted first, since they may hold any scheme.
+	line = authorizationPattern.ReplaceAllString(line, "${1}"+Redacted)
+	// Bearer and basic credentials are redacted, keeping the scheme.
+	line = credentialsPattern.ReplaceAllString(line, "${1} "+Redacted)
+	// JWTs are redacted wherever they appear, such as in a query string.
+	line = jwtPattern.ReplaceAllString(line, Redacted)
+	// The values of sensitive fields are redacted, keeping the quotes of JSON so that the line still reads as JSON.
+	line = quotedFieldPattern.ReplaceAllString(line, `${1}"`+Redacted+`"`)
+	line = bareFieldPattern.ReplaceAllString(line, "${1}"+Redacted)
+	// Email addresses keep their first character and domain, which is enough to tell accounts and providers apart when reading the logs.
+	return emailPattern.ReplaceAllString(line, "${1}***${2}")
+}
+
+// writer redacts what is written to it before writing it to another writer.
+type writer struct {
+	// mu guards the pending line, since the writer is shared by loggers that write concurrently.
+	mu sync.Mutex
+	// out is the writer the redacted lines are written to.
+	out io.Writer
+	// pending is the start of a line whose end was not written yet.
+	pending []byte
+}
+
+// NewWriter returns a writer that redacts whole lines before passing them on. A line is held back until its end is written,
+// so that a value split across two writes is redacted as one. A line that has not ended after maxPendingBytes is written anyway.
+//
+// @param out io.Writer - The writer of the redacted lines, such as os.Stderr.
+// @return io.Writer - The redacting writer.
+func NewWriter(out io.Writer) io.Writer {
+	// The writer is returned.
+	return &writer{out: out}
+}
+
+// Write redacts the lines that were ended and writes them, holding back the rest until its end is written.
+//
+// @param p []byte - The text.
+// @return int - The length of the text as given, since a redacted line differs in length from what the logger wrote.
+// @return error - An error if the lines cannot be written.
+func (w *writer) Write(p []byte) (int, error) {
+	// This locks the pending line until the write is done.
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// The text is added to the line that was held back.
+	w.pending = append(w.pending, p...)
+	// end is the length of the complete lines.
+	end := bytes.LastIndexByte(w.pending, '\n') + 1
+	// This checks if no line ended and the pending line grew too long.
+	if end == 0 && len(w.pending) > maxPendingBytes {
+		// If it did, it is written as it is.
+		end = len(w.pending)
+	}
+	// This checks if there is nothing to write yet.
+	if end == 0 {
+		// If there is not, the text is reported as written.
+		return len(p), nil
+	}
+
+	// redacted is the complete lines, redacted.
+	redacted := String(string(w.pending[:end]))
+	// The rest is kept for the next write.
+	w.pending = append(w.pending[:0], w.pending[end:]...)
+	// The redacted lines are written.
+	if _, err := io.WriteString(w.out, redacted); err != nil {
+		// If an error occurs, it is returned.
+		return 0, err
+	}
+	// The whole text is reported as written.
+	return len(p), nil
+}
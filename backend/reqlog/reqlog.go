@@ -0,0 +1,96 @@
+// This file defines the logger of a request, which prefixes each line with the ID of the request, the authenticated user, and the route,
+// so that a line about a failure can be matched with the request, its response, and its access log line without passing them around.
+// The logger is carried by the context of the request, so services log through the context they already take.
+package reqlog
+
+// "context" provides a way to carry request-scoped values. It is used here to carry the fields of the logger.
+import (
+	"context"
+	// "log" provides logging functions. It is used here to write the log lines.
+	"log"
+	// "sync" provides synchronization primitives. It is used here to guard the user, which is set after the logger is created.
+	"sync"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to read the context of a request.
+	"github.com/gofiber/fiber/v2"
+)
+
+// contextKey is the type of the key the fields are stored under, so that no other package can overwrite them.
+type contextKey struct{}
+
+// fields holds what the lines of a request are prefixed with.
+type fields struct {
+	// requestID is the ID of the request, as sent in the X-Request-ID header.
+	requestID string
+	// route is the method and path of the request.
+	route string
+	// mu guards the user.
+	mu sync.Mutex
+	// userID is the ID of the authenticated user, or empty until the authentication middlewares find one.
+	userID string
+}
+
+// WithRequest returns a context that carries the logger of a request.
+//
+// @param ctx context.Context - The context of the request.
+// @param requestID string - The ID of the request.
+// @param method string - The method of the request.
+// @param path string - The path of the request.
+// @return context.Context - The context with the logger.
+func WithRequest(ctx context.Context, requestID string, method string, path string) context.Context {
+	// The fields are stored in the context.
+	return context.WithValue(ctx, contextKey{}, &fields{requestID: requestID, route: method + " " + path})
+}
+
+// SetUser records the authenticated user of a request, so that the lines logged after it name the user.
+// It does nothing if the context carries no logger.
+//
+// @param ctx context.Context - The context of the request.
+// @param userID string - The ID of the user.
+func SetUser(ctx context.Context, userID string) {
+	// f is the fields of the request, if any.
+	f, ok := ctx.Value(contextKey{}).(*fields)
+	// This checks if the context carries no logger.
+	if !ok {
+		// If it does not, there is nothing to record.
+		return
+	}
+	// The user is recorded.
+	f.mu.Lock()
+	f.userID = userID
+	f.mu.Unlock()
+}
+
+// From returns the logger of the context of a request. Outside of a request, such as in a background job, it returns the standard logger.
+//
+// @param ctx context.Context - The context.
+// @return *log.Logger - The logger.
+func From(ctx context.Context) *log.Logger {
+	// f is the fields of the request, if any.
+	f, ok := ctx.Value(contextKey{}).(*fields)
+	// This checks if the context carries no logger.
+	if !ok {
+		// If it does not, the standard logger is returned.
+		return log.Default()
+	}
+	// userID is the authenticated user, read under the lock.
+	f.mu.Lock()
+	userID := f.userID
+	f.mu.Unlock()
+	// This checks if the request has no authenticated user.
+	if userID == "" {
+		// If it has none, a dash is logged in its place.
+		userID = "-"
+	}
+	// The logger writes where the standard logger does, with the fields after the timestamp.
+	return log.New(log.Writer(), "request="+f.requestID+" user="+userID+" route="+f.route+" ", log.Flags()|log.Lmsgprefix)
+}
+
+// Ctx returns the logger of a request from its Fiber context.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return *log.Logger - The logger.
+func Ctx(c *fiber.Ctx) *log.Logger {
+	// The logger of the user context is returned.
+	return From(c.UserContext())
+}
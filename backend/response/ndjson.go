@@ -0,0 +1,124 @@
+// This file provides streamed responses in newline-delimited JSON, for the endpoints that can return a very large number of rows.
+package response
+
+// "bufio" implements buffered I/O. It is used here to write the stream.
+import (
+	"bufio"
+	// "context" provides a way to carry deadlines and cancellation signals. It is used here to keep the query of a stream running after the handler returns.
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to read the rows of a stream.
+	"database/sql"
+	// "encoding/json" provides functions for encoding JSON. It is used here to write each row on its own line.
+	"encoding/json"
+	// "log" provides logging functions. It is used here to log a stream that failed after it started.
+	"log"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to send the stream.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/backend/i18n" is a local package that translates messages. It is used here to translate the message of a failed stream.
+	"github.com/rahulcodepython/todo-backend/backend/i18n"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides the standard response structure.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+)
+
+// MIMEApplicationNDJSON is the media type of newline-delimited JSON, which holds one JSON value per line.
+const MIMEApplicationNDJSON = "application/x-ndjson"
+
+// ndjsonFlushEvery is the number of rows written between flushes, so that the client receives rows steadily without a write per row.
+const ndjsonFlushEvery = 100
+
+// WantsNDJSON reports whether a request asks for newline-delimited JSON in its Accept header.
+// A request without an Accept header, or one that accepts both, is answered with the usual JSON response.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return bool - True if the request prefers newline-delimited JSON.
+func WantsNDJSON(c *fiber.Ctx) bool {
+	// The request wants newline-delimited JSON if it is the best of the two offers.
+	return c.Accepts(fiber.MIMEApplicationJSON, MIMEApplicationNDJSON) == MIMEApplicationNDJSON
+}
+
+// NDJSON sends the rows of a query as newline-delimited JSON, writing each row as it is scanned instead of holding them all in memory.
+// The query runs without the request budget, since a stream is expected to outlast it, and stops when the client goes away.
+// If the query fails before the first row, the usual error response is sent. If it fails after the stream started,
+// the status can no longer change, so a last line is written with "success" set to false, the message, and the error.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param message string - The message of a failed query.
+// @param query func(ctx context.Context) (*sql.Rows, error) - The function that runs the query with the context of the stream.
+// @param scan func(rows *sql.Rows) (any, error) - The function that scans a row into the value written on its line.
+// @return error - An error if one occurred while sending the response.
+func NDJSON(c *fiber.Ctx, message string, query func(ctx context.Context) (*sql.Rows, error), scan func(rows *sql.Rows) (any, error)) error {
+	// rows is the result of the query, run with the values of the request but without its deadline.
+	rows, err := query(context.WithoutCancel(c.UserContext()))
+	// This checks if an error occurred while querying the database.
+	if err != nil {
+		// If an error occurs, an internal server error response is returned.
+		return InternelServerError(c, err, message)
+	}
+
+	// failure is the translated message of a failed stream, resolved now since the context is released before the stream is written.
+	failure := i18n.T(c, message)
+	// path is the path of the request, kept for the log of a failed stream.
+	path := c.Path()
+
+	// The content type is set to newline-delimited JSON.
+	c.Set(fiber.HeaderContentType, MIMEApplicationNDJSON)
+	// The rows are written by the server once the handler returns, while it sends the response.
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// This defers the closing of the rows until the stream ends.
+		defer rows.Close()
+		// encoder writes each value followed by a newline.
+		encoder := json.NewEncoder(w)
+		// written is the number of rows written.
+		written := 0
+		// This iterates over the rows.
+		for rows.Next() {
+			// value is the result of scanning the row.
+			value, err := scan(rows)
+			// This checks if an error occurred while scanning the row.
+			if err != nil {
+				// If an error occurs, the stream ends with the error.
+				endNDJSON(encoder, w, path, failure, err)
+				return
+			}
+			// This writes the row.
+			if err := encoder.Encode(value); err != nil {
+				// If the write fails, the client went away and the stream ends.
+				return
+			}
+			// written is counted up, and every few rows the buffer is flushed.
+			if written++; written%ndjsonFlushEvery == 0 {
+				// This flushes the buffer.
+				if err := w.Flush(); err != nil {
+					// If the flush fails, the client went away and the stream ends.
+					return
+				}
+			}
+		}
+		// This checks if an error occurred while iterating over the rows.
+		if err := rows.Err(); err != nil {
+			// If an error occurs, the stream ends with the error.
+			endNDJSON(encoder, w, path, failure, err)
+			return
+		}
+		// The rest of the buffer is flushed.
+		_ = w.Flush()
+	})
+	// The stream is sent once the handler returns.
+	return nil
+}
+
+// endNDJSON ends a stream that failed after it started, with a last line that describes the failure.
+//
+// @param encoder *json.Encoder - The encoder of the stream.
+// @param w *bufio.Writer - The writer of the stream.
+// @param path string - The path of the request.
+// @param message string - The translated message.
+// @param err error - The error that occurred.
+func endNDJSON(encoder *json.Encoder, w *bufio.Writer, path string, message string, err error) {
+	// The error is logged, since the status of the response still says the request succeeded.
+	log.Printf("Stream of %s failed: %v", path, err)
+	// The failure is written as the last line, and flushed.
+	_ = encoder.Encode(utils.Response{Success: false, Message: message, Error: err.Error()})
+	_ = w.Flush()
+}
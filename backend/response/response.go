@@ -1,36 +1,184 @@
 // This file provides standardized functions for sending API responses.
 package response
 
-// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to send HTTP responses.
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to recognize a request that ran out of time.
 import (
+	"context"
+	// "errors" provides functions for inspecting errors. It is used here to recognize a passed deadline.
+	"errors"
+	// "math" provides mathematical functions. It is used here to round the Retry-After header up to whole seconds.
+	"math"
+	// "net/url" provides functions for working with URLs. It is used here to encode the query parameters of page links.
+	"net/url"
+	// "strconv" provides functions for converting strings. It is used here to build page links.
+	"strconv"
+	// "strings" provides functions for working with strings. It is used here to list the allowed methods.
+	"strings"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to send HTTP responses.
 	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/backend/binding" is a local package that binds request parameters. It is used here to describe the invalid parameters.
+	"github.com/rahulcodepython/todo-backend/backend/binding"
+	// "github.com/rahulcodepython/todo-backend/backend/breaker" is a local package that provides the circuit breaker. It is used here to answer 503 while the database breaker is open.
+	"github.com/rahulcodepython/todo-backend/backend/breaker"
+	// "github.com/rahulcodepython/todo-backend/backend/dberr" is a local package that classifies database errors. It is used here to answer constraint violations with a client error.
+	"github.com/rahulcodepython/todo-backend/backend/dberr"
+	// "github.com/rahulcodepython/todo-backend/backend/i18n" is a local package that translates messages. It is used here to answer in the locale of the request.
+	"github.com/rahulcodepython/todo-backend/backend/i18n"
+	// "github.com/rahulcodepython/todo-backend/backend/quota" is a local package that enforces usage limits. It is used here to describe the limit that was reached.
+	"github.com/rahulcodepython/todo-backend/backend/quota"
+	// "github.com/rahulcodepython/todo-backend/backend/reqlog" is a local package that provides the logger of a request. It is used here to log the server errors.
+	"github.com/rahulcodepython/todo-backend/backend/reqlog"
 	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides the standard response structure.
 	"github.com/rahulcodepython/todo-backend/backend/utils"
 )
 
+// errorDetail returns the message of an error, or nil if there is no error.
+// It allows the error helpers to be called with a nil error.
+//
+// @param err error - The error that occurred.
+// @return interface{} - The error message, or nil.
+func errorDetail(err error) interface{} {
+	// This checks if the error is nil.
+	if err == nil {
+		// If it is, nil is returned so that the field is omitted from the response.
+		return nil
+	}
+	// The error message is returned.
+	return err.Error()
+}
+
+// timedOut reports whether an error was caused by the request running out of time.
+// A query cancelled by the driver does not always wrap the deadline, so the context of the request is checked as well.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param err error - The error that occurred.
+// @return bool - True if the deadline of the request has passed.
+func timedOut(c *fiber.Ctx, err error) bool {
+	// The request timed out if the error is the passed deadline, or if its context has passed its deadline.
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(c.UserContext().Err(), context.DeadlineExceeded)
+}
+
 // InternelServerError sends a 500 Internal Server Error response.
 // It takes the Fiber context, an error, and a message as input.
+// If the request ran out of time, a 504 Gateway Timeout response is sent instead,
+// and if the circuit breaker of the database is open, a 503 Service Unavailable response.
+// A constraint violation or an aborted transaction is not a fault of the server, so it is answered with
+// 409 Conflict or 400 Bad Request instead, whichever handler it reaches.
 //
 // @param c *fiber.Ctx - The Fiber context.
 // @param err error - The error that occurred.
 // @param message string - A message to be included in the response.
 // @return error - An error if one occurred while sending the response.
 func InternelServerError(c *fiber.Ctx, err error, message string) error {
+	// openErr is the error of the open circuit breaker, if the error is one.
+	var openErr *breaker.OpenError
+	// This checks if the database was not called because its circuit breaker is open.
+	if errors.As(err, &openErr) {
+		// If it was not, a service unavailable response is returned.
+		return ServiceUnavailable(c, err, "Database is temporarily unavailable")
+	}
+	// This checks if the error was caused by the request running out of time.
+	if timedOut(c, err) {
+		// If it was, a gateway timeout response is returned.
+		return GatewayTimeout(c, err, "")
+	}
+	// This checks what kind of database error occurred, if any.
+	switch classified := dberr.Classify(err); {
+	// A unique index rejected the write.
+	case errors.Is(classified, dberr.ErrUniqueViolation):
+		// A conflict response is returned.
+		return Conflict(c, classified, "The resource already exists")
+	// The write referenced a missing row, or deleted a row that is still referenced.
+	case errors.Is(classified, dberr.ErrForeignKeyViolation):
+		// A conflict response is returned.
+		return Conflict(c, classified, "A related resource does not exist or is still in use")
+	// A check constraint rejected the write.
+	case errors.Is(classified, dberr.ErrCheckViolation):
+		// A bad request response is returned.
+		return BadResponse(c, "The request contains a value that is not allowed")
+	// The transaction conflicted with a concurrent one.
+	case errors.Is(classified, dberr.ErrSerializationFailure), errors.Is(classified, dberr.ErrDeadlock):
+		// A conflict response is returned, which the client may retry.
+		return Conflict(c, classified, "The request conflicted with a concurrent change. Try again")
+	}
 	// This checks if a custom message is provided.
 	if message == "" {
 		// If no message is provided, a default message is used.
 		message = "Internal Server Error"
 	}
+	// The failure is logged with the request it belongs to, since the response only tells the client.
+	reqlog.Ctx(c).Printf("%s: %v", message, err)
 
 	// c.Status() sets the HTTP status code of the response.
 	// c.JSON() sends a JSON response.
 	return c.Status(fiber.StatusInternalServerError).JSON(utils.Response{
 		// Success is set to false to indicate that the request was not successful.
 		Success: false,
-		// The message is included in the response.
-		Message: message,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, message),
+		// The error message is included in the response.
+		Error: errorDetail(err),
+	})
+}
+
+// GatewayTimeout sends a 504 Gateway Timeout response.
+// It takes the Fiber context, an error, and a message as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param err error - The error that occurred.
+// @param message string - A message to be included in the response.
+// @return error - An error if one occurred while sending the response.
+func GatewayTimeout(c *fiber.Ctx, err error, message string) error {
+	// This checks if a custom message is provided.
+	if message == "" {
+		// If no message is provided, a default message is used.
+		message = "Request timed out"
+	}
+
+	// c.Status() sets the HTTP status code of the response.
+	// c.JSON() sends a JSON response.
+	return c.Status(fiber.StatusGatewayTimeout).JSON(utils.Response{
+		// Success is set to false to indicate that the request was not successful.
+		Success: false,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, message),
 		// The error message is included in the response.
-		Error: err.Error(),
+		Error: errorDetail(err),
+	})
+}
+
+// ServiceUnavailable sends a 503 Service Unavailable response.
+// It takes the Fiber context, an error, and a message as input.
+// If the error comes from an open circuit breaker, the Retry-After header is set.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param err error - The error that occurred.
+// @param message string - A message to be included in the response.
+// @return error - An error if one occurred while sending the response.
+func ServiceUnavailable(c *fiber.Ctx, err error, message string) error {
+	// This checks if a custom message is provided.
+	if message == "" {
+		// If no message is provided, a default message is used.
+		message = "Service Unavailable"
+	}
+	// openErr is the error of the open circuit breaker, if the error is one.
+	var openErr *breaker.OpenError
+	// This checks if the error comes from an open circuit breaker.
+	if errors.As(err, &openErr) {
+		// If it does, the client is told when the breaker lets a probe through, in whole seconds.
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(max(1, int(math.Ceil(openErr.RetryAfter.Seconds())))))
+	}
+
+	// c.Status() sets the HTTP status code of the response.
+	// c.JSON() sends a JSON response.
+	return c.Status(fiber.StatusServiceUnavailable).JSON(utils.Response{
+		// Success is set to false to indicate that the request was not successful.
+		Success: false,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, message),
+		// The error message is included in the response.
+		Error: errorDetail(err),
 	})
 }
 
@@ -48,15 +196,102 @@ func BadInternalResponse(c *fiber.Ctx, err error, message string) error {
 		message = "Bad Request"
 	}
 
+	// fieldErrs is the list of invalid fields, if the error names them, such as an unknown field of a strict JSON body.
+	var fieldErrs binding.FieldErrors
+	// This checks if the error names the invalid fields.
+	if errors.As(err, &fieldErrs) {
+		// If it does, they are listed like invalid query parameters.
+		return c.Status(fiber.StatusBadRequest).JSON(utils.Response{
+			// Success is set to false to indicate that the request was not successful.
+			Success: false,
+			// The message is included in the response, translated into the locale of the request.
+			Message: i18n.T(c, message),
+			// The code lets clients highlight the invalid fields.
+			Code: "invalid_parameters",
+			// The invalid fields are included in the response.
+			Error: translateFieldErrors(c, fieldErrs),
+		})
+	}
+
 	// c.Status() sets the HTTP status code of the response.
 	// c.JSON() sends a JSON response.
 	return c.Status(fiber.StatusBadRequest).JSON(utils.Response{
 		// Success is set to false to indicate that the request was not successful.
 		Success: false,
-		// The message is included in the response.
-		Message: message,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, message),
 		// The error message is included in the response.
-		Error: err.Error(),
+		Error: errorDetail(err),
+	})
+}
+
+// Conflict sends a 409 Conflict response.
+// It takes the Fiber context, an error, and a message as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param err error - The error that occurred.
+// @param message string - A message to be included in the response.
+// @return error - An error if one occurred while sending the response.
+func Conflict(c *fiber.Ctx, err error, message string) error {
+	// This checks if a custom message is provided.
+	if message == "" {
+		// If no message is provided, a default message is used.
+		message = "Conflict"
+	}
+
+	// c.Status() sets the HTTP status code of the response.
+	// c.JSON() sends a JSON response.
+	return c.Status(fiber.StatusConflict).JSON(utils.Response{
+		// Success is set to false to indicate that the request was not successful.
+		Success: false,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, message),
+		// The error message is included in the response.
+		Error: errorDetail(err),
+	})
+}
+
+// Duplicate sends a 409 Conflict response with the "duplicate" code and the existing resource as data,
+// so that a client that added the same item twice can use the existing one instead.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param message string - A message to be included in the response.
+// @param existing any - The existing resource.
+// @return error - An error if one occurred while sending the response.
+func Duplicate(c *fiber.Ctx, message string, existing any) error {
+	// c.Status() sets the HTTP status code of the response.
+	// c.JSON() sends a JSON response.
+	return c.Status(fiber.StatusConflict).JSON(utils.Response{
+		// Success is set to false to indicate that the request was not successful.
+		Success: false,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, message),
+		// The code lets clients tell a duplicate from other conflicts.
+		Code: "duplicate",
+		// The existing resource is included in the response.
+		Data: existing,
+	})
+}
+
+// ConfirmationRequired sends a 428 Precondition Required response with the "confirmation_required" code,
+// for an action that changes too much to be done on the first request. The data holds the token that confirms it.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param message string - A message to be included in the response.
+// @param confirmation any - The confirmation token and what it confirms.
+// @return error - An error if one occurred while sending the response.
+func ConfirmationRequired(c *fiber.Ctx, message string, confirmation any) error {
+	// c.Status() sets the HTTP status code of the response.
+	// c.JSON() sends a JSON response.
+	return c.Status(fiber.StatusPreconditionRequired).JSON(utils.Response{
+		// Success is set to false to indicate that the request was not successful.
+		Success: false,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, message),
+		// The code tells the client to repeat the request with the confirmation token.
+		Code: "confirmation_required",
+		// The confirmation is included in the response.
+		Data: confirmation,
 	})
 }
 
@@ -79,10 +314,74 @@ func UnauthorizedAccess(c *fiber.Ctx, err error, message string) error {
 	return c.Status(fiber.StatusUnauthorized).JSON(utils.Response{
 		// Success is set to false to indicate that the request was not successful.
 		Success: false,
-		// The message is included in the response.
-		Message: message,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, message),
+		// The error message is included in the response.
+		Error: errorDetail(err),
+	})
+}
+
+// CaptchaRequired sends a 401 Unauthorized response with the "captcha_required" code,
+// so that a client shows a captcha before it lets the user try to log in again.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param err error - The error that occurred.
+// @param message string - A message to be included in the response.
+// @return error - An error if one occurred while sending the response.
+func CaptchaRequired(c *fiber.Ctx, err error, message string) error {
+	// c.Status() sets the HTTP status code of the response.
+	// c.JSON() sends a JSON response.
+	return c.Status(fiber.StatusUnauthorized).JSON(utils.Response{
+		// Success is set to false to indicate that the request was not successful.
+		Success: false,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, message),
+		// The code tells the client to ask for a captcha.
+		Code: "captcha_required",
+		// The error message is included in the response.
+		Error: errorDetail(err),
+	})
+}
+
+// CaptchaFailed sends a 400 Bad Request response with the "captcha_failed" code and the error codes of the captcha provider,
+// such as "missing-input-response" or "timeout-or-duplicate", so that a client can tell a missing captcha from an expired one.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param message string - A message to be included in the response.
+// @param codes []string - The error codes of the provider.
+// @return error - An error if one occurred while sending the response.
+func CaptchaFailed(c *fiber.Ctx, message string, codes []string) error {
+	// c.Status() sets the HTTP status code of the response.
+	// c.JSON() sends a JSON response.
+	return c.Status(fiber.StatusBadRequest).JSON(utils.Response{
+		// Success is set to false to indicate that the request was not successful.
+		Success: false,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, message),
+		// The code lets clients show the captcha again.
+		Code: "captcha_failed",
+		// The codes of the provider are included in the response.
+		Error: codes,
+	})
+}
+
+// UnsupportedMediaType sends a 415 Unsupported Media Type response.
+// It takes the Fiber context, an error, and a message as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param err error - The error that occurred.
+// @param message string - A message to be included in the response.
+// @return error - An error if one occurred while sending the response.
+func UnsupportedMediaType(c *fiber.Ctx, err error, message string) error {
+	// c.Status() sets the HTTP status code of the response.
+	// c.JSON() sends a JSON response.
+	return c.Status(fiber.StatusUnsupportedMediaType).JSON(utils.Response{
+		// Success is set to false to indicate that the request was not successful.
+		Success: false,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, message),
 		// The error message is included in the response.
-		Error: err.Error(),
+		Error: errorDetail(err),
 	})
 }
 
@@ -105,10 +404,118 @@ func NotFound(c *fiber.Ctx, err error, message string) error {
 	return c.Status(fiber.StatusNotFound).JSON(utils.Response{
 		// Success is set to false to indicate that the request was not successful.
 		Success: false,
-		// The message is included in the response.
-		Message: message,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, message),
 		// The error message is included in the response.
-		Error: err.Error(),
+		Error: errorDetail(err),
+	})
+}
+
+// Forbidden sends a 403 Forbidden response.
+// It takes the Fiber context and a message as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param message string - A message to be included in the response.
+// @return error - An error if one occurred while sending the response.
+func Forbidden(c *fiber.Ctx, message string) error {
+	// This checks if a custom message is provided.
+	if message == "" {
+		// If no message is provided, a default message is used.
+		message = "Forbidden"
+	}
+
+	// c.Status() sets the HTTP status code of the response.
+	// c.JSON() sends a JSON response.
+	return c.Status(fiber.StatusForbidden).JSON(utils.Response{
+		// Success is set to false to indicate that the request was not successful.
+		Success: false,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, message),
+	})
+}
+
+// InsufficientScope sends a 403 Forbidden response with the "insufficient_scope" code, for a token that is not allowed to call an endpoint.
+// It takes the Fiber context and the scope the endpoint needs as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param scope string - The scope the endpoint needs, or empty if no scope allows it and only a session can call it.
+// @return error - An error if one occurred while sending the response.
+func InsufficientScope(c *fiber.Ctx, scope string) error {
+	// message is the message of the response, which names the missing scope if there is one.
+	message := i18n.T(c, "This endpoint cannot be called with an API key")
+	// This checks if a scope allows the endpoint.
+	if scope != "" {
+		// If one does, it is named.
+		message = i18n.Sprintf(c, "This token does not have the %s scope", scope)
+	}
+
+	// c.Status() sets the HTTP status code of the response.
+	// c.JSON() sends a JSON response.
+	return c.Status(fiber.StatusForbidden).JSON(utils.Response{
+		// Success is set to false to indicate that the request was not successful.
+		Success: false,
+		// The message says what the token is missing.
+		Message: message,
+		// The code lets clients tell a key that needs more scopes from a user who lacks access.
+		Code: "insufficient_scope",
+	})
+}
+
+// RegistrationRequired sends a 403 Forbidden response with the "registration_required" code, for a guest who calls an endpoint
+// only registered users can, so that a client can offer to claim the account instead of showing an error.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @return error - An error if one occurred while sending the response.
+func RegistrationRequired(c *fiber.Ctx) error {
+	// c.Status() sets the HTTP status code of the response.
+	// c.JSON() sends a JSON response.
+	return c.Status(fiber.StatusForbidden).JSON(utils.Response{
+		// Success is set to false to indicate that the request was not successful.
+		Success: false,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, "Register to use this endpoint"),
+		// The code tells the client to offer registration.
+		Code: "registration_required",
+	})
+}
+
+// DeviceGrantError sends a 400 Bad Request response with the error code of the device authorization grant, such as
+// "authorization_pending" or "slow_down", so that a polling device can tell whether to keep polling.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param code string - The error code of the grant.
+// @param message string - A message to be included in the response.
+// @return error - An error if one occurred while sending the response.
+func DeviceGrantError(c *fiber.Ctx, code string, message string) error {
+	// c.Status() sets the HTTP status code of the response.
+	// c.JSON() sends a JSON response.
+	return c.Status(fiber.StatusBadRequest).JSON(utils.Response{
+		// Success is set to false to indicate that the request was not successful.
+		Success: false,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, message),
+		// The code tells the device what to do next.
+		Code: code,
+	})
+}
+
+// MethodNotAllowed sends a 405 Method Not Allowed response with an Allow header.
+// It takes the Fiber context and the methods allowed on the path as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param allowed []string - The methods allowed on the path.
+// @return error - An error if one occurred while sending the response.
+func MethodNotAllowed(c *fiber.Ctx, allowed []string) error {
+	// The Allow header lists the methods the client can use instead.
+	c.Set(fiber.HeaderAllow, strings.Join(allowed, ", "))
+
+	// c.Status() sets the HTTP status code of the response.
+	// c.JSON() sends a JSON response.
+	return c.Status(fiber.StatusMethodNotAllowed).JSON(utils.Response{
+		// Success is set to false to indicate that the request was not successful.
+		Success: false,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.Sprintf(c, "Method %s is not allowed on this path", c.Method()),
 	})
 }
 
@@ -130,11 +537,65 @@ func BadResponse(c *fiber.Ctx, message string) error {
 	return c.Status(fiber.StatusBadRequest).JSON(utils.Response{
 		// Success is set to false to indicate that the request was not successful.
 		Success: false,
-		// The message is included in the response.
-		Message: message,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, message),
 	})
 }
 
+// OKPaginatedResponse sends a 200 OK response with metadata that links to the neighbouring pages.
+// It takes the Fiber context, a message, data, and the pagination links as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param message string - A message to be included in the response.
+// @param data interface{} - The data to be included in the response.
+// @param pagination utils.Pagination - The links to the neighbouring pages.
+// @return error - An error if one occurred while sending the response.
+func OKPaginatedResponse(c *fiber.Ctx, message string, data interface{}, pagination utils.Pagination) error {
+	// c.Status() sets the HTTP status code of the response.
+	// c.JSON() sends a JSON response.
+	return c.Status(fiber.StatusOK).JSON(utils.Response{
+		// Success is set to true to indicate that the request was successful.
+		Success: true,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, message),
+		// The data is included in the response.
+		Data: data,
+		// The metadata is included in the response.
+		Meta: newMeta(c, &pagination),
+	})
+}
+
+// PageURL returns the URL of the request with its "page" query parameter replaced, keeping the other parameters.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param page int - The page number.
+// @return string - The URL of the page.
+func PageURL(c *fiber.Ctx, page int) string {
+	// values is a copy of the query parameters of the request.
+	values := url.Values{}
+	// This iterates over the query parameters.
+	for key, value := range c.Queries() {
+		// The parameter is copied.
+		values.Set(key, value)
+	}
+	// The page is replaced.
+	values.Set("page", strconv.Itoa(page))
+	// The URL is built from the base URL, the path, and the query parameters.
+	return c.BaseURL() + c.Path() + "?" + values.Encode()
+}
+
+// newMeta builds the metadata of a response.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param pagination *utils.Pagination - The links to the neighbouring pages, or nil.
+// @return *utils.Meta - The metadata.
+func newMeta(c *fiber.Ctx, pagination *utils.Pagination) *utils.Meta {
+	// requestId is the ID the RequestID middleware gave the request, if any.
+	requestId, _ := c.Locals("requestid").(string)
+	// The metadata is returned.
+	return &utils.Meta{RequestID: requestId, APIVersion: utils.APIVersion, Pagination: pagination}
+}
+
 // OKResponse sends a 200 OK response.
 // It takes the Fiber context, a message, and data as input.
 //
@@ -148,28 +609,35 @@ func OKResponse(c *fiber.Ctx, message string, data interface{}) error {
 	return c.Status(fiber.StatusOK).JSON(utils.Response{
 		// Success is set to true to indicate that the request was successful.
 		Success: true,
-		// The message is included in the response.
-		Message: message,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, message),
 		// The data is included in the response.
 		Data: data,
 	})
 }
 
 // OKCreatedResponse sends a 201 Created response.
-// It takes the Fiber context, a message, and data as input.
+// It takes the Fiber context, a message, data, and optionally the path of the created resource as input.
+// When the path is given, it is sent in the Location header so that clients can fetch or cache the resource.
 //
 // @param c *fiber.Ctx - The Fiber context.
 // @param message string - A message to be included in the response.
 // @param data interface{} - The data to be included in the response.
+// @param location ...string - The optional path of the created resource, such as "/api/v1/todos/<id>".
 // @return error - An error if one occurred while sending the response.
-func OKCreatedResponse(c *fiber.Ctx, message string, data interface{}) error {
+func OKCreatedResponse(c *fiber.Ctx, message string, data interface{}, location ...string) error {
+	// This checks if the path of the created resource was given.
+	if len(location) > 0 && location[0] != "" {
+		// If it was, the Location header is set to it.
+		c.Location(location[0])
+	}
 	// c.Status() sets the HTTP status code of the response.
 	// c.JSON() sends a JSON response.
 	return c.Status(fiber.StatusCreated).JSON(utils.Response{
 		// Success is set to true to indicate that the request was successful.
 		Success: true,
-		// The message is included in the response.
-		Message: message,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, message),
 		// The data is included in the response.
 		Data: data,
 	})
@@ -187,7 +655,77 @@ func TooManyRequests(c *fiber.Ctx, message string) error {
 	return c.Status(fiber.StatusTooManyRequests).JSON(utils.Response{
 		// Success is set to false to indicate that the request was not successful.
 		Success: false,
-		// The message is included in the response.
-		Message: message,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, message),
+	})
+}
+
+// QuotaExceeded sends a 403 Forbidden response with the "quota_exceeded" code.
+// It takes the Fiber context and the exceeded limit as input.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param err *quota.ExceededError - The limit that was reached.
+// @return error - An error if one occurred while sending the response.
+func QuotaExceeded(c *fiber.Ctx, err *quota.ExceededError) error {
+	// c.Status() sets the HTTP status code of the response.
+	// c.JSON() sends a JSON response.
+	return c.Status(fiber.StatusForbidden).JSON(utils.Response{
+		// Success is set to false to indicate that the request was not successful.
+		Success: false,
+		// The message names the limit that was reached.
+		Message: i18n.Sprintf(c, "You have reached the %s limit of your plan", err.Resource),
+		// The code lets clients offer an upgrade instead of showing a generic error.
+		Code: "quota_exceeded",
+		// The limit and the current usage are included in the response.
+		Error: err,
+	})
+}
+
+// translateFieldErrors translates the messages of invalid parameters into the locale of the request.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param fieldErrs binding.FieldErrors - The invalid parameters.
+// @return binding.FieldErrors - The invalid parameters with translated messages.
+func translateFieldErrors(c *fiber.Ctx, fieldErrs binding.FieldErrors) binding.FieldErrors {
+	// translated is the list of invalid parameters with translated messages.
+	translated := make(binding.FieldErrors, 0, len(fieldErrs))
+	// This iterates over the invalid parameters.
+	for _, fieldErr := range fieldErrs {
+		// The message is translated into the locale of the request.
+		fieldErr.Message = i18n.Sprintf(c, fieldErr.Format, fieldErr.Args...)
+		// The parameter is appended to the translated list.
+		translated = append(translated, fieldErr)
+	}
+	// The translated list is returned.
+	return translated
+}
+
+// InvalidParameters sends a 400 Bad Request response with the "invalid_parameters" code.
+// It takes the Fiber context and the error of binding the parameters as input.
+// The invalid parameters are listed under "error", each with a message in the locale of the request.
+//
+// @param c *fiber.Ctx - The Fiber context.
+// @param err error - The error of binding the parameters, usually binding.FieldErrors.
+// @return error - An error if one occurred while sending the response.
+func InvalidParameters(c *fiber.Ctx, err error) error {
+	// fieldErrs is the list of invalid parameters.
+	fieldErrs, ok := err.(binding.FieldErrors)
+	// This checks if the error does not list the invalid parameters.
+	if !ok {
+		// If it does not, a plain bad request response is returned.
+		return BadInternalResponse(c, err, "Invalid query parameters")
+	}
+
+	// c.Status() sets the HTTP status code of the response.
+	// c.JSON() sends a JSON response.
+	return c.Status(fiber.StatusBadRequest).JSON(utils.Response{
+		// Success is set to false to indicate that the request was not successful.
+		Success: false,
+		// The message is included in the response, translated into the locale of the request.
+		Message: i18n.T(c, "Invalid query parameters"),
+		// The code lets clients highlight the invalid parameters.
+		Code: "invalid_parameters",
+		// The invalid parameters are included in the response.
+		Error: translateFieldErrors(c, fieldErrs),
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,68 @@
+// This file defines the layer that keeps deprecated paths working while clients move to their successors.
+package router
+
+// "strings" provides functions for working with strings. It is used here to fill in the parameters of the successor path.
+import (
+	"strings"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to register the aliases and set their headers.
+	"github.com/gofiber/fiber/v2"
+)
+
+// alias is a deprecated path that serves the same handler as the path that replaced it.
+// Aliases are kept for one release, so that clients have time to move to the successor.
+type alias struct {
+	// method is the HTTP method of the alias.
+	method string
+	// path is the deprecated path, relative to the group, such as "/update/:id".
+	path string
+	// successor is the path that replaced it, relative to the group and with the same parameters, such as "/:id".
+	successor string
+	// handler handles the requests of both paths.
+	handler fiber.Handler
+}
+
+// registerAliases registers deprecated paths on a group.
+// Each alias answers like its successor, with a "Deprecation: true" header and a Link header that points to the successor.
+// They must be registered before the parameter routes of the group, so that a path such as "/list" is not read as an ID.
+//
+// @param group fiber.Router - The group of the aliases.
+// @param prefix string - The full path of the group, such as "/api/v1/todos", used to build the Link header.
+// @param aliases []alias - The aliases.
+func registerAliases(group fiber.Router, prefix string, aliases []alias) {
+	// This iterates over the aliases.
+	for _, a := range aliases {
+		// This checks if the alias reads a resource.
+		if a.method == fiber.MethodGet {
+			// If it does, it is registered with Get, which also answers HEAD like the successor does.
+			group.Get(a.path, deprecated(prefix+a.successor), a.handler)
+			// The next alias is registered.
+			continue
+		}
+		// Otherwise the alias is registered for its method.
+		group.Add(a.method, a.path, deprecated(prefix+a.successor), a.handler)
+	}
+}
+
+// deprecated returns a middleware that marks the response of a deprecated path.
+//
+// @param successor string - The full path of the successor, whose parameters are filled in from the request.
+// @return fiber.Handler - The Fiber handler.
+func deprecated(successor string) fiber.Handler {
+	// This returns a new Fiber handler.
+	return func(c *fiber.Ctx) error {
+		// path is the successor path of this request.
+		path := successor
+		// This iterates over the parameters of the route.
+		for _, name := range c.Route().Params {
+			// The parameter is replaced with its value in the request.
+			path = strings.ReplaceAll(path, ":"+name, c.Params(name))
+		}
+		// The Deprecation header tells the client that the path will be removed.
+		c.Set("Deprecation", "true")
+		// The Link header points the client to the path that replaces it.
+		c.Set(fiber.HeaderLink, "<"+path+">; rel=\"successor-version\"")
+		// The request is passed on to the handler.
+		return c.Next()
+	}
+}
@@ -0,0 +1,78 @@
+// This file defines the set of controllers that the router serves.
+package router
+
+// "github.com/rahulcodepython/todo-backend/apps/admin" is a local package that contains the admin console controllers.
+import (
+	"github.com/rahulcodepython/todo-backend/apps/admin"
+	// "github.com/rahulcodepython/todo-backend/apps/audit" is a local package that contains the audit log controllers.
+	"github.com/rahulcodepython/todo-backend/apps/audit"
+	// "github.com/rahulcodepython/todo-backend/apps/caldav" is a local package that contains the CalDAV controllers.
+	"github.com/rahulcodepython/todo-backend/apps/caldav"
+	// "github.com/rahulcodepython/todo-backend/apps/diagnostics" is a local package that contains the runtime diagnostics controllers.
+	"github.com/rahulcodepython/todo-backend/apps/diagnostics"
+	// "github.com/rahulcodepython/todo-backend/apps/lists" is a local package that contains the list controllers.
+	"github.com/rahulcodepython/todo-backend/apps/lists"
+	// "github.com/rahulcodepython/todo-backend/apps/media" is a local package that contains the media controllers.
+	"github.com/rahulcodepython/todo-backend/apps/media"
+	// "github.com/rahulcodepython/todo-backend/apps/meta" is a local package that contains the capabilities controller.
+	"github.com/rahulcodepython/todo-backend/apps/meta"
+	// "github.com/rahulcodepython/todo-backend/apps/notifications" is a local package that contains the notification controllers.
+	"github.com/rahulcodepython/todo-backend/apps/notifications"
+	// "github.com/rahulcodepython/todo-backend/apps/offlinesync" is a local package that contains the offline sync controllers.
+	"github.com/rahulcodepython/todo-backend/apps/offlinesync"
+	// "github.com/rahulcodepython/todo-backend/apps/scim" is a local package that contains the SCIM provisioning controllers.
+	"github.com/rahulcodepython/todo-backend/apps/scim"
+	// "github.com/rahulcodepython/todo-backend/apps/serviceaccounts" is a local package that contains the service account controllers.
+	"github.com/rahulcodepython/todo-backend/apps/serviceaccounts"
+	// "github.com/rahulcodepython/todo-backend/apps/slack" is a local package that contains the Slack integration controllers.
+	"github.com/rahulcodepython/todo-backend/apps/slack"
+	// "github.com/rahulcodepython/todo-backend/apps/sso" is a local package that contains the single sign-on controllers.
+	"github.com/rahulcodepython/todo-backend/apps/sso"
+	// "github.com/rahulcodepython/todo-backend/apps/tags" is a local package that contains the tag controllers.
+	"github.com/rahulcodepython/todo-backend/apps/tags"
+	// "github.com/rahulcodepython/todo-backend/apps/telegram" is a local package that contains the Telegram integration controllers.
+	"github.com/rahulcodepython/todo-backend/apps/telegram"
+	// "github.com/rahulcodepython/todo-backend/apps/todos" is a local package that contains the todo controllers.
+	"github.com/rahulcodepython/todo-backend/apps/todos"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains the user controllers.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+)
+
+// Controllers holds one instance of every controller. It is built by the bootstrap package,
+// so that the router only maps routes to handlers and never constructs dependencies itself.
+type Controllers struct {
+	// Users is the user controller.
+	Users *users.UserControl
+	// Todos is the todo controller.
+	Todos *todos.TodoController
+	// Lists is the list controller.
+	Lists *lists.ListController
+	// Tags is the tag controller.
+	Tags *tags.TagController
+	// Sync is the offline sync controller.
+	Sync *offlinesync.SyncController
+	// Media is the media controller.
+	Media *media.MediaController
+	// Notifications is the notification controller.
+	Notifications *notifications.NotificationController
+	// Telegram is the Telegram integration controller.
+	Telegram *telegram.TelegramController
+	// Slack is the Slack integration controller.
+	Slack *slack.SlackController
+	// Audit is the audit log controller.
+	Audit *audit.AuditController
+	// CalDAV is the CalDAV controller.
+	CalDAV *caldav.CalDAVController
+	// Diagnostics is the runtime diagnostics controller.
+	Diagnostics *diagnostics.DiagnosticsController
+	// Admin is the admin console controller.
+	Admin *admin.AdminController
+	// Meta is the capabilities controller.
+	Meta *meta.MetaController
+	// ServiceAccounts is the service account controller.
+	ServiceAccounts *serviceaccounts.ServiceAccountController
+	// SSO is the single sign-on controller.
+	SSO *sso.SSOController
+	// SCIM is the SCIM provisioning controller.
+	SCIM *scim.SCIMController
+}
@@ -0,0 +1,688 @@
+// This file defines the contract tests of docs/openapi.json, which check that the spec describes every route the router
+// serves, and that its schemas and query parameters match the types the handlers read and send.
+package router
+
+// "encoding/json" provides functions for encoding and decoding JSON. It is used here to read the spec.
+import (
+	"encoding/json"
+	// "os" provides a platform-independent interface to operating system functionality. It is used here to read the spec file.
+	"os"
+	// "reflect" implements run-time reflection. It is used here to read the fields of the types the spec describes.
+	"reflect"
+	// "regexp" implements regular expression search. It is used here to rewrite the parameters of the routes.
+	"regexp"
+	// "sort" provides primitives for sorting. It is used here to report the differences in a stable order.
+	"sort"
+	// "strings" provides functions for working with strings. It is used here to read the paths and the JSON tags.
+	"strings"
+	// "testing" provides support for automated tests. It is used here to run the tests.
+	"testing"
+	// "time" provides functionality for measuring and displaying time. It is used here to recognize the timestamps.
+	"time"
+
+	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to list the routes.
+	"github.com/gofiber/fiber/v2"
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to recognize the IDs.
+	"github.com/google/uuid"
+	// "github.com/rahulcodepython/todo-backend/apps/admin" is a local package for the admin API. It is used here for its response types.
+	"github.com/rahulcodepython/todo-backend/apps/admin"
+	// "github.com/rahulcodepython/todo-backend/apps/audit" is a local package for the audit log. It is used here for its response and query types.
+	"github.com/rahulcodepython/todo-backend/apps/audit"
+	// "github.com/rahulcodepython/todo-backend/apps/diagnostics" is a local package for the runtime diagnostics. It is used here for its response and query types.
+	"github.com/rahulcodepython/todo-backend/apps/diagnostics"
+	// "github.com/rahulcodepython/todo-backend/apps/lists" is a local package for lists. It is used here for its request, response, and query types.
+	"github.com/rahulcodepython/todo-backend/apps/lists"
+	// "github.com/rahulcodepython/todo-backend/apps/media" is a local package for uploaded images. It is used here for its response and query types.
+	"github.com/rahulcodepython/todo-backend/apps/media"
+	// "github.com/rahulcodepython/todo-backend/apps/meta" is a local package for the capabilities of the server. It is used here for its response type.
+	"github.com/rahulcodepython/todo-backend/apps/meta"
+	// "github.com/rahulcodepython/todo-backend/apps/notifications" is a local package for notifications. It is used here for its request and response types.
+	"github.com/rahulcodepython/todo-backend/apps/notifications"
+	// "github.com/rahulcodepython/todo-backend/apps/offlinesync" is a local package for offline sync. It is used here for its request, response, and query types.
+	"github.com/rahulcodepython/todo-backend/apps/offlinesync"
+	// "github.com/rahulcodepython/todo-backend/apps/scim" is a local package for SCIM provisioning. It is used here for its response types.
+	"github.com/rahulcodepython/todo-backend/apps/scim"
+	// "github.com/rahulcodepython/todo-backend/apps/serviceaccounts" is a local package for service accounts. It is used here for its response types.
+	"github.com/rahulcodepython/todo-backend/apps/serviceaccounts"
+	// "github.com/rahulcodepython/todo-backend/apps/slack" is a local package for the Slack app. It is used here for its response types.
+	"github.com/rahulcodepython/todo-backend/apps/slack"
+	// "github.com/rahulcodepython/todo-backend/apps/sso" is a local package for single sign-on. It is used here for its response types.
+	"github.com/rahulcodepython/todo-backend/apps/sso"
+	// "github.com/rahulcodepython/todo-backend/apps/tags" is a local package for tags. It is used here for its request, response, and query types.
+	"github.com/rahulcodepython/todo-backend/apps/tags"
+	// "github.com/rahulcodepython/todo-backend/apps/telegram" is a local package for the Telegram bot. It is used here for its update and response types.
+	"github.com/rahulcodepython/todo-backend/apps/telegram"
+	// "github.com/rahulcodepython/todo-backend/apps/todos" is a local package for todos. It is used here for its request, response, and query types.
+	"github.com/rahulcodepython/todo-backend/apps/todos"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package for users. It is used here for its response types.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/binding" is a local package that reads request parameters. It is used here for the details of invalid parameters.
+	"github.com/rahulcodepython/todo-backend/backend/binding"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+	// "github.com/rahulcodepython/todo-backend/backend/quota" is a local package that enforces the plan limits. It is used here for the details of an exceeded limit.
+	"github.com/rahulcodepython/todo-backend/backend/quota"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package with shared helpers. It is used here for the metadata of the envelope.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+	// "github.com/rahulcodepython/todo-backend/backend/version" is a local package that describes the build. It is used here for the data of the health check.
+	"github.com/rahulcodepython/todo-backend/backend/version"
+)
+
+// specPath is the path of the spec, from the directory of this package.
+const specPath = "../../docs/openapi.json"
+
+// undocumentedPrefixes are the paths the spec leaves out. CalDAV speaks WebDAV methods, such as PROPFIND and REPORT,
+// that OpenAPI cannot describe, and the admin console is an HTML page over the same data as the admin API.
+var undocumentedPrefixes = []string{"/caldav", "/.well-known/caldav", "/admin"}
+
+// specSchemaTypes maps the schemas of the spec to the exported types they describe. The schemas of types a package keeps
+// unexported, such as the request bodies of users, are checked by the routes that use them rather than here.
+var specSchemaTypes = map[string]any{
+	"VersionInfo":                          version.Info{},
+	"Meta":                                 utils.Meta{},
+	"FieldError":                           binding.FieldError{},
+	"ExceededError":                        quota.ExceededError{},
+	"ProfileResponse":                      users.ProfileResponse{},
+	"UsageResponse":                        users.UsageResponse{},
+	"SessionResponse":                      users.SessionResponse{},
+	"APIKeyResponse":                       users.APIKeyResponse{},
+	"DeviceCodeResponse":                   users.DeviceCodeResponse{},
+	"DeviceRequestResponse":                users.DeviceRequestResponse{},
+	"ServiceAccountResponse":               serviceaccounts.ServiceAccountResponse{},
+	"TokenResponse":                        serviceaccounts.TokenResponse{},
+	"ConnectionResponse":                   sso.ConnectionResponse{},
+	"LoginURLResponse":                     sso.LoginURLResponse{},
+	"LoginResponse":                        sso.LoginResponse{},
+	"SCIMTokenResponse":                    sso.SCIMTokenResponse{},
+	"SCIMUserResponse":                     scim.UserResponse{},
+	"SCIMListResponse":                     scim.ListResponse{},
+	"SCIMErrorResponse":                    scim.ErrorResponse{},
+	"Create_UpdateTodoRequest":             todos.Create_UpdateTodoRequest{},
+	"QuickAddTodoRequest":                  todos.QuickAddTodoRequest{},
+	"CompleteTodoRequest":                  todos.CompleteTodoRequest{},
+	"DuplicateTodoRequest":                 todos.DuplicateTodoRequest{},
+	"MoveTodosRequest":                     todos.MoveTodosRequest{},
+	"SetTodoStatusRequest":                 todos.SetTodoStatusRequest{},
+	"AddBlockerRequest":                    todos.AddBlockerRequest{},
+	"SnoozeTodoRequest":                    todos.SnoozeTodoRequest{},
+	"UndoTodoRequest":                      todos.UndoTodoRequest{},
+	"TodoResponse":                         todos.TodoResponse{},
+	"PaginatedTodoResponse":                todos.PaginatedTodoResponse{},
+	"PaginatedArchivedTodoResponse":        todos.PaginatedArchivedTodoResponse{},
+	"PlanResponse":                         todos.PlanResponse{},
+	"BoardColumnResponse":                  todos.BoardColumnResponse{},
+	"UndoableTodoResponse":                 todos.UndoableTodoResponse{},
+	"DeleteTodoResponse":                   todos.DeleteTodoResponse{},
+	"CreateListRequest":                    lists.CreateListRequest{},
+	"UpdateListRequest":                    lists.UpdateListRequest{},
+	"ReorderListRequest":                   lists.ReorderListRequest{},
+	"CompleteAllRequest":                   lists.CompleteAllRequest{},
+	"CompleteAllConfirmation":              lists.CompleteAllConfirmation{},
+	"ListCompletedEvent":                   lists.ListCompletedEvent{},
+	"ListResponse":                         lists.ListResponse{},
+	"TagStyleRequest":                      tags.TagStyleRequest{},
+	"TagResponse":                          tags.TagResponse{},
+	"PullResponse":                         offlinesync.PullResponse{},
+	"PushRequest":                          offlinesync.PushRequest{},
+	"PushResponse":                         offlinesync.PushResponse{},
+	"UpdateNotificationPreferencesRequest": notifications.UpdatePreferencesRequest{},
+	"PreferenceResponse":                   notifications.PreferenceResponse{},
+	"DigestRequest":                        notifications.DigestRequest{},
+	"DigestResponse":                       notifications.DigestResponse{},
+	"RegisterDeviceRequest":                notifications.RegisterDeviceRequest{},
+	"DeviceResponse":                       notifications.DeviceResponse{},
+	"WebPushKeyResponse":                   notifications.WebPushKeyResponse{},
+	"WebPushSubscriptionRequest":           notifications.WebPushSubscriptionRequest{},
+	"WebPushSubscriptionResponse":          notifications.WebPushSubscriptionResponse{},
+	"UnsubscribeResponse":                  notifications.UnsubscribeResponse{},
+	"MediaResponse":                        media.MediaResponse{},
+	"MetaResponse":                         meta.MetaResponse{},
+	"TelegramUpdate":                       telegram.Update{},
+	"LinkCodeResponse":                     telegram.LinkCodeResponse{},
+	"InstallURLResponse":                   slack.InstallURLResponse{},
+	"InstallResponse":                      slack.InstallResponse{},
+	"UsersResponse":                        admin.UsersResponse{},
+	"JobsResponse":                         admin.JobsResponse{},
+	"FeatureFlag":                          admin.FeatureFlag{},
+	"BackupJob":                            admin.BackupJob{},
+	"BackupJobsResponse":                   admin.BackupJobsResponse{},
+	"RestoreRequest":                       admin.RestoreRequest{},
+	"EntriesResponse":                      audit.EntriesResponse{},
+	"RuntimeResponse":                      diagnostics.RuntimeResponse{},
+	"SlowQueriesResponse":                  diagnostics.SlowQueriesResponse{},
+}
+
+// specQueryTypes maps the operations of the spec to the types their query parameters are bound to.
+var specQueryTypes = map[string]any{
+	"createTodo":           todos.CreateTodoParams{},
+	"createTodoDeprecated": todos.CreateTodoParams{},
+	"listTodos":            todos.ListTodosQuery{},
+	"listTodosDeprecated":  todos.ListTodosQuery{},
+	"planTodos":            todos.PlanQuery{},
+	"getBoard":             todos.BoardQuery{},
+	"listArchivedTodos":    todos.ArchivedTodosQuery{},
+	"listLists":            lists.ListListsQuery{},
+	"listTags":             tags.ListTagsQuery{},
+	"pullChanges":          offlinesync.PullQuery{},
+	"getMedia":             media.MediaParams{},
+	"listAuditEntries":     audit.EntriesQuery{},
+	"listUsers":            admin.UsersQuery{},
+	"listJobs":             admin.JobsQuery{},
+	"listBackups":          admin.BackupJobsQuery{},
+	"listSlowQueries":      diagnostics.SlowQueriesQuery{},
+}
+
+// openAPISpec holds the parts of the spec the tests read.
+type openAPISpec struct {
+	// Paths maps each path to its operations by method, next to keys such as "servers" that are not operations.
+	Paths map[string]map[string]json.RawMessage `json:"paths"`
+	// Components holds the shared schemas.
+	Components struct {
+		// Schemas maps the name of each schema to the schema.
+		Schemas map[string]specSchema `json:"schemas"`
+	} `json:"components"`
+}
+
+// specOperation holds the parts of an operation the tests read.
+type specOperation struct {
+	// OperationID is the unique name of the operation.
+	OperationID string `json:"operationId"`
+	// Parameters are the parameters of the operation.
+	Parameters []struct {
+		// Name is the name of the parameter.
+		Name string `json:"name"`
+		// In is where the parameter is sent: "path", "query", or "header".
+		In string `json:"in"`
+	} `json:"parameters"`
+}
+
+// specSchema holds the parts of a schema the tests read.
+type specSchema struct {
+	// Ref is the reference to a shared schema, or empty.
+	Ref string `json:"$ref"`
+	// AllOf are the schemas a nullable reference wraps.
+	AllOf []specSchema `json:"allOf"`
+	// Type is the JSON type of the value, or empty for any value.
+	Type string `json:"type"`
+	// Items is the schema of the items of an array.
+	Items *specSchema `json:"items"`
+	// AdditionalProperties is the schema of the values of a map.
+	AdditionalProperties *specSchema `json:"additionalProperties"`
+	// Properties maps the names of the properties of an object to their schemas.
+	Properties map[string]specSchema `json:"properties"`
+}
+
+// operations are the methods that are operations in a path item of the spec.
+var operations = map[string]bool{"get": true, "put": true, "post": true, "delete": true, "options": true, "head": true, "patch": true, "trace": true}
+
+// routeParam matches a parameter of a Fiber route, such as ":id".
+var routeParam = regexp.MustCompile(`:(\w+)`)
+
+// loadSpec reads and decodes the spec.
+//
+// @param t *testing.T - The test state.
+// @return openAPISpec - The spec.
+func loadSpec(t *testing.T) openAPISpec {
+	t.Helper()
+	// data is the content of the spec file.
+	data, err := os.ReadFile(specPath)
+	// This checks if the file could not be read.
+	if err != nil {
+		// If it could not, the test fails.
+		t.Fatal(err)
+	}
+	// spec is the decoded spec.
+	var spec openAPISpec
+	// This decodes the spec.
+	if err := json.Unmarshal(data, &spec); err != nil {
+		// If it is not valid JSON, the test fails.
+		t.Fatalf("decode %s: %v", specPath, err)
+	}
+	return spec
+}
+
+// specOperations decodes the operations of the spec, keyed by "METHOD path".
+//
+// @param t *testing.T - The test state.
+// @param spec openAPISpec - The spec.
+// @return map[string]specOperation - The operations of the spec.
+func specOperations(t *testing.T, spec openAPISpec) map[string]specOperation {
+	t.Helper()
+	// ops are the decoded operations.
+	ops := map[string]specOperation{}
+	// This loops over the paths and their keys.
+	for path, item := range spec.Paths {
+		for method, raw := range item {
+			// Keys such as "servers" are not operations.
+			if !operations[method] {
+				continue
+			}
+			// op is the decoded operation.
+			var op specOperation
+			// This decodes the operation.
+			if err := json.Unmarshal(raw, &op); err != nil {
+				// If it cannot be decoded, the test fails.
+				t.Fatalf("decode %s %s: %v", method, path, err)
+			}
+			ops[strings.ToUpper(method)+" "+path] = op
+		}
+	}
+	return ops
+}
+
+// routerOperations lists the routes the router serves, in the form of the spec, keyed by "METHOD path".
+// The routes the spec leaves out, and the HEAD routes Fiber adds to every GET route, are skipped.
+//
+// @return map[string]bool - The routes of the router.
+func routerOperations() map[string]bool {
+	// app is an application with the WebDAV methods CalDAV needs, as the server configures it.
+	app := fiber.New(fiber.Config{RequestMethods: append(fiber.DefaultMethods[:len(fiber.DefaultMethods):len(fiber.DefaultMethods)], "PROPFIND", "REPORT")})
+	// The diagnostics are turned on so that their routes are registered too. No route runs, so no database or controller is needed.
+	Router(app, &config.Config{Diagnostics: config.DiagnosticsConfig{Enabled: true}}, nil, Controllers{})
+
+	// routes are the routes in the form of the spec.
+	routes := map[string]bool{}
+	// This loops over the routes of the application.
+	for _, route := range app.GetRoutes(true) {
+		// HEAD routes are added by Fiber for every GET route.
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		// path is the path of the route.
+		path := route.Path
+		// This checks if the spec leaves the path out.
+		if undocumented(path) {
+			continue
+		}
+		// The paths of the API are relative to the server of the spec.
+		if rest, ok := strings.CutPrefix(path, "/api/"+utils.APIVersion); ok {
+			path = rest
+		}
+		// The root of a group carries a trailing slash that the server does not require.
+		if len(path) > 1 {
+			path = strings.TrimSuffix(path, "/")
+		}
+		// The API root is "/".
+		if path == "" {
+			path = "/"
+		}
+		// Parameters are written as "{id}" instead of ":id".
+		path = routeParam.ReplaceAllString(path, "{$1}")
+		routes[route.Method+" "+path] = true
+	}
+	return routes
+}
+
+// undocumented reports whether the spec leaves a path of the router out.
+//
+// @param path string - The path of a route.
+// @return bool - True if the spec does not describe the path.
+func undocumented(path string) bool {
+	// This loops over the prefixes the spec leaves out.
+	for _, prefix := range undocumentedPrefixes {
+		// The prefix matches itself and the paths under it.
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// TestOpenAPISpecMatchesRouter checks that the spec describes every route the router serves, and no route it does not,
+// and that every operation has a unique ID and declares the parameters of its path.
+//
+// @param t *testing.T - The test state.
+func TestOpenAPISpecMatchesRouter(t *testing.T) {
+	// ops are the operations of the spec.
+	ops := specOperations(t, loadSpec(t))
+	// routes are the routes of the router.
+	routes := routerOperations()
+
+	// missing are the routes the spec does not describe.
+	var missing []string
+	for route := range routes {
+		if _, ok := ops[route]; !ok {
+			missing = append(missing, route)
+		}
+	}
+	sort.Strings(missing)
+	// Every route is described.
+	for _, route := range missing {
+		t.Errorf("route %s is not in %s", route, specPath)
+	}
+
+	// extra are the operations the router does not serve.
+	var extra []string
+	// ids are the operation IDs seen so far.
+	ids := map[string]string{}
+	for key, op := range ops {
+		if !routes[key] {
+			extra = append(extra, key)
+		}
+		// This checks if the operation has no ID or shares it.
+		if op.OperationID == "" {
+			t.Errorf("%s has no operationId", key)
+		} else if other, ok := ids[op.OperationID]; ok {
+			t.Errorf("%s and %s share the operationId %q", key, other, op.OperationID)
+		}
+		ids[op.OperationID] = key
+
+		// want are the parameters of the path.
+		want := map[string]bool{}
+		for _, m := range regexp.MustCompile(`\{(\w+)\}`).FindAllStringSubmatch(strings.SplitN(key, " ", 2)[1], -1) {
+			want[m[1]] = true
+		}
+		// got are the path parameters the operation declares.
+		got := map[string]bool{}
+		for _, p := range op.Parameters {
+			if p.In == "path" {
+				got[p.Name] = true
+			}
+		}
+		// The declared path parameters are the ones of the path.
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("%s declares path parameters %v, want %v", key, keys(got), keys(want))
+		}
+	}
+	sort.Strings(extra)
+	// No operation is described that the router does not serve.
+	for _, route := range extra {
+		t.Errorf("%s describes %s, which the router does not serve", specPath, route)
+	}
+}
+
+// TestOpenAPISpecRefsResolve checks that every reference in the spec names a component it defines.
+//
+// @param t *testing.T - The test state.
+func TestOpenAPISpecRefsResolve(t *testing.T) {
+	// data is the content of the spec file.
+	data, err := os.ReadFile(specPath)
+	// This checks if the file could not be read.
+	if err != nil {
+		// If it could not, the test fails.
+		t.Fatal(err)
+	}
+	// doc is the spec as generic JSON, so that references can be followed anywhere in it.
+	var doc map[string]any
+	// This decodes the spec.
+	if err := json.Unmarshal(data, &doc); err != nil {
+		// If it is not valid JSON, the test fails.
+		t.Fatal(err)
+	}
+
+	// walk visits every value of the spec and checks its references.
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			// This checks if the object is a reference.
+			if ref, ok := v["$ref"].(string); ok {
+				// target is the value the reference points to.
+				var target any = doc
+				for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+					obj, _ := target.(map[string]any)
+					target = obj[part]
+				}
+				// The reference points to a value.
+				if target == nil {
+					t.Errorf("%s does not resolve", ref)
+				}
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+}
+
+// TestOpenAPISpecSchemasMatchTypes checks that the schemas of the spec have the JSON fields and types of the Go types they
+// describe, following the schemas they reference to the types of the nested fields.
+//
+// @param t *testing.T - The test state.
+func TestOpenAPISpecSchemasMatchTypes(t *testing.T) {
+	// schemas are the shared schemas of the spec.
+	schemas := loadSpec(t).Components.Schemas
+	// checked are the schemas already compared, so that recursive types end.
+	checked := map[string]bool{}
+	// This loops over the registered types.
+	for name, value := range specSchemaTypes {
+		compareSchema(t, schemas, checked, name, reflect.TypeOf(value))
+	}
+}
+
+// compareSchema compares a shared schema with the struct type it describes, and the schemas its fields reference with their types.
+//
+// @param t *testing.T - The test state.
+// @param schemas map[string]specSchema - The shared schemas of the spec.
+// @param checked map[string]bool - The schemas already compared.
+// @param name string - The name of the schema.
+// @param typ reflect.Type - The struct type.
+func compareSchema(t *testing.T, schemas map[string]specSchema, checked map[string]bool, name string, typ reflect.Type) {
+	t.Helper()
+	// This checks if the schema was already compared.
+	if checked[name] {
+		return
+	}
+	checked[name] = true
+	// schema is the schema of the type.
+	schema, ok := schemas[name]
+	// The schema exists.
+	if !ok {
+		t.Errorf("schema %s of %s is not in %s", name, typ, specPath)
+		return
+	}
+
+	// fields are the JSON fields of the type.
+	fields := jsonFields(typ)
+	// This loops over the fields of the type.
+	for field, fieldType := range fields {
+		// property is the schema of the field.
+		property, ok := schema.Properties[field]
+		// The field is described.
+		if !ok {
+			t.Errorf("schema %s has no property %q of %s", name, field, typ)
+			continue
+		}
+		compareProperty(t, schemas, checked, name+"."+field, property, fieldType)
+	}
+	// This loops over the properties of the schema.
+	for property := range schema.Properties {
+		// The property is a field of the type.
+		if _, ok := fields[property]; !ok {
+			t.Errorf("schema %s has property %q, which %s does not have", name, property, typ)
+		}
+	}
+}
+
+// compareProperty compares the schema of a value with its Go type.
+//
+// @param t *testing.T - The test state.
+// @param schemas map[string]specSchema - The shared schemas of the spec.
+// @param checked map[string]bool - The schemas already compared.
+// @param at string - Where the value is, for the messages.
+// @param schema specSchema - The schema of the value.
+// @param typ reflect.Type - The Go type of the value.
+func compareProperty(t *testing.T, schemas map[string]specSchema, checked map[string]bool, at string, schema specSchema, typ reflect.Type) {
+	t.Helper()
+	// A nullable reference wraps the reference in allOf.
+	if schema.Ref == "" && len(schema.AllOf) == 1 {
+		schema = schema.AllOf[0]
+	}
+	// Pointers are the nullable values of their element type.
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	// want is the JSON type of the Go type.
+	want := jsonType(typ)
+	// This checks if the schema references a shared schema.
+	if schema.Ref != "" {
+		// name is the name of the referenced schema.
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		// This checks if the value is an object of its own.
+		if want == "object" && typ.Kind() == reflect.Struct {
+			// If it is, the referenced schema is compared with its type.
+			compareSchema(t, schemas, checked, name, typ)
+			return
+		}
+		schema = schemas[name]
+	}
+	// This checks if the type has no fixed JSON type, such as raw JSON, or the schema allows any value.
+	if want == "" || schema.Type == "" {
+		// Only both may be open.
+		if want != schema.Type {
+			t.Errorf("%s has type %q, want %q for %s", at, schema.Type, want, typ)
+		}
+		return
+	}
+	// The JSON types match.
+	if schema.Type != want {
+		t.Errorf("%s has type %q, want %q for %s", at, schema.Type, want, typ)
+		return
+	}
+	// This checks the items of arrays and the values of maps.
+	switch {
+	case want == "array" && schema.Items != nil:
+		compareProperty(t, schemas, checked, at+"[]", *schema.Items, typ.Elem())
+	case want == "object" && typ.Kind() == reflect.Map && schema.AdditionalProperties != nil:
+		compareProperty(t, schemas, checked, at+"{}", *schema.AdditionalProperties, typ.Elem())
+	}
+}
+
+// jsonFields lists the fields of a struct type by their JSON names, with the fields of embedded structs promoted.
+//
+// @param typ reflect.Type - The struct type.
+// @return map[string]reflect.Type - The types of the fields by their JSON names.
+func jsonFields(typ reflect.Type) map[string]reflect.Type {
+	// fields are the fields found so far.
+	fields := map[string]reflect.Type{}
+	// This loops over the fields of the type.
+	for i := 0; i < typ.NumField(); i++ {
+		// field is the field.
+		field := typ.Field(i)
+		// tag is the JSON tag of the field.
+		tag := field.Tag.Get("json")
+		// Fields tagged "-" are not encoded.
+		if tag == "-" {
+			continue
+		}
+		// name is the JSON name of the field.
+		name, _, _ := strings.Cut(tag, ",")
+		// This checks if the field is an embedded struct without a name of its own.
+		if field.Anonymous && name == "" {
+			// embedded is the type of the embedded struct.
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			// Its fields are promoted.
+			for promoted, promotedType := range jsonFields(embedded) {
+				fields[promoted] = promotedType
+			}
+			continue
+		}
+		// Unexported fields are not encoded.
+		if !field.IsExported() {
+			continue
+		}
+		// A field without a JSON name is encoded by its Go name.
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// jsonType tells the JSON type a Go type is encoded as.
+//
+// @param typ reflect.Type - The Go type, which is not a pointer.
+// @return string - The JSON type, or empty for a type that can hold any value.
+func jsonType(typ reflect.Type) string {
+	// This checks the types with their own encoding first.
+	switch typ {
+	case reflect.TypeOf(time.Time{}), reflect.TypeOf(uuid.UUID{}), reflect.TypeOf(uuid.NullUUID{}):
+		return "string"
+	case reflect.TypeOf(json.RawMessage{}):
+		return ""
+	}
+	// The other types are encoded by their kind.
+	switch typ.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		// Byte slices are encoded as base64 strings.
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return ""
+}
+
+// TestOpenAPISpecQueryParameters checks that the query parameters of the operations are the ones their handlers bind.
+//
+// @param t *testing.T - The test state.
+func TestOpenAPISpecQueryParameters(t *testing.T) {
+	// ops are the operations of the spec, by their IDs.
+	ops := map[string]specOperation{}
+	for _, op := range specOperations(t, loadSpec(t)) {
+		ops[op.OperationID] = op
+	}
+	// This loops over the operations with bound query parameters.
+	for id, value := range specQueryTypes {
+		// op is the operation.
+		op, ok := ops[id]
+		// The operation exists.
+		if !ok {
+			t.Errorf("operation %s is not in %s", id, specPath)
+			continue
+		}
+		// typ is the type the parameters are bound to.
+		typ := reflect.TypeOf(value)
+		// want are the query parameters of the type.
+		want := map[string]bool{}
+		for i := 0; i < typ.NumField(); i++ {
+			if name := typ.Field(i).Tag.Get("query"); name != "" {
+				want[name] = true
+			}
+		}
+		// got are the query parameters the operation declares.
+		got := map[string]bool{}
+		for _, p := range op.Parameters {
+			if p.In == "query" {
+				got[p.Name] = true
+			}
+		}
+		// The declared parameters are the bound ones.
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("%s declares query parameters %v, want %v of %s", id, keys(got), keys(want), typ)
+		}
+	}
+}
+
+// keys lists the keys of a set in order.
+//
+// @param set map[string]bool - The set.
+// @return []string - The sorted keys.
+func keys(set map[string]bool) []string {
+	// out are the keys.
+	out := make([]string, 0, len(set))
+	for key := range set {
+		out = append(out, key)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -2,16 +2,16 @@
 // It sets up all the API routes and applies the necessary middleware.
 package router
 
-// "database/sql" provides a generic SQL interface. It is used here to pass the database connection to the controllers.
+// "database/sql" provides a generic SQL interface. It is used here to pass the database connection to the middleware.
 import (
 	"database/sql"
+	// "errors" provides functions for working with errors. It is used here to report the error of the degraded database.
+	"errors"
 
 	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to create the router and define the routes.
 	"github.com/gofiber/fiber/v2"
-	// "github.com/rahulcodepython/todo-backend/apps/todos" is a local package that contains the todo controllers.
-	"github.com/rahulcodepython/todo-backend/apps/todos"
-	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains the user controllers.
-	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/gofiber/fiber/v2/middleware/pprof" is a Fiber middleware that serves the net/http/pprof profiles. It is used here for the admin diagnostics.
+	"github.com/gofiber/fiber/v2/middleware/pprof"
 	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
 	"github.com/rahulcodepython/todo-backend/backend/config"
 	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database-related functions.
@@ -20,71 +20,481 @@ import (
 	"github.com/rahulcodepython/todo-backend/backend/middleware"
 	// "github.com/rahulcodepython/todo-backend/backend/response" is a local package that provides standardized API responses.
 	"github.com/rahulcodepython/todo-backend/backend/response"
+	// "github.com/rahulcodepython/todo-backend/backend/utils" is a local package that provides constant values. It is used here to version the API prefix.
+	"github.com/rahulcodepython/todo-backend/backend/utils"
+	// "github.com/rahulcodepython/todo-backend/backend/version" is a local package that holds the version of the build. It is used here to report it in the health check.
+	"github.com/rahulcodepython/todo-backend/backend/version"
 )
 
 // Router sets up the application's routes.
-// It takes the Fiber app, configuration, and database connection as input.
+// It takes the Fiber app, configuration, database connection, and controllers as input.
 //
 // @param app *fiber.App - The Fiber application.
 // @param cfg *config.Config - The application configuration.
-// @param db *sql.DB - The database connection.
-func Router(app *fiber.App, cfg *config.Config, db *sql.DB) {
+// @param db *sql.DB - The database connection, used by the middleware and the health check.
+// @param controllers Controllers - The controllers that handle the routes.
+func Router(app *fiber.App, cfg *config.Config, db *sql.DB, controllers Controllers) {
 	// app.Use() applies middleware to all routes.
+	// middleware.RequestID() is a middleware that gives each request an ID for the X-Request-ID header and the response metadata.
+	app.Use(middleware.RequestID())
+	// middleware.RequestLogger() is a middleware that gives each request a logger that names its ID, user, and route.
+	app.Use(middleware.RequestLogger())
+	// rateLimits holds the request counts shared by the rate limiters and the rate limit headers.
+	rateLimits := middleware.NewRateLimits(cfg)
+	// rateLimits.Headers() is a middleware that reports the caller's budget on the responses that no limiter saw.
+	app.Use(rateLimits.Headers())
+	// middleware.AppVersion() is a middleware that names the build in the X-App-Version header when it is turned on.
+	app.Use(middleware.AppVersion(cfg))
 	// middleware.Cors() is a middleware that handles Cross-Origin Resource Sharing.
 	app.Use(middleware.Cors(cfg))
 	// middleware.Logger() is a middleware that logs information about each request.
 	app.Use(middleware.Logger(cfg))
+	// middleware.Locale() is a middleware that picks the language of the responses from the Accept-Language header.
+	app.Use(middleware.Locale())
+	// middleware.Audit() is a middleware that records mutating requests in the audit log when auditing is enabled.
+	app.Use(middleware.Audit(cfg, db))
 
 	// authMiddleware is a middleware that checks if a user is authenticated.
-	authMiddleware := middleware.Authenticated(db)
+	authMiddleware := middleware.Authenticated(cfg, db)
 	// authenticatedUserMiddleware is a middleware that retrieves the authenticated user's information.
 	authenticatedUserMiddleware := middleware.AuthenticatedUser(db)
+	// sessionOnly is a middleware that rejects API keys, for the routes that no scope covers.
+	sessionOnly := middleware.RequireSession()
+	// profileScope is a middleware that asks API keys for the profile:read or profile:write scope.
+	profileScope := middleware.RequireScope("profile")
+	// userRateLimiter is a middleware that limits the requests of the authenticated user, with separate read and write buckets.
+	// It is placed after the authentication middlewares so that it can key on the user.
+	userRateLimiter := rateLimits.User()
+	// anonymousRateLimiter is a middleware that limits the requests of an IP address to the endpoints that do not require a user.
+	anonymousRateLimiter := rateLimits.Anonymous()
+
+	// captchaMiddleware is a middleware that asks for a solved captcha on the endpoints that create accounts, when a provider is configured.
+	captchaMiddleware := middleware.Captcha(cfg)
+
+	// requestTimeout is a middleware that cancels the queries of a request once its budget has passed and answers 504.
+	requestTimeout := middleware.Timeout(cfg.Server.RequestTimeout)
+
+	// This defines a GET route for the readiness check of load balancers and orchestrators, outside of the API prefix.
+	// It reads the state the database monitor last saw instead of pinging, so that frequent probes do not load the database.
+	app.Get("/readyz", func(c *fiber.Ctx) error {
+		// health is the state of the database connection.
+		health := database.CurrentHealth()
+		// This checks if the database cannot be reached.
+		if health.Degraded {
+			// If it cannot, a service unavailable response is returned so that load balancers stop routing here until it is reconnected.
+			return response.ServiceUnavailable(c, errors.New(health.LastError), "Database is unavailable")
+		}
+		// response.OKResponse() sends a 200 OK response with a success message.
+		return response.OKResponse(c, "Server is ready", nil)
+	})
 
 	// api is a new group of routes with the prefix "/api/v1".
-	api := app.Group("/api/v1")
+	// Its requests are bounded by the request budget, including the queries of the authentication middlewares.
+	api := app.Group("/api/"+utils.APIVersion, requestTimeout)
 
 	// This defines a GET route for the root of the API group.
 	// It serves as a health check endpoint.
 	api.Get("/", func(c *fiber.Ctx) error {
-		// database.PingDB() checks if the database connection is alive.
-		database.PingDB(db)
-		// response.OKResponse() sends a 200 OK response with a success message.
-		return response.OKResponse(c, "Database connected successfully", nil)
+		// database.PingDB() checks if the database connection is alive, within the lifetime of the request.
+		if err := database.PingDB(c.UserContext(), db); err != nil {
+			// If it is not, a service unavailable response is returned so that load balancers stop routing here.
+			return response.ServiceUnavailable(c, err, "Database is unavailable")
+		}
+		// response.OKResponse() sends a 200 OK response with a success message and the version of the build.
+		return response.OKResponse(c, "Database connected successfully", version.Current())
 	})
 
+	// This defines a GET route for the capabilities of the server: the API version, build commit, features, page sizes, and auth modes.
+	// It is limited by IP address, since clients read it before logging in.
+	api.Get("/meta", anonymousRateLimiter, controllers.Meta.MetaController)
+
 	// auth is a new group of routes with the prefix "/auth".
 	auth := api.Group("/auth")
 
-	// userController is a new instance of the user controller.
-	userController := users.NewUserControl(cfg, db)
+	// userController is the user controller.
+	userController := controllers.Users
 
 	// This defines a POST route for user registration.
-	auth.Post("/register", userController.RegisterUserController)
+	// It is limited by IP address, since there is no user yet, and asks for a captcha so that bots cannot create accounts in bulk.
+	auth.Post("/register", anonymousRateLimiter, captchaMiddleware, userController.RegisterUserController)
 	// This defines a POST route for user login.
-	auth.Post("/login", userController.LoginUserController)
+	// It is limited by IP address, since there is no user yet.
+	auth.Post("/login", anonymousRateLimiter, userController.LoginUserController)
+	// This defines a POST route for starting a guest session, which is bound to the device named by the X-Device-ID header.
+	// Like registration, it is limited by IP address and asks for a captcha, since it creates an account.
+	auth.Post("/guest", anonymousRateLimiter, captchaMiddleware, userController.StartGuestController)
+	// This defines a POST route for registering the current guest, who keeps their todos and lists.
+	auth.Post("/guest/claim", authMiddleware, authenticatedUserMiddleware, userRateLimiter, userController.ClaimGuestController)
+	// This defines GET and POST routes for the revoke link of the new device alerts.
+	// They are authenticated by the signed token of the link rather than a user token, so that a stolen session can be ended without logging in.
+	// GET only shows a page that asks before the session ends, since mail scanners open links, and its button POSTs the token to end it.
+	auth.Get("/sessions/revoke", anonymousRateLimiter, userController.RevokeSessionPageController)
+	auth.Post("/sessions/revoke", anonymousRateLimiter, userController.RevokeSessionController)
+	// This defines GET and POST routes for the links of an email change, which the emails to the new and the old address carry.
+	// Like the revoke link, they are authenticated by their signed token, since the user is logged out once the change applies,
+	// and GET only shows a page that asks, whose button POSTs the token to apply or cancel the change.
+	auth.Get("/email/confirm", anonymousRateLimiter, userController.ConfirmEmailChangePageController)
+	auth.Post("/email/confirm", anonymousRateLimiter, userController.ConfirmEmailChangeController)
+	auth.Get("/email/cancel", anonymousRateLimiter, userController.CancelEmailChangePageController)
+	auth.Post("/email/cancel", anonymousRateLimiter, userController.CancelEmailChangeController)
+	// This defines POST routes for the device authorization grant, which signs in command-line tools such as todoctl.
+	// The device asks for its codes and polls for its session without a token, so they are limited by IP address.
+	auth.Post("/device/code", anonymousRateLimiter, userController.StartDeviceController)
+	auth.Post("/device/token", anonymousRateLimiter, userController.DeviceTokenController)
+	// This defines the routes the page of the verification URL calls to show, approve, or deny a device.
+	// They only accept sessions, so that an API key cannot hand out sessions.
+	auth.Get("/device", authMiddleware, sessionOnly, authenticatedUserMiddleware, userRateLimiter, userController.PendingDeviceController)
+	auth.Post("/device/approve", authMiddleware, sessionOnly, authenticatedUserMiddleware, userRateLimiter, userController.ApproveDeviceController)
+	auth.Post("/device/deny", authMiddleware, sessionOnly, authenticatedUserMiddleware, userRateLimiter, userController.DenyDeviceController)
 
 	// This defines a GET route for user logout.
-	// It is protected by the authMiddleware.
-	auth.Get("/logout", authMiddleware, userController.LogoutUserController)
+	// It is protected by the authMiddleware, and limited per token.
+	auth.Get("/logout", authMiddleware, userRateLimiter, userController.LogoutUserController)
+	// This defines a GET route for listing the user's sessions, with the device and last use of each.
+	auth.Get("/sessions", authMiddleware, profileScope, authenticatedUserMiddleware, userRateLimiter, userController.SessionsController)
 	// This defines a GET route for retrieving the user's profile.
-	// It is protected by both the authMiddleware and the authenticatedUserMiddleware.
-	auth.Get("/profile", authMiddleware, authenticatedUserMiddleware, userController.UserProfileController)
+	// It is protected by both the authMiddleware and the authenticatedUserMiddleware, and limited per user.
+	auth.Get("/profile", authMiddleware, profileScope, authenticatedUserMiddleware, userRateLimiter, userController.UserProfileController)
+	// This defines a PATCH route for updating the user's preferences, such as the time zone.
+	auth.Patch("/preferences", authMiddleware, profileScope, authenticatedUserMiddleware, userRateLimiter, userController.UpdatePreferencesController)
+	// This defines a GET route for the current user's usage against the limits of their plan.
+	auth.Get("/usage", authMiddleware, profileScope, authenticatedUserMiddleware, userRateLimiter, userController.UsageController)
+	// This defines a POST route for changing the user's email address, which asks for the password and only accepts sessions.
+	auth.Post("/email", authMiddleware, sessionOnly, authenticatedUserMiddleware, userRateLimiter, userController.ChangeEmailController)
+
+	// This defines a POST route for creating an API key limited to a list of scopes, for handing to another tool.
+	// The routes of the API keys only accept sessions, so that a key cannot create a key with more scopes than its own.
+	auth.Post("/keys", authMiddleware, sessionOnly, authenticatedUserMiddleware, userRateLimiter, userController.CreateAPIKeyController)
+	// This defines a GET route for listing the user's API keys.
+	auth.Get("/keys", authMiddleware, sessionOnly, authenticatedUserMiddleware, userRateLimiter, userController.APIKeysController)
+	// This defines a DELETE route for deleting an API key.
+	auth.Delete("/keys/:id", authMiddleware, sessionOnly, authenticatedUserMiddleware, userRateLimiter, userController.DeleteAPIKeyController)
+
+	// serviceAccountController is the service account controller.
+	serviceAccountController := controllers.ServiceAccounts
+
+	// This defines a POST route for exchanging the client credentials of a service account for a token.
+	// It is limited by IP address, since the caller has no token yet.
+	auth.Post("/token", anonymousRateLimiter, serviceAccountController.TokenController)
+
+	// ssoController is the single sign-on controller.
+	ssoController := controllers.SSO
+
+	// This defines a GET route for the authorization URL of a single sign-on connection.
+	auth.Get("/sso/:slug", anonymousRateLimiter, ssoController.LoginController)
+	// This defines a GET route for the callback the identity provider sends the user back to, which signs them in.
+	auth.Get("/sso/:slug/callback", anonymousRateLimiter, ssoController.CallbackController)
+
+	// scimController is the SCIM provisioning controller.
+	scimController := controllers.SCIM
+
+	// scimGroup is a new group of routes with the prefix "/scim/v2", which the identity providers of the connections call.
+	// They authenticate with the SCIM token of a connection rather than a user's token, and are limited by IP address.
+	scimGroup := api.Group("/scim/v2", anonymousRateLimiter, scimController.Authenticated)
+
+	// This defines a GET route for paging through the provisioned users, optionally filtered by userName or externalId.
+	scimGroup.Get("/Users", scimController.ListUsersController)
+	// This defines a POST route for provisioning a user.
+	scimGroup.Post("/Users", scimController.CreateUserController)
+	// This defines a GET route for one provisioned user.
+	scimGroup.Get("/Users/:id", scimController.GetUserController)
+	// This defines a PUT route for replacing the attributes of a provisioned user.
+	scimGroup.Put("/Users/:id", scimController.ReplaceUserController)
+	// This defines a PATCH route for changing some attributes of a provisioned user, such as deactivating them.
+	scimGroup.Patch("/Users/:id", scimController.PatchUserController)
+	// This defines a DELETE route for deleting a provisioned user.
+	scimGroup.Delete("/Users/:id", scimController.DeleteUserController)
+
+	// serviceAccountGroup is a new group of routes with the prefix "/service-accounts", for managing the service accounts of the current user.
+	// It is closed to API keys and service accounts, so that a machine cannot create more machines.
+	serviceAccountGroup := api.Group("/service-accounts", authMiddleware, sessionOnly, authenticatedUserMiddleware, userRateLimiter)
+
+	// This defines a POST route for creating a service account.
+	serviceAccountGroup.Post("/", serviceAccountController.CreateServiceAccountController)
+	// This defines a GET route for listing the service accounts.
+	serviceAccountGroup.Get("/", serviceAccountController.ServiceAccountsController)
+	// This defines a POST route for replacing the client secret of a service account.
+	serviceAccountGroup.Post("/:id/secret", serviceAccountController.RotateSecretController)
+	// This defines a DELETE route for deleting a service account.
+	serviceAccountGroup.Delete("/:id", serviceAccountController.DeleteServiceAccountController)
 
 	// todo is a new group of routes with the prefix "/todos".
-	// It is protected by both the authMiddleware and the authenticatedUserMiddleware.
-	todo := api.Group("/todos", authMiddleware, authenticatedUserMiddleware)
+	// It is protected by both the authMiddleware and the authenticatedUserMiddleware, open to API keys with the todos scopes, and limited per user.
+	todo := api.Group("/todos", authMiddleware, middleware.RequireScope("todos"), authenticatedUserMiddleware, userRateLimiter)
 
-	// todoController is a new instance of the todo controller.
-	todoController := todos.NewTodoControl(cfg, db)
+	// todoController is the todo controller.
+	todoController := controllers.Todos
+
+	// The paths of the first release are kept as deprecated aliases of the resource paths below.
+	registerAliases(todo, "/api/"+utils.APIVersion+"/todos", []alias{
+		// POST /todos/create was replaced by POST /todos.
+		{method: fiber.MethodPost, path: "/create", successor: "", handler: todoController.CreateTodoController},
+		// GET /todos/list was replaced by GET /todos.
+		{method: fiber.MethodGet, path: "/list", successor: "", handler: todoController.GetTodosController},
+		// PUT /todos/update/:id was replaced by PUT /todos/:id.
+		{method: fiber.MethodPut, path: "/update/:id", successor: "/:id", handler: todoController.UpdateTodoController},
+		// PATCH /todos/complete/:id was replaced by PATCH /todos/:id.
+		{method: fiber.MethodPatch, path: "/complete/:id", successor: "/:id", handler: todoController.CompleteTodoController},
+		// DELETE /todos/delete/:id was replaced by DELETE /todos/:id.
+		{method: fiber.MethodDelete, path: "/delete/:id", successor: "/:id", handler: todoController.DeleteTodoController},
+	})
 
 	// This defines a POST route for creating a new todo.
-	todo.Post("/create", todoController.CreateTodoController)
-	// This defines a GET route for retrieving all todos.
-	todo.Get("/list", todoController.GetTodosController)
+	todo.Post("/", todoController.CreateTodoController)
+	// This defines a POST route for creating a todo from a single line of text.
+	todo.Post("/quick", todoController.QuickAddTodoController)
+	// This defines a GET route for retrieving a page of todos.
+	todo.Get("/", todoController.GetTodosController)
+	// This defines a GET route for the estimated workload per day. It is registered before "/:id", which would take "plan" for an ID.
+	todo.Get("/plan", todoController.PlanController)
+	// This defines a GET route for retrieving the todos grouped by status, like the columns of a board.
+	todo.Get("/board", todoController.BoardController)
+	// This defines a GET route for retrieving a page of the todos that were moved to the archive.
+	todo.Get("/archive", todoController.ArchivedTodosController)
+	// This defines a GET route for retrieving a todo, which is where the Location header of a created todo points.
+	todo.Get("/:id", todoController.GetTodoController)
 	// This defines a PUT route for updating a todo.
-	todo.Put("/update/:id", todoController.UpdateTodoController)
+	todo.Put("/:id", todoController.UpdateTodoController)
 	// This defines a PATCH route for completing a todo.
-	todo.Patch("/complete/:id", todoController.CompleteTodoController)
+	todo.Patch("/:id", todoController.CompleteTodoController)
+	// This defines a DELETE route for deleting a todo.
+	todo.Delete("/:id", todoController.DeleteTodoController)
+	// This defines a POST route for moving several todos into a list.
+	todo.Post("/move", todoController.MoveTodosController)
+	// This defines a POST route for duplicating a todo.
+	todo.Post("/:id/duplicate", todoController.DuplicateTodoController)
+	// This defines a PUT route for moving a todo to another column of the board.
+	todo.Put("/:id/status", todoController.SetTodoStatusController)
+	// This defines a GET route for retrieving the blockers of a todo.
+	todo.Get("/:id/blockers", todoController.GetBlockersController)
+	// This defines a POST route for making a todo wait on another todo.
+	todo.Post("/:id/blockers", todoController.AddBlockerController)
+	// This defines a DELETE route for removing a blocker from a todo.
+	todo.Delete("/:id/blockers/:blockerId", todoController.RemoveBlockerController)
+	// This defines a POST route for snoozing a todo until a later time.
+	todo.Post("/:id/snooze", todoController.SnoozeTodoController)
+	// This defines a POST route for undoing a recent delete, complete, snooze, or status action.
+	todo.Post("/undo", todoController.UndoTodoController)
+
+	// list is a new group of routes with the prefix "/lists".
+	// It is protected by both the authMiddleware and the authenticatedUserMiddleware, open to API keys with the lists scopes, and limited per user.
+	list := api.Group("/lists", authMiddleware, middleware.RequireScope("lists"), authenticatedUserMiddleware, userRateLimiter)
+
+	// listController is the list controller.
+	listController := controllers.Lists
+
+	// This defines a POST route for creating a new list.
+	list.Post("/", listController.CreateListController)
+	// This defines a GET route for retrieving all lists.
+	list.Get("/", listController.GetListsController)
+	// This defines a GET route for retrieving a list, which is where the Location header of a created list points.
+	list.Get("/:id", listController.GetListController)
+	// This defines a PATCH route for renaming a list and changing its color and icon.
+	list.Patch("/:id", listController.UpdateListController)
+	// This defines a POST route for archiving a list, which hides its todos from the default views.
+	list.Post("/:id/archive", listController.ArchiveListController)
+	// This defines a POST route for unarchiving a list.
+	list.Post("/:id/unarchive", listController.UnarchiveListController)
+	// This defines a POST route for completing every open todo of a list.
+	list.Post("/:id/complete-all", listController.CompleteAllController)
+	// This defines a POST route for reordering the todos of a list.
+	list.Post("/:id/reorder", listController.ReorderListController)
+
+	// tag is a new group of routes with the prefix "/tags".
+	// Tags live on todos, so the group is open to API keys with the todos scopes, and is limited per user.
+	tag := api.Group("/tags", authMiddleware, middleware.RequireScope("todos"), authenticatedUserMiddleware, userRateLimiter)
+
+	// tagController is the tag controller.
+	tagController := controllers.Tags
+
+	// This defines a GET route for retrieving the user's tags and their styles.
+	tag.Get("/", tagController.GetTagsController)
+	// This defines a PUT route for setting the color and icon of a tag.
+	tag.Put("/:tag", tagController.SetTagStyleController)
+
+	// syncController is the offline sync controller.
+	syncController := controllers.Sync
+	// syncScope is a middleware that asks API keys for the scopes of both todos and lists, since a sync carries both.
+	syncScope := middleware.RequireScope("todos", "lists")
+
+	// This defines a GET route for pulling the changes since a cursor.
+	api.Get("/sync", authMiddleware, syncScope, authenticatedUserMiddleware, userRateLimiter, syncController.PullController)
+	// This defines a POST route for pushing changes made offline.
+	api.Post("/sync", authMiddleware, syncScope, authenticatedUserMiddleware, userRateLimiter, syncController.PushController)
+
+	// This defines GET and POST routes for the unsubscribe links of emails, which are authenticated by their signature.
+	// They are defined before the notification group so that its authentication middlewares do not run for them,
+	// and POST serves the one-click unsubscribing of mail clients.
+	api.Get("/notifications/unsubscribe", anonymousRateLimiter, controllers.Notifications.UnsubscribeController)
+	api.Post("/notifications/unsubscribe", anonymousRateLimiter, controllers.Notifications.UnsubscribeController)
+
+	// notificationGroup is a new group of routes with the prefix "/notifications".
+	// It is protected by the authentication middlewares, and closed to API keys.
+	notificationGroup := api.Group("/notifications", authMiddleware, sessionOnly, authenticatedUserMiddleware, userRateLimiter)
+
+	// notificationController is the notification controller.
+	notificationController := controllers.Notifications
+
+	// This defines a GET route for the current user's channel preferences.
+	notificationGroup.Get("/preferences", notificationController.GetPreferencesController)
+	// This defines a PUT route for changing the current user's channel preferences.
+	notificationGroup.Put("/preferences", notificationController.UpdatePreferencesController)
+	// This defines a GET route for the current user's weekly email digest settings.
+	notificationGroup.Get("/digest", notificationController.GetDigestController)
+	// This defines a PUT route for changing the current user's weekly email digest settings.
+	notificationGroup.Put("/digest", notificationController.UpdateDigestController)
+	// This defines a GET route for the current user's push devices.
+	notificationGroup.Get("/devices", notificationController.GetDevicesController)
+	// This defines a POST route for registering a push device.
+	notificationGroup.Post("/devices", notificationController.RegisterDeviceController)
+	// This defines a DELETE route for unregistering a push device.
+	notificationGroup.Delete("/devices/:token", notificationController.DeleteDeviceController)
+	// This defines a GET route for the VAPID public key browsers subscribe with.
+	notificationGroup.Get("/webpush/key", notificationController.GetWebPushKeyController)
+	// This defines a POST route for subscribing a browser to web push.
+	notificationGroup.Post("/webpush/subscriptions", notificationController.SubscribeWebPushController)
+	// This defines a DELETE route for unsubscribing a browser from web push.
+	notificationGroup.Delete("/webpush/subscriptions", notificationController.UnsubscribeWebPushController)
+
+	// mediaController is the media controller.
+	mediaController := controllers.Media
+
+	// This defines a POST route for uploading an image, which is sent as the request body.
+	api.Post("/media", authMiddleware, middleware.RequireScope("media"), authenticatedUserMiddleware, userRateLimiter, mediaController.UploadMediaController)
+	// This defines a GET route for a stored image, resized with the "w" and "h" query parameters.
+	// It is limited as a read, since each size is resized once and then served from the cache.
+	api.Get("/media/:id", authMiddleware, middleware.RequireScope("media"), authenticatedUserMiddleware, userRateLimiter, mediaController.GetMediaController)
+
+	// telegramGroup is a new group of routes with the prefix "/integrations/telegram".
+	telegramGroup := api.Group("/integrations/telegram")
+
+	// telegramController is the Telegram controller.
+	telegramController := controllers.Telegram
+
+	// This defines a POST route for the Telegram webhook.
+	// It is authenticated by the webhook secret instead of a user token.
+	telegramGroup.Post("/webhook", telegramController.WebhookController)
+	// This defines a POST route for creating a code that links a Telegram chat.
+	telegramGroup.Post("/link", authMiddleware, sessionOnly, authenticatedUserMiddleware, userRateLimiter, telegramController.CreateLinkController)
+	// This defines a DELETE route for unlinking Telegram.
+	telegramGroup.Delete("/link", authMiddleware, sessionOnly, authenticatedUserMiddleware, userRateLimiter, telegramController.DeleteLinkController)
+
+	// slackGroup is a new group of routes with the prefix "/integrations/slack".
+	slackGroup := api.Group("/integrations/slack")
+
+	// slackController is the Slack controller.
+	slackController := controllers.Slack
+
+	// This defines a POST route for the /todo slash command.
+	// It is authenticated by the Slack request signature instead of a user token.
+	slackGroup.Post("/commands", slackController.CommandController)
+	// This defines a GET route for the OAuth redirect that completes the install flow.
+	// It is authenticated by the signed state issued by the install route.
+	slackGroup.Get("/oauth/callback", slackController.OAuthCallbackController)
+	// This defines a GET route for the URL that installs the app and connects the current user's Slack account.
+	slackGroup.Get("/install", authMiddleware, sessionOnly, authenticatedUserMiddleware, userRateLimiter, slackController.InstallController)
+	// This defines a DELETE route for disconnecting the current user's Slack accounts.
+	slackGroup.Delete("/link", authMiddleware, sessionOnly, authenticatedUserMiddleware, userRateLimiter, slackController.DisconnectController)
+
+	// admin is a new group of routes with the prefix "/admin".
+	// It is protected by the authentication middlewares, closed to API keys, and only open to users with the admin role.
+	// When request signing keys are configured, its requests must also be signed, so that a leaked admin token alone cannot call it.
+	admin := api.Group("/admin", middleware.RequireSignature(cfg), authMiddleware, sessionOnly, authenticatedUserMiddleware, userRateLimiter, middleware.AdminUser(db))
+
+	// auditController is the audit log controller.
+	auditController := controllers.Audit
+
+	// This defines a GET route for searching the audit log.
+	admin.Get("/audit", auditController.ListEntriesController)
+
+	// adminController is the admin console controller.
+	adminController := controllers.Admin
+
+	// This defines a GET route for paging through the users.
+	admin.Get("/users", adminController.ListUsersController)
+	// This defines a GET route for paging through the outbox events and their deliveries.
+	admin.Get("/jobs", adminController.ListJobsController)
+	// This defines a GET route for the optional features the configuration turns on.
+	admin.Get("/flags", adminController.FeatureFlagsController)
+	// This defines a POST route for starting a backup of the database to the storage backend.
+	admin.Post("/backups", adminController.StartBackupController)
+	// This defines a GET route for paging through the backups and restores and their progress.
+	admin.Get("/backups", adminController.ListBackupJobsController)
+	// This defines a GET route for the progress of one backup or restore.
+	admin.Get("/backups/:id", adminController.GetBackupJobController)
+	// This defines a POST route for restoring a backup into an empty database.
+	admin.Post("/backups/:id/restore", adminController.RestoreBackupController)
+	// This defines a POST route for creating a single sign-on connection.
+	admin.Post("/sso", ssoController.CreateConnectionController)
+	// This defines a GET route for listing the single sign-on connections.
+	admin.Get("/sso", ssoController.ConnectionsController)
+	// This defines a GET route for one single sign-on connection.
+	admin.Get("/sso/:id", ssoController.ConnectionController)
+	// This defines a PUT route for updating a single sign-on connection.
+	admin.Put("/sso/:id", ssoController.UpdateConnectionController)
+	// This defines a DELETE route for deleting a single sign-on connection.
+	admin.Delete("/sso/:id", ssoController.DeleteConnectionController)
+	// This defines a POST route for creating or rotating the SCIM token of a single sign-on connection.
+	admin.Post("/sso/:id/scim-token", ssoController.CreateSCIMTokenController)
+	// This defines a DELETE route for revoking the SCIM token of a single sign-on connection.
+	admin.Delete("/sso/:id/scim-token", ssoController.DeleteSCIMTokenController)
+
+	// console is a new group of routes with the prefix "/admin" that serves the admin console.
+	// A browser cannot send bearer tokens when opening a page, so it is protected by HTTP Basic authentication and the admin role.
+	console := app.Group("/admin", requestTimeout, middleware.BasicAuthenticatedUser(cfg, db), userRateLimiter, middleware.AdminUser(db))
+
+	// This defines a GET route for the page of the console.
+	console.Get("/", adminController.UIController)
+	// The console reads the same data as the admin API, with the Basic credentials the browser already holds.
+	console.Get("/users", adminController.ListUsersController)
+	// This defines a GET route for the outbox events of the console.
+	console.Get("/jobs", adminController.ListJobsController)
+	// This defines a GET route for the feature flags of the console.
+	console.Get("/flags", adminController.FeatureFlagsController)
+	// This defines a GET route for the audit log of the console.
+	console.Get("/audit", auditController.ListEntriesController)
+
+	// This checks if the profiling and runtime diagnostics endpoints are enabled.
+	if cfg.Diagnostics.Enabled {
+		// diagnosticsController is the runtime diagnostics controller.
+		diagnosticsController := controllers.Diagnostics
+
+		// pprof.New() serves the net/http/pprof profiles under "/admin/debug/pprof/", behind the admin middlewares of the group.
+		admin.Use(pprof.New(pprof.Config{Prefix: "/api/" + utils.APIVersion + "/admin"}))
+		// This defines a GET route for the goroutine count, heap statistics, and database pool statistics.
+		admin.Get("/diagnostics", diagnosticsController.RuntimeController)
+		// This defines a GET route for the statements that were slowest on this server.
+		admin.Get("/diagnostics/queries", diagnosticsController.SlowQueriesController)
+	}
+
+	// caldavController is the CalDAV controller.
+	caldavController := controllers.CalDAV
+
+	// This defines the CalDAV service discovery route, which redirects to the principal.
+	app.All("/.well-known/caldav", caldavController.WellKnownController)
+
+	// dav is a new group of routes with the prefix "/caldav".
+	// CalDAV clients cannot send bearer tokens, so it is protected by HTTP Basic authentication with the user's email and password.
+	dav := app.Group("/caldav", requestTimeout, middleware.BasicAuthenticatedUser(cfg, db), userRateLimiter)
+
+	// This defines an OPTIONS route that advertises the supported DAV classes.
+	dav.Options("/*", caldavController.OptionsController)
+	// This defines a PROPFIND route for the principal and calendar home.
+	dav.Add("PROPFIND", "/", caldavController.PropfindPrincipalController)
+	// This defines a PROPFIND route for the todo calendar.
+	dav.Add("PROPFIND", "/todos", caldavController.PropfindCollectionController)
+	// This defines a REPORT route for the todo calendar.
+	dav.Add("REPORT", "/todos", caldavController.ReportController)
+	// This defines a PROPFIND route for a todo.
+	dav.Add("PROPFIND", "/todos/:name", caldavController.PropfindTodoController)
+	// This defines a GET route for the calendar data of a todo.
+	dav.Get("/todos/:name", caldavController.GetTodoController)
+	// This defines a PUT route for creating or replacing a todo.
+	dav.Put("/todos/:name", caldavController.PutTodoController)
 	// This defines a DELETE route for deleting a todo.
-	todo.Delete("/delete/:id", todoController.DeleteTodoController)
-}
\ No newline at end of file
+	dav.Delete("/todos/:name", caldavController.DeleteTodoController)
+
+	// This answers the requests that no route matched, with 405 and an Allow header for known paths and 404 otherwise.
+	// It must stay after every route.
+	app.Use(middleware.MethodNotAllowed(app))
+}
@@ -0,0 +1,162 @@
+// This file defines the storage backend of files, such as images and backups.
+// Files are addressed by slash-separated keys, such as "media/<id>", so that another backend, like an object store,
+// can replace the local one without changing its callers.
+package storage
+
+// "context" provides a way to carry deadlines and cancellation signals. It is part of the Storage interface.
+import (
+	"context"
+	// "errors" provides functions for working with errors. It is used here to define the storage errors.
+	"errors"
+	// "io" provides basic I/O interfaces. It is used here to stream the files.
+	"io"
+	// "io/fs" provides the file system errors. It is used here to recognize a missing file.
+	"io/fs"
+	// "os" provides functions for working with the operating system. It is used here to read and write the files.
+	"os"
+	// "path" provides functions for slash-separated paths. It is used here to check the keys.
+	"path"
+	// "path/filepath" provides functions for file paths of the operating system. It is used here to turn keys into paths.
+	"path/filepath"
+	// "strings" provides functions for working with strings. It is used here to check the keys.
+	"strings"
+
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+// ErrNotFound is returned when no file has a key.
+var ErrNotFound = errors.New("file not found")
+
+// ErrInvalidKey is returned when a key is empty, absolute, or climbs out of the storage with "..".
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage stores files by key.
+type Storage interface {
+	// Open opens the file of a key for reading, and returns its size. The caller closes it.
+	Open(ctx context.Context, key string) (io.ReadCloser, int64, error)
+	// Put stores the content of a reader under a key, replacing any earlier file only once the new one is complete.
+	Put(ctx context.Context, key string, content io.Reader) error
+}
+
+// New creates the storage backend of the configuration.
+//
+// @param cfg config.StorageConfig - The storage configuration.
+// @return Storage - The storage backend.
+func New(cfg config.StorageConfig) Storage {
+	// A local storage in the configured directory is returned.
+	return &Local{dir: cfg.Dir}
+}
+
+// Local stores files in a directory of the local file system.
+type Local struct {
+	// dir is the directory the files are stored in.
+	dir string
+}
+
+// path returns the file path of a key.
+//
+// @param key string - The key.
+// @return string - The file path.
+// @return error - ErrInvalidKey if the key does not name a file inside the directory.
+func (l *Local) path(key string) (string, error) {
+	// This checks if the key is empty, absolute, or not in its clean form, which is how ".." would climb out of the directory.
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || key == ".." || strings.HasPrefix(key, "../") {
+		// If it is, an error is returned.
+		return "", ErrInvalidKey
+	}
+	// The path inside the directory is returned.
+	return filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
+
+// Open opens the file of a key for reading.
+//
+// @param ctx context.Context - The context of the caller.
+// @param key string - The key.
+// @return io.ReadCloser - The file.
+// @return int64 - The size of the file in bytes.
+// @return error - ErrNotFound or ErrInvalidKey if there is no such file, or another error if one occurred.
+func (l *Local) Open(ctx context.Context, key string) (io.ReadCloser, int64, error) {
+	// name is the file path of the key.
+	name, err := l.path(key)
+	// This checks if the key is invalid.
+	if err != nil {
+		// If it is, the error is returned.
+		return nil, 0, err
+	}
+
+	// file is the opened file.
+	file, err := os.Open(name)
+	// This checks if the file does not exist.
+	if errors.Is(err, fs.ErrNotExist) {
+		// If it does not, ErrNotFound is returned.
+		return nil, 0, ErrNotFound
+	}
+	// This checks if another error occurred while opening the file.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, 0, err
+	}
+	// info describes the file.
+	info, err := file.Stat()
+	// This checks if an error occurred while describing the file, or if the key names a directory.
+	if err != nil || info.IsDir() {
+		// If so, the file is closed.
+		_ = file.Close()
+		// This checks if the key names a directory.
+		if err == nil {
+			// If it does, there is no file with the key.
+			return nil, 0, ErrNotFound
+		}
+		// Otherwise the error is returned.
+		return nil, 0, err
+	}
+	// The file and its size are returned.
+	return file, info.Size(), nil
+}
+
+// Put stores the content of a reader under a key.
+// The content is written to a temporary file that is renamed into place, so that a reader never sees half a file.
+//
+// @param ctx context.Context - The context of the caller.
+// @param key string - The key.
+// @param content io.Reader - The content.
+// @return error - ErrInvalidKey if the key is invalid, or another error if one occurred.
+func (l *Local) Put(ctx context.Context, key string, content io.Reader) error {
+	// name is the file path of the key.
+	name, err := l.path(key)
+	// This checks if the key is invalid.
+	if err != nil {
+		// If it is, the error is returned.
+		return err
+	}
+	// This creates the directory of the file.
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+
+	// temp is the temporary file, created next to the file so that the rename does not cross file systems.
+	temp, err := os.CreateTemp(filepath.Dir(name), ".put-*")
+	// This checks if an error occurred while creating the temporary file.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// This defers removing the temporary file, which only remains if the rename did not happen.
+	defer os.Remove(temp.Name())
+
+	// This copies the content to the temporary file.
+	if _, err := io.Copy(temp, content); err != nil {
+		// If an error occurs, the file is closed and the error is returned.
+		_ = temp.Close()
+		return err
+	}
+	// This closes the temporary file, which flushes it.
+	if err := temp.Close(); err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// The temporary file is renamed into place.
+	return os.Rename(temp.Name(), name)
+}
@@ -0,0 +1,46 @@
+// This file provides a function for parsing HTTP Basic credentials.
+package utils
+
+// "encoding/base64" provides base64 encoding. It is used here to decode the credentials.
+import (
+	"encoding/base64"
+	// "strings" provides functions for working with strings. It is used here to split the header and the credentials.
+	"strings"
+	// "unicode/utf8" provides functions for UTF-8 text. It is used here to reject credentials that are not valid text.
+	"unicode/utf8"
+)
+
+// ParseBasicAuth parses the value of an Authorization header that uses the Basic scheme.
+//
+// @param authorization string - The value of the Authorization header.
+// @return string - The username.
+// @return string - The password.
+// @return bool - True if the header holds valid Basic credentials.
+func ParseBasicAuth(authorization string) (string, string, bool) {
+	// scheme and encoded are the scheme and the encoded credentials.
+	scheme, encoded, found := strings.Cut(authorization, " ")
+	// This checks if the scheme is not Basic.
+	if !found || !strings.EqualFold(scheme, "Basic") {
+		// If it is not, no credentials are returned.
+		return "", "", false
+	}
+
+	// decoded is the decoded "username:password" pair.
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	// This checks if the credentials are not valid base64.
+	if err != nil {
+		// If they are not, no credentials are returned.
+		return "", "", false
+	}
+
+	// This checks if the credentials are not valid UTF-8 or contain a NUL byte, which the database cannot compare.
+	if !utf8.Valid(decoded) || strings.ContainsRune(string(decoded), 0) {
+		// If they are, no credentials are returned.
+		return "", "", false
+	}
+
+	// username and password are split at the first colon.
+	username, password, found := strings.Cut(string(decoded), ":")
+	// The credentials are returned if both parts are present.
+	return username, password, found && username != "" && password != ""
+}
@@ -0,0 +1,76 @@
+// This file provides a function for parsing bearer tokens from the Authorization header.
+package utils
+
+// "errors" provides functions for working with errors. It is used here to define the parse errors.
+import (
+	"errors"
+	// "strings" provides functions for working with strings. It is used here to split the header.
+	"strings"
+)
+
+// maxBearerTokenLength is the length above which a bearer token is rejected without a database lookup.
+// The tokens issued by CreateToken are a few hundred bytes long.
+const maxBearerTokenLength = 4096
+
+// ErrAuthorizationMissing is returned when the Authorization header is empty.
+var ErrAuthorizationMissing = errors.New("authorization header is missing")
+
+// ErrAuthorizationFormat is returned when the Authorization header is not a scheme followed by one credential.
+var ErrAuthorizationFormat = errors.New("authorization header is not in the format 'Bearer <token>'")
+
+// ErrAuthorizationScheme is returned when the Authorization header uses a scheme other than Bearer.
+var ErrAuthorizationScheme = errors.New("authorization type must be 'Bearer'")
+
+// ErrTokenMalformed is returned when a bearer token is too long or contains characters that no JWT contains.
+var ErrTokenMalformed = errors.New("token is malformed")
+
+// ParseBearerToken parses the value of an Authorization header that uses the Bearer scheme.
+// The scheme is matched case-insensitively and surrounding whitespace is ignored. The token must be made
+// of the base64url alphabet and dots, like a JWT, so that arbitrary bytes never reach the database.
+//
+// @param authorization string - The value of the Authorization header.
+// @return string - The token.
+// @return error - ErrAuthorizationMissing, ErrAuthorizationFormat, ErrAuthorizationScheme, or ErrTokenMalformed if the header is rejected.
+func ParseBearerToken(authorization string) (string, error) {
+	// authorization is the header without surrounding whitespace.
+	authorization = strings.TrimSpace(authorization)
+	// This checks if the header is empty.
+	if authorization == "" {
+		// If it is, the missing header error is returned.
+		return "", ErrAuthorizationMissing
+	}
+
+	// scheme and token are the scheme and the credential.
+	scheme, token, found := strings.Cut(authorization, " ")
+	// token is the credential without surrounding whitespace.
+	token = strings.TrimSpace(token)
+	// This checks if the header has no credential or more than one.
+	if !found || token == "" || strings.ContainsAny(token, " \t") {
+		// If it does, the format error is returned.
+		return "", ErrAuthorizationFormat
+	}
+	// This checks if the scheme is not Bearer.
+	if !strings.EqualFold(scheme, "Bearer") {
+		// If it is not, the scheme error is returned.
+		return "", ErrAuthorizationScheme
+	}
+
+	// This checks if the token is too long.
+	if len(token) > maxBearerTokenLength {
+		// If it is, the malformed token error is returned.
+		return "", ErrTokenMalformed
+	}
+	// This iterates over the bytes of the token.
+	for i := 0; i < len(token); i++ {
+		// char is the current byte.
+		char := token[i]
+		// This checks if the byte is outside the base64url alphabet and the dot.
+		if !(char >= 'A' && char <= 'Z' || char >= 'a' && char <= 'z' || char >= '0' && char <= '9' || char == '-' || char == '_' || char == '.') {
+			// If it is, the malformed token error is returned.
+			return "", ErrTokenMalformed
+		}
+	}
+
+	// The token is returned.
+	return token, nil
+}
@@ -0,0 +1,74 @@
+// This file defines a fuzz test for the parsing of the Authorization header.
+package utils
+
+// "errors" provides functions for working with errors. It is used here to check that a rejected header is rejected for a known reason.
+import (
+	"errors"
+	// "strings" provides functions for working with strings. It is used here to check the accepted tokens.
+	"strings"
+	// "testing" provides support for automated tests. It is used here to run the fuzz test.
+	"testing"
+)
+
+// FuzzParseBearerToken checks that no Authorization header makes ParseBearerToken panic, that a rejected header is rejected
+// with one of its errors, which the middleware answers with 401 rather than 500, and that an accepted token is one that could
+// have been issued.
+//
+// @param f *testing.F - The fuzzing state.
+func FuzzParseBearerToken(f *testing.F) {
+	// seeds are headers of every kind the parser tells apart.
+	seeds := []string{
+		"",
+		"Bearer",
+		"Bearer ",
+		"Bearer abc.def.ghi",
+		"bearer tdk_0123456789abcdef",
+		"  Bearer   abc  ",
+		"Basic dXNlcjpwYXNz",
+		"Bearer abc def",
+		"Bearer abc\tdef",
+		"Bearer abc\x00def",
+		"Bearer " + strings.Repeat("a", maxBearerTokenLength+1),
+		"Bearer é",
+	}
+	// This adds the seeds to the corpus.
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	// This runs the test on every input.
+	f.Fuzz(func(t *testing.T, authorization string) {
+		// token and err are the result of parsing the header.
+		token, err := ParseBearerToken(authorization)
+		// This checks if the header was rejected.
+		if err != nil {
+			// This checks if the header was rejected for a reason the middleware does not know.
+			if !errors.Is(err, ErrAuthorizationMissing) && !errors.Is(err, ErrAuthorizationFormat) && !errors.Is(err, ErrAuthorizationScheme) && !errors.Is(err, ErrTokenMalformed) {
+				// If it was, the test fails.
+				t.Fatalf("ParseBearerToken(%q) returned an unknown error: %v", authorization, err)
+			}
+			// This checks if a token was returned with the error.
+			if token != "" {
+				// If one was, the test fails.
+				t.Fatalf("ParseBearerToken(%q) returned the token %q with the error %v", authorization, token, err)
+			}
+			return
+		}
+
+		// This checks if the accepted token is empty or too long.
+		if token == "" || len(token) > maxBearerTokenLength {
+			// If it is, the test fails.
+			t.Fatalf("ParseBearerToken(%q) accepted a token of length %d", authorization, len(token))
+		}
+		// This checks if the accepted token has a character that no issued token has.
+		if strings.Trim(token, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_.") != "" {
+			// If it has, the test fails.
+			t.Fatalf("ParseBearerToken(%q) accepted the token %q", authorization, token)
+		}
+		// This checks if the accepted token is not the credential of a Bearer header.
+		if scheme, _, _ := strings.Cut(strings.TrimSpace(authorization), " "); !strings.EqualFold(scheme, "Bearer") || !strings.HasSuffix(strings.TrimSpace(authorization), token) {
+			// If it is not, the test fails.
+			t.Fatalf("ParseBearerToken(%q) accepted the token %q", authorization, token)
+		}
+	})
+}
@@ -3,18 +3,148 @@ package utils
 
 // const is a keyword that declares a constant value.
 const (
+	// APIVersion is the version of the API, used in the route prefix and the response metadata.
+	APIVersion = "v1"
+
 	// UserTableName is the name of the users table in the database.
 	UserTableName = "users"
 	// UserTableSchema is the schema of the users table in the database.
-	UserTableSchema = "id, name, email, image, password, jwt, created_at, updated_at"
+	UserTableSchema = "id, name, email, image, password, jwt, created_at, updated_at, timezone, locale"
 
 	// JWTTableName is the name of the jwt_tokens table in the database.
 	JWTTableName = "jwt_tokens"
 	// JWTTableSchema is the schema of the jwt_tokens table in the database.
 	JWTTableSchema = "id, token, expires_at"
 
+	// JWTInsertSchema is the list of jwt_tokens columns that are written when a JWT is issued. It adds the session metadata to JWTTableSchema.
+	JWTInsertSchema = JWTTableSchema + ", user_id, user_agent, ip"
+
+	// SessionSelectSchema is the list of jwt_tokens columns that describe a session to its user, leaving out the token itself.
+	SessionSelectSchema = "id, user_agent, ip, created_at, last_used_at, expires_at"
+
+	// ScopedTokenInsertSchema is the list of jwt_tokens columns that are written when a token limited to scopes is created, such as an API key.
+	ScopedTokenInsertSchema = JWTTableSchema + ", user_id, kind, name, scopes"
+
+	// APIKeySelectSchema is the list of jwt_tokens columns that describe an API key to its user, leaving out the key itself.
+	APIKeySelectSchema = "id, name, scopes, created_at, last_used_at, expires_at"
+
+	// EmailChangeTableName is the name of the email_changes table in the database.
+	EmailChangeTableName = "email_changes"
+
+	// KnownDeviceTableName is the name of the known_devices table in the database.
+	KnownDeviceTableName = "known_devices"
+
+	// LoginFailureTableName is the name of the login_failures table in the database.
+	LoginFailureTableName = "login_failures"
+
+	// ServiceAccountTableName is the name of the service_accounts table in the database.
+	ServiceAccountTableName = "service_accounts"
+
+	// SSOConnectionTableName is the name of the sso_connections table in the database.
+	SSOConnectionTableName = "sso_connections"
+
+	// SSOIdentityTableName is the name of the sso_identities table in the database.
+	SSOIdentityTableName = "sso_identities"
+
+	// SCIMUserTableName is the name of the scim_users table in the database.
+	SCIMUserTableName = "scim_users"
+
+	// DeviceCodeTableName is the name of the device_codes table in the database.
+	DeviceCodeTableName = "device_codes"
+
+	// RevokedTokenTableName is the name of the revoked_tokens table in the database.
+	RevokedTokenTableName = "revoked_tokens"
+
 	// TodoTableName is the name of the todos table in the database.
 	TodoTableName = "todos"
 	// TodoTableSchema is the schema of the todos table in the database.
-	TodoTableSchema = "id, title, completed, owner, created_at"
-)
\ No newline at end of file
+	TodoTableSchema = "id, title, description, priority, completed, owner, created_at, list_id, position, due_at, tags"
+
+	// TodoSelectSchema is the list of todo columns that are read back. It adds the columns maintained by the database to TodoTableSchema.
+	// The last column is computed by todo_blocked(), since whether a todo is blocked depends on other todos.
+	TodoSelectSchema = TodoTableSchema + ", version, ical_uid, updated_at, snoozed_until, estimate_minutes, status, todo_blocked(id)"
+
+	// ListTableName is the name of the lists table in the database.
+	ListTableName = "lists"
+	// ListTableSchema is the schema of the lists table in the database.
+	ListTableSchema = "id, name, owner, created_at"
+
+	// ListSelectSchema is the list of list columns that are read back. It adds the columns maintained by the database,
+	// the optional color and icon, and the archive time to ListTableSchema.
+	ListSelectSchema = ListTableSchema + ", version, color, icon, archived_at"
+
+	// TelegramLinkTableName is the name of the telegram_links table in the database.
+	TelegramLinkTableName = "telegram_links"
+
+	// SlackInstallationTableName is the name of the slack_installations table in the database.
+	SlackInstallationTableName = "slack_installations"
+
+	// SlackUserTableName is the name of the slack_users table in the database.
+	SlackUserTableName = "slack_users"
+
+	// NotificationChannelTableName is the name of the notification_channels table in the database.
+	NotificationChannelTableName = "notification_channels"
+
+	// DigestSubscriptionTableName is the name of the digest_subscriptions table in the database.
+	DigestSubscriptionTableName = "digest_subscriptions"
+
+	// PushDeviceTableName is the name of the push_devices table in the database.
+	PushDeviceTableName = "push_devices"
+
+	// WebPushSubscriptionTableName is the name of the web_push_subscriptions table in the database.
+	WebPushSubscriptionTableName = "web_push_subscriptions"
+
+	// OutboxTableName is the name of the outbox table in the database.
+	OutboxTableName = "outbox"
+	// BackupJobTableName is the name of the backup_jobs table in the database.
+	BackupJobTableName = "backup_jobs"
+	// OutboxTableSchema is the schema of the outbox table in the database.
+	OutboxTableSchema = "event_id, event_type, aggregate_id, owner, payload"
+
+	// AuditTableName is the name of the api_audit table in the database.
+	AuditTableName = "api_audit"
+	// AuditTableSchema is the schema of the api_audit table in the database.
+	AuditTableSchema = "method, path, user_id, ip, status, latency_ms, actor_kind"
+
+	// TodoActivityTableName is the name of the todo_activities table in the database.
+	TodoActivityTableName = "todo_activities"
+	// TodoActivityTableSchema is the schema of the todo_activities table in the database.
+	TodoActivityTableSchema = "id, todo_id, owner, action, previous"
+
+	// TodoIdempotencyKeyTableName is the name of the todo_idempotency_keys table in the database.
+	TodoIdempotencyKeyTableName = "todo_idempotency_keys"
+	// TodoIdempotencyKeyTableSchema is the schema of the todo_idempotency_keys table in the database.
+	TodoIdempotencyKeyTableSchema = "owner, key, todo_id"
+
+	// TagStyleTableName is the name of the tag_styles table in the database.
+	TagStyleTableName = "tag_styles"
+	// TagStyleTableSchema is the schema of the tag_styles table in the database.
+	TagStyleTableSchema = "user_id, tag, color, icon"
+
+	// TodoDependencyTableName is the name of the todo_dependencies table in the database.
+	TodoDependencyTableName = "todo_dependencies"
+	// TodoDependencyTableSchema is the schema of the todo_dependencies table in the database.
+	TodoDependencyTableSchema = "todo_id, blocker_id"
+
+	// TodoArchiveTableName is the name of the todo_archive table in the database.
+	TodoArchiveTableName = "todo_archive"
+	// TodoArchiveTableSchema is the schema of the todo_archive table in the database, without the archive time the database sets.
+	TodoArchiveTableSchema = "id, title, description, priority, owner, list_id, due_at, tags, estimate_minutes, created_at, completed_at"
+	// TodoTombstoneTableName is the name of the todo_tombstones table in the database, which records the todos removed from the todos table.
+	TodoTombstoneTableName = "todo_tombstones"
+	// TodoTombstoneTableSchema is the schema of the todo_tombstones table in the database, without the version, transaction, and time the database sets.
+	TodoTombstoneTableSchema = "id, owner, name"
+
+	// AttachmentTableName is the name of the attachments table in the database, which records the owner and size of each stored image.
+	AttachmentTableName = "attachments"
+	// AttachmentTableSchema is the schema of the attachments table in the database, without the time the database sets.
+	AttachmentTableSchema = "id, owner, content_type, size, width, height"
+
+	// TodoCountTableName is the name of the todo_counts table in the database, which the database keeps up to date as todos are written.
+	TodoCountTableName = "todo_counts"
+
+	// SchemaBackfillTableName is the name of the schema_backfills table in the database.
+	SchemaBackfillTableName = "schema_backfills"
+	// SchemaToggleTableName is the name of the schema_toggles table in the database.
+	SchemaToggleTableName = "schema_toggles"
+)
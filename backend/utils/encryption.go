@@ -38,4 +38,4 @@ func CompareEncryptedPassword(encryptedPassword, password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(encryptedPassword), []byte(password))
 	// The function returns true if the error is nil, indicating that the passwords match.
 	return err == nil
-}
\ No newline at end of file
+}
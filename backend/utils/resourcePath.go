@@ -0,0 +1,16 @@
+// This file provides a utility function for building the canonical path of a resource.
+package utils
+
+// "github.com/google/uuid" is a package for working with UUIDs. It is used here to name the resource.
+import "github.com/google/uuid"
+
+// ResourcePath returns the canonical path of a resource under the current API version, such as "/api/v1/todos/<id>".
+// It is sent in the Location header of a created resource and in the "url" field of its response.
+//
+// @param collection string - The route prefix of the collection, such as "todos".
+// @param id uuid.UUID - The ID of the resource.
+// @return string - The path of the resource.
+func ResourcePath(collection string, id uuid.UUID) string {
+	// The path is built from the API prefix, the collection, and the ID.
+	return "/api/" + APIVersion + "/" + collection + "/" + id.String()
+}
@@ -0,0 +1,170 @@
+// This file defines time-limited URLs signed with HMAC-SHA256, for links that must work without a login,
+// such as export downloads, public share links, calendar feeds, and unsubscribe links.
+// A signed URL carries three query parameters: "exp", the Unix time it expires at, "kid", the key that signed it,
+// and "sig", the signature of its path and every other query parameter. The host is not signed, so that a URL
+// keeps working behind a proxy that rewrites it.
+package signing
+
+// "crypto/hmac" implements HMAC. It is used here to sign URLs and to compare signatures in constant time.
+import (
+	"crypto/hmac"
+	// "crypto/sha256" implements SHA-256. It is used here as the HMAC hash.
+	"crypto/sha256"
+	// "encoding/base64" implements base64 encoding. It is used here to put the signature in the query string.
+	"encoding/base64"
+	// "errors" provides functions for working with errors. It is used here to define the verification errors.
+	"errors"
+	// "net/url" provides functions for working with URLs. It is used here to read and write the query parameters.
+	"net/url"
+	// "strconv" provides functions for converting strings. It is used here to read and write the expiry.
+	"strconv"
+	// "time" provides functions for working with time. It is used here to expire the URLs.
+	"time"
+
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
+	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that provides access to the application configuration.
+	"github.com/rahulcodepython/todo-backend/backend/config"
+)
+
+const (
+	// ExpiresParam is the query parameter of the expiry.
+	ExpiresParam = "exp"
+	// KeyParam is the query parameter of the ID of the signing key.
+	KeyParam = "kid"
+	// SignatureParam is the query parameter of the signature.
+	SignatureParam = "sig"
+)
+
+// ErrInvalidSignature is returned when a URL is unsigned, was tampered with, or was signed by a key that is no longer configured.
+var ErrInvalidSignature = errors.New("invalid URL signature")
+
+// ErrExpired is returned when a URL has a valid signature but its expiry has passed.
+var ErrExpired = errors.New("signed URL has expired")
+
+// Signer signs and verifies URLs.
+type Signer struct {
+	// keys are the secrets by key ID.
+	keys map[string][]byte
+	// current is the ID of the key that signs new URLs.
+	current string
+	// clock tells the current time.
+	clock clock.Clock
+}
+
+// NewSigner creates a new Signer.
+// The first key of the configuration signs new URLs, and every key verifies them.
+//
+// @param cfg config.URLSigningConfig - The keys of signed URLs, of which there is at least one.
+// @param clk clock.Clock - The clock that tells the current time.
+// @return *Signer - A pointer to the new Signer.
+func NewSigner(cfg config.URLSigningConfig, clk clock.Clock) *Signer {
+	// keys is the map of the secrets.
+	keys := make(map[string][]byte, len(cfg.Keys))
+	// This iterates over the keys.
+	for _, key := range cfg.Keys {
+		// The secret is stored under the ID of the key.
+		keys[key.ID] = []byte(key.Secret)
+	}
+	// A new Signer is returned.
+	return &Signer{
+		// The keys field is set to the secrets.
+		keys: keys,
+		// The current field is set to the ID of the first key.
+		current: cfg.Keys[0].ID,
+		// The clock field is set to the clock.
+		clock: clk,
+	}
+}
+
+// Sign returns a URL that is valid for a duration.
+// Any "exp", "kid", or "sig" parameter the URL already has is replaced.
+//
+// @param rawURL string - The URL, absolute or relative to the host.
+// @param ttl time.Duration - How long the URL is valid.
+// @return string - The signed URL.
+// @return error - An error if the URL cannot be parsed.
+func (s *Signer) Sign(rawURL string, ttl time.Duration) (string, error) {
+	// parsed is the parsed URL.
+	parsed, err := url.Parse(rawURL)
+	// This checks if the URL cannot be parsed.
+	if err != nil {
+		// If it cannot, the error is returned.
+		return "", err
+	}
+
+	// query is the query of the URL, with the parameters of the signature set.
+	query := parsed.Query()
+	// The expiry is set, in whole seconds.
+	query.Set(ExpiresParam, strconv.FormatInt(s.clock.Now().Add(ttl).Unix(), 10))
+	// The signing key is named.
+	query.Set(KeyParam, s.current)
+	// Any earlier signature is removed before signing.
+	query.Del(SignatureParam)
+	// The signature is added.
+	query.Set(SignatureParam, signature(s.keys[s.current], parsed.EscapedPath(), query))
+	// The query is written back, sorted by key.
+	parsed.RawQuery = query.Encode()
+	// The signed URL is returned.
+	return parsed.String(), nil
+}
+
+// Verify checks the signature and expiry of a URL.
+// It takes the URL as the client requested it, which may be relative to the host, such as the original URL of a request.
+//
+// @param rawURL string - The signed URL.
+// @return error - ErrInvalidSignature or ErrExpired if the URL is rejected, or nil if it is valid.
+func (s *Signer) Verify(rawURL string) error {
+	// parsed is the parsed URL.
+	parsed, err := url.Parse(rawURL)
+	// This checks if the URL cannot be parsed.
+	if err != nil {
+		// If it cannot, its signature cannot be valid.
+		return ErrInvalidSignature
+	}
+
+	// query is the query of the URL.
+	query := parsed.Query()
+	// secret is the secret of the key that signed the URL.
+	secret, ok := s.keys[query.Get(KeyParam)]
+	// given is the signature of the URL.
+	given := query.Get(SignatureParam)
+	// This checks if the key is unknown or the URL has no signature.
+	if !ok || given == "" {
+		// If so, the signature is invalid.
+		return ErrInvalidSignature
+	}
+	// The signature is removed, since it is not part of what was signed.
+	query.Del(SignatureParam)
+	// This compares the signatures in constant time, so that the comparison does not reveal how much of a guess is right.
+	if !hmac.Equal([]byte(given), []byte(signature(secret, parsed.EscapedPath(), query))) {
+		// If they differ, the signature is invalid.
+		return ErrInvalidSignature
+	}
+
+	// expires is the expiry of the URL, which the signature covers.
+	expires, err := strconv.ParseInt(query.Get(ExpiresParam), 10, 64)
+	// This checks if the expiry is missing or has passed.
+	if err != nil || !s.clock.Now().Before(time.Unix(expires, 0)) {
+		// If it has, the URL is expired.
+		return ErrExpired
+	}
+	// The URL is valid.
+	return nil
+}
+
+// signature computes the signature of a path and its query.
+// The query is encoded sorted by key, so that reordering the parameters does not change the signature.
+//
+// @param secret []byte - The secret of the key.
+// @param path string - The escaped path of the URL.
+// @param query url.Values - The query of the URL, without the signature.
+// @return string - The signature, in unpadded URL-safe base64.
+func signature(secret []byte, path string, query url.Values) string {
+	// mac is the HMAC of the secret.
+	mac := hmac.New(sha256.New, secret)
+	// The path and the query are written to the HMAC.
+	mac.Write([]byte(path + "?" + query.Encode()))
+	// The encoded signature is returned.
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
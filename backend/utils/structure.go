@@ -10,6 +10,9 @@ type Response struct {
 	// Message provides a human-readable description of the response.
 	// json:"message" specifies that this field should be marshalled to/from a JSON object with the key "message".
 	Message string `json:"message"`
+	// Code is a machine-readable code for errors that clients handle specially, such as "quota_exceeded".
+	// json:"code,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "code", and should be omitted if empty.
+	Code string `json:"code,omitempty"`
 	// Data holds the actual payload of the response.
 	// It is an empty interface to allow for any type of data.
 	// json:"data,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "data", and should be omitted if empty.
@@ -18,4 +21,33 @@ type Response struct {
 	// It is an empty interface to allow for various error structures.
 	// json:"error,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "error", and should be omitted if empty.
 	Error interface{} `json:"error,omitempty"`
-}
\ No newline at end of file
+	// Meta holds information about the response itself, such as the request ID and the pagination links.
+	// json:"meta,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "meta", and should be omitted if empty.
+	Meta *Meta `json:"meta,omitempty"`
+}
+
+// Meta represents the metadata of a response.
+type Meta struct {
+	// RequestID is the ID of the request, as sent in the X-Request-ID header.
+	// json:"request_id,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "request_id", and should be omitted if empty.
+	RequestID string `json:"request_id,omitempty"`
+	// APIVersion is the version of the API that answered the request.
+	// json:"api_version" specifies that this field should be marshalled to/from a JSON object with the key "api_version".
+	APIVersion string `json:"api_version"`
+	// Pagination holds the links to the neighbouring pages, if the data is paginated.
+	// json:"pagination,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "pagination", and should be omitted if empty.
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination represents the links to the neighbouring pages of a paginated response.
+type Pagination struct {
+	// Next is the URL of the next page, or empty on the last page.
+	// json:"next,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "next", and should be omitted if empty.
+	Next string `json:"next,omitempty"`
+	// Prev is the URL of the previous page, or empty on the first page.
+	// json:"prev,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "prev", and should be omitted if empty.
+	Prev string `json:"prev,omitempty"`
+	// Cursor is the opaque position to resume from, for endpoints paginated by cursor.
+	// json:"cursor,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "cursor", and should be omitted if empty.
+	Cursor string `json:"cursor,omitempty"`
+}
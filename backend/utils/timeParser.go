@@ -13,4 +13,4 @@ func ParseTime(t time.Time) string {
 	// t.Format() returns a textual representation of the time value formatted according to the layout defined by the argument.
 	// time.RFC3339 is a predefined layout for RFC3339 format.
 	return t.Format(time.RFC3339)
-}
\ No newline at end of file
+}
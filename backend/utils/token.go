@@ -1,8 +1,12 @@
-// This file provides functionality for creating JSON Web Tokens (JWTs).
+// This file provides functionality for creating JSON Web Tokens (JWTs) and opaque tokens.
 package utils
 
-// "time" provides functions for working with time. It is used here to set the expiration time of the JWT.
+// "crypto/rand" provides a secure random generator. It is used here to create opaque tokens.
 import (
+	"crypto/rand"
+	// "encoding/base64" implements base64 encoding. It is used here to encode opaque tokens with characters a bearer token may contain.
+	"encoding/base64"
+	// "time" provides functions for working with time. It is used here to set the expiration time of the JWT.
 	"time"
 
 	// "github.com/golang-jwt/jwt/v5" is a package for creating and signing JWTs.
@@ -21,30 +25,40 @@ type Token struct {
 	ExpiresAt time.Time `json:"expires_at"`
 }
 
-// CreateToken generates a new JWT for a given user ID.
-// It takes a user ID and the application configuration as input.
+// CreateToken generates a new JWT for a given user ID and session.
+// It takes a user ID, a session ID, a token ID, the lifetime of the token, the application configuration, and the current time as input.
 // It returns a pointer to a Token struct containing the JWT and its expiration time, or nil if an error occurs.
+// The session ID makes every token distinct, even two issued to the same user in the same second, and the token ID is
+// remembered after the token is revoked, so that a replay of it can be recognized.
 //
 // @param userId string - The ID of the user for whom the token is being created.
+// @param sessionId string - The ID of the session record the token belongs to.
+// @param jti string - The ID of the token itself, sent as the "jti" claim.
+// @param lifetime time.Duration - How long the token is valid.
 // @param cfg *config.Config - A pointer to the application's configuration struct.
+// @param now time.Time - The time the token is issued at.
 // @return *Token - A pointer to a Token struct, or nil if an error occurs.
-func CreateToken(userId string, cfg *config.Config) *Token {
+func CreateToken(userId string, sessionId string, jti string, lifetime time.Duration, cfg *config.Config, now time.Time) *Token {
 	// token is a new instance of the Token struct.
 	token := Token{
 		// The Token field is initialized as an empty string.
 		Token: "",
-		// The ExpiresAt field is set to the current time plus the configured JWT expiration duration.
-		ExpiresAt: time.Now().Add(cfg.JWT.Expires),
+		// The ExpiresAt field is set to the issue time plus the lifetime of the token.
+		ExpiresAt: now.Add(lifetime),
 	}
 
 	// claims is a map that holds the JWT claims.
 	claims := jwt.MapClaims{
 		// "user_id" is a claim that stores the user's ID.
 		"user_id": userId,
+		// "sid" is a claim that stores the ID of the session the token belongs to.
+		"sid": sessionId,
+		// "jti" is a claim that stores the ID of the token.
+		"jti": jti,
 		// "exp" is a claim that stores the expiration time of the token as a Unix timestamp.
-		"exp": time.Now().Add(cfg.JWT.Expires).Unix(),
+		"exp": now.Add(lifetime).Unix(),
 		// "iat" is a claim that stores the time the token was issued as a Unix timestamp.
-		"iat": time.Now().Unix(),
+		"iat": now.Unix(),
 	}
 
 	// tokenClaims is a new JWT token with the specified signing method and claims.
@@ -62,4 +76,22 @@ func CreateToken(userId string, cfg *config.Config) *Token {
 	token.Token = tokenString
 	// A pointer to the token struct is returned.
 	return &token
-}
\ No newline at end of file
+}
+
+// CreateOpaqueToken generates a random token that carries no claims, for credentials that are only ever looked up in the database,
+// such as API keys. The prefix makes a leaked token easy to recognize and search for.
+//
+// @param prefix string - The prefix of the token, such as "tdk_".
+// @return string - The token.
+// @return error - An error if the secure random generator failed.
+func CreateOpaqueToken(prefix string) (string, error) {
+	// raw is the random bytes of the token.
+	raw := make([]byte, 32)
+	// This fills the bytes from the secure random generator.
+	if _, err := rand.Read(raw); err != nil {
+		// If an error occurs, it is returned.
+		return "", err
+	}
+	// The prefixed, encoded bytes are returned.
+	return prefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
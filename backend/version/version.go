@@ -0,0 +1,104 @@
+// This file holds the version of the build, injected by the linker, so that a running server can say which code it runs.
+//
+// The values are set with -ldflags at build time:
+//
+//	go build -ldflags "-X github.com/rahulcodepython/todo-backend/backend/version.Version=1.4.0 \
+//		-X github.com/rahulcodepython/todo-backend/backend/version.Commit=$(git rev-parse HEAD) \
+//		-X github.com/rahulcodepython/todo-backend/backend/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package version
+
+// "fmt" provides functions for formatted I/O. It is used here to describe the build in one line.
+import (
+	"fmt"
+	// "runtime/debug" provides access to the build information of the binary. It is used here to read the VCS revision when no commit was injected.
+	"runtime/debug"
+)
+
+// Version is the release of the build, such as "1.4.0", or "dev" when it was not injected.
+var Version = "dev"
+
+// Commit is the VCS revision of the build. When it is not injected, the revision recorded by the Go toolchain is used.
+var Commit = ""
+
+// BuildTime is the time of the build in RFC 3339, or empty when it was not injected.
+var BuildTime = ""
+
+// Info defines the structure for the version of the build.
+type Info struct {
+	// Version is the release of the build.
+	// json:"version" specifies that this field should be marshalled to/from a JSON object with the key "version".
+	Version string `json:"version"`
+	// Commit is the VCS revision of the build, or "unknown".
+	// json:"commit" specifies that this field should be marshalled to/from a JSON object with the key "commit".
+	Commit string `json:"commit"`
+	// BuildTime is the time of the build, or empty if it is not known.
+	// json:"build_time" specifies that this field should be marshalled to/from a JSON object with the key "build_time".
+	BuildTime string `json:"build_time,omitempty"`
+}
+
+// Current returns the version of the running build.
+//
+// @return Info - The version, commit, and build time.
+func Current() Info {
+	// commit is the injected commit.
+	commit := Commit
+	// This checks if no commit was injected.
+	if commit == "" {
+		// If none was, the revision recorded by the toolchain is used.
+		commit = vcsRevision()
+	}
+	// The version of the build is returned.
+	return Info{Version: Version, Commit: commit, BuildTime: BuildTime}
+}
+
+// String describes the build in one line, such as "1.4.0 (commit 3f2c1ab, built 2026-10-01T12:00:00Z)".
+//
+// @return string - The description.
+func (i Info) String() string {
+	// This checks if the build time is not known.
+	if i.BuildTime == "" {
+		// If it is not, only the version and commit are described.
+		return fmt.Sprintf("%s (commit %s)", i.Version, i.Commit)
+	}
+	// The version, commit, and build time are described.
+	return fmt.Sprintf("%s (commit %s, built %s)", i.Version, i.Commit, i.BuildTime)
+}
+
+// vcsRevision returns the VCS revision that the Go toolchain recorded when the binary was built from a checkout.
+//
+// @return string - The revision, with a "-dirty" suffix for uncommitted changes, or "unknown".
+func vcsRevision() string {
+	// info is the build information of the binary.
+	info, ok := debug.ReadBuildInfo()
+	// This checks if the binary carries no build information.
+	if !ok {
+		// If it does not, the revision is unknown.
+		return "unknown"
+	}
+	// revision is the recorded revision, and modified reports uncommitted changes.
+	var revision, modified string
+	// This iterates over the build settings.
+	for _, setting := range info.Settings {
+		// This picks out the VCS settings.
+		switch setting.Key {
+		case "vcs.revision":
+			// The revision is kept.
+			revision = setting.Value
+		case "vcs.modified":
+			// The modification state is kept.
+			modified = setting.Value
+		}
+	}
+	// This checks if no revision was recorded, as with "go run" or a build outside a checkout.
+	if revision == "" {
+		// If none was, the revision is unknown.
+		return "unknown"
+	}
+	// This checks if the checkout had uncommitted changes.
+	if modified == "true" {
+		// If it had, the revision is marked as dirty.
+		return revision + "-dirty"
+	}
+	// The revision is returned.
+	return revision
+}
@@ -0,0 +1,269 @@
+// This file defines the methods of the client for the /auth endpoints: registering, logging in and out, the profile, the
+// sessions, the API keys, and the device authorization grant.
+package client
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to cancel the requests and the polls.
+import (
+	"context"
+	// "errors" provides functions for working with errors. It is used here to read the code of a poll that has no session yet.
+	"errors"
+	// "net/http" provides HTTP client and server implementations. It is used here for the HTTP methods.
+	"net/http"
+	// "time" provides functions for working with time. It is used here to wait between polls.
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to identify API keys.
+	"github.com/google/uuid"
+)
+
+// DeviceCodeGrantType is the grant type a device polls for its session with.
+const DeviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// slowDownStep is how much longer a device waits between polls every time the server says it polls too fast, as RFC 8628 asks.
+const slowDownStep = 5 * time.Second
+
+// Register creates a user and authenticates the client with the token of their first session.
+//
+// @param ctx context.Context - The context of the request.
+// @param body RegisterRequest - The user to create.
+// @return LoginResponse - The profile and the session of the user.
+// @return error - An error if one occurred.
+func (c *Client) Register(ctx context.Context, body RegisterRequest) (LoginResponse, error) {
+	// login is the profile and the session of the new user.
+	var login LoginResponse
+	// This sends the request. A user is not created twice, so it is not retried after a network error.
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: "/auth/register", body: body}, &login); err != nil {
+		// If an error occurs, it is returned.
+		return LoginResponse{}, err
+	}
+	// The client is authenticated with the new session.
+	c.SetToken(login.Token)
+	// The profile and the session are returned.
+	return login, nil
+}
+
+// Login opens a session with an email address and a password, and authenticates the client with its token.
+//
+// @param ctx context.Context - The context of the request.
+// @param body LoginRequest - The credentials.
+// @return LoginResponse - The profile and the session of the user.
+// @return error - An error if one occurred.
+func (c *Client) Login(ctx context.Context, body LoginRequest) (LoginResponse, error) {
+	// login is the profile and the session of the user.
+	var login LoginResponse
+	// This sends the request.
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: "/auth/login", body: body}, &login); err != nil {
+		// If an error occurs, it is returned.
+		return LoginResponse{}, err
+	}
+	// The client is authenticated with the new session.
+	c.SetToken(login.Token)
+	// The profile and the session are returned.
+	return login, nil
+}
+
+// Logout ends the session of the client and forgets its token.
+//
+// @param ctx context.Context - The context of the request.
+// @return error - An error if one occurred.
+func (c *Client) Logout(ctx context.Context) error {
+	// This sends the request.
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/auth/logout", idempotent: true}, nil); err != nil {
+		// If an error occurs, it is returned.
+		return err
+	}
+	// The token is forgotten.
+	c.SetToken("")
+	// No error is returned.
+	return nil
+}
+
+// Profile returns the profile of the authenticated user.
+//
+// @param ctx context.Context - The context of the request.
+// @return ProfileResponse - The profile.
+// @return error - An error if one occurred.
+func (c *Client) Profile(ctx context.Context) (ProfileResponse, error) {
+	// profile is the profile of the user.
+	var profile ProfileResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/auth/profile", idempotent: true}, &profile)
+	// The profile is returned.
+	return profile, err
+}
+
+// UpdatePreferences changes the time zone or the locale of the authenticated user.
+//
+// @param ctx context.Context - The context of the request.
+// @param body UpdatePreferencesRequest - The preferences to change.
+// @return ProfileResponse - The updated profile.
+// @return error - An error if one occurred.
+func (c *Client) UpdatePreferences(ctx context.Context, body UpdatePreferencesRequest) (ProfileResponse, error) {
+	// profile is the updated profile.
+	var profile ProfileResponse
+	// This sends the request. Setting the same preferences twice has the same effect as once.
+	_, err := c.do(ctx, request{method: http.MethodPatch, path: "/auth/preferences", body: body, idempotent: true}, &profile)
+	// The profile is returned.
+	return profile, err
+}
+
+// Usage returns the usage of the authenticated user against the limits of their plan.
+//
+// @param ctx context.Context - The context of the request.
+// @return UsageResponse - The usage.
+// @return error - An error if one occurred.
+func (c *Client) Usage(ctx context.Context) (UsageResponse, error) {
+	// usage is the usage of the user.
+	var usage UsageResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/auth/usage", idempotent: true}, &usage)
+	// The usage is returned.
+	return usage, err
+}
+
+// Sessions returns the open sessions of the authenticated user.
+//
+// @param ctx context.Context - The context of the request.
+// @return []SessionResponse - The sessions.
+// @return error - An error if one occurred.
+func (c *Client) Sessions(ctx context.Context) ([]SessionResponse, error) {
+	// sessions is the sessions of the user.
+	var sessions []SessionResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/auth/sessions", idempotent: true}, &sessions)
+	// The sessions are returned.
+	return sessions, err
+}
+
+// CreateAPIKey creates an API key. Its token is only returned here.
+//
+// @param ctx context.Context - The context of the request.
+// @param body CreateAPIKeyRequest - The key to create.
+// @return APIKeyResponse - The key, with its token.
+// @return error - An error if one occurred.
+func (c *Client) CreateAPIKey(ctx context.Context, body CreateAPIKeyRequest) (APIKeyResponse, error) {
+	// key is the created key.
+	var key APIKeyResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/auth/keys", body: body}, &key)
+	// The key is returned.
+	return key, err
+}
+
+// APIKeys returns the API keys of the authenticated user, without their tokens.
+//
+// @param ctx context.Context - The context of the request.
+// @return []APIKeyResponse - The keys.
+// @return error - An error if one occurred.
+func (c *Client) APIKeys(ctx context.Context) ([]APIKeyResponse, error) {
+	// keys is the keys of the user.
+	var keys []APIKeyResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/auth/keys", idempotent: true}, &keys)
+	// The keys are returned.
+	return keys, err
+}
+
+// DeleteAPIKey deletes an API key.
+//
+// @param ctx context.Context - The context of the request.
+// @param id uuid.UUID - The ID of the key.
+// @return error - An error if one occurred.
+func (c *Client) DeleteAPIKey(ctx context.Context, id uuid.UUID) error {
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/auth/keys/" + id.String(), idempotent: true}, nil)
+	// The error, if any, is returned.
+	return err
+}
+
+// StartDeviceAuthorization asks for the codes of the device authorization grant. The user enters the user code at the
+// verification URI while the device polls with the device code.
+//
+// @param ctx context.Context - The context of the request.
+// @param clientName string - The name of the device, such as "todoctl", which the user is shown before they approve it.
+// @return DeviceCodeResponse - The codes.
+// @return error - An error if one occurred.
+func (c *Client) StartDeviceAuthorization(ctx context.Context, clientName string) (DeviceCodeResponse, error) {
+	// codes is the codes of the device.
+	var codes DeviceCodeResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/auth/device/code", body: map[string]string{"client_name": clientName}}, &codes)
+	// The codes are returned.
+	return codes, err
+}
+
+// PollDeviceToken polls once for the session of a device. Until the user decides, it returns an *APIError with the code
+// "authorization_pending", or "slow_down" if the device polls too fast.
+//
+// @param ctx context.Context - The context of the request.
+// @param deviceCode string - The device code.
+// @return LoginResponse - The profile and the session of the user who approved the device.
+// @return error - An error if one occurred.
+func (c *Client) PollDeviceToken(ctx context.Context, deviceCode string) (LoginResponse, error) {
+	// login is the profile and the session of the user.
+	var login LoginResponse
+	// This sends the request.
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: "/auth/device/token", body: map[string]string{"grant_type": DeviceCodeGrantType, "device_code": deviceCode}}, &login); err != nil {
+		// If an error occurs, it is returned.
+		return LoginResponse{}, err
+	}
+	// The client is authenticated with the new session.
+	c.SetToken(login.Token)
+	// The profile and the session are returned.
+	return login, nil
+}
+
+// AuthorizeDevice signs the client in with the device authorization grant. It asks for the codes, hands them to prompt to
+// show the user, and polls at the interval the server asks for until the user approves or denies the device, the codes
+// expire, or the context is done.
+//
+// @param ctx context.Context - The context of the sign-in.
+// @param clientName string - The name of the device, which the user is shown before they approve it.
+// @param prompt func(DeviceCodeResponse) - The function that shows the user code and the verification URI to the user.
+// @return LoginResponse - The profile and the session of the user who approved the device.
+// @return error - An *APIError with the code "access_denied" or "expired_token" if the device gets no session, or another error if one occurred.
+func (c *Client) AuthorizeDevice(ctx context.Context, clientName string, prompt func(DeviceCodeResponse)) (LoginResponse, error) {
+	// codes is the result of asking for the codes.
+	codes, err := c.StartDeviceAuthorization(ctx, clientName)
+	// This checks if an error occurred while asking for the codes.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return LoginResponse{}, err
+	}
+	// The codes are shown to the user.
+	prompt(codes)
+
+	// interval is the wait between polls.
+	interval := time.Duration(codes.Interval) * time.Second
+	// This loops until the device gets a session or no longer can.
+	for {
+		// timer fires when the wait is over.
+		timer := time.NewTimer(interval)
+		// This waits for the timer or the cancellation of the context, whichever comes first.
+		select {
+		case <-ctx.Done():
+			// The timer is stopped, and the error of the context is returned.
+			timer.Stop()
+			return LoginResponse{}, ctx.Err()
+		case <-timer.C:
+		}
+
+		// login is the result of the poll.
+		login, err := c.PollDeviceToken(ctx, codes.DeviceCode)
+		// apiErr is the error of the API, if the poll got one.
+		var apiErr *APIError
+		// This checks if the poll got no session yet.
+		if errors.As(err, &apiErr) && apiErr.Code == "authorization_pending" {
+			// If it did not, the device polls again.
+			continue
+		}
+		// This checks if the device polls too fast.
+		if errors.As(err, &apiErr) && apiErr.Code == "slow_down" {
+			// If it does, it waits longer from now on.
+			interval += slowDownStep
+			continue
+		}
+		// The session, or the error that ends the polls, is returned.
+		return login, err
+	}
+}
@@ -0,0 +1,503 @@
+// Package client is a Go client of the todo-backend API. It wraps the endpoints in typed methods that take a context, unwrap the
+// response envelope, retry the requests that are safe to retry with exponential backoff, and page through lists with iterators,
+// so that Go programs, such as the load tester, do not build HTTP requests by hand.
+package client
+
+// "bytes" provides functions for manipulating byte slices. It is used here to send the same request body on every attempt.
+import (
+	"bytes"
+	// "context" provides a way to carry deadlines and cancellation signals. It is used here to cancel requests and the waits between them.
+	"context"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to encode request bodies and decode the envelope.
+	"encoding/json"
+	// "fmt" provides functions for formatted I/O. It is used here to format errors.
+	"fmt"
+	// "io" provides basic interfaces to I/O primitives. It is used here to read the response body.
+	"io"
+	// "math/rand/v2" provides pseudo-random numbers. It is used here to add jitter to the backoff.
+	"math/rand/v2"
+	// "net/http" provides HTTP client and server implementations. It is used here to send the requests.
+	"net/http"
+	// "net/url" provides functions for working with URLs. It is used here to build the request URLs.
+	"net/url"
+	// "strconv" provides functions for converting strings. It is used here to read the Retry-After header.
+	"strconv"
+	// "strings" provides functions for manipulating strings. It is used here to join the base URL and the paths.
+	"strings"
+	// "sync" provides synchronization primitives. It is used here to guard the token, which a login replaces.
+	"sync"
+	// "time" provides functions for working with time. It is used here to wait between attempts.
+	"time"
+)
+
+// Default values of a client.
+const (
+	// defaultTimeout is the timeout of a request, including reading its body, when no HTTP client is given.
+	defaultTimeout = 30 * time.Second
+	// defaultMaxRetries is the number of times a failed request is retried.
+	defaultMaxRetries = 3
+	// defaultMinBackoff is the wait before the first retry, which doubles on every retry after it.
+	defaultMinBackoff = 250 * time.Millisecond
+	// defaultMaxBackoff is the longest wait between two attempts, unless the server asks for a longer one in Retry-After.
+	defaultMaxBackoff = 10 * time.Second
+	// defaultUserAgent is the User-Agent header the client sends, which the server shows in the list of sessions.
+	defaultUserAgent = "todo-backend-go-client"
+	// maxErrorBody is the largest body of an error response that is read, so that a proxy that answers with a large page is cut short.
+	maxErrorBody = 1 << 20
+)
+
+// Client is a client of the API. It is safe for concurrent use.
+type Client struct {
+	// baseURL is the URL of the API, including its version, such as "http://127.0.0.1:8000/api/v1", without a trailing slash.
+	baseURL string
+	// httpClient is the HTTP client the requests are sent with.
+	httpClient *http.Client
+	// userAgent is the User-Agent header of the requests.
+	userAgent string
+	// maxRetries is the number of times a failed request is retried.
+	maxRetries int
+	// minBackoff is the wait before the first retry.
+	minBackoff time.Duration
+	// maxBackoff is the longest wait between two attempts.
+	maxBackoff time.Duration
+
+	// mu guards the token.
+	mu sync.RWMutex
+	// token is the JWT, API key, or service account token the requests are authenticated with, or empty for anonymous requests.
+	token string
+}
+
+// Option configures a client.
+type Option func(*Client)
+
+// WithToken authenticates the requests with a token, such as a JWT, an API key, or a service account token.
+//
+// @param token string - The token.
+// @return Option - The option.
+func WithToken(token string) Option {
+	// The option sets the token.
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithHTTPClient sends the requests with an HTTP client, such as one with a custom transport or timeout.
+//
+// @param httpClient *http.Client - The HTTP client.
+// @return Option - The option.
+func WithHTTPClient(httpClient *http.Client) Option {
+	// The option sets the HTTP client.
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithUserAgent sets the User-Agent header of the requests, which the server shows in the list of sessions.
+//
+// @param userAgent string - The User-Agent header.
+// @return Option - The option.
+func WithUserAgent(userAgent string) Option {
+	// The option sets the User-Agent header.
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithRetry sets how failed requests are retried. Zero retries sends every request once.
+//
+// @param maxRetries int - The number of times a failed request is retried.
+// @param minBackoff time.Duration - The wait before the first retry, which doubles on every retry after it.
+// @param maxBackoff time.Duration - The longest wait between two attempts.
+// @return Option - The option.
+func WithRetry(maxRetries int, minBackoff, maxBackoff time.Duration) Option {
+	// The option sets the retry policy.
+	return func(c *Client) {
+		c.maxRetries = max(maxRetries, 0)
+		c.minBackoff = minBackoff
+		c.maxBackoff = max(maxBackoff, minBackoff)
+	}
+}
+
+// New creates a client of the API at a base URL, such as "http://127.0.0.1:8000/api/v1".
+//
+// @param baseURL string - The URL of the API, including its version.
+// @param options ...Option - The options of the client.
+// @return *Client - The client.
+func New(baseURL string, options ...Option) *Client {
+	// c is the client with the default values.
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		userAgent:  defaultUserAgent,
+		maxRetries: defaultMaxRetries,
+		minBackoff: defaultMinBackoff,
+		maxBackoff: defaultMaxBackoff,
+	}
+	// This iterates over the options.
+	for _, option := range options {
+		// The option is applied.
+		option(c)
+	}
+	// The client is returned.
+	return c
+}
+
+// SetToken replaces the token the requests are authenticated with. Register, Login, and the device authorization grant call it
+// with the token of the new session.
+//
+// @param token string - The token, or empty for anonymous requests.
+func (c *Client) SetToken(token string) {
+	// The lock is held while the token is replaced.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// The token is replaced.
+	c.token = token
+}
+
+// Token returns the token the requests are authenticated with.
+//
+// @return string - The token, or empty if there is none.
+func (c *Client) Token() string {
+	// The lock is held while the token is read.
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	// The token is returned.
+	return c.token
+}
+
+// Meta is the metadata of a response.
+type Meta struct {
+	// RequestID is the ID of the request, which the server logs errors with.
+	// json:"request_id" specifies that this field should be marshalled to/from a JSON object with the key "request_id".
+	RequestID string `json:"request_id"`
+	// APIVersion is the version of the API that answered the request.
+	// json:"api_version" specifies that this field should be marshalled to/from a JSON object with the key "api_version".
+	APIVersion string `json:"api_version"`
+	// Pagination holds the links to the neighbouring pages, if the data is paginated.
+	// json:"pagination" specifies that this field should be marshalled to/from a JSON object with the key "pagination".
+	Pagination *Pagination `json:"pagination"`
+}
+
+// Pagination is the links to the neighbouring pages of a paginated response.
+type Pagination struct {
+	// Next is the URL of the next page, or empty on the last page.
+	// json:"next" specifies that this field should be marshalled to/from a JSON object with the key "next".
+	Next string `json:"next"`
+	// Prev is the URL of the previous page, or empty on the first page.
+	// json:"prev" specifies that this field should be marshalled to/from a JSON object with the key "prev".
+	Prev string `json:"prev"`
+	// Cursor is the opaque position to resume from, for endpoints paginated by cursor.
+	// json:"cursor" specifies that this field should be marshalled to/from a JSON object with the key "cursor".
+	Cursor string `json:"cursor"`
+}
+
+// envelope is the standard structure every response of the API is wrapped in.
+type envelope struct {
+	// Success reports whether the request was successful.
+	// json:"success" specifies that this field should be marshalled to/from a JSON object with the key "success".
+	Success bool `json:"success"`
+	// Message is a human-readable description of the response.
+	// json:"message" specifies that this field should be marshalled to/from a JSON object with the key "message".
+	Message string `json:"message"`
+	// Code is a machine-readable code of the error, if any.
+	// json:"code" specifies that this field should be marshalled to/from a JSON object with the key "code".
+	Code string `json:"code"`
+	// Data is the payload of the response, decoded into the type the method returns.
+	// json:"data" specifies that this field should be marshalled to/from a JSON object with the key "data".
+	Data json.RawMessage `json:"data"`
+	// Error is the detail of the error, if any, such as the invalid fields.
+	// json:"error" specifies that this field should be marshalled to/from a JSON object with the key "error".
+	Error json.RawMessage `json:"error"`
+	// Meta is the metadata of the response.
+	// json:"meta" specifies that this field should be marshalled to/from a JSON object with the key "meta".
+	Meta *Meta `json:"meta"`
+}
+
+// APIError is the error returned when the API answers a request with an error status.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Code is the machine-readable code of the error, such as "quota_exceeded", or empty if it has none.
+	Code string
+	// Message is the human-readable description of the error.
+	Message string
+	// RequestID is the ID of the request, to look up in the server logs.
+	RequestID string
+	// Details is the raw detail of the error, such as the invalid fields, or nil if it has none.
+	Details json.RawMessage
+	// Data is the raw payload of the error, such as the token of a 428 Precondition Required response, or nil if it has none.
+	Data json.RawMessage
+	// RetryAfter is the wait the server asked for in the Retry-After header, or zero if it asked for none.
+	RetryAfter time.Duration
+}
+
+// Error returns the description of the error.
+//
+// @return string - The description.
+func (e *APIError) Error() string {
+	// This checks if the error has a machine-readable code.
+	if e.Code != "" {
+		// If it has, the code is included.
+		return fmt.Sprintf("todo api: %d %s: %s", e.StatusCode, e.Code, e.Message)
+	}
+	// The status and the message are returned.
+	return fmt.Sprintf("todo api: %d: %s", e.StatusCode, e.Message)
+}
+
+// request is a request to the API.
+type request struct {
+	// method is the HTTP method.
+	method string
+	// path is the path below the base URL, such as "/todos".
+	path string
+	// query is the query parameters, or nil if there are none.
+	query url.Values
+	// body is the value encoded as the JSON body, or nil if there is none.
+	body any
+	// header is the extra headers, or nil if there are none.
+	header http.Header
+	// idempotent reports whether sending the request twice has the same effect as sending it once, which makes it safe to
+	// retry after a network error or a 5xx response that the server may have acted on.
+	idempotent bool
+}
+
+// do sends a request, retrying it as the retry policy allows, and decodes the data of the response into out.
+//
+// @param ctx context.Context - The context of the request, which cancels it and the waits between attempts.
+// @param req request - The request.
+// @param out any - A pointer the data of the response is decoded into, or nil to discard it.
+// @return *Meta - The metadata of the response.
+// @return error - An *APIError if the API answered with an error status, or another error if one occurred.
+func (c *Client) do(ctx context.Context, req request, out any) (*Meta, error) {
+	// body is the encoded body, which is sent again on every attempt.
+	var body []byte
+	// This checks if the request has a body.
+	if req.body != nil {
+		// encoded is the result of encoding the body.
+		encoded, err := json.Marshal(req.body)
+		// This checks if an error occurred while encoding the body.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return nil, fmt.Errorf("encoding request body: %w", err)
+		}
+		body = encoded
+	}
+
+	// This loops until the request succeeds or is not retried.
+	for attempt := 0; ; attempt++ {
+		// meta and err are the result of the attempt.
+		meta, err := c.send(ctx, req, body, out)
+		// This checks if the attempt succeeded.
+		if err == nil {
+			// If it did, the metadata is returned.
+			return meta, nil
+		}
+		// wait is how long to wait before the next attempt, or a negative duration if there is none.
+		wait := c.retryWait(ctx, req, err, attempt)
+		// This checks if the request is not retried.
+		if wait < 0 {
+			// If it is not, the error is returned.
+			return nil, err
+		}
+		// timer fires when the wait is over.
+		timer := time.NewTimer(wait)
+		// This waits for the timer or the cancellation of the context, whichever comes first.
+		select {
+		case <-ctx.Done():
+			// The timer is stopped, and the error of the context is returned.
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryWait decides whether a failed attempt is retried, and how long to wait before it is.
+// A 429 Too Many Requests response is always retried, since the server did not act on the request. A network error and a
+// 502, 503, or 504 response are only retried if the request is idempotent, since the server may have acted on it.
+//
+// @param ctx context.Context - The context of the request.
+// @param req request - The request.
+// @param err error - The error of the attempt.
+// @param attempt int - The number of the attempt, starting at 0.
+// @return time.Duration - The wait before the next attempt, or -1 if the request is not retried.
+func (c *Client) retryWait(ctx context.Context, req request, err error, attempt int) time.Duration {
+	// This checks if the retries are used up or the context is done.
+	if attempt >= c.maxRetries || ctx.Err() != nil {
+		// If they are, the request is not retried.
+		return -1
+	}
+	// backoff is the wait of the attempt.
+	backoff := c.backoff(attempt)
+	// apiErr is the error of the API, if the attempt got a response.
+	apiErr, ok := err.(*APIError)
+	// This checks if the attempt got no response, which means a network error.
+	if !ok {
+		// If it did not, the request is retried only if it is idempotent.
+		if req.idempotent {
+			return backoff
+		}
+		return -1
+	}
+	// This checks the status of the response.
+	switch apiErr.StatusCode {
+	// The rate limiter refused the request.
+	case http.StatusTooManyRequests:
+		// The request is retried after the wait the server asked for, if it is longer.
+		return max(backoff, apiErr.RetryAfter)
+	// A proxy or the server could not answer the request in time, or a dependency of the server is down.
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		// This checks if the request is idempotent.
+		if req.idempotent {
+			// If it is, it is retried after the wait the server asked for, if it is longer.
+			return max(backoff, apiErr.RetryAfter)
+		}
+	}
+	// Any other error is not retried.
+	return -1
+}
+
+// backoff returns the wait before a retry: the minimum backoff doubled for every attempt before it, capped at the maximum
+// backoff, of which a random half is taken off, so that clients that failed together do not retry together.
+//
+// @param attempt int - The number of the failed attempt, starting at 0.
+// @return time.Duration - The wait.
+func (c *Client) backoff(attempt int) time.Duration {
+	// wait is the minimum backoff doubled for every attempt, capped at the maximum backoff.
+	wait := c.minBackoff
+	// This doubles the wait for every attempt, stopping at the cap.
+	for i := 0; i < attempt && wait < c.maxBackoff; i++ {
+		wait *= 2
+	}
+	wait = min(wait, c.maxBackoff)
+	// This checks if the wait is too short to take a random part off.
+	if wait < 2 {
+		// If it is, it is returned as it is.
+		return wait
+	}
+	// Half of the wait is kept, and a random part of the other half is added.
+	return wait/2 + rand.N(wait/2)
+}
+
+// send makes one attempt at a request.
+//
+// @param ctx context.Context - The context of the request.
+// @param req request - The request.
+// @param body []byte - The encoded body, or nil if there is none.
+// @param out any - A pointer the data of the response is decoded into, or nil to discard it.
+// @return *Meta - The metadata of the response.
+// @return error - An *APIError if the API answered with an error status, or another error if one occurred.
+func (c *Client) send(ctx context.Context, req request, body []byte, out any) (*Meta, error) {
+	// target is the URL of the request.
+	target := c.baseURL + req.path
+	// This checks if the request has query parameters.
+	if len(req.query) > 0 {
+		// If it has, they are appended.
+		target += "?" + req.query.Encode()
+	}
+
+	// reader is the body of the request, or nil if there is none.
+	var reader io.Reader
+	// This checks if the request has a body.
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	// httpReq is the HTTP request.
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, reader)
+	// This checks if an error occurred while building the request.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// The extra headers are copied.
+	for key, values := range req.header {
+		httpReq.Header[key] = values
+	}
+	// The client asks for JSON.
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	// This checks if the request has a body.
+	if body != nil {
+		// If it has, its type is set.
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	// This checks if the client has a token.
+	if token := c.Token(); token != "" {
+		// If it has, the request is authenticated with it.
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	// res is the response of the API.
+	res, err := c.httpClient.Do(httpReq)
+	// This checks if an error occurred while sending the request.
+	if err != nil {
+		// If an error occurs, it is returned.
+		return nil, err
+	}
+	// This defers the closing of the response body until the function returns.
+	defer res.Body.Close()
+
+	// This checks if the response is an error.
+	if res.StatusCode >= http.StatusBadRequest {
+		// If it is, it is turned into an *APIError.
+		return nil, newAPIError(res)
+	}
+
+	// env is the envelope of the response.
+	var env envelope
+	// This decodes the envelope.
+	if err := json.NewDecoder(res.Body).Decode(&env); err != nil && err != io.EOF {
+		// If an error occurs, it is returned.
+		return nil, fmt.Errorf("decoding response of %s %s: %w", req.method, req.path, err)
+	}
+	// This checks if the data should be decoded.
+	if out != nil && len(env.Data) > 0 {
+		// If it should, it is decoded into out.
+		if err := json.Unmarshal(env.Data, out); err != nil {
+			// If an error occurs, it is returned.
+			return nil, fmt.Errorf("decoding data of %s %s: %w", req.method, req.path, err)
+		}
+	}
+	// The metadata is returned.
+	return env.Meta, nil
+}
+
+// newAPIError turns an error response into an *APIError. A body that is not the envelope of the API, such as the page of a
+// proxy, leaves the message as the status text.
+//
+// @param res *http.Response - The error response.
+// @return *APIError - The error.
+func newAPIError(res *http.Response) *APIError {
+	// apiErr is the error with what the response always has.
+	apiErr := &APIError{
+		StatusCode: res.StatusCode,
+		Message:    http.StatusText(res.StatusCode),
+		RequestID:  res.Header.Get("X-Request-ID"),
+	}
+	// This checks if the server asked for a wait in whole seconds, which is how it sets Retry-After.
+	if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		// If it did, the wait is kept.
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	// env is the envelope of the response.
+	var env envelope
+	// This decodes the envelope, reading no more than the cap.
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxErrorBody)).Decode(&env); err != nil {
+		// If it is not the envelope, the error is returned with the status text.
+		return apiErr
+	}
+	// This checks if the envelope has a message.
+	if env.Message != "" {
+		apiErr.Message = env.Message
+	}
+	apiErr.Code = env.Code
+	apiErr.Details = env.Error
+	apiErr.Data = env.Data
+	// This checks if the envelope has a request ID and the header had none.
+	if env.Meta != nil && apiErr.RequestID == "" {
+		apiErr.RequestID = env.Meta.RequestID
+	}
+	// The error is returned.
+	return apiErr
+}
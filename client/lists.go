@@ -0,0 +1,205 @@
+// This file defines the methods of the client for the /lists and /tags endpoints.
+package client
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to cancel the requests.
+import (
+	"context"
+	// "encoding/json" provides functions for encoding and decoding JSON. It is used here to decode the confirmation of a complete all request.
+	"encoding/json"
+	// "errors" provides functions for working with errors. It is used here to recognize a request that must be confirmed.
+	"errors"
+	// "net/http" provides HTTP client and server implementations. It is used here for the HTTP methods.
+	"net/http"
+	// "net/url" provides functions for working with URLs. It is used here to build the query parameters and escape the tag names.
+	"net/url"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to identify lists and todos.
+	"github.com/google/uuid"
+)
+
+// CreateList creates a list.
+//
+// @param ctx context.Context - The context of the request.
+// @param body CreateListRequest - The list to create.
+// @return ListResponse - The created list.
+// @return error - An error if one occurred.
+func (c *Client) CreateList(ctx context.Context, body CreateListRequest) (ListResponse, error) {
+	// list is the created list.
+	var list ListResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/lists", body: body}, &list)
+	// The list is returned.
+	return list, err
+}
+
+// Lists returns the lists of the authenticated user, or their archived lists.
+//
+// @param ctx context.Context - The context of the request.
+// @param color string - The hex color the lists must have, or empty for every list.
+// @param archived bool - Whether to return the archived lists instead of the others.
+// @return []ListResponse - The lists.
+// @return error - An error if one occurred.
+func (c *Client) Lists(ctx context.Context, color string, archived bool) ([]ListResponse, error) {
+	// values is the query parameters.
+	values := url.Values{}
+	// This checks if a color is given.
+	if color != "" {
+		values.Set("color", color)
+	}
+	// This checks if the archived lists are asked for.
+	if archived {
+		values.Set("archived", "true")
+	}
+	// lists is the lists.
+	var lists []ListResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/lists", query: values, idempotent: true}, &lists)
+	// The lists are returned.
+	return lists, err
+}
+
+// GetList returns a list.
+//
+// @param ctx context.Context - The context of the request.
+// @param id uuid.UUID - The ID of the list.
+// @return ListResponse - The list.
+// @return error - An error if one occurred.
+func (c *Client) GetList(ctx context.Context, id uuid.UUID) (ListResponse, error) {
+	// list is the list.
+	var list ListResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/lists/" + id.String(), idempotent: true}, &list)
+	// The list is returned.
+	return list, err
+}
+
+// UpdateList changes the name, the color, or the icon of a list.
+//
+// @param ctx context.Context - The context of the request.
+// @param id uuid.UUID - The ID of the list.
+// @param body UpdateListRequest - The fields to change.
+// @return ListResponse - The updated list.
+// @return error - An error if one occurred.
+func (c *Client) UpdateList(ctx context.Context, id uuid.UUID, body UpdateListRequest) (ListResponse, error) {
+	// list is the updated list.
+	var list ListResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodPatch, path: "/lists/" + id.String(), body: body, idempotent: true}, &list)
+	// The list is returned.
+	return list, err
+}
+
+// ArchiveList archives a list.
+//
+// @param ctx context.Context - The context of the request.
+// @param id uuid.UUID - The ID of the list.
+// @return ListResponse - The archived list.
+// @return error - An error if one occurred.
+func (c *Client) ArchiveList(ctx context.Context, id uuid.UUID) (ListResponse, error) {
+	// list is the archived list.
+	var list ListResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/lists/" + id.String() + "/archive", idempotent: true}, &list)
+	// The list is returned.
+	return list, err
+}
+
+// UnarchiveList brings an archived list back.
+//
+// @param ctx context.Context - The context of the request.
+// @param id uuid.UUID - The ID of the list.
+// @return ListResponse - The list.
+// @return error - An error if one occurred.
+func (c *Client) UnarchiveList(ctx context.Context, id uuid.UUID) (ListResponse, error) {
+	// list is the list.
+	var list ListResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/lists/" + id.String() + "/unarchive", idempotent: true}, &list)
+	// The list is returned.
+	return list, err
+}
+
+// CompleteAll completes the open todos of a list. When the list has many open todos, the server asks for a confirmation;
+// with confirm, the request is sent again with the confirmation token, and without it, the *APIError with the code
+// "confirmation_required" is returned with the confirmation as its data.
+//
+// @param ctx context.Context - The context of the request.
+// @param id uuid.UUID - The ID of the list.
+// @param confirm bool - Whether to confirm completing many todos.
+// @return CompleteAllResponse - The completed todos.
+// @return error - An error if one occurred.
+func (c *Client) CompleteAll(ctx context.Context, id uuid.UUID, confirm bool) (CompleteAllResponse, error) {
+	// completed is the completed todos.
+	var completed CompleteAllResponse
+	// req is the request, which is sent again with the confirmation token if one is asked for.
+	req := request{method: http.MethodPost, path: "/lists/" + id.String() + "/complete-all", body: map[string]string{}, idempotent: true}
+	// err is the result of sending the request.
+	_, err := c.do(ctx, req, &completed)
+	// apiErr is the error of the API, if the request got one.
+	var apiErr *APIError
+	// This checks if a confirmation is asked for and should be given.
+	if confirm && errors.As(err, &apiErr) && apiErr.Code == "confirmation_required" {
+		// confirmation is the confirmation the server asked for.
+		var confirmation CompleteAllConfirmation
+		// This decodes the confirmation.
+		if err := json.Unmarshal(apiErr.Data, &confirmation); err != nil {
+			// If an error occurs, it is returned.
+			return CompleteAllResponse{}, err
+		}
+		// The request is sent again with the confirmation token.
+		req.body = map[string]string{"confirmation_token": confirmation.ConfirmationToken}
+		_, err = c.do(ctx, req, &completed)
+	}
+	// The completed todos are returned.
+	return completed, err
+}
+
+// ReorderList sets the order of the todos of a list. The first ID gets the first position.
+//
+// @param ctx context.Context - The context of the request.
+// @param id uuid.UUID - The ID of the list.
+// @param todoIds []uuid.UUID - The todos, in their new order.
+// @return error - An error if one occurred.
+func (c *Client) ReorderList(ctx context.Context, id uuid.UUID, todoIds []uuid.UUID) error {
+	// This sends the request. Setting the same order twice has the same effect as once.
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/lists/" + id.String() + "/reorder", body: map[string]any{"todo_ids": todoIds}, idempotent: true}, nil)
+	// The error, if any, is returned.
+	return err
+}
+
+// Tags returns the tags of the authenticated user with the number of todos that use them.
+//
+// @param ctx context.Context - The context of the request.
+// @param color string - The hex color the tags must have, or empty for every tag.
+// @return []TagResponse - The tags.
+// @return error - An error if one occurred.
+func (c *Client) Tags(ctx context.Context, color string) ([]TagResponse, error) {
+	// values is the query parameters.
+	values := url.Values{}
+	// This checks if a color is given.
+	if color != "" {
+		values.Set("color", color)
+	}
+	// tags is the tags.
+	var tags []TagResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/tags", query: values, idempotent: true}, &tags)
+	// The tags are returned.
+	return tags, err
+}
+
+// SetTagStyle sets the color and the icon of a tag.
+//
+// @param ctx context.Context - The context of the request.
+// @param tag string - The name of the tag.
+// @param body TagStyleRequest - The style.
+// @return TagResponse - The tag.
+// @return error - An error if one occurred.
+func (c *Client) SetTagStyle(ctx context.Context, tag string, body TagStyleRequest) (TagResponse, error) {
+	// styled is the tag.
+	var styled TagResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodPut, path: "/tags/" + url.PathEscape(tag), body: body, idempotent: true}, &styled)
+	// The tag is returned.
+	return styled, err
+}
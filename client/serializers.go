@@ -0,0 +1,591 @@
+// This file defines the requests and responses of the API as the client sends and receives them.
+// They mirror the serializers of the apps, with the times parsed, so that a program using the client does not import the server.
+package client
+
+// "time" provides functions for working with time. It is used here to type the times of the responses.
+import (
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to type the IDs.
+	"github.com/google/uuid"
+)
+
+// RegisterRequest defines the structure for a register request.
+type RegisterRequest struct {
+	// Name is the user's name.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+	// Email is the user's email address.
+	// json:"email" specifies that this field should be marshalled to/from a JSON object with the key "email".
+	Email string `json:"email"`
+	// Image is the user's optional profile image.
+	// json:"image,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "image", and should be omitted if empty.
+	Image string `json:"image,omitempty"`
+	// Password is the user's password.
+	// json:"password" specifies that this field should be marshalled to/from a JSON object with the key "password".
+	Password string `json:"password"`
+	// Timezone is the optional IANA name of the user's time zone. It defaults to UTC.
+	// json:"timezone,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "timezone", and should be omitted if empty.
+	Timezone string `json:"timezone,omitempty"`
+	// Locale is the optional language for API responses, such as "es".
+	// json:"locale,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "locale", and should be omitted if empty.
+	Locale string `json:"locale,omitempty"`
+}
+
+// LoginRequest defines the structure for a login request.
+type LoginRequest struct {
+	// Email is the user's email address.
+	// json:"email" specifies that this field should be marshalled to/from a JSON object with the key "email".
+	Email string `json:"email"`
+	// Password is the user's password.
+	// json:"password" specifies that this field should be marshalled to/from a JSON object with the key "password".
+	Password string `json:"password"`
+	// RememberMe reports whether the session is kept for the longer lifetime, rather than the default one.
+	// json:"remember_me,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "remember_me", and should be omitted if false.
+	RememberMe bool `json:"remember_me,omitempty"`
+}
+
+// ProfileResponse defines the structure for a profile response.
+type ProfileResponse struct {
+	// ID is the user's ID.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// Name is the user's name.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+	// Email is the user's email address.
+	// json:"email" specifies that this field should be marshalled to/from a JSON object with the key "email".
+	Email string `json:"email"`
+	// Image is the user's profile image, or nil if the user has none.
+	// json:"image" specifies that this field should be marshalled to/from a JSON object with the key "image".
+	Image *string `json:"image"`
+	// CreatedAt is the time the user was created.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the time the user was last updated.
+	// json:"updated_at" specifies that this field should be marshalled to/from a JSON object with the key "updated_at".
+	UpdatedAt time.Time `json:"updated_at"`
+	// Timezone is the IANA name of the user's time zone.
+	// json:"timezone" specifies that this field should be marshalled to/from a JSON object with the key "timezone".
+	Timezone string `json:"timezone"`
+	// Locale is the language the user chose for API responses, or empty to follow the Accept-Language header.
+	// json:"locale" specifies that this field should be marshalled to/from a JSON object with the key "locale".
+	Locale string `json:"locale"`
+}
+
+// LoginResponse defines the structure for the response of a register, a login, or an approved device.
+type LoginResponse struct {
+	// ProfileResponse is the user's profile, whose fields are part of the response object.
+	ProfileResponse
+	// Token is the JWT of the new session.
+	// json:"token" specifies that this field should be marshalled to/from a JSON object with the key "token".
+	Token string `json:"token"`
+	// ExpiresAt is the expiration time of the JWT.
+	// json:"expires_at" specifies that this field should be marshalled to/from a JSON object with the key "expires_at".
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// UpdatePreferencesRequest defines the structure for an update preferences request. A nil field is left unchanged.
+type UpdatePreferencesRequest struct {
+	// Timezone is the IANA name of the user's time zone, such as "Asia/Kolkata".
+	// json:"timezone,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "timezone", and should be omitted if empty.
+	Timezone *string `json:"timezone,omitempty"`
+	// Locale is the language for API responses, such as "es", or an empty string to follow the Accept-Language header.
+	// json:"locale,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "locale", and should be omitted if empty.
+	Locale *string `json:"locale,omitempty"`
+}
+
+// UsageAmount defines the structure for the usage of one resource.
+type UsageAmount struct {
+	// Used is the current usage.
+	// json:"used" specifies that this field should be marshalled to/from a JSON object with the key "used".
+	Used int64 `json:"used"`
+	// Limit is the limit of the user's plan, or zero if it is unlimited.
+	// json:"limit" specifies that this field should be marshalled to/from a JSON object with the key "limit".
+	Limit int64 `json:"limit"`
+}
+
+// UsageResponse defines the structure for a usage response.
+type UsageResponse struct {
+	// Plan is the plan of the user.
+	// json:"plan" specifies that this field should be marshalled to/from a JSON object with the key "plan".
+	Plan string `json:"plan"`
+	// Todos is the usage of todos.
+	// json:"todos" specifies that this field should be marshalled to/from a JSON object with the key "todos".
+	Todos UsageAmount `json:"todos"`
+	// Lists is the usage of lists.
+	// json:"lists" specifies that this field should be marshalled to/from a JSON object with the key "lists".
+	Lists UsageAmount `json:"lists"`
+	// AttachmentBytes is the usage of attachment storage in bytes.
+	// json:"attachment_bytes" specifies that this field should be marshalled to/from a JSON object with the key "attachment_bytes".
+	AttachmentBytes UsageAmount `json:"attachment_bytes"`
+}
+
+// SessionResponse defines the structure for a session response.
+type SessionResponse struct {
+	// ID is the ID of the session.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// UserAgent is the User-Agent of the device the session was opened on.
+	// json:"user_agent" specifies that this field should be marshalled to/from a JSON object with the key "user_agent".
+	UserAgent string `json:"user_agent"`
+	// IP is the IP address the session was last used from.
+	// json:"ip" specifies that this field should be marshalled to/from a JSON object with the key "ip".
+	IP string `json:"ip"`
+	// CreatedAt is the time the session was opened.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt time.Time `json:"created_at"`
+	// LastUsedAt is the time the session was last used, or nil if it was never used.
+	// json:"last_used_at" specifies that this field should be marshalled to/from a JSON object with the key "last_used_at".
+	LastUsedAt *time.Time `json:"last_used_at"`
+	// ExpiresAt is the time the session expires.
+	// json:"expires_at" specifies that this field should be marshalled to/from a JSON object with the key "expires_at".
+	ExpiresAt time.Time `json:"expires_at"`
+	// Current reports whether the session is the one of the request.
+	// json:"current" specifies that this field should be marshalled to/from a JSON object with the key "current".
+	Current bool `json:"current"`
+}
+
+// CreateAPIKeyRequest defines the structure for a create API key request.
+type CreateAPIKeyRequest struct {
+	// Name is the name of the key, such as the tool it is for.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+	// Scopes is the list of scopes the key is limited to, such as "todos:read".
+	// json:"scopes" specifies that this field should be marshalled to/from a JSON object with the key "scopes".
+	Scopes []string `json:"scopes"`
+	// ExpiresInDays is the lifetime of the key in days, or zero for API_KEY_EXPIRY_DAYS.
+	// json:"expires_in_days,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "expires_in_days", and should be omitted if empty.
+	ExpiresInDays int `json:"expires_in_days,omitempty"`
+}
+
+// APIKeyResponse defines the structure for an API key response.
+type APIKeyResponse struct {
+	// ID is the ID of the key, which deletes it.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// Name is the name of the key.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+	// Scopes is the list of scopes the key is limited to.
+	// json:"scopes" specifies that this field should be marshalled to/from a JSON object with the key "scopes".
+	Scopes []string `json:"scopes"`
+	// Token is the key itself, which is only included when the key is created.
+	// json:"token" specifies that this field should be marshalled to/from a JSON object with the key "token".
+	Token string `json:"token"`
+	// CreatedAt is the time the key was created.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt time.Time `json:"created_at"`
+	// LastUsedAt is the time the key was last used, or nil if it was never used.
+	// json:"last_used_at" specifies that this field should be marshalled to/from a JSON object with the key "last_used_at".
+	LastUsedAt *time.Time `json:"last_used_at"`
+	// ExpiresAt is the time the key expires.
+	// json:"expires_at" specifies that this field should be marshalled to/from a JSON object with the key "expires_at".
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DeviceCodeResponse defines the structure for the response that starts the device authorization grant.
+type DeviceCodeResponse struct {
+	// DeviceCode is the code the device polls with.
+	// json:"device_code" specifies that this field should be marshalled to/from a JSON object with the key "device_code".
+	DeviceCode string `json:"device_code"`
+	// UserCode is the code the user enters.
+	// json:"user_code" specifies that this field should be marshalled to/from a JSON object with the key "user_code".
+	UserCode string `json:"user_code"`
+	// VerificationURI is the page where the user enters the code.
+	// json:"verification_uri" specifies that this field should be marshalled to/from a JSON object with the key "verification_uri".
+	VerificationURI string `json:"verification_uri"`
+	// VerificationURIComplete is the page with the code filled in.
+	// json:"verification_uri_complete" specifies that this field should be marshalled to/from a JSON object with the key "verification_uri_complete".
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	// ExpiresIn is the number of seconds the codes work for.
+	// json:"expires_in" specifies that this field should be marshalled to/from a JSON object with the key "expires_in".
+	ExpiresIn int64 `json:"expires_in"`
+	// Interval is the number of seconds the device waits between polls.
+	// json:"interval" specifies that this field should be marshalled to/from a JSON object with the key "interval".
+	Interval int `json:"interval"`
+}
+
+// TodoRequest defines the structure for a create or update todo request.
+type TodoRequest struct {
+	// Title is the title of the todo.
+	// json:"title" specifies that this field should be marshalled to/from a JSON object with the key "title".
+	Title string `json:"title"`
+	// Description is the optional description of the todo.
+	// json:"description,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "description", and should be omitted if empty.
+	Description string `json:"description,omitempty"`
+	// Priority is the optional priority of the todo: none, low, medium, or high.
+	// json:"priority,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "priority", and should be omitted if empty.
+	Priority string `json:"priority,omitempty"`
+	// DueAt is the optional time the todo is due.
+	// json:"due_at,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "due_at", and should be omitted if empty.
+	DueAt *time.Time `json:"due_at,omitempty"`
+	// Tags is the optional list of tags of the todo.
+	// json:"tags,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "tags", and should be omitted if empty.
+	Tags []string `json:"tags,omitempty"`
+	// EstimateMinutes is the optional estimated effort of the todo in minutes.
+	// json:"estimate_minutes,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "estimate_minutes", and should be omitted if empty.
+	EstimateMinutes *int `json:"estimate_minutes,omitempty"`
+	// Version is the optional version of the todo an update is based on. The update is refused with 409 if the todo has changed since.
+	// json:"version,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "version", and should be omitted if empty.
+	Version *int64 `json:"version,omitempty"`
+}
+
+// TodoResponse defines the structure for a todo response.
+type TodoResponse struct {
+	// ID is the unique identifier for the todo.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// Title is the title of the todo.
+	// json:"title" specifies that this field should be marshalled to/from a JSON object with the key "title".
+	Title string `json:"title"`
+	// Description is the description of the todo.
+	// json:"description" specifies that this field should be marshalled to/from a JSON object with the key "description".
+	Description string `json:"description"`
+	// Priority is the priority of the todo.
+	// json:"priority" specifies that this field should be marshalled to/from a JSON object with the key "priority".
+	Priority string `json:"priority"`
+	// Completed is the completion status of the todo.
+	// json:"completed" specifies that this field should be marshalled to/from a JSON object with the key "completed".
+	Completed bool `json:"completed"`
+	// CreatedAt is the time the todo was created.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the time the todo was last changed.
+	// json:"updated_at" specifies that this field should be marshalled to/from a JSON object with the key "updated_at".
+	UpdatedAt time.Time `json:"updated_at"`
+	// ListID is the ID of the list the todo belongs to, or nil if it is in none.
+	// json:"list_id" specifies that this field should be marshalled to/from a JSON object with the key "list_id".
+	ListID *uuid.UUID `json:"list_id"`
+	// Position is the position of the todo within its list.
+	// json:"position" specifies that this field should be marshalled to/from a JSON object with the key "position".
+	Position int `json:"position"`
+	// DueAt is the time the todo is due, or nil if it has no due date.
+	// json:"due_at" specifies that this field should be marshalled to/from a JSON object with the key "due_at".
+	DueAt *time.Time `json:"due_at"`
+	// Tags is the list of tags of the todo.
+	// json:"tags" specifies that this field should be marshalled to/from a JSON object with the key "tags".
+	Tags []string `json:"tags"`
+	// EstimateMinutes is the estimated effort of the todo in minutes, or nil if it has no estimate.
+	// json:"estimate_minutes" specifies that this field should be marshalled to/from a JSON object with the key "estimate_minutes".
+	EstimateMinutes *int64 `json:"estimate_minutes"`
+	// SnoozedUntil is the time the todo was last snoozed until, or nil if it is not snoozed.
+	// json:"snoozed_until" specifies that this field should be marshalled to/from a JSON object with the key "snoozed_until".
+	SnoozedUntil *time.Time `json:"snoozed_until"`
+	// Status is the board column of the todo: backlog, in_progress, blocked, or done.
+	// json:"status" specifies that this field should be marshalled to/from a JSON object with the key "status".
+	Status string `json:"status"`
+	// Blocked is true while the todo waits on a blocker that is not completed.
+	// json:"blocked" specifies that this field should be marshalled to/from a JSON object with the key "blocked".
+	Blocked bool `json:"blocked"`
+	// Version is the change sequence number of the todo, which an update can be based on.
+	// json:"version" specifies that this field should be marshalled to/from a JSON object with the key "version".
+	Version int64 `json:"version"`
+	// URL is the canonical path of the todo.
+	// json:"url" specifies that this field should be marshalled to/from a JSON object with the key "url".
+	URL string `json:"url"`
+}
+
+// UndoableTodoResponse defines the structure for a todo response to an action that can be undone.
+type UndoableTodoResponse struct {
+	// TodoResponse is the todo after the action was performed.
+	TodoResponse
+	// UndoToken is the token that undoes the action.
+	// json:"undo_token" specifies that this field should be marshalled to/from a JSON object with the key "undo_token".
+	UndoToken uuid.UUID `json:"undo_token"`
+	// UndoExpiresAt is the time after which the action can no longer be undone.
+	// json:"undo_expires_at" specifies that this field should be marshalled to/from a JSON object with the key "undo_expires_at".
+	UndoExpiresAt time.Time `json:"undo_expires_at"`
+}
+
+// DeleteTodoResponse defines the structure for a delete todo response.
+type DeleteTodoResponse struct {
+	// TodoID is the ID of the deleted todo.
+	// json:"todo_id" specifies that this field should be marshalled to/from a JSON object with the key "todo_id".
+	TodoID uuid.UUID `json:"todo_id"`
+	// UndoToken is the token that restores the todo.
+	// json:"undo_token" specifies that this field should be marshalled to/from a JSON object with the key "undo_token".
+	UndoToken uuid.UUID `json:"undo_token"`
+	// UndoExpiresAt is the time after which the todo can no longer be restored.
+	// json:"undo_expires_at" specifies that this field should be marshalled to/from a JSON object with the key "undo_expires_at".
+	UndoExpiresAt time.Time `json:"undo_expires_at"`
+}
+
+// ListTodosQuery defines the query parameters of a list todos request. A zero field is left out of the query.
+type ListTodosQuery struct {
+	// Page is the page to start from, starting at 1.
+	Page int
+	// Limit is the number of todos per page, or zero for the default of the server.
+	Limit int
+	// Sort is the order of the todos, such as "-created_at".
+	Sort string
+	// Completed is the optional completion status filter.
+	Completed *bool
+	// ListID is the optional list filter.
+	ListID *uuid.UUID
+	// DueAfter is the optional start of the due date range, inclusive.
+	DueAfter *time.Time
+	// DueBefore is the optional end of the due date range, exclusive.
+	DueBefore *time.Time
+	// HasDueDate is the optional filter on whether a todo has a due date at all.
+	HasDueDate *bool
+	// View is the optional bucket of due dates: today, upcoming, or someday.
+	View string
+	// Total is how the total is counted: exact or approx.
+	Total string
+}
+
+// PaginatedTodoResponse defines the structure for a page of todos.
+type PaginatedTodoResponse struct {
+	// Results is the todos of the page.
+	// json:"results" specifies that this field should be marshalled to/from a JSON object with the key "results".
+	Results []TodoResponse `json:"results"`
+	// Count is the number of todos in the page.
+	// json:"count" specifies that this field should be marshalled to/from a JSON object with the key "count".
+	Count int `json:"count"`
+	// TotalItems is the total number of todos.
+	// json:"total_items" specifies that this field should be marshalled to/from a JSON object with the key "total_items".
+	TotalItems int64 `json:"total_items"`
+	// TotalPages is the total number of pages.
+	// json:"total_pages" specifies that this field should be marshalled to/from a JSON object with the key "total_pages".
+	TotalPages int `json:"total_pages"`
+	// Page is the page number.
+	// json:"page" specifies that this field should be marshalled to/from a JSON object with the key "page".
+	Page int `json:"page"`
+	// Limit is the number of todos per page.
+	// json:"limit" specifies that this field should be marshalled to/from a JSON object with the key "limit".
+	Limit int `json:"limit"`
+}
+
+// ArchivedTodoResponse defines the structure for an archived todo response.
+type ArchivedTodoResponse struct {
+	// ID is the unique identifier for the todo.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// Title is the title of the todo.
+	// json:"title" specifies that this field should be marshalled to/from a JSON object with the key "title".
+	Title string `json:"title"`
+	// Description is the description of the todo.
+	// json:"description" specifies that this field should be marshalled to/from a JSON object with the key "description".
+	Description string `json:"description"`
+	// Priority is the priority of the todo.
+	// json:"priority" specifies that this field should be marshalled to/from a JSON object with the key "priority".
+	Priority string `json:"priority"`
+	// ListID is the ID of the list the todo belonged to, or nil if it was in none.
+	// json:"list_id" specifies that this field should be marshalled to/from a JSON object with the key "list_id".
+	ListID *uuid.UUID `json:"list_id"`
+	// DueAt is the time the todo was due, or nil if it had no due date.
+	// json:"due_at" specifies that this field should be marshalled to/from a JSON object with the key "due_at".
+	DueAt *time.Time `json:"due_at"`
+	// Tags is the list of tags of the todo.
+	// json:"tags" specifies that this field should be marshalled to/from a JSON object with the key "tags".
+	Tags []string `json:"tags"`
+	// EstimateMinutes is the estimated effort of the todo in minutes, or nil if it had no estimate.
+	// json:"estimate_minutes" specifies that this field should be marshalled to/from a JSON object with the key "estimate_minutes".
+	EstimateMinutes *int64 `json:"estimate_minutes"`
+	// CreatedAt is the time the todo was created.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt time.Time `json:"created_at"`
+	// CompletedAt is the time the todo was last changed while completed.
+	// json:"completed_at" specifies that this field should be marshalled to/from a JSON object with the key "completed_at".
+	CompletedAt time.Time `json:"completed_at"`
+	// ArchivedAt is the time the todo was moved to the archive.
+	// json:"archived_at" specifies that this field should be marshalled to/from a JSON object with the key "archived_at".
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+// PaginatedArchivedTodoResponse defines the structure for a page of archived todos.
+type PaginatedArchivedTodoResponse struct {
+	// Results is the archived todos of the page.
+	// json:"results" specifies that this field should be marshalled to/from a JSON object with the key "results".
+	Results []ArchivedTodoResponse `json:"results"`
+	// Count is the number of todos in the page.
+	// json:"count" specifies that this field should be marshalled to/from a JSON object with the key "count".
+	Count int `json:"count"`
+	// TotalItems is the total number of archived todos.
+	// json:"total_items" specifies that this field should be marshalled to/from a JSON object with the key "total_items".
+	TotalItems int64 `json:"total_items"`
+	// TotalPages is the total number of pages.
+	// json:"total_pages" specifies that this field should be marshalled to/from a JSON object with the key "total_pages".
+	TotalPages int `json:"total_pages"`
+	// Page is the page number.
+	// json:"page" specifies that this field should be marshalled to/from a JSON object with the key "page".
+	Page int `json:"page"`
+	// Limit is the number of todos per page.
+	// json:"limit" specifies that this field should be marshalled to/from a JSON object with the key "limit".
+	Limit int `json:"limit"`
+}
+
+// PlanDayResponse defines the structure for one day of a plan.
+type PlanDayResponse struct {
+	// Date is the day as YYYY-MM-DD in the user's time zone.
+	// json:"date" specifies that this field should be marshalled to/from a JSON object with the key "date".
+	Date string `json:"date"`
+	// TodoCount is the number of open todos due that day.
+	// json:"todo_count" specifies that this field should be marshalled to/from a JSON object with the key "todo_count".
+	TodoCount int `json:"todo_count"`
+	// EstimatedMinutes is the sum of the estimates of those todos.
+	// json:"estimated_minutes" specifies that this field should be marshalled to/from a JSON object with the key "estimated_minutes".
+	EstimatedMinutes int64 `json:"estimated_minutes"`
+	// UnestimatedCount is the number of those todos without an estimate.
+	// json:"unestimated_count" specifies that this field should be marshalled to/from a JSON object with the key "unestimated_count".
+	UnestimatedCount int `json:"unestimated_count"`
+	// Overloaded reports whether the estimates of the day exceed the capacity.
+	// json:"overloaded" specifies that this field should be marshalled to/from a JSON object with the key "overloaded".
+	Overloaded bool `json:"overloaded"`
+}
+
+// PlanResponse defines the structure for a plan response.
+type PlanResponse struct {
+	// CapacityMinutes is the estimated effort of a day the days are compared with.
+	// json:"capacity_minutes" specifies that this field should be marshalled to/from a JSON object with the key "capacity_minutes".
+	CapacityMinutes int `json:"capacity_minutes"`
+	// Days is the list of days, in order.
+	// json:"days" specifies that this field should be marshalled to/from a JSON object with the key "days".
+	Days []PlanDayResponse `json:"days"`
+	// OverloadedDays is the list of the dates of the overloaded days.
+	// json:"overloaded_days" specifies that this field should be marshalled to/from a JSON object with the key "overloaded_days".
+	OverloadedDays []string `json:"overloaded_days"`
+}
+
+// BoardColumnResponse defines the structure for one column of a board.
+type BoardColumnResponse struct {
+	// Status is the status of the todos of the column.
+	// json:"status" specifies that this field should be marshalled to/from a JSON object with the key "status".
+	Status string `json:"status"`
+	// Count is the number of todos with the status, which may be more than the todos returned.
+	// json:"count" specifies that this field should be marshalled to/from a JSON object with the key "count".
+	Count int64 `json:"count"`
+	// Todos is the first todos of the column, in list order.
+	// json:"todos" specifies that this field should be marshalled to/from a JSON object with the key "todos".
+	Todos []TodoResponse `json:"todos"`
+}
+
+// MoveTodosResponse defines the structure for a move todos response.
+type MoveTodosResponse struct {
+	// ListID is the list the todos were moved into, or nil if they were moved out of any list.
+	// json:"list_id" specifies that this field should be marshalled to/from a JSON object with the key "list_id".
+	ListID *uuid.UUID `json:"list_id"`
+	// TodoIDs is the IDs of the moved todos.
+	// json:"todo_ids" specifies that this field should be marshalled to/from a JSON object with the key "todo_ids".
+	TodoIDs []uuid.UUID `json:"todo_ids"`
+	// SkippedIDs is the IDs past the batch limit, which must be moved in another request.
+	// json:"skipped_ids" specifies that this field should be marshalled to/from a JSON object with the key "skipped_ids".
+	SkippedIDs []uuid.UUID `json:"skipped_ids"`
+}
+
+// SnoozeTodoRequest defines the structure for a snooze todo request. Exactly one of its fields is given.
+type SnoozeTodoRequest struct {
+	// Until is the time the snooze ends.
+	// json:"until,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "until", and should be omitted if empty.
+	Until *time.Time `json:"until,omitempty"`
+	// Duration is the length of the snooze from now, such as "30m" or "2h".
+	// json:"duration,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "duration", and should be omitted if empty.
+	Duration string `json:"duration,omitempty"`
+}
+
+// CreateListRequest defines the structure for a create list request.
+type CreateListRequest struct {
+	// Name is the name of the list.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+	// Color is the optional hex color of the list, such as "#1e90ff".
+	// json:"color,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "color", and should be omitted if empty.
+	Color string `json:"color,omitempty"`
+	// Icon is the optional emoji of the list.
+	// json:"icon,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "icon", and should be omitted if empty.
+	Icon string `json:"icon,omitempty"`
+}
+
+// UpdateListRequest defines the structure for an update list request. A nil field is left unchanged.
+type UpdateListRequest struct {
+	// Name is the new name of the list.
+	// json:"name,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "name", and should be omitted if empty.
+	Name *string `json:"name,omitempty"`
+	// Color is the new hex color of the list.
+	// json:"color,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "color", and should be omitted if empty.
+	Color *string `json:"color,omitempty"`
+	// Icon is the new emoji of the list.
+	// json:"icon,omitempty" specifies that this field should be marshalled to/from a JSON object with the key "icon", and should be omitted if empty.
+	Icon *string `json:"icon,omitempty"`
+}
+
+// ListResponse defines the structure for a list response.
+type ListResponse struct {
+	// ID is the unique identifier for the list.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// Name is the name of the list.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+	// CreatedAt is the time the list was created.
+	// json:"created_at" specifies that this field should be marshalled to/from a JSON object with the key "created_at".
+	CreatedAt time.Time `json:"created_at"`
+	// Version is the change sequence number of the list.
+	// json:"version" specifies that this field should be marshalled to/from a JSON object with the key "version".
+	Version int64 `json:"version"`
+	// Color is the hex color of the list, or nil if none was picked.
+	// json:"color" specifies that this field should be marshalled to/from a JSON object with the key "color".
+	Color *string `json:"color"`
+	// Icon is the emoji of the list, or nil if none was picked.
+	// json:"icon" specifies that this field should be marshalled to/from a JSON object with the key "icon".
+	Icon *string `json:"icon"`
+	// ArchivedAt is the time the list was archived, or nil if it is not archived.
+	// json:"archived_at" specifies that this field should be marshalled to/from a JSON object with the key "archived_at".
+	ArchivedAt *time.Time `json:"archived_at"`
+	// URL is the canonical path of the list.
+	// json:"url" specifies that this field should be marshalled to/from a JSON object with the key "url".
+	URL string `json:"url"`
+}
+
+// CompleteAllConfirmation defines the structure for the confirmation a complete all request asks for when it completes many todos.
+type CompleteAllConfirmation struct {
+	// ConfirmationToken is the token to send back to complete the todos.
+	// json:"confirmation_token" specifies that this field should be marshalled to/from a JSON object with the key "confirmation_token".
+	ConfirmationToken string `json:"confirmation_token"`
+	// TodoCount is the number of open todos the token confirms completing.
+	// json:"todo_count" specifies that this field should be marshalled to/from a JSON object with the key "todo_count".
+	TodoCount int `json:"todo_count"`
+	// ExpiresAt is the time the token stops working.
+	// json:"expires_at" specifies that this field should be marshalled to/from a JSON object with the key "expires_at".
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CompleteAllResponse defines the structure for a complete all response.
+type CompleteAllResponse struct {
+	// ID is the ID of the list.
+	// json:"id" specifies that this field should be marshalled to/from a JSON object with the key "id".
+	ID uuid.UUID `json:"id"`
+	// CompletedCount is the number of todos that were completed.
+	// json:"completed_count" specifies that this field should be marshalled to/from a JSON object with the key "completed_count".
+	CompletedCount int `json:"completed_count"`
+	// TodoIDs are the IDs of the todos that were completed.
+	// json:"todo_ids" specifies that this field should be marshalled to/from a JSON object with the key "todo_ids".
+	TodoIDs []uuid.UUID `json:"todo_ids"`
+}
+
+// TagStyleRequest defines the structure for a request that sets the style of a tag.
+type TagStyleRequest struct {
+	// Color is the hex color of the tag, such as "#1e90ff".
+	// json:"color" specifies that this field should be marshalled to/from a JSON object with the key "color".
+	Color string `json:"color"`
+	// Icon is the emoji of the tag.
+	// json:"icon" specifies that this field should be marshalled to/from a JSON object with the key "icon".
+	Icon string `json:"icon"`
+}
+
+// TagResponse defines the structure for a tag response.
+type TagResponse struct {
+	// Name is the name of the tag.
+	// json:"name" specifies that this field should be marshalled to/from a JSON object with the key "name".
+	Name string `json:"name"`
+	// TodoCount is the number of todos that use the tag.
+	// json:"todo_count" specifies that this field should be marshalled to/from a JSON object with the key "todo_count".
+	TodoCount int64 `json:"todo_count"`
+	// Color is the hex color of the tag, or nil if none was picked.
+	// json:"color" specifies that this field should be marshalled to/from a JSON object with the key "color".
+	Color *string `json:"color"`
+	// Icon is the emoji of the tag, or nil if none was picked.
+	// json:"icon" specifies that this field should be marshalled to/from a JSON object with the key "icon".
+	Icon *string `json:"icon"`
+}
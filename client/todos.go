@@ -0,0 +1,468 @@
+// This file defines the methods of the client for the /todos endpoints, including the iterators that page through the todos.
+package client
+
+// "context" provides a way to carry deadlines and cancellation signals. It is used here to cancel the requests.
+import (
+	"context"
+	// "iter" provides the iterator types. It is used here to page through the todos as one sequence.
+	"iter"
+	// "net/http" provides HTTP client and server implementations. It is used here for the HTTP methods.
+	"net/http"
+	// "net/url" provides functions for working with URLs. It is used here to build the query parameters.
+	"net/url"
+	// "strconv" provides functions for converting strings. It is used here to format the query parameters.
+	"strconv"
+	// "time" provides functions for working with time. It is used here to format the due date filters.
+	"time"
+
+	// "github.com/google/uuid" is a package for working with UUIDs. It is used here to identify todos and lists.
+	"github.com/google/uuid"
+)
+
+// headerIdempotencyKey is the header that makes creating a todo safe to retry.
+const headerIdempotencyKey = "Idempotency-Key"
+
+// CreateTodo creates a todo. The request carries a new Idempotency-Key, so that it is retried like an idempotent request:
+// if an earlier attempt created the todo, the retry is answered with 409 Conflict instead of creating it twice.
+//
+// @param ctx context.Context - The context of the request.
+// @param body TodoRequest - The todo to create.
+// @return TodoResponse - The created todo.
+// @return error - An error if one occurred.
+func (c *Client) CreateTodo(ctx context.Context, body TodoRequest) (TodoResponse, error) {
+	// todo is the created todo.
+	var todo TodoResponse
+	// header holds the Idempotency-Key, which every attempt shares.
+	header := http.Header{}
+	header.Set(headerIdempotencyKey, uuid.NewString())
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/todos", body: body, header: header, idempotent: true}, &todo)
+	// The todo is returned.
+	return todo, err
+}
+
+// QuickAddTodo creates a todo from a line such as "Pay rent tomorrow 5pm #finance !high".
+//
+// @param ctx context.Context - The context of the request.
+// @param text string - The line to parse.
+// @return TodoResponse - The created todo.
+// @return error - An error if one occurred.
+func (c *Client) QuickAddTodo(ctx context.Context, text string) (TodoResponse, error) {
+	// todo is the created todo.
+	var todo TodoResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/todos/quick", body: map[string]string{"text": text}}, &todo)
+	// The todo is returned.
+	return todo, err
+}
+
+// values turns the query into query parameters, leaving out the zero fields.
+//
+// @return url.Values - The query parameters.
+func (q ListTodosQuery) values() url.Values {
+	// values is the query parameters.
+	values := url.Values{}
+	// Each field is set if it is given.
+	if q.Page > 0 {
+		values.Set("page", strconv.Itoa(q.Page))
+	}
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Sort != "" {
+		values.Set("sort", q.Sort)
+	}
+	if q.Completed != nil {
+		values.Set("completed", strconv.FormatBool(*q.Completed))
+	}
+	if q.ListID != nil {
+		values.Set("list_id", q.ListID.String())
+	}
+	if q.DueAfter != nil {
+		values.Set("due_after", q.DueAfter.Format(time.RFC3339))
+	}
+	if q.DueBefore != nil {
+		values.Set("due_before", q.DueBefore.Format(time.RFC3339))
+	}
+	if q.HasDueDate != nil {
+		values.Set("has_due_date", strconv.FormatBool(*q.HasDueDate))
+	}
+	if q.View != "" {
+		values.Set("view", q.View)
+	}
+	if q.Total != "" {
+		values.Set("total", q.Total)
+	}
+	// The query parameters are returned.
+	return values
+}
+
+// ListTodos returns one page of the todos that match a query.
+//
+// @param ctx context.Context - The context of the request.
+// @param query ListTodosQuery - The filters, the order, and the page.
+// @return PaginatedTodoResponse - The page.
+// @return *Meta - The metadata of the response, with the links to the neighbouring pages.
+// @return error - An error if one occurred.
+func (c *Client) ListTodos(ctx context.Context, query ListTodosQuery) (PaginatedTodoResponse, *Meta, error) {
+	// page is the page of todos.
+	var page PaginatedTodoResponse
+	// This sends the request.
+	meta, err := c.do(ctx, request{method: http.MethodGet, path: "/todos", query: query.values(), idempotent: true}, &page)
+	// The page is returned.
+	return page, meta, err
+}
+
+// Todos returns an iterator over every todo that matches a query, from the page of the query on. It fetches the next page
+// only once the todos of the page before are used, by the page number of the next link of the response. An error ends the
+// iteration after it is yielded.
+//
+// @param ctx context.Context - The context of the requests.
+// @param query ListTodosQuery - The filters and the order.
+// @return iter.Seq2[TodoResponse, error] - The todos.
+func (c *Client) Todos(ctx context.Context, query ListTodosQuery) iter.Seq2[TodoResponse, error] {
+	// The iterator fetches the pages one after the other.
+	return func(yield func(TodoResponse, error) bool) {
+		// This loops over the pages.
+		for {
+			// page and meta are the result of fetching the page.
+			page, meta, err := c.ListTodos(ctx, query)
+			// This checks if an error occurred while fetching the page.
+			if err != nil {
+				// If one did, it is yielded and the iteration ends.
+				yield(TodoResponse{}, err)
+				return
+			}
+			// This iterates over the todos of the page.
+			for _, todo := range page.Results {
+				// The todo is yielded, and the iteration ends if the caller stops.
+				if !yield(todo, nil) {
+					return
+				}
+			}
+			// next is the number of the next page, or zero on the last page.
+			next := nextPage(meta)
+			// This checks if this is the last page.
+			if next == 0 {
+				// If it is, the iteration ends.
+				return
+			}
+			query.Page = next
+		}
+	}
+}
+
+// ArchivedTodos returns an iterator over the archived todos, most recently completed first, optionally of one list.
+// An error ends the iteration after it is yielded.
+//
+// @param ctx context.Context - The context of the requests.
+// @param listId *uuid.UUID - The list to filter by, or nil for every list.
+// @param limit int - The number of todos per page, or zero for the default of the server.
+// @return iter.Seq2[ArchivedTodoResponse, error] - The archived todos.
+func (c *Client) ArchivedTodos(ctx context.Context, listId *uuid.UUID, limit int) iter.Seq2[ArchivedTodoResponse, error] {
+	// The iterator fetches the pages one after the other.
+	return func(yield func(ArchivedTodoResponse, error) bool) {
+		// values is the query parameters of the first page.
+		values := url.Values{}
+		// This checks if a list is given.
+		if listId != nil {
+			values.Set("list_id", listId.String())
+		}
+		// This checks if a page size is given.
+		if limit > 0 {
+			values.Set("limit", strconv.Itoa(limit))
+		}
+		// This loops over the pages.
+		for {
+			// page is the page of archived todos.
+			var page PaginatedArchivedTodoResponse
+			// meta and err are the result of fetching the page.
+			meta, err := c.do(ctx, request{method: http.MethodGet, path: "/todos/archive", query: values, idempotent: true}, &page)
+			// This checks if an error occurred while fetching the page.
+			if err != nil {
+				// If one did, it is yielded and the iteration ends.
+				yield(ArchivedTodoResponse{}, err)
+				return
+			}
+			// This iterates over the todos of the page.
+			for _, todo := range page.Results {
+				// The todo is yielded, and the iteration ends if the caller stops.
+				if !yield(todo, nil) {
+					return
+				}
+			}
+			// next is the number of the next page, or zero on the last page.
+			next := nextPage(meta)
+			// This checks if this is the last page.
+			if next == 0 {
+				// If it is, the iteration ends.
+				return
+			}
+			values.Set("page", strconv.Itoa(next))
+		}
+	}
+}
+
+// nextPage reads the number of the next page from the next link of a response. Only the page is taken from the link, since
+// the server builds it from the host it sees, which is not the one the client knows behind a proxy.
+//
+// @param meta *Meta - The metadata of the response.
+// @return int - The number of the next page, or zero if there is none.
+func nextPage(meta *Meta) int {
+	// This checks if the response links to no next page.
+	if meta == nil || meta.Pagination == nil || meta.Pagination.Next == "" {
+		// If it does not, there is none.
+		return 0
+	}
+	// next is the result of parsing the link.
+	next, err := url.Parse(meta.Pagination.Next)
+	// This checks if the link is not a URL.
+	if err != nil {
+		// If it is not, there is no next page to follow.
+		return 0
+	}
+	// page is the page number of the link.
+	page, err := strconv.Atoi(next.Query().Get("page"))
+	// This checks if the link has no page number.
+	if err != nil {
+		// If it has none, there is no next page to follow.
+		return 0
+	}
+	// The page number is returned.
+	return page
+}
+
+// Plan returns the estimated effort of the open todos per day, from a date on.
+//
+// @param ctx context.Context - The context of the request.
+// @param date string - The first day as YYYY-MM-DD, or empty for today in the user's time zone.
+// @param days int - The number of days, or zero for the default of the server.
+// @return PlanResponse - The plan.
+// @return error - An error if one occurred.
+func (c *Client) Plan(ctx context.Context, date string, days int) (PlanResponse, error) {
+	// values is the query parameters.
+	values := url.Values{}
+	// This checks if a date is given.
+	if date != "" {
+		values.Set("date", date)
+	}
+	// This checks if a number of days is given.
+	if days > 0 {
+		values.Set("days", strconv.Itoa(days))
+	}
+	// plan is the plan.
+	var plan PlanResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/todos/plan", query: values, idempotent: true}, &plan)
+	// The plan is returned.
+	return plan, err
+}
+
+// Board returns the todos grouped by status, optionally of one list.
+//
+// @param ctx context.Context - The context of the request.
+// @param listId *uuid.UUID - The list to filter by, or nil for every list that is not archived.
+// @return []BoardColumnResponse - The columns.
+// @return error - An error if one occurred.
+func (c *Client) Board(ctx context.Context, listId *uuid.UUID) ([]BoardColumnResponse, error) {
+	// values is the query parameters.
+	values := url.Values{}
+	// This checks if a list is given.
+	if listId != nil {
+		values.Set("list_id", listId.String())
+	}
+	// board is the columns.
+	var board []BoardColumnResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/todos/board", query: values, idempotent: true}, &board)
+	// The columns are returned.
+	return board, err
+}
+
+// GetTodo returns a todo.
+//
+// @param ctx context.Context - The context of the request.
+// @param id uuid.UUID - The ID of the todo.
+// @return TodoResponse - The todo.
+// @return error - An error if one occurred.
+func (c *Client) GetTodo(ctx context.Context, id uuid.UUID) (TodoResponse, error) {
+	// todo is the todo.
+	var todo TodoResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/todos/" + id.String(), idempotent: true}, &todo)
+	// The todo is returned.
+	return todo, err
+}
+
+// UpdateTodo replaces the fields of a todo. With a version, the update is refused with 409 Conflict if the todo has changed since.
+//
+// @param ctx context.Context - The context of the request.
+// @param id uuid.UUID - The ID of the todo.
+// @param body TodoRequest - The new fields.
+// @return TodoResponse - The updated todo.
+// @return error - An error if one occurred.
+func (c *Client) UpdateTodo(ctx context.Context, id uuid.UUID, body TodoRequest) (TodoResponse, error) {
+	// todo is the updated todo.
+	var todo TodoResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodPut, path: "/todos/" + id.String(), body: body, idempotent: true}, &todo)
+	// The todo is returned.
+	return todo, err
+}
+
+// CompleteTodo sets the completion status of a todo.
+//
+// @param ctx context.Context - The context of the request.
+// @param id uuid.UUID - The ID of the todo.
+// @param completed bool - The completion status.
+// @return UndoableTodoResponse - The updated todo, with the token that undoes the change.
+// @return error - An error if one occurred.
+func (c *Client) CompleteTodo(ctx context.Context, id uuid.UUID, completed bool) (UndoableTodoResponse, error) {
+	// todo is the updated todo.
+	var todo UndoableTodoResponse
+	// This sends the request. Each attempt records an activity that can be undone, so it is not retried after a network error.
+	_, err := c.do(ctx, request{method: http.MethodPatch, path: "/todos/" + id.String(), body: map[string]bool{"completed": completed}}, &todo)
+	// The todo is returned.
+	return todo, err
+}
+
+// DeleteTodo deletes a todo.
+//
+// @param ctx context.Context - The context of the request.
+// @param id uuid.UUID - The ID of the todo.
+// @return DeleteTodoResponse - The token that restores the todo.
+// @return error - An error if one occurred.
+func (c *Client) DeleteTodo(ctx context.Context, id uuid.UUID) (DeleteTodoResponse, error) {
+	// deleted is the token that restores the todo.
+	var deleted DeleteTodoResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/todos/" + id.String(), idempotent: true}, &deleted)
+	// The token is returned.
+	return deleted, err
+}
+
+// MoveTodos moves todos into a list, or out of any list with a nil list. The IDs past the batch limit are returned as skipped.
+//
+// @param ctx context.Context - The context of the request.
+// @param todoIds []uuid.UUID - The todos, in the order they are appended to the list.
+// @param listId *uuid.UUID - The list, or nil.
+// @return MoveTodosResponse - The moved and skipped todos.
+// @return error - An error if one occurred.
+func (c *Client) MoveTodos(ctx context.Context, todoIds []uuid.UUID, listId *uuid.UUID) (MoveTodosResponse, error) {
+	// moved is the moved and skipped todos.
+	var moved MoveTodosResponse
+	// This sends the request. Moving the same todos twice has the same effect as once.
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/todos/move", body: map[string]any{"todo_ids": todoIds, "list_id": listId}, idempotent: true}, &moved)
+	// The result is returned.
+	return moved, err
+}
+
+// DuplicateTodo copies a todo, optionally into another list.
+//
+// @param ctx context.Context - The context of the request.
+// @param id uuid.UUID - The ID of the todo.
+// @param listId *uuid.UUID - The list to place the copy in, or nil for the list of the todo.
+// @return TodoResponse - The copy.
+// @return error - An error if one occurred.
+func (c *Client) DuplicateTodo(ctx context.Context, id uuid.UUID, listId *uuid.UUID) (TodoResponse, error) {
+	// todo is the copy.
+	var todo TodoResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/todos/" + id.String() + "/duplicate", body: map[string]any{"list_id": listId}}, &todo)
+	// The copy is returned.
+	return todo, err
+}
+
+// SetTodoStatus moves a todo to another column of the board.
+//
+// @param ctx context.Context - The context of the request.
+// @param id uuid.UUID - The ID of the todo.
+// @param status string - The new status: backlog, in_progress, blocked, or done.
+// @return UndoableTodoResponse - The updated todo, with the token that undoes the change.
+// @return error - An error if one occurred.
+func (c *Client) SetTodoStatus(ctx context.Context, id uuid.UUID, status string) (UndoableTodoResponse, error) {
+	// todo is the updated todo.
+	var todo UndoableTodoResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodPut, path: "/todos/" + id.String() + "/status", body: map[string]string{"status": status}}, &todo)
+	// The todo is returned.
+	return todo, err
+}
+
+// Blockers returns the todos a todo waits on.
+//
+// @param ctx context.Context - The context of the request.
+// @param id uuid.UUID - The ID of the todo.
+// @return []TodoResponse - The blockers.
+// @return error - An error if one occurred.
+func (c *Client) Blockers(ctx context.Context, id uuid.UUID) ([]TodoResponse, error) {
+	// blockers is the blockers of the todo.
+	var blockers []TodoResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/todos/" + id.String() + "/blockers", idempotent: true}, &blockers)
+	// The blockers are returned.
+	return blockers, err
+}
+
+// AddBlocker makes a todo wait on another todo.
+//
+// @param ctx context.Context - The context of the request.
+// @param id uuid.UUID - The ID of the todo.
+// @param blockerId uuid.UUID - The ID of the todo that has to be completed first.
+// @return TodoResponse - The todo.
+// @return error - An error if one occurred.
+func (c *Client) AddBlocker(ctx context.Context, id uuid.UUID, blockerId uuid.UUID) (TodoResponse, error) {
+	// todo is the todo.
+	var todo TodoResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/todos/" + id.String() + "/blockers", body: map[string]string{"blocker_id": blockerId.String()}}, &todo)
+	// The todo is returned.
+	return todo, err
+}
+
+// RemoveBlocker stops a todo from waiting on another todo.
+//
+// @param ctx context.Context - The context of the request.
+// @param id uuid.UUID - The ID of the todo.
+// @param blockerId uuid.UUID - The ID of the blocker.
+// @return TodoResponse - The todo.
+// @return error - An error if one occurred.
+func (c *Client) RemoveBlocker(ctx context.Context, id uuid.UUID, blockerId uuid.UUID) (TodoResponse, error) {
+	// todo is the todo.
+	var todo TodoResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/todos/" + id.String() + "/blockers/" + blockerId.String(), idempotent: true}, &todo)
+	// The todo is returned.
+	return todo, err
+}
+
+// SnoozeTodo hides a todo until a time or for a duration.
+//
+// @param ctx context.Context - The context of the request.
+// @param id uuid.UUID - The ID of the todo.
+// @param body SnoozeTodoRequest - The end or the length of the snooze.
+// @return UndoableTodoResponse - The snoozed todo, with the token that undoes the snooze.
+// @return error - An error if one occurred.
+func (c *Client) SnoozeTodo(ctx context.Context, id uuid.UUID, body SnoozeTodoRequest) (UndoableTodoResponse, error) {
+	// todo is the snoozed todo.
+	var todo UndoableTodoResponse
+	// This sends the request.
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/todos/" + id.String() + "/snooze", body: body}, &todo)
+	// The todo is returned.
+	return todo, err
+}
+
+// UndoTodo undoes a delete, a completion, or a snooze with the token its response returned.
+//
+// @param ctx context.Context - The context of the request.
+// @param undoToken uuid.UUID - The undo token.
+// @return TodoResponse - The todo as it was before the action.
+// @return error - An error if one occurred.
+func (c *Client) UndoTodo(ctx context.Context, undoToken uuid.UUID) (TodoResponse, error) {
+	// todo is the restored todo.
+	var todo TodoResponse
+	// This sends the request. A token undoes its action once, so a retry of a request that succeeded fails instead of undoing twice.
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/todos/undo", body: map[string]string{"undo_token": undoToken.String()}, idempotent: true}, &todo)
+	// The todo is returned.
+	return todo, err
+}
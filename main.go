@@ -1,83 +1,233 @@
 // This file is the main entry point for the todo-backend application.
-// It initializes the configuration, database connection, and the Fiber web server.
-// It also handles graceful shutdown of the application.
+// It loads the configuration, builds the application with the bootstrap package, and runs it until a shutdown signal.
+// It also starts the graceful shutdown of the application, which the lifecycle package runs.
 package main
 
-// "fmt" provides functions for formatted I/O. It is used here to print messages to the console.
+// "bufio" provides buffered I/O. It is used here to read the answers to the prompts of a command.
 import (
+	"bufio"
+	// "context" provides a way to carry cancellation signals. It is used here to stop a command on an interrupt.
+	"context"
+	// "database/sql" provides a generic SQL interface. It is used here to connect to the database for a toggle.
+	"database/sql"
+	// "fmt" provides functions for formatted I/O. It is used here to print the key of a backup and the usage of the commands.
 	"fmt"
-	// "log" provides a simple logging package. It is used here to log fatal server errors.
+	// "log" provides a simple logging package. It is used here to log fatal startup errors.
 	"log"
-	// "os" provides a platform-independent interface to operating system functionality. It is used here to receive signals for graceful shutdown.
+	// "os" provides a platform-independent interface to operating system functionality. It is used here to name the shutdown signals and to flush the logs.
 	"os"
-	// "os/signal" provides functions for handling incoming signals from the operating system. It is used here to listen for interrupt and terminate signals.
+	// "os/signal" provides functions for handling incoming signals from the operating system. It is used here to stop a command on an interrupt or terminate signal.
 	"os/signal"
+	// "strings" provides functions for working with strings. It is used here to trim the answers to the prompts.
+	"strings"
 	// "syscall" provides a low-level interface to operating system primitives. It is used here to specify the SIGTERM signal.
 	"syscall"
+	// "time" provides functions for working with time. It is used here to name the backups.
+	"time"
+	// _ "time/tzdata" embeds the time zone database so that user time zones can be loaded in minimal containers.
+	_ "time/tzdata"
 
-	// "github.com/gofiber/fiber/v2" is a web framework for Go. It is used here to create the HTTP server and define API routes.
-	"github.com/gofiber/fiber/v2"
+	// "github.com/rahulcodepython/todo-backend/apps/users" is a local package that contains the user service. It is used here to create the first admin.
+	"github.com/rahulcodepython/todo-backend/apps/users"
+	// "github.com/rahulcodepython/todo-backend/backend/backup" is a local package that backs up and restores the database.
+	"github.com/rahulcodepython/todo-backend/backend/backup"
+	// "github.com/rahulcodepython/todo-backend/backend/bootstrap" is a local package that wires the application together.
+	"github.com/rahulcodepython/todo-backend/backend/bootstrap"
+	// "github.com/rahulcodepython/todo-backend/backend/clock" is a local package that tells the current time.
+	"github.com/rahulcodepython/todo-backend/backend/clock"
 	// "github.com/rahulcodepython/todo-backend/backend/config" is a local package that handles loading application configuration.
 	"github.com/rahulcodepython/todo-backend/backend/config"
-	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that manages the database connection.
+	// "github.com/rahulcodepython/todo-backend/backend/database" is a local package that provides database helpers.
 	"github.com/rahulcodepython/todo-backend/backend/database"
-	// "github.com/rahulcodepython/todo-backend/backend/router" is a local package that sets up the application's API routes.
-	"github.com/rahulcodepython/todo-backend/backend/router"
+	// "github.com/rahulcodepython/todo-backend/backend/idgen" is a local package that creates new IDs.
+	"github.com/rahulcodepython/todo-backend/backend/idgen"
+	// "github.com/rahulcodepython/todo-backend/backend/lifecycle" is a local package that runs the shutdown of the application.
+	"github.com/rahulcodepython/todo-backend/backend/lifecycle"
+	// "github.com/rahulcodepython/todo-backend/backend/migrate" is a local package that helps change the schema without downtime.
+	"github.com/rahulcodepython/todo-backend/backend/migrate"
+	// "github.com/rahulcodepython/todo-backend/backend/redact" is a local package that redacts credentials from the logs.
+	"github.com/rahulcodepython/todo-backend/backend/redact"
+	// "github.com/rahulcodepython/todo-backend/backend/storage" is a local package that stores files.
+	"github.com/rahulcodepython/todo-backend/backend/storage"
+	// "github.com/rahulcodepython/todo-backend/backend/version" is a local package that holds the version of the build.
+	"github.com/rahulcodepython/todo-backend/backend/version"
+	// "golang.org/x/term" provides support for terminals. It is used here to read the admin password without echoing it.
+	"golang.org/x/term"
 )
 
 // main is the entry point of the application.
-// It initializes the server, database, and router, and then starts the server.
-// It also includes logic for graceful shutdown.
+// It builds and starts the application, and then waits for a signal to shut it down gracefully.
 func main() {
+	// The standard logger redacts credentials and email addresses from every line, including the lines of the request loggers, which write where it does.
+	log.SetOutput(redact.NewWriter(os.Stderr))
+	// The version of the build is logged first, so that every log of a deployment says which code produced it.
+	log.Printf("Starting todo-backend %s", version.Current())
+
 	// cfg is a variable that holds the application configuration.
 	// config.LoadConfig() is called to load the configuration from environment variables or a .env file.
 	cfg := config.LoadConfig()
 
-	// db is a variable that holds the database connection.
-	// database.ConnectDB() is called to establish a connection to the database using the loaded configuration.
-	db := database.ConnectDB(cfg)
+	// This checks if a command was given instead of starting the server.
+	if len(os.Args) > 1 {
+		// The command is run, and the application exits with its outcome.
+		if err := runCommand(cfg, os.Args[1:]); err != nil {
+			// If it failed, a fatal error is logged.
+			log.Fatalf("%s failed: %v", os.Args[1], err)
+		}
+		return
+	}
 
-	// server is a new instance of a Fiber application.
-	// fiber.New() creates a new Fiber server.
-	server := fiber.New()
+	// container holds the database connection, server, and workers of the application.
+	container, err := bootstrap.New(cfg)
+	// This checks if the application could not be built.
+	if err != nil {
+		// If it could not, a fatal error is logged.
+		log.Fatalf("Unable to start application: %v", err)
+	}
+	// lc runs the shutdown hooks within the deadline of the configuration.
+	lc := lifecycle.New(cfg.Server.ShutdownTimeout)
+	// The shutdown of the server, workers, and database is registered.
+	container.RegisterShutdown(lc)
+	// The logs are flushed last, so that the lines of the shutdown reach a redirected output before the process exits.
+	lc.OnShutdown("flush logs", func(context.Context) error {
+		// The outputs are synced, ignoring the error of a pipe or terminal, which cannot be synced and holds nothing back.
+		_ = os.Stdout.Sync()
+		_ = os.Stderr.Sync()
+		return nil
+	})
+	// container.Start() starts the background workers and the server.
+	container.Start()
 
-	// router.Router() is called to set up all the application routes and middleware.
-	// It takes the Fiber server, configuration, and database connection as arguments.
-	router.Router(server, cfg, db)
+	// This blocks until an interrupt (Ctrl+C) or SIGTERM is received.
+	lc.Wait(os.Interrupt, syscall.SIGTERM)
+	// The hooks are run, and the application exits with an error if they did not finish within the deadline.
+	if err := lc.Shutdown(); err != nil {
+		// If they did not, a fatal error is logged.
+		log.Fatalf("Unable to shut down cleanly: %v", err)
+	}
+}
 
-	// address is a string that represents the server address.
-	// It is constructed by combining the server host and port from the configuration.
-	address := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port)
+// runCommand runs an operator command instead of the server:
+// "backup [key]" backs up the database to the storage backend, "restore <key>" restores a backup into the configured database, which must be empty,
+// "toggle <name> on|off" switches a dual write toggle of a schema change for every server,
+// and "bootstrap-admin" creates the first admin, with the BOOTSTRAP_ADMIN_* variables or the answers to its prompts.
+// None starts the server, and only "bootstrap-admin" creates the tables, so that a restore runs before the server first starts on a new database.
+//
+// @param cfg *config.Config - The application configuration.
+// @param args []string - The command and its arguments.
+// @return error - An error if the command is unknown or failed.
+func runCommand(cfg *config.Config, args []string) error {
+	// ctx is the context of the command, cancelled by an interrupt so that the tool it runs is stopped.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	// This defers releasing the signal handler until the command returns.
+	defer stop()
+	// store is the storage backend of the backups.
+	store := storage.New(cfg.Storage)
+	// progress logs each step the tool reports.
+	progress := func(step string) { log.Println(step) }
 
-	// A new goroutine is started to run the Fiber server.
-	// This allows the main goroutine to continue and handle graceful shutdown.
-	go func() {
-		// server.Listen() starts the HTTP server and listens for incoming requests on the specified address.
-		if err := server.Listen(address); err != nil {
-			// If an error occurs while starting the server, log the error and panic.
-			log.Panicf("Server error: %v", err)
+	// This selects the command.
+	switch {
+	case args[0] == "backup" && len(args) <= 2:
+		// key is the storage key of the backup, named after the current time unless one is given.
+		key := backup.NewKey(time.Now())
+		// This checks if a key was given.
+		if len(args) == 2 {
+			// If it was, it is used.
+			key = args[1]
 		}
-	}()
-
-	// c is a channel that will receive operating system signals.
-	// It has a buffer size of 1.
-	c := make(chan os.Signal, 1)
-	// signal.Notify() registers the given channel to receive notifications of the specified signals.
-	// In this case, it listens for os.Interrupt (Ctrl+C) and syscall.SIGTERM.
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-	// This is a blocking call that waits for a signal to be received on the channel c.
-	<-c
-
-	// A message is printed to the console to indicate that the server is shutting down.
-	fmt.Println("Gracefully shutting down...")
-	// server.Shutdown() gracefully shuts down the server without interrupting any active connections.
-	_ = server.Shutdown()
+		// The database is dumped to the storage backend.
+		if err := backup.Dump(ctx, cfg.Database, store, key, progress); err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// The key is printed, since it is what a restore takes.
+		fmt.Println(key)
+		return nil
+	case args[0] == "restore" && len(args) == 2:
+		// The backup is restored into the configured database.
+		return backup.Restore(ctx, cfg.Database, store, args[1], progress)
+	case args[0] == "toggle" && len(args) == 3 && (args[2] == "on" || args[2] == "off"):
+		// db is a connection to the database, used for this command only.
+		db, err := sql.Open("postgres", database.ConnectionString(cfg.Database))
+		// This checks if an error occurred while opening the connection.
+		if err != nil {
+			// If an error occurs, it is returned.
+			return err
+		}
+		// This defers closing the connection until the command returns.
+		defer db.Close()
+		// The toggle is written, and the servers follow it once their cached toggles are refreshed.
+		return migrate.SetToggle(ctx, db, args[1], args[2] == "on")
+	case args[0] == "bootstrap-admin" && len(args) == 1:
+		// email and password are the credentials of the admin, asked for if they are not configured.
+		email, password := cfg.Bootstrap.AdminEmail, cfg.Bootstrap.AdminPassword
+		// input reads the answers to the prompts.
+		input := bufio.NewReader(os.Stdin)
+		// This checks if the email address is not configured.
+		if email == "" {
+			// If it is not, it is asked for.
+			email = prompt(input, "Admin email: ")
+		}
+		// This checks if the password is not configured.
+		if password == "" {
+			// err is the result of reading the password.
+			var err error
+			// If it is not, it is asked for without showing it on the screen.
+			password, err = promptPassword(input, "Admin password: ")
+			// This checks if the password could not be read.
+			if err != nil {
+				// If it could not, the error is returned.
+				return fmt.Errorf("reading the admin password: %w", err)
+			}
+		}
+		// db is the database connection, with the tables created, since the command may run before the server ever started.
+		db := database.ConnectDB(cfg)
+		// This defers closing the connection until the command returns.
+		defer db.Close()
+		// The admin is created, unless an admin exists or one was bootstrapped before.
+		return bootstrap.BootstrapAdmin(ctx, users.NewUserService(cfg, db, clock.System{}, idgen.UUIDv7{}, nil), email, password)
+	default:
+		// Any other command is rejected with the usage.
+		return fmt.Errorf("unknown command, usage: todo-backend [backup [key] | restore <key> | toggle <name> on|off | bootstrap-admin]")
+	}
+}
 
-	// A message is printed to the console to indicate that cleanup tasks are running.
-	fmt.Println("Running cleanup tasks...")
-	// db.Close() closes the database connection.
-	_ = db.Close()
+// prompt asks for a line on the standard error and reads the answer from the standard input, so that the answers can also be piped in.
+//
+// @param input *bufio.Reader - The reader of the standard input.
+// @param question string - The question.
+// @return string - The answer, without the line break.
+func prompt(input *bufio.Reader, question string) string {
+	// The question is written where the logs go, so that the standard output stays free.
+	fmt.Fprint(os.Stderr, question)
+	// answer is the line that was typed, which is empty if the input ended.
+	answer, _ := input.ReadString('\n')
+	// The answer is returned without the line break.
+	return strings.TrimRight(answer, "\r\n")
+}
 
-	// A message is printed to the console to indicate that the server has shut down successfully.
-	fmt.Println("Fiber was successful shutdown.")
-}
\ No newline at end of file
+// promptPassword asks for a password like prompt, but reads it with the echo of the terminal turned off when the standard input
+// is one, so that it is not shown on the screen or left in the scrollback. A piped password is read as a line, like any other answer.
+//
+// @param input *bufio.Reader - The reader of the standard input, used when the password is piped in.
+// @param question string - The question.
+// @return string - The password, without the line break.
+// @return error - An error if the terminal cannot be read.
+func promptPassword(input *bufio.Reader, question string) (string, error) {
+	// fd is the file descriptor of the standard input.
+	fd := int(os.Stdin.Fd())
+	// This checks if the standard input is not a terminal, which means the password is piped in.
+	if !term.IsTerminal(fd) {
+		// If it is not, the password is read as a line.
+		return prompt(input, question), nil
+	}
+	// The question is written where the logs go, so that the standard output stays free.
+	fmt.Fprint(os.Stderr, question)
+	// password is the password that was typed, read without echo.
+	password, err := term.ReadPassword(fd)
+	// The line break that was typed is not echoed either, so it is written for the next line of output.
+	fmt.Fprintln(os.Stderr)
+	// The password and the error, if any, are returned.
+	return string(password), err
+}